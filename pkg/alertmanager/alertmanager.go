@@ -88,6 +88,12 @@ type Config struct {
 	PersisterConfig   PersisterConfig
 	APIConcurrency    int
 	GCInterval        time.Duration
+
+	// ReceiverHealthWindow is the window over which the health of the receivers is tracked.
+	ReceiverHealthWindow time.Duration
+
+	// AlertsArchiveFlushInterval is the interval at which the received alerts are archived, if enabled.
+	AlertsArchiveFlushInterval time.Duration
 }
 
 // An Alertmanager manages the alerts for one user.
@@ -102,6 +108,7 @@ type Alertmanager struct {
 	marker          types.Marker
 	alerts          *mem.Alerts
 	dispatcher      *dispatch.Dispatcher
+	alertsLimiter   *alertsLimiter
 	inhibitor       *inhibit.Inhibitor
 	pipelineBuilder *notify.PipelineBuilder
 	stop            chan struct{}
@@ -122,6 +129,10 @@ type Alertmanager struct {
 	configHashMetric prometheus.Gauge
 
 	rateLimitedNotifications *prometheus.CounterVec
+	routeAnalytics           *routeAnalytics
+	receiverHealth           *receiverHealth
+	silencesGC               *silencesGC
+	alertsArchive            *alertsArchive
 }
 
 var (
@@ -176,6 +187,8 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 			Help: "Number of rate-limited notifications per integration.",
 		}, []string{"integration"}), // "integration" is consistent with other alertmanager metrics.
 
+		routeAnalytics: newRouteAnalytics(reg),
+		receiverHealth: newReceiverHealth(cfg.ReceiverHealthWindow),
 	}
 
 	am.registry = reg
@@ -192,7 +205,7 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		level.Debug(am.logger).Log("msg", "starting tenant alertmanager with ring-based replication")
 		state := newReplicatedStates(cfg.UserID, cfg.ReplicationFactor, cfg.Replicator, cfg.Store, am.logger, am.registry)
 		am.state = state
-		am.persister = newStatePersister(cfg.PersisterConfig, cfg.UserID, state, cfg.Store, am.logger, am.registry)
+		am.persister = newStatePersister(cfg.PersisterConfig, cfg.UserID, state, cfg.Store, cfg.Limits, am.logger, am.registry)
 	} else {
 		level.Debug(am.logger).Log("msg", "starting tenant alertmanager without replication")
 		am.state = &NilPeer{}
@@ -260,9 +273,20 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		am.wg.Done()
 	}()
 
-	var callback mem.AlertStoreCallback
+	var callbacks alertStoreCallbacks
 	if am.cfg.Limits != nil {
-		callback = newAlertsLimiter(am.cfg.UserID, am.cfg.Limits, reg)
+		am.alertsLimiter = newAlertsLimiter(am.cfg.UserID, am.cfg.Limits, reg)
+		callbacks = append(callbacks, am.alertsLimiter)
+
+		// The alerts are archived once stored, so only the alerts accepted by the limiter are archived.
+		if store, ok := am.cfg.Store.(alertstore.AlertsArchiveStore); ok {
+			am.alertsArchive = newAlertsArchive(am.cfg.UserID, am.cfg.Limits, store.AlertsArchiveBucket(am.cfg.UserID), am.state.Position, log.With(am.logger, "component", "alerts_archive"), am.registry)
+			callbacks = append(callbacks, am.alertsArchive)
+		}
+	}
+	var callback mem.AlertStoreCallback
+	if len(callbacks) > 0 {
+		callback = callbacks
 	}
 	am.alerts, err = mem.NewAlerts(context.Background(), am.marker, am.cfg.GCInterval, callback, am.logger, am.registry)
 	if err != nil {
@@ -302,6 +326,34 @@ func New(cfg *Config, reg *prometheus.Registry) (*Alertmanager, error) {
 		am.mux.Handle(a, http.NotFoundHandler())
 	}
 
+	am.mux.Handle(path.Join(am.cfg.ExternalURL.Path, "/api/v1/route_analytics"), am.routeAnalytics)
+	am.mux.Handle(path.Join(am.cfg.ExternalURL.Path, "/api/v1/receivers_health"), am.receiverHealth)
+
+	if am.cfg.Limits != nil {
+		am.silencesGC = newSilencesGC(am.cfg.UserID, am.cfg.Limits, am.silences, am.alerts, log.With(am.logger, "component", "silences_gc"), am.registry)
+
+		// Enforce the silences limits on the silences created through the API.
+		silencesPath := path.Join(am.cfg.ExternalURL.Path, "/api/v2/silences")
+		next, _ := am.mux.Handler(&http.Request{Method: http.MethodPost, URL: &url.URL{Path: silencesPath}})
+		am.mux.Handle(silencesPath, am.silencesGC.wrapPostSilences(next))
+
+		am.wg.Add(1)
+		go func() {
+			am.silencesGC.run(silencesGCInterval, am.stop)
+			am.wg.Done()
+		}()
+	}
+
+	if am.alertsArchive != nil {
+		am.mux.Handle(path.Join(am.cfg.ExternalURL.Path, "/api/v1/alerts_archive"), am.alertsArchive)
+
+		am.wg.Add(1)
+		go func() {
+			am.alertsArchive.run(am.cfg.AlertsArchiveFlushInterval, am.stop)
+			am.wg.Done()
+		}()
+	}
+
 	am.dispatcherMetrics = dispatch.NewDispatcherMetrics(true, am.registry)
 
 	//TODO: From this point onward, the alertmanager _might_ receive requests - we need to make sure we've settled and are ready.
@@ -325,8 +377,9 @@ func clusterWait(position func() int, timeout time.Duration) func() time.Duratio
 	}
 }
 
-// ApplyConfig applies a new configuration to an Alertmanager.
-func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg string) error {
+// ApplyConfig applies a new configuration to an Alertmanager. The webhooks of the receivers
+// in webhookPayloadTemplates send the payload rendered with the named template.
+func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg string, webhookPayloadTemplates map[string]string) error {
 	templateFiles := make([]string, len(conf.Templates))
 	for i, t := range conf.Templates {
 		templateFilepath, err := safeTemplateFilepath(filepath.Join(am.cfg.TenantDataDir, templatesDir), t)
@@ -337,7 +390,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 		templateFiles[i] = templateFilepath
 	}
 
-	tmpl, err := template.FromGlobs(templateFiles)
+	tmpl, err := template.FromGlobs(templateFiles, withWebhookPayloadFuncs)
 	if err != nil {
 		return err
 	}
@@ -369,7 +422,7 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 	// Create a firewall binded to the per-tenant config.
 	firewallDialer := util_net.NewFirewallDialer(newFirewallDialerConfigProvider(userID, am.cfg.Limits))
 
-	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
+	integrationsMap, err := buildIntegrationsMap(conf.Receivers, tmpl, webhookPayloadTemplates, firewallDialer, am.logger, func(integrationName string, notifier notify.Notifier) notify.Notifier {
 		if am.cfg.Limits != nil {
 			rl := &tenantRateLimits{
 				tenant:      userID,
@@ -377,9 +430,9 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 				integration: integrationName,
 			}
 
-			return newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
+			notifier = newRateLimitedNotifier(notifier, rl, 10*time.Second, am.rateLimitedNotifications.WithLabelValues(integrationName))
 		}
-		return notifier
+		return am.receiverHealth.wrapNotifier(integrationName, am.routeAnalytics.wrapNotifier(integrationName, notifier))
 	})
 	if err != nil {
 		return nil
@@ -404,10 +457,17 @@ func (am *Alertmanager) ApplyConfig(userID string, conf *config.Config, rawCfg s
 		am.state,
 	)
 	am.lastPipeline = pipeline
+
+	route := dispatch.NewRoute(conf.Route, nil)
+	if am.alertsLimiter != nil {
+		am.alertsLimiter.setRoute(route)
+	}
+	am.routeAnalytics.removeStaleRoutes(route)
+
 	am.dispatcher = dispatch.NewDispatcher(
 		am.alerts,
-		dispatch.NewRoute(conf.Route, nil),
-		pipeline,
+		route,
+		am.routeAnalytics.wrapStage(pipeline),
 		am.marker,
 		timeoutFunc,
 		&dispatcherLimits{tenant: am.cfg.UserID, limits: am.cfg.Limits},
@@ -478,10 +538,10 @@ func (am *Alertmanager) getFullState() (*clusterpb.FullState, error) {
 
 // buildIntegrationsMap builds a map of name to the list of integration notifiers off of a
 // list of receiver config.
-func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
+func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, webhookPayloadTemplates map[string]string, firewallDialer *util_net.FirewallDialer, logger log.Logger, notifierWrapper func(string, notify.Notifier) notify.Notifier) (map[string][]notify.Integration, error) {
 	integrationsMap := make(map[string][]notify.Integration, len(nc))
 	for _, rcv := range nc {
-		integrations, err := buildReceiverIntegrations(rcv, tmpl, firewallDialer, logger, notifierWrapper)
+		integrations, err := buildReceiverIntegrations(rcv, tmpl, webhookPayloadTemplates[rcv.Name], firewallDialer, logger, notifierWrapper)
 		if err != nil {
 			return nil, err
 		}
@@ -491,9 +551,10 @@ func buildIntegrationsMap(nc []config.Receiver, tmpl *template.Template, firewal
 }
 
 // buildReceiverIntegrations builds a list of integration notifiers off of a
-// receiver config.
+// receiver config. The webhooks send the payload rendered with the webhookPayloadTemplate,
+// unless empty.
 // Taken from https://github.com/prometheus/alertmanager/blob/d7b4f0c7322e7151d6e3b1e31cbc15361e295d8d/cmd/alertmanager/main.go#L135-L193.
-func buildReceiverIntegrations(nc config.Receiver, tmpl *template.Template, firewallDialer *util_net.FirewallDialer, logger log.Logger, wrapper func(string, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
+func buildReceiverIntegrations(nc config.Receiver, tmpl *template.Template, webhookPayloadTemplate string, firewallDialer *util_net.FirewallDialer, logger log.Logger, wrapper func(string, notify.Notifier) notify.Notifier) ([]notify.Integration, error) {
 	var (
 		errs         types.MultiError
 		integrations []notify.Integration
@@ -514,6 +575,12 @@ func buildReceiverIntegrations(nc config.Receiver, tmpl *template.Template, fire
 	}
 
 	for i, c := range nc.WebhookConfigs {
+		if webhookPayloadTemplate != "" {
+			add("webhook", i, c, func(l log.Logger) (notify.Notifier, error) {
+				return newTemplatedWebhook(c, tmpl, webhookPayloadTemplate, l, httpOps...)
+			})
+			continue
+		}
 		add("webhook", i, c, func(l log.Logger) (notify.Notifier, error) { return webhook.New(c, tmpl, l, httpOps...) })
 	}
 	for i, c := range nc.EmailConfigs {
@@ -629,23 +696,62 @@ func (g *dispatcherLimits) MaxNumberOfAggregationGroups() int {
 }
 
 var (
-	errTooManyAlerts = "too many alerts, limit: %d, alert name: %s"
-	errAlertsTooBig  = "alerts too big, total size limit: %d bytes"
+	errTooManyAlerts      = "too many alerts, limit: %d, alert name: %s"
+	errAlertsTooBig       = "alerts too big, total size limit: %d bytes"
+	errTooManyAlertGroups = "too many alert groups, limit: %d, alert name: %s, new group: %s"
 )
 
 // alertsLimiter limits the number and size of alerts being received by the Alertmanager.
 // We consider an alert unique based on its fingerprint (a hash of its labels) and
 // its size it's determined by the sum of bytes of its labels, annotations, and generator URL.
+//
+// The limiter also tracks the groups the alerts belong to, which are the groups the dispatcher
+// aggregates the alerts in, in order to reject the alerts that would create too many groups
+// (eg. because of a group_by on a label with a high cardinality). The groups are only tracked
+// while the limit is enabled: when it gets enabled at runtime, the alerts already stored are
+// tracked once they're received again.
 type alertsLimiter struct {
 	tenant string
 	limits Limits
 
-	failureCounter prometheus.Counter
+	failureCounter       prometheus.Counter
+	groupsFailureCounter prometheus.Counter
 
 	mx        sync.Mutex
 	sizes     map[model.Fingerprint]int
 	count     int
 	totalSize int
+
+	// The route tree of the current configuration, used to compute the groups of the alerts.
+	route       *dispatch.Route
+	labels      map[model.Fingerprint]model.LabelSet
+	alertGroups map[model.Fingerprint][]string
+	groups      map[string]int
+}
+
+// alertStoreCallbacks is a mem.AlertStoreCallback calling multiple callbacks in order. An alert
+// is not stored if any of the callbacks fails before storing it.
+type alertStoreCallbacks []mem.AlertStoreCallback
+
+func (c alertStoreCallbacks) PreStore(alert *types.Alert, existing bool) error {
+	for _, callback := range c {
+		if err := callback.PreStore(alert, existing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c alertStoreCallbacks) PostStore(alert *types.Alert, existing bool) {
+	for _, callback := range c {
+		callback.PostStore(alert, existing)
+	}
+}
+
+func (c alertStoreCallbacks) PostDelete(alert *types.Alert) {
+	for _, callback := range c {
+		callback.PostDelete(alert)
+	}
 }
 
 func newAlertsLimiter(tenant string, limits Limits, reg prometheus.Registerer) *alertsLimiter {
@@ -657,6 +763,13 @@ func newAlertsLimiter(tenant string, limits Limits, reg prometheus.Registerer) *
 			Name: "alertmanager_alerts_insert_limited_total",
 			Help: "Number of failures to insert new alerts to in-memory alert store.",
 		}),
+		groupsFailureCounter: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_insert_groups_limited_total",
+			Help: "Number of failures to insert new alerts to in-memory alert store because they would create too many alert groups.",
+		}),
+		labels:      map[model.Fingerprint]model.LabelSet{},
+		alertGroups: map[model.Fingerprint][]string{},
+		groups:      map[string]int{},
 	}
 
 	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
@@ -675,9 +788,33 @@ func newAlertsLimiter(tenant string, limits Limits, reg prometheus.Registerer) *
 		return float64(s)
 	})
 
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "alertmanager_alerts_limiter_current_alert_groups",
+		Help: "Number of alert groups tracked by alerts limiter.",
+	}, func() float64 {
+		return float64(limiter.currentGroups())
+	})
+
 	return limiter
 }
 
+// setRoute sets the route tree used to compute the groups of the alerts, and recomputes
+// the groups of the tracked alerts given they may have changed with the configuration.
+func (a *alertsLimiter) setRoute(route *dispatch.Route) {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	a.route = route
+	a.groups = map[string]int{}
+	for fp, lset := range a.labels {
+		keys := a.groupKeys(lset)
+		for _, k := range keys {
+			a.groups[k]++
+		}
+		a.alertGroups[fp] = keys
+	}
+}
+
 func (a *alertsLimiter) PreStore(alert *types.Alert, existing bool) error {
 	if alert == nil {
 		return nil
@@ -687,6 +824,7 @@ func (a *alertsLimiter) PreStore(alert *types.Alert, existing bool) error {
 
 	countLimit := a.limits.AlertmanagerMaxAlertsCount(a.tenant)
 	sizeLimit := a.limits.AlertmanagerMaxAlertsSizeBytes(a.tenant)
+	groupsLimit := a.limits.AlertmanagerMaxAlertGroups(a.tenant)
 
 	sizeDiff := alertSize(alert.Alert)
 
@@ -707,6 +845,23 @@ func (a *alertsLimiter) PreStore(alert *types.Alert, existing bool) error {
 		return fmt.Errorf(errAlertsTooBig, sizeLimit)
 	}
 
+	if groupsLimit > 0 {
+		// The alerts belonging to existing groups are always accepted, so that the groups
+		// within the limit keep working properly.
+		var newGroups []string
+		for _, k := range a.groupKeys(alert.Labels) {
+			if _, ok := a.groups[k]; !ok {
+				newGroups = append(newGroups, k)
+			}
+		}
+
+		if len(newGroups) > 0 && len(a.groups)+len(newGroups) > groupsLimit {
+			a.failureCounter.Inc()
+			a.groupsFailureCounter.Inc()
+			return fmt.Errorf(errTooManyAlertGroups, groupsLimit, alert.Name(), newGroups[0])
+		}
+	}
+
 	return nil
 }
 
@@ -728,6 +883,19 @@ func (a *alertsLimiter) PostStore(alert *types.Alert, existing bool) {
 	}
 	a.sizes[fp] = newSize
 	a.totalSize += newSize
+
+	if a.limits.AlertmanagerMaxAlertGroups(a.tenant) <= 0 {
+		a.resetGroups()
+		return
+	}
+
+	a.untrackGroups(fp)
+	keys := a.groupKeys(alert.Labels)
+	for _, k := range keys {
+		a.groups[k]++
+	}
+	a.labels[fp] = alert.Labels
+	a.alertGroups[fp] = keys
 }
 
 func (a *alertsLimiter) PostDelete(alert *types.Alert) {
@@ -743,6 +911,58 @@ func (a *alertsLimiter) PostDelete(alert *types.Alert) {
 	a.totalSize -= a.sizes[fp]
 	delete(a.sizes, fp)
 	a.count--
+
+	a.untrackGroups(fp)
+	delete(a.labels, fp)
+}
+
+// resetGroups stops tracking the groups of all alerts. Must be called with the lock held.
+func (a *alertsLimiter) resetGroups() {
+	if len(a.labels) == 0 {
+		return
+	}
+
+	a.labels = map[model.Fingerprint]model.LabelSet{}
+	a.alertGroups = map[model.Fingerprint][]string{}
+	a.groups = map[string]int{}
+}
+
+// untrackGroups removes the alert from its groups. Must be called with the lock held.
+func (a *alertsLimiter) untrackGroups(fp model.Fingerprint) {
+	for _, k := range a.alertGroups[fp] {
+		if a.groups[k]--; a.groups[k] <= 0 {
+			delete(a.groups, k)
+		}
+	}
+	delete(a.alertGroups, fp)
+}
+
+// groupKeys returns the keys of the groups the dispatcher aggregates the alert with the input
+// labels in, one for each matching route. Must be called with the lock held.
+func (a *alertsLimiter) groupKeys(lset model.LabelSet) []string {
+	if a.route == nil {
+		return nil
+	}
+
+	routes := a.route.Match(lset)
+	keys := make([]string, 0, len(routes))
+	for _, r := range routes {
+		groupLabels := model.LabelSet{}
+		for ln, lv := range lset {
+			if _, ok := r.RouteOpts.GroupBy[ln]; ok || r.RouteOpts.GroupByAll {
+				groupLabels[ln] = lv
+			}
+		}
+		keys = append(keys, fmt.Sprintf("%s:%s", r.ID(), groupLabels))
+	}
+	return keys
+}
+
+func (a *alertsLimiter) currentGroups() int {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+
+	return len(a.groups)
 }
 
 func (a *alertsLimiter) currentStats() (count, totalSize int) {
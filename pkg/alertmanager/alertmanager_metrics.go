@@ -58,14 +58,28 @@ type alertmanagerMetrics struct {
 	initialSyncDuration     *prometheus.Desc
 	persistTotal            *prometheus.Desc
 	persistFailed           *prometheus.Desc
+	persistedSize           *prometheus.Desc
+	persistCompacted        *prometheus.Desc
 
 	notificationRateLimited                 *prometheus.Desc
+	routeAlertsGrouped                      *prometheus.Desc
+	routeGroupFlushes                       *prometheus.Desc
+	routeGroupFlushesThrottled              *prometheus.Desc
+	routeNotifications                      *prometheus.Desc
+	routeNotificationsFailed                *prometheus.Desc
+	routeNotificationsRateLimited           *prometheus.Desc
 	dispatcherAggregationGroups             *prometheus.Desc
 	dispatcherProcessingDuration            *prometheus.Desc
 	dispatcherAggregationGroupsLimitReached *prometheus.Desc
 	insertAlertFailures                     *prometheus.Desc
+	insertAlertGroupsFailures               *prometheus.Desc
 	alertsLimiterAlertsCount                *prometheus.Desc
 	alertsLimiterAlertsSize                 *prometheus.Desc
+	alertsLimiterAlertGroups                *prometheus.Desc
+	silencesLimited                         *prometheus.Desc
+	silencesGCExpired                       *prometheus.Desc
+	alertsArchived                          *prometheus.Desc
+	alertsArchiveFlushesFailed              *prometheus.Desc
 }
 
 func newAlertmanagerMetrics() *alertmanagerMetrics {
@@ -211,10 +225,42 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_state_persist_failed_total",
 			"Number of times we have failed to persist the running state to storage.",
 			nil, nil),
+		persistedSize: prometheus.NewDesc(
+			"cortex_alertmanager_state_persisted_size_bytes",
+			"Size of the state last persisted to storage, after compaction.",
+			[]string{"user"}, nil),
+		persistCompacted: prometheus.NewDesc(
+			"cortex_alertmanager_state_persist_compacted_entries_total",
+			"Number of notification log entries and silences not persisted to storage because of the state compaction.",
+			[]string{"reason"}, nil),
 		notificationRateLimited: prometheus.NewDesc(
 			"cortex_alertmanager_notification_rate_limited_total",
 			"Total number of rate-limited notifications per integration.",
 			[]string{"user", "integration"}, nil),
+		routeAlertsGrouped: prometheus.NewDesc(
+			"cortex_alertmanager_route_alerts_grouped_total",
+			"Total number of alerts in the aggregation groups flushed per route.",
+			[]string{"user", "route"}, nil),
+		routeGroupFlushes: prometheus.NewDesc(
+			"cortex_alertmanager_route_group_flushes_total",
+			"Total number of aggregation group flushes per route.",
+			[]string{"user", "route"}, nil),
+		routeGroupFlushesThrottled: prometheus.NewDesc(
+			"cortex_alertmanager_route_group_flushes_throttled_total",
+			"Total number of aggregation group flushes per route which didn't notify any integration.",
+			[]string{"user", "route"}, nil),
+		routeNotifications: prometheus.NewDesc(
+			"cortex_alertmanager_route_notifications_total",
+			"Total number of notifications sent per route and integration.",
+			[]string{"user", "route", "integration"}, nil),
+		routeNotificationsFailed: prometheus.NewDesc(
+			"cortex_alertmanager_route_notifications_failed_total",
+			"Total number of notifications failed per route and integration.",
+			[]string{"user", "route", "integration"}, nil),
+		routeNotificationsRateLimited: prometheus.NewDesc(
+			"cortex_alertmanager_route_notifications_rate_limited_total",
+			"Total number of notifications rate-limited per route and integration.",
+			[]string{"user", "route", "integration"}, nil),
 		dispatcherAggregationGroupsLimitReached: prometheus.NewDesc(
 			"cortex_alertmanager_dispatcher_aggregation_group_limit_reached_total",
 			"Number of times when dispatcher failed to create new aggregation group due to limit.",
@@ -231,6 +277,10 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_alerts_insert_limited_total",
 			"Total number of failures to store alert due to hitting alertmanager limits.",
 			[]string{"user"}, nil),
+		insertAlertGroupsFailures: prometheus.NewDesc(
+			"cortex_alertmanager_alerts_insert_groups_limited_total",
+			"Total number of failures to store alert because it would create too many alert groups.",
+			[]string{"user"}, nil),
 		alertsLimiterAlertsCount: prometheus.NewDesc(
 			"cortex_alertmanager_alerts_limiter_current_alerts",
 			"Number of alerts tracked by alerts limiter.",
@@ -239,6 +289,26 @@ func newAlertmanagerMetrics() *alertmanagerMetrics {
 			"cortex_alertmanager_alerts_limiter_current_alerts_size_bytes",
 			"Total size of alerts tracked by alerts limiter.",
 			[]string{"user"}, nil),
+		alertsLimiterAlertGroups: prometheus.NewDesc(
+			"cortex_alertmanager_alerts_limiter_current_alert_groups",
+			"Number of alert groups tracked by alerts limiter.",
+			[]string{"user"}, nil),
+		silencesLimited: prometheus.NewDesc(
+			"cortex_alertmanager_silences_limited_total",
+			"Number of silences rejected because of the silences limits.",
+			[]string{"user", "reason"}, nil),
+		silencesGCExpired: prometheus.NewDesc(
+			"cortex_alertmanager_silences_gc_expired_total",
+			"Number of silences expired by the silences GC policies.",
+			[]string{"user", "reason"}, nil),
+		alertsArchived: prometheus.NewDesc(
+			"cortex_alertmanager_alerts_archived_total",
+			"Number of alerts archived to object storage.",
+			[]string{"user"}, nil),
+		alertsArchiveFlushesFailed: prometheus.NewDesc(
+			"cortex_alertmanager_alerts_archive_flushes_failed_total",
+			"Number of batches of alerts which have failed to be archived to object storage.",
+			[]string{"user"}, nil),
 	}
 }
 
@@ -288,13 +358,27 @@ func (m *alertmanagerMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.initialSyncDuration
 	out <- m.persistTotal
 	out <- m.persistFailed
+	out <- m.persistedSize
+	out <- m.persistCompacted
 	out <- m.notificationRateLimited
+	out <- m.routeAlertsGrouped
+	out <- m.routeGroupFlushes
+	out <- m.routeGroupFlushesThrottled
+	out <- m.routeNotifications
+	out <- m.routeNotificationsFailed
+	out <- m.routeNotificationsRateLimited
 	out <- m.dispatcherAggregationGroups
 	out <- m.dispatcherProcessingDuration
 	out <- m.dispatcherAggregationGroupsLimitReached
 	out <- m.insertAlertFailures
+	out <- m.insertAlertGroupsFailures
 	out <- m.alertsLimiterAlertsCount
 	out <- m.alertsLimiterAlertsSize
+	out <- m.alertsLimiterAlertGroups
+	out <- m.silencesLimited
+	out <- m.silencesGCExpired
+	out <- m.alertsArchived
+	out <- m.alertsArchiveFlushesFailed
 }
 
 func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
@@ -340,12 +424,26 @@ func (m *alertmanagerMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfHistograms(out, m.initialSyncDuration, "alertmanager_state_initial_sync_duration_seconds")
 	data.SendSumOfCounters(out, m.persistTotal, "alertmanager_state_persist_total")
 	data.SendSumOfCounters(out, m.persistFailed, "alertmanager_state_persist_failed_total")
+	data.SendSumOfGaugesPerUser(out, m.persistedSize, "alertmanager_state_persisted_size_bytes")
+	data.SendSumOfCountersWithLabels(out, m.persistCompacted, "alertmanager_state_persist_compacted_entries_total", "reason")
 
 	data.SendSumOfCountersPerUserWithLabels(out, m.notificationRateLimited, "alertmanager_notification_rate_limited_total", "integration")
+	data.SendSumOfCountersPerUserWithLabels(out, m.routeAlertsGrouped, "alertmanager_route_alerts_grouped_total", "route")
+	data.SendSumOfCountersPerUserWithLabels(out, m.routeGroupFlushes, "alertmanager_route_group_flushes_total", "route")
+	data.SendSumOfCountersPerUserWithLabels(out, m.routeGroupFlushesThrottled, "alertmanager_route_group_flushes_throttled_total", "route")
+	data.SendSumOfCountersPerUserWithLabels(out, m.routeNotifications, "alertmanager_route_notifications_total", "route", "integration")
+	data.SendSumOfCountersPerUserWithLabels(out, m.routeNotificationsFailed, "alertmanager_route_notifications_failed_total", "route", "integration")
+	data.SendSumOfCountersPerUserWithLabels(out, m.routeNotificationsRateLimited, "alertmanager_route_notifications_rate_limited_total", "route", "integration")
 	data.SendSumOfGaugesPerUser(out, m.dispatcherAggregationGroups, "alertmanager_dispatcher_aggregation_groups")
 	data.SendSumOfSummariesPerUser(out, m.dispatcherProcessingDuration, "alertmanager_dispatcher_alert_processing_duration_seconds")
 	data.SendSumOfCountersPerUser(out, m.dispatcherAggregationGroupsLimitReached, "alertmanager_dispatcher_aggregation_group_limit_reached_total")
 	data.SendSumOfCountersPerUser(out, m.insertAlertFailures, "alertmanager_alerts_insert_limited_total")
 	data.SendSumOfGaugesPerUser(out, m.alertsLimiterAlertsCount, "alertmanager_alerts_limiter_current_alerts")
 	data.SendSumOfGaugesPerUser(out, m.alertsLimiterAlertsSize, "alertmanager_alerts_limiter_current_alerts_size_bytes")
+	data.SendSumOfCountersPerUser(out, m.insertAlertGroupsFailures, "alertmanager_alerts_insert_groups_limited_total")
+	data.SendSumOfGaugesPerUser(out, m.alertsLimiterAlertGroups, "alertmanager_alerts_limiter_current_alert_groups")
+	data.SendSumOfCountersPerUserWithLabels(out, m.silencesLimited, "alertmanager_silences_limited_total", "reason")
+	data.SendSumOfCountersPerUserWithLabels(out, m.silencesGCExpired, "alertmanager_silences_gc_expired_total", "reason")
+	data.SendSumOfCountersPerUser(out, m.alertsArchived, "alertmanager_alerts_archived_total")
+	data.SendSumOfCountersPerUser(out, m.alertsArchiveFlushesFailed, "alertmanager_alerts_archive_flushes_failed_total")
 }
@@ -298,6 +298,11 @@ func TestAlertmanagerMetricsStore(t *testing.T) {
 		# TYPE cortex_alertmanager_state_persist_total counter
 		cortex_alertmanager_state_persist_total 0
 
+		# HELP cortex_alertmanager_alerts_limiter_current_alert_groups Number of alert groups tracked by alerts limiter.
+		# TYPE cortex_alertmanager_alerts_limiter_current_alert_groups gauge
+		cortex_alertmanager_alerts_limiter_current_alert_groups{user="user1"} 3
+		cortex_alertmanager_alerts_limiter_current_alert_groups{user="user2"} 30
+		cortex_alertmanager_alerts_limiter_current_alert_groups{user="user3"} 300
 		# HELP cortex_alertmanager_alerts_limiter_current_alerts Number of alerts tracked by alerts limiter.
 		# TYPE cortex_alertmanager_alerts_limiter_current_alerts gauge
 		cortex_alertmanager_alerts_limiter_current_alerts{user="user1"} 10
@@ -308,6 +313,11 @@ func TestAlertmanagerMetricsStore(t *testing.T) {
 		cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user1"} 100
 		cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user2"} 1000
 		cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user3"} 10000
+		# HELP cortex_alertmanager_alerts_insert_groups_limited_total Total number of failures to store alert because it would create too many alert groups.
+		# TYPE cortex_alertmanager_alerts_insert_groups_limited_total counter
+		cortex_alertmanager_alerts_insert_groups_limited_total{user="user1"} 2
+		cortex_alertmanager_alerts_insert_groups_limited_total{user="user2"} 20
+		cortex_alertmanager_alerts_insert_groups_limited_total{user="user3"} 200
 		# HELP cortex_alertmanager_alerts_insert_limited_total Total number of failures to store alert due to hitting alertmanager limits.
 		# TYPE cortex_alertmanager_alerts_insert_limited_total counter
 		cortex_alertmanager_alerts_insert_limited_total{user="user1"} 7
@@ -619,6 +629,11 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 						# TYPE cortex_alertmanager_state_persist_total counter
 						cortex_alertmanager_state_persist_total 0
 
+						# HELP cortex_alertmanager_alerts_limiter_current_alert_groups Number of alert groups tracked by alerts limiter.
+						# TYPE cortex_alertmanager_alerts_limiter_current_alert_groups gauge
+						cortex_alertmanager_alerts_limiter_current_alert_groups{user="user1"} 3
+						cortex_alertmanager_alerts_limiter_current_alert_groups{user="user2"} 30
+						cortex_alertmanager_alerts_limiter_current_alert_groups{user="user3"} 300
 						# HELP cortex_alertmanager_alerts_limiter_current_alerts Number of alerts tracked by alerts limiter.
 						# TYPE cortex_alertmanager_alerts_limiter_current_alerts gauge
 						cortex_alertmanager_alerts_limiter_current_alerts{user="user1"} 10
@@ -629,6 +644,11 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 						cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user1"} 100
 						cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user2"} 1000
 						cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user3"} 10000
+						# HELP cortex_alertmanager_alerts_insert_groups_limited_total Total number of failures to store alert because it would create too many alert groups.
+						# TYPE cortex_alertmanager_alerts_insert_groups_limited_total counter
+						cortex_alertmanager_alerts_insert_groups_limited_total{user="user1"} 2
+						cortex_alertmanager_alerts_insert_groups_limited_total{user="user2"} 20
+						cortex_alertmanager_alerts_insert_groups_limited_total{user="user3"} 200
 						# HELP cortex_alertmanager_alerts_insert_limited_total Total number of failures to store alert due to hitting alertmanager limits.
 						# TYPE cortex_alertmanager_alerts_insert_limited_total counter
 						cortex_alertmanager_alerts_insert_limited_total{user="user1"} 7
@@ -884,6 +904,10 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 			# TYPE cortex_alertmanager_state_persist_total counter
 			cortex_alertmanager_state_persist_total 0
 
+			# HELP cortex_alertmanager_alerts_limiter_current_alert_groups Number of alert groups tracked by alerts limiter.
+			# TYPE cortex_alertmanager_alerts_limiter_current_alert_groups gauge
+			cortex_alertmanager_alerts_limiter_current_alert_groups{user="user1"} 3
+			cortex_alertmanager_alerts_limiter_current_alert_groups{user="user2"} 30
 			# HELP cortex_alertmanager_alerts_limiter_current_alerts Number of alerts tracked by alerts limiter.
 			# TYPE cortex_alertmanager_alerts_limiter_current_alerts gauge
 			cortex_alertmanager_alerts_limiter_current_alerts{user="user1"} 10
@@ -892,6 +916,10 @@ func TestAlertmanagerMetricsRemoval(t *testing.T) {
 			# TYPE cortex_alertmanager_alerts_limiter_current_alerts_size_bytes gauge
 			cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user1"} 100
 			cortex_alertmanager_alerts_limiter_current_alerts_size_bytes{user="user2"} 1000
+			# HELP cortex_alertmanager_alerts_insert_groups_limited_total Total number of failures to store alert because it would create too many alert groups.
+			# TYPE cortex_alertmanager_alerts_insert_groups_limited_total counter
+			cortex_alertmanager_alerts_insert_groups_limited_total{user="user1"} 2
+			cortex_alertmanager_alerts_insert_groups_limited_total{user="user2"} 20
 			# HELP cortex_alertmanager_alerts_insert_limited_total Total number of failures to store alert due to hitting alertmanager limits.
 			# TYPE cortex_alertmanager_alerts_insert_limited_total counter
 			cortex_alertmanager_alerts_insert_limited_total{user="user1"} 7
@@ -950,6 +978,8 @@ func populateAlertmanager(base float64) *prometheus.Registry {
 	lm.count.Set(10 * base)
 	lm.size.Set(100 * base)
 	lm.insertFailures.Add(7 * base)
+	lm.groups.Set(3 * base)
+	lm.insertGroupsFailures.Add(2 * base)
 
 	sr := newStateReplicationMetrics(reg)
 	sr.partialStateMergesFailed.WithLabelValues("nfl").Add(base * 2)
@@ -1160,9 +1190,11 @@ func newAPIMetrics(version string, r prometheus.Registerer) *apiMetrics {
 }
 
 type limiterMetrics struct {
-	count          prometheus.Gauge
-	size           prometheus.Gauge
-	insertFailures prometheus.Counter
+	count                prometheus.Gauge
+	size                 prometheus.Gauge
+	groups               prometheus.Gauge
+	insertFailures       prometheus.Counter
+	insertGroupsFailures prometheus.Counter
 }
 
 func newLimiterMetrics(r prometheus.Registerer) *limiterMetrics {
@@ -1176,15 +1208,27 @@ func newLimiterMetrics(r prometheus.Registerer) *limiterMetrics {
 		Help: "Total size of alerts tracked by alerts limiter.",
 	})
 
+	groups := promauto.With(r).NewGauge(prometheus.GaugeOpts{
+		Name: "alertmanager_alerts_limiter_current_alert_groups",
+		Help: "Number of alert groups tracked by alerts limiter.",
+	})
+
 	insertAlertFailures := promauto.With(r).NewCounter(prometheus.CounterOpts{
 		Name: "alertmanager_alerts_insert_limited_total",
 		Help: "Number of failures to insert new alerts to in-memory alert store.",
 	})
 
+	insertAlertGroupsFailures := promauto.With(r).NewCounter(prometheus.CounterOpts{
+		Name: "alertmanager_alerts_insert_groups_limited_total",
+		Help: "Number of failures to insert new alerts to in-memory alert store because they would create too many alert groups.",
+	})
+
 	return &limiterMetrics{
-		count:          count,
-		size:           size,
-		insertFailures: insertAlertFailures,
+		count:                count,
+		size:                 size,
+		groups:               groups,
+		insertFailures:       insertAlertFailures,
+		insertGroupsFailures: insertAlertGroupsFailures,
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
 	"github.com/prometheus/alertmanager/types"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
@@ -62,7 +63,7 @@ route:
 
 	cfg, err := config.Load(cfgRaw)
 	require.NoError(t, err)
-	require.NoError(t, am.ApplyConfig(user, cfg, cfgRaw))
+	require.NoError(t, am.ApplyConfig(user, cfg, cfgRaw, nil))
 
 	now := time.Now()
 
@@ -213,6 +214,74 @@ func TestAlertsLimiterWithSizeLimitAndAnnotationUpdate(t *testing.T) {
 	})
 }
 
+func TestAlertsLimiterWithAlertGroupsLimit(t *testing.T) {
+	newAlert := func(lset model.LabelSet) *types.Alert {
+		return &types.Alert{Alert: model.Alert{Labels: lset}}
+	}
+
+	newRoute := func(groupByAll bool, groupBy ...model.LabelName) *dispatch.Route {
+		return dispatch.NewRoute(&config.Route{Receiver: "default", GroupBy: groupBy, GroupByAll: groupByAll}, nil)
+	}
+
+	var (
+		alertA1 = newAlert(model.LabelSet{"alertname": "A", "instance": "1"})
+		alertA2 = newAlert(model.LabelSet{"alertname": "A", "instance": "2"})
+		alertA3 = newAlert(model.LabelSet{"alertname": "A", "instance": "3"})
+		alertB  = newAlert(model.LabelSet{"alertname": "B", "instance": "1"})
+		alertC  = newAlert(model.LabelSet{"alertname": "C", "instance": "1"})
+	)
+
+	limits := &mockAlertManagerLimits{maxAlertGroups: 2}
+	limiter := newAlertsLimiter("test", limits, prometheus.NewPedanticRegistry())
+	limiter.setRoute(newRoute(false, "alertname"))
+
+	insert := func(alert *types.Alert, existing bool) error {
+		err := limiter.PreStore(alert, existing)
+		if err == nil {
+			limiter.PostStore(alert, existing)
+		}
+		return err
+	}
+
+	require.NoError(t, insert(alertA1, false))
+	require.NoError(t, insert(alertA2, false))
+	require.NoError(t, insert(alertB, false))
+	assert.Equal(t, 2, limiter.currentGroups())
+
+	// A new group can't be created once the limit is reached.
+	require.EqualError(t, insert(alertC, false), fmt.Sprintf(errTooManyAlertGroups, 2, "C", "{}:{alertname=\"C\"}"))
+	assert.Equal(t, 2, limiter.currentGroups())
+
+	// The group is removed once its last alert is deleted.
+	limiter.PostDelete(alertB)
+	require.NoError(t, insert(alertC, false))
+	assert.Equal(t, 2, limiter.currentGroups())
+
+	// The groups are recomputed when the route changes, and the alerts of the existing
+	// groups are still accepted when the limit is exceeded.
+	limiter.setRoute(newRoute(true))
+	assert.Equal(t, 3, limiter.currentGroups())
+	require.NoError(t, insert(alertA1, true))
+	require.Error(t, insert(alertA3, false))
+	assert.Equal(t, 3, limiter.currentGroups())
+
+	count, _ := limiter.currentStats()
+	assert.Equal(t, 3, count)
+	assert.Equal(t, float64(2), testutil.ToFloat64(limiter.groupsFailureCounter))
+
+	// The groups are not tracked while the limit is disabled.
+	limits.maxAlertGroups = 0
+	require.NoError(t, insert(alertA3, false))
+	assert.Equal(t, 0, limiter.currentGroups())
+	assert.Empty(t, limiter.labels)
+
+	// Once enabled again, the alerts are tracked as they're received.
+	limits.maxAlertGroups = 2
+	require.NoError(t, insert(alertA1, true))
+	require.NoError(t, insert(alertA2, true))
+	assert.Equal(t, 2, limiter.currentGroups())
+}
+
 // testLimiter sends sequence of alerts to limiter, and checks if limiter updated reacted correctly.
 func testLimiter(t *testing.T, limits Limits, ops []callbackOp) {
 	reg := prometheus.NewPedanticRegistry()
@@ -0,0 +1,357 @@
+package alertmanager
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/runutil"
+)
+
+const (
+	// alertsArchiveTimeout is the timeout of the flushes to and the reads from the object storage.
+	alertsArchiveTimeout = 30 * time.Second
+
+	// alertsArchiveDefaultRange is the time range of the archived alerts returned by default.
+	alertsArchiveDefaultRange = time.Hour
+
+	// alertsArchiveRetentionInterval is how often the batches older than the retention are deleted,
+	// given it requires to list all the batches of the tenant.
+	alertsArchiveRetentionInterval = time.Hour
+
+	alertsArchiveBatchExt = ".json.gz"
+)
+
+// archivedAlert is an alert as stored in the archive.
+type archivedAlert struct {
+	Fingerprint  string         `json:"fingerprint"`
+	Labels       model.LabelSet `json:"labels"`
+	Annotations  model.LabelSet `json:"annotations"`
+	StartsAt     time.Time      `json:"startsAt"`
+	EndsAt       time.Time      `json:"endsAt"`
+	GeneratorURL string         `json:"generatorURL,omitempty"`
+	ReceivedAt   time.Time      `json:"receivedAt"`
+}
+
+// alertsArchive buffers the alerts received by a tenant, deduplicated by fingerprint, and periodically
+// flushes them to object storage as a gzipped JSON batch. Only the replica at position zero writes the
+// batches, so the alerts are archived once regardless of the replication factor. The batches are named
+// after the time range in which their alerts have been received, so that they can be listed by time range
+// and deleted after the retention without being read.
+type alertsArchive struct {
+	tenant   string
+	limits   Limits
+	bkt      objstore.Bucket
+	position func() int
+	logger   log.Logger
+	now      func() time.Time
+
+	mtx          sync.Mutex
+	pending      map[model.Fingerprint]archivedAlert
+	pendingSince time.Time
+
+	// The last time the retention has been enforced, only accessed by flush.
+	retentionEnforcedAt time.Time
+
+	archived      prometheus.Counter
+	flushesFailed prometheus.Counter
+}
+
+func newAlertsArchive(tenant string, limits Limits, bkt objstore.Bucket, position func() int, logger log.Logger, reg prometheus.Registerer) *alertsArchive {
+	return &alertsArchive{
+		tenant:   tenant,
+		limits:   limits,
+		bkt:      bkt,
+		position: position,
+		logger:   logger,
+		now:      time.Now,
+		pending:  map[model.Fingerprint]archivedAlert{},
+		archived: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_archived_total",
+			Help: "Number of alerts archived to object storage.",
+		}),
+		flushesFailed: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "alertmanager_alerts_archive_flushes_failed_total",
+			Help: "Number of batches of alerts which have failed to be archived to object storage.",
+		}),
+	}
+}
+
+// PreStore implements mem.AlertStoreCallback.
+func (a *alertsArchive) PreStore(_ *types.Alert, _ bool) error {
+	return nil
+}
+
+// PostStore implements mem.AlertStoreCallback. The stored alert is the received one, merged with the
+// existing alert with the same fingerprint.
+func (a *alertsArchive) PostStore(alert *types.Alert, _ bool) {
+	if a.limits.AlertmanagerAlertsArchiveRetention(a.tenant) <= 0 {
+		return
+	}
+
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	now := a.now()
+	if len(a.pending) == 0 {
+		a.pendingSince = now
+	}
+	a.pending[alert.Fingerprint()] = archivedAlert{
+		Fingerprint:  alert.Fingerprint().String(),
+		Labels:       alert.Labels,
+		Annotations:  alert.Annotations,
+		StartsAt:     alert.StartsAt,
+		EndsAt:       alert.EndsAt,
+		GeneratorURL: alert.GeneratorURL,
+		ReceivedAt:   now,
+	}
+}
+
+// PostDelete implements mem.AlertStoreCallback.
+func (a *alertsArchive) PostDelete(_ *types.Alert) {}
+
+// run periodically flushes the pending alerts until stop is closed, then flushes them a last time.
+func (a *alertsArchive) run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			a.flush(context.Background())
+			return
+		case <-ticker.C:
+			a.flush(context.Background())
+		}
+	}
+}
+
+// flush writes the pending alerts as a batch and periodically deletes the batches older than the
+// retention. The alerts which fail to be written are kept pending until the next flush.
+func (a *alertsArchive) flush(ctx context.Context) {
+	a.mtx.Lock()
+	pending, since := a.pending, a.pendingSince
+	a.pending = map[model.Fingerprint]archivedAlert{}
+	a.mtx.Unlock()
+
+	// Only the replica at position zero archives the alerts.
+	if a.position() != 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, alertsArchiveTimeout)
+	defer cancel()
+
+	now := a.now()
+	if len(pending) > 0 {
+		if err := a.writeBatch(ctx, pending, since, now); err != nil {
+			a.flushesFailed.Inc()
+			level.Warn(a.logger).Log("msg", "failed to archive alerts, retrying at the next flush", "user", a.tenant, "alerts", len(pending), "err", err)
+			a.restorePending(pending, since)
+		} else {
+			a.archived.Add(float64(len(pending)))
+		}
+	}
+
+	if retention := a.limits.AlertmanagerAlertsArchiveRetention(a.tenant); retention > 0 && now.Sub(a.retentionEnforcedAt) >= alertsArchiveRetentionInterval {
+		a.retentionEnforcedAt = now
+		if err := a.deleteExpiredBatches(ctx, now.Add(-retention)); err != nil {
+			level.Warn(a.logger).Log("msg", "failed to delete the expired archived alerts", "user", a.tenant, "err", err)
+		}
+	}
+}
+
+// restorePending puts back the alerts which failed to be written with the pending ones. The alerts
+// received since then take precedence, because they're more recent.
+func (a *alertsArchive) restorePending(alerts map[model.Fingerprint]archivedAlert, since time.Time) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	if len(a.pending) == 0 || since.Before(a.pendingSince) {
+		a.pendingSince = since
+	}
+	for fp, alert := range alerts {
+		if _, ok := a.pending[fp]; !ok {
+			a.pending[fp] = alert
+		}
+	}
+}
+
+func (a *alertsArchive) writeBatch(ctx context.Context, alerts map[model.Fingerprint]archivedAlert, from, to time.Time) error {
+	batch := make([]archivedAlert, 0, len(alerts))
+	for _, alert := range alerts {
+		batch = append(batch, alert)
+	}
+	sortArchivedAlerts(batch)
+
+	buf := bytes.Buffer{}
+	gz := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(gz).Encode(batch); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	return a.bkt.Upload(ctx, alertsArchiveBatchName(from, to), &buf)
+}
+
+// deleteExpiredBatches deletes the batches whose alerts have all been received before the given time.
+func (a *alertsArchive) deleteExpiredBatches(ctx context.Context, before time.Time) error {
+	var expired []string
+	err := a.bkt.Iter(ctx, "", func(name string) error {
+		if _, to, ok := parseAlertsArchiveBatchName(name); ok && to.Before(before) {
+			expired = append(expired, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range expired {
+		if err := a.bkt.Delete(ctx, name); err != nil && !a.bkt.IsObjNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// read returns the archived alerts received within the given time range, sorted by receive time.
+func (a *alertsArchive) read(ctx context.Context, start, end time.Time) ([]archivedAlert, error) {
+	var names []string
+	err := a.bkt.Iter(ctx, "", func(name string) error {
+		if from, to, ok := parseAlertsArchiveBatchName(name); ok && !from.After(end) && !to.Before(start) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	out := []archivedAlert{}
+	for _, name := range names {
+		batch, err := a.readBatch(ctx, name)
+		if a.bkt.IsObjNotFoundErr(err) {
+			// The batch has been deleted in the meanwhile.
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read the archived alerts batch %s", name)
+		}
+		for _, alert := range batch {
+			if !alert.ReceivedAt.Before(start) && !alert.ReceivedAt.After(end) {
+				out = append(out, alert)
+			}
+		}
+	}
+	sortArchivedAlerts(out)
+
+	return out, nil
+}
+
+func (a *alertsArchive) readBatch(ctx context.Context, name string) ([]archivedAlert, error) {
+	r, err := a.bkt.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer runutil.CloseWithLogOnErr(a.logger, r, "close archived alerts batch reader")
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch []archivedAlert
+	if err := json.NewDecoder(gz).Decode(&batch); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// ServeHTTP serves, as JSON, the archived alerts of the tenant received between the start and end
+// URL query parameters, which default to the last hour.
+func (a *alertsArchive) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	now := a.now()
+	end, err := util.ParseTimeParam(r, "end", util.TimeToMillis(now))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	start, err := util.ParseTimeParam(r, "start", end-alertsArchiveDefaultRange.Milliseconds())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if end < start {
+		http.Error(w, "end timestamp must not be before start time", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), alertsArchiveTimeout)
+	defer cancel()
+
+	alerts, err := a.read(ctx, util.TimeFromMillis(start), util.TimeFromMillis(end))
+	if err != nil {
+		level.Error(a.logger).Log("msg", "failed to read the archived alerts", "user", a.tenant, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, alerts)
+}
+
+func sortArchivedAlerts(alerts []archivedAlert) {
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].ReceivedAt.Equal(alerts[j].ReceivedAt) {
+			return alerts[i].ReceivedAt.Before(alerts[j].ReceivedAt)
+		}
+		return alerts[i].Fingerprint < alerts[j].Fingerprint
+	})
+}
+
+// alertsArchiveBatchName returns the name of the batch of the alerts received between from and to.
+func alertsArchiveBatchName(from, to time.Time) string {
+	return fmt.Sprintf("%d-%d%s", util.TimeToMillis(from), util.TimeToMillis(to), alertsArchiveBatchExt)
+}
+
+func parseAlertsArchiveBatchName(name string) (from, to time.Time, ok bool) {
+	name = path.Base(name)
+	if !strings.HasSuffix(name, alertsArchiveBatchExt) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	fromStr, toStr, found := strings.Cut(strings.TrimSuffix(name, alertsArchiveBatchExt), "-")
+	if !found {
+		return time.Time{}, time.Time{}, false
+	}
+	fromMs, err := strconv.ParseInt(fromStr, 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	toMs, err := strconv.ParseInt(toStr, 10, 64)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return util.TimeFromMillis(fromMs), util.TimeFromMillis(toMs), true
+}
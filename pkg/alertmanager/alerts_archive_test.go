@@ -0,0 +1,189 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	promutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+)
+
+func TestAlertsArchive(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	limits := &mockAlertManagerLimits{alertsArchiveRetention: time.Hour}
+	position := 0
+
+	a := newAlertsArchive("user-1", limits, bkt, func() int { return position }, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	now := time.Unix(10000, 0)
+	a.now = func() time.Time { return now }
+
+	newAlert := func(name, summary string) *types.Alert {
+		return &types.Alert{Alert: model.Alert{
+			Labels:      model.LabelSet{"alertname": model.LabelValue(name)},
+			Annotations: model.LabelSet{"summary": model.LabelValue(summary)},
+			StartsAt:    time.Unix(9000, 0),
+		}}
+	}
+
+	// The alerts are deduplicated within a batch, keeping the last received version.
+	a.PostStore(newAlert("a", "first"), false)
+	a.PostStore(newAlert("b", "first"), false)
+	now = now.Add(time.Minute)
+	a.PostStore(newAlert("a", "second"), true)
+	now = now.Add(time.Minute)
+	a.flush(ctx)
+
+	assert.Len(t, bkt.Objects(), 1)
+	assert.Contains(t, bkt.Objects(), "10000000-10120000.json.gz")
+	assert.Equal(t, float64(2), promutil.ToFloat64(a.archived))
+
+	// The alerts are only archived by the replica at position zero.
+	position = 1
+	a.PostStore(newAlert("c", "first"), false)
+	a.flush(ctx)
+	assert.Len(t, bkt.Objects(), 1)
+
+	position = 0
+	now = now.Add(time.Minute)
+	a.PostStore(newAlert("b", "second"), true)
+	now = now.Add(time.Minute)
+	a.flush(ctx)
+	assert.Len(t, bkt.Objects(), 2)
+	assert.Equal(t, float64(3), promutil.ToFloat64(a.archived))
+
+	alerts, err := a.read(ctx, time.Unix(0, 0), now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 3)
+	assert.Equal(t, model.LabelSet{"alertname": "b"}, alerts[0].Labels)
+	assert.Equal(t, time.Unix(10000, 0).UTC(), alerts[0].ReceivedAt.UTC())
+	assert.Equal(t, model.LabelSet{"summary": "second"}, alerts[1].Annotations)
+	assert.Equal(t, time.Unix(10060, 0).UTC(), alerts[1].ReceivedAt.UTC())
+	assert.Equal(t, model.LabelSet{"alertname": "b"}, alerts[2].Labels)
+	assert.Equal(t, model.LabelSet{"summary": "second"}, alerts[2].Annotations)
+
+	// Only the alerts received within the time range are returned.
+	alerts, err = a.read(ctx, time.Unix(10030, 0), time.Unix(10090, 0))
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, model.LabelSet{"alertname": "a"}, alerts[0].Labels)
+
+	// The batches whose alerts have all been received before the retention are deleted, at most
+	// once per retention interval.
+	now = time.Unix(10000, 0).Add(time.Hour + 3*time.Minute)
+	a.flush(ctx)
+	assert.Len(t, bkt.Objects(), 1)
+	assert.Contains(t, bkt.Objects(), "10180000-10240000.json.gz")
+
+	// The alerts aren't archived once disabled.
+	limits.alertsArchiveRetention = 0
+	a.PostStore(newAlert("d", "first"), false)
+	a.flush(ctx)
+	assert.Len(t, bkt.Objects(), 1)
+}
+
+type failingUploadBucket struct {
+	*objstore.InMemBucket
+	failing bool
+}
+
+func (b *failingUploadBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	if b.failing {
+		return errors.New("upload failed")
+	}
+	return b.InMemBucket.Upload(ctx, name, r)
+}
+
+func TestAlertsArchive_ShouldRetryTheAlertsFailedToBeArchived(t *testing.T) {
+	ctx := context.Background()
+	bkt := &failingUploadBucket{InMemBucket: objstore.NewInMemBucket(), failing: true}
+
+	a := newAlertsArchive("user-1", &mockAlertManagerLimits{alertsArchiveRetention: time.Hour}, bkt, func() int { return 0 }, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	now := time.Unix(10000, 0)
+	a.now = func() time.Time { return now }
+
+	a.PostStore(&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a"}, Annotations: model.LabelSet{"summary": "first"}}}, false)
+	a.PostStore(&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "b"}}}, false)
+	now = now.Add(time.Minute)
+	a.flush(ctx)
+	assert.Empty(t, bkt.Objects())
+	assert.Equal(t, float64(1), promutil.ToFloat64(a.flushesFailed))
+
+	// The alerts received in the meanwhile take precedence over the ones failed to be archived.
+	a.PostStore(&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a"}, Annotations: model.LabelSet{"summary": "second"}}}, true)
+	now = now.Add(time.Minute)
+	bkt.failing = false
+	a.flush(ctx)
+	assert.Len(t, bkt.Objects(), 1)
+	assert.Contains(t, bkt.Objects(), "10000000-10120000.json.gz")
+	assert.Equal(t, float64(2), promutil.ToFloat64(a.archived))
+
+	alerts, err := a.read(ctx, time.Unix(0, 0), now)
+	require.NoError(t, err)
+	require.Len(t, alerts, 2)
+	assert.Equal(t, model.LabelSet{"alertname": "b"}, alerts[0].Labels)
+	assert.Equal(t, model.LabelSet{"summary": "second"}, alerts[1].Annotations)
+}
+
+func TestAlertsArchive_ServeHTTP(t *testing.T) {
+	bkt := objstore.NewInMemBucket()
+	a := newAlertsArchive("user-1", &mockAlertManagerLimits{alertsArchiveRetention: time.Hour}, bkt, func() int { return 0 }, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	now := time.Unix(10000, 0)
+	a.now = func() time.Time { return now }
+
+	a.PostStore(&types.Alert{Alert: model.Alert{Labels: model.LabelSet{"alertname": "a"}}}, false)
+	a.flush(context.Background())
+
+	for name, tc := range map[string]struct {
+		query          string
+		expectedStatus int
+		expectedAlerts int
+	}{
+		"default range": {
+			expectedStatus: http.StatusOK,
+			expectedAlerts: 1,
+		},
+		"range without alerts": {
+			query:          "?start=9000&end=9999",
+			expectedStatus: http.StatusOK,
+		},
+		"range with alerts": {
+			query:          "?start=1970-01-01T02:00:00Z&end=10000",
+			expectedStatus: http.StatusOK,
+			expectedAlerts: 1,
+		},
+		"invalid start": {
+			query:          "?start=invalid",
+			expectedStatus: http.StatusBadRequest,
+		},
+		"end before start": {
+			query:          "?start=10000&end=9000",
+			expectedStatus: http.StatusBadRequest,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/alerts_archive"+tc.query, nil))
+			require.Equal(t, tc.expectedStatus, w.Code)
+			if tc.expectedStatus != http.StatusOK {
+				return
+			}
+
+			var alerts []archivedAlert
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &alerts))
+			assert.Len(t, alerts, tc.expectedAlerts)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 	clusterpb "github.com/prometheus/alertmanager/cluster/clusterpb"
 	io "io"
 	math "math"
@@ -30,6 +31,8 @@ type AlertConfigDesc struct {
 	User      string          `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
 	RawConfig string          `protobuf:"bytes,2,opt,name=raw_config,json=rawConfig,proto3" json:"raw_config,omitempty"`
 	Templates []*TemplateDesc `protobuf:"bytes,3,rep,name=templates,proto3" json:"templates,omitempty"`
+	// Name of the template rendering the payload of the webhooks, by receiver name.
+	WebhookPayloadTemplates map[string]string `protobuf:"bytes,4,rep,name=webhook_payload_templates,json=webhookPayloadTemplates,proto3" json:"webhook_payload_templates,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *AlertConfigDesc) Reset()      { *m = AlertConfigDesc{} }
@@ -85,6 +88,13 @@ func (m *AlertConfigDesc) GetTemplates() []*TemplateDesc {
 	return nil
 }
 
+func (m *AlertConfigDesc) GetWebhookPayloadTemplates() map[string]string {
+	if m != nil {
+		return m.WebhookPayloadTemplates
+	}
+	return nil
+}
+
 type TemplateDesc struct {
 	Filename string `protobuf:"bytes,1,opt,name=filename,proto3" json:"filename,omitempty"`
 	Body     string `protobuf:"bytes,2,opt,name=body,proto3" json:"body,omitempty"`
@@ -181,6 +191,7 @@ func (m *FullStateDesc) GetState() *clusterpb.FullState {
 
 func init() {
 	proto.RegisterType((*AlertConfigDesc)(nil), "alerts.AlertConfigDesc")
+	proto.RegisterMapType((map[string]string)(nil), "alerts.AlertConfigDesc.WebhookPayloadTemplatesEntry")
 	proto.RegisterType((*TemplateDesc)(nil), "alerts.TemplateDesc")
 	proto.RegisterType((*FullStateDesc)(nil), "alerts.FullStateDesc")
 }
@@ -188,27 +199,33 @@ func init() {
 func init() { proto.RegisterFile("alerts.proto", fileDescriptor_20493709c38b81dc) }
 
 var fileDescriptor_20493709c38b81dc = []byte{
-	// 320 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x4c, 0x50, 0xbf, 0x4e, 0xc2, 0x40,
-	0x18, 0xbf, 0x13, 0x24, 0x70, 0x62, 0x4c, 0x1a, 0x06, 0x42, 0xe2, 0x27, 0x61, 0x22, 0x0e, 0x6d,
-	0x82, 0x9b, 0x03, 0x09, 0x68, 0x7c, 0x00, 0x74, 0x72, 0x31, 0xd7, 0x7a, 0x14, 0x92, 0x96, 0x6b,
-	0xee, 0xae, 0x41, 0x37, 0x1f, 0xc1, 0x47, 0x70, 0xf4, 0x51, 0x1c, 0x19, 0x19, 0xe5, 0x58, 0x18,
-	0x79, 0x04, 0x73, 0x77, 0xa5, 0x38, 0xf5, 0xf7, 0xf5, 0xf7, 0xe7, 0xfb, 0x7e, 0x47, 0x9a, 0x34,
-	0x61, 0x42, 0x49, 0x3f, 0x13, 0x5c, 0x71, 0xaf, 0xe6, 0xa6, 0x4e, 0x2b, 0xe6, 0x31, 0xb7, 0xbf,
-	0x02, 0x83, 0x1c, 0xdb, 0x19, 0xc7, 0x73, 0x35, 0xcb, 0x43, 0x3f, 0xe2, 0x69, 0x90, 0x09, 0x9e,
-	0x32, 0x35, 0x63, 0xb9, 0x0c, 0xac, 0x27, 0xa5, 0x0b, 0x1a, 0x33, 0x11, 0x44, 0x49, 0x2e, 0xd5,
-	0xf1, 0x9b, 0x85, 0x07, 0xe4, 0x32, 0x7a, 0x6f, 0xe4, 0x62, 0x64, 0xf4, 0x77, 0x7c, 0x31, 0x9d,
-	0xc7, 0xf7, 0x4c, 0x46, 0x9e, 0x47, 0xaa, 0xb9, 0x64, 0xa2, 0x8d, 0xbb, 0xb8, 0xdf, 0x98, 0x58,
-	0xec, 0x5d, 0x12, 0x22, 0xe8, 0xf2, 0x25, 0xb2, 0xaa, 0xf6, 0x89, 0x65, 0x1a, 0x82, 0x2e, 0x9d,
-	0xcd, 0x1b, 0x90, 0x86, 0x62, 0x69, 0x96, 0x50, 0xc5, 0x64, 0xbb, 0xd2, 0xad, 0xf4, 0xcf, 0x06,
-	0x2d, 0xbf, 0x68, 0xf2, 0x54, 0x10, 0x26, 0x7b, 0x72, 0x94, 0xf5, 0x86, 0xa4, 0xf9, 0x9f, 0xf2,
-	0x3a, 0xa4, 0x3e, 0x9d, 0x27, 0x6c, 0x41, 0x53, 0x56, 0xac, 0x2e, 0x67, 0x73, 0x52, 0xc8, 0x5f,
-	0xdf, 0x8b, 0xc5, 0x16, 0xf7, 0x46, 0xe4, 0xfc, 0x21, 0x4f, 0x92, 0x47, 0x75, 0x08, 0xb8, 0x26,
-	0xa7, 0xd2, 0x0c, 0xd6, 0x6d, 0x0e, 0x28, 0x3b, 0xfb, 0xa5, 0x70, 0xe2, 0x24, 0xb7, 0xd5, 0xdd,
-	0xd7, 0x15, 0x1a, 0x0f, 0x57, 0x1b, 0x40, 0xeb, 0x0d, 0xa0, 0xfd, 0x06, 0xf0, 0x87, 0x06, 0xfc,
-	0xad, 0x01, 0xff, 0x68, 0xc0, 0x2b, 0x0d, 0xf8, 0x57, 0x03, 0xde, 0x69, 0x40, 0x7b, 0x0d, 0xf8,
-	0x73, 0x0b, 0x68, 0xb5, 0x05, 0xb4, 0xde, 0x02, 0x7a, 0xae, 0xbb, 0x62, 0x59, 0x18, 0xd6, 0xec,
-	0x1b, 0xde, 0xfc, 0x05, 0x00, 0x00, 0xff, 0xff, 0x3d, 0xbd, 0xc1, 0x50, 0xb5, 0x01, 0x00, 0x00,
+	// 404 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0xcf, 0xce, 0xd2, 0x40,
+	0x14, 0xc5, 0x3b, 0xc0, 0xf7, 0x05, 0x46, 0x8c, 0x66, 0x42, 0x22, 0x36, 0x3a, 0x12, 0x56, 0xc4,
+	0x45, 0x9b, 0xa0, 0x0b, 0xc3, 0x82, 0x04, 0xfc, 0xb3, 0x70, 0x65, 0xaa, 0x89, 0x89, 0x1b, 0x32,
+	0x2d, 0x43, 0x21, 0x4c, 0x3b, 0xcd, 0xcc, 0xd4, 0xa6, 0x3b, 0x1f, 0xc1, 0x47, 0x70, 0xe9, 0x13,
+	0xf8, 0x0c, 0x2e, 0x59, 0xb2, 0x94, 0xb2, 0x61, 0xc9, 0x23, 0x98, 0xce, 0xb4, 0x60, 0x8c, 0x71,
+	0xd5, 0x73, 0x7b, 0xef, 0xf9, 0xb5, 0xe7, 0xce, 0xc0, 0x2e, 0x61, 0x54, 0x28, 0xe9, 0x24, 0x82,
+	0x2b, 0x8e, 0x6e, 0x4d, 0x65, 0xf7, 0x42, 0x1e, 0x72, 0xfd, 0xca, 0x2d, 0x95, 0xe9, 0xda, 0xf3,
+	0x70, 0xa3, 0xd6, 0xa9, 0xef, 0x04, 0x3c, 0x72, 0x13, 0xc1, 0x23, 0xaa, 0xd6, 0x34, 0x95, 0xae,
+	0xf6, 0x44, 0x24, 0x26, 0x21, 0x15, 0x6e, 0xc0, 0x52, 0xa9, 0xae, 0xcf, 0xc4, 0xaf, 0x95, 0x61,
+	0x0c, 0x7f, 0x34, 0xe0, 0xbd, 0x59, 0x69, 0x78, 0xc9, 0xe3, 0xd5, 0x26, 0x7c, 0x45, 0x65, 0x80,
+	0x10, 0x6c, 0xa5, 0x92, 0x8a, 0x3e, 0x18, 0x80, 0x51, 0xc7, 0xd3, 0x1a, 0x3d, 0x86, 0x50, 0x90,
+	0x6c, 0x11, 0xe8, 0xa9, 0x7e, 0x43, 0x77, 0x3a, 0x82, 0x64, 0xc6, 0x86, 0xc6, 0xb0, 0xa3, 0x68,
+	0x94, 0x30, 0xa2, 0xa8, 0xec, 0x37, 0x07, 0xcd, 0xd1, 0x9d, 0x71, 0xcf, 0xa9, 0xa2, 0x7c, 0xa8,
+	0x1a, 0x25, 0xdb, 0xbb, 0x8e, 0xa1, 0x04, 0x3e, 0xcc, 0xa8, 0xbf, 0xe6, 0x7c, 0xbb, 0x48, 0x48,
+	0xce, 0x38, 0x59, 0x2e, 0xae, 0x8c, 0x96, 0x66, 0x3c, 0xaf, 0x19, 0x7f, 0xfd, 0xa2, 0xf3, 0xd1,
+	0x18, 0xdf, 0x19, 0x5f, 0xfd, 0x05, 0xf9, 0x3a, 0x56, 0x22, 0xf7, 0x1e, 0x64, 0xff, 0xee, 0xda,
+	0x6f, 0xe1, 0xa3, 0xff, 0x19, 0xd1, 0x7d, 0xd8, 0xdc, 0xd2, 0xbc, 0xca, 0x5d, 0x4a, 0xd4, 0x83,
+	0x37, 0x9f, 0x09, 0x4b, 0x69, 0x95, 0xd8, 0x14, 0x93, 0xc6, 0x0b, 0x30, 0x9c, 0xc2, 0xee, 0x9f,
+	0xc1, 0x90, 0x0d, 0xdb, 0xab, 0x0d, 0xa3, 0x31, 0x89, 0x68, 0x05, 0xb8, 0xd4, 0xe5, 0x42, 0x7d,
+	0xbe, 0xcc, 0x2b, 0x88, 0xd6, 0xc3, 0x19, 0xbc, 0xfb, 0x26, 0x65, 0xec, 0xbd, 0xaa, 0x01, 0x4f,
+	0xe1, 0x8d, 0x2c, 0x0b, 0xed, 0x2e, 0xd7, 0x77, 0x39, 0x32, 0xe7, 0x32, 0xe8, 0x99, 0x91, 0x49,
+	0xeb, 0xf4, 0xed, 0x89, 0x35, 0x9f, 0xee, 0x0e, 0xd8, 0xda, 0x1f, 0xb0, 0x75, 0x3e, 0x60, 0xf0,
+	0xa5, 0xc0, 0xe0, 0x7b, 0x81, 0xc1, 0xcf, 0x02, 0x83, 0x5d, 0x81, 0xc1, 0xaf, 0x02, 0x83, 0x53,
+	0x81, 0xad, 0x73, 0x81, 0xc1, 0xd7, 0x23, 0xb6, 0x76, 0x47, 0x6c, 0xed, 0x8f, 0xd8, 0xfa, 0xd4,
+	0x36, 0x2b, 0x4d, 0x7c, 0xff, 0x56, 0x5f, 0x81, 0x67, 0xbf, 0x07, 0x00, 0xe2, 0x55, 0xaf, 0x29,
+	0x74, 0x02, 0x00, 0x00,
 }
 
 func (this *AlertConfigDesc) Equal(that interface{}) bool {
@@ -244,6 +261,14 @@ func (this *AlertConfigDesc) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.WebhookPayloadTemplates) != len(that1.WebhookPayloadTemplates) {
+		return false
+	}
+	for i := range this.WebhookPayloadTemplates {
+		if this.WebhookPayloadTemplates[i] != that1.WebhookPayloadTemplates[i] {
+			return false
+		}
+	}
 	return true
 }
 func (this *TemplateDesc) Equal(that interface{}) bool {
@@ -277,13 +302,26 @@ func (this *AlertConfigDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&alertspb.AlertConfigDesc{")
 	s = append(s, "User: "+fmt.Sprintf("%#v", this.User)+",\n")
 	s = append(s, "RawConfig: "+fmt.Sprintf("%#v", this.RawConfig)+",\n")
 	if this.Templates != nil {
 		s = append(s, "Templates: "+fmt.Sprintf("%#v", this.Templates)+",\n")
 	}
+	keysForWebhookPayloadTemplates := make([]string, 0, len(this.WebhookPayloadTemplates))
+	for k, _ := range this.WebhookPayloadTemplates {
+		keysForWebhookPayloadTemplates = append(keysForWebhookPayloadTemplates, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForWebhookPayloadTemplates)
+	mapStringForWebhookPayloadTemplates := "map[string]string{"
+	for _, k := range keysForWebhookPayloadTemplates {
+		mapStringForWebhookPayloadTemplates += fmt.Sprintf("%#v: %#v,", k, this.WebhookPayloadTemplates[k])
+	}
+	mapStringForWebhookPayloadTemplates += "}"
+	if this.WebhookPayloadTemplates != nil {
+		s = append(s, "WebhookPayloadTemplates: "+mapStringForWebhookPayloadTemplates+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -338,6 +376,25 @@ func (m *AlertConfigDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.WebhookPayloadTemplates) > 0 {
+		for k := range m.WebhookPayloadTemplates {
+			v := m.WebhookPayloadTemplates[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintAlerts(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintAlerts(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintAlerts(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x22
+		}
+	}
 	if len(m.Templates) > 0 {
 		for iNdEx := len(m.Templates) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -472,6 +529,14 @@ func (m *AlertConfigDesc) Size() (n int) {
 			n += 1 + l + sovAlerts(uint64(l))
 		}
 	}
+	if len(m.WebhookPayloadTemplates) > 0 {
+		for k, v := range m.WebhookPayloadTemplates {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovAlerts(uint64(len(k))) + 1 + len(v) + sovAlerts(uint64(len(v)))
+			n += mapEntrySize + 1 + sovAlerts(uint64(mapEntrySize))
+		}
+	}
 	return n
 }
 
@@ -520,10 +585,21 @@ func (this *AlertConfigDesc) String() string {
 		repeatedStringForTemplates += strings.Replace(f.String(), "TemplateDesc", "TemplateDesc", 1) + ","
 	}
 	repeatedStringForTemplates += "}"
+	keysForWebhookPayloadTemplates := make([]string, 0, len(this.WebhookPayloadTemplates))
+	for k, _ := range this.WebhookPayloadTemplates {
+		keysForWebhookPayloadTemplates = append(keysForWebhookPayloadTemplates, k)
+	}
+	github_com_gogo_protobuf_sortkeys.Strings(keysForWebhookPayloadTemplates)
+	mapStringForWebhookPayloadTemplates := "map[string]string{"
+	for _, k := range keysForWebhookPayloadTemplates {
+		mapStringForWebhookPayloadTemplates += fmt.Sprintf("%v: %v,", k, this.WebhookPayloadTemplates[k])
+	}
+	mapStringForWebhookPayloadTemplates += "}"
 	s := strings.Join([]string{`&AlertConfigDesc{`,
 		`User:` + fmt.Sprintf("%v", this.User) + `,`,
 		`RawConfig:` + fmt.Sprintf("%v", this.RawConfig) + `,`,
 		`Templates:` + repeatedStringForTemplates + `,`,
+		`WebhookPayloadTemplates:` + mapStringForWebhookPayloadTemplates + `,`,
 		`}`,
 	}, "")
 	return s
@@ -684,6 +760,133 @@ func (m *AlertConfigDesc) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WebhookPayloadTemplates", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowAlerts
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthAlerts
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.WebhookPayloadTemplates == nil {
+				m.WebhookPayloadTemplates = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowAlerts
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAlerts
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthAlerts
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthAlerts
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowAlerts
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthAlerts
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthAlerts
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipAlerts(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if skippy < 0 {
+						return ErrInvalidLengthAlerts
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.WebhookPayloadTemplates[mapkey] = mapvalue
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipAlerts(dAtA[iNdEx:])
@@ -34,6 +34,9 @@ const (
 	// The name of alertmanager full state objects (notification log + silences).
 	fullStateName = "fullstate"
 
+	// The name of the directory under which the archived alerts are stored.
+	alertsArchiveName = "alerts-archive"
+
 	// How many users to load concurrently.
 	fetchConcurrency = 16
 )
@@ -182,6 +185,11 @@ func (s *BucketAlertStore) DeleteFullState(ctx context.Context, userID string) e
 	return err
 }
 
+// AlertsArchiveBucket implements alertstore.AlertsArchiveStore.
+func (s *BucketAlertStore) AlertsArchiveBucket(userID string) objstore.Bucket {
+	return bucket.NewPrefixedBucketClient(s.getAlertmanagerUserBucket(userID), alertsArchiveName)
+}
+
 func (s *BucketAlertStore) getAlertConfig(ctx context.Context, userID string) (alertspb.AlertConfigDesc, objstore.Bucket, error) {
 	config := alertspb.AlertConfigDesc{}
 	userBkt := s.getUserBucket(userID)
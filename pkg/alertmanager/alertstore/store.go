@@ -5,6 +5,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
 
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertstore/bucketclient"
@@ -48,6 +49,13 @@ type AlertStore interface {
 	DeleteFullState(ctx context.Context, user string) error
 }
 
+// AlertsArchiveStore is implemented by the AlertStore backends supporting the archive of the alerts,
+// which are the object storage ones.
+type AlertsArchiveStore interface {
+	// AlertsArchiveBucket returns the bucket where the archived alerts of the given user are stored.
+	AlertsArchiveBucket(user string) objstore.Bucket
+}
+
 // NewAlertStore returns a alertmanager store backend client based on the provided cfg.
 func NewAlertStore(ctx context.Context, cfg Config, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) (AlertStore, error) {
 	if cfg.Backend == configdb.Name {
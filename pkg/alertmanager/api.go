@@ -2,12 +2,18 @@ package alertmanager
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -15,6 +21,7 @@ import (
 	"github.com/prometheus/alertmanager/config"
 	"github.com/prometheus/alertmanager/template"
 	commoncfg "github.com/prometheus/common/config"
+	"golang.org/x/time/rate"
 	"gopkg.in/yaml.v2"
 
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
@@ -35,6 +42,8 @@ const (
 	errConfigurationTooBig   = "Alertmanager configuration is too big, limit: %d bytes"
 	errTooManyTemplates      = "too many templates in the configuration: %d (limit: %d)"
 	errTemplateTooBig        = "template %s is too big: %d bytes (limit: %d bytes)"
+	errConfigUpdateLimited   = "too many Alertmanager config updates, limit: %v updates/sec"
+	errConfigChanged         = "the Alertmanager config doesn't match the request preconditions, it has been changed since it was read"
 
 	fetchConcurrency = 16
 )
@@ -58,6 +67,10 @@ var (
 type UserConfig struct {
 	TemplateFiles      map[string]string `yaml:"template_files"`
 	AlertmanagerConfig string            `yaml:"alertmanager_config"`
+
+	// WebhookPayloadTemplates is the name of the template rendering the payload of the
+	// webhooks, by receiver name. The receivers not listed send the Alertmanager payload.
+	WebhookPayloadTemplates map[string]string `yaml:"webhook_payload_templates,omitempty"`
 }
 
 func (am *MultitenantAlertmanager) GetUserConfig(w http.ResponseWriter, r *http.Request) {
@@ -84,8 +97,9 @@ func (am *MultitenantAlertmanager) GetUserConfig(w http.ResponseWriter, r *http.
 	}
 
 	d, err := yaml.Marshal(&UserConfig{
-		TemplateFiles:      alertspb.ParseTemplates(cfg),
-		AlertmanagerConfig: cfg.RawConfig,
+		TemplateFiles:           alertspb.ParseTemplates(cfg),
+		AlertmanagerConfig:      cfg.RawConfig,
+		WebhookPayloadTemplates: cfg.WebhookPayloadTemplates,
 	})
 
 	if err != nil {
@@ -95,6 +109,7 @@ func (am *MultitenantAlertmanager) GetUserConfig(w http.ResponseWriter, r *http.
 	}
 
 	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("ETag", configETag(cfg))
 	if _, err := w.Write(d); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -110,6 +125,13 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
+	if am.configUpdatesLimiter != nil && !am.configUpdatesLimiter.AllowN(time.Now(), userID, 1) {
+		msg := fmt.Sprintf(errConfigUpdateLimited, am.limits.AlertmanagerConfigUpdatesRateLimit(userID))
+		level.Warn(logger).Log("msg", msg)
+		http.Error(w, msg, http.StatusTooManyRequests)
+		return
+	}
+
 	var input io.Reader
 	maxConfigSize := am.limits.AlertmanagerMaxConfigSize(userID)
 	if maxConfigSize > 0 {
@@ -143,12 +165,32 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 	}
 
 	cfgDesc := alertspb.ToProto(cfg.AlertmanagerConfig, cfg.TemplateFiles, userID)
+	cfgDesc.WebhookPayloadTemplates = cfg.WebhookPayloadTemplates
 	if err := validateUserConfig(logger, cfgDesc, am.limits, userID); err != nil {
 		level.Warn(logger).Log("msg", errValidatingConfig, "err", err.Error())
 		http.Error(w, fmt.Sprintf("%s: %s", errValidatingConfig, err.Error()), http.StatusBadRequest)
 		return
 	}
 
+	// The preconditions are checked against the stored config right before storing the new one.
+	// The alertmanager store has no compare-and-set, so this is not atomic: it protects from
+	// updating a config which changed since it was read, but concurrent updates can still
+	// overwrite each other.
+	ifMatch, ifNoneMatch := r.Header.Get("If-Match"), r.Header.Get("If-None-Match")
+	if ifMatch != "" || ifNoneMatch != "" {
+		ok, err := am.checkConfigPreconditions(r.Context(), userID, ifMatch, ifNoneMatch)
+		if err != nil {
+			level.Error(logger).Log("msg", errReadingConfiguration, "err", err.Error())
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			level.Warn(logger).Log("msg", errConfigChanged)
+			http.Error(w, errConfigChanged, http.StatusPreconditionFailed)
+			return
+		}
+	}
+
 	err = am.store.SetAlertConfig(r.Context(), cfgDesc)
 	if err != nil {
 		level.Error(logger).Log("msg", errStoringConfiguration, "err", err.Error())
@@ -156,9 +198,93 @@ func (am *MultitenantAlertmanager) SetUserConfig(w http.ResponseWriter, r *http.
 		return
 	}
 
+	w.Header().Set("ETag", configETag(cfgDesc))
 	w.WriteHeader(http.StatusCreated)
 }
 
+// checkConfigPreconditions returns whether the current config of the tenant matches the
+// input If-Match and If-None-Match header values.
+func (am *MultitenantAlertmanager) checkConfigPreconditions(ctx context.Context, userID, ifMatch, ifNoneMatch string) (bool, error) {
+	etag := ""
+	cfg, err := am.store.GetAlertConfig(ctx, userID)
+	switch {
+	case err == nil:
+		etag = configETag(cfg)
+	case err != alertspb.ErrNotFound:
+		return false, err
+	}
+
+	if ifMatch != "" && !etagMatches(ifMatch, etag) {
+		return false, nil
+	}
+	if ifNoneMatch != "" && etagMatches(ifNoneMatch, etag) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// configETag returns the entity tag identifying the version of the input config.
+func configETag(cfg alertspb.AlertConfigDesc) string {
+	// The templates order is not preserved by the API, so they're hashed in filename order.
+	templates := append([]*alertspb.TemplateDesc(nil), cfg.Templates...)
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Filename < templates[j].Filename
+	})
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(cfg.RawConfig))
+	for _, t := range templates {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(t.Filename))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(t.Body))
+	}
+
+	receivers := make([]string, 0, len(cfg.WebhookPayloadTemplates))
+	for receiver := range cfg.WebhookPayloadTemplates {
+		receivers = append(receivers, receiver)
+	}
+	sort.Strings(receivers)
+	for _, receiver := range receivers {
+		_, _ = h.Write([]byte{1})
+		_, _ = h.Write([]byte(receiver))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(cfg.WebhookPayloadTemplates[receiver]))
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)) + `"`
+}
+
+// etagMatches returns whether the input If-Match or If-None-Match header value matches
+// the input entity tag, which is empty if there's no config.
+func etagMatches(header, etag string) bool {
+	if etag == "" {
+		return false
+	}
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+		if value == "*" || value == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// configUpdatesRateLimiterStrategy is the rate limiter strategy of the config updates via the API.
+type configUpdatesRateLimiterStrategy struct {
+	limits Limits
+}
+
+func (s configUpdatesRateLimiterStrategy) Limit(userID string) float64 {
+	if limit := s.limits.AlertmanagerConfigUpdatesRateLimit(userID); limit > 0 {
+		return limit
+	}
+	return float64(rate.Inf)
+}
+
+func (s configUpdatesRateLimiterStrategy) Burst(userID string) int {
+	return s.limits.AlertmanagerConfigUpdatesBurstSize(userID)
+}
+
 // DeleteUserConfig is exposed via user-visible API (if enabled, uses DELETE method), but also as an internal endpoint using POST method.
 // Note that if no config exists for a user, StatusOK is returned.
 func (am *MultitenantAlertmanager) DeleteUserConfig(w http.ResponseWriter, r *http.Request) {
@@ -256,10 +382,16 @@ func validateUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits
 		templateFiles[i] = filepath.Join(userTempDir, t)
 	}
 
-	_, err = template.FromGlobs(templateFiles)
+	tmpl, err := template.FromGlobs(templateFiles, withWebhookPayloadFuncs)
 	if err != nil {
 		return err
 	}
+	// The external URL is only known by the Alertmanagers running the config.
+	tmpl.ExternalURL = &url.URL{}
+
+	if err := validateWebhookPayloadTemplates(amCfg, tmpl, cfg.WebhookPayloadTemplates); err != nil {
+		return err
+	}
 
 	// Note: Not validating the MultitenantAlertmanager.transformConfig function as that
 	// that function shouldn't break configuration. Only way it can fail is if the base
@@ -295,8 +427,9 @@ func (am *MultitenantAlertmanager) ListAllConfigs(w http.ResponseWriter, r *http
 		}
 		data := map[string]*UserConfig{
 			userID: {
-				TemplateFiles:      alertspb.ParseTemplates(cfg),
-				AlertmanagerConfig: cfg.RawConfig,
+				TemplateFiles:           alertspb.ParseTemplates(cfg),
+				AlertmanagerConfig:      cfg.RawConfig,
+				WebhookPayloadTemplates: cfg.WebhookPayloadTemplates,
 			},
 		}
 
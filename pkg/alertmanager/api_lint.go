@@ -0,0 +1,263 @@
+package alertmanager
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	lintDeprecatedField      = "deprecated_field"
+	lintUnreferencedReceiver = "unreferenced_receiver"
+	lintUnreachableRoute     = "unreachable_route"
+	upstreamAlertmanagerPath = "github.com/prometheus/alertmanager"
+	unknownUpstreamAMVersion = "unknown"
+	errLintingConfiguration  = "unable to lint the Alertmanager config"
+)
+
+// upstreamAlertmanagerVersion is the version of the Alertmanager vendored in this binary.
+var upstreamAlertmanagerVersion = func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return unknownUpstreamAMVersion
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != upstreamAlertmanagerPath {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return unknownUpstreamAMVersion
+}()
+
+// lintFinding is an issue found in an Alertmanager config which doesn't prevent it from
+// being loaded, together with the suggested fix.
+type lintFinding struct {
+	Kind       string `json:"kind"`
+	Path       string `json:"path"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+type lintResult struct {
+	UpstreamVersion string        `json:"upstream_version"`
+	Valid           bool          `json:"valid"`
+	Error           string        `json:"error,omitempty"`
+	Findings        []lintFinding `json:"findings"`
+}
+
+// LintUserConfig lints the Alertmanager config of the tenant against the vendored upstream
+// version. The config in the request body is linted on POST, the stored one on GET.
+func (am *MultitenantAlertmanager) LintUserConfig(w http.ResponseWriter, r *http.Request) {
+	logger := util_log.WithContext(r.Context(), am.logger)
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		level.Error(logger).Log("msg", errNoOrgID, "err", err.Error())
+		http.Error(w, fmt.Sprintf("%s: %s", errNoOrgID, err.Error()), http.StatusUnauthorized)
+		return
+	}
+
+	var cfgDesc alertspb.AlertConfigDesc
+	if r.Method == http.MethodPost {
+		var input io.Reader = r.Body
+		maxConfigSize := am.limits.AlertmanagerMaxConfigSize(userID)
+		if maxConfigSize > 0 {
+			// Allow one extra byte to check if the config is too big.
+			input = io.LimitReader(r.Body, int64(maxConfigSize)+1)
+		}
+
+		payload, err := io.ReadAll(input)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", errReadingConfiguration, err.Error()), http.StatusBadRequest)
+			return
+		}
+		if maxConfigSize > 0 && len(payload) > maxConfigSize {
+			http.Error(w, fmt.Sprintf(errConfigurationTooBig, maxConfigSize), http.StatusBadRequest)
+			return
+		}
+
+		cfg := &UserConfig{}
+		if err := yaml.Unmarshal(payload, cfg); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", errMarshallingYAML, err.Error()), http.StatusBadRequest)
+			return
+		}
+		cfgDesc = alertspb.ToProto(cfg.AlertmanagerConfig, cfg.TemplateFiles, userID)
+		cfgDesc.WebhookPayloadTemplates = cfg.WebhookPayloadTemplates
+	} else {
+		cfgDesc, err = am.store.GetAlertConfig(r.Context(), userID)
+		if err != nil {
+			switch {
+			case err == alertspb.ErrNotFound:
+				http.Error(w, err.Error(), http.StatusNotFound)
+			case err == alertspb.ErrAccessDenied:
+				http.Error(w, err.Error(), http.StatusForbidden)
+			default:
+				level.Error(logger).Log("msg", errLintingConfiguration, "err", err.Error())
+				http.Error(w, fmt.Sprintf("%s: %s", errLintingConfiguration, err.Error()), http.StatusInternalServerError)
+			}
+			return
+		}
+	}
+
+	util.WriteJSONResponse(w, lintUserConfig(logger, cfgDesc, am.limits, userID))
+}
+
+// lintUserConfig validates the config as when it is stored and, if it can be loaded,
+// reports its deprecated fields, unreferenced receivers and unreachable routes.
+func lintUserConfig(logger log.Logger, cfg alertspb.AlertConfigDesc, limits Limits, user string) lintResult {
+	res := lintResult{
+		UpstreamVersion: upstreamAlertmanagerVersion,
+		Valid:           true,
+		Findings:        []lintFinding{},
+	}
+
+	if err := validateUserConfig(logger, cfg, limits, user); err != nil {
+		res.Valid = false
+		res.Error = err.Error()
+	}
+
+	amCfg, err := config.Load(cfg.RawConfig)
+	if err != nil {
+		return res
+	}
+
+	referenced := map[string]struct{}{}
+	if amCfg.Route != nil {
+		res.Findings = lintRoute(amCfg.Route, "route", referenced, res.Findings)
+	}
+
+	for i, r := range amCfg.InhibitRules {
+		path := fmt.Sprintf("inhibit_rules[%d]", i)
+		if len(r.SourceMatch) > 0 || len(r.SourceMatchRE) > 0 {
+			res.Findings = append(res.Findings, deprecatedMatchersFinding(path, "source_match and source_match_re", "source_matchers", legacyMatchers(r.SourceMatch, r.SourceMatchRE)))
+		}
+		if len(r.TargetMatch) > 0 || len(r.TargetMatchRE) > 0 {
+			res.Findings = append(res.Findings, deprecatedMatchersFinding(path, "target_match and target_match_re", "target_matchers", legacyMatchers(r.TargetMatch, r.TargetMatchRE)))
+		}
+	}
+
+	if len(amCfg.MuteTimeIntervals) > 0 {
+		res.Findings = append(res.Findings, lintFinding{
+			Kind:       lintDeprecatedField,
+			Path:       "mute_time_intervals",
+			Message:    "mute_time_intervals is deprecated and will be removed in Alertmanager 1.0",
+			Suggestion: "Rename mute_time_intervals to time_intervals.",
+		})
+	}
+
+	for i, r := range amCfg.Receivers {
+		if _, ok := referenced[r.Name]; ok {
+			continue
+		}
+		res.Findings = append(res.Findings, lintFinding{
+			Kind:       lintUnreferencedReceiver,
+			Path:       fmt.Sprintf("receivers[%d]", i),
+			Message:    fmt.Sprintf("receiver %q is not referenced by any route", r.Name),
+			Suggestion: "Remove the receiver or reference it from a route.",
+		})
+	}
+
+	return res
+}
+
+// lintRoute lints the route and its children, recording the receivers they reference.
+func lintRoute(r *config.Route, path string, referenced map[string]struct{}, findings []lintFinding) []lintFinding {
+	if r.Receiver != "" {
+		referenced[r.Receiver] = struct{}{}
+	}
+
+	if len(r.Match) > 0 || len(r.MatchRE) > 0 {
+		findings = append(findings, deprecatedMatchersFinding(path, "match and match_re", "matchers", legacyMatchers(r.Match, r.MatchRE)))
+	}
+
+	for i, child := range r.Routes {
+		childPath := fmt.Sprintf("%s.routes[%d]", path, i)
+
+		// A route is never reached if a previous sibling not continuing matches all its alerts,
+		// which is the case when the sibling matchers are a subset of the route ones.
+		for j, sibling := range r.Routes[:i] {
+			if sibling.Continue || !isSubset(routeMatchers(sibling), routeMatchers(child)) {
+				continue
+			}
+			siblingPath := fmt.Sprintf("%s.routes[%d]", path, j)
+			findings = append(findings, lintFinding{
+				Kind:       lintUnreachableRoute,
+				Path:       childPath,
+				Message:    fmt.Sprintf("route is unreachable because all its alerts are matched by %s, which doesn't continue", siblingPath),
+				Suggestion: fmt.Sprintf("Move the route before %s, or set continue: true on %s.", siblingPath, siblingPath),
+			})
+			break
+		}
+
+		findings = lintRoute(child, childPath, referenced, findings)
+	}
+
+	return findings
+}
+
+func deprecatedMatchersFinding(path, fields, replacement string, matchers []string) lintFinding {
+	return lintFinding{
+		Kind:       lintDeprecatedField,
+		Path:       path,
+		Message:    fmt.Sprintf("%s are deprecated and will be removed in Alertmanager 1.0", fields),
+		Suggestion: fmt.Sprintf("Replace %s with %s: [%s].", fields, replacement, strings.Join(quoteAll(matchers), ", ")),
+	}
+}
+
+// routeMatchers returns the sorted matchers of the route, including the deprecated ones.
+func routeMatchers(r *config.Route) []string {
+	out := legacyMatchers(r.Match, r.MatchRE)
+	for _, m := range r.Matchers {
+		out = append(out, m.String())
+	}
+	sort.Strings(out)
+	return out
+}
+
+// legacyMatchers converts the deprecated match and match_re fields to sorted matchers.
+func legacyMatchers(match map[string]string, matchRE config.MatchRegexps) []string {
+	out := make([]string, 0, len(match)+len(matchRE))
+	for name, value := range match {
+		out = append(out, (&labels.Matcher{Type: labels.MatchEqual, Name: name, Value: value}).String())
+	}
+	for name, re := range matchRE {
+		value, _ := re.MarshalYAML()
+		out = append(out, (&labels.Matcher{Type: labels.MatchRegexp, Name: name, Value: fmt.Sprint(value)}).String())
+	}
+	sort.Strings(out)
+	return out
+}
+
+func isSubset(sub, set []string) bool {
+	for _, s := range sub {
+		if !util.StringsContain(set, s) {
+			return false
+		}
+	}
+	return true
+}
+
+func quoteAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		out = append(out, fmt.Sprintf("'%s'", v))
+	}
+	return out
+}
@@ -0,0 +1,187 @@
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/weaveworks/common/user"
+	"gopkg.in/yaml.v2"
+
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
+	"github.com/cortexproject/cortex/pkg/alertmanager/alertstore/bucketclient"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+func TestLintUserConfig(t *testing.T) {
+	tests := map[string]struct {
+		config           string
+		expectedValid    bool
+		expectedFindings []lintFinding
+	}{
+		"clean config": {
+			config: `
+route:
+  receiver: default
+  routes:
+    - matchers: ['team="a"']
+      receiver: team-a
+receivers:
+  - name: default
+  - name: team-a
+`,
+			expectedValid:    true,
+			expectedFindings: []lintFinding{},
+		},
+		"deprecated fields": {
+			config: `
+route:
+  receiver: default
+  routes:
+    - match: {team: a}
+      match_re: {service: 'api|web'}
+      receiver: default
+inhibit_rules:
+  - source_match: {severity: critical}
+    target_matchers: ['severity="warning"']
+receivers:
+  - name: default
+`,
+			expectedValid: true,
+			expectedFindings: []lintFinding{
+				{
+					Kind:       lintDeprecatedField,
+					Path:       "route.routes[0]",
+					Message:    "match and match_re are deprecated and will be removed in Alertmanager 1.0",
+					Suggestion: `Replace match and match_re with matchers: ['service=~"api|web"', 'team="a"'].`,
+				},
+				{
+					Kind:       lintDeprecatedField,
+					Path:       "inhibit_rules[0]",
+					Message:    "source_match and source_match_re are deprecated and will be removed in Alertmanager 1.0",
+					Suggestion: `Replace source_match and source_match_re with source_matchers: ['severity="critical"'].`,
+				},
+			},
+		},
+		"unreferenced receiver and unreachable routes": {
+			config: `
+route:
+  receiver: default
+  routes:
+    - matchers: ['team="a"']
+      receiver: team-a
+    - matchers: ['team="a"', 'severity="critical"']
+      receiver: team-a
+    - matchers: ['team="b"']
+      receiver: default
+      continue: true
+    - matchers: ['team="b"']
+      receiver: default
+    - receiver: default
+    - matchers: ['team="c"']
+      receiver: default
+receivers:
+  - name: default
+  - name: team-a
+  - name: unused
+`,
+			expectedValid: true,
+			expectedFindings: []lintFinding{
+				{
+					Kind:       lintUnreachableRoute,
+					Path:       "route.routes[1]",
+					Message:    "route is unreachable because all its alerts are matched by route.routes[0], which doesn't continue",
+					Suggestion: "Move the route before route.routes[0], or set continue: true on route.routes[0].",
+				},
+				{
+					Kind:       lintUnreachableRoute,
+					Path:       "route.routes[5]",
+					Message:    "route is unreachable because all its alerts are matched by route.routes[4], which doesn't continue",
+					Suggestion: "Move the route before route.routes[4], or set continue: true on route.routes[4].",
+				},
+				{
+					Kind:       lintUnreferencedReceiver,
+					Path:       "receivers[2]",
+					Message:    `receiver "unused" is not referenced by any route`,
+					Suggestion: "Remove the receiver or reference it from a route.",
+				},
+			},
+		},
+		"invalid config": {
+			config: `
+route:
+  receiver: missing
+receivers:
+  - name: default
+`,
+			expectedValid:    false,
+			expectedFindings: []lintFinding{},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			res := lintUserConfig(log.NewNopLogger(), alertspb.ToProto(tc.config, nil, "user"), &mockAlertManagerLimits{}, "user")
+			assert.Equal(t, tc.expectedValid, res.Valid)
+			assert.Equal(t, !tc.expectedValid, res.Error != "")
+			assert.Equal(t, tc.expectedFindings, res.Findings)
+			assert.Equal(t, "v0.27.0", res.UpstreamVersion)
+		})
+	}
+}
+
+func TestMultitenantAlertmanager_LintUserConfig(t *testing.T) {
+	alertStore := bucketclient.NewBucketAlertStore(objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	am := &MultitenantAlertmanager{
+		store:  alertStore,
+		logger: util_log.Logger,
+		limits: &mockAlertManagerLimits{},
+	}
+
+	const cfg = `
+route:
+  receiver: default
+receivers:
+  - name: default
+  - name: unused
+`
+	ctx := user.InjectOrgID(context.Background(), "user")
+	lint := func(req *http.Request) (int, lintResult) {
+		rec := httptest.NewRecorder()
+		am.LintUserConfig(rec, req.WithContext(ctx))
+
+		var res lintResult
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		}
+		return rec.Code, res
+	}
+
+	// The stored config doesn't exist yet.
+	code, _ := lint(httptest.NewRequest(http.MethodGet, "/api/v1/alerts/lint", nil))
+	require.Equal(t, http.StatusNotFound, code)
+
+	// The config in the request body is linted.
+	payload, err := yaml.Marshal(&UserConfig{AlertmanagerConfig: cfg})
+	require.NoError(t, err)
+	code, res := lint(httptest.NewRequest(http.MethodPost, "/api/v1/alerts/lint", bytes.NewReader(payload)))
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, res.Valid)
+	require.Len(t, res.Findings, 1)
+	require.Equal(t, lintUnreferencedReceiver, res.Findings[0].Kind)
+
+	// The stored config is linted.
+	require.NoError(t, alertStore.SetAlertConfig(ctx, alertspb.ToProto(cfg, nil, "user")))
+	code, res = lint(httptest.NewRequest(http.MethodGet, "/api/v1/alerts/lint", nil))
+	require.Equal(t, http.StatusOK, code)
+	require.True(t, res.Valid)
+	require.Len(t, res.Findings, 1)
+	require.Equal(t, "receivers[1]", res.Findings[0].Path)
+}
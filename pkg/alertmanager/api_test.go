@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gorilla/mux"
@@ -24,6 +25,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertspb"
 	"github.com/cortexproject/cortex/pkg/alertmanager/alertstore/bucketclient"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_limiter "github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
@@ -649,6 +651,105 @@ alertmanager_config: |
 `,
 			err: errors.Wrap(errTelegramBotTokenFileNotAllowed, "error validating Alertmanager config"),
 		},
+		{
+			name: "Should pass if the webhook payload template renders valid JSON",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://ticketing.example.com/
+    - name: email-receiver
+      email_configs:
+        - to: oncall@example.com
+          from: alertmanager@example.com
+          smarthost: smtp.example.com:587
+  route:
+    receiver: 'default-receiver'
+  templates:
+    - "ticket.tpl"
+template_files:
+  "ticket.tpl": |
+    {{ define "ticket.json" }}{"title": {{ .CommonLabels.alertname | toJson }}, "alerts": {{ len .Alerts }}}{{ end }}
+webhook_payload_templates:
+  default-receiver: ticket.json
+`,
+		},
+		{
+			name: "Should return error if the webhook payload template doesn't render valid JSON",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://ticketing.example.com/
+    - name: email-receiver
+      email_configs:
+        - to: oncall@example.com
+          from: alertmanager@example.com
+          smarthost: smtp.example.com:587
+  route:
+    receiver: 'default-receiver'
+  templates:
+    - "ticket.tpl"
+template_files:
+  "ticket.tpl": |
+    {{ define "ticket.json" }}{"title": {{ .CommonLabels.alertname }}}{{ end }}
+webhook_payload_templates:
+  default-receiver: ticket.json
+`,
+			err: errors.Wrap(errors.Wrap(errWebhookPayloadNotJSON, `invalid webhook payload template "ticket.json" for receiver "default-receiver"`), "error validating Alertmanager config"),
+		},
+		{
+			name: "Should return error if the webhook payload template is not defined",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://ticketing.example.com/
+    - name: email-receiver
+      email_configs:
+        - to: oncall@example.com
+          from: alertmanager@example.com
+          smarthost: smtp.example.com:587
+  route:
+    receiver: 'default-receiver'
+  templates:
+    - "ticket.tpl"
+template_files:
+  "ticket.tpl": |
+    {{ define "ticket.json" }}{"title": {{ .CommonLabels.alertname | toJson }}, "alerts": {{ len .Alerts }}}{{ end }}
+webhook_payload_templates:
+  default-receiver: unknown.json
+`,
+			err: fmt.Errorf(`error validating Alertmanager config: invalid webhook payload template "unknown.json" for receiver "default-receiver": template: :1:12: executing "" at <{{template "unknown.json" .}}>: template "unknown.json" not defined`),
+		},
+		{
+			name: "Should return error if the webhook payload template is configured for a receiver without webhooks",
+			cfg: `
+alertmanager_config: |
+  receivers:
+    - name: default-receiver
+      webhook_configs:
+        - url: http://ticketing.example.com/
+    - name: email-receiver
+      email_configs:
+        - to: oncall@example.com
+          from: alertmanager@example.com
+          smarthost: smtp.example.com:587
+  route:
+    receiver: 'default-receiver'
+  templates:
+    - "ticket.tpl"
+template_files:
+  "ticket.tpl": |
+    {{ define "ticket.json" }}{"title": {{ .CommonLabels.alertname | toJson }}, "alerts": {{ len .Alerts }}}{{ end }}
+webhook_payload_templates:
+  email-receiver: ticket.json
+`,
+			err: fmt.Errorf(`error validating Alertmanager config: webhook payload template configured for receiver "email-receiver", which has no webhook`),
+		},
 	}
 
 	limits := &mockAlertManagerLimits{}
@@ -683,6 +784,66 @@ alertmanager_config: |
 	}
 }
 
+func TestMultitenantAlertmanager_SetUserConfigPreconditionsAndRateLimit(t *testing.T) {
+	const cfg = `
+alertmanager_config: |
+  route:
+    receiver: 'default-receiver'
+  receivers:
+    - name: default-receiver
+`
+
+	limits := &mockAlertManagerLimits{configUpdatesRateLimit: 0.0001, configUpdatesBurstSize: 3}
+	am := &MultitenantAlertmanager{
+		store:                prepareInMemoryAlertStore(),
+		logger:               util_log.Logger,
+		limits:               limits,
+		configUpdatesLimiter: util_limiter.NewRateLimiter(configUpdatesRateLimiterStrategy{limits: limits}, time.Minute),
+	}
+
+	setConfig := func(header, value string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, "http://alertmanager/api/v1/alerts", bytes.NewReader([]byte(cfg)))
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		am.SetUserConfig(w, req.WithContext(user.InjectOrgID(req.Context(), "testing")))
+		return w.Result()
+	}
+
+	// Updating a config which doesn't exist yet fails.
+	resp := setConfig("If-Match", "*")
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	// Creating it succeeds, and returns the version of the config.
+	resp = setConfig("If-None-Match", "*")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	// The config returned has the same version.
+	req := httptest.NewRequest(http.MethodGet, "http://alertmanager/api/v1/alerts", nil)
+	w := httptest.NewRecorder()
+	am.GetUserConfig(w, req.WithContext(user.InjectOrgID(req.Context(), "testing")))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, etag, w.Header().Get("ETag"))
+
+	// Updating the config from a stale version fails.
+	resp = setConfig("If-Match", `"stale"`)
+	require.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+	// The burst has been consumed, so the following updates are rate limited.
+	resp = setConfig("If-Match", etag)
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+}
+
+func TestEtagMatches(t *testing.T) {
+	assert.False(t, etagMatches("*", ""))
+	assert.True(t, etagMatches("*", `"a"`))
+	assert.True(t, etagMatches(`"b", W/"a"`, `"a"`))
+	assert.False(t, etagMatches(`"b"`, `"a"`))
+}
+
 func TestMultitenantAlertmanager_DeleteUserConfig(t *testing.T) {
 	storage := objstore.NewInMemBucket()
 	alertStore := bucketclient.NewBucketAlertStore(storage, nil, log.NewNopLogger())
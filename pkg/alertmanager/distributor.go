@@ -84,6 +84,13 @@ func (d *Distributor) isQuorumReadPath(p string) (bool, merger.Merger) {
 	if strings.HasSuffix(p, "/v2/alerts/groups") {
 		return true, merger.V2AlertGroups{}
 	}
+	return false, nil
+}
+
+// isAllReplicasReadPath returns true for the silences reads. Silences are created on a single
+// alertmanager and then replicated asynchronously, so these reads are sent to all the replicas
+// owning the tenant in order to never miss a silence which has just been created.
+func (d *Distributor) isAllReplicasReadPath(p string) (bool, merger.Merger) {
 	if strings.HasSuffix(p, "/v2/silences") {
 		return true, merger.V2Silences{}
 	}
@@ -135,6 +142,10 @@ func (d *Distributor) DistributeRequest(w http.ResponseWriter, r *http.Request,
 			d.doQuorum(userID, w, r, logger, m)
 			return
 		}
+		if ok, m := d.isAllReplicasReadPath(r.URL.Path); ok {
+			d.doAllReplicas(userID, w, r, logger, m)
+			return
+		}
 		d.doUnary(userID, w, r, logger)
 		return
 	}
@@ -206,6 +217,91 @@ func (d *Distributor) doQuorum(userID string, w http.ResponseWriter, r *http.Req
 	}
 }
 
+// doAllReplicas sends the request to all the replicas owning the tenant and waits for all of them,
+// instead of returning as soon as the quorum is reached. The successful responses are merged, while
+// the request fails only if more replicas than tolerated by the ring failed. Replicas responding
+// with 404 are not considered failed, since they may not have received a newly created resource yet.
+func (d *Distributor) doAllReplicas(userID string, w http.ResponseWriter, r *http.Request, logger log.Logger, m merger.Merger) {
+	replicationSet, err := d.alertmanagerRing.Get(shardByUser(userID), RingOp, nil, nil, nil)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to get replication set from the ring", "err", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, d.maxRecvMsgSize))
+		if err != nil {
+			if util.IsRequestBodyTooLarge(err) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			level.Error(logger).Log("msg", "failed to read the request body during read", "err", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	sp, ctx := opentracing.StartSpanFromContext(r.Context(), "Distributor.doAllReplicas")
+	defer sp.Finish()
+
+	var (
+		wg        sync.WaitGroup
+		mtx       sync.Mutex
+		responses []*httpgrpc.HTTPResponse
+		notFound  *httpgrpc.HTTPResponse
+		errs      []error
+	)
+
+	grpcHeaders := httpToHttpgrpcHeaders(r.Header)
+	for _, instance := range replicationSet.Instances {
+		wg.Add(1)
+		go func(instance ring.InstanceDesc) {
+			defer wg.Done()
+
+			resp, err := d.doRequest(ctx, instance, &httpgrpc.HTTPRequest{
+				Method:  r.Method,
+				Url:     r.RequestURI,
+				Body:    body,
+				Headers: grpcHeaders,
+			})
+			if err == nil && resp.Code/100 != 2 {
+				err = httpgrpc.ErrorFromHTTPResponse(resp)
+			}
+
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			if httpResp, ok := httpgrpc.HTTPResponseFromError(errors.Cause(err)); ok && httpResp.Code == http.StatusNotFound {
+				notFound = httpResp
+				return
+			}
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			responses = append(responses, resp)
+		}(instance)
+	}
+	wg.Wait()
+
+	if len(errs) > replicationSet.MaxErrors {
+		respondFromError(errs[len(errs)-1], w, logger)
+		return
+	}
+
+	if len(responses) > 0 {
+		respondFromMultipleHTTPGRPCResponses(w, logger, responses, m)
+	} else if notFound != nil {
+		respondFromHTTPGRPCResponse(w, notFound)
+	} else {
+		// This should not happen.
+		level.Error(logger).Log("msg", "distributor did not receive any response from alertmanagers, but there were no errors")
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
 func (d *Distributor) doUnary(userID string, w http.ResponseWriter, r *http.Request, logger log.Logger) {
 	key := shardByUser(userID)
 	replicationSet, err := d.alertmanagerRing.Get(key, RingOp, nil, nil, nil)
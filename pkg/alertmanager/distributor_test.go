@@ -133,6 +133,25 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 			expectedTotalCalls: 3,
 			route:              "/v2/silences",
 			responseBody:       []byte(`[]`),
+		}, {
+			name:               "Read /v2/silences waits for all the AMs when 1 is not happy",
+			numAM:              3,
+			numHappyAM:         2,
+			replicationFactor:  3,
+			isRead:             true,
+			expStatusCode:      http.StatusOK,
+			expectedTotalCalls: 3,
+			route:              "/v2/silences",
+			responseBody:       []byte(`[]`),
+		}, {
+			name:               "Read /v2/silences fails when 2 AMs are not happy",
+			numAM:              3,
+			numHappyAM:         1,
+			replicationFactor:  3,
+			isRead:             true,
+			expStatusCode:      http.StatusInternalServerError,
+			expectedTotalCalls: 3,
+			route:              "/v2/silences",
 		}, {
 			name:               "Write /silences is sent to only 1 AM",
 			numAM:              5,
@@ -303,6 +322,27 @@ func TestDistributor_DistributeRequest(t *testing.T) {
 
 }
 
+func TestDistributor_DistributeRequest_SilencesFromAllReplicas(t *testing.T) {
+	silence := `{"id":"aaa","status":{"state":"active"},"updatedAt":"2020-01-01T00:00:00.000Z","comment":"","createdBy":"","endsAt":"2020-01-01T01:00:00.000Z","matchers":[],"startsAt":"2020-01-01T00:00:00.000Z"}`
+
+	d, ams, cleanup := prepare(t, 3, 3, 3, []byte(`[]`))
+	t.Cleanup(cleanup)
+
+	// The silence has just been created on a single replica and not replicated yet.
+	ams[2].responseBody = []byte(`[` + silence + `]`)
+
+	ctx := user.InjectOrgID(context.Background(), "1")
+	url := "http://127.0.0.1:9999/alertmanager/api/v2/silences"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	req.RequestURI = url
+
+	w := httptest.NewRecorder()
+	d.DistributeRequest(w, req, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `[`+silence+`]`, w.Body.String())
+}
+
 func prepare(t *testing.T, numAM, numHappyAM, replicationFactor int, responseBody []byte) (*Distributor, []*mockAlertmanager, func()) {
 	ams := []*mockAlertmanager{}
 	remainingFailure := atomic.NewInt32(int32(numAM - numHappyAM))
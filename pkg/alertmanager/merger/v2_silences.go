@@ -11,7 +11,9 @@ import (
 
 // V2Silences implements the Merger interface for GET /v2/silences. It returns the union of silences
 // over all the responses. When a silence with the same ID exists in multiple responses, the silence
-// most recently updated silence is returned (newest UpdatedAt timestamp).
+// most recently updated silence is returned (newest UpdatedAt timestamp). If they have been updated
+// at the same time, the silence ending first is returned, since expiring a silence only moves its
+// end time backwards.
 type V2Silences struct{}
 
 func (V2Silences) MergeResponses(in [][]byte) ([]byte, error) {
@@ -45,7 +47,7 @@ func mergeV2Silences(in v2_models.GettableSilences) (v2_models.GettableSilences,
 
 		key := *silence.ID
 		if current, ok := silences[key]; ok {
-			if time.Time(*silence.UpdatedAt).After(time.Time(*current.UpdatedAt)) {
+			if silenceUpdatedAfter(silence, current) {
 				silences[key] = silence
 			}
 		} else {
@@ -63,3 +65,15 @@ func mergeV2Silences(in v2_models.GettableSilences) (v2_models.GettableSilences,
 
 	return result, nil
 }
+
+// silenceUpdatedAfter returns true if the silence a is a more recent version than the silence b.
+func silenceUpdatedAfter(a, b *v2_models.GettableSilence) bool {
+	aUpdatedAt, bUpdatedAt := time.Time(*a.UpdatedAt), time.Time(*b.UpdatedAt)
+	if !aUpdatedAt.Equal(bUpdatedAt) {
+		return aUpdatedAt.After(bUpdatedAt)
+	}
+	if a.EndsAt == nil || b.EndsAt == nil {
+		return false
+	}
+	return time.Time(*a.EndsAt).Before(time.Time(*b.EndsAt))
+}
@@ -101,6 +101,7 @@ func TestMergeV2Silences(t *testing.T) {
 	var (
 		silence1      = v2silence("id1", "2020-01-01T12:11:11.000Z", "2020-01-01T12:00:00.000Z")
 		newerSilence1 = v2silence("id1", "2020-01-01T12:11:11.000Z", "2020-01-01T12:00:00.001Z")
+		endedSilence1 = v2silence("id1", "2020-01-01T12:00:00.000Z", "2020-01-01T12:00:00.000Z")
 		silence2      = v2silence("id2", "2020-01-01T12:22:22.000Z", "2020-01-01T12:00:00.000Z")
 		silence3      = v2silence("id3", "2020-01-01T12:33:33.000Z", "2020-01-01T12:00:00.000Z")
 	)
@@ -155,6 +156,16 @@ func TestMergeV2Silences(t *testing.T) {
 			in:   v2silences(newerSilence1, silence1),
 			out:  v2silences(newerSilence1),
 		},
+		{
+			name: "two duplicates updated at the same time, should return the silence ending first",
+			in:   v2silences(silence1, endedSilence1),
+			out:  v2silences(endedSilence1),
+		},
+		{
+			name: "two duplicates updated at the same time (ending first first), should return the silence ending first",
+			in:   v2silences(endedSilence1, silence1),
+			out:  v2silences(endedSilence1),
+		},
 		{
 			name: "two duplicates plus others, should return newer silence and others",
 			in:   v2silences(newerSilence1, silence3, silence1, silence2),
@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
@@ -37,6 +38,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
+	util_limiter "github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
@@ -60,6 +62,8 @@ var (
 	errInvalidExternalURL                  = errors.New("the configured external URL is invalid: should not end with /")
 	errShardingUnsupportedStorage          = errors.New("the configured alertmanager storage backend is not supported when sharding is enabled")
 	errZoneAwarenessEnabledWithoutZoneInfo = errors.New("the configured alertmanager has zone awareness enabled but zone is not set")
+	errInvalidReceiverHealthWindow         = errors.New("the configured alertmanager receiver health window should be greater than 0")
+	errInvalidAlertsArchiveFlushInterval   = errors.New("the configured alertmanager alerts archive flush interval should be greater than 0")
 )
 
 // MultitenantAlertmanagerConfig is the configuration for a multitenant Alertmanager.
@@ -83,6 +87,10 @@ type MultitenantAlertmanagerConfig struct {
 	APIConcurrency int           `yaml:"api_concurrency"`
 	GCInterval     time.Duration `yaml:"gc_interval"`
 
+	ReceiverHealthWindow time.Duration `yaml:"receiver_health_window"`
+
+	AlertsArchiveFlushInterval time.Duration `yaml:"alerts_archive_flush_interval"`
+
 	// For distributor.
 	AlertmanagerClient ClientConfig `yaml:"alertmanager_client"`
 
@@ -122,6 +130,8 @@ func (cfg *MultitenantAlertmanagerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.EnableAPI, "experimental.alertmanager.enable-api", false, "Enable the experimental alertmanager config api.")
 	f.IntVar(&cfg.APIConcurrency, "alertmanager.api-concurrency", 0, "Maximum number of concurrent GET API requests before returning an error.")
 	f.DurationVar(&cfg.GCInterval, "alertmanager.alerts-gc-interval", 30*time.Minute, "Alertmanager alerts Garbage collection interval.")
+	f.DurationVar(&cfg.ReceiverHealthWindow, "alertmanager.receiver-health-window", time.Hour, "Window over which the delivery attempts of the receivers integrations are aggregated by the receivers health endpoints.")
+	f.DurationVar(&cfg.AlertsArchiveFlushInterval, "alertmanager.alerts-archive-flush-interval", 5*time.Minute, "[Experimental] Interval at which the alerts received by the tenants with the alerts archive enabled are flushed to object storage as a batch. Within a batch, the alerts are deduplicated, keeping the last received version of each alert.")
 	f.BoolVar(&cfg.ShardingEnabled, "alertmanager.sharding-enabled", false, "Shard tenants across multiple alertmanager instances.")
 	f.Var(&cfg.EnabledTenants, "alertmanager.enabled-tenants", "Comma separated list of tenants whose alerts this alertmanager can process. If specified, only these tenants will be handled by alertmanager, otherwise this alertmanager can process alerts from all tenants.")
 	f.Var(&cfg.DisabledTenants, "alertmanager.disabled-tenants", "Comma separated list of tenants whose alerts this alertmanager cannot process. If specified, a alertmanager that would normally pick the specified tenant(s) for processing will ignore them instead.")
@@ -152,6 +162,14 @@ func (cfg *MultitenantAlertmanagerConfig) Validate(storageCfg alertstore.Config)
 		return err
 	}
 
+	if cfg.ReceiverHealthWindow <= 0 {
+		return errInvalidReceiverHealthWindow
+	}
+
+	if cfg.AlertsArchiveFlushInterval <= 0 {
+		return errInvalidAlertsArchiveFlushInterval
+	}
+
 	if cfg.ShardingEnabled {
 		if !storageCfg.IsFullStateSupported() {
 			return errShardingUnsupportedStorage
@@ -228,6 +246,37 @@ type Limits interface {
 	// AlertmanagerMaxAlertsSizeBytes returns total max size of alerts that tenant can have active at the same time. 0 = no limit.
 	// Size of the alert is computed from alert labels, annotations and generator URL.
 	AlertmanagerMaxAlertsSizeBytes(tenant string) int
+
+	// AlertmanagerMaxAlertGroups returns max number of alert groups, derived from the group_by of the routes
+	// of the tenant configuration, that the alerts of the tenant can belong to at the same time. 0 = no limit.
+	AlertmanagerMaxAlertGroups(tenant string) int
+
+	// AlertmanagerMaxSilencesCount returns max number of active and pending silences that tenant can have. 0 = no limit.
+	AlertmanagerMaxSilencesCount(tenant string) int
+
+	// AlertmanagerMaxSilenceLifetime returns max duration a silence of the tenant can be active for. 0 = no limit.
+	AlertmanagerMaxSilenceLifetime(tenant string) time.Duration
+
+	// AlertmanagerExpireUnusedSilencesAfter returns the duration after which the active silences of the tenant
+	// not matching any alert are expired. 0 = disabled.
+	AlertmanagerExpireUnusedSilencesAfter(tenant string) time.Duration
+
+	// AlertmanagerConfigUpdatesRateLimit returns the rate limit of the config updates via the API, in updates/sec. 0 = no limit.
+	AlertmanagerConfigUpdatesRateLimit(tenant string) float64
+
+	// AlertmanagerConfigUpdatesBurstSize returns the burst size of the config updates via the API.
+	AlertmanagerConfigUpdatesBurstSize(tenant string) int
+
+	// AlertmanagerMaxStateSizeBytes returns the max size of the tenant's state persisted to object storage. 0 = no limit.
+	AlertmanagerMaxStateSizeBytes(tenant string) int
+
+	// AlertmanagerNotificationLogRetention returns how long the notification log entries of the tenant are kept
+	// in the state persisted to object storage. 0 = until they expire.
+	AlertmanagerNotificationLogRetention(tenant string) time.Duration
+
+	// AlertmanagerAlertsArchiveRetention returns how long the archived alerts of the tenant are kept in
+	// object storage. 0 = archive disabled.
+	AlertmanagerAlertsArchiveRetention(tenant string) time.Duration
 }
 
 // A MultitenantAlertmanager manages Alertmanager instances for multiple
@@ -278,6 +327,9 @@ type MultitenantAlertmanager struct {
 
 	limits Limits
 
+	// configUpdatesLimiter rate limits the config updates via the API.
+	configUpdatesLimiter *util_limiter.RateLimiter
+
 	allowedTenants *util.AllowedTenants
 
 	registry          prometheus.Registerer
@@ -400,6 +452,8 @@ func createMultitenantAlertmanager(cfg *MultitenantAlertmanagerConfig, fallbackC
 		am.syncFailures.WithLabelValues(r)
 	}
 
+	am.configUpdatesLimiter = util_limiter.NewRateLimiter(configUpdatesRateLimiterStrategy{limits: limits}, 10*time.Second)
+
 	if cfg.ShardingEnabled {
 		lifecyclerCfg, err := am.cfg.ShardingRing.ToLifecyclerConfig(am.logger)
 		if err != nil {
@@ -931,15 +985,15 @@ func (am *MultitenantAlertmanager) setConfig(cfg alertspb.AlertConfigDesc) error
 	// If no Alertmanager instance exists for this user yet, start one.
 	if !hasExisting {
 		level.Debug(am.logger).Log("msg", "initializing new per-tenant alertmanager", "user", cfg.User)
-		newAM, err := am.newAlertmanager(cfg.User, userAmConfig, rawCfg)
+		newAM, err := am.newAlertmanager(cfg.User, userAmConfig, rawCfg, cfg.WebhookPayloadTemplates)
 		if err != nil {
 			return err
 		}
 		am.alertmanagers[cfg.User] = newAM
-	} else if am.cfgs[cfg.User].RawConfig != cfg.RawConfig || hasTemplateChanges {
+	} else if am.cfgs[cfg.User].RawConfig != cfg.RawConfig || hasTemplateChanges || !maps.Equal(am.cfgs[cfg.User].WebhookPayloadTemplates, cfg.WebhookPayloadTemplates) {
 		level.Info(am.logger).Log("msg", "updating new per-tenant alertmanager", "user", cfg.User)
 		// If the config changed, apply the new one.
-		err := existing.ApplyConfig(cfg.User, userAmConfig, rawCfg)
+		err := existing.ApplyConfig(cfg.User, userAmConfig, rawCfg, cfg.WebhookPayloadTemplates)
 		if err != nil {
 			return fmt.Errorf("unable to apply Alertmanager config for user %v: %v", cfg.User, err)
 		}
@@ -953,7 +1007,7 @@ func (am *MultitenantAlertmanager) getTenantDirectory(userID string) string {
 	return filepath.Join(am.cfg.DataDir, userID)
 }
 
-func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amconfig.Config, rawCfg string) (*Alertmanager, error) {
+func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amconfig.Config, rawCfg string, webhookPayloadTemplates map[string]string) (*Alertmanager, error) {
 	reg := prometheus.NewRegistry()
 
 	tenantDir := am.getTenantDirectory(userID)
@@ -978,12 +1032,15 @@ func (am *MultitenantAlertmanager) newAlertmanager(userID string, amConfig *amco
 		Limits:            am.limits,
 		APIConcurrency:    am.cfg.APIConcurrency,
 		GCInterval:        am.cfg.GCInterval,
+
+		ReceiverHealthWindow:       am.cfg.ReceiverHealthWindow,
+		AlertsArchiveFlushInterval: am.cfg.AlertsArchiveFlushInterval,
 	}, reg)
 	if err != nil {
 		return nil, fmt.Errorf("unable to start Alertmanager for user %v: %v", userID, err)
 	}
 
-	if err := newAM.ApplyConfig(userID, amConfig, rawCfg); err != nil {
+	if err := newAM.ApplyConfig(userID, amConfig, rawCfg, webhookPayloadTemplates); err != nil {
 		return nil, fmt.Errorf("unable to apply initial config for user %v: %v", userID, err)
 	}
 
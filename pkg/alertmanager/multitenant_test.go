@@ -108,6 +108,18 @@ func TestMultitenantAlertmanagerConfig_Validate(t *testing.T) {
 			},
 			expected: errInvalidPersistInterval,
 		},
+		"should fail if receiver health window is 0": {
+			setup: func(t *testing.T, cfg *MultitenantAlertmanagerConfig, storageCfg *alertstore.Config) {
+				cfg.ReceiverHealthWindow = 0
+			},
+			expected: errInvalidReceiverHealthWindow,
+		},
+		"should fail if alerts archive flush interval is 0": {
+			setup: func(t *testing.T, cfg *MultitenantAlertmanagerConfig, storageCfg *alertstore.Config) {
+				cfg.AlertsArchiveFlushInterval = 0
+			},
+			expected: errInvalidAlertsArchiveFlushInterval,
+		},
 		"should fail if external URL ends with /": {
 			setup: func(t *testing.T, cfg *MultitenantAlertmanagerConfig, storageCfg *alertstore.Config) {
 				require.NoError(t, cfg.ExternalURL.Set("http://localhost/prefix/"))
@@ -2219,6 +2231,15 @@ type mockAlertManagerLimits struct {
 	maxDispatcherAggregationGroups int
 	maxAlertsCount                 int
 	maxAlertsSizeBytes             int
+	maxAlertGroups                 int
+	maxSilencesCount               int
+	maxSilenceLifetime             time.Duration
+	expireUnusedSilencesAfter      time.Duration
+	configUpdatesRateLimit         float64
+	configUpdatesBurstSize         int
+	maxStateSizeBytes              int
+	notificationLogRetention       time.Duration
+	alertsArchiveRetention         time.Duration
 }
 
 func (m *mockAlertManagerLimits) AlertmanagerMaxConfigSize(tenant string) int {
@@ -2260,3 +2281,39 @@ func (m *mockAlertManagerLimits) AlertmanagerMaxAlertsCount(_ string) int {
 func (m *mockAlertManagerLimits) AlertmanagerMaxAlertsSizeBytes(_ string) int {
 	return m.maxAlertsSizeBytes
 }
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxAlertGroups(_ string) int {
+	return m.maxAlertGroups
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxSilencesCount(_ string) int {
+	return m.maxSilencesCount
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxSilenceLifetime(_ string) time.Duration {
+	return m.maxSilenceLifetime
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerExpireUnusedSilencesAfter(_ string) time.Duration {
+	return m.expireUnusedSilencesAfter
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerConfigUpdatesRateLimit(_ string) float64 {
+	return m.configUpdatesRateLimit
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerConfigUpdatesBurstSize(_ string) int {
+	return m.configUpdatesBurstSize
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerMaxStateSizeBytes(_ string) int {
+	return m.maxStateSizeBytes
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerNotificationLogRetention(_ string) time.Duration {
+	return m.notificationLogRetention
+}
+
+func (m *mockAlertManagerLimits) AlertmanagerAlertsArchiveRetention(_ string) time.Duration {
+	return m.alertsArchiveRetention
+}
@@ -0,0 +1,241 @@
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// receiverHealthBuckets is the number of buckets the receiver health window is split into.
+const receiverHealthBuckets = 60
+
+type receiverIntegration struct {
+	receiver    string
+	integration string
+}
+
+// receiverHealthBucket holds the delivery attempts of an integration within a bucket of the window.
+type receiverHealthBucket struct {
+	// idx is the index of the bucket since the Unix epoch.
+	idx       int64
+	succeeded uint64
+	failed    uint64
+}
+
+type integrationHealth struct {
+	buckets         [receiverHealthBuckets]receiverHealthBucket
+	lastError       string
+	lastErrorTime   time.Time
+	lastSuccessTime time.Time
+}
+
+// integrationHealthStats is the health of a receiver integration over the window.
+type integrationHealthStats struct {
+	Integration     string     `json:"integration"`
+	Succeeded       uint64     `json:"succeeded"`
+	Failed          uint64     `json:"failed"`
+	SuccessRate     float64    `json:"success_rate"`
+	LastError       string     `json:"last_error,omitempty"`
+	LastErrorTime   *time.Time `json:"last_error_time,omitempty"`
+	LastSuccessTime *time.Time `json:"last_success_time,omitempty"`
+}
+
+// receiverHealthStats is the health of a receiver over the window, aggregated across its integrations.
+type receiverHealthStats struct {
+	Receiver      string                   `json:"receiver"`
+	Succeeded     uint64                   `json:"succeeded"`
+	Failed        uint64                   `json:"failed"`
+	SuccessRate   float64                  `json:"success_rate"`
+	LastError     string                   `json:"last_error,omitempty"`
+	LastErrorTime *time.Time               `json:"last_error_time,omitempty"`
+	Integrations  []integrationHealthStats `json:"integrations"`
+}
+
+// receiverHealth tracks, per receiver and integration, the delivery attempts which succeeded and
+// failed over a sliding window, along with the last error.
+type receiverHealth struct {
+	bucketSize time.Duration
+	now        func() time.Time
+
+	mtx          sync.Mutex
+	integrations map[receiverIntegration]*integrationHealth
+}
+
+func newReceiverHealth(window time.Duration) *receiverHealth {
+	return &receiverHealth{
+		bucketSize:   max(window/receiverHealthBuckets, time.Millisecond),
+		now:          time.Now,
+		integrations: map[receiverIntegration]*integrationHealth{},
+	}
+}
+
+func (r *receiverHealth) bucketIndex(t time.Time) int64 {
+	return t.UnixNano() / int64(r.bucketSize)
+}
+
+func (r *receiverHealth) observe(receiver, integration string, err error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	key := receiverIntegration{receiver: receiver, integration: integration}
+	h, ok := r.integrations[key]
+	if !ok {
+		h = &integrationHealth{}
+		r.integrations[key] = h
+	}
+
+	now := r.now()
+	idx := r.bucketIndex(now)
+	b := &h.buckets[idx%receiverHealthBuckets]
+	if b.idx != idx {
+		*b = receiverHealthBucket{idx: idx}
+	}
+
+	if err != nil {
+		b.failed++
+		h.lastError = err.Error()
+		h.lastErrorTime = now
+	} else {
+		b.succeeded++
+		h.lastSuccessTime = now
+	}
+}
+
+// wrapNotifier returns a notifier tracking the delivery attempts of the given notifier.
+func (r *receiverHealth) wrapNotifier(integration string, next notify.Notifier) notify.Notifier {
+	return &receiverHealthNotifier{health: r, integration: integration, next: next}
+}
+
+type receiverHealthNotifier struct {
+	health      *receiverHealth
+	integration string
+	next        notify.Notifier
+}
+
+func (n *receiverHealthNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	retry, err := n.next.Notify(ctx, alerts...)
+
+	receiver, _ := notify.ReceiverName(ctx)
+	n.health.observe(receiver, n.integration, err)
+
+	return retry, err
+}
+
+// report returns the health of the receivers with delivery attempts within the window, sorted
+// by receiver. The integrations without any attempt within the window are forgotten.
+func (r *receiverHealth) report() []receiverHealthStats {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	minIdx := r.bucketIndex(r.now()) - receiverHealthBuckets + 1
+	receivers := map[string]*receiverHealthStats{}
+
+	for key, h := range r.integrations {
+		s := integrationHealthStats{Integration: key.integration}
+		for _, b := range h.buckets {
+			if b.idx >= minIdx {
+				s.Succeeded += b.succeeded
+				s.Failed += b.failed
+			}
+		}
+		if s.Succeeded+s.Failed == 0 {
+			delete(r.integrations, key)
+			continue
+		}
+
+		s.SuccessRate = float64(s.Succeeded) / float64(s.Succeeded+s.Failed)
+		if !h.lastErrorTime.IsZero() {
+			s.LastError = h.lastError
+			s.LastErrorTime = timePtr(h.lastErrorTime)
+		}
+		if !h.lastSuccessTime.IsZero() {
+			s.LastSuccessTime = timePtr(h.lastSuccessTime)
+		}
+
+		rs, ok := receivers[key.receiver]
+		if !ok {
+			rs = &receiverHealthStats{Receiver: key.receiver}
+			receivers[key.receiver] = rs
+		}
+		rs.Succeeded += s.Succeeded
+		rs.Failed += s.Failed
+		if s.LastErrorTime != nil && (rs.LastErrorTime == nil || s.LastErrorTime.After(*rs.LastErrorTime)) {
+			rs.LastError = s.LastError
+			rs.LastErrorTime = s.LastErrorTime
+		}
+		rs.Integrations = append(rs.Integrations, s)
+	}
+
+	out := make([]receiverHealthStats, 0, len(receivers))
+	for _, rs := range receivers {
+		rs.SuccessRate = float64(rs.Succeeded) / float64(rs.Succeeded+rs.Failed)
+		sort.Slice(rs.Integrations, func(i, j int) bool {
+			return rs.Integrations[i].Integration < rs.Integrations[j].Integration
+		})
+		out = append(out, *rs)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Receiver < out[j].Receiver
+	})
+
+	return out
+}
+
+// ServeHTTP serves the health of the receivers of the tenant as JSON.
+func (r *receiverHealth) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, r.report())
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// tenantReceiversHealth is the health of the receivers of a tenant.
+type tenantReceiversHealth struct {
+	UserID    string                `json:"user_id"`
+	Receivers []receiverHealthStats `json:"receivers"`
+}
+
+// ReceiversHealthHandler serves, as JSON, the health of the receivers of all the tenants of this
+// Alertmanager. The tenants without delivery attempts within the window, or without any failure
+// if failing=true is set, are omitted.
+func (am *MultitenantAlertmanager) ReceiversHealthHandler(w http.ResponseWriter, r *http.Request) {
+	onlyFailing := r.FormValue("failing") == "true"
+
+	am.alertmanagersMtx.Lock()
+	ams := make(map[string]*Alertmanager, len(am.alertmanagers))
+	for userID, userAM := range am.alertmanagers {
+		ams[userID] = userAM
+	}
+	am.alertmanagersMtx.Unlock()
+
+	out := make([]tenantReceiversHealth, 0, len(ams))
+	for userID, userAM := range ams {
+		receivers := userAM.receiverHealth.report()
+		if onlyFailing {
+			failing := receivers[:0]
+			for _, rs := range receivers {
+				if rs.Failed > 0 {
+					failing = append(failing, rs)
+				}
+			}
+			receivers = failing
+		}
+		if len(receivers) == 0 {
+			continue
+		}
+		out = append(out, tenantReceiversHealth{UserID: userID, Receivers: receivers})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].UserID < out[j].UserID
+	})
+
+	util.WriteJSONResponse(w, out)
+}
@@ -0,0 +1,71 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReceiverHealth(t *testing.T) {
+	now := time.Unix(1000, 0).UTC()
+	health := newReceiverHealth(time.Hour)
+	health.now = func() time.Time { return now }
+
+	okWebhook := health.wrapNotifier("webhook", &errorNotifier{})
+	failingWebhook := health.wrapNotifier("webhook", &errorNotifier{err: errors.New("connection refused")})
+	failingEmail := health.wrapNotifier("email", &errorNotifier{err: errors.New("auth failed")})
+
+	teamA := notify.WithReceiverName(context.Background(), "team-a")
+	teamB := notify.WithReceiverName(context.Background(), "team-b")
+
+	// An old failure, which is out of the window once reported.
+	_, _ = failingWebhook.Notify(teamA)
+
+	now = now.Add(50 * time.Minute)
+	_, _ = okWebhook.Notify(teamA)
+	_, _ = okWebhook.Notify(teamA)
+	_, _ = failingEmail.Notify(teamA)
+	_, _ = okWebhook.Notify(teamB)
+
+	now = now.Add(20 * time.Minute)
+	lastError := now.Add(-20 * time.Minute)
+	lastSuccess := now.Add(-20 * time.Minute)
+
+	assert.Equal(t, []receiverHealthStats{
+		{
+			Receiver:      "team-a",
+			Succeeded:     2,
+			Failed:        1,
+			SuccessRate:   2 / 3.0,
+			LastError:     "auth failed",
+			LastErrorTime: &lastError,
+			Integrations: []integrationHealthStats{
+				{Integration: "email", Failed: 1, LastError: "auth failed", LastErrorTime: &lastError},
+				{Integration: "webhook", Succeeded: 2, SuccessRate: 1, LastError: "connection refused", LastErrorTime: timePtr(time.Unix(1000, 0).UTC()), LastSuccessTime: &lastSuccess},
+			},
+		},
+		{
+			Receiver:    "team-b",
+			Succeeded:   1,
+			SuccessRate: 1,
+			Integrations: []integrationHealthStats{
+				{Integration: "webhook", Succeeded: 1, SuccessRate: 1, LastSuccessTime: &lastSuccess},
+			},
+		},
+	}, health.report())
+
+	rec := httptest.NewRecorder()
+	health.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/receivers_health", nil))
+	assert.Contains(t, rec.Body.String(), `"receiver":"team-a"`)
+
+	// The integrations without delivery attempts within the window are forgotten.
+	now = now.Add(time.Hour)
+	require.Empty(t, health.report())
+	assert.Empty(t, health.integrations)
+}
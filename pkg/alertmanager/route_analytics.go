@@ -0,0 +1,219 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+type routeAnalyticsFlushKey struct{}
+
+// routeStats holds the notification analytics of a single route.
+type routeStats struct {
+	Route                    string `json:"route"`
+	AlertsGrouped            uint64 `json:"alerts_grouped"`
+	GroupFlushes             uint64 `json:"group_flushes"`
+	ThrottledFlushes         uint64 `json:"throttled_flushes"`
+	NotificationsSent        uint64 `json:"notifications_sent"`
+	NotificationsFailed      uint64 `json:"notifications_failed"`
+	NotificationsRateLimited uint64 `json:"notifications_rate_limited"`
+}
+
+// routeFlush tracks whether any integration has been notified while flushing an aggregation group.
+// Integrations are notified concurrently.
+type routeFlush struct {
+	notified atomic.Bool
+}
+
+// routeAnalytics aggregates, per route, the number of alerts grouped, notifications sent, failed and
+// rate-limited, and aggregation group flushes which didn't notify any integration because nothing changed since
+// the last notification (throttled by group_interval and repeat_interval).
+type routeAnalytics struct {
+	mtx   sync.Mutex
+	stats map[string]*routeStats
+
+	alertsGrouped            *prometheus.CounterVec
+	groupFlushes             *prometheus.CounterVec
+	throttledFlushes         *prometheus.CounterVec
+	notificationsSent        *prometheus.CounterVec
+	notificationsFailed      *prometheus.CounterVec
+	notificationsRateLimited *prometheus.CounterVec
+}
+
+func newRouteAnalytics(reg prometheus.Registerer) *routeAnalytics {
+	return &routeAnalytics{
+		stats: map[string]*routeStats{},
+		alertsGrouped: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_route_alerts_grouped_total",
+			Help: "Total number of alerts in the aggregation groups flushed per route.",
+		}, []string{"route"}),
+		groupFlushes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_route_group_flushes_total",
+			Help: "Total number of aggregation group flushes per route.",
+		}, []string{"route"}),
+		throttledFlushes: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_route_group_flushes_throttled_total",
+			Help: "Total number of aggregation group flushes per route which didn't notify any integration.",
+		}, []string{"route"}),
+		notificationsSent: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_route_notifications_total",
+			Help: "Total number of notifications sent per route and integration.",
+		}, []string{"route", "integration"}),
+		notificationsFailed: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_route_notifications_failed_total",
+			Help: "Total number of notifications failed per route and integration.",
+		}, []string{"route", "integration"}),
+		notificationsRateLimited: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_route_notifications_rate_limited_total",
+			Help: "Total number of notifications rate-limited per route and integration.",
+		}, []string{"route", "integration"}),
+	}
+}
+
+// routeFromContext returns the key of the route which generated the notification. The aggregation
+// group key is made of the route key followed by the group labels.
+func routeFromContext(ctx context.Context) string {
+	groupKey, ok := notify.GroupKey(ctx)
+	if !ok {
+		return ""
+	}
+	if idx := strings.Index(groupKey, "}:{"); idx >= 0 {
+		return groupKey[:idx+1]
+	}
+	return groupKey
+}
+
+func (r *routeAnalytics) update(route string, fn func(s *routeStats)) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	s, ok := r.stats[route]
+	if !ok {
+		s = &routeStats{Route: route}
+		r.stats[route] = s
+	}
+	fn(s)
+}
+
+// removeStaleRoutes forgets the analytics of the routes not part of the given routing tree
+// anymore, once the configuration has been reloaded.
+func (r *routeAnalytics) removeStaleRoutes(root *dispatch.Route) {
+	routes := map[string]struct{}{}
+	root.Walk(func(route *dispatch.Route) {
+		routes[route.Key()] = struct{}{}
+	})
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for route := range r.stats {
+		if _, ok := routes[route]; ok {
+			continue
+		}
+
+		delete(r.stats, route)
+		r.alertsGrouped.DeleteLabelValues(route)
+		r.groupFlushes.DeleteLabelValues(route)
+		r.throttledFlushes.DeleteLabelValues(route)
+		r.notificationsSent.DeletePartialMatch(prometheus.Labels{"route": route})
+		r.notificationsFailed.DeletePartialMatch(prometheus.Labels{"route": route})
+		r.notificationsRateLimited.DeletePartialMatch(prometheus.Labels{"route": route})
+	}
+}
+
+// wrapStage returns a stage tracking the aggregation group flushes executed by the given stage.
+func (r *routeAnalytics) wrapStage(next notify.Stage) notify.Stage {
+	return notify.StageFunc(func(ctx context.Context, l log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		route := routeFromContext(ctx)
+		flush := &routeFlush{}
+
+		ctx, res, err := next.Exec(context.WithValue(ctx, routeAnalyticsFlushKey{}, flush), l, alerts...)
+
+		r.alertsGrouped.WithLabelValues(route).Add(float64(len(alerts)))
+		r.groupFlushes.WithLabelValues(route).Inc()
+		if !flush.notified.Load() {
+			r.throttledFlushes.WithLabelValues(route).Inc()
+		}
+		r.update(route, func(s *routeStats) {
+			s.AlertsGrouped += uint64(len(alerts))
+			s.GroupFlushes++
+			if !flush.notified.Load() {
+				s.ThrottledFlushes++
+			}
+		})
+
+		return ctx, res, err
+	})
+}
+
+// wrapNotifier returns a notifier tracking the notifications sent by the given notifier.
+func (r *routeAnalytics) wrapNotifier(integration string, next notify.Notifier) notify.Notifier {
+	return &routeAnalyticsNotifier{analytics: r, integration: integration, next: next}
+}
+
+type routeAnalyticsNotifier struct {
+	analytics   *routeAnalytics
+	integration string
+	next        notify.Notifier
+}
+
+func (n *routeAnalyticsNotifier) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	if flush, ok := ctx.Value(routeAnalyticsFlushKey{}).(*routeFlush); ok {
+		flush.notified.Store(true)
+	}
+
+	route := routeFromContext(ctx)
+	retry, err := n.next.Notify(ctx, alerts...)
+
+	// Rate-limited notifications haven't been attempted, so they are not counted as failed.
+	rateLimited := errors.Is(err, errRateLimited)
+	switch {
+	case rateLimited:
+		n.analytics.notificationsRateLimited.WithLabelValues(route, n.integration).Inc()
+	case err != nil:
+		n.analytics.notificationsFailed.WithLabelValues(route, n.integration).Inc()
+	default:
+		n.analytics.notificationsSent.WithLabelValues(route, n.integration).Inc()
+	}
+	n.analytics.update(route, func(s *routeStats) {
+		switch {
+		case rateLimited:
+			s.NotificationsRateLimited++
+		case err != nil:
+			s.NotificationsFailed++
+		default:
+			s.NotificationsSent++
+		}
+	})
+
+	return retry, err
+}
+
+// ServeHTTP serves the per-route notification analytics as JSON, sorted by route.
+func (r *routeAnalytics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	r.mtx.Lock()
+	out := make([]routeStats, 0, len(r.stats))
+	for _, s := range r.stats {
+		out = append(out, *s)
+	}
+	r.mtx.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Route < out[j].Route
+	})
+
+	util.WriteJSONResponse(w, out)
+}
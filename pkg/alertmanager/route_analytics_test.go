@@ -0,0 +1,117 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/dispatch"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/pkg/labels"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type errorNotifier struct {
+	err error
+}
+
+func (n *errorNotifier) Notify(_ context.Context, _ ...*types.Alert) (bool, error) {
+	return false, n.err
+}
+
+func TestRouteAnalytics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	analytics := newRouteAnalytics(reg)
+
+	okNotifier := analytics.wrapNotifier("webhook", &errorNotifier{})
+	failingNotifier := analytics.wrapNotifier("email", &errorNotifier{err: errors.New("failed")})
+	rateLimitedNotifier := analytics.wrapNotifier("pagerduty", &errorNotifier{err: errRateLimited})
+
+	// The stage notifies the integrations only if requested, simulating the deduplication
+	// of the notifications done by the pipeline.
+	notifyIntegrations := true
+	stage := analytics.wrapStage(notifyStageFunc(func(ctx context.Context, alerts ...*types.Alert) {
+		if notifyIntegrations {
+			_, _ = okNotifier.Notify(ctx, alerts...)
+			_, _ = failingNotifier.Notify(ctx, alerts...)
+			_, _ = rateLimitedNotifier.Notify(ctx, alerts...)
+		}
+	}))
+
+	ctx := notify.WithGroupKey(context.Background(), `{}/{team="a"}:{alertname="test"}`)
+	_, _, err := stage.Exec(ctx, log.NewNopLogger(), &types.Alert{}, &types.Alert{})
+	require.NoError(t, err)
+
+	notifyIntegrations = false
+	_, _, err = stage.Exec(ctx, log.NewNopLogger(), &types.Alert{}, &types.Alert{})
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP alertmanager_route_alerts_grouped_total Total number of alerts in the aggregation groups flushed per route.
+		# TYPE alertmanager_route_alerts_grouped_total counter
+		alertmanager_route_alerts_grouped_total{route="{}/{team=\"a\"}"} 4
+
+		# HELP alertmanager_route_group_flushes_total Total number of aggregation group flushes per route.
+		# TYPE alertmanager_route_group_flushes_total counter
+		alertmanager_route_group_flushes_total{route="{}/{team=\"a\"}"} 2
+
+		# HELP alertmanager_route_group_flushes_throttled_total Total number of aggregation group flushes per route which didn't notify any integration.
+		# TYPE alertmanager_route_group_flushes_throttled_total counter
+		alertmanager_route_group_flushes_throttled_total{route="{}/{team=\"a\"}"} 1
+
+		# HELP alertmanager_route_notifications_total Total number of notifications sent per route and integration.
+		# TYPE alertmanager_route_notifications_total counter
+		alertmanager_route_notifications_total{integration="webhook",route="{}/{team=\"a\"}"} 1
+
+		# HELP alertmanager_route_notifications_failed_total Total number of notifications failed per route and integration.
+		# TYPE alertmanager_route_notifications_failed_total counter
+		alertmanager_route_notifications_failed_total{integration="email",route="{}/{team=\"a\"}"} 1
+
+		# HELP alertmanager_route_notifications_rate_limited_total Total number of notifications rate-limited per route and integration.
+		# TYPE alertmanager_route_notifications_rate_limited_total counter
+		alertmanager_route_notifications_rate_limited_total{integration="pagerduty",route="{}/{team=\"a\"}"} 1
+	`)))
+
+	rec := httptest.NewRecorder()
+	analytics.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/route_analytics", nil))
+	assert.JSONEq(t, `[{
+		"route": "{}/{team=\"a\"}",
+		"alerts_grouped": 4,
+		"group_flushes": 2,
+		"throttled_flushes": 1,
+		"notifications_sent": 1,
+		"notifications_failed": 1,
+		"notifications_rate_limited": 1
+	}]`, rec.Body.String())
+
+	// The routes still configured are kept once the configuration is reloaded.
+	matcher, err := labels.NewMatcher(labels.MatchEqual, "team", "a")
+	require.NoError(t, err)
+	analytics.removeStaleRoutes(dispatch.NewRoute(&config.Route{
+		Routes: []*config.Route{{Matchers: config.Matchers{matcher}}},
+	}, nil))
+	assert.Equal(t, 6, testutil.CollectAndCount(reg))
+
+	// The routes removed from the configuration are forgotten.
+	analytics.removeStaleRoutes(dispatch.NewRoute(&config.Route{}, nil))
+	assert.Equal(t, 0, testutil.CollectAndCount(reg))
+
+	rec = httptest.NewRecorder()
+	analytics.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/route_analytics", nil))
+	assert.JSONEq(t, `[]`, rec.Body.String())
+}
+
+func notifyStageFunc(fn func(ctx context.Context, alerts ...*types.Alert)) notify.Stage {
+	return notify.StageFunc(func(ctx context.Context, _ log.Logger, alerts ...*types.Alert) (context.Context, []*types.Alert, error) {
+		fn(ctx, alerts...)
+		return ctx, alerts, nil
+	})
+}
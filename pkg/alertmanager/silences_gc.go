@@ -0,0 +1,234 @@
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	// silencesGCInterval is the interval at which the silences GC policies are enforced.
+	silencesGCInterval = time.Minute
+
+	silencesReasonMaxCount    = "max_silences_count"
+	silencesReasonMaxLifetime = "max_silence_lifetime"
+	silencesReasonUnused      = "unused"
+)
+
+var (
+	errTooManySilences = "exceeded the maximum number of active and pending silences, limit: %d"
+	errSilenceTooLong  = "exceeded the maximum silence lifetime, limit: %s"
+	errInvalidSilence  = "failed to decode the silence: %v"
+)
+
+// silencesGC enforces the per-tenant silences limits. The number of active and pending
+// silences and the lifetime of new silences are limited when they're created, while the
+// silences active for longer than the max lifetime or without matching alerts for too long
+// are periodically expired.
+type silencesGC struct {
+	tenant   string
+	limits   Limits
+	silences *silence.Silences
+	alerts   *mem.Alerts
+	logger   log.Logger
+
+	// The time the silences started to be tracked, used as last match time of
+	// the silences which haven't matched any alert since then.
+	startedAt time.Time
+
+	// The last time each active silence matched an alert. Only accessed by gc().
+	lastMatched map[string]time.Time
+
+	limited *prometheus.CounterVec
+	expired *prometheus.CounterVec
+}
+
+func newSilencesGC(tenant string, limits Limits, silences *silence.Silences, alerts *mem.Alerts, logger log.Logger, reg prometheus.Registerer) *silencesGC {
+	return &silencesGC{
+		tenant:      tenant,
+		limits:      limits,
+		silences:    silences,
+		alerts:      alerts,
+		logger:      logger,
+		startedAt:   time.Now(),
+		lastMatched: map[string]time.Time{},
+		limited: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_silences_limited_total",
+			Help: "Number of silences rejected because of the silences limits.",
+		}, []string{"reason"}),
+		expired: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_silences_gc_expired_total",
+			Help: "Number of silences expired by the silences GC policies.",
+		}, []string{"reason"}),
+	}
+}
+
+// run periodically enforces the silences GC policies until stop is closed.
+func (g *silencesGC) run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			g.gc(time.Now())
+		}
+	}
+}
+
+// gc expires the active silences exceeding the max lifetime or which haven't matched
+// any alert for longer than the configured period.
+func (g *silencesGC) gc(now time.Time) {
+	maxLifetime := g.limits.AlertmanagerMaxSilenceLifetime(g.tenant)
+	expireUnusedAfter := g.limits.AlertmanagerExpireUnusedSilencesAfter(g.tenant)
+
+	active, _, err := g.silences.Query(silence.QState(types.SilenceStateActive))
+	if err != nil {
+		level.Warn(g.logger).Log("msg", "failed to query the active silences", "err", err)
+		return
+	}
+
+	if expireUnusedAfter > 0 {
+		g.trackMatchedSilences(now)
+	}
+
+	activeIDs := make(map[string]struct{}, len(active))
+	for _, sil := range active {
+		activeIDs[sil.Id] = struct{}{}
+
+		switch {
+		case maxLifetime > 0 && now.Sub(sil.StartsAt) > maxLifetime:
+			g.expire(sil.Id, silencesReasonMaxLifetime)
+		case expireUnusedAfter > 0 && now.Sub(g.lastMatchedAt(sil.Id, sil.StartsAt)) > expireUnusedAfter:
+			g.expire(sil.Id, silencesReasonUnused)
+		}
+	}
+
+	// Forget the silences which are not active anymore.
+	for id := range g.lastMatched {
+		if _, ok := activeIDs[id]; !ok {
+			delete(g.lastMatched, id)
+		}
+	}
+}
+
+// trackMatchedSilences records the active silences matching any of the firing alerts.
+func (g *silencesGC) trackMatchedSilences(now time.Time) {
+	it := g.alerts.GetPending()
+	defer it.Close()
+
+	for alert := range it.Next() {
+		if alert.Resolved() {
+			continue
+		}
+
+		matched, _, err := g.silences.Query(silence.QState(types.SilenceStateActive), silence.QMatches(alert.Labels))
+		if err != nil {
+			level.Warn(g.logger).Log("msg", "failed to query the silences matching an alert", "err", err)
+			continue
+		}
+		for _, sil := range matched {
+			g.lastMatched[sil.Id] = now
+		}
+	}
+}
+
+func (g *silencesGC) lastMatchedAt(id string, startsAt time.Time) time.Time {
+	lastMatched := g.startedAt
+	if startsAt.After(lastMatched) {
+		lastMatched = startsAt
+	}
+	if t, ok := g.lastMatched[id]; ok && t.After(lastMatched) {
+		lastMatched = t
+	}
+	return lastMatched
+}
+
+func (g *silencesGC) expire(id, reason string) {
+	if err := g.silences.Expire(id); err != nil {
+		level.Warn(g.logger).Log("msg", "failed to expire silence", "silence_id", id, "reason", reason, "err", err)
+		return
+	}
+
+	level.Info(g.logger).Log("msg", "expired silence", "silence_id", id, "reason", reason)
+	g.expired.WithLabelValues(reason).Inc()
+}
+
+// wrapPostSilences returns a http.Handler enforcing the silences limits on the silences
+// created or updated through the input handler.
+func (g *silencesGC) wrapPostSilences(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var sil struct {
+			ID       string    `json:"id"`
+			StartsAt time.Time `json:"startsAt"`
+			EndsAt   time.Time `json:"endsAt"`
+		}
+		if err := json.Unmarshal(body, &sil); err != nil {
+			http.Error(w, fmt.Sprintf(errInvalidSilence, err), http.StatusBadRequest)
+			return
+		}
+
+		if reason, msg := g.checkLimits(sil.ID, sil.StartsAt, sil.EndsAt, time.Now()); reason != "" {
+			g.limited.WithLabelValues(reason).Inc()
+			level.Warn(g.logger).Log("msg", "silence rejected", "reason", reason)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkLimits returns the reason and the error message if the input silence exceeds the
+// silences limits, or an empty reason otherwise.
+func (g *silencesGC) checkLimits(id string, startsAt, endsAt, now time.Time) (string, string) {
+	if maxLifetime := g.limits.AlertmanagerMaxSilenceLifetime(g.tenant); maxLifetime > 0 {
+		if startsAt.Before(now) {
+			startsAt = now
+		}
+		if endsAt.Sub(startsAt) > maxLifetime {
+			return silencesReasonMaxLifetime, fmt.Sprintf(errSilenceTooLong, maxLifetime)
+		}
+	}
+
+	if maxCount := g.limits.AlertmanagerMaxSilencesCount(g.tenant); maxCount > 0 {
+		// Updating an active or pending silence doesn't change the number of silences.
+		if id != "" {
+			if existing, _, err := g.silences.Query(silence.QIDs(id), silence.QState(types.SilenceStateActive, types.SilenceStatePending)); err == nil && len(existing) > 0 {
+				return "", ""
+			}
+		}
+
+		count, err := g.silences.CountState(types.SilenceStateActive, types.SilenceStatePending)
+		if err == nil && count >= maxCount {
+			return silencesReasonMaxCount, fmt.Sprintf(errTooManySilences, maxCount)
+		}
+	}
+
+	return "", ""
+}
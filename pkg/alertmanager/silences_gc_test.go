@@ -0,0 +1,132 @@
+package alertmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/provider/mem"
+	"github.com/prometheus/alertmanager/silence"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/prometheus/alertmanager/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSilencesGC(t *testing.T, limits *mockAlertManagerLimits) (*silencesGC, *silence.Silences, *mem.Alerts, *prometheus.Registry) {
+	silences, err := silence.New(silence.Options{Retention: time.Hour})
+	require.NoError(t, err)
+
+	alerts, err := mem.NewAlerts(context.Background(), types.NewMarker(prometheus.NewRegistry()), time.Hour, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	t.Cleanup(alerts.Close)
+
+	reg := prometheus.NewPedanticRegistry()
+	return newSilencesGC("user", limits, silences, alerts, log.NewNopLogger(), reg), silences, alerts, reg
+}
+
+func setTestSilence(t *testing.T, silences *silence.Silences, alertName string, startsAt, endsAt time.Time) string {
+	id, err := silences.Set(&silencepb.Silence{
+		Matchers:  []*silencepb.Matcher{{Name: "alertname", Pattern: alertName, Type: silencepb.Matcher_EQUAL}},
+		StartsAt:  startsAt,
+		EndsAt:    endsAt,
+		CreatedBy: "test",
+		Comment:   "test",
+	})
+	require.NoError(t, err)
+	return id
+}
+
+func silenceState(t *testing.T, silences *silence.Silences, id string) types.SilenceState {
+	sils, _, err := silences.Query(silence.QIDs(id))
+	require.NoError(t, err)
+	require.Len(t, sils, 1)
+	return types.CalcSilenceState(sils[0].StartsAt, sils[0].EndsAt)
+}
+
+func TestSilencesGC_WrapPostSilences(t *testing.T) {
+	gc, silences, _, reg := newTestSilencesGC(t, &mockAlertManagerLimits{maxSilencesCount: 1, maxSilenceLifetime: time.Hour})
+
+	handler := gc.wrapPostSilences(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	post := func(body string) int {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v2/silences", strings.NewReader(body)))
+		return w.Code
+	}
+
+	now := time.Now()
+	silenceBody := func(id string, lifetime time.Duration) string {
+		return `{"id":"` + id + `","startsAt":"` + now.Format(time.RFC3339) + `","endsAt":"` + now.Add(lifetime).Format(time.RFC3339) + `"}`
+	}
+
+	// A silence within the limits is accepted.
+	assert.Equal(t, http.StatusOK, post(silenceBody("", 30*time.Minute)))
+
+	// A silence longer than the max lifetime is rejected.
+	assert.Equal(t, http.StatusBadRequest, post(silenceBody("", 2*time.Hour)))
+
+	// A new silence is rejected once the max number of silences is reached, but the existing ones can be updated.
+	id := setTestSilence(t, silences, "alert-1", now, now.Add(30*time.Minute))
+	assert.Equal(t, http.StatusBadRequest, post(silenceBody("", 30*time.Minute)))
+	assert.Equal(t, http.StatusOK, post(silenceBody(id, 45*time.Minute)))
+
+	// The other methods are not limited.
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/silences", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP alertmanager_silences_limited_total Number of silences rejected because of the silences limits.
+		# TYPE alertmanager_silences_limited_total counter
+		alertmanager_silences_limited_total{reason="max_silence_lifetime"} 1
+		alertmanager_silences_limited_total{reason="max_silences_count"} 1
+	`), "alertmanager_silences_limited_total"))
+}
+
+func TestSilencesGC_GC(t *testing.T) {
+	gc, silences, alerts, reg := newTestSilencesGC(t, &mockAlertManagerLimits{maxSilenceLifetime: 3 * time.Hour, expireUnusedSilencesAfter: time.Hour})
+
+	now := time.Now()
+	matching := setTestSilence(t, silences, "alert-1", now, now.Add(24*time.Hour))
+	unused := setTestSilence(t, silences, "alert-2", now, now.Add(24*time.Hour))
+
+	require.NoError(t, alerts.Put(&types.Alert{
+		Alert: model.Alert{
+			Labels:   model.LabelSet{"alertname": "alert-1"},
+			StartsAt: now,
+			EndsAt:   now.Add(24 * time.Hour),
+		},
+		UpdatedAt: now,
+	}))
+
+	// Nothing is expired before the configured durations.
+	gc.gc(now.Add(30 * time.Minute))
+	assert.Equal(t, types.SilenceStateActive, silenceState(t, silences, matching))
+	assert.Equal(t, types.SilenceStateActive, silenceState(t, silences, unused))
+
+	// The silence not matching any alert is expired.
+	gc.gc(now.Add(2 * time.Hour))
+	assert.Equal(t, types.SilenceStateActive, silenceState(t, silences, matching))
+	assert.Equal(t, types.SilenceStateExpired, silenceState(t, silences, unused))
+
+	// The silence active for longer than the max lifetime is expired.
+	gc.gc(now.Add(4 * time.Hour))
+	assert.Equal(t, types.SilenceStateExpired, silenceState(t, silences, matching))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP alertmanager_silences_gc_expired_total Number of silences expired by the silences GC policies.
+		# TYPE alertmanager_silences_gc_expired_total counter
+		alertmanager_silences_gc_expired_total{reason="max_silence_lifetime"} 1
+		alertmanager_silences_gc_expired_total{reason="unused"} 1
+	`), "alertmanager_silences_gc_expired_total"))
+}
@@ -0,0 +1,197 @@
+package alertmanager
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/cluster/clusterpb"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+)
+
+const (
+	compactionReasonExpired   = "expired"
+	compactionReasonRetention = "retention"
+	compactionReasonSize      = "size"
+
+	stateTypeNotificationLog = "nfl"
+	stateTypeSilences        = "sil"
+)
+
+var errInvalidDelimitedMessage = errors.New("invalid length-delimited message")
+
+// compactFullState compacts the notification log and the silences of the full state before
+// it gets persisted. It drops the entries which already expired, given the in-memory state is
+// only garbage collected periodically, and the notification log entries older than the retention.
+// Then, if the state is bigger than maxSizeBytes, it drops the oldest notification log entries
+// until it fits. The silences are never dropped because of the size, given they're created by
+// the users. It returns the number of dropped entries by reason.
+//
+// The notification log entries dropped because of the retention or the size haven't expired yet,
+// so the notifications they deduplicate may be sent again once the state is restored from the
+// persisted one. They're only dropped if the retention or the max size are configured.
+func compactFullState(fs *clusterpb.FullState, now time.Time, retention time.Duration, maxSizeBytes int) (map[string]int, error) {
+	dropped := map[string]int{}
+
+	// The notification log entries kept, by part index.
+	entries := map[int][]*nflogpb.MeshEntry{}
+
+	for i, p := range fs.Parts {
+		switch getStateTypeFromKey(p.Key) {
+		case stateTypeNotificationLog:
+			all, err := decodeDelimited[nflogpb.MeshEntry](p.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode the notification log for key: %v", p.Key)
+			}
+
+			kept := all[:0]
+			for _, e := range all {
+				switch {
+				case e.ExpiresAt.Before(now):
+					dropped[compactionReasonExpired]++
+				case retention > 0 && e.Entry != nil && e.Entry.Timestamp.Before(now.Add(-retention)):
+					dropped[compactionReasonRetention]++
+				default:
+					kept = append(kept, e)
+				}
+			}
+			entries[i] = kept
+
+		case stateTypeSilences:
+			all, err := decodeDelimited[silencepb.MeshSilence](p.Data)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to decode the silences for key: %v", p.Key)
+			}
+
+			kept := all[:0]
+			for _, s := range all {
+				if s.ExpiresAt.Before(now) {
+					dropped[compactionReasonExpired]++
+					continue
+				}
+				kept = append(kept, s)
+			}
+			if len(kept) < len(all) {
+				if fs.Parts[i].Data, err = encodeDelimited(kept); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if maxSizeBytes > 0 {
+		if n := dropOldestEntries(fs, entries, maxSizeBytes); n > 0 {
+			dropped[compactionReasonSize] = n
+		}
+	}
+
+	for i, kept := range entries {
+		data, err := encodeDelimited(kept)
+		if err != nil {
+			return nil, err
+		}
+		fs.Parts[i].Data = data
+	}
+
+	return dropped, nil
+}
+
+// dropOldestEntries drops the oldest notification log entries until the size of the full state,
+// once the entries are encoded, doesn't exceed maxSizeBytes, and returns the number of dropped entries.
+func dropOldestEntries(fs *clusterpb.FullState, entries map[int][]*nflogpb.MeshEntry, maxSizeBytes int) int {
+	size := 0
+	all := []*nflogpb.MeshEntry(nil)
+	for i, p := range fs.Parts {
+		kept, ok := entries[i]
+		if !ok {
+			size += len(p.Key) + len(p.Data)
+			continue
+		}
+
+		size += len(p.Key)
+		for _, e := range kept {
+			size += delimitedSize(e)
+			all = append(all, e)
+		}
+	}
+	if size <= maxSizeBytes {
+		return 0
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return entryTimestamp(all[i]).Before(entryTimestamp(all[j]))
+	})
+
+	dropped := map[*nflogpb.MeshEntry]struct{}{}
+	for _, e := range all {
+		if size <= maxSizeBytes {
+			break
+		}
+		size -= delimitedSize(e)
+		dropped[e] = struct{}{}
+	}
+
+	for i, kept := range entries {
+		filtered := kept[:0]
+		for _, e := range kept {
+			if _, ok := dropped[e]; !ok {
+				filtered = append(filtered, e)
+			}
+		}
+		entries[i] = filtered
+	}
+
+	return len(dropped)
+}
+
+func entryTimestamp(e *nflogpb.MeshEntry) time.Time {
+	if e.Entry == nil {
+		return time.Time{}
+	}
+	return e.Entry.Timestamp
+}
+
+// decodeDelimited decodes a sequence of length-delimited messages, as encoded by the
+// notification log and silences.
+func decodeDelimited[T any, PT interface {
+	*T
+	proto.Message
+}](data []byte) ([]PT, error) {
+	var result []PT
+	for len(data) > 0 {
+		size, n := binary.Uvarint(data)
+		if n <= 0 || size > uint64(len(data)-n) {
+			return nil, errInvalidDelimitedMessage
+		}
+
+		m := PT(new(T))
+		if err := proto.Unmarshal(data[n:n+int(size)], m); err != nil {
+			return nil, err
+		}
+		result = append(result, m)
+		data = data[n+int(size):]
+	}
+	return result, nil
+}
+
+func encodeDelimited[PT proto.Message](msgs []PT) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, m := range msgs {
+		b, err := proto.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(proto.EncodeVarint(uint64(len(b))))
+		buf.Write(b)
+	}
+	return buf.Bytes(), nil
+}
+
+func delimitedSize(m proto.Message) int {
+	size := proto.Size(m)
+	return proto.SizeVarint(uint64(size)) + size
+}
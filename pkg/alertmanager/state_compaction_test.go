@@ -0,0 +1,134 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/cluster/clusterpb"
+	"github.com/prometheus/alertmanager/nflog/nflogpb"
+	"github.com/prometheus/alertmanager/silence/silencepb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompactFullState(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	newEntry := func(groupKey string, ts, expiresAt time.Time) *nflogpb.MeshEntry {
+		return &nflogpb.MeshEntry{
+			Entry: &nflogpb.Entry{
+				GroupKey:  []byte(groupKey),
+				Receiver:  &nflogpb.Receiver{GroupName: "receiver", Integration: "webhook"},
+				Timestamp: ts,
+			},
+			ExpiresAt: expiresAt,
+		}
+	}
+	newSilence := func(id string, expiresAt time.Time) *silencepb.MeshSilence {
+		return &silencepb.MeshSilence{
+			Silence:   &silencepb.Silence{Id: id, EndsAt: expiresAt},
+			ExpiresAt: expiresAt,
+		}
+	}
+
+	entries := []*nflogpb.MeshEntry{
+		newEntry("expired", now.Add(-3*time.Hour), now.Add(-time.Minute)),
+		newEntry("old", now.Add(-2*time.Hour), now.Add(time.Hour)),
+		newEntry("older", now.Add(-90*time.Minute), now.Add(time.Hour)),
+		newEntry("recent", now.Add(-time.Minute), now.Add(time.Hour)),
+	}
+	silences := []*silencepb.MeshSilence{
+		newSilence("expired", now.Add(-time.Minute)),
+		newSilence("active", now.Add(time.Hour)),
+	}
+
+	tests := map[string]struct {
+		retention        time.Duration
+		maxSizeBytes     int
+		expectedEntries  []string
+		expectedSilences []string
+		expectedDropped  map[string]int
+	}{
+		"should drop the expired entries": {
+			expectedEntries:  []string{"old", "older", "recent"},
+			expectedSilences: []string{"active"},
+			expectedDropped:  map[string]int{compactionReasonExpired: 2},
+		},
+		"should drop the notification log entries older than the retention": {
+			retention:        time.Hour,
+			expectedEntries:  []string{"recent"},
+			expectedSilences: []string{"active"},
+			expectedDropped:  map[string]int{compactionReasonExpired: 2, compactionReasonRetention: 2},
+		},
+		"should drop the oldest notification log entries exceeding the max size": {
+			maxSizeBytes:     stateSizeWithEntries(t, entries[2:], silences[1:]),
+			expectedEntries:  []string{"older", "recent"},
+			expectedSilences: []string{"active"},
+			expectedDropped:  map[string]int{compactionReasonExpired: 2, compactionReasonSize: 1},
+		},
+		"should never drop the silences because of the size": {
+			maxSizeBytes:     1,
+			expectedEntries:  nil,
+			expectedSilences: []string{"active"},
+			expectedDropped:  map[string]int{compactionReasonExpired: 2, compactionReasonSize: 3},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			fs := newTestFullState(t, entries, silences)
+			dropped, err := compactFullState(fs, now, testData.retention, testData.maxSizeBytes)
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedDropped, dropped)
+
+			require.Len(t, fs.Parts, 2)
+
+			actualEntries, err := decodeDelimited[nflogpb.MeshEntry](fs.Parts[0].Data)
+			require.NoError(t, err)
+			var actualGroupKeys []string
+			for _, e := range actualEntries {
+				actualGroupKeys = append(actualGroupKeys, string(e.Entry.GroupKey))
+			}
+			assert.ElementsMatch(t, testData.expectedEntries, actualGroupKeys)
+
+			actualSilences, err := decodeDelimited[silencepb.MeshSilence](fs.Parts[1].Data)
+			require.NoError(t, err)
+			var actualIDs []string
+			for _, s := range actualSilences {
+				actualIDs = append(actualIDs, s.Silence.Id)
+			}
+			assert.ElementsMatch(t, testData.expectedSilences, actualIDs)
+		})
+	}
+}
+
+func TestCompactFullState_InvalidState(t *testing.T) {
+	t.Parallel()
+
+	fs := &clusterpb.FullState{Parts: []clusterpb.Part{{Key: "nfl:user-1", Data: []byte{0xff}}}}
+	_, err := compactFullState(fs, time.Now(), 0, 0)
+	require.Error(t, err)
+}
+
+func newTestFullState(t *testing.T, entries []*nflogpb.MeshEntry, silences []*silencepb.MeshSilence) *clusterpb.FullState {
+	nfl, err := encodeDelimited(entries)
+	require.NoError(t, err)
+	sil, err := encodeDelimited(silences)
+	require.NoError(t, err)
+
+	return &clusterpb.FullState{Parts: []clusterpb.Part{
+		{Key: "nfl:user-1", Data: nfl},
+		{Key: "sil:user-1", Data: sil},
+	}}
+}
+
+// stateSizeWithEntries returns the size accounted by the compaction for a state with the given entries and silences.
+func stateSizeWithEntries(t *testing.T, entries []*nflogpb.MeshEntry, silences []*silencepb.MeshSilence) int {
+	fs := newTestFullState(t, entries, silences)
+	return len(fs.Parts[0].Key) + len(fs.Parts[0].Data) + len(fs.Parts[1].Key) + len(fs.Parts[1].Data)
+}
@@ -52,21 +52,26 @@ type statePersister struct {
 	state  PersistableState
 	store  alertstore.AlertStore
 	userID string
+	limits Limits
 	logger log.Logger
 
 	timeout time.Duration
 
-	persistTotal  prometheus.Counter
-	persistFailed prometheus.Counter
+	persistTotal     prometheus.Counter
+	persistFailed    prometheus.Counter
+	persistedSize    prometheus.Gauge
+	compactedEntries *prometheus.CounterVec
 }
 
 // newStatePersister creates a new state persister.
-func newStatePersister(cfg PersisterConfig, userID string, state PersistableState, store alertstore.AlertStore, l log.Logger, r prometheus.Registerer) *statePersister {
+// The limits are optional.
+func newStatePersister(cfg PersisterConfig, userID string, state PersistableState, store alertstore.AlertStore, limits Limits, l log.Logger, r prometheus.Registerer) *statePersister {
 
 	s := &statePersister{
 		state:   state,
 		store:   store,
 		userID:  userID,
+		limits:  limits,
 		logger:  l,
 		timeout: defaultPersistTimeout,
 		persistTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
@@ -77,6 +82,14 @@ func newStatePersister(cfg PersisterConfig, userID string, state PersistableStat
 			Name: "alertmanager_state_persist_failed_total",
 			Help: "Number of times we have failed to persist the running state to remote storage.",
 		}),
+		persistedSize: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Name: "alertmanager_state_persisted_size_bytes",
+			Help: "Size of the state last persisted to remote storage, after compaction.",
+		}),
+		compactedEntries: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "alertmanager_state_persist_compacted_entries_total",
+			Help: "Number of notification log entries and silences not persisted to remote storage because of the state compaction.",
+		}, []string{"reason"}),
 	}
 
 	s.Service = services.NewTimerService(cfg.Interval, s.starting, s.iteration, nil)
@@ -118,13 +131,38 @@ func (s *statePersister) persist(ctx context.Context) (err error) {
 		return err
 	}
 
+	var (
+		retention    time.Duration
+		maxSizeBytes int
+	)
+	if s.limits != nil {
+		retention = s.limits.AlertmanagerNotificationLogRetention(s.userID)
+		maxSizeBytes = s.limits.AlertmanagerMaxStateSizeBytes(s.userID)
+	}
+
+	var dropped map[string]int
+	dropped, err = compactFullState(fs, time.Now(), retention, maxSizeBytes)
+	if err != nil {
+		return errors.Wrap(err, "failed to compact state")
+	}
+	for reason, count := range dropped {
+		s.compactedEntries.WithLabelValues(reason).Add(float64(count))
+	}
+	if unexpired := dropped[compactionReasonRetention] + dropped[compactionReasonSize]; unexpired > 0 {
+		level.Warn(s.logger).Log("msg", "not persisting notification log entries not expired yet, their notifications may be sent again if the state is restored", "user", s.userID, "entries", unexpired, "retention", retention, "max_size_bytes", maxSizeBytes)
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
 	desc := alertspb.FullStateDesc{State: fs}
+	if maxSizeBytes > 0 && desc.Size() > maxSizeBytes {
+		level.Warn(s.logger).Log("msg", "persisting state exceeding the max size, because of the silences", "user", s.userID, "size", desc.Size(), "limit", maxSizeBytes)
+	}
 	if err = s.store.SetFullState(ctx, s.userID, desc); err != nil {
 		return err
 	}
 
+	s.persistedSize.Set(float64(desc.Size()))
 	return nil
 }
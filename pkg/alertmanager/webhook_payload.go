@@ -0,0 +1,171 @@
+package alertmanager
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	tmplhtml "html/template"
+	"io"
+	"net/http"
+	tmpltext "text/template"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+)
+
+var errWebhookPayloadNotJSON = errors.New("the rendered webhook payload is not valid JSON")
+
+// webhookPayloadFuncs are the functions added to the tenant templates to build the webhook payloads.
+var webhookPayloadFuncs = map[string]interface{}{
+	// toJson encodes the input value as JSON, eg. to quote and escape a string.
+	"toJson": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// withWebhookPayloadFuncs is a template.Option adding the webhookPayloadFuncs to the templates.
+func withWebhookPayloadFuncs(text *tmpltext.Template, html *tmplhtml.Template) {
+	text.Funcs(webhookPayloadFuncs)
+	html.Funcs(webhookPayloadFuncs)
+}
+
+// webhookPayloadData is the data the webhook payload templates are executed with: the
+// notification data, plus the fields of the Alertmanager webhook payload.
+type webhookPayloadData struct {
+	*template.Data
+
+	GroupKey        string
+	TruncatedAlerts uint64
+}
+
+// renderWebhookPayload executes the named template with the input data, and checks the
+// result is valid JSON.
+func renderWebhookPayload(tmpl *template.Template, name string, data *webhookPayloadData) ([]byte, error) {
+	payload, err := tmpl.ExecuteTextString(fmt.Sprintf("{{ template %q . }}", name), data)
+	if err != nil {
+		return nil, err
+	}
+	if !json.Valid([]byte(payload)) {
+		return nil, errWebhookPayloadNotJSON
+	}
+	return []byte(payload), nil
+}
+
+// validateWebhookPayloadTemplates checks that the webhook payload templates are configured
+// for receivers with webhooks, and that they render valid JSON for a sample notification.
+func validateWebhookPayloadTemplates(cfg *config.Config, tmpl *template.Template, payloadTemplates map[string]string) error {
+	for receiver, name := range payloadTemplates {
+		hasWebhooks := false
+		for _, rcv := range cfg.Receivers {
+			if rcv.Name == receiver {
+				hasWebhooks = len(rcv.WebhookConfigs) > 0
+				break
+			}
+		}
+		if !hasWebhooks {
+			return fmt.Errorf("webhook payload template configured for receiver %q, which has no webhook", receiver)
+		}
+
+		now := time.Now()
+		alert := &types.Alert{
+			Alert: model.Alert{
+				Labels:      model.LabelSet{model.AlertNameLabel: "SampleAlert", "severity": "critical"},
+				Annotations: model.LabelSet{"summary": "Sample alert \"summary\""},
+				StartsAt:    now.Add(-time.Minute),
+				EndsAt:      now.Add(time.Minute),
+			},
+		}
+		data := &webhookPayloadData{
+			Data:     tmpl.Data(receiver, model.LabelSet{model.AlertNameLabel: "SampleAlert"}, alert),
+			GroupKey: "{}:{alertname=\"SampleAlert\"}",
+		}
+		if _, err := renderWebhookPayload(tmpl, name, data); err != nil {
+			return errors.Wrapf(err, "invalid webhook payload template %q for receiver %q", name, receiver)
+		}
+	}
+	return nil
+}
+
+// templatedWebhook is a webhook notifier sending a payload rendered with a tenant template,
+// rather than the Alertmanager payload. It otherwise behaves like the webhook notifier.
+type templatedWebhook struct {
+	conf     *config.WebhookConfig
+	tmpl     *template.Template
+	template string
+	logger   log.Logger
+	client   *http.Client
+	retrier  *notify.Retrier
+}
+
+func newTemplatedWebhook(conf *config.WebhookConfig, t *template.Template, name string, l log.Logger, httpOpts ...commoncfg.HTTPClientOption) (*templatedWebhook, error) {
+	client, err := commoncfg.NewClientFromConfig(*conf.HTTPConfig, "webhook", httpOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &templatedWebhook{
+		conf:     conf,
+		tmpl:     t,
+		template: name,
+		logger:   l,
+		client:   client,
+		// Webhooks are assumed to respond with 2xx response codes on a successful
+		// request and 5xx response codes are assumed to be recoverable.
+		retrier: &notify.Retrier{
+			CustomDetailsFunc: func(_ int, body io.Reader) string {
+				if body == nil {
+					return conf.URL.String()
+				}
+				bs, err := io.ReadAll(body)
+				if err != nil {
+					return conf.URL.String()
+				}
+				return fmt.Sprintf("%s: %s", conf.URL.String(), string(bs))
+			},
+		},
+	}, nil
+}
+
+// Notify implements notify.Notifier.
+func (n *templatedWebhook) Notify(ctx context.Context, alerts ...*types.Alert) (bool, error) {
+	var truncated uint64
+	if n.conf.MaxAlerts != 0 && uint64(len(alerts)) > n.conf.MaxAlerts {
+		alerts, truncated = alerts[:n.conf.MaxAlerts], uint64(len(alerts))-n.conf.MaxAlerts
+	}
+
+	groupKey, err := notify.ExtractGroupKey(ctx)
+	if err != nil {
+		level.Error(n.logger).Log("err", err)
+	}
+
+	payload, err := renderWebhookPayload(n.tmpl, n.template, &webhookPayloadData{
+		Data:            notify.GetTemplateData(ctx, n.tmpl, alerts, n.logger),
+		GroupKey:        groupKey.String(),
+		TruncatedAlerts: truncated,
+	})
+	if err != nil {
+		// The payload would be rendered the same way on retries.
+		return false, errors.Wrapf(err, "render webhook payload template %q", n.template)
+	}
+
+	resp, err := notify.PostJSON(ctx, n.client, n.conf.URL.String(), bytes.NewReader(payload))
+	if err != nil {
+		return true, notify.RedactURL(err)
+	}
+	defer notify.Drain(resp)
+
+	shouldRetry, err := n.retrier.Check(resp.StatusCode, resp.Body)
+	if err != nil {
+		return shouldRetry, notify.NewErrorWithReason(notify.GetFailureReasonFromStatusCode(resp.StatusCode), err)
+	}
+	return shouldRetry, err
+}
@@ -0,0 +1,86 @@
+package alertmanager
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/alertmanager/config"
+	"github.com/prometheus/alertmanager/notify"
+	"github.com/prometheus/alertmanager/template"
+	"github.com/prometheus/alertmanager/types"
+	commoncfg "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplatedWebhook_Notify(t *testing.T) {
+	const templates = `
+{{ define "ticket.json" }}{"receiver": {{ .Receiver | toJson }}, "key": {{ .GroupKey | toJson }}, "summary": {{ (index .Alerts 0).Annotations.summary | toJson }}, "alerts": {{ len .Alerts }}, "truncated": {{ .TruncatedAlerts }}}{{ end }}
+{{ define "broken.json" }}{"summary": {{ (index .Alerts 0).Annotations.summary }}}{{ end }}
+`
+	templatePath := filepath.Join(t.TempDir(), "ticket.tpl")
+	require.NoError(t, os.WriteFile(templatePath, []byte(templates), 0600))
+
+	tmpl, err := template.FromGlobs([]string{templatePath}, withWebhookPayloadFuncs)
+	require.NoError(t, err)
+	tmpl.ExternalURL = &url.URL{}
+
+	var (
+		received []byte
+		status   = http.StatusOK
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		received, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	newNotifier := func(name string) *templatedWebhook {
+		n, err := newTemplatedWebhook(&config.WebhookConfig{
+			HTTPConfig: &commoncfg.HTTPClientConfig{},
+			URL:        &config.SecretURL{URL: serverURL},
+			MaxAlerts:  1,
+		}, tmpl, name, log.NewNopLogger())
+		require.NoError(t, err)
+		return n
+	}
+
+	ctx := notify.WithGroupKey(context.Background(), "group-key")
+	ctx = notify.WithReceiverName(ctx, "ticketing")
+	ctx = notify.WithGroupLabels(ctx, model.LabelSet{})
+
+	alerts := []*types.Alert{
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "first"}, Annotations: model.LabelSet{"summary": `"quoted" summary`}}},
+		{Alert: model.Alert{Labels: model.LabelSet{"alertname": "second"}}},
+	}
+
+	retry, err := newNotifier("ticket.json").Notify(ctx, alerts...)
+	require.NoError(t, err)
+	require.False(t, retry)
+	require.JSONEq(t, `{"receiver": "ticketing", "key": "group-key", "summary": "\"quoted\" summary", "alerts": 1, "truncated": 1}`, string(received))
+
+	// The server errors are retried.
+	status = http.StatusInternalServerError
+	retry, err = newNotifier("ticket.json").Notify(ctx, alerts...)
+	require.Error(t, err)
+	require.True(t, retry)
+
+	// The payloads which are not valid JSON are not sent.
+	received = nil
+	retry, err = newNotifier("broken.json").Notify(ctx, alerts...)
+	require.ErrorIs(t, err, errWebhookPayloadNotJSON)
+	require.False(t, retry)
+	require.Nil(t, received)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/go-kit/log/level"
 	"github.com/grafana/regexp"
 	"github.com/klauspost/compress/gzhttp"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/prometheus/model/relabel"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/util/httputil"
@@ -24,13 +25,16 @@ import (
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/distributor"
 	"github.com/cortexproject/cortex/pkg/distributor/distributorpb"
+	"github.com/cortexproject/cortex/pkg/frontend/transport"
 	frontendv1 "github.com/cortexproject/cortex/pkg/frontend/v1"
 	"github.com/cortexproject/cortex/pkg/frontend/v1/frontendv1pb"
 	frontendv2 "github.com/cortexproject/cortex/pkg/frontend/v2"
 	"github.com/cortexproject/cortex/pkg/frontend/v2/frontendv2pb"
+	"github.com/cortexproject/cortex/pkg/ha"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/purger"
 	"github.com/cortexproject/cortex/pkg/querier"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/ruler"
 	"github.com/cortexproject/cortex/pkg/scheduler"
@@ -39,6 +43,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/storegateway/storegatewaypb"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 	"github.com/cortexproject/cortex/pkg/util/push"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 // DistributorPushWrapper wraps around a push. It is similar to middleware.Interface.
@@ -117,9 +122,10 @@ type API struct {
 	indexPage            *IndexPageContent
 	HTTPHeaderMiddleware *HTTPHeaderMiddleware
 	corsOrigin           *regexp.Regexp
+	pushMetrics          *push.HandlerMetrics
 }
 
-func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logger) (*API, error) {
+func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logger, reg prometheus.Registerer) (*API, error) {
 	// Ensure the encoded path is used. Required for the rules API
 	s.HTTP.UseEncodedPath()
 
@@ -146,6 +152,7 @@ func New(cfg Config, serverCfg server.Config, s *server.Server, logger log.Logge
 		sourceIPs:      sourceIPs,
 		indexPage:      newIndexPageContent(),
 		corsOrigin:     corsOrigin,
+		pushMetrics:    push.NewHandlerMetrics(reg),
 	}
 
 	// If no authentication middleware is present in the config, use the default authentication middleware.
@@ -215,6 +222,7 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, tar
 	a.RegisterRoute("/multitenant_alertmanager/status", am.GetStatusHandler(), false, "GET")
 	a.RegisterRoute("/multitenant_alertmanager/configs", http.HandlerFunc(am.ListAllConfigs), false, "GET")
 	a.RegisterRoute("/multitenant_alertmanager/ring", http.HandlerFunc(am.RingHandler), false, "GET", "POST")
+	a.RegisterRoute("/multitenant_alertmanager/receivers_health", http.HandlerFunc(am.ReceiversHealthHandler), false, "GET")
 	a.RegisterRoute("/multitenant_alertmanager/delete_tenant_config", http.HandlerFunc(am.DeleteUserConfig), true, "POST")
 
 	// UI components lead to a large number of routes to support, utilize a path prefix instead
@@ -226,6 +234,7 @@ func (a *API) RegisterAlertmanager(am *alertmanager.MultitenantAlertmanager, tar
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.GetUserConfig), true, "GET")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.SetUserConfig), true, "POST")
 		a.RegisterRoute("/api/v1/alerts", http.HandlerFunc(am.DeleteUserConfig), true, "DELETE")
+		a.RegisterRoute("/api/v1/alerts/lint", http.HandlerFunc(am.LintUserConfig), true, "GET", "POST")
 	}
 
 	// If the target is Alertmanager, enable the legacy behaviour. Otherwise only enable
@@ -249,19 +258,22 @@ func (a *API) RegisterAPI(httpPathPrefix string, actualCfg interface{}, defaultC
 }
 
 // RegisterRuntimeConfig registers the endpoints associates with the runtime configuration
-func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc) {
+func (a *API) RegisterRuntimeConfig(runtimeConfigHandler http.HandlerFunc, runtimeConfigValidateHandler http.HandlerFunc) {
 	a.indexPage.AddLink(SectionAdminEndpoints, "/runtime_config", "Current Runtime Config (incl. Overrides)")
 	a.indexPage.AddLink(SectionAdminEndpoints, "/runtime_config?mode=diff", "Current Runtime Config (show only values that differ from the defaults)")
 
 	a.RegisterRoute("/runtime_config", runtimeConfigHandler, false, "GET")
+	a.RegisterRoute("/runtime_config/validate", runtimeConfigValidateHandler, false, "POST")
 }
 
 // RegisterDistributor registers the endpoints associated with the distributor.
-func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config) {
-	distributorpb.RegisterDistributorServer(a.server.GRPC, d)
+func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distributor.Config, overrides *validation.Overrides) {
+	distributorpb.RegisterDistributorServer(a.server.GRPC, d.PushTokenGRPCServer())
+	ha.RegisterHATrackerStateServer(a.server.GRPC, d.HATracker)
 
-	a.RegisterRoute("/api/v1/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
-	a.RegisterRoute("/api/v1/otlp/v1/metrics", push.OTLPHandler(a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
+	a.RegisterRoute("/api/v1/push", d.PushTokenMiddleware(d.RequestRateLimitMiddleware(push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.wrapDistributorPush(d), a.pushMetrics))), true, "POST")
+	a.RegisterRoute("/api/v1/otlp/v1/metrics", d.PushTokenMiddleware(d.RequestRateLimitMiddleware(push.OTLPHandler(overrides, a.sourceIPs, a.cfg.wrapDistributorPush(d)))), true, "POST")
+	a.RegisterRoute("/api/v1/rejected_series", http.HandlerFunc(d.RejectedSeriesHandler), true, "GET")
 
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/ring", "Distributor Ring Status")
 	a.indexPage.AddLink(SectionAdminEndpoints, "/distributor/all_user_stats", "Usage Statistics")
@@ -270,11 +282,21 @@ func (a *API) RegisterDistributor(d *distributor.Distributor, pushConfig distrib
 	a.RegisterRoute("/distributor/ring", d, false, "GET", "POST")
 	a.RegisterRoute("/distributor/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, "GET")
 	a.RegisterRoute("/distributor/ha_tracker", d.HATracker, false, "GET")
+	a.RegisterRoute("/distributor/ha_tracker/elected_replicas", http.HandlerFunc(d.HATracker.ElectedReplicasHandler), false, "GET")
+	a.RegisterRoute("/distributor/prewarm_tenant", http.HandlerFunc(d.PrewarmTenantHandler), true, "POST")
+
+	// Administrative API of the push tokens, uses authentication to inform which tenant's push tokens to manage.
+	a.RegisterRoute("/distributor/push_tokens", http.HandlerFunc(d.PushTokensHandler), true, "GET")
+	if pushConfig.PushTokens.APIEnabled {
+		a.RegisterRoute("/distributor/push_tokens", http.HandlerFunc(d.CreatePushTokenHandler), true, "POST")
+		a.RegisterRoute("/distributor/push_tokens/{name}", http.HandlerFunc(d.RevokePushTokenHandler), true, "DELETE")
+	}
 
 	// Legacy Routes
-	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/push"), push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.wrapDistributorPush(d)), true, "POST")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/push"), d.PushTokenMiddleware(d.RequestRateLimitMiddleware(push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, a.cfg.wrapDistributorPush(d), a.pushMetrics))), true, "POST")
 	a.RegisterRoute("/all_user_stats", http.HandlerFunc(d.AllUserStatsHandler), false, "GET")
 	a.RegisterRoute("/ha-tracker", d.HATracker, false, "GET")
+	a.RegisterRoute("/ha-tracker/elected-replicas", http.HandlerFunc(d.HATracker.ElectedReplicasHandler), false, "GET")
 }
 
 // Ingester is defined as an interface to allow for alternative implementations
@@ -283,6 +305,7 @@ type Ingester interface {
 	client.IngesterServer
 	FlushHandler(http.ResponseWriter, *http.Request)
 	ShutdownHandler(http.ResponseWriter, *http.Request)
+	WALReplayHandler(http.ResponseWriter, *http.Request)
 	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
 }
 
@@ -294,12 +317,14 @@ func (a *API) RegisterIngester(i Ingester, pushConfig distributor.Config) {
 	a.indexPage.AddLink(SectionDangerous, "/ingester/shutdown", "Trigger Ingester Shutdown (Dangerous)")
 	a.RegisterRoute("/ingester/flush", http.HandlerFunc(i.FlushHandler), false, "GET", "POST")
 	a.RegisterRoute("/ingester/shutdown", http.HandlerFunc(i.ShutdownHandler), false, "GET", "POST")
-	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, i.Push), true, "POST") // For testing and debugging.
+	a.indexPage.AddLink(SectionAdminEndpoints, "/ingester/wal-replay", "Ingester WAL Replay Progress")
+	a.RegisterRoute("/ingester/wal-replay", http.HandlerFunc(i.WALReplayHandler), false, "GET")
+	a.RegisterRoute("/ingester/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, i.Push, a.pushMetrics), true, "POST") // For testing and debugging.
 
 	// Legacy Routes
 	a.RegisterRoute("/flush", http.HandlerFunc(i.FlushHandler), false, "GET", "POST")
 	a.RegisterRoute("/shutdown", http.HandlerFunc(i.ShutdownHandler), false, "GET", "POST")
-	a.RegisterRoute("/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, i.Push), true, "POST") // For testing and debugging.
+	a.RegisterRoute("/push", push.Handler(pushConfig.MaxRecvMsgSize, a.sourceIPs, i.Push, a.pushMetrics), true, "POST") // For testing and debugging.
 }
 
 func (a *API) RegisterTenantDeletion(api *purger.TenantDeletionAPI) {
@@ -307,6 +332,12 @@ func (a *API) RegisterTenantDeletion(api *purger.TenantDeletionAPI) {
 	a.RegisterRoute("/purger/delete_tenant_status", http.HandlerFunc(api.DeleteTenantStatus), true, "GET")
 }
 
+// RegisterBlocksExport registers the endpoints associated with the blocks export.
+func (a *API) RegisterBlocksExport(api *purger.BlocksExportAPI) {
+	a.RegisterRoute("/purger/export_blocks", http.HandlerFunc(api.ExportBlocks), true, "POST")
+	a.RegisterRoute("/purger/export_blocks_status", http.HandlerFunc(api.ExportBlocksStatus), true, "GET")
+}
+
 // RegisterRuler registers routes associated with the Ruler service.
 func (a *API) RegisterRuler(r *ruler.Ruler) {
 	a.indexPage.AddLink(SectionAdminEndpoints, "/ruler/ring", "Ruler Ring Status")
@@ -337,6 +368,11 @@ func (a *API) RegisterRulerAPI(r *ruler.API) {
 	a.RegisterRoute("/api/v1/rules/{namespace}", http.HandlerFunc(r.CreateRuleGroup), true, "POST")
 	a.RegisterRoute("/api/v1/rules/{namespace}/{groupName}", http.HandlerFunc(r.DeleteRuleGroup), true, "DELETE")
 	a.RegisterRoute("/api/v1/rules/{namespace}", http.HandlerFunc(r.DeleteNamespace), true, "DELETE")
+	a.RegisterRoute("/api/v1/rules/{namespace}/pause", http.HandlerFunc(r.PauseRuleGroup), true, "POST")
+	a.RegisterRoute("/api/v1/rules/{namespace}/resume", http.HandlerFunc(r.ResumeRuleGroup), true, "POST")
+	a.RegisterRoute("/api/v1/rules/{namespace}/{groupName}/pause", http.HandlerFunc(r.PauseRuleGroup), true, "POST")
+	a.RegisterRoute("/api/v1/rules/{namespace}/{groupName}/resume", http.HandlerFunc(r.ResumeRuleGroup), true, "POST")
+	a.RegisterRoute("/ruler/openapi.yaml", http.HandlerFunc(r.OpenAPISpec), false, "GET")
 
 	// Legacy Prometheus Rule API Routes
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/rules"), http.HandlerFunc(r.PrometheusRules), true, "GET")
@@ -349,6 +385,15 @@ func (a *API) RegisterRulerAPI(r *ruler.API) {
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}"), http.HandlerFunc(r.CreateRuleGroup), true, "POST")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}/{groupName}"), http.HandlerFunc(r.DeleteRuleGroup), true, "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}"), http.HandlerFunc(r.DeleteNamespace), true, "DELETE")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}/pause"), http.HandlerFunc(r.PauseRuleGroup), true, "POST")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}/resume"), http.HandlerFunc(r.ResumeRuleGroup), true, "POST")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}/{groupName}/pause"), http.HandlerFunc(r.PauseRuleGroup), true, "POST")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/rules/{namespace}/{groupName}/resume"), http.HandlerFunc(r.ResumeRuleGroup), true, "POST")
+}
+
+// RegisterRulerBacktestAPI registers the endpoint backtesting the candidate alerting rule expressions.
+func (a *API) RegisterRulerBacktestAPI(b *ruler.Backtester) {
+	a.RegisterRoute("/ruler/backtest", http.HandlerFunc(b.BacktestHandler), true, "GET", "POST")
 }
 
 // RegisterRing registers the ring UI page associated with the distributor for writes.
@@ -372,6 +417,9 @@ func (a *API) RegisterStoreGateway(s *storegateway.StoreGateway) {
 func (a *API) RegisterCompactor(c *compactor.Compactor) {
 	a.indexPage.AddLink(SectionAdminEndpoints, "/compactor/ring", "Compactor Ring Status")
 	a.RegisterRoute("/compactor/ring", http.HandlerFunc(c.RingHandler), false, "GET", "POST")
+	a.RegisterRoute("/compactor/jobs", http.HandlerFunc(c.CompactionJobsHandler), false, "GET")
+	a.RegisterRoute("/compactor/jobs/approve", http.HandlerFunc(c.ApproveCompactionJobHandler), false, "POST")
+	a.RegisterRoute("/compactor/planning_report", http.HandlerFunc(c.PlanningReportHandler), false, "GET")
 }
 
 type Distributor interface {
@@ -406,6 +454,7 @@ func (a *API) RegisterQueryAPI(handler http.Handler) {
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/label/{name}/values"), hf, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/series"), hf, true, "GET", "POST", "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/metadata"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.PrometheusHTTPPrefix, "/api/v1/status/tsdb"), hf, true, "GET")
 
 	// Register Legacy Routers
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/read"), hf, true, "POST")
@@ -416,6 +465,7 @@ func (a *API) RegisterQueryAPI(handler http.Handler) {
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/label/{name}/values"), hf, true, "GET")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/series"), hf, true, "GET", "POST", "DELETE")
 	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/metadata"), hf, true, "GET")
+	a.RegisterRoute(path.Join(a.cfg.LegacyHTTPPrefix, "/api/v1/status/tsdb"), hf, true, "GET")
 
 	if a.cfg.buildInfoEnabled {
 		infoHandler := &buildInfoHandler{logger: a.logger}
@@ -431,6 +481,16 @@ func (a *API) RegisterQueryFrontendHandler(h http.Handler) {
 	a.RegisterQueryAPI(h)
 }
 
+// RegisterQueryFrontendUserQueryStats registers the endpoint returning the aggregated stats of the queries of a tenant.
+func (a *API) RegisterQueryFrontendUserQueryStats(h *transport.Handler) {
+	a.RegisterRoute("/api/v1/user_query_stats", http.HandlerFunc(h.UserQueryStatsHandler), true, "GET")
+}
+
+// RegisterQueryFrontendLabelsCache registers the endpoint invalidating the labels cache of a tenant.
+func (a *API) RegisterQueryFrontendLabelsCache(c *tripperware.LabelsCache) {
+	a.RegisterRoute("/frontend/labels_cache/invalidate", http.HandlerFunc(c.InvalidateHandler), true, "POST")
+}
+
 func (a *API) RegisterQueryFrontend1(f *frontendv1.Frontend) {
 	frontendv1pb.RegisterFrontendServer(a.server.GRPC, f)
 }
@@ -34,7 +34,7 @@ func TestNewApiWithoutSourceIPExtractor(t *testing.T) {
 	server, err := server.New(serverCfg)
 	require.NoError(t, err)
 
-	api, err := New(cfg, serverCfg, server, &FakeLogger{})
+	api, err := New(cfg, serverCfg, server, &FakeLogger{}, nil)
 	require.NoError(t, err)
 	require.Nil(t, api.sourceIPs)
 }
@@ -49,7 +49,7 @@ func TestNewApiWithSourceIPExtractor(t *testing.T) {
 	server, err := server.New(serverCfg)
 	require.NoError(t, err)
 
-	api, err := New(cfg, serverCfg, server, &FakeLogger{})
+	api, err := New(cfg, serverCfg, server, &FakeLogger{}, nil)
 	require.NoError(t, err)
 	require.NotNil(t, api.sourceIPs)
 }
@@ -67,7 +67,7 @@ func TestNewApiWithInvalidSourceIPExtractor(t *testing.T) {
 		MetricsNamespace:   "with_invalid_source_ip_extractor",
 	}
 
-	api, err := New(cfg, serverCfg, &s, &FakeLogger{})
+	api, err := New(cfg, serverCfg, &s, &FakeLogger{}, nil)
 	require.Error(t, err)
 	require.Nil(t, api)
 }
@@ -83,7 +83,7 @@ func TestNewApiWithHeaderLogging(t *testing.T) {
 	server, err := server.New(serverCfg)
 	require.NoError(t, err)
 
-	api, err := New(cfg, serverCfg, server, &FakeLogger{})
+	api, err := New(cfg, serverCfg, server, &FakeLogger{}, nil)
 	require.NoError(t, err)
 	require.NotNil(t, api.HTTPHeaderMiddleware)
 
@@ -100,7 +100,7 @@ func TestNewApiWithoutHeaderLogging(t *testing.T) {
 	server, err := server.New(serverCfg)
 	require.NoError(t, err)
 
-	api, err := New(cfg, serverCfg, server, &FakeLogger{})
+	api, err := New(cfg, serverCfg, server, &FakeLogger{}, nil)
 	require.NoError(t, err)
 	require.Nil(t, api.HTTPHeaderMiddleware)
 
@@ -157,7 +157,7 @@ func Benchmark_Compression(b *testing.B) {
 
 			server, err := server.New(serverCfg)
 			require.NoError(b, err)
-			api, err := New(cfg, serverCfg, server, &FakeLogger{})
+			api, err := New(cfg, serverCfg, server, &FakeLogger{}, nil)
 			require.NoError(b, err)
 
 			labels := labels.ScratchBuilder{}
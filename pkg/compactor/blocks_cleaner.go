@@ -35,6 +35,8 @@ type BlocksCleanerConfig struct {
 	CleanupConcurrency                 int
 	BlockDeletionMarksMigrationEnabled bool          // TODO Discuss whether we should remove it in Cortex 1.8.0 and document that upgrading to 1.7.0 before 1.8.0 is required.
 	TenantCleanupDelay                 time.Duration // Delay before removing tenant deletion mark and "debug".
+	BlockSeriesHintsMaxSeries          uint64        // Max series of the blocks whose series hints are stored in the bucket index.
+	TenantActivityEnabled              bool          // Whether to record the bucket index fingerprint in the tenant activity marker.
 }
 
 type BlocksCleaner struct {
@@ -62,6 +64,8 @@ type BlocksCleaner struct {
 	tenantBlocksMarkedForNoCompaction *prometheus.GaugeVec
 	tenantPartialBlocks               *prometheus.GaugeVec
 	tenantBucketIndexLastUpdate       *prometheus.GaugeVec
+	tenantHistogramSeries             *prometheus.GaugeVec
+	tenantHistogramChunksBytes        *prometheus.GaugeVec
 }
 
 func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.InstrumentedBucket, usersScanner *cortex_tsdb.UsersScanner, cfgProvider ConfigProvider, logger log.Logger, reg prometheus.Registerer) *BlocksCleaner {
@@ -124,6 +128,14 @@ func NewBlocksCleaner(cfg BlocksCleanerConfig, bucketClient objstore.Instrumente
 			Name: "cortex_bucket_index_last_successful_update_timestamp_seconds",
 			Help: "Timestamp of the last successful update of a tenant's bucket index.",
 		}, []string{"user"}),
+		tenantHistogramSeries: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_native_histogram_series",
+			Help: "Total number of series with native histograms, summed across the blocks in the bucket not marked for deletion. Only includes the blocks compacted by the compactor.",
+		}, []string{"user"}),
+		tenantHistogramChunksBytes: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_bucket_native_histogram_chunks_bytes",
+			Help: "Total size in bytes of the native histogram chunks in the blocks in the bucket not marked for deletion. Only includes the blocks compacted by the compactor.",
+		}, []string{"user"}),
 	}
 
 	c.Service = services.NewTimerService(cfg.CleanupInterval, c.starting, c.ticker, nil)
@@ -182,6 +194,8 @@ func (c *BlocksCleaner) cleanUsers(ctx context.Context, firstRun bool) error {
 			c.tenantBlocksMarkedForNoCompaction.DeleteLabelValues(userID)
 			c.tenantPartialBlocks.DeleteLabelValues(userID)
 			c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
+			c.tenantHistogramSeries.DeleteLabelValues(userID)
+			c.tenantHistogramChunksBytes.DeleteLabelValues(userID)
 		}
 	}
 	c.lastOwnedUsers = allUsers
@@ -213,6 +227,11 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	}
 	c.tenantBucketIndexLastUpdate.DeleteLabelValues(userID)
 
+	// Delete the marker of the last compaction, so that a tenant re-created later is compacted.
+	if err := deleteTenantActivity(ctx, c.bucketClient, userID); err != nil {
+		return err
+	}
+
 	var deletedBlocks, failed int
 	err := userBucket.Iter(ctx, "", func(name string) error {
 		if err := ctx.Err(); err != nil {
@@ -258,6 +277,8 @@ func (c *BlocksCleaner) deleteUserMarkedForDeletion(ctx context.Context, userID
 	c.tenantBlocksMarkedForDelete.DeleteLabelValues(userID)
 	c.tenantBlocksMarkedForNoCompaction.DeleteLabelValues(userID)
 	c.tenantPartialBlocks.DeleteLabelValues(userID)
+	c.tenantHistogramSeries.DeleteLabelValues(userID)
+	c.tenantHistogramChunksBytes.DeleteLabelValues(userID)
 
 	if deletedBlocks > 0 {
 		level.Info(userLogger).Log("msg", "deleted blocks for tenant marked for deletion", "deletedBlocks", deletedBlocks)
@@ -378,7 +399,7 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, firstRun b
 	}
 
 	// Generate an updated in-memory version of the bucket index.
-	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.logger)
+	w := bucketindex.NewUpdater(c.bucketClient, userID, c.cfgProvider, c.logger).WithSeriesHints(c.cfg.BlockSeriesHintsMaxSeries)
 	idx, partials, totalBlocksBlocksMarkedForNoCompaction, err := w.UpdateIndex(ctx, idx)
 	if err != nil {
 		idxs.Status = bucketindex.GenericError
@@ -432,9 +453,62 @@ func (c *BlocksCleaner) cleanUser(ctx context.Context, userID string, firstRun b
 	c.tenantBlocksMarkedForNoCompaction.WithLabelValues(userID).Set(float64(totalBlocksBlocksMarkedForNoCompaction))
 	c.tenantBucketIndexLastUpdate.WithLabelValues(userID).SetToCurrentTime()
 	c.tenantPartialBlocks.WithLabelValues(userID).Set(float64(len(partials)))
+	c.updateHistogramMetrics(userID, idx)
+	if c.cfg.TenantActivityEnabled {
+		c.updateTenantActivity(ctx, userID, startTime, idx, userLogger)
+	}
 	return nil
 }
 
+// updateTenantActivity records the fingerprint of the bucket index in the tenant activity marker
+// written by the last compaction, if the compaction completed before this cleaner pass started,
+// so that the bucket index includes the blocks produced by the compaction. This is a best-effort.
+func (c *BlocksCleaner) updateTenantActivity(ctx context.Context, userID string, startTime time.Time, idx *bucketindex.Index, userLogger log.Logger) {
+	activity, err := readTenantActivity(ctx, c.bucketClient, userID, userLogger)
+	if err != nil {
+		level.Warn(userLogger).Log("msg", "unable to read the tenant activity marker", "err", err)
+		return
+	}
+	if activity == nil || !activity.indexedBy(startTime) {
+		return
+	}
+
+	// The cleaner pass may take a while, so the ownership is checked right before writing the
+	// marker, which must not be written by a cleaner not owning the user anymore.
+	if owned, err := c.usersScanner.IsOwned(userID); err != nil || !owned {
+		level.Debug(userLogger).Log("msg", "not recording the tenant activity fingerprint because the user is not owned anymore", "err", err)
+		return
+	}
+
+	activity.Fingerprint = indexFingerprint(idx)
+	activity.IndexedAt = startTime.Unix()
+	if err := writeTenantActivity(ctx, c.bucketClient, userID, activity); err != nil {
+		level.Warn(userLogger).Log("msg", "failed to write the tenant activity marker", "err", err)
+	}
+}
+
+// updateHistogramMetrics updates the native histogram statistics of the tenant from the bucket
+// index. The blocks marked for deletion are excluded, given they've been compacted into other blocks.
+func (c *BlocksCleaner) updateHistogramMetrics(userID string, idx *bucketindex.Index) {
+	deleted := idx.BlockDeletionMarks.GetULIDs()
+	isDeleted := make(map[ulid.ULID]struct{}, len(deleted))
+	for _, id := range deleted {
+		isDeleted[id] = struct{}{}
+	}
+
+	var series, chunksBytes uint64
+	for _, b := range idx.Blocks {
+		if _, ok := isDeleted[b.ID]; ok {
+			continue
+		}
+		series += b.HistogramSeries
+		chunksBytes += b.HistogramChunksBytes
+	}
+
+	c.tenantHistogramSeries.WithLabelValues(userID).Set(float64(series))
+	c.tenantHistogramChunksBytes.WithLabelValues(userID).Set(float64(chunksBytes))
+}
+
 // cleanUserPartialBlocks delete partial blocks which are safe to be deleted. The provided partials map
 // and index are updated accordingly.
 func (c *BlocksCleaner) cleanUserPartialBlocks(ctx context.Context, partials map[ulid.ULID]error, idx *bucketindex.Index, userBucket objstore.InstrumentedBucket, userLogger log.Logger) {
@@ -0,0 +1,344 @@
+package compactor
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// maxCompactionHistory is the number of the last compactions run by this compactor which the
+// estimates are based on.
+const maxCompactionHistory = 100
+
+// compactionEstimate is the estimated cost of a compaction job planned by the compactor.
+type compactionEstimate struct {
+	ID                       string    `json:"id"`
+	User                     string    `json:"user"`
+	Blocks                   []string  `json:"blocks"`
+	MinTime                  int64     `json:"min_time"`
+	MaxTime                  int64     `json:"max_time"`
+	Resolution               int64     `json:"resolution"`
+	PlannedAt                time.Time `json:"planned_at"`
+	InputBytes               int64     `json:"input_bytes"`
+	EstimatedOutputBytes     int64     `json:"estimated_output_bytes"`
+	EstimatedDurationSeconds float64   `json:"estimated_duration_seconds"`
+}
+
+// compactionHistory sums up the last compactions run by this compactor.
+type compactionHistory struct {
+	Compactions     int     `json:"compactions"`
+	InputBytes      int64   `json:"input_bytes"`
+	OutputBytes     int64   `json:"output_bytes"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+type compactionRecord struct {
+	inputBytes  int64
+	outputBytes int64
+	duration    time.Duration
+}
+
+// compactionEstimator estimates the cost of the compaction jobs when they're planned, based on
+// the size of their blocks and the ratios between the input size, the output size and the
+// duration of the last compactions run by this compactor. An estimate is kept until one of its
+// blocks is compacted, or the jobs of the tenant are planned again.
+type compactionEstimator struct {
+	now func() time.Time
+
+	mtx       sync.Mutex
+	estimates map[string]*compactionEstimate
+	history   []compactionRecord
+	next      int
+
+	plannedInputBytes         prometheus.Gauge
+	plannedOutputBytes        prometheus.Gauge
+	plannedDurationSeconds    prometheus.Gauge
+	compactedInputBytes       prometheus.Counter
+	compactedOutputBytes      prometheus.Counter
+	compactionDurationSeconds prometheus.Counter
+}
+
+func newCompactionEstimator(reg prometheus.Registerer) *compactionEstimator {
+	return &compactionEstimator{
+		now:       time.Now,
+		estimates: map[string]*compactionEstimate{},
+
+		plannedInputBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_compactor_planned_compactions_input_bytes",
+			Help: "Total size of the blocks of the compaction jobs planned by this compactor and not compacted yet.",
+		}),
+		plannedOutputBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_compactor_planned_compactions_estimated_output_bytes",
+			Help: "Estimated total size of the blocks output by the compaction jobs planned by this compactor and not compacted yet.",
+		}),
+		plannedDurationSeconds: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_compactor_planned_compactions_estimated_duration_seconds",
+			Help: "Estimated total duration of the compaction jobs planned by this compactor and not compacted yet.",
+		}),
+		compactedInputBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_compacted_input_bytes_total",
+			Help: "Total size of the blocks compacted by this compactor.",
+		}),
+		compactedOutputBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_compacted_output_bytes_total",
+			Help: "Total size of the blocks output by the compactions run by this compactor.",
+		}),
+		compactionDurationSeconds: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_compaction_duration_seconds_total",
+			Help: "Total time spent compacting blocks by this compactor, excluding the download and upload of the blocks.",
+		}),
+	}
+}
+
+// replan replaces the estimates of the user with the ones of the compaction of the input groups
+// of blocks. The size of the blocks is read from their meta.json, so blocks without files listed
+// are accounted as empty.
+func (e *compactionEstimator) replan(userID string, groups [][]*metadata.Meta) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	for id, estimate := range e.estimates {
+		if estimate.User == userID {
+			delete(e.estimates, id)
+		}
+	}
+
+	now := e.now()
+	h := e.historyLocked()
+	for _, metas := range groups {
+		if len(metas) == 0 {
+			continue
+		}
+		estimate := newCompactionEstimate(userID, metas, h)
+		estimate.PlannedAt = now
+		e.estimates[estimate.ID] = estimate
+	}
+	e.updateMetricsLocked()
+}
+
+func newCompactionEstimate(userID string, metas []*metadata.Meta, h compactionHistory) *compactionEstimate {
+	id, blocks := compactionJobID(userID, metas)
+
+	estimate := &compactionEstimate{
+		ID:         id,
+		User:       userID,
+		Blocks:     blocks,
+		MinTime:    metas[0].MinTime,
+		MaxTime:    metas[0].MaxTime,
+		Resolution: metas[0].Thanos.Downsample.Resolution,
+	}
+	for _, m := range metas {
+		estimate.MinTime = min(estimate.MinTime, m.MinTime)
+		estimate.MaxTime = max(estimate.MaxTime, m.MaxTime)
+		for _, f := range m.Thanos.Files {
+			estimate.InputBytes += f.SizeBytes
+		}
+	}
+
+	// Without history, the blocks are assumed not to shrink and the duration is unknown.
+	estimate.EstimatedOutputBytes = estimate.InputBytes
+	if h.InputBytes > 0 {
+		ratio := float64(estimate.InputBytes) / float64(h.InputBytes)
+		estimate.EstimatedOutputBytes = int64(ratio * float64(h.OutputBytes))
+		estimate.EstimatedDurationSeconds = ratio * h.DurationSeconds
+	}
+	return estimate
+}
+
+// compacted records the compaction of the input blocks, and removes the estimates of the jobs
+// compacting any of them, because they're not planned anymore.
+func (e *compactionEstimator) compacted(blocks []string, inputBytes, outputBytes int64, duration time.Duration) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	for id, estimate := range e.estimates {
+		if slices.ContainsFunc(estimate.Blocks, func(b string) bool { return slices.Contains(blocks, b) }) {
+			delete(e.estimates, id)
+		}
+	}
+
+	record := compactionRecord{inputBytes: inputBytes, outputBytes: outputBytes, duration: duration}
+	if len(e.history) < maxCompactionHistory {
+		e.history = append(e.history, record)
+	} else {
+		e.history[e.next] = record
+		e.next = (e.next + 1) % maxCompactionHistory
+	}
+
+	e.compactedInputBytes.Add(float64(inputBytes))
+	e.compactedOutputBytes.Add(float64(outputBytes))
+	e.compactionDurationSeconds.Add(duration.Seconds())
+	e.updateMetricsLocked()
+}
+
+// report returns the estimates sorted by user and time range, and the history they're based on.
+func (e *compactionEstimator) report() ([]compactionEstimate, compactionHistory) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	estimates := make([]compactionEstimate, 0, len(e.estimates))
+	for _, estimate := range e.estimates {
+		estimates = append(estimates, *estimate)
+	}
+
+	sort.Slice(estimates, func(i, j int) bool {
+		if estimates[i].User != estimates[j].User {
+			return estimates[i].User < estimates[j].User
+		}
+		if estimates[i].MinTime != estimates[j].MinTime {
+			return estimates[i].MinTime < estimates[j].MinTime
+		}
+		return estimates[i].ID < estimates[j].ID
+	})
+	return estimates, e.historyLocked()
+}
+
+func (e *compactionEstimator) historyLocked() compactionHistory {
+	h := compactionHistory{Compactions: len(e.history)}
+	for _, r := range e.history {
+		h.InputBytes += r.inputBytes
+		h.OutputBytes += r.outputBytes
+		h.DurationSeconds += r.duration.Seconds()
+	}
+	return h
+}
+
+func (e *compactionEstimator) updateMetricsLocked() {
+	var inputBytes, outputBytes int64
+	var durationSeconds float64
+	for _, estimate := range e.estimates {
+		inputBytes += estimate.InputBytes
+		outputBytes += estimate.EstimatedOutputBytes
+		durationSeconds += estimate.EstimatedDurationSeconds
+	}
+	e.plannedInputBytes.Set(float64(inputBytes))
+	e.plannedOutputBytes.Set(float64(outputBytes))
+	e.plannedDurationSeconds.Set(durationSeconds)
+}
+
+// plannedGroupsGrouper is implemented by the groupers which return only the groups they have the
+// capacity to compact, to report all the groups they have planned.
+type plannedGroupsGrouper interface {
+	PlannedGroups() [][]*metadata.Meta
+}
+
+// estimationGrouper wraps a grouper to estimate the cost of all the compaction jobs planned
+// each time the blocks are grouped, before compacting them. The groupers not reporting their
+// planned groups are estimated from the next job planned in each group by the planner, which
+// must have no side effect since it doesn't plan the actual compaction.
+type estimationGrouper struct {
+	compact.Grouper
+
+	ctx       context.Context
+	planner   compact.Planner
+	estimator *compactionEstimator
+	userID    string
+}
+
+func (g *estimationGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) ([]*compact.Group, error) {
+	groups, err := g.Grouper.Groups(blocks)
+	if err != nil {
+		return nil, err
+	}
+
+	if pg, ok := g.Grouper.(plannedGroupsGrouper); ok {
+		g.estimator.replan(g.userID, pg.PlannedGroups())
+		return groups, nil
+	}
+
+	var planned [][]*metadata.Meta
+	for _, group := range groups {
+		ids := group.IDs()
+		metas := make([]*metadata.Meta, 0, len(ids))
+		for _, id := range ids {
+			if m, ok := blocks[id]; ok {
+				metas = append(metas, m)
+			}
+		}
+		sort.Slice(metas, func(i, j int) bool { return metas[i].MinTime < metas[j].MinTime })
+
+		// The planning errors are reported by the compaction of the group.
+		if toCompact, err := g.planner.Plan(g.ctx, metas, nil, nil); err == nil && len(toCompact) > 0 {
+			planned = append(planned, toCompact)
+		}
+	}
+	g.estimator.replan(g.userID, planned)
+	return groups, nil
+}
+
+// estimationCompactor wraps a compactor to record the size and duration of the compactions.
+type estimationCompactor struct {
+	compact.Compactor
+
+	estimator *compactionEstimator
+}
+
+func (c *estimationCompactor) Compact(dest string, dirs []string, open []*tsdb.Block) ([]ulid.ULID, error) {
+	start := time.Now()
+	ids, err := c.Compactor.Compact(dest, dirs, open)
+	if err == nil {
+		c.record(dest, dirs, ids, time.Since(start))
+	}
+	return ids, err
+}
+
+func (c *estimationCompactor) CompactWithBlockPopulator(dest string, dirs []string, open []*tsdb.Block, blockPopulator tsdb.BlockPopulator) ([]ulid.ULID, error) {
+	start := time.Now()
+	ids, err := c.Compactor.CompactWithBlockPopulator(dest, dirs, open, blockPopulator)
+	if err == nil {
+		c.record(dest, dirs, ids, time.Since(start))
+	}
+	return ids, err
+}
+
+func (c *estimationCompactor) record(dest string, dirs []string, ids []ulid.ULID, duration time.Duration) {
+	blocks := make([]string, 0, len(dirs))
+	var inputBytes, outputBytes int64
+	for _, dir := range dirs {
+		blocks = append(blocks, filepath.Base(dir))
+		inputBytes += dirSize(dir)
+	}
+	for _, id := range ids {
+		outputBytes += dirSize(filepath.Join(dest, id.String()))
+	}
+	c.estimator.compacted(blocks, inputBytes, outputBytes, duration)
+}
+
+// dirSize returns the size of the files in the directory, ignoring the errors.
+func dirSize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// PlanningReportHandler returns the estimated cost of the compaction jobs planned by this
+// compactor, and the history of compactions the estimates are based on.
+func (c *Compactor) PlanningReportHandler(w http.ResponseWriter, _ *http.Request) {
+	estimates, history := c.compactionEstimator.report()
+	util.WriteJSONResponse(w, struct {
+		Jobs    []compactionEstimate `json:"jobs"`
+		History compactionHistory    `json:"history"`
+	}{Jobs: estimates, History: history})
+}
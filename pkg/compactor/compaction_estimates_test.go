@@ -0,0 +1,188 @@
+package compactor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+)
+
+func TestCompactionEstimator(t *testing.T) {
+	newMeta := func(id uint64, minTime, maxTime, size int64) *metadata.Meta {
+		return &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(id, nil), MinTime: minTime, MaxTime: maxTime},
+			Thanos:    metadata.Thanos{Files: []metadata.File{{RelPath: "index", SizeBytes: size / 2}, {RelPath: "chunks/000001", SizeBytes: size / 2}}},
+		}
+	}
+	first := []*metadata.Meta{newMeta(1, 0, 10, 100), newMeta(2, 10, 20, 300)}
+	second := []*metadata.Meta{newMeta(3, 20, 30, 1000)}
+
+	now := time.Unix(1000, 0)
+	reg := prometheus.NewPedanticRegistry()
+	estimator := newCompactionEstimator(reg)
+	estimator.now = func() time.Time { return now }
+	grouper := &plannedGroupsGrouperMock{groups: [][]*metadata.Meta{first, second}}
+	estimationGrouper := &estimationGrouper{Grouper: grouper, estimator: estimator, userID: "user-1"}
+
+	// All the planned groups are estimated, without history the output is estimated to be the
+	// size of the input.
+	_, err := estimationGrouper.Groups(nil)
+	require.NoError(t, err)
+
+	estimates, history := estimator.report()
+	require.Len(t, estimates, 2)
+	assert.Equal(t, "user-1", estimates[0].User)
+	assert.Equal(t, []string{first[0].ULID.String(), first[1].ULID.String()}, estimates[0].Blocks)
+	assert.Equal(t, int64(0), estimates[0].MinTime)
+	assert.Equal(t, int64(20), estimates[0].MaxTime)
+	assert.Equal(t, int64(400), estimates[0].InputBytes)
+	assert.Equal(t, int64(400), estimates[0].EstimatedOutputBytes)
+	assert.Equal(t, float64(0), estimates[0].EstimatedDurationSeconds)
+	assert.Equal(t, []string{second[0].ULID.String()}, estimates[1].Blocks)
+	assert.Equal(t, compactionHistory{}, history)
+
+	// The compaction of some blocks of a group removes its estimate and is recorded in the history.
+	estimator.compacted([]string{first[1].ULID.String()}, 400, 200, 4*time.Second)
+	estimates, history = estimator.report()
+	require.Len(t, estimates, 1)
+	assert.Equal(t, []string{second[0].ULID.String()}, estimates[0].Blocks)
+	assert.Equal(t, compactionHistory{Compactions: 1, InputBytes: 400, OutputBytes: 200, DurationSeconds: 4}, history)
+
+	// The next plan replaces the estimates, based on the history.
+	grouper.groups = [][]*metadata.Meta{second}
+	_, err = estimationGrouper.Groups(nil)
+	require.NoError(t, err)
+	estimates, _ = estimator.report()
+	require.Len(t, estimates, 1)
+	assert.Equal(t, int64(1000), estimates[0].InputBytes)
+	assert.Equal(t, int64(500), estimates[0].EstimatedOutputBytes)
+	assert.Equal(t, float64(10), estimates[0].EstimatedDurationSeconds)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_compactor_planned_compactions_input_bytes Total size of the blocks of the compaction jobs planned by this compactor and not compacted yet.
+		# TYPE cortex_compactor_planned_compactions_input_bytes gauge
+		cortex_compactor_planned_compactions_input_bytes 1000
+		# HELP cortex_compactor_planned_compactions_estimated_output_bytes Estimated total size of the blocks output by the compaction jobs planned by this compactor and not compacted yet.
+		# TYPE cortex_compactor_planned_compactions_estimated_output_bytes gauge
+		cortex_compactor_planned_compactions_estimated_output_bytes 500
+		# HELP cortex_compactor_planned_compactions_estimated_duration_seconds Estimated total duration of the compaction jobs planned by this compactor and not compacted yet.
+		# TYPE cortex_compactor_planned_compactions_estimated_duration_seconds gauge
+		cortex_compactor_planned_compactions_estimated_duration_seconds 10
+		# HELP cortex_compactor_compacted_input_bytes_total Total size of the blocks compacted by this compactor.
+		# TYPE cortex_compactor_compacted_input_bytes_total counter
+		cortex_compactor_compacted_input_bytes_total 400
+		# HELP cortex_compactor_compacted_output_bytes_total Total size of the blocks output by the compactions run by this compactor.
+		# TYPE cortex_compactor_compacted_output_bytes_total counter
+		cortex_compactor_compacted_output_bytes_total 200
+	`),
+		"cortex_compactor_planned_compactions_input_bytes",
+		"cortex_compactor_planned_compactions_estimated_output_bytes",
+		"cortex_compactor_planned_compactions_estimated_duration_seconds",
+		"cortex_compactor_compacted_input_bytes_total",
+		"cortex_compactor_compacted_output_bytes_total",
+	))
+
+	// The estimates not planned anymore are removed by the next plan.
+	grouper.groups = nil
+	_, err = estimationGrouper.Groups(nil)
+	require.NoError(t, err)
+	estimates, _ = estimator.report()
+	assert.Empty(t, estimates)
+}
+
+func TestEstimationGrouper_ShouldEstimateTheNextJobPlannedInEachGroup(t *testing.T) {
+	blocks := map[ulid.ULID]*metadata.Meta{}
+	group, err := compact.NewGroup(log.NewNopLogger(), nil, "group-1", labels.EmptyLabels(), 0, false, false, nil, nil, nil, nil, nil, nil, nil, nil, metadata.NoneFunc, 1, 1)
+	require.NoError(t, err)
+	for i := 1; i <= 2; i++ {
+		m := &metadata.Meta{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(uint64(i), nil), MinTime: int64(i * 10), MaxTime: int64(i*10 + 10)}}
+		blocks[m.ULID] = m
+		require.NoError(t, group.AppendMeta(m))
+	}
+
+	estimator := newCompactionEstimator(nil)
+	estimationGrouper := &estimationGrouper{
+		Grouper:   &groupsGrouperMock{groups: []*compact.Group{group}},
+		ctx:       context.Background(),
+		planner:   passthroughPlanner{},
+		estimator: estimator,
+		userID:    "user-1",
+	}
+
+	groups, err := estimationGrouper.Groups(blocks)
+	require.NoError(t, err)
+	assert.Len(t, groups, 1)
+
+	estimates, _ := estimator.report()
+	require.Len(t, estimates, 1)
+	assert.Equal(t, []string{ulid.MustNew(1, nil).String(), ulid.MustNew(2, nil).String()}, estimates[0].Blocks)
+	assert.Equal(t, int64(10), estimates[0].MinTime)
+	assert.Equal(t, int64(30), estimates[0].MaxTime)
+}
+
+type groupsGrouperMock struct {
+	groups []*compact.Group
+}
+
+func (m *groupsGrouperMock) Groups(map[ulid.ULID]*metadata.Meta) ([]*compact.Group, error) {
+	return m.groups, nil
+}
+
+type plannedGroupsGrouperMock struct {
+	groups [][]*metadata.Meta
+}
+
+func (m *plannedGroupsGrouperMock) Groups(map[ulid.ULID]*metadata.Meta) ([]*compact.Group, error) {
+	return nil, nil
+}
+
+func (m *plannedGroupsGrouperMock) PlannedGroups() [][]*metadata.Meta {
+	return m.groups
+}
+
+type writingCompactorMock struct {
+	tsdbCompactorMock
+}
+
+func (m *writingCompactorMock) CompactWithBlockPopulator(dest string, _ []string, _ []*tsdb.Block, _ tsdb.BlockPopulator) ([]ulid.ULID, error) {
+	id := ulid.MustNew(10, nil)
+	if err := os.MkdirAll(filepath.Join(dest, id.String()), 0750); err != nil {
+		return nil, err
+	}
+	return []ulid.ULID{id}, os.WriteFile(filepath.Join(dest, id.String(), "index"), make([]byte, 30), 0600)
+}
+
+func TestEstimationCompactor(t *testing.T) {
+	dir := t.TempDir()
+	var dirs []string
+	for i, size := range []int{10, 20} {
+		blockDir := filepath.Join(dir, ulid.MustNew(uint64(i+1), nil).String())
+		require.NoError(t, os.MkdirAll(filepath.Join(blockDir, "chunks"), 0750))
+		require.NoError(t, os.WriteFile(filepath.Join(blockDir, "chunks", "000001"), make([]byte, size), 0600))
+		dirs = append(dirs, blockDir)
+	}
+
+	estimator := newCompactionEstimator(nil)
+	c := &estimationCompactor{Compactor: &writingCompactorMock{}, estimator: estimator}
+
+	_, err := c.CompactWithBlockPopulator(dir, dirs, nil, tsdb.DefaultBlockPopulator{})
+	require.NoError(t, err)
+
+	_, history := estimator.report()
+	assert.Equal(t, 1, history.Compactions)
+	assert.Equal(t, int64(30), history.InputBytes)
+	assert.Equal(t, int64(30), history.OutputBytes)
+}
@@ -0,0 +1,229 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+const (
+	// CompactionJobsApprovalAuto compacts the planned jobs straight away.
+	CompactionJobsApprovalAuto = "auto"
+
+	// CompactionJobsApprovalExternal only compacts the planned jobs approved through the API.
+	CompactionJobsApprovalExternal = "external"
+)
+
+var (
+	supportedCompactionJobsApprovalModes = []string{CompactionJobsApprovalAuto, CompactionJobsApprovalExternal}
+	errInvalidCompactionJobsApprovalMode = fmt.Errorf("invalid compaction jobs approval mode, supported values are: %s", strings.Join(supportedCompactionJobsApprovalModes, ", "))
+	errCompactionJobNotFound             = errors.New("compaction job not found")
+)
+
+// compactionJob is a compaction planned by the compactor, waiting for approval.
+type compactionJob struct {
+	ID         string    `json:"id"`
+	User       string    `json:"user"`
+	Blocks     []string  `json:"blocks"`
+	MinTime    int64     `json:"min_time"`
+	MaxTime    int64     `json:"max_time"`
+	Resolution int64     `json:"resolution"`
+	PlannedAt  time.Time `json:"planned_at"`
+	Approved   bool      `json:"approved"`
+	NotBefore  time.Time `json:"not_before,omitempty"`
+}
+
+// compactionJobsQueue holds the compaction jobs planned by this compactor when the
+// external approval mode is enabled. A job is identified by the blocks it compacts, so
+// the same job is found again when planned by the next compaction runs.
+//
+// The queue is best-effort: it is kept in memory and not shared across compactors, so
+// the jobs and their approvals are lost when the compactor restarts or when the tenant
+// is resharded to another compactor. The jobs are planned again by the next compaction
+// of the tenant and have to be approved again.
+type compactionJobsQueue struct {
+	now func() time.Time
+
+	mtx  sync.Mutex
+	jobs map[string]*compactionJob
+}
+
+func newCompactionJobsQueue() *compactionJobsQueue {
+	return &compactionJobsQueue{
+		now:  time.Now,
+		jobs: map[string]*compactionJob{},
+	}
+}
+
+// plan records the compaction of the input blocks as planned and returns whether it has
+// been approved and can run now. Jobs allowed to run are removed from the queue.
+func (q *compactionJobsQueue) plan(userID string, metas []*metadata.Meta) bool {
+	id, blocks := compactionJobID(userID, metas)
+
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	now := q.now()
+	job, ok := q.jobs[id]
+	if !ok {
+		job = &compactionJob{
+			ID:         id,
+			User:       userID,
+			Blocks:     blocks,
+			MinTime:    metas[0].MinTime,
+			MaxTime:    metas[0].MaxTime,
+			Resolution: metas[0].Thanos.Downsample.Resolution,
+		}
+		for _, m := range metas[1:] {
+			job.MinTime = min(job.MinTime, m.MinTime)
+			job.MaxTime = max(job.MaxTime, m.MaxTime)
+		}
+		q.jobs[id] = job
+	}
+	job.PlannedAt = now
+
+	if !job.Approved || now.Before(job.NotBefore) {
+		return false
+	}
+
+	delete(q.jobs, id)
+	return true
+}
+
+// compactionJobID returns the ID of the compaction of the input blocks of the user, and the
+// sorted IDs of the blocks.
+func compactionJobID(userID string, metas []*metadata.Meta) (string, []string) {
+	blocks := make([]string, 0, len(metas))
+	for _, m := range metas {
+		blocks = append(blocks, m.ULID.String())
+	}
+	sort.Strings(blocks)
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(userID))
+	for _, b := range blocks {
+		_, _ = hash.Write([]byte(b))
+	}
+	return fmt.Sprintf("%x", hash.Sum64()), blocks
+}
+
+// removeStale removes the jobs of the user not planned since the input time, because
+// their blocks have been compacted or changed in the meantime.
+func (q *compactionJobsQueue) removeStale(userID string, plannedBefore time.Time) {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	for id, job := range q.jobs {
+		if job.User == userID && job.PlannedAt.Before(plannedBefore) {
+			delete(q.jobs, id)
+		}
+	}
+}
+
+// hasJobs returns whether there are planned jobs of the user.
+func (q *compactionJobsQueue) hasJobs(userID string) bool {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	for _, job := range q.jobs {
+		if job.User == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// approve approves the job, which runs at the next compaction not before the input time.
+func (q *compactionJobsQueue) approve(id string, notBefore time.Time) error {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	job, ok := q.jobs[id]
+	if !ok {
+		return errCompactionJobNotFound
+	}
+
+	job.Approved = true
+	job.NotBefore = notBefore
+	return nil
+}
+
+// list returns the jobs sorted by user and time range.
+func (q *compactionJobsQueue) list() []compactionJob {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	jobs := make([]compactionJob, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		jobs = append(jobs, *job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].User != jobs[j].User {
+			return jobs[i].User < jobs[j].User
+		}
+		if jobs[i].MinTime != jobs[j].MinTime {
+			return jobs[i].MinTime < jobs[j].MinTime
+		}
+		return jobs[i].ID < jobs[j].ID
+	})
+	return jobs
+}
+
+// approvalPlanner wraps a planner to only compact the jobs approved in the queue.
+type approvalPlanner struct {
+	compact.Planner
+
+	queue  *compactionJobsQueue
+	userID string
+}
+
+func (p *approvalPlanner) Plan(ctx context.Context, metasByMinTime []*metadata.Meta, errChan chan error, extensions any) ([]*metadata.Meta, error) {
+	toCompact, err := p.Planner.Plan(ctx, metasByMinTime, errChan, extensions)
+	if err != nil || len(toCompact) == 0 {
+		return toCompact, err
+	}
+
+	if !p.queue.plan(p.userID, toCompact) {
+		return nil, nil
+	}
+	return toCompact, nil
+}
+
+// CompactionJobsHandler lists the compaction jobs waiting for approval.
+func (c *Compactor) CompactionJobsHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, struct {
+		Jobs []compactionJob `json:"jobs"`
+	}{Jobs: c.compactionJobs.list()})
+}
+
+// ApproveCompactionJobHandler approves a compaction job. The optional not_before parameter
+// (RFC3339) defers the job until the given time.
+func (c *Compactor) ApproveCompactionJobHandler(w http.ResponseWriter, req *http.Request) {
+	var notBefore time.Time
+	if v := req.FormValue("not_before"); v != "" {
+		var err error
+		if notBefore, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid not_before parameter: %s", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := c.compactionJobs.approve(req.FormValue("id"), notBefore); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,103 @@
+package compactor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+type passthroughPlanner struct{}
+
+func (passthroughPlanner) Plan(_ context.Context, metasByMinTime []*metadata.Meta, _ chan error, _ any) ([]*metadata.Meta, error) {
+	return metasByMinTime, nil
+}
+
+func TestApprovalPlanner(t *testing.T) {
+	metas := []*metadata.Meta{
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(1, nil), MinTime: 0, MaxTime: 10}},
+		{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(2, nil), MinTime: 10, MaxTime: 20}},
+	}
+
+	now := time.Unix(1000, 0)
+	queue := newCompactionJobsQueue()
+	queue.now = func() time.Time { return now }
+	planner := &approvalPlanner{Planner: passthroughPlanner{}, queue: queue, userID: "user-1"}
+
+	// The job is not compacted until approved.
+	toCompact, err := planner.Plan(context.Background(), metas, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, toCompact)
+
+	jobs := queue.list()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "user-1", jobs[0].User)
+	assert.Equal(t, []string{metas[0].ULID.String(), metas[1].ULID.String()}, jobs[0].Blocks)
+	assert.Equal(t, int64(0), jobs[0].MinTime)
+	assert.Equal(t, int64(20), jobs[0].MaxTime)
+	assert.False(t, jobs[0].Approved)
+
+	// The job is deferred.
+	require.NoError(t, queue.approve(jobs[0].ID, now.Add(time.Hour)))
+	toCompact, err = planner.Plan(context.Background(), metas, nil, nil)
+	require.NoError(t, err)
+	assert.Empty(t, toCompact)
+
+	// Once the deferral expired, the job is compacted and removed from the queue.
+	now = now.Add(time.Hour)
+	toCompact, err = planner.Plan(context.Background(), metas, nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, metas, toCompact)
+	assert.Empty(t, queue.list())
+
+	assert.Equal(t, errCompactionJobNotFound, queue.approve(jobs[0].ID, time.Time{}))
+}
+
+func TestCompactionJobsQueue_RemoveStale(t *testing.T) {
+	now := time.Unix(1000, 0)
+	queue := newCompactionJobsQueue()
+	queue.now = func() time.Time { return now }
+
+	queue.plan("user-1", []*metadata.Meta{{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(1, nil)}}})
+	queue.plan("user-2", []*metadata.Meta{{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(2, nil)}}})
+
+	queue.removeStale("user-1", now.Add(time.Second))
+
+	jobs := queue.list()
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "user-2", jobs[0].User)
+}
+
+func TestCompactor_ApproveCompactionJobHandler(t *testing.T) {
+	c := &Compactor{compactionJobs: newCompactionJobsQueue()}
+	c.compactionJobs.plan("user-1", []*metadata.Meta{{BlockMeta: tsdb.BlockMeta{ULID: ulid.MustNew(1, nil)}}})
+	id := c.compactionJobs.list()[0].ID
+
+	for _, tc := range []struct {
+		body       string
+		statusCode int
+	}{
+		{body: "id=unknown", statusCode: http.StatusNotFound},
+		{body: "id=" + id + "&not_before=tomorrow", statusCode: http.StatusBadRequest},
+		{body: "id=" + id + "&not_before=2030-01-01T00:00:00Z", statusCode: http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/compactor/jobs/approve", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		c.ApproveCompactionJobHandler(w, req)
+		assert.Equal(t, tc.statusCode, w.Code, tc.body)
+	}
+
+	jobs := c.compactionJobs.list()
+	require.Len(t, jobs, 1)
+	assert.True(t, jobs[0].Approved)
+	assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), jobs[0].NotBefore)
+}
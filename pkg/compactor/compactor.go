@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -33,6 +34,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/backoff"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/services"
@@ -55,7 +57,7 @@ var (
 	errInvalidShardingStrategy  = errors.New("invalid sharding strategy")
 	errInvalidTenantShardSize   = errors.New("invalid tenant shard size, the value must be greater than 0")
 
-	DefaultBlocksGrouperFactory = func(ctx context.Context, cfg Config, bkt objstore.InstrumentedBucket, logger log.Logger, reg prometheus.Registerer, blocksMarkedForDeletion, blocksMarkedForNoCompaction, garbageCollectedBlocks prometheus.Counter, _ prometheus.Gauge, _ prometheus.Counter, _ prometheus.Counter, _ *ring.Ring, _ *ring.Lifecycler, _ Limits, _ string, _ *compact.GatherNoCompactionMarkFilter) compact.Grouper {
+	DefaultBlocksGrouperFactory = func(ctx context.Context, cfg Config, bkt objstore.InstrumentedBucket, logger log.Logger, reg prometheus.Registerer, blocksMarkedForDeletion, blocksMarkedForNoCompaction, garbageCollectedBlocks prometheus.Counter, _ prometheus.Gauge, _ prometheus.Counter, _ prometheus.Counter, _ *ring.Ring, _ *ring.Lifecycler, _ Limits, _ string, _ *compact.GatherNoCompactionMarkFilter, _ *WorkStealingJobs) compact.Grouper {
 		return compact.NewDefaultGrouper(
 			logger,
 			bkt,
@@ -70,7 +72,7 @@ var (
 			cfg.BlocksFetchConcurrency)
 	}
 
-	ShuffleShardingGrouperFactory = func(ctx context.Context, cfg Config, bkt objstore.InstrumentedBucket, logger log.Logger, reg prometheus.Registerer, blocksMarkedForDeletion, blocksMarkedForNoCompaction, garbageCollectedBlocks prometheus.Counter, remainingPlannedCompactions prometheus.Gauge, blockVisitMarkerReadFailed prometheus.Counter, blockVisitMarkerWriteFailed prometheus.Counter, ring *ring.Ring, ringLifecycle *ring.Lifecycler, limits Limits, userID string, noCompactionMarkFilter *compact.GatherNoCompactionMarkFilter) compact.Grouper {
+	ShuffleShardingGrouperFactory = func(ctx context.Context, cfg Config, bkt objstore.InstrumentedBucket, logger log.Logger, reg prometheus.Registerer, blocksMarkedForDeletion, blocksMarkedForNoCompaction, garbageCollectedBlocks prometheus.Counter, remainingPlannedCompactions prometheus.Gauge, blockVisitMarkerReadFailed prometheus.Counter, blockVisitMarkerWriteFailed prometheus.Counter, ring *ring.Ring, ringLifecycle *ring.Lifecycler, limits Limits, userID string, noCompactionMarkFilter *compact.GatherNoCompactionMarkFilter, workStealing *WorkStealingJobs) compact.Grouper {
 		return NewShuffleShardingGrouper(
 			ctx,
 			logger,
@@ -95,7 +97,8 @@ var (
 			cfg.BlockVisitMarkerTimeout,
 			blockVisitMarkerReadFailed,
 			blockVisitMarkerWriteFailed,
-			noCompactionMarkFilter.NoCompactMarkedBlocks)
+			noCompactionMarkFilter.NoCompactMarkedBlocks,
+			workStealing)
 	}
 
 	DefaultBlocksCompactorFactory = func(ctx context.Context, cfg Config, logger log.Logger, reg prometheus.Registerer) (compact.Compactor, PlannerFactory, error) {
@@ -143,6 +146,7 @@ type BlocksGrouperFactory func(
 	limit Limits,
 	userID string,
 	noCompactionMarkFilter *compact.GatherNoCompactionMarkFilter,
+	workStealing *WorkStealingJobs,
 ) compact.Grouper
 
 // BlocksCompactorFactory builds and returns the compactor and planner to use to compact a tenant's blocks.
@@ -198,6 +202,9 @@ type Config struct {
 	ShardingStrategy string     `yaml:"sharding_strategy"`
 	ShardingRing     RingConfig `yaml:"sharding_ring"`
 
+	// Stealing of the compaction jobs between the compactors.
+	WorkStealing WorkStealingConfig `yaml:"work_stealing"`
+
 	// No need to add options to customize the retry backoff,
 	// given the defaults should be fine, but allow to override
 	// it in tests.
@@ -214,11 +221,22 @@ type Config struct {
 
 	AcceptMalformedIndex bool `yaml:"accept_malformed_index"`
 	CachingBucketEnabled bool `yaml:"caching_bucket_enabled"`
+
+	CompactionJobsApprovalMode string `yaml:"compaction_jobs_approval_mode"`
+
+	BlockSeriesHintsMaxSeries uint64 `yaml:"block_series_hints_max_series"`
+
+	SkipUnchangedTenantsMaxAge time.Duration `yaml:"skip_unchanged_tenants_max_age"`
+
+	NativeHistogramsValidationEnabled bool `yaml:"native_histograms_validation_enabled"`
+
+	ResumableBlockUploadsEnabled bool `yaml:"resumable_block_uploads_enabled"`
 }
 
 // RegisterFlags registers the Compactor flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.ShardingRing.RegisterFlags(f)
+	cfg.WorkStealing.RegisterFlags(f)
 
 	cfg.BlockRanges = cortex_tsdb.DurationList{2 * time.Hour, 12 * time.Hour, 24 * time.Hour}
 	cfg.retryMinBackoff = 10 * time.Second
@@ -253,6 +271,11 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 
 	f.BoolVar(&cfg.AcceptMalformedIndex, "compactor.accept-malformed-index", false, "When enabled, index verification will ignore out of order label names.")
 	f.BoolVar(&cfg.CachingBucketEnabled, "compactor.caching-bucket-enabled", false, "When enabled, caching bucket will be used for compactor, except cleaner service, which serves as the source of truth for block status")
+	f.StringVar(&cfg.CompactionJobsApprovalMode, "compactor.compaction-jobs-approval-mode", CompactionJobsApprovalAuto, fmt.Sprintf("[Experimental] How the planned compaction jobs get approved. Supported values are: %s. With %q, the planned jobs are only compacted once approved through the compactor jobs API, which allows an external controller to defer or reorder them. The jobs are kept in memory by each compactor, so the pending approvals are lost when the compactor restarts or the tenant is resharded to another compactor, and have to be given again.", strings.Join(supportedCompactionJobsApprovalModes, ", "), CompactionJobsApprovalExternal))
+	f.DurationVar(&cfg.SkipUnchangedTenantsMaxAge, "compactor.skip-unchanged-tenants-max-age", 0, "[Experimental] When greater than 0, the compactor skips the tenants whose bucket index has no block change since their last successful compaction, saving the listing of their blocks. The last successful compaction of each tenant is tracked by a compactor-activity.json marker in the tenant directory, shared by all compactors, which records the fingerprint of the bucket index at the first blocks cleaner pass after the compaction. The tenants are compacted again at least once every this period anyway, since some blocks only become eligible for compaction over time. 0 to disable.")
+	f.Uint64Var(&cfg.BlockSeriesHintsMaxSeries, "compactor.block-series-hints-max-series", 0, "[Experimental] When greater than 0, the bucket index stores the series hints (label names and a bloom filter of the label pairs) of the new blocks having at most this number of series. The queriers skip the blocks whose hints don't match the query, so that the store-gateways don't load their index-header. Building the hints requires downloading the index of the block. 0 to disable.")
+	f.BoolVar(&cfg.NativeHistogramsValidationEnabled, "compactor.native-histograms-validation-enabled", false, "[Experimental] When enabled, the native histogram chunks written by the compactor are validated: all the histograms of a chunk must have the same supported schema and no counter reset can happen within a chunk. The compaction of a group fails if an invalid chunk is found.")
+	f.BoolVar(&cfg.ResumableBlockUploadsEnabled, "compactor.resumable-block-uploads-enabled", false, "[Experimental] When enabled, the upload of a compacted block is resumed after a failure or a compactor restart, instead of compacting and uploading the block again from scratch. The compacted blocks are kept in the data directory until uploaded, which must be persisted across restarts, and the objects already uploaded are skipped. A block whose upload is never resumed, because its source blocks changed in the meantime, is left as a partial block in the bucket.")
 }
 
 func (cfg *Config) Validate(limits validation.Limits) error {
@@ -279,6 +302,14 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		}
 	}
 
+	if err := cfg.WorkStealing.Validate(cfg.ShardingEnabled, cfg.ShardingStrategy); err != nil {
+		return err
+	}
+
+	if !util.StringsContain(supportedCompactionJobsApprovalModes, cfg.CompactionJobsApprovalMode) {
+		return errInvalidCompactionJobsApprovalMode
+	}
+
 	return nil
 }
 
@@ -317,6 +348,20 @@ type Compactor struct {
 
 	blocksPlannerFactory PlannerFactory
 
+	// Compaction jobs waiting for approval, when the external approval mode is enabled.
+	compactionJobs *compactionJobsQueue
+
+	// Estimated cost of the planned compaction jobs.
+	compactionEstimator *compactionEstimator
+
+	// Bucket index of the tenants at their last successful compaction.
+
+	// Queue of the compaction jobs which can be stolen, nil if the work stealing is disabled.
+	workStealing *workStealingQueue
+
+	// Compacted blocks whose upload can be resumed, nil if the resumable uploads are disabled.
+	resumableUploads *resumableUploads
+
 	// Client used to run operations on the bucket storing blocks.
 	bucketClient objstore.InstrumentedBucket
 
@@ -337,6 +382,7 @@ type Compactor struct {
 	compactionRunSkippedTenants    prometheus.Gauge
 	compactionRunSucceededTenants  prometheus.Gauge
 	compactionRunFailedTenants     prometheus.Gauge
+	unchangedTenantsSkipped        prometheus.Counter
 	compactionRunInterval          prometheus.Gauge
 	blocksMarkedForDeletion        prometheus.Counter
 	blocksMarkedForNoCompaction    prometheus.Counter
@@ -344,6 +390,8 @@ type Compactor struct {
 	remainingPlannedCompactions    prometheus.Gauge
 	blockVisitMarkerReadFailed     prometheus.Counter
 	blockVisitMarkerWriteFailed    prometheus.Counter
+	nativeHistogramsInvalid        prometheus.Counter
+	stolenJobsFailed               prometheus.Counter
 
 	// TSDB syncer metrics
 	syncerMetrics *syncerMetrics
@@ -409,6 +457,8 @@ func newCompactor(
 		blocksGrouperFactory:   blocksGrouperFactory,
 		blocksCompactorFactory: blocksCompactorFactory,
 		allowedTenants:         util.NewAllowedTenants(compactorCfg.EnabledTenants, compactorCfg.DisabledTenants),
+		compactionJobs:         newCompactionJobsQueue(),
+		compactionEstimator:    newCompactionEstimator(registerer),
 
 		CompactorStartDurationSeconds: promauto.With(registerer).NewGauge(prometheus.GaugeOpts{
 			Name: "cortex_compactor_start_duration_seconds",
@@ -471,14 +521,38 @@ func newCompactor(
 			Name: "cortex_compactor_block_visit_marker_read_failed",
 			Help: "Number of block visit marker file failed to be read.",
 		}),
+		unchangedTenantsSkipped: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_unchanged_tenants_skipped_total",
+			Help: "Total number of tenants skipped because their blocks have not changed since their last successful compaction.",
+		}),
 		blockVisitMarkerWriteFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 			Name: "cortex_compactor_block_visit_marker_write_failed",
 			Help: "Number of block visit marker file failed to be written.",
 		}),
+		nativeHistogramsInvalid: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_native_histograms_validation_failures_total",
+			Help: "Total number of compactions failed because of an invalid native histogram chunk.",
+		}),
+		stolenJobsFailed: promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_work_stealing_stolen_jobs_failed_total",
+			Help: "Total number of compaction jobs stolen from the other compactors which have failed to be compacted.",
+		}),
 		remainingPlannedCompactions: remainingPlannedCompactions,
 		limits:                      limits,
 	}
 
+	if compactorCfg.WorkStealing.Enabled {
+		var err error
+		c.workStealing, err = newWorkStealingQueue(compactorCfg.WorkStealing, compactorCfg.BlockVisitMarkerTimeout, c.logger, registerer)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create the compaction jobs work stealing queue")
+		}
+	}
+
+	if compactorCfg.ResumableBlockUploadsEnabled {
+		c.resumableUploads = newResumableUploads(c.logger, registerer)
+	}
+
 	if len(compactorCfg.EnabledTenants) > 0 {
 		level.Info(c.logger).Log("msg", "compactor using enabled users", "enabled", strings.Join(compactorCfg.EnabledTenants, ", "))
 	}
@@ -515,6 +589,10 @@ func (c *Compactor) starting(ctx context.Context) error {
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize compactor dependencies")
 	}
+	c.blocksCompactor = &estimationCompactor{Compactor: c.blocksCompactor, estimator: c.compactionEstimator}
+	if c.resumableUploads != nil {
+		c.blocksCompactor = &resumableCompactor{Compactor: c.blocksCompactor, uploads: c.resumableUploads}
+	}
 
 	// Wrap the bucket client to write block deletion marks in the global location too.
 	c.bucketClient = bucketindex.BucketWithGlobalMarkers(c.bucketClient)
@@ -529,6 +607,8 @@ func (c *Compactor) starting(ctx context.Context) error {
 		CleanupConcurrency:                 c.compactorCfg.CleanupConcurrency,
 		BlockDeletionMarksMigrationEnabled: c.compactorCfg.BlockDeletionMarksMigrationEnabled,
 		TenantCleanupDelay:                 c.compactorCfg.TenantCleanupDelay,
+		BlockSeriesHintsMaxSeries:          c.compactorCfg.BlockSeriesHintsMaxSeries,
+		TenantActivityEnabled:              c.compactorCfg.SkipUnchangedTenantsMaxAge > 0,
 	}, c.bucketClient, c.usersScanner, c.limits, c.parentLogger, c.registerer)
 
 	// Initialize the compactors ring if sharding is enabled.
@@ -630,10 +710,20 @@ func (c *Compactor) running(ctx context.Context) error {
 	ticker := time.NewTicker(util.DurationWithJitter(c.compactorCfg.CompactionInterval, 0.05))
 	defer ticker.Stop()
 
+	// The jobs of the other compactors are stolen between the compaction runs.
+	var stealC <-chan time.Time
+	if c.workStealing != nil {
+		stealTicker := time.NewTicker(c.compactorCfg.WorkStealing.PollInterval)
+		defer stealTicker.Stop()
+		stealC = stealTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
 			c.compactUsers(ctx)
+		case <-stealC:
+			c.stealJobs(ctx)
 		case <-ctx.Done():
 			return nil
 		case err := <-c.ringSubservicesWatcher.Chan():
@@ -688,6 +778,9 @@ func (c *Compactor) compactUsers(ctx context.Context) {
 		users[i], users[j] = users[j], users[i]
 	})
 
+	// Check the users concurrently, since it requires reading a few objects from the bucket for each user.
+	checks := c.checkUsersForCompaction(ctx, users)
+
 	// Keep track of users owned by this shard, so that we can delete the local files for all other users.
 	ownedUsers := map[string]struct{}{}
 	for _, userID := range users {
@@ -698,40 +791,17 @@ func (c *Compactor) compactUsers(ctx context.Context) {
 			return
 		}
 
-		// Ensure the user ID belongs to our shard.
-		if owned, err := c.ownUserForCompaction(userID); err != nil {
-			c.compactionRunSkippedTenants.Inc()
-			level.Warn(c.logger).Log("msg", "unable to check if user is owned by this shard", "user", userID, "err", err)
-			continue
-		} else if !owned {
-			c.compactionRunSkippedTenants.Inc()
-			level.Debug(c.logger).Log("msg", "skipping user because it is not owned by this shard", "user", userID)
-			continue
-		}
-
-		// Skipping compaction if the  bucket index failed to sync due to CMK errors.
-		if idxs, err := bucketindex.ReadSyncStatus(ctx, c.bucketClient, userID, util_log.WithUserID(userID, c.logger)); err == nil {
-			if idxs.Status == bucketindex.CustomerManagedKeyError {
-				c.compactionRunSkippedTenants.Inc()
-				level.Info(c.logger).Log("msg", "skipping compactUser due CustomerManagedKeyError", "user", userID)
-				continue
-			}
+		check := checks[userID]
+		if check.owned {
+			ownedUsers[userID] = struct{}{}
 		}
-
-		ownedUsers[userID] = struct{}{}
-
-		if markedForDeletion, err := cortex_tsdb.TenantDeletionMarkExists(ctx, c.bucketClient, userID); err != nil {
-			c.compactionRunSkippedTenants.Inc()
-			level.Warn(c.logger).Log("msg", "unable to check if user is marked for deletion", "user", userID, "err", err)
-			continue
-		} else if markedForDeletion {
-			c.compactionRunSkippedTenants.Inc()
-			level.Debug(c.logger).Log("msg", "skipping user because it is marked for deletion", "user", userID)
+		if !check.compact || !c.checkUserBeforeCompaction(ctx, userID) {
 			continue
 		}
 
 		level.Info(c.logger).Log("msg", "starting compaction of user blocks", "user", userID)
 
+		startedAt := time.Now()
 		if err = c.compactUserWithRetries(ctx, userID); err != nil {
 			// TODO: patch thanos error types to support errors.Is(err, context.Canceled) here
 			if ctx.Err() != nil && ctx.Err() == context.Canceled {
@@ -746,6 +816,8 @@ func (c *Compactor) compactUsers(ctx context.Context) {
 			continue
 		}
 
+		c.recordTenantActivity(ctx, userID, startedAt, time.Now())
+
 		c.compactionRunSucceededTenants.Inc()
 		level.Info(c.logger).Log("msg", "successfully compacted user blocks", "user", userID)
 	}
@@ -778,6 +850,140 @@ func (c *Compactor) compactUsers(ctx context.Context) {
 	}
 }
 
+// userCompactionCheck is the outcome of the checks run on a user before compacting it.
+type userCompactionCheck struct {
+	owned   bool
+	compact bool
+}
+
+// checkUsersForCompaction runs the checks on the input users concurrently. The users not checked
+// because the context has been canceled are neither owned nor compacted.
+func (c *Compactor) checkUsersForCompaction(ctx context.Context, users []string) map[string]userCompactionCheck {
+	var mtx sync.Mutex
+	checks := make(map[string]userCompactionCheck, len(users))
+
+	_ = concurrency.ForEachUser(ctx, users, c.compactorCfg.MetaSyncConcurrency, func(ctx context.Context, userID string) error {
+		check := c.checkUserForCompaction(ctx, userID)
+
+		mtx.Lock()
+		checks[userID] = check
+		mtx.Unlock()
+		return nil
+	})
+
+	return checks
+}
+
+// checkUserForCompaction returns whether the user is owned by this shard and should be compacted.
+func (c *Compactor) checkUserForCompaction(ctx context.Context, userID string) userCompactionCheck {
+	// Ensure the user ID belongs to our shard.
+	if owned, err := c.ownUserForCompaction(userID); err != nil {
+		c.compactionRunSkippedTenants.Inc()
+		level.Warn(c.logger).Log("msg", "unable to check if user is owned by this shard", "user", userID, "err", err)
+		return userCompactionCheck{}
+	} else if !owned {
+		c.compactionRunSkippedTenants.Inc()
+		level.Debug(c.logger).Log("msg", "skipping user because it is not owned by this shard", "user", userID)
+		return userCompactionCheck{}
+	}
+
+	// Skipping compaction if the  bucket index failed to sync due to CMK errors.
+	if idxs, err := bucketindex.ReadSyncStatus(ctx, c.bucketClient, userID, util_log.WithUserID(userID, c.logger)); err == nil {
+		if idxs.Status == bucketindex.CustomerManagedKeyError {
+			c.compactionRunSkippedTenants.Inc()
+			level.Info(c.logger).Log("msg", "skipping compactUser due CustomerManagedKeyError", "user", userID)
+			return userCompactionCheck{}
+		}
+	}
+
+	if markedForDeletion, err := cortex_tsdb.TenantDeletionMarkExists(ctx, c.bucketClient, userID); err != nil {
+		c.compactionRunSkippedTenants.Inc()
+		level.Warn(c.logger).Log("msg", "unable to check if user is marked for deletion", "user", userID, "err", err)
+		return userCompactionCheck{owned: true}
+	} else if markedForDeletion {
+		c.compactionRunSkippedTenants.Inc()
+		level.Debug(c.logger).Log("msg", "skipping user because it is marked for deletion", "user", userID)
+		return userCompactionCheck{owned: true}
+	}
+
+	return userCompactionCheck{owned: true, compact: true}
+}
+
+// checkUserBeforeCompaction returns whether the user should still be compacted. The users are
+// compacted one after the other, so the ownership and the tenant activity are checked right
+// before compacting each of them, rather than once when the compaction run starts.
+func (c *Compactor) checkUserBeforeCompaction(ctx context.Context, userID string) bool {
+	if owned, err := c.ownUserForCompaction(userID); err != nil {
+		c.compactionRunSkippedTenants.Inc()
+		level.Warn(c.logger).Log("msg", "unable to check if user is owned by this shard", "user", userID, "err", err)
+		return false
+	} else if !owned {
+		c.compactionRunSkippedTenants.Inc()
+		level.Debug(c.logger).Log("msg", "skipping user because it is no longer owned by this shard", "user", userID)
+		return false
+	}
+
+	if c.checkTenantActivity(ctx, userID, time.Now()) {
+		c.compactionRunSkippedTenants.Inc()
+		c.unchangedTenantsSkipped.Inc()
+		level.Debug(c.logger).Log("msg", "skipping user because its blocks have not changed since the last compaction", "user", userID)
+		return false
+	}
+
+	return true
+}
+
+// checkTenantActivity returns whether the tenant blocks have not changed since its last
+// successful compaction. It returns false if skipping the unchanged tenants is disabled, or
+// the tenant activity marker or the bucket index can't be read.
+func (c *Compactor) checkTenantActivity(ctx context.Context, userID string, now time.Time) bool {
+	maxAge := c.compactorCfg.SkipUnchangedTenantsMaxAge
+	if maxAge <= 0 {
+		return false
+	}
+
+	// The jobs waiting for approval run at the next compaction, even if the blocks have not changed.
+	if c.compactionJobs.hasJobs(userID) {
+		return false
+	}
+
+	userLogger := util_log.WithUserID(userID, c.logger)
+	activity, err := readTenantActivity(ctx, c.bucketClient, userID, userLogger)
+	if err != nil {
+		level.Warn(userLogger).Log("msg", "unable to read the tenant activity marker", "err", err)
+		return false
+	}
+	if activity == nil {
+		return false
+	}
+
+	idx, err := bucketindex.ReadIndex(ctx, c.bucketClient, userID, c.limits, userLogger)
+	if err != nil {
+		level.Debug(userLogger).Log("msg", "unable to read the bucket index to check if the user blocks have changed", "err", err)
+		return false
+	}
+
+	return activity.unchanged(indexFingerprint(idx), now, maxAge)
+}
+
+// recordTenantActivity writes the tenant activity marker after a successful compaction. The
+// fingerprint of the bucket index is taken later by the blocks cleaner, once the bucket index
+// includes the blocks produced by the compaction.
+func (c *Compactor) recordTenantActivity(ctx context.Context, userID string, startedAt, completedAt time.Time) {
+	if c.compactorCfg.SkipUnchangedTenantsMaxAge <= 0 {
+		return
+	}
+
+	activity := &tenantActivity{
+		Version:     tenantActivityVersion1,
+		StartedAt:   startedAt.Unix(),
+		CompletedAt: completedAt.Unix(),
+	}
+	if err := writeTenantActivity(ctx, c.bucketClient, userID, activity); err != nil {
+		level.Warn(util_log.WithUserID(userID, c.logger)).Log("msg", "failed to write the tenant activity marker", "err", err)
+	}
+}
+
 func (c *Compactor) compactUserWithRetries(ctx context.Context, userID string) error {
 	var lastErr error
 
@@ -788,7 +994,7 @@ func (c *Compactor) compactUserWithRetries(ctx context.Context, userID string) e
 	})
 
 	for retries.Ongoing() {
-		lastErr = c.compactUser(ctx, userID)
+		lastErr = c.compactUser(ctx, userID, "")
 		if lastErr == nil {
 			return nil
 		}
@@ -803,8 +1009,13 @@ func (c *Compactor) compactUserWithRetries(ctx context.Context, userID string) e
 	return lastErr
 }
 
-func (c *Compactor) compactUser(ctx context.Context, userID string) error {
+// compactUser compacts the blocks of the user. If the stolen group key is set, only the group of
+// the job stolen from another compactor is compacted.
+func (c *Compactor) compactUser(ctx context.Context, userID, stolenGroupKey string) error {
 	bucket := bucket.NewUserBucketClient(userID, c.bucketClient, c.limits)
+	if c.resumableUploads != nil {
+		bucket = newResumableUploadsBucket(bucket, c.resumableUploads)
+	}
 
 	reg := prometheus.NewRegistry()
 	defer c.syncerMetrics.gatherThanosSyncerMetrics(reg)
@@ -880,12 +1091,39 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 
 	currentCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	compactor, err := compact.NewBucketCompactor(
+
+	var workStealing *WorkStealingJobs
+	if c.workStealing != nil {
+		workStealing = &WorkStealingJobs{queue: c.workStealing, userID: userID, compactorID: c.ringLifecycler.ID, stolenGroupKey: stolenGroupKey}
+	}
+
+	grouper := &estimationGrouper{
+		Grouper:   c.blocksGrouperFactory(currentCtx, c.compactorCfg, bucket, ulogger, reg, c.blocksMarkedForDeletion, c.blocksMarkedForNoCompaction, c.garbageCollectedBlocks, c.remainingPlannedCompactions, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.ring, c.ringLifecycler, c.limits, userID, noCompactMarkerFilter, workStealing),
+		ctx:       currentCtx,
+		planner:   compact.NewPlanner(ulogger, c.compactorCfg.BlockRanges.ToMilliseconds(), noCompactMarkerFilter),
+		estimator: c.compactionEstimator,
+		userID:    userID,
+	}
+
+	planner := c.blocksPlannerFactory(currentCtx, bucket, ulogger, c.compactorCfg, noCompactMarkerFilter, c.ringLifecycler, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed)
+	if c.compactorCfg.CompactionJobsApprovalMode == CompactionJobsApprovalExternal {
+		planner = &approvalPlanner{Planner: planner, queue: c.compactionJobs, userID: userID}
+
+		// Jobs not planned anymore by this run are not relevant anymore.
+		defer c.compactionJobs.removeStale(userID, c.compactionJobs.now())
+	}
+
+	compactor, err := compact.NewBucketCompactorWithCheckerAndCallback(
 		ulogger,
 		syncer,
-		c.blocksGrouperFactory(currentCtx, c.compactorCfg, bucket, ulogger, reg, c.blocksMarkedForDeletion, c.blocksMarkedForNoCompaction, c.garbageCollectedBlocks, c.remainingPlannedCompactions, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed, c.ring, c.ringLifecycler, c.limits, userID, noCompactMarkerFilter),
-		c.blocksPlannerFactory(currentCtx, bucket, ulogger, c.compactorCfg, noCompactMarkerFilter, c.ringLifecycler, c.blockVisitMarkerReadFailed, c.blockVisitMarkerWriteFailed),
+		grouper,
+		planner,
 		c.blocksCompactor,
+		compact.DefaultBlockDeletableChecker{},
+		nativeHistogramsLifecycleCallback{
+			validate:           c.compactorCfg.NativeHistogramsValidationEnabled,
+			validationFailures: c.nativeHistogramsInvalid,
+		},
 		c.compactDirForUser(userID),
 		bucket,
 		c.compactorCfg.CompactionConcurrency,
@@ -909,6 +1147,47 @@ func (c *Compactor) compactUser(ctx context.Context, userID string) error {
 	return nil
 }
 
+// stealJobs compacts the jobs published by the other compactors, until there's no job left to steal.
+func (c *Compactor) stealJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := c.workStealing.steal(ctx, c.ringLifecycler.ID, c.allowedTenants.IsAllowed)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "failed to steal a compaction job from the other compactors", "err", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+
+		c.compactStolenJob(ctx, job)
+	}
+}
+
+func (c *Compactor) compactStolenJob(ctx context.Context, job *stealableJob) {
+	defer func() {
+		if err := c.workStealing.complete(ctx, job, c.ringLifecycler.ID); err != nil {
+			level.Warn(c.logger).Log("msg", "failed to remove the stolen compaction job from the queue", "user", job.User, "group_key", job.GroupKey, "err", err)
+		}
+	}()
+
+	if markedForDeletion, err := cortex_tsdb.TenantDeletionMarkExists(ctx, c.bucketClient, job.User); err != nil {
+		c.stolenJobsFailed.Inc()
+		level.Warn(c.logger).Log("msg", "unable to check if user is marked for deletion", "user", job.User, "err", err)
+		return
+	} else if markedForDeletion {
+		level.Debug(c.logger).Log("msg", "skipping stolen compaction job because the user is marked for deletion", "user", job.User, "group_key", job.GroupKey)
+		return
+	}
+
+	level.Info(c.logger).Log("msg", "starting compaction of the job stolen from another compactor", "user", job.User, "group_key", job.GroupKey, "owner", job.Owner)
+	if err := c.compactUser(ctx, job.User, job.GroupKey); err != nil {
+		c.stolenJobsFailed.Inc()
+		level.Error(c.logger).Log("msg", "failed to compact the job stolen from another compactor", "user", job.User, "group_key", job.GroupKey, "err", err)
+		return
+	}
+	level.Info(c.logger).Log("msg", "successfully compacted the job stolen from another compactor", "user", job.User, "group_key", job.GroupKey)
+}
+
 func (c *Compactor) discoverUsersWithRetries(ctx context.Context) ([]string, error) {
 	var lastErr error
 
@@ -140,6 +140,32 @@ func TestConfig_Validate(t *testing.T) {
 			initLimits: func(_ *validation.Limits) {},
 			expected:   errInvalidTenantShardSize.Error(),
 		},
+		"should pass with work stealing and shuffle sharding": {
+			setup: func(cfg *Config) {
+				cfg.ShardingStrategy = util.ShardingStrategyShuffle
+				cfg.ShardingEnabled = true
+				cfg.WorkStealing.Enabled = true
+			},
+			initLimits: func(limits *validation.Limits) {
+				limits.CompactorTenantShardSize = 1
+			},
+			expected: "",
+		},
+		"should fail with work stealing and default sharding": {
+			setup: func(cfg *Config) {
+				cfg.ShardingEnabled = true
+				cfg.WorkStealing.Enabled = true
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidWorkStealingShardingStrategy.Error(),
+		},
+		"should fail with unsupported compaction jobs approval mode": {
+			setup: func(cfg *Config) {
+				cfg.CompactionJobsApprovalMode = "manual"
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidCompactionJobsApprovalMode.Error(),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -969,6 +995,7 @@ func TestCompactor_ShouldNotCompactBlocksForUsersMarkedForDeletion(t *testing.T)
 	bucketClient.MockDelete("user-1/01DTVP434PA9VFXSW2JKB3392D/index", nil)
 	bucketClient.MockDelete("user-1/bucket-index.json.gz", nil)
 	bucketClient.MockDelete("user-1/bucket-index-sync-status.json", nil)
+	bucketClient.MockDelete("user-1/compactor-activity.json", nil)
 
 	c, _, tsdbPlanner, logs, registry := prepare(t, cfg, bucketClient, nil)
 
@@ -0,0 +1,146 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-kit/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/thanos-io/thanos/pkg/compact"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+)
+
+const (
+	// Range of the exponential schemas supported by the native histograms.
+	nativeHistogramMinSchema = -4
+	nativeHistogramMaxSchema = 8
+)
+
+// nativeHistogramsLifecycleCallback populates the compacted blocks collecting the statistics
+// of their native histogram chunks, which are recorded in the block meta.json extensions, and
+// optionally validating them.
+type nativeHistogramsLifecycleCallback struct {
+	compact.DefaultCompactionLifecycleCallback
+
+	validate           bool
+	validationFailures prometheus.Counter
+}
+
+func (c nativeHistogramsLifecycleCallback) GetBlockPopulator(_ context.Context, _ log.Logger, group *compact.Group) (tsdb.BlockPopulator, error) {
+	return &nativeHistogramsBlockPopulator{
+		group:              group,
+		validate:           c.validate,
+		validationFailures: c.validationFailures,
+	}, nil
+}
+
+type nativeHistogramsBlockPopulator struct {
+	tsdb.DefaultBlockPopulator
+
+	group              *compact.Group
+	validate           bool
+	validationFailures prometheus.Counter
+}
+
+func (p *nativeHistogramsBlockPopulator) PopulateBlock(ctx context.Context, metrics *tsdb.CompactorMetrics, logger log.Logger, chunkPool chunkenc.Pool, mergeFunc storage.VerticalChunkSeriesMergeFunc, blocks []tsdb.BlockReader, meta *tsdb.BlockMeta, indexw tsdb.IndexWriter, chunkw tsdb.ChunkWriter, postingsFunc tsdb.IndexReaderPostingsFunc) error {
+	w := &nativeHistogramsChunkWriter{ChunkWriter: chunkw, validate: p.validate}
+	if err := p.DefaultBlockPopulator.PopulateBlock(ctx, metrics, logger, chunkPool, mergeFunc, blocks, meta, indexw, w, postingsFunc); err != nil {
+		if errors.Is(err, errInvalidNativeHistogramChunk) {
+			p.validationFailures.Inc()
+		}
+		return err
+	}
+
+	// The group extensions are written to the meta.json of the compacted block, so we
+	// record the statistics there. A group runs a single compaction at a time.
+	ext := &cortex_tsdb.BlockExtensions{}
+	if curr, ok := p.group.Extensions().(*cortex_tsdb.BlockExtensions); ok && curr != nil {
+		*ext = *curr
+	}
+	ext.HistogramStats = nil
+	if w.stats.Series > 0 {
+		stats := w.stats
+		ext.HistogramStats = &stats
+	}
+	p.group.SetExtensions(ext)
+
+	return nil
+}
+
+var errInvalidNativeHistogramChunk = errors.New("invalid native histogram chunk")
+
+// nativeHistogramsChunkWriter collects the statistics of the native histogram chunks written
+// to the compacted block. The chunks of a series are written with a single call.
+type nativeHistogramsChunkWriter struct {
+	tsdb.ChunkWriter
+
+	validate bool
+	stats    cortex_tsdb.BlockHistogramStats
+}
+
+func (w *nativeHistogramsChunkWriter) WriteChunks(chks ...chunks.Meta) error {
+	hasHistograms := false
+	for _, c := range chks {
+		enc := c.Chunk.Encoding()
+		if enc != chunkenc.EncHistogram && enc != chunkenc.EncFloatHistogram {
+			continue
+		}
+
+		hasHistograms = true
+		w.stats.ChunksBytes += uint64(len(c.Chunk.Bytes()))
+
+		if w.validate {
+			if err := validateNativeHistogramChunk(c.Chunk); err != nil {
+				return errors.Wrapf(err, "series chunk [%d, %d]", c.MinTime, c.MaxTime)
+			}
+		}
+	}
+	if hasHistograms {
+		w.stats.Series++
+	}
+
+	return w.ChunkWriter.WriteChunks(chks...)
+}
+
+// validateNativeHistogramChunk checks that all the histograms of the chunk have the same
+// supported schema and, for counter histograms, that no counter reset happens within the
+// chunk, given the TSDB cuts a new chunk on schema changes and counter resets.
+func validateNativeHistogramChunk(chk chunkenc.Chunk) error {
+	var (
+		it   = chk.Iterator(nil)
+		prev *histogram.FloatHistogram
+	)
+
+	for it.Next() != chunkenc.ValNone {
+		_, h := it.AtFloatHistogram(nil)
+
+		if prev == nil {
+			if h.Schema < nativeHistogramMinSchema || h.Schema > nativeHistogramMaxSchema {
+				return fmt.Errorf("%w: unsupported schema %d", errInvalidNativeHistogramChunk, h.Schema)
+			}
+		} else {
+			if h.Schema != prev.Schema {
+				return fmt.Errorf("%w: schema changed from %d to %d within the chunk", errInvalidNativeHistogramChunk, prev.Schema, h.Schema)
+			}
+
+			// The hint of the histograms after the first one doesn't reflect the actual
+			// counter resets, so we ignore it to detect them.
+			if h.CounterResetHint != histogram.GaugeType {
+				h.CounterResetHint = histogram.UnknownCounterReset
+				if h.DetectReset(prev) {
+					return fmt.Errorf("%w: counter reset within the chunk", errInvalidNativeHistogramChunk)
+				}
+			}
+		}
+		prev = h
+	}
+
+	return it.Err()
+}
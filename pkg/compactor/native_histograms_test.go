@@ -0,0 +1,145 @@
+package compactor
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	histogram_util "github.com/cortexproject/cortex/pkg/util/histogram"
+)
+
+func TestNativeHistogramsChunkWriter(t *testing.T) {
+	floatChunk := chunkenc.NewXORChunk()
+	app, err := floatChunk.Appender()
+	require.NoError(t, err)
+	app.Append(0, 1)
+
+	histogramChunk := newTestHistogramChunk(t, histogram_util.GenerateTestHistogram(1), histogram_util.GenerateTestHistogram(2))
+
+	invalidSchema := histogram_util.GenerateTestHistogram(1)
+	invalidSchema.Schema = 9
+
+	// The test histograms are gauges by default.
+	counterHistograms := []*histogram.FloatHistogram{histogram_util.GenerateTestFloatHistogram(2), histogram_util.GenerateTestFloatHistogram(1)}
+	for _, h := range counterHistograms {
+		h.CounterResetHint = histogram.UnknownCounterReset
+	}
+	counterReset := &testHistogramsChunk{Chunk: histogramChunk, histograms: counterHistograms}
+
+	tests := map[string]struct {
+		series        [][]chunkenc.Chunk
+		validate      bool
+		expectedStats cortex_tsdb.BlockHistogramStats
+		expectedErr   string
+	}{
+		"should collect the stats of the histogram chunks": {
+			series: [][]chunkenc.Chunk{
+				{floatChunk},
+				{histogramChunk, histogramChunk},
+				{floatChunk, histogramChunk},
+			},
+			validate:      true,
+			expectedStats: cortex_tsdb.BlockHistogramStats{Series: 2, ChunksBytes: 3 * uint64(len(histogramChunk.Bytes()))},
+		},
+		"should fail on unsupported schema": {
+			series:      [][]chunkenc.Chunk{{newTestHistogramChunk(t, invalidSchema)}},
+			validate:    true,
+			expectedErr: "unsupported schema 9",
+		},
+		"should fail on counter reset within a chunk": {
+			series:      [][]chunkenc.Chunk{{counterReset}},
+			validate:    true,
+			expectedErr: "counter reset within the chunk",
+		},
+		"should not validate the chunks if disabled": {
+			series:        [][]chunkenc.Chunk{{counterReset}},
+			validate:      false,
+			expectedStats: cortex_tsdb.BlockHistogramStats{Series: 1, ChunksBytes: uint64(len(histogramChunk.Bytes()))},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			w := &nativeHistogramsChunkWriter{ChunkWriter: &nopChunkWriter{}, validate: testData.validate}
+
+			var err error
+			for _, series := range testData.series {
+				metas := make([]chunks.Meta, 0, len(series))
+				for _, c := range series {
+					metas = append(metas, chunks.Meta{Chunk: c})
+				}
+				if err = w.WriteChunks(metas...); err != nil {
+					break
+				}
+			}
+
+			if testData.expectedErr != "" {
+				require.Error(t, err)
+				assert.True(t, errors.Is(err, errInvalidNativeHistogramChunk))
+				assert.Contains(t, err.Error(), testData.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedStats, w.stats)
+		})
+	}
+}
+
+func newTestHistogramChunk(t *testing.T, hs ...*histogram.Histogram) chunkenc.Chunk {
+	chk := chunkenc.NewHistogramChunk()
+	app, err := chk.Appender()
+	require.NoError(t, err)
+
+	for i, h := range hs {
+		_, _, app, err = app.AppendHistogram(nil, int64(i), h, true)
+		require.NoError(t, err)
+	}
+	return chk
+}
+
+// testHistogramsChunk is a chunk iterating over the given histograms, which allows
+// to build chunks which the TSDB appenders would never write.
+type testHistogramsChunk struct {
+	chunkenc.Chunk
+
+	histograms []*histogram.FloatHistogram
+}
+
+func (c *testHistogramsChunk) Iterator(chunkenc.Iterator) chunkenc.Iterator {
+	return &testHistogramsIterator{histograms: c.histograms, idx: -1}
+}
+
+type testHistogramsIterator struct {
+	chunkenc.Iterator
+
+	histograms []*histogram.FloatHistogram
+	idx        int
+}
+
+func (it *testHistogramsIterator) Next() chunkenc.ValueType {
+	it.idx++
+	if it.idx >= len(it.histograms) {
+		return chunkenc.ValNone
+	}
+	return chunkenc.ValFloatHistogram
+}
+
+func (it *testHistogramsIterator) AtFloatHistogram(*histogram.FloatHistogram) (int64, *histogram.FloatHistogram) {
+	return int64(it.idx), it.histograms[it.idx].Copy()
+}
+
+func (it *testHistogramsIterator) Err() error {
+	return nil
+}
+
+type nopChunkWriter struct{}
+
+func (nopChunkWriter) WriteChunks(...chunks.Meta) error { return nil }
+func (nopChunkWriter) Close() error                     { return nil }
@@ -0,0 +1,244 @@
+package compactor
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/compact"
+)
+
+// resumableCompactionPrefix is the prefix of the file written in the compaction group directory,
+// listing the blocks output by the compaction of the source blocks.
+const resumableCompactionPrefix = "resumable-compaction-"
+
+// resumableCompaction is the content of the file listing the blocks output by a compaction.
+type resumableCompaction struct {
+	Sources []string    `json:"sources"`
+	Blocks  []ulid.ULID `json:"blocks"`
+}
+
+// resumableUploads lets the compactor resume the upload of the compacted blocks after a failure
+// or a restart, instead of compacting and uploading them again from scratch. The compacted blocks
+// are kept in the compaction group directory until uploaded, and listed in a file named after their
+// source blocks, so that the next compaction of the same source blocks reuses them. The objects of
+// their partial upload are not deleted from the bucket on failure, and the objects already uploaded
+// with the same size are skipped when the upload is retried.
+type resumableUploads struct {
+	logger log.Logger
+
+	mtx    sync.Mutex
+	blocks map[ulid.ULID]struct{}
+
+	resumedCompactions prometheus.Counter
+	skippedObjects     prometheus.Counter
+	skippedBytes       prometheus.Counter
+}
+
+func newResumableUploads(logger log.Logger, reg prometheus.Registerer) *resumableUploads {
+	return &resumableUploads{
+		logger: logger,
+		blocks: map[ulid.ULID]struct{}{},
+		resumedCompactions: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_resumed_compactions_total",
+			Help: "Total number of compactions whose blocks were already compacted by a previous attempt, and whose upload has been resumed.",
+		}),
+		skippedObjects: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_resumed_uploads_skipped_objects_total",
+			Help: "Total number of objects of the compacted blocks not uploaded again, because already uploaded by a previous attempt.",
+		}),
+		skippedBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_resumed_uploads_skipped_bytes_total",
+			Help: "Total size of the objects of the compacted blocks not uploaded again, because already uploaded by a previous attempt.",
+		}),
+	}
+}
+
+func (r *resumableUploads) add(ids []ulid.ULID) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	for _, id := range ids {
+		r.blocks[id] = struct{}{}
+	}
+}
+
+func (r *resumableUploads) remove(id ulid.ULID) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	delete(r.blocks, id)
+}
+
+// isResumable returns the ID of the block of the object, if the upload of the block can be resumed.
+func (r *resumableUploads) isResumable(name string) (ulid.ULID, bool) {
+	dir, _, ok := strings.Cut(name, objstore.DirDelim)
+	if !ok {
+		return ulid.ULID{}, false
+	}
+	id, ok := block.IsBlockDir(dir)
+	if !ok {
+		return ulid.ULID{}, false
+	}
+
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	_, ok = r.blocks[id]
+	return id, ok
+}
+
+// resumableCompactor wraps a compactor to reuse the blocks compacted by a previous attempt of the
+// same compaction whose upload has failed.
+type resumableCompactor struct {
+	compact.Compactor
+
+	uploads *resumableUploads
+}
+
+func (c *resumableCompactor) Compact(dest string, dirs []string, open []*tsdb.Block) ([]ulid.ULID, error) {
+	return c.compact(dest, dirs, func() ([]ulid.ULID, error) {
+		return c.Compactor.Compact(dest, dirs, open)
+	})
+}
+
+func (c *resumableCompactor) CompactWithBlockPopulator(dest string, dirs []string, open []*tsdb.Block, blockPopulator tsdb.BlockPopulator) ([]ulid.ULID, error) {
+	return c.compact(dest, dirs, func() ([]ulid.ULID, error) {
+		return c.Compactor.CompactWithBlockPopulator(dest, dirs, open, blockPopulator)
+	})
+}
+
+func (c *resumableCompactor) compact(dest string, dirs []string, compactFn func() ([]ulid.ULID, error)) ([]ulid.ULID, error) {
+	sources := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		sources = append(sources, filepath.Base(dir))
+	}
+	sort.Strings(sources)
+	file := filepath.Join(dest, resumableCompactionFilename(sources))
+
+	if ids, ok := c.resume(dest, file); ok {
+		level.Info(c.uploads.logger).Log("msg", "resuming the upload of blocks compacted by a previous attempt", "sources", strings.Join(sources, ","), "blocks", len(ids))
+		c.uploads.resumedCompactions.Inc()
+		c.uploads.add(ids)
+		return ids, nil
+	}
+
+	ids, err := compactFn()
+	if err != nil || len(ids) == 0 {
+		return ids, err
+	}
+
+	data, err := json.Marshal(resumableCompaction{Sources: sources, Blocks: ids})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return nil, err
+	}
+	c.uploads.add(ids)
+	return ids, nil
+}
+
+// resume returns the blocks compacted by a previous attempt of the compaction, if all of them are
+// still in the destination directory.
+func (c *resumableCompactor) resume(dest, file string) ([]ulid.ULID, bool) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, false
+	}
+	compaction := resumableCompaction{}
+	if err := json.Unmarshal(data, &compaction); err != nil || len(compaction.Blocks) == 0 {
+		return nil, false
+	}
+
+	for _, id := range compaction.Blocks {
+		bdir := filepath.Join(dest, id.String())
+		if _, err := os.Stat(filepath.Join(bdir, block.MetaFilename)); err != nil {
+			return nil, false
+		}
+
+		// The tombstones are removed from the compacted block before its upload, but expected by
+		// the compaction group, so they're written again.
+		if _, err := os.Stat(filepath.Join(bdir, tombstones.TombstonesFilename)); os.IsNotExist(err) {
+			if _, err := tombstones.WriteFile(c.uploads.logger, bdir, tombstones.NewMemTombstones()); err != nil {
+				return nil, false
+			}
+		}
+	}
+	return compaction.Blocks, true
+}
+
+func resumableCompactionFilename(sources []string) string {
+	h := sha256.Sum256([]byte(strings.Join(sources, ",")))
+	return resumableCompactionPrefix + hex.EncodeToString(h[:]) + ".json"
+}
+
+// resumableUploadsBucket wraps the bucket of a tenant to resume the upload of the compacted blocks.
+type resumableUploadsBucket struct {
+	objstore.InstrumentedBucket
+
+	uploads *resumableUploads
+}
+
+func newResumableUploadsBucket(bkt objstore.InstrumentedBucket, uploads *resumableUploads) objstore.InstrumentedBucket {
+	return &resumableUploadsBucket{InstrumentedBucket: bkt, uploads: uploads}
+}
+
+// Upload implements objstore.Bucket. The objects of the blocks being resumed are skipped if already
+// uploaded with the same size, except the meta.json which marks the block as complete.
+func (b *resumableUploadsBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	id, ok := b.uploads.isResumable(name)
+	if !ok {
+		return b.InstrumentedBucket.Upload(ctx, name, r)
+	}
+
+	if f, ok := r.(*os.File); ok && path.Base(name) != block.MetaFilename {
+		if info, err := f.Stat(); err == nil {
+			if attrs, err := b.InstrumentedBucket.Attributes(ctx, name); err == nil && attrs.Size == info.Size() {
+				b.uploads.skippedObjects.Inc()
+				b.uploads.skippedBytes.Add(float64(info.Size()))
+				return nil
+			}
+		}
+	}
+
+	if err := b.InstrumentedBucket.Upload(ctx, name, r); err != nil {
+		return err
+	}
+	b.uploadDone(id, name)
+	return nil
+}
+
+// uploadDone stops tracking the block once its meta.json, which is always uploaded last, is uploaded.
+func (b *resumableUploadsBucket) uploadDone(id ulid.ULID, name string) {
+	if path.Base(name) == block.MetaFilename {
+		b.uploads.remove(id)
+	}
+}
+
+// Delete implements objstore.Bucket. The objects of the blocks being resumed are kept, so that the
+// next attempt doesn't upload them again. If the block is never uploaded, it's left as a partial
+// block in the bucket.
+func (b *resumableUploadsBucket) Delete(ctx context.Context, name string) error {
+	if _, ok := b.uploads.isResumable(name); ok {
+		return nil
+	}
+	return b.InstrumentedBucket.Delete(ctx, name)
+}
@@ -0,0 +1,124 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/tombstones"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+)
+
+type countingCompactorMock struct {
+	tsdbCompactorMock
+
+	calls int
+}
+
+func (m *countingCompactorMock) CompactWithBlockPopulator(dest string, _ []string, _ []*tsdb.Block, _ tsdb.BlockPopulator) ([]ulid.ULID, error) {
+	m.calls++
+
+	id := ulid.MustNew(uint64(10+m.calls), nil)
+	bdir := filepath.Join(dest, id.String())
+	if err := os.MkdirAll(bdir, 0750); err != nil {
+		return nil, err
+	}
+	if _, err := tombstones.WriteFile(log.NewNopLogger(), bdir, tombstones.NewMemTombstones()); err != nil {
+		return nil, err
+	}
+	return []ulid.ULID{id}, os.WriteFile(filepath.Join(bdir, block.MetaFilename), []byte("{}"), 0600)
+}
+
+func TestResumableCompactor(t *testing.T) {
+	dest := t.TempDir()
+	dirs := []string{filepath.Join(dest, ulid.MustNew(2, nil).String()), filepath.Join(dest, ulid.MustNew(1, nil).String())}
+
+	reg := prometheus.NewPedanticRegistry()
+	uploads := newResumableUploads(log.NewNopLogger(), reg)
+	mock := &countingCompactorMock{}
+	c := &resumableCompactor{Compactor: mock, uploads: uploads}
+
+	ids, err := c.CompactWithBlockPopulator(dest, dirs, nil, tsdb.DefaultBlockPopulator{})
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.Equal(t, 1, mock.calls)
+	_, ok := uploads.isResumable(ids[0].String() + "/index")
+	assert.True(t, ok)
+
+	// The compaction group removes the tombstones before uploading the block.
+	require.NoError(t, os.Remove(filepath.Join(dest, ids[0].String(), tombstones.TombstonesFilename)))
+
+	// The next compaction of the same source blocks, in any order, reuses the compacted block.
+	resumed, err := c.CompactWithBlockPopulator(dest, []string{dirs[1], dirs[0]}, nil, tsdb.DefaultBlockPopulator{})
+	require.NoError(t, err)
+	assert.Equal(t, ids, resumed)
+	assert.Equal(t, 1, mock.calls)
+	assert.FileExists(t, filepath.Join(dest, ids[0].String(), tombstones.TombstonesFilename))
+	assert.Equal(t, float64(1), testutil.ToFloat64(uploads.resumedCompactions))
+
+	// Other source blocks are compacted.
+	_, err = c.CompactWithBlockPopulator(dest, dirs[:1], nil, tsdb.DefaultBlockPopulator{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, mock.calls)
+
+	// The compaction is run again if the compacted block has been removed.
+	require.NoError(t, os.RemoveAll(filepath.Join(dest, ids[0].String())))
+	_, err = c.CompactWithBlockPopulator(dest, dirs, nil, tsdb.DefaultBlockPopulator{})
+	require.NoError(t, err)
+	assert.Equal(t, 3, mock.calls)
+}
+
+func TestResumableUploadsBucket(t *testing.T) {
+	ctx := context.Background()
+	resumable := ulid.MustNew(1, nil)
+	other := ulid.MustNew(2, nil)
+
+	uploads := newResumableUploads(log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	uploads.add([]ulid.ULID{resumable})
+
+	inmem := objstore.NewInMemBucket()
+	bkt := newResumableUploadsBucket(objstore.WithNoopInstr(inmem), uploads)
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) *os.File {
+		file := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(file, []byte(content), 0600))
+		f, err := os.Open(file)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = f.Close() })
+		return f
+	}
+
+	// A partial upload is kept when cleaned up.
+	require.NoError(t, bkt.Upload(ctx, resumable.String()+"/index", strings.NewReader("index")))
+	require.NoError(t, bkt.Upload(ctx, other.String()+"/index", strings.NewReader("index")))
+	require.NoError(t, block.Delete(ctx, log.NewNopLogger(), bkt, resumable))
+	require.NoError(t, block.Delete(ctx, log.NewNopLogger(), bkt, other))
+	assert.Equal(t, map[string][]byte{resumable.String() + "/index": []byte("index")}, inmem.Objects())
+
+	// The objects already uploaded with the same size are skipped.
+	require.NoError(t, bkt.Upload(ctx, resumable.String()+"/index", writeFile("index", "INDEX")))
+	require.NoError(t, bkt.Upload(ctx, resumable.String()+"/chunks/000001", writeFile("chunks", "chunks")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(uploads.skippedObjects))
+	assert.Equal(t, float64(5), testutil.ToFloat64(uploads.skippedBytes))
+	assert.Equal(t, []byte("index"), inmem.Objects()[resumable.String()+"/index"])
+	assert.Equal(t, []byte("chunks"), inmem.Objects()[resumable.String()+"/chunks/000001"])
+
+	// Once the meta.json is uploaded, the block is not tracked anymore.
+	require.NoError(t, bkt.Upload(ctx, resumable.String()+"/meta.json", bytes.NewReader([]byte("{}"))))
+	_, ok := uploads.isResumable(resumable.String() + "/index")
+	assert.False(t, ok)
+	require.NoError(t, block.Delete(ctx, log.NewNopLogger(), bkt, resumable))
+	assert.Empty(t, inmem.Objects())
+}
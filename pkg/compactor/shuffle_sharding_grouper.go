@@ -20,6 +20,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/compact"
 
 	"github.com/cortexproject/cortex/pkg/ring"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 )
 
 type ShuffleShardingGrouper struct {
@@ -55,6 +56,13 @@ type ShuffleShardingGrouper struct {
 	blockVisitMarkerWriteFailed prometheus.Counter
 
 	noCompBlocksFunc func() map[ulid.ULID]*metadata.NoCompactMark
+
+	// Compaction jobs shared with the other compactors, nil if the work stealing is disabled.
+	workStealing *WorkStealingJobs
+
+	// Blocks of the groups planned by the last call to Groups, including the ones this
+	// compactor has no capacity to compact yet.
+	plannedGroups [][]*metadata.Meta
 }
 
 func NewShuffleShardingGrouper(
@@ -82,6 +90,7 @@ func NewShuffleShardingGrouper(
 	blockVisitMarkerReadFailed prometheus.Counter,
 	blockVisitMarkerWriteFailed prometheus.Counter,
 	noCompBlocksFunc func() map[ulid.ULID]*metadata.NoCompactMark,
+	workStealing *WorkStealingJobs,
 ) *ShuffleShardingGrouper {
 	if logger == nil {
 		logger = log.NewNopLogger()
@@ -133,11 +142,14 @@ func NewShuffleShardingGrouper(
 		blockVisitMarkerReadFailed:  blockVisitMarkerReadFailed,
 		blockVisitMarkerWriteFailed: blockVisitMarkerWriteFailed,
 		noCompBlocksFunc:            noCompBlocksFunc,
+		workStealing:                workStealing,
 	}
 }
 
 // Groups function modified from https://github.com/cortexproject/cortex/pull/2616
 func (g *ShuffleShardingGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) (res []*compact.Group, err error) {
+	g.plannedGroups = nil
+
 	noCompactMarked := g.noCompBlocksFunc()
 	// First of all we have to group blocks using the Thanos default
 	// grouping (based on downsample resolution + external labels).
@@ -153,21 +165,40 @@ func (g *ShuffleShardingGrouper) Groups(blocks map[ulid.ULID]*metadata.Meta) (re
 	// which we can parallelly compact.
 	var outGroups []*compact.Group
 
-	// Check if this compactor is on the subring.
-	// If the compactor is not on the subring when using the userID as a identifier
-	// no plans generated below will be owned by the compactor so we can just return an empty array
-	// as there will be no planned groups
-	onSubring, err := g.checkSubringForCompactor()
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to check sub-ring for compactor ownership")
-	}
-	if !onSubring {
-		level.Debug(g.logger).Log("msg", "compactor is not on the current sub-ring skipping user", "user", g.userID)
-		return outGroups, nil
+	// When compacting a job stolen from another compactor, the stolen group is the only one
+	// to compact, whether this compactor is on the subring or not.
+	var stolenGroupKey string
+	if g.workStealing != nil {
+		stolenGroupKey = g.workStealing.stolenGroupKey
 	}
-	// Metrics for the remaining planned compactions
+	// Whether the groups are shared with the other compactors of the subring, and the ones this
+	// compactor has no capacity to compact published to be stolen.
+	sharing := g.workStealing != nil && stolenGroupKey == ""
+
+	var subRing ring.ReadRing
 	var remainingCompactions = 0.
-	defer func() { g.remainingPlannedCompactions.Set(remainingCompactions) }()
+	if stolenGroupKey == "" {
+		subRing = g.ring.ShuffleShard(g.userID, g.limits.CompactorTenantShardSize(g.userID))
+
+		// Check if this compactor is on the subring.
+		// If the compactor is not on the subring when using the userID as a identifier
+		// no plans generated below will be owned by the compactor so we can just return an empty array
+		// as there will be no planned groups
+		onSubring, err := g.checkSubringForCompactor(subRing)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to check sub-ring for compactor ownership")
+		}
+		if !onSubring {
+			level.Debug(g.logger).Log("msg", "compactor is not on the current sub-ring skipping user", "user", g.userID)
+			return outGroups, nil
+		}
+		// Metrics for the remaining planned compactions
+		defer func() { g.remainingPlannedCompactions.Set(remainingCompactions) }()
+	}
+
+	// Groups owned by this compactor which it has no capacity to compact, published to be stolen
+	// by the other compactors.
+	var surplusGroupKeys []string
 
 	var groups []blocksGroup
 	for _, mainBlocks := range mainGroups {
@@ -218,6 +249,31 @@ mainLoop:
 		}
 
 		groupHash := hashGroup(g.userID, group.rangeStart, group.rangeEnd)
+		groupKey := createGroupKey(groupHash, group)
+
+		if stolenGroupKey != "" && groupKey != stolenGroupKey {
+			continue
+		}
+
+		if sharing {
+			// Each group is planned by a single compactor of the subring.
+			if owned, err := g.ownGroup(subRing, groupHash); err != nil {
+				level.Warn(g.logger).Log("msg", "unable to check if group is owned by this compactor", "group_hash", groupHash, "err", err)
+				continue
+			} else if !owned {
+				level.Debug(g.logger).Log("msg", "skipping group because it is owned by another compactor", "group_hash", groupHash)
+				continue
+			}
+		}
+
+		if len(outGroups) == g.compactionConcurrency {
+			// The group is left to the next runs, or to the other compactors if shared.
+			if sharing {
+				surplusGroupKeys = append(surplusGroupKeys, groupKey)
+			}
+			g.plannedGroups = append(g.plannedGroups, group.blocks)
+			continue
+		}
 
 		if isVisited, err := g.isGroupVisited(group.blocks, g.ringLifecyclerID); err != nil {
 			level.Warn(g.logger).Log("msg", "unable to check if blocks in group are visited", "group hash", groupHash, "err", err, "group", group.String())
@@ -227,8 +283,18 @@ mainLoop:
 			continue
 		}
 
+		if sharing {
+			// The group can't be compacted if it has been stolen by another compactor in the meantime.
+			if taken, err := g.workStealing.take(g.ctx, groupKey); err != nil {
+				level.Warn(g.logger).Log("msg", "unable to check if group has been stolen by another compactor", "group_hash", groupHash, "err", err)
+				continue
+			} else if !taken {
+				level.Info(g.logger).Log("msg", "skipping group because it has been stolen by another compactor", "group_hash", groupHash)
+				continue
+			}
+		}
+
 		remainingCompactions++
-		groupKey := createGroupKey(groupHash, group)
 
 		level.Info(g.logger).Log("msg", "found compactable group for user", "group_hash", groupHash, "group", group.String())
 		blockVisitMarker := BlockVisitMarker{
@@ -273,17 +339,38 @@ mainLoop:
 			}
 		}
 
+		// Carry over the Cortex extensions (eg. the metric metadata) of the source blocks
+		// to the compacted block.
+		if ext := cortex_tsdb.MergeBlockExtensions(group.blocks); ext != nil {
+			thanosGroup.SetExtensions(ext)
+		}
+
 		outGroups = append(outGroups, thanosGroup)
-		if len(outGroups) == g.compactionConcurrency {
+		g.plannedGroups = append(g.plannedGroups, group.blocks)
+		if stolenGroupKey != "" {
 			break mainLoop
 		}
 	}
 
+	if sharing {
+		if err := g.workStealing.publish(g.ctx, surplusGroupKeys); err != nil {
+			level.Warn(g.logger).Log("msg", "unable to publish the groups to be stolen by the other compactors", "groups", len(surplusGroupKeys), "err", err)
+		} else if len(surplusGroupKeys) > 0 {
+			level.Info(g.logger).Log("msg", "published groups to be stolen by the other compactors", "groups", len(surplusGroupKeys))
+		}
+	}
+
 	level.Info(g.logger).Log("msg", fmt.Sprintf("total groups for compaction: %d", len(outGroups)))
 
 	return outGroups, nil
 }
 
+// PlannedGroups returns the blocks of the groups planned by the last call to Groups, including
+// the ones not returned because this compactor has no capacity to compact them yet.
+func (g *ShuffleShardingGrouper) PlannedGroups() [][]*metadata.Meta {
+	return g.plannedGroups
+}
+
 func (g *ShuffleShardingGrouper) isGroupVisited(blocks []*metadata.Meta, compactorID string) (bool, error) {
 	for _, block := range blocks {
 		blockID := block.ULID.String()
@@ -305,9 +392,7 @@ func (g *ShuffleShardingGrouper) isGroupVisited(blocks []*metadata.Meta, compact
 }
 
 // Check whether this compactor exists on the subring based on user ID
-func (g *ShuffleShardingGrouper) checkSubringForCompactor() (bool, error) {
-	subRing := g.ring.ShuffleShard(g.userID, g.limits.CompactorTenantShardSize(g.userID))
-
+func (g *ShuffleShardingGrouper) checkSubringForCompactor(subRing ring.ReadRing) (bool, error) {
 	rs, err := subRing.GetAllHealthy(RingOp)
 	if err != nil {
 		return false, err
@@ -316,6 +401,20 @@ func (g *ShuffleShardingGrouper) checkSubringForCompactor() (bool, error) {
 	return rs.Includes(g.ringLifecyclerAddr), nil
 }
 
+// ownGroup returns whether this compactor is the one of the subring planning the group.
+func (g *ShuffleShardingGrouper) ownGroup(subRing ring.ReadRing, groupHash uint32) (bool, error) {
+	rs, err := subRing.Get(groupHash, RingOp, nil, nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	if len(rs.Instances) != 1 {
+		return false, fmt.Errorf("unexpected number of compactors in the shard (expected 1, got %d)", len(rs.Instances))
+	}
+
+	return rs.Instances[0].Addr == g.ringLifecyclerAddr, nil
+}
+
 // Get the hash of a group based on the UserID, and the starting and ending time of the group's range.
 func hashGroup(userID string, rangeStart int64, rangeEnd int64) uint32 {
 	groupString := fmt.Sprintf("%v%v%v", userID, rangeStart, rangeEnd)
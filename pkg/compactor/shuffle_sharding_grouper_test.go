@@ -410,6 +410,7 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 				blockVisitMarkerReadFailed,
 				blockVisitMarkerWriteFailed,
 				noCompactFilter,
+				nil,
 			)
 			actual, err := g.Groups(testData.blocks)
 			require.NoError(t, err)
@@ -425,6 +426,109 @@ func TestShuffleShardingGrouper_Groups(t *testing.T) {
 	}
 }
 
+func TestShuffleShardingGrouper_WorkStealing(t *testing.T) {
+	block0hto1hExt1Ulid := ulid.MustNew(1, nil)
+	block1hto2hExt1Ulid := ulid.MustNew(2, nil)
+	block2hto3hExt1Ulid := ulid.MustNew(3, nil)
+	block3hto4hExt1Ulid := ulid.MustNew(4, nil)
+	block0hto1hExt2Ulid := ulid.MustNew(5, nil)
+	block1hto2hExt2Ulid := ulid.MustNew(6, nil)
+
+	blocks := map[ulid.ULID]*metadata.Meta{}
+	for id, meta := range map[ulid.ULID]struct {
+		minT, maxT time.Duration
+		external   string
+	}{
+		block0hto1hExt1Ulid: {0, time.Hour, "1"},
+		block1hto2hExt1Ulid: {time.Hour, 2 * time.Hour, "1"},
+		block2hto3hExt1Ulid: {2 * time.Hour, 3 * time.Hour, "1"},
+		block3hto4hExt1Ulid: {3 * time.Hour, 4 * time.Hour, "1"},
+		block0hto1hExt2Ulid: {0, time.Hour, "2"},
+		block1hto2hExt2Ulid: {time.Hour, 2 * time.Hour, "2"},
+	} {
+		blocks[id] = &metadata.Meta{
+			BlockMeta: tsdb.BlockMeta{ULID: id, MinTime: meta.minT.Milliseconds(), MaxTime: meta.maxT.Milliseconds()},
+			Thanos:    metadata.Thanos{Labels: map[string]string{"external": meta.external}},
+		}
+	}
+
+	now := time.Now()
+	queue := newTestWorkStealingQueue(t, &now)
+	bkt := objstore.WithNoopInstr(objstore.NewInMemBucket())
+
+	// The groups of the 0h-2h range are owned by this compactor, the group of the 2h-4h range by another one.
+	subring := &RingMock{}
+	subring.On("GetAllHealthy", mock.Anything).Return(ring.ReplicationSet{Instances: []ring.InstanceDesc{{Addr: "owner-addr"}, {Addr: "other-addr"}}}, nil)
+	subring.On("Get", hashGroup("user-1", 2*time.Hour.Milliseconds(), 4*time.Hour.Milliseconds()), mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(ring.ReplicationSet{Instances: []ring.InstanceDesc{{Addr: "other-addr"}}}, nil)
+	subring.On("Get", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(ring.ReplicationSet{Instances: []ring.InstanceDesc{{Addr: "owner-addr"}}}, nil)
+	ownerRing := &RingMock{}
+	ownerRing.On("ShuffleShard", mock.Anything, mock.Anything).Return(subring, nil)
+
+	newGrouper := func(r ring.ReadRing, addr, compactorID, stolenGroupKey string) *ShuffleShardingGrouper {
+		registerer := prometheus.NewPedanticRegistry()
+		overrides, err := validation.NewOverrides(validation.Limits{}, nil)
+		require.NoError(t, err)
+
+		return NewShuffleShardingGrouper(
+			context.Background(),
+			nil,
+			bkt,
+			false, // Do not accept malformed indexes
+			true,  // Enable vertical compaction
+			registerer,
+			nil,
+			nil,
+			nil,
+			promauto.With(registerer).NewGauge(prometheus.GaugeOpts{Name: "cortex_compactor_remaining_planned_compactions"}),
+			metadata.NoneFunc,
+			Config{BlockRanges: []time.Duration{2 * time.Hour}},
+			r,
+			addr,
+			compactorID,
+			overrides,
+			"user-1",
+			10,
+			3,
+			1,
+			5*time.Minute,
+			promauto.With(registerer).NewCounter(prometheus.CounterOpts{Name: "cortex_compactor_block_visit_marker_read_failed"}),
+			promauto.With(registerer).NewCounter(prometheus.CounterOpts{Name: "cortex_compactor_block_visit_marker_write_failed"}),
+			func() map[ulid.ULID]*metadata.NoCompactMark { return nil },
+			&WorkStealingJobs{queue: queue, userID: "user-1", compactorID: compactorID, stolenGroupKey: stolenGroupKey},
+		)
+	}
+
+	// The owner compacts one of its groups, and publishes the other one.
+	actual, err := newGrouper(ownerRing, "owner-addr", "owner", "").Groups(blocks)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	assert.Equal(t, []ulid.ULID{block0hto1hExt2Ulid, block1hto2hExt2Ulid}, actual[0].IDs())
+
+	published := readStealableJobs(t, queue, "user-1")
+	require.Len(t, published, 1)
+	assert.Equal(t, "owner", published[0].Owner)
+
+	// Another compactor, not in the tenant shard, steals the published group.
+	job, err := queue.steal(context.Background(), "thief", func(string) bool { return true })
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	actual, err = newGrouper(&RingMock{}, "thief-addr", "thief", job.GroupKey).Groups(blocks)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	assert.Equal(t, []ulid.ULID{block0hto1hExt1Ulid, block1hto2hExt1Ulid}, actual[0].IDs())
+
+	// The owner doesn't compact the stolen group.
+	actual, err = newGrouper(ownerRing, "owner-addr", "owner", "").Groups(blocks)
+	require.NoError(t, err)
+	require.Len(t, actual, 1)
+	assert.Equal(t, []ulid.ULID{block0hto1hExt2Ulid, block1hto2hExt2Ulid}, actual[0].IDs())
+
+	published = readStealableJobs(t, queue, "user-1")
+	require.Len(t, published, 1)
+	assert.Equal(t, "thief", published[0].ClaimedBy)
+}
+
 func TestGroupBlocksByCompactableRanges(t *testing.T) {
 	tests := map[string]struct {
 		ranges   []int64
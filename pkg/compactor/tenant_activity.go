@@ -0,0 +1,142 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/runutil"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+)
+
+const (
+	// tenantActivityFile is the known json filename of the marker storing the last successful
+	// compaction of a tenant.
+	tenantActivityFile = "compactor-activity.json"
+	// tenantActivityVersion1 is the current supported version of the tenant activity marker.
+	tenantActivityVersion1 = 1
+)
+
+// tenantActivity is the marker of the last successful compaction of a tenant, stored in the
+// tenant directory so that the tenants whose blocks have not changed since then can be skipped
+// without listing their blocks, even after a compactor restart or a resharding.
+//
+// The marker is written by the compactor when the compaction completes, and the fingerprint of
+// the bucket index is recorded by the first blocks cleaner pass started after that, given the
+// blocks produced by the compaction are added to the bucket index by the cleaner. A tenant is
+// unchanged if its bucket index still has the same blocks as when the fingerprint was taken.
+// The compaction runs until there are no more planned jobs, so it has nothing left to do until
+// new blocks get uploaded. However some blocks only become eligible for compaction over time
+// (ie. once older than the consistency delay), or may be uploaded after the last planning of
+// the compaction, so the tenants are compacted again at least once every max age.
+type tenantActivity struct {
+	Version int `json:"version"`
+	// The unix timestamp (seconds) the compaction started at.
+	StartedAt int64 `json:"started_at"`
+	// The unix timestamp (seconds) the compaction completed at.
+	CompletedAt int64 `json:"completed_at"`
+	// The fingerprint of the bucket index blocks, and the unix timestamp (seconds) the cleaner
+	// pass which has taken it started at. Zero until the fingerprint has been taken.
+	Fingerprint uint64 `json:"fingerprint,omitempty"`
+	IndexedAt   int64  `json:"indexed_at,omitempty"`
+}
+
+// unchanged returns whether the tenant bucket index, with the input fingerprint, is unchanged
+// since the compaction.
+func (a *tenantActivity) unchanged(fingerprint uint64, now time.Time, maxAge time.Duration) bool {
+	startedAt := time.Unix(a.StartedAt, 0)
+	return a.IndexedAt > 0 && a.Fingerprint == fingerprint && now.Sub(startedAt) < maxAge
+}
+
+// indexedBy returns whether the fingerprint should be taken by the cleaner pass started at the
+// input time. The timestamps have a seconds precision, so the pass must have started at least
+// in the second following the completion of the compaction.
+func (a *tenantActivity) indexedBy(cleanupStartedAt time.Time) bool {
+	return a.IndexedAt == 0 && a.CompletedAt < cleanupStartedAt.Unix()
+}
+
+// readTenantActivity reads the tenant activity marker. It returns nil if the marker doesn't exist.
+func readTenantActivity(ctx context.Context, bkt objstore.Bucket, userID string, logger log.Logger) (*tenantActivity, error) {
+	userBkt := bucket.NewPrefixedBucketClient(bkt, userID)
+
+	reader, err := userBkt.WithExpectedErrs(userBkt.IsObjNotFoundErr).Get(ctx, tenantActivityFile)
+	if err != nil {
+		if userBkt.IsObjNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read tenant activity marker")
+	}
+	defer runutil.CloseWithLogOnErr(logger, reader, "close tenant activity marker reader")
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "read tenant activity marker")
+	}
+
+	activity := &tenantActivity{}
+	if err := json.Unmarshal(content, activity); err != nil {
+		return nil, errors.Wrap(err, "unmarshal tenant activity marker")
+	}
+	if activity.Version != tenantActivityVersion1 {
+		return nil, errors.Errorf("unsupported tenant activity marker version %d", activity.Version)
+	}
+	return activity, nil
+}
+
+// writeTenantActivity writes the tenant activity marker.
+func writeTenantActivity(ctx context.Context, bkt objstore.Bucket, userID string, activity *tenantActivity) error {
+	content, err := json.Marshal(activity)
+	if err != nil {
+		return errors.Wrap(err, "marshal tenant activity marker")
+	}
+
+	userBkt := bucket.NewPrefixedBucketClient(bkt, userID)
+	return errors.Wrap(userBkt.Upload(ctx, tenantActivityFile, bytes.NewReader(content)), "upload tenant activity marker")
+}
+
+// deleteTenantActivity deletes the tenant activity marker. No error is returned if the marker
+// doesn't exist.
+func deleteTenantActivity(ctx context.Context, bkt objstore.Bucket, userID string) error {
+	userBkt := bucket.NewPrefixedBucketClient(bkt, userID)
+
+	err := userBkt.Delete(ctx, tenantActivityFile)
+	if err != nil && !userBkt.IsObjNotFoundErr(err) {
+		return errors.Wrap(err, "delete tenant activity marker")
+	}
+	return nil
+}
+
+// indexFingerprint returns a hash of the blocks in the bucket index which are not marked for
+// deletion, so that the deletion of the blocks compacted into other blocks doesn't change it.
+func indexFingerprint(idx *bucketindex.Index) uint64 {
+	deleted := idx.BlockDeletionMarks.GetULIDs()
+	marked := make(map[ulid.ULID]struct{}, len(deleted))
+	for _, id := range deleted {
+		marked[id] = struct{}{}
+	}
+
+	ids := make([]string, 0, len(idx.Blocks))
+	for _, b := range idx.Blocks {
+		if _, ok := marked[b.ID]; ok {
+			continue
+		}
+		ids = append(ids, b.ID.String())
+	}
+	sort.Strings(ids)
+
+	h := xxhash.New()
+	for _, id := range ids {
+		_, _ = h.WriteString(id)
+	}
+	return h.Sum64()
+}
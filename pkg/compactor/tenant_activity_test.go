@@ -0,0 +1,131 @@
+package compactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	cortex_storage_testutil "github.com/cortexproject/cortex/pkg/storage/tsdb/testutil"
+)
+
+func TestCompactor_CheckTenantActivity(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bucketClient, _ := cortex_storage_testutil.PrepareFilesystemBucket(t)
+
+	cfg := prepareConfig()
+	cfg.SkipUnchangedTenantsMaxAge = time.Hour
+	c, _, _, _, _ := prepare(t, cfg, bucketClient, nil)
+	c.bucketClient = bucketClient
+
+	logger := log.NewNopLogger()
+	owned := true
+	scanner := cortex_tsdb.NewUsersScanner(bucketClient, func(string) (bool, error) { return owned, nil }, logger)
+	cleaner := NewBlocksCleaner(BlocksCleanerConfig{TenantActivityEnabled: true}, bucketClient, scanner, newMockConfigProvider(), logger, nil)
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	now := time.Unix(time.Now().Unix(), 0)
+	writeIndex := func(deleted []ulid.ULID, blocks ...ulid.ULID) *bucketindex.Index {
+		idx := &bucketindex.Index{Version: bucketindex.IndexVersion1, UpdatedAt: now.Unix()}
+		for _, id := range blocks {
+			idx.Blocks = append(idx.Blocks, &bucketindex.Block{ID: id})
+		}
+		for _, id := range deleted {
+			idx.BlockDeletionMarks = append(idx.BlockDeletionMarks, &bucketindex.BlockDeletionMark{ID: id})
+		}
+		require.NoError(t, bucketindex.WriteIndex(ctx, bucketClient, userID, nil, idx))
+		return idx
+	}
+
+	// The user has never been compacted.
+	writeIndex(nil, block1, block2)
+	assert.False(t, c.checkTenantActivity(ctx, userID, now))
+
+	// The marker is written after the compaction, without the fingerprint of the bucket index
+	// which doesn't include the blocks produced by the compaction yet.
+	c.recordTenantActivity(ctx, userID, now, now.Add(time.Minute))
+	activity, err := readTenantActivity(ctx, bucketClient, userID, c.logger)
+	require.NoError(t, err)
+	require.NotNil(t, activity)
+	assert.Equal(t, now.Unix(), activity.StartedAt)
+	assert.Equal(t, now.Add(time.Minute).Unix(), activity.CompletedAt)
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(2*time.Minute)))
+
+	// The cleaner pass started before the compaction completed doesn't take the fingerprint.
+	idx := writeIndex(nil, block1, block2)
+	cleaner.updateTenantActivity(ctx, userID, now.Add(time.Minute), idx, logger)
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(2*time.Minute)))
+
+	// The cleaner doesn't take the fingerprint of a user it doesn't own anymore.
+	owned = false
+	idx = writeIndex([]ulid.ULID{block1, block2}, block1, block2, block3)
+	cleaner.updateTenantActivity(ctx, userID, now.Add(2*time.Minute), idx, logger)
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+
+	// The next cleaner pass takes the fingerprint of the bucket index including the compacted block.
+	owned = true
+	cleaner.updateTenantActivity(ctx, userID, now.Add(2*time.Minute), idx, logger)
+	assert.True(t, c.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+
+	// The fingerprint is taken once.
+	idx = writeIndex(nil, block1)
+	cleaner.updateTenantActivity(ctx, userID, now.Add(3*time.Minute), idx, logger)
+	activity, err = readTenantActivity(ctx, bucketClient, userID, c.logger)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(2*time.Minute).Unix(), activity.IndexedAt)
+
+	// The deletion of the compacted blocks doesn't change the fingerprint.
+	writeIndex(nil, block3)
+	assert.True(t, c.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+
+	// The marker is shared across compactors, ie. after a restart or a resharding.
+	c2, _, _, _, _ := prepare(t, cfg, bucketClient, nil)
+	c2.bucketClient = bucketClient
+	assert.True(t, c2.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+
+	// The user is compacted again once the max age is reached.
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(time.Hour)))
+
+	// The user is compacted if it has jobs waiting for approval.
+	c.compactionJobs.plan(userID, []*metadata.Meta{{BlockMeta: tsdb.BlockMeta{ULID: block3}}})
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+	c.compactionJobs.removeStale(userID, now.Add(time.Hour))
+
+	// The user is compacted if its blocks have changed.
+	writeIndex(nil, block3, block1)
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+
+	// The user is compacted once the marker has been deleted.
+	writeIndex(nil, block3)
+	require.NoError(t, deleteTenantActivity(ctx, bucketClient, userID))
+	assert.False(t, c.checkTenantActivity(ctx, userID, now.Add(3*time.Minute)))
+}
+
+func TestCompactor_CheckTenantActivity_Disabled(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bucketClient, _ := cortex_storage_testutil.PrepareFilesystemBucket(t)
+	c, _, _, _, _ := prepare(t, prepareConfig(), bucketClient, nil)
+	c.bucketClient = bucketClient
+
+	// The marker is not written when the feature is disabled.
+	c.recordTenantActivity(ctx, userID, time.Now(), time.Now())
+	activity, err := readTenantActivity(ctx, bucketClient, userID, c.logger)
+	require.NoError(t, err)
+	assert.Nil(t, activity)
+
+	assert.False(t, c.checkTenantActivity(ctx, userID, time.Now()))
+}
@@ -0,0 +1,291 @@
+package compactor
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"math/rand"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+var (
+	errInvalidWorkStealingShardingStrategy = errors.New("the compactor work stealing requires the shuffle-sharding strategy to be enabled")
+	errInvalidWorkStealingPollInterval     = errors.New("invalid compactor work stealing poll interval, the value must be greater than 0")
+)
+
+// WorkStealingConfig configures the stealing of the compaction jobs between the compactors.
+type WorkStealingConfig struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	KVStore      kv.Config     `yaml:"kvstore" doc:"description=Backend storage to use for the queue of the compaction jobs which can be stolen, shared by all compactors. Please be aware that memberlist is not supported."`
+}
+
+func (cfg *WorkStealingConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "compactor.work-stealing.enabled", false, "[Experimental] True to let the idle compactors steal the compaction jobs of the other compactors. Requires the shuffle-sharding strategy. Each compaction group of a tenant is planned by a single compactor of the tenant shard, which publishes the groups it has no capacity to compact in a queue shared by all compactors.")
+	f.DurationVar(&cfg.PollInterval, "compactor.work-stealing.poll-interval", time.Minute, "How frequently the compactor looks for compaction jobs to steal from the other compactors.")
+	cfg.KVStore.RegisterFlagsWithPrefix("compactor.work-stealing.", "compactor-jobs/", f)
+}
+
+func (cfg *WorkStealingConfig) Validate(shardingEnabled bool, shardingStrategy string) error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !shardingEnabled || shardingStrategy != util.ShardingStrategyShuffle {
+		return errInvalidWorkStealingShardingStrategy
+	}
+	if cfg.PollInterval <= 0 {
+		return errInvalidWorkStealingPollInterval
+	}
+	return nil
+}
+
+// stealableJob is a compaction group of a tenant published by the compactor owning it, because it
+// has no capacity to compact it. The job is compacted by the compactor which has claimed it, or by
+// its owner if not claimed.
+type stealableJob struct {
+	User      string `json:"-"`
+	GroupKey  string `json:"group_key"`
+	Owner     string `json:"owner"`
+	ClaimedBy string `json:"claimed_by,omitempty"`
+	// ClaimedAt is a unix timestamp of when the job was claimed.
+	ClaimedAt int64 `json:"claimed_at,omitempty"`
+}
+
+// stealableJobs are the jobs of a tenant, stored as JSON under the tenant key.
+type stealableJobs struct {
+	Jobs []stealableJob `json:"jobs"`
+}
+
+// workStealingQueue is the queue of the compaction jobs which can be stolen, stored in a KV store
+// shared by all compactors. The jobs of a tenant are stored under the same key, so that claiming a
+// job is atomic with regard to its owner taking it back. A claim expires after the timeout, then the
+// job can be claimed again or compacted by its owner; the visit markers written by the compactor
+// which has claimed it still prevent its blocks from being compacted twice.
+type workStealingQueue struct {
+	kv           kv.Client
+	claimTimeout time.Duration
+	logger       log.Logger
+	now          func() time.Time
+
+	jobsPublished prometheus.Counter
+	jobsStolen    prometheus.Counter
+}
+
+func newWorkStealingQueue(cfg WorkStealingConfig, claimTimeout time.Duration, logger log.Logger, reg prometheus.Registerer) (*workStealingQueue, error) {
+	client, err := kv.NewClient(cfg.KVStore, codec.String{}, kv.RegistererWithKVName(reg, "compactor-work-stealing"), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workStealingQueue{
+		kv:           client,
+		claimTimeout: claimTimeout,
+		logger:       logger,
+		now:          time.Now,
+		jobsPublished: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_work_stealing_published_jobs_total",
+			Help: "Total number of compaction jobs published by this compactor to be stolen by the other compactors.",
+		}),
+		jobsStolen: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_compactor_work_stealing_stolen_jobs_total",
+			Help: "Total number of compaction jobs stolen by this compactor from the other compactors.",
+		}),
+	}, nil
+}
+
+// publish replaces the unclaimed jobs of the tenant published by the owner with the input groups.
+func (q *workStealingQueue) publish(ctx context.Context, userID, owner string, groupKeys []string) error {
+	published := 0
+	err := q.kv.CAS(ctx, userID, func(in interface{}) (out interface{}, retry bool, err error) {
+		jobs := decodeStealableJobs(in)
+		published = 0
+
+		pending := make(map[string]struct{}, len(groupKeys))
+		for _, key := range groupKeys {
+			pending[key] = struct{}{}
+		}
+
+		changed := false
+		kept := jobs.Jobs[:0]
+		for _, job := range jobs.Jobs {
+			if q.isClaimed(job) {
+				// The group is being compacted by the compactor which has claimed it.
+				delete(pending, job.GroupKey)
+				kept = append(kept, job)
+				continue
+			}
+			if _, ok := pending[job.GroupKey]; ok {
+				delete(pending, job.GroupKey)
+				changed = changed || job.Owner != owner || job.ClaimedBy != ""
+				kept = append(kept, stealableJob{GroupKey: job.GroupKey, Owner: owner})
+				continue
+			}
+			if job.Owner != owner {
+				kept = append(kept, job)
+				continue
+			}
+			// The group is not planned anymore by its owner.
+			changed = true
+		}
+
+		for _, key := range groupKeys {
+			if _, ok := pending[key]; ok {
+				kept = append(kept, stealableJob{GroupKey: key, Owner: owner})
+				published++
+			}
+		}
+
+		if !changed && published == 0 {
+			return nil, false, nil
+		}
+		jobs.Jobs = kept
+		return encodeStealableJobs(jobs)
+	})
+	if err != nil {
+		return err
+	}
+
+	q.jobsPublished.Add(float64(published))
+	return nil
+}
+
+// take removes the job of the group from the queue, and returns whether the group can be compacted
+// by its owner, which is the case unless it has been claimed by another compactor.
+func (q *workStealingQueue) take(ctx context.Context, userID, groupKey, compactorID string) (bool, error) {
+	taken := true
+	err := q.kv.CAS(ctx, userID, func(in interface{}) (out interface{}, retry bool, err error) {
+		jobs := decodeStealableJobs(in)
+		taken = true
+
+		for i, job := range jobs.Jobs {
+			if job.GroupKey != groupKey {
+				continue
+			}
+			if job.ClaimedBy != compactorID && q.isClaimed(job) {
+				taken = false
+				return nil, false, nil
+			}
+			jobs.Jobs = append(jobs.Jobs[:i], jobs.Jobs[i+1:]...)
+			return encodeStealableJobs(jobs)
+		}
+		return nil, false, nil
+	})
+	return taken, err
+}
+
+// steal claims a job published by another compactor, and returns nil if there's no job to steal.
+// Only the jobs of the tenants for which the filter returns true are stolen.
+func (q *workStealingQueue) steal(ctx context.Context, compactorID string, filter func(userID string) bool) (*stealableJob, error) {
+	users, err := q.kv.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Spread the compactors stealing at the same time across the tenants.
+	rand.Shuffle(len(users), func(i, j int) {
+		users[i], users[j] = users[j], users[i]
+	})
+
+	for _, userID := range users {
+		if !filter(userID) {
+			continue
+		}
+
+		var stolen *stealableJob
+		err := q.kv.CAS(ctx, userID, func(in interface{}) (out interface{}, retry bool, err error) {
+			jobs := decodeStealableJobs(in)
+			stolen = nil
+
+			for i, job := range jobs.Jobs {
+				if job.Owner == compactorID || q.isClaimed(job) {
+					continue
+				}
+				job.ClaimedBy = compactorID
+				job.ClaimedAt = q.now().Unix()
+				jobs.Jobs[i] = job
+
+				job.User = userID
+				stolen = &job
+				return encodeStealableJobs(jobs)
+			}
+			return nil, false, nil
+		})
+		if err != nil {
+			level.Warn(q.logger).Log("msg", "failed to steal a compaction job", "user", userID, "err", err)
+			continue
+		}
+		if stolen != nil {
+			q.jobsStolen.Inc()
+			return stolen, nil
+		}
+	}
+	return nil, nil
+}
+
+// complete removes the stolen job from the queue, if still claimed by the compactor.
+func (q *workStealingQueue) complete(ctx context.Context, job *stealableJob, compactorID string) error {
+	return q.kv.CAS(ctx, job.User, func(in interface{}) (out interface{}, retry bool, err error) {
+		jobs := decodeStealableJobs(in)
+		for i, j := range jobs.Jobs {
+			if j.GroupKey == job.GroupKey && j.ClaimedBy == compactorID {
+				jobs.Jobs = append(jobs.Jobs[:i], jobs.Jobs[i+1:]...)
+				return encodeStealableJobs(jobs)
+			}
+		}
+		return nil, false, nil
+	})
+}
+
+func (q *workStealingQueue) isClaimed(job stealableJob) bool {
+	return job.ClaimedBy != "" && q.now().Before(time.Unix(job.ClaimedAt, 0).Add(q.claimTimeout))
+}
+
+// decodeStealableJobs returns the jobs from the KV store value, which are empty if the value is
+// missing or invalid.
+func decodeStealableJobs(value interface{}) stealableJobs {
+	jobs := stealableJobs{}
+	if s, ok := value.(string); ok && s != "" {
+		_ = json.Unmarshal([]byte(s), &jobs)
+	}
+	return jobs
+}
+
+func encodeStealableJobs(jobs stealableJobs) (interface{}, bool, error) {
+	b, err := json.Marshal(jobs)
+	if err != nil {
+		return nil, false, err
+	}
+	return string(b), true, nil
+}
+
+// WorkStealingJobs gives the grouper access to the compaction jobs of a tenant shared through the
+// work stealing queue. It is nil if the work stealing is disabled.
+type WorkStealingJobs struct {
+	queue       *workStealingQueue
+	userID      string
+	compactorID string
+
+	// stolenGroupKey is the key of the group to compact, if the tenant is compacted because of
+	// a job stolen from another compactor.
+	stolenGroupKey string
+}
+
+// take returns whether this compactor, owning the group, can compact it.
+func (w *WorkStealingJobs) take(ctx context.Context, groupKey string) (bool, error) {
+	return w.queue.take(ctx, w.userID, groupKey, w.compactorID)
+}
+
+// publish publishes the groups owned by this compactor which it has no capacity to compact.
+func (w *WorkStealingJobs) publish(ctx context.Context, groupKeys []string) error {
+	return w.queue.publish(ctx, w.userID, w.compactorID, groupKeys)
+}
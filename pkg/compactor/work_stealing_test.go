@@ -0,0 +1,112 @@
+package compactor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+)
+
+func newTestWorkStealingQueue(t *testing.T, now *time.Time) *workStealingQueue {
+	client, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	cfg := WorkStealingConfig{}
+	cfg.KVStore.Mock = client
+	q, err := newWorkStealingQueue(cfg, 5*time.Minute, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, err)
+	q.now = func() time.Time { return *now }
+	return q
+}
+
+func TestWorkStealingQueue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(10000, 0)
+	q := newTestWorkStealingQueue(t, &now)
+	allowAll := func(string) bool { return true }
+
+	require.NoError(t, q.publish(ctx, "user-1", "compactor-1", []string{"group-1", "group-2"}))
+
+	// The owner doesn't steal its own jobs.
+	job, err := q.steal(ctx, "compactor-1", allowAll)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	// The tenants not allowed are skipped.
+	job, err = q.steal(ctx, "compactor-2", func(string) bool { return false })
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	stolen, err := q.steal(ctx, "compactor-2", allowAll)
+	require.NoError(t, err)
+	require.NotNil(t, stolen)
+	assert.Equal(t, "user-1", stolen.User)
+	assert.Equal(t, "group-1", stolen.GroupKey)
+	assert.Equal(t, "compactor-1", stolen.Owner)
+
+	// The owner can't compact the stolen group, but can take back the other one.
+	taken, err := q.take(ctx, "user-1", "group-1", "compactor-1")
+	require.NoError(t, err)
+	assert.False(t, taken)
+	taken, err = q.take(ctx, "user-1", "group-2", "compactor-1")
+	require.NoError(t, err)
+	assert.True(t, taken)
+
+	// There's nothing left to steal.
+	job, err = q.steal(ctx, "compactor-3", allowAll)
+	require.NoError(t, err)
+	assert.Nil(t, job)
+
+	// Publishing again keeps the claimed job, and removes the groups not planned anymore.
+	require.NoError(t, q.publish(ctx, "user-1", "compactor-1", []string{"group-1", "group-3"}))
+	require.NoError(t, q.publish(ctx, "user-1", "compactor-1", []string{"group-1", "group-4"}))
+	assert.Equal(t, []stealableJob{
+		{GroupKey: "group-1", Owner: "compactor-1", ClaimedBy: "compactor-2", ClaimedAt: 10000},
+		{GroupKey: "group-4", Owner: "compactor-1"},
+	}, readStealableJobs(t, q, "user-1"))
+
+	// The job is removed once compacted by the compactor which has claimed it.
+	require.NoError(t, q.complete(ctx, stolen, "compactor-3"))
+	assert.Len(t, readStealableJobs(t, q, "user-1"), 2)
+	require.NoError(t, q.complete(ctx, stolen, "compactor-2"))
+	assert.Equal(t, []stealableJob{{GroupKey: "group-4", Owner: "compactor-1"}}, readStealableJobs(t, q, "user-1"))
+}
+
+func TestWorkStealingQueue_ClaimTimeout(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(10000, 0)
+	q := newTestWorkStealingQueue(t, &now)
+	allowAll := func(string) bool { return true }
+
+	require.NoError(t, q.publish(ctx, "user-1", "compactor-1", []string{"group-1"}))
+	job, err := q.steal(ctx, "compactor-2", allowAll)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+
+	// The job can be claimed again once the claim has expired.
+	now = now.Add(5 * time.Minute)
+	job, err = q.steal(ctx, "compactor-3", allowAll)
+	require.NoError(t, err)
+	require.NotNil(t, job)
+	assert.Equal(t, "group-1", job.GroupKey)
+
+	// The owner can take back the job once the claim has expired.
+	now = now.Add(5 * time.Minute)
+	taken, err := q.take(ctx, "user-1", "group-1", "compactor-1")
+	require.NoError(t, err)
+	assert.True(t, taken)
+	assert.Empty(t, readStealableJobs(t, q, "user-1"))
+}
+
+func readStealableJobs(t *testing.T, q *workStealingQueue, userID string) []stealableJob {
+	value, err := q.kv.Get(context.Background(), userID)
+	require.NoError(t, err)
+	return decodeStealableJobs(value).Jobs
+}
@@ -35,8 +35,16 @@ var (
 // Config configures Configs API
 type Config struct {
 	Notifications NotificationsConfig `yaml:"notifications"`
+
+	// Authorize, when set, is called for every request to the configs API with the tenant
+	// extracted from the request (empty for the internal APIs). The request is rejected
+	// with 403 if it returns an error.
+	Authorize AuthorizeFunc `yaml:"-"`
 }
 
+// AuthorizeFunc authorizes a request to the configs API made on behalf of the given tenant.
+type AuthorizeFunc func(r *http.Request, userID string) error
+
 // NotificationsConfig configures Alertmanager notifications method.
 type NotificationsConfig struct {
 	DisableEmail   bool `yaml:"disable_email"`
@@ -82,6 +90,7 @@ func (a *API) admin(w http.ResponseWriter, r *http.Request) {
 }
 
 // RegisterRoutes registers the configs API HTTP routes with the provided Router.
+// Every API is exposed both under its legacy /api/prom path and under /api/v1.
 func (a *API) RegisterRoutes(r *mux.Router) {
 	for _, route := range []struct {
 		name, method, path string
@@ -103,7 +112,31 @@ func (a *API) RegisterRoutes(r *mux.Router) {
 		{"private_get_rules", "GET", "/private/api/prom/configs/rules", a.getConfigs},
 		{"private_get_alertmanager_config", "GET", "/private/api/prom/configs/alertmanager", a.getConfigs},
 	} {
-		r.Handle(route.path, route.handler).Methods(route.method).Name(route.name)
+		if route.name == "root" {
+			r.Handle(route.path, route.handler).Methods(route.method).Name(route.name)
+			continue
+		}
+
+		handler := a.authorize(route.handler)
+		r.Handle(route.path, handler).Methods(route.method).Name(route.name)
+		r.Handle(strings.Replace(route.path, "/api/prom/", "/api/v1/", 1), handler).Methods(route.method).Name(route.name + "_v1")
+	}
+}
+
+// authorize wraps the handler with the configured authorization hook, if any.
+func (a *API) authorize(next http.HandlerFunc) http.HandlerFunc {
+	if a.cfg.Authorize == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The handlers reject the requests of the public APIs without a tenant.
+		userID, _, _ := tenant.ExtractTenantIDFromHTTPRequest(r)
+		if err := a.cfg.Authorize(r, userID); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		next(w, r)
 	}
 }
 
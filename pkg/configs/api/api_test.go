@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/cortexproject/cortex/pkg/configs/db/dbtest"
 	"github.com/cortexproject/cortex/pkg/configs/userconfig"
 
 	"github.com/stretchr/testify/assert"
@@ -125,6 +127,64 @@ func Test_PostConfig_UpdatesConfig(t *testing.T) {
 	}
 }
 
+// The configs are also exposed under the versioned API paths.
+func Test_PostConfig_VersionedAPI(t *testing.T) {
+	setup(t)
+	defer cleanup(t)
+
+	v1RulesClient := configurable{"/api/v1/configs/rules", "/private/api/v1/configs/rules"}
+
+	userID := makeUserID()
+	config := makeConfig()
+	view := v1RulesClient.post(t, userID, config)
+	assert.Equal(t, config, view.Config)
+
+	// The config set through the versioned API is served by the legacy one, and vice versa.
+	assert.Equal(t, view, rulesClient.get(t, userID))
+
+	w := request(t, "GET", v1RulesClient.PrivateEndpoint, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var found ConfigsView
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &found))
+	assert.Equal(t, ConfigsView{Configs: map[string]userconfig.View{userID: view}}, found)
+}
+
+// The requests rejected by the authorization hook get a 403.
+func Test_Authorize(t *testing.T) {
+	database = dbtest.Setup(t)
+	defer cleanup(t)
+
+	var authorizedUsers []string
+	app = New(database, Config{
+		Authorize: func(_ *http.Request, userID string) error {
+			authorizedUsers = append(authorizedUsers, userID)
+			if userID != "allowed" {
+				return errors.New("tenant not allowed")
+			}
+			return nil
+		},
+	})
+
+	for _, c := range allClients {
+		authorizedUsers = nil
+
+		w := requestAsUser(t, "allowed", "GET", c.Endpoint, "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = requestAsUser(t, "denied", "POST", c.Endpoint, "", readerFromConfig(t, makeConfig()))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w = request(t, "GET", c.PrivateEndpoint, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		assert.Equal(t, []string{"allowed", "denied", ""}, authorizedUsers)
+	}
+
+	// The root page isn't subject to authorization.
+	w := request(t, "GET", "/", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
 // Different users can have different configurations.
 func Test_PostConfig_MultipleUsers(t *testing.T) {
 	setup(t)
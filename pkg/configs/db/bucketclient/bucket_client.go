@@ -0,0 +1,464 @@
+package bucketclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/configs/userconfig"
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	"github.com/cortexproject/cortex/pkg/util/runutil"
+)
+
+const (
+	// The bucket prefix under which all tenants configs are stored.
+	// Note that objects stored under this prefix follow the pattern:
+	//     configs/<user-id>/<ulid>
+	configsPrefix = "configs"
+
+	// The key of the configs index in the KV store.
+	indexKey = "index"
+
+	// How many users to load concurrently.
+	fetchConcurrency = 16
+
+	// How many times a write is attempted when the index is concurrently updated.
+	maxWriteAttempts = 10
+
+	// How often the objects not referenced by the index are deleted.
+	orphansCleanupInterval = time.Hour
+
+	// The objects uploaded less than this ago aren't deleted by the cleanup, since they may be
+	// referenced by an index update in progress.
+	orphansGracePeriod = time.Hour
+)
+
+var errIndexChanged = errors.New("the configs index has changed")
+
+// DB is a configs database storing the configuration of each user as a JSON object in an
+// object storage bucket.
+//
+// The latest config of each user is tracked by an index stored in a KV store, which is the
+// only object polled for changes. The index is updated by compare-and-swap after uploading
+// the new config to a new object, so that the writes of several configs services are
+// serialised and the IDs are strictly increasing. The objects left unreferenced by the writes
+// failing to update the index are deleted by a periodic cleanup.
+type DB struct {
+	bkt    objstore.Bucket
+	kv     kv.Client
+	logger log.Logger
+	now    func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// index is the configs index stored in the KV store.
+type index struct {
+	// LastID is the ID of the last written config.
+	LastID userconfig.ID `json:"last_id"`
+	// Users is the latest config of each user.
+	Users map[string]indexEntry `json:"users"`
+}
+
+type indexEntry struct {
+	ID userconfig.ID `json:"id"`
+	// Object is the name of the bucket object storing the config.
+	Object string `json:"object"`
+}
+
+// New creates a new configs database backed by the given bucket, and the given KV client
+// storing the index. The KV client must use the string codec.
+func New(bkt objstore.Bucket, client kv.Client, logger log.Logger) *DB {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	d := &DB{
+		bkt:    bucket.NewPrefixedBucketClient(bkt, configsPrefix),
+		kv:     client,
+		logger: logger,
+		now:    time.Now,
+		cancel: cancel,
+	}
+
+	d.wg.Add(1)
+	go d.orphansCleanupLoop(ctx)
+
+	return d
+}
+
+// GetConfig gets the user's configuration.
+func (d *DB) GetConfig(ctx context.Context, userID string) (userconfig.View, error) {
+	for attempt := 0; ; attempt++ {
+		idx, err := d.readIndex(ctx)
+		if err != nil {
+			return userconfig.View{}, err
+		}
+		entry, ok := idx.Users[userID]
+		if !ok {
+			return userconfig.View{}, sql.ErrNoRows
+		}
+
+		// The object is deleted once replaced by a newer config, so the index is read again.
+		view, err := d.readConfig(ctx, userID, entry)
+		if err == sql.ErrNoRows && attempt < maxWriteAttempts {
+			continue
+		}
+		return view, err
+	}
+}
+
+// SetConfig sets configuration for a user.
+func (d *DB) SetConfig(ctx context.Context, userID string, cfg userconfig.Config) error {
+	if !cfg.RulesConfig.FormatVersion.IsValid() {
+		return fmt.Errorf("invalid rule format version %v", cfg.RulesConfig.FormatVersion)
+	}
+
+	_, err := d.write(ctx, userID, func(userconfig.View, bool) (userconfig.View, bool, error) {
+		return userconfig.View{Config: cfg}, true, nil
+	})
+	return err
+}
+
+// GetAllConfigs gets all of the userconfig.
+func (d *DB) GetAllConfigs(ctx context.Context) (map[string]userconfig.View, error) {
+	return d.findConfigs(ctx, -1)
+}
+
+// GetConfigs gets all of the configs that have changed recently.
+func (d *DB) GetConfigs(ctx context.Context, since userconfig.ID) (map[string]userconfig.View, error) {
+	return d.findConfigs(ctx, since)
+}
+
+// DeactivateConfig deactivates configuration for a user by writing it again with DeletedAt set to now.
+func (d *DB) DeactivateConfig(ctx context.Context, userID string) error {
+	return d.setDeletedAt(ctx, userID, d.now())
+}
+
+// RestoreConfig restores deactivated configuration for a user by writing it again with an empty DeletedAt.
+func (d *DB) RestoreConfig(ctx context.Context, userID string) error {
+	return d.setDeletedAt(ctx, userID, time.Time{})
+}
+
+// Close finishes using the db, stopping the cleanup of the orphaned objects.
+func (d *DB) Close() error {
+	d.cancel()
+	d.wg.Wait()
+	return nil
+}
+
+// GetRulesConfig gets the rules config for a user.
+func (d *DB) GetRulesConfig(ctx context.Context, userID string) (userconfig.VersionedRulesConfig, error) {
+	view, err := d.GetConfig(ctx, userID)
+	if err != nil {
+		return userconfig.VersionedRulesConfig{}, err
+	}
+	cfg := view.GetVersionedRulesConfig()
+	if cfg == nil {
+		return userconfig.VersionedRulesConfig{}, sql.ErrNoRows
+	}
+	return *cfg, nil
+}
+
+// SetRulesConfig sets the rules config for a user.
+func (d *DB) SetRulesConfig(ctx context.Context, userID string, oldConfig, newConfig userconfig.RulesConfig) (bool, error) {
+	if !newConfig.FormatVersion.IsValid() {
+		return false, fmt.Errorf("invalid rule format version %v", newConfig.FormatVersion)
+	}
+
+	return d.write(ctx, userID, func(current userconfig.View, exists bool) (userconfig.View, bool, error) {
+		// The supplied oldConfig must match the current config. If no config
+		// exists, then oldConfig must be nil. Otherwise, it must exactly
+		// equal the existing config.
+		if !((!exists && oldConfig.Files == nil) || oldConfig.Equal(current.Config.RulesConfig)) {
+			return userconfig.View{}, false, nil
+		}
+
+		return userconfig.View{Config: userconfig.Config{
+			AlertmanagerConfig: current.Config.AlertmanagerConfig,
+			RulesConfig:        newConfig,
+		}}, true, nil
+	})
+}
+
+// GetAllRulesConfigs gets the rules configs for all users that have them.
+func (d *DB) GetAllRulesConfigs(ctx context.Context) (map[string]userconfig.VersionedRulesConfig, error) {
+	return d.findRulesConfigs(ctx, -1)
+}
+
+// GetRulesConfigs gets the rules configs that have changed
+// since the given config version.
+func (d *DB) GetRulesConfigs(ctx context.Context, since userconfig.ID) (map[string]userconfig.VersionedRulesConfig, error) {
+	return d.findRulesConfigs(ctx, since)
+}
+
+func (d *DB) findRulesConfigs(ctx context.Context, since userconfig.ID) (map[string]userconfig.VersionedRulesConfig, error) {
+	views, err := d.findConfigs(ctx, since)
+	if err != nil {
+		return nil, err
+	}
+
+	cfgs := map[string]userconfig.VersionedRulesConfig{}
+	for userID, view := range views {
+		if cfg := view.GetVersionedRulesConfig(); cfg != nil {
+			cfgs[userID] = *cfg
+		}
+	}
+	return cfgs, nil
+}
+
+// findConfigs returns the configs of all the users with an ID greater than since. Only the
+// index is read if no config has changed.
+func (d *DB) findConfigs(ctx context.Context, since userconfig.ID) (map[string]userconfig.View, error) {
+	idx, err := d.readIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var userIDs []string
+	for userID, entry := range idx.Users {
+		if entry.ID > since {
+			userIDs = append(userIDs, userID)
+		}
+	}
+
+	var (
+		cfgsMx = sync.Mutex{}
+		cfgs   = make(map[string]userconfig.View, len(userIDs))
+	)
+
+	err = concurrency.ForEach(ctx, concurrency.CreateJobsFromStrings(userIDs), fetchConcurrency, func(ctx context.Context, job interface{}) error {
+		userID := job.(string)
+
+		view, err := d.readConfig(ctx, userID, idx.Users[userID])
+		if err == sql.ErrNoRows {
+			// The config has been replaced meanwhile, and the newer one is returned at the next poll.
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		cfgsMx.Lock()
+		cfgs[userID] = view
+		cfgsMx.Unlock()
+
+		return nil
+	})
+
+	return cfgs, err
+}
+
+func (d *DB) setDeletedAt(ctx context.Context, userID string, deletedAt time.Time) error {
+	_, err := d.write(ctx, userID, func(current userconfig.View, exists bool) (userconfig.View, bool, error) {
+		if !exists {
+			return userconfig.View{}, false, sql.ErrNoRows
+		}
+		current.DeletedAt = deletedAt
+		return current, true, nil
+	})
+	return err
+}
+
+// write writes the config of the user returned by the input function given the current one,
+// with a new ID. The function returns false to leave the config unchanged. The config is
+// uploaded to a new object, and the index is then updated to point to it if it hasn't changed
+// since the current config has been read, otherwise the write is attempted again.
+func (d *DB) write(ctx context.Context, userID string, f func(current userconfig.View, exists bool) (userconfig.View, bool, error)) (bool, error) {
+	for attempt := 0; attempt < maxWriteAttempts; attempt++ {
+		idx, err := d.readIndex(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		var current userconfig.View
+		prev, exists := idx.Users[userID]
+		if exists {
+			current, err = d.readConfig(ctx, userID, prev)
+			if err == sql.ErrNoRows {
+				// The config has been replaced meanwhile.
+				continue
+			} else if err != nil {
+				return false, err
+			}
+		}
+
+		view, ok, err := f(current, exists)
+		if err != nil || !ok {
+			return false, err
+		}
+
+		view.ID = idx.LastID + 1
+		entry := indexEntry{ID: view.ID, Object: path.Join(userID, ulid.MustNew(ulid.Now(), rand.Reader).String())}
+		if err := d.upload(ctx, userID, entry, view); err != nil {
+			return false, err
+		}
+
+		err = d.kv.CAS(ctx, indexKey, func(in interface{}) (out interface{}, retry bool, err error) {
+			latest, err := decodeIndex(in)
+			if err != nil {
+				return nil, false, err
+			}
+			// Any other write since the index has been read may have changed the user config, or used the ID.
+			if latest.LastID != idx.LastID {
+				return nil, false, errIndexChanged
+			}
+
+			latest.LastID = entry.ID
+			latest.Users[userID] = entry
+			buf, err := json.Marshal(latest)
+			if err != nil {
+				return nil, false, err
+			}
+			return string(buf), true, nil
+		})
+		if errors.Is(err, errIndexChanged) {
+			d.deleteObject(ctx, entry.Object)
+			continue
+		}
+		if err != nil {
+			// The index may have been updated even if the CAS failed, eg. on a timeout, so the
+			// uploaded object is only deleted once the index is confirmed not to reference it.
+			// Otherwise, it's left to the cleanup of the orphaned objects.
+			latest, readErr := d.readIndex(ctx)
+			if readErr == nil && latest.Users[userID] != entry {
+				d.deleteObject(ctx, entry.Object)
+			}
+			if readErr != nil || latest.Users[userID] != entry {
+				return false, errors.Wrapf(err, "failed to update the configs index for user %s", userID)
+			}
+		}
+
+		if exists {
+			d.deleteObject(ctx, prev.Object)
+		}
+		return true, nil
+	}
+
+	return false, errors.Errorf("failed to write config for user %s: too many concurrent writes", userID)
+}
+
+func (d *DB) readIndex(ctx context.Context) (*index, error) {
+	value, err := d.kv.Get(ctx, indexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the configs index")
+	}
+	return decodeIndex(value)
+}
+
+func decodeIndex(value interface{}) (*index, error) {
+	idx := &index{}
+	if value != nil {
+		if err := json.Unmarshal([]byte(value.(string)), idx); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal the configs index")
+		}
+	}
+	if idx.Users == nil {
+		idx.Users = map[string]indexEntry{}
+	}
+	return idx, nil
+}
+
+// readConfig reads the config of the user stored in the object of the input index entry. It
+// returns sql.ErrNoRows if the object doesn't exist anymore.
+func (d *DB) readConfig(ctx context.Context, userID string, entry indexEntry) (userconfig.View, error) {
+	var view userconfig.View
+
+	readCloser, err := d.bkt.Get(ctx, entry.Object)
+	if d.bkt.IsObjNotFoundErr(err) {
+		return view, sql.ErrNoRows
+	} else if err != nil {
+		return view, errors.Wrapf(err, "failed to fetch config for user %s", userID)
+	}
+	defer runutil.CloseWithLogOnErr(d.logger, readCloser, "close bucket reader")
+
+	buf, err := io.ReadAll(readCloser)
+	if err != nil {
+		return view, errors.Wrapf(err, "failed to read config for user %s", userID)
+	}
+
+	err = json.Unmarshal(buf, &view)
+	return view, errors.Wrapf(err, "failed to unmarshal config for user %s", userID)
+}
+
+func (d *DB) upload(ctx context.Context, userID string, entry indexEntry, view userconfig.View) error {
+	buf, err := json.Marshal(view)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal config for user %s", userID)
+	}
+
+	if err := d.bkt.Upload(ctx, entry.Object, bytes.NewReader(buf)); err != nil {
+		return errors.Wrapf(err, "failed to upload config for user %s", userID)
+	}
+	return nil
+}
+
+func (d *DB) orphansCleanupLoop(ctx context.Context) {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(orphansCleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.deleteOrphans(ctx); err != nil && ctx.Err() == nil {
+				level.Warn(d.logger).Log("msg", "failed to delete the orphaned config objects", "err", err)
+			}
+		}
+	}
+}
+
+// deleteOrphans deletes the objects which aren't referenced by the index and have been uploaded
+// before the grace period. They're left by the writes which failed to update the index, or to
+// delete the replaced config.
+func (d *DB) deleteOrphans(ctx context.Context) error {
+	idx, err := d.readIndex(ctx)
+	if err != nil {
+		return err
+	}
+
+	referenced := make(map[string]struct{}, len(idx.Users))
+	for _, entry := range idx.Users {
+		referenced[entry.Object] = struct{}{}
+	}
+
+	deadline := d.now().Add(-orphansGracePeriod)
+	return d.bkt.Iter(ctx, "", func(name string) error {
+		if _, ok := referenced[name]; ok {
+			return nil
+		}
+
+		// The object names are ULIDs, whose time is the upload time.
+		id, err := ulid.Parse(path.Base(name))
+		if err != nil || !ulid.Time(id.Time()).Before(deadline) {
+			return nil
+		}
+
+		d.deleteObject(ctx, name)
+		return nil
+	}, objstore.WithRecursiveIter)
+}
+
+// deleteObject deletes an object which isn't referenced by the index. Errors are only logged,
+// since the object is never read.
+func (d *DB) deleteObject(ctx context.Context, name string) {
+	if err := d.bkt.Delete(ctx, name); err != nil && !d.bkt.IsObjNotFoundErr(err) {
+		level.Warn(d.logger).Log("msg", "failed to delete config object", "object", name, "err", err)
+	}
+}
@@ -0,0 +1,272 @@
+package bucketclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/configs/userconfig"
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+)
+
+func TestDB_Configs(t *testing.T) {
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	d := newTestDB(t, bkt)
+
+	_, err := d.GetConfig(ctx, "user-1")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	cfg1 := userconfig.Config{AlertmanagerConfig: "config-1", RulesConfig: userconfig.RulesConfig{FormatVersion: userconfig.RuleFormatV2}}
+	cfg2 := userconfig.Config{AlertmanagerConfig: "config-2", RulesConfig: userconfig.RulesConfig{FormatVersion: userconfig.RuleFormatV2}}
+	require.NoError(t, d.SetConfig(ctx, "user-1", cfg1))
+	require.NoError(t, d.SetConfig(ctx, "user-2", cfg2))
+
+	view1, err := d.GetConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, cfg1, view1.Config)
+	view2, err := d.GetConfig(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, cfg2, view2.Config)
+	assert.Greater(t, view2.ID, view1.ID)
+
+	// The configs are stored under the configs prefix, and only the latest config is kept.
+	require.NoError(t, d.SetConfig(ctx, "user-1", cfg1))
+	view1, err = d.GetConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Greater(t, view1.ID, view2.ID)
+	var objects []string
+	require.NoError(t, bkt.Iter(ctx, "configs/user-1/", func(name string) error {
+		objects = append(objects, name)
+		return nil
+	}))
+	assert.Len(t, objects, 1)
+
+	all, err := d.GetAllConfigs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]userconfig.View{"user-1": view1, "user-2": view2}, all)
+
+	since, err := d.GetConfigs(ctx, view2.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]userconfig.View{"user-1": view1}, since)
+
+	// Deactivating a config writes it again with a new ID.
+	require.NoError(t, d.DeactivateConfig(ctx, "user-1"))
+	deactivated, err := d.GetConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.True(t, deactivated.IsDeleted())
+	assert.Greater(t, deactivated.ID, view1.ID)
+
+	since, err = d.GetConfigs(ctx, view1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]userconfig.View{"user-1": deactivated}, since)
+
+	require.NoError(t, d.RestoreConfig(ctx, "user-1"))
+	restored, err := d.GetConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.False(t, restored.IsDeleted())
+	assert.Equal(t, cfg1, restored.Config)
+
+	assert.Equal(t, sql.ErrNoRows, d.DeactivateConfig(ctx, "user-3"))
+}
+
+func TestDB_SetRulesConfig(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDB(t, objstore.NewInMemBucket())
+
+	rules1 := userconfig.RulesConfig{FormatVersion: userconfig.RuleFormatV2, Files: map[string]string{"rules.yaml": "groups: []"}}
+	rules2 := userconfig.RulesConfig{FormatVersion: userconfig.RuleFormatV2, Files: map[string]string{"rules.yaml": "groups: [{name: g}]"}}
+
+	_, err := d.GetRulesConfig(ctx, "user-1")
+	assert.Equal(t, sql.ErrNoRows, err)
+
+	// No config exists yet, so the old config must be empty.
+	updated, err := d.SetRulesConfig(ctx, "user-1", rules2, rules1)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	updated, err = d.SetRulesConfig(ctx, "user-1", userconfig.RulesConfig{}, rules1)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	// The old config doesn't match the current one.
+	updated, err = d.SetRulesConfig(ctx, "user-1", rules2, rules2)
+	require.NoError(t, err)
+	assert.False(t, updated)
+
+	updated, err = d.SetRulesConfig(ctx, "user-1", rules1, rules2)
+	require.NoError(t, err)
+	assert.True(t, updated)
+
+	current, err := d.GetRulesConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, rules2, current.Config)
+
+	all, err := d.GetAllRulesConfigs(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]userconfig.VersionedRulesConfig{"user-1": current}, all)
+
+	since, err := d.GetRulesConfigs(ctx, current.ID)
+	require.NoError(t, err)
+	assert.Empty(t, since)
+}
+
+func TestDB_ShouldSerialiseTheWritesOfSeveralDBs(t *testing.T) {
+	const writes = 10
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	client, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+	d1 := New(bkt, client, log.NewNopLogger())
+	d2 := New(bkt, client, log.NewNopLogger())
+	t.Cleanup(func() {
+		assert.NoError(t, d1.Close())
+		assert.NoError(t, d2.Close())
+	})
+
+	// Each rules config update expects the previous one, so only one of the concurrent
+	// updates applying the same change succeeds.
+	rulesConfig := func(i int) userconfig.RulesConfig {
+		if i < 0 {
+			return userconfig.RulesConfig{}
+		}
+		return userconfig.RulesConfig{FormatVersion: userconfig.RuleFormatV2, Files: map[string]string{"rules.yaml": strconv.Itoa(i)}}
+	}
+
+	for i := 0; i < writes; i++ {
+		var (
+			wg      sync.WaitGroup
+			updated atomic.Int32
+		)
+		for _, d := range []*DB{d1, d2} {
+			wg.Add(1)
+			go func(d *DB) {
+				defer wg.Done()
+				ok, err := d.SetRulesConfig(ctx, "user-1", rulesConfig(i-1), rulesConfig(i))
+				assert.NoError(t, err)
+				if ok {
+					updated.Inc()
+				}
+			}(d)
+		}
+		wg.Wait()
+		require.Equal(t, int32(1), updated.Load())
+	}
+
+	current, err := d2.GetRulesConfig(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, rulesConfig(writes-1), current.Config)
+	assert.Equal(t, userconfig.ID(writes), current.ID)
+
+	// The IDs are strictly increasing across the DBs.
+	require.NoError(t, d1.SetConfig(ctx, "user-2", userconfig.Config{RulesConfig: rulesConfig(0)}))
+	view, err := d2.GetConfig(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, userconfig.ID(writes+1), view.ID)
+}
+
+func TestDB_ShouldOnlyDeleteTheUploadedObjectOnConfirmedIndexUpdateFailures(t *testing.T) {
+	errCAS := errors.New("CAS failed")
+
+	for name, tc := range map[string]struct {
+		applyCAS      bool
+		failGet       bool
+		expectedErr   bool
+		expectedObjs  int
+		expectedAfter int
+	}{
+		"index updated despite the CAS error": {
+			applyCAS:      true,
+			expectedObjs:  1,
+			expectedAfter: 1,
+		},
+		"index not updated": {
+			expectedErr:   true,
+			expectedObjs:  0,
+			expectedAfter: 0,
+		},
+		"index update unknown": {
+			failGet:       true,
+			expectedErr:   true,
+			expectedObjs:  1,
+			expectedAfter: 0,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			bkt := objstore.NewInMemBucket()
+			inmem, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			client := &failingCASClient{Client: inmem, err: errCAS, applyCAS: tc.applyCAS, failGetAfterCAS: tc.failGet}
+			d := New(bkt, client, log.NewNopLogger())
+			t.Cleanup(func() { assert.NoError(t, d.Close()) })
+
+			err := d.SetConfig(ctx, "user-1", userconfig.Config{})
+			if tc.expectedErr {
+				require.ErrorIs(t, err, errCAS)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Len(t, bkt.Objects(), tc.expectedObjs)
+
+			// The orphaned objects are deleted by the cleanup once older than the grace period.
+			client.failGetAfterCAS, client.casCalled = false, false
+			require.NoError(t, d.deleteOrphans(ctx))
+			assert.Len(t, bkt.Objects(), tc.expectedObjs)
+
+			d.now = func() time.Time { return time.Now().Add(orphansGracePeriod + time.Minute) }
+			require.NoError(t, d.deleteOrphans(ctx))
+			assert.Len(t, bkt.Objects(), tc.expectedAfter)
+		})
+	}
+}
+
+// failingCASClient is a KV client whose CAS always fails, after applying the update if applyCAS
+// is set. Get fails after the CAS if failGetAfterCAS is set.
+type failingCASClient struct {
+	kv.Client
+	err             error
+	applyCAS        bool
+	failGetAfterCAS bool
+	casCalled       bool
+}
+
+func (c *failingCASClient) CAS(ctx context.Context, key string, f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	c.casCalled = true
+	if c.applyCAS {
+		if err := c.Client.CAS(ctx, key, f); err != nil {
+			return err
+		}
+	}
+	return c.err
+}
+
+func (c *failingCASClient) Get(ctx context.Context, key string) (interface{}, error) {
+	if c.failGetAfterCAS && c.casCalled {
+		return nil, c.err
+	}
+	return c.Client.Get(ctx, key)
+}
+
+func newTestDB(t *testing.T, bkt objstore.Bucket) *DB {
+	client, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	d := New(bkt, client, log.NewNopLogger())
+	t.Cleanup(func() { assert.NoError(t, d.Close()) })
+	return d
+}
@@ -8,9 +8,16 @@ import (
 	"os"
 	"strings"
 
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cortexproject/cortex/pkg/configs/db/bucketclient"
 	"github.com/cortexproject/cortex/pkg/configs/db/memory"
 	"github.com/cortexproject/cortex/pkg/configs/db/postgres"
 	"github.com/cortexproject/cortex/pkg/configs/userconfig"
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
 )
 
 // Config configures the database.
@@ -19,15 +26,22 @@ type Config struct {
 	MigrationsDir string `yaml:"migrations_dir"`
 	PasswordFile  string `yaml:"password_file"`
 
+	// Storage configures the object storage used when the URI is bucket://.
+	Storage bucket.Config `yaml:"storage"`
+	// Index configures the KV store of the configs index used when the URI is bucket://.
+	Index kv.Config `yaml:"index" doc:"description=Backend storage to use for the index of the configs stored in object storage, shared by all configs services. Please be aware that memberlist is not supported."`
+
 	// Allow injection of mock DBs for unit testing.
 	Mock DB `yaml:"-"`
 }
 
 // RegisterFlags adds the flags required to configure this to the given FlagSet.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
-	f.StringVar(&cfg.URI, "configs.database.uri", "postgres://postgres@configs-db.weave.local/configs?sslmode=disable", "URI where the database can be found (for dev you can use memory://)")
+	f.StringVar(&cfg.URI, "configs.database.uri", "postgres://postgres@configs-db.weave.local/configs?sslmode=disable", "URI where the database can be found (for dev you can use memory://, to store the configs in object storage use bucket://)")
 	f.StringVar(&cfg.MigrationsDir, "configs.database.migrations-dir", "", "Path where the database migration files can be found")
 	f.StringVar(&cfg.PasswordFile, "configs.database.password-file", "", "File containing password (username goes in URI)")
+	cfg.Storage.RegisterFlagsWithPrefix("configs.database.storage.", f)
+	cfg.Index.RegisterFlagsWithPrefix("configs.database.index.", "configs/", f)
 }
 
 // DB is the interface for the database.
@@ -60,7 +74,7 @@ type DB interface {
 }
 
 // New creates a new database.
-func New(cfg Config) (DB, error) {
+func New(cfg Config, logger log.Logger, reg prometheus.Registerer) (DB, error) {
 	if cfg.Mock != nil {
 		return cfg.Mock, nil
 	}
@@ -84,6 +98,8 @@ func New(cfg Config) (DB, error) {
 		d, err = memory.New(u.String(), cfg.MigrationsDir)
 	case "postgres":
 		d, err = postgres.New(u.String(), cfg.MigrationsDir)
+	case "bucket":
+		d, err = newBucketDB(cfg.Storage, cfg.Index, logger, reg)
 	default:
 		return nil, fmt.Errorf("unknown database type: %s", u.Scheme)
 	}
@@ -93,6 +109,23 @@ func New(cfg Config) (DB, error) {
 	return traced{timed{d}}, nil
 }
 
+func newBucketDB(cfg bucket.Config, indexCfg kv.Config, logger log.Logger, reg prometheus.Registerer) (DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	bkt, err := bucket.NewClient(context.Background(), cfg, "configs", logger, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := kv.NewClient(indexCfg, codec.String{}, kv.RegistererWithKVName(reg, "configs-index"), logger)
+	if err != nil {
+		return nil, err
+	}
+	return bucketclient.New(bkt, client, logger), nil
+}
+
 func setPassword(u *url.URL, passwordFile string) (*url.URL, error) {
 	if u.User == nil {
 		return nil, fmt.Errorf("--database.password-file requires username in --database.uri")
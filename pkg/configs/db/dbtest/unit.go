@@ -6,6 +6,7 @@ package dbtest
 import (
 	"testing"
 
+	"github.com/go-kit/log"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/logging"
 
@@ -17,7 +18,7 @@ func Setup(t *testing.T) db.DB {
 	require.NoError(t, logging.Setup("debug"))
 	database, err := db.New(db.Config{
 		URI: "memory://",
-	})
+	}, log.NewNopLogger(), nil)
 	require.NoError(t, err)
 	return database
 }
@@ -35,9 +35,11 @@ import (
 	"github.com/cortexproject/cortex/pkg/distributor"
 	"github.com/cortexproject/cortex/pkg/flusher"
 	"github.com/cortexproject/cortex/pkg/frontend"
+	"github.com/cortexproject/cortex/pkg/frontend/transport"
 	frontendv1 "github.com/cortexproject/cortex/pkg/frontend/v1"
 	"github.com/cortexproject/cortex/pkg/ingester"
 	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/purger"
 	"github.com/cortexproject/cortex/pkg/querier"
 	"github.com/cortexproject/cortex/pkg/querier/tenantfederation"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
@@ -112,6 +114,8 @@ type Config struct {
 	Compactor        compactor.Config                `yaml:"compactor"`
 	StoreGateway     storegateway.Config             `yaml:"store_gateway"`
 	TenantFederation tenantfederation.Config         `yaml:"tenant_federation"`
+	TenantSigning    grpcclient.TenantSigningConfig  `yaml:"tenant_signing"`
+	Purger           purger.Config                   `yaml:"purger"`
 
 	Ruler               ruler.Config                               `yaml:"ruler"`
 	RulerStorage        rulestore.Config                           `yaml:"ruler_storage"`
@@ -158,6 +162,8 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.Compactor.RegisterFlags(f)
 	c.StoreGateway.RegisterFlags(f)
 	c.TenantFederation.RegisterFlags(f)
+	c.TenantSigning.RegisterFlags(f)
+	c.Purger.RegisterFlags(f)
 
 	c.Ruler.RegisterFlags(f)
 	c.RulerStorage.RegisterFlags(f)
@@ -177,6 +183,14 @@ func (c *Config) Validate(log log.Logger) error {
 		return err
 	}
 
+	if err := c.TenantSigning.Validate(); err != nil {
+		return errors.Wrap(err, "invalid tenant signing config")
+	}
+
+	if err := c.Purger.Validate(); err != nil {
+		return err
+	}
+
 	if c.HTTPPrefix != "" && !strings.HasPrefix(c.HTTPPrefix, "/") {
 		return errInvalidHTTPPrefix
 	}
@@ -211,6 +225,9 @@ func (c *Config) Validate(log log.Logger) error {
 	if err := c.QueryRange.Validate(c.Querier); err != nil {
 		return errors.Wrap(err, "invalid query_range config")
 	}
+	if err := c.Frontend.Validate(); err != nil {
+		return errors.Wrap(err, "invalid frontend config")
+	}
 	if err := c.StoreGateway.Validate(c.LimitsConfig); err != nil {
 		return errors.Wrap(err, "invalid store-gateway config")
 	}
@@ -309,6 +326,7 @@ type Cortex struct {
 	ExemplarQueryable        prom_storage.ExemplarQueryable
 	QuerierEngine            promql.QueryEngine
 	QueryFrontendTripperware tripperware.Tripperware
+	QueryBytesBudget         *transport.QueryBytesBudget
 
 	Ruler        *ruler.Ruler
 	RulerStorage rulestore.RuleStore
@@ -319,6 +337,9 @@ type Cortex struct {
 	StoreGateway *storegateway.StoreGateway
 	MemberlistKV *memberlist.KVInitService
 
+	// Verifies the tenant signature of requests, if tenant signing is enabled.
+	TenantSigningVerifier *grpcclient.TenantSigningVerifier
+
 	// Queryables that the querier should use to query the long
 	// term storage. It depends on the storage engine used.
 	StoreQueryables []querier.QueryableWithFilter
@@ -353,6 +374,12 @@ func New(cfg Config) (*Cortex, error) {
 			"/schedulerpb.SchedulerForQuerier/NotifyQuerierShutdown",
 		})
 
+	// The query ID is propagated and logged alongside the HTTP request headers to log.
+	if cfg.Frontend.Handler.QueryIDEnabled {
+		util_log.WarnExperimentalUse("query ID")
+		cfg.API.HTTPRequestHeadersToLog = append(cfg.API.HTTPRequestHeadersToLog, util_log.QueryIDHeaderName)
+	}
+
 	cortex := &Cortex{
 		Cfg: cfg,
 	}
@@ -389,6 +416,16 @@ func (t *Cortex) setupRequestSigning() {
 		util_log.WarnExperimentalUse("Distributor SignWriteRequestsEnabled")
 		t.Cfg.Server.GRPCMiddleware = append(t.Cfg.Server.GRPCMiddleware, grpcclient.UnarySigningServerInterceptor)
 	}
+
+	if t.Cfg.TenantSigning.Enabled {
+		util_log.WarnExperimentalUse("Tenant signing")
+		signer := grpcclient.NewTenantSigner(t.Cfg.TenantSigning.Keys, t.Cfg.TenantSigning.MaxAge)
+		grpcclient.SetTenantSigner(signer)
+
+		t.TenantSigningVerifier = grpcclient.NewTenantSigningVerifier(signer, t.Cfg.TenantSigning.Enforce, util_log.Logger, prometheus.DefaultRegisterer)
+		t.Cfg.Server.GRPCMiddleware = append(t.Cfg.Server.GRPCMiddleware, t.TenantSigningVerifier.UnaryServerInterceptor)
+		t.Cfg.Server.GRPCStreamMiddleware = append(t.Cfg.Server.GRPCStreamMiddleware, t.TenantSigningVerifier.StreamServerInterceptor)
+	}
 }
 
 // Run starts Cortex running, and blocks until a Cortex stops.
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/go-kit/log"
@@ -33,6 +34,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/flusher"
 	"github.com/cortexproject/cortex/pkg/frontend"
 	"github.com/cortexproject/cortex/pkg/frontend/transport"
+	"github.com/cortexproject/cortex/pkg/ha"
 	"github.com/cortexproject/cortex/pkg/ingester"
 	"github.com/cortexproject/cortex/pkg/purger"
 	"github.com/cortexproject/cortex/pkg/querier"
@@ -73,6 +75,7 @@ const (
 	StoreQueryable           string = "store-queryable"
 	QueryFrontend            string = "query-frontend"
 	QueryFrontendTripperware string = "query-frontend-tripperware"
+	QueryBytesBudget         string = "query-bytes-budget"
 	RulerStorage             string = "ruler-storage"
 	Ruler                    string = "ruler"
 	Configs                  string = "configs"
@@ -98,7 +101,7 @@ func (t *Cortex) initAPI() (services.Service, error) {
 	t.Cfg.API.ServerPrefix = t.Cfg.Server.PathPrefix
 	t.Cfg.API.LegacyHTTPPrefix = t.Cfg.HTTPPrefix
 
-	a, err := api.New(t.Cfg.API, t.Cfg.Server, t.Server, util_log.Logger)
+	a, err := api.New(t.Cfg.API, t.Cfg.Server, t.Server, util_log.Logger, prometheus.DefaultRegisterer)
 	if err != nil {
 		return nil, err
 	}
@@ -171,7 +174,7 @@ func (t *Cortex) initRuntimeConfig() (services.Service, error) {
 	}
 
 	t.RuntimeConfig = serv
-	t.API.RegisterRuntimeConfig(runtimeConfigHandler(t.RuntimeConfig, t.Cfg.LimitsConfig))
+	t.API.RegisterRuntimeConfig(runtimeConfigHandler(t.RuntimeConfig, t.Cfg.LimitsConfig), runtimeConfigValidateHandler(t.Cfg.Distributor.ShardByAllLabels))
 	return serv, err
 }
 
@@ -217,7 +220,7 @@ func (t *Cortex) initDistributorService() (serv services.Service, err error) {
 }
 
 func (t *Cortex) initDistributor() (serv services.Service, err error) {
-	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor)
+	t.API.RegisterDistributor(t.Distributor, t.Cfg.Distributor, t.Overrides)
 
 	return nil, nil
 }
@@ -233,6 +236,12 @@ func (t *Cortex) initQueryable() (serv services.Service, err error) {
 	// Register the default endpoints that are always enabled for the querier module
 	t.API.RegisterQueryable(t.QuerierQueryable, t.Distributor)
 
+	// Wait for the queries the engine executes in the background (ie. the shadow engine queries) at shutdown.
+	if closer, ok := t.QuerierEngine.(io.Closer); ok {
+		return services.NewIdleService(nil, func(_ error) error {
+			return closer.Close()
+		}), nil
+	}
 	return nil, nil
 }
 
@@ -305,6 +314,7 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 		t.QuerierQueryable,
 		t.ExemplarQueryable,
 		t.QuerierEngine,
+		querier.NewMetricsMetadataQuerier(t.Distributor, t.StoreQueryables, util_log.Logger),
 		t.Distributor,
 		prometheus.DefaultRegisterer,
 		util_log.Logger,
@@ -352,7 +362,14 @@ func (t *Cortex) initQuerier() (serv services.Service, err error) {
 
 	t.Cfg.Worker.MaxConcurrentRequests = t.Cfg.Querier.MaxConcurrent
 	t.Cfg.Worker.TargetHeaders = t.Cfg.API.HTTPRequestHeadersToLog
-	return querier_worker.NewQuerierWorker(t.Cfg.Worker, httpgrpc_server.NewServer(internalQuerierRouter), util_log.Logger, prometheus.DefaultRegisterer)
+
+	var handler querier_worker.RequestHandler = httpgrpc_server.NewServer(internalQuerierRouter)
+	if t.TenantSigningVerifier != nil {
+		// Queries are received from the query-frontend or query-scheduler as HTTP requests wrapped in
+		// protobuf, so their tenant signature is not verified by the gRPC server interceptors.
+		handler = t.TenantSigningVerifier.WrapHTTPGRPCHandler(handler)
+	}
+	return querier_worker.NewQuerierWorker(t.Cfg.Worker, handler, util_log.Logger, prometheus.DefaultRegisterer)
 }
 
 func (t *Cortex) initStoreQueryables() (services.Service, error) {
@@ -402,6 +419,10 @@ func (t *Cortex) initIngesterService() (serv services.Service, err error) {
 	t.Cfg.Ingester.LifecyclerConfig.ListenPort = t.Cfg.Server.GRPCListenPort
 	t.Cfg.Ingester.DistributorShardingStrategy = t.Cfg.Distributor.ShardingStrategy
 	t.Cfg.Ingester.DistributorShardByAllLabels = t.Cfg.Distributor.ShardByAllLabels
+	if len(t.Cfg.Distributor.ShardByLabels) > 0 {
+		t.Cfg.Ingester.DistributorShardByLabelsShardCount = t.Cfg.Distributor.ShardByLabelsShardCount
+	}
+	t.Cfg.Ingester.SignWriteRequestsEnabled = t.Cfg.Distributor.SignWriteRequestsEnabled
 	t.Cfg.Ingester.InstanceLimitsFn = ingesterInstanceLimits(t.RuntimeConfig)
 	t.Cfg.Ingester.QueryIngestersWithin = t.Cfg.Querier.QueryIngestersWithin
 	t.tsdbIngesterConfig()
@@ -442,9 +463,9 @@ func (t *Cortex) initFlusher() (serv services.Service, err error) {
 func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err error) {
 	queryAnalyzer := querysharding.NewQueryAnalyzer()
 	// PrometheusCodec is a codec to encode and decode Prometheus query range requests and responses.
-	prometheusCodec := queryrange.NewPrometheusCodec(false)
+	prometheusCodec := queryrange.NewPrometheusCodec(false, t.Cfg.QueryRange.StreamResponses)
 	// ShardedPrometheusCodec is same as PrometheusCodec but to be used on the sharded queries (it sum up the stats)
-	shardedPrometheusCodec := queryrange.NewPrometheusCodec(true)
+	shardedPrometheusCodec := queryrange.NewPrometheusCodec(true, false)
 
 	queryRangeMiddlewares, cache, err := queryrange.Middlewares(
 		t.Cfg.QueryRange,
@@ -456,12 +477,21 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		prometheusCodec,
 		shardedPrometheusCodec,
 		t.Cfg.Querier.LookbackDelta,
+		t.Cfg.Querier.DefaultEvaluationInterval,
 	)
 	if err != nil {
 		return nil, err
 	}
 
-	instantQueryMiddlewares, err := instantquery.Middlewares(util_log.Logger, t.Overrides, queryAnalyzer, t.Cfg.Querier.LookbackDelta)
+	instantQueryMiddlewares, instantQueryCache, err := instantquery.Middlewares(
+		t.Cfg.QueryRange.InstantQueryCache,
+		util_log.Logger,
+		t.Overrides,
+		prometheus.DefaultRegisterer,
+		queryAnalyzer,
+		t.Cfg.Querier.LookbackDelta,
+		t.Cfg.Querier.DefaultEvaluationInterval,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -480,15 +510,61 @@ func (t *Cortex) initQueryFrontendTripperware() (serv services.Service, err erro
 		t.Cfg.Querier.LookbackDelta,
 	)
 
+	var labelsCache *tripperware.LabelsCache
+	if t.Cfg.QueryRange.LabelsCache.Enabled {
+		labelsCache, err = tripperware.NewLabelsCache(t.Cfg.QueryRange.LabelsCache, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		t.API.RegisterQueryFrontendLabelsCache(labelsCache)
+
+		queryTripperware := t.QueryFrontendTripperware
+		t.QueryFrontendTripperware = func(next http.RoundTripper) http.RoundTripper {
+			return queryTripperware(labelsCache.Wrap(next))
+		}
+	}
+
+	if t.Cfg.Frontend.Federation.RemoteURL != "" {
+		federation, err := frontend.NewFederation(t.Cfg.Frontend.Federation, http.DefaultTransport, prometheusCodec, instantquery.InstantQueryCodec, t.Cfg.QueryRange.ForwardHeaders, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+
+		queryTripperware := t.QueryFrontendTripperware
+		t.QueryFrontendTripperware = func(next http.RoundTripper) http.RoundTripper {
+			return federation.Wrap(queryTripperware(next))
+		}
+	}
+
 	return services.NewIdleService(nil, func(_ error) error {
 		if cache != nil {
 			cache.Stop()
 			cache = nil
 		}
+		if instantQueryCache != nil {
+			instantQueryCache.Stop()
+			instantQueryCache = nil
+		}
+		if labelsCache != nil {
+			labelsCache.Stop()
+			labelsCache = nil
+		}
 		return nil
 	}), nil
 }
 
+func (t *Cortex) initQueryBytesBudget() (serv services.Service, err error) {
+	if !t.Cfg.Frontend.Handler.QueryBytesBudget.Enabled {
+		return nil, nil
+	}
+
+	t.QueryBytesBudget, err = transport.NewQueryBytesBudget(t.Cfg.Frontend.Handler.QueryBytesBudget, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, err
+	}
+	return t.QueryBytesBudget, nil
+}
+
 func (t *Cortex) initQueryFrontend() (serv services.Service, err error) {
 	retry := transport.NewRetry(t.Cfg.QueryRange.MaxRetries, prometheus.DefaultRegisterer)
 	roundTripper, frontendV1, frontendV2, err := frontend.InitFrontend(t.Cfg.Frontend, t.Overrides, t.Cfg.Server.GRPCListenPort, util_log.Logger, prometheus.DefaultRegisterer, retry)
@@ -499,8 +575,9 @@ func (t *Cortex) initQueryFrontend() (serv services.Service, err error) {
 	// Wrap roundtripper into Tripperware.
 	roundTripper = t.QueryFrontendTripperware(roundTripper)
 
-	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, util_log.Logger, prometheus.DefaultRegisterer)
+	handler := transport.NewHandler(t.Cfg.Frontend.Handler, roundTripper, t.QueryBytesBudget, util_log.Logger, prometheus.DefaultRegisterer)
 	t.API.RegisterQueryFrontendHandler(handler)
+	t.API.RegisterQueryFrontendUserQueryStats(handler)
 
 	if frontendV1 != nil {
 		t.API.RegisterQueryFrontend1(frontendV1)
@@ -541,7 +618,11 @@ func createActiveQueryTracker(cfg querier.Config, logger log.Logger) promql.Quer
 }
 
 func (t *Cortex) initRuler() (serv services.Service, err error) {
-	var manager *ruler.DefaultMultiTenantManager
+	var (
+		manager        *ruler.DefaultMultiTenantManager
+		rulerQueryable prom_storage.Queryable
+		rulerEngine    promql.QueryEngine
+	)
 	if t.RulerStorage == nil {
 		level.Info(util_log.Logger).Log("msg", "RulerStorage is nil.  Not starting the ruler.")
 		return nil, nil
@@ -580,6 +661,7 @@ func (t *Cortex) initRuler() (serv services.Service, err error) {
 
 		managerFactory := ruler.DefaultTenantManagerFactory(t.Cfg.Ruler, t.Cfg.ExternalPusher, t.Cfg.ExternalQueryable, queryEngine, t.Overrides, metrics, prometheus.DefaultRegisterer)
 		manager, err = ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, metrics, prometheus.DefaultRegisterer, util_log.Logger)
+		rulerQueryable, rulerEngine = t.Cfg.ExternalQueryable, queryEngine
 	} else {
 		rulerRegisterer := prometheus.WrapRegistererWith(prometheus.Labels{"engine": "ruler"}, prometheus.DefaultRegisterer)
 		// TODO: Consider wrapping logger to differentiate from querier module logger
@@ -587,6 +669,7 @@ func (t *Cortex) initRuler() (serv services.Service, err error) {
 
 		managerFactory := ruler.DefaultTenantManagerFactory(t.Cfg.Ruler, t.Distributor, queryable, engine, t.Overrides, metrics, prometheus.DefaultRegisterer)
 		manager, err = ruler.NewDefaultMultiTenantManager(t.Cfg.Ruler, managerFactory, metrics, prometheus.DefaultRegisterer, util_log.Logger)
+		rulerQueryable, rulerEngine = queryable, engine
 	}
 
 	if err != nil {
@@ -611,13 +694,14 @@ func (t *Cortex) initRuler() (serv services.Service, err error) {
 	// If the API is enabled, register the Ruler API
 	if t.Cfg.Ruler.EnableAPI {
 		t.API.RegisterRulerAPI(ruler.NewAPI(t.Ruler, t.RulerStorage, util_log.Logger))
+		t.API.RegisterRulerBacktestAPI(ruler.NewBacktester(t.Cfg.Ruler, rulerEngine, rulerQueryable, t.Overrides, util_log.Logger))
 	}
 
 	return t.Ruler, nil
 }
 
 func (t *Cortex) initConfig() (serv services.Service, err error) {
-	t.ConfigDB, err = db.New(t.Cfg.Configs.DB)
+	t.ConfigDB, err = db.New(t.Cfg.Configs.DB, util_log.Logger, prometheus.DefaultRegisterer)
 	if err != nil {
 		return
 	}
@@ -680,6 +764,7 @@ func (t *Cortex) initMemberlistKV() (services.Service, error) {
 	t.Cfg.MemberlistKV.MetricsRegisterer = reg
 	t.Cfg.MemberlistKV.Codecs = []codec.Codec{
 		ring.GetCodec(),
+		ha.GetReplicaDescCodec(),
 	}
 	dnsProviderReg := prometheus.WrapRegistererWithPrefix(
 		"cortex_",
@@ -694,6 +779,7 @@ func (t *Cortex) initMemberlistKV() (services.Service, error) {
 
 	// Update the config.
 	t.Cfg.Distributor.DistributorRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
+	t.Cfg.Distributor.HATrackerConfig.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
 	t.Cfg.Ingester.LifecyclerConfig.RingConfig.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
 	t.Cfg.StoreGateway.ShardingRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
 	t.Cfg.Compactor.ShardingRing.KVStore.MemberlistKV = t.MemberlistKV.GetMemberlistKV
@@ -711,6 +797,18 @@ func (t *Cortex) initTenantDeletionAPI() (services.Service, error) {
 	}
 
 	t.API.RegisterTenantDeletion(tenantDeletionAPI)
+
+	if t.Cfg.Purger.ExportEnabled() {
+		blocksExportAPI, err := purger.NewBlocksExportAPI(t.Cfg.BlocksStorage, t.Cfg.Purger, t.Overrides, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+
+		t.API.RegisterBlocksExport(blocksExportAPI)
+
+		// The export jobs are canceled when the module stops.
+		return blocksExportAPI, nil
+	}
 	return nil, nil
 }
 
@@ -745,6 +843,7 @@ func (t *Cortex) setupModuleManager() error {
 	mm.RegisterModule(Querier, t.initQuerier)
 	mm.RegisterModule(StoreQueryable, t.initStoreQueryables, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryFrontendTripperware, t.initQueryFrontendTripperware, modules.UserInvisibleModule)
+	mm.RegisterModule(QueryBytesBudget, t.initQueryBytesBudget, modules.UserInvisibleModule)
 	mm.RegisterModule(QueryFrontend, t.initQueryFrontend)
 	mm.RegisterModule(RulerStorage, t.initRulerStorage, modules.UserInvisibleModule)
 	mm.RegisterModule(Ruler, t.initRuler)
@@ -775,7 +874,8 @@ func (t *Cortex) setupModuleManager() error {
 		Querier:                  {TenantFederation},
 		StoreQueryable:           {Overrides, Overrides, MemberlistKV},
 		QueryFrontendTripperware: {API, Overrides},
-		QueryFrontend:            {QueryFrontendTripperware},
+		QueryBytesBudget:         {Overrides},
+		QueryFrontend:            {QueryFrontendTripperware, QueryBytesBudget},
 		QueryScheduler:           {API, Overrides},
 		Ruler:                    {DistributorService, Overrides, StoreQueryable, RulerStorage},
 		RulerStorage:             {Overrides},
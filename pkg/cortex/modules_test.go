@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
 	prom_storage "github.com/prometheus/prometheus/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -80,6 +81,13 @@ func TestAPIConfig(t *testing.T) {
 		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
+			// The API registers the push handlers metrics.
+			savedRegistry := prometheus.DefaultRegisterer
+			prometheus.DefaultRegisterer = prometheus.NewRegistry()
+			t.Cleanup(func() {
+				prometheus.DefaultRegisterer = savedRegistry
+			})
+
 			cortex.Server.HTTP = mux.NewRouter()
 
 			cortex.Cfg = *actualCfg
@@ -2,8 +2,10 @@ package cortex
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"sort"
 
 	"gopkg.in/yaml.v2"
 
@@ -18,6 +20,8 @@ var (
 	errMultipleDocuments = errors.New("the provided runtime configuration contains multiple documents")
 )
 
+const maxRuntimeConfigValidateRequestSize = 10 << 20 // 10MB
+
 // RuntimeConfigValues are values that can be reloaded from configuration file while Cortex is running.
 // Reloading is done by runtime_config.Manager, which also keeps the currently loaded config.
 // These values are then pushed to the components that are interested in them.
@@ -163,3 +167,74 @@ func runtimeConfigHandler(runtimeCfgManager *runtimeconfig.Manager, defaultLimit
 		util.WriteYAMLResponse(w, output)
 	}
 }
+
+// runtimeConfigValidationError is a single validation error found in a candidate runtime config.
+type runtimeConfigValidationError struct {
+	Tenant string `json:"tenant,omitempty"`
+	Field  string `json:"field,omitempty"`
+	Error  string `json:"error"`
+}
+
+type runtimeConfigValidationResponse struct {
+	Valid  bool                           `json:"valid"`
+	Errors []runtimeConfigValidationError `json:"errors,omitempty"`
+}
+
+// validateRuntimeConfig validates the per-tenant limits of the given runtime config, including the
+// constraints spanning multiple fields, and returns all the errors found sorted by tenant.
+func validateRuntimeConfig(cfg *RuntimeConfigValues, shardByAllLabels bool) []runtimeConfigValidationError {
+	var errs []runtimeConfigValidationError
+
+	for userID, l := range cfg.TenantLimits {
+		if l == nil {
+			continue
+		}
+
+		if err := l.Validate(shardByAllLabels); err != nil {
+			errs = append(errs, runtimeConfigValidationError{Tenant: userID, Error: err.Error()})
+		}
+
+		// The single HA label pair is ignored when the HA label pairs are configured, which are
+		// checked by the limits validation.
+		if !l.AcceptHASamples || len(l.HALabelPairs) > 0 {
+			continue
+		}
+		if l.HAClusterLabel == "" {
+			errs = append(errs, runtimeConfigValidationError{Tenant: userID, Field: "ha_cluster_label", Error: "the HA cluster label must be set when accept_ha_samples is enabled"})
+		}
+		if l.HAReplicaLabel == "" {
+			errs = append(errs, runtimeConfigValidationError{Tenant: userID, Field: "ha_replica_label", Error: "the HA replica label must be set when accept_ha_samples is enabled"})
+		}
+		if l.HAClusterLabel != "" && l.HAClusterLabel == l.HAReplicaLabel {
+			errs = append(errs, runtimeConfigValidationError{Tenant: userID, Field: "ha_replica_label", Error: fmt.Sprintf("the HA replica label must be different than the HA cluster label %q", l.HAClusterLabel)})
+		}
+	}
+
+	sort.SliceStable(errs, func(i, j int) bool {
+		return errs[i].Tenant < errs[j].Tenant
+	})
+
+	return errs
+}
+
+// runtimeConfigValidateHandler validates the candidate runtime config received in the request body
+// without applying it.
+func runtimeConfigValidateHandler(shardByAllLabels bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resp := runtimeConfigValidationResponse{}
+
+		cfg, err := loadRuntimeConfig(http.MaxBytesReader(w, r.Body, maxRuntimeConfigValidateRequestSize))
+		if err != nil {
+			resp.Errors = []runtimeConfigValidationError{{Error: err.Error()}}
+		} else {
+			resp.Errors = validateRuntimeConfig(cfg.(*RuntimeConfigValues), shardByAllLabels)
+		}
+
+		resp.Valid = len(resp.Errors) == 0
+		if !resp.Valid {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+		}
+		util.WriteJSONResponse(w, resp)
+	}
+}
@@ -1,6 +1,8 @@
 package cortex
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
@@ -107,3 +109,61 @@ overrides:
 		assert.Nil(t, actual)
 	}
 }
+
+func TestRuntimeConfigValidateHandler(t *testing.T) {
+	tests := map[string]struct {
+		body             string
+		shardByAllLabels bool
+		expectedStatus   int
+		expectedBody     string
+	}{
+		"valid config": {
+			body: `
+overrides:
+  user-1:
+    ingestion_rate: 1500
+`,
+			shardByAllLabels: true,
+			expectedStatus:   http.StatusOK,
+			expectedBody:     `{"valid":true}`,
+		},
+		"malformed config": {
+			body: `
+overrides:
+  user-1:
+    unknown_limit: 1
+`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `{"valid":false,"errors":[{"error":"yaml: unmarshal errors:\n  line 4: field unknown_limit not found in type validation.plain"}]}`,
+		},
+		"per-tenant validation errors": {
+			body: `
+overrides:
+  user-2:
+    max_global_series_per_user: 100
+  user-1:
+    accept_ha_samples: true
+    ha_cluster_label: ""
+    ha_replica_label: ""
+`,
+			expectedStatus: http.StatusBadRequest,
+			expectedBody: `{"valid":false,"errors":[` +
+				`{"tenant":"user-1","field":"ha_cluster_label","error":"the HA cluster label must be set when accept_ha_samples is enabled"},` +
+				`{"tenant":"user-1","field":"ha_replica_label","error":"the HA replica label must be set when accept_ha_samples is enabled"},` +
+				`{"tenant":"user-2","error":"The ingester.max-global-series-per-user limit is unsupported if distributor.shard-by-all-labels is disabled"}]}`,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/runtime_config/validate", strings.NewReader(testData.body))
+			rec := httptest.NewRecorder()
+
+			runtimeConfigValidateHandler(testData.shardByAllLabels)(rec, req)
+
+			assert.Equal(t, testData.expectedStatus, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.JSONEq(t, testData.expectedBody, rec.Body.String())
+		})
+	}
+}
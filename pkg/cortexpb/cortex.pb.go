@@ -85,7 +85,7 @@ var MetricMetadata_MetricType_value = map[string]int32{
 }
 
 func (MetricMetadata_MetricType) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{5, 0}
+	return fileDescriptor_893a47d0a749d749, []int{9, 0}
 }
 
 type Histogram_ResetHint int32
@@ -112,7 +112,7 @@ var Histogram_ResetHint_value = map[string]int32{
 }
 
 func (Histogram_ResetHint) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{8, 0}
+	return fileDescriptor_893a47d0a749d749, []int{12, 0}
 }
 
 type WriteRequest struct {
@@ -176,6 +176,11 @@ func (m *WriteRequest) GetSkipLabelNameValidation() bool {
 }
 
 type WriteResponse struct {
+	// Number of float samples, histogram samples and exemplars accepted by the distributor, after the
+	// HA deduplication, relabelling and validation. Not set by the ingesters.
+	SamplesWritten    int64 `protobuf:"varint,1,opt,name=samples_written,proto3" json:"samplesWritten,omitempty"`
+	HistogramsWritten int64 `protobuf:"varint,2,opt,name=histograms_written,proto3" json:"histogramsWritten,omitempty"`
+	ExemplarsWritten  int64 `protobuf:"varint,3,opt,name=exemplars_written,proto3" json:"exemplarsWritten,omitempty"`
 }
 
 func (m *WriteResponse) Reset()      { *m = WriteResponse{} }
@@ -210,18 +215,302 @@ func (m *WriteResponse) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_WriteResponse proto.InternalMessageInfo
 
+func (m *WriteResponse) GetSamplesWritten() int64 {
+	if m != nil {
+		return m.SamplesWritten
+	}
+	return 0
+}
+
+func (m *WriteResponse) GetHistogramsWritten() int64 {
+	if m != nil {
+		return m.HistogramsWritten
+	}
+	return 0
+}
+
+func (m *WriteResponse) GetExemplarsWritten() int64 {
+	if m != nil {
+		return m.ExemplarsWritten
+	}
+	return 0
+}
+
+// WriteRequestV2 is the Prometheus remote write 2.0 request (io.prometheus.write.v2.Request).
+// The label names and values, and the metadata help and unit of the series are references to
+// the symbols table, in which the first symbol is always the empty string.
+type WriteRequestV2 struct {
+	Symbols    []string       `protobuf:"bytes,4,rep,name=symbols,proto3" json:"symbols,omitempty"`
+	Timeseries []TimeSeriesV2 `protobuf:"bytes,5,rep,name=timeseries,proto3" json:"timeseries"`
+}
+
+func (m *WriteRequestV2) Reset()      { *m = WriteRequestV2{} }
+func (*WriteRequestV2) ProtoMessage() {}
+func (*WriteRequestV2) Descriptor() ([]byte, []int) {
+	return fileDescriptor_893a47d0a749d749, []int{2}
+}
+func (m *WriteRequestV2) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WriteRequestV2) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WriteRequestV2.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WriteRequestV2) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteRequestV2.Merge(m, src)
+}
+func (m *WriteRequestV2) XXX_Size() int {
+	return m.Size()
+}
+func (m *WriteRequestV2) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteRequestV2.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteRequestV2 proto.InternalMessageInfo
+
+func (m *WriteRequestV2) GetSymbols() []string {
+	if m != nil {
+		return m.Symbols
+	}
+	return nil
+}
+
+func (m *WriteRequestV2) GetTimeseries() []TimeSeriesV2 {
+	if m != nil {
+		return m.Timeseries
+	}
+	return nil
+}
+
+type TimeSeriesV2 struct {
+	// Pairs of references to the label name and value in the symbols table.
+	LabelsRefs []uint32 `protobuf:"varint,1,rep,packed,name=labels_refs,json=labelsRefs,proto3" json:"labels_refs,omitempty"`
+	// Sorted by time, oldest sample first.
+	Samples    []Sample     `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples"`
+	Histograms []Histogram  `protobuf:"bytes,3,rep,name=histograms,proto3" json:"histograms"`
+	Exemplars  []ExemplarV2 `protobuf:"bytes,4,rep,name=exemplars,proto3" json:"exemplars"`
+	Metadata   MetadataV2   `protobuf:"bytes,5,opt,name=metadata,proto3" json:"metadata"`
+	// Timestamp (in ms) the counter, histogram or summary was created at. 0 if unknown.
+	CreatedTimestamp int64 `protobuf:"varint,6,opt,name=created_timestamp,json=createdTimestamp,proto3" json:"created_timestamp,omitempty"`
+}
+
+func (m *TimeSeriesV2) Reset()      { *m = TimeSeriesV2{} }
+func (*TimeSeriesV2) ProtoMessage() {}
+func (*TimeSeriesV2) Descriptor() ([]byte, []int) {
+	return fileDescriptor_893a47d0a749d749, []int{3}
+}
+func (m *TimeSeriesV2) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TimeSeriesV2) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TimeSeriesV2.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TimeSeriesV2) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeSeriesV2.Merge(m, src)
+}
+func (m *TimeSeriesV2) XXX_Size() int {
+	return m.Size()
+}
+func (m *TimeSeriesV2) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeSeriesV2.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeSeriesV2 proto.InternalMessageInfo
+
+func (m *TimeSeriesV2) GetLabelsRefs() []uint32 {
+	if m != nil {
+		return m.LabelsRefs
+	}
+	return nil
+}
+
+func (m *TimeSeriesV2) GetSamples() []Sample {
+	if m != nil {
+		return m.Samples
+	}
+	return nil
+}
+
+func (m *TimeSeriesV2) GetHistograms() []Histogram {
+	if m != nil {
+		return m.Histograms
+	}
+	return nil
+}
+
+func (m *TimeSeriesV2) GetExemplars() []ExemplarV2 {
+	if m != nil {
+		return m.Exemplars
+	}
+	return nil
+}
+
+func (m *TimeSeriesV2) GetMetadata() MetadataV2 {
+	if m != nil {
+		return m.Metadata
+	}
+	return MetadataV2{}
+}
+
+func (m *TimeSeriesV2) GetCreatedTimestamp() int64 {
+	if m != nil {
+		return m.CreatedTimestamp
+	}
+	return 0
+}
+
+type ExemplarV2 struct {
+	// Pairs of references to the label name and value in the symbols table.
+	LabelsRefs []uint32 `protobuf:"varint,1,rep,packed,name=labels_refs,json=labelsRefs,proto3" json:"labels_refs,omitempty"`
+	Value      float64  `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	Timestamp  int64    `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+}
+
+func (m *ExemplarV2) Reset()      { *m = ExemplarV2{} }
+func (*ExemplarV2) ProtoMessage() {}
+func (*ExemplarV2) Descriptor() ([]byte, []int) {
+	return fileDescriptor_893a47d0a749d749, []int{4}
+}
+func (m *ExemplarV2) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ExemplarV2) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ExemplarV2.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ExemplarV2) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExemplarV2.Merge(m, src)
+}
+func (m *ExemplarV2) XXX_Size() int {
+	return m.Size()
+}
+func (m *ExemplarV2) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExemplarV2.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExemplarV2 proto.InternalMessageInfo
+
+func (m *ExemplarV2) GetLabelsRefs() []uint32 {
+	if m != nil {
+		return m.LabelsRefs
+	}
+	return nil
+}
+
+func (m *ExemplarV2) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *ExemplarV2) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+type MetadataV2 struct {
+	Type MetricMetadata_MetricType `protobuf:"varint,1,opt,name=type,proto3,enum=cortexpb.MetricMetadata_MetricType" json:"type,omitempty"`
+	// References to the help and unit in the symbols table.
+	HelpRef uint32 `protobuf:"varint,3,opt,name=help_ref,json=helpRef,proto3" json:"help_ref,omitempty"`
+	UnitRef uint32 `protobuf:"varint,4,opt,name=unit_ref,json=unitRef,proto3" json:"unit_ref,omitempty"`
+}
+
+func (m *MetadataV2) Reset()      { *m = MetadataV2{} }
+func (*MetadataV2) ProtoMessage() {}
+func (*MetadataV2) Descriptor() ([]byte, []int) {
+	return fileDescriptor_893a47d0a749d749, []int{5}
+}
+func (m *MetadataV2) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MetadataV2) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MetadataV2.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MetadataV2) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetadataV2.Merge(m, src)
+}
+func (m *MetadataV2) XXX_Size() int {
+	return m.Size()
+}
+func (m *MetadataV2) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetadataV2.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetadataV2 proto.InternalMessageInfo
+
+func (m *MetadataV2) GetType() MetricMetadata_MetricType {
+	if m != nil {
+		return m.Type
+	}
+	return UNKNOWN
+}
+
+func (m *MetadataV2) GetHelpRef() uint32 {
+	if m != nil {
+		return m.HelpRef
+	}
+	return 0
+}
+
+func (m *MetadataV2) GetUnitRef() uint32 {
+	if m != nil {
+		return m.UnitRef
+	}
+	return 0
+}
+
 type TimeSeries struct {
 	Labels []LabelAdapter `protobuf:"bytes,1,rep,name=labels,proto3,customtype=LabelAdapter" json:"labels"`
 	// Sorted by time, oldest sample first.
 	Samples    []Sample    `protobuf:"bytes,2,rep,name=samples,proto3" json:"samples"`
 	Exemplars  []Exemplar  `protobuf:"bytes,3,rep,name=exemplars,proto3" json:"exemplars"`
 	Histograms []Histogram `protobuf:"bytes,4,rep,name=histograms,proto3" json:"histograms"`
+	// Timestamp (in ms) the counter, histogram or summary was created at, received with the
+	// remote write 2.0 protocol. 0 if unknown.
+	CreatedTimestampMs int64 `protobuf:"varint,5,opt,name=created_timestamp_ms,json=createdTimestampMs,proto3" json:"created_timestamp_ms,omitempty"`
 }
 
 func (m *TimeSeries) Reset()      { *m = TimeSeries{} }
 func (*TimeSeries) ProtoMessage() {}
 func (*TimeSeries) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{2}
+	return fileDescriptor_893a47d0a749d749, []int{6}
 }
 func (m *TimeSeries) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -271,6 +560,13 @@ func (m *TimeSeries) GetHistograms() []Histogram {
 	return nil
 }
 
+func (m *TimeSeries) GetCreatedTimestampMs() int64 {
+	if m != nil {
+		return m.CreatedTimestampMs
+	}
+	return 0
+}
+
 type LabelPair struct {
 	Name  []byte `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	Value []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
@@ -279,7 +575,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{3}
+	return fileDescriptor_893a47d0a749d749, []int{7}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -330,7 +626,7 @@ type Sample struct {
 func (m *Sample) Reset()      { *m = Sample{} }
 func (*Sample) ProtoMessage() {}
 func (*Sample) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{4}
+	return fileDescriptor_893a47d0a749d749, []int{8}
 }
 func (m *Sample) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -383,7 +679,7 @@ type MetricMetadata struct {
 func (m *MetricMetadata) Reset()      { *m = MetricMetadata{} }
 func (*MetricMetadata) ProtoMessage() {}
 func (*MetricMetadata) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{5}
+	return fileDescriptor_893a47d0a749d749, []int{9}
 }
 func (m *MetricMetadata) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -447,7 +743,7 @@ type Metric struct {
 func (m *Metric) Reset()      { *m = Metric{} }
 func (*Metric) ProtoMessage() {}
 func (*Metric) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{6}
+	return fileDescriptor_893a47d0a749d749, []int{10}
 }
 func (m *Metric) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -486,7 +782,7 @@ type Exemplar struct {
 func (m *Exemplar) Reset()      { *m = Exemplar{} }
 func (*Exemplar) ProtoMessage() {}
 func (*Exemplar) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{7}
+	return fileDescriptor_893a47d0a749d749, []int{11}
 }
 func (m *Exemplar) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -537,7 +833,6 @@ func (m *Exemplar) GetTimestampMs() int64 {
 // integer histogram as well as a float histogram.
 type Histogram struct {
 	// Types that are valid to be assigned to Count:
-	//
 	//	*Histogram_CountInt
 	//	*Histogram_CountFloat
 	Count isHistogram_Count `protobuf_oneof:"count"`
@@ -552,7 +847,6 @@ type Histogram struct {
 	Schema        int32   `protobuf:"zigzag32,4,opt,name=schema,proto3" json:"schema,omitempty"`
 	ZeroThreshold float64 `protobuf:"fixed64,5,opt,name=zero_threshold,json=zeroThreshold,proto3" json:"zero_threshold,omitempty"`
 	// Types that are valid to be assigned to ZeroCount:
-	//
 	//	*Histogram_ZeroCountInt
 	//	*Histogram_ZeroCountFloat
 	ZeroCount isHistogram_ZeroCount `protobuf_oneof:"zero_count"`
@@ -579,7 +873,7 @@ type Histogram struct {
 func (m *Histogram) Reset()      { *m = Histogram{} }
 func (*Histogram) ProtoMessage() {}
 func (*Histogram) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{8}
+	return fileDescriptor_893a47d0a749d749, []int{12}
 }
 func (m *Histogram) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -780,7 +1074,7 @@ type BucketSpan struct {
 func (m *BucketSpan) Reset()      { *m = BucketSpan{} }
 func (*BucketSpan) ProtoMessage() {}
 func (*BucketSpan) Descriptor() ([]byte, []int) {
-	return fileDescriptor_893a47d0a749d749, []int{9}
+	return fileDescriptor_893a47d0a749d749, []int{13}
 }
 func (m *BucketSpan) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -829,6 +1123,10 @@ func init() {
 	proto.RegisterEnum("cortexpb.Histogram_ResetHint", Histogram_ResetHint_name, Histogram_ResetHint_value)
 	proto.RegisterType((*WriteRequest)(nil), "cortexpb.WriteRequest")
 	proto.RegisterType((*WriteResponse)(nil), "cortexpb.WriteResponse")
+	proto.RegisterType((*WriteRequestV2)(nil), "cortexpb.WriteRequestV2")
+	proto.RegisterType((*TimeSeriesV2)(nil), "cortexpb.TimeSeriesV2")
+	proto.RegisterType((*ExemplarV2)(nil), "cortexpb.ExemplarV2")
+	proto.RegisterType((*MetadataV2)(nil), "cortexpb.MetadataV2")
 	proto.RegisterType((*TimeSeries)(nil), "cortexpb.TimeSeries")
 	proto.RegisterType((*LabelPair)(nil), "cortexpb.LabelPair")
 	proto.RegisterType((*Sample)(nil), "cortexpb.Sample")
@@ -842,72 +1140,87 @@ func init() {
 func init() { proto.RegisterFile("cortex.proto", fileDescriptor_893a47d0a749d749) }
 
 var fileDescriptor_893a47d0a749d749 = []byte{
-	// 1031 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4b, 0x6f, 0x23, 0x45,
-	0x17, 0xed, 0x72, 0xfb, 0x79, 0x63, 0x3b, 0x3d, 0xf5, 0x45, 0x1f, 0xad, 0x48, 0xd3, 0x71, 0x1a,
-	0x01, 0x16, 0x42, 0x01, 0x05, 0x01, 0x9a, 0x51, 0x84, 0x64, 0x0f, 0xce, 0x43, 0x33, 0x76, 0xa2,
-	0xb2, 0xc3, 0x68, 0xd8, 0x58, 0x15, 0xa7, 0x12, 0xb7, 0xa6, 0x5f, 0x74, 0x95, 0xa3, 0x09, 0x2b,
-	0x56, 0x88, 0x25, 0x6b, 0xb6, 0x6c, 0xf8, 0x05, 0xfc, 0x86, 0x2c, 0xb3, 0x1c, 0xb1, 0x88, 0x88,
-	0xb3, 0x99, 0xe5, 0x2c, 0xf8, 0x01, 0xa8, 0xaa, 0x5f, 0xce, 0x84, 0x11, 0x9b, 0xd9, 0x55, 0x9d,
-	0x7b, 0xcf, 0xbd, 0xa7, 0xea, 0x9e, 0x2e, 0x35, 0xd4, 0x27, 0x41, 0x24, 0xd8, 0x8b, 0x8d, 0x30,
-	0x0a, 0x44, 0x80, 0xab, 0xf1, 0x2e, 0x3c, 0x5a, 0x5d, 0x39, 0x0d, 0x4e, 0x03, 0x05, 0x7e, 0x2a,
-	0x57, 0x71, 0xdc, 0xfe, 0xa3, 0x00, 0xf5, 0xa7, 0x91, 0x23, 0x18, 0x61, 0xdf, 0xcf, 0x18, 0x17,
-	0xf8, 0x00, 0x40, 0x38, 0x1e, 0xe3, 0x2c, 0x72, 0x18, 0x37, 0x51, 0x4b, 0x6f, 0x2f, 0x6d, 0xae,
-	0x6c, 0xa4, 0x55, 0x36, 0x46, 0x8e, 0xc7, 0x86, 0x2a, 0xd6, 0x5d, 0xbd, 0xb8, 0x5a, 0xd3, 0xfe,
-	0xbc, 0x5a, 0xc3, 0x07, 0x11, 0xa3, 0xae, 0x1b, 0x4c, 0x46, 0x19, 0x8f, 0x2c, 0xd4, 0xc0, 0x0f,
-	0xa0, 0x3c, 0x0c, 0x66, 0xd1, 0x84, 0x99, 0x85, 0x16, 0x6a, 0x37, 0x37, 0xd7, 0xf3, 0x6a, 0x8b,
-	0x9d, 0x37, 0xe2, 0xa4, 0x9e, 0x3f, 0xf3, 0x48, 0x42, 0xc0, 0x0f, 0xa1, 0xea, 0x31, 0x41, 0x8f,
-	0xa9, 0xa0, 0xa6, 0xae, 0xa4, 0x98, 0x39, 0xb9, 0xcf, 0x44, 0xe4, 0x4c, 0xfa, 0x49, 0xbc, 0x5b,
-	0xbc, 0xb8, 0x5a, 0x43, 0x24, 0xcb, 0xc7, 0x5b, 0xb0, 0xca, 0x9f, 0x3b, 0xe1, 0xd8, 0xa5, 0x47,
-	0xcc, 0x1d, 0xfb, 0xd4, 0x63, 0xe3, 0x33, 0xea, 0x3a, 0xc7, 0x54, 0x38, 0x81, 0x6f, 0xbe, 0xaa,
-	0xb4, 0x50, 0xbb, 0x4a, 0xde, 0x93, 0x29, 0x4f, 0x64, 0xc6, 0x80, 0x7a, 0xec, 0xdb, 0x2c, 0x6e,
-	0xaf, 0x01, 0xe4, 0x7a, 0x70, 0x05, 0xf4, 0xce, 0xc1, 0x9e, 0xa1, 0xe1, 0x2a, 0x14, 0xc9, 0xe1,
-	0x93, 0x9e, 0x81, 0xec, 0x65, 0x68, 0x24, 0xea, 0x79, 0x18, 0xf8, 0x9c, 0xd9, 0x7f, 0x23, 0x80,
-	0xfc, 0x76, 0x70, 0x07, 0xca, 0xaa, 0x73, 0x7a, 0x87, 0xff, 0xcb, 0x85, 0xab, 0x7e, 0x07, 0xd4,
-	0x89, 0xba, 0x2b, 0xc9, 0x15, 0xd6, 0x15, 0xd4, 0x39, 0xa6, 0xa1, 0x60, 0x11, 0x49, 0x88, 0xf8,
-	0x33, 0xa8, 0x70, 0xea, 0x85, 0x2e, 0xe3, 0x66, 0x41, 0xd5, 0x30, 0xf2, 0x1a, 0x43, 0x15, 0x50,
-	0x87, 0xd6, 0x48, 0x9a, 0x86, 0xbf, 0x84, 0x1a, 0x7b, 0xc1, 0xbc, 0xd0, 0xa5, 0x11, 0x4f, 0x2e,
-	0x0c, 0xe7, 0x9c, 0x5e, 0x12, 0x4a, 0x58, 0x79, 0x2a, 0x7e, 0x00, 0x30, 0x75, 0xb8, 0x08, 0x4e,
-	0x23, 0xea, 0x71, 0xb3, 0xf8, 0xa6, 0xe0, 0xdd, 0x34, 0x96, 0x30, 0x17, 0x92, 0xed, 0x2f, 0xa0,
-	0x96, 0x9d, 0x07, 0x63, 0x28, 0xca, 0x8b, 0x36, 0x51, 0x0b, 0xb5, 0xeb, 0x44, 0xad, 0xf1, 0x0a,
-	0x94, 0xce, 0xa8, 0x3b, 0x8b, 0xa7, 0x5f, 0x27, 0xf1, 0xc6, 0xee, 0x40, 0x39, 0x3e, 0x42, 0x1e,
-	0x97, 0x24, 0x94, 0xc4, 0xf1, 0x3a, 0xd4, 0x95, 0x85, 0x04, 0xf5, 0xc2, 0xb1, 0xc7, 0x15, 0x59,
-	0x27, 0x4b, 0x19, 0xd6, 0xe7, 0xf6, 0xaf, 0x05, 0x68, 0xde, 0xf6, 0x00, 0xfe, 0x0a, 0x8a, 0xe2,
-	0x3c, 0x8c, 0x4b, 0x35, 0x37, 0xdf, 0x7f, 0x9b, 0x57, 0x92, 0xed, 0xe8, 0x3c, 0x64, 0x44, 0x11,
-	0xf0, 0x27, 0x80, 0x3d, 0x85, 0x8d, 0x4f, 0xa8, 0xe7, 0xb8, 0xe7, 0xca, 0x2f, 0xaa, 0x69, 0x8d,
-	0x18, 0x71, 0x64, 0x5b, 0x05, 0xa4, 0x4d, 0xe4, 0x31, 0xa7, 0xcc, 0x0d, 0xcd, 0xa2, 0x8a, 0xab,
-	0xb5, 0xc4, 0x66, 0xbe, 0x23, 0xcc, 0x52, 0x8c, 0xc9, 0xb5, 0x7d, 0x0e, 0x90, 0x77, 0xc2, 0x4b,
-	0x50, 0x39, 0x1c, 0x3c, 0x1e, 0xec, 0x3f, 0x1d, 0x18, 0x9a, 0xdc, 0x3c, 0xda, 0x3f, 0x1c, 0x8c,
-	0x7a, 0xc4, 0x40, 0xb8, 0x06, 0xa5, 0x9d, 0xce, 0xe1, 0x4e, 0xcf, 0x28, 0xe0, 0x06, 0xd4, 0x76,
-	0xf7, 0x86, 0xa3, 0xfd, 0x1d, 0xd2, 0xe9, 0x1b, 0x3a, 0xc6, 0xd0, 0x54, 0x91, 0x1c, 0x2b, 0x4a,
-	0xea, 0xf0, 0xb0, 0xdf, 0xef, 0x90, 0x67, 0x46, 0x49, 0x1a, 0x72, 0x6f, 0xb0, 0xbd, 0x6f, 0x94,
-	0x71, 0x1d, 0xaa, 0xc3, 0x51, 0x67, 0xd4, 0x1b, 0xf6, 0x46, 0x46, 0xc5, 0x7e, 0x0c, 0xe5, 0xb8,
-	0xf5, 0x3b, 0x30, 0xa2, 0xfd, 0x13, 0x82, 0x6a, 0x6a, 0x9e, 0x77, 0x61, 0xec, 0x5b, 0x96, 0x78,
-	0xeb, 0xc8, 0xf5, 0xbb, 0x23, 0xbf, 0x2c, 0x41, 0x2d, 0x33, 0x23, 0xbe, 0x0f, 0xb5, 0x49, 0x30,
-	0xf3, 0xc5, 0xd8, 0xf1, 0x85, 0x1a, 0x79, 0x71, 0x57, 0x23, 0x55, 0x05, 0xed, 0xf9, 0x02, 0xaf,
-	0xc3, 0x52, 0x1c, 0x3e, 0x71, 0x03, 0x2a, 0xe2, 0x5e, 0xbb, 0x1a, 0x01, 0x05, 0x6e, 0x4b, 0x0c,
-	0x1b, 0xa0, 0xf3, 0x99, 0xa7, 0x3a, 0x21, 0x22, 0x97, 0xf8, 0xff, 0x50, 0xe6, 0x93, 0x29, 0xf3,
-	0xa8, 0x1a, 0xee, 0x3d, 0x92, 0xec, 0xf0, 0x07, 0xd0, 0xfc, 0x81, 0x45, 0xc1, 0x58, 0x4c, 0x23,
-	0xc6, 0xa7, 0x81, 0x7b, 0xac, 0x06, 0x8d, 0x48, 0x43, 0xa2, 0xa3, 0x14, 0xc4, 0x1f, 0x26, 0x69,
-	0xb9, 0xae, 0xb2, 0xd2, 0x85, 0x48, 0x5d, 0xe2, 0x8f, 0x52, 0x6d, 0x1f, 0x83, 0xb1, 0x90, 0x17,
-	0x0b, 0xac, 0x28, 0x81, 0x88, 0x34, 0xb3, 0xcc, 0x58, 0x64, 0x07, 0x9a, 0x3e, 0x3b, 0xa5, 0xc2,
-	0x39, 0x63, 0x63, 0x1e, 0x52, 0x9f, 0x9b, 0xd5, 0x37, 0x5f, 0xe5, 0xee, 0x6c, 0xf2, 0x9c, 0x89,
-	0x61, 0x48, 0xfd, 0xe4, 0x0b, 0x6d, 0xa4, 0x0c, 0x89, 0x71, 0xfc, 0x11, 0x2c, 0x67, 0x25, 0x8e,
-	0x99, 0x2b, 0x28, 0x37, 0x6b, 0x2d, 0xbd, 0x8d, 0x49, 0x56, 0xf9, 0x1b, 0x85, 0xde, 0x4a, 0x54,
-	0xda, 0xb8, 0x09, 0x2d, 0xbd, 0x8d, 0xf2, 0x44, 0x25, 0x4c, 0x3e, 0x6f, 0xcd, 0x30, 0xe0, 0xce,
-	0x82, 0xa8, 0xa5, 0xff, 0x16, 0x95, 0x32, 0x32, 0x51, 0x59, 0x89, 0x44, 0x54, 0x3d, 0x16, 0x95,
-	0xc2, 0xb9, 0xa8, 0x2c, 0x31, 0x11, 0xd5, 0x88, 0x45, 0xa5, 0x70, 0x22, 0x6a, 0x0b, 0x20, 0x62,
-	0x9c, 0x89, 0xf1, 0x54, 0xde, 0x7c, 0x53, 0x3d, 0x02, 0xf7, 0xff, 0xe5, 0x19, 0xdb, 0x20, 0x32,
-	0x6b, 0xd7, 0xf1, 0x05, 0xa9, 0x45, 0xe9, 0xf2, 0x8e, 0xff, 0x96, 0xef, 0xfa, 0xef, 0x21, 0xd4,
-	0x32, 0xea, 0xed, 0xef, 0xb9, 0x02, 0xfa, 0xb3, 0xde, 0xd0, 0x40, 0xb8, 0x0c, 0x85, 0xc1, 0xbe,
-	0x51, 0xc8, 0xbf, 0x69, 0x7d, 0xb5, 0xf8, 0xf3, 0x6f, 0x16, 0xea, 0x56, 0xa0, 0xa4, 0xc4, 0x77,
-	0xeb, 0x00, 0xf9, 0xec, 0xed, 0x2d, 0x80, 0xfc, 0xa2, 0xa4, 0xfd, 0x82, 0x93, 0x13, 0xce, 0x62,
-	0x3f, 0xdf, 0x23, 0xc9, 0x4e, 0xe2, 0x2e, 0xf3, 0x4f, 0xc5, 0x54, 0xd9, 0xb8, 0x41, 0x92, 0x5d,
-	0xf7, 0xeb, 0xcb, 0x6b, 0x4b, 0x7b, 0x79, 0x6d, 0x69, 0xaf, 0xaf, 0x2d, 0xf4, 0xe3, 0xdc, 0x42,
-	0xbf, 0xcf, 0x2d, 0x74, 0x31, 0xb7, 0xd0, 0xe5, 0xdc, 0x42, 0x7f, 0xcd, 0x2d, 0xf4, 0x6a, 0x6e,
-	0x69, 0xaf, 0xe7, 0x16, 0xfa, 0xe5, 0xc6, 0xd2, 0x2e, 0x6f, 0x2c, 0xed, 0xe5, 0x8d, 0xa5, 0x7d,
-	0x97, 0xfd, 0x14, 0x1c, 0x95, 0xd5, 0x5f, 0xc0, 0xe7, 0xff, 0x04, 0x00, 0x00, 0xff, 0xff, 0x4b,
-	0xb6, 0xdb, 0xd4, 0x35, 0x08, 0x00, 0x00,
+	// 1272 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xcf, 0x6f, 0x1b, 0x45,
+	0x1b, 0xf6, 0x78, 0xd7, 0x3f, 0xf6, 0x8d, 0xed, 0x6e, 0xe6, 0x8b, 0xfa, 0xed, 0x17, 0x7d, 0xdd,
+	0xb8, 0x8b, 0x00, 0x8b, 0x42, 0xa8, 0x82, 0x28, 0xb4, 0x8a, 0x90, 0xec, 0xe2, 0x36, 0xa1, 0x8d,
+	0x13, 0x8d, 0x9d, 0x56, 0xe5, 0x62, 0x6d, 0x9c, 0x71, 0xbc, 0xea, 0xfe, 0x62, 0x77, 0x5c, 0x1a,
+	0xb8, 0x70, 0x42, 0x1c, 0x39, 0x70, 0xe2, 0x8a, 0x84, 0xf8, 0x0b, 0xb8, 0xf0, 0x0f, 0xf4, 0x98,
+	0x63, 0xc5, 0xa1, 0xa2, 0xee, 0xa5, 0xc7, 0xfe, 0x09, 0x68, 0x66, 0x7f, 0x8c, 0x9d, 0x14, 0x55,
+	0x54, 0xbd, 0xcd, 0x3c, 0xef, 0xf3, 0xce, 0xfb, 0xec, 0xfb, 0x3e, 0x33, 0x36, 0xd4, 0x46, 0x41,
+	0xc4, 0xe8, 0xc3, 0xf5, 0x30, 0x0a, 0x58, 0x80, 0xab, 0xc9, 0x2e, 0x3c, 0x58, 0x5d, 0x39, 0x0a,
+	0x8e, 0x02, 0x01, 0x7e, 0xc8, 0x57, 0x49, 0xdc, 0xfa, 0xbd, 0x08, 0xb5, 0xbb, 0x91, 0xc3, 0x28,
+	0xa1, 0x5f, 0x4d, 0x69, 0xcc, 0xf0, 0x1e, 0x00, 0x73, 0x3c, 0x1a, 0xd3, 0xc8, 0xa1, 0xb1, 0x81,
+	0x9a, 0x4a, 0x6b, 0x69, 0x63, 0x65, 0x3d, 0x3b, 0x65, 0x7d, 0xe0, 0x78, 0xb4, 0x2f, 0x62, 0x9d,
+	0xd5, 0x47, 0x4f, 0xd6, 0x0a, 0x7f, 0x3e, 0x59, 0xc3, 0x7b, 0x11, 0xb5, 0x5d, 0x37, 0x18, 0x0d,
+	0xf2, 0x3c, 0x32, 0x77, 0x06, 0xbe, 0x0a, 0xe5, 0x7e, 0x30, 0x8d, 0x46, 0xd4, 0x28, 0x36, 0x51,
+	0xab, 0xb1, 0x71, 0x51, 0x9e, 0x36, 0x5f, 0x79, 0x3d, 0x21, 0x75, 0xfd, 0xa9, 0x47, 0xd2, 0x04,
+	0x7c, 0x0d, 0xaa, 0x1e, 0x65, 0xf6, 0xa1, 0xcd, 0x6c, 0x43, 0x11, 0x52, 0x0c, 0x99, 0xbc, 0x43,
+	0x59, 0xe4, 0x8c, 0x76, 0xd2, 0x78, 0x47, 0x7d, 0xf4, 0x64, 0x0d, 0x91, 0x9c, 0x8f, 0x37, 0x61,
+	0x35, 0xbe, 0xef, 0x84, 0x43, 0xd7, 0x3e, 0xa0, 0xee, 0xd0, 0xb7, 0x3d, 0x3a, 0x7c, 0x60, 0xbb,
+	0xce, 0xa1, 0xcd, 0x9c, 0xc0, 0x37, 0x9e, 0x57, 0x9a, 0xa8, 0x55, 0x25, 0xff, 0xe5, 0x94, 0xdb,
+	0x9c, 0xd1, 0xb3, 0x3d, 0x7a, 0x27, 0x8f, 0x5b, 0x6b, 0x00, 0x52, 0x0f, 0xae, 0x80, 0xd2, 0xde,
+	0xdb, 0xd6, 0x0b, 0xb8, 0x0a, 0x2a, 0xd9, 0xbf, 0xdd, 0xd5, 0x91, 0xf5, 0x13, 0x82, 0x7a, 0x2a,
+	0x3f, 0x0e, 0x03, 0x3f, 0xa6, 0xf8, 0x5d, 0x38, 0x17, 0xdb, 0x5e, 0xe8, 0xd2, 0x78, 0xf8, 0x75,
+	0xe4, 0x30, 0x46, 0x7d, 0x03, 0x35, 0x51, 0x4b, 0x21, 0x8d, 0x14, 0xbe, 0x9b, 0xa0, 0xf8, 0x03,
+	0xc0, 0x13, 0x27, 0x66, 0xc1, 0x51, 0x64, 0x7b, 0x92, 0x5b, 0x14, 0xdc, 0x65, 0x19, 0xc9, 0xe8,
+	0x97, 0x60, 0x99, 0x3e, 0xa4, 0x5e, 0xe8, 0xda, 0x91, 0x64, 0x2b, 0x82, 0xad, 0xe7, 0x81, 0x94,
+	0x6c, 0xf9, 0xd0, 0x98, 0x6f, 0xea, 0x9d, 0x0d, 0x6c, 0x40, 0x25, 0x3e, 0xf6, 0x0e, 0x02, 0x37,
+	0x36, 0xd4, 0xa6, 0xd2, 0xd2, 0x48, 0xb6, 0xc5, 0x9b, 0x0b, 0xa3, 0x2e, 0x89, 0xfe, 0x9e, 0x7f,
+	0xd9, 0xa8, 0xef, 0x6c, 0x88, 0xee, 0x16, 0xe6, 0xc7, 0xfa, 0x85, 0x5a, 0x45, 0xba, 0x6a, 0xfd,
+	0x51, 0x84, 0xda, 0x3c, 0x11, 0xaf, 0xc1, 0x92, 0xe8, 0x78, 0x3c, 0x8c, 0xe8, 0x38, 0x31, 0x50,
+	0x9d, 0x40, 0x02, 0x11, 0x3a, 0x8e, 0xf1, 0x65, 0xa8, 0xa4, 0xfd, 0x30, 0x8a, 0xa2, 0xa4, 0x2e,
+	0x4b, 0xf6, 0x45, 0x20, 0x2d, 0x96, 0xd1, 0xf0, 0x55, 0x00, 0xd9, 0x95, 0xd4, 0x07, 0xff, 0x91,
+	0x49, 0x5b, 0x59, 0x2c, 0x13, 0x29, 0xc9, 0xf8, 0x53, 0xd0, 0xf2, 0x16, 0x19, 0xea, 0x69, 0x33,
+	0x77, 0xd3, 0x50, 0xfe, 0x7d, 0x92, 0x8c, 0xaf, 0xcc, 0x59, 0xaf, 0xd4, 0x44, 0x8b, 0x89, 0x99,
+	0xe9, 0xf2, 0x44, 0x69, 0xbb, 0x4b, 0xb0, 0x3c, 0x8a, 0xa8, 0xcd, 0xe8, 0xe1, 0x50, 0x34, 0x8b,
+	0xd9, 0x5e, 0x68, 0x94, 0x93, 0x69, 0xa5, 0x81, 0x41, 0x86, 0x5b, 0x36, 0x80, 0xd4, 0xf0, 0xea,
+	0xd6, 0xad, 0x40, 0xe9, 0x81, 0xed, 0x4e, 0x93, 0x8b, 0x84, 0x48, 0xb2, 0xc1, 0xff, 0x07, 0x4d,
+	0x56, 0x4a, 0x7c, 0x21, 0x01, 0xeb, 0x5b, 0x00, 0xa9, 0x16, 0x7f, 0x02, 0x2a, 0x3b, 0x0e, 0xa9,
+	0x30, 0x66, 0x63, 0xe3, 0xad, 0x7f, 0xba, 0x4c, 0xe9, 0x76, 0x70, 0x1c, 0x52, 0x22, 0x12, 0xf0,
+	0xff, 0xa0, 0x3a, 0xa1, 0x6e, 0xc8, 0x95, 0x89, 0x1a, 0x75, 0x52, 0xe1, 0x7b, 0x42, 0xc7, 0x3c,
+	0x34, 0xf5, 0x1d, 0x26, 0x42, 0x6a, 0x12, 0xe2, 0x7b, 0x42, 0xc7, 0xd6, 0xaf, 0x45, 0x00, 0xe9,
+	0x0e, 0xdc, 0x86, 0x72, 0xf2, 0x35, 0x06, 0x3a, 0x3d, 0x44, 0x71, 0x07, 0xf7, 0x6c, 0x27, 0xea,
+	0xac, 0xa4, 0xcf, 0x4a, 0x4d, 0x40, 0xed, 0x43, 0x3b, 0x64, 0x34, 0x22, 0x69, 0xe2, 0x6b, 0xb8,
+	0xe7, 0xca, 0xbc, 0x05, 0x12, 0xf3, 0xe0, 0xb3, 0x16, 0x38, 0x6b, 0x80, 0x45, 0xd7, 0xa9, 0xff,
+	0xc6, 0x75, 0x97, 0x61, 0xe5, 0x8c, 0x07, 0x86, 0x5e, 0x2c, 0x7c, 0xa4, 0x10, 0x7c, 0xda, 0x06,
+	0x3b, 0xb1, 0xf5, 0x31, 0x68, 0x79, 0x07, 0x30, 0x06, 0x95, 0x3f, 0x57, 0x62, 0x48, 0x35, 0x22,
+	0xd6, 0x8b, 0xa3, 0xaf, 0xa5, 0xa3, 0xb7, 0xda, 0x50, 0x4e, 0x3e, 0x5a, 0xc6, 0xd1, 0xbc, 0x35,
+	0x2e, 0x42, 0x6d, 0x41, 0x40, 0xf2, 0xc6, 0x2c, 0xb1, 0xb9, 0xca, 0x3f, 0x17, 0xa1, 0xb1, 0x38,
+	0xfc, 0xd7, 0x37, 0xc9, 0xfb, 0x80, 0x3d, 0x81, 0x0d, 0xc7, 0xb6, 0xe7, 0xb8, 0xc7, 0xe2, 0xd5,
+	0x15, 0x45, 0x35, 0xa2, 0x27, 0x91, 0x1b, 0x22, 0xc0, 0x1f, 0x5b, 0xfe, 0x99, 0xdc, 0x42, 0xc2,
+	0x33, 0x1a, 0x11, 0x6b, 0x8e, 0x71, 0xef, 0x88, 0x4e, 0x69, 0x44, 0xac, 0xad, 0x63, 0x00, 0x59,
+	0x09, 0x2f, 0x41, 0x65, 0xbf, 0x77, 0xab, 0xb7, 0x7b, 0xb7, 0xa7, 0x17, 0xf8, 0xe6, 0xfa, 0xee,
+	0x7e, 0x6f, 0xd0, 0x25, 0x3a, 0xc2, 0x1a, 0x94, 0x6e, 0xb6, 0xf7, 0x6f, 0x76, 0xf5, 0x22, 0xae,
+	0x83, 0xb6, 0xb5, 0xdd, 0x1f, 0xec, 0xde, 0x24, 0xed, 0x1d, 0x5d, 0xc1, 0x18, 0x1a, 0x22, 0x22,
+	0x31, 0x95, 0xa7, 0xf6, 0xf7, 0x77, 0x76, 0xda, 0xe4, 0x9e, 0x5e, 0xe2, 0xcf, 0xfa, 0x76, 0xef,
+	0xc6, 0xae, 0x5e, 0xc6, 0x35, 0xa8, 0xf6, 0x07, 0xed, 0x41, 0xb7, 0xdf, 0x1d, 0xe8, 0x15, 0xeb,
+	0x16, 0x94, 0x93, 0xd2, 0x6f, 0xc0, 0xba, 0xd6, 0xf7, 0x08, 0xaa, 0x99, 0xdd, 0xde, 0xc4, 0x55,
+	0x78, 0xf9, 0x6b, 0x70, 0x7a, 0xe4, 0xca, 0xd9, 0x91, 0x9f, 0x94, 0x40, 0xcb, 0xed, 0x8b, 0x2f,
+	0x80, 0x36, 0x0a, 0xa6, 0x3e, 0x1b, 0x3a, 0x3e, 0x13, 0x23, 0x57, 0xb7, 0x0a, 0xa4, 0x2a, 0xa0,
+	0x6d, 0x9f, 0xe1, 0x8b, 0xb0, 0x94, 0x84, 0xc7, 0x6e, 0x60, 0xb3, 0xa4, 0xd6, 0x56, 0x81, 0x80,
+	0x00, 0x6f, 0x70, 0x0c, 0xeb, 0xa0, 0xc4, 0x53, 0x4f, 0x54, 0x42, 0x84, 0x2f, 0xf1, 0x79, 0x28,
+	0xc7, 0xa3, 0x09, 0xf5, 0x6c, 0x31, 0xdc, 0x65, 0x92, 0xee, 0xf0, 0xdb, 0xd0, 0xf8, 0x86, 0x46,
+	0xc1, 0x90, 0x4d, 0x22, 0x1a, 0x4f, 0x02, 0xf7, 0x50, 0x0c, 0x1a, 0x91, 0x3a, 0x47, 0x07, 0x19,
+	0x88, 0xdf, 0x49, 0x69, 0x52, 0x57, 0x59, 0xe8, 0x42, 0xa4, 0xc6, 0xf1, 0xeb, 0x99, 0xb6, 0xf7,
+	0x40, 0x9f, 0xe3, 0x25, 0x02, 0x2b, 0x42, 0x20, 0x22, 0x8d, 0x9c, 0x99, 0x88, 0x6c, 0x43, 0xc3,
+	0xa7, 0x47, 0x36, 0x73, 0x1e, 0xd0, 0x61, 0x1c, 0xda, 0x7e, 0x6c, 0x54, 0x4f, 0xff, 0x1c, 0x74,
+	0xa6, 0xa3, 0xfb, 0x94, 0xf5, 0x43, 0xdb, 0x4f, 0xef, 0x74, 0x3d, 0xcb, 0xe0, 0x58, 0xcc, 0x7f,
+	0xe0, 0xf3, 0x23, 0x0e, 0xa9, 0xcb, 0xec, 0xd8, 0xd0, 0x9a, 0x4a, 0x0b, 0x93, 0xfc, 0xe4, 0xcf,
+	0x05, 0xba, 0x40, 0x14, 0xda, 0x62, 0x03, 0x9a, 0x4a, 0x0b, 0x49, 0xa2, 0x10, 0xc6, 0x1f, 0xc4,
+	0x46, 0x18, 0xc4, 0xce, 0x9c, 0xa8, 0xa5, 0x57, 0x8b, 0xca, 0x32, 0x72, 0x51, 0xf9, 0x11, 0xa9,
+	0xa8, 0x5a, 0x22, 0x2a, 0x83, 0xa5, 0xa8, 0x9c, 0x98, 0x8a, 0xaa, 0x27, 0xa2, 0x32, 0x38, 0x15,
+	0xb5, 0x09, 0x10, 0xd1, 0x98, 0xb2, 0xe1, 0x84, 0x77, 0xbe, 0x21, 0x1e, 0x81, 0x0b, 0x2f, 0x79,
+	0xf8, 0xd6, 0x09, 0x67, 0x6d, 0x39, 0x3e, 0x23, 0x5a, 0x94, 0x2d, 0xcf, 0xf8, 0xef, 0xdc, 0x59,
+	0xff, 0x5d, 0x03, 0x2d, 0x4f, 0x5d, 0xbc, 0xcf, 0x15, 0x50, 0xee, 0x75, 0xfb, 0x3a, 0xc2, 0x65,
+	0x28, 0xf6, 0x76, 0xf5, 0xa2, 0xbc, 0xd3, 0xca, 0xaa, 0xfa, 0xc3, 0x2f, 0x26, 0xea, 0x54, 0xa0,
+	0x24, 0xc4, 0x77, 0x6a, 0x00, 0x72, 0xf6, 0xd6, 0x26, 0x80, 0x6c, 0x14, 0xb7, 0x5f, 0x30, 0x1e,
+	0xc7, 0x34, 0xf1, 0xf3, 0x32, 0x49, 0x77, 0x1c, 0x77, 0xa9, 0x7f, 0xc4, 0x26, 0xc2, 0xc6, 0x75,
+	0x92, 0xee, 0x3a, 0x9f, 0x9d, 0x3c, 0x35, 0x0b, 0x8f, 0x9f, 0x9a, 0x85, 0x17, 0x4f, 0x4d, 0xf4,
+	0xdd, 0xcc, 0x44, 0xbf, 0xcd, 0x4c, 0xf4, 0x68, 0x66, 0xa2, 0x93, 0x99, 0x89, 0xfe, 0x9a, 0x99,
+	0xe8, 0xf9, 0xcc, 0x2c, 0xbc, 0x98, 0x99, 0xe8, 0xc7, 0x67, 0x66, 0xe1, 0xe4, 0x99, 0x59, 0x78,
+	0xfc, 0xcc, 0x2c, 0x7c, 0x99, 0xff, 0xb5, 0x3e, 0x28, 0x8b, 0xff, 0xd2, 0x1f, 0xfd, 0x3d, 0x00,
+	0x1c, 0xa8, 0x32, 0x45, 0x7b, 0x0b, 0x00, 0x00,
 }
 
 func (x WriteRequest_SourceEnum) String() string {
@@ -993,16 +1306,25 @@ func (this *WriteResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if this.SamplesWritten != that1.SamplesWritten {
+		return false
+	}
+	if this.HistogramsWritten != that1.HistogramsWritten {
+		return false
+	}
+	if this.ExemplarsWritten != that1.ExemplarsWritten {
+		return false
+	}
 	return true
 }
-func (this *TimeSeries) Equal(that interface{}) bool {
+func (this *WriteRequestV2) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*TimeSeries)
+	that1, ok := that.(*WriteRequestV2)
 	if !ok {
-		that2, ok := that.(TimeSeries)
+		that2, ok := that.(WriteRequestV2)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1014,11 +1336,48 @@ func (this *TimeSeries) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Labels) != len(that1.Labels) {
+	if len(this.Symbols) != len(that1.Symbols) {
 		return false
 	}
-	for i := range this.Labels {
-		if !this.Labels[i].Equal(that1.Labels[i]) {
+	for i := range this.Symbols {
+		if this.Symbols[i] != that1.Symbols[i] {
+			return false
+		}
+	}
+	if len(this.Timeseries) != len(that1.Timeseries) {
+		return false
+	}
+	for i := range this.Timeseries {
+		if !this.Timeseries[i].Equal(&that1.Timeseries[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *TimeSeriesV2) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TimeSeriesV2)
+	if !ok {
+		that2, ok := that.(TimeSeriesV2)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.LabelsRefs) != len(that1.LabelsRefs) {
+		return false
+	}
+	for i := range this.LabelsRefs {
+		if this.LabelsRefs[i] != that1.LabelsRefs[i] {
 			return false
 		}
 	}
@@ -1030,6 +1389,14 @@ func (this *TimeSeries) Equal(that interface{}) bool {
 			return false
 		}
 	}
+	if len(this.Histograms) != len(that1.Histograms) {
+		return false
+	}
+	for i := range this.Histograms {
+		if !this.Histograms[i].Equal(&that1.Histograms[i]) {
+			return false
+		}
+	}
 	if len(this.Exemplars) != len(that1.Exemplars) {
 		return false
 	}
@@ -1038,24 +1405,22 @@ func (this *TimeSeries) Equal(that interface{}) bool {
 			return false
 		}
 	}
-	if len(this.Histograms) != len(that1.Histograms) {
+	if !this.Metadata.Equal(&that1.Metadata) {
 		return false
 	}
-	for i := range this.Histograms {
-		if !this.Histograms[i].Equal(&that1.Histograms[i]) {
-			return false
-		}
+	if this.CreatedTimestamp != that1.CreatedTimestamp {
+		return false
 	}
 	return true
 }
-func (this *LabelPair) Equal(that interface{}) bool {
+func (this *ExemplarV2) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*LabelPair)
+	that1, ok := that.(*ExemplarV2)
 	if !ok {
-		that2, ok := that.(LabelPair)
+		that2, ok := that.(ExemplarV2)
 		if ok {
 			that1 = &that2
 		} else {
@@ -1067,7 +1432,128 @@ func (this *LabelPair) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if !bytes.Equal(this.Name, that1.Name) {
+	if len(this.LabelsRefs) != len(that1.LabelsRefs) {
+		return false
+	}
+	for i := range this.LabelsRefs {
+		if this.LabelsRefs[i] != that1.LabelsRefs[i] {
+			return false
+		}
+	}
+	if this.Value != that1.Value {
+		return false
+	}
+	if this.Timestamp != that1.Timestamp {
+		return false
+	}
+	return true
+}
+func (this *MetadataV2) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MetadataV2)
+	if !ok {
+		that2, ok := that.(MetadataV2)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Type != that1.Type {
+		return false
+	}
+	if this.HelpRef != that1.HelpRef {
+		return false
+	}
+	if this.UnitRef != that1.UnitRef {
+		return false
+	}
+	return true
+}
+func (this *TimeSeries) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TimeSeries)
+	if !ok {
+		that2, ok := that.(TimeSeries)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
+		}
+	}
+	if len(this.Samples) != len(that1.Samples) {
+		return false
+	}
+	for i := range this.Samples {
+		if !this.Samples[i].Equal(&that1.Samples[i]) {
+			return false
+		}
+	}
+	if len(this.Exemplars) != len(that1.Exemplars) {
+		return false
+	}
+	for i := range this.Exemplars {
+		if !this.Exemplars[i].Equal(&that1.Exemplars[i]) {
+			return false
+		}
+	}
+	if len(this.Histograms) != len(that1.Histograms) {
+		return false
+	}
+	for i := range this.Histograms {
+		if !this.Histograms[i].Equal(&that1.Histograms[i]) {
+			return false
+		}
+	}
+	if this.CreatedTimestampMs != that1.CreatedTimestampMs {
+		return false
+	}
+	return true
+}
+func (this *LabelPair) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*LabelPair)
+	if !ok {
+		that2, ok := that.(LabelPair)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !bytes.Equal(this.Name, that1.Name) {
 		return false
 	}
 	if !bytes.Equal(this.Value, that1.Value) {
@@ -1443,8 +1929,85 @@ func (this *WriteResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 4)
+	s := make([]string, 0, 7)
 	s = append(s, "&cortexpb.WriteResponse{")
+	s = append(s, "SamplesWritten: "+fmt.Sprintf("%#v", this.SamplesWritten)+",\n")
+	s = append(s, "HistogramsWritten: "+fmt.Sprintf("%#v", this.HistogramsWritten)+",\n")
+	s = append(s, "ExemplarsWritten: "+fmt.Sprintf("%#v", this.ExemplarsWritten)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *WriteRequestV2) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&cortexpb.WriteRequestV2{")
+	s = append(s, "Symbols: "+fmt.Sprintf("%#v", this.Symbols)+",\n")
+	if this.Timeseries != nil {
+		vs := make([]*TimeSeriesV2, len(this.Timeseries))
+		for i := range vs {
+			vs[i] = &this.Timeseries[i]
+		}
+		s = append(s, "Timeseries: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *TimeSeriesV2) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 10)
+	s = append(s, "&cortexpb.TimeSeriesV2{")
+	s = append(s, "LabelsRefs: "+fmt.Sprintf("%#v", this.LabelsRefs)+",\n")
+	if this.Samples != nil {
+		vs := make([]*Sample, len(this.Samples))
+		for i := range vs {
+			vs[i] = &this.Samples[i]
+		}
+		s = append(s, "Samples: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.Histograms != nil {
+		vs := make([]*Histogram, len(this.Histograms))
+		for i := range vs {
+			vs[i] = &this.Histograms[i]
+		}
+		s = append(s, "Histograms: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.Exemplars != nil {
+		vs := make([]*ExemplarV2, len(this.Exemplars))
+		for i := range vs {
+			vs[i] = &this.Exemplars[i]
+		}
+		s = append(s, "Exemplars: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "Metadata: "+strings.Replace(this.Metadata.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "CreatedTimestamp: "+fmt.Sprintf("%#v", this.CreatedTimestamp)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ExemplarV2) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&cortexpb.ExemplarV2{")
+	s = append(s, "LabelsRefs: "+fmt.Sprintf("%#v", this.LabelsRefs)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "Timestamp: "+fmt.Sprintf("%#v", this.Timestamp)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *MetadataV2) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&cortexpb.MetadataV2{")
+	s = append(s, "Type: "+fmt.Sprintf("%#v", this.Type)+",\n")
+	s = append(s, "HelpRef: "+fmt.Sprintf("%#v", this.HelpRef)+",\n")
+	s = append(s, "UnitRef: "+fmt.Sprintf("%#v", this.UnitRef)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1452,7 +2015,7 @@ func (this *TimeSeries) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 8)
+	s := make([]string, 0, 9)
 	s = append(s, "&cortexpb.TimeSeries{")
 	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
 	if this.Samples != nil {
@@ -1476,6 +2039,7 @@ func (this *TimeSeries) GoString() string {
 		}
 		s = append(s, "Histograms: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
+	s = append(s, "CreatedTimestampMs: "+fmt.Sprintf("%#v", this.CreatedTimestampMs)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1713,10 +2277,25 @@ func (m *WriteResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ExemplarsWritten != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.ExemplarsWritten))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.HistogramsWritten != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.HistogramsWritten))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.SamplesWritten != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.SamplesWritten))
+		i--
+		dAtA[i] = 0x8
+	}
 	return len(dAtA) - i, nil
 }
 
-func (m *TimeSeries) Marshal() (dAtA []byte, err error) {
+func (m *WriteRequestV2) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1726,20 +2305,20 @@ func (m *TimeSeries) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *TimeSeries) MarshalTo(dAtA []byte) (int, error) {
+func (m *WriteRequestV2) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *WriteRequestV2) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Histograms) > 0 {
-		for iNdEx := len(m.Histograms) - 1; iNdEx >= 0; iNdEx-- {
+	if len(m.Timeseries) > 0 {
+		for iNdEx := len(m.Timeseries) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size, err := m.Histograms[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				size, err := m.Timeseries[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -1747,9 +2326,56 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 				i = encodeVarintCortex(dAtA, i, uint64(size))
 			}
 			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.Symbols) > 0 {
+		for iNdEx := len(m.Symbols) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Symbols[iNdEx])
+			copy(dAtA[i:], m.Symbols[iNdEx])
+			i = encodeVarintCortex(dAtA, i, uint64(len(m.Symbols[iNdEx])))
+			i--
 			dAtA[i] = 0x22
 		}
 	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TimeSeriesV2) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TimeSeriesV2) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TimeSeriesV2) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.CreatedTimestamp != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.CreatedTimestamp))
+		i--
+		dAtA[i] = 0x30
+	}
+	{
+		size, err := m.Metadata.MarshalToSizedBuffer(dAtA[:i])
+		if err != nil {
+			return 0, err
+		}
+		i -= size
+		i = encodeVarintCortex(dAtA, i, uint64(size))
+	}
+	i--
+	dAtA[i] = 0x2a
 	if len(m.Exemplars) > 0 {
 		for iNdEx := len(m.Exemplars) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -1761,13 +2387,13 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 				i = encodeVarintCortex(dAtA, i, uint64(size))
 			}
 			i--
-			dAtA[i] = 0x1a
+			dAtA[i] = 0x22
 		}
 	}
-	if len(m.Samples) > 0 {
-		for iNdEx := len(m.Samples) - 1; iNdEx >= 0; iNdEx-- {
+	if len(m.Histograms) > 0 {
+		for iNdEx := len(m.Histograms) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size, err := m.Samples[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				size, err := m.Histograms[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -1775,27 +2401,45 @@ func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 				i = encodeVarintCortex(dAtA, i, uint64(size))
 			}
 			i--
-			dAtA[i] = 0x12
+			dAtA[i] = 0x1a
 		}
 	}
-	if len(m.Labels) > 0 {
-		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+	if len(m.Samples) > 0 {
+		for iNdEx := len(m.Samples) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size := m.Labels[iNdEx].Size()
-				i -= size
-				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+				size, err := m.Samples[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
 					return 0, err
 				}
+				i -= size
 				i = encodeVarintCortex(dAtA, i, uint64(size))
 			}
 			i--
-			dAtA[i] = 0xa
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.LabelsRefs) > 0 {
+		dAtA3 := make([]byte, len(m.LabelsRefs)*10)
+		var j2 int
+		for _, num := range m.LabelsRefs {
+			for num >= 1<<7 {
+				dAtA3[j2] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j2++
+			}
+			dAtA3[j2] = uint8(num)
+			j2++
 		}
+		i -= j2
+		copy(dAtA[i:], dAtA3[:j2])
+		i = encodeVarintCortex(dAtA, i, uint64(j2))
+		i--
+		dAtA[i] = 0xa
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *LabelPair) Marshal() (dAtA []byte, err error) {
+func (m *ExemplarV2) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -1805,26 +2449,200 @@ func (m *LabelPair) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *LabelPair) MarshalTo(dAtA []byte) (int, error) {
+func (m *ExemplarV2) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *LabelPair) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *ExemplarV2) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Value) > 0 {
-		i -= len(m.Value)
-		copy(dAtA[i:], m.Value)
-		i = encodeVarintCortex(dAtA, i, uint64(len(m.Value)))
+	if m.Timestamp != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.Timestamp))
 		i--
-		dAtA[i] = 0x12
+		dAtA[i] = 0x18
 	}
-	if len(m.Name) > 0 {
-		i -= len(m.Name)
-		copy(dAtA[i:], m.Name)
+	if m.Value != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Value))))
+		i--
+		dAtA[i] = 0x11
+	}
+	if len(m.LabelsRefs) > 0 {
+		dAtA5 := make([]byte, len(m.LabelsRefs)*10)
+		var j4 int
+		for _, num := range m.LabelsRefs {
+			for num >= 1<<7 {
+				dAtA5[j4] = uint8(uint64(num)&0x7f | 0x80)
+				num >>= 7
+				j4++
+			}
+			dAtA5[j4] = uint8(num)
+			j4++
+		}
+		i -= j4
+		copy(dAtA[i:], dAtA5[:j4])
+		i = encodeVarintCortex(dAtA, i, uint64(j4))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetadataV2) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetadataV2) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetadataV2) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.UnitRef != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.UnitRef))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.HelpRef != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.HelpRef))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Type != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.Type))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *TimeSeries) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *TimeSeries) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *TimeSeries) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.CreatedTimestampMs != 0 {
+		i = encodeVarintCortex(dAtA, i, uint64(m.CreatedTimestampMs))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Histograms) > 0 {
+		for iNdEx := len(m.Histograms) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Histograms[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintCortex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.Exemplars) > 0 {
+		for iNdEx := len(m.Exemplars) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Exemplars[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintCortex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Samples) > 0 {
+		for iNdEx := len(m.Samples) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Samples[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintCortex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.Labels) > 0 {
+		for iNdEx := len(m.Labels) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size := m.Labels[iNdEx].Size()
+				i -= size
+				if _, err := m.Labels[iNdEx].MarshalTo(dAtA[i:]); err != nil {
+					return 0, err
+				}
+				i = encodeVarintCortex(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *LabelPair) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *LabelPair) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *LabelPair) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintCortex(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
 		i = encodeVarintCortex(dAtA, i, uint64(len(m.Name)))
 		i--
 		dAtA[i] = 0xa
@@ -2032,30 +2850,30 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.PositiveCounts) > 0 {
 		for iNdEx := len(m.PositiveCounts) - 1; iNdEx >= 0; iNdEx-- {
-			f1 := math.Float64bits(float64(m.PositiveCounts[iNdEx]))
+			f6 := math.Float64bits(float64(m.PositiveCounts[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f1))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f6))
 		}
 		i = encodeVarintCortex(dAtA, i, uint64(len(m.PositiveCounts)*8))
 		i--
 		dAtA[i] = 0x6a
 	}
 	if len(m.PositiveDeltas) > 0 {
-		var j2 int
-		dAtA4 := make([]byte, len(m.PositiveDeltas)*10)
+		var j7 int
+		dAtA9 := make([]byte, len(m.PositiveDeltas)*10)
 		for _, num := range m.PositiveDeltas {
-			x3 := (uint64(num) << 1) ^ uint64((num >> 63))
-			for x3 >= 1<<7 {
-				dAtA4[j2] = uint8(uint64(x3)&0x7f | 0x80)
-				j2++
-				x3 >>= 7
-			}
-			dAtA4[j2] = uint8(x3)
-			j2++
+			x8 := (uint64(num) << 1) ^ uint64((num >> 63))
+			for x8 >= 1<<7 {
+				dAtA9[j7] = uint8(uint64(x8)&0x7f | 0x80)
+				j7++
+				x8 >>= 7
+			}
+			dAtA9[j7] = uint8(x8)
+			j7++
 		}
-		i -= j2
-		copy(dAtA[i:], dAtA4[:j2])
-		i = encodeVarintCortex(dAtA, i, uint64(j2))
+		i -= j7
+		copy(dAtA[i:], dAtA9[:j7])
+		i = encodeVarintCortex(dAtA, i, uint64(j7))
 		i--
 		dAtA[i] = 0x62
 	}
@@ -2075,30 +2893,30 @@ func (m *Histogram) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	}
 	if len(m.NegativeCounts) > 0 {
 		for iNdEx := len(m.NegativeCounts) - 1; iNdEx >= 0; iNdEx-- {
-			f5 := math.Float64bits(float64(m.NegativeCounts[iNdEx]))
+			f10 := math.Float64bits(float64(m.NegativeCounts[iNdEx]))
 			i -= 8
-			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f5))
+			encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(f10))
 		}
 		i = encodeVarintCortex(dAtA, i, uint64(len(m.NegativeCounts)*8))
 		i--
 		dAtA[i] = 0x52
 	}
 	if len(m.NegativeDeltas) > 0 {
-		var j6 int
-		dAtA8 := make([]byte, len(m.NegativeDeltas)*10)
+		var j11 int
+		dAtA13 := make([]byte, len(m.NegativeDeltas)*10)
 		for _, num := range m.NegativeDeltas {
-			x7 := (uint64(num) << 1) ^ uint64((num >> 63))
-			for x7 >= 1<<7 {
-				dAtA8[j6] = uint8(uint64(x7)&0x7f | 0x80)
-				j6++
-				x7 >>= 7
-			}
-			dAtA8[j6] = uint8(x7)
-			j6++
-		}
-		i -= j6
-		copy(dAtA[i:], dAtA8[:j6])
-		i = encodeVarintCortex(dAtA, i, uint64(j6))
+			x12 := (uint64(num) << 1) ^ uint64((num >> 63))
+			for x12 >= 1<<7 {
+				dAtA13[j11] = uint8(uint64(x12)&0x7f | 0x80)
+				j11++
+				x12 >>= 7
+			}
+			dAtA13[j11] = uint8(x12)
+			j11++
+		}
+		i -= j11
+		copy(dAtA[i:], dAtA13[:j11])
+		i = encodeVarintCortex(dAtA, i, uint64(j11))
 		i--
 		dAtA[i] = 0x4a
 	}
@@ -2277,6 +3095,115 @@ func (m *WriteResponse) Size() (n int) {
 	}
 	var l int
 	_ = l
+	if m.SamplesWritten != 0 {
+		n += 1 + sovCortex(uint64(m.SamplesWritten))
+	}
+	if m.HistogramsWritten != 0 {
+		n += 1 + sovCortex(uint64(m.HistogramsWritten))
+	}
+	if m.ExemplarsWritten != 0 {
+		n += 1 + sovCortex(uint64(m.ExemplarsWritten))
+	}
+	return n
+}
+
+func (m *WriteRequestV2) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Symbols) > 0 {
+		for _, s := range m.Symbols {
+			l = len(s)
+			n += 1 + l + sovCortex(uint64(l))
+		}
+	}
+	if len(m.Timeseries) > 0 {
+		for _, e := range m.Timeseries {
+			l = e.Size()
+			n += 1 + l + sovCortex(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TimeSeriesV2) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelsRefs) > 0 {
+		l = 0
+		for _, e := range m.LabelsRefs {
+			l += sovCortex(uint64(e))
+		}
+		n += 1 + sovCortex(uint64(l)) + l
+	}
+	if len(m.Samples) > 0 {
+		for _, e := range m.Samples {
+			l = e.Size()
+			n += 1 + l + sovCortex(uint64(l))
+		}
+	}
+	if len(m.Histograms) > 0 {
+		for _, e := range m.Histograms {
+			l = e.Size()
+			n += 1 + l + sovCortex(uint64(l))
+		}
+	}
+	if len(m.Exemplars) > 0 {
+		for _, e := range m.Exemplars {
+			l = e.Size()
+			n += 1 + l + sovCortex(uint64(l))
+		}
+	}
+	l = m.Metadata.Size()
+	n += 1 + l + sovCortex(uint64(l))
+	if m.CreatedTimestamp != 0 {
+		n += 1 + sovCortex(uint64(m.CreatedTimestamp))
+	}
+	return n
+}
+
+func (m *ExemplarV2) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.LabelsRefs) > 0 {
+		l = 0
+		for _, e := range m.LabelsRefs {
+			l += sovCortex(uint64(e))
+		}
+		n += 1 + sovCortex(uint64(l)) + l
+	}
+	if m.Value != 0 {
+		n += 9
+	}
+	if m.Timestamp != 0 {
+		n += 1 + sovCortex(uint64(m.Timestamp))
+	}
+	return n
+}
+
+func (m *MetadataV2) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Type != 0 {
+		n += 1 + sovCortex(uint64(m.Type))
+	}
+	if m.HelpRef != 0 {
+		n += 1 + sovCortex(uint64(m.HelpRef))
+	}
+	if m.UnitRef != 0 {
+		n += 1 + sovCortex(uint64(m.UnitRef))
+	}
 	return n
 }
 
@@ -2310,6 +3237,9 @@ func (m *TimeSeries) Size() (n int) {
 			n += 1 + l + sovCortex(uint64(l))
 		}
 	}
+	if m.CreatedTimestampMs != 0 {
+		n += 1 + sovCortex(uint64(m.CreatedTimestampMs))
+	}
 	return n
 }
 
@@ -2547,11 +3477,30 @@ func (this *WriteResponse) String() string {
 		return "nil"
 	}
 	s := strings.Join([]string{`&WriteResponse{`,
+		`SamplesWritten:` + fmt.Sprintf("%v", this.SamplesWritten) + `,`,
+		`HistogramsWritten:` + fmt.Sprintf("%v", this.HistogramsWritten) + `,`,
+		`ExemplarsWritten:` + fmt.Sprintf("%v", this.ExemplarsWritten) + `,`,
 		`}`,
 	}, "")
 	return s
 }
-func (this *TimeSeries) String() string {
+func (this *WriteRequestV2) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForTimeseries := "[]TimeSeriesV2{"
+	for _, f := range this.Timeseries {
+		repeatedStringForTimeseries += strings.Replace(strings.Replace(f.String(), "TimeSeriesV2", "TimeSeriesV2", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForTimeseries += "}"
+	s := strings.Join([]string{`&WriteRequestV2{`,
+		`Symbols:` + fmt.Sprintf("%v", this.Symbols) + `,`,
+		`Timeseries:` + repeatedStringForTimeseries + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TimeSeriesV2) String() string {
 	if this == nil {
 		return "nil"
 	}
@@ -2560,10 +3509,64 @@ func (this *TimeSeries) String() string {
 		repeatedStringForSamples += strings.Replace(strings.Replace(f.String(), "Sample", "Sample", 1), `&`, ``, 1) + ","
 	}
 	repeatedStringForSamples += "}"
-	repeatedStringForExemplars := "[]Exemplar{"
-	for _, f := range this.Exemplars {
-		repeatedStringForExemplars += strings.Replace(strings.Replace(f.String(), "Exemplar", "Exemplar", 1), `&`, ``, 1) + ","
-	}
+	repeatedStringForHistograms := "[]Histogram{"
+	for _, f := range this.Histograms {
+		repeatedStringForHistograms += strings.Replace(strings.Replace(f.String(), "Histogram", "Histogram", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForHistograms += "}"
+	repeatedStringForExemplars := "[]ExemplarV2{"
+	for _, f := range this.Exemplars {
+		repeatedStringForExemplars += strings.Replace(strings.Replace(f.String(), "ExemplarV2", "ExemplarV2", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForExemplars += "}"
+	s := strings.Join([]string{`&TimeSeriesV2{`,
+		`LabelsRefs:` + fmt.Sprintf("%v", this.LabelsRefs) + `,`,
+		`Samples:` + repeatedStringForSamples + `,`,
+		`Histograms:` + repeatedStringForHistograms + `,`,
+		`Exemplars:` + repeatedStringForExemplars + `,`,
+		`Metadata:` + strings.Replace(strings.Replace(this.Metadata.String(), "MetadataV2", "MetadataV2", 1), `&`, ``, 1) + `,`,
+		`CreatedTimestamp:` + fmt.Sprintf("%v", this.CreatedTimestamp) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ExemplarV2) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ExemplarV2{`,
+		`LabelsRefs:` + fmt.Sprintf("%v", this.LabelsRefs) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`Timestamp:` + fmt.Sprintf("%v", this.Timestamp) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *MetadataV2) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&MetadataV2{`,
+		`Type:` + fmt.Sprintf("%v", this.Type) + `,`,
+		`HelpRef:` + fmt.Sprintf("%v", this.HelpRef) + `,`,
+		`UnitRef:` + fmt.Sprintf("%v", this.UnitRef) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TimeSeries) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSamples := "[]Sample{"
+	for _, f := range this.Samples {
+		repeatedStringForSamples += strings.Replace(strings.Replace(f.String(), "Sample", "Sample", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSamples += "}"
+	repeatedStringForExemplars := "[]Exemplar{"
+	for _, f := range this.Exemplars {
+		repeatedStringForExemplars += strings.Replace(strings.Replace(f.String(), "Exemplar", "Exemplar", 1), `&`, ``, 1) + ","
+	}
 	repeatedStringForExemplars += "}"
 	repeatedStringForHistograms := "[]Histogram{"
 	for _, f := range this.Histograms {
@@ -2575,6 +3578,7 @@ func (this *TimeSeries) String() string {
 		`Samples:` + repeatedStringForSamples + `,`,
 		`Exemplars:` + repeatedStringForExemplars + `,`,
 		`Histograms:` + repeatedStringForHistograms + `,`,
+		`CreatedTimestampMs:` + fmt.Sprintf("%v", this.CreatedTimestampMs) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -2916,6 +3920,63 @@ func (m *WriteResponse) Unmarshal(dAtA []byte) error {
 			return fmt.Errorf("proto: WriteResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SamplesWritten", wireType)
+			}
+			m.SamplesWritten = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SamplesWritten |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HistogramsWritten", wireType)
+			}
+			m.HistogramsWritten = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HistogramsWritten |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExemplarsWritten", wireType)
+			}
+			m.ExemplarsWritten = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ExemplarsWritten |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCortex(dAtA[iNdEx:])
@@ -2940,7 +4001,7 @@ func (m *WriteResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *TimeSeries) Unmarshal(dAtA []byte) error {
+func (m *WriteRequestV2) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -2963,17 +4024,17 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: TimeSeries: wiretype end group for non-group")
+			return fmt.Errorf("proto: WriteRequestV2: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: TimeSeries: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: WriteRequestV2: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 1:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Symbols", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowCortex
@@ -2983,29 +4044,27 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthCortex
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthCortex
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Labels = append(m.Labels, LabelAdapter{})
-			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Symbols = append(m.Symbols, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
-		case 2:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Samples", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Timeseries", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3032,14 +4091,143 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Samples = append(m.Samples, Sample{})
-			if err := m.Samples[len(m.Samples)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Timeseries = append(m.Timeseries, TimeSeriesV2{})
+			if err := m.Timeseries[len(m.Timeseries)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 3:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCortex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TimeSeriesV2) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCortex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TimeSeriesV2: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TimeSeriesV2: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v uint32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowCortex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.LabelsRefs = append(m.LabelsRefs, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowCortex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthCortex
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthCortex
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.LabelsRefs) == 0 {
+					m.LabelsRefs = make([]uint32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowCortex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.LabelsRefs = append(m.LabelsRefs, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelsRefs", wireType)
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Exemplars", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Samples", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -3066,12 +4254,12 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Exemplars = append(m.Exemplars, Exemplar{})
-			if err := m.Exemplars[len(m.Exemplars)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.Samples = append(m.Samples, Sample{})
+			if err := m.Samples[len(m.Samples)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Histograms", wireType)
 			}
@@ -3105,6 +4293,569 @@ func (m *TimeSeries) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exemplars", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCortex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Exemplars = append(m.Exemplars, ExemplarV2{})
+			if err := m.Exemplars[len(m.Exemplars)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCortex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := m.Metadata.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedTimestamp", wireType)
+			}
+			m.CreatedTimestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedTimestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCortex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExemplarV2) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCortex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExemplarV2: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExemplarV2: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType == 0 {
+				var v uint32
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowCortex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					v |= uint32(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				m.LabelsRefs = append(m.LabelsRefs, v)
+			} else if wireType == 2 {
+				var packedLen int
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowCortex
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					packedLen |= int(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				if packedLen < 0 {
+					return ErrInvalidLengthCortex
+				}
+				postIndex := iNdEx + packedLen
+				if postIndex < 0 {
+					return ErrInvalidLengthCortex
+				}
+				if postIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				var elementCount int
+				var count int
+				for _, integer := range dAtA[iNdEx:postIndex] {
+					if integer < 128 {
+						count++
+					}
+				}
+				elementCount = count
+				if elementCount != 0 && len(m.LabelsRefs) == 0 {
+					m.LabelsRefs = make([]uint32, 0, elementCount)
+				}
+				for iNdEx < postIndex {
+					var v uint32
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowCortex
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						v |= uint32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					m.LabelsRefs = append(m.LabelsRefs, v)
+				}
+			} else {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelsRefs", wireType)
+			}
+		case 2:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Value = float64(math.Float64frombits(v))
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Timestamp", wireType)
+			}
+			m.Timestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Timestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCortex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetadataV2) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCortex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetadataV2: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetadataV2: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= MetricMetadata_MetricType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HelpRef", wireType)
+			}
+			m.HelpRef = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HelpRef |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UnitRef", wireType)
+			}
+			m.UnitRef = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.UnitRef |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipCortex(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TimeSeries) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowCortex
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TimeSeries: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TimeSeries: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCortex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Samples", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCortex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Samples = append(m.Samples, Sample{})
+			if err := m.Samples[len(m.Samples)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exemplars", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCortex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Exemplars = append(m.Exemplars, Exemplar{})
+			if err := m.Exemplars[len(m.Exemplars)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Histograms", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthCortex
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthCortex
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Histograms = append(m.Histograms, Histogram{})
+			if err := m.Histograms[len(m.Histograms)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedTimestampMs", wireType)
+			}
+			m.CreatedTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowCortex
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreatedTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipCortex(dAtA[iNdEx:])
@@ -0,0 +1,150 @@
+package cortexpb
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/model/labels"
+)
+
+// ToWriteRequest converts the remote write 2.0 request into a WriteRequest, resolving the references to
+// the symbols table. The metadata of the series are converted to the metadata of their metric name.
+// It gets timeseries from the pool, so ReuseSlice() should be called when done.
+func (m *WriteRequestV2) ToWriteRequest(source WriteRequest_SourceEnum) (*WriteRequest, error) {
+	if len(m.Symbols) > 0 && m.Symbols[0] != "" {
+		return nil, fmt.Errorf("the first symbol must be an empty string, got %q", m.Symbols[0])
+	}
+
+	req := &WriteRequest{
+		Timeseries: PreallocTimeseriesSliceFromPool(),
+		Source:     source,
+	}
+	seenMetadata := map[string]struct{}{}
+
+	for _, series := range m.Timeseries {
+		ts := TimeseriesFromPool()
+		req.Timeseries = append(req.Timeseries, PreallocTimeseries{TimeSeries: ts})
+
+		var err error
+		if ts.Labels, err = m.labelAdapters(ts.Labels, series.LabelsRefs); err != nil {
+			ReuseSlice(req.Timeseries)
+			return nil, err
+		}
+		ts.Samples = append(ts.Samples, series.Samples...)
+		ts.Histograms = append(ts.Histograms, series.Histograms...)
+		ts.CreatedTimestampMs = series.CreatedTimestamp
+
+		for _, e := range series.Exemplars {
+			lbls, err := m.labelAdapters(nil, e.LabelsRefs)
+			if err != nil {
+				ReuseSlice(req.Timeseries)
+				return nil, err
+			}
+			ts.Exemplars = append(ts.Exemplars, Exemplar{Labels: lbls, Value: e.Value, TimestampMs: e.Timestamp})
+		}
+
+		md := series.Metadata
+		if md.Type == UNKNOWN && md.HelpRef == 0 && md.UnitRef == 0 {
+			continue
+		}
+		help, err := m.symbol(md.HelpRef)
+		if err != nil {
+			ReuseSlice(req.Timeseries)
+			return nil, err
+		}
+		unit, err := m.symbol(md.UnitRef)
+		if err != nil {
+			ReuseSlice(req.Timeseries)
+			return nil, err
+		}
+		name := FromLabelAdaptersToLabels(ts.Labels).Get(labels.MetricName)
+		if _, ok := seenMetadata[name]; ok || name == "" {
+			continue
+		}
+		seenMetadata[name] = struct{}{}
+		req.Metadata = append(req.Metadata, &MetricMetadata{Type: md.Type, MetricFamilyName: name, Help: help, Unit: unit})
+	}
+
+	return req, nil
+}
+
+func (m *WriteRequestV2) labelAdapters(dst []LabelAdapter, refs []uint32) ([]LabelAdapter, error) {
+	if len(refs)%2 != 0 {
+		return nil, fmt.Errorf("odd number of label references: %d", len(refs))
+	}
+	for i := 0; i < len(refs); i += 2 {
+		name, err := m.symbol(refs[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := m.symbol(refs[i+1])
+		if err != nil {
+			return nil, err
+		}
+		dst = append(dst, LabelAdapter{Name: name, Value: value})
+	}
+	return dst, nil
+}
+
+func (m *WriteRequestV2) symbol(ref uint32) (string, error) {
+	if int(ref) >= len(m.Symbols) {
+		return "", fmt.Errorf("symbol reference %d out of range, the symbols table has %d symbols", ref, len(m.Symbols))
+	}
+	return m.Symbols[ref], nil
+}
+
+// FromWriteRequest converts the WriteRequest into a remote write 2.0 request, interning the strings
+// in the symbols table. The metadata are attached to the series with the same metric name.
+func FromWriteRequest(req *WriteRequest) *WriteRequestV2 {
+	st := newSymbolsTable()
+	metadata := make(map[string]*MetricMetadata, len(req.Metadata))
+	for _, md := range req.Metadata {
+		metadata[md.MetricFamilyName] = md
+	}
+
+	res := &WriteRequestV2{Timeseries: make([]TimeSeriesV2, 0, len(req.Timeseries))}
+	for _, ts := range req.Timeseries {
+		series := TimeSeriesV2{
+			LabelsRefs:       st.labelsRefs(ts.Labels),
+			Samples:          ts.Samples,
+			Histograms:       ts.Histograms,
+			CreatedTimestamp: ts.CreatedTimestampMs,
+		}
+		for _, e := range ts.Exemplars {
+			series.Exemplars = append(series.Exemplars, ExemplarV2{LabelsRefs: st.labelsRefs(e.Labels), Value: e.Value, Timestamp: e.TimestampMs})
+		}
+		if md, ok := metadata[FromLabelAdaptersToLabels(ts.Labels).Get(labels.MetricName)]; ok {
+			series.Metadata = MetadataV2{Type: md.Type, HelpRef: st.ref(md.Help), UnitRef: st.ref(md.Unit)}
+		}
+		res.Timeseries = append(res.Timeseries, series)
+	}
+	res.Symbols = st.symbols
+	return res
+}
+
+type symbolsTable struct {
+	symbols []string
+	refs    map[string]uint32
+}
+
+func newSymbolsTable() *symbolsTable {
+	// The first symbol is always the empty string.
+	return &symbolsTable{symbols: []string{""}, refs: map[string]uint32{"": 0}}
+}
+
+func (t *symbolsTable) ref(s string) uint32 {
+	if ref, ok := t.refs[s]; ok {
+		return ref
+	}
+	ref := uint32(len(t.symbols))
+	t.symbols = append(t.symbols, s)
+	t.refs[s] = ref
+	return ref
+}
+
+func (t *symbolsTable) labelsRefs(lbls []LabelAdapter) []uint32 {
+	refs := make([]uint32, 0, 2*len(lbls))
+	for _, l := range lbls {
+		refs = append(refs, t.ref(l.Name), t.ref(l.Value))
+	}
+	return refs
+}
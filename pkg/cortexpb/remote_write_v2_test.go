@@ -0,0 +1,75 @@
+package cortexpb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRequestV2_RoundTrip(t *testing.T) {
+	req := &WriteRequest{
+		Timeseries: []PreallocTimeseries{
+			{TimeSeries: &TimeSeries{
+				Labels:             []LabelAdapter{{Name: "__name__", Value: "requests_total"}, {Name: "job", Value: "api"}},
+				Samples:            []Sample{{Value: 1, TimestampMs: 20}},
+				Exemplars:          []Exemplar{{Labels: []LabelAdapter{{Name: "trace_id", Value: "abc"}}, Value: 1, TimestampMs: 20}},
+				CreatedTimestampMs: 10,
+			}},
+			{TimeSeries: &TimeSeries{
+				Labels:  []LabelAdapter{{Name: "__name__", Value: "requests_total"}, {Name: "job", Value: "db"}},
+				Samples: []Sample{{Value: 2, TimestampMs: 20}},
+			}},
+		},
+		Metadata: []*MetricMetadata{{Type: COUNTER, MetricFamilyName: "requests_total", Help: "Total requests.", Unit: "requests"}},
+		Source:   API,
+	}
+
+	v2 := FromWriteRequest(req)
+	// The symbols shared by the series are interned once.
+	assert.Equal(t, []string{"", "__name__", "requests_total", "job", "api", "trace_id", "abc", "Total requests.", "requests", "db"}, v2.Symbols)
+
+	data, err := v2.Marshal()
+	require.NoError(t, err)
+	decoded := &WriteRequestV2{}
+	require.NoError(t, decoded.Unmarshal(data))
+
+	actual, err := decoded.ToWriteRequest(API)
+	require.NoError(t, err)
+	assert.Equal(t, req.Metadata, actual.Metadata)
+	require.Len(t, actual.Timeseries, len(req.Timeseries))
+	for i := range req.Timeseries {
+		assert.Equal(t, req.Timeseries[i].Labels, actual.Timeseries[i].Labels)
+		assert.Equal(t, req.Timeseries[i].Samples, actual.Timeseries[i].Samples)
+		assert.Equal(t, req.Timeseries[i].CreatedTimestampMs, actual.Timeseries[i].CreatedTimestampMs)
+		assert.ElementsMatch(t, req.Timeseries[i].Exemplars, actual.Timeseries[i].Exemplars)
+	}
+}
+
+func TestWriteRequestV2_ToWriteRequest_InvalidReferences(t *testing.T) {
+	tests := map[string]*WriteRequestV2{
+		"first symbol not empty": {
+			Symbols:    []string{"__name__", "foo"},
+			Timeseries: []TimeSeriesV2{{LabelsRefs: []uint32{0, 1}}},
+		},
+		"odd number of label references": {
+			Symbols:    []string{"", "__name__", "foo"},
+			Timeseries: []TimeSeriesV2{{LabelsRefs: []uint32{1, 2, 1}}},
+		},
+		"label reference out of range": {
+			Symbols:    []string{"", "__name__", "foo"},
+			Timeseries: []TimeSeriesV2{{LabelsRefs: []uint32{1, 3}}},
+		},
+		"metadata reference out of range": {
+			Symbols:    []string{"", "__name__", "foo"},
+			Timeseries: []TimeSeriesV2{{LabelsRefs: []uint32{1, 2}, Metadata: MetadataV2{Type: GAUGE, HelpRef: 5}}},
+		},
+	}
+
+	for name, req := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := req.ToWriteRequest(API)
+			require.Error(t, err)
+		})
+	}
+}
@@ -349,5 +349,6 @@ func ReuseTimeseries(ts *TimeSeries) {
 
 	ts.Exemplars = ts.Exemplars[:0]
 	ts.Histograms = ts.Histograms[:0]
+	ts.CreatedTimestampMs = 0
 	timeSeriesPool.Put(ts)
 }
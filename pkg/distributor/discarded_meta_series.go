@@ -0,0 +1,97 @@
+package distributor
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+const (
+	// Name of the meta series tracking the samples discarded by a distributor, pushed into the
+	// tenant's own data.
+	discardedSamplesMetaSeriesName = "cortex_discarded_samples_total"
+)
+
+// pushDiscardedSamplesMetaSeries pushes, for each tenant enabling it, the number of samples discarded
+// by this distributor so far, per reason, into the tenant's own data. The meta series are counters
+// labelled with the distributor instance ID, so the tenant can query the rate of its discarded samples
+// summing them across the distributors.
+func (d *Distributor) pushDiscardedSamplesMetaSeries(ctx context.Context, now time.Time) {
+	for userID, reasons := range d.discardedSamplesPerUser() {
+		if !d.limits.DiscardedSamplesMetaSeriesEnabled(userID) {
+			continue
+		}
+
+		req := discardedSamplesMetaSeriesRequest(d.cfg.DistributorRing.InstanceID, reasons, now)
+		if _, err := d.Push(user.InjectOrgID(ctx, userID), req); err != nil {
+			level.Warn(d.log).Log("msg", "failed to push the discarded samples meta series", "user", userID, "err", err)
+		}
+	}
+}
+
+// discardedSamplesPerUser returns the number of samples discarded by this distributor, by user and reason.
+func (d *Distributor) discardedSamplesPerUser() map[string]map[string]float64 {
+	ch := make(chan prometheus.Metric, 16)
+	go func() {
+		defer close(ch)
+		d.validateMetrics.DiscardedSamples.Collect(ch)
+	}()
+
+	result := map[string]map[string]float64{}
+	for m := range ch {
+		metric := &dto.Metric{}
+		if err := m.Write(metric); err != nil {
+			// We cannot return here, to avoid blocking the goroutine calling Collect().
+			continue
+		}
+
+		var userID, reason string
+		for _, lp := range metric.GetLabel() {
+			switch lp.GetName() {
+			case "user":
+				userID = lp.GetValue()
+			case "reason":
+				reason = lp.GetValue()
+			}
+		}
+		if userID == "" || reason == "" {
+			continue
+		}
+
+		if result[userID] == nil {
+			result[userID] = map[string]float64{}
+		}
+		result[userID][reason] += metric.GetCounter().GetValue()
+	}
+
+	return result
+}
+
+func discardedSamplesMetaSeriesRequest(instanceID string, reasons map[string]float64, now time.Time) *cortexpb.WriteRequest {
+	names := make([]string, 0, len(reasons))
+	for reason := range reasons {
+		names = append(names, reason)
+	}
+	sort.Strings(names)
+
+	series := make([]labels.Labels, 0, len(names))
+	samples := make([]cortexpb.Sample, 0, len(names))
+	for _, reason := range names {
+		series = append(series, labels.FromStrings(
+			labels.MetricName, discardedSamplesMetaSeriesName,
+			"distributor", instanceID,
+			"reason", reason,
+		))
+		samples = append(samples, cortexpb.Sample{TimestampMs: now.UnixMilli(), Value: reasons[reason]})
+	}
+
+	return cortexpb.ToWriteRequest(series, samples, nil, nil, cortexpb.API)
+}
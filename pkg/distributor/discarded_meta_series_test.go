@@ -0,0 +1,79 @@
+package distributor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestDistributor_PushDiscardedSamplesMetaSeries(t *testing.T) {
+	t.Parallel()
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.DiscardedSamplesMetaSeriesEnabled = true
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{
+			labels.FromStrings(labels.MetricName, "invalid", "label-name", "value"),
+			labels.FromStrings(labels.MetricName, "invalid", "other-label-name", "value"),
+		},
+		[]cortexpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 1, Value: 1}}, nil, nil, cortexpb.API)
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+
+	now := time.Now()
+	ds[0].pushDiscardedSamplesMetaSeries(context.Background(), now)
+
+	expected := labels.FromStrings(
+		labels.MetricName, discardedSamplesMetaSeriesName,
+		"distributor", ds[0].cfg.DistributorRing.InstanceID,
+		"reason", "label_invalid",
+	)
+
+	// The meta series is replicated like any other series, and the push returns once
+	// a quorum of the ingesters has received it.
+	found := 0
+	for _, ing := range ingesters {
+		for _, ts := range ing.series() {
+			if !labels.Equal(expected, cortexpb.FromLabelAdaptersToLabels(ts.Labels)) {
+				continue
+			}
+			found++
+			require.Len(t, ts.Samples, 1)
+			assert.Equal(t, cortexpb.Sample{TimestampMs: now.UnixMilli(), Value: 2}, ts.Samples[0])
+		}
+	}
+	assert.GreaterOrEqual(t, found, 2)
+}
+
+func TestDiscardedSamplesMetaSeriesRequest(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	req := discardedSamplesMetaSeriesRequest("distributor-1", map[string]float64{"rate_limited": 10, "label_invalid": 2}, now)
+
+	require.Len(t, req.Timeseries, 2)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, discardedSamplesMetaSeriesName, "distributor", "distributor-1", "reason", "label_invalid"), cortexpb.FromLabelAdaptersToLabels(req.Timeseries[0].Labels))
+	assert.Equal(t, []cortexpb.Sample{{TimestampMs: now.UnixMilli(), Value: 2}}, req.Timeseries[0].Samples)
+	assert.Equal(t, labels.FromStrings(labels.MetricName, discardedSamplesMetaSeriesName, "distributor", "distributor-1", "reason", "rate_limited"), cortexpb.FromLabelAdaptersToLabels(req.Timeseries[1].Labels))
+	assert.Equal(t, []cortexpb.Sample{{TimestampMs: now.UnixMilli(), Value: 10}}, req.Timeseries[1].Samples)
+}
@@ -19,13 +19,16 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
-	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/scrape"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/instrument"
 	"github.com/weaveworks/common/user"
 	"go.uber.org/atomic"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ha"
 	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
@@ -34,6 +37,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/extract"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
@@ -50,6 +54,12 @@ var (
 	errInvalidShardingStrategy = errors.New("invalid sharding strategy")
 	errInvalidTenantShardSize  = errors.New("invalid tenant shard size. The value must be greater than or equal to 0")
 
+	errInvalidIngesterStateTransitionRetries = errors.New("invalid ingester state transition retries. The value must be greater than or equal to 0")
+	errInvalidShardByLabelsShardCount        = errors.New("invalid shard by labels shard count. The value must be greater than 0")
+
+	// errPushAttemptBudgetExceeded is returned when a push to an ingester exceeds its share of the deadline budget.
+	errPushAttemptBudgetExceeded = status.Error(codes.DeadlineExceeded, "the push to the ingester exceeded its share of the write deadline budget")
+
 	// Distributor instance limits errors.
 	errTooManyInflightPushRequests    = errors.New("too many inflight push requests in distributor")
 	errMaxSamplesPushRateLimitReached = errors.New("distributor's samples push rate limit reached")
@@ -93,8 +103,9 @@ type Distributor struct {
 	// For handling HA replicas.
 	HATracker *ha.HATracker
 
-	// Per-user rate limiter.
+	// Per-user rate limiters.
 	ingestionRateLimiter *limiter.RateLimiter
+	requestRateLimiter   *limiter.RateLimiter
 
 	// Manager for subservices (HA Tracker, distributor ring and client pool)
 	subservices        *services.Manager
@@ -105,6 +116,24 @@ type Distributor struct {
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
 
+	// The push path, made of a chain of PushMiddleware.
+	push PushFunc
+
+	// Keys of the push requests pushed successfully, nil if the deduplication is disabled.
+	idempotencyKeys cache.Cache
+
+	// The buffer of the pushes failed because a quorum of ingesters is unavailable, if enabled.
+	spillBuffer *spillBuffer
+
+	// Push tokens created through the API, nil if disabled.
+	pushTokenStore *pushTokenStore
+
+	// Samples of the series rejected by the validation, per tenant.
+	rejectedSeries *rejectedSeriesSampler
+
+	// The last sample timestamp of the series of the tenants with a min sample interval.
+	sampleIntervals *sampleIntervalTracker
+
 	// Metrics
 	queryDuration                    *instrument.HistogramCollector
 	receivedSamples                  *prometheus.CounterVec
@@ -115,11 +144,17 @@ type Distributor struct {
 	incomingMetadata                 *prometheus.CounterVec
 	nonHASamples                     *prometheus.CounterVec
 	dedupedSamples                   *prometheus.CounterVec
+	dedupedPushRequests              *prometheus.CounterVec
+	rateLimitedPushRequests          *prometheus.CounterVec
+	rejectedPushTokenRequests        *prometheus.CounterVec
+	droppedLabelNames                *prometheus.CounterVec
+	endOfSeriesEvents                *prometheus.CounterVec
 	labelsHistogram                  prometheus.Histogram
 	ingesterAppends                  *prometheus.CounterVec
 	ingesterAppendFailures           *prometheus.CounterVec
 	ingesterQueries                  *prometheus.CounterVec
 	ingesterQueryFailures            *prometheus.CounterVec
+	preferredZoneQueries             *prometheus.CounterVec
 	replicationFactor                prometheus.Gauge
 	latestSeenSampleTimestampPerUser *prometheus.GaugeVec
 
@@ -142,6 +177,17 @@ type Config struct {
 	ExtendWrites             bool   `yaml:"extend_writes"`
 	SignWriteRequestsEnabled bool   `yaml:"sign_write_requests"`
 
+	// Experimental. Shard the series by the values of a subset of labels.
+	ShardByLabels           flagext.StringSliceCSV `yaml:"shard_by_labels"`
+	ShardByLabelsShardCount int                    `yaml:"shard_by_labels_shard_count"`
+
+	// Experimental. Max number of times a push failed on an ingester transitioning state is retried
+	// on the ingesters extending the replica set.
+	IngesterStateTransitionRetries int `yaml:"ingester_state_transition_retries"`
+
+	// Experimental. Whether the deadline of a push is split across the attempts on the ingesters.
+	WriteDeadlineBudgetEnabled bool `yaml:"write_deadline_budget_enabled"`
+
 	// Distributors ring
 	DistributorRing RingConfig `yaml:"ring"`
 
@@ -160,8 +206,24 @@ type Config struct {
 	// from quorum number of zones will be included to reduce data merged and improve performance.
 	ZoneResultsQuorumMetadata bool `yaml:"zone_results_quorum_metadata" doc:"hidden"`
 
+	// Experimental. Zone whose ingesters are queried first, along with the other zones of the quorum,
+	// when zone-awareness is enabled.
+	PreferredQueryZone string `yaml:"preferred_query_zone"`
+
+	// Period at which the discarded samples meta series are pushed for the tenants enabling them.
+	DiscardedSamplesMetaSeriesInterval time.Duration `yaml:"discarded_samples_meta_series_interval"`
+
 	// Limits for distributor
 	InstanceLimits InstanceLimits `yaml:"instance_limits"`
+
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+
+	SpillBuffer SpillBufferConfig `yaml:"spill_buffer"`
+
+	PushTokens PushTokensConfig `yaml:"push_tokens"`
+
+	// Allow downstream projects to insert custom stages in the push path, see PushMiddleware.
+	PushMiddlewares []PushMiddleware `yaml:"-"`
 }
 
 type InstanceLimits struct {
@@ -174,15 +236,24 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.PoolConfig.RegisterFlags(f)
 	cfg.HATrackerConfig.RegisterFlags(f)
 	cfg.DistributorRing.RegisterFlags(f)
+	cfg.Idempotency.RegisterFlags(f)
+	cfg.SpillBuffer.RegisterFlags(f)
+	cfg.PushTokens.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxRecvMsgSize, "distributor.max-recv-msg-size", 100<<20, "remote_write API max receive message size (bytes).")
 	f.DurationVar(&cfg.RemoteTimeout, "distributor.remote-timeout", 2*time.Second, "Timeout for downstream ingesters.")
 	f.DurationVar(&cfg.ExtraQueryDelay, "distributor.extra-query-delay", 0, "Time to wait before sending more than the minimum successful query requests.")
 	f.BoolVar(&cfg.ShardByAllLabels, "distributor.shard-by-all-labels", false, "Distribute samples based on all labels, as opposed to solely by user and metric name.")
+	f.Var(&cfg.ShardByLabels, "distributor.shard-by-labels", "[Experimental] Comma separated list of label names whose values are used to shard the series across the ingesters, instead of the metric name or all the labels (-distributor.shard-by-all-labels). The series sharing the values of these labels are stored on the same ingesters, and the queries with an equality matcher for each of these labels only fetch the series from these ingesters, unless the shuffle-sharding is enabled. Must be set on distributors, ingesters and queriers. Changing it makes the queries miss the series already in the ingesters.")
+	f.IntVar(&cfg.ShardByLabelsShardCount, "distributor.shard-by-labels-shard-count", 1, "[Experimental] Number of replication sets of ingesters across which the series sharing the values of the -distributor.shard-by-labels labels are spread, to limit the hotspots. The queries matching these labels by equality fetch the series from all of them. The global series limits are divided by the shard count to compute the per-ingester limits. Must be set on distributors, ingesters and queriers. Increasing it only moves the series to the new shards, so the queries miss part of the series already in the ingesters.")
 	f.BoolVar(&cfg.SignWriteRequestsEnabled, "distributor.sign-write-requests", false, "EXPERIMENTAL: If enabled, sign the write request between distributors and ingesters.")
 	f.StringVar(&cfg.ShardingStrategy, "distributor.sharding-strategy", util.ShardingStrategyDefault, fmt.Sprintf("The sharding strategy to use. Supported values are: %s.", strings.Join(supportedShardingStrategies, ", ")))
 	f.BoolVar(&cfg.ExtendWrites, "distributor.extend-writes", true, "Try writing to an additional ingester in the presence of an ingester not in the ACTIVE state. It is useful to disable this along with -ingester.unregister-on-shutdown=false in order to not spread samples to extra ingesters during rolling restarts with consistent naming.")
+	f.BoolVar(&cfg.WriteDeadlineBudgetEnabled, "distributor.write-deadline-budget-enabled", false, "[Experimental] Split the deadline of a push, which is the earliest between the request deadline and -distributor.remote-timeout, across the attempts on an ingester, so that each attempt is given an even share of the time left among the attempts left. A push exceeding its share is retried like the pushes rejected by the ingesters transitioning state, so that a slow ingester doesn't consume the whole timeout of the request. Retries require -distributor.ingester-state-transition-retries.")
+	f.IntVar(&cfg.IngesterStateTransitionRetries, "distributor.ingester-state-transition-retries", 0, "[Experimental] Max number of times the series pushed to an ingester which rejected them because it's transitioning state (eg. shutting down during a rollout) are retried, within the same request, on the ingesters extending their replica set, instead of failing the push. Requires -distributor.extend-writes. 0 to disable.")
+	f.DurationVar(&cfg.DiscardedSamplesMetaSeriesInterval, "distributor.discarded-samples-meta-series-interval", time.Minute, "[Experimental] Period at which the number of discarded samples is pushed into the data of the tenants enabling -distributor.discarded-samples-meta-series-enabled.")
 	f.BoolVar(&cfg.ZoneResultsQuorumMetadata, "distributor.zone-results-quorum-metadata", false, "Experimental, this flag may change in the future. If zone awareness and this both enabled, when querying metadata APIs (labels names and values for now), only results from quorum number of zones will be included.")
+	f.StringVar(&cfg.PreferredQueryZone, "distributor.preferred-query-zone", "", "[Experimental] Zone whose ingesters are queried first for the series, samples and metadata when zone-awareness is enabled, typically the zone of the querier, to reduce the cross-zone data transfer. Only the ingesters of this zone and of the next zones needed to reach the quorum of zones are queried, instead of all the zones. All the zones are queried if an ingester of these zones fails, or if this zone has unhealthy ingesters. Empty to query all the zones.")
 
 	f.Float64Var(&cfg.InstanceLimits.MaxIngestionRate, "distributor.instance-limits.max-ingestion-rate", 0, "Max ingestion rate (samples/sec) that this distributor will accept. This limit is per-distributor, not per-tenant. Additional push requests will be rejected. Current ingestion rate is computed as exponentially weighted moving average, updated every second. 0 = unlimited.")
 	f.IntVar(&cfg.InstanceLimits.MaxInflightPushRequests, "distributor.instance-limits.max-inflight-push-requests", 0, "Max inflight push requests that this distributor can handle. This limit is per-distributor, not per-tenant. Additional requests will be rejected. 0 = unlimited.")
@@ -198,6 +269,26 @@ func (cfg *Config) Validate(limits validation.Limits) error {
 		return errInvalidTenantShardSize
 	}
 
+	if cfg.IngesterStateTransitionRetries < 0 {
+		return errInvalidIngesterStateTransitionRetries
+	}
+
+	if len(cfg.ShardByLabels) > 0 && cfg.ShardByLabelsShardCount <= 0 {
+		return errInvalidShardByLabelsShardCount
+	}
+
+	if err := cfg.Idempotency.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.SpillBuffer.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.PushTokens.Validate(); err != nil {
+		return err
+	}
+
 	haHATrackerConfig := cfg.HATrackerConfig.ToHATrackerConfig()
 
 	return haHATrackerConfig.Validate()
@@ -234,12 +325,13 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	// Create the configured ingestion rate limit strategy (local or global). In case
 	// it's an internal dependency and can't join the distributors ring, we skip rate
 	// limiting.
-	var ingestionRateStrategy limiter.RateLimiterStrategy
+	var ingestionRateStrategy, requestRateStrategy limiter.RateLimiterStrategy
 	var distributorsLifeCycler *ring.Lifecycler
 	var distributorsRing *ring.Ring
 
 	if !canJoinDistributorsRing {
 		ingestionRateStrategy = newInfiniteIngestionRateStrategy()
+		requestRateStrategy = newInfiniteIngestionRateStrategy()
 	} else if limits.IngestionRateStrategy() == validation.GlobalIngestionRateStrategy {
 		distributorsLifeCycler, err = ring.NewLifecycler(cfg.DistributorRing.ToLifecyclerConfig(), nil, "distributor", ringKey, true, true, log, prometheus.WrapRegistererWithPrefix("cortex_", reg))
 		if err != nil {
@@ -253,8 +345,10 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		subservices = append(subservices, distributorsLifeCycler, distributorsRing)
 
 		ingestionRateStrategy = newGlobalIngestionRateStrategy(limits, distributorsLifeCycler)
+		requestRateStrategy = newGlobalRequestRateStrategy(limits, distributorsLifeCycler)
 	} else {
 		ingestionRateStrategy = newLocalIngestionRateStrategy(limits)
+		requestRateStrategy = newLocalRequestRateStrategy(limits)
 	}
 
 	d := &Distributor{
@@ -262,10 +356,13 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 		log:                    log,
 		ingestersRing:          ingestersRing,
 		ingesterPool:           NewPool(cfg.PoolConfig, ingestersRing, cfg.IngesterClientFactory, log),
+		rejectedSeries:         newRejectedSeriesSampler(),
+		sampleIntervals:        newSampleIntervalTracker(),
 		distributorsLifeCycler: distributorsLifeCycler,
 		distributorsRing:       distributorsRing,
 		limits:                 limits,
 		ingestionRateLimiter:   limiter.NewRateLimiter(ingestionRateStrategy, 10*time.Second),
+		requestRateLimiter:     limiter.NewRateLimiter(requestRateStrategy, 10*time.Second),
 		HATracker:              haTracker,
 		ingestionRate:          util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
 
@@ -315,6 +412,31 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_deduped_samples_total",
 			Help:      "The total number of deduplicated samples.",
 		}, []string{"user", "cluster"}),
+		dedupedPushRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_deduped_push_requests_total",
+			Help:      "The total number of push requests acknowledged without being pushed, because a request with the same idempotency key had already been pushed successfully.",
+		}, []string{"user"}),
+		rateLimitedPushRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_rate_limited_push_requests_total",
+			Help:      "The total number of push requests rejected, before decoding their body, because the tenant exceeded its request rate limit.",
+		}, []string{"user"}),
+		rejectedPushTokenRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_push_token_rejected_requests_total",
+			Help:      "The total number of push requests rejected because of a missing or invalid push token, or a push token not allowing the content of the request.",
+		}, []string{"user"}),
+		droppedLabelNames: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_dropped_label_names_total",
+			Help:      "The total number of label names dropped from series exceeding the max label names per series limit. Label names not in the tenant priority list are tracked as other.",
+		}, []string{"user", "label_name"}),
+		endOfSeriesEvents: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_end_of_series_events_total",
+			Help:      "The total number of staleness markers received and converted to end-of-series events instead of being ingested.",
+		}, []string{"user"}),
 		labelsHistogram: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 			Namespace: "cortex",
 			Name:      "labels_per_sample",
@@ -341,6 +463,11 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 			Name:      "distributor_ingester_query_failures_total",
 			Help:      "The total number of failed queries sent to ingesters.",
 		}, []string{"ingester"}),
+		preferredZoneQueries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "distributor_preferred_zone_queries_total",
+			Help:      "The total number of queries sent to the ingesters of the preferred query zone, by result. A fallback is a query sent to the ingesters of all the zones because an ingester of the preferred zone or of the other zones of the quorum failed, and unavailable a query sent to all the zones because the preferred zone has unhealthy ingesters or the ingesters aren't zone-aware.",
+		}, []string{"result"}),
 		replicationFactor: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
 			Namespace: "cortex",
 			Name:      "distributor_replication_factor",
@@ -379,8 +506,30 @@ func New(cfg Config, clientConfig ingester_client.Config, limits *validation.Ove
 	})
 
 	d.replicationFactor.Set(float64(ingestersRing.ReplicationFactor()))
+
+	if cfg.Idempotency.Enabled {
+		if d.idempotencyKeys, err = cache.New(cfg.Idempotency.Cache, reg, log); err != nil {
+			return nil, errors.Wrap(err, "failed to create the idempotency keys cache")
+		}
+		if cache.IsEmptyTieredCache(d.idempotencyKeys) {
+			return nil, errIdempotencyKeysCacheMissing
+		}
+	}
+	d.push = d.newPushChain()
 	d.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(d.cleanupInactiveUser)
 
+	if cfg.SpillBuffer.Enabled {
+		d.spillBuffer = newSpillBuffer(cfg.SpillBuffer, limits, d.replaySpilledPush, log, reg)
+		subservices = append(subservices, d.spillBuffer)
+	}
+
+	if cfg.PushTokens.APIEnabled {
+		if d.pushTokenStore, err = newPushTokenStore(cfg.PushTokens, log, reg); err != nil {
+			return nil, errors.Wrap(err, "failed to create the push tokens store")
+		}
+		subservices = append(subservices, d.pushTokenStore)
+	}
+
 	subservices = append(subservices, d.ingesterPool, d.activeUsers)
 	d.subservices, err = services.NewManager(subservices...)
 	if err != nil {
@@ -409,6 +558,16 @@ func (d *Distributor) running(ctx context.Context) error {
 	staleIngesterMetricTicker := time.NewTicker(clearStaleIngesterMetricsInterval)
 	defer staleIngesterMetricTicker.Stop()
 
+	sampleIntervalsPurgeTicker := time.NewTicker(sampleIntervalsPurgeInterval)
+	defer sampleIntervalsPurgeTicker.Stop()
+
+	var discardedMetaSeriesTickerChan <-chan time.Time
+	if d.cfg.DiscardedSamplesMetaSeriesInterval > 0 {
+		discardedMetaSeriesTicker := time.NewTicker(d.cfg.DiscardedSamplesMetaSeriesInterval)
+		defer discardedMetaSeriesTicker.Stop()
+		discardedMetaSeriesTickerChan = discardedMetaSeriesTicker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -420,6 +579,12 @@ func (d *Distributor) running(ctx context.Context) error {
 		case <-staleIngesterMetricTicker.C:
 			d.cleanStaleIngesterMetrics()
 
+		case <-sampleIntervalsPurgeTicker.C:
+			d.sampleIntervals.purge(time.Now(), d.limits.MinSampleInterval)
+
+		case <-discardedMetaSeriesTickerChan:
+			d.pushDiscardedSamplesMetaSeries(ctx, time.Now())
+
 		case err := <-d.subservicesWatcher.Chan():
 			return errors.Wrap(err, "distributor subservice failed")
 		}
@@ -430,6 +595,8 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.ingestersRing.CleanupShuffleShardCache(userID)
 
 	d.HATracker.CleanupHATrackerMetricsForUser(userID)
+	d.rejectedSeries.deleteUser(userID)
+	d.sampleIntervals.deleteUser(userID)
 
 	d.receivedSamples.DeleteLabelValues(userID, sampleMetricTypeFloat)
 	d.receivedSamples.DeleteLabelValues(userID, sampleMetricTypeHistogram)
@@ -440,21 +607,45 @@ func (d *Distributor) cleanupInactiveUser(userID string) {
 	d.incomingExemplars.DeleteLabelValues(userID)
 	d.incomingMetadata.DeleteLabelValues(userID)
 	d.nonHASamples.DeleteLabelValues(userID)
+	d.dedupedPushRequests.DeleteLabelValues(userID)
+	d.rateLimitedPushRequests.DeleteLabelValues(userID)
+	d.rejectedPushTokenRequests.DeleteLabelValues(userID)
+	d.endOfSeriesEvents.DeleteLabelValues(userID)
 	d.latestSeenSampleTimestampPerUser.DeleteLabelValues(userID)
 
 	if err := util.DeleteMatchingLabels(d.dedupedSamples, map[string]string{"user": userID}); err != nil {
 		level.Warn(d.log).Log("msg", "failed to remove cortex_distributor_deduped_samples_total metric for user", "user", userID, "err", err)
 	}
 
+	if err := util.DeleteMatchingLabels(d.droppedLabelNames, map[string]string{"user": userID}); err != nil {
+		level.Warn(d.log).Log("msg", "failed to remove cortex_distributor_dropped_label_names_total metric for user", "user", userID, "err", err)
+	}
+
+	if d.spillBuffer != nil {
+		d.spillBuffer.deleteUserMetrics(userID)
+	}
+
 	validation.DeletePerUserValidationMetrics(d.validateMetrics, userID, d.log)
 }
 
 // Called after distributor is asked to stop via StopAsync.
 func (d *Distributor) stopping(_ error) error {
+	if d.idempotencyKeys != nil {
+		d.idempotencyKeys.Stop()
+	}
 	return services.StopManagerAndAwaitStopped(context.Background(), d.subservices)
 }
 
 func (d *Distributor) tokenForLabels(userID string, labels []cortexpb.LabelAdapter) (uint32, error) {
+	if len(d.cfg.ShardByLabels) > 0 {
+		// The series sharing the values of the labels are spread across the shards by all their labels.
+		shard := uint32(0)
+		if d.cfg.ShardByLabelsShardCount > 1 {
+			shard = shardByAllLabels(userID, labels) % uint32(d.cfg.ShardByLabelsShardCount)
+		}
+		return shardByLabels(userID, d.cfg.ShardByLabels, cortexpb.FromLabelAdaptersToLabels(labels).Get, shard), nil
+	}
+
 	if d.cfg.ShardByAllLabels {
 		return shardByAllLabels(userID, labels), nil
 	}
@@ -488,6 +679,24 @@ func shardByUser(userID string) uint32 {
 	return h
 }
 
+// shardByLabels returns the token for a series based on the values of the input label names and
+// the input shard only, so that the series sharing them are stored on the same ingesters. Missing
+// labels have an empty value. The shard 0 token doesn't depend on the shard, so that the series
+// keep their ingesters when the shard count is increased from 1.
+func shardByLabels(userID string, names []string, value func(name string) string, shard uint32) uint32 {
+	h := shardByUser(userID)
+	for _, name := range names {
+		h = ingester_client.HashAdd32(h, name)
+		h = ingester_client.HashAdd32(h, value(name))
+	}
+	if shard > 0 {
+		for i := 0; i < 4; i++ {
+			h = ingester_client.HashAddByte32(h, byte(shard>>(8*i)))
+		}
+	}
+	return h
+}
+
 // This function generates different values for different order of same labels.
 func shardByAllLabels(userID string, labels []cortexpb.LabelAdapter) uint32 {
 	h := shardByUser(userID)
@@ -511,6 +720,69 @@ func removeLabel(labelName string, labels *[]cortexpb.LabelAdapter) {
 	}
 }
 
+// reduceLabelNames drops labels from the sorted slice of LabelPairs until at most maxLabelNames
+// are left. The metric name is always kept, followed by the labels listed in priority (in order)
+// and then the remaining labels in lexicographic order. Returns the names of the dropped labels.
+// Labels are left untouched if maxLabelNames is not positive or if the series contains duplicate
+// label names, so that the series is rejected by the validation with the proper error.
+func reduceLabelNames(labels *[]cortexpb.LabelAdapter, maxLabelNames int, priority []string) []string {
+	if maxLabelNames <= 0 || len(*labels) <= maxLabelNames {
+		return nil
+	}
+
+	for i := 1; i < len(*labels); i++ {
+		if (*labels)[i].Name == (*labels)[i-1].Name {
+			return nil
+		}
+	}
+
+	keep := make(map[string]struct{}, maxLabelNames)
+	for _, name := range append([]string{model.MetricNameLabel}, priority...) {
+		if len(keep) >= maxLabelNames {
+			break
+		}
+		for _, pair := range *labels {
+			if pair.Name == name {
+				keep[name] = struct{}{}
+				break
+			}
+		}
+	}
+
+	for _, pair := range *labels {
+		if len(keep) >= maxLabelNames {
+			break
+		}
+		keep[pair.Name] = struct{}{}
+	}
+
+	var dropped []string
+	kept := (*labels)[:0]
+	for _, pair := range *labels {
+		if _, ok := keep[pair.Name]; ok {
+			kept = append(kept, pair)
+			continue
+		}
+		// Label names are unmarshalled into yoloString, so we clone them before retaining them.
+		dropped = append(dropped, util.StringsClone(pair.Name))
+	}
+	*labels = kept
+
+	return dropped
+}
+
+// droppedLabelNameMetricValue returns the label_name value used to track a dropped label name. Only
+// the label names configured in the tenant's priority list are tracked individually, to bound the
+// cardinality of the metric, while all the others are tracked as "other".
+func droppedLabelNameMetricValue(name string, priority []string) string {
+	for _, p := range priority {
+		if p == name {
+			return name
+		}
+	}
+	return "other"
+}
+
 // Returns a boolean that indicates whether or not we want to remove the replica label going forward,
 // and an error that indicates whether we want to accept samples based on the cluster/replica found in ts.
 // nil for the error means accept the sample.
@@ -547,6 +819,32 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		return emptyPreallocSeries, err
 	}
 
+	// Enforce the min interval between the samples of the series, if set for the tenant. The
+	// timestamp of the last sample accepted is only updated once the whole series is validated.
+	var (
+		intervals     *userSampleIntervals
+		seriesHash    uint64
+		lastTimestamp int64
+	)
+	minInterval := time.Duration(limits.MinSampleInterval).Milliseconds()
+	if minInterval > 0 {
+		intervals = d.sampleIntervals.user(userID)
+		seriesHash = cortexpb.FromLabelAdaptersToLabels(ts.Labels).Hash()
+		lastTimestamp = intervals.lastTimestamp(seriesHash)
+	}
+	// tooClose returns whether the sample is closer than the min interval to the last sample
+	// accepted for the series. Samples with the same or older timestamp are left to the ingesters.
+	tooClose := func(timestampMs int64) bool {
+		if minInterval <= 0 {
+			return false
+		}
+		if lastTimestamp > 0 && timestampMs > lastTimestamp && timestampMs-lastTimestamp < minInterval {
+			return true
+		}
+		lastTimestamp = max(lastTimestamp, timestampMs)
+		return false
+	}
+
 	var samples []cortexpb.Sample
 	if len(ts.Samples) > 0 {
 		// Only alloc when data present
@@ -555,8 +853,32 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 			if err := validation.ValidateSampleTimestamp(d.validateMetrics, limits, userID, ts.Labels, s.TimestampMs); err != nil {
 				return emptyPreallocSeries, err
 			}
+
+			if value.IsStaleNaN(s.Value) {
+				switch limits.StalenessMarkerPolicy {
+				case validation.StalenessMarkerPolicyDrop:
+					d.validateMetrics.DiscardedSamples.WithLabelValues(validation.StalenessMarkerDropped, userID).Inc()
+					continue
+				case validation.StalenessMarkerPolicyConvert:
+					d.endOfSeriesEvents.WithLabelValues(userID).Inc()
+					continue
+				}
+			} else if tooClose(s.TimestampMs) {
+				d.validateMetrics.DiscardedSamples.WithLabelValues(validation.SampleIntervalTooShort, userID).Inc()
+				if limits.MinSampleIntervalPolicy == validation.MinSampleIntervalPolicyCoalesce {
+					continue
+				}
+				unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ts.Labels)
+				return emptyPreallocSeries, validation.NewSampleIntervalTooShortError(unsafeMetricName, s.TimestampMs)
+			}
 			samples = append(samples, s)
 		}
+
+		// Skip the series if all its samples were staleness markers not to be ingested,
+		// or were coalesced.
+		if len(samples) == 0 && len(ts.Exemplars) == 0 && len(ts.Histograms) == 0 {
+			return emptyPreallocSeries, nil
+		}
 	}
 
 	var exemplars []cortexpb.Exemplar
@@ -580,21 +902,41 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 		// Only alloc when data present
 		histograms = make([]cortexpb.Histogram, 0, len(ts.Histograms))
 		for _, h := range ts.Histograms {
-			// TODO(yeya24): add other validations for native histogram.
-			// For example, Prometheus scrape has bucket limit and schema check.
 			if err := validation.ValidateSampleTimestamp(d.validateMetrics, limits, userID, ts.Labels, h.TimestampMs); err != nil {
 				return emptyPreallocSeries, err
 			}
+			if tooClose(h.TimestampMs) {
+				d.validateMetrics.DiscardedSamples.WithLabelValues(validation.SampleIntervalTooShort, userID).Inc()
+				if limits.MinSampleIntervalPolicy == validation.MinSampleIntervalPolicyCoalesce {
+					continue
+				}
+				unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ts.Labels)
+				return emptyPreallocSeries, validation.NewSampleIntervalTooShortError(unsafeMetricName, h.TimestampMs)
+			}
+			h, err := validation.ValidateNativeHistogram(d.validateMetrics, limits, userID, ts.Labels, h)
+			if err != nil {
+				return emptyPreallocSeries, err
+			}
+			histograms = append(histograms, h)
 		}
-		histograms = append(histograms, ts.Histograms...)
+
+		// Skip the series if all its samples were coalesced.
+		if len(samples) == 0 && len(histograms) == 0 && len(exemplars) == 0 {
+			return emptyPreallocSeries, nil
+		}
+	}
+
+	if intervals != nil {
+		intervals.update(seriesHash, lastTimestamp)
 	}
 
 	return cortexpb.PreallocTimeseries{
 			TimeSeries: &cortexpb.TimeSeries{
-				Labels:     ts.Labels,
-				Samples:    samples,
-				Exemplars:  exemplars,
-				Histograms: histograms,
+				Labels:             ts.Labels,
+				Samples:            samples,
+				Exemplars:          exemplars,
+				Histograms:         histograms,
+				CreatedTimestampMs: ts.CreatedTimestampMs,
 			},
 		},
 		nil
@@ -602,129 +944,7 @@ func (d *Distributor) validateSeries(ts cortexpb.PreallocTimeseries, userID stri
 
 // Push implements client.IngesterServer
 func (d *Distributor) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
-	userID, err := tenant.TenantID(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	span, ctx := opentracing.StartSpanFromContext(ctx, "Distributor.Push")
-	defer span.Finish()
-
-	// We will report *this* request in the error too.
-	inflight := d.inflightPushRequests.Inc()
-	defer d.inflightPushRequests.Dec()
-
-	now := time.Now()
-	d.activeUsers.UpdateUserTimestamp(userID, now)
-
-	numFloatSamples := 0
-	numHistogramSamples := 0
-	numExemplars := 0
-	for _, ts := range req.Timeseries {
-		numFloatSamples += len(ts.Samples)
-		numHistogramSamples += len(ts.Histograms)
-		numExemplars += len(ts.Exemplars)
-	}
-	// Count the total samples, exemplars in, prior to validation or deduplication, for comparison with other metrics.
-	d.incomingSamples.WithLabelValues(userID, sampleMetricTypeFloat).Add(float64(numFloatSamples))
-	d.incomingSamples.WithLabelValues(userID, sampleMetricTypeHistogram).Add(float64(numHistogramSamples))
-	d.incomingExemplars.WithLabelValues(userID).Add(float64(numExemplars))
-	// Count the total number of metadata in.
-	d.incomingMetadata.WithLabelValues(userID).Add(float64(len(req.Metadata)))
-
-	if d.cfg.InstanceLimits.MaxInflightPushRequests > 0 && inflight > int64(d.cfg.InstanceLimits.MaxInflightPushRequests) {
-		return nil, errTooManyInflightPushRequests
-	}
-
-	if d.cfg.InstanceLimits.MaxIngestionRate > 0 {
-		if rate := d.ingestionRate.Rate(); rate >= d.cfg.InstanceLimits.MaxIngestionRate {
-			return nil, errMaxSamplesPushRateLimitReached
-		}
-	}
-
-	removeReplica := false
-	// Cache user limit with overrides so we spend less CPU doing locking. See issue #4904
-	limits := d.limits.GetOverridesForUser(userID)
-
-	if limits.AcceptHASamples && len(req.Timeseries) > 0 {
-		cluster, replica := findHALabels(limits.HAReplicaLabel, limits.HAClusterLabel, req.Timeseries[0].Labels)
-		removeReplica, err = d.checkSample(ctx, userID, cluster, replica, limits)
-		if err != nil {
-			// Ensure the request slice is reused if the series get deduped.
-			cortexpb.ReuseSlice(req.Timeseries)
-
-			if errors.Is(err, ha.ReplicasNotMatchError{}) {
-				// These samples have been deduped.
-				d.dedupedSamples.WithLabelValues(userID, cluster).Add(float64(numFloatSamples + numHistogramSamples))
-				return nil, httpgrpc.Errorf(http.StatusAccepted, err.Error())
-			}
-
-			if errors.Is(err, ha.TooManyReplicaGroupsError{}) {
-				d.validateMetrics.DiscardedSamples.WithLabelValues(validation.TooManyHAClusters, userID).Add(float64(numFloatSamples + numHistogramSamples))
-				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
-			}
-
-			return nil, err
-		}
-		// If there wasn't an error but removeReplica is false that means we didn't find both HA labels.
-		if !removeReplica {
-			d.nonHASamples.WithLabelValues(userID).Add(float64(numFloatSamples + numHistogramSamples))
-		}
-	}
-
-	// A WriteRequest can only contain series or metadata but not both. This might change in the future.
-	seriesKeys, validatedTimeseries, validatedFloatSamples, validatedHistogramSamples, validatedExemplars, firstPartialErr, err := d.prepareSeriesKeys(ctx, req, userID, limits, removeReplica)
-	if err != nil {
-		return nil, err
-	}
-	metadataKeys, validatedMetadata, firstPartialErr := d.prepareMetadataKeys(req, limits, userID, firstPartialErr)
-
-	d.receivedSamples.WithLabelValues(userID, sampleMetricTypeFloat).Add(float64(validatedFloatSamples))
-	d.receivedSamples.WithLabelValues(userID, sampleMetricTypeHistogram).Add(float64(validatedHistogramSamples))
-	d.receivedExemplars.WithLabelValues(userID).Add(float64(validatedExemplars))
-	d.receivedMetadata.WithLabelValues(userID).Add(float64(len(validatedMetadata)))
-
-	if len(seriesKeys) == 0 && len(metadataKeys) == 0 {
-		// Ensure the request slice is reused if there's no series or metadata passing the validation.
-		cortexpb.ReuseSlice(req.Timeseries)
-
-		return &cortexpb.WriteResponse{}, firstPartialErr
-	}
-
-	totalSamples := validatedFloatSamples + validatedHistogramSamples
-	totalN := totalSamples + validatedExemplars + len(validatedMetadata)
-	if !d.ingestionRateLimiter.AllowN(now, userID, totalN) {
-		// Ensure the request slice is reused if the request is rate limited.
-		cortexpb.ReuseSlice(req.Timeseries)
-
-		d.validateMetrics.DiscardedSamples.WithLabelValues(validation.RateLimited, userID).Add(float64(totalSamples))
-		d.validateMetrics.DiscardedExemplars.WithLabelValues(validation.RateLimited, userID).Add(float64(validatedExemplars))
-		d.validateMetrics.DiscardedMetadata.WithLabelValues(validation.RateLimited, userID).Add(float64(len(validatedMetadata)))
-		// Return a 429 here to tell the client it is going too fast.
-		// Client may discard the data or slow down and re-send.
-		// Prometheus v2.26 added a remote-write option 'retry_on_http_429'.
-		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (%v) exceeded while adding %d samples and %d metadata", d.ingestionRateLimiter.Limit(now, userID), totalSamples, len(validatedMetadata))
-	}
-
-	// totalN included samples and metadata. Ingester follows this pattern when computing its ingestion rate.
-	d.ingestionRate.Add(int64(totalN))
-
-	subRing := d.ingestersRing
-
-	// Obtain a subring if required.
-	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
-		subRing = d.ingestersRing.ShuffleShard(userID, limits.IngestionTenantShardSize)
-	}
-
-	keys := append(seriesKeys, metadataKeys...)
-	initialMetadataIndex := len(seriesKeys)
-
-	err = d.doBatch(ctx, req, subRing, keys, initialMetadataIndex, validatedMetadata, validatedTimeseries, userID)
-	if err != nil {
-		return nil, err
-	}
-
-	return &cortexpb.WriteResponse{}, firstPartialErr
+	return d.push(ctx, req)
 }
 
 func (d *Distributor) cleanStaleIngesterMetrics() {
@@ -762,12 +982,18 @@ func (d *Distributor) cleanStaleIngesterMetrics() {
 	}
 }
 
-func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, subRing ring.ReadRing, keys []uint32, initialMetadataIndex int, validatedMetadata []*cortexpb.MetricMetadata, validatedTimeseries []cortexpb.PreallocTimeseries, userID string) error {
+// doBatch pushes the series and metadata to the ingesters. The series are released once all the
+// pushes are done and, if not nil, releaseAfter is closed.
+func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, subRing ring.ReadRing, keys []uint32, initialMetadataIndex int, validatedMetadata []*cortexpb.MetricMetadata, validatedTimeseries []cortexpb.PreallocTimeseries, userID string, tracker *pushTracker) error {
 	span, _ := opentracing.StartSpanFromContext(ctx, "doBatch")
 	defer span.Finish()
 
 	// Use a background context to make sure all ingesters get samples even if we return early
-	localCtx, cancel := context.WithTimeout(context.Background(), d.cfg.RemoteTimeout)
+	deadline := time.Now().Add(d.cfg.RemoteTimeout)
+	if reqDeadline, ok := ctx.Deadline(); ok && d.cfg.WriteDeadlineBudgetEnabled && reqDeadline.Before(deadline) {
+		deadline = reqDeadline
+	}
+	localCtx, cancel := context.WithDeadline(context.Background(), deadline)
 	localCtx = user.InjectOrgID(localCtx, userID)
 	if sp := opentracing.SpanFromContext(ctx); sp != nil {
 		localCtx = opentracing.ContextWithSpan(localCtx, sp)
@@ -781,11 +1007,37 @@ func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, s
 	localCtx = util.AddSourceIPsToOutgoingContext(localCtx, source)
 
 	op := ring.WriteNoExtend
+	opts := ring.DoBatchOptions{}
 	if d.cfg.ExtendWrites {
 		op = ring.Write
+
+		// Retry the pushes rejected by the ingesters transitioning state on the ingesters
+		// extending the replica set, like it's done for the ingesters not ACTIVE in the ring.
+		opts.IsRetriable = isIngesterTransitioningStateError
+		opts.MaxRetries = d.cfg.IngesterStateTransitionRetries
+	}
+	if d.cfg.WriteDeadlineBudgetEnabled && opts.IsRetriable != nil {
+		opts.IsRetriable = func(err error) bool {
+			return errors.Is(err, errPushAttemptBudgetExceeded) || isIngesterTransitioningStateError(err)
+		}
+	}
+	opts.Cleanup = func() {
+		if tracker == nil {
+			cortexpb.ReuseSlice(req.Timeseries)
+			cancel()
+			return
+		}
+
+		close(tracker.done)
+		// The cleanup can be called before doBatch returns, so it mustn't block.
+		go func() {
+			<-tracker.releaseAfter
+			cortexpb.ReuseSlice(req.Timeseries)
+			cancel()
+		}()
 	}
 
-	return ring.DoBatch(ctx, op, subRing, keys, func(ingester ring.InstanceDesc, indexes []int) error {
+	return ring.DoBatchWithOptions(ctx, op, subRing, keys, func(ingester ring.InstanceDesc, indexes []int, attempt int) error {
 		timeseries := make([]cortexpb.PreallocTimeseries, 0, len(indexes))
 		var metadata []*cortexpb.MetricMetadata
 
@@ -797,11 +1049,67 @@ func (d *Distributor) doBatch(ctx context.Context, req *cortexpb.WriteRequest, s
 			}
 		}
 
-		return d.send(localCtx, ingester, timeseries, metadata, req.Source)
-	}, func() {
-		cortexpb.ReuseSlice(req.Timeseries)
-		cancel()
-	})
+		if !d.cfg.WriteDeadlineBudgetEnabled {
+			err := d.send(localCtx, ingester, timeseries, metadata, req.Source)
+			tracker.track(err)
+			return err
+		}
+
+		attemptCtx, attemptCancel := attemptContext(localCtx, attempt, opts.MaxRetries+1)
+		defer attemptCancel()
+
+		err := d.send(attemptCtx, ingester, timeseries, metadata, req.Source)
+		tracker.track(err)
+		if err != nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && localCtx.Err() == nil {
+			return errPushAttemptBudgetExceeded
+		}
+		return err
+	}, opts)
+}
+
+// pushTracker tracks the sends of a push to the ingesters, so that the push can be buffered if it
+// failed on all of them.
+type pushTracker struct {
+	// releaseAfter must be closed once the series of the push aren't used anymore.
+	releaseAfter chan struct{}
+	// done is closed once all the sends to the ingesters have completed.
+	done chan struct{}
+	// succeeded is true if any send to the ingesters succeeded.
+	succeeded atomic.Bool
+}
+
+func newPushTracker() *pushTracker {
+	return &pushTracker{releaseAfter: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (t *pushTracker) track(err error) {
+	if t != nil && err == nil {
+		t.succeeded.Store(true)
+	}
+}
+
+// attemptContext returns the context of the input attempt of a push to an ingester. Its timeout is an
+// even share of the time left to the deadline of the push among the attempts left, so that a slow
+// ingester doesn't consume the time left for the retries. The last attempt is given all the time left.
+func attemptContext(ctx context.Context, attempt, maxAttempts int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	attemptsLeft := maxAttempts - attempt
+	if !ok || attemptsLeft <= 1 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, time.Until(deadline)/time.Duration(attemptsLeft))
+}
+
+// isIngesterTransitioningStateError returns whether the push error has been caused by the ingester
+// transitioning state (eg. starting or shutting down), so the push can be retried on another ingester.
+func isIngesterTransitioningStateError(err error) bool {
+	if status.Code(err) == codes.Unavailable {
+		return true
+	}
+
+	httpResp, ok := httpgrpc.HTTPResponseFromError(err)
+	return ok && httpResp.Code == http.StatusServiceUnavailable
 }
 
 func (d *Distributor) prepareMetadataKeys(req *cortexpb.WriteRequest, limits *validation.Limits, userID string, firstPartialErr error) ([]uint32, []*cortexpb.MetricMetadata, error) {
@@ -825,7 +1133,7 @@ func (d *Distributor) prepareMetadataKeys(req *cortexpb.WriteRequest, limits *va
 	return metadataKeys, validatedMetadata, firstPartialErr
 }
 
-func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.WriteRequest, userID string, limits *validation.Limits, removeReplica bool) ([]uint32, []cortexpb.PreallocTimeseries, int, int, int, error, error) {
+func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.WriteRequest, userID string, limits *validation.Limits) ([]uint32, []cortexpb.PreallocTimeseries, int, int, int, error, error) {
 	pSpan, _ := opentracing.StartSpanFromContext(ctx, "prepareSeriesKeys")
 	defer pSpan.Finish()
 
@@ -839,59 +1147,10 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 
 	var firstPartialErr error
 
-	latestSampleTimestampMs := int64(0)
-	defer func() {
-		// Update this metric even in case of errors.
-		if latestSampleTimestampMs > 0 {
-			d.latestSeenSampleTimestampPerUser.WithLabelValues(userID).Set(float64(latestSampleTimestampMs) / 1000)
-		}
-	}()
-
 	// For each timeseries, compute a hash to distribute across ingesters;
 	// check each sample and discard if outside limits.
 	skipLabelNameValidation := d.cfg.SkipLabelNameValidation || req.GetSkipLabelNameValidation()
 	for _, ts := range req.Timeseries {
-		// Use timestamp of latest sample in the series. If samples for series are not ordered, metric for user may be wrong.
-		if len(ts.Samples) > 0 {
-			latestSampleTimestampMs = max(latestSampleTimestampMs, ts.Samples[len(ts.Samples)-1].TimestampMs)
-		}
-		if len(ts.Histograms) > 0 {
-			latestSampleTimestampMs = max(latestSampleTimestampMs, ts.Histograms[len(ts.Histograms)-1].TimestampMs)
-		}
-
-		if mrc := limits.MetricRelabelConfigs; len(mrc) > 0 {
-			l, _ := relabel.Process(cortexpb.FromLabelAdaptersToLabels(ts.Labels), mrc...)
-			if len(l) == 0 {
-				// all labels are gone, samples will be discarded
-				d.validateMetrics.DiscardedSamples.WithLabelValues(
-					validation.DroppedByRelabelConfiguration,
-					userID,
-				).Add(float64(len(ts.Samples)))
-				continue
-			}
-			ts.Labels = cortexpb.FromLabelsToLabelAdapters(l)
-		}
-
-		// If we found both the cluster and replica labels, we only want to include the cluster label when
-		// storing series in Cortex. If we kept the replica label we would end up with another series for the same
-		// series we're trying to dedupe when HA tracking moves over to a different replica.
-		if removeReplica {
-			removeLabel(limits.HAReplicaLabel, &ts.Labels)
-		}
-
-		for _, labelName := range limits.DropLabels {
-			removeLabel(labelName, &ts.Labels)
-		}
-
-		if len(ts.Labels) == 0 {
-			d.validateMetrics.DiscardedExemplars.WithLabelValues(
-				validation.DroppedByUserConfigurationOverride,
-				userID,
-			).Add(float64(len(ts.Samples)))
-
-			continue
-		}
-
 		// We rely on sorted labels in different places:
 		// 1) When computing token for labels, and sharding by all labels. Here different order of labels returns
 		// different tokens, which is bad.
@@ -899,6 +1158,17 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 		// later in the validation phase, we ignore them here.
 		sortLabelsIfNeeded(ts.Labels)
 
+		// Drop the labels exceeding the limit instead of rejecting the series, if enabled
+		// for the tenant. This must happen before the sharding token is computed.
+		if limits.MaxLabelNamesPerSeriesReductionEnabled {
+			if dropped := reduceLabelNames(&ts.Labels, limits.MaxLabelNamesPerSeries, limits.MaxLabelNamesPerSeriesPriority); len(dropped) > 0 {
+				for _, name := range dropped {
+					d.droppedLabelNames.WithLabelValues(userID, droppedLabelNameMetricValue(name, limits.MaxLabelNamesPerSeriesPriority)).Inc()
+				}
+				level.Debug(d.log).Log("msg", "dropped labels from series exceeding the max label names per series limit", "user", userID, "dropped", strings.Join(dropped, ","))
+			}
+		}
+
 		// Generate the sharding token based on the series labels without the HA replica
 		// label and dropped labels (if any)
 		key, err := d.tokenForLabels(userID, ts.Labels)
@@ -909,6 +1179,9 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 
 		// Errors in validation are considered non-fatal, as one series in a request may contain
 		// invalid data but all the remaining series could be perfectly valid.
+		if validationErr != nil {
+			d.rejectedSeries.sample(userID, ts.Labels, validationErr, limits.RejectedSeriesSamplesPerReason, time.Now())
+		}
 		if validationErr != nil && firstPartialErr == nil {
 			// The series labels may be retained by validationErr but that's not a problem for this
 			// use case because we format it calling Error() and then we discard it.
@@ -922,9 +1195,9 @@ func (d *Distributor) prepareSeriesKeys(ctx context.Context, req *cortexpb.Write
 
 		seriesKeys = append(seriesKeys, key)
 		validatedTimeseries = append(validatedTimeseries, validatedSeries)
-		validatedFloatSamples += len(ts.Samples)
-		validatedHistogramSamples += len(ts.Histograms)
-		validatedExemplars += len(ts.Exemplars)
+		validatedFloatSamples += len(validatedSeries.Samples)
+		validatedHistogramSamples += len(validatedSeries.Histograms)
+		validatedExemplars += len(validatedSeries.Exemplars)
 	}
 	return seriesKeys, validatedTimeseries, validatedFloatSamples, validatedHistogramSamples, validatedExemplars, firstPartialErr, nil
 }
@@ -997,6 +1270,32 @@ func getErrorStatus(err error) string {
 	return status
 }
 
+// writeSubRing returns the ring of the ingesters the user's series are written to.
+func (d *Distributor) writeSubRing(userID string, limits *validation.Limits) ring.ReadRing {
+	subRing := d.ingestersRing
+
+	// Obtain a subring if required.
+	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
+		subRing = d.ingestersRing.ShuffleShard(userID, limits.IngestionTenantShardSize)
+	}
+
+	// Apply the tenant's replication factor, if overridden.
+	return ring.WithTenantReplicationFactor(subRing, limits.IngestionReplicationFactor)
+}
+
+// forQueryReplicationSet is like ForReplicationSet, querying the ingesters of the preferred query zone
+// first if any.
+func (d *Distributor) forQueryReplicationSet(ctx context.Context, replicationSet ring.ReplicationSet, zoneResultsQuorum bool, f func(context.Context, ingester_client.IngesterClient) (interface{}, error)) ([]interface{}, error) {
+	return d.doQueryPreferringZone(ctx, replicationSet, zoneResultsQuorum, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+		client, err := d.ingesterPool.GetClientFor(ing.Addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return f(ctx, client.(ingester_client.IngesterClient))
+	})
+}
+
 // ForReplicationSet runs f, in parallel, for all ingesters in the input replication set.
 func (d *Distributor) ForReplicationSet(ctx context.Context, replicationSet ring.ReplicationSet, zoneResultsQuorum bool, f func(context.Context, ingester_client.IngesterClient) (interface{}, error)) ([]interface{}, error) {
 	return replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, zoneResultsQuorum, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
@@ -1048,7 +1347,7 @@ func (d *Distributor) LabelValuesForLabelNameCommon(ctx context.Context, from, t
 // LabelValuesForLabelName returns all the label values that are associated with a given label name.
 func (d *Distributor) LabelValuesForLabelName(ctx context.Context, from, to model.Time, labelName model.LabelName, matchers ...*labels.Matcher) ([]string, error) {
 	return d.LabelValuesForLabelNameCommon(ctx, from, to, labelName, func(ctx context.Context, rs ring.ReplicationSet, req *ingester_client.LabelValuesRequest) ([]interface{}, error) {
-		return d.ForReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return d.forQueryReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 			resp, err := client.LabelValues(ctx, req)
 			if err != nil {
 				return nil, err
@@ -1061,7 +1360,7 @@ func (d *Distributor) LabelValuesForLabelName(ctx context.Context, from, to mode
 // LabelValuesForLabelNameStream returns all the label values that are associated with a given label name.
 func (d *Distributor) LabelValuesForLabelNameStream(ctx context.Context, from, to model.Time, labelName model.LabelName, matchers ...*labels.Matcher) ([]string, error) {
 	return d.LabelValuesForLabelNameCommon(ctx, from, to, labelName, func(ctx context.Context, rs ring.ReplicationSet, req *ingester_client.LabelValuesRequest) ([]interface{}, error) {
-		return d.ForReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return d.forQueryReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 			stream, err := client.LabelValuesStream(ctx, req)
 			if err != nil {
 				return nil, err
@@ -1121,7 +1420,7 @@ func (d *Distributor) LabelNamesCommon(ctx context.Context, from, to model.Time,
 
 func (d *Distributor) LabelNamesStream(ctx context.Context, from, to model.Time) ([]string, error) {
 	return d.LabelNamesCommon(ctx, from, to, func(ctx context.Context, rs ring.ReplicationSet, req *ingester_client.LabelNamesRequest) ([]interface{}, error) {
-		return d.ForReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return d.forQueryReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 			stream, err := client.LabelNamesStream(ctx, req)
 			if err != nil {
 				return nil, err
@@ -1147,7 +1446,7 @@ func (d *Distributor) LabelNamesStream(ctx context.Context, from, to model.Time)
 // LabelNames returns all the label names.
 func (d *Distributor) LabelNames(ctx context.Context, from, to model.Time) ([]string, error) {
 	return d.LabelNamesCommon(ctx, from, to, func(ctx context.Context, rs ring.ReplicationSet, req *ingester_client.LabelNamesRequest) ([]interface{}, error) {
-		return d.ForReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return d.forQueryReplicationSet(ctx, rs, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 			resp, err := client.LabelNames(ctx, req)
 			if err != nil {
 				return nil, err
@@ -1160,7 +1459,7 @@ func (d *Distributor) LabelNames(ctx context.Context, from, to model.Time) ([]st
 // MetricsForLabelMatchers gets the metrics that match said matchers
 func (d *Distributor) MetricsForLabelMatchers(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) ([]model.Metric, error) {
 	return d.metricsForLabelMatchersCommon(ctx, from, through, func(ctx context.Context, rs ring.ReplicationSet, req *ingester_client.MetricsForLabelMatchersRequest, metrics *map[model.Fingerprint]model.Metric, mutex *sync.Mutex, queryLimiter *limiter.QueryLimiter) error {
-		_, err := d.ForReplicationSet(ctx, rs, false, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		_, err := d.forQueryReplicationSet(ctx, rs, false, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 			resp, err := client.MetricsForLabelMatchers(ctx, req)
 			if err != nil {
 				return nil, err
@@ -1189,7 +1488,7 @@ func (d *Distributor) MetricsForLabelMatchers(ctx context.Context, from, through
 
 func (d *Distributor) MetricsForLabelMatchersStream(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) ([]model.Metric, error) {
 	return d.metricsForLabelMatchersCommon(ctx, from, through, func(ctx context.Context, rs ring.ReplicationSet, req *ingester_client.MetricsForLabelMatchersRequest, metrics *map[model.Fingerprint]model.Metric, mutex *sync.Mutex, queryLimiter *limiter.QueryLimiter) error {
-		_, err := d.ForReplicationSet(ctx, rs, false, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		_, err := d.forQueryReplicationSet(ctx, rs, false, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 			stream, err := client.MetricsForLabelMatchersStream(ctx, req)
 			if err != nil {
 				return nil, err
@@ -1265,7 +1564,7 @@ func (d *Distributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetad
 
 	req := &ingester_client.MetricsMetadataRequest{}
 	// TODO(gotjosh): We only need to look in all the ingesters if shardByAllLabels is enabled.
-	resps, err := d.ForReplicationSet(ctx, replicationSet, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+	resps, err := d.forQueryReplicationSet(ctx, replicationSet, d.cfg.ZoneResultsQuorumMetadata, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
 		return client.MetricsMetadata(ctx, req)
 	})
 	if err != nil {
@@ -1298,6 +1597,11 @@ func (d *Distributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetad
 
 // UserStats returns statistics about the current user.
 func (d *Distributor) UserStats(ctx context.Context) (*UserStats, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	replicationSet, err := d.GetIngestersForMetadata(ctx)
 	if err != nil {
 		return nil, err
@@ -1322,12 +1626,15 @@ func (d *Distributor) UserStats(ctx context.Context) (*UserStats, error) {
 		totalStats.RuleIngestionRate += r.RuleIngestionRate
 		totalStats.NumSeries += r.NumSeries
 		totalStats.ActiveSeries += r.ActiveSeries
+		totalStats.addNativeHistogramStats(r)
 	}
 
-	factor := d.ingestersRing.ReplicationFactor()
+	factor := ring.WithTenantReplicationFactor(d.ingestersRing, d.limits.IngestionReplicationFactor(userID)).ReplicationFactor()
 	totalStats.IngestionRate /= float64(factor)
 	totalStats.NumSeries /= uint64(factor)
 	totalStats.ActiveSeries /= uint64(factor)
+	totalStats.ActiveNativeHistogramSeries /= uint64(factor)
+	totalStats.ActiveNativeHistogramBuckets /= uint64(factor)
 
 	return totalStats, nil
 }
@@ -1367,6 +1674,7 @@ func (d *Distributor) AllUserStats(ctx context.Context) ([]UserIDStats, error) {
 			s.RuleIngestionRate += u.Data.RuleIngestionRate
 			s.NumSeries += u.Data.NumSeries
 			s.ActiveSeries += u.Data.ActiveSeries
+			s.addNativeHistogramStats(u.Data)
 			perUserTotals[u.UserId] = s
 		}
 	}
@@ -1377,11 +1685,14 @@ func (d *Distributor) AllUserStats(ctx context.Context) ([]UserIDStats, error) {
 		response = append(response, UserIDStats{
 			UserID: id,
 			UserStats: UserStats{
-				IngestionRate:     stats.IngestionRate,
-				APIIngestionRate:  stats.APIIngestionRate,
-				RuleIngestionRate: stats.RuleIngestionRate,
-				NumSeries:         stats.NumSeries,
-				ActiveSeries:      stats.ActiveSeries,
+				IngestionRate:                      stats.IngestionRate,
+				APIIngestionRate:                   stats.APIIngestionRate,
+				RuleIngestionRate:                  stats.RuleIngestionRate,
+				NumSeries:                          stats.NumSeries,
+				ActiveSeries:                       stats.ActiveSeries,
+				ActiveNativeHistogramSeries:        stats.ActiveNativeHistogramSeries,
+				ActiveNativeHistogramBuckets:       stats.ActiveNativeHistogramBuckets,
+				ActiveNativeHistogramAverageSchema: stats.ActiveNativeHistogramAverageSchema,
 			},
 		})
 	}
@@ -1409,6 +1720,22 @@ func (d *Distributor) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// findHALabelPair returns the cluster and replica of the first HA label pair whose labels are
+// both found in the input labels, along with the replica label name. If none is found, it returns
+// the labels found for the first pair.
+func findHALabelPair(pairs validation.HALabelPairs, labels []cortexpb.LabelAdapter) (cluster, replica, replicaLabel string) {
+	for i, p := range pairs {
+		c, r := findHALabels(p.Replica, p.Cluster, labels)
+		if c != "" && r != "" {
+			return c, r, p.Replica
+		}
+		if i == 0 {
+			cluster, replica, replicaLabel = c, r, p.Replica
+		}
+	}
+	return cluster, replica, replicaLabel
+}
+
 func findHALabels(replicaLabel, clusterLabel string, labels []cortexpb.LabelAdapter) (string, string) {
 	var cluster, replica string
 	var pair cortexpb.LabelAdapter
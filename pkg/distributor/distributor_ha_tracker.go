@@ -21,8 +21,9 @@ type HATrackerConfig struct {
 	// between the stored timestamp and the time we received a sample is
 	// more than this duration
 	FailoverTimeout time.Duration `yaml:"ha_tracker_failover_timeout"`
+	ElectionMode    string        `yaml:"ha_tracker_election_mode"`
 
-	KVStore kv.Config `yaml:"kvstore" doc:"description=Backend storage to use for the ring. Please be aware that memberlist is not supported by the HA tracker since gossip propagation is too slow for HA purposes."`
+	KVStore kv.Config `yaml:"kvstore" doc:"description=Backend storage to use for the ring. Please be aware that memberlist is not supported by the HA tracker since its CAS isn't linearizable, so the distributors could elect different replicas. Use the crdt election mode to refresh the elected replicas through memberlist gossip."`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -31,6 +32,7 @@ func (cfg *HATrackerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.UpdateTimeout, "distributor.ha-tracker.update-timeout", 15*time.Second, "Update the timestamp in the KV store for a given cluster/replica only after this amount of time has passed since the current stored timestamp.")
 	f.DurationVar(&cfg.UpdateTimeoutJitterMax, "distributor.ha-tracker.update-timeout-jitter-max", 5*time.Second, "Maximum jitter applied to the update timeout, in order to spread the HA heartbeats over time.")
 	f.DurationVar(&cfg.FailoverTimeout, "distributor.ha-tracker.failover-timeout", 30*time.Second, "If we don't receive any samples from the accepted replica for a cluster in this amount of time we will failover to the next replica we receive a sample from. This value must be greater than the update timeout")
+	f.StringVar(&cfg.ElectionMode, "distributor.ha-tracker.election-mode", ha.ElectionModeKV, "The algorithm used to elect the replica to accept samples from. Supported values are: kv, crdt. The crdt mode refreshes the elected replicas through memberlist gossip, which must be configured, and only uses the KV store to arbitrate the failovers.")
 
 	// We want the ability to use different Consul instances for the ring and
 	// for HA cluster tracking. We also customize the default keys prefix, in
@@ -47,6 +49,7 @@ func (cfg *HATrackerConfig) ToHATrackerConfig() ha.HATrackerConfig {
 	haCfg.UpdateTimeout = cfg.UpdateTimeout
 	haCfg.UpdateTimeoutJitterMax = cfg.UpdateTimeoutJitterMax
 	haCfg.FailoverTimeout = cfg.FailoverTimeout
+	haCfg.ElectionMode = cfg.ElectionMode
 	haCfg.KVStore = cfg.KVStore
 
 	return haCfg
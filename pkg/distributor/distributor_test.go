@@ -2,11 +2,16 @@ package distributor
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"math/rand"
 	"net/http"
+	"net/http/httptest"
+	"path"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,11 +22,13 @@ import (
 	"google.golang.org/grpc/codes"
 
 	"github.com/go-kit/log"
+	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/prometheus/prometheus/model/value"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -32,6 +39,7 @@ import (
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	promchunk "github.com/cortexproject/cortex/pkg/chunk/encoding"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/ha"
@@ -40,6 +48,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ring"
 	ring_client "github.com/cortexproject/cortex/pkg/ring/client"
 	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
 	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
@@ -47,6 +56,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 	histogram_util "github.com/cortexproject/cortex/pkg/util/histogram"
 	"github.com/cortexproject/cortex/pkg/util/limiter"
+	"github.com/cortexproject/cortex/pkg/util/push"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/test"
 	"github.com/cortexproject/cortex/pkg/util/validation"
@@ -77,6 +87,22 @@ func TestConfig_Validate(t *testing.T) {
 			initLimits: func(_ *validation.Limits) {},
 			expected:   nil,
 		},
+		"should fail if the push tokens API is enabled with memberlist": {
+			initConfig: func(cfg *Config) {
+				cfg.PushTokens.APIEnabled = true
+				cfg.PushTokens.KVStore.Store = "memberlist"
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errInvalidPushTokensKVStore,
+		},
+		"should pass if the push tokens API is enabled with consul": {
+			initConfig: func(cfg *Config) {
+				cfg.PushTokens.APIEnabled = true
+				cfg.PushTokens.KVStore.Store = "consul"
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   nil,
+		},
 		"should fail on invalid sharding strategy": {
 			initConfig: func(cfg *Config) {
 				cfg.ShardingStrategy = "xxx"
@@ -111,6 +137,23 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expected: errInvalidTenantShardSize,
 		},
+		"should fail if the push requests deduplication is enabled without any cache backend": {
+			initConfig: func(cfg *Config) {
+				cfg.Idempotency.Enabled = true
+				cfg.Idempotency.Cache.DefaultValidity = time.Hour
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   errIdempotencyKeysCacheMissing,
+		},
+		"should pass if the push requests deduplication is enabled with a cache backend": {
+			initConfig: func(cfg *Config) {
+				cfg.Idempotency.Enabled = true
+				cfg.Idempotency.Cache.DefaultValidity = time.Hour
+				cfg.Idempotency.Cache.Redis.Endpoint = "localhost:6379"
+			},
+			initLimits: func(_ *validation.Limits) {},
+			expected:   nil,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -164,7 +207,7 @@ func TestDistributor_Push(t *testing.T) {
 			happyIngesters:   3,
 			samples:          samplesIn{num: 5, startTimestampMs: 123456789000},
 			metadata:         5,
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{SamplesWritten: 5},
 			metricNames:      []string{lastSeenTimestamp},
 			expectedMetrics: `
 				# HELP cortex_distributor_latest_seen_sample_timestamp_seconds Unix timestamp of latest received sample per user.
@@ -177,7 +220,7 @@ func TestDistributor_Push(t *testing.T) {
 			happyIngesters:   2,
 			samples:          samplesIn{num: 5, startTimestampMs: 123456789000},
 			metadata:         5,
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{SamplesWritten: 5},
 			metricNames:      []string{lastSeenTimestamp},
 			expectedMetrics: `
 				# HELP cortex_distributor_latest_seen_sample_timestamp_seconds Unix timestamp of latest received sample per user.
@@ -228,7 +271,7 @@ func TestDistributor_Push(t *testing.T) {
 			samples:          samplesIn{num: 1, startTimestampMs: 123456789000},
 			metadata:         0,
 			metricNames:      []string{distributorAppend, distributorAppendFailure},
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{SamplesWritten: 1},
 			expectedMetrics: `
 				# HELP cortex_distributor_ingester_append_failures_total The total number of failed batch appends sent to ingesters.
 				# TYPE cortex_distributor_ingester_append_failures_total counter
@@ -284,7 +327,7 @@ func TestDistributor_Push(t *testing.T) {
 			samples:          samplesIn{num: 5, startTimestampMs: 123456789000},
 			histogramSamples: true,
 			metadata:         5,
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{HistogramsWritten: 5},
 			metricNames:      []string{lastSeenTimestamp, distributorReceivedSamples},
 			expectedMetrics: `
 				# HELP cortex_distributor_latest_seen_sample_timestamp_seconds Unix timestamp of latest received sample per user.
@@ -302,7 +345,7 @@ func TestDistributor_Push(t *testing.T) {
 			samples:          samplesIn{num: 5, startTimestampMs: 123456789000},
 			histogramSamples: true,
 			metadata:         5,
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{HistogramsWritten: 5},
 			metricNames:      []string{lastSeenTimestamp, distributorReceivedSamples},
 			expectedMetrics: `
 				# HELP cortex_distributor_latest_seen_sample_timestamp_seconds Unix timestamp of latest received sample per user.
@@ -394,6 +437,210 @@ func TestDistributor_Push(t *testing.T) {
 	}
 }
 
+func TestDistributor_ShardByLabels(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	for _, shardCount := range []int{1, 2} {
+		shardCount := shardCount
+		t.Run(fmt.Sprintf("shard count %d", shardCount), func(t *testing.T) {
+			t.Parallel()
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:            12,
+				happyIngesters:          12,
+				numDistributors:         1,
+				shardByAllLabels:        true,
+				shardByLabels:           []string{"cluster", "namespace"},
+				shardByLabelsShardCount: shardCount,
+			})
+
+			// Push series with different metric names and labels, but the same cluster and namespace.
+			req := &cortexpb.WriteRequest{}
+			for i := 0; i < 20; i++ {
+				req.Timeseries = append(req.Timeseries, makeWriteRequestTimeseries([]cortexpb.LabelAdapter{
+					{Name: model.MetricNameLabel, Value: fmt.Sprintf("metric_%d", i)},
+					{Name: "cluster", Value: "cluster-1"},
+					{Name: "namespace", Value: "namespace-1"},
+					{Name: "pod", Value: fmt.Sprintf("pod-%d", i)},
+				}, 1000, i, false))
+			}
+			_, err := ds[0].Push(ctx, req)
+			require.NoError(t, err)
+
+			// The queries matching all the shard labels by equality should only fetch from the
+			// ingesters of the shards.
+			replicationSet, err := ds[0].GetIngestersForQuery(ctx,
+				labels.MustNewMatcher(labels.MatchEqual, "cluster", "cluster-1"),
+				labels.MustNewMatcher(labels.MatchEqual, "namespace", "namespace-1"),
+				labels.MustNewMatcher(labels.MatchRegexp, "pod", "pod-.*"),
+			)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(replicationSet.Instances), 3*shardCount)
+			if shardCount == 1 {
+				assert.Len(t, replicationSet.Instances, 3)
+			}
+
+			shardIngesters := map[string]struct{}{}
+			for _, instance := range replicationSet.Instances {
+				shardIngesters[instance.Addr] = struct{}{}
+			}
+
+			// All the series should have been stored on the ingesters of the shards. The push returns
+			// once the quorum is reached, so wait for the last replica too.
+			test.Poll(t, time.Second, 60, func() interface{} {
+				total := 0
+				for _, ing := range ingesters {
+					ing.Lock()
+					total += len(ing.timeseries)
+					ing.Unlock()
+				}
+				return total
+			})
+			for i, ing := range ingesters {
+				if _, ok := shardIngesters[strconv.Itoa(i)]; !ok {
+					ing.Lock()
+					assert.Empty(t, ing.timeseries)
+					ing.Unlock()
+				}
+			}
+
+			// The queries not matching all the shard labels by equality should fetch from all the ingesters.
+			for _, matchers := range [][]*labels.Matcher{
+				{labels.MustNewMatcher(labels.MatchEqual, "cluster", "cluster-1")},
+				{labels.MustNewMatcher(labels.MatchEqual, model.MetricNameLabel, "metric_1")},
+				{
+					labels.MustNewMatcher(labels.MatchEqual, "cluster", "cluster-1"),
+					labels.MustNewMatcher(labels.MatchRegexp, "namespace", "namespace-1"),
+				},
+			} {
+				replicationSet, err := ds[0].GetIngestersForQuery(ctx, matchers...)
+				require.NoError(t, err)
+				assert.Len(t, replicationSet.Instances, 12)
+			}
+		})
+	}
+}
+
+func TestDistributor_Push_ShouldRetryOnIngestersTransitioningState(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+	errIngesterStopping := status.Error(codes.Unavailable, "ingester stopping")
+
+	for name, tc := range map[string]struct {
+		retries       int
+		ingesterError error
+		expectedError bool
+	}{
+		"should fail if retries are disabled": {
+			retries:       0,
+			ingesterError: errIngesterStopping,
+			expectedError: true,
+		},
+		"should fail if the ingesters error is not retriable": {
+			retries:       1,
+			ingesterError: errFail,
+			expectedError: true,
+		},
+		"should succeed retrying on the ingesters extending the replica set": {
+			retries:       1,
+			ingesterError: errIngesterStopping,
+			expectedError: false,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// 2 out of 5 ingesters are failing, so the series whose replica set includes
+			// both of them can't reach the quorum unless retried.
+			ds, _, _, _ := prepare(t, prepConfig{
+				numIngesters:                   5,
+				happyIngesters:                 3,
+				numDistributors:                1,
+				shardByAllLabels:               true,
+				errFail:                        tc.ingesterError,
+				ingesterStateTransitionRetries: tc.retries,
+			})
+
+			_, err := ds[0].Push(ctx, makeWriteRequest(0, 50, 0, 0))
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDistributor_Push_ShouldSplitTheDeadlineBudgetAcrossTheAttempts(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	for name, tc := range map[string]struct {
+		budgetEnabled bool
+		expectedError bool
+	}{
+		"should fail if the slow ingesters consume the whole timeout": {
+			budgetEnabled: false,
+			expectedError: true,
+		},
+		"should succeed retrying on the ingesters extending the replica set once the slow ingesters exceed their budget": {
+			budgetEnabled: true,
+			expectedError: false,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// 2 out of 5 ingesters are slow, so the series whose replica set includes
+			// both of them can't reach the quorum unless retried.
+			ds, _, _, _ := prepare(t, prepConfig{
+				numIngesters:                   5,
+				happyIngesters:                 3,
+				numDistributors:                1,
+				shardByAllLabels:               true,
+				pushDelay:                      time.Minute,
+				ingesterStateTransitionRetries: 1,
+				writeDeadlineBudgetEnabled:     tc.budgetEnabled,
+			})
+
+			_, err := ds[0].Push(ctx, makeWriteRequest(0, 50, 0, 0))
+			if tc.expectedError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAttemptContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// The first of 3 attempts should be given a third of the time left.
+	attemptCtx, attemptCancel := attemptContext(ctx, 0, 3)
+	defer attemptCancel()
+	deadline, ok := attemptCtx.Deadline()
+	require.True(t, ok)
+	assert.InDelta(t, 20*time.Second, time.Until(deadline), float64(time.Second))
+
+	// The last attempt should be given all the time left.
+	attemptCtx, attemptCancel = attemptContext(ctx, 2, 3)
+	defer attemptCancel()
+	assert.Equal(t, ctx, attemptCtx)
+
+	// No deadline should be set if the push has none.
+	attemptCtx, attemptCancel = attemptContext(context.Background(), 0, 3)
+	defer attemptCancel()
+	_, ok = attemptCtx.Deadline()
+	assert.False(t, ok)
+}
+
 func TestDistributor_MetricsCleanup(t *testing.T) {
 	t.Parallel()
 	dists, _, regs, r := prepare(t, prepConfig{
@@ -662,7 +909,11 @@ func TestDistributor_PushIngestionRateLimiter(t *testing.T) {
 					response, err := distributors[0].Push(ctx, request)
 
 					if push.expectedError == nil {
-						assert.Equal(t, emptyResponse, response)
+						expectedResponse := &cortexpb.WriteResponse{SamplesWritten: int64(push.samples)}
+						if enableHistogram {
+							expectedResponse = &cortexpb.WriteResponse{HistogramsWritten: int64(push.samples)}
+						}
+						assert.Equal(t, expectedResponse, response)
 						assert.Nil(t, err)
 					} else {
 						assert.Nil(t, response)
@@ -674,6 +925,283 @@ func TestDistributor_PushIngestionRateLimiter(t *testing.T) {
 	}
 }
 
+func TestDistributor_RequestRateLimitMiddleware(t *testing.T) {
+	t.Parallel()
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.RequestRate = 1
+	limits.RequestBurstSize = 2
+
+	distributors, _, regs, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           limits,
+	})
+
+	nextCalls := 0
+	handler := distributors[0].RequestRateLimitMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextCalls++
+	}))
+
+	// The requests within the burst should be accepted, the following one rejected.
+	for _, expectedCode := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/push", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, expectedCode, resp.Code)
+	}
+	assert.Equal(t, 2, nextCalls)
+
+	// The requests without a tenant should be left to the push handler.
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/api/v1/push", nil))
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 3, nextCalls)
+
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_rate_limited_push_requests_total The total number of push requests rejected, before decoding their body, because the tenant exceeded its request rate limit.
+		# TYPE cortex_distributor_rate_limited_push_requests_total counter
+		cortex_distributor_rate_limited_push_requests_total{user="user"} 1
+	`), "cortex_distributor_rate_limited_push_requests_total"))
+}
+
+func TestDistributor_PushTokens(t *testing.T) {
+	t.Parallel()
+
+	hash := func(token string) string {
+		h := sha256.Sum256([]byte(token))
+		return hex.EncodeToString(h[:])
+	}
+
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.PushTokens = []validation.PushToken{
+		{Name: "prometheus", TokenSHA256: hash("series-token"), Scopes: []string{validation.PushTokenScopeSeries}},
+		{Name: "metadata-sync", TokenSHA256: hash("metadata-token"), Scopes: []string{validation.PushTokenScopeMetadata}},
+	}
+
+	distributors, _, regs, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           limits,
+	})
+	d := distributors[0]
+
+	var (
+		pushReq *cortexpb.WriteRequest
+		pushErr error
+	)
+	handler := d.PushTokenMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, pushErr = d.Push(r.Context(), pushReq)
+	}))
+
+	for name, tc := range map[string]struct {
+		token        string
+		req          func() *cortexpb.WriteRequest
+		expectedCode int
+		expectedErr  int32
+	}{
+		"missing token": {
+			req:          func() *cortexpb.WriteRequest { return makeWriteRequest(0, 1, 0, 0) },
+			expectedCode: http.StatusUnauthorized,
+		},
+		"invalid token": {
+			token:        "unknown-token",
+			req:          func() *cortexpb.WriteRequest { return makeWriteRequest(0, 1, 0, 0) },
+			expectedCode: http.StatusUnauthorized,
+		},
+		"series with the series scope": {
+			token:        "series-token",
+			req:          func() *cortexpb.WriteRequest { return makeWriteRequest(0, 1, 0, 1) },
+			expectedCode: http.StatusOK,
+		},
+		"series and exemplars with the series scope": {
+			token: "series-token",
+			req: func() *cortexpb.WriteRequest {
+				req := makeWriteRequest(0, 1, 0, 0)
+				req.Timeseries[0].Exemplars = []cortexpb.Exemplar{{Labels: []cortexpb.LabelAdapter{{Name: "trace_id", Value: "1"}}, TimestampMs: 1}}
+				return req
+			},
+			expectedCode: http.StatusOK,
+		},
+		"metadata with the series scope": {
+			token:        "series-token",
+			req:          func() *cortexpb.WriteRequest { return makeWriteRequest(0, 1, 1, 0) },
+			expectedCode: http.StatusOK,
+			expectedErr:  http.StatusForbidden,
+		},
+		"metadata with the metadata scope": {
+			token:        "metadata-token",
+			req:          func() *cortexpb.WriteRequest { return makeWriteRequest(0, 0, 1, 0) },
+			expectedCode: http.StatusOK,
+		},
+		"series with the metadata scope": {
+			token:        "metadata-token",
+			req:          func() *cortexpb.WriteRequest { return makeWriteRequest(0, 1, 0, 0) },
+			expectedCode: http.StatusOK,
+			expectedErr:  http.StatusForbidden,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			pushReq, pushErr = tc.req(), nil
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/push", nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, tc.expectedCode, resp.Code)
+
+			if tc.expectedErr == 0 {
+				require.NoError(t, pushErr)
+				return
+			}
+			httpResp, ok := httpgrpc.HTTPResponseFromError(pushErr)
+			require.True(t, ok)
+			assert.Equal(t, tc.expectedErr, httpResp.Code)
+		})
+	}
+
+	// The push requests without push token, e.g. from the ruler, aren't restricted.
+	_, err := d.Push(user.InjectOrgID(context.Background(), "user"), makeWriteRequest(0, 1, 1, 0))
+	require.NoError(t, err)
+
+	// The push requests received via gRPC can't carry a push token, so they're rejected.
+	_, err = d.PushTokenGRPCServer().Push(user.InjectOrgID(context.Background(), "user"), makeWriteRequest(0, 1, 0, 0))
+	httpResp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusUnauthorized), httpResp.Code)
+
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_push_token_rejected_requests_total The total number of push requests rejected because of a missing or invalid push token, or a push token not allowing the content of the request.
+		# TYPE cortex_distributor_push_token_rejected_requests_total counter
+		cortex_distributor_push_token_rejected_requests_total{user="user"} 5
+		# HELP cortex_discarded_exemplars_total The total number of exemplars that were discarded.
+		# TYPE cortex_discarded_exemplars_total counter
+		cortex_discarded_exemplars_total{reason="push_token_scope_missing",user="user"} 1
+	`), "cortex_distributor_push_token_rejected_requests_total", "cortex_discarded_exemplars_total"))
+
+	// The push tokens API doesn't expose the hashes.
+	req := httptest.NewRequest(http.MethodGet, "/distributor/push_tokens", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+	resp := httptest.NewRecorder()
+	d.PushTokensHandler(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"name":"prometheus","scopes":["series"],"source":"runtime_config"},{"name":"metadata-sync","scopes":["metadata"],"source":"runtime_config"}]`, resp.Body.String())
+}
+
+func TestDistributor_PushTokensAPI(t *testing.T) {
+	t.Parallel()
+
+	hash := sha256.Sum256([]byte("series-token"))
+	limits := &validation.Limits{}
+	flagext.DefaultValues(limits)
+	limits.PushTokens = []validation.PushToken{
+		{Name: "prometheus", TokenSHA256: hex.EncodeToString(hash[:]), Scopes: []string{validation.PushTokenScopeSeries}},
+	}
+
+	distributors, _, _, _ := prepare(t, prepConfig{
+		numIngesters:     3,
+		happyIngesters:   3,
+		numDistributors:  2,
+		shardByAllLabels: true,
+		limits:           limits,
+	})
+
+	// The distributors share the push tokens created through the API.
+	kvClient, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+	for _, d := range distributors {
+		d.pushTokenStore = newPushTokenStoreWithClient(kvClient, log.NewNopLogger())
+		require.NoError(t, services.StartAndAwaitRunning(context.Background(), d.pushTokenStore))
+		t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(context.Background(), d.pushTokenStore)) })
+	}
+
+	serveWithToken := func(handler http.HandlerFunc, method, target, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req = mux.SetURLVars(req, map[string]string{"name": path.Base(target)})
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp := httptest.NewRecorder()
+		handler(resp, req)
+		return resp
+	}
+	serve := func(handler http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		return serveWithToken(handler, method, target, body, "")
+	}
+	push := func(d *Distributor, token string) int {
+		handler := d.PushTokenMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/push", nil)
+		req = req.WithContext(user.InjectOrgID(req.Context(), "user"))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp.Code
+	}
+
+	// The created push token must be valid along with the configured ones.
+	resp := serve(distributors[0].CreatePushTokenHandler, http.MethodPost, "/distributor/push_tokens", `{"name":"prometheus","scopes":["series"]}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = serve(distributors[0].CreatePushTokenHandler, http.MethodPost, "/distributor/push_tokens", `{"name":"agent","scopes":["unknown"]}`)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = serve(distributors[0].CreatePushTokenHandler, http.MethodPost, "/distributor/push_tokens", `{"name":"agent","scopes":["series","exemplars"]}`)
+	require.Equal(t, http.StatusOK, resp.Code)
+	var created struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		Token  string   `json:"token"`
+	}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &created))
+	assert.Equal(t, "agent", created.Name)
+	assert.Equal(t, []string{"series", "exemplars"}, created.Scopes)
+	require.NotEmpty(t, created.Token)
+
+	// The created push token is accepted by all the distributors, along with the configured ones.
+	assert.Equal(t, http.StatusOK, push(distributors[0], created.Token))
+	test.Poll(t, 5*time.Second, http.StatusOK, func() interface{} {
+		return push(distributors[1], created.Token)
+	})
+	assert.Equal(t, http.StatusOK, push(distributors[1], "series-token"))
+
+	resp = serve(distributors[1].PushTokensHandler, http.MethodGet, "/distributor/push_tokens", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `[{"name":"prometheus","scopes":["series"],"source":"runtime_config"},{"name":"agent","scopes":["series","exemplars"],"source":"api"}]`, resp.Body.String())
+
+	// The push tokens can't be managed with a push token.
+	resp = serveWithToken(distributors[1].PushTokensHandler, http.MethodGet, "/distributor/push_tokens", "", created.Token)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	resp = serveWithToken(distributors[1].CreatePushTokenHandler, http.MethodPost, "/distributor/push_tokens", `{"name":"other","scopes":["series","metadata","exemplars"]}`, "series-token")
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	resp = serveWithToken(distributors[1].RevokePushTokenHandler, http.MethodDelete, "/distributor/push_tokens/prometheus", "", created.Token)
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+
+	// Only the push tokens created through the API can be revoked.
+	resp = serve(distributors[1].RevokePushTokenHandler, http.MethodDelete, "/distributor/push_tokens/prometheus", "")
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	resp = serve(distributors[1].RevokePushTokenHandler, http.MethodDelete, "/distributor/push_tokens/unknown", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	resp = serve(distributors[1].RevokePushTokenHandler, http.MethodDelete, "/distributor/push_tokens/agent", "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	// The revoked push token is rejected by all the distributors.
+	assert.Equal(t, http.StatusUnauthorized, push(distributors[1], created.Token))
+	test.Poll(t, 5*time.Second, http.StatusUnauthorized, func() interface{} {
+		return push(distributors[0], created.Token)
+	})
+	assert.Equal(t, http.StatusOK, push(distributors[0], "series-token"))
+}
+
 func TestPush_QuorumError(t *testing.T) {
 	t.Parallel()
 
@@ -964,7 +1492,7 @@ func TestDistributor_PushHAInstances(t *testing.T) {
 			testReplica:      "instance0",
 			cluster:          "cluster0",
 			samples:          5,
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{SamplesWritten: 5},
 		},
 		// The 202 indicates that we didn't accept this sample.
 		{
@@ -982,7 +1510,7 @@ func TestDistributor_PushHAInstances(t *testing.T) {
 			testReplica:      "instance0",
 			cluster:          "cluster0",
 			samples:          5,
-			expectedResponse: emptyResponse,
+			expectedResponse: &cortexpb.WriteResponse{SamplesWritten: 5},
 		},
 		// Using very long replica label value results in validation error.
 		{
@@ -1025,7 +1553,11 @@ func TestDistributor_PushHAInstances(t *testing.T) {
 
 					request := makeWriteRequestHA(tc.samples, tc.testReplica, tc.cluster, enableHistogram)
 					response, err := d.Push(ctx, request)
-					assert.Equal(t, tc.expectedResponse, response)
+					expectedResponse := tc.expectedResponse
+					if expectedResponse != nil && enableHistogram {
+						expectedResponse = &cortexpb.WriteResponse{HistogramsWritten: expectedResponse.SamplesWritten}
+					}
+					assert.Equal(t, expectedResponse, response)
 
 					httpResp, ok := httpgrpc.HTTPResponseFromError(err)
 					if ok {
@@ -1186,7 +1718,7 @@ func TestDistributor_PushQuery(t *testing.T) {
 
 			request := makeWriteRequest(0, tc.samples, tc.metadata, 0)
 			writeResponse, err := ds[0].Push(ctx, request)
-			assert.Equal(t, &cortexpb.WriteResponse{}, writeResponse)
+			assert.Equal(t, &cortexpb.WriteResponse{SamplesWritten: int64(tc.samples)}, writeResponse)
 			assert.Nil(t, err)
 
 			var response model.Matrix
@@ -1212,6 +1744,78 @@ func TestDistributor_PushQuery(t *testing.T) {
 	}
 }
 
+func TestDistributor_QueryStream_ShouldQueryThePreferredZoneFirst(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	// Prepare distributors, with 2 ingesters in each of the 3 zones.
+	ds, ingesters, regs, _ := prepare(t, prepConfig{
+		numIngesters:       6,
+		happyIngesters:     6,
+		numDistributors:    1,
+		shardByAllLabels:   true,
+		zones:              3,
+		preferredQueryZone: "zone-0",
+	})
+
+	const numSeries = 20
+	writeRes, err := ds[0].Push(ctx, makeWriteRequest(0, numSeries, 0, 0))
+	assert.Equal(t, &cortexpb.WriteResponse{SamplesWritten: numSeries}, writeRes)
+	require.NoError(t, err)
+
+	allSeriesMatchers := []*labels.Matcher{
+		labels.MustNewMatcher(labels.MatchRegexp, model.MetricNameLabel, ".+"),
+	}
+
+	// Wait until the series have been written to all the zones.
+	test.Poll(t, time.Second, numSeries*3, func() interface{} {
+		total := 0
+		for _, ing := range ingesters {
+			total += len(ing.series())
+		}
+		return total
+	})
+
+	// A sample missing from an ingester of the preferred zone, because its write only succeeded
+	// on a quorum of zones, is returned by the next zone of the quorum.
+	ingesters[0].Lock()
+	for token := range ingesters[0].timeseries {
+		delete(ingesters[0].timeseries, token)
+		break
+	}
+	ingesters[0].Unlock()
+
+	// Only the ingesters of the preferred zone and of the next zone of the quorum are queried.
+	queryRes, err := ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, numSeries)
+	for i, ing := range ingesters {
+		if i%3 == 2 {
+			assert.Equal(t, 0, ing.countCalls("QueryStream"), i)
+		} else {
+			assert.Equal(t, 1, ing.countCalls("QueryStream"), i)
+		}
+	}
+
+	// All the zones are queried if an ingester of the quorum fails.
+	ingesters[3].happy.Store(false)
+	queryRes, err = ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
+	require.NoError(t, err)
+	assert.Len(t, queryRes.Chunkseries, numSeries)
+	for i, ing := range ingesters {
+		if i%3 == 2 {
+			assert.Equal(t, 1, ing.countCalls("QueryStream"), i)
+		}
+	}
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_preferred_zone_queries_total The total number of queries sent to the ingesters of the preferred query zone, by result. A fallback is a query sent to the ingesters of all the zones because an ingester of the preferred zone or of the other zones of the quorum failed, and unavailable a query sent to all the zones because the preferred zone has unhealthy ingesters or the ingesters aren't zone-aware.
+		# TYPE cortex_distributor_preferred_zone_queries_total counter
+		cortex_distributor_preferred_zone_queries_total{result="fallback"} 1
+		cortex_distributor_preferred_zone_queries_total{result="success"} 1
+	`), "cortex_distributor_preferred_zone_queries_total"))
+}
+
 func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunksPerQueryLimitIsReached(t *testing.T) {
 	t.Parallel()
 	const maxChunksLimit = 30 // Chunks are duplicated due to replication factor.
@@ -1242,8 +1846,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunksPerQueryLimitIsReac
 		} else {
 			writeReq = makeWriteRequest(0, initialSeries, 0, 0)
 		}
+		expectedRes := expectedWriteResponse(writeReq)
 		writeRes, err := ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		allSeriesMatchers := []*labels.Matcher{
@@ -1264,8 +1869,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunksPerQueryLimitIsReac
 			)
 		}
 
+		expectedRes = expectedWriteResponse(writeReq)
 		writeRes, err = ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		// Since the number of series (and thus chunks) is exceeding to the limit, we expect
@@ -1304,8 +1910,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxSeriesPerQueryLimitIsReac
 			writeReq = makeWriteRequest(0, initialSeries, 0, 0)
 		}
 
+		expectedRes := expectedWriteResponse(writeReq)
 		writeRes, err := ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		allSeriesMatchers := []*labels.Matcher{
@@ -1324,8 +1931,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxSeriesPerQueryLimitIsReac
 			makeWriteRequestTimeseries([]cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "another_series"}}, 0, 0, histogram),
 		)
 
+		expectedRes = expectedWriteResponse(writeReq)
 		writeRes, err = ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		// Since the number of series is exceeding the limit, we expect
@@ -1365,8 +1973,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIs
 		writeReq.Timeseries = append(writeReq.Timeseries,
 			makeWriteRequestTimeseries([]cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "another_series"}}, 0, 0, histogram),
 		)
+		expectedRes := expectedWriteResponse(writeReq)
 		writeRes, err := ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 		chunkSizeResponse, err := ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
 		require.NoError(t, err)
@@ -1384,8 +1993,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIs
 		} else {
 			writeReq = makeWriteRequest(0, seriesToAdd-1, 0, 0)
 		}
+		expectedRes = expectedWriteResponse(writeReq)
 		writeRes, err = ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		// Since the number of chunk bytes is equal to the limit (but doesn't
@@ -1400,8 +2010,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxChunkBytesPerQueryLimitIs
 			makeWriteRequestTimeseries([]cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "another_series_1"}}, 0, 0, histogram),
 		)
 
+		expectedRes = expectedWriteResponse(writeReq)
 		writeRes, err = ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		// Since the aggregated chunk size is exceeding the limit, we expect
@@ -1442,8 +2053,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxDataBytesPerQueryLimitIsR
 			makeWriteRequestTimeseries([]cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "another_series"}}, 0, 0, histogram),
 		)
 
+		expectedRes := expectedWriteResponse(writeReq)
 		writeRes, err := ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 		dataSizeResponse, err := ds[0].QueryStream(ctx, math.MinInt32, math.MaxInt32, allSeriesMatchers...)
 		require.NoError(t, err)
@@ -1461,8 +2073,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxDataBytesPerQueryLimitIsR
 		} else {
 			writeReq = makeWriteRequest(0, seriesToAdd-1, 0, 0)
 		}
+		expectedRes = expectedWriteResponse(writeReq)
 		writeRes, err = ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		// Since the number of chunk bytes is equal to the limit (but doesn't
@@ -1477,8 +2090,9 @@ func TestDistributor_QueryStream_ShouldReturnErrorIfMaxDataBytesPerQueryLimitIsR
 			makeWriteRequestTimeseries([]cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "another_series_1"}}, 0, 0, histogram),
 		)
 
+		expectedRes = expectedWriteResponse(writeReq)
 		writeRes, err = ds[0].Push(ctx, writeReq)
-		assert.Equal(t, &cortexpb.WriteResponse{}, writeRes)
+		assert.Equal(t, expectedRes, writeRes)
 		assert.Nil(t, err)
 
 		// Since the aggregated chunk size is exceeding the limit, we expect
@@ -1622,67 +2236,232 @@ func TestDistributor_Push_LabelRemoval_RemovingNameLabelWillError(t *testing.T)
 	assert.Equal(t, "rpc error: code = Code(400) desc = sample missing metric name", err.Error())
 }
 
-func TestDistributor_Push_ShouldGuaranteeShardingTokenConsistencyOverTheTime(t *testing.T) {
+func TestDistributor_Push_CustomPushMiddlewares(t *testing.T) {
 	t.Parallel()
 	ctx := user.InjectOrgID(context.Background(), "user")
-	tests := map[string]struct {
-		inputSeries    labels.Labels
-		expectedSeries labels.Labels
-		expectedToken  uint32
-	}{
-		"metric_1 with value_1": {
-			inputSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_1"},
-				{Name: "cluster", Value: "cluster_1"},
-				{Name: "key", Value: "value_1"},
-			},
-			expectedSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_1"},
-				{Name: "cluster", Value: "cluster_1"},
-				{Name: "key", Value: "value_1"},
-			},
-			expectedToken: 0xec0a2e9d,
-		},
-		"metric_1 with value_1 and dropped label due to config": {
-			inputSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_1"},
-				{Name: "cluster", Value: "cluster_1"},
-				{Name: "key", Value: "value_1"},
-				{Name: "dropped", Value: "unused"}, // will be dropped, doesn't need to be in correct order
-			},
-			expectedSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_1"},
-				{Name: "cluster", Value: "cluster_1"},
-				{Name: "key", Value: "value_1"},
-			},
-			expectedToken: 0xec0a2e9d,
-		},
-		"metric_1 with value_1 and dropped HA replica label": {
-			inputSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_1"},
-				{Name: "cluster", Value: "cluster_1"},
-				{Name: "key", Value: "value_1"},
-				{Name: "__replica__", Value: "replica_1"},
-			},
-			expectedSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_1"},
-				{Name: "cluster", Value: "cluster_1"},
-				{Name: "key", Value: "value_1"},
-			},
-			expectedToken: 0xec0a2e9d,
-		},
-		"metric_2 with value_1": {
-			inputSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_2"},
-				{Name: "key", Value: "value_1"},
-			},
-			expectedSeries: labels.Labels{
-				{Name: "__name__", Value: "metric_2"},
-				{Name: "key", Value: "value_1"},
-			},
-			expectedToken: 0xa60906f2,
-		},
-		"metric_1 with value_2": {
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	// The custom stages run in order, after the tenant has been authenticated.
+	var stages []string
+	addLabel := func(next PushFunc) PushFunc {
+		return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			userID, err := tenant.TenantID(ctx)
+			require.NoError(t, err)
+			stages = append(stages, "add-label:"+userID)
+
+			for _, ts := range req.Timeseries {
+				ts.Labels = append(ts.Labels, cortexpb.LabelAdapter{Name: "env", Value: "prod"})
+			}
+			return next(ctx, req)
+		}
+	}
+	rejectBlocked := func(next PushFunc) PushFunc {
+		return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			stages = append(stages, "reject-blocked")
+
+			for _, ts := range req.Timeseries {
+				for _, l := range ts.Labels {
+					if l.Name == "blocked" {
+						return nil, httpgrpc.Errorf(http.StatusForbidden, "blocked series")
+					}
+				}
+			}
+			return next(ctx, req)
+		}
+	}
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:     2,
+		happyIngesters:   2,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+		pushMiddlewares:  []PushMiddleware{addLabel, rejectBlocked},
+	})
+
+	req := mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "some_metric")}, 1, 1, false)
+	_, err := ds[0].Push(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"add-label:user", "reject-blocked"}, stages)
+
+	// The series modified by the custom stages are validated and forwarded to the ingesters.
+	for i := range ingesters {
+		timeseries := ingesters[i].series()
+		require.Equal(t, 1, len(timeseries))
+		for _, v := range timeseries {
+			assert.Equal(t, labels.FromStrings(labels.MetricName, "some_metric", "env", "prod"), cortexpb.FromLabelAdaptersToLabels(v.Labels))
+		}
+	}
+
+	req = mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "some_metric", "blocked", "true")}, 1, 1, false)
+	_, err = ds[0].Push(ctx, req)
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusForbidden), resp.Code)
+}
+
+func TestDistributor_Push_ShouldDeduplicateRequestsWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+
+	ds, ingesters, regs, _ := prepare(t, prepConfig{
+		numIngesters:      1,
+		happyIngesters:    1,
+		numDistributors:   2,
+		shardByAllLabels:  true,
+		limits:            &limits,
+		replicationFactor: 1,
+		idempotencyKeys:   cache.NewMockCache(),
+	})
+
+	pushRequest := func(d *Distributor, userID, key string) *cortexpb.WriteResponse {
+		ctx := user.InjectOrgID(context.Background(), userID)
+		if key != "" {
+			ctx = push.ContextWithIdempotencyKey(ctx, key)
+		}
+		req := mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "some_metric")}, 1, 1, false)
+		resp, err := d.Push(ctx, req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// The retries of a request are deduplicated, even when sent to another distributor sharing the cache,
+	// and report the samples written by the first push.
+	expected := &cortexpb.WriteResponse{SamplesWritten: 1}
+	assert.Equal(t, expected, pushRequest(ds[0], "user-1", "key-1"))
+	assert.Equal(t, expected, pushRequest(ds[0], "user-1", "key-1"))
+	assert.Equal(t, expected, pushRequest(ds[1], "user-1", "key-1"))
+	assert.Equal(t, 1, ingesters[0].countCalls("Push"))
+
+	// The keys are per tenant, and the requests without a key are never deduplicated.
+	pushRequest(ds[0], "user-2", "key-1")
+	pushRequest(ds[0], "user-1", "key-2")
+	pushRequest(ds[0], "user-1", "")
+	pushRequest(ds[0], "user-1", "")
+	assert.Equal(t, 5, ingesters[0].countCalls("Push"))
+
+	assert.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_deduped_push_requests_total The total number of push requests acknowledged without being pushed, because a request with the same idempotency key had already been pushed successfully.
+		# TYPE cortex_distributor_deduped_push_requests_total counter
+		cortex_distributor_deduped_push_requests_total{user="user-1"} 1
+	`), "cortex_distributor_deduped_push_requests_total"))
+}
+
+func TestDistributor_Push_ShouldHonorTenantReplicationFactor(t *testing.T) {
+	t.Parallel()
+
+	for _, tenantReplicationFactor := range []int{1, 5} {
+		tenantReplicationFactor := tenantReplicationFactor
+
+		t.Run(fmt.Sprintf("tenant replication factor: %d", tenantReplicationFactor), func(t *testing.T) {
+			t.Parallel()
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.IngestionReplicationFactor = tenantReplicationFactor
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:               5,
+				happyIngesters:             5,
+				numDistributors:            1,
+				shardByAllLabels:           true,
+				limits:                     &limits,
+				replicationFactor:          3,
+				maxTenantReplicationFactor: 5,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			req := mockWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "some_metric")}, 1, 1, false)
+			_, err := ds[0].Push(ctx, req)
+			require.NoError(t, err)
+
+			// The series has been written to the tenant's replication factor number of ingesters. The push
+			// returns once the quorum is reached, so the remaining ingesters may be written asynchronously.
+			test.Poll(t, time.Second, tenantReplicationFactor, func() interface{} {
+				written := 0
+				for _, ing := range ingesters {
+					if ing.countCalls("Push") > 0 {
+						written++
+					}
+				}
+				return written
+			})
+
+			// Reads tolerate failures based on the tenant's replication factor.
+			replicationSet, err := ds[0].GetIngestersForQuery(ctx)
+			require.NoError(t, err)
+			assert.Len(t, replicationSet.Instances, 5)
+			assert.Equal(t, tenantReplicationFactor/2, replicationSet.MaxErrors)
+		})
+	}
+}
+
+func TestDistributor_Push_ShouldGuaranteeShardingTokenConsistencyOverTheTime(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+	tests := map[string]struct {
+		inputSeries    labels.Labels
+		expectedSeries labels.Labels
+		expectedToken  uint32
+	}{
+		"metric_1 with value_1": {
+			inputSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_1"},
+				{Name: "cluster", Value: "cluster_1"},
+				{Name: "key", Value: "value_1"},
+			},
+			expectedSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_1"},
+				{Name: "cluster", Value: "cluster_1"},
+				{Name: "key", Value: "value_1"},
+			},
+			expectedToken: 0xec0a2e9d,
+		},
+		"metric_1 with value_1 and dropped label due to config": {
+			inputSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_1"},
+				{Name: "cluster", Value: "cluster_1"},
+				{Name: "key", Value: "value_1"},
+				{Name: "dropped", Value: "unused"}, // will be dropped, doesn't need to be in correct order
+			},
+			expectedSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_1"},
+				{Name: "cluster", Value: "cluster_1"},
+				{Name: "key", Value: "value_1"},
+			},
+			expectedToken: 0xec0a2e9d,
+		},
+		"metric_1 with value_1 and dropped HA replica label": {
+			inputSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_1"},
+				{Name: "cluster", Value: "cluster_1"},
+				{Name: "key", Value: "value_1"},
+				{Name: "__replica__", Value: "replica_1"},
+			},
+			expectedSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_1"},
+				{Name: "cluster", Value: "cluster_1"},
+				{Name: "key", Value: "value_1"},
+			},
+			expectedToken: 0xec0a2e9d,
+		},
+		"metric_2 with value_1": {
+			inputSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_2"},
+				{Name: "key", Value: "value_1"},
+			},
+			expectedSeries: labels.Labels{
+				{Name: "__name__", Value: "metric_2"},
+				{Name: "key", Value: "value_1"},
+			},
+			expectedToken: 0xa60906f2,
+		},
+		"metric_1 with value_2": {
 			inputSeries: labels.Labels{
 				{Name: "__name__", Value: "metric_1"},
 				{Name: "key", Value: "value_2"},
@@ -2659,22 +3438,33 @@ func mockWriteRequest(lbls []labels.Labels, value int64, timestampMs int64, hist
 }
 
 type prepConfig struct {
-	numIngesters, happyIngesters int
-	queryDelay                   time.Duration
-	shardByAllLabels             bool
-	shuffleShardEnabled          bool
-	shuffleShardSize             int
-	lblValuesPerIngester         int
-	lblValuesDuplicateRatio      float64
-	limits                       *validation.Limits
-	numDistributors              int
-	skipLabelNameValidation      bool
-	maxInflightRequests          int
-	maxIngestionRate             float64
-	replicationFactor            int
-	enableTracker                bool
-	errFail                      error
-	tokens                       [][]uint32
+	numIngesters, happyIngesters   int
+	queryDelay                     time.Duration
+	shardByAllLabels               bool
+	shuffleShardEnabled            bool
+	shuffleShardSize               int
+	lblValuesPerIngester           int
+	lblValuesDuplicateRatio        float64
+	limits                         *validation.Limits
+	numDistributors                int
+	skipLabelNameValidation        bool
+	maxInflightRequests            int
+	maxIngestionRate               float64
+	replicationFactor              int
+	maxTenantReplicationFactor     int
+	enableTracker                  bool
+	errFail                        error
+	tokens                         [][]uint32
+	pushMiddlewares                []PushMiddleware
+	idempotencyKeys                cache.Cache
+	ingesterStateTransitionRetries int
+	writeDeadlineBudgetEnabled     bool
+	pushDelay                      time.Duration
+	spillBuffer                    SpillBufferConfig
+	zones                          int
+	preferredQueryZone             string
+	shardByLabels                  []string
+	shardByLabelsShardCount        int
 }
 
 type prepState struct {
@@ -2703,6 +3493,7 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 
 		ingesters = append(ingesters, &mockIngester{
 			queryDelay: cfg.queryDelay,
+			pushDelay:  cfg.pushDelay,
 			failResp:   *atomic.NewError(miError),
 		})
 	}
@@ -2718,9 +3509,13 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 			tokens = []uint32{uint32((math.MaxUint32 / cfg.numIngesters) * i)}
 		}
 		addr := fmt.Sprintf("%d", i)
+		zone := ""
+		if cfg.zones > 0 {
+			zone = fmt.Sprintf("zone-%d", i%cfg.zones)
+		}
 		ingesterDescs[addr] = ring.InstanceDesc{
 			Addr:                addr,
-			Zone:                "",
+			Zone:                zone,
 			State:               ring.ACTIVE,
 			Timestamp:           time.Now().Unix(),
 			RegisteredTimestamp: time.Now().Add(-2 * time.Hour).Unix(),
@@ -2751,8 +3546,11 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 		KVStore: kv.Config{
 			Mock: kvStore,
 		},
-		HeartbeatTimeout:  60 * time.Minute,
-		ReplicationFactor: rf,
+		HeartbeatTimeout:           60 * time.Minute,
+		ReplicationFactor:          rf,
+		ZoneAwarenessEnabled:       cfg.zones > 0,
+		MinTenantReplicationFactor: 1,
+		MaxTenantReplicationFactor: cfg.maxTenantReplicationFactor,
 	}, ingester.RingKey, ingester.RingKey, nil, nil)
 	require.NoError(tb, err)
 	require.NoError(tb, services.StartAndAwaitRunning(context.Background(), ingestersRing))
@@ -2787,6 +3585,19 @@ func prepare(tb testing.TB, cfg prepConfig) ([]*Distributor, []*mockIngester, []
 		distributorCfg.SkipLabelNameValidation = cfg.skipLabelNameValidation
 		distributorCfg.InstanceLimits.MaxInflightPushRequests = cfg.maxInflightRequests
 		distributorCfg.InstanceLimits.MaxIngestionRate = cfg.maxIngestionRate
+		distributorCfg.PushMiddlewares = cfg.pushMiddlewares
+		distributorCfg.IngesterStateTransitionRetries = cfg.ingesterStateTransitionRetries
+		distributorCfg.WriteDeadlineBudgetEnabled = cfg.writeDeadlineBudgetEnabled
+		distributorCfg.SpillBuffer = cfg.spillBuffer
+		distributorCfg.PreferredQueryZone = cfg.preferredQueryZone
+		distributorCfg.ShardByLabels = cfg.shardByLabels
+		if cfg.shardByLabelsShardCount > 0 {
+			distributorCfg.ShardByLabelsShardCount = cfg.shardByLabelsShardCount
+		}
+		if cfg.idempotencyKeys != nil {
+			distributorCfg.Idempotency.Enabled = true
+			distributorCfg.Idempotency.Cache.Cache = cfg.idempotencyKeys
+		}
 
 		if cfg.shuffleShardEnabled {
 			distributorCfg.ShardingStrategy = util.ShardingStrategyShuffle
@@ -2843,6 +3654,17 @@ func stopAll(ds []*Distributor, r *ring.Ring) {
 	r.StopAsync()
 }
 
+// expectedWriteResponse returns the response to the push of the request, when all its samples and
+// exemplars are accepted. It must be called before the push, which releases the request.
+func expectedWriteResponse(req *cortexpb.WriteRequest) *cortexpb.WriteResponse {
+	numFloatSamples, numHistogramSamples, numExemplars := countSamples(req)
+	return &cortexpb.WriteResponse{
+		SamplesWritten:    int64(numFloatSamples),
+		HistogramsWritten: int64(numHistogramSamples),
+		ExemplarsWritten:  int64(numExemplars),
+	}
+}
+
 func makeWriteRequest(startTimestampMs int64, samples int, metadata int, histograms int) *cortexpb.WriteRequest {
 	request := &cortexpb.WriteRequest{}
 	for i := 0; i < samples; i++ {
@@ -2980,6 +3802,7 @@ type mockIngester struct {
 	timeseries map[uint32]*cortexpb.PreallocTimeseries
 	metadata   map[uint32]map[cortexpb.MetricMetadata]struct{}
 	queryDelay time.Duration
+	pushDelay  time.Duration
 	calls      map[string]int
 	lblsValues []string
 }
@@ -3045,6 +3868,14 @@ func (i *mockIngester) PushPreAlloc(ctx context.Context, in *cortexpb.PreallocWr
 }
 
 func (i *mockIngester) Push(ctx context.Context, req *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error) {
+	if i.pushDelay > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(i.pushDelay):
+		}
+	}
+
 	i.Lock()
 	defer i.Unlock()
 
@@ -3074,8 +3905,9 @@ func (i *mockIngester) Push(ctx context.Context, req *cortexpb.WriteRequest, opt
 		if !ok {
 			// Make a copy because the request Timeseries are reused
 			item := cortexpb.TimeSeries{
-				Labels:  make([]cortexpb.LabelAdapter, len(series.TimeSeries.Labels)),
-				Samples: make([]cortexpb.Sample, len(series.TimeSeries.Samples)),
+				Labels:     make([]cortexpb.LabelAdapter, len(series.TimeSeries.Labels)),
+				Samples:    make([]cortexpb.Sample, len(series.TimeSeries.Samples)),
+				Histograms: append([]cortexpb.Histogram(nil), series.TimeSeries.Histograms...),
 			}
 
 			copy(item.Labels, series.TimeSeries.Labels)
@@ -3084,6 +3916,7 @@ func (i *mockIngester) Push(ctx context.Context, req *cortexpb.WriteRequest, opt
 			i.timeseries[hash] = &cortexpb.PreallocTimeseries{TimeSeries: &item}
 		} else {
 			existing.Samples = append(existing.Samples, series.Samples...)
+			existing.Histograms = append(existing.Histograms, series.Histograms...)
 		}
 	}
 
@@ -3126,6 +3959,10 @@ func (i *mockIngester) Query(ctx context.Context, req *client.QueryRequest, opts
 	return &response, nil
 }
 
+func (i *mockIngester) PushStream(ctx context.Context, opts ...grpc.CallOption) (client.Ingester_PushStreamClient, error) {
+	return nil, status.Error(codes.Unimplemented, "not implemented")
+}
+
 func (i *mockIngester) QueryStream(ctx context.Context, req *client.QueryRequest, opts ...grpc.CallOption) (client.Ingester_QueryStreamClient, error) {
 	time.Sleep(i.queryDelay)
 
@@ -3265,6 +4102,45 @@ func (i *mockIngester) MetricsMetadata(ctx context.Context, req *client.MetricsM
 	return resp, nil
 }
 
+func (i *mockIngester) TSDBStatus(ctx context.Context, req *client.TSDBStatusRequest, opts ...grpc.CallOption) (*client.TSDBStatusResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("TSDBStatus")
+
+	if !i.happy.Load() {
+		return nil, errFail
+	}
+
+	resp := &client.TSDBStatusResponse{MinTime: math.MaxInt64, MaxTime: math.MinInt64}
+	seriesCountByMetricName := map[string]uint64{}
+	for _, ts := range i.timeseries {
+		resp.NumSeries++
+		resp.ChunkCount++
+		seriesCountByMetricName[cortexpb.FromLabelAdaptersToLabels(ts.Labels).Get(model.MetricNameLabel)]++
+		for _, s := range ts.Samples {
+			resp.MinTime = min(resp.MinTime, s.TimestampMs)
+			resp.MaxTime = max(resp.MaxTime, s.TimestampMs)
+		}
+	}
+	resp.SeriesCountByMetricName = topTSDBStatistics(seriesCountByMetricName, 1, int(req.Limit))
+
+	return resp, nil
+}
+
+func (i *mockIngester) PrewarmTenant(ctx context.Context, req *client.PrewarmTenantRequest, opts ...grpc.CallOption) (*client.PrewarmTenantResponse, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	i.trackCall("PrewarmTenant")
+
+	if !i.happy.Load() {
+		return nil, errFail
+	}
+
+	return &client.PrewarmTenantResponse{Created: len(i.timeseries) == 0}, nil
+}
+
 func (i *mockIngester) trackCall(name string) {
 	if i.calls == nil {
 		i.calls = map[string]int{}
@@ -3362,6 +4238,7 @@ func TestDistributorValidation(t *testing.T) {
 		labels     []labels.Labels
 		samples    []cortexpb.Sample
 		histograms []cortexpb.Histogram
+		oooWindow  time.Duration
 		err        error
 	}{
 		// Test validation passes.
@@ -3385,6 +4262,18 @@ func TestDistributorValidation(t *testing.T) {
 			}},
 			err: httpgrpc.Errorf(http.StatusBadRequest, `timestamp too old: %d metric: "testmetric"`, past),
 		},
+		// Test validation passes for old samples within the out-of-order time window.
+		{
+			labels: []labels.Labels{{{Name: labels.MetricName, Value: "testmetric"}, {Name: "foo", Value: "bar"}}},
+			samples: []cortexpb.Sample{{
+				TimestampMs: int64(past),
+				Value:       2,
+			}},
+			histograms: []cortexpb.Histogram{
+				cortexpb.HistogramToHistogramProto(int64(past), testHistogram),
+			},
+			oooWindow: 48 * time.Hour,
+		},
 		// Test validation fails for samples from the future.
 		{
 			labels: []labels.Labels{{{Name: labels.MetricName, Value: "testmetric"}, {Name: "foo", Value: "bar"}}},
@@ -3460,6 +4349,7 @@ func TestDistributorValidation(t *testing.T) {
 			limits.CreationGracePeriod = model.Duration(2 * time.Hour)
 			limits.RejectOldSamples = true
 			limits.RejectOldSamplesMaxAge = model.Duration(24 * time.Hour)
+			limits.OutOfOrderTimeWindow = model.Duration(tc.oooWindow)
 			limits.MaxLabelNamesPerSeries = 2
 
 			ds, _, _, _ := prepare(t, prepConfig{
@@ -3521,6 +4411,384 @@ func TestRemoveReplicaLabel(t *testing.T) {
 	}
 }
 
+func TestReduceLabelNames(t *testing.T) {
+	t.Parallel()
+	cases := map[string]struct {
+		labelsIn        []cortexpb.LabelAdapter
+		maxLabelNames   int
+		priority        []string
+		labelsOut       []cortexpb.LabelAdapter
+		expectedDropped []string
+	}{
+		"should not drop labels within the limit": {
+			labelsIn: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			},
+			maxLabelNames: 2,
+			labelsOut: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			},
+		},
+		"should keep labels in lexicographic order without priority": {
+			labelsIn: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+				{Name: "pod", Value: "1"},
+				{Name: "zone", Value: "a"},
+			},
+			maxLabelNames: 2,
+			labelsOut: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			},
+			expectedDropped: []string{"pod", "zone"},
+		},
+		"should keep priority labels first": {
+			labelsIn: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+				{Name: "pod", Value: "1"},
+				{Name: "zone", Value: "a"},
+			},
+			maxLabelNames: 3,
+			priority:      []string{"missing", "zone"},
+			labelsOut: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+				{Name: "zone", Value: "a"},
+			},
+			expectedDropped: []string{"pod"},
+		},
+		"should only keep the metric name if it meets the limit": {
+			labelsIn: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			},
+			maxLabelNames: 1,
+			priority:      []string{"bar"},
+			labelsOut: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+			},
+			expectedDropped: []string{"bar"},
+		},
+		"should not drop labels if the limit is not positive": {
+			labelsIn: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			},
+			maxLabelNames: 0,
+			labelsOut: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+			},
+		},
+		"should not drop labels if the series has duplicate label names": {
+			labelsIn: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+				{Name: "bar", Value: "qux"},
+			},
+			maxLabelNames: 2,
+			labelsOut: []cortexpb.LabelAdapter{
+				{Name: "__name__", Value: "foo"},
+				{Name: "bar", Value: "baz"},
+				{Name: "bar", Value: "qux"},
+			},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			dropped := reduceLabelNames(&c.labelsIn, c.maxLabelNames, c.priority)
+			assert.Equal(t, c.labelsOut, c.labelsIn)
+			assert.Equal(t, c.expectedDropped, dropped)
+		})
+	}
+}
+
+func TestDistributor_Push_LabelNamesReduction(t *testing.T) {
+	t.Parallel()
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.MaxLabelNamesPerSeries = 3
+	limits.MaxLabelNamesPerSeriesReductionEnabled = true
+	limits.MaxLabelNamesPerSeriesPriority = []string{"namespace", "pod"}
+
+	ds, ingesters, regs, _ := prepare(t, prepConfig{
+		numIngesters:     2,
+		happyIngesters:   2,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	// Both series only differ by the "zone" label, so they're merged into the same series once reduced.
+	req := mockWriteRequest([]labels.Labels{
+		labels.FromStrings("__name__", "some_metric", "cluster", "one", "namespace", "two", "pod", "three", "zone", "a"),
+		labels.FromStrings("__name__", "some_metric", "cluster", "one", "namespace", "two", "pod", "three", "zone", "b"),
+	}, 1, 1, false)
+	_, err := ds[0].Push(ctx, req)
+	require.NoError(t, err)
+
+	for i := range ingesters {
+		timeseries := ingesters[i].series()
+		assert.Equal(t, 1, len(timeseries))
+		for _, v := range timeseries {
+			assert.Equal(t, labels.FromStrings("__name__", "some_metric", "namespace", "two", "pod", "three"), cortexpb.FromLabelAdaptersToLabels(v.Labels))
+			assert.Equal(t, 2, len(v.Samples))
+		}
+	}
+
+	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(`
+		# HELP cortex_distributor_dropped_label_names_total The total number of label names dropped from series exceeding the max label names per series limit. Label names not in the tenant priority list are tracked as other.
+		# TYPE cortex_distributor_dropped_label_names_total counter
+		cortex_distributor_dropped_label_names_total{label_name="other",user="user"} 4
+	`), "cortex_distributor_dropped_label_names_total"))
+}
+
+func TestDistributor_Push_StalenessMarkerPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy          string
+		expectedSamples int
+		expectedMetrics string
+	}{
+		"accept": {
+			policy:          validation.StalenessMarkerPolicyAccept,
+			expectedSamples: 2,
+		},
+		"drop": {
+			policy:          validation.StalenessMarkerPolicyDrop,
+			expectedSamples: 1,
+			expectedMetrics: `
+				# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+				# TYPE cortex_discarded_samples_total counter
+				cortex_discarded_samples_total{reason="staleness_marker",user="user"} 1
+			`,
+		},
+		"convert": {
+			policy:          validation.StalenessMarkerPolicyConvert,
+			expectedSamples: 1,
+			expectedMetrics: `
+				# HELP cortex_distributor_end_of_series_events_total The total number of staleness markers received and converted to end-of-series events instead of being ingested.
+				# TYPE cortex_distributor_end_of_series_events_total counter
+				cortex_distributor_end_of_series_events_total{user="user"} 1
+			`,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := user.InjectOrgID(context.Background(), "user")
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.StalenessMarkerPolicy = tc.policy
+
+			ds, ingesters, regs, _ := prepare(t, prepConfig{
+				numIngesters:     2,
+				happyIngesters:   2,
+				numDistributors:  1,
+				shardByAllLabels: true,
+				limits:           &limits,
+			})
+
+			req := cortexpb.ToWriteRequest(
+				[]labels.Labels{
+					labels.FromStrings("__name__", "some_metric", "series", "one"),
+					labels.FromStrings("__name__", "some_metric", "series", "two"),
+				},
+				[]cortexpb.Sample{
+					{TimestampMs: 1, Value: 1},
+					{TimestampMs: 1, Value: math.Float64frombits(value.StaleNaN)},
+				}, nil, nil, cortexpb.API)
+			_, err := ds[0].Push(ctx, req)
+			require.NoError(t, err)
+
+			for i := range ingesters {
+				assert.Equal(t, tc.expectedSamples, len(ingesters[i].series()))
+			}
+
+			require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(tc.expectedMetrics), "cortex_discarded_samples_total", "cortex_distributor_end_of_series_events_total"))
+		})
+	}
+}
+
+func TestDistributor_Push_MinSampleInterval(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy             string
+		expectedErr        bool
+		expectedTimestamps []int64
+		expectedDiscarded  int
+	}{
+		"reject": {
+			policy:             validation.MinSampleIntervalPolicyReject,
+			expectedErr:        true,
+			expectedTimestamps: []int64{10000, 30000},
+			expectedDiscarded:  1,
+		},
+		"coalesce": {
+			policy:             validation.MinSampleIntervalPolicyCoalesce,
+			expectedTimestamps: []int64{10000, 25000},
+			expectedDiscarded:  2,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := user.InjectOrgID(context.Background(), "user")
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.MinSampleInterval = model.Duration(10 * time.Second)
+			limits.MinSampleIntervalPolicy = tc.policy
+
+			ds, ingesters, regs, _ := prepare(t, prepConfig{
+				numIngesters:      1,
+				happyIngesters:    1,
+				replicationFactor: 1,
+				numDistributors:   1,
+				shardByAllLabels:  true,
+				limits:            &limits,
+			})
+
+			push := func(timestamps ...int64) error {
+				samples := make([]cortexpb.Sample, 0, len(timestamps))
+				for _, ts := range timestamps {
+					samples = append(samples, cortexpb.Sample{TimestampMs: ts, Value: 1})
+				}
+				_, err := ds[0].Push(ctx, &cortexpb.WriteRequest{Timeseries: []cortexpb.PreallocTimeseries{{
+					TimeSeries: &cortexpb.TimeSeries{
+						Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: "some_metric"}},
+						Samples: samples,
+					},
+				}}})
+				return err
+			}
+
+			require.NoError(t, push(10000))
+
+			// The first sample is closer than the min interval to the previous one.
+			err := push(15000, 25000)
+			if tc.expectedErr {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+				assert.Contains(t, string(resp.Body), "timestamp too close to the previous sample of the series")
+			} else {
+				require.NoError(t, err)
+			}
+
+			// The sample is only accepted if the previous push has been rejected.
+			require.NoError(t, push(30000))
+
+			var timestamps []int64
+			for _, series := range ingesters[0].series() {
+				for _, s := range series.Samples {
+					timestamps = append(timestamps, s.TimestampMs)
+				}
+			}
+			assert.Equal(t, tc.expectedTimestamps, timestamps)
+
+			require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(fmt.Sprintf(`
+				# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+				# TYPE cortex_discarded_samples_total counter
+				cortex_discarded_samples_total{reason="sample_interval_too_short",user="user"} %d
+			`, tc.expectedDiscarded)), "cortex_discarded_samples_total"))
+		})
+	}
+}
+
+func TestDistributor_Push_NativeHistogramLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		policy          string
+		expectedErr     bool
+		expectedSchema  int32
+		expectedMetrics string
+	}{
+		"reject": {
+			policy:      validation.NativeHistogramLimitsPolicyReject,
+			expectedErr: true,
+			expectedMetrics: `
+				# HELP cortex_discarded_samples_total The total number of samples that were discarded.
+				# TYPE cortex_discarded_samples_total counter
+				cortex_discarded_samples_total{reason="native_histogram_buckets_exceeded",user="user"} 1
+			`,
+		},
+		"downscale": {
+			policy:         validation.NativeHistogramLimitsPolicyDownscale,
+			expectedSchema: 0,
+			expectedMetrics: `
+				# HELP cortex_downscaled_native_histograms_total The total number of native histograms whose resolution was reduced to fit within the limits.
+				# TYPE cortex_downscaled_native_histograms_total counter
+				cortex_downscaled_native_histograms_total{user="user"} 1
+			`,
+		},
+	}
+
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			ctx := user.InjectOrgID(context.Background(), "user")
+
+			var limits validation.Limits
+			flagext.DefaultValues(&limits)
+			limits.MaxNativeHistogramBuckets = 8
+			limits.NativeHistogramLimitsPolicy = tc.policy
+
+			ds, ingesters, regs, _ := prepare(t, prepConfig{
+				numIngesters:      1,
+				happyIngesters:    1,
+				replicationFactor: 1,
+				numDistributors:   1,
+				shardByAllLabels:  true,
+				limits:            &limits,
+			})
+
+			// The test histogram has 20 buckets at schema 2, and 8 buckets at schema 0.
+			_, err := ds[0].Push(ctx, &cortexpb.WriteRequest{Timeseries: []cortexpb.PreallocTimeseries{{
+				TimeSeries: &cortexpb.TimeSeries{
+					Labels:     []cortexpb.LabelAdapter{{Name: "__name__", Value: "some_metric"}},
+					Histograms: []cortexpb.Histogram{cortexpb.HistogramToHistogramProto(time.Now().UnixMilli(), histogram_util.GenerateTestHistogram(0))},
+				},
+			}}})
+
+			var histograms []cortexpb.Histogram
+			for _, series := range ingesters[0].series() {
+				histograms = append(histograms, series.Histograms...)
+			}
+
+			if tc.expectedErr {
+				resp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok)
+				assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+				assert.Contains(t, string(resp.Body), "native histogram has too many buckets")
+				assert.Empty(t, histograms)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, histograms, 1)
+				assert.Equal(t, tc.expectedSchema, histograms[0].Schema)
+			}
+
+			require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(tc.expectedMetrics), "cortex_discarded_samples_total", "cortex_downscaled_native_histograms_total"))
+		})
+	}
+}
+
 // This is not great, but we deal with unsorted labels when validating labels.
 func TestShardByAllLabelsReturnsWrongResultsForUnsortedLabels(t *testing.T) {
 	t.Parallel()
@@ -3792,11 +5060,13 @@ func TestDistributor_Push_RelabelDropWillExportMetricOfDroppedSamples(t *testing
 		limits:           &limits,
 	})
 
-	// Push the series to the distributor
-	req := mockWriteRequest(inputSeries, 1, 1, false)
+	// Push the series to the distributor, with float samples and then histograms.
 	ctx := user.InjectOrgID(context.Background(), "userDistributorPushRelabelDropWillExportMetricOfDroppedSamples")
-	_, err = ds[0].Push(ctx, req)
-	require.NoError(t, err)
+	for i, histogram := range []bool{false, true} {
+		req := mockWriteRequest(inputSeries, 1, int64(i+1), histogram)
+		_, err = ds[0].Push(ctx, req)
+		require.NoError(t, err)
+	}
 
 	// Since each test pushes only 1 series, we do expect the ingester
 	// to have received exactly 1 series
@@ -3810,11 +5080,11 @@ func TestDistributor_Push_RelabelDropWillExportMetricOfDroppedSamples(t *testing
 	expectedMetrics := `
 		# HELP cortex_discarded_samples_total The total number of samples that were discarded.
 		# TYPE cortex_discarded_samples_total counter
-		cortex_discarded_samples_total{reason="relabel_configuration",user="userDistributorPushRelabelDropWillExportMetricOfDroppedSamples"} 1
+		cortex_discarded_samples_total{reason="relabel_configuration",user="userDistributorPushRelabelDropWillExportMetricOfDroppedSamples"} 2
 		# HELP cortex_distributor_received_samples_total The total number of received samples, excluding rejected and deduped samples.
 		# TYPE cortex_distributor_received_samples_total counter
         cortex_distributor_received_samples_total{type="float",user="userDistributorPushRelabelDropWillExportMetricOfDroppedSamples"} 1
-        cortex_distributor_received_samples_total{type="histogram",user="userDistributorPushRelabelDropWillExportMetricOfDroppedSamples"} 0
+        cortex_distributor_received_samples_total{type="histogram",user="userDistributorPushRelabelDropWillExportMetricOfDroppedSamples"} 1
 		`
 	require.NoError(t, testutil.GatherAndCompare(regs[0], strings.NewReader(expectedMetrics), metrics...))
 }
@@ -3876,3 +5146,61 @@ func TestFindHALabels(t *testing.T) {
 		assert.Equal(t, c.expected.replica, replica)
 	}
 }
+
+func TestFindHALabelPair(t *testing.T) {
+	t.Parallel()
+	pairs := validation.HALabelPairs{
+		{Cluster: "cluster", Replica: "__replica__"},
+		{Cluster: "k8s_cluster", Replica: "pod"},
+	}
+
+	tests := map[string]struct {
+		labels               []cortexpb.LabelAdapter
+		expectedCluster      string
+		expectedReplica      string
+		expectedReplicaLabel string
+	}{
+		"should match the first pair": {
+			labels:               []cortexpb.LabelAdapter{{Name: "cluster", Value: "c1"}, {Name: "__replica__", Value: "r1"}},
+			expectedCluster:      "c1",
+			expectedReplica:      "r1",
+			expectedReplicaLabel: "__replica__",
+		},
+		"should match the second pair": {
+			labels:               []cortexpb.LabelAdapter{{Name: "k8s_cluster", Value: "c2"}, {Name: "pod", Value: "r2"}},
+			expectedCluster:      "c2",
+			expectedReplica:      "r2",
+			expectedReplicaLabel: "pod",
+		},
+		"should prefer the first pair when both match": {
+			labels:               []cortexpb.LabelAdapter{{Name: "cluster", Value: "c1"}, {Name: "__replica__", Value: "r1"}, {Name: "k8s_cluster", Value: "c2"}, {Name: "pod", Value: "r2"}},
+			expectedCluster:      "c1",
+			expectedReplica:      "r1",
+			expectedReplicaLabel: "__replica__",
+		},
+		"should skip a partially matching pair": {
+			labels:               []cortexpb.LabelAdapter{{Name: "cluster", Value: "c1"}, {Name: "k8s_cluster", Value: "c2"}, {Name: "pod", Value: "r2"}},
+			expectedCluster:      "c2",
+			expectedReplica:      "r2",
+			expectedReplicaLabel: "pod",
+		},
+		"should return the labels of the first pair when none matches": {
+			labels:               []cortexpb.LabelAdapter{{Name: "cluster", Value: "c1"}, {Name: "pod", Value: "r2"}},
+			expectedCluster:      "c1",
+			expectedReplica:      "",
+			expectedReplicaLabel: "__replica__",
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			cluster, replica, replicaLabel := findHALabelPair(pairs, testData.labels)
+			assert.Equal(t, testData.expectedCluster, cluster)
+			assert.Equal(t, testData.expectedReplica, replica)
+			assert.Equal(t, testData.expectedReplicaLabel, replicaLabel)
+		})
+	}
+}
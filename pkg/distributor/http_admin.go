@@ -31,6 +31,8 @@ const tpl = `
 						<th>User</th>
 						<th># Series</th>
 						<th># Active Series</th>
+						<th># Active Native Histogram Series</th>
+						<th># Active Native Histogram Buckets</th>
 						<th>Total Ingest Rate</th>
 						<th>API Ingest Rate</th>
 						<th>Rule Ingest Rate</th>
@@ -42,6 +44,8 @@ const tpl = `
 						<td>{{ .UserID }}</td>
 						<td align='right'>{{ .UserStats.NumSeries }}</td>
 						<td align='right'>{{ .UserStats.ActiveSeries }}</td>
+						<td align='right'>{{ .UserStats.ActiveNativeHistogramSeries }}</td>
+						<td align='right'>{{ .UserStats.ActiveNativeHistogramBuckets }}</td>
 						<td align='right'>{{ printf "%.2f" .UserStats.IngestionRate }}</td>
 						<td align='right'>{{ printf "%.2f" .UserStats.APIIngestionRate }}</td>
 						<td align='right'>{{ printf "%.2f" .UserStats.RuleIngestionRate }}</td>
@@ -1,8 +1,12 @@
 package distributor
 
 import (
+	"fmt"
 	"net/http"
+	"time"
 
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 )
 
@@ -13,6 +17,22 @@ type UserStats struct {
 	APIIngestionRate  float64 `json:"APIIngestionRate"`
 	RuleIngestionRate float64 `json:"RuleIngestionRate"`
 	ActiveSeries      uint64  `json:"activeSeries"`
+
+	ActiveNativeHistogramSeries        uint64  `json:"activeNativeHistogramSeries"`
+	ActiveNativeHistogramBuckets       uint64  `json:"activeNativeHistogramBuckets"`
+	ActiveNativeHistogramAverageSchema float64 `json:"activeNativeHistogramAverageSchema"`
+}
+
+// addNativeHistogramStats adds the active native histogram stats of an ingester, keeping the average
+// schema weighted by the number of series.
+func (s *UserStats) addNativeHistogramStats(r *ingester_client.UserStatsResponse) {
+	series := s.ActiveNativeHistogramSeries + r.ActiveNativeHistogramSeries
+	if series > 0 {
+		s.ActiveNativeHistogramAverageSchema = (s.ActiveNativeHistogramAverageSchema*float64(s.ActiveNativeHistogramSeries) +
+			r.ActiveNativeHistogramAverageSchema*float64(r.ActiveNativeHistogramSeries)) / float64(series)
+	}
+	s.ActiveNativeHistogramSeries = series
+	s.ActiveNativeHistogramBuckets += r.ActiveNativeHistogramBuckets
 }
 
 // UserStatsHandler handles user stats to the Distributor.
@@ -25,3 +45,25 @@ func (d *Distributor) UserStatsHandler(w http.ResponseWriter, r *http.Request) {
 
 	util.WriteJSONResponse(w, stats)
 }
+
+// RequestRateLimitMiddleware rejects the push requests of the tenants exceeding their request rate
+// limit, before the request body is read and decoded.
+func (d *Distributor) RequestRateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The requests without a valid tenant are rejected by the push handler.
+		userID, err := tenant.TenantID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		if !d.requestRateLimiter.AllowN(now, userID, 1) {
+			d.rateLimitedPushRequests.WithLabelValues(userID).Inc()
+			http.Error(w, fmt.Sprintf("request rate limit (%v) exceeded", d.requestRateLimiter.Limit(now, userID)), http.StatusTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,92 @@
+package distributor
+
+import (
+	"context"
+	"flag"
+
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/push"
+)
+
+var (
+	errInvalidIdempotencyKeysValidity = errors.New("the idempotency keys validity must be greater than 0 when the push requests deduplication is enabled")
+	errIdempotencyKeysCacheMissing    = errors.New("a cache backend must be configured to store the idempotency keys when the push requests deduplication is enabled")
+)
+
+// IdempotencyConfig configures the deduplication of the push requests retries.
+type IdempotencyConfig struct {
+	Enabled bool         `yaml:"enabled"`
+	Cache   cache.Config `yaml:"cache"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *IdempotencyConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.idempotency.enabled", false, "[Experimental] True to deduplicate the retries of the push requests carrying the same "+push.IdempotencyKeyHeader+" header: once a request has been pushed successfully, its key is kept in the cache for -distributor.idempotency.default-validity, and the requests of the tenant with the same key are acknowledged without being pushed again. Use a shared cache, such as memcached or redis, to deduplicate the retries sent to another distributor.")
+	cfg.Cache.RegisterFlagsWithPrefix("distributor.idempotency.", "", f)
+}
+
+// Validate the config.
+func (cfg *IdempotencyConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Cache.DefaultValidity <= 0 {
+		return errInvalidIdempotencyKeysValidity
+	}
+	if !cfg.hasCacheBackend() {
+		return errIdempotencyKeysCacheMissing
+	}
+	return cfg.Cache.Validate()
+}
+
+// hasCacheBackend returns whether any cache backend is configured. A backend may still end up
+// not being created, eg. a FIFO cache without size, which is checked once the cache is created.
+func (cfg *IdempotencyConfig) hasCacheBackend() bool {
+	return cfg.Cache.Cache != nil ||
+		cfg.Cache.EnableFifoCache ||
+		cfg.Cache.MemcacheClient.Host != "" ||
+		cfg.Cache.MemcacheClient.Addresses != "" ||
+		cfg.Cache.Redis.Endpoint != ""
+}
+
+// pushIdempotencyMiddleware acknowledges the retries of the push requests which have already been
+// pushed successfully, identified by their idempotency key, without pushing them again.
+func (d *Distributor) pushIdempotencyMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		key := push.IdempotencyKeyFromContext(ctx)
+		if key == "" {
+			return next(ctx, req)
+		}
+
+		state := pushStateFromContext(ctx)
+		cacheKey := cache.HashKey(state.userID + ":" + key)
+		if _, bufs, _ := d.idempotencyKeys.Fetch(ctx, []string{cacheKey}); len(bufs) > 0 {
+			// Ensure the request slice is reused if the request is deduplicated.
+			cortexpb.ReuseSlice(req.Timeseries)
+
+			d.dedupedPushRequests.WithLabelValues(state.userID).Inc()
+
+			// The retry reports the samples accepted by the first push. The entries which can't be
+			// decoded report nothing written.
+			resp := &cortexpb.WriteResponse{}
+			if err := resp.Unmarshal(bufs[0]); err != nil {
+				resp.Reset()
+			}
+			return resp, nil
+		}
+
+		resp, err := next(ctx, req)
+		if err == nil {
+			// The accepted samples are kept along with the key, for the retries to report them.
+			buf := []byte{}
+			if resp != nil {
+				buf, _ = resp.Marshal()
+			}
+			d.idempotencyKeys.Store(ctx, []string{cacheKey}, [][]byte{buf})
+		}
+		return resp, err
+	}
+}
@@ -1,6 +1,8 @@
 package distributor
 
 import (
+	"math"
+
 	"golang.org/x/time/rate"
 
 	"github.com/cortexproject/cortex/pkg/util/limiter"
@@ -72,3 +74,46 @@ func (s *infiniteStrategy) Burst(tenantID string) int {
 	// Burst is ignored when limit = rate.Inf
 	return 0
 }
+
+// requestRateStrategy is the strategy of the push request rate limit. It's applied like the
+// ingestion rate limit: individually to each distributor if the ring is nil, or evenly shared
+// across the distributors otherwise.
+type requestRateStrategy struct {
+	limits *validation.Overrides
+	ring   ReadLifecycler
+}
+
+func newLocalRequestRateStrategy(limits *validation.Overrides) limiter.RateLimiterStrategy {
+	return &requestRateStrategy{
+		limits: limits,
+	}
+}
+
+func newGlobalRequestRateStrategy(limits *validation.Overrides, ring ReadLifecycler) limiter.RateLimiterStrategy {
+	return &requestRateStrategy{
+		limits: limits,
+		ring:   ring,
+	}
+}
+
+func (s *requestRateStrategy) Limit(tenantID string) float64 {
+	limit := s.limits.RequestRate(tenantID)
+	if limit <= 0 {
+		return float64(rate.Inf)
+	}
+
+	if s.ring != nil {
+		if numDistributors := s.ring.HealthyInstancesCount(); numDistributors > 0 {
+			return limit / float64(numDistributors)
+		}
+	}
+	return limit
+}
+
+func (s *requestRateStrategy) Burst(tenantID string) int {
+	// As for the ingestion rate, the meaning of burst doesn't change for the global strategy.
+	if burst := s.limits.RequestBurstSize(tenantID); burst > 0 {
+		return burst
+	}
+	return int(math.Ceil(s.limits.RequestRate(tenantID)))
+}
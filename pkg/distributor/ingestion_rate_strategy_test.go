@@ -85,6 +85,77 @@ func TestIngestionRateStrategy(t *testing.T) {
 	}
 }
 
+func TestRequestRateStrategy(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		limits        validation.Limits
+		ring          ReadLifecycler
+		expectedLimit float64
+		expectedBurst int
+	}{
+		"local rate limiter should just return configured limits": {
+			limits: validation.Limits{
+				IngestionRateStrategy: validation.LocalIngestionRateStrategy,
+				RequestRate:           float64(10),
+				RequestBurstSize:      100,
+			},
+			expectedLimit: float64(10),
+			expectedBurst: 100,
+		},
+		"global rate limiter should share the limit across the number of distributors": {
+			limits: validation.Limits{
+				IngestionRateStrategy: validation.GlobalIngestionRateStrategy,
+				RequestRate:           float64(10),
+				RequestBurstSize:      100,
+			},
+			ring: func() ReadLifecycler {
+				ring := newReadLifecyclerMock()
+				ring.On("HealthyInstancesCount").Return(2)
+				return ring
+			}(),
+			expectedLimit: float64(5),
+			expectedBurst: 100,
+		},
+		"rate limiter should default the burst to the rate limit rounded up": {
+			limits: validation.Limits{
+				IngestionRateStrategy: validation.LocalIngestionRateStrategy,
+				RequestRate:           float64(2.5),
+			},
+			expectedLimit: float64(2.5),
+			expectedBurst: 3,
+		},
+		"rate limiter should return unlimited settings if the limit is disabled": {
+			limits: validation.Limits{
+				IngestionRateStrategy: validation.LocalIngestionRateStrategy,
+				RequestRate:           0,
+			},
+			expectedLimit: float64(rate.Inf),
+			expectedBurst: 0,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			overrides, err := validation.NewOverrides(testData.limits, nil)
+			require.NoError(t, err)
+
+			var strategy limiter.RateLimiterStrategy
+			if testData.ring != nil {
+				strategy = newGlobalRequestRateStrategy(overrides, testData.ring)
+			} else {
+				strategy = newLocalRequestRateStrategy(overrides)
+			}
+
+			assert.Equal(t, testData.expectedLimit, strategy.Limit("test"))
+			assert.Equal(t, testData.expectedBurst, strategy.Burst("test"))
+		})
+	}
+}
+
 type readLifecyclerMock struct {
 	mock.Mock
 }
@@ -0,0 +1,75 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-kit/log/level"
+
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// PrewarmTenantResponse is the response of the tenant pre-warm API.
+type PrewarmTenantResponse struct {
+	// Number of ingesters of the tenant shard which have been pre-warmed.
+	Ingesters int `json:"ingesters"`
+	// Number of ingesters which didn't have the TSDB of the tenant yet.
+	CreatedTSDBs int `json:"created_tsdbs"`
+}
+
+// PrewarmTenant pre-warms the write path of the user ahead of a known migration cutover, so
+// that the first minutes of heavy ingestion don't pay the shard discovery and TSDB creation
+// costs. It resolves the tenant shard, which gets cached by the ring, connects to the ingesters
+// of the shard and creates the TSDB of the user on each of them.
+func (d *Distributor) PrewarmTenant(ctx context.Context) (*PrewarmTenantResponse, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	replicationSet, err := d.writeSubRing(userID, d.limits.GetOverridesForUser(userID)).GetAllHealthy(ring.Write)
+	if err != nil {
+		return nil, err
+	}
+
+	// Make sure all the ingesters of the shard get pre-warmed.
+	replicationSet.MaxErrors = 0
+	replicationSet.MaxUnavailableZones = 0
+
+	req := &ingester_client.PrewarmTenantRequest{}
+	resps, err := d.ForReplicationSet(ctx, replicationSet, false, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return client.PrewarmTenant(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PrewarmTenantResponse{Ingesters: len(resps)}
+	for _, resp := range resps {
+		if resp.(*ingester_client.PrewarmTenantResponse).Created {
+			result.CreatedTSDBs++
+		}
+	}
+
+	level.Info(d.log).Log("msg", "pre-warmed the tenant", "user", userID, "ingesters", result.Ingesters, "created_tsdbs", result.CreatedTSDBs)
+	return result, nil
+}
+
+// PrewarmTenantHandler serves the tenant pre-warm API.
+func (d *Distributor) PrewarmTenantHandler(w http.ResponseWriter, r *http.Request) {
+	if _, err := tenant.TenantID(r.Context()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := d.PrewarmTenant(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	util.WriteJSONResponse(w, resp)
+}
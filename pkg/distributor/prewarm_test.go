@@ -0,0 +1,92 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+)
+
+func TestDistributor_PrewarmTenant(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		shuffleShardSize     int
+		happyIngesters       int
+		expectedIngesters    int
+		expectedCreatedTSDBs int
+		expectedErr          bool
+	}{
+		"should pre-warm all the ingesters": {
+			happyIngesters:       5,
+			expectedIngesters:    5,
+			expectedCreatedTSDBs: 5,
+		},
+		"should only pre-warm the ingesters of the tenant shard": {
+			shuffleShardSize:     3,
+			happyIngesters:       5,
+			expectedIngesters:    3,
+			expectedCreatedTSDBs: 3,
+		},
+		"should fail if an ingester fails to be pre-warmed": {
+			happyIngesters: 4,
+			expectedErr:    true,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:        5,
+				happyIngesters:      testData.happyIngesters,
+				numDistributors:     1,
+				shardByAllLabels:    true,
+				shuffleShardEnabled: testData.shuffleShardSize > 0,
+				shuffleShardSize:    testData.shuffleShardSize,
+				replicationFactor:   3,
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			resp, err := ds[0].PrewarmTenant(ctx)
+			if testData.expectedErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &PrewarmTenantResponse{Ingesters: testData.expectedIngesters, CreatedTSDBs: testData.expectedCreatedTSDBs}, resp)
+			assert.Equal(t, testData.expectedIngesters, countMockIngestersCalls(ingesters, "PrewarmTenant"))
+		})
+	}
+}
+
+func TestDistributor_PrewarmTenantHandler(t *testing.T) {
+	t.Parallel()
+
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		shardByAllLabels:  true,
+		replicationFactor: 3,
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/distributor/prewarm_tenant", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "test"))
+	rec := httptest.NewRecorder()
+	ds[0].PrewarmTenantHandler(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"ingesters":3,"created_tsdbs":3}`, rec.Body.String())
+
+	// The request should fail without a tenant.
+	rec = httptest.NewRecorder()
+	ds[0].PrewarmTenantHandler(rec, httptest.NewRequest(http.MethodPost, "/distributor/prewarm_tenant", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
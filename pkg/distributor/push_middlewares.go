@@ -0,0 +1,351 @@
+package distributor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/opentracing/opentracing-go"
+	"github.com/prometheus/prometheus/model/relabel"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/ha"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// PushFunc pushes a write request of the tenant in the context.
+type PushFunc func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+
+// PushMiddleware wraps a PushFunc with a stage of the push path. A stage can modify the request,
+// reject it by returning an error without calling the next PushFunc, or act on the response.
+//
+// The push path is made of the following stages:
+//  1. authentication of the tenant, instance limits and accounting of the incoming samples;
+//  2. deduplication of the retries of the requests carrying an idempotency key, if enabled;
+//  3. the custom stages configured in Config.PushMiddlewares, in order;
+//  4. HA deduplication;
+//  5. relabelling and removal of the dropped labels;
+//  6. validation;
+//  7. forwarding to the ingesters, subject to the tenant ingestion rate limit, and buffering of the
+//     pushes failed because a quorum of ingesters is unavailable, if enabled.
+//
+// The response reports the number of samples and exemplars accepted, that is forwarded to the ingesters.
+type PushMiddleware func(next PushFunc) PushFunc
+
+type pushStateContextKey int
+
+const pushStateKey pushStateContextKey = 0
+
+// pushState holds the state of a push request shared between the built-in stages.
+type pushState struct {
+	userID string
+	now    time.Time
+
+	// Cache user limit with overrides so we spend less CPU doing locking. See issue #4904
+	limits *validation.Limits
+
+	// Set by the HA deduplication stage.
+	removeReplica  bool
+	haReplicaLabel string
+
+	// Set by the validation stage.
+	seriesKeys                []uint32
+	metadataKeys              []uint32
+	validatedTimeseries       []cortexpb.PreallocTimeseries
+	validatedMetadata         []*cortexpb.MetricMetadata
+	validatedFloatSamples     int
+	validatedHistogramSamples int
+	validatedExemplars        int
+}
+
+func pushStateFromContext(ctx context.Context) *pushState {
+	return ctx.Value(pushStateKey).(*pushState)
+}
+
+// newPushChain returns the push path made of the built-in and custom stages.
+func (d *Distributor) newPushChain() PushFunc {
+	middlewares := make([]PushMiddleware, 0, len(d.cfg.PushMiddlewares)+5)
+	middlewares = append(middlewares, d.pushAuthMiddleware)
+	if d.idempotencyKeys != nil {
+		middlewares = append(middlewares, d.pushIdempotencyMiddleware)
+	}
+	middlewares = append(middlewares, d.cfg.PushMiddlewares...)
+	middlewares = append(middlewares, d.pushHADedupeMiddleware, d.pushRelabelMiddleware, d.pushValidationMiddleware)
+
+	push := d.pushToIngesters
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		push = middlewares[i](push)
+	}
+	return push
+}
+
+// pushAuthMiddleware authenticates the tenant, accounts the incoming samples and enforces the instance limits.
+func (d *Distributor) pushAuthMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		span, ctx := opentracing.StartSpanFromContext(ctx, "Distributor.Push")
+		defer span.Finish()
+
+		// We will report *this* request in the error too.
+		inflight := d.inflightPushRequests.Inc()
+		defer d.inflightPushRequests.Dec()
+
+		now := time.Now()
+		d.activeUsers.UpdateUserTimestamp(userID, now)
+
+		numFloatSamples, numHistogramSamples, numExemplars := countSamples(req)
+		// Count the total samples, exemplars in, prior to validation or deduplication, for comparison with other metrics.
+		d.incomingSamples.WithLabelValues(userID, sampleMetricTypeFloat).Add(float64(numFloatSamples))
+		d.incomingSamples.WithLabelValues(userID, sampleMetricTypeHistogram).Add(float64(numHistogramSamples))
+		d.incomingExemplars.WithLabelValues(userID).Add(float64(numExemplars))
+		// Count the total number of metadata in.
+		d.incomingMetadata.WithLabelValues(userID).Add(float64(len(req.Metadata)))
+
+		if d.cfg.InstanceLimits.MaxInflightPushRequests > 0 && inflight > int64(d.cfg.InstanceLimits.MaxInflightPushRequests) {
+			return nil, errTooManyInflightPushRequests
+		}
+
+		if d.cfg.InstanceLimits.MaxIngestionRate > 0 {
+			if rate := d.ingestionRate.Rate(); rate >= d.cfg.InstanceLimits.MaxIngestionRate {
+				return nil, errMaxSamplesPushRateLimitReached
+			}
+		}
+
+		if err := d.enforcePushTokenScopes(ctx, userID, req, numFloatSamples+numHistogramSamples, numExemplars); err != nil {
+			return nil, err
+		}
+
+		state := &pushState{
+			userID: userID,
+			now:    now,
+			limits: d.limits.GetOverridesForUser(userID),
+		}
+		return next(context.WithValue(ctx, pushStateKey, state), req)
+	}
+}
+
+// pushHADedupeMiddleware drops the requests of the non elected replicas of HA Prometheus pairs.
+func (d *Distributor) pushHADedupeMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		state := pushStateFromContext(ctx)
+		if !state.limits.AcceptHASamples || len(req.Timeseries) == 0 {
+			return next(ctx, req)
+		}
+
+		numFloatSamples, numHistogramSamples, _ := countSamples(req)
+		cluster, replica, replicaLabel := findHALabelPair(state.limits.HALabels(), req.Timeseries[0].Labels)
+		removeReplica, err := d.checkSample(ctx, state.userID, cluster, replica, state.limits)
+		if err != nil {
+			// Ensure the request slice is reused if the series get deduped.
+			cortexpb.ReuseSlice(req.Timeseries)
+
+			if errors.Is(err, ha.ReplicasNotMatchError{}) {
+				// These samples have been deduped.
+				d.dedupedSamples.WithLabelValues(state.userID, cluster).Add(float64(numFloatSamples + numHistogramSamples))
+				return nil, httpgrpc.Errorf(http.StatusAccepted, err.Error())
+			}
+
+			if errors.Is(err, ha.TooManyReplicaGroupsError{}) {
+				d.validateMetrics.DiscardedSamples.WithLabelValues(validation.TooManyHAClusters, state.userID).Add(float64(numFloatSamples + numHistogramSamples))
+				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+			}
+
+			return nil, err
+		}
+		// If there wasn't an error but removeReplica is false that means we didn't find both HA labels.
+		if !removeReplica {
+			d.nonHASamples.WithLabelValues(state.userID).Add(float64(numFloatSamples + numHistogramSamples))
+		}
+
+		state.removeReplica = removeReplica
+		state.haReplicaLabel = replicaLabel
+		return next(ctx, req)
+	}
+}
+
+// pushRelabelMiddleware applies the tenant relabel configs and removes the HA replica and dropped
+// labels. The series left without labels are removed from the request.
+func (d *Distributor) pushRelabelMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		state := pushStateFromContext(ctx)
+		userID, limits := state.userID, state.limits
+
+		latestSampleTimestampMs := int64(0)
+		kept := req.Timeseries[:0]
+		for _, ts := range req.Timeseries {
+			// Use timestamp of latest sample in the series. If samples for series are not ordered, metric for user may be wrong.
+			if len(ts.Samples) > 0 {
+				latestSampleTimestampMs = max(latestSampleTimestampMs, ts.Samples[len(ts.Samples)-1].TimestampMs)
+			}
+			if len(ts.Histograms) > 0 {
+				latestSampleTimestampMs = max(latestSampleTimestampMs, ts.Histograms[len(ts.Histograms)-1].TimestampMs)
+			}
+
+			if mrc := limits.MetricRelabelConfigs; len(mrc) > 0 {
+				l, _ := relabel.Process(cortexpb.FromLabelAdaptersToLabels(ts.Labels), mrc...)
+				if len(l) == 0 {
+					// all labels are gone, samples will be discarded
+					d.discardSeries(validation.DroppedByRelabelConfiguration, userID, ts)
+					continue
+				}
+				ts.Labels = cortexpb.FromLabelsToLabelAdapters(l)
+			}
+
+			// If we found both the cluster and replica labels, we only want to include the cluster label when
+			// storing series in Cortex. If we kept the replica label we would end up with another series for the same
+			// series we're trying to dedupe when HA tracking moves over to a different replica.
+			if state.removeReplica {
+				removeLabel(state.haReplicaLabel, &ts.Labels)
+			}
+
+			for _, labelName := range limits.DropLabels {
+				removeLabel(labelName, &ts.Labels)
+			}
+
+			if len(ts.Labels) == 0 {
+				d.discardSeries(validation.DroppedByUserConfigurationOverride, userID, ts)
+				continue
+			}
+
+			kept = append(kept, ts)
+		}
+		req.Timeseries = kept
+
+		if latestSampleTimestampMs > 0 {
+			d.latestSeenSampleTimestampPerUser.WithLabelValues(userID).Set(float64(latestSampleTimestampMs) / 1000)
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// discardSeries accounts the samples and exemplars of the series as discarded for the input reason,
+// and releases it.
+func (d *Distributor) discardSeries(reason, userID string, ts cortexpb.PreallocTimeseries) {
+	d.validateMetrics.DiscardedSamples.WithLabelValues(reason, userID).Add(float64(len(ts.Samples) + len(ts.Histograms)))
+	if len(ts.Exemplars) > 0 {
+		d.validateMetrics.DiscardedExemplars.WithLabelValues(reason, userID).Add(float64(len(ts.Exemplars)))
+	}
+	cortexpb.ReuseTimeseries(ts.TimeSeries)
+}
+
+// pushValidationMiddleware validates the series and metadata, and computes their sharding tokens.
+// Invalid series and metadata are dropped, and the first validation error is returned once the
+// valid ones have been pushed.
+func (d *Distributor) pushValidationMiddleware(next PushFunc) PushFunc {
+	return func(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		state := pushStateFromContext(ctx)
+		userID := state.userID
+
+		// A WriteRequest can only contain series or metadata but not both. This might change in the future.
+		seriesKeys, validatedTimeseries, validatedFloatSamples, validatedHistogramSamples, validatedExemplars, firstPartialErr, err := d.prepareSeriesKeys(ctx, req, userID, state.limits)
+		if err != nil {
+			return nil, err
+		}
+		metadataKeys, validatedMetadata, firstPartialErr := d.prepareMetadataKeys(req, state.limits, userID, firstPartialErr)
+
+		d.receivedSamples.WithLabelValues(userID, sampleMetricTypeFloat).Add(float64(validatedFloatSamples))
+		d.receivedSamples.WithLabelValues(userID, sampleMetricTypeHistogram).Add(float64(validatedHistogramSamples))
+		d.receivedExemplars.WithLabelValues(userID).Add(float64(validatedExemplars))
+		d.receivedMetadata.WithLabelValues(userID).Add(float64(len(validatedMetadata)))
+
+		if len(seriesKeys) == 0 && len(metadataKeys) == 0 {
+			// Ensure the request slice is reused if there's no series or metadata passing the validation.
+			cortexpb.ReuseSlice(req.Timeseries)
+
+			return &cortexpb.WriteResponse{}, firstPartialErr
+		}
+
+		state.seriesKeys, state.validatedTimeseries = seriesKeys, validatedTimeseries
+		state.metadataKeys, state.validatedMetadata = metadataKeys, validatedMetadata
+		state.validatedFloatSamples, state.validatedHistogramSamples, state.validatedExemplars = validatedFloatSamples, validatedHistogramSamples, validatedExemplars
+
+		resp, err := next(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return resp, firstPartialErr
+	}
+}
+
+// pushToIngesters forwards the validated series and metadata to the ingesters.
+func (d *Distributor) pushToIngesters(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	state := pushStateFromContext(ctx)
+	userID, now := state.userID, state.now
+
+	totalSamples := state.validatedFloatSamples + state.validatedHistogramSamples
+	totalN := totalSamples + state.validatedExemplars + len(state.validatedMetadata)
+	if !d.ingestionRateLimiter.AllowN(now, userID, totalN) {
+		// Ensure the request slice is reused if the request is rate limited.
+		cortexpb.ReuseSlice(req.Timeseries)
+
+		d.validateMetrics.DiscardedSamples.WithLabelValues(validation.RateLimited, userID).Add(float64(totalSamples))
+		d.validateMetrics.DiscardedExemplars.WithLabelValues(validation.RateLimited, userID).Add(float64(state.validatedExemplars))
+		d.validateMetrics.DiscardedMetadata.WithLabelValues(validation.RateLimited, userID).Add(float64(len(state.validatedMetadata)))
+		// Return a 429 here to tell the client it is going too fast.
+		// Client may discard the data or slow down and re-send.
+		// Prometheus v2.26 added a remote-write option 'retry_on_http_429'.
+		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, "ingestion rate limit (%v) exceeded while adding %d samples and %d metadata", d.ingestionRateLimiter.Limit(now, userID), totalSamples, len(state.validatedMetadata))
+	}
+
+	// totalN included samples and metadata. Ingester follows this pattern when computing its ingestion rate.
+	d.ingestionRate.Add(int64(totalN))
+
+	subRing := d.writeSubRing(userID, state.limits)
+
+	keys := append(state.seriesKeys, state.metadataKeys...)
+	initialMetadataIndex := len(state.seriesKeys)
+
+	// The pushes are only buffered for the tenants accepting out-of-order samples, since the
+	// buffered samples are replayed after the newer ones.
+	var tracker *pushTracker
+	if d.spillBuffer != nil && state.limits.OutOfOrderTimeWindow > 0 {
+		// The series must not be released before they're buffered, if the push fails.
+		tracker = newPushTracker()
+		defer close(tracker.releaseAfter)
+	}
+
+	if err := d.doBatch(ctx, req, subRing, keys, initialMetadataIndex, state.validatedMetadata, state.validatedTimeseries, userID, tracker); err != nil {
+		if tracker == nil || !isSpillableError(err) {
+			return nil, err
+		}
+
+		// The push fails as soon as a quorum can't be reached for a series, while the sends to the
+		// other ingesters may still be in flight. The push is only buffered if it failed on all the
+		// ingesters, otherwise the error is returned for the client to retry it.
+		<-tracker.done
+		if tracker.succeeded.Load() {
+			return nil, err
+		}
+
+		spilled := &cortexpb.WriteRequest{Timeseries: state.validatedTimeseries, Metadata: state.validatedMetadata, Source: req.Source}
+		if spillErr := d.spillBuffer.spill(userID, spilled); spillErr != nil {
+			level.Warn(d.log).Log("msg", "failed to buffer the push failed on the ingesters", "user", userID, "err", spillErr)
+			return nil, err
+		}
+	}
+
+	return &cortexpb.WriteResponse{
+		SamplesWritten:    int64(state.validatedFloatSamples),
+		HistogramsWritten: int64(state.validatedHistogramSamples),
+		ExemplarsWritten:  int64(state.validatedExemplars),
+	}, nil
+}
+
+// countSamples returns the number of float samples, histogram samples and exemplars in the request.
+func countSamples(req *cortexpb.WriteRequest) (numFloatSamples, numHistogramSamples, numExemplars int) {
+	for _, ts := range req.Timeseries {
+		numFloatSamples += len(ts.Samples)
+		numHistogramSamples += len(ts.Histograms)
+		numExemplars += len(ts.Exemplars)
+	}
+	return
+}
@@ -0,0 +1,272 @@
+package distributor
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-kit/log/level"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/distributor/distributorpb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+type pushTokenContextKey int
+
+const pushTokenKey pushTokenContextKey = 0
+
+// PushTokenMiddleware authenticates the push requests of the tenants having push tokens configured,
+// before the request body is read and decoded. The token of the request is injected in the context,
+// so that the push path enforces its scopes.
+func (d *Distributor) PushTokenMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The requests without a valid tenant are rejected by the push handler.
+		userID, err := tenant.TenantID(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		tokens := d.pushTokens(userID)
+		if len(tokens) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, ok := findPushToken(tokens, pushTokenFromRequest(r))
+		if !ok {
+			d.rejectedPushTokenRequests.WithLabelValues(userID).Inc()
+			http.Error(w, "missing or invalid push token", http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), pushTokenKey, token)))
+	})
+}
+
+// PushTokenGRPCServer returns the distributor gRPC server, rejecting the pushes of the tenants having push
+// tokens configured, since the push tokens are only carried by the requests to the HTTP push endpoints.
+func (d *Distributor) PushTokenGRPCServer() distributorpb.DistributorServer {
+	return pushTokenGRPCServer{d}
+}
+
+type pushTokenGRPCServer struct {
+	*Distributor
+}
+
+// Push implements distributorpb.DistributorServer.
+func (s pushTokenGRPCServer) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	// The requests without a valid tenant are rejected by the push.
+	if userID, err := tenant.TenantID(ctx); err == nil && len(s.pushTokens(userID)) > 0 {
+		s.rejectedPushTokenRequests.WithLabelValues(userID).Inc()
+		return nil, httpgrpc.Errorf(http.StatusUnauthorized, "the tenant has push tokens configured, so its push requests must be sent to the HTTP push endpoints with a push token")
+	}
+	return s.Distributor.Push(ctx, req)
+}
+
+const (
+	pushTokenSourceRuntimeConfig = "runtime_config"
+	pushTokenSourceAPI           = "api"
+)
+
+// pushTokens returns the push tokens of the tenant, both the configured ones and the ones created
+// through the API.
+func (d *Distributor) pushTokens(userID string) []validation.PushToken {
+	tokens := d.limits.PushTokens(userID)
+	if d.pushTokenStore == nil {
+		return tokens
+	}
+
+	created := d.pushTokenStore.get(userID)
+	if len(created) == 0 {
+		return tokens
+	}
+	return append(append(make([]validation.PushToken, 0, len(tokens)+len(created)), tokens...), created...)
+}
+
+// pushTokenAdminUserID returns the tenant whose push tokens are managed by the request to the
+// administrative API, or writes an error if the request is invalid. The requests authenticated
+// with a push token are refused, so that a push token can't be used to manage the push tokens.
+func (d *Distributor) pushTokenAdminUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return "", false
+	}
+
+	if _, ok := findPushToken(d.pushTokens(userID), pushTokenFromRequest(r)); ok {
+		http.Error(w, "the push tokens can't be managed with a push token", http.StatusForbidden)
+		return "", false
+	}
+	return userID, true
+}
+
+// PushTokensHandler returns the names and scopes of the push tokens of the tenant.
+func (d *Distributor) PushTokensHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := d.pushTokenAdminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	type pushToken struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		Source string   `json:"source"`
+	}
+	res := []pushToken{}
+	for _, t := range d.limits.PushTokens(userID) {
+		res = append(res, pushToken{Name: t.Name, Scopes: t.Scopes, Source: pushTokenSourceRuntimeConfig})
+	}
+	if d.pushTokenStore != nil {
+		for _, t := range d.pushTokenStore.get(userID) {
+			res = append(res, pushToken{Name: t.Name, Scopes: t.Scopes, Source: pushTokenSourceAPI})
+		}
+	}
+
+	util.WriteJSONResponse(w, res)
+}
+
+// CreatePushTokenHandler creates a push token for the tenant, with the name and scopes of the request
+// body. The token is only returned in the response, only its hash is stored.
+func (d *Distributor) CreatePushTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := d.pushTokenAdminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid push token request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token := hex.EncodeToString(secret)
+	hash := sha256.Sum256([]byte(token))
+
+	created := validation.PushToken{Name: req.Name, TokenSHA256: hex.EncodeToString(hash[:]), Scopes: req.Scopes}
+	if err := d.pushTokenStore.create(r.Context(), userID, created, d.limits.PushTokens(userID)); err != nil {
+		if errors.Is(err, validation.ErrInvalidPushToken) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		level.Error(d.log).Log("msg", "failed to create the push token", "user", userID, "token", req.Name, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(d.log).Log("msg", "push token created", "user", userID, "token", req.Name)
+	util.WriteJSONResponse(w, struct {
+		Name   string   `json:"name"`
+		Scopes []string `json:"scopes"`
+		Token  string   `json:"token"`
+	}{Name: created.Name, Scopes: created.Scopes, Token: token})
+}
+
+// RevokePushTokenHandler revokes the push token of the tenant created through the API with the name
+// of the request path. The configured push tokens can't be revoked.
+func (d *Distributor) RevokePushTokenHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := d.pushTokenAdminUserID(w, r)
+	if !ok {
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	for _, t := range d.limits.PushTokens(userID) {
+		if t.Name == name {
+			http.Error(w, "the push token is set in the runtime configuration and can't be revoked through the API", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := d.pushTokenStore.revoke(r.Context(), userID, name); err != nil {
+		if errors.Is(err, errPushTokenNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		level.Error(d.log).Log("msg", "failed to revoke the push token", "user", userID, "token", name, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(d.log).Log("msg", "push token revoked", "user", userID, "token", name)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// enforcePushTokenScopes rejects the request if it carries series or metadata not allowed by the
+// push token in the context, and drops the exemplars if not allowed. The requests without a push
+// token, eg. pushed by the ruler, aren't restricted.
+func (d *Distributor) enforcePushTokenScopes(ctx context.Context, userID string, req *cortexpb.WriteRequest, numSamples, numExemplars int) error {
+	token, ok := ctx.Value(pushTokenKey).(validation.PushToken)
+	if !ok {
+		return nil
+	}
+
+	if numSamples > 0 && !token.HasScope(validation.PushTokenScopeSeries) {
+		return d.rejectPushTokenScope(userID, token, validation.PushTokenScopeSeries)
+	}
+	if len(req.Metadata) > 0 && !token.HasScope(validation.PushTokenScopeMetadata) {
+		return d.rejectPushTokenScope(userID, token, validation.PushTokenScopeMetadata)
+	}
+	if numExemplars > 0 && !token.HasScope(validation.PushTokenScopeExemplars) {
+		d.validateMetrics.DiscardedExemplars.WithLabelValues(validation.PushTokenScopeMissing, userID).Add(float64(numExemplars))
+		for _, ts := range req.Timeseries {
+			ts.Exemplars = ts.Exemplars[:0]
+		}
+	}
+	return nil
+}
+
+func (d *Distributor) rejectPushTokenScope(userID string, token validation.PushToken, scope string) error {
+	d.rejectedPushTokenRequests.WithLabelValues(userID).Inc()
+	level.Debug(d.log).Log("msg", "push request rejected because the push token misses a scope", "user", userID, "token", token.Name, "scope", scope)
+	return httpgrpc.Errorf(http.StatusForbidden, "the push token %q doesn't allow to push %s", token.Name, scope)
+}
+
+// pushTokenFromRequest returns the bearer token of the request, or an empty string if none.
+func pushTokenFromRequest(r *http.Request) string {
+	const prefix = "Bearer "
+
+	auth := r.Header.Get("Authorization")
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return ""
+	}
+	return auth[len(prefix):]
+}
+
+// findPushToken returns the push token whose hash matches the given token.
+func findPushToken(tokens []validation.PushToken, token string) (validation.PushToken, bool) {
+	if token == "" {
+		return validation.PushToken{}, false
+	}
+
+	hash := sha256.Sum256([]byte(token))
+	for _, t := range tokens {
+		expected, err := hex.DecodeString(t.TokenSHA256)
+		if err != nil {
+			continue
+		}
+		if subtle.ConstantTimeCompare(hash[:], expected) == 1 {
+			return t, true
+		}
+	}
+	return validation.PushToken{}, false
+}
@@ -0,0 +1,212 @@
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+var (
+	errPushTokenNotFound        = errors.New("push token not found")
+	errInvalidPushTokensKVStore = errors.New("memberlist is not supported as the push tokens KV store, since the revoked push tokens must be rejected by all the distributors right away")
+)
+
+// PushTokensConfig configures the push tokens created and revoked through the API.
+type PushTokensConfig struct {
+	APIEnabled bool      `yaml:"api_enabled"`
+	KVStore    kv.Config `yaml:"kvstore" doc:"description=Backend storage to use for the push tokens created through the API, shared by all distributors. Please be aware that memberlist is not supported."`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *PushTokensConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.APIEnabled, "distributor.push-tokens.api-enabled", false, "[Experimental] True to allow to create and revoke the push tokens of the tenants through the POST /distributor/push_tokens and DELETE /distributor/push_tokens/{name} endpoints. The tokens created through the API are stored in the KV store, and accepted along with the push_tokens limit of the tenant, which can't be revoked through the API.")
+	cfg.KVStore.RegisterFlagsWithPrefix("distributor.push-tokens.", "push-tokens/", f)
+}
+
+// Validate the config.
+func (cfg *PushTokensConfig) Validate() error {
+	if !cfg.APIEnabled {
+		return nil
+	}
+	if cfg.KVStore.Store == "memberlist" || (cfg.KVStore.Store == "multi" && (cfg.KVStore.Multi.Primary == "memberlist" || cfg.KVStore.Multi.Secondary == "memberlist")) {
+		return errInvalidPushTokensKVStore
+	}
+	return nil
+}
+
+// pushTokenStore keeps the push tokens created through the API in a KV store, under a key per
+// tenant holding its JSON encoded tokens, and caches them locally by watching the KV store.
+// The key of a tenant is never deleted, the revoked tokens are removed from its value instead,
+// so that the revocations are propagated to the watchers.
+type pushTokenStore struct {
+	services.Service
+
+	kv     kv.Client
+	logger log.Logger
+
+	mtx    sync.RWMutex
+	tokens map[string][]validation.PushToken
+}
+
+func newPushTokenStore(cfg PushTokensConfig, logger log.Logger, reg prometheus.Registerer) (*pushTokenStore, error) {
+	client, err := kv.NewClient(cfg.KVStore, codec.String{}, kv.RegistererWithKVName(reg, "distributor-push-tokens"), logger)
+	if err != nil {
+		return nil, err
+	}
+	return newPushTokenStoreWithClient(client, logger), nil
+}
+
+func newPushTokenStoreWithClient(client kv.Client, logger log.Logger) *pushTokenStore {
+	s := &pushTokenStore{
+		kv:     client,
+		logger: logger,
+		tokens: map[string][]validation.PushToken{},
+	}
+	s.Service = services.NewBasicService(s.starting, s.running, nil)
+	return s
+}
+
+// starting loads the push tokens of all the tenants, so that they're accepted as soon as the
+// distributor is ready.
+func (s *pushTokenStore) starting(ctx context.Context) error {
+	userIDs, err := s.kv.List(ctx, "")
+	if err != nil {
+		return errors.Wrap(err, "failed to list the tenants with push tokens")
+	}
+
+	for _, userID := range userIDs {
+		value, err := s.kv.Get(ctx, userID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read the push tokens of tenant %s", userID)
+		}
+		s.update(userID, value)
+	}
+	return nil
+}
+
+func (s *pushTokenStore) running(ctx context.Context) error {
+	s.kv.WatchPrefix(ctx, "", func(userID string, value interface{}) bool {
+		s.update(userID, value)
+		return true
+	})
+	return nil
+}
+
+// update caches the push tokens of the tenant from the KV store value.
+func (s *pushTokenStore) update(userID string, value interface{}) {
+	tokens, err := decodePushTokens(value)
+	if err != nil {
+		level.Warn(s.logger).Log("msg", "failed to decode the push tokens", "user", userID, "err", err)
+		return
+	}
+	s.set(userID, tokens)
+}
+
+func (s *pushTokenStore) set(userID string, tokens []validation.PushToken) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if len(tokens) == 0 {
+		delete(s.tokens, userID)
+		return
+	}
+	s.tokens[userID] = tokens
+}
+
+// get returns the push tokens of the tenant created through the API.
+func (s *pushTokenStore) get(userID string) []validation.PushToken {
+	s.mtx.RLock()
+	defer s.mtx.RUnlock()
+
+	return s.tokens[userID]
+}
+
+// create adds the push token to the tenant, after checking that it's valid along with the
+// other tokens of the tenant, including the configured ones.
+func (s *pushTokenStore) create(ctx context.Context, userID string, token validation.PushToken, configured []validation.PushToken) error {
+	var tokens []validation.PushToken
+	err := s.kv.CAS(ctx, userID, func(in interface{}) (out interface{}, retry bool, err error) {
+		existing, err := decodePushTokens(in)
+		if err != nil {
+			return nil, false, err
+		}
+
+		all := append(append(append([]validation.PushToken{}, configured...), existing...), token)
+		if err := validation.ValidatePushTokens(all); err != nil {
+			return nil, false, err
+		}
+
+		tokens = append(existing, token)
+		value, err := encodePushTokens(tokens)
+		return value, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Don't wait for the watch to accept the token on this distributor.
+	s.set(userID, tokens)
+	return nil
+}
+
+// revoke removes the push token with the given name from the tenant.
+func (s *pushTokenStore) revoke(ctx context.Context, userID, name string) error {
+	var tokens []validation.PushToken
+	err := s.kv.CAS(ctx, userID, func(in interface{}) (out interface{}, retry bool, err error) {
+		existing, err := decodePushTokens(in)
+		if err != nil {
+			return nil, false, err
+		}
+
+		tokens = make([]validation.PushToken, 0, len(existing))
+		for _, t := range existing {
+			if t.Name != name {
+				tokens = append(tokens, t)
+			}
+		}
+		if len(tokens) == len(existing) {
+			return nil, false, errPushTokenNotFound
+		}
+
+		value, err := encodePushTokens(tokens)
+		return value, true, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Don't wait for the watch to reject the token on this distributor.
+	s.set(userID, tokens)
+	return nil
+}
+
+// decodePushTokens returns the push tokens from the KV store value, which are none if the
+// value is missing.
+func decodePushTokens(value interface{}) ([]validation.PushToken, error) {
+	s, ok := value.(string)
+	if !ok || s == "" {
+		return nil, nil
+	}
+
+	var tokens []validation.PushToken
+	if err := json.Unmarshal([]byte(s), &tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func encodePushTokens(tokens []validation.PushToken) (string, error) {
+	value, err := json.Marshal(tokens)
+	return string(value), err
+}
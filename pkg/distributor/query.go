@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/weaveworks/common/instrument"
@@ -85,6 +86,8 @@ func (d *Distributor) GetIngestersForQuery(ctx context.Context, matchers ...*lab
 		return ring.ReplicationSet{}, err
 	}
 
+	ingestersRing := ring.WithTenantReplicationFactor(d.ingestersRing, d.limits.IngestionReplicationFactor(userID))
+
 	// If shuffle sharding is enabled we should only query ingesters which are
 	// part of the tenant's subring.
 	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
@@ -92,20 +95,110 @@ func (d *Distributor) GetIngestersForQuery(ctx context.Context, matchers ...*lab
 		lookbackPeriod := d.cfg.ShuffleShardingLookbackPeriod
 
 		if shardSize > 0 && lookbackPeriod > 0 {
-			return d.ingestersRing.ShuffleShardWithLookback(userID, shardSize, lookbackPeriod, time.Now()).GetReplicationSetForOperation(ring.Read)
+			return ingestersRing.ShuffleShardWithLookback(userID, shardSize, lookbackPeriod, time.Now()).GetReplicationSetForOperation(ring.Read)
 		}
 	}
 
+	// If the series are sharded by a subset of labels, we can get ingesters by their values if
+	// all of them are matched by equality.
+	if len(d.cfg.ShardByLabels) > 0 {
+		if values, ok := equalityMatchersValues(d.cfg.ShardByLabels, matchers); ok {
+			return d.getIngestersForShardByLabels(ingestersRing, userID, values)
+		}
+
+		return ingestersRing.GetReplicationSetForOperation(ring.Read)
+	}
+
 	// If "shard by all labels" is disabled, we can get ingesters by metricName if exists.
 	if !d.cfg.ShardByAllLabels && len(matchers) > 0 {
 		metricNameMatcher, _, ok := extract.MetricNameMatcherFromMatchers(matchers)
 
 		if ok && metricNameMatcher.Type == labels.MatchEqual {
-			return d.ingestersRing.Get(shardByMetricName(userID, metricNameMatcher.Value), ring.Read, nil, nil, nil)
+			return ingestersRing.Get(shardByMetricName(userID, metricNameMatcher.Value), ring.Read, nil, nil, nil)
 		}
 	}
 
-	return d.ingestersRing.GetReplicationSetForOperation(ring.Read)
+	return ingestersRing.GetReplicationSetForOperation(ring.Read)
+}
+
+// doQueryPreferringZone runs f on the ingesters of the preferred query zone and of the other zones
+// needed to reach the quorum of zones, if any and the replication set is zone-aware, and falls back to
+// the ingesters of all the zones if any of them fails. A zone alone doesn't hold all the series, since
+// a write succeeds once written to a quorum of zones, but a quorum of zones does.
+func (d *Distributor) doQueryPreferringZone(ctx context.Context, replicationSet ring.ReplicationSet, zoneResultsQuorum bool, f func(context.Context, *ring.InstanceDesc) (interface{}, error)) ([]interface{}, error) {
+	if d.cfg.PreferredQueryZone == "" {
+		return replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, zoneResultsQuorum, f)
+	}
+
+	quorum, ok := replicationSet.ZoneQuorum(d.cfg.PreferredQueryZone)
+	if !ok {
+		d.preferredZoneQueries.WithLabelValues("unavailable").Inc()
+		return replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, zoneResultsQuorum, f)
+	}
+
+	results, err := quorum.Do(ctx, 0, false, f)
+	if err == nil {
+		d.preferredZoneQueries.WithLabelValues("success").Inc()
+		return results, nil
+	}
+
+	// The query isn't retried if it has been canceled or exceeded a limit.
+	var limitErr validation.LimitError
+	if ctx.Err() != nil || errors.As(err, &limitErr) {
+		return nil, err
+	}
+
+	d.preferredZoneQueries.WithLabelValues("fallback").Inc()
+	return replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, zoneResultsQuorum, f)
+}
+
+// getIngestersForShardByLabels returns the replication set including the ingesters of all the shards
+// of the series with the input values of the labels they are sharded by. The failures tolerated are
+// the ones tolerated by every shard replication set.
+func (d *Distributor) getIngestersForShardByLabels(ingestersRing ring.ReadRing, userID string, values map[string]string) (ring.ReplicationSet, error) {
+	value := func(name string) string { return values[name] }
+
+	var result ring.ReplicationSet
+	seen := map[string]struct{}{}
+	for shard := 0; shard < max(1, d.cfg.ShardByLabelsShardCount); shard++ {
+		rs, err := ingestersRing.Get(shardByLabels(userID, d.cfg.ShardByLabels, value, uint32(shard)), ring.Read, nil, nil, nil)
+		if err != nil {
+			return ring.ReplicationSet{}, err
+		}
+
+		if shard == 0 {
+			result.MaxErrors, result.MaxUnavailableZones = rs.MaxErrors, rs.MaxUnavailableZones
+		} else {
+			result.MaxErrors = min(result.MaxErrors, rs.MaxErrors)
+			result.MaxUnavailableZones = min(result.MaxUnavailableZones, rs.MaxUnavailableZones)
+		}
+
+		for _, instance := range rs.Instances {
+			if _, ok := seen[instance.Addr]; !ok {
+				seen[instance.Addr] = struct{}{}
+				result.Instances = append(result.Instances, instance)
+			}
+		}
+	}
+	return result, nil
+}
+
+// equalityMatchersValues returns the values of the input label names matched by equality matchers,
+// or false if any of them isn't.
+func equalityMatchersValues(names []string, matchers []*labels.Matcher) (map[string]string, bool) {
+	values := make(map[string]string, len(names))
+	for _, m := range matchers {
+		if m.Type == labels.MatchEqual {
+			values[m.Name] = m.Value
+		}
+	}
+
+	for _, name := range names {
+		if _, ok := values[name]; !ok {
+			return nil, false
+		}
+	}
+	return values, true
 }
 
 // GetIngestersForMetadata returns a replication set including all ingesters that should be queried
@@ -116,6 +209,31 @@ func (d *Distributor) GetIngestersForMetadata(ctx context.Context) (ring.Replica
 		return ring.ReplicationSet{}, err
 	}
 
+	return d.ingestersRingForMetadata(userID).GetReplicationSetForOperation(ring.Read)
+}
+
+// GetIngesterIDsForMetadata returns the IDs of the ingesters that should be queried to fetch metadata,
+// which are the ingesters that may hold data of the tenant.
+func (d *Distributor) GetIngesterIDsForMetadata(ctx context.Context) ([]string, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instances, err := d.ingestersRingForMetadata(userID).GetInstanceDescsForOperation(ring.Read)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(instances))
+	for id := range instances {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (d *Distributor) ingestersRingForMetadata(userID string) ring.ReadRing {
+	ingestersRing := ring.WithTenantReplicationFactor(d.ingestersRing, d.limits.IngestionReplicationFactor(userID))
+
 	// If shuffle sharding is enabled we should only query ingesters which are
 	// part of the tenant's subring.
 	if d.cfg.ShardingStrategy == util.ShardingStrategyShuffle {
@@ -123,11 +241,11 @@ func (d *Distributor) GetIngestersForMetadata(ctx context.Context) (ring.Replica
 		lookbackPeriod := d.cfg.ShuffleShardingLookbackPeriod
 
 		if shardSize > 0 && lookbackPeriod > 0 {
-			return d.ingestersRing.ShuffleShardWithLookback(userID, shardSize, lookbackPeriod, time.Now()).GetReplicationSetForOperation(ring.Read)
+			return ingestersRing.ShuffleShardWithLookback(userID, shardSize, lookbackPeriod, time.Now())
 		}
 	}
 
-	return d.ingestersRing.GetReplicationSetForOperation(ring.Read)
+	return ingestersRing
 }
 
 // mergeExemplarSets merges and dedupes two sets of already sorted exemplar pairs.
@@ -159,7 +277,7 @@ func mergeExemplarSets(a, b []cortexpb.Exemplar) []cortexpb.Exemplar {
 func (d *Distributor) queryIngestersExemplars(ctx context.Context, replicationSet ring.ReplicationSet, req *ingester_client.ExemplarQueryRequest) (*ingester_client.ExemplarQueryResponse, error) {
 	// Fetch exemplars from multiple ingesters in parallel, using the replicationSet
 	// to deal with consistency.
-	results, err := replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, false, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+	results, err := d.doQueryPreferringZone(ctx, replicationSet, false, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
 			return nil, err
@@ -220,7 +338,7 @@ func (d *Distributor) queryIngesterStream(ctx context.Context, replicationSet ri
 	)
 
 	// Fetch samples from multiple ingesters
-	results, err := replicationSet.Do(ctx, d.cfg.ExtraQueryDelay, false, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
+	results, err := d.doQueryPreferringZone(ctx, replicationSet, false, func(ctx context.Context, ing *ring.InstanceDesc) (interface{}, error) {
 		client, err := d.ingesterPool.GetClientFor(ing.Addr)
 		if err != nil {
 			return nil, err
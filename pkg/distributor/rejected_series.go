@@ -0,0 +1,116 @@
+package distributor
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const (
+	// rejectedSeriesSamplingPeriod is the period after which the sampled rejected series
+	// of a tenant are reset, so that the first series rejected in each period are kept.
+	rejectedSeriesSamplingPeriod = time.Hour
+)
+
+// RejectedSeries is a series rejected by the distributor validation.
+type RejectedSeries struct {
+	Labels    string    `json:"labels"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// RejectedSeriesResponse is the response of the rejected series API.
+type RejectedSeriesResponse struct {
+	PeriodStart time.Time                   `json:"period_start"`
+	Reasons     map[string][]RejectedSeries `json:"reasons"`
+}
+
+// rejectedSeriesSampler keeps, for each tenant, the full label set of the first series rejected
+// by the distributor validation for each reason within the current sampling period.
+type rejectedSeriesSampler struct {
+	mtx   sync.Mutex
+	users map[string]*userRejectedSeries
+}
+
+type userRejectedSeries struct {
+	periodStart time.Time
+	reasons     map[string][]RejectedSeries
+}
+
+func newRejectedSeriesSampler() *rejectedSeriesSampler {
+	return &rejectedSeriesSampler{
+		users: map[string]*userRejectedSeries{},
+	}
+}
+
+// sample records the input series rejected because of the input error, unless the max number
+// of series has already been sampled for the same tenant and reason in the current period.
+func (s *rejectedSeriesSampler) sample(userID string, series []cortexpb.LabelAdapter, err validation.ValidationError, limit int, now time.Time) {
+	reason := validation.ValidationErrorReason(err)
+	if limit <= 0 || reason == "" {
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok || now.Sub(u.periodStart) >= rejectedSeriesSamplingPeriod {
+		u = &userRejectedSeries{periodStart: now, reasons: map[string][]RejectedSeries{}}
+		s.users[userID] = u
+	}
+
+	if len(u.reasons[reason]) >= limit {
+		return
+	}
+
+	// The series labels may be unsafe strings referencing the request buffer, so
+	// they're copied by formatting them.
+	u.reasons[reason] = append(u.reasons[reason], RejectedSeries{
+		Labels:    cortexpb.FromLabelAdaptersToLabels(series).String(),
+		Error:     err.Error(),
+		Timestamp: now,
+	})
+}
+
+// get returns the series of the tenant sampled in the current period.
+func (s *rejectedSeriesSampler) get(userID string, now time.Time) RejectedSeriesResponse {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	u, ok := s.users[userID]
+	if !ok || now.Sub(u.periodStart) >= rejectedSeriesSamplingPeriod {
+		return RejectedSeriesResponse{Reasons: map[string][]RejectedSeries{}}
+	}
+
+	reasons := make(map[string][]RejectedSeries, len(u.reasons))
+	for reason, series := range u.reasons {
+		reasons[reason] = append([]RejectedSeries(nil), series...)
+	}
+	return RejectedSeriesResponse{PeriodStart: u.periodStart, Reasons: reasons}
+}
+
+// deleteUser removes the sampled series of the tenant.
+func (s *rejectedSeriesSampler) deleteUser(userID string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	delete(s.users, userID)
+}
+
+// RejectedSeriesHandler returns the series of the tenant rejected by the validation of this
+// distributor, sampled by reason within the current period.
+func (d *Distributor) RejectedSeriesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	util.WriteJSONResponse(w, d.rejectedSeries.get(userID, time.Now()))
+}
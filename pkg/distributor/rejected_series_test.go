@@ -0,0 +1,101 @@
+package distributor
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+func TestRejectedSeriesSampler(t *testing.T) {
+	t.Parallel()
+
+	s := newRejectedSeriesSampler()
+	now := time.Now()
+
+	series := func(value string) []cortexpb.LabelAdapter {
+		return []cortexpb.LabelAdapter{{Name: labels.MetricName, Value: "metric"}, {Name: "series", Value: value}}
+	}
+	validateMetrics := validation.NewValidateMetrics(prometheus.NewRegistry())
+	validate := func(ls []cortexpb.LabelAdapter) validation.ValidationError {
+		limits := &validation.Limits{MaxLabelNamesPerSeries: 1}
+		return validation.ValidateLabels(validateMetrics, limits, "user", ls, false)
+	}
+
+	// Only the first 2 series are sampled per reason.
+	for _, v := range []string{"1", "2", "3"} {
+		s.sample("user", series(v), validate(series(v)), 2, now)
+	}
+	// Errors which are not series validation errors are not sampled.
+	s.sample("user", series("4"), assert.AnError, 2, now)
+	// Nothing is sampled if the limit is disabled.
+	s.sample("other", series("1"), validate(series("1")), 0, now)
+
+	res := s.get("user", now.Add(time.Minute))
+	assert.Equal(t, now, res.PeriodStart)
+	require.Len(t, res.Reasons, 1)
+	require.Len(t, res.Reasons["max_label_names_per_series"], 2)
+	assert.Equal(t, `{__name__="metric", series="1"}`, res.Reasons["max_label_names_per_series"][0].Labels)
+	assert.Equal(t, `{__name__="metric", series="2"}`, res.Reasons["max_label_names_per_series"][1].Labels)
+	assert.Empty(t, s.get("other", now).Reasons)
+
+	// The samples are reset once the period has elapsed.
+	assert.Empty(t, s.get("user", now.Add(rejectedSeriesSamplingPeriod)).Reasons)
+
+	s.sample("user", series("5"), validate(series("5")), 2, now.Add(rejectedSeriesSamplingPeriod))
+	res = s.get("user", now.Add(rejectedSeriesSamplingPeriod))
+	require.Len(t, res.Reasons["max_label_names_per_series"], 1)
+	assert.Equal(t, `{__name__="metric", series="5"}`, res.Reasons["max_label_names_per_series"][0].Labels)
+
+	s.deleteUser("user")
+	assert.Empty(t, s.get("user", now).Reasons)
+}
+
+func TestDistributor_RejectedSeriesHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := user.InjectOrgID(context.Background(), "user")
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	limits.RejectedSeriesSamplesPerReason = 10
+
+	ds, _, _, _ := prepare(t, prepConfig{
+		numIngesters:     2,
+		happyIngesters:   2,
+		numDistributors:  1,
+		shardByAllLabels: true,
+		limits:           &limits,
+	})
+
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{
+			labels.FromStrings(labels.MetricName, "valid"),
+			labels.FromStrings(labels.MetricName, "invalid", "label-name", "value"),
+		},
+		[]cortexpb.Sample{{TimestampMs: 1, Value: 1}, {TimestampMs: 1, Value: 1}}, nil, nil, cortexpb.API)
+	_, err := ds[0].Push(ctx, req)
+	require.Error(t, err)
+
+	httpReq := httptest.NewRequest(http.MethodGet, "/api/v1/rejected_series", nil).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	ds[0].RejectedSeriesHandler(rec, httpReq)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var res RejectedSeriesResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	require.Len(t, res.Reasons["label_invalid"], 1)
+	assert.Equal(t, `{__name__="invalid", label-name="value"}`, res.Reasons["label_invalid"][0].Labels)
+}
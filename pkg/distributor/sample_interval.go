@@ -0,0 +1,98 @@
+package distributor
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// sampleIntervalsPurgeInterval is how frequently the series which can't be affected by the
+	// min sample interval anymore are removed from the tracker.
+	sampleIntervalsPurgeInterval = time.Minute
+)
+
+// sampleIntervalTracker keeps, for each tenant with a min sample interval, the timestamp of the
+// last sample accepted for each series, to enforce the interval between the samples of the series.
+type sampleIntervalTracker struct {
+	mtx   sync.Mutex
+	users map[string]*userSampleIntervals
+}
+
+type userSampleIntervals struct {
+	mtx    sync.Mutex
+	series map[uint64]int64
+}
+
+func newSampleIntervalTracker() *sampleIntervalTracker {
+	return &sampleIntervalTracker{
+		users: map[string]*userSampleIntervals{},
+	}
+}
+
+func (t *sampleIntervalTracker) user(userID string) *userSampleIntervals {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	u, ok := t.users[userID]
+	if !ok {
+		u = &userSampleIntervals{series: map[uint64]int64{}}
+		t.users[userID] = u
+	}
+	return u
+}
+
+// lastTimestamp returns the timestamp of the last sample accepted for the series, or 0 if unknown.
+func (u *userSampleIntervals) lastTimestamp(series uint64) int64 {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	return u.series[series]
+}
+
+// update sets the timestamp of the last sample accepted for the series, unless it's older than the
+// timestamp tracked, which may have been updated in the meantime by a concurrent push.
+func (u *userSampleIntervals) update(series uint64, timestamp int64) {
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+
+	if timestamp > u.series[series] {
+		u.series[series] = timestamp
+	}
+}
+
+// purge removes the series whose last sample is older than the min sample interval of their tenant,
+// since the next samples of these series can't be closer than the interval anymore, and the tenants
+// without series or min sample interval.
+func (t *sampleIntervalTracker) purge(now time.Time, minInterval func(userID string) time.Duration) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for userID, u := range t.users {
+		interval := minInterval(userID)
+		if interval <= 0 {
+			delete(t.users, userID)
+			continue
+		}
+
+		before := now.Add(-interval).UnixMilli()
+		u.mtx.Lock()
+		for series, timestamp := range u.series {
+			if timestamp < before {
+				delete(u.series, series)
+			}
+		}
+		empty := len(u.series) == 0
+		u.mtx.Unlock()
+
+		if empty {
+			delete(t.users, userID)
+		}
+	}
+}
+
+func (t *sampleIntervalTracker) deleteUser(userID string) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	delete(t.users, userID)
+}
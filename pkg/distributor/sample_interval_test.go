@@ -0,0 +1,30 @@
+package distributor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleIntervalTracker_Purge(t *testing.T) {
+	now := time.UnixMilli(100000)
+	intervals := map[string]time.Duration{"user-1": 10 * time.Second, "user-2": 10 * time.Second}
+
+	tracker := newSampleIntervalTracker()
+	tracker.user("user-1").update(1, 95000)
+	tracker.user("user-1").update(2, 80000)
+	tracker.user("user-2").update(1, 80000)
+	tracker.user("user-3").update(1, 95000)
+
+	// The timestamp is only updated forward.
+	tracker.user("user-1").update(1, 90000)
+	assert.Equal(t, int64(95000), tracker.user("user-1").lastTimestamp(1))
+
+	tracker.purge(now, func(userID string) time.Duration { return intervals[userID] })
+
+	// The series older than the interval and the tenants without series or interval are removed.
+	assert.Equal(t, map[uint64]int64{1: 95000}, tracker.users["user-1"].series)
+	assert.NotContains(t, tracker.users, "user-2")
+	assert.NotContains(t, tracker.users, "user-3")
+}
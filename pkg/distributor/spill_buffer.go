@@ -0,0 +1,386 @@
+package distributor
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+const (
+	spillFileExt = ".spill"
+
+	spillFailureBufferFull  = "buffer_full"
+	spillFailureTenantQuota = "tenant_quota"
+	spillFailureError       = "error"
+
+	spillDropExpired   = "expired"
+	spillDropRejected  = "rejected"
+	spillDropCorrupted = "corrupted"
+)
+
+var (
+	errInvalidSpillBufferConfig     = errors.New("the spill buffer directory, max size, max age and replay interval must be set when the spill buffer is enabled")
+	errSpillBufferFull              = errors.New("the spill buffer is full")
+	errSpillBufferTenantQuotaExceed = errors.New("the tenant spill buffer quota is exceeded")
+)
+
+// SpillBufferConfig configures the on-disk buffer of the pushes failed because the ingesters are
+// unavailable.
+type SpillBufferConfig struct {
+	Enabled        bool          `yaml:"enabled"`
+	Dir            string        `yaml:"dir"`
+	MaxSizeBytes   int64         `yaml:"max_size_bytes"`
+	MaxAge         time.Duration `yaml:"max_age"`
+	ReplayInterval time.Duration `yaml:"replay_interval"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *SpillBufferConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "distributor.spill-buffer.enabled", false, "[Experimental] True to buffer on local disk the pushes failed on all the ingesters because they're unavailable, and acknowledge them instead of rejecting them. Only the pushes of the tenants with an out-of-order time window are buffered, since they're replayed after the newer samples. The buffered pushes are retried asynchronously, and dropped once the ingesters reject them or they're older than -distributor.spill-buffer.max-age.")
+	f.StringVar(&cfg.Dir, "distributor.spill-buffer.dir", "./spill-buffer/", "Directory where the pushes are buffered. It should be persisted across restarts.")
+	f.Int64Var(&cfg.MaxSizeBytes, "distributor.spill-buffer.max-size-bytes", 1<<30, "Max size (in bytes) of the pushes buffered on disk, across all the tenants. The pushes exceeding it are rejected.")
+	f.DurationVar(&cfg.MaxAge, "distributor.spill-buffer.max-age", time.Hour, "Max age of a buffered push. The older pushes are dropped without being retried.")
+	f.DurationVar(&cfg.ReplayInterval, "distributor.spill-buffer.replay-interval", 10*time.Second, "How frequently the buffered pushes are retried.")
+}
+
+// Validate the config.
+func (cfg *SpillBufferConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Dir == "" || cfg.MaxSizeBytes <= 0 || cfg.MaxAge <= 0 || cfg.ReplayInterval <= 0 {
+		return errInvalidSpillBufferConfig
+	}
+	return nil
+}
+
+type spillBufferLimits interface {
+	SpillBufferMaxSizeBytes(userID string) int
+}
+
+// spillBuffer persists the pushes on local disk, in a directory per tenant, and periodically
+// replays them in order. A push is removed from the buffer once replayed successfully, rejected
+// by the ingesters or expired.
+type spillBuffer struct {
+	services.Service
+
+	cfg    SpillBufferConfig
+	limits spillBufferLimits
+	replay func(ctx context.Context, userID string, req *cortexpb.WriteRequest) error
+	logger log.Logger
+
+	mtx       sync.Mutex
+	totalSize int64
+	userSizes map[string]int64
+	seq       uint64
+
+	spilledRequests  *prometheus.CounterVec
+	spillFailures    *prometheus.CounterVec
+	replayedRequests *prometheus.CounterVec
+	droppedRequests  *prometheus.CounterVec
+	bufferedBytes    *prometheus.GaugeVec
+}
+
+func newSpillBuffer(cfg SpillBufferConfig, limits spillBufferLimits, replay func(context.Context, string, *cortexpb.WriteRequest) error, logger log.Logger, reg prometheus.Registerer) *spillBuffer {
+	b := &spillBuffer{
+		cfg:       cfg,
+		limits:    limits,
+		replay:    replay,
+		logger:    logger,
+		userSizes: map[string]int64{},
+
+		spilledRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_buffer_spilled_requests_total",
+			Help: "The total number of pushes buffered on disk because a quorum of ingesters was unavailable.",
+		}, []string{"user"}),
+		spillFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_buffer_spill_failures_total",
+			Help: "The total number of pushes failed on the ingesters which couldn't be buffered on disk.",
+		}, []string{"user", "reason"}),
+		replayedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_buffer_replayed_requests_total",
+			Help: "The total number of buffered pushes replayed successfully to the ingesters.",
+		}, []string{"user"}),
+		droppedRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_distributor_spill_buffer_dropped_requests_total",
+			Help: "The total number of buffered pushes dropped without being replayed successfully.",
+		}, []string{"user", "reason"}),
+		bufferedBytes: promauto.With(reg).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_distributor_spill_buffer_size_bytes",
+			Help: "The size of the pushes buffered on disk.",
+		}, []string{"user"}),
+	}
+
+	b.Service = services.NewTimerService(cfg.ReplayInterval, b.starting, b.replayAll, nil)
+	return b
+}
+
+// starting creates the buffer directory and accounts the pushes buffered before a restart.
+func (b *spillBuffer) starting(_ context.Context) error {
+	if err := os.MkdirAll(b.cfg.Dir, 0750); err != nil {
+		return errors.Wrap(err, "failed to create the spill buffer directory")
+	}
+
+	users, err := os.ReadDir(b.cfg.Dir)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the spill buffer directory")
+	}
+	for _, u := range users {
+		if !u.IsDir() {
+			continue
+		}
+
+		userDir := filepath.Join(b.cfg.Dir, u.Name())
+		files, err := os.ReadDir(userDir)
+		if err != nil {
+			return errors.Wrap(err, "failed to read the spill buffer directory")
+		}
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			// Remove the pushes which were being written when the distributor stopped.
+			if filepath.Ext(f.Name()) != spillFileExt {
+				_ = os.Remove(filepath.Join(userDir, f.Name()))
+				continue
+			}
+			if info, err := f.Info(); err == nil {
+				b.addSize(u.Name(), info.Size())
+			}
+		}
+	}
+	return nil
+}
+
+// spill persists the input push of the tenant. Returns an error if it exceeds the buffer size.
+func (b *spillBuffer) spill(userID string, req *cortexpb.WriteRequest) error {
+	data, err := req.Marshal()
+	if err != nil {
+		b.spillFailures.WithLabelValues(userID, spillFailureError).Inc()
+		return err
+	}
+	data = snappy.Encode(nil, data)
+	size := int64(len(data))
+
+	b.mtx.Lock()
+	if b.totalSize+size > b.cfg.MaxSizeBytes {
+		b.mtx.Unlock()
+		b.spillFailures.WithLabelValues(userID, spillFailureBufferFull).Inc()
+		return errSpillBufferFull
+	}
+	if quota := int64(b.limits.SpillBufferMaxSizeBytes(userID)); quota > 0 && b.userSizes[userID]+size > quota {
+		b.mtx.Unlock()
+		b.spillFailures.WithLabelValues(userID, spillFailureTenantQuota).Inc()
+		return errSpillBufferTenantQuotaExceed
+	}
+	// Reserve the space before writing, so that concurrent pushes don't exceed the limits.
+	b.addSizeLocked(userID, size)
+	b.seq++
+	// The file names sort in the order the pushes have been buffered.
+	name := fmt.Sprintf("%020d-%010d%s", time.Now().UnixNano(), b.seq, spillFileExt)
+	b.mtx.Unlock()
+
+	if err := b.write(userID, name, data); err != nil {
+		b.addSize(userID, -size)
+		b.spillFailures.WithLabelValues(userID, spillFailureError).Inc()
+		return err
+	}
+
+	b.spilledRequests.WithLabelValues(userID).Inc()
+	return nil
+}
+
+// write atomically writes the file of a buffered push.
+func (b *spillBuffer) write(userID, name string, data []byte) error {
+	userDir := filepath.Join(b.cfg.Dir, userID)
+	if err := os.MkdirAll(userDir, 0750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(userDir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(userDir, name))
+}
+
+// replayAll replays the buffered pushes of all the tenants.
+func (b *spillBuffer) replayAll(ctx context.Context) error {
+	users, err := os.ReadDir(b.cfg.Dir)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "failed to read the spill buffer directory", "err", err)
+		return nil
+	}
+
+	for _, u := range users {
+		if ctx.Err() != nil {
+			return nil
+		}
+		if u.IsDir() {
+			b.replayUser(ctx, u.Name())
+		}
+	}
+	return nil
+}
+
+// replayUser replays the buffered pushes of a tenant in order, stopping at the first one failed
+// because a quorum of ingesters is still unavailable.
+func (b *spillBuffer) replayUser(ctx context.Context, userID string) {
+	userDir := filepath.Join(b.cfg.Dir, userID)
+	files, err := os.ReadDir(userDir)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "failed to read the spill buffer directory", "user", userID, "err", err)
+		return
+	}
+
+	for _, f := range files {
+		if ctx.Err() != nil {
+			return
+		}
+		if f.IsDir() || filepath.Ext(f.Name()) != spillFileExt {
+			continue
+		}
+
+		info, err := f.Info()
+		if err != nil {
+			continue
+		}
+
+		reason, err := b.replayFile(ctx, userID, filepath.Join(userDir, f.Name()))
+		if err != nil && reason == "" {
+			level.Debug(b.logger).Log("msg", "failed to replay the buffered push, will retry", "user", userID, "err", err)
+			return
+		}
+
+		if reason != "" {
+			level.Warn(b.logger).Log("msg", "dropped the buffered push", "user", userID, "reason", reason, "err", err)
+			b.droppedRequests.WithLabelValues(userID, reason).Inc()
+		} else {
+			b.replayedRequests.WithLabelValues(userID).Inc()
+		}
+
+		if err := os.Remove(filepath.Join(userDir, f.Name())); err != nil && !os.IsNotExist(err) {
+			level.Warn(b.logger).Log("msg", "failed to remove the buffered push", "user", userID, "err", err)
+			return
+		}
+		b.addSize(userID, -info.Size())
+	}
+}
+
+// replayFile replays a buffered push. Returns the reason why the push must be dropped, or an
+// error without reason if it must be retried.
+func (b *spillBuffer) replayFile(ctx context.Context, userID, path string) (string, error) {
+	spilledAt, err := strconv.ParseInt(strings.SplitN(filepath.Base(path), "-", 2)[0], 10, 64)
+	if err != nil {
+		return spillDropCorrupted, err
+	}
+	if time.Since(time.Unix(0, spilledAt)) > b.cfg.MaxAge {
+		return spillDropExpired, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	data, err = snappy.Decode(nil, data)
+	if err != nil {
+		return spillDropCorrupted, err
+	}
+	req := cortexpb.PreallocWriteRequest{}
+	if err := req.Unmarshal(data); err != nil {
+		return spillDropCorrupted, err
+	}
+
+	if err := b.replay(ctx, userID, &req.WriteRequest); err != nil {
+		if ctx.Err() != nil || isSpillableError(err) {
+			return "", err
+		}
+		return spillDropRejected, err
+	}
+	return "", nil
+}
+
+func (b *spillBuffer) addSize(userID string, delta int64) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.addSizeLocked(userID, delta)
+}
+
+func (b *spillBuffer) addSizeLocked(userID string, delta int64) {
+	b.totalSize += delta
+	b.userSizes[userID] += delta
+
+	if b.userSizes[userID] <= 0 {
+		delete(b.userSizes, userID)
+		b.bufferedBytes.DeleteLabelValues(userID)
+		return
+	}
+	b.bufferedBytes.WithLabelValues(userID).Set(float64(b.userSizes[userID]))
+}
+
+// deleteUserMetrics removes the counters of an inactive tenant.
+func (b *spillBuffer) deleteUserMetrics(userID string) {
+	b.spilledRequests.DeleteLabelValues(userID)
+	b.replayedRequests.DeleteLabelValues(userID)
+	for _, reason := range []string{spillFailureBufferFull, spillFailureTenantQuota, spillFailureError} {
+		b.spillFailures.DeleteLabelValues(userID, reason)
+	}
+	for _, reason := range []string{spillDropExpired, spillDropRejected, spillDropCorrupted} {
+		b.droppedRequests.DeleteLabelValues(userID, reason)
+	}
+}
+
+// isSpillableError returns whether the push failed because a quorum of ingesters is unavailable,
+// rather than because the ingesters rejected it or the client canceled it.
+func isSpillableError(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	return getErrorStatus(err) == "5xx"
+}
+
+// replaySpilledPush pushes a buffered push to the ingesters. The push has already been validated
+// and accounted when it was buffered.
+func (d *Distributor) replaySpilledPush(ctx context.Context, userID string, req *cortexpb.WriteRequest) error {
+	keys := make([]uint32, 0, len(req.Timeseries)+len(req.Metadata))
+	for _, ts := range req.Timeseries {
+		key, err := d.tokenForLabels(userID, ts.Labels)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	for _, m := range req.Metadata {
+		keys = append(keys, d.tokenForMetadata(userID, m.MetricFamilyName))
+	}
+
+	ctx = user.InjectOrgID(ctx, userID)
+	subRing := d.writeSubRing(userID, d.limits.GetOverridesForUser(userID))
+	return d.doBatch(ctx, req, subRing, keys, len(req.Timeseries), req.Metadata, req.Timeseries, userID, nil)
+}
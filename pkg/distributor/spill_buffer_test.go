@@ -0,0 +1,256 @@
+package distributor
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/test"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+type spillBufferLimitsMock map[string]int
+
+func (m spillBufferLimitsMock) SpillBufferMaxSizeBytes(userID string) int {
+	return m[userID]
+}
+
+func TestSpillBuffer_SpillAndReplay(t *testing.T) {
+	cfg := SpillBufferConfig{
+		Enabled:        true,
+		Dir:            t.TempDir(),
+		MaxSizeBytes:   1 << 20,
+		MaxAge:         time.Hour,
+		ReplayInterval: time.Hour,
+	}
+
+	var (
+		replayed  []string
+		replayErr error
+	)
+	replay := func(_ context.Context, userID string, req *cortexpb.WriteRequest) error {
+		if replayErr != nil {
+			return replayErr
+		}
+		replayed = append(replayed, userID+"/"+req.Timeseries[0].Labels[0].Value)
+		return nil
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	b := newSpillBuffer(cfg, spillBufferLimitsMock{}, replay, log.NewNopLogger(), reg)
+	require.NoError(t, b.starting(context.Background()))
+
+	for _, name := range []string{"first", "second"} {
+		require.NoError(t, b.spill("user-1", makeSpillRequest(name)))
+	}
+	require.NoError(t, b.spill("user-2", makeSpillRequest("third")))
+
+	// The pushes are kept while a quorum of ingesters is unavailable.
+	replayErr = httpgrpc.Errorf(http.StatusInternalServerError, "unavailable")
+	require.NoError(t, b.replayAll(context.Background()))
+	require.Empty(t, replayed)
+	assert.Equal(t, 2, countSpillFiles(t, cfg.Dir, "user-1"))
+	assert.Equal(t, 1, countSpillFiles(t, cfg.Dir, "user-2"))
+
+	// The pushes buffered before a restart are accounted.
+	restarted := newSpillBuffer(cfg, spillBufferLimitsMock{}, replay, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+	require.NoError(t, restarted.starting(context.Background()))
+	assert.Equal(t, b.userSizes, restarted.userSizes)
+	assert.Equal(t, b.totalSize, restarted.totalSize)
+
+	// The pushes are replayed in order once the ingesters are available.
+	replayErr = nil
+	require.NoError(t, b.replayAll(context.Background()))
+	assert.Equal(t, []string{"user-1/first", "user-1/second", "user-2/third"}, replayed)
+	assert.Equal(t, 0, countSpillFiles(t, cfg.Dir, "user-1"))
+	assert.Equal(t, 0, countSpillFiles(t, cfg.Dir, "user-2"))
+	assert.Equal(t, int64(0), b.totalSize)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_spill_buffer_spilled_requests_total The total number of pushes buffered on disk because a quorum of ingesters was unavailable.
+		# TYPE cortex_distributor_spill_buffer_spilled_requests_total counter
+		cortex_distributor_spill_buffer_spilled_requests_total{user="user-1"} 2
+		cortex_distributor_spill_buffer_spilled_requests_total{user="user-2"} 1
+		# HELP cortex_distributor_spill_buffer_replayed_requests_total The total number of buffered pushes replayed successfully to the ingesters.
+		# TYPE cortex_distributor_spill_buffer_replayed_requests_total counter
+		cortex_distributor_spill_buffer_replayed_requests_total{user="user-1"} 2
+		cortex_distributor_spill_buffer_replayed_requests_total{user="user-2"} 1
+	`), "cortex_distributor_spill_buffer_spilled_requests_total", "cortex_distributor_spill_buffer_replayed_requests_total", "cortex_distributor_spill_buffer_size_bytes"))
+}
+
+func TestSpillBuffer_Drop(t *testing.T) {
+	cfg := SpillBufferConfig{
+		Enabled:        true,
+		Dir:            t.TempDir(),
+		MaxSizeBytes:   1 << 20,
+		MaxAge:         time.Hour,
+		ReplayInterval: time.Hour,
+	}
+
+	replay := func(context.Context, string, *cortexpb.WriteRequest) error {
+		return httpgrpc.Errorf(http.StatusBadRequest, "out of order sample")
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	b := newSpillBuffer(cfg, spillBufferLimitsMock{}, replay, log.NewNopLogger(), reg)
+	require.NoError(t, b.starting(context.Background()))
+
+	require.NoError(t, b.spill("user-1", makeSpillRequest("rejected")))
+
+	// Buffer a push as if it had been buffered longer than the max age.
+	expired := filepath.Join(cfg.Dir, "user-1", "00000000000000000001-0000000000"+spillFileExt)
+	require.NoError(t, os.WriteFile(expired, []byte("data"), 0600))
+
+	require.NoError(t, b.replayAll(context.Background()))
+	assert.Equal(t, 0, countSpillFiles(t, cfg.Dir, "user-1"))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_spill_buffer_dropped_requests_total The total number of buffered pushes dropped without being replayed successfully.
+		# TYPE cortex_distributor_spill_buffer_dropped_requests_total counter
+		cortex_distributor_spill_buffer_dropped_requests_total{reason="expired",user="user-1"} 1
+		cortex_distributor_spill_buffer_dropped_requests_total{reason="rejected",user="user-1"} 1
+	`), "cortex_distributor_spill_buffer_dropped_requests_total"))
+}
+
+func TestSpillBuffer_Limits(t *testing.T) {
+	size := int64(len(mustMarshalSpillRequest(t, makeSpillRequest("series"))))
+
+	cfg := SpillBufferConfig{
+		Enabled:        true,
+		Dir:            t.TempDir(),
+		MaxSizeBytes:   3 * size,
+		MaxAge:         time.Hour,
+		ReplayInterval: time.Hour,
+	}
+	limits := spillBufferLimitsMock{"user-1": int(2 * size)}
+
+	reg := prometheus.NewPedanticRegistry()
+	b := newSpillBuffer(cfg, limits, nil, log.NewNopLogger(), reg)
+	require.NoError(t, b.starting(context.Background()))
+
+	require.NoError(t, b.spill("user-1", makeSpillRequest("series")))
+	require.NoError(t, b.spill("user-1", makeSpillRequest("series")))
+	require.ErrorIs(t, b.spill("user-1", makeSpillRequest("series")), errSpillBufferTenantQuotaExceed)
+
+	require.NoError(t, b.spill("user-2", makeSpillRequest("series")))
+	require.ErrorIs(t, b.spill("user-2", makeSpillRequest("series")), errSpillBufferFull)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_distributor_spill_buffer_spill_failures_total The total number of pushes failed on the ingesters which couldn't be buffered on disk.
+		# TYPE cortex_distributor_spill_buffer_spill_failures_total counter
+		cortex_distributor_spill_buffer_spill_failures_total{reason="buffer_full",user="user-2"} 1
+		cortex_distributor_spill_buffer_spill_failures_total{reason="tenant_quota",user="user-1"} 1
+	`), "cortex_distributor_spill_buffer_spill_failures_total"))
+}
+
+func TestDistributor_Push_SpillBuffer(t *testing.T) {
+	tests := map[string]struct {
+		outOfOrderTimeWindow model.Duration
+		happyIngesters       int
+		expectedSpilled      bool
+	}{
+		"should buffer the push failed on all the ingesters": {
+			outOfOrderTimeWindow: model.Duration(time.Hour),
+			expectedSpilled:      true,
+		},
+		"should not buffer the push if the tenant doesn't accept out-of-order samples": {
+			expectedSpilled: false,
+		},
+		"should not buffer the push if it succeeded on an ingester": {
+			outOfOrderTimeWindow: model.Duration(time.Hour),
+			happyIngesters:       1,
+			expectedSpilled:      false,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := &validation.Limits{}
+			flagext.DefaultValues(limits)
+			limits.OutOfOrderTimeWindow = testData.outOfOrderTimeWindow
+
+			ds, ingesters, _, _ := prepare(t, prepConfig{
+				numIngesters:     3,
+				happyIngesters:   testData.happyIngesters,
+				numDistributors:  1,
+				shardByAllLabels: true,
+				limits:           limits,
+				spillBuffer: SpillBufferConfig{
+					Enabled:        true,
+					Dir:            t.TempDir(),
+					MaxSizeBytes:   1 << 20,
+					MaxAge:         time.Hour,
+					ReplayInterval: time.Hour,
+				},
+			})
+			d := ds[0]
+			ctx := user.InjectOrgID(context.Background(), "user")
+
+			_, err := d.Push(ctx, makeWriteRequest(0, 5, 0, 0))
+			if !testData.expectedSpilled {
+				require.Error(t, err)
+				assert.Equal(t, 0, countSpillFiles(t, d.cfg.SpillBuffer.Dir, "user"))
+				return
+			}
+
+			// The push is acknowledged while no ingester is available.
+			require.NoError(t, err)
+			assert.Equal(t, 1, countSpillFiles(t, d.cfg.SpillBuffer.Dir, "user"))
+			for _, ing := range ingesters {
+				assert.Empty(t, ing.series())
+			}
+
+			// The push is replayed once the ingesters are available.
+			for _, ing := range ingesters {
+				ing.happy.Store(true)
+			}
+			require.NoError(t, d.spillBuffer.replayAll(context.Background()))
+			for _, ing := range ingesters {
+				// The replay returns once a quorum of ingesters succeeded.
+				test.Poll(t, time.Second, 5, func() interface{} {
+					return len(ing.series())
+				})
+			}
+			assert.Equal(t, int64(0), d.spillBuffer.totalSize)
+		})
+	}
+}
+
+func makeSpillRequest(name string) *cortexpb.WriteRequest {
+	return &cortexpb.WriteRequest{
+		Timeseries: []cortexpb.PreallocTimeseries{{
+			TimeSeries: &cortexpb.TimeSeries{
+				Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: name}},
+				Samples: []cortexpb.Sample{{Value: 1, TimestampMs: 1}},
+			},
+		}},
+	}
+}
+
+func mustMarshalSpillRequest(t *testing.T, req *cortexpb.WriteRequest) []byte {
+	data, err := req.Marshal()
+	require.NoError(t, err)
+	return snappy.Encode(nil, data)
+}
+
+func countSpillFiles(t *testing.T, dir, userID string) int {
+	files, err := filepath.Glob(filepath.Join(dir, userID, "*"+spillFileExt))
+	require.NoError(t, err)
+	return len(files)
+}
@@ -0,0 +1,111 @@
+package distributor
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+// TSDBStatus returns the cardinality statistics of the TSDB head of the user, aggregated across
+// the ingesters of the tenant shard. Each ingester only returns the top limit items of each
+// statistic, so the aggregated statistics are an approximation when the user has more items:
+// the series counts are summed and divided by the replication factor, while the label value
+// counts and sizes are the max across the ingesters, given the label values are shared.
+func (d *Distributor) TSDBStatus(ctx context.Context, limit int) (*ingester_client.TSDBStatusResponse, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The statistics are computed from the ingesters answering within the replication set quorum,
+	// so they are an estimate while some ingesters are failing.
+	replicationSet, err := d.GetIngestersForMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &ingester_client.TSDBStatusRequest{Limit: int32(limit)}
+	resps, err := d.ForReplicationSet(ctx, replicationSet, false, func(ctx context.Context, client ingester_client.IngesterClient) (interface{}, error) {
+		return client.TSDBStatus(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		result                      = &ingester_client.TSDBStatusResponse{MinTime: math.MaxInt64, MaxTime: math.MinInt64}
+		seriesCountByMetricName     = map[string]uint64{}
+		labelValueCountByLabelName  = map[string]uint64{}
+		memoryInBytesByLabelName    = map[string]uint64{}
+		seriesCountByLabelValuePair = map[string]uint64{}
+	)
+
+	for _, resp := range resps {
+		r := resp.(*ingester_client.TSDBStatusResponse)
+		if r.NumSeries == 0 {
+			continue
+		}
+
+		result.NumSeries += r.NumSeries
+		result.ChunkCount += r.ChunkCount
+		result.NumLabelPairs = max(result.NumLabelPairs, r.NumLabelPairs)
+		result.MinTime = min(result.MinTime, r.MinTime)
+		result.MaxTime = max(result.MaxTime, r.MaxTime)
+
+		sumTSDBStatistics(seriesCountByMetricName, r.SeriesCountByMetricName)
+		maxTSDBStatistics(labelValueCountByLabelName, r.LabelValueCountByLabelName)
+		maxTSDBStatistics(memoryInBytesByLabelName, r.MemoryInBytesByLabelName)
+		sumTSDBStatistics(seriesCountByLabelValuePair, r.SeriesCountByLabelValuePair)
+	}
+
+	if result.NumSeries == 0 {
+		return &ingester_client.TSDBStatusResponse{}, nil
+	}
+
+	factor := uint64(ring.WithTenantReplicationFactor(d.ingestersRing, d.limits.IngestionReplicationFactor(userID)).ReplicationFactor())
+	result.NumSeries /= factor
+	result.ChunkCount /= factor
+	result.SeriesCountByMetricName = topTSDBStatistics(seriesCountByMetricName, factor, limit)
+	result.LabelValueCountByLabelName = topTSDBStatistics(labelValueCountByLabelName, 1, limit)
+	result.MemoryInBytesByLabelName = topTSDBStatistics(memoryInBytesByLabelName, 1, limit)
+	result.SeriesCountByLabelValuePair = topTSDBStatistics(seriesCountByLabelValuePair, factor, limit)
+
+	return result, nil
+}
+
+func sumTSDBStatistics(dst map[string]uint64, stats []ingester_client.TSDBStatistic) {
+	for _, s := range stats {
+		dst[s.Name] += s.Value
+	}
+}
+
+func maxTSDBStatistics(dst map[string]uint64, stats []ingester_client.TSDBStatistic) {
+	for _, s := range stats {
+		dst[s.Name] = max(dst[s.Name], s.Value)
+	}
+}
+
+// topTSDBStatistics returns the top limit statistics, sorted by value descending, after
+// having divided the values by the input factor.
+func topTSDBStatistics(stats map[string]uint64, factor uint64, limit int) []ingester_client.TSDBStatistic {
+	result := make([]ingester_client.TSDBStatistic, 0, len(stats))
+	for name, value := range stats {
+		result = append(result, ingester_client.TSDBStatistic{Name: name, Value: value / factor})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Value != result[j].Value {
+			return result[i].Value > result[j].Value
+		}
+		return result[i].Name < result[j].Name
+	})
+
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
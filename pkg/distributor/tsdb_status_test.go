@@ -0,0 +1,67 @@
+package distributor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	ingester_client "github.com/cortexproject/cortex/pkg/ingester/client"
+)
+
+func TestDistributor_TSDBStatus(t *testing.T) {
+	t.Parallel()
+
+	ds, ingesters, _, _ := prepare(t, prepConfig{
+		numIngesters:      3,
+		happyIngesters:    3,
+		numDistributors:   1,
+		shardByAllLabels:  true,
+		replicationFactor: 1,
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	// No series pushed yet.
+	resp, err := ds[0].TSDBStatus(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, &ingester_client.TSDBStatusResponse{}, resp)
+
+	req := makeWriteRequest(100, 10, 0, 0)
+	req.Timeseries = append(req.Timeseries, makeWriteRequestTimeseries([]cortexpb.LabelAdapter{
+		{Name: "__name__", Value: "bar"},
+	}, 50, 1, false))
+	_, err = ds[0].Push(ctx, req)
+	require.NoError(t, err)
+
+	resp, err = ds[0].TSDBStatus(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), resp.NumSeries)
+	assert.Equal(t, uint64(11), resp.ChunkCount)
+	assert.Equal(t, int64(50), resp.MinTime)
+	assert.Equal(t, int64(109), resp.MaxTime)
+	assert.Equal(t, []ingester_client.TSDBStatistic{{Name: "foo", Value: 10}, {Name: "bar", Value: 1}}, resp.SeriesCountByMetricName)
+	assert.Equal(t, 3, countMockIngestersCalls(ingesters, "TSDBStatus"))
+
+	// The statistics should honor the limit.
+	resp, err = ds[0].TSDBStatus(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []ingester_client.TSDBStatistic{{Name: "foo", Value: 10}}, resp.SeriesCountByMetricName)
+}
+
+func TestTopTSDBStatistics(t *testing.T) {
+	t.Parallel()
+
+	stats := map[string]uint64{"a": 9, "b": 30, "c": 9, "d": 3}
+
+	assert.Equal(t, []ingester_client.TSDBStatistic{
+		{Name: "b", Value: 10},
+		{Name: "a", Value: 3},
+		{Name: "c", Value: 3},
+	}, topTSDBStatistics(stats, 3, 3))
+
+	assert.Len(t, topTSDBStatistics(stats, 1, 0), 4)
+}
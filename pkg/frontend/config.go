@@ -20,7 +20,8 @@ type CombinedFrontendConfig struct {
 	FrontendV1 v1.Config               `yaml:",inline"`
 	FrontendV2 v2.Config               `yaml:",inline"`
 
-	DownstreamURL string `yaml:"downstream_url"`
+	DownstreamURL string           `yaml:"downstream_url"`
+	Federation    FederationConfig `yaml:"federation"`
 }
 
 func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet) {
@@ -29,6 +30,15 @@ func (cfg *CombinedFrontendConfig) RegisterFlags(f *flag.FlagSet) {
 	cfg.FrontendV2.RegisterFlags(f)
 
 	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Prometheus.")
+	cfg.Federation.RegisterFlags(f)
+}
+
+// Validate validates the config.
+func (cfg *CombinedFrontendConfig) Validate() error {
+	if err := cfg.Handler.QueryBytesBudget.Validate(); err != nil {
+		return err
+	}
+	return cfg.Federation.Validate()
 }
 
 // InitFrontend initializes frontend (either V1 -- without scheduler, or V2 -- with scheduler) or no frontend at
@@ -0,0 +1,240 @@
+package frontend
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/instantquery"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+// federatedQueryHeader is set on the queries sent to the remote cell, so that they're
+// not federated again when both cells federate their queries with each other.
+const federatedQueryHeader = "X-Cortex-Federated-Query"
+
+var errInvalidFederationTimeout = errors.New("the federation timeout must be greater than 0")
+
+// FederationConfig is the config to federate the queries with a remote Cortex cell.
+type FederationConfig struct {
+	RemoteURL  string        `yaml:"remote_url"`
+	DedupLabel string        `yaml:"dedup_label"`
+	Timeout    time.Duration `yaml:"timeout"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *FederationConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.RemoteURL, "frontend.federation.remote-url", "", "[Experimental] URL of the query-frontend of a remote Cortex cell. When set, the instant and range queries are sent both to the local and the remote cell, and the results are merged.")
+	f.StringVar(&cfg.DedupLabel, "frontend.federation.dedup-label", "", "[Experimental] Label identifying the cell a series has been written to. The label is removed from the series returned by both cells, so that the series written to both cells are deduplicated when merging the results.")
+	f.DurationVar(&cfg.Timeout, "frontend.federation.timeout", 30*time.Second, "[Experimental] Timeout of the queries sent to the remote cell. If the remote cell fails or times out, only the local results are returned.")
+}
+
+// Validate validates the config.
+func (cfg *FederationConfig) Validate() error {
+	if cfg.RemoteURL == "" {
+		return nil
+	}
+	if _, err := url.Parse(cfg.RemoteURL); err != nil {
+		return errors.Wrap(err, "invalid federation remote URL")
+	}
+	if cfg.Timeout <= 0 {
+		return errInvalidFederationTimeout
+	}
+	return nil
+}
+
+// Federation fans out the instant and range queries to a remote Cortex cell in addition
+// to the local one, for active-active deployments, and merges the results deduplicating
+// the series by the configured label.
+type Federation struct {
+	cfg               FederationConfig
+	remote            http.RoundTripper
+	queryRangeCodec   tripperware.Codec
+	instantQueryCodec tripperware.Codec
+	forwardHeaders    []string
+	logger            log.Logger
+
+	queries        prometheus.Counter
+	remoteFailures prometheus.Counter
+}
+
+// NewFederation makes a new Federation sending the remote queries through the input transport.
+func NewFederation(cfg FederationConfig, transport http.RoundTripper, queryRangeCodec, instantQueryCodec tripperware.Codec, forwardHeaders []string, logger log.Logger, reg prometheus.Registerer) (*Federation, error) {
+	remote, err := NewDownstreamRoundTripper(cfg.RemoteURL, transport)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Federation{
+		cfg:               cfg,
+		remote:            remote,
+		queryRangeCodec:   queryRangeCodec,
+		instantQueryCodec: instantQueryCodec,
+		forwardHeaders:    forwardHeaders,
+		logger:            logger,
+		queries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_federated_queries_total",
+			Help: "Total number of queries sent to both the local and the remote cell.",
+		}),
+		remoteFailures: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_federated_queries_remote_failures_total",
+			Help: "Total number of federated queries for which the remote cell failed, and only the local results have been returned.",
+		}),
+	}, nil
+}
+
+// Wrap returns a http.RoundTripper sending the instant and range queries both to the next
+// round tripper and to the remote cell, and forwarding all the other requests to the next
+// round tripper only.
+func (f *Federation) Wrap(next http.RoundTripper) http.RoundTripper {
+	return tripperware.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var codec tripperware.Codec
+		switch {
+		case r.Header.Get(federatedQueryHeader) != "":
+			return next.RoundTrip(r)
+		case strings.HasSuffix(r.URL.Path, "/query_range"):
+			codec = f.queryRangeCodec
+		case strings.HasSuffix(r.URL.Path, "/query"):
+			codec = f.instantQueryCodec
+		default:
+			return next.RoundTrip(r)
+		}
+
+		req, err := codec.DecodeRequest(r.Context(), r, f.forwardHeaders)
+		if err != nil {
+			return nil, err
+		}
+
+		f.queries.Inc()
+
+		// Query the remote cell while the local one is queried.
+		remoteDone := make(chan struct{})
+		var (
+			remoteResp tripperware.Response
+			remoteErr  error
+		)
+		go func() {
+			defer close(remoteDone)
+			remoteResp, remoteErr = f.queryRemote(r.Context(), codec, req)
+		}()
+
+		// The local response is decoded as JSON, so it must not be encoded in protobuf.
+		localReq := r.Clone(r.Context())
+		localReq.Header.Del("Accept")
+
+		localHTTPResp, err := next.RoundTrip(localReq)
+		if err != nil || localHTTPResp.StatusCode/100 != 2 {
+			return localHTTPResp, err
+		}
+
+		localResp, err := codec.DecodeResponse(r.Context(), localHTTPResp, req)
+		_ = localHTTPResp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		<-remoteDone
+		if remoteErr != nil {
+			f.remoteFailures.Inc()
+			level.Warn(util_log.WithContext(r.Context(), f.logger)).Log("msg", "failed to query the remote cell, returning the local results only", "err", remoteErr)
+			return codec.EncodeResponse(r.Context(), localResp)
+		}
+
+		if !isMergeableResponse(localResp) || !isMergeableResponse(remoteResp) {
+			return codec.EncodeResponse(r.Context(), localResp)
+		}
+
+		if f.cfg.DedupLabel != "" {
+			dropResponseLabel(localResp, f.cfg.DedupLabel)
+			dropResponseLabel(remoteResp, f.cfg.DedupLabel)
+		}
+
+		// The series with the same labels are deduplicated when merging.
+		merged, err := codec.MergeResponse(r.Context(), req, localResp, remoteResp)
+		if err != nil {
+			return nil, err
+		}
+		return codec.EncodeResponse(r.Context(), merged)
+	})
+}
+
+func (f *Federation) queryRemote(ctx context.Context, codec tripperware.Codec, req tripperware.Request) (tripperware.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, f.cfg.Timeout)
+	defer cancel()
+
+	r, err := codec.EncodeRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	r = r.WithContext(ctx)
+	r.Header.Set(federatedQueryHeader, "true")
+	if err := user.InjectOrgIDIntoHTTPRequest(ctx, r); err != nil {
+		return nil, err
+	}
+
+	resp, err := f.remote.RoundTrip(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	return codec.DecodeResponse(ctx, resp, req)
+}
+
+// isMergeableResponse returns whether the input response is a successful response with
+// a result type which can be merged.
+func isMergeableResponse(resp tripperware.Response) bool {
+	switch r := resp.(type) {
+	case *queryrange.PrometheusResponse:
+		return r.Status == queryrange.StatusSuccess
+	case *instantquery.PrometheusInstantQueryResponse:
+		return r.Status == queryrange.StatusSuccess &&
+			(r.Data.ResultType == model.ValVector.String() || r.Data.ResultType == model.ValMatrix.String())
+	default:
+		return false
+	}
+}
+
+// dropResponseLabel removes the input label from all the series of the response.
+func dropResponseLabel(resp tripperware.Response, name string) {
+	switch r := resp.(type) {
+	case *queryrange.PrometheusResponse:
+		for i := range r.Data.Result {
+			r.Data.Result[i].Labels = dropLabel(r.Data.Result[i].Labels, name)
+		}
+	case *instantquery.PrometheusInstantQueryResponse:
+		if vector := r.Data.Result.GetVector(); vector != nil {
+			for _, sample := range vector.Samples {
+				sample.Labels = dropLabel(sample.Labels, name)
+			}
+		}
+		if matrix := r.Data.Result.GetMatrix(); matrix != nil {
+			for i := range matrix.SampleStreams {
+				matrix.SampleStreams[i].Labels = dropLabel(matrix.SampleStreams[i].Labels, name)
+			}
+		}
+	}
+}
+
+func dropLabel(lbls []cortexpb.LabelAdapter, name string) []cortexpb.LabelAdapter {
+	for i, l := range lbls {
+		if l.Name == name {
+			return append(lbls[:i:i], lbls[i+1:]...)
+		}
+	}
+	return lbls
+}
@@ -0,0 +1,156 @@
+package frontend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/instantquery"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
+)
+
+func TestFederation(t *testing.T) {
+	const (
+		localRangeResponse   = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","cell":"local"},"values":[[1,"1"],[2,"1"]]}]}}`
+		remoteRangeResponse  = `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"__name__":"up","cell":"remote"},"values":[[1,"1"],[2,"1"],[3,"1"]]},{"metric":{"__name__":"up","cell":"remote","job":"remote-only"},"values":[[1,"2"]]}]}}`
+		localInstantResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"up","cell":"local"},"value":[3,"1"]}]}}`
+		remoteInstantScalar  = `{"status":"success","data":{"resultType":"scalar","result":[3,"1"]}}`
+	)
+
+	type series struct {
+		Metric map[string]string `json:"metric"`
+		Values [][]interface{}   `json:"values"`
+		Value  []interface{}     `json:"value"`
+	}
+	type response struct {
+		Status string `json:"status"`
+		Data   struct {
+			ResultType string   `json:"resultType"`
+			Result     []series `json:"result"`
+		} `json:"data"`
+	}
+
+	tests := map[string]struct {
+		path             string
+		remoteStatusCode int
+		remoteResponse   string
+		expected         []series
+		expectedFailures int
+	}{
+		"should merge the range query results deduplicating the series by label": {
+			path:             "/api/v1/query_range?query=up&start=1&end=3&step=1",
+			remoteStatusCode: http.StatusOK,
+			remoteResponse:   remoteRangeResponse,
+			expected: []series{
+				{Metric: map[string]string{"__name__": "up"}, Values: [][]interface{}{{1.0, "1"}, {2.0, "1"}, {3.0, "1"}}},
+				{Metric: map[string]string{"__name__": "up", "job": "remote-only"}, Values: [][]interface{}{{1.0, "2"}}},
+			},
+		},
+		"should return the local results if the remote cell fails": {
+			path:             "/api/v1/query_range?query=up&start=1&end=3&step=1",
+			remoteStatusCode: http.StatusInternalServerError,
+			remoteResponse:   "error",
+			expected: []series{
+				{Metric: map[string]string{"__name__": "up", "cell": "local"}, Values: [][]interface{}{{1.0, "1"}, {2.0, "1"}}},
+			},
+			expectedFailures: 1,
+		},
+		"should return the local results if the results can't be merged": {
+			path:             "/api/v1/query?query=up&time=3",
+			remoteStatusCode: http.StatusOK,
+			remoteResponse:   remoteInstantScalar,
+			expected: []series{
+				{Metric: map[string]string{"__name__": "up", "cell": "local"}, Value: []interface{}{3.0, "1"}},
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "user-1", r.Header.Get(user.OrgIDHeaderName))
+				assert.Equal(t, "true", r.Header.Get(federatedQueryHeader))
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(testData.remoteStatusCode)
+				_, _ = w.Write([]byte(testData.remoteResponse))
+			}))
+			t.Cleanup(remote.Close)
+
+			reg := prometheus.NewPedanticRegistry()
+			federation, err := NewFederation(FederationConfig{RemoteURL: remote.URL, DedupLabel: "cell", Timeout: time.Minute},
+				http.DefaultTransport, queryrange.NewPrometheusCodec(false, false), instantquery.InstantQueryCodec, nil, log.NewNopLogger(), reg)
+			require.NoError(t, err)
+
+			rt := federation.Wrap(tripperware.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+				body := localRangeResponse
+				if strings.HasSuffix(r.URL.Path, "/query") {
+					body = localInstantResponse
+				}
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{"Content-Type": []string{"application/json"}},
+					Body:       io.NopCloser(strings.NewReader(body)),
+				}, nil
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, testData.path, nil)
+			req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+			resp, err := rt.RoundTrip(req)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+
+			actual := response{}
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&actual))
+			assert.Equal(t, "success", actual.Status)
+			assert.ElementsMatch(t, testData.expected, actual.Data.Result)
+
+			assert.Equal(t, 1.0, testutil.ToFloat64(federation.queries))
+			assert.Equal(t, float64(testData.expectedFailures), testutil.ToFloat64(federation.remoteFailures))
+		})
+	}
+}
+
+func TestFederation_ShouldNotFederateOtherRequests(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the remote cell should not be queried")
+	}))
+	t.Cleanup(remote.Close)
+
+	federation, err := NewFederation(FederationConfig{RemoteURL: remote.URL, Timeout: time.Minute},
+		http.DefaultTransport, queryrange.NewPrometheusCodec(false, false), instantquery.InstantQueryCodec, nil, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	rt := federation.Wrap(tripperware.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"status":"success","data":[]}`))}, nil
+	}))
+
+	// The requests other than the queries are not federated.
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/series?match[]=up", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The queries received from a remote cell are not federated again.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/query?query=up", nil)
+	req.Header.Set(federatedQueryHeader, "true")
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+	resp, err = rt.RoundTrip(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Equal(t, 0.0, testutil.ToFloat64(federation.queries))
+}
@@ -7,6 +7,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -33,6 +34,10 @@ const (
 	// StatusClientClosedRequest is the status code for when a client request cancellation of a http request
 	StatusClientClosedRequest = 499
 	ServiceTimingHeaderName   = "Server-Timing"
+
+	// QueryStatsHeaderName is the header used to request the query statistics, and the
+	// response header carrying them.
+	QueryStatsHeaderName = "X-Cortex-Query-Stats"
 )
 
 var (
@@ -54,6 +59,7 @@ const (
 	reasonSeriesLimitStoreGateway  = "store_gateway_series_limit"
 	reasonChunksLimitStoreGateway  = "store_gateway_chunks_limit"
 	reasonBytesLimitStoreGateway   = "store_gateway_bytes_limit"
+	reasonQueryBytesBudget         = "query_bytes_budget_exceeded"
 
 	limitTooManySamples    = `query processing would load too many samples into memory`
 	limitTimeRangeExceeded = `the query time range exceeds the limit`
@@ -73,12 +79,19 @@ type HandlerConfig struct {
 	LogQueriesLongerThan time.Duration `yaml:"log_queries_longer_than"`
 	MaxBodySize          int64         `yaml:"max_body_size"`
 	QueryStatsEnabled    bool          `yaml:"query_stats_enabled"`
+	QueryIDEnabled       bool          `yaml:"query_id_enabled"`
+	UserQueryStatsWindow time.Duration `yaml:"user_query_stats_window"`
+
+	QueryBytesBudget QueryBytesBudgetConfig `yaml:"query_bytes_budget"`
 }
 
 func (cfg *HandlerConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.LogQueriesLongerThan, "frontend.log-queries-longer-than", 0, "Log queries that are slower than the specified duration. Set to 0 to disable. Set to < 0 to enable on all queries.")
 	f.Int64Var(&cfg.MaxBodySize, "frontend.max-body-size", 10*1024*1024, "Max body size for downstream prometheus.")
 	f.BoolVar(&cfg.QueryStatsEnabled, "frontend.query-stats-enabled", false, "True to enable query statistics tracking. When enabled, a message with some statistics is logged for every query.")
+	f.BoolVar(&cfg.QueryIDEnabled, "frontend.query-id-enabled", false, "[Experimental] True to assign an ID to every query, returned in the X-Cortex-Query-Id response header. The ID is logged by every component processing the query, and each split and shard of the query gets a child ID derived from it. The ID is taken from the X-Cortex-Query-Id request header, if set. This flag must be set on all Cortex components.")
+	f.DurationVar(&cfg.UserQueryStatsWindow, "frontend.user-query-stats-window", time.Hour, "The rolling window the stats of the queries of each tenant are aggregated over, and returned by the /api/v1/user_query_stats endpoint. Only used when query statistics tracking is enabled.")
+	cfg.QueryBytesBudget.RegisterFlags(f)
 }
 
 // Handler accepts queries and forwards them to RoundTripper. It can log slow queries,
@@ -87,6 +100,7 @@ type Handler struct {
 	cfg          HandlerConfig
 	log          log.Logger
 	roundTripper http.RoundTripper
+	budget       *QueryBytesBudget
 
 	// Metrics.
 	querySeconds    *prometheus.CounterVec
@@ -94,15 +108,17 @@ type Handler struct {
 	queryChunkBytes *prometheus.CounterVec
 	queryDataBytes  *prometheus.CounterVec
 	rejectedQueries *prometheus.CounterVec
+	userStats       *userQueryStats
 	activeUsers     *util.ActiveUsersCleanupService
 }
 
-// NewHandler creates a new frontend handler.
-func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logger, reg prometheus.Registerer) *Handler {
+// NewHandler creates a new frontend handler. The budget is optional.
+func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, budget *QueryBytesBudget, log log.Logger, reg prometheus.Registerer) *Handler {
 	h := &Handler{
 		cfg:          cfg,
 		log:          log,
 		roundTripper: roundTripper,
+		budget:       budget,
 	}
 
 	if cfg.QueryStatsEnabled {
@@ -134,7 +150,10 @@ func NewHandler(cfg HandlerConfig, roundTripper http.RoundTripper, log log.Logge
 			[]string{"reason", "user"},
 		)
 
+		h.userStats = newUserQueryStats(cfg.UserQueryStatsWindow, reg)
+
 		h.activeUsers = util.NewActiveUsersCleanupWithDefaultValues(func(user string) {
+			h.userStats.deleteMetrics(user)
 			h.querySeconds.DeleteLabelValues(user)
 			h.querySeries.DeleteLabelValues(user)
 			h.queryChunkBytes.DeleteLabelValues(user)
@@ -163,8 +182,11 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	userID := tenant.JoinTenantIDs(tenantIDs)
 
 	// Initialise the stats in the context and make sure it's propagated
-	// down the request chain.
-	if f.cfg.QueryStatsEnabled {
+	// down the request chain. The stats are also tracked to account the fetched
+	// bytes in the query bytes budget. Users can only get the stats in the response
+	// when the query stats are enabled.
+	statsRequested := f.cfg.QueryStatsEnabled && strings.EqualFold(r.Header.Get(QueryStatsHeaderName), "true")
+	if f.cfg.QueryStatsEnabled || f.budget != nil {
 		// Check if querier stats is enabled in the context.
 		stats = querier_stats.FromContext(r.Context())
 		if stats == nil {
@@ -174,6 +196,16 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if f.cfg.QueryIDEnabled {
+		queryID := r.Header.Get(util_log.QueryIDHeaderName)
+		if queryID == "" {
+			queryID = fmt.Sprintf("%016x", rand.Uint64())
+			r.Header.Set(util_log.QueryIDHeaderName, queryID)
+		}
+		r = r.WithContext(util_log.ContextWithQueryID(r.Context(), queryID))
+		w.Header().Set(util_log.QueryIDHeaderName, queryID)
+	}
+
 	defer func() {
 		_ = r.Body.Close()
 	}()
@@ -200,10 +232,36 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r.Body = io.NopCloser(&buf)
 	}
 
+	if f.budget != nil {
+		for _, tenantID := range tenantIDs {
+			if err := f.budget.Check(r.Context(), tenantID); err != nil {
+				if f.cfg.QueryStatsEnabled {
+					f.rejectedQueries.WithLabelValues(reasonQueryBytesBudget, userID).Inc()
+				}
+				writeError(util_log.WithContext(r.Context(), f.log), w, err, w.Header())
+				return
+			}
+		}
+	}
+
 	startTime := time.Now()
 	resp, err := f.roundTripper.RoundTrip(r)
 	queryResponseTime := time.Since(startTime)
 
+	if f.budget != nil {
+		// The fetched bytes are accounted even if the client went away, and split across
+		// the tenants of a federated query.
+		fetched := stats.LoadFetchedDataBytes()
+		share := fetched / uint64(len(tenantIDs))
+		for i, tenantID := range tenantIDs {
+			if i == 0 {
+				f.budget.Add(tenantID, share+fetched%uint64(len(tenantIDs)))
+				continue
+			}
+			f.budget.Add(tenantID, share)
+		}
+	}
+
 	// Check whether we should parse the query string.
 	shouldReportSlowQuery := f.cfg.LogQueriesLongerThan != 0 && queryResponseTime > f.cfg.LogQueriesLongerThan
 	if shouldReportSlowQuery || f.cfg.QueryStatsEnabled {
@@ -238,6 +296,9 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if f.cfg.QueryStatsEnabled {
 		writeServiceTimingHeader(queryResponseTime, hs, stats)
 	}
+	if statsRequested {
+		writeQueryStatsHeader(hs, stats)
+	}
 
 	logger := util_log.WithContext(r.Context(), f.log)
 	if err != nil {
@@ -249,6 +310,9 @@ func (f *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		hs[h] = vs
 	}
 
+	// The body may be streamed while it's copied, so it must be closed to release its resources.
+	defer func() { _ = resp.Body.Close() }()
+
 	w.WriteHeader(resp.StatusCode)
 	// log copy response body error so that we will know even though success response code returned
 	bytesCopied, err := io.Copy(w, resp.Body)
@@ -298,6 +362,11 @@ func (f *Handler) reportQueryStats(r *http.Request, userID string, queryString u
 	numDataBytes := stats.LoadFetchedDataBytes()
 	numStoreGatewayTouchedPostings := stats.LoadStoreGatewayTouchedPostings()
 	numStoreGatewayTouchedPostingBytes := stats.LoadStoreGatewayTouchedPostingBytes()
+	numStoreGatewayChunkBytesAvoided := stats.LoadStoreGatewayChunkBytesAvoided()
+	numDeduplicatedChunks := stats.LoadDeduplicatedChunks()
+	numDeduplicatedChunkBytes := stats.LoadDeduplicatedChunkBytes()
+	numResultsCacheHits := stats.LoadResultsCacheHits()
+	numResultsCacheMisses := stats.LoadResultsCacheMisses()
 	splitQueries := stats.LoadSplitQueries()
 	dataSelectMaxTime := stats.LoadDataSelectMaxTime()
 	dataSelectMinTime := stats.LoadDataSelectMinTime()
@@ -307,6 +376,7 @@ func (f *Handler) reportQueryStats(r *http.Request, userID string, queryString u
 	f.querySeries.WithLabelValues(userID).Add(float64(numSeries))
 	f.queryChunkBytes.WithLabelValues(userID).Add(float64(numChunkBytes))
 	f.queryDataBytes.WithLabelValues(userID).Add(float64(numDataBytes))
+	f.userStats.add(userID, stats, queryFailureType(statusCode))
 	f.activeUsers.UpdateUserTimestamp(userID, time.Now())
 
 	var (
@@ -341,6 +411,20 @@ func (f *Handler) reportQueryStats(r *http.Request, userID string, queryString u
 		logMessage = append(logMessage, "store_gateway_touched_posting_bytes", numStoreGatewayTouchedPostingBytes)
 	}
 
+	if numStoreGatewayChunkBytesAvoided > 0 {
+		logMessage = append(logMessage, "store_gateway_chunk_bytes_avoided", numStoreGatewayChunkBytesAvoided)
+	}
+
+	if numDeduplicatedChunks > 0 {
+		logMessage = append(logMessage, "deduplicated_chunks_count", numDeduplicatedChunks)
+		logMessage = append(logMessage, "deduplicated_chunk_bytes", numDeduplicatedChunkBytes)
+	}
+
+	if numResultsCacheHits > 0 || numResultsCacheMisses > 0 {
+		logMessage = append(logMessage, "results_cache_hits", numResultsCacheHits)
+		logMessage = append(logMessage, "results_cache_misses", numResultsCacheMisses)
+	}
+
 	grafanaFields := formatGrafanaStatsFields(r)
 	if len(grafanaFields) > 0 {
 		logMessage = append(logMessage, grafanaFields...)
@@ -479,6 +563,29 @@ func writeServiceTimingHeader(queryResponseTime time.Duration, headers http.Head
 	}
 }
 
+// writeQueryStatsHeader writes the statistics of the query data fetched from ingesters and store-gateways,
+// and of the results cache lookups, so that users can see why a query was slow.
+func writeQueryStatsHeader(headers http.Header, stats *querier_stats.QueryStats) {
+	if stats == nil {
+		return
+	}
+	parts := []string{
+		"fetched_series=" + strconv.FormatUint(stats.LoadFetchedSeries(), 10),
+		"fetched_chunks=" + strconv.FormatUint(stats.LoadFetchedChunks(), 10),
+		"fetched_chunk_bytes=" + strconv.FormatUint(stats.LoadFetchedChunkBytes(), 10),
+		"fetched_data_bytes=" + strconv.FormatUint(stats.LoadFetchedDataBytes(), 10),
+		"fetched_samples=" + strconv.FormatUint(stats.LoadFetchedSamples(), 10),
+		"split_queries=" + strconv.FormatUint(stats.LoadSplitQueries(), 10),
+		"store_gateway_touched_postings=" + strconv.FormatUint(stats.LoadStoreGatewayTouchedPostings(), 10),
+		"store_gateway_touched_posting_bytes=" + strconv.FormatUint(stats.LoadStoreGatewayTouchedPostingBytes(), 10),
+		"store_gateway_queried_blocks=" + strconv.FormatUint(stats.LoadStoreGatewayQueriedBlocks(), 10),
+		"store_gateway_chunk_bytes_avoided=" + strconv.FormatUint(stats.LoadStoreGatewayChunkBytesAvoided(), 10),
+		"results_cache_hits=" + strconv.FormatUint(stats.LoadResultsCacheHits(), 10),
+		"results_cache_misses=" + strconv.FormatUint(stats.LoadResultsCacheMisses(), 10),
+	}
+	headers.Set(QueryStatsHeaderName, strings.Join(parts, ", "))
+}
+
 func statsValue(name string, d time.Duration) string {
 	durationInMs := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', -1, 64)
 	return name + ";dur=" + durationInMs
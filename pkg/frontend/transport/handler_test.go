@@ -378,7 +378,7 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			reg := prometheus.NewPedanticRegistry()
-			handler := NewHandler(tt.cfg, tt.roundTripperFunc, log.NewNopLogger(), reg)
+			handler := NewHandler(tt.cfg, tt.roundTripperFunc, nil, log.NewNopLogger(), reg)
 
 			ctx := user.InjectOrgID(context.Background(), userID)
 			req := httptest.NewRequest("GET", "/", nil)
@@ -406,10 +406,100 @@ func TestHandler_ServeHTTP(t *testing.T) {
 	}
 }
 
+func TestHandler_ServeHTTP_QueryID(t *testing.T) {
+	var (
+		receivedQueryID  string
+		receivedHeaderID string
+	)
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		receivedQueryID = util_log.QueryIDFromContext(req.Context())
+		receivedHeaderID = req.Header.Get(util_log.QueryIDHeaderName)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	handler := NewHandler(HandlerConfig{QueryIDEnabled: true}, roundTripper, nil, log.NewNopLogger(), nil)
+	ctx := user.InjectOrgID(context.Background(), "12345")
+
+	// A query ID is generated if the request has none.
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	require.NotEmpty(t, receivedQueryID)
+	assert.Equal(t, receivedQueryID, receivedHeaderID)
+	assert.Equal(t, receivedQueryID, resp.Header().Get(util_log.QueryIDHeaderName))
+
+	// The query ID of the request is used, if any.
+	req = httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	req.Header.Set(util_log.QueryIDHeaderName, "abc")
+	resp = httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "abc", receivedQueryID)
+	assert.Equal(t, "abc", resp.Header().Get(util_log.QueryIDHeaderName))
+}
+
+func TestHandler_ServeHTTP_QueryStatsHeader(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		stats := querier_stats.FromContext(req.Context())
+		stats.AddFetchedSeries(3)
+		stats.AddFetchedChunks(5)
+		stats.AddFetchedChunkBytes(1024)
+		stats.AddFetchedDataBytes(2048)
+		stats.AddFetchedSamples(100)
+		stats.AddSplitQueries(2)
+		stats.AddStoreGatewayTouchedPostings(7)
+		stats.AddStoreGatewayTouchedPostingBytes(512)
+		stats.AddStoreGatewayQueriedBlocks(4)
+		stats.AddStoreGatewayChunkBytesAvoided(4096)
+		stats.AddResultsCacheHits(1)
+		stats.AddResultsCacheMisses(2)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	tests := map[string]struct {
+		queryStatsEnabled bool
+		expectedHeader    string
+	}{
+		"query stats enabled": {
+			queryStatsEnabled: true,
+			expectedHeader:    "fetched_series=3, fetched_chunks=5, fetched_chunk_bytes=1024, fetched_data_bytes=2048, fetched_samples=100, split_queries=2, store_gateway_touched_postings=7, store_gateway_touched_posting_bytes=512, store_gateway_queried_blocks=4, store_gateway_chunk_bytes_avoided=4096, results_cache_hits=1, results_cache_misses=2",
+		},
+		"query stats disabled": {
+			// The clients can't enable the stats when the operator disabled them.
+			queryStatsEnabled: false,
+			expectedHeader:    "",
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			handler := NewHandler(HandlerConfig{QueryStatsEnabled: testData.queryStatsEnabled}, roundTripper, nil, log.NewNopLogger(), nil)
+			ctx := user.InjectOrgID(context.Background(), "12345")
+
+			req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+			req.Header.Set(QueryStatsHeaderName, "true")
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			require.Equal(t, http.StatusOK, resp.Code)
+			assert.Equal(t, testData.expectedHeader, resp.Header().Get(QueryStatsHeaderName))
+		})
+	}
+}
+
 func TestReportQueryStatsFormat(t *testing.T) {
 	outputBuf := bytes.NewBuffer(nil)
 	logger := log.NewSyncLogger(log.NewLogfmtLogger(outputBuf))
-	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true}, http.DefaultTransport, logger, nil)
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true}, http.DefaultTransport, nil, logger, nil)
 	userID := "fake"
 	req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/prometheus/api/v1/query", nil)
 	resp := &http.Response{ContentLength: 1000}
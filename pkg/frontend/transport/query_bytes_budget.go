@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+const (
+	budgetDayLayout = "2006-01-02"
+
+	errQueryBytesBudgetExceeded = "the daily query bytes budget of %d bytes has been exceeded, queries are rejected until %s"
+)
+
+var errInvalidQueryBytesBudgetFlushInterval = errors.New("the query bytes budget flush interval must be greater than 0")
+
+// QueryBytesBudgetConfig configures the daily query bytes budget of the tenants.
+type QueryBytesBudgetConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	KVStore       kv.Config     `yaml:"kvstore" doc:"description=Backend storage to use for the bytes fetched by the queries of each tenant, shared by all query-frontends. Please be aware that memberlist is not supported."`
+}
+
+func (cfg *QueryBytesBudgetConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "frontend.query-bytes-budget.enabled", false, "[Experimental] True to track the size of the data fetched by the queries of each tenant per day, and reject the queries of the tenants exceeding their -frontend.max-query-bytes-per-day limit.")
+	f.DurationVar(&cfg.FlushInterval, "frontend.query-bytes-budget.flush-interval", 15*time.Second, "[Experimental] How frequently the bytes fetched by the queries are flushed to the KV store, and the usage of the tenants is read back from it. In between, the budget is enforced on the usage known locally, so the tenants can exceed it by the bytes fetched across all query-frontends in an interval.")
+	cfg.KVStore.RegisterFlagsWithPrefix("frontend.query-bytes-budget.", "query-bytes-budget/", f)
+}
+
+func (cfg *QueryBytesBudgetConfig) Validate() error {
+	if cfg.Enabled && cfg.FlushInterval <= 0 {
+		return errInvalidQueryBytesBudgetFlushInterval
+	}
+	return nil
+}
+
+// QueryBytesBudgetLimits are the per-tenant limits of the query bytes budget.
+type QueryBytesBudgetLimits interface {
+	MaxQueryBytesPerDay(userID string) int64
+}
+
+// QueryBytesBudget tracks the size of the data fetched by the queries of each tenant in
+// the current day (UTC) in a KV store, so that the budget is shared by all query-frontends.
+// The value of each tenant is stored as "<day>:<bytes>" and is reset on day rollover.
+//
+// The queries are checked and accounted locally, and the bytes fetched are periodically
+// flushed to the KV store, reading back the usage of all query-frontends.
+type QueryBytesBudget struct {
+	services.Service
+
+	kv     kv.Client
+	limits QueryBytesBudgetLimits
+	logger log.Logger
+	now    func() time.Time
+
+	mtx   sync.Mutex
+	usage map[string]*tenantBudgetUsage
+}
+
+// tenantBudgetUsage is the usage of a tenant known by the query-frontend.
+type tenantBudgetUsage struct {
+	day string
+	// The usage read from the KV store on the last flush.
+	flushed uint64
+	// The bytes fetched by the queries since the last flush.
+	pending uint64
+}
+
+// NewQueryBytesBudget makes a new QueryBytesBudget.
+func NewQueryBytesBudget(cfg QueryBytesBudgetConfig, limits QueryBytesBudgetLimits, logger log.Logger, reg prometheus.Registerer) (*QueryBytesBudget, error) {
+	client, err := kv.NewClient(cfg.KVStore, codec.String{}, kv.RegistererWithKVName(reg, "frontend-query-bytes-budget"), logger)
+	if err != nil {
+		return nil, err
+	}
+
+	b := newQueryBytesBudget(client, limits, logger)
+	b.Service = services.NewTimerService(cfg.FlushInterval, nil, b.flush, b.stopping)
+	return b, nil
+}
+
+func newQueryBytesBudget(client kv.Client, limits QueryBytesBudgetLimits, logger log.Logger) *QueryBytesBudget {
+	return &QueryBytesBudget{
+		kv:     client,
+		limits: limits,
+		logger: logger,
+		now:    time.Now,
+		usage:  map[string]*tenantBudgetUsage{},
+	}
+}
+
+// Check returns an error if the tenant has exceeded its budget for the day. The usage of
+// a tenant is read from the KV store the first time it's checked, and errors reading it
+// are logged and don't reject the query.
+func (b *QueryBytesBudget) Check(ctx context.Context, userID string) error {
+	limit := b.limits.MaxQueryBytesPerDay(userID)
+	if limit <= 0 {
+		return nil
+	}
+
+	now := b.now().UTC()
+	day := now.Format(budgetDayLayout)
+
+	b.mtx.Lock()
+	_, tracked := b.usage[userID]
+	b.mtx.Unlock()
+
+	var flushed uint64
+	if !tracked {
+		value, err := b.kv.Get(ctx, userID)
+		if err != nil {
+			level.Warn(b.logger).Log("msg", "failed to read the query bytes budget", "user", userID, "err", err)
+		}
+		flushed = budgetUsage(value, day)
+	}
+
+	b.mtx.Lock()
+	u, ok := b.usage[userID]
+	if !ok {
+		u = &tenantBudgetUsage{day: day, flushed: flushed}
+		b.usage[userID] = u
+	}
+	used := u.usedOn(day)
+	b.mtx.Unlock()
+
+	if used < uint64(limit) {
+		return nil
+	}
+
+	rollover := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+	return httpgrpc.Errorf(http.StatusTooManyRequests, errQueryBytesBudgetExceeded, limit, rollover.Format(time.RFC3339))
+}
+
+// Add adds the bytes fetched by a query of the tenant to its budget usage for the day.
+// The bytes are flushed to the KV store on the next flush.
+func (b *QueryBytesBudget) Add(userID string, bytes uint64) {
+	if bytes == 0 || b.limits.MaxQueryBytesPerDay(userID) <= 0 {
+		return
+	}
+
+	day := b.now().UTC().Format(budgetDayLayout)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	u, ok := b.usage[userID]
+	if !ok {
+		u = &tenantBudgetUsage{day: day}
+		b.usage[userID] = u
+	}
+	if u.day != day {
+		*u = tenantBudgetUsage{day: day}
+	}
+	u.pending += bytes
+}
+
+// usedOn returns the bytes used on the input day. Must be called with the lock held.
+func (u *tenantBudgetUsage) usedOn(day string) uint64 {
+	if u.day != day {
+		return 0
+	}
+	return u.flushed + u.pending
+}
+
+// flush adds the bytes fetched since the last flush to the usage of each tenant in the
+// KV store, and reads back the usage of the tenants, including the bytes flushed by the
+// other query-frontends. Errors are logged and the bytes are flushed again the next time.
+func (b *QueryBytesBudget) flush(ctx context.Context) error {
+	day := b.now().UTC().Format(budgetDayLayout)
+
+	// Take the bytes to flush, and stop tracking the tenants without limit or not queried today.
+	pending := map[string]uint64{}
+	b.mtx.Lock()
+	for userID, u := range b.usage {
+		if u.day != day || b.limits.MaxQueryBytesPerDay(userID) <= 0 {
+			delete(b.usage, userID)
+			continue
+		}
+		pending[userID] = u.pending
+		u.pending = 0
+	}
+	b.mtx.Unlock()
+
+	for userID, bytes := range pending {
+		flushed, err := b.flushTenant(ctx, userID, day, bytes)
+
+		b.mtx.Lock()
+		if u, ok := b.usage[userID]; ok && u.day == day {
+			if err != nil {
+				u.pending += bytes
+			} else {
+				u.flushed = flushed
+			}
+		}
+		b.mtx.Unlock()
+
+		if err != nil {
+			level.Warn(b.logger).Log("msg", "failed to update the query bytes budget", "user", userID, "err", err)
+		}
+	}
+
+	return nil
+}
+
+// flushTenant adds the input bytes to the usage of the tenant on the input day in the KV
+// store, and returns the resulting usage. The usage is only read if there are no bytes.
+func (b *QueryBytesBudget) flushTenant(ctx context.Context, userID, day string, bytes uint64) (uint64, error) {
+	if bytes == 0 {
+		value, err := b.kv.Get(ctx, userID)
+		return budgetUsage(value, day), err
+	}
+
+	var flushed uint64
+	err := b.kv.CAS(ctx, userID, func(in interface{}) (out interface{}, retry bool, err error) {
+		flushed = budgetUsage(in, day) + bytes
+		return fmt.Sprintf("%s:%d", day, flushed), true, nil
+	})
+	return flushed, err
+}
+
+func (b *QueryBytesBudget) stopping(_ error) error {
+	// Flush the bytes fetched since the last flush before shutting down.
+	return b.flush(context.Background())
+}
+
+// budgetUsage returns the bytes used on the input day from the KV store value, which is 0
+// if the value is missing, invalid or from another day.
+func budgetUsage(value interface{}, day string) uint64 {
+	s, ok := value.(string)
+	if !ok {
+		return 0
+	}
+
+	valueDay, bytes, ok := strings.Cut(s, ":")
+	if !ok || valueDay != day {
+		return 0
+	}
+
+	used, err := strconv.ParseUint(bytes, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return used
+}
@@ -0,0 +1,171 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+type queryBytesBudgetLimits map[string]int64
+
+func (l queryBytesBudgetLimits) MaxQueryBytesPerDay(userID string) int64 {
+	return l[userID]
+}
+
+func newTestQueryBytesBudget(t *testing.T, limits queryBytesBudgetLimits, now *time.Time) *QueryBytesBudget {
+	client, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	budget := newQueryBytesBudget(client, limits, log.NewNopLogger())
+	budget.now = func() time.Time { return *now }
+	return budget
+}
+
+func TestQueryBytesBudget(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 10, 22, 0, 0, 0, time.UTC)
+	budget := newTestQueryBytesBudget(t, queryBytesBudgetLimits{"user-1": 100}, &now)
+
+	require.NoError(t, budget.Check(ctx, "user-1"))
+
+	budget.Add("user-1", 60)
+	require.NoError(t, budget.Check(ctx, "user-1"))
+
+	// The query exceeding the budget completes, but the next ones are rejected, even before
+	// the usage is flushed.
+	budget.Add("user-1", 60)
+	err := budget.Check(ctx, "user-1")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the daily query bytes budget of 100 bytes has been exceeded, queries are rejected until 2024-05-11T00:00:00Z")
+
+	value, err := budget.kv.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	require.NoError(t, budget.flush(ctx))
+	value, err = budget.kv.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-10:120", value)
+
+	// Tenants without a limit are never rejected, nor tracked.
+	budget.Add("user-2", 1000)
+	require.NoError(t, budget.Check(ctx, "user-2"))
+	require.NoError(t, budget.flush(ctx))
+	value, err = budget.kv.Get(ctx, "user-2")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	// The budget is reset on the next day.
+	now = now.Add(3 * time.Hour)
+	require.NoError(t, budget.Check(ctx, "user-1"))
+	budget.Add("user-1", 10)
+	require.NoError(t, budget.flush(ctx))
+	value, err = budget.kv.Get(ctx, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-05-11:10", value)
+}
+
+func TestQueryBytesBudget_ShouldShareTheUsageAcrossQueryFrontends(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 10, 22, 0, 0, 0, time.UTC)
+	limits := queryBytesBudgetLimits{"user-1": 100}
+
+	client, closer := consul.NewInMemoryClient(codec.String{}, log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	budgets := make([]*QueryBytesBudget, 2)
+	for i := range budgets {
+		budgets[i] = newQueryBytesBudget(client, limits, log.NewNopLogger())
+		budgets[i].now = func() time.Time { return now }
+	}
+
+	require.NoError(t, budgets[0].Check(ctx, "user-1"))
+	require.NoError(t, budgets[1].Check(ctx, "user-1"))
+	budgets[0].Add("user-1", 60)
+	budgets[1].Add("user-1", 60)
+
+	// Each query-frontend only knows its own usage until flushed.
+	require.NoError(t, budgets[0].Check(ctx, "user-1"))
+	require.NoError(t, budgets[1].Check(ctx, "user-1"))
+
+	require.NoError(t, budgets[0].flush(ctx))
+	require.NoError(t, budgets[1].flush(ctx))
+	require.Error(t, budgets[1].Check(ctx, "user-1"))
+
+	// The first query-frontend reads back the usage of the second one on the next flush.
+	require.NoError(t, budgets[0].Check(ctx, "user-1"))
+	require.NoError(t, budgets[0].flush(ctx))
+	require.Error(t, budgets[0].Check(ctx, "user-1"))
+
+	// The usage is read from the KV store when a tenant is checked the first time.
+	restarted := newQueryBytesBudget(client, limits, log.NewNopLogger())
+	restarted.now = func() time.Time { return now }
+	require.Error(t, restarted.Check(ctx, "user-1"))
+}
+
+func TestHandler_ServeHTTP_QueryBytesBudget(t *testing.T) {
+	now := time.Now()
+	budget := newTestQueryBytesBudget(t, queryBytesBudgetLimits{"user-1": 100}, &now)
+
+	queries := 0
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		queries++
+		querier_stats.FromContext(req.Context()).AddFetchedDataBytes(60)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true}, roundTripper, budget, log.NewNopLogger(), nil)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	expectedCodes := []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}
+	for _, expected := range expectedCodes {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/query", nil).WithContext(ctx))
+		require.Equal(t, expected, resp.Code)
+	}
+	assert.Equal(t, 2, queries)
+}
+
+func TestHandler_ServeHTTP_QueryBytesBudget_ShouldSplitTheBytesAcrossTenants(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() { tenant.WithDefaultResolver(tenant.NewSingleResolver()) })
+
+	now := time.Now()
+	budget := newTestQueryBytesBudget(t, queryBytesBudgetLimits{"user-1": 1000, "user-2": 1000}, &now)
+
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		querier_stats.FromContext(req.Context()).AddFetchedDataBytes(101)
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("{}")),
+		}, nil
+	})
+
+	handler := NewHandler(HandlerConfig{}, roundTripper, budget, log.NewNopLogger(), nil)
+	ctx := user.InjectOrgID(context.Background(), "user-1|user-2")
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest("GET", "/api/v1/query", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	day := now.UTC().Format(budgetDayLayout)
+	assert.Equal(t, uint64(51), budget.usage["user-1"].usedOn(day))
+	assert.Equal(t, uint64(50), budget.usage["user-2"].usedOn(day))
+}
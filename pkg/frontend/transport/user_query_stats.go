@@ -0,0 +1,227 @@
+package transport
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// userQueryStatsBuckets is the number of buckets the rolling window of the user query stats
+// is split into.
+const userQueryStatsBuckets = 60
+
+const (
+	failureCanceled        = "canceled"
+	failureTimeout         = "timeout"
+	failureTooManyRequests = "too_many_requests"
+	failureLimitExceeded   = "limit_exceeded"
+	failureBadRequest      = "bad_request"
+	failureServerError     = "server_error"
+)
+
+// UserQueryStats are the stats of the queries of a tenant aggregated over the rolling window.
+type UserQueryStats struct {
+	WindowSeconds        float64           `json:"window_seconds"`
+	Queries              uint64            `json:"queries"`
+	QueryWallTimeSeconds float64           `json:"query_wall_time_seconds"`
+	FetchedSeries        uint64            `json:"fetched_series"`
+	FetchedChunksBytes   uint64            `json:"fetched_chunks_bytes"`
+	FetchedDataBytes     uint64            `json:"fetched_data_bytes"`
+	ResultsCacheHits     uint64            `json:"results_cache_hits"`
+	ResultsCacheMisses   uint64            `json:"results_cache_misses"`
+	ResultsCacheHitRatio float64           `json:"results_cache_hit_ratio"`
+	Failures             map[string]uint64 `json:"failures"`
+}
+
+type userQueryStatsBucket struct {
+	start int64
+	stats UserQueryStats
+}
+
+// userQueryStats aggregates the stats of the queries of each tenant into rolling counters,
+// kept in buckets covering the window, and exposes them as metrics too.
+type userQueryStats struct {
+	window time.Duration
+	bucket time.Duration
+	now    func() time.Time
+
+	mtx        sync.Mutex
+	users      map[string][]userQueryStatsBucket
+	lastPurged int64
+
+	queries            *prometheus.CounterVec
+	failedQueries      *prometheus.CounterVec
+	resultsCacheHits   *prometheus.CounterVec
+	resultsCacheMisses *prometheus.CounterVec
+}
+
+func newUserQueryStats(window time.Duration, reg prometheus.Registerer) *userQueryStats {
+	return &userQueryStats{
+		window: window,
+		bucket: max(window/userQueryStatsBuckets, time.Second),
+		now:    time.Now,
+		users:  map[string][]userQueryStatsBucket{},
+
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_user_queries_total",
+			Help: "Total number of queries run by the tenant, whose stats are tracked.",
+		}, []string{"user"}),
+		failedQueries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_user_failed_queries_total",
+			Help: "Total number of queries run by the tenant which failed, by type of failure.",
+		}, []string{"user", "type"}),
+		resultsCacheHits: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_user_results_cache_hits_total",
+			Help: "Total number of lookups in the results cache which found cached results for the queries of the tenant.",
+		}, []string{"user"}),
+		resultsCacheMisses: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_query_frontend_user_results_cache_misses_total",
+			Help: "Total number of lookups in the results cache which found no cached results for the queries of the tenant.",
+		}, []string{"user"}),
+	}
+}
+
+// add accounts the stats of a query of the user. The query failed if the failure type isn't empty.
+func (s *userQueryStats) add(userID string, stats *querier_stats.QueryStats, failure string) {
+	hits, misses := stats.LoadResultsCacheHits(), stats.LoadResultsCacheMisses()
+
+	s.queries.WithLabelValues(userID).Inc()
+	s.resultsCacheHits.WithLabelValues(userID).Add(float64(hits))
+	s.resultsCacheMisses.WithLabelValues(userID).Add(float64(misses))
+	if failure != "" {
+		s.failedQueries.WithLabelValues(userID, failure).Inc()
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	start := s.now().UnixNano() / int64(s.bucket)
+	if start != s.lastPurged {
+		s.purgeLocked(start)
+	}
+
+	buckets, ok := s.users[userID]
+	if !ok {
+		buckets = make([]userQueryStatsBucket, userQueryStatsBuckets)
+		s.users[userID] = buckets
+	}
+
+	b := &buckets[start%userQueryStatsBuckets]
+	if b.start != start {
+		*b = userQueryStatsBucket{start: start}
+	}
+
+	b.stats.Queries++
+	b.stats.QueryWallTimeSeconds += stats.LoadWallTime().Seconds()
+	b.stats.FetchedSeries += stats.LoadFetchedSeries()
+	b.stats.FetchedChunksBytes += stats.LoadFetchedChunkBytes()
+	b.stats.FetchedDataBytes += stats.LoadFetchedDataBytes()
+	b.stats.ResultsCacheHits += hits
+	b.stats.ResultsCacheMisses += misses
+	if failure != "" {
+		if b.stats.Failures == nil {
+			b.stats.Failures = map[string]uint64{}
+		}
+		b.stats.Failures[failure]++
+	}
+}
+
+// purgeLocked removes the stats of the users which have run no query within the window,
+// once per bucket.
+func (s *userQueryStats) purgeLocked(current int64) {
+	s.lastPurged = current
+
+	for userID, buckets := range s.users {
+		active := false
+		for _, b := range buckets {
+			if b.start > current-userQueryStatsBuckets {
+				active = true
+				break
+			}
+		}
+		if !active {
+			delete(s.users, userID)
+		}
+	}
+}
+
+// report returns the stats of the queries of the user over the window.
+func (s *userQueryStats) report(userID string) UserQueryStats {
+	res := UserQueryStats{WindowSeconds: s.window.Seconds(), Failures: map[string]uint64{}}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	oldest := s.now().Add(-s.window).UnixNano() / int64(s.bucket)
+	for _, b := range s.users[userID] {
+		if b.start <= oldest {
+			continue
+		}
+		res.Queries += b.stats.Queries
+		res.QueryWallTimeSeconds += b.stats.QueryWallTimeSeconds
+		res.FetchedSeries += b.stats.FetchedSeries
+		res.FetchedChunksBytes += b.stats.FetchedChunksBytes
+		res.FetchedDataBytes += b.stats.FetchedDataBytes
+		res.ResultsCacheHits += b.stats.ResultsCacheHits
+		res.ResultsCacheMisses += b.stats.ResultsCacheMisses
+		for failure, count := range b.stats.Failures {
+			res.Failures[failure] += count
+		}
+	}
+
+	if lookups := res.ResultsCacheHits + res.ResultsCacheMisses; lookups > 0 {
+		res.ResultsCacheHitRatio = float64(res.ResultsCacheHits) / float64(lookups)
+	}
+	return res
+}
+
+// deleteMetrics removes the metrics of the user. Its stats are kept until they're out of the window.
+func (s *userQueryStats) deleteMetrics(userID string) {
+	s.queries.DeleteLabelValues(userID)
+	s.resultsCacheHits.DeleteLabelValues(userID)
+	s.resultsCacheMisses.DeleteLabelValues(userID)
+	s.failedQueries.DeletePartialMatch(prometheus.Labels{"user": userID})
+}
+
+// queryFailureType returns the type of failure of a query from its status code, or an empty
+// string if the query succeeded.
+func queryFailureType(statusCode int) string {
+	switch {
+	case statusCode/100 == 2 || statusCode == 0:
+		return ""
+	case statusCode == StatusClientClosedRequest:
+		return failureCanceled
+	case statusCode == http.StatusGatewayTimeout:
+		return failureTimeout
+	case statusCode == http.StatusTooManyRequests:
+		return failureTooManyRequests
+	case statusCode == http.StatusUnprocessableEntity || statusCode == http.StatusRequestEntityTooLarge:
+		return failureLimitExceeded
+	case statusCode/100 == 4:
+		return failureBadRequest
+	default:
+		return failureServerError
+	}
+}
+
+// UserQueryStatsHandler returns the stats of the queries of the tenant over the rolling window.
+func (f *Handler) UserQueryStatsHandler(w http.ResponseWriter, r *http.Request) {
+	tenantIDs, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if f.userStats == nil {
+		http.Error(w, "query stats are not enabled", http.StatusNotFound)
+		return
+	}
+
+	util.WriteJSONResponse(w, f.userStats.report(tenant.JoinTenantIDs(tenantIDs)))
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promtest "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+)
+
+func TestHandler_UserQueryStats(t *testing.T) {
+	roundTripper := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		stats := querier_stats.FromContext(req.Context())
+		stats.AddFetchedDataBytes(100)
+		if req.URL.Query().Get("query") == "cached" {
+			stats.AddResultsCacheHits(3)
+			stats.AddResultsCacheMisses(1)
+		}
+		if req.URL.Query().Get("query") == "slow" {
+			return &http.Response{StatusCode: http.StatusGatewayTimeout, Body: io.NopCloser(strings.NewReader("timeout"))}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	})
+
+	reg := prometheus.NewPedanticRegistry()
+	handler := NewHandler(HandlerConfig{QueryStatsEnabled: true, UserQueryStatsWindow: time.Hour}, roundTripper, nil, log.NewNopLogger(), reg)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	for _, query := range []string{"cached", "slow"} {
+		req := httptest.NewRequest("GET", "/api/v1/query?query="+query, nil).WithContext(ctx)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	resp := httptest.NewRecorder()
+	handler.UserQueryStatsHandler(resp, httptest.NewRequest("GET", "/api/v1/user_query_stats", nil).WithContext(ctx))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var stats UserQueryStats
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &stats))
+	assert.Equal(t, UserQueryStats{
+		WindowSeconds:        3600,
+		Queries:              2,
+		FetchedDataBytes:     200,
+		ResultsCacheHits:     3,
+		ResultsCacheMisses:   1,
+		ResultsCacheHitRatio: 0.75,
+		Failures:             map[string]uint64{failureTimeout: 1},
+	}, stats)
+
+	assert.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_frontend_user_queries_total Total number of queries run by the tenant, whose stats are tracked.
+		# TYPE cortex_query_frontend_user_queries_total counter
+		cortex_query_frontend_user_queries_total{user="user-1"} 2
+		# HELP cortex_query_frontend_user_failed_queries_total Total number of queries run by the tenant which failed, by type of failure.
+		# TYPE cortex_query_frontend_user_failed_queries_total counter
+		cortex_query_frontend_user_failed_queries_total{type="timeout",user="user-1"} 1
+		# HELP cortex_query_frontend_user_results_cache_hits_total Total number of lookups in the results cache which found cached results for the queries of the tenant.
+		# TYPE cortex_query_frontend_user_results_cache_hits_total counter
+		cortex_query_frontend_user_results_cache_hits_total{user="user-1"} 3
+		# HELP cortex_query_frontend_user_results_cache_misses_total Total number of lookups in the results cache which found no cached results for the queries of the tenant.
+		# TYPE cortex_query_frontend_user_results_cache_misses_total counter
+		cortex_query_frontend_user_results_cache_misses_total{user="user-1"} 1
+	`),
+		"cortex_query_frontend_user_queries_total",
+		"cortex_query_frontend_user_failed_queries_total",
+		"cortex_query_frontend_user_results_cache_hits_total",
+		"cortex_query_frontend_user_results_cache_misses_total",
+	))
+}
+
+func TestHandler_UserQueryStats_Disabled(t *testing.T) {
+	handler := NewHandler(HandlerConfig{}, http.DefaultTransport, nil, log.NewNopLogger(), nil)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	resp := httptest.NewRecorder()
+	handler.UserQueryStatsHandler(resp, httptest.NewRequest("GET", "/api/v1/user_query_stats", nil).WithContext(ctx))
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
+
+func TestUserQueryStats_RollingWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	s := newUserQueryStats(time.Hour, nil)
+	s.now = func() time.Time { return now }
+
+	stats := &querier_stats.QueryStats{}
+	stats.AddFetchedSeries(10)
+
+	s.add("user-1", stats, "")
+	now = now.Add(30 * time.Minute)
+	s.add("user-1", stats, failureBadRequest)
+	s.add("user-2", stats, "")
+
+	assert.Equal(t, uint64(2), s.report("user-1").Queries)
+	assert.Equal(t, uint64(20), s.report("user-1").FetchedSeries)
+
+	// The queries out of the window are not accounted anymore.
+	now = now.Add(45 * time.Minute)
+	report := s.report("user-1")
+	assert.Equal(t, uint64(1), report.Queries)
+	assert.Equal(t, map[string]uint64{failureBadRequest: 1}, report.Failures)
+
+	// The users without queries in the window are purged.
+	now = now.Add(time.Hour)
+	s.add("user-2", stats, "")
+	assert.NotContains(t, s.users, "user-1")
+	assert.Equal(t, uint64(1), s.report("user-2").Queries)
+}
+
+func TestQueryFailureType(t *testing.T) {
+	for statusCode, expected := range map[int]string{
+		http.StatusOK:                    "",
+		StatusClientClosedRequest:        failureCanceled,
+		http.StatusGatewayTimeout:        failureTimeout,
+		http.StatusTooManyRequests:       failureTooManyRequests,
+		http.StatusUnprocessableEntity:   failureLimitExceeded,
+		http.StatusBadRequest:            failureBadRequest,
+		http.StatusInternalServerError:   failureServerError,
+		http.StatusRequestEntityTooLarge: failureLimitExceeded,
+	} {
+		assert.Equal(t, expected, queryFailureType(statusCode), "status code %d", statusCode)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/scheduler/queue"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
 	"github.com/cortexproject/cortex/pkg/util/httpgrpcutil"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/validation"
@@ -271,6 +272,9 @@ func (f *Frontend) Process(server frontendv1pb.Frontend_ProcessServer) error {
 			continue
 		}
 
+		// Sign the tenant once dequeued, so that the time spent in the queue doesn't expire the signature.
+		grpcclient.SignTenantHTTPRequest(req.originalCtx, req.request)
+
 		// Handle the stream sending & receiving on a goroutine so we can
 		// monitoring the contexts in a select and cancel things appropriately.
 		resps := make(chan *frontendv1pb.ClientToFrontend, 1)
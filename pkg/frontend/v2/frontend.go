@@ -94,6 +94,15 @@ type frontendRequest struct {
 	response chan *frontendv2pb.QueryResultRequest
 
 	retryOnTooManyOutstandingRequests bool
+
+	// Addresses of the query-schedulers which have handed off the request because they were shutting down.
+	// The request is not enqueued to them again.
+	excludedSchedulers map[string]struct{}
+}
+
+func (r *frontendRequest) isSchedulerExcluded(addr string) bool {
+	_, ok := r.excludedSchedulers[addr]
+	return ok
 }
 
 type enqueueStatus int
@@ -104,11 +113,17 @@ const (
 
 	// Failed to forward request to scheduler, frontend will try again.
 	failed
+
+	// Not forwarded to the scheduler because it has handed off the request already, frontend will try
+	// again with another scheduler.
+	excluded
 )
 
 type enqueueResult struct {
 	status enqueueStatus
 
+	schedulerAddr string // Address of the scheduler the request has been enqueued to.
+
 	cancelCh chan<- uint64 // Channel that can be used for request cancellation. If nil, cancellation is not possible.
 }
 
@@ -221,7 +236,10 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 			// Enqueued, let's wait for response.
 		}
 
-		var cancelCh chan<- uint64
+		var (
+			cancelCh      chan<- uint64
+			schedulerAddr string
+		)
 
 		select {
 		case <-ctx.Done():
@@ -230,12 +248,18 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 		case enqRes := <-freq.enqueue:
 			if enqRes.status == waitForResponse {
 				cancelCh = enqRes.cancelCh
+				schedulerAddr = enqRes.schedulerAddr
 				break // go wait for response.
 			} else if enqRes.status == failed {
 				retries--
 				if retries > 0 {
 					goto enqueueAgain
 				}
+			} else if enqRes.status == excluded {
+				// Picked up by a worker of a scheduler which has handed off the request, try with another one.
+				if f.schedulerWorkers.hasWorkersExcept(freq.excludedSchedulers) {
+					goto enqueueAgain
+				}
 			}
 
 			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
@@ -256,6 +280,21 @@ func (f *Frontend) RoundTripGRPC(ctx context.Context, req *httpgrpc.HTTPRequest)
 			return nil, ctx.Err()
 
 		case resp := <-freq.response:
+			if resp.HandedOff {
+				// The query-scheduler is shutting down and hasn't dispatched the request, enqueue it again
+				// to another one.
+				if freq.excludedSchedulers == nil {
+					freq.excludedSchedulers = map[string]struct{}{}
+				}
+				freq.excludedSchedulers[schedulerAddr] = struct{}{}
+
+				retries--
+				if retries > 0 && f.schedulerWorkers.hasWorkersExcept(freq.excludedSchedulers) {
+					goto enqueueAgain
+				}
+				return nil, httpgrpc.Errorf(http.StatusInternalServerError, "failed to enqueue request")
+			}
+
 			if stats.ShouldTrackHTTPGRPCResponse(resp.HttpResponse) {
 				stats := stats.FromContext(ctx)
 				stats.Merge(resp.Stats) // Safe if stats is nil.
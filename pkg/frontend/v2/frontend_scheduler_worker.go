@@ -126,6 +126,19 @@ func (f *frontendSchedulerWorkers) getWorkersCount() int {
 	return len(f.workers)
 }
 
+// Returns whether there is a worker for a scheduler not in the excluded addresses.
+func (f *frontendSchedulerWorkers) hasWorkersExcept(excluded map[string]struct{}) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for addr := range f.workers {
+		if _, ok := excluded[addr]; !ok {
+			return true
+		}
+	}
+	return false
+}
+
 func (f *frontendSchedulerWorkers) connectToScheduler(address string) (*grpc.ClientConn, error) {
 	// Because we only use single long-running method, it doesn't make sense to inject user ID, send over tracing or add metrics.
 	opts, err := f.cfg.GRPCClientConfig.DialOption(nil, nil)
@@ -256,6 +269,11 @@ func (w *frontendSchedulerWorker) schedulerLoop(loop schedulerpb.SchedulerForFro
 			return nil
 
 		case req := <-w.requestCh:
+			if req.isSchedulerExcluded(w.schedulerAddr) {
+				req.enqueue <- enqueueResult{status: excluded}
+				continue
+			}
+
 			err := loop.Send(&schedulerpb.FrontendToScheduler{
 				Type:            schedulerpb.ENQUEUE,
 				QueryID:         req.queryID,
@@ -278,7 +296,7 @@ func (w *frontendSchedulerWorker) schedulerLoop(loop schedulerpb.SchedulerForFro
 
 			switch resp.Status {
 			case schedulerpb.OK:
-				req.enqueue <- enqueueResult{status: waitForResponse, cancelCh: w.cancelCh}
+				req.enqueue <- enqueueResult{status: waitForResponse, cancelCh: w.cancelCh, schedulerAddr: w.schedulerAddr}
 				// Response will come from querier.
 
 			case schedulerpb.SHUTTING_DOWN:
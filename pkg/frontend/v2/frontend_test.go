@@ -94,6 +94,48 @@ func sendResponseWithDelay(f *Frontend, delay time.Duration, userID string, quer
 	})
 }
 
+func sendHandedOffWithDelay(f *Frontend, delay time.Duration, userID string, queryID uint64) {
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	_, _ = f.QueryResult(ctx, &frontendv2pb.QueryResultRequest{
+		QueryID:   queryID,
+		HandedOff: true,
+	})
+}
+
+// addMockScheduler starts another mock scheduler and connects the frontend to it.
+func addMockScheduler(t *testing.T, f *Frontend, schedulerReplyFunc func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend) *mockScheduler {
+	l, err := net.Listen("tcp", "")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	ms := newMockScheduler(t, f, schedulerReplyFunc)
+	schedulerpb.RegisterSchedulerForFrontendServer(server, ms)
+
+	go func() {
+		_ = server.Serve(l)
+	}()
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	f.schedulerWorkers.AddressAdded(l.Addr().String())
+
+	// Wait for frontend to connect to scheduler.
+	test.Poll(t, 1*time.Second, 1, func() interface{} {
+		ms.mu.Lock()
+		defer ms.mu.Unlock()
+
+		return len(ms.frontendAddr)
+	})
+
+	return ms
+}
+
 func TestFrontendBasicWorkflow(t *testing.T) {
 	const (
 		body   = "all fine here"
@@ -172,6 +214,67 @@ func TestFrontendRetryEnqueue(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestFrontendEnqueueAgainHandedOffRequest(t *testing.T) {
+	const (
+		body   = "hello world"
+		userID = "test"
+	)
+
+	// The first scheduler is shutting down and hands off all the requests.
+	f, draining := setupFrontend(t, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		go sendHandedOffWithDelay(f, 10*time.Millisecond, userID, msg.QueryID)
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	}, 0)
+
+	// The second scheduler runs them.
+	running := addMockScheduler(t, f, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		go sendResponseWithDelay(f, 10*time.Millisecond, userID, msg.QueryID, &httpgrpc.HTTPResponse{Code: 200, Body: []byte(body)})
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	})
+
+	const numRequests = 10
+	for i := 0; i < numRequests; i++ {
+		resp, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+		require.NoError(t, err)
+		require.Equal(t, int32(200), resp.Code)
+		require.Equal(t, []byte(body), resp.Body)
+	}
+
+	// Each request is enqueued at most once to the scheduler which has handed it off.
+	draining.checkWithLock(func() {
+		enqueued := map[uint64]int{}
+		for _, msg := range draining.msgs {
+			enqueued[msg.QueryID]++
+		}
+		for queryID, count := range enqueued {
+			require.Equal(t, 1, count, "query %d", queryID)
+		}
+	})
+	running.checkWithLock(func() {
+		require.Len(t, running.msgs, numRequests)
+	})
+}
+
+func TestFrontendHandedOffRequestWithoutOtherScheduler(t *testing.T) {
+	const userID = "test"
+
+	f, ms := setupFrontend(t, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
+		go sendHandedOffWithDelay(f, 10*time.Millisecond, userID, msg.QueryID)
+
+		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.OK}
+	}, 0)
+
+	_, err := f.RoundTripGRPC(user.InjectOrgID(context.Background(), userID), &httpgrpc.HTTPRequest{})
+	require.EqualError(t, err, "rpc error: code = Code(500) desc = failed to enqueue request")
+
+	// The request isn't enqueued again to the scheduler which has handed it off.
+	ms.checkWithLock(func() {
+		require.Len(t, ms.msgs, 1)
+	})
+}
+
 func TestFrontendEnqueueFailure(t *testing.T) {
 	f, _ := setupFrontend(t, func(f *Frontend, msg *schedulerpb.FrontendToScheduler) *schedulerpb.SchedulerToFrontend {
 		return &schedulerpb.SchedulerToFrontend{Status: schedulerpb.SHUTTING_DOWN}
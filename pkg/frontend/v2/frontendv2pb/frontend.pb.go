@@ -36,6 +36,7 @@ type QueryResultRequest struct {
 	QueryID      uint64                                                        `protobuf:"varint,1,opt,name=queryID,proto3" json:"queryID,omitempty"`
 	HttpResponse *httpgrpc.HTTPResponse                                        `protobuf:"bytes,2,opt,name=httpResponse,proto3" json:"httpResponse,omitempty"`
 	Stats        *github_com_cortexproject_cortex_pkg_querier_stats.QueryStats `protobuf:"bytes,3,opt,name=stats,proto3,customtype=github.com/cortexproject/cortex/pkg/querier/stats.QueryStats" json:"stats,omitempty"`
+	HandedOff    bool                                                          `protobuf:"varint,4,opt,name=handedOff,proto3" json:"handedOff,omitempty"`
 }
 
 func (m *QueryResultRequest) Reset()      { *m = QueryResultRequest{} }
@@ -84,6 +85,13 @@ func (m *QueryResultRequest) GetHttpResponse() *httpgrpc.HTTPResponse {
 	return nil
 }
 
+func (m *QueryResultRequest) GetHandedOff() bool {
+	if m != nil {
+		return m.HandedOff
+	}
+	return false
+}
+
 type QueryResultResponse struct {
 }
 
@@ -127,30 +135,31 @@ func init() {
 func init() { proto.RegisterFile("frontend.proto", fileDescriptor_eca3873955a29cfe) }
 
 var fileDescriptor_eca3873955a29cfe = []byte{
-	// 359 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x52, 0xcd, 0x4e, 0x02, 0x31,
-	0x10, 0xde, 0xfa, 0x9b, 0x14, 0xe2, 0xa1, 0xfe, 0x64, 0xc3, 0xa1, 0x22, 0x27, 0x4e, 0x6d, 0x82,
-	0x9e, 0x8c, 0x26, 0x86, 0x18, 0xa2, 0x37, 0x59, 0x39, 0x79, 0x83, 0xa5, 0x2e, 0x3f, 0xb2, 0x2d,
-	0x6d, 0x17, 0xe4, 0xe6, 0x23, 0xf8, 0x18, 0x3e, 0x8a, 0xc7, 0x3d, 0x12, 0x0f, 0x46, 0xca, 0xc5,
-	0x23, 0x8f, 0x60, 0x76, 0x0b, 0x04, 0x62, 0x62, 0xe2, 0x65, 0x32, 0x93, 0xf9, 0xbe, 0x99, 0x6f,
-	0xfa, 0x15, 0xee, 0x3d, 0x4a, 0x1e, 0x6a, 0x16, 0x36, 0x89, 0x90, 0x5c, 0x73, 0x94, 0x5d, 0xd4,
-	0x83, 0x92, 0x68, 0xe4, 0x0e, 0x02, 0x1e, 0xf0, 0xb4, 0x41, 0x93, 0xcc, 0x62, 0x72, 0x67, 0x41,
-	0x5b, 0xb7, 0xa2, 0x06, 0xf1, 0x79, 0x8f, 0x0e, 0x59, 0x7d, 0xc0, 0x86, 0x5c, 0x76, 0x15, 0xf5,
-	0x79, 0xaf, 0xc7, 0x43, 0xda, 0xd2, 0x5a, 0x04, 0x52, 0xf8, 0xcb, 0x64, 0xce, 0xba, 0x5c, 0x61,
-	0xf9, 0x5c, 0x6a, 0xf6, 0x2c, 0x24, 0xef, 0x30, 0x5f, 0xcf, 0x2b, 0x2a, 0xba, 0x01, 0xed, 0x47,
-	0x4c, 0xb6, 0x99, 0xa4, 0x4a, 0xd7, 0xb5, 0xb2, 0xd1, 0xd2, 0x0b, 0x31, 0x80, 0xa8, 0x1a, 0x31,
-	0x39, 0xf2, 0x98, 0x8a, 0x9e, 0xb4, 0xc7, 0xfa, 0x11, 0x53, 0x1a, 0xb9, 0x70, 0x37, 0xe1, 0x8c,
-	0x6e, 0xaf, 0x5d, 0x90, 0x07, 0xc5, 0x2d, 0x6f, 0x51, 0xa2, 0x73, 0x98, 0x4d, 0x14, 0x78, 0x4c,
-	0x09, 0x1e, 0x2a, 0xe6, 0x6e, 0xe4, 0x41, 0x31, 0x53, 0x3a, 0x22, 0x4b, 0x59, 0x37, 0xb5, 0xda,
-	0xdd, 0xa2, 0xeb, 0xad, 0x61, 0x51, 0x13, 0x6e, 0xa7, 0xbb, 0xdd, 0xcd, 0x94, 0x94, 0x25, 0x56,
-	0xc9, 0x7d, 0x12, 0xcb, 0x57, 0x1f, 0x9f, 0xc7, 0x17, 0xff, 0x3e, 0x86, 0xa4, 0xe2, 0xd3, 0x09,
-	0x9e, 0x1d, 0x5e, 0x38, 0x84, 0xfb, 0x6b, 0x17, 0xd9, 0xe5, 0xa5, 0x0e, 0x44, 0x95, 0xb9, 0x09,
-	0x15, 0x2e, 0xab, 0x76, 0x08, 0xaa, 0xc1, 0xcc, 0x0a, 0x18, 0xe5, 0xc9, 0xaa, 0x51, 0xe4, 0xf7,
-	0xcb, 0xe4, 0x4e, 0xfe, 0x40, 0xd8, 0x4d, 0x05, 0xa7, 0x5c, 0x8e, 0x27, 0xd8, 0x19, 0x4f, 0xb0,
-	0x33, 0x9b, 0x60, 0xf0, 0x62, 0x30, 0x78, 0x33, 0x18, 0xbc, 0x1b, 0x0c, 0x62, 0x83, 0xc1, 0x97,
-	0xc1, 0xe0, 0xdb, 0x60, 0x67, 0x66, 0x30, 0x78, 0x9d, 0x62, 0x27, 0x9e, 0x62, 0x67, 0x3c, 0xc5,
-	0xce, 0xc3, 0xda, 0x27, 0x69, 0xec, 0xa4, 0x06, 0x9d, 0xfe, 0x04, 0x00, 0x00, 0xff, 0xff, 0xa4,
-	0xdf, 0xd6, 0xe9, 0x4b, 0x02, 0x00, 0x00,
+	// 380 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x52, 0x4d, 0x4f, 0xf2, 0x40,
+	0x10, 0xee, 0xbe, 0x2f, 0xef, 0xab, 0x2e, 0xc4, 0xc3, 0xfa, 0x91, 0x86, 0x98, 0xb5, 0x72, 0xe2,
+	0xd4, 0x26, 0xe8, 0xc9, 0x68, 0x62, 0x88, 0x21, 0x7a, 0x52, 0x2a, 0x27, 0x6f, 0xd0, 0x0e, 0xe5,
+	0x43, 0xba, 0x65, 0xbb, 0x05, 0xb9, 0xf9, 0x13, 0xfc, 0x19, 0xfe, 0x14, 0x8f, 0x1c, 0x89, 0x07,
+	0x23, 0xcb, 0xc5, 0x93, 0xe1, 0x27, 0x98, 0x76, 0x01, 0x21, 0x26, 0x26, 0x5e, 0x26, 0x33, 0x9d,
+	0xe7, 0x99, 0x79, 0xa6, 0xcf, 0xe2, 0xcd, 0x3a, 0x67, 0xbe, 0x00, 0xdf, 0x35, 0x03, 0xce, 0x04,
+	0x23, 0x99, 0x79, 0xdd, 0x2b, 0x04, 0xb5, 0xec, 0xb6, 0xc7, 0x3c, 0x96, 0x34, 0xac, 0x38, 0x53,
+	0x98, 0xec, 0x91, 0xd7, 0x14, 0x8d, 0xa8, 0x66, 0x3a, 0xac, 0x63, 0xf5, 0xa1, 0xda, 0x83, 0x3e,
+	0xe3, 0xed, 0xd0, 0x72, 0x58, 0xa7, 0xc3, 0x7c, 0xab, 0x21, 0x44, 0xe0, 0xf1, 0xc0, 0x59, 0x24,
+	0x33, 0xd6, 0xe9, 0x12, 0xcb, 0x61, 0x5c, 0xc0, 0x7d, 0xc0, 0x59, 0x0b, 0x1c, 0x31, 0xab, 0xac,
+	0xa0, 0xed, 0x59, 0xdd, 0x08, 0x78, 0x13, 0xb8, 0x15, 0x8a, 0xaa, 0x08, 0x55, 0x54, 0xf4, 0xdc,
+	0x07, 0xc2, 0xa4, 0x1c, 0x01, 0x1f, 0xd8, 0x10, 0x46, 0x77, 0xc2, 0x86, 0x6e, 0x04, 0xa1, 0x20,
+	0x3a, 0x5e, 0x8b, 0x39, 0x83, 0xcb, 0x73, 0x1d, 0x19, 0x28, 0x9f, 0xb2, 0xe7, 0x25, 0x39, 0xc6,
+	0x99, 0x58, 0x81, 0x0d, 0x61, 0xc0, 0xfc, 0x10, 0xf4, 0x3f, 0x06, 0xca, 0xa7, 0x0b, 0xbb, 0xe6,
+	0x42, 0xd6, 0x45, 0xa5, 0x72, 0x3d, 0xef, 0xda, 0x2b, 0x58, 0xe2, 0xe2, 0x7f, 0xc9, 0x6e, 0xfd,
+	0x6f, 0x42, 0xca, 0x98, 0x4a, 0xc9, 0x4d, 0x1c, 0x8b, 0x67, 0x2f, 0xaf, 0xfb, 0x27, 0xbf, 0x3e,
+	0xc6, 0x4c, 0xc4, 0x27, 0x13, 0x6c, 0x35, 0x9c, 0xec, 0xe1, 0x8d, 0x46, 0xd5, 0x77, 0xc1, 0xbd,
+	0xaa, 0xd7, 0xf5, 0x94, 0x81, 0xf2, 0xeb, 0xf6, 0xd7, 0x87, 0xdc, 0x0e, 0xde, 0x5a, 0xb9, 0x57,
+	0x49, 0x2b, 0xb4, 0x30, 0x29, 0xcd, 0x2c, 0x2a, 0x31, 0x5e, 0x56, 0x2b, 0x48, 0x05, 0xa7, 0x97,
+	0xc0, 0xc4, 0x30, 0x97, 0x6d, 0x34, 0xbf, 0xff, 0xb7, 0xec, 0xc1, 0x0f, 0x08, 0xb5, 0x29, 0xa7,
+	0x15, 0x8b, 0xc3, 0x31, 0xd5, 0x46, 0x63, 0xaa, 0x4d, 0xc7, 0x14, 0x3d, 0x48, 0x8a, 0x9e, 0x24,
+	0x45, 0xcf, 0x92, 0xa2, 0xa1, 0xa4, 0xe8, 0x4d, 0x52, 0xf4, 0x2e, 0xa9, 0x36, 0x95, 0x14, 0x3d,
+	0x4e, 0xa8, 0x36, 0x9c, 0x50, 0x6d, 0x34, 0xa1, 0xda, 0xed, 0xca, 0x13, 0xaa, 0xfd, 0x4f, 0xec,
+	0x3b, 0xfc, 0x1c, 0x00, 0x21, 0xf5, 0x46, 0xf8, 0x69, 0x02, 0x00, 0x00,
 }
 
 func (this *QueryResultRequest) Equal(that interface{}) bool {
@@ -185,6 +194,9 @@ func (this *QueryResultRequest) Equal(that interface{}) bool {
 	} else if !this.Stats.Equal(*that1.Stats) {
 		return false
 	}
+	if this.HandedOff != that1.HandedOff {
+		return false
+	}
 	return true
 }
 func (this *QueryResultResponse) Equal(that interface{}) bool {
@@ -212,13 +224,14 @@ func (this *QueryResultRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
+	s := make([]string, 0, 8)
 	s = append(s, "&frontendv2pb.QueryResultRequest{")
 	s = append(s, "QueryID: "+fmt.Sprintf("%#v", this.QueryID)+",\n")
 	if this.HttpResponse != nil {
 		s = append(s, "HttpResponse: "+fmt.Sprintf("%#v", this.HttpResponse)+",\n")
 	}
 	s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	s = append(s, "HandedOff: "+fmt.Sprintf("%#v", this.HandedOff)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -340,6 +353,16 @@ func (m *QueryResultRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.HandedOff {
+		i--
+		if m.HandedOff {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
 	if m.Stats != nil {
 		{
 			size := m.Stats.Size()
@@ -423,6 +446,9 @@ func (m *QueryResultRequest) Size() (n int) {
 		l = m.Stats.Size()
 		n += 1 + l + sovFrontend(uint64(l))
 	}
+	if m.HandedOff {
+		n += 2
+	}
 	return n
 }
 
@@ -449,6 +475,7 @@ func (this *QueryResultRequest) String() string {
 		`QueryID:` + fmt.Sprintf("%v", this.QueryID) + `,`,
 		`HttpResponse:` + strings.Replace(fmt.Sprintf("%v", this.HttpResponse), "HTTPResponse", "httpgrpc.HTTPResponse", 1) + `,`,
 		`Stats:` + fmt.Sprintf("%v", this.Stats) + `,`,
+		`HandedOff:` + fmt.Sprintf("%v", this.HandedOff) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -590,6 +617,26 @@ func (m *QueryResultRequest) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HandedOff", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowFrontend
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.HandedOff = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipFrontend(dAtA[iNdEx:])
@@ -20,19 +20,40 @@ import (
 	"github.com/cortexproject/cortex/pkg/ring/kv"
 	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
 
 var (
 	errNegativeUpdateTimeoutJitterMax = errors.New("HA tracker max update timeout jitter shouldn't be negative")
+	errInvalidElectionMode            = fmt.Errorf("unsupported HA tracker election mode (supported values: %s)", strings.Join(electionModes, ", "))
 	errInvalidFailoverTimeout         = "HA Tracker failover timeout (%v) must be at least 1s greater than update timeout - max jitter (%v)"
+	errMemberlistKVStore              = errors.New("memberlist is not supported as the HA tracker KV store, since it can't arbitrate the elections: use the crdt election mode to refresh the elected replicas through memberlist gossip")
 )
 
+const (
+	// ElectionModeKV elects the replicas and refreshes their timestamp with a CAS on the KV store.
+	ElectionModeKV = "kv"
+
+	// ElectionModeCRDT refreshes the elected replicas timestamp through a last-writer-wins
+	// register gossiped between the distributors, and only uses the KV store to arbitrate
+	// the elections. See HATracker.checkGossip() for the correctness bounds.
+	ElectionModeCRDT = "crdt"
+)
+
+var electionModes = []string{ElectionModeKV, ElectionModeCRDT}
+
 // nolint:revive
 type HATrackerLimits interface {
 	// MaxHAReplicaGroups returns max number of replica groups that HA tracker should track for a user.
 	// Samples from additional replicaGroups are rejected.
 	MaxHAReplicaGroups(user string) int
+
+	// HATrackerUpdateTimeout returns the update timeout for a user, or 0 to use the configured one.
+	HATrackerUpdateTimeout(user string) time.Duration
+
+	// HATrackerFailoverTimeout returns the failover timeout for a user, or 0 to use the configured one.
+	HATrackerFailoverTimeout(user string) time.Duration
 }
 
 // ProtoReplicaDescFactory makes new InstanceDescs
@@ -59,8 +80,9 @@ type HATrackerConfig struct {
 	// between the stored timestamp and the time we received a sample is
 	// more than this duration
 	FailoverTimeout time.Duration `yaml:"ha_tracker_failover_timeout"`
+	ElectionMode    string        `yaml:"ha_tracker_election_mode"`
 
-	KVStore kv.Config `yaml:"kvstore" doc:"description=Backend storage to use for the ring. Please be aware that memberlist is not supported by the HA tracker since gossip propagation is too slow for HA purposes."`
+	KVStore kv.Config `yaml:"kvstore" doc:"description=Backend storage to use for the ring. Please be aware that memberlist is not supported by the HA tracker since its CAS isn't linearizable, so the distributors could elect different replicas. Use the crdt election mode to refresh the elected replicas through memberlist gossip."`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet with a specified prefix
@@ -84,6 +106,7 @@ func (cfg *HATrackerConfig) RegisterFlagsWithPrefix(flagPrefix string, kvPrefix
 	f.DurationVar(&cfg.UpdateTimeout, finalFlagPrefix+"ha-tracker.update-timeout", 15*time.Second, "Update the timestamp in the KV store for a given cluster/replicaGroup only after this amount of time has passed since the current stored timestamp.")
 	f.DurationVar(&cfg.UpdateTimeoutJitterMax, finalFlagPrefix+"ha-tracker.update-timeout-jitter-max", 5*time.Second, "Maximum jitter applied to the update timeout, in order to spread the HA heartbeats over time.")
 	f.DurationVar(&cfg.FailoverTimeout, finalFlagPrefix+"ha-tracker.failover-timeout", 30*time.Second, "If we don't receive any data from the accepted replica for a cluster/replicaGroup in this amount of time we will failover to the next replica we receive a sample from. This value must be greater than the update timeout")
+	f.StringVar(&cfg.ElectionMode, finalFlagPrefix+"ha-tracker.election-mode", ElectionModeKV, fmt.Sprintf("The algorithm used to elect the replica to accept samples from. Supported values are: %s. The %q mode refreshes the elected replicas through memberlist gossip, which must be configured, and only uses the KV store to arbitrate the failovers.", strings.Join(electionModes, ", "), ElectionModeCRDT))
 
 	// We want the ability to use different Consul instances for the ring and
 	// for HA cluster tracking. We also customize the default keys prefix, in
@@ -103,8 +126,19 @@ func (cfg *HATrackerConfig) Validate() error {
 		return fmt.Errorf(errInvalidFailoverTimeout, cfg.FailoverTimeout, minFailureTimeout)
 	}
 
-	// Tracker kv store only supports consul and etcd.
-	storeAllowedList := []string{"consul", "etcd"}
+	if !util.StringsContain(electionModes, cfg.ElectionMode) {
+		return errInvalidElectionMode
+	}
+
+	// Memberlist can't arbitrate the elections, since its CAS isn't linearizable: two distributors
+	// could elect different replicas. The CRDT election mode gossips the refreshes of the elected
+	// replicas through memberlist instead, while a consensus KV store arbitrates the failovers.
+	if cfg.KVStore.Store == "memberlist" {
+		return errMemberlistKVStore
+	}
+
+	// Tracker kv store only supports consul, etcd and zookeeper.
+	storeAllowedList := []string{"consul", "etcd", "zookeeper"}
 	for _, as := range storeAllowedList {
 		if cfg.KVStore.Store == as {
 			return nil
@@ -113,8 +147,15 @@ func (cfg *HATrackerConfig) Validate() error {
 	return fmt.Errorf("invalid HATracker KV store type: %s", cfg.KVStore.Store)
 }
 
-func GetReplicaDescCodec() codec.Proto {
-	return codec.NewProtoCodec("replicaDesc", ProtoReplicaDescFactory)
+// replicaDescMigrations are the ReplicaDesc schema migrations, see codec.Versioned. The
+// write version must only be bumped once all the clients know about the new migration.
+var (
+	replicaDescMigrations   []codec.Migration
+	replicaDescWriteVersion = 0
+)
+
+func GetReplicaDescCodec() codec.Versioned {
+	return codec.NewVersionedCodec(codec.NewProtoCodec("replicaDesc", ProtoReplicaDescFactory), replicaDescMigrations, replicaDescWriteVersion)
 }
 
 // Track the replica we're accepting samples from
@@ -126,17 +167,20 @@ type HATracker struct {
 	logger              log.Logger
 	cfg                 HATrackerConfig
 	client              kv.Client
+	gossipClient        kv.Client // Only set with the CRDT election mode.
 	updateTimeoutJitter time.Duration
 	limits              HATrackerLimits
 
 	electedLock   sync.RWMutex
 	elected       map[string]ReplicaDesc         // Replicas we are accepting samples from. Key = "user/replicaGroup".
 	replicaGroups map[string]map[string]struct{} // Known replica groups with elected replicas per user. First key = user, second key = replica group name (e.g. cluster).
+	watchers      map[*electedWatcher]struct{}   // Clients of the WatchElected API.
 
 	electedReplicaChanges         *prometheus.CounterVec
 	electedReplicaTimestamp       *prometheus.GaugeVec
 	electedReplicaPropagationTime prometheus.Histogram
 	kvCASCalls                    *prometheus.CounterVec
+	gossipCASCalls                *prometheus.CounterVec
 
 	cleanupRuns               prometheus.Counter
 	replicasMarkedForDeletion prometheus.Counter
@@ -160,6 +204,7 @@ func NewHATracker(cfg HATrackerConfig, limits HATrackerLimits, trackerStatusConf
 		updateTimeoutJitter: jitter,
 		limits:              limits,
 		elected:             map[string]ReplicaDesc{},
+		watchers:            map[*electedWatcher]struct{}{},
 		replicaGroups:       map[string]map[string]struct{}{},
 
 		trackerStatusConfig: trackerStatusConfig,
@@ -181,6 +226,10 @@ func NewHATracker(cfg HATrackerConfig, limits HATrackerLimits, trackerStatusConf
 			Name: "ha_tracker_kv_store_cas_total",
 			Help: "The total number of CAS calls to the KV store for a user ID/cluster.",
 		}, []string{"user", "cluster"}),
+		gossipCASCalls: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "ha_tracker_gossip_cas_total",
+			Help: "The total number of CAS calls to the gossiped replicas state for a user ID/cluster. Only tracked with the CRDT election mode.",
+		}, []string{"user", "cluster"}),
 
 		cleanupRuns: promauto.With(reg).NewCounter(prometheus.CounterOpts{
 			Name: "ha_tracker_replicas_cleanup_started_total",
@@ -211,12 +260,103 @@ func NewHATracker(cfg HATrackerConfig, limits HATrackerLimits, trackerStatusConf
 			return nil, err
 		}
 		t.client = client
+
+		if cfg.ElectionMode == ElectionModeCRDT {
+			if cfg.KVStore.MemberlistKV == nil {
+				return nil, errors.New("the HA tracker CRDT election mode requires memberlist to be configured")
+			}
+
+			gossipClient, err := kv.NewClient(
+				kv.Config{
+					Store:       "memberlist",
+					Prefix:      cfg.KVStore.Prefix,
+					StoreConfig: kv.StoreConfig{MemberlistKV: cfg.KVStore.MemberlistKV},
+				},
+				GetReplicaDescCodec(),
+				kv.RegistererWithKVName(reg, kvNameLabel+"-gossip"),
+				logger,
+			)
+			if err != nil {
+				return nil, err
+			}
+			t.gossipClient = gossipClient
+		}
 	}
 
-	t.Service = services.NewBasicService(nil, t.loop, nil)
+	t.Service = services.NewBasicService(t.starting, t.loop, nil)
 	return t, nil
 }
 
+// Max number of concurrent KV store reads while warming up the elected replicas.
+const warmupConcurrency = 16
+
+func (c *HATracker) starting(ctx context.Context) error {
+	if !c.cfg.EnableHATracker {
+		return nil
+	}
+
+	c.warmupElectedReplicas(ctx, "KV store", c.client)
+
+	// With the CRDT election mode the KV store only tracks the elections, so we wait for the
+	// first memberlist sync to receive the last refreshes too. Otherwise the replica groups whose
+	// last election is older than the failover timeout would fail over on the first sample.
+	if c.gossipClient != nil {
+		mkv, err := c.cfg.KVStore.MemberlistKV()
+		if err == nil {
+			err = mkv.WaitJoined(ctx)
+		}
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "warmup: failed to wait for the memberlist cluster join", "err", err)
+			return nil
+		}
+
+		c.warmupElectedReplicas(ctx, "memberlist", c.gossipClient)
+	}
+	return nil
+}
+
+// warmupElectedReplicas populates the in-memory elected replicas reading them from the input store,
+// so that after a restart the tracker doesn't issue a CAS for each replica group it receives
+// samples for. The warmup is best-effort: the replicas which fail to be read are received
+// through the store watch anyway.
+func (c *HATracker) warmupElectedReplicas(ctx context.Context, store string, client kv.Client) {
+	start := time.Now()
+
+	keys, err := client.List(ctx, "")
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "warmup: failed to list replica keys", "store", store, "err", err)
+		return
+	}
+
+	err = concurrency.ForEach(ctx, concurrency.CreateJobsFromStrings(keys), warmupConcurrency, func(ctx context.Context, job interface{}) error {
+		key := job.(string)
+
+		val, err := client.Get(ctx, key)
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "warmup: failed to get replica value", "store", store, "key", key, "err", err)
+			return nil
+		}
+
+		desc, ok := val.(*ReplicaDesc)
+		if !ok || desc == nil {
+			return nil
+		}
+
+		c.updateElected(key, desc)
+		return nil
+	})
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "warmup: failed to read the elected replicas", "store", store, "err", err)
+		return
+	}
+
+	c.electedLock.RLock()
+	elected := len(c.elected)
+	c.electedLock.RUnlock()
+
+	level.Info(c.logger).Log("msg", "warmed up the elected replicas", "store", store, "elected", elected, "duration", time.Since(start))
+}
+
 // Follows pattern used by ring for WatchKey.
 func (c *HATracker) loop(ctx context.Context) error {
 	if !c.cfg.EnableHATracker {
@@ -233,53 +373,84 @@ func (c *HATracker) loop(ctx context.Context) error {
 		c.cleanupOldReplicasLoop(ctx)
 	}()
 
+	// With the CRDT election mode the elected replicas are refreshed through gossip, while the
+	// KV store is only updated on elections and deletions, so we watch both.
+	if c.gossipClient != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.gossipClient.WatchPrefix(ctx, "", func(key string, value interface{}) bool {
+				c.updateElected(key, value.(*ReplicaDesc))
+				return true
+			})
+		}()
+	}
+
 	// The KVStore config we gave when creating c should have contained a prefix,
 	// which would have given us a prefixed KVStore client. So, we can pass empty string here.
 	c.client.WatchPrefix(ctx, "", func(key string, value interface{}) bool {
-		replica := value.(*ReplicaDesc)
-		user, cluster, keyHasSeparator := strings.Cut(key, "/")
+		c.updateElected(key, value.(*ReplicaDesc))
+		return true
+	})
 
-		// Valid key would look like cluster/replica, and a key without a / such as `ring` would be invalid.
-		if !keyHasSeparator {
-			return true
-		}
+	wg.Wait()
+	return nil
+}
 
-		c.electedLock.Lock()
-		defer c.electedLock.Unlock()
+// updateElected updates the in-memory elected replica for the input key.
+func (c *HATracker) updateElected(key string, replica *ReplicaDesc) {
+	user, cluster, keyHasSeparator := strings.Cut(key, "/")
 
-		if replica.DeletedAt > 0 {
-			delete(c.elected, key)
-			c.electedReplicaChanges.DeleteLabelValues(user, cluster)
-			c.electedReplicaTimestamp.DeleteLabelValues(user, cluster)
+	// Valid key would look like cluster/replica, and a key without a / such as `ring` would be invalid.
+	// The gossiped descriptors whose tombstone has been removed have no replica.
+	if !keyHasSeparator || replica.Replica == "" {
+		return
+	}
 
-			userClusters := c.replicaGroups[user]
-			if userClusters != nil {
-				delete(userClusters, cluster)
-				if len(userClusters) == 0 {
-					delete(c.replicaGroups, user)
-				}
-			}
-			return true
-		}
+	c.electedLock.Lock()
+	defer c.electedLock.Unlock()
 
-		elected, exists := c.elected[key]
-		if replica.Replica != elected.Replica {
-			c.electedReplicaChanges.WithLabelValues(user, cluster).Inc()
-		}
-		if !exists {
-			if c.replicaGroups[user] == nil {
-				c.replicaGroups[user] = map[string]struct{}{}
+	elected, exists := c.elected[key]
+
+	// With the CRDT election mode the updates are received from both the KV store and gossip,
+	// so we only apply the ones winning over the in-memory state.
+	if c.gossipClient != nil && exists && !replica.newerThan(&elected) {
+		return
+	}
+
+	if replica.DeletedAt > 0 {
+		delete(c.elected, key)
+		c.electedReplicaChanges.DeleteLabelValues(user, cluster)
+		c.electedReplicaTimestamp.DeleteLabelValues(user, cluster)
+
+		userClusters := c.replicaGroups[user]
+		if userClusters != nil {
+			delete(userClusters, cluster)
+			if len(userClusters) == 0 {
+				delete(c.replicaGroups, user)
 			}
-			c.replicaGroups[user][cluster] = struct{}{}
 		}
-		c.elected[key] = *replica
-		c.electedReplicaTimestamp.WithLabelValues(user, cluster).Set(float64(replica.ReceivedAt / 1000))
-		c.electedReplicaPropagationTime.Observe(time.Since(timestamp.Time(replica.ReceivedAt)).Seconds())
-		return true
-	})
+		if exists {
+			c.notifyWatchers(&ElectedReplicaUpdate{User: user, Cluster: cluster, Deleted: true})
+		}
+		return
+	}
 
-	wg.Wait()
-	return nil
+	if replica.Replica != elected.Replica {
+		c.electedReplicaChanges.WithLabelValues(user, cluster).Inc()
+
+		desc := *replica
+		c.notifyWatchers(&ElectedReplicaUpdate{User: user, Cluster: cluster, Replica: &desc})
+	}
+	if !exists {
+		if c.replicaGroups[user] == nil {
+			c.replicaGroups[user] = map[string]struct{}{}
+		}
+		c.replicaGroups[user][cluster] = struct{}{}
+	}
+	c.elected[key] = *replica
+	c.electedReplicaTimestamp.WithLabelValues(user, cluster).Set(float64(replica.ReceivedAt / 1000))
+	c.electedReplicaPropagationTime.Observe(time.Since(timestamp.Time(replica.ReceivedAt)).Seconds())
 }
 
 const (
@@ -358,6 +529,12 @@ func (c *HATracker) cleanupOldReplicas(ctx context.Context, deadline time.Time)
 
 		// Not marked as deleted yet.
 		if desc.DeletedAt == 0 && timestamp.Time(desc.ReceivedAt).Before(deadline) {
+			// With the CRDT election mode the KV store only tracks the elections, so we
+			// check the last refresh received through gossip too.
+			if c.gossipClient != nil && c.electedAfter(key, deadline) {
+				continue
+			}
+
 			err := c.client.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
 				d, ok := in.(*ReplicaDesc)
 				if !ok || d == nil || d.DeletedAt > 0 || !timestamp.Time(desc.ReceivedAt).Before(deadline) {
@@ -374,6 +551,10 @@ func (c *HATracker) cleanupOldReplicas(ctx context.Context, deadline time.Time)
 			} else {
 				c.replicasMarkedForDeletion.Inc()
 				level.Info(c.logger).Log("msg", "cleanup: marked replica as deleted", "key", key)
+
+				if c.gossipClient != nil {
+					c.markGossipForDeletion(ctx, key)
+				}
 			}
 		}
 	}
@@ -398,7 +579,7 @@ func (c *HATracker) CheckReplica(ctx context.Context, userID, replicaGroup, repl
 	replicaGroups := len(c.replicaGroups[userID])
 	c.electedLock.RUnlock()
 
-	if ok && now.Sub(timestamp.Time(entry.ReceivedAt)) < c.cfg.UpdateTimeout+c.updateTimeoutJitter {
+	if ok && now.Sub(timestamp.Time(entry.ReceivedAt)) < c.updateTimeout(userID)+c.updateTimeoutJitter {
 		if entry.Replica != replica {
 			return ReplicasNotMatchError{replica: replica, elected: entry.Replica}
 		}
@@ -414,8 +595,13 @@ func (c *HATracker) CheckReplica(ctx context.Context, userID, replicaGroup, repl
 		}
 	}
 
-	err := c.checkKVStore(ctx, key, replica, now)
-	c.kvCASCalls.WithLabelValues(userID, replicaGroup).Inc()
+	var err error
+	if c.gossipClient != nil {
+		err = c.checkGossip(ctx, userID, replicaGroup, key, replica, now)
+	} else {
+		err = c.checkKVStore(ctx, userID, key, replica, now)
+		c.kvCASCalls.WithLabelValues(userID, replicaGroup).Inc()
+	}
 	if err != nil {
 		// The callback within checkKVStore will return a ReplicasNotMatchError if the sample is being deduped,
 		// otherwise there may have been an actual error CAS'ing that we should log.
@@ -426,18 +612,23 @@ func (c *HATracker) CheckReplica(ctx context.Context, userID, replicaGroup, repl
 	return err
 }
 
-func (c *HATracker) checkKVStore(ctx context.Context, key, replica string, now time.Time) error {
+func (c *HATracker) checkKVStore(ctx context.Context, userID, key, replica string, now time.Time) error {
+	var (
+		updateTimeout   = c.updateTimeout(userID) + c.updateTimeoutJitter
+		failoverTimeout = c.failoverTimeout(userID)
+	)
+
 	return c.client.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
 		if desc, ok := in.(*ReplicaDesc); ok && desc.DeletedAt == 0 {
 			// We don't need to CAS and update the timestamp in the KV store if the timestamp we've received
 			// this sample at is less than updateTimeout amount of time since the timestamp in the KV store.
-			if desc.Replica == replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < c.cfg.UpdateTimeout+c.updateTimeoutJitter {
+			if desc.Replica == replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < updateTimeout {
 				return nil, false, nil
 			}
 
 			// We shouldn't failover to accepting a new replica if the timestamp we've received this sample at
 			// is less than failover timeout amount of time since the timestamp in the KV store.
-			if desc.Replica != replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < c.cfg.FailoverTimeout {
+			if desc.Replica != replica && now.Sub(timestamp.Time(desc.ReceivedAt)) < failoverTimeout {
 				return nil, false, ReplicasNotMatchError{replica: replica, elected: desc.Replica}
 			}
 		}
@@ -453,6 +644,29 @@ func (c *HATracker) checkKVStore(ctx context.Context, key, replica string, now t
 	})
 }
 
+// updateTimeout returns the update timeout of the user, without the jitter.
+func (c *HATracker) updateTimeout(userID string) time.Duration {
+	if c.limits != nil {
+		if timeout := c.limits.HATrackerUpdateTimeout(userID); timeout > 0 {
+			return timeout
+		}
+	}
+	return c.cfg.UpdateTimeout
+}
+
+// failoverTimeout returns the failover timeout of the user. Like for the configured timeouts,
+// the failover timeout is at least 1s greater than the update timeout plus the max jitter, so
+// that the per-user overrides can't cause failovers while the elected replica is healthy.
+func (c *HATracker) failoverTimeout(userID string) time.Duration {
+	timeout := c.cfg.FailoverTimeout
+	if c.limits != nil {
+		if override := c.limits.HATrackerFailoverTimeout(userID); override > 0 {
+			timeout = override
+		}
+	}
+	return max(timeout, c.updateTimeout(userID)+c.cfg.UpdateTimeoutJitterMax+time.Second)
+}
+
 func (c *HATracker) Cfg() HATrackerConfig {
 	return c.cfg
 }
@@ -504,6 +718,9 @@ func (c *HATracker) CleanupHATrackerMetricsForUser(userID string) {
 	if err := util.DeleteMatchingLabels(c.kvCASCalls, filter); err != nil {
 		level.Warn(c.logger).Log("msg", "failed to remove cortex_ha_tracker_kv_store_cas_total metric for user", "user", userID, "err", err)
 	}
+	if err := util.DeleteMatchingLabels(c.gossipCASCalls, filter); err != nil {
+		level.Warn(c.logger).Log("msg", "failed to remove cortex_ha_tracker_gossip_cas_total metric for user", "user", userID, "err", err)
+	}
 }
 
 // Returns a snapshot of the currently elected replicas.  Useful for status display
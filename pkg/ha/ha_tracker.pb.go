@@ -4,9 +4,13 @@
 package ha
 
 import (
+	context "context"
 	fmt "fmt"
 	_ "github.com/gogo/protobuf/gogoproto"
 	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -89,27 +93,232 @@ func (m *ReplicaDesc) GetDeletedAt() int64 {
 	return 0
 }
 
+type GetElectedReplicaRequest struct {
+	Cluster string `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+}
+
+func (m *GetElectedReplicaRequest) Reset()      { *m = GetElectedReplicaRequest{} }
+func (*GetElectedReplicaRequest) ProtoMessage() {}
+func (*GetElectedReplicaRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86f0e7bcf71d860b, []int{1}
+}
+func (m *GetElectedReplicaRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetElectedReplicaRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetElectedReplicaRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetElectedReplicaRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetElectedReplicaRequest.Merge(m, src)
+}
+func (m *GetElectedReplicaRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetElectedReplicaRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetElectedReplicaRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetElectedReplicaRequest proto.InternalMessageInfo
+
+func (m *GetElectedReplicaRequest) GetCluster() string {
+	if m != nil {
+		return m.Cluster
+	}
+	return ""
+}
+
+type GetElectedReplicaResponse struct {
+	// Not set if no replica is elected for the cluster.
+	Replica *ReplicaDesc `protobuf:"bytes,1,opt,name=replica,proto3" json:"replica,omitempty"`
+}
+
+func (m *GetElectedReplicaResponse) Reset()      { *m = GetElectedReplicaResponse{} }
+func (*GetElectedReplicaResponse) ProtoMessage() {}
+func (*GetElectedReplicaResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86f0e7bcf71d860b, []int{2}
+}
+func (m *GetElectedReplicaResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetElectedReplicaResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetElectedReplicaResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetElectedReplicaResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetElectedReplicaResponse.Merge(m, src)
+}
+func (m *GetElectedReplicaResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetElectedReplicaResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetElectedReplicaResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetElectedReplicaResponse proto.InternalMessageInfo
+
+func (m *GetElectedReplicaResponse) GetReplica() *ReplicaDesc {
+	if m != nil {
+		return m.Replica
+	}
+	return nil
+}
+
+type WatchElectedRequest struct {
+}
+
+func (m *WatchElectedRequest) Reset()      { *m = WatchElectedRequest{} }
+func (*WatchElectedRequest) ProtoMessage() {}
+func (*WatchElectedRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86f0e7bcf71d860b, []int{3}
+}
+func (m *WatchElectedRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WatchElectedRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WatchElectedRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WatchElectedRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchElectedRequest.Merge(m, src)
+}
+func (m *WatchElectedRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *WatchElectedRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchElectedRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchElectedRequest proto.InternalMessageInfo
+
+type ElectedReplicaUpdate struct {
+	User    string       `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
+	Cluster string       `protobuf:"bytes,2,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	Replica *ReplicaDesc `protobuf:"bytes,3,opt,name=replica,proto3" json:"replica,omitempty"`
+	// Whether the cluster has no elected replica anymore.
+	Deleted bool `protobuf:"varint,4,opt,name=deleted,proto3" json:"deleted,omitempty"`
+}
+
+func (m *ElectedReplicaUpdate) Reset()      { *m = ElectedReplicaUpdate{} }
+func (*ElectedReplicaUpdate) ProtoMessage() {}
+func (*ElectedReplicaUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_86f0e7bcf71d860b, []int{4}
+}
+func (m *ElectedReplicaUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ElectedReplicaUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ElectedReplicaUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ElectedReplicaUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ElectedReplicaUpdate.Merge(m, src)
+}
+func (m *ElectedReplicaUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *ElectedReplicaUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_ElectedReplicaUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ElectedReplicaUpdate proto.InternalMessageInfo
+
+func (m *ElectedReplicaUpdate) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *ElectedReplicaUpdate) GetCluster() string {
+	if m != nil {
+		return m.Cluster
+	}
+	return ""
+}
+
+func (m *ElectedReplicaUpdate) GetReplica() *ReplicaDesc {
+	if m != nil {
+		return m.Replica
+	}
+	return nil
+}
+
+func (m *ElectedReplicaUpdate) GetDeleted() bool {
+	if m != nil {
+		return m.Deleted
+	}
+	return false
+}
+
 func init() {
 	proto.RegisterType((*ReplicaDesc)(nil), "ha.ReplicaDesc")
+	proto.RegisterType((*GetElectedReplicaRequest)(nil), "ha.GetElectedReplicaRequest")
+	proto.RegisterType((*GetElectedReplicaResponse)(nil), "ha.GetElectedReplicaResponse")
+	proto.RegisterType((*WatchElectedRequest)(nil), "ha.WatchElectedRequest")
+	proto.RegisterType((*ElectedReplicaUpdate)(nil), "ha.ElectedReplicaUpdate")
 }
 
 func init() { proto.RegisterFile("ha_tracker.proto", fileDescriptor_86f0e7bcf71d860b) }
 
 var fileDescriptor_86f0e7bcf71d860b = []byte{
-	// 197 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0x12, 0xc8, 0x48, 0x8c, 0x2f,
-	0x29, 0x4a, 0x4c, 0xce, 0x4e, 0x2d, 0xd2, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x62, 0xca, 0x48,
-	0x94, 0x12, 0x49, 0xcf, 0x4f, 0xcf, 0x07, 0x73, 0xf5, 0x41, 0x2c, 0x88, 0x8c, 0x52, 0x3a, 0x17,
-	0x77, 0x50, 0x6a, 0x41, 0x4e, 0x66, 0x72, 0xa2, 0x4b, 0x6a, 0x71, 0xb2, 0x90, 0x04, 0x17, 0x7b,
-	0x11, 0x84, 0x2b, 0xc1, 0xa8, 0xc0, 0xa8, 0xc1, 0x19, 0x04, 0xe3, 0x0a, 0xc9, 0x73, 0x71, 0x17,
-	0xa5, 0x26, 0xa7, 0x66, 0x96, 0xa5, 0xa6, 0xc4, 0x27, 0x96, 0x48, 0x30, 0x29, 0x30, 0x6a, 0x30,
-	0x07, 0x71, 0xc1, 0x84, 0x1c, 0x4b, 0x84, 0x64, 0xb9, 0xb8, 0x52, 0x52, 0x73, 0x52, 0x4b, 0x20,
-	0xf2, 0xcc, 0x60, 0x79, 0x4e, 0xa8, 0x88, 0x63, 0x89, 0x93, 0xc9, 0x85, 0x87, 0x72, 0x0c, 0x37,
-	0x1e, 0xca, 0x31, 0x7c, 0x78, 0x28, 0xc7, 0xd8, 0xf0, 0x48, 0x8e, 0x71, 0xc5, 0x23, 0x39, 0xc6,
-	0x13, 0x8f, 0xe4, 0x18, 0x2f, 0x3c, 0x92, 0x63, 0x7c, 0xf0, 0x48, 0x8e, 0xf1, 0xc5, 0x23, 0x39,
-	0x86, 0x0f, 0x8f, 0xe4, 0x18, 0x27, 0x3c, 0x96, 0x63, 0xb8, 0xf0, 0x58, 0x8e, 0xe1, 0xc6, 0x63,
-	0x39, 0x86, 0x24, 0x36, 0xb0, 0x2b, 0x8d, 0x01, 0x01, 0x00, 0x00, 0xff, 0xff, 0xb4, 0x1b, 0xc6,
-	0x42, 0xd3, 0x00, 0x00, 0x00,
+	// 377 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7c, 0x92, 0x3f, 0x4f, 0x22, 0x51,
+	0x14, 0xc5, 0xdf, 0x05, 0xb2, 0x0b, 0x97, 0xcd, 0x2e, 0xfb, 0x96, 0x64, 0x47, 0x94, 0x27, 0x99,
+	0x0a, 0x1b, 0x34, 0x68, 0x65, 0x87, 0x11, 0x35, 0x96, 0xa3, 0xc6, 0x92, 0x3c, 0x67, 0x6e, 0x18,
+	0xe2, 0xc4, 0x19, 0x67, 0x1e, 0xd6, 0xb6, 0x76, 0x7e, 0x0c, 0x13, 0xbf, 0x88, 0x25, 0x25, 0xa5,
+	0x0c, 0x8d, 0x25, 0x1f, 0xc1, 0xcc, 0x3f, 0x02, 0x11, 0xec, 0xde, 0x79, 0x27, 0xef, 0x9e, 0x73,
+	0x7f, 0x33, 0x58, 0xb1, 0x65, 0x4f, 0xf9, 0xd2, 0xbc, 0x25, 0xbf, 0xe5, 0xf9, 0xae, 0x72, 0x79,
+	0xce, 0x96, 0xb5, 0x6a, 0xdf, 0xed, 0xbb, 0xb1, 0xdc, 0x8d, 0x4e, 0x89, 0xa3, 0xf7, 0xb1, 0x6c,
+	0x90, 0xe7, 0x0c, 0x4c, 0x79, 0x4c, 0x81, 0xc9, 0x35, 0xfc, 0xe9, 0x27, 0x52, 0x83, 0x06, 0x34,
+	0x4b, 0x46, 0x26, 0xf9, 0x36, 0x96, 0x7d, 0x32, 0x69, 0xf0, 0x40, 0x56, 0x4f, 0x2a, 0x2d, 0xd7,
+	0x80, 0x66, 0xde, 0xc0, 0xec, 0xaa, 0xa3, 0x78, 0x1d, 0xd1, 0x22, 0x87, 0x54, 0xe2, 0xe7, 0x63,
+	0xbf, 0x94, 0xde, 0x74, 0x94, 0x7e, 0x88, 0xda, 0x29, 0xa9, 0xae, 0x43, 0xa6, 0x22, 0x2b, 0x8d,
+	0x34, 0xe8, 0x7e, 0x48, 0x81, 0x8a, 0x52, 0x4d, 0x67, 0x18, 0x28, 0xf2, 0xe3, 0xb9, 0x25, 0x23,
+	0x93, 0xe7, 0x85, 0x22, 0x54, 0x72, 0xfa, 0x09, 0x6e, 0xac, 0x78, 0x1b, 0x78, 0xee, 0x5d, 0x40,
+	0x7c, 0x67, 0xb9, 0x72, 0xb9, 0xfd, 0xa7, 0x65, 0xcb, 0xd6, 0xc2, 0x52, 0xf3, 0x1d, 0xf4, 0x4d,
+	0xfc, 0x77, 0x2d, 0x95, 0x69, 0xcf, 0x27, 0xc5, 0xf1, 0x69, 0xc8, 0x13, 0x60, 0x75, 0x39, 0xe2,
+	0xca, 0xb3, 0xa4, 0x22, 0xce, 0xb1, 0x30, 0x0c, 0xc8, 0x4f, 0x81, 0xc4, 0xe7, 0xf5, 0x8d, 0x17,
+	0xeb, 0xe4, 0xbf, 0xaf, 0x13, 0x0d, 0x49, 0xf9, 0x68, 0x85, 0x06, 0x34, 0x8b, 0x46, 0x26, 0xdb,
+	0xaf, 0x80, 0xbf, 0xcf, 0x3a, 0x97, 0xc9, 0x37, 0xbc, 0x50, 0x51, 0x0b, 0x03, 0xff, 0x7e, 0x61,
+	0xc0, 0xb7, 0xa2, 0xd9, 0xeb, 0xb0, 0xd6, 0xea, 0x6b, 0xdc, 0x04, 0x9c, 0xce, 0x78, 0x17, 0x7f,
+	0x2d, 0xf2, 0xe0, 0xff, 0xa3, 0x07, 0x2b, 0x08, 0xd5, 0xb4, 0xc8, 0x58, 0x05, 0x47, 0x67, 0x7b,
+	0x70, 0x74, 0x30, 0x9a, 0x08, 0x36, 0x9e, 0x08, 0x36, 0x9b, 0x08, 0x78, 0x0c, 0x05, 0xbc, 0x84,
+	0x02, 0xde, 0x42, 0x01, 0xa3, 0x50, 0xc0, 0x7b, 0x28, 0xe0, 0x23, 0x14, 0x6c, 0x16, 0x0a, 0x78,
+	0x9e, 0x0a, 0x36, 0x9a, 0x0a, 0x36, 0x9e, 0x0a, 0x76, 0xf3, 0x23, 0xfe, 0x01, 0xf7, 0x3f, 0x07,
+	0x00, 0xa4, 0x21, 0xaf, 0x80, 0xae, 0x02, 0x00, 0x00,
 }
 
 func (this *ReplicaDesc) Equal(that interface{}) bool {
@@ -142,6 +351,108 @@ func (this *ReplicaDesc) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *GetElectedReplicaRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*GetElectedReplicaRequest)
+	if !ok {
+		that2, ok := that.(GetElectedReplicaRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Cluster != that1.Cluster {
+		return false
+	}
+	return true
+}
+func (this *GetElectedReplicaResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*GetElectedReplicaResponse)
+	if !ok {
+		that2, ok := that.(GetElectedReplicaResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Replica.Equal(that1.Replica) {
+		return false
+	}
+	return true
+}
+func (this *WatchElectedRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*WatchElectedRequest)
+	if !ok {
+		that2, ok := that.(WatchElectedRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *ElectedReplicaUpdate) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ElectedReplicaUpdate)
+	if !ok {
+		that2, ok := that.(ElectedReplicaUpdate)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.User != that1.User {
+		return false
+	}
+	if this.Cluster != that1.Cluster {
+		return false
+	}
+	if !this.Replica.Equal(that1.Replica) {
+		return false
+	}
+	if this.Deleted != that1.Deleted {
+		return false
+	}
+	return true
+}
 func (this *ReplicaDesc) GoString() string {
 	if this == nil {
 		return "nil"
@@ -154,6 +465,52 @@ func (this *ReplicaDesc) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *GetElectedReplicaRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&ha.GetElectedReplicaRequest{")
+	s = append(s, "Cluster: "+fmt.Sprintf("%#v", this.Cluster)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *GetElectedReplicaResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&ha.GetElectedReplicaResponse{")
+	if this.Replica != nil {
+		s = append(s, "Replica: "+fmt.Sprintf("%#v", this.Replica)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *WatchElectedRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&ha.WatchElectedRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *ElectedReplicaUpdate) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 8)
+	s = append(s, "&ha.ElectedReplicaUpdate{")
+	s = append(s, "User: "+fmt.Sprintf("%#v", this.User)+",\n")
+	s = append(s, "Cluster: "+fmt.Sprintf("%#v", this.Cluster)+",\n")
+	if this.Replica != nil {
+		s = append(s, "Replica: "+fmt.Sprintf("%#v", this.Replica)+",\n")
+	}
+	s = append(s, "Deleted: "+fmt.Sprintf("%#v", this.Deleted)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringHaTracker(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -162,26 +519,181 @@ func valueToGoStringHaTracker(v interface{}, typ string) string {
 	pv := reflect.Indirect(rv).Interface()
 	return fmt.Sprintf("func(v %v) *%v { return &v } ( %#v )", typ, typ, pv)
 }
-func (m *ReplicaDesc) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// HATrackerStateClient is the client API for HATrackerState service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HATrackerStateClient interface {
+	// GetElectedReplica returns the replica currently elected for a cluster of the tenant
+	// the request is made for.
+	GetElectedReplica(ctx context.Context, in *GetElectedReplicaRequest, opts ...grpc.CallOption) (*GetElectedReplicaResponse, error)
+	// WatchElected streams the elected replicas of the tenant the request is made for.
+	// The currently elected replicas are sent first, followed by the elected replica changes. The
+	// refreshes of the received_at timestamp of an elected replica aren't streamed.
+	WatchElected(ctx context.Context, in *WatchElectedRequest, opts ...grpc.CallOption) (HATrackerState_WatchElectedClient, error)
+}
+
+type hATrackerStateClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewHATrackerStateClient(cc *grpc.ClientConn) HATrackerStateClient {
+	return &hATrackerStateClient{cc}
+}
+
+func (c *hATrackerStateClient) GetElectedReplica(ctx context.Context, in *GetElectedReplicaRequest, opts ...grpc.CallOption) (*GetElectedReplicaResponse, error) {
+	out := new(GetElectedReplicaResponse)
+	err := c.cc.Invoke(ctx, "/ha.HATrackerState/GetElectedReplica", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	return out, nil
 }
 
-func (m *ReplicaDesc) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (c *hATrackerStateClient) WatchElected(ctx context.Context, in *WatchElectedRequest, opts ...grpc.CallOption) (HATrackerState_WatchElectedClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_HATrackerState_serviceDesc.Streams[0], "/ha.HATrackerState/WatchElected", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &hATrackerStateWatchElectedClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func (m *ReplicaDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
+type HATrackerState_WatchElectedClient interface {
+	Recv() (*ElectedReplicaUpdate, error)
+	grpc.ClientStream
+}
+
+type hATrackerStateWatchElectedClient struct {
+	grpc.ClientStream
+}
+
+func (x *hATrackerStateWatchElectedClient) Recv() (*ElectedReplicaUpdate, error) {
+	m := new(ElectedReplicaUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HATrackerStateServer is the server API for HATrackerState service.
+type HATrackerStateServer interface {
+	// GetElectedReplica returns the replica currently elected for a cluster of the tenant
+	// the request is made for.
+	GetElectedReplica(context.Context, *GetElectedReplicaRequest) (*GetElectedReplicaResponse, error)
+	// WatchElected streams the elected replicas of the tenant the request is made for.
+	// The currently elected replicas are sent first, followed by the elected replica changes. The
+	// refreshes of the received_at timestamp of an elected replica aren't streamed.
+	WatchElected(*WatchElectedRequest, HATrackerState_WatchElectedServer) error
+}
+
+// UnimplementedHATrackerStateServer can be embedded to have forward compatible implementations.
+type UnimplementedHATrackerStateServer struct {
+}
+
+func (*UnimplementedHATrackerStateServer) GetElectedReplica(ctx context.Context, req *GetElectedReplicaRequest) (*GetElectedReplicaResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetElectedReplica not implemented")
+}
+func (*UnimplementedHATrackerStateServer) WatchElected(req *WatchElectedRequest, srv HATrackerState_WatchElectedServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchElected not implemented")
+}
+
+func RegisterHATrackerStateServer(s *grpc.Server, srv HATrackerStateServer) {
+	s.RegisterService(&_HATrackerState_serviceDesc, srv)
+}
+
+func _HATrackerState_GetElectedReplica_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetElectedReplicaRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HATrackerStateServer).GetElectedReplica(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ha.HATrackerState/GetElectedReplica",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HATrackerStateServer).GetElectedReplica(ctx, req.(*GetElectedReplicaRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HATrackerState_WatchElected_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchElectedRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HATrackerStateServer).WatchElected(m, &hATrackerStateWatchElectedServer{stream})
+}
+
+type HATrackerState_WatchElectedServer interface {
+	Send(*ElectedReplicaUpdate) error
+	grpc.ServerStream
+}
+
+type hATrackerStateWatchElectedServer struct {
+	grpc.ServerStream
+}
+
+func (x *hATrackerStateWatchElectedServer) Send(m *ElectedReplicaUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _HATrackerState_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "ha.HATrackerState",
+	HandlerType: (*HATrackerStateServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetElectedReplica",
+			Handler:    _HATrackerState_GetElectedReplica_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchElected",
+			Handler:       _HATrackerState_WatchElected_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "ha_tracker.proto",
+}
+
+func (m *ReplicaDesc) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicaDesc) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicaDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
 	if m.DeletedAt != 0 {
 		i = encodeVarintHaTracker(dAtA, i, uint64(m.DeletedAt))
 		i--
@@ -199,66 +711,664 @@ func (m *ReplicaDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 		i--
 		dAtA[i] = 0xa
 	}
-	return len(dAtA) - i, nil
-}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetElectedReplicaRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetElectedReplicaRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetElectedReplicaRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Cluster) > 0 {
+		i -= len(m.Cluster)
+		copy(dAtA[i:], m.Cluster)
+		i = encodeVarintHaTracker(dAtA, i, uint64(len(m.Cluster)))
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetElectedReplicaResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetElectedReplicaResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetElectedReplicaResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Replica != nil {
+		{
+			size, err := m.Replica.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHaTracker(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *WatchElectedRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WatchElectedRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *WatchElectedRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *ElectedReplicaUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ElectedReplicaUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ElectedReplicaUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Deleted {
+		i--
+		if m.Deleted {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Replica != nil {
+		{
+			size, err := m.Replica.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintHaTracker(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Cluster) > 0 {
+		i -= len(m.Cluster)
+		copy(dAtA[i:], m.Cluster)
+		i = encodeVarintHaTracker(dAtA, i, uint64(len(m.Cluster)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.User) > 0 {
+		i -= len(m.User)
+		copy(dAtA[i:], m.User)
+		i = encodeVarintHaTracker(dAtA, i, uint64(len(m.User)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintHaTracker(dAtA []byte, offset int, v uint64) int {
+	offset -= sovHaTracker(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ReplicaDesc) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Replica)
+	if l > 0 {
+		n += 1 + l + sovHaTracker(uint64(l))
+	}
+	if m.ReceivedAt != 0 {
+		n += 1 + sovHaTracker(uint64(m.ReceivedAt))
+	}
+	if m.DeletedAt != 0 {
+		n += 1 + sovHaTracker(uint64(m.DeletedAt))
+	}
+	return n
+}
+
+func (m *GetElectedReplicaRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Cluster)
+	if l > 0 {
+		n += 1 + l + sovHaTracker(uint64(l))
+	}
+	return n
+}
+
+func (m *GetElectedReplicaResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Replica != nil {
+		l = m.Replica.Size()
+		n += 1 + l + sovHaTracker(uint64(l))
+	}
+	return n
+}
+
+func (m *WatchElectedRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *ElectedReplicaUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovHaTracker(uint64(l))
+	}
+	l = len(m.Cluster)
+	if l > 0 {
+		n += 1 + l + sovHaTracker(uint64(l))
+	}
+	if m.Replica != nil {
+		l = m.Replica.Size()
+		n += 1 + l + sovHaTracker(uint64(l))
+	}
+	if m.Deleted {
+		n += 2
+	}
+	return n
+}
+
+func sovHaTracker(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozHaTracker(x uint64) (n int) {
+	return sovHaTracker(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (this *ReplicaDesc) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ReplicaDesc{`,
+		`Replica:` + fmt.Sprintf("%v", this.Replica) + `,`,
+		`ReceivedAt:` + fmt.Sprintf("%v", this.ReceivedAt) + `,`,
+		`DeletedAt:` + fmt.Sprintf("%v", this.DeletedAt) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *GetElectedReplicaRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&GetElectedReplicaRequest{`,
+		`Cluster:` + fmt.Sprintf("%v", this.Cluster) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *GetElectedReplicaResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&GetElectedReplicaResponse{`,
+		`Replica:` + strings.Replace(this.Replica.String(), "ReplicaDesc", "ReplicaDesc", 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *WatchElectedRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&WatchElectedRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *ElectedReplicaUpdate) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&ElectedReplicaUpdate{`,
+		`User:` + fmt.Sprintf("%v", this.User) + `,`,
+		`Cluster:` + fmt.Sprintf("%v", this.Cluster) + `,`,
+		`Replica:` + strings.Replace(this.Replica.String(), "ReplicaDesc", "ReplicaDesc", 1) + `,`,
+		`Deleted:` + fmt.Sprintf("%v", this.Deleted) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringHaTracker(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHaTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicaDesc: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicaDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Replica = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReceivedAt", wireType)
+			}
+			m.ReceivedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReceivedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeletedAt", wireType)
+			}
+			m.DeletedAt = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeletedAt |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHaTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetElectedReplicaRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHaTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetElectedReplicaRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetElectedReplicaRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cluster", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cluster = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHaTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
 
-func encodeVarintHaTracker(dAtA []byte, offset int, v uint64) int {
-	offset -= sovHaTracker(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return nil
 }
-func (m *ReplicaDesc) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.Replica)
-	if l > 0 {
-		n += 1 + l + sovHaTracker(uint64(l))
-	}
-	if m.ReceivedAt != 0 {
-		n += 1 + sovHaTracker(uint64(m.ReceivedAt))
-	}
-	if m.DeletedAt != 0 {
-		n += 1 + sovHaTracker(uint64(m.DeletedAt))
+func (m *GetElectedReplicaResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHaTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetElectedReplicaResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetElectedReplicaResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Replica == nil {
+				m.Replica = &ReplicaDesc{}
+			}
+			if err := m.Replica.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHaTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	return n
-}
 
-func sovHaTracker(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozHaTracker(x uint64) (n int) {
-	return sovHaTracker(uint64((x << 1) ^ uint64((int64(x) >> 63))))
-}
-func (this *ReplicaDesc) String() string {
-	if this == nil {
-		return "nil"
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	s := strings.Join([]string{`&ReplicaDesc{`,
-		`Replica:` + fmt.Sprintf("%v", this.Replica) + `,`,
-		`ReceivedAt:` + fmt.Sprintf("%v", this.ReceivedAt) + `,`,
-		`DeletedAt:` + fmt.Sprintf("%v", this.DeletedAt) + `,`,
-		`}`,
-	}, "")
-	return s
+	return nil
 }
-func valueToStringHaTracker(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
-		return "nil"
+func (m *WatchElectedRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowHaTracker
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WatchElectedRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WatchElectedRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipHaTracker(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
+func (m *ElectedReplicaUpdate) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -281,15 +1391,15 @@ func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReplicaDesc: wiretype end group for non-group")
+			return fmt.Errorf("proto: ElectedReplicaUpdate: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReplicaDesc: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ElectedReplicaUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -317,13 +1427,13 @@ func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Replica = string(dAtA[iNdEx:postIndex])
+			m.User = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReceivedAt", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Cluster", wireType)
 			}
-			m.ReceivedAt = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowHaTracker
@@ -333,16 +1443,65 @@ func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReceivedAt |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Cluster = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Replica", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowHaTracker
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthHaTracker
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Replica == nil {
+				m.Replica = &ReplicaDesc{}
+			}
+			if err := m.Replica.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field DeletedAt", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Deleted", wireType)
 			}
-			m.DeletedAt = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowHaTracker
@@ -352,11 +1511,12 @@ func (m *ReplicaDesc) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.DeletedAt |= int64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			m.Deleted = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipHaTracker(dAtA[iNdEx:])
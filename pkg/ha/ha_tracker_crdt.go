@@ -0,0 +1,212 @@
+package ha
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/prometheus/model/timestamp"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
+)
+
+// checkGossip is the CRDT election mode counterpart of checkKVStore. The elected replica timestamp
+// is refreshed through a last-writer-wins register gossiped between the distributors, while the
+// elections are arbitrated by a CAS on the KV store. Given the refreshes are the vast majority of
+// the writes, this removes most of the KV store CAS calls.
+//
+// Correctness bounds, compared to the KV election mode:
+//   - The elections are serialized by the KV store, so two distributors can't elect two different
+//     replicas of the same group when failing over.
+//   - The refreshes aren't serialized, but they never change the elected replica. Distributors
+//     converge to the most recent refresh within the gossip propagation time, so a distributor may
+//     fail over up to the gossip propagation time later (or earlier) than the others.
+//   - The KV store only tracks the time of the last election, so a distributor without the gossiped
+//     state (eg. before joining the memberlist cluster) may fail over a healthy replica group whose
+//     last election is older than the failover timeout. The failover is then gossiped and wins
+//     over the previous refreshes, so all the distributors converge to the new elected replica.
+//   - The replicas deleted from the KV store are kept as gossiped tombstones, since memberlist
+//     doesn't support deletions. The tombstones are removed after the memberlist left ingesters
+//     timeout, and the clients never see them, since memberlist removes them before returning
+//     the values.
+func (c *HATracker) checkGossip(ctx context.Context, userID, replicaGroup, key, replica string, now time.Time) error {
+	var (
+		elect           bool
+		notMatched      error
+		updateTimeout   = c.updateTimeout(userID) + c.updateTimeoutJitter
+		failoverTimeout = c.failoverTimeout(userID)
+	)
+
+	// The memberlist client wraps the errors returned by the CAS function, so the
+	// ReplicasNotMatchError is tracked outside of it.
+	err := c.gossipClient.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+		elect, notMatched = false, nil
+
+		if desc, ok := in.(*ReplicaDesc); ok && desc != nil && desc.DeletedAt == 0 {
+			if desc.Replica == replica {
+				if now.Sub(timestamp.Time(desc.ReceivedAt)) < updateTimeout {
+					return nil, false, nil
+				}
+
+				// Refreshing the elected replica doesn't require arbitration.
+				return &ReplicaDesc{Replica: replica, ReceivedAt: timestamp.FromTime(now)}, true, nil
+			}
+
+			if now.Sub(timestamp.Time(desc.ReceivedAt)) < failoverTimeout {
+				notMatched = ReplicasNotMatchError{replica: replica, elected: desc.Replica}
+				return nil, false, nil
+			}
+		}
+
+		elect = true
+		return nil, false, nil
+	})
+	c.gossipCASCalls.WithLabelValues(userID, replicaGroup).Inc()
+
+	if err != nil {
+		return err
+	}
+	if notMatched != nil || !elect {
+		return notMatched
+	}
+
+	err = c.electInKVStore(ctx, key, replica, now, failoverTimeout)
+	c.kvCASCalls.WithLabelValues(userID, replicaGroup).Inc()
+	if err != nil {
+		return err
+	}
+
+	// Gossip the election outcome, unless a more recent write has been received in the meanwhile.
+	elected := &ReplicaDesc{Replica: replica, ReceivedAt: timestamp.FromTime(now)}
+	err = c.gossipClient.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+		if desc, ok := in.(*ReplicaDesc); ok && desc != nil && !elected.newerThan(desc) {
+			return nil, false, nil
+		}
+		return elected, true, nil
+	})
+	c.gossipCASCalls.WithLabelValues(userID, replicaGroup).Inc()
+
+	return err
+}
+
+// electInKVStore elects the input replica in the KV store, unless another replica has been elected
+// less than the failover timeout ago.
+func (c *HATracker) electInKVStore(ctx context.Context, key, replica string, now time.Time, failoverTimeout time.Duration) error {
+	return c.client.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+		if desc, ok := in.(*ReplicaDesc); ok && desc.DeletedAt == 0 && now.Sub(timestamp.Time(desc.ReceivedAt)) < failoverTimeout {
+			if desc.Replica != replica {
+				return nil, false, ReplicasNotMatchError{replica: replica, elected: desc.Replica}
+			}
+
+			// The replica has just been elected by another distributor.
+			return nil, false, nil
+		}
+
+		return &ReplicaDesc{
+			Replica:    replica,
+			ReceivedAt: timestamp.FromTime(now),
+			DeletedAt:  0,
+		}, true, nil
+	})
+}
+
+// electedAfter returns whether the in-memory elected replica for the input key has been
+// received after the input deadline.
+func (c *HATracker) electedAfter(key string, deadline time.Time) bool {
+	c.electedLock.RLock()
+	defer c.electedLock.RUnlock()
+
+	elected, ok := c.elected[key]
+	return ok && !timestamp.Time(elected.ReceivedAt).Before(deadline)
+}
+
+// markGossipForDeletion propagates the deletion mark of the input key through gossip.
+func (c *HATracker) markGossipForDeletion(ctx context.Context, key string) {
+	err := c.gossipClient.CAS(ctx, key, func(in interface{}) (out interface{}, retry bool, err error) {
+		d, ok := in.(*ReplicaDesc)
+		if !ok || d == nil || d.Replica == "" || d.DeletedAt > 0 {
+			return nil, false, nil
+		}
+
+		d.DeletedAt = timestamp.FromTime(time.Now())
+		return d, true, nil
+	})
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "cleanup: failed to gossip the replica deletion", "key", key, "err", err)
+	}
+}
+
+// version returns the logical version of the descriptor. Marking a replica for deletion
+// is a more recent write than the last refresh.
+func (d *ReplicaDesc) version() int64 {
+	return max(d.ReceivedAt, d.DeletedAt)
+}
+
+// newerThan returns whether d wins over other with last-writer-wins semantics. The ties
+// are broken comparing the other fields, so that the order is total and all the
+// distributors converge to the same value regardless of the order of the updates.
+func (d *ReplicaDesc) newerThan(other *ReplicaDesc) bool {
+	if d.version() != other.version() {
+		return d.version() > other.version()
+	}
+	if d.Replica != other.Replica {
+		return d.Replica > other.Replica
+	}
+	if d.ReceivedAt != other.ReceivedAt {
+		return d.ReceivedAt > other.ReceivedAt
+	}
+	return d.DeletedAt > other.DeletedAt
+}
+
+// Merge implements memberlist.Mergeable. The descriptors are merged with last-writer-wins semantics.
+func (d *ReplicaDesc) Merge(mergeable memberlist.Mergeable, _ bool) (memberlist.Mergeable, error) {
+	if mergeable == nil {
+		return nil, nil
+	}
+
+	other, ok := mergeable.(*ReplicaDesc)
+	if !ok {
+		return nil, fmt.Errorf("expected *ha.ReplicaDesc, got %T", mergeable)
+	}
+
+	if other == nil || !other.newerThan(d) {
+		return nil, nil
+	}
+
+	d.Replica = other.Replica
+	d.ReceivedAt = other.ReceivedAt
+	d.DeletedAt = other.DeletedAt
+
+	return other, nil
+}
+
+// MergeContent implements memberlist.Mergeable. A descriptor whose tombstone has been removed
+// has no content, so it's not gossiped.
+func (d *ReplicaDesc) MergeContent() []string {
+	if d.Replica == "" {
+		return nil
+	}
+	return []string{d.Replica}
+}
+
+// RemoveTombstones implements memberlist.Mergeable. A descriptor can't remove itself, so the
+// tombstones marked for deletion before the limit are reset to an empty descriptor, which loses
+// against any update. If the limit is zero, the tombstone is always removed.
+func (d *ReplicaDesc) RemoveTombstones(limit time.Time) (total, removed int) {
+	if d.DeletedAt == 0 {
+		return 0, 0
+	}
+
+	if limit.IsZero() || timestamp.Time(d.DeletedAt).Before(limit) {
+		d.Reset()
+		return 0, 1
+	}
+	return 1, 0
+}
+
+// Clone implements memberlist.Mergeable.
+func (d *ReplicaDesc) Clone() interface{} {
+	return proto.Clone(d).(*ReplicaDesc)
+}
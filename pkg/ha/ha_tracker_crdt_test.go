@@ -0,0 +1,202 @@
+package ha
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/timestamp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+type dnsProviderMock struct {
+	resolved []string
+}
+
+func (p *dnsProviderMock) Resolve(_ context.Context, addrs []string) error {
+	p.resolved = addrs
+	return nil
+}
+
+func (p dnsProviderMock) Addresses() []string {
+	return p.resolved
+}
+
+func TestReplicaDesc_Merge(t *testing.T) {
+	t.Parallel()
+
+	descs := []*ReplicaDesc{
+		{Replica: "r1", ReceivedAt: 1000},
+		{Replica: "r1", ReceivedAt: 3000},
+		{Replica: "r2", ReceivedAt: 3000},
+		{Replica: "r2", ReceivedAt: 2000},
+		{Replica: "r1", ReceivedAt: 1000, DeletedAt: 2500},
+	}
+	expected := &ReplicaDesc{Replica: "r2", ReceivedAt: 3000}
+
+	// The merge result doesn't depend on the order the updates are received.
+	for _, order := range [][]int{{0, 1, 2, 3, 4}, {4, 3, 2, 1, 0}, {2, 0, 4, 1, 3}} {
+		merged := descs[order[0]].Clone().(*ReplicaDesc)
+		for _, i := range order[1:] {
+			_, err := merged.Merge(descs[i].Clone().(*ReplicaDesc), false)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, expected, merged)
+	}
+
+	// Merging the same value again is a no-op.
+	change, err := expected.Clone().(*ReplicaDesc).Merge(expected.Clone().(*ReplicaDesc), false)
+	require.NoError(t, err)
+	assert.Nil(t, change)
+
+	// Marking for deletion is more recent than the last refresh.
+	merged := &ReplicaDesc{Replica: "r1", ReceivedAt: 1000}
+	change, err = merged.Merge(&ReplicaDesc{Replica: "r1", ReceivedAt: 1000, DeletedAt: 2000}, false)
+	require.NoError(t, err)
+	assert.NotNil(t, change)
+	assert.Equal(t, int64(2000), merged.DeletedAt)
+
+	// The tombstones are only removed once marked for deletion before the limit.
+	total, removed := merged.RemoveTombstones(timestamp.Time(2000))
+	assert.Equal(t, 1, total)
+	assert.Equal(t, 0, removed)
+	assert.Equal(t, int64(2000), merged.DeletedAt)
+
+	total, removed = merged.RemoveTombstones(timestamp.Time(2001))
+	assert.Equal(t, 0, total)
+	assert.Equal(t, 1, removed)
+	assert.Empty(t, merged.MergeContent())
+
+	// A removed tombstone loses against any update.
+	change, err = merged.Merge(&ReplicaDesc{Replica: "r2", ReceivedAt: 500}, false)
+	require.NoError(t, err)
+	assert.NotNil(t, change)
+	assert.Equal(t, "r2", merged.Replica)
+
+	total, removed = merged.RemoveTombstones(time.Time{})
+	assert.Equal(t, 0, total)
+	assert.Equal(t, 0, removed)
+}
+
+func TestCheckReplica_CRDTElectionMode(t *testing.T) {
+	t.Parallel()
+
+	kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	var mkvCfg memberlist.KVConfig
+	flagext.DefaultValues(&mkvCfg)
+	mkvCfg.TCPTransport = memberlist.TCPTransportConfig{
+		BindAddrs: []string{"localhost"},
+		BindPort:  0,
+	}
+	mkvCfg.Codecs = []codec.Codec{GetReplicaDescCodec()}
+
+	mkv := memberlist.NewKV(mkvCfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), mkv))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(context.Background(), mkv)) })
+
+	reg := prometheus.NewPedanticRegistry()
+	c, err := NewHATracker(HATrackerConfig{
+		EnableHATracker: true,
+		KVStore: kv.Config{
+			Mock:        kvStore,
+			StoreConfig: kv.StoreConfig{MemberlistKV: func() (*memberlist.KV, error) { return mkv, nil }},
+		},
+		UpdateTimeout:          time.Second,
+		UpdateTimeoutJitterMax: 0,
+		FailoverTimeout:        5 * time.Second,
+		ElectionMode:           ElectionModeCRDT,
+	}, trackerLimits{maxReplicaGroups: 100}, haTrackerStatusConfig, reg, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+	kvReceivedAt := func() int64 {
+		val, err := kvStore.Get(context.Background(), "user/cluster")
+		require.NoError(t, err)
+		return val.(*ReplicaDesc).ReceivedAt
+	}
+
+	// The first sample elects the replica through the KV store.
+	now := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, c.CheckReplica(context.Background(), "user", "cluster", "r1", now))
+	checkReplicaTimestamp(t, time.Second, c, "user", "cluster", "r1", now)
+	assert.Equal(t, timestamp.FromTime(now), kvReceivedAt())
+
+	// The samples from the other replicas are rejected.
+	err = c.CheckReplica(context.Background(), "user", "cluster", "r2", now)
+	assert.ErrorIs(t, err, ReplicasNotMatchError{})
+
+	// Refreshing the elected replica is only gossiped.
+	refreshedAt := now.Add(2 * time.Second)
+	require.NoError(t, c.CheckReplica(context.Background(), "user", "cluster", "r1", refreshedAt))
+	checkReplicaTimestamp(t, time.Second, c, "user", "cluster", "r1", refreshedAt)
+	assert.Equal(t, timestamp.FromTime(now), kvReceivedAt())
+
+	// The failover timeout is computed from the last gossiped refresh, not from the KV store.
+	err = c.CheckReplica(context.Background(), "user", "cluster", "r2", now.Add(6*time.Second))
+	assert.ErrorIs(t, err, ReplicasNotMatchError{})
+
+	// Failing over elects the new replica through the KV store.
+	failoverAt := refreshedAt.Add(6 * time.Second)
+	require.NoError(t, c.CheckReplica(context.Background(), "user", "cluster", "r2", failoverAt))
+	checkReplicaTimestamp(t, time.Second, c, "user", "cluster", "r2", failoverAt)
+	assert.Equal(t, timestamp.FromTime(failoverAt), kvReceivedAt())
+
+	// Only the elections went through the KV store.
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP ha_tracker_kv_store_cas_total The total number of CAS calls to the KV store for a user ID/cluster.
+		# TYPE ha_tracker_kv_store_cas_total counter
+		ha_tracker_kv_store_cas_total{cluster="cluster",user="user"} 2
+	`), "ha_tracker_kv_store_cas_total"))
+
+	// A restarted tracker warms up the last refresh from memberlist, not only the last election from the KV store.
+	refreshedAt = failoverAt.Add(2 * time.Second)
+	require.NoError(t, c.CheckReplica(context.Background(), "user", "cluster", "r2", refreshedAt))
+	assert.Equal(t, timestamp.FromTime(failoverAt), kvReceivedAt())
+
+	restarted, err := NewHATracker(HATrackerConfig{
+		EnableHATracker: true,
+		KVStore: kv.Config{
+			Mock:        kvStore,
+			StoreConfig: kv.StoreConfig{MemberlistKV: func() (*memberlist.KV, error) { return mkv, nil }},
+		},
+		UpdateTimeout:          time.Second,
+		UpdateTimeoutJitterMax: 0,
+		FailoverTimeout:        5 * time.Second,
+		ElectionMode:           ElectionModeCRDT,
+	}, trackerLimits{maxReplicaGroups: 100}, haTrackerStatusConfig, prometheus.NewPedanticRegistry(), "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), restarted))
+	defer services.StopAndAwaitTerminated(context.Background(), restarted) //nolint:errcheck
+
+	restarted.electedLock.RLock()
+	elected := restarted.elected["user/cluster"]
+	restarted.electedLock.RUnlock()
+	assert.Equal(t, "r2", elected.Replica)
+	assert.Equal(t, timestamp.FromTime(refreshedAt), elected.ReceivedAt)
+}
+
+func TestHATracker_ShouldRequireMemberlistWithCRDTElectionMode(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewHATracker(HATrackerConfig{
+		EnableHATracker: true,
+		KVStore:         kv.Config{Store: "inmemory"},
+		ElectionMode:    ElectionModeCRDT,
+	}, trackerLimits{maxReplicaGroups: 100}, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.Error(t, err)
+}
@@ -0,0 +1,115 @@
+package ha
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+// Max number of updates buffered for each watcher. A watcher not keeping up with the updates
+// is disconnected, and has to watch again to resync.
+const watcherBufferSize = 1024
+
+var errWatcherTooSlow = status.Error(codes.ResourceExhausted, "the watcher is too slow to consume the elected replicas updates, watch again to resync")
+
+// electedWatcher is a client of the WatchElected API.
+type electedWatcher struct {
+	user    string
+	updates chan *ElectedReplicaUpdate
+}
+
+// GetElectedReplica implements HATrackerStateServer.
+func (c *HATracker) GetElectedReplica(ctx context.Context, req *GetElectedReplicaRequest) (*GetElectedReplicaResponse, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	if req.Cluster == "" {
+		return nil, status.Error(codes.InvalidArgument, "the cluster must be set")
+	}
+
+	c.electedLock.RLock()
+	defer c.electedLock.RUnlock()
+
+	resp := &GetElectedReplicaResponse{}
+	if desc, ok := c.elected[fmt.Sprintf("%s/%s", userID, req.Cluster)]; ok {
+		resp.Replica = &desc
+	}
+	return resp, nil
+}
+
+// WatchElected implements HATrackerStateServer.
+func (c *HATracker) WatchElected(_ *WatchElectedRequest, stream HATrackerState_WatchElectedServer) error {
+	userID, err := tenant.TenantID(stream.Context())
+	if err != nil {
+		return status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	w := &electedWatcher{
+		user:    userID,
+		updates: make(chan *ElectedReplicaUpdate, watcherBufferSize),
+	}
+
+	// Take a snapshot of the elected replicas while registering the watcher, so that
+	// no update gets lost in between.
+	c.electedLock.Lock()
+	snapshot := make([]*ElectedReplicaUpdate, 0, len(c.elected))
+	for key, desc := range c.elected {
+		user, cluster, _ := strings.Cut(key, "/")
+		if w.user != user {
+			continue
+		}
+		desc := desc
+		snapshot = append(snapshot, &ElectedReplicaUpdate{User: user, Cluster: cluster, Replica: &desc})
+	}
+	c.watchers[w] = struct{}{}
+	c.electedLock.Unlock()
+
+	defer func() {
+		c.electedLock.Lock()
+		delete(c.watchers, w)
+		c.electedLock.Unlock()
+	}()
+
+	for _, u := range snapshot {
+		if err := stream.Send(u); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case u, ok := <-w.updates:
+			if !ok {
+				return errWatcherTooSlow
+			}
+			if err := stream.Send(u); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// notifyWatchers sends the input update to the watchers of the user. Watchers whose buffer is full
+// are disconnected. Must be called with the electedLock held.
+func (c *HATracker) notifyWatchers(u *ElectedReplicaUpdate) {
+	for w := range c.watchers {
+		if w.user != u.User {
+			continue
+		}
+
+		select {
+		case w.updates <- u:
+		default:
+			close(w.updates)
+			delete(c.watchers, w)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ha
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestHATracker_GetElectedReplica(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewHATracker(HATrackerConfig{EnableHATracker: false}, nil, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-1", ReceivedAt: 1000})
+
+	c.updateElected("user-2/cluster-2", &ReplicaDesc{Replica: "replica-1", ReceivedAt: 1000})
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	resp, err := c.GetElectedReplica(ctx, &GetElectedReplicaRequest{Cluster: "cluster-1"})
+	require.NoError(t, err)
+	assert.Equal(t, &ReplicaDesc{Replica: "replica-1", ReceivedAt: 1000}, resp.Replica)
+
+	// The clusters of other tenants shouldn't be visible.
+	resp, err = c.GetElectedReplica(ctx, &GetElectedReplicaRequest{Cluster: "cluster-2"})
+	require.NoError(t, err)
+	assert.Nil(t, resp.Replica)
+
+	_, err = c.GetElectedReplica(ctx, &GetElectedReplicaRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = c.GetElectedReplica(context.Background(), &GetElectedReplicaRequest{Cluster: "cluster-1"})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestHATracker_WatchElected(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewHATracker(HATrackerConfig{EnableHATracker: false}, nil, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-1", ReceivedAt: 1000})
+	c.updateElected("user-2/cluster-1", &ReplicaDesc{Replica: "replica-1", ReceivedAt: 1000})
+
+	ctx, cancel := context.WithCancel(user.InjectOrgID(context.Background(), "user-1"))
+	stream := &mockWatchElectedServer{ctx: ctx, updates: make(chan *ElectedReplicaUpdate, 10)}
+	done := make(chan error)
+	go func() {
+		done <- c.WatchElected(&WatchElectedRequest{}, stream)
+	}()
+
+	// The currently elected replicas of the user should be sent first. The watcher is registered
+	// before the snapshot is sent, so no update can be missed from now on.
+	assert.Equal(t, &ElectedReplicaUpdate{User: "user-1", Cluster: "cluster-1", Replica: &ReplicaDesc{Replica: "replica-1", ReceivedAt: 1000}}, <-stream.updates)
+
+	// The timestamp refreshes and the updates of other users shouldn't be streamed.
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-1", ReceivedAt: 2000})
+	c.updateElected("user-2/cluster-1", &ReplicaDesc{Replica: "replica-2", ReceivedAt: 2000})
+
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-2", ReceivedAt: 3000})
+	assert.Equal(t, &ElectedReplicaUpdate{User: "user-1", Cluster: "cluster-1", Replica: &ReplicaDesc{Replica: "replica-2", ReceivedAt: 3000}}, <-stream.updates)
+
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-2", ReceivedAt: 3000, DeletedAt: 4000})
+	assert.Equal(t, &ElectedReplicaUpdate{User: "user-1", Cluster: "cluster-1", Deleted: true}, <-stream.updates)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Empty(t, stream.updates)
+	assert.Empty(t, c.watchers)
+}
+
+func TestHATracker_WatchElected_ShouldRequireTenant(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewHATracker(HATrackerConfig{EnableHATracker: false}, nil, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+
+	stream := &mockWatchElectedServer{ctx: context.Background(), updates: make(chan *ElectedReplicaUpdate, 10)}
+	err = c.WatchElected(&WatchElectedRequest{}, stream)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Empty(t, c.watchers)
+}
+
+func TestHATracker_WatchElected_ShouldDisconnectSlowWatchers(t *testing.T) {
+	t.Parallel()
+
+	c, err := NewHATracker(HATrackerConfig{EnableHATracker: false}, nil, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+
+	w := &electedWatcher{user: "user-1", updates: make(chan *ElectedReplicaUpdate, 1)}
+	c.watchers[w] = struct{}{}
+
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-1"})
+	c.updateElected("user-1/cluster-1", &ReplicaDesc{Replica: "replica-2"})
+	assert.Empty(t, c.watchers)
+
+	// The buffered update should still be received before the channel gets closed.
+	_, ok := <-w.updates
+	assert.True(t, ok)
+	_, ok = <-w.updates
+	assert.False(t, ok)
+}
+
+type mockWatchElectedServer struct {
+	grpc.ServerStream
+
+	ctx     context.Context
+	updates chan *ElectedReplicaUpdate
+}
+
+func (m *mockWatchElectedServer) Send(u *ElectedReplicaUpdate) error {
+	m.updates <- u
+	return nil
+}
+
+func (m *mockWatchElectedServer) Context() context.Context {
+	return m.ctx
+}
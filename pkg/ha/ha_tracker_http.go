@@ -81,8 +81,8 @@ func (h *HATracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			Cluster:      chunks[1],
 			Replica:      desc.Replica,
 			ElectedAt:    timestamp.Time(desc.ReceivedAt),
-			UpdateTime:   time.Until(timestamp.Time(desc.ReceivedAt).Add(h.cfg.UpdateTimeout)),
-			FailoverTime: time.Until(timestamp.Time(desc.ReceivedAt).Add(h.cfg.FailoverTimeout)),
+			UpdateTime:   time.Until(timestamp.Time(desc.ReceivedAt).Add(h.updateTimeout(chunks[0]))),
+			FailoverTime: time.Until(timestamp.Time(desc.ReceivedAt).Add(h.failoverTimeout(chunks[0]))),
 		})
 	}
 	h.electedLock.RUnlock()
@@ -107,3 +107,61 @@ func (h *HATracker) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		Config:  h.trackerStatusConfig,
 	}, trackerTmpl, req)
 }
+
+// ElectedReplica is the replica elected for a cluster of a user, as returned by the elected replicas API.
+type ElectedReplica struct {
+	UserID     string    `json:"user_id"`
+	Cluster    string    `json:"cluster"`
+	Replica    string    `json:"replica"`
+	ReceivedAt time.Time `json:"received_at"`
+	// The time after which another replica can be elected, if no samples are received from the elected one.
+	FailoverAt time.Time `json:"failover_at"`
+	// Seconds until FailoverAt, or 0 if the failover timeout has already expired.
+	TimeUntilFailoverSeconds float64 `json:"time_until_failover_seconds"`
+}
+
+// ElectedReplicasResponse is the response of the elected replicas API.
+type ElectedReplicasResponse struct {
+	Replicas []ElectedReplica `json:"replicas"`
+}
+
+// ElectedReplicasHandler returns the replicas elected by this HA tracker as JSON, sorted by user and
+// cluster. The optional user and cluster query parameters filter the returned replicas.
+func (h *HATracker) ElectedReplicasHandler(w http.ResponseWriter, req *http.Request) {
+	var (
+		userFilter    = req.URL.Query().Get("user")
+		clusterFilter = req.URL.Query().Get("cluster")
+		now           = time.Now()
+		res           = ElectedReplicasResponse{Replicas: []ElectedReplica{}}
+	)
+
+	for key, desc := range h.SnapshotElectedReplicas() {
+		chunks := strings.SplitN(key, "/", 2)
+		if len(chunks) != 2 || desc.DeletedAt > 0 {
+			continue
+		}
+		if (userFilter != "" && chunks[0] != userFilter) || (clusterFilter != "" && chunks[1] != clusterFilter) {
+			continue
+		}
+
+		receivedAt := timestamp.Time(desc.ReceivedAt)
+		failoverAt := receivedAt.Add(h.failoverTimeout(chunks[0]))
+		res.Replicas = append(res.Replicas, ElectedReplica{
+			UserID:                   chunks[0],
+			Cluster:                  chunks[1],
+			Replica:                  desc.Replica,
+			ReceivedAt:               receivedAt,
+			FailoverAt:               failoverAt,
+			TimeUntilFailoverSeconds: max(failoverAt.Sub(now), 0).Seconds(),
+		})
+	}
+
+	sort.Slice(res.Replicas, func(i, j int) bool {
+		if res.Replicas[i].UserID != res.Replicas[j].UserID {
+			return res.Replicas[i].UserID < res.Replicas[j].UserID
+		}
+		return res.Replicas[i].Cluster < res.Replicas[j].Cluster
+	})
+
+	util.WriteJSONResponse(w, res)
+}
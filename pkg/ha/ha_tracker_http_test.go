@@ -0,0 +1,98 @@
+package ha
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv"
+	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+func TestHATracker_ElectedReplicasHandler(t *testing.T) {
+	t.Parallel()
+
+	kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	c, err := NewHATracker(HATrackerConfig{
+		EnableHATracker:        true,
+		KVStore:                kv.Config{Mock: kvStore},
+		UpdateTimeout:          time.Second,
+		UpdateTimeoutJitterMax: 0,
+		FailoverTimeout:        time.Minute,
+	}, trackerLimits{maxReplicaGroups: 100, failoverTimeout: 2 * time.Minute}, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+	now := time.Now().Truncate(time.Millisecond)
+	require.NoError(t, c.CheckReplica(context.Background(), "user-2", "cluster-1", "replica-1", now))
+	require.NoError(t, c.CheckReplica(context.Background(), "user-1", "cluster-2", "replica-2", now))
+	require.NoError(t, c.CheckReplica(context.Background(), "user-1", "cluster-1", "replica-1", now))
+	checkReplicaTimestamp(t, time.Second, c, "user-2", "cluster-1", "replica-1", now)
+	checkReplicaTimestamp(t, time.Second, c, "user-1", "cluster-2", "replica-2", now)
+	checkReplicaTimestamp(t, time.Second, c, "user-1", "cluster-1", "replica-1", now)
+
+	tests := map[string]struct {
+		query    string
+		expected []ElectedReplica
+	}{
+		"should return all the elected replicas sorted by user and cluster": {
+			expected: []ElectedReplica{
+				{UserID: "user-1", Cluster: "cluster-1", Replica: "replica-1"},
+				{UserID: "user-1", Cluster: "cluster-2", Replica: "replica-2"},
+				{UserID: "user-2", Cluster: "cluster-1", Replica: "replica-1"},
+			},
+		},
+		"should filter the elected replicas by user": {
+			query: "?user=user-1",
+			expected: []ElectedReplica{
+				{UserID: "user-1", Cluster: "cluster-1", Replica: "replica-1"},
+				{UserID: "user-1", Cluster: "cluster-2", Replica: "replica-2"},
+			},
+		},
+		"should filter the elected replicas by user and cluster": {
+			query: "?user=user-1&cluster=cluster-2",
+			expected: []ElectedReplica{
+				{UserID: "user-1", Cluster: "cluster-2", Replica: "replica-2"},
+			},
+		},
+		"should return no replicas for an unknown user": {
+			query:    "?user=unknown",
+			expected: []ElectedReplica{},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c.ElectedReplicasHandler(rec, httptest.NewRequest(http.MethodGet, "/ha-tracker/elected-replicas"+testData.query, nil))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var res ElectedReplicasResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			require.Len(t, res.Replicas, len(testData.expected))
+
+			for i, expected := range testData.expected {
+				actual := res.Replicas[i]
+				assert.Equal(t, expected.UserID, actual.UserID)
+				assert.Equal(t, expected.Cluster, actual.Cluster)
+				assert.Equal(t, expected.Replica, actual.Replica)
+				assert.True(t, now.Equal(actual.ReceivedAt))
+				// The failover time honors the per-user failover timeout.
+				assert.True(t, now.Add(2*time.Minute).Equal(actual.FailoverAt))
+				assert.InDelta(t, 120, actual.TimeUntilFailoverSeconds, 5)
+			}
+		})
+	}
+}
@@ -109,13 +109,41 @@ func TestHATrackerConfig_Validate(t *testing.T) {
 			expectedErr: nil,
 		},
 		"should failed with invalid kv store": {
+			cfg: func() HATrackerConfig {
+				cfg := HATrackerConfig{}
+				flagext.DefaultValues(&cfg)
+				cfg.KVStore.Store = "inmemory"
+				return cfg
+			}(),
+			expectedErr: fmt.Errorf("invalid HATracker KV store type: %s", "inmemory"),
+		},
+		"should fail with memberlist kv store": {
 			cfg: func() HATrackerConfig {
 				cfg := HATrackerConfig{}
 				flagext.DefaultValues(&cfg)
 				cfg.KVStore.Store = "memberlist"
 				return cfg
 			}(),
-			expectedErr: fmt.Errorf("invalid HATracker KV store type: %s", "memberlist"),
+			expectedErr: errMemberlistKVStore,
+		},
+		"should fail with memberlist kv store and crdt election mode": {
+			cfg: func() HATrackerConfig {
+				cfg := HATrackerConfig{}
+				flagext.DefaultValues(&cfg)
+				cfg.KVStore.Store = "memberlist"
+				cfg.ElectionMode = ElectionModeCRDT
+				return cfg
+			}(),
+			expectedErr: errMemberlistKVStore,
+		},
+		"should fail with invalid election mode": {
+			cfg: func() HATrackerConfig {
+				cfg := HATrackerConfig{}
+				flagext.DefaultValues(&cfg)
+				cfg.ElectionMode = "unknown"
+				return cfg
+			}(),
+			expectedErr: errInvalidElectionMode,
 		},
 	}
 
@@ -160,6 +188,48 @@ func TestWatchPrefixAssignment(t *testing.T) {
 	checkReplicaTimestamp(t, time.Second, c, "user", replicaGroup, replica, now)
 }
 
+func TestHATracker_ShouldWarmupElectedReplicasOnStartup(t *testing.T) {
+	t.Parallel()
+
+	kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	// Populate the KV store as a previous distributor would have done.
+	now := time.Now()
+	for key, desc := range map[string]*ReplicaDesc{
+		"user-1/cluster-1": {Replica: "replica-1", ReceivedAt: timestamp.FromTime(now)},
+		"user-1/cluster-2": {Replica: "replica-2", ReceivedAt: timestamp.FromTime(now)},
+		"user-2/cluster-1": {Replica: "replica-1", ReceivedAt: timestamp.FromTime(now), DeletedAt: timestamp.FromTime(now)},
+	} {
+		require.NoError(t, kvStore.CAS(context.Background(), key, func(interface{}) (interface{}, bool, error) {
+			return desc, true, nil
+		}))
+	}
+
+	c, err := NewHATracker(HATrackerConfig{
+		EnableHATracker:        true,
+		KVStore:                kv.Config{Mock: kvStore},
+		UpdateTimeout:          time.Minute,
+		UpdateTimeoutJitterMax: 0,
+		FailoverTimeout:        2 * time.Minute,
+	}, trackerLimits{maxReplicaGroups: 100}, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+	defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+	// The elected replicas are available as soon as the tracker is running.
+	elected := c.SnapshotElectedReplicas()
+	require.Len(t, elected, 2)
+	assert.Equal(t, "replica-1", elected["user-1/cluster-1"].Replica)
+	assert.Equal(t, "replica-2", elected["user-1/cluster-2"].Replica)
+
+	// The samples from the elected replicas are accepted without updating the KV store.
+	require.NoError(t, c.CheckReplica(context.Background(), "user-1", "cluster-1", "replica-1", now))
+	require.NoError(t, c.CheckReplica(context.Background(), "user-1", "cluster-2", "replica-2", now))
+	assert.Equal(t, float64(0), testutil.ToFloat64(c.kvCASCalls.WithLabelValues("user-1", "cluster-1")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(c.kvCASCalls.WithLabelValues("user-1", "cluster-2")))
+}
+
 func TestCheckReplicaOverwriteTimeout(t *testing.T) {
 	t.Parallel()
 	replica1 := "replica1"
@@ -198,6 +268,70 @@ func TestCheckReplicaOverwriteTimeout(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestCheckReplicaPerUserTimeouts(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		limits           trackerLimits
+		rejectedFailover time.Duration
+		acceptedFailover time.Duration
+	}{
+		"should use the configured failover timeout without overrides": {
+			limits:           trackerLimits{maxReplicaGroups: 100},
+			rejectedFailover: 900 * time.Millisecond,
+			acceptedFailover: 1100 * time.Millisecond,
+		},
+		"should use the per-user failover timeout": {
+			limits:           trackerLimits{maxReplicaGroups: 100, failoverTimeout: 5 * time.Second},
+			rejectedFailover: 4900 * time.Millisecond,
+			acceptedFailover: 5100 * time.Millisecond,
+		},
+		"should raise the per-user failover timeout to be greater than the update timeout": {
+			limits:           trackerLimits{maxReplicaGroups: 100, failoverTimeout: 200 * time.Millisecond},
+			rejectedFailover: 900 * time.Millisecond,
+			acceptedFailover: 1100 * time.Millisecond,
+		},
+		"should raise the configured failover timeout to be greater than the per-user update timeout": {
+			limits:           trackerLimits{maxReplicaGroups: 100, updateTimeout: 3 * time.Second},
+			rejectedFailover: 3900 * time.Millisecond,
+			acceptedFailover: 4100 * time.Millisecond,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			kvStore, closer := consul.NewInMemoryClient(GetReplicaDescCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			c, err := NewHATracker(HATrackerConfig{
+				EnableHATracker:        true,
+				KVStore:                kv.Config{Mock: kvStore},
+				UpdateTimeout:          100 * time.Millisecond,
+				UpdateTimeoutJitterMax: 0,
+				FailoverTimeout:        time.Second,
+			}, testData.limits, haTrackerStatusConfig, nil, "test-ha-tracker", log.NewNopLogger())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), c))
+			defer services.StopAndAwaitTerminated(context.Background(), c) //nolint:errcheck
+
+			now := time.Now()
+
+			// Write the first time.
+			require.NoError(t, c.CheckReplica(context.Background(), "user", "test", "replica1", now))
+
+			// Throw away the samples from replica2 before the failover timeout.
+			err = c.CheckReplica(context.Background(), "user", "test", "replica2", now.Add(testData.rejectedFailover))
+			assert.True(t, errors.Is(err, ReplicasNotMatchError{}))
+
+			// Fail over to replica2 after the failover timeout.
+			require.NoError(t, c.CheckReplica(context.Background(), "user", "test", "replica2", now.Add(testData.acceptedFailover)))
+		})
+	}
+}
+
 func TestCheckReplicaMultiCluster(t *testing.T) {
 	t.Parallel()
 	replica1 := "replica1"
@@ -613,12 +747,22 @@ func TestReplicasNotMatchError(t *testing.T) {
 
 type trackerLimits struct {
 	maxReplicaGroups int
+	updateTimeout    time.Duration
+	failoverTimeout  time.Duration
 }
 
 func (l trackerLimits) MaxHAReplicaGroups(_ string) int {
 	return l.maxReplicaGroups
 }
 
+func (l trackerLimits) HATrackerUpdateTimeout(_ string) time.Duration {
+	return l.updateTimeout
+}
+
+func (l trackerLimits) HATrackerFailoverTimeout(_ string) time.Duration {
+	return l.failoverTimeout
+}
+
 func TestHATracker_MetricsCleanup(t *testing.T) {
 	t.Parallel()
 	reg := prometheus.NewPedanticRegistry()
@@ -34,6 +34,15 @@ type activeSeriesStripe struct {
 type activeSeriesEntry struct {
 	lbs   labels.Labels
 	nanos *atomic.Int64 // Unix timestamp in nanoseconds. Needs to be a pointer because we don't store pointers to entries in the stripe.
+
+	// nativeHistogram is set for the native histogram series. Needs to be a pointer for the same reason as nanos.
+	nativeHistogram *activeNativeHistogram
+}
+
+// activeNativeHistogram holds the number of buckets and the schema of the last sample of a native histogram series.
+type activeNativeHistogram struct {
+	buckets atomic.Int32
+	schema  atomic.Int32
 }
 
 func NewActiveSeries() *ActiveSeries {
@@ -51,7 +60,15 @@ func NewActiveSeries() *ActiveSeries {
 func (c *ActiveSeries) UpdateSeries(series labels.Labels, hash uint64, now time.Time, labelsCopy func(labels.Labels) labels.Labels) {
 	stripeID := hash % numActiveSeriesStripes
 
-	c.stripes[stripeID].updateSeriesTimestamp(now, series, hash, labelsCopy)
+	c.stripes[stripeID].updateSeriesTimestamp(now, series, hash, false, 0, 0, labelsCopy)
+}
+
+// UpdateNativeHistogramSeries is like UpdateSeries for a native histogram series, and also keeps track of the
+// number of buckets and the schema of its last sample.
+func (c *ActiveSeries) UpdateNativeHistogramSeries(series labels.Labels, hash uint64, now time.Time, buckets int, schema int32, labelsCopy func(labels.Labels) labels.Labels) {
+	stripeID := hash % numActiveSeriesStripes
+
+	c.stripes[stripeID].updateSeriesTimestamp(now, series, hash, true, buckets, schema, labelsCopy)
 }
 
 // Purge removes expired entries from the cache. This function should be called
@@ -77,13 +94,31 @@ func (c *ActiveSeries) Active() int {
 	return total
 }
 
-func (s *activeSeriesStripe) updateSeriesTimestamp(now time.Time, series labels.Labels, fingerprint uint64, labelsCopy func(labels.Labels) labels.Labels) {
+// ActiveNativeHistograms returns the number of active native histogram series, along with the total number
+// of buckets and the sum of the schemas of their last sample.
+func (c *ActiveSeries) ActiveNativeHistograms() (series, buckets int, schemas int64) {
+	for s := 0; s < numActiveSeriesStripes; s++ {
+		stripeSeries, stripeBuckets, stripeSchemas := c.stripes[s].getActiveNativeHistograms()
+		series += stripeSeries
+		buckets += stripeBuckets
+		schemas += stripeSchemas
+	}
+	return series, buckets, schemas
+}
+
+func (s *activeSeriesStripe) updateSeriesTimestamp(now time.Time, series labels.Labels, fingerprint uint64, isHistogram bool, buckets int, schema int32, labelsCopy func(labels.Labels) labels.Labels) {
 	nowNanos := now.UnixNano()
 
-	e := s.findEntryForSeries(fingerprint, series)
+	e, h := s.findEntryForSeries(fingerprint, series)
 	entryTimeSet := false
-	if e == nil {
-		e, entryTimeSet = s.findOrCreateEntryForSeries(fingerprint, series, nowNanos, labelsCopy)
+	// The entry is also updated under lock when the type of the series changes.
+	if e == nil || isHistogram != (h != nil) {
+		e, h, entryTimeSet = s.findOrCreateEntryForSeries(fingerprint, series, nowNanos, isHistogram, labelsCopy)
+	}
+
+	if h != nil {
+		h.buckets.Store(int32(buckets))
+		h.schema.Store(schema)
 	}
 
 	if !entryTimeSet {
@@ -103,28 +138,34 @@ func (s *activeSeriesStripe) updateSeriesTimestamp(now time.Time, series labels.
 	}
 }
 
-func (s *activeSeriesStripe) findEntryForSeries(fingerprint uint64, series labels.Labels) *atomic.Int64 {
+func (s *activeSeriesStripe) findEntryForSeries(fingerprint uint64, series labels.Labels) (*atomic.Int64, *activeNativeHistogram) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	// Check if already exists within the entries.
 	for ix, entry := range s.refs[fingerprint] {
 		if labels.Equal(entry.lbs, series) {
-			return s.refs[fingerprint][ix].nanos
+			return s.refs[fingerprint][ix].nanos, s.refs[fingerprint][ix].nativeHistogram
 		}
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (s *activeSeriesStripe) findOrCreateEntryForSeries(fingerprint uint64, series labels.Labels, nowNanos int64, labelsCopy func(labels.Labels) labels.Labels) (*atomic.Int64, bool) {
+func (s *activeSeriesStripe) findOrCreateEntryForSeries(fingerprint uint64, series labels.Labels, nowNanos int64, isHistogram bool, labelsCopy func(labels.Labels) labels.Labels) (*atomic.Int64, *activeNativeHistogram, bool) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	// Check if already exists within the entries.
 	for ix, entry := range s.refs[fingerprint] {
 		if labels.Equal(entry.lbs, series) {
-			return s.refs[fingerprint][ix].nanos, false
+			e := &s.refs[fingerprint][ix]
+			if !isHistogram {
+				e.nativeHistogram = nil
+			} else if e.nativeHistogram == nil {
+				e.nativeHistogram = &activeNativeHistogram{}
+			}
+			return e.nanos, e.nativeHistogram, false
 		}
 	}
 
@@ -133,10 +174,13 @@ func (s *activeSeriesStripe) findOrCreateEntryForSeries(fingerprint uint64, seri
 		lbs:   labelsCopy(series),
 		nanos: atomic.NewInt64(nowNanos),
 	}
+	if isHistogram {
+		e.nativeHistogram = &activeNativeHistogram{}
+	}
 
 	s.refs[fingerprint] = append(s.refs[fingerprint], e)
 
-	return e.nanos, true
+	return e.nanos, e.nativeHistogram, true
 }
 
 // nolint // Linter reports that this method is unused, but it is.
@@ -217,3 +261,20 @@ func (s *activeSeriesStripe) getActive() int {
 
 	return s.active
 }
+
+func (s *activeSeriesStripe) getActiveNativeHistograms() (series, buckets int, schemas int64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, entries := range s.refs {
+		for _, e := range entries {
+			if e.nativeHistogram == nil {
+				continue
+			}
+			series++
+			buckets += int(e.nativeHistogram.buckets.Load())
+			schemas += int64(e.nativeHistogram.schema.Load())
+		}
+	}
+	return series, buckets, schemas
+}
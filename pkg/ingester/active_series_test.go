@@ -38,6 +38,42 @@ func TestActiveSeries_UpdateSeries(t *testing.T) {
 	assert.Equal(t, 2, c.Active())
 }
 
+func TestActiveSeries_UpdateNativeHistogramSeries(t *testing.T) {
+	ls1 := []labels.Label{{Name: "a", Value: "1"}}
+	ls2 := []labels.Label{{Name: "a", Value: "2"}}
+	labels1Hash := fromLabelToLabels(ls1).Hash()
+	labels2Hash := fromLabelToLabels(ls2).Hash()
+
+	c := NewActiveSeries()
+	c.UpdateSeries(ls1, labels1Hash, time.Now(), copyFn)
+	c.UpdateNativeHistogramSeries(ls2, labels2Hash, time.Now(), 10, 3, copyFn)
+	assert.Equal(t, 2, c.Active())
+	assertActiveNativeHistograms(t, c, 1, 10, 3)
+
+	// The buckets and schema of the last sample are tracked.
+	c.UpdateNativeHistogramSeries(ls2, labels2Hash, time.Now(), 4, 1, copyFn)
+	assertActiveNativeHistograms(t, c, 1, 4, 1)
+
+	// The series changing type are tracked accordingly.
+	c.UpdateNativeHistogramSeries(ls1, labels1Hash, time.Now(), 6, -1, copyFn)
+	assertActiveNativeHistograms(t, c, 2, 10, 0)
+
+	c.UpdateSeries(ls2, labels2Hash, time.Now(), copyFn)
+	assert.Equal(t, 2, c.Active())
+	assertActiveNativeHistograms(t, c, 1, 6, -1)
+
+	c.Purge(time.Now().Add(time.Minute))
+	assert.Equal(t, 0, c.Active())
+	assertActiveNativeHistograms(t, c, 0, 0, 0)
+}
+
+func assertActiveNativeHistograms(t *testing.T, c *ActiveSeries, expectedSeries, expectedBuckets int, expectedSchemas int64) {
+	series, buckets, schemas := c.ActiveNativeHistograms()
+	assert.Equal(t, expectedSeries, series)
+	assert.Equal(t, expectedBuckets, buckets)
+	assert.Equal(t, expectedSchemas, schemas)
+}
+
 func TestActiveSeries_Purge(t *testing.T) {
 	series := [][]labels.Label{
 		{{Name: "a", Value: "1"}},
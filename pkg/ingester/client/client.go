@@ -52,10 +52,20 @@ type closableHealthAndIngesterClient struct {
 	maxInflightPushRequests int64
 	inflightRequests        atomic.Int64
 	inflightPushRequests    *prometheus.GaugeVec
+
+	// pushStreams is set when the pushes are sent on streams.
+	pushStreams *pushStreams
 }
 
 func (c *closableHealthAndIngesterClient) PushPreAlloc(ctx context.Context, in *cortexpb.PreallocWriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error) {
 	return c.handlePushRequest(func() (*cortexpb.WriteResponse, error) {
+		if c.pushStreams != nil {
+			out, err := c.pushStreams.push(ctx, &in.WriteRequest)
+			if !errors.Is(err, errPushStreamUnavailable) {
+				return out, err
+			}
+		}
+
 		out := new(cortexpb.WriteResponse)
 		err := c.conn.Invoke(ctx, "/cortex.Ingester/Push", in, out, opts...)
 		if err != nil {
@@ -67,6 +77,13 @@ func (c *closableHealthAndIngesterClient) PushPreAlloc(ctx context.Context, in *
 
 func (c *closableHealthAndIngesterClient) Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error) {
 	return c.handlePushRequest(func() (*cortexpb.WriteResponse, error) {
+		if c.pushStreams != nil {
+			out, err := c.pushStreams.push(ctx, in)
+			if !errors.Is(err, errPushStreamUnavailable) {
+				return out, err
+			}
+		}
+
 		return c.IngesterClient.Push(ctx, in, opts...)
 	})
 }
@@ -93,17 +110,24 @@ func MakeIngesterClient(addr string, cfg Config) (HealthAndIngesterClient, error
 	if err != nil {
 		return nil, err
 	}
-	return &closableHealthAndIngesterClient{
+	c := &closableHealthAndIngesterClient{
 		IngesterClient:          NewIngesterClient(conn),
 		HealthClient:            grpc_health_v1.NewHealthClient(conn),
 		conn:                    conn,
 		addr:                    addr,
 		maxInflightPushRequests: cfg.MaxInflightPushRequests,
 		inflightPushRequests:    ingesterClientInflightPushRequests,
-	}, nil
+	}
+	if cfg.PushStreamingEnabled {
+		c.pushStreams = newPushStreams(c.IngesterClient, cfg.GRPCClientConfig.SignWriteRequestsEnabled)
+	}
+	return c, nil
 }
 
 func (c *closableHealthAndIngesterClient) Close() error {
+	if c.pushStreams != nil {
+		c.pushStreams.close()
+	}
 	c.inflightPushRequests.DeleteLabelValues(c.addr)
 	return c.conn.Close()
 }
@@ -112,12 +136,14 @@ func (c *closableHealthAndIngesterClient) Close() error {
 type Config struct {
 	GRPCClientConfig        grpcclient.Config `yaml:"grpc_client_config"`
 	MaxInflightPushRequests int64             `yaml:"max_inflight_push_requests"`
+	PushStreamingEnabled    bool              `yaml:"push_streaming_enabled"`
 }
 
 // RegisterFlags registers configuration settings used by the ingester client config.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("ingester.client", f)
 	f.Int64Var(&cfg.MaxInflightPushRequests, "ingester.client.max-inflight-push-requests", 0, "Max inflight push requests that this ingester client can handle. This limit is per-ingester-client. Additional requests will be rejected. 0 = unlimited.")
+	f.BoolVar(&cfg.PushStreamingEnabled, "ingester.client.push-streaming-enabled", false, "[Experimental] True to send the pushes to the ingesters on long-lived gRPC streams, kept per tenant, instead of one unary call per push. The pushes are sent with unary calls to the ingesters not supporting the push streams.")
 }
 
 func (cfg *Config) Validate(log log.Logger) error {
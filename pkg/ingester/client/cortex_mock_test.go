@@ -17,6 +17,11 @@ func (m *IngesterServerMock) Push(ctx context.Context, r *cortexpb.WriteRequest)
 	return args.Get(0).(*cortexpb.WriteResponse), args.Error(1)
 }
 
+func (m *IngesterServerMock) PushStream(s Ingester_PushStreamServer) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
+
 func (m *IngesterServerMock) Query(ctx context.Context, r *QueryRequest) (*QueryResponse, error) {
 	args := m.Called(ctx, r)
 	return args.Get(0).(*QueryResponse), args.Error(1)
@@ -76,3 +81,13 @@ func (m *IngesterServerMock) MetricsMetadata(ctx context.Context, r *MetricsMeta
 	args := m.Called(ctx, r)
 	return args.Get(0).(*MetricsMetadataResponse), args.Error(1)
 }
+
+func (m *IngesterServerMock) TSDBStatus(ctx context.Context, r *TSDBStatusRequest) (*TSDBStatusResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*TSDBStatusResponse), args.Error(1)
+}
+
+func (m *IngesterServerMock) PrewarmTenant(ctx context.Context, r *PrewarmTenantRequest) (*PrewarmTenantResponse, error) {
+	args := m.Called(ctx, r)
+	return args.Get(0).(*PrewarmTenantResponse), args.Error(1)
+}
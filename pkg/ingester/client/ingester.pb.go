@@ -723,11 +723,14 @@ func (m *UserStatsRequest) XXX_DiscardUnknown() {
 var xxx_messageInfo_UserStatsRequest proto.InternalMessageInfo
 
 type UserStatsResponse struct {
-	IngestionRate     float64 `protobuf:"fixed64,1,opt,name=ingestion_rate,json=ingestionRate,proto3" json:"ingestion_rate,omitempty"`
-	NumSeries         uint64  `protobuf:"varint,2,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
-	ApiIngestionRate  float64 `protobuf:"fixed64,3,opt,name=api_ingestion_rate,json=apiIngestionRate,proto3" json:"api_ingestion_rate,omitempty"`
-	RuleIngestionRate float64 `protobuf:"fixed64,4,opt,name=rule_ingestion_rate,json=ruleIngestionRate,proto3" json:"rule_ingestion_rate,omitempty"`
-	ActiveSeries      uint64  `protobuf:"varint,5,opt,name=active_series,json=activeSeries,proto3" json:"active_series,omitempty"`
+	IngestionRate                      float64 `protobuf:"fixed64,1,opt,name=ingestion_rate,json=ingestionRate,proto3" json:"ingestion_rate,omitempty"`
+	NumSeries                          uint64  `protobuf:"varint,2,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
+	ApiIngestionRate                   float64 `protobuf:"fixed64,3,opt,name=api_ingestion_rate,json=apiIngestionRate,proto3" json:"api_ingestion_rate,omitempty"`
+	RuleIngestionRate                  float64 `protobuf:"fixed64,4,opt,name=rule_ingestion_rate,json=ruleIngestionRate,proto3" json:"rule_ingestion_rate,omitempty"`
+	ActiveSeries                       uint64  `protobuf:"varint,5,opt,name=active_series,json=activeSeries,proto3" json:"active_series,omitempty"`
+	ActiveNativeHistogramSeries        uint64  `protobuf:"varint,6,opt,name=active_native_histogram_series,json=activeNativeHistogramSeries,proto3" json:"active_native_histogram_series,omitempty"`
+	ActiveNativeHistogramBuckets       uint64  `protobuf:"varint,7,opt,name=active_native_histogram_buckets,json=activeNativeHistogramBuckets,proto3" json:"active_native_histogram_buckets,omitempty"`
+	ActiveNativeHistogramAverageSchema float64 `protobuf:"fixed64,8,opt,name=active_native_histogram_average_schema,json=activeNativeHistogramAverageSchema,proto3" json:"active_native_histogram_average_schema,omitempty"`
 }
 
 func (m *UserStatsResponse) Reset()      { *m = UserStatsResponse{} }
@@ -797,6 +800,308 @@ func (m *UserStatsResponse) GetActiveSeries() uint64 {
 	return 0
 }
 
+func (m *UserStatsResponse) GetActiveNativeHistogramSeries() uint64 {
+	if m != nil {
+		return m.ActiveNativeHistogramSeries
+	}
+	return 0
+}
+
+func (m *UserStatsResponse) GetActiveNativeHistogramBuckets() uint64 {
+	if m != nil {
+		return m.ActiveNativeHistogramBuckets
+	}
+	return 0
+}
+
+func (m *UserStatsResponse) GetActiveNativeHistogramAverageSchema() float64 {
+	if m != nil {
+		return m.ActiveNativeHistogramAverageSchema
+	}
+	return 0
+}
+
+type TSDBStatusRequest struct {
+	// Max number of items returned for each statistic.
+	Limit int32 `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (m *TSDBStatusRequest) Reset()      { *m = TSDBStatusRequest{} }
+func (*TSDBStatusRequest) ProtoMessage() {}
+func (*TSDBStatusRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{15}
+}
+func (m *TSDBStatusRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TSDBStatusRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TSDBStatusRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TSDBStatusRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatusRequest.Merge(m, src)
+}
+func (m *TSDBStatusRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *TSDBStatusRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatusRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TSDBStatusRequest proto.InternalMessageInfo
+
+func (m *TSDBStatusRequest) GetLimit() int32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type TSDBStatusResponse struct {
+	NumSeries                   uint64          `protobuf:"varint,1,opt,name=num_series,json=numSeries,proto3" json:"num_series,omitempty"`
+	NumLabelPairs               uint64          `protobuf:"varint,2,opt,name=num_label_pairs,json=numLabelPairs,proto3" json:"num_label_pairs,omitempty"`
+	ChunkCount                  uint64          `protobuf:"varint,3,opt,name=chunk_count,json=chunkCount,proto3" json:"chunk_count,omitempty"`
+	MinTime                     int64           `protobuf:"varint,4,opt,name=min_time,json=minTime,proto3" json:"min_time,omitempty"`
+	MaxTime                     int64           `protobuf:"varint,5,opt,name=max_time,json=maxTime,proto3" json:"max_time,omitempty"`
+	SeriesCountByMetricName     []TSDBStatistic `protobuf:"bytes,6,rep,name=series_count_by_metric_name,json=seriesCountByMetricName,proto3" json:"series_count_by_metric_name"`
+	LabelValueCountByLabelName  []TSDBStatistic `protobuf:"bytes,7,rep,name=label_value_count_by_label_name,json=labelValueCountByLabelName,proto3" json:"label_value_count_by_label_name"`
+	MemoryInBytesByLabelName    []TSDBStatistic `protobuf:"bytes,8,rep,name=memory_in_bytes_by_label_name,json=memoryInBytesByLabelName,proto3" json:"memory_in_bytes_by_label_name"`
+	SeriesCountByLabelValuePair []TSDBStatistic `protobuf:"bytes,9,rep,name=series_count_by_label_value_pair,json=seriesCountByLabelValuePair,proto3" json:"series_count_by_label_value_pair"`
+}
+
+func (m *TSDBStatusResponse) Reset()      { *m = TSDBStatusResponse{} }
+func (*TSDBStatusResponse) ProtoMessage() {}
+func (*TSDBStatusResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{16}
+}
+func (m *TSDBStatusResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TSDBStatusResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TSDBStatusResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TSDBStatusResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatusResponse.Merge(m, src)
+}
+func (m *TSDBStatusResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *TSDBStatusResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatusResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TSDBStatusResponse proto.InternalMessageInfo
+
+func (m *TSDBStatusResponse) GetNumSeries() uint64 {
+	if m != nil {
+		return m.NumSeries
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetNumLabelPairs() uint64 {
+	if m != nil {
+		return m.NumLabelPairs
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetChunkCount() uint64 {
+	if m != nil {
+		return m.ChunkCount
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetMinTime() int64 {
+	if m != nil {
+		return m.MinTime
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetMaxTime() int64 {
+	if m != nil {
+		return m.MaxTime
+	}
+	return 0
+}
+
+func (m *TSDBStatusResponse) GetSeriesCountByMetricName() []TSDBStatistic {
+	if m != nil {
+		return m.SeriesCountByMetricName
+	}
+	return nil
+}
+
+func (m *TSDBStatusResponse) GetLabelValueCountByLabelName() []TSDBStatistic {
+	if m != nil {
+		return m.LabelValueCountByLabelName
+	}
+	return nil
+}
+
+func (m *TSDBStatusResponse) GetMemoryInBytesByLabelName() []TSDBStatistic {
+	if m != nil {
+		return m.MemoryInBytesByLabelName
+	}
+	return nil
+}
+
+func (m *TSDBStatusResponse) GetSeriesCountByLabelValuePair() []TSDBStatistic {
+	if m != nil {
+		return m.SeriesCountByLabelValuePair
+	}
+	return nil
+}
+
+type TSDBStatistic struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value uint64 `protobuf:"varint,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *TSDBStatistic) Reset()      { *m = TSDBStatistic{} }
+func (*TSDBStatistic) ProtoMessage() {}
+func (*TSDBStatistic) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{17}
+}
+func (m *TSDBStatistic) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *TSDBStatistic) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_TSDBStatistic.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *TSDBStatistic) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TSDBStatistic.Merge(m, src)
+}
+func (m *TSDBStatistic) XXX_Size() int {
+	return m.Size()
+}
+func (m *TSDBStatistic) XXX_DiscardUnknown() {
+	xxx_messageInfo_TSDBStatistic.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TSDBStatistic proto.InternalMessageInfo
+
+func (m *TSDBStatistic) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *TSDBStatistic) GetValue() uint64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+type PrewarmTenantRequest struct {
+}
+
+func (m *PrewarmTenantRequest) Reset()      { *m = PrewarmTenantRequest{} }
+func (*PrewarmTenantRequest) ProtoMessage() {}
+func (*PrewarmTenantRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{18}
+}
+func (m *PrewarmTenantRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PrewarmTenantRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PrewarmTenantRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PrewarmTenantRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrewarmTenantRequest.Merge(m, src)
+}
+func (m *PrewarmTenantRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PrewarmTenantRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrewarmTenantRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrewarmTenantRequest proto.InternalMessageInfo
+
+type PrewarmTenantResponse struct {
+	// Whether the TSDB of the tenant has been created by the request.
+	Created bool `protobuf:"varint,1,opt,name=created,proto3" json:"created,omitempty"`
+}
+
+func (m *PrewarmTenantResponse) Reset()      { *m = PrewarmTenantResponse{} }
+func (*PrewarmTenantResponse) ProtoMessage() {}
+func (*PrewarmTenantResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{19}
+}
+func (m *PrewarmTenantResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PrewarmTenantResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PrewarmTenantResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PrewarmTenantResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PrewarmTenantResponse.Merge(m, src)
+}
+func (m *PrewarmTenantResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *PrewarmTenantResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PrewarmTenantResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PrewarmTenantResponse proto.InternalMessageInfo
+
+func (m *PrewarmTenantResponse) GetCreated() bool {
+	if m != nil {
+		return m.Created
+	}
+	return false
+}
+
 type UserIDStatsResponse struct {
 	UserId string             `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	Data   *UserStatsResponse `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
@@ -805,7 +1110,7 @@ type UserIDStatsResponse struct {
 func (m *UserIDStatsResponse) Reset()      { *m = UserIDStatsResponse{} }
 func (*UserIDStatsResponse) ProtoMessage() {}
 func (*UserIDStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{15}
+	return fileDescriptor_60f6df4f3586b478, []int{20}
 }
 func (m *UserIDStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -855,7 +1160,7 @@ type UsersStatsResponse struct {
 func (m *UsersStatsResponse) Reset()      { *m = UsersStatsResponse{} }
 func (*UsersStatsResponse) ProtoMessage() {}
 func (*UsersStatsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{16}
+	return fileDescriptor_60f6df4f3586b478, []int{21}
 }
 func (m *UsersStatsResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -900,7 +1205,7 @@ type MetricsForLabelMatchersRequest struct {
 func (m *MetricsForLabelMatchersRequest) Reset()      { *m = MetricsForLabelMatchersRequest{} }
 func (*MetricsForLabelMatchersRequest) ProtoMessage() {}
 func (*MetricsForLabelMatchersRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{17}
+	return fileDescriptor_60f6df4f3586b478, []int{22}
 }
 func (m *MetricsForLabelMatchersRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -957,7 +1262,7 @@ type MetricsForLabelMatchersResponse struct {
 func (m *MetricsForLabelMatchersResponse) Reset()      { *m = MetricsForLabelMatchersResponse{} }
 func (*MetricsForLabelMatchersResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{18}
+	return fileDescriptor_60f6df4f3586b478, []int{23}
 }
 func (m *MetricsForLabelMatchersResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1000,7 +1305,7 @@ type MetricsForLabelMatchersStreamResponse struct {
 func (m *MetricsForLabelMatchersStreamResponse) Reset()      { *m = MetricsForLabelMatchersStreamResponse{} }
 func (*MetricsForLabelMatchersStreamResponse) ProtoMessage() {}
 func (*MetricsForLabelMatchersStreamResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{19}
+	return fileDescriptor_60f6df4f3586b478, []int{24}
 }
 func (m *MetricsForLabelMatchersStreamResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1042,7 +1347,7 @@ type MetricsMetadataRequest struct {
 func (m *MetricsMetadataRequest) Reset()      { *m = MetricsMetadataRequest{} }
 func (*MetricsMetadataRequest) ProtoMessage() {}
 func (*MetricsMetadataRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{20}
+	return fileDescriptor_60f6df4f3586b478, []int{25}
 }
 func (m *MetricsMetadataRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1078,7 +1383,7 @@ type MetricsMetadataResponse struct {
 func (m *MetricsMetadataResponse) Reset()      { *m = MetricsMetadataResponse{} }
 func (*MetricsMetadataResponse) ProtoMessage() {}
 func (*MetricsMetadataResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{21}
+	return fileDescriptor_60f6df4f3586b478, []int{26}
 }
 func (m *MetricsMetadataResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1124,7 +1429,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{22}
+	return fileDescriptor_60f6df4f3586b478, []int{27}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1184,7 +1489,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{23}
+	return fileDescriptor_60f6df4f3586b478, []int{28}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1248,7 +1553,7 @@ type LabelMatchers struct {
 func (m *LabelMatchers) Reset()      { *m = LabelMatchers{} }
 func (*LabelMatchers) ProtoMessage() {}
 func (*LabelMatchers) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{24}
+	return fileDescriptor_60f6df4f3586b478, []int{29}
 }
 func (m *LabelMatchers) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1293,7 +1598,7 @@ type LabelMatcher struct {
 func (m *LabelMatcher) Reset()      { *m = LabelMatcher{} }
 func (*LabelMatcher) ProtoMessage() {}
 func (*LabelMatcher) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{25}
+	return fileDescriptor_60f6df4f3586b478, []int{30}
 }
 func (m *LabelMatcher) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1353,7 +1658,7 @@ type TimeSeriesFile struct {
 func (m *TimeSeriesFile) Reset()      { *m = TimeSeriesFile{} }
 func (*TimeSeriesFile) ProtoMessage() {}
 func (*TimeSeriesFile) Descriptor() ([]byte, []int) {
-	return fileDescriptor_60f6df4f3586b478, []int{26}
+	return fileDescriptor_60f6df4f3586b478, []int{31}
 }
 func (m *TimeSeriesFile) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1410,136 +1715,331 @@ func (m *TimeSeriesFile) GetData() []byte {
 	return nil
 }
 
-func init() {
-	proto.RegisterEnum("cortex.MatchType", MatchType_name, MatchType_value)
-	proto.RegisterType((*ReadRequest)(nil), "cortex.ReadRequest")
-	proto.RegisterType((*ReadResponse)(nil), "cortex.ReadResponse")
-	proto.RegisterType((*QueryResponse)(nil), "cortex.QueryResponse")
-	proto.RegisterType((*QueryRequest)(nil), "cortex.QueryRequest")
-	proto.RegisterType((*ExemplarQueryRequest)(nil), "cortex.ExemplarQueryRequest")
-	proto.RegisterType((*QueryStreamResponse)(nil), "cortex.QueryStreamResponse")
-	proto.RegisterType((*ExemplarQueryResponse)(nil), "cortex.ExemplarQueryResponse")
-	proto.RegisterType((*LabelValuesRequest)(nil), "cortex.LabelValuesRequest")
-	proto.RegisterType((*LabelValuesResponse)(nil), "cortex.LabelValuesResponse")
-	proto.RegisterType((*LabelValuesStreamResponse)(nil), "cortex.LabelValuesStreamResponse")
-	proto.RegisterType((*LabelNamesRequest)(nil), "cortex.LabelNamesRequest")
-	proto.RegisterType((*LabelNamesResponse)(nil), "cortex.LabelNamesResponse")
-	proto.RegisterType((*LabelNamesStreamResponse)(nil), "cortex.LabelNamesStreamResponse")
-	proto.RegisterType((*UserStatsRequest)(nil), "cortex.UserStatsRequest")
-	proto.RegisterType((*UserStatsResponse)(nil), "cortex.UserStatsResponse")
-	proto.RegisterType((*UserIDStatsResponse)(nil), "cortex.UserIDStatsResponse")
-	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
-	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
-	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
-	proto.RegisterType((*MetricsForLabelMatchersStreamResponse)(nil), "cortex.MetricsForLabelMatchersStreamResponse")
-	proto.RegisterType((*MetricsMetadataRequest)(nil), "cortex.MetricsMetadataRequest")
-	proto.RegisterType((*MetricsMetadataResponse)(nil), "cortex.MetricsMetadataResponse")
-	proto.RegisterType((*TimeSeriesChunk)(nil), "cortex.TimeSeriesChunk")
-	proto.RegisterType((*Chunk)(nil), "cortex.Chunk")
-	proto.RegisterType((*LabelMatchers)(nil), "cortex.LabelMatchers")
-	proto.RegisterType((*LabelMatcher)(nil), "cortex.LabelMatcher")
-	proto.RegisterType((*TimeSeriesFile)(nil), "cortex.TimeSeriesFile")
+// PushStreamRequest is a push sent on a PushStream, along with its gRPC metadata (e.g. the signature,
+// the source IPs and the trace context of the push), since the metadata of the stream is sent once.
+type PushStreamRequest struct {
+	Request  *cortexpb.WriteRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	Metadata []PushStreamMetadata   `protobuf:"bytes,2,rep,name=metadata,proto3" json:"metadata"`
 }
 
-func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
-
-var fileDescriptor_60f6df4f3586b478 = []byte{
-	// 1292 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x57, 0x4b, 0x6f, 0xd4, 0xd6,
-	0x17, 0xb7, 0x33, 0x93, 0x49, 0xe6, 0xcc, 0x64, 0x98, 0xdc, 0x04, 0x32, 0x98, 0x3f, 0x0e, 0xf8,
-	0x2f, 0xda, 0xa8, 0x2d, 0x09, 0xa4, 0xad, 0x04, 0x7d, 0xa1, 0x04, 0x02, 0x04, 0x08, 0x01, 0x67,
-	0xa0, 0x55, 0xd5, 0xca, 0x72, 0x66, 0x2e, 0x13, 0x17, 0xbf, 0xb0, 0xaf, 0x11, 0x74, 0x55, 0xa9,
-	0x1f, 0xa0, 0x5d, 0x76, 0xdb, 0x5d, 0xd7, 0xfd, 0x00, 0x5d, 0xb3, 0x64, 0x89, 0xaa, 0x0a, 0x95,
-	0x41, 0xaa, 0xba, 0xa4, 0xdf, 0xa0, 0xf2, 0x7d, 0x78, 0x6c, 0xc7, 0x93, 0x0c, 0x12, 0xe9, 0x6e,
-	0x7c, 0xce, 0xef, 0x9c, 0xfb, 0x3b, 0x8f, 0x7b, 0xcf, 0x19, 0x68, 0x58, 0x6e, 0x0f, 0x87, 0x04,
-	0x07, 0x8b, 0x7e, 0xe0, 0x11, 0x0f, 0x55, 0x3a, 0x5e, 0x40, 0xf0, 0x23, 0x65, 0xb6, 0xe7, 0xf5,
-	0x3c, 0x2a, 0x5a, 0x8a, 0x7f, 0x31, 0xad, 0x72, 0xbe, 0x67, 0x91, 0x9d, 0x68, 0x7b, 0xb1, 0xe3,
-	0x39, 0x4b, 0x0c, 0xe8, 0x07, 0xde, 0x37, 0xb8, 0x43, 0xf8, 0xd7, 0x92, 0x7f, 0xbf, 0x27, 0x14,
-	0xdb, 0xfc, 0x07, 0x33, 0xd5, 0x3e, 0x85, 0x9a, 0x8e, 0xcd, 0xae, 0x8e, 0x1f, 0x44, 0x38, 0x24,
-	0x68, 0x11, 0x26, 0x1e, 0x44, 0x38, 0xb0, 0x70, 0xd8, 0x92, 0x4f, 0x94, 0x16, 0x6a, 0xcb, 0xb3,
-	0x8b, 0x1c, 0x7e, 0x3b, 0xc2, 0xc1, 0x63, 0x0e, 0xd3, 0x05, 0x48, 0xbb, 0x00, 0x75, 0x66, 0x1e,
-	0xfa, 0x9e, 0x1b, 0x62, 0xb4, 0x04, 0x13, 0x01, 0x0e, 0x23, 0x9b, 0x08, 0xfb, 0xc3, 0x39, 0x7b,
-	0x86, 0xd3, 0x05, 0x4a, 0xbb, 0x0e, 0x53, 0x19, 0x0d, 0xfa, 0x08, 0x80, 0x58, 0x0e, 0x0e, 0x8b,
-	0x48, 0xf8, 0xdb, 0x8b, 0x6d, 0xcb, 0xc1, 0x5b, 0x54, 0xb7, 0x5a, 0x7e, 0xf2, 0x7c, 0x5e, 0xd2,
-	0x53, 0x68, 0xed, 0x27, 0x19, 0xea, 0x69, 0x9e, 0xe8, 0x3d, 0x40, 0x21, 0x31, 0x03, 0x62, 0x50,
-	0x10, 0x31, 0x1d, 0xdf, 0x70, 0x62, 0xa7, 0xf2, 0x42, 0x49, 0x6f, 0x52, 0x4d, 0x5b, 0x28, 0x36,
-	0x42, 0xb4, 0x00, 0x4d, 0xec, 0x76, 0xb3, 0xd8, 0x31, 0x8a, 0x6d, 0x60, 0xb7, 0x9b, 0x46, 0x9e,
-	0x81, 0x49, 0xc7, 0x24, 0x9d, 0x1d, 0x1c, 0x84, 0xad, 0x52, 0x36, 0x4f, 0x37, 0xcc, 0x6d, 0x6c,
-	0x6f, 0x30, 0xa5, 0x9e, 0xa0, 0xb4, 0x9f, 0x65, 0x98, 0x5d, 0x7b, 0x84, 0x1d, 0xdf, 0x36, 0x83,
-	0xff, 0x84, 0xe2, 0xd9, 0x5d, 0x14, 0x0f, 0x17, 0x51, 0x0c, 0x53, 0x1c, 0xbf, 0x82, 0x19, 0x4a,
-	0x6d, 0x8b, 0x04, 0xd8, 0x74, 0x92, 0x8a, 0x5c, 0x80, 0x5a, 0x67, 0x27, 0x72, 0xef, 0x67, 0x4a,
-	0x32, 0x27, 0x9c, 0x0d, 0x0a, 0x72, 0x31, 0x06, 0xf1, 0xaa, 0xa4, 0x2d, 0xae, 0x95, 0x27, 0xc7,
-	0x9a, 0x25, 0x6d, 0x0b, 0x0e, 0xe7, 0x12, 0xf0, 0x06, 0x2a, 0xfe, 0x9b, 0x0c, 0x88, 0x86, 0x73,
-	0xd7, 0xb4, 0x23, 0x1c, 0x8a, 0xa4, 0x1e, 0x07, 0xb0, 0x63, 0xa9, 0xe1, 0x9a, 0x0e, 0xa6, 0xc9,
-	0xac, 0xea, 0x55, 0x2a, 0xb9, 0x69, 0x3a, 0x78, 0x48, 0xce, 0xc7, 0x5e, 0x23, 0xe7, 0xa5, 0x7d,
-	0x73, 0x5e, 0x3e, 0x21, 0x8f, 0x92, 0xf3, 0x73, 0x30, 0x93, 0xe1, 0xcf, 0x73, 0x72, 0x12, 0xea,
-	0x2c, 0x80, 0x87, 0x54, 0x4e, 0xb3, 0x52, 0xd5, 0x6b, 0xf6, 0x00, 0xaa, 0x7d, 0x06, 0x47, 0x53,
-	0x96, 0xb9, 0x9a, 0x8d, 0x60, 0x7f, 0x1f, 0xa6, 0x6f, 0x88, 0x8c, 0x84, 0x07, 0xdc, 0x8d, 0xda,
-	0x87, 0xbc, 0x4c, 0xfc, 0x30, 0xce, 0x72, 0x1e, 0x6a, 0x83, 0x32, 0x09, 0x92, 0x90, 0xd4, 0x29,
-	0xd4, 0x3e, 0x86, 0xd6, 0xc0, 0x2c, 0x17, 0xe2, 0xbe, 0xc6, 0x08, 0x9a, 0x77, 0x42, 0x1c, 0x6c,
-	0x11, 0x93, 0x88, 0xf8, 0xb4, 0x3f, 0x64, 0x98, 0x4e, 0x09, 0xb9, 0xab, 0x53, 0xe2, 0xbd, 0xb5,
-	0x3c, 0xd7, 0x08, 0x4c, 0xc2, 0x5a, 0x46, 0xd6, 0xa7, 0x12, 0xa9, 0x6e, 0x12, 0x1c, 0x77, 0x95,
-	0x1b, 0x39, 0x06, 0x6f, 0xd4, 0x38, 0xd0, 0xb2, 0x5e, 0x75, 0x23, 0x87, 0x75, 0x67, 0x9c, 0x3b,
-	0xd3, 0xb7, 0x8c, 0x9c, 0xa7, 0x12, 0xf5, 0xd4, 0x34, 0x7d, 0x6b, 0x3d, 0xe3, 0x6c, 0x11, 0x66,
-	0x82, 0xc8, 0xc6, 0x79, 0x78, 0x99, 0xc2, 0xa7, 0x63, 0x55, 0x16, 0xff, 0x7f, 0x98, 0x32, 0x3b,
-	0xc4, 0x7a, 0x88, 0xc5, 0xf9, 0xe3, 0xf4, 0xfc, 0x3a, 0x13, 0x32, 0x0a, 0xda, 0xd7, 0x30, 0x13,
-	0x47, 0xb7, 0x7e, 0x29, 0x1b, 0xdf, 0x1c, 0x4c, 0x44, 0x21, 0x0e, 0x0c, 0xab, 0xcb, 0xef, 0x42,
-	0x25, 0xfe, 0x5c, 0xef, 0xa2, 0xd3, 0x50, 0xee, 0x9a, 0xc4, 0xa4, 0xb1, 0xd4, 0x96, 0x8f, 0x8a,
-	0x66, 0xdd, 0x95, 0x21, 0x9d, 0xc2, 0xb4, 0x2b, 0x80, 0x62, 0x55, 0x98, 0xf5, 0x7e, 0x16, 0xc6,
-	0xc3, 0x58, 0xc0, 0xaf, 0xee, 0xb1, 0xb4, 0x97, 0x1c, 0x13, 0x9d, 0x21, 0xb5, 0x5f, 0x65, 0x50,
-	0x37, 0x30, 0x09, 0xac, 0x4e, 0x78, 0xd9, 0x0b, 0xb2, 0x77, 0xe3, 0x80, 0xdf, 0xc5, 0x73, 0x50,
-	0x17, 0x97, 0xcf, 0x08, 0x31, 0xd9, 0xfb, 0x6d, 0xac, 0x09, 0xe8, 0x16, 0x26, 0xda, 0x75, 0x98,
-	0x1f, 0xca, 0x99, 0xa7, 0x62, 0x01, 0x2a, 0x0e, 0x85, 0xf0, 0x5c, 0x34, 0x07, 0xcf, 0x18, 0x33,
-	0xd5, 0xb9, 0x5e, 0xbb, 0x0d, 0xa7, 0x86, 0x38, 0xcb, 0xb5, 0xf9, 0xe8, 0x2e, 0x5b, 0x70, 0x84,
-	0xbb, 0xdc, 0xc0, 0xc4, 0x8c, 0x0b, 0x26, 0xba, 0x7e, 0x13, 0xe6, 0x76, 0x69, 0xb8, 0xfb, 0x0f,
-	0x60, 0xd2, 0xe1, 0x32, 0x7e, 0x40, 0x2b, 0x7f, 0x40, 0x62, 0x93, 0x20, 0xb5, 0x7f, 0x64, 0x38,
-	0x94, 0x7b, 0xf8, 0xe3, 0x12, 0xdc, 0x0b, 0x3c, 0xc7, 0x10, 0x9b, 0xcb, 0xa0, 0xdb, 0x1a, 0xb1,
-	0x7c, 0x9d, 0x8b, 0xd7, 0xbb, 0xe9, 0x76, 0x1c, 0xcb, 0xb4, 0xa3, 0x0b, 0x15, 0x7a, 0x7f, 0xc5,
-	0xc4, 0x9a, 0x19, 0x50, 0xa1, 0x29, 0xba, 0x65, 0x5a, 0xc1, 0xea, 0x4a, 0x3c, 0x04, 0x7e, 0x7f,
-	0x3e, 0xff, 0x5a, 0x4b, 0x0f, 0xb3, 0x5f, 0xe9, 0x9a, 0x3e, 0xc1, 0x81, 0xce, 0x4f, 0x41, 0xef,
-	0x42, 0x85, 0xcd, 0xa9, 0x56, 0x99, 0x9e, 0x37, 0x25, 0xba, 0x20, 0x3d, 0xca, 0x38, 0x44, 0xfb,
-	0x41, 0x86, 0x71, 0x16, 0xe9, 0x41, 0xb5, 0xa6, 0x02, 0x93, 0xd8, 0xed, 0x78, 0x5d, 0xcb, 0xed,
-	0xd1, 0x67, 0x63, 0x5c, 0x4f, 0xbe, 0x11, 0xe2, 0x37, 0x35, 0x7e, 0x1f, 0xea, 0xfc, 0x3a, 0xae,
-	0xc0, 0x54, 0xa6, 0x73, 0x32, 0x6b, 0x89, 0x3c, 0xd2, 0x5a, 0x62, 0x40, 0x3d, 0xad, 0x41, 0xa7,
-	0xa0, 0x4c, 0x1e, 0xfb, 0xec, 0xfd, 0x6b, 0x2c, 0x4f, 0x0b, 0x6b, 0xaa, 0x6e, 0x3f, 0xf6, 0xb1,
-	0x4e, 0xd5, 0x31, 0x1b, 0x3a, 0x59, 0x59, 0xf9, 0xe8, 0x6f, 0x34, 0x0b, 0xe3, 0x74, 0xd8, 0x50,
-	0xea, 0x55, 0x9d, 0x7d, 0x68, 0xdf, 0xcb, 0xd0, 0x18, 0x74, 0xca, 0x65, 0xcb, 0xc6, 0x6f, 0xa2,
-	0x51, 0x14, 0x98, 0xbc, 0x67, 0xd9, 0x98, 0x72, 0x60, 0xc7, 0x25, 0xdf, 0x45, 0x99, 0x7a, 0xe7,
-	0x1a, 0x54, 0x93, 0x10, 0x50, 0x15, 0xc6, 0xd7, 0x6e, 0xdf, 0x59, 0xb9, 0xd1, 0x94, 0xd0, 0x14,
-	0x54, 0x6f, 0x6e, 0xb6, 0x0d, 0xf6, 0x29, 0xa3, 0x43, 0x50, 0xd3, 0xd7, 0xae, 0xac, 0x7d, 0x61,
-	0x6c, 0xac, 0xb4, 0x2f, 0x5e, 0x6d, 0x8e, 0x21, 0x04, 0x0d, 0x26, 0xb8, 0xb9, 0xc9, 0x65, 0xa5,
-	0xe5, 0xbf, 0x26, 0x60, 0x52, 0x70, 0x44, 0xe7, 0xa1, 0x7c, 0x2b, 0x0a, 0x77, 0xd0, 0x91, 0x41,
-	0xa7, 0x7e, 0x1e, 0x58, 0x04, 0xf3, 0x9b, 0xa7, 0xcc, 0xed, 0x92, 0xb3, 0x7b, 0xa7, 0x49, 0xe8,
-	0x12, 0xd4, 0x52, 0xdb, 0x16, 0x2a, 0x5c, 0xb4, 0x95, 0x63, 0x19, 0x69, 0xf6, 0x69, 0xd0, 0xa4,
-	0x33, 0x32, 0xda, 0x84, 0x06, 0x55, 0x89, 0xd5, 0x2a, 0x44, 0xff, 0x13, 0x26, 0x45, 0xeb, 0xa6,
-	0x72, 0x7c, 0x88, 0x36, 0xa1, 0x75, 0x15, 0x6a, 0xa9, 0xb5, 0x02, 0x29, 0x99, 0x06, 0xca, 0x6c,
-	0x59, 0x03, 0x72, 0x05, 0x1b, 0x8c, 0x26, 0xa1, 0xbb, 0x7c, 0xc1, 0x48, 0x2f, 0x28, 0x7b, 0xfa,
-	0x3b, 0x59, 0xa0, 0x2b, 0x08, 0x79, 0x0d, 0x60, 0xb0, 0x14, 0xa0, 0xa3, 0x19, 0xa3, 0xf4, 0x32,
-	0xa3, 0x28, 0x45, 0xaa, 0x84, 0xde, 0x16, 0x34, 0xf3, 0xbb, 0xc5, 0x5e, 0xce, 0x4e, 0xec, 0x56,
-	0x15, 0x70, 0x5b, 0x85, 0x6a, 0x32, 0x3c, 0x51, 0xab, 0x60, 0x9e, 0x32, 0x67, 0xc3, 0x27, 0xad,
-	0x26, 0xa1, 0xcb, 0x50, 0x5f, 0xb1, 0xed, 0x51, 0xdc, 0x28, 0x69, 0x4d, 0x98, 0xf7, 0x63, 0x27,
-	0xaf, 0x7e, 0x7e, 0xc4, 0xa0, 0xb7, 0x92, 0x8b, 0xbd, 0xe7, 0x10, 0x56, 0xde, 0xde, 0x17, 0x97,
-	0x9c, 0xf6, 0x2d, 0x1c, 0xdf, 0x73, 0xa0, 0x8d, 0x7c, 0xe6, 0xe9, 0x7d, 0x70, 0x05, 0x59, 0x6f,
-	0xc3, 0xa1, 0xdc, 0x7c, 0x43, 0x6a, 0xce, 0x4b, 0x6e, 0x24, 0x2a, 0xf3, 0x43, 0xf5, 0xc2, 0xef,
-	0xea, 0x27, 0x4f, 0x5f, 0xa8, 0xd2, 0xb3, 0x17, 0xaa, 0xf4, 0xea, 0x85, 0x2a, 0x7f, 0xd7, 0x57,
-	0xe5, 0x5f, 0xfa, 0xaa, 0xfc, 0xa4, 0xaf, 0xca, 0x4f, 0xfb, 0xaa, 0xfc, 0x67, 0x5f, 0x95, 0xff,
-	0xee, 0xab, 0xd2, 0xab, 0xbe, 0x2a, 0xff, 0xf8, 0x52, 0x95, 0x9e, 0xbe, 0x54, 0xa5, 0x67, 0x2f,
-	0x55, 0xe9, 0xcb, 0x4a, 0xc7, 0xb6, 0xb0, 0x4b, 0xb6, 0x2b, 0xf4, 0xff, 0xf5, 0xfb, 0xff, 0x06,
-	0x00, 0x00, 0xff, 0xff, 0xa7, 0xb7, 0xb7, 0x0a, 0xca, 0x0f, 0x00, 0x00,
+func (m *PushStreamRequest) Reset()      { *m = PushStreamRequest{} }
+func (*PushStreamRequest) ProtoMessage() {}
+func (*PushStreamRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{32}
 }
-
-func (x MatchType) String() string {
-	s, ok := MatchType_name[int32(x)]
-	if ok {
-		return s
-	}
-	return strconv.Itoa(int(x))
+func (m *PushStreamRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-func (this *ReadRequest) Equal(that interface{}) bool {
-	if that == nil {
-		return this == nil
-	}
-
+func (m *PushStreamRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushStreamRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PushStreamRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushStreamRequest.Merge(m, src)
+}
+func (m *PushStreamRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *PushStreamRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushStreamRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushStreamRequest proto.InternalMessageInfo
+
+func (m *PushStreamRequest) GetRequest() *cortexpb.WriteRequest {
+	if m != nil {
+		return m.Request
+	}
+	return nil
+}
+
+func (m *PushStreamRequest) GetMetadata() []PushStreamMetadata {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+type PushStreamMetadata struct {
+	Key    string   `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Values []string `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+}
+
+func (m *PushStreamMetadata) Reset()      { *m = PushStreamMetadata{} }
+func (*PushStreamMetadata) ProtoMessage() {}
+func (*PushStreamMetadata) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{33}
+}
+func (m *PushStreamMetadata) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushStreamMetadata) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushStreamMetadata.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PushStreamMetadata) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushStreamMetadata.Merge(m, src)
+}
+func (m *PushStreamMetadata) XXX_Size() int {
+	return m.Size()
+}
+func (m *PushStreamMetadata) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushStreamMetadata.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushStreamMetadata proto.InternalMessageInfo
+
+func (m *PushStreamMetadata) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *PushStreamMetadata) GetValues() []string {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+// PushStreamResponse is the response to a push sent on a PushStream. The status is the marshaled
+// google.rpc.Status of the push failed, and is empty if the push succeeded.
+type PushStreamResponse struct {
+	Response *cortexpb.WriteResponse `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	Status   []byte                  `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+}
+
+func (m *PushStreamResponse) Reset()      { *m = PushStreamResponse{} }
+func (*PushStreamResponse) ProtoMessage() {}
+func (*PushStreamResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_60f6df4f3586b478, []int{34}
+}
+func (m *PushStreamResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *PushStreamResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_PushStreamResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *PushStreamResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushStreamResponse.Merge(m, src)
+}
+func (m *PushStreamResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *PushStreamResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushStreamResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushStreamResponse proto.InternalMessageInfo
+
+func (m *PushStreamResponse) GetResponse() *cortexpb.WriteResponse {
+	if m != nil {
+		return m.Response
+	}
+	return nil
+}
+
+func (m *PushStreamResponse) GetStatus() []byte {
+	if m != nil {
+		return m.Status
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("cortex.MatchType", MatchType_name, MatchType_value)
+	proto.RegisterType((*ReadRequest)(nil), "cortex.ReadRequest")
+	proto.RegisterType((*ReadResponse)(nil), "cortex.ReadResponse")
+	proto.RegisterType((*QueryResponse)(nil), "cortex.QueryResponse")
+	proto.RegisterType((*QueryRequest)(nil), "cortex.QueryRequest")
+	proto.RegisterType((*ExemplarQueryRequest)(nil), "cortex.ExemplarQueryRequest")
+	proto.RegisterType((*QueryStreamResponse)(nil), "cortex.QueryStreamResponse")
+	proto.RegisterType((*ExemplarQueryResponse)(nil), "cortex.ExemplarQueryResponse")
+	proto.RegisterType((*LabelValuesRequest)(nil), "cortex.LabelValuesRequest")
+	proto.RegisterType((*LabelValuesResponse)(nil), "cortex.LabelValuesResponse")
+	proto.RegisterType((*LabelValuesStreamResponse)(nil), "cortex.LabelValuesStreamResponse")
+	proto.RegisterType((*LabelNamesRequest)(nil), "cortex.LabelNamesRequest")
+	proto.RegisterType((*LabelNamesResponse)(nil), "cortex.LabelNamesResponse")
+	proto.RegisterType((*LabelNamesStreamResponse)(nil), "cortex.LabelNamesStreamResponse")
+	proto.RegisterType((*UserStatsRequest)(nil), "cortex.UserStatsRequest")
+	proto.RegisterType((*UserStatsResponse)(nil), "cortex.UserStatsResponse")
+	proto.RegisterType((*TSDBStatusRequest)(nil), "cortex.TSDBStatusRequest")
+	proto.RegisterType((*TSDBStatusResponse)(nil), "cortex.TSDBStatusResponse")
+	proto.RegisterType((*TSDBStatistic)(nil), "cortex.TSDBStatistic")
+	proto.RegisterType((*PrewarmTenantRequest)(nil), "cortex.PrewarmTenantRequest")
+	proto.RegisterType((*PrewarmTenantResponse)(nil), "cortex.PrewarmTenantResponse")
+	proto.RegisterType((*UserIDStatsResponse)(nil), "cortex.UserIDStatsResponse")
+	proto.RegisterType((*UsersStatsResponse)(nil), "cortex.UsersStatsResponse")
+	proto.RegisterType((*MetricsForLabelMatchersRequest)(nil), "cortex.MetricsForLabelMatchersRequest")
+	proto.RegisterType((*MetricsForLabelMatchersResponse)(nil), "cortex.MetricsForLabelMatchersResponse")
+	proto.RegisterType((*MetricsForLabelMatchersStreamResponse)(nil), "cortex.MetricsForLabelMatchersStreamResponse")
+	proto.RegisterType((*MetricsMetadataRequest)(nil), "cortex.MetricsMetadataRequest")
+	proto.RegisterType((*MetricsMetadataResponse)(nil), "cortex.MetricsMetadataResponse")
+	proto.RegisterType((*TimeSeriesChunk)(nil), "cortex.TimeSeriesChunk")
+	proto.RegisterType((*Chunk)(nil), "cortex.Chunk")
+	proto.RegisterType((*LabelMatchers)(nil), "cortex.LabelMatchers")
+	proto.RegisterType((*LabelMatcher)(nil), "cortex.LabelMatcher")
+	proto.RegisterType((*TimeSeriesFile)(nil), "cortex.TimeSeriesFile")
+	proto.RegisterType((*PushStreamRequest)(nil), "cortex.PushStreamRequest")
+	proto.RegisterType((*PushStreamMetadata)(nil), "cortex.PushStreamMetadata")
+	proto.RegisterType((*PushStreamResponse)(nil), "cortex.PushStreamResponse")
+}
+
+func init() { proto.RegisterFile("ingester.proto", fileDescriptor_60f6df4f3586b478) }
+
+var fileDescriptor_60f6df4f3586b478 = []byte{
+	// 1765 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0x4b, 0x73, 0x1b, 0xc7,
+	0x11, 0xc6, 0x12, 0x20, 0x08, 0x34, 0x00, 0x0a, 0x1c, 0x52, 0x22, 0xb4, 0x34, 0x97, 0xf2, 0xa6,
+	0xa4, 0x30, 0x0f, 0x53, 0x12, 0x9d, 0x54, 0x59, 0x89, 0x63, 0x17, 0x21, 0x51, 0x16, 0x6d, 0x91,
+	0x92, 0x16, 0xb0, 0xf3, 0x28, 0xa7, 0x36, 0x03, 0x60, 0x0c, 0x6e, 0x88, 0x5d, 0xc0, 0xbb, 0xb3,
+	0x8a, 0x90, 0x53, 0xaa, 0xfc, 0x03, 0x92, 0x63, 0xae, 0xb9, 0xe5, 0x9a, 0x9c, 0x53, 0x39, 0xeb,
+	0xa8, 0xa3, 0x2b, 0x07, 0x57, 0x44, 0x5d, 0x72, 0x74, 0xfe, 0x41, 0x6a, 0x5e, 0xfb, 0xc2, 0x82,
+	0xa4, 0xab, 0xac, 0x9c, 0x88, 0xe9, 0xfe, 0xfa, 0x9b, 0x9e, 0xee, 0x9e, 0xde, 0x1e, 0xc2, 0xb2,
+	0xe3, 0x0d, 0x49, 0x40, 0x89, 0xbf, 0x33, 0xf1, 0xc7, 0x74, 0x8c, 0xca, 0xfd, 0xb1, 0x4f, 0xc9,
+	0x33, 0x7d, 0x6d, 0x38, 0x1e, 0x8e, 0xb9, 0xe8, 0x26, 0xfb, 0x25, 0xb4, 0xfa, 0x9d, 0xa1, 0x43,
+	0x8f, 0xc3, 0xde, 0x4e, 0x7f, 0xec, 0xde, 0x14, 0xc0, 0x89, 0x3f, 0xfe, 0x2d, 0xe9, 0x53, 0xb9,
+	0xba, 0x39, 0x39, 0x19, 0x2a, 0x45, 0x4f, 0xfe, 0x10, 0xa6, 0xe6, 0xcf, 0xa0, 0x66, 0x11, 0x3c,
+	0xb0, 0xc8, 0xe7, 0x21, 0x09, 0x28, 0xda, 0x81, 0xa5, 0xcf, 0x43, 0xe2, 0x3b, 0x24, 0x68, 0x69,
+	0xd7, 0x8a, 0xdb, 0xb5, 0xdd, 0xb5, 0x1d, 0x09, 0x7f, 0x12, 0x12, 0x7f, 0x2a, 0x61, 0x96, 0x02,
+	0x99, 0xef, 0x43, 0x5d, 0x98, 0x07, 0x93, 0xb1, 0x17, 0x10, 0x74, 0x13, 0x96, 0x7c, 0x12, 0x84,
+	0x23, 0xaa, 0xec, 0x2f, 0x67, 0xec, 0x05, 0xce, 0x52, 0x28, 0xf3, 0x23, 0x68, 0xa4, 0x34, 0xe8,
+	0x27, 0x00, 0xd4, 0x71, 0x49, 0x90, 0xe7, 0xc4, 0xa4, 0xb7, 0xd3, 0x75, 0x5c, 0xd2, 0xe1, 0xba,
+	0x76, 0xe9, 0xf9, 0x57, 0x5b, 0x05, 0x2b, 0x81, 0x36, 0xff, 0xac, 0x41, 0x3d, 0xe9, 0x27, 0xfa,
+	0x21, 0xa0, 0x80, 0x62, 0x9f, 0xda, 0x1c, 0x44, 0xb1, 0x3b, 0xb1, 0x5d, 0x46, 0xaa, 0x6d, 0x17,
+	0xad, 0x26, 0xd7, 0x74, 0x95, 0xe2, 0x30, 0x40, 0xdb, 0xd0, 0x24, 0xde, 0x20, 0x8d, 0x5d, 0xe0,
+	0xd8, 0x65, 0xe2, 0x0d, 0x92, 0xc8, 0x5b, 0x50, 0x71, 0x31, 0xed, 0x1f, 0x13, 0x3f, 0x68, 0x15,
+	0xd3, 0x71, 0x7a, 0x88, 0x7b, 0x64, 0x74, 0x28, 0x94, 0x56, 0x84, 0x32, 0xff, 0xa2, 0xc1, 0xda,
+	0xfe, 0x33, 0xe2, 0x4e, 0x46, 0xd8, 0xff, 0xbf, 0xb8, 0x78, 0x7b, 0xc6, 0xc5, 0xcb, 0x79, 0x2e,
+	0x06, 0x09, 0x1f, 0x3f, 0x85, 0x55, 0xee, 0x5a, 0x87, 0xfa, 0x04, 0xbb, 0x51, 0x46, 0xde, 0x87,
+	0x5a, 0xff, 0x38, 0xf4, 0x4e, 0x52, 0x29, 0x59, 0x57, 0x64, 0x71, 0x42, 0xee, 0x32, 0x90, 0xcc,
+	0x4a, 0xd2, 0xe2, 0xc3, 0x52, 0x65, 0xa1, 0x59, 0x34, 0x3b, 0x70, 0x39, 0x13, 0x80, 0x6f, 0x21,
+	0xe3, 0xff, 0xd4, 0x00, 0xf1, 0xe3, 0x7c, 0x82, 0x47, 0x21, 0x09, 0x54, 0x50, 0x37, 0x01, 0x46,
+	0x4c, 0x6a, 0x7b, 0xd8, 0x25, 0x3c, 0x98, 0x55, 0xab, 0xca, 0x25, 0x47, 0xd8, 0x25, 0x73, 0x62,
+	0xbe, 0xf0, 0x0d, 0x62, 0x5e, 0x3c, 0x37, 0xe6, 0xa5, 0x6b, 0xda, 0x45, 0x62, 0xfe, 0x0e, 0xac,
+	0xa6, 0xfc, 0x97, 0x31, 0x79, 0x13, 0xea, 0xe2, 0x00, 0x4f, 0xb9, 0x9c, 0x47, 0xa5, 0x6a, 0xd5,
+	0x46, 0x31, 0xd4, 0x7c, 0x0f, 0xae, 0x26, 0x2c, 0x33, 0x39, 0xbb, 0x80, 0xfd, 0x09, 0xac, 0x3c,
+	0x54, 0x11, 0x09, 0x5e, 0x73, 0x35, 0x9a, 0x3f, 0x06, 0x94, 0xdc, 0x4c, 0x7a, 0xb9, 0x05, 0xb5,
+	0x38, 0x4d, 0xca, 0x49, 0x88, 0xf2, 0x14, 0x98, 0x3f, 0x85, 0x56, 0x6c, 0x96, 0x39, 0xe2, 0xb9,
+	0xc6, 0x08, 0x9a, 0x1f, 0x07, 0xc4, 0xef, 0x50, 0x4c, 0xd5, 0xf9, 0xcc, 0x7f, 0x14, 0x61, 0x25,
+	0x21, 0x94, 0x54, 0xd7, 0x55, 0xbf, 0x75, 0xc6, 0x9e, 0xed, 0x63, 0x2a, 0x4a, 0x46, 0xb3, 0x1a,
+	0x91, 0xd4, 0xc2, 0x94, 0xb0, 0xaa, 0xf2, 0x42, 0xd7, 0x96, 0x85, 0xca, 0x0e, 0x5a, 0xb2, 0xaa,
+	0x5e, 0xe8, 0x8a, 0xea, 0x64, 0xb1, 0xc3, 0x13, 0xc7, 0xce, 0x30, 0x15, 0x39, 0x53, 0x13, 0x4f,
+	0x9c, 0x83, 0x14, 0xd9, 0x0e, 0xac, 0xfa, 0xe1, 0x88, 0x64, 0xe1, 0x25, 0x0e, 0x5f, 0x61, 0xaa,
+	0x34, 0xfe, 0x3b, 0xd0, 0xc0, 0x7d, 0xea, 0x3c, 0x25, 0x6a, 0xff, 0x45, 0xbe, 0x7f, 0x5d, 0x08,
+	0xa5, 0x0b, 0x77, 0xc1, 0x90, 0x20, 0x0f, 0xf3, 0x3f, 0xc7, 0x4e, 0x40, 0xc7, 0x43, 0x1f, 0x47,
+	0x5e, 0x97, 0xb9, 0xd5, 0x86, 0x40, 0x1d, 0x71, 0xd0, 0x03, 0x85, 0x91, 0x24, 0xfb, 0xb0, 0x35,
+	0x8f, 0xa4, 0x17, 0xf6, 0x4f, 0x08, 0x0d, 0x5a, 0x4b, 0x9c, 0xe5, 0x8d, 0x5c, 0x96, 0xb6, 0xc0,
+	0x20, 0x0b, 0x6e, 0xcc, 0xa3, 0xc1, 0x4f, 0x89, 0x8f, 0x87, 0xc4, 0x0e, 0xfa, 0xc7, 0xc4, 0xc5,
+	0xad, 0x0a, 0x3f, 0xb3, 0x99, 0xcb, 0xb6, 0x27, 0xa0, 0x1d, 0x8e, 0x34, 0xbf, 0x07, 0x2b, 0xdd,
+	0xce, 0xbd, 0x36, 0xcb, 0x5e, 0x18, 0xd5, 0xec, 0x1a, 0x2c, 0x8e, 0x1c, 0xd7, 0xa1, 0x3c, 0x69,
+	0x8b, 0x96, 0x58, 0x98, 0x7f, 0x2b, 0x01, 0x4a, 0x62, 0x65, 0xaa, 0xd3, 0x39, 0xd4, 0xb2, 0x39,
+	0xbc, 0x01, 0x97, 0x98, 0x5a, 0x14, 0xd6, 0x04, 0x3b, 0xbe, 0xca, 0x73, 0xc3, 0x0b, 0x5d, 0x5e,
+	0x8a, 0x8f, 0x99, 0x90, 0x15, 0x1f, 0xef, 0x70, 0x76, 0x7f, 0x1c, 0x7a, 0x94, 0x27, 0xb9, 0x64,
+	0x01, 0x17, 0xdd, 0x65, 0x12, 0x74, 0x15, 0x2a, 0xae, 0xe3, 0xf1, 0xab, 0xc1, 0x73, 0x5a, 0xb4,
+	0x96, 0x5c, 0xc7, 0x63, 0x57, 0x82, 0xab, 0xf0, 0x33, 0xa1, 0x5a, 0x94, 0x2a, 0xfc, 0x8c, 0xab,
+	0x7e, 0x09, 0x1b, 0xc2, 0x33, 0xc1, 0x6b, 0xf7, 0xa6, 0xb6, 0x4b, 0xa8, 0xef, 0xf4, 0x45, 0x23,
+	0x2b, 0xa7, 0xfb, 0xb8, 0x3a, 0x9e, 0x13, 0x50, 0xa7, 0x2f, 0x9b, 0xe3, 0xba, 0xb0, 0xe7, 0x4e,
+	0xb4, 0xa7, 0x87, 0xdc, 0x98, 0xf7, 0xbc, 0xdf, 0xc0, 0x56, 0xa2, 0x23, 0xc4, 0xfc, 0x89, 0x3e,
+	0xb9, 0x74, 0x3e, 0xbd, 0x1e, 0x77, 0x10, 0xb9, 0x45, 0x74, 0x3f, 0xd1, 0xa7, 0xb0, 0xe9, 0x12,
+	0x77, 0xec, 0x4f, 0x6d, 0xc7, 0xb3, 0x7b, 0x53, 0x4a, 0x82, 0x0c, 0x7f, 0xe5, 0x7c, 0xfe, 0x96,
+	0x60, 0x38, 0xf0, 0xda, 0xcc, 0x3e, 0xc9, 0xde, 0x83, 0x6b, 0xd9, 0xd0, 0x24, 0xcf, 0xc3, 0x72,
+	0xd5, 0xaa, 0x9e, 0xbf, 0xc1, 0x46, 0x2a, 0x3e, 0x71, 0x03, 0x65, 0x69, 0x35, 0xef, 0x40, 0x23,
+	0x65, 0x83, 0x10, 0x94, 0x12, 0x5f, 0x10, 0xfe, 0x9b, 0x95, 0x1b, 0xdf, 0x52, 0x16, 0x86, 0x58,
+	0x98, 0x57, 0x60, 0xed, 0xb1, 0x4f, 0x7e, 0x87, 0x7d, 0xb7, 0x4b, 0x3c, 0xec, 0x51, 0xd5, 0x70,
+	0x6e, 0xc3, 0xe5, 0x8c, 0x5c, 0x16, 0x62, 0x0b, 0x96, 0xfa, 0x3e, 0xc1, 0x94, 0x0c, 0x38, 0x7b,
+	0xc5, 0x52, 0x4b, 0xf3, 0xd7, 0xb0, 0xca, 0x5a, 0xd4, 0xc1, 0xbd, 0x74, 0x93, 0x5a, 0x87, 0xa5,
+	0x30, 0x20, 0xbe, 0xed, 0x0c, 0xa4, 0x3b, 0x65, 0xb6, 0x3c, 0x18, 0xa0, 0xb7, 0xa0, 0x34, 0xc0,
+	0x14, 0x73, 0x7f, 0x6a, 0xbb, 0x57, 0xd5, 0xe9, 0x67, 0xda, 0x9c, 0xc5, 0x61, 0xe6, 0x07, 0x80,
+	0x98, 0x2a, 0x48, 0xb3, 0xdf, 0x86, 0xc5, 0x80, 0x09, 0xe4, 0xf7, 0x77, 0x23, 0xc9, 0x92, 0xf1,
+	0xc4, 0x12, 0x48, 0xf3, 0xef, 0x1a, 0x18, 0xa2, 0xc0, 0x82, 0xfb, 0x63, 0x3f, 0xfd, 0x81, 0x7b,
+	0xcd, 0xc3, 0xcd, 0x3b, 0x50, 0x57, 0x5f, 0x50, 0x3b, 0x20, 0xf4, 0xec, 0x01, 0xa7, 0xa6, 0xa0,
+	0x1d, 0x42, 0xcd, 0x8f, 0x60, 0x6b, 0xae, 0xcf, 0x32, 0x14, 0xdb, 0x50, 0x16, 0x97, 0x4e, 0xc6,
+	0xa2, 0x19, 0xcf, 0x22, 0xc2, 0xd4, 0x92, 0x7a, 0xf3, 0x09, 0x5c, 0x9f, 0x43, 0x96, 0xf9, 0x56,
+	0x5d, 0x9c, 0xb2, 0x05, 0x57, 0x24, 0xe5, 0x21, 0xa1, 0x98, 0x25, 0x4c, 0x55, 0xd2, 0x23, 0x58,
+	0x9f, 0xd1, 0x48, 0xfa, 0x1f, 0x41, 0xc5, 0x95, 0x32, 0xb9, 0x41, 0x2b, 0xbb, 0x41, 0x64, 0x13,
+	0x21, 0xcd, 0xff, 0x6a, 0x70, 0x29, 0x33, 0xbd, 0xb1, 0x14, 0x7c, 0xe6, 0x8f, 0x5d, 0x5b, 0x3d,
+	0x3f, 0xe2, 0x6a, 0x5b, 0x66, 0xf2, 0x03, 0x29, 0x3e, 0x18, 0x24, 0xcb, 0x71, 0x21, 0x55, 0x8e,
+	0x1e, 0x94, 0xf9, 0xc5, 0x54, 0x63, 0xe7, 0x6a, 0xec, 0x4a, 0xd4, 0x40, 0xdb, 0x7b, 0xec, 0x32,
+	0xfe, 0xeb, 0xab, 0xad, 0x6f, 0xf4, 0x72, 0x11, 0xf6, 0x7b, 0x03, 0x3c, 0xa1, 0xc4, 0xb7, 0xe4,
+	0x2e, 0xe8, 0x07, 0x50, 0x16, 0xc3, 0x66, 0xab, 0xc4, 0xf7, 0x6b, 0xa8, 0x2a, 0x48, 0xce, 0xa3,
+	0x12, 0x62, 0xfe, 0x51, 0x83, 0x45, 0x71, 0xd2, 0xd7, 0x55, 0x9a, 0x3a, 0x54, 0x88, 0xd7, 0x1f,
+	0x0f, 0x1c, 0x6f, 0xc8, 0x3f, 0x0b, 0x8b, 0x56, 0xb4, 0x66, 0xed, 0x84, 0xe7, 0x88, 0x7d, 0x10,
+	0xea, 0xf2, 0x3a, 0xee, 0x41, 0x23, 0x55, 0x39, 0xa9, 0xb7, 0x85, 0x76, 0xa1, 0xb7, 0x85, 0x0d,
+	0xf5, 0xa4, 0x06, 0x5d, 0x87, 0x12, 0x9d, 0x4e, 0x44, 0xd7, 0x5a, 0xde, 0x5d, 0x51, 0xd6, 0x5c,
+	0xdd, 0x9d, 0x4e, 0x88, 0xc5, 0xd5, 0x51, 0x73, 0x5b, 0xc8, 0x6b, 0x6e, 0x45, 0x2e, 0x14, 0x0b,
+	0xf3, 0x0b, 0x0d, 0x96, 0xe3, 0x4a, 0xb9, 0xef, 0x8c, 0xc8, 0xb7, 0x51, 0x28, 0x3a, 0x54, 0x3e,
+	0x73, 0x46, 0x84, 0xfb, 0x20, 0xb6, 0x8b, 0xd6, 0xb9, 0x91, 0xfa, 0x42, 0x83, 0x95, 0xc7, 0x61,
+	0x70, 0xac, 0xee, 0x96, 0x68, 0x31, 0xb7, 0xd8, 0x8b, 0x93, 0xff, 0xe4, 0xfb, 0xd7, 0x76, 0xaf,
+	0xc4, 0xf5, 0xf6, 0x73, 0xdf, 0xa1, 0x24, 0x7a, 0xb3, 0x4a, 0x18, 0x7a, 0x37, 0x71, 0x5b, 0x16,
+	0x78, 0x80, 0x75, 0x15, 0xa2, 0x98, 0x5e, 0xdd, 0x17, 0x59, 0x3f, 0xf1, 0xad, 0x79, 0x0f, 0xd0,
+	0x2c, 0x0a, 0x35, 0xa1, 0x78, 0x42, 0xa6, 0x32, 0x02, 0xec, 0x27, 0xba, 0x02, 0x65, 0x39, 0x7b,
+	0x2f, 0xf0, 0xc9, 0x54, 0xae, 0x4c, 0x9c, 0xb4, 0x8f, 0x6e, 0xf0, 0xdb, 0x50, 0xf1, 0xe5, 0x6f,
+	0x79, 0x8c, 0xf5, 0x99, 0x63, 0x08, 0xb5, 0x15, 0x01, 0xd9, 0x16, 0x01, 0x9f, 0x6e, 0x78, 0x60,
+	0xeb, 0x96, 0x5c, 0x7d, 0xff, 0x43, 0xa8, 0x46, 0xb9, 0x46, 0x55, 0x58, 0xdc, 0x7f, 0xf2, 0xf1,
+	0xde, 0xc3, 0x66, 0x01, 0x35, 0xa0, 0x7a, 0xf4, 0xa8, 0x6b, 0x8b, 0xa5, 0x86, 0x2e, 0x41, 0xcd,
+	0xda, 0xff, 0x60, 0xff, 0x17, 0xf6, 0xe1, 0x5e, 0xf7, 0xee, 0x83, 0xe6, 0x02, 0x42, 0xb0, 0x2c,
+	0x04, 0x47, 0x8f, 0xa4, 0xac, 0xb8, 0xfb, 0xbc, 0x0a, 0x15, 0x95, 0x4c, 0x74, 0x07, 0x4a, 0xcc,
+	0x77, 0x34, 0x27, 0xc4, 0xfa, 0x3c, 0x9f, 0xcd, 0x02, 0x3a, 0x00, 0x88, 0x8f, 0x8d, 0xae, 0xce,
+	0x06, 0x5c, 0x71, 0xe8, 0x79, 0x2a, 0x45, 0xb3, 0xad, 0xdd, 0xd2, 0xd0, 0x3d, 0xa8, 0x25, 0x9e,
+	0xa9, 0x28, 0xf7, 0x3f, 0x14, 0xfa, 0x46, 0x4a, 0x9a, 0xe5, 0xb9, 0xa5, 0xa1, 0x47, 0xb0, 0xcc,
+	0x55, 0xea, 0x4d, 0x1a, 0xa0, 0x37, 0x94, 0x49, 0xde, 0x3b, 0x5d, 0xdf, 0x9c, 0xa3, 0x8d, 0x4e,
+	0xf8, 0x00, 0x6a, 0x89, 0xf7, 0x18, 0xd2, 0x53, 0x97, 0x36, 0xf5, 0x3c, 0xd5, 0x37, 0x72, 0x75,
+	0x11, 0xd3, 0x27, 0xb0, 0x92, 0x50, 0xc8, 0x63, 0x9e, 0xc5, 0xf7, 0x66, 0x8e, 0x2e, 0xe7, 0xc8,
+	0xfb, 0x00, 0xf1, 0x6b, 0x2a, 0xce, 0xc1, 0xcc, 0x2b, 0x50, 0xd7, 0xf3, 0x54, 0x91, 0x7b, 0x1d,
+	0x68, 0x66, 0x1f, 0x65, 0x67, 0x91, 0x5d, 0x9b, 0x55, 0xe5, 0xf8, 0xd6, 0x86, 0x6a, 0x34, 0xb0,
+	0xa0, 0x56, 0xce, 0x0c, 0x23, 0xc8, 0xe6, 0x4f, 0x37, 0x66, 0x01, 0xdd, 0x87, 0xfa, 0xde, 0x68,
+	0x74, 0x11, 0x1a, 0x3d, 0xa9, 0x09, 0xb2, 0x3c, 0x23, 0x58, 0x9f, 0xf3, 0x59, 0x47, 0x37, 0xa2,
+	0x66, 0x7a, 0xe6, 0xe0, 0xa3, 0x7f, 0xf7, 0x5c, 0x5c, 0xb4, 0xdb, 0xef, 0x61, 0xf3, 0xcc, 0x21,
+	0xe2, 0xc2, 0x7b, 0xbe, 0x75, 0x0e, 0x2e, 0x27, 0xea, 0x5d, 0xb8, 0x94, 0x99, 0x29, 0x90, 0x91,
+	0x61, 0xc9, 0x8c, 0x21, 0xfa, 0xd6, 0x5c, 0x7d, 0x74, 0xa2, 0x7d, 0x80, 0xf8, 0xe5, 0x15, 0x97,
+	0xc6, 0xcc, 0xcb, 0x4d, 0xd7, 0xf3, 0x54, 0x11, 0xcd, 0x11, 0x34, 0x52, 0xa3, 0x73, 0x7c, 0x41,
+	0xf3, 0x26, 0x6d, 0x7d, 0x73, 0x8e, 0x56, 0xf1, 0xb5, 0xdf, 0x7d, 0xf1, 0xd2, 0x28, 0x7c, 0xf9,
+	0xd2, 0x28, 0x7c, 0xfd, 0xd2, 0xd0, 0xfe, 0x70, 0x6a, 0x68, 0x7f, 0x3d, 0x35, 0xb4, 0xe7, 0xa7,
+	0x86, 0xf6, 0xe2, 0xd4, 0xd0, 0xfe, 0x7d, 0x6a, 0x68, 0xff, 0x39, 0x35, 0x0a, 0x5f, 0x9f, 0x1a,
+	0xda, 0x9f, 0x5e, 0x19, 0x85, 0x17, 0xaf, 0x8c, 0xc2, 0x97, 0xaf, 0x8c, 0xc2, 0xaf, 0xca, 0xfd,
+	0x91, 0x43, 0x3c, 0xda, 0x2b, 0xf3, 0xff, 0x97, 0xbe, 0xfd, 0xbf, 0x01, 0x00, 0x5d, 0x76, 0xa3,
+	0x75, 0x9a, 0x15, 0x00, 0x00,
+}
+
+func (x MatchType) String() string {
+	s, ok := MatchType_name[int32(x)]
+	if ok {
+		return s
+	}
+	return strconv.Itoa(int(x))
+}
+func (this *ReadRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
 	that1, ok := that.(*ReadRequest)
 	if !ok {
 		that2, ok := that.(ReadRequest)
@@ -1981,16 +2481,25 @@ func (this *UserStatsResponse) Equal(that interface{}) bool {
 	if this.ActiveSeries != that1.ActiveSeries {
 		return false
 	}
+	if this.ActiveNativeHistogramSeries != that1.ActiveNativeHistogramSeries {
+		return false
+	}
+	if this.ActiveNativeHistogramBuckets != that1.ActiveNativeHistogramBuckets {
+		return false
+	}
+	if this.ActiveNativeHistogramAverageSchema != that1.ActiveNativeHistogramAverageSchema {
+		return false
+	}
 	return true
 }
-func (this *UserIDStatsResponse) Equal(that interface{}) bool {
+func (this *TSDBStatusRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UserIDStatsResponse)
+	that1, ok := that.(*TSDBStatusRequest)
 	if !ok {
-		that2, ok := that.(UserIDStatsResponse)
+		that2, ok := that.(TSDBStatusRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2002,22 +2511,19 @@ func (this *UserIDStatsResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.UserId != that1.UserId {
-		return false
-	}
-	if !this.Data.Equal(that1.Data) {
+	if this.Limit != that1.Limit {
 		return false
 	}
 	return true
 }
-func (this *UsersStatsResponse) Equal(that interface{}) bool {
+func (this *TSDBStatusResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*UsersStatsResponse)
+	that1, ok := that.(*TSDBStatusResponse)
 	if !ok {
-		that2, ok := that.(UsersStatsResponse)
+		that2, ok := that.(TSDBStatusResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2029,24 +2535,63 @@ func (this *UsersStatsResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Stats) != len(that1.Stats) {
+	if this.NumSeries != that1.NumSeries {
 		return false
 	}
-	for i := range this.Stats {
-		if !this.Stats[i].Equal(that1.Stats[i]) {
+	if this.NumLabelPairs != that1.NumLabelPairs {
+		return false
+	}
+	if this.ChunkCount != that1.ChunkCount {
+		return false
+	}
+	if this.MinTime != that1.MinTime {
+		return false
+	}
+	if this.MaxTime != that1.MaxTime {
+		return false
+	}
+	if len(this.SeriesCountByMetricName) != len(that1.SeriesCountByMetricName) {
+		return false
+	}
+	for i := range this.SeriesCountByMetricName {
+		if !this.SeriesCountByMetricName[i].Equal(&that1.SeriesCountByMetricName[i]) {
+			return false
+		}
+	}
+	if len(this.LabelValueCountByLabelName) != len(that1.LabelValueCountByLabelName) {
+		return false
+	}
+	for i := range this.LabelValueCountByLabelName {
+		if !this.LabelValueCountByLabelName[i].Equal(&that1.LabelValueCountByLabelName[i]) {
+			return false
+		}
+	}
+	if len(this.MemoryInBytesByLabelName) != len(that1.MemoryInBytesByLabelName) {
+		return false
+	}
+	for i := range this.MemoryInBytesByLabelName {
+		if !this.MemoryInBytesByLabelName[i].Equal(&that1.MemoryInBytesByLabelName[i]) {
+			return false
+		}
+	}
+	if len(this.SeriesCountByLabelValuePair) != len(that1.SeriesCountByLabelValuePair) {
+		return false
+	}
+	for i := range this.SeriesCountByLabelValuePair {
+		if !this.SeriesCountByLabelValuePair[i].Equal(&that1.SeriesCountByLabelValuePair[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
+func (this *TSDBStatistic) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsForLabelMatchersRequest)
+	that1, ok := that.(*TSDBStatistic)
 	if !ok {
-		that2, ok := that.(MetricsForLabelMatchersRequest)
+		that2, ok := that.(TSDBStatistic)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2058,30 +2603,22 @@ func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.StartTimestampMs != that1.StartTimestampMs {
-		return false
-	}
-	if this.EndTimestampMs != that1.EndTimestampMs {
+	if this.Name != that1.Name {
 		return false
 	}
-	if len(this.MatchersSet) != len(that1.MatchersSet) {
+	if this.Value != that1.Value {
 		return false
 	}
-	for i := range this.MatchersSet {
-		if !this.MatchersSet[i].Equal(that1.MatchersSet[i]) {
-			return false
-		}
-	}
 	return true
 }
-func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
+func (this *PrewarmTenantRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsForLabelMatchersResponse)
+	that1, ok := that.(*PrewarmTenantRequest)
 	if !ok {
-		that2, ok := that.(MetricsForLabelMatchersResponse)
+		that2, ok := that.(PrewarmTenantRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2093,24 +2630,16 @@ func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Metric) != len(that1.Metric) {
-		return false
-	}
-	for i := range this.Metric {
-		if !this.Metric[i].Equal(that1.Metric[i]) {
-			return false
-		}
-	}
 	return true
 }
-func (this *MetricsForLabelMatchersStreamResponse) Equal(that interface{}) bool {
+func (this *PrewarmTenantResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsForLabelMatchersStreamResponse)
+	that1, ok := that.(*PrewarmTenantResponse)
 	if !ok {
-		that2, ok := that.(MetricsForLabelMatchersStreamResponse)
+		that2, ok := that.(PrewarmTenantResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2122,24 +2651,19 @@ func (this *MetricsForLabelMatchersStreamResponse) Equal(that interface{}) bool
 	} else if this == nil {
 		return false
 	}
-	if len(this.Metric) != len(that1.Metric) {
+	if this.Created != that1.Created {
 		return false
 	}
-	for i := range this.Metric {
-		if !this.Metric[i].Equal(that1.Metric[i]) {
-			return false
-		}
-	}
 	return true
 }
-func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
+func (this *UserIDStatsResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsMetadataRequest)
+	that1, ok := that.(*UserIDStatsResponse)
 	if !ok {
-		that2, ok := that.(MetricsMetadataRequest)
+		that2, ok := that.(UserIDStatsResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2151,16 +2675,22 @@ func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
+	if this.UserId != that1.UserId {
+		return false
+	}
+	if !this.Data.Equal(that1.Data) {
+		return false
+	}
 	return true
 }
-func (this *MetricsMetadataResponse) Equal(that interface{}) bool {
+func (this *UsersStatsResponse) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*MetricsMetadataResponse)
+	that1, ok := that.(*UsersStatsResponse)
 	if !ok {
-		that2, ok := that.(MetricsMetadataResponse)
+		that2, ok := that.(UsersStatsResponse)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2172,24 +2702,24 @@ func (this *MetricsMetadataResponse) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if len(this.Metadata) != len(that1.Metadata) {
+	if len(this.Stats) != len(that1.Stats) {
 		return false
 	}
-	for i := range this.Metadata {
-		if !this.Metadata[i].Equal(that1.Metadata[i]) {
+	for i := range this.Stats {
+		if !this.Stats[i].Equal(that1.Stats[i]) {
 			return false
 		}
 	}
 	return true
 }
-func (this *TimeSeriesChunk) Equal(that interface{}) bool {
+func (this *MetricsForLabelMatchersRequest) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
 	}
 
-	that1, ok := that.(*TimeSeriesChunk)
+	that1, ok := that.(*MetricsForLabelMatchersRequest)
 	if !ok {
-		that2, ok := that.(TimeSeriesChunk)
+		that2, ok := that.(MetricsForLabelMatchersRequest)
 		if ok {
 			that1 = &that2
 		} else {
@@ -2201,18 +2731,161 @@ func (this *TimeSeriesChunk) Equal(that interface{}) bool {
 	} else if this == nil {
 		return false
 	}
-	if this.FromIngesterId != that1.FromIngesterId {
+	if this.StartTimestampMs != that1.StartTimestampMs {
 		return false
 	}
-	if this.UserId != that1.UserId {
+	if this.EndTimestampMs != that1.EndTimestampMs {
 		return false
 	}
-	if len(this.Labels) != len(that1.Labels) {
+	if len(this.MatchersSet) != len(that1.MatchersSet) {
 		return false
 	}
-	for i := range this.Labels {
-		if !this.Labels[i].Equal(that1.Labels[i]) {
-			return false
+	for i := range this.MatchersSet {
+		if !this.MatchersSet[i].Equal(that1.MatchersSet[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *MetricsForLabelMatchersResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MetricsForLabelMatchersResponse)
+	if !ok {
+		that2, ok := that.(MetricsForLabelMatchersResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Metric) != len(that1.Metric) {
+		return false
+	}
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *MetricsForLabelMatchersStreamResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MetricsForLabelMatchersStreamResponse)
+	if !ok {
+		that2, ok := that.(MetricsForLabelMatchersStreamResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Metric) != len(that1.Metric) {
+		return false
+	}
+	for i := range this.Metric {
+		if !this.Metric[i].Equal(that1.Metric[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *MetricsMetadataRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MetricsMetadataRequest)
+	if !ok {
+		that2, ok := that.(MetricsMetadataRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	return true
+}
+func (this *MetricsMetadataResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*MetricsMetadataResponse)
+	if !ok {
+		that2, ok := that.(MetricsMetadataResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Metadata) != len(that1.Metadata) {
+		return false
+	}
+	for i := range this.Metadata {
+		if !this.Metadata[i].Equal(that1.Metadata[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *TimeSeriesChunk) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*TimeSeriesChunk)
+	if !ok {
+		that2, ok := that.(TimeSeriesChunk)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.FromIngesterId != that1.FromIngesterId {
+		return false
+	}
+	if this.UserId != that1.UserId {
+		return false
+	}
+	if len(this.Labels) != len(that1.Labels) {
+		return false
+	}
+	for i := range this.Labels {
+		if !this.Labels[i].Equal(that1.Labels[i]) {
+			return false
 		}
 	}
 	if len(this.Chunks) != len(that1.Chunks) {
@@ -2350,6 +3023,97 @@ func (this *TimeSeriesFile) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *PushStreamRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*PushStreamRequest)
+	if !ok {
+		that2, ok := that.(PushStreamRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Request.Equal(that1.Request) {
+		return false
+	}
+	if len(this.Metadata) != len(that1.Metadata) {
+		return false
+	}
+	for i := range this.Metadata {
+		if !this.Metadata[i].Equal(&that1.Metadata[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *PushStreamMetadata) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*PushStreamMetadata)
+	if !ok {
+		that2, ok := that.(PushStreamMetadata)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Key != that1.Key {
+		return false
+	}
+	if len(this.Values) != len(that1.Values) {
+		return false
+	}
+	for i := range this.Values {
+		if this.Values[i] != that1.Values[i] {
+			return false
+		}
+	}
+	return true
+}
+func (this *PushStreamResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*PushStreamResponse)
+	if !ok {
+		that2, ok := that.(PushStreamResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Response.Equal(that1.Response) {
+		return false
+	}
+	if !bytes.Equal(this.Status, that1.Status) {
+		return false
+	}
+	return true
+}
 func (this *ReadRequest) GoString() string {
 	if this == nil {
 		return "nil"
@@ -2529,52 +3293,137 @@ func (this *UserStatsResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 12)
 	s = append(s, "&client.UserStatsResponse{")
 	s = append(s, "IngestionRate: "+fmt.Sprintf("%#v", this.IngestionRate)+",\n")
 	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
 	s = append(s, "ApiIngestionRate: "+fmt.Sprintf("%#v", this.ApiIngestionRate)+",\n")
 	s = append(s, "RuleIngestionRate: "+fmt.Sprintf("%#v", this.RuleIngestionRate)+",\n")
 	s = append(s, "ActiveSeries: "+fmt.Sprintf("%#v", this.ActiveSeries)+",\n")
+	s = append(s, "ActiveNativeHistogramSeries: "+fmt.Sprintf("%#v", this.ActiveNativeHistogramSeries)+",\n")
+	s = append(s, "ActiveNativeHistogramBuckets: "+fmt.Sprintf("%#v", this.ActiveNativeHistogramBuckets)+",\n")
+	s = append(s, "ActiveNativeHistogramAverageSchema: "+fmt.Sprintf("%#v", this.ActiveNativeHistogramAverageSchema)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func (this *UserIDStatsResponse) GoString() string {
+func (this *TSDBStatusRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
-	s = append(s, "&client.UserIDStatsResponse{")
-	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
-	if this.Data != nil {
-		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
-	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.TSDBStatusRequest{")
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func (this *UsersStatsResponse) GoString() string {
+func (this *TSDBStatusResponse) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
-	s = append(s, "&client.UsersStatsResponse{")
-	if this.Stats != nil {
-		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	s := make([]string, 0, 13)
+	s = append(s, "&client.TSDBStatusResponse{")
+	s = append(s, "NumSeries: "+fmt.Sprintf("%#v", this.NumSeries)+",\n")
+	s = append(s, "NumLabelPairs: "+fmt.Sprintf("%#v", this.NumLabelPairs)+",\n")
+	s = append(s, "ChunkCount: "+fmt.Sprintf("%#v", this.ChunkCount)+",\n")
+	s = append(s, "MinTime: "+fmt.Sprintf("%#v", this.MinTime)+",\n")
+	s = append(s, "MaxTime: "+fmt.Sprintf("%#v", this.MaxTime)+",\n")
+	if this.SeriesCountByMetricName != nil {
+		vs := make([]*TSDBStatistic, len(this.SeriesCountByMetricName))
+		for i := range vs {
+			vs[i] = &this.SeriesCountByMetricName[i]
+		}
+		s = append(s, "SeriesCountByMetricName: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.LabelValueCountByLabelName != nil {
+		vs := make([]*TSDBStatistic, len(this.LabelValueCountByLabelName))
+		for i := range vs {
+			vs[i] = &this.LabelValueCountByLabelName[i]
+		}
+		s = append(s, "LabelValueCountByLabelName: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.MemoryInBytesByLabelName != nil {
+		vs := make([]*TSDBStatistic, len(this.MemoryInBytesByLabelName))
+		for i := range vs {
+			vs[i] = &this.MemoryInBytesByLabelName[i]
+		}
+		s = append(s, "MemoryInBytesByLabelName: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	if this.SeriesCountByLabelValuePair != nil {
+		vs := make([]*TSDBStatistic, len(this.SeriesCountByLabelValuePair))
+		for i := range vs {
+			vs[i] = &this.SeriesCountByLabelValuePair[i]
+		}
+		s = append(s, "SeriesCountByLabelValuePair: "+fmt.Sprintf("%#v", vs)+",\n")
 	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
-func (this *MetricsForLabelMatchersRequest) GoString() string {
+func (this *TSDBStatistic) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 7)
-	s = append(s, "&client.MetricsForLabelMatchersRequest{")
-	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
-	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
-	if this.MatchersSet != nil {
-		s = append(s, "MatchersSet: "+fmt.Sprintf("%#v", this.MatchersSet)+",\n")
-	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.TSDBStatistic{")
+	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
+	s = append(s, "Value: "+fmt.Sprintf("%#v", this.Value)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *PrewarmTenantRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 4)
+	s = append(s, "&client.PrewarmTenantRequest{")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *PrewarmTenantResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.PrewarmTenantResponse{")
+	s = append(s, "Created: "+fmt.Sprintf("%#v", this.Created)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *UserIDStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.UserIDStatsResponse{")
+	s = append(s, "UserId: "+fmt.Sprintf("%#v", this.UserId)+",\n")
+	if this.Data != nil {
+		s = append(s, "Data: "+fmt.Sprintf("%#v", this.Data)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *UsersStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&client.UsersStatsResponse{")
+	if this.Stats != nil {
+		s = append(s, "Stats: "+fmt.Sprintf("%#v", this.Stats)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *MetricsForLabelMatchersRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&client.MetricsForLabelMatchersRequest{")
+	s = append(s, "StartTimestampMs: "+fmt.Sprintf("%#v", this.StartTimestampMs)+",\n")
+	s = append(s, "EndTimestampMs: "+fmt.Sprintf("%#v", this.EndTimestampMs)+",\n")
+	if this.MatchersSet != nil {
+		s = append(s, "MatchersSet: "+fmt.Sprintf("%#v", this.MatchersSet)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -2692,6 +3541,49 @@ func (this *TimeSeriesFile) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *PushStreamRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.PushStreamRequest{")
+	if this.Request != nil {
+		s = append(s, "Request: "+fmt.Sprintf("%#v", this.Request)+",\n")
+	}
+	if this.Metadata != nil {
+		vs := make([]*PushStreamMetadata, len(this.Metadata))
+		for i := range vs {
+			vs[i] = &this.Metadata[i]
+		}
+		s = append(s, "Metadata: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *PushStreamMetadata) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.PushStreamMetadata{")
+	s = append(s, "Key: "+fmt.Sprintf("%#v", this.Key)+",\n")
+	s = append(s, "Values: "+fmt.Sprintf("%#v", this.Values)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *PushStreamResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 6)
+	s = append(s, "&client.PushStreamResponse{")
+	if this.Response != nil {
+		s = append(s, "Response: "+fmt.Sprintf("%#v", this.Response)+",\n")
+	}
+	s = append(s, "Status: "+fmt.Sprintf("%#v", this.Status)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func valueToGoStringIngester(v interface{}, typ string) string {
 	rv := reflect.ValueOf(v)
 	if rv.IsNil() {
@@ -2714,6 +3606,9 @@ const _ = grpc.SupportPackageIsVersion4
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
 type IngesterClient interface {
 	Push(ctx context.Context, in *cortexpb.WriteRequest, opts ...grpc.CallOption) (*cortexpb.WriteResponse, error)
+	// PushStream receives the pushes of a tenant on a long-lived stream, answering each of them in order.
+	// The error of a push is returned in its response, without ending the stream.
+	PushStream(ctx context.Context, opts ...grpc.CallOption) (Ingester_PushStreamClient, error)
 	QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Ingester_QueryStreamClient, error)
 	QueryExemplars(ctx context.Context, in *ExemplarQueryRequest, opts ...grpc.CallOption) (*ExemplarQueryResponse, error)
 	LabelValues(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (*LabelValuesResponse, error)
@@ -2725,6 +3620,8 @@ type IngesterClient interface {
 	MetricsForLabelMatchers(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (*MetricsForLabelMatchersResponse, error)
 	MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error)
 	MetricsMetadata(ctx context.Context, in *MetricsMetadataRequest, opts ...grpc.CallOption) (*MetricsMetadataResponse, error)
+	TSDBStatus(ctx context.Context, in *TSDBStatusRequest, opts ...grpc.CallOption) (*TSDBStatusResponse, error)
+	PrewarmTenant(ctx context.Context, in *PrewarmTenantRequest, opts ...grpc.CallOption) (*PrewarmTenantResponse, error)
 }
 
 type ingesterClient struct {
@@ -2744,8 +3641,39 @@ func (c *ingesterClient) Push(ctx context.Context, in *cortexpb.WriteRequest, op
 	return out, nil
 }
 
+func (c *ingesterClient) PushStream(ctx context.Context, opts ...grpc.CallOption) (Ingester_PushStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[0], "/cortex.Ingester/PushStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &ingesterPushStreamClient{stream}
+	return x, nil
+}
+
+type Ingester_PushStreamClient interface {
+	Send(*PushStreamRequest) error
+	Recv() (*PushStreamResponse, error)
+	grpc.ClientStream
+}
+
+type ingesterPushStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *ingesterPushStreamClient) Send(m *PushStreamRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *ingesterPushStreamClient) Recv() (*PushStreamResponse, error) {
+	m := new(PushStreamResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *ingesterClient) QueryStream(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (Ingester_QueryStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[0], "/cortex.Ingester/QueryStream", opts...)
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[1], "/cortex.Ingester/QueryStream", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -2795,7 +3723,7 @@ func (c *ingesterClient) LabelValues(ctx context.Context, in *LabelValuesRequest
 }
 
 func (c *ingesterClient) LabelValuesStream(ctx context.Context, in *LabelValuesRequest, opts ...grpc.CallOption) (Ingester_LabelValuesStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[1], "/cortex.Ingester/LabelValuesStream", opts...)
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[2], "/cortex.Ingester/LabelValuesStream", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -2836,7 +3764,7 @@ func (c *ingesterClient) LabelNames(ctx context.Context, in *LabelNamesRequest,
 }
 
 func (c *ingesterClient) LabelNamesStream(ctx context.Context, in *LabelNamesRequest, opts ...grpc.CallOption) (Ingester_LabelNamesStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[2], "/cortex.Ingester/LabelNamesStream", opts...)
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[3], "/cortex.Ingester/LabelNamesStream", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -2895,7 +3823,7 @@ func (c *ingesterClient) MetricsForLabelMatchers(ctx context.Context, in *Metric
 }
 
 func (c *ingesterClient) MetricsForLabelMatchersStream(ctx context.Context, in *MetricsForLabelMatchersRequest, opts ...grpc.CallOption) (Ingester_MetricsForLabelMatchersStreamClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[3], "/cortex.Ingester/MetricsForLabelMatchersStream", opts...)
+	stream, err := c.cc.NewStream(ctx, &_Ingester_serviceDesc.Streams[4], "/cortex.Ingester/MetricsForLabelMatchersStream", opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -2935,9 +3863,30 @@ func (c *ingesterClient) MetricsMetadata(ctx context.Context, in *MetricsMetadat
 	return out, nil
 }
 
+func (c *ingesterClient) TSDBStatus(ctx context.Context, in *TSDBStatusRequest, opts ...grpc.CallOption) (*TSDBStatusResponse, error) {
+	out := new(TSDBStatusResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/TSDBStatus", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ingesterClient) PrewarmTenant(ctx context.Context, in *PrewarmTenantRequest, opts ...grpc.CallOption) (*PrewarmTenantResponse, error) {
+	out := new(PrewarmTenantResponse)
+	err := c.cc.Invoke(ctx, "/cortex.Ingester/PrewarmTenant", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // IngesterServer is the server API for Ingester service.
 type IngesterServer interface {
 	Push(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
+	// PushStream receives the pushes of a tenant on a long-lived stream, answering each of them in order.
+	// The error of a push is returned in its response, without ending the stream.
+	PushStream(Ingester_PushStreamServer) error
 	QueryStream(*QueryRequest, Ingester_QueryStreamServer) error
 	QueryExemplars(context.Context, *ExemplarQueryRequest) (*ExemplarQueryResponse, error)
 	LabelValues(context.Context, *LabelValuesRequest) (*LabelValuesResponse, error)
@@ -2949,6 +3898,8 @@ type IngesterServer interface {
 	MetricsForLabelMatchers(context.Context, *MetricsForLabelMatchersRequest) (*MetricsForLabelMatchersResponse, error)
 	MetricsForLabelMatchersStream(*MetricsForLabelMatchersRequest, Ingester_MetricsForLabelMatchersStreamServer) error
 	MetricsMetadata(context.Context, *MetricsMetadataRequest) (*MetricsMetadataResponse, error)
+	TSDBStatus(context.Context, *TSDBStatusRequest) (*TSDBStatusResponse, error)
+	PrewarmTenant(context.Context, *PrewarmTenantRequest) (*PrewarmTenantResponse, error)
 }
 
 // UnimplementedIngesterServer can be embedded to have forward compatible implementations.
@@ -2958,6 +3909,9 @@ type UnimplementedIngesterServer struct {
 func (*UnimplementedIngesterServer) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Push not implemented")
 }
+func (*UnimplementedIngesterServer) PushStream(srv Ingester_PushStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method PushStream not implemented")
+}
 func (*UnimplementedIngesterServer) QueryStream(req *QueryRequest, srv Ingester_QueryStreamServer) error {
 	return status.Errorf(codes.Unimplemented, "method QueryStream not implemented")
 }
@@ -2991,6 +3945,12 @@ func (*UnimplementedIngesterServer) MetricsForLabelMatchersStream(req *MetricsFo
 func (*UnimplementedIngesterServer) MetricsMetadata(ctx context.Context, req *MetricsMetadataRequest) (*MetricsMetadataResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MetricsMetadata not implemented")
 }
+func (*UnimplementedIngesterServer) TSDBStatus(ctx context.Context, req *TSDBStatusRequest) (*TSDBStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TSDBStatus not implemented")
+}
+func (*UnimplementedIngesterServer) PrewarmTenant(ctx context.Context, req *PrewarmTenantRequest) (*PrewarmTenantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PrewarmTenant not implemented")
+}
 
 func RegisterIngesterServer(s *grpc.Server, srv IngesterServer) {
 	s.RegisterService(&_Ingester_serviceDesc, srv)
@@ -3014,6 +3974,32 @@ func _Ingester_Push_Handler(srv interface{}, ctx context.Context, dec func(inter
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ingester_PushStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(IngesterServer).PushStream(&ingesterPushStreamServer{stream})
+}
+
+type Ingester_PushStreamServer interface {
+	Send(*PushStreamResponse) error
+	Recv() (*PushStreamRequest, error)
+	grpc.ServerStream
+}
+
+type ingesterPushStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *ingesterPushStreamServer) Send(m *PushStreamResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *ingesterPushStreamServer) Recv() (*PushStreamRequest, error) {
+	m := new(PushStreamRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Ingester_QueryStream_Handler(srv interface{}, stream grpc.ServerStream) error {
 	m := new(QueryRequest)
 	if err := stream.RecvMsg(m); err != nil {
@@ -3224,6 +4210,42 @@ func _Ingester_MetricsMetadata_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Ingester_TSDBStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TSDBStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngesterServer).TSDBStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/TSDBStatus",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).TSDBStatus(ctx, req.(*TSDBStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Ingester_PrewarmTenant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrewarmTenantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IngesterServer).PrewarmTenant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cortex.Ingester/PrewarmTenant",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IngesterServer).PrewarmTenant(ctx, req.(*PrewarmTenantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Ingester_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "cortex.Ingester",
 	HandlerType: (*IngesterServer)(nil),
@@ -3260,8 +4282,22 @@ var _Ingester_serviceDesc = grpc.ServiceDesc{
 			MethodName: "MetricsMetadata",
 			Handler:    _Ingester_MetricsMetadata_Handler,
 		},
+		{
+			MethodName: "TSDBStatus",
+			Handler:    _Ingester_TSDBStatus_Handler,
+		},
+		{
+			MethodName: "PrewarmTenant",
+			Handler:    _Ingester_PrewarmTenant_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "PushStream",
+			Handler:       _Ingester_PushStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 		{
 			StreamName:    "QueryStream",
 			Handler:       _Ingester_QueryStream_Handler,
@@ -3821,6 +4857,22 @@ func (m *UserStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.ActiveNativeHistogramAverageSchema != 0 {
+		i -= 8
+		encoding_binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.ActiveNativeHistogramAverageSchema))))
+		i--
+		dAtA[i] = 0x41
+	}
+	if m.ActiveNativeHistogramBuckets != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.ActiveNativeHistogramBuckets))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.ActiveNativeHistogramSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.ActiveNativeHistogramSeries))
+		i--
+		dAtA[i] = 0x30
+	}
 	if m.ActiveSeries != 0 {
 		i = encodeVarintIngester(dAtA, i, uint64(m.ActiveSeries))
 		i--
@@ -3852,7 +4904,7 @@ func (m *UserStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func (m *UserIDStatsResponse) Marshal() (dAtA []byte, err error) {
+func (m *TSDBStatusRequest) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -3862,39 +4914,25 @@ func (m *UserIDStatsResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *UserIDStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *TSDBStatusRequest) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *UserIDStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *TSDBStatusRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if m.Data != nil {
-		{
-			size, err := m.Data.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintIngester(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
-	}
-	if len(m.UserId) > 0 {
-		i -= len(m.UserId)
-		copy(dAtA[i:], m.UserId)
-		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+	if m.Limit != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Limit))
 		i--
-		dAtA[i] = 0xa
+		dAtA[i] = 0x8
 	}
 	return len(dAtA) - i, nil
 }
 
-func (m *UsersStatsResponse) Marshal() (dAtA []byte, err error) {
+func (m *TSDBStatusResponse) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -3904,20 +4942,20 @@ func (m *UsersStatsResponse) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *UsersStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+func (m *TSDBStatusResponse) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *TSDBStatusResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.Stats) > 0 {
-		for iNdEx := len(m.Stats) - 1; iNdEx >= 0; iNdEx-- {
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for iNdEx := len(m.SeriesCountByLabelValuePair) - 1; iNdEx >= 0; iNdEx-- {
 			{
-				size, err := m.Stats[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				size, err := m.SeriesCountByLabelValuePair[iNdEx].MarshalToSizedBuffer(dAtA[:i])
 				if err != nil {
 					return 0, err
 				}
@@ -3925,13 +4963,80 @@ func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 				i = encodeVarintIngester(dAtA, i, uint64(size))
 			}
 			i--
-			dAtA[i] = 0xa
+			dAtA[i] = 0x4a
+		}
+	}
+	if len(m.MemoryInBytesByLabelName) > 0 {
+		for iNdEx := len(m.MemoryInBytesByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MemoryInBytesByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x42
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for iNdEx := len(m.LabelValueCountByLabelName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.LabelValueCountByLabelName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x3a
+		}
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for iNdEx := len(m.SeriesCountByMetricName) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.SeriesCountByMetricName[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x32
 		}
 	}
+	if m.MaxTime != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MaxTime))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.MinTime != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.MinTime))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.ChunkCount != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.ChunkCount))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.NumLabelPairs != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumLabelPairs))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.NumSeries != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.NumSeries))
+		i--
+		dAtA[i] = 0x8
+	}
 	return len(dAtA) - i, nil
 }
 
-func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
+func (m *TSDBStatistic) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
 	n, err := m.MarshalToSizedBuffer(dAtA[:size])
@@ -3941,24 +5046,194 @@ func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
 	return dAtA[:n], nil
 }
 
-func (m *MetricsForLabelMatchersRequest) MarshalTo(dAtA []byte) (int, error) {
+func (m *TSDBStatistic) MarshalTo(dAtA []byte) (int, error) {
 	size := m.Size()
 	return m.MarshalToSizedBuffer(dAtA[:size])
 }
 
-func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+func (m *TSDBStatistic) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	i := len(dAtA)
 	_ = i
 	var l int
 	_ = l
-	if len(m.MatchersSet) > 0 {
-		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
+	if m.Value != 0 {
+		i = encodeVarintIngester(dAtA, i, uint64(m.Value))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *PrewarmTenantRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PrewarmTenantRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PrewarmTenantRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	return len(dAtA) - i, nil
+}
+
+func (m *PrewarmTenantResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *PrewarmTenantResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PrewarmTenantResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Created {
+		i--
+		if m.Created {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UserIDStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UserIDStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UserIDStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.Data != nil {
+		{
+			size, err := m.Data.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.UserId) > 0 {
+		i -= len(m.UserId)
+		copy(dAtA[i:], m.UserId)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.UserId)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *UsersStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *UsersStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *UsersStatsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Stats) > 0 {
+		for iNdEx := len(m.Stats) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Stats[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MetricsForLabelMatchersRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MetricsForLabelMatchersRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MetricsForLabelMatchersRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.MatchersSet) > 0 {
+		for iNdEx := len(m.MatchersSet) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.MatchersSet[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
 				i = encodeVarintIngester(dAtA, i, uint64(size))
 			}
 			i--
@@ -4352,53 +5627,183 @@ func (m *TimeSeriesFile) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	return len(dAtA) - i, nil
 }
 
-func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
-	offset -= sovIngester(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *PushStreamRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return dAtA[:n], nil
 }
-func (m *ReadRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
+
+func (m *PushStreamRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushStreamRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Queries) > 0 {
-		for _, e := range m.Queries {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
+	if len(m.Metadata) > 0 {
+		for iNdEx := len(m.Metadata) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Metadata[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintIngester(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
 		}
 	}
-	return n
+	if m.Request != nil {
+		{
+			size, err := m.Request.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
 }
 
-func (m *ReadResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *PushStreamMetadata) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *PushStreamMetadata) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushStreamMetadata) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	if len(m.Results) > 0 {
-		for _, e := range m.Results {
-			l = e.Size()
-			n += 1 + l + sovIngester(uint64(l))
+	if len(m.Values) > 0 {
+		for iNdEx := len(m.Values) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Values[iNdEx])
+			copy(dAtA[i:], m.Values[iNdEx])
+			i = encodeVarintIngester(dAtA, i, uint64(len(m.Values[iNdEx])))
+			i--
+			dAtA[i] = 0x12
 		}
 	}
-	return n
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
 }
 
-func (m *QueryResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *PushStreamResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
+	return dAtA[:n], nil
+}
+
+func (m *PushStreamResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *PushStreamResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if len(m.Status) > 0 {
+		i -= len(m.Status)
+		copy(dAtA[i:], m.Status)
+		i = encodeVarintIngester(dAtA, i, uint64(len(m.Status)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Response != nil {
+		{
+			size, err := m.Response.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintIngester(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintIngester(dAtA []byte, offset int, v uint64) int {
+	offset -= sovIngester(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ReadRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Queries) > 0 {
+		for _, e := range m.Queries {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ReadResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Results) > 0 {
+		for _, e := range m.Results {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *QueryResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
 	if len(m.Timeseries) > 0 {
 		for _, e := range m.Timeseries {
 			l = e.Size()
@@ -4608,6 +6013,112 @@ func (m *UserStatsResponse) Size() (n int) {
 	if m.ActiveSeries != 0 {
 		n += 1 + sovIngester(uint64(m.ActiveSeries))
 	}
+	if m.ActiveNativeHistogramSeries != 0 {
+		n += 1 + sovIngester(uint64(m.ActiveNativeHistogramSeries))
+	}
+	if m.ActiveNativeHistogramBuckets != 0 {
+		n += 1 + sovIngester(uint64(m.ActiveNativeHistogramBuckets))
+	}
+	if m.ActiveNativeHistogramAverageSchema != 0 {
+		n += 9
+	}
+	return n
+}
+
+func (m *TSDBStatusRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Limit != 0 {
+		n += 1 + sovIngester(uint64(m.Limit))
+	}
+	return n
+}
+
+func (m *TSDBStatusResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.NumSeries != 0 {
+		n += 1 + sovIngester(uint64(m.NumSeries))
+	}
+	if m.NumLabelPairs != 0 {
+		n += 1 + sovIngester(uint64(m.NumLabelPairs))
+	}
+	if m.ChunkCount != 0 {
+		n += 1 + sovIngester(uint64(m.ChunkCount))
+	}
+	if m.MinTime != 0 {
+		n += 1 + sovIngester(uint64(m.MinTime))
+	}
+	if m.MaxTime != 0 {
+		n += 1 + sovIngester(uint64(m.MaxTime))
+	}
+	if len(m.SeriesCountByMetricName) > 0 {
+		for _, e := range m.SeriesCountByMetricName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.LabelValueCountByLabelName) > 0 {
+		for _, e := range m.LabelValueCountByLabelName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.MemoryInBytesByLabelName) > 0 {
+		for _, e := range m.MemoryInBytesByLabelName {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	if len(m.SeriesCountByLabelValuePair) > 0 {
+		for _, e := range m.SeriesCountByLabelValuePair {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *TSDBStatistic) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if m.Value != 0 {
+		n += 1 + sovIngester(uint64(m.Value))
+	}
+	return n
+}
+
+func (m *PrewarmTenantRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	return n
+}
+
+func (m *PrewarmTenantResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Created {
+		n += 2
+	}
 	return n
 }
 
@@ -4829,6 +6340,61 @@ func (m *TimeSeriesFile) Size() (n int) {
 	return n
 }
 
+func (m *PushStreamRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Request != nil {
+		l = m.Request.Size()
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if len(m.Metadata) > 0 {
+		for _, e := range m.Metadata {
+			l = e.Size()
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *PushStreamMetadata) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	if len(m.Values) > 0 {
+		for _, s := range m.Values {
+			l = len(s)
+			n += 1 + l + sovIngester(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *PushStreamResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Response != nil {
+		l = m.Response.Size()
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	l = len(m.Status)
+	if l > 0 {
+		n += 1 + l + sovIngester(uint64(l))
+	}
+	return n
+}
+
 func sovIngester(x uint64) (n int) {
 	return (math_bits.Len64(x|1) + 6) / 7
 }
@@ -5027,6 +6593,87 @@ func (this *UserStatsResponse) String() string {
 		`ApiIngestionRate:` + fmt.Sprintf("%v", this.ApiIngestionRate) + `,`,
 		`RuleIngestionRate:` + fmt.Sprintf("%v", this.RuleIngestionRate) + `,`,
 		`ActiveSeries:` + fmt.Sprintf("%v", this.ActiveSeries) + `,`,
+		`ActiveNativeHistogramSeries:` + fmt.Sprintf("%v", this.ActiveNativeHistogramSeries) + `,`,
+		`ActiveNativeHistogramBuckets:` + fmt.Sprintf("%v", this.ActiveNativeHistogramBuckets) + `,`,
+		`ActiveNativeHistogramAverageSchema:` + fmt.Sprintf("%v", this.ActiveNativeHistogramAverageSchema) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TSDBStatusRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TSDBStatusRequest{`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TSDBStatusResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	repeatedStringForSeriesCountByMetricName := "[]TSDBStatistic{"
+	for _, f := range this.SeriesCountByMetricName {
+		repeatedStringForSeriesCountByMetricName += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeriesCountByMetricName += "}"
+	repeatedStringForLabelValueCountByLabelName := "[]TSDBStatistic{"
+	for _, f := range this.LabelValueCountByLabelName {
+		repeatedStringForLabelValueCountByLabelName += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForLabelValueCountByLabelName += "}"
+	repeatedStringForMemoryInBytesByLabelName := "[]TSDBStatistic{"
+	for _, f := range this.MemoryInBytesByLabelName {
+		repeatedStringForMemoryInBytesByLabelName += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForMemoryInBytesByLabelName += "}"
+	repeatedStringForSeriesCountByLabelValuePair := "[]TSDBStatistic{"
+	for _, f := range this.SeriesCountByLabelValuePair {
+		repeatedStringForSeriesCountByLabelValuePair += strings.Replace(strings.Replace(f.String(), "TSDBStatistic", "TSDBStatistic", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForSeriesCountByLabelValuePair += "}"
+	s := strings.Join([]string{`&TSDBStatusResponse{`,
+		`NumSeries:` + fmt.Sprintf("%v", this.NumSeries) + `,`,
+		`NumLabelPairs:` + fmt.Sprintf("%v", this.NumLabelPairs) + `,`,
+		`ChunkCount:` + fmt.Sprintf("%v", this.ChunkCount) + `,`,
+		`MinTime:` + fmt.Sprintf("%v", this.MinTime) + `,`,
+		`MaxTime:` + fmt.Sprintf("%v", this.MaxTime) + `,`,
+		`SeriesCountByMetricName:` + repeatedStringForSeriesCountByMetricName + `,`,
+		`LabelValueCountByLabelName:` + repeatedStringForLabelValueCountByLabelName + `,`,
+		`MemoryInBytesByLabelName:` + repeatedStringForMemoryInBytesByLabelName + `,`,
+		`SeriesCountByLabelValuePair:` + repeatedStringForSeriesCountByLabelValuePair + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *TSDBStatistic) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&TSDBStatistic{`,
+		`Name:` + fmt.Sprintf("%v", this.Name) + `,`,
+		`Value:` + fmt.Sprintf("%v", this.Value) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *PrewarmTenantRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&PrewarmTenantRequest{`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *PrewarmTenantResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&PrewarmTenantResponse{`,
+		`Created:` + fmt.Sprintf("%v", this.Created) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -5199,23 +6846,61 @@ func (this *TimeSeriesFile) String() string {
 	}, "")
 	return s
 }
-func valueToStringIngester(v interface{}) string {
-	rv := reflect.ValueOf(v)
-	if rv.IsNil() {
+func (this *PushStreamRequest) String() string {
+	if this == nil {
 		return "nil"
 	}
-	pv := reflect.Indirect(rv).Interface()
-	return fmt.Sprintf("*%v", pv)
+	repeatedStringForMetadata := "[]PushStreamMetadata{"
+	for _, f := range this.Metadata {
+		repeatedStringForMetadata += strings.Replace(strings.Replace(f.String(), "PushStreamMetadata", "PushStreamMetadata", 1), `&`, ``, 1) + ","
+	}
+	repeatedStringForMetadata += "}"
+	s := strings.Join([]string{`&PushStreamRequest{`,
+		`Request:` + strings.Replace(fmt.Sprintf("%v", this.Request), "WriteRequest", "cortexpb.WriteRequest", 1) + `,`,
+		`Metadata:` + repeatedStringForMetadata + `,`,
+		`}`,
+	}, "")
+	return s
 }
-func (m *ReadRequest) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIngester
+func (this *PushStreamMetadata) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&PushStreamMetadata{`,
+		`Key:` + fmt.Sprintf("%v", this.Key) + `,`,
+		`Values:` + fmt.Sprintf("%v", this.Values) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *PushStreamResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&PushStreamResponse{`,
+		`Response:` + strings.Replace(fmt.Sprintf("%v", this.Response), "WriteResponse", "cortexpb.WriteResponse", 1) + `,`,
+		`Status:` + fmt.Sprintf("%v", this.Status) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func valueToStringIngester(v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.IsNil() {
+		return "nil"
+	}
+	pv := reflect.Indirect(rv).Interface()
+	return fmt.Sprintf("*%v", pv)
+}
+func (m *ReadRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
 			}
 			if iNdEx >= l {
 				return io.ErrUnexpectedEOF
@@ -6635,64 +8320,11 @@ func (m *UserStatsResponse) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if skippy < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIngester
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: UserIDStatsResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: UserIDStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ActiveNativeHistogramSeries", wireType)
 			}
-			var stringLen uint64
+			m.ActiveNativeHistogramSeries = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6702,29 +8334,16 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.ActiveNativeHistogramSeries |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.UserId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ActiveNativeHistogramBuckets", wireType)
 			}
-			var msglen int
+			m.ActiveNativeHistogramBuckets = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6734,28 +8353,22 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.ActiveNativeHistogramBuckets |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
+		case 8:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ActiveNativeHistogramAverageSchema", wireType)
 			}
-			if postIndex > l {
+			var v uint64
+			if (iNdEx + 8) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.Data == nil {
-				m.Data = &UserStatsResponse{}
-			}
-			if err := m.Data.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
+			v = uint64(encoding_binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.ActiveNativeHistogramAverageSchema = float64(math.Float64frombits(v))
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -6780,7 +8393,7 @@ func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
+func (m *TSDBStatusRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6803,17 +8416,17 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: UsersStatsResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: TSDBStatusRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: UsersStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TSDBStatusRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
 			}
-			var msglen int
+			m.Limit = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6823,26 +8436,11 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				m.Limit |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Stats = append(m.Stats, &UserIDStatsResponse{})
-			if err := m.Stats[len(m.Stats)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -6867,7 +8465,7 @@ func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
+func (m *TSDBStatusResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -6890,17 +8488,17 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: TSDBStatusResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TSDBStatusResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field NumSeries", wireType)
 			}
-			m.StartTimestampMs = 0
+			m.NumSeries = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6910,16 +8508,16 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
+				m.NumSeries |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field NumLabelPairs", wireType)
 			}
-			m.EndTimestampMs = 0
+			m.NumLabelPairs = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -6929,14 +8527,71 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
+				m.NumLabelPairs |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkCount", wireType)
+			}
+			m.ChunkCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunkCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinTime", wireType)
+			}
+			m.MinTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxTime", wireType)
+			}
+			m.MaxTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field MatchersSet", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByMetricName", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -6963,67 +8618,82 @@ func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.MatchersSet = append(m.MatchersSet, &LabelMatchers{})
-			if err := m.MatchersSet[len(m.MatchersSet)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.SeriesCountByMetricName = append(m.SeriesCountByMetricName, TSDBStatistic{})
+			if err := m.SeriesCountByMetricName[len(m.SeriesCountByMetricName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipIngester(dAtA[iNdEx:])
-			if err != nil {
-				return err
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LabelValueCountByLabelName", wireType)
 			}
-			if skippy < 0 {
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			if (iNdEx + skippy) < 0 {
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
-			if (iNdEx + skippy) > l {
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowIngester
+			m.LabelValueCountByLabelName = append(m.LabelValueCountByLabelName, TSDBStatistic{})
+			if err := m.LabelValueCountByLabelName[len(m.LabelValueCountByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			if iNdEx >= l {
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MemoryInBytesByLabelName", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
+			m.MemoryInBytesByLabelName = append(m.MemoryInBytesByLabelName, TSDBStatistic{})
+			if err := m.MemoryInBytesByLabelName[len(m.MemoryInBytesByLabelName)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+			iNdEx = postIndex
+		case 9:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SeriesCountByLabelValuePair", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -7050,8 +8720,8 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Metric = append(m.Metric, &cortexpb.Metric{})
-			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			m.SeriesCountByLabelValuePair = append(m.SeriesCountByLabelValuePair, TSDBStatistic{})
+			if err := m.SeriesCountByLabelValuePair[len(m.SeriesCountByLabelValuePair)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -7079,7 +8749,7 @@ func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
+func (m *TSDBStatistic) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7102,17 +8772,17 @@ func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: TSDBStatistic: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TSDBStatistic: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7122,26 +8792,43 @@ func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Metric = append(m.Metric, &cortexpb.Metric{})
-			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			m.Value = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Value |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -7166,7 +8853,7 @@ func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
+func (m *PrewarmTenantRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7189,10 +8876,10 @@ func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsMetadataRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: PrewarmTenantRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsMetadataRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PrewarmTenantRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		default:
@@ -7219,7 +8906,7 @@ func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
+func (m *PrewarmTenantResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7242,17 +8929,17 @@ func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: MetricsMetadataResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: PrewarmTenantResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: MetricsMetadataResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PrewarmTenantResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Created", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7262,26 +8949,12 @@ func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + msglen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Metadata = append(m.Metadata, &cortexpb.MetricMetadata{})
-			if err := m.Metadata[len(m.Metadata)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
+			m.Created = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipIngester(dAtA[iNdEx:])
@@ -7306,7 +8979,7 @@ func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
+func (m *UserIDStatsResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7329,15 +9002,15 @@ func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: TimeSeriesChunk: wiretype end group for non-group")
+			return fmt.Errorf("proto: UserIDStatsResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: TimeSeriesChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: UserIDStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FromIngesterId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -7365,13 +9038,13 @@ func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.FromIngesterId = string(dAtA[iNdEx:postIndex])
+			m.UserId = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7381,29 +9054,1047 @@ func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.UserId = string(dAtA[iNdEx:postIndex])
+			if m.Data == nil {
+				m.Data = &UserStatsResponse{}
+			}
+			if err := m.Data.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
 			iNdEx = postIndex
-		case 3:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *UsersStatsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: UsersStatsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: UsersStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Stats", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Stats = append(m.Stats, &UserIDStatsResponse{})
+			if err := m.Stats[len(m.Stats)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetricsForLabelMatchersRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricsForLabelMatchersRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MatchersSet", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MatchersSet = append(m.MatchersSet, &LabelMatchers{})
+			if err := m.MatchersSet[len(m.MatchersSet)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetricsForLabelMatchersResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricsForLabelMatchersResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metric = append(m.Metric, &cortexpb.Metric{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetricsForLabelMatchersStreamResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricsForLabelMatchersStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metric", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metric = append(m.Metric, &cortexpb.Metric{})
+			if err := m.Metric[len(m.Metric)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetricsMetadataRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricsMetadataRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricsMetadataRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MetricsMetadataResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MetricsMetadataResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MetricsMetadataResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metadata = append(m.Metadata, &cortexpb.MetricMetadata{})
+			if err := m.Metadata[len(m.Metadata)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: TimeSeriesChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: TimeSeriesChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromIngesterId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FromIngesterId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = append(m.Labels, github_com_cortexproject_cortex_pkg_cortexpb.LabelAdapter{})
+			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Chunks = append(m.Chunks, Chunk{})
+			if err := m.Chunks[len(m.Chunks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Chunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Chunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Chunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			}
+			m.StartTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StartTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			}
+			m.EndTimestampMs = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.EndTimestampMs |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Encoding", wireType)
+			}
+			m.Encoding = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Encoding |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelMatchers) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelMatchers: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelMatchers: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &LabelMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipIngester(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowIngester
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: LabelMatcher: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: LabelMatcher: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
+			}
+			m.Type = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Type |= MatchType(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7413,31 +10104,29 @@ func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Labels = append(m.Labels, github_com_cortexproject_cortex_pkg_cortexpb.LabelAdapter{})
-			if err := m.Labels[len(m.Labels)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 4:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7447,25 +10136,23 @@ func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Chunks = append(m.Chunks, Chunk{})
-			if err := m.Chunks[len(m.Chunks)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
+			m.Value = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -7491,7 +10178,7 @@ func (m *TimeSeriesChunk) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Chunk) Unmarshal(dAtA []byte) error {
+func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7514,17 +10201,17 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Chunk: wiretype end group for non-group")
+			return fmt.Errorf("proto: TimeSeriesFile: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Chunk: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: TimeSeriesFile: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field StartTimestampMs", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FromIngesterId", wireType)
 			}
-			m.StartTimestampMs = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7534,16 +10221,29 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.StartTimestampMs |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.FromIngesterId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndTimestampMs", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
 			}
-			m.EndTimestampMs = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7553,16 +10253,29 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.EndTimestampMs |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.UserId = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Encoding", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filename", wireType)
 			}
-			m.Encoding = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7572,11 +10285,24 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Encoding |= int32(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filename = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 4:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
@@ -7635,7 +10361,7 @@ func (m *Chunk) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelMatchers) Unmarshal(dAtA []byte) error {
+func (m *PushStreamRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7658,15 +10384,15 @@ func (m *LabelMatchers) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelMatchers: wiretype end group for non-group")
+			return fmt.Errorf("proto: PushStreamRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelMatchers: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PushStreamRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Request", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -7693,8 +10419,44 @@ func (m *LabelMatchers) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Matchers = append(m.Matchers, &LabelMatcher{})
-			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+			if m.Request == nil {
+				m.Request = &cortexpb.WriteRequest{}
+			}
+			if err := m.Request.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowIngester
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthIngester
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthIngester
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Metadata = append(m.Metadata, PushStreamMetadata{})
+			if err := m.Metadata[len(m.Metadata)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
 			iNdEx = postIndex
@@ -7722,7 +10484,7 @@ func (m *LabelMatchers) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
+func (m *PushStreamMetadata) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7745,34 +10507,15 @@ func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: LabelMatcher: wiretype end group for non-group")
+			return fmt.Errorf("proto: PushStreamMetadata: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: LabelMatcher: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PushStreamMetadata: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
-			}
-			m.Type = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Type |= MatchType(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -7800,11 +10543,11 @@ func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Name = string(dAtA[iNdEx:postIndex])
+			m.Key = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 3:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Values", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -7832,7 +10575,7 @@ func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Value = string(dAtA[iNdEx:postIndex])
+			m.Values = append(m.Values, string(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -7858,7 +10601,7 @@ func (m *LabelMatcher) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
+func (m *PushStreamResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -7881,49 +10624,17 @@ func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: TimeSeriesFile: wiretype end group for non-group")
+			return fmt.Errorf("proto: PushStreamResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: TimeSeriesFile: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: PushStreamResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FromIngesterId", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.FromIngesterId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 2:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field UserId", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Response", wireType)
 			}
-			var stringLen uint64
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowIngester
@@ -7933,59 +10644,31 @@ func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthIngester
 			}
-			postIndex := iNdEx + intStringLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthIngester
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.UserId = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
-		case 3:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Filename", wireType)
-			}
-			var stringLen uint64
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowIngester
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthIngester
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthIngester
+			if m.Response == nil {
+				m.Response = &cortexpb.WriteResponse{}
 			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
+			if err := m.Response.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
-			m.Filename = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 4:
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
 			}
 			var byteLen int
 			for shift := uint(0); ; shift += 7 {
@@ -8012,9 +10695,9 @@ func (m *TimeSeriesFile) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
+			m.Status = append(m.Status[:0], dAtA[iNdEx:postIndex]...)
+			if m.Status == nil {
+				m.Status = []byte{}
 			}
 			iNdEx = postIndex
 		default:
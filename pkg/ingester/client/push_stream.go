@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/opentracing/opentracing-go"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+	spb "google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
+)
+
+// maxIdlePushStreamsPerTenant is the max number of idle push streams kept open per tenant by
+// an ingester client. The additional streams are closed once their push is done.
+const maxIdlePushStreamsPerTenant = 16
+
+var ingesterClientPushStreamFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "cortex",
+	Name:      "ingester_client_push_stream_fallbacks_total",
+	Help:      "Number of pushes sent with a unary call because they couldn't be sent on a push stream.",
+}, []string{"reason"})
+
+// errPushStreamUnavailable is returned when the push couldn't be sent on a stream, and should
+// be sent with a unary call instead.
+var errPushStreamUnavailable = errors.New("push stream unavailable")
+
+// pushStreams sends the pushes to an ingester on long-lived PushStream streams, kept per tenant
+// because the tenant is propagated in the metadata of the streams. Each stream is used by one
+// push at a time, and the metadata of each push is sent along with it.
+type pushStreams struct {
+	client IngesterClient
+
+	// signWriteRequests is set when the pushes are signed, like the unary pushes.
+	signWriteRequests bool
+
+	// The streams outlive the pushes, so they're opened with the context of the client.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// unsupported is set when the ingester doesn't implement the PushStream.
+	unsupported atomic.Bool
+
+	mtx  sync.Mutex
+	idle map[string][]*pushStream
+}
+
+type pushStream struct {
+	stream Ingester_PushStreamClient
+	cancel context.CancelFunc
+}
+
+func newPushStreams(client IngesterClient, signWriteRequests bool) *pushStreams {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &pushStreams{
+		client:            client,
+		signWriteRequests: signWriteRequests,
+		ctx:               ctx,
+		cancel:            cancel,
+		idle:              map[string][]*pushStream{},
+	}
+}
+
+// push sends the push on a stream of the tenant, and waits for its response. It returns
+// errPushStreamUnavailable if the push wasn't sent, or may not have been processed because the
+// stream was broken before the push.
+func (p *pushStreams) push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	if p.unsupported.Load() {
+		return nil, errPushStreamUnavailable
+	}
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, errPushStreamUnavailable
+	}
+	md, err := p.pushMetadata(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s, reused, err := p.get(userID)
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			p.unsupported.Store(true)
+		}
+		ingesterClientPushStreamFallbacks.WithLabelValues("open_failed").Inc()
+		return nil, errPushStreamUnavailable
+	}
+
+	// The stream is closed if the push is canceled or times out, which cancels the push on the
+	// ingester too.
+	stop := context.AfterFunc(ctx, s.cancel)
+
+	if err := s.stream.Send(&PushStreamRequest{Request: req, Metadata: md}); err != nil {
+		stop()
+		s.cancel()
+		if ctx.Err() != nil {
+			return nil, status.FromContextError(ctx.Err()).Err()
+		}
+		ingesterClientPushStreamFallbacks.WithLabelValues("send_failed").Inc()
+		return nil, errPushStreamUnavailable
+	}
+
+	resp, err := s.stream.Recv()
+	if !stop() {
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+	if err != nil {
+		// The errors of the pushes are returned in the responses, so the stream is broken.
+		s.cancel()
+		if status.Code(err) == codes.Unimplemented {
+			// The streams are opened lazily, so an ingester which doesn't implement them only
+			// fails the first push, without having processed it.
+			p.unsupported.Store(true)
+			ingesterClientPushStreamFallbacks.WithLabelValues("open_failed").Inc()
+			return nil, errPushStreamUnavailable
+		}
+		if reused && isBrokenStreamError(err) {
+			ingesterClientPushStreamFallbacks.WithLabelValues("stream_broken").Inc()
+			return nil, errPushStreamUnavailable
+		}
+		return nil, err
+	}
+
+	p.put(userID, s)
+	if len(resp.Status) > 0 {
+		return nil, pushStreamError(resp.Status)
+	}
+	return resp.Response, nil
+}
+
+// pushMetadata returns the gRPC metadata to send along with the push, since the metadata of the
+// stream is the one of the push which opened it: the outgoing metadata of the push (e.g. its source
+// IPs), its signature and its trace context.
+func (p *pushStreams) pushMetadata(ctx context.Context, req *cortexpb.WriteRequest) ([]PushStreamMetadata, error) {
+	if p.signWriteRequests {
+		var err error
+		if ctx, err = grpcclient.SignRequestToOutgoingContext(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	if span := opentracing.SpanFromContext(ctx); span != nil {
+		// The trace context is optional, so the push isn't failed if it can't be injected.
+		_ = span.Tracer().Inject(span.Context(), opentracing.HTTPHeaders, metadataTextMap(md))
+	}
+
+	out := make([]PushStreamMetadata, 0, len(md))
+	for key, values := range md {
+		out = append(out, PushStreamMetadata{Key: key, Values: values})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out, nil
+}
+
+// StartPushStreamSpan returns the span of a push received on a stream, as a child of the trace
+// context sent with the push, and the context of the push holding the span along with the incoming
+// metadata of the stream and the metadata sent with the push.
+func StartPushStreamSpan(ctx context.Context, req *PushStreamRequest) (opentracing.Span, context.Context) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	md = md.Copy()
+	for _, m := range req.Metadata {
+		md.Set(m.Key, m.Values...)
+	}
+	ctx = metadata.NewIncomingContext(ctx, md)
+
+	tracer := opentracing.GlobalTracer()
+	if parent, err := tracer.Extract(opentracing.HTTPHeaders, metadataTextMap(md)); err == nil {
+		span := tracer.StartSpan("Ingester.PushStream.Push", opentracing.ChildOf(parent))
+		return span, opentracing.ContextWithSpan(ctx, span)
+	}
+	return opentracing.StartSpanFromContext(ctx, "Ingester.PushStream.Push")
+}
+
+// NewPushStreamResponse returns the response to a push received on a stream, holding the
+// status of the error of the push if it failed.
+func NewPushStreamResponse(resp *cortexpb.WriteResponse, err error) (*PushStreamResponse, error) {
+	if err == nil {
+		return &PushStreamResponse{Response: resp}, nil
+	}
+
+	st, err := proto.Marshal(status.Convert(err).Proto())
+	if err != nil {
+		return nil, err
+	}
+	return &PushStreamResponse{Status: st}, nil
+}
+
+// pushStreamError returns the error of the push from its status, like it's returned by a
+// unary call.
+func pushStreamError(b []byte) error {
+	st := &spb.Status{}
+	if err := proto.Unmarshal(b, st); err != nil {
+		return status.Errorf(codes.Internal, "failed to decode the push status: %v", err)
+	}
+	return status.ErrorProto(st)
+}
+
+// metadataTextMap carries the trace context in the gRPC metadata.
+type metadataTextMap metadata.MD
+
+func (m metadataTextMap) Set(key, val string) {
+	key = strings.ToLower(key)
+	m[key] = append(m[key], val)
+}
+
+func (m metadataTextMap) ForeachKey(handler func(key, val string) error) error {
+	for key, values := range m {
+		for _, val := range values {
+			if err := handler(key, val); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// get returns an idle stream of the tenant, or opens a new one.
+func (p *pushStreams) get(userID string) (_ *pushStream, reused bool, _ error) {
+	p.mtx.Lock()
+	if streams := p.idle[userID]; len(streams) > 0 {
+		s := streams[len(streams)-1]
+		p.idle[userID] = streams[:len(streams)-1]
+		p.mtx.Unlock()
+		return s, true, nil
+	}
+	p.mtx.Unlock()
+
+	ctx, cancel := context.WithCancel(user.InjectOrgID(p.ctx, userID))
+	stream, err := p.client.PushStream(ctx)
+	if err != nil {
+		cancel()
+		return nil, false, err
+	}
+	return &pushStream{stream: stream, cancel: cancel}, false, nil
+}
+
+func (p *pushStreams) put(userID string, s *pushStream) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if len(p.idle[userID]) >= maxIdlePushStreamsPerTenant || p.ctx.Err() != nil {
+		s.cancel()
+		return
+	}
+	p.idle[userID] = append(p.idle[userID], s)
+}
+
+// close closes all the streams.
+func (p *pushStreams) close() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.cancel()
+	p.idle = map[string][]*pushStream{}
+}
+
+func isBrokenStreamError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Canceled:
+		return true
+	default:
+		return false
+	}
+}
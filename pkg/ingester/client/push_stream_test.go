@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
+)
+
+type pushStreamServerMock struct {
+	UnimplementedIngesterServer
+
+	streaming bool
+	signed    bool
+	streams   atomic.Int64
+	pushes    atomic.Int64
+	unary     atomic.Int64
+	sourceIPs atomic.String
+}
+
+func (s *pushStreamServerMock) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	s.unary.Inc()
+	return s.push(ctx, req)
+}
+
+func (s *pushStreamServerMock) PushStream(stream Ingester_PushStreamServer) error {
+	if !s.streaming {
+		return s.UnimplementedIngesterServer.PushStream(stream)
+	}
+
+	s.streams.Inc()
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		span, ctx := StartPushStreamSpan(stream.Context(), req)
+		resp, err := NewPushStreamResponse(s.push(ctx, req.Request))
+		span.Finish()
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *pushStreamServerMock) push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	s.pushes.Inc()
+	if userID, err := user.ExtractOrgID(ctx); err != nil || userID != "user-1" {
+		return nil, httpgrpc.Errorf(http.StatusUnauthorized, "unexpected tenant")
+	}
+	if s.signed {
+		if err := grpcclient.VerifyRequestSignature(ctx, req); err != nil {
+			return nil, err
+		}
+	}
+	s.sourceIPs.Store(util.GetSourceIPsFromIncomingCtx(ctx))
+	if len(req.Timeseries) == 0 {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "no series")
+	}
+	return &cortexpb.WriteResponse{}, nil
+}
+
+func newPushStreamTestClient(t *testing.T, server IngesterServer) *closableHealthAndIngesterClient {
+	listen := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer(grpc.StreamInterceptor(middleware.StreamServerUserHeaderInterceptor), grpc.UnaryInterceptor(middleware.ServerUserHeaderInterceptor))
+	RegisterIngesterServer(grpcServer, server)
+	go func() {
+		_ = grpcServer.Serve(listen)
+	}()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough://bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listen.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(middleware.ClientUserHeaderInterceptor),
+		grpc.WithStreamInterceptor(middleware.StreamClientUserHeaderInterceptor),
+	)
+	require.NoError(t, err)
+
+	c := &closableHealthAndIngesterClient{
+		IngesterClient:       NewIngesterClient(conn),
+		conn:                 conn,
+		addr:                 "bufnet",
+		inflightPushRequests: ingesterClientInflightPushRequests,
+	}
+	c.pushStreams = newPushStreams(c.IngesterClient, true)
+	t.Cleanup(func() { _ = c.Close() })
+	return c
+}
+
+func TestClosableHealthAndIngesterClient_PushStream(t *testing.T) {
+	server := &pushStreamServerMock{streaming: true, signed: true}
+	c := newPushStreamTestClient(t, server)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	req := cortexpb.PreallocWriteRequest{WriteRequest: cortexpb.WriteRequest{Timeseries: []cortexpb.PreallocTimeseries{{
+		TimeSeries: &cortexpb.TimeSeries{
+			Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: "foo"}},
+			Samples: []cortexpb.Sample{{Value: 1, TimestampMs: 1}},
+		},
+	}}}}
+
+	// The pushes are sent on the same stream, each with its own signature and source IPs.
+	for _, sourceIPs := range []string{"1.1.1.1", "2.2.2.2", "3.3.3.3"} {
+		_, err := c.PushPreAlloc(util.AddSourceIPsToOutgoingContext(ctx, sourceIPs), &req)
+		require.NoError(t, err)
+		assert.Equal(t, sourceIPs, server.sourceIPs.Load())
+	}
+	assert.Equal(t, int64(1), server.streams.Load())
+	assert.Equal(t, int64(3), server.pushes.Load())
+	assert.Equal(t, int64(0), server.unary.Load())
+
+	// The error of a push is returned like for a unary call, without ending the stream.
+	_, err := c.PushPreAlloc(ctx, &cortexpb.PreallocWriteRequest{})
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+
+	_, err = c.PushPreAlloc(ctx, &req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), server.streams.Load())
+	assert.Equal(t, int64(0), server.unary.Load())
+
+	// The pushes which aren't signed are rejected.
+	c.pushStreams.signWriteRequests = false
+	_, err = c.PushPreAlloc(ctx, &req)
+	require.ErrorContains(t, err, grpcclient.ErrSignatureNotPresent.Error())
+	assert.Equal(t, int64(1), server.streams.Load())
+
+	// A canceled push closes its stream.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = c.PushPreAlloc(canceledCtx, &req)
+	require.Error(t, err)
+}
+
+func TestClosableHealthAndIngesterClient_PushStream_FallbackToUnary(t *testing.T) {
+	server := &pushStreamServerMock{streaming: false}
+	c := newPushStreamTestClient(t, server)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	req := cortexpb.WriteRequest{Timeseries: []cortexpb.PreallocTimeseries{{
+		TimeSeries: &cortexpb.TimeSeries{
+			Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: "foo"}},
+			Samples: []cortexpb.Sample{{Value: 1, TimestampMs: 1}},
+		},
+	}}}
+
+	for i := 0; i < 2; i++ {
+		_, err := c.Push(ctx, &req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(2), server.unary.Load())
+	assert.True(t, c.pushStreams.unsupported.Load())
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/tsdb/index"
 	"github.com/prometheus/prometheus/tsdb/wlog"
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
@@ -53,6 +54,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	"github.com/cortexproject/cortex/pkg/util/extract"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
 	logutil "github.com/cortexproject/cortex/pkg/util/log"
 	util_math "github.com/cortexproject/cortex/pkg/util/math"
 	"github.com/cortexproject/cortex/pkg/util/services"
@@ -74,6 +76,9 @@ const (
 	// Jitter applied to the idle timeout to prevent compaction in all ingesters concurrently.
 	compactionIdleTimeoutJitter = 0.25
 
+	// Period at which to check whether the tenants with a custom head compaction interval are due.
+	headCompactionIntervalOverridesCheckPeriod = 15 * time.Second
+
 	instanceIngestionRateTickInterval = time.Second
 
 	// Number of timeseries to return in each batch of a QueryStream.
@@ -89,11 +94,20 @@ const (
 
 	// Period at which we should reset the max inflight query requests counter.
 	maxInflightRequestResetPeriod = 1 * time.Minute
+
+	// Default number of items returned for each TSDB status statistic, like Prometheus.
+	defaultTSDBStatusLimit = 10
+
+	// Number of buckets the series hashes are split into to pick the series rejected while
+	// gradually enforcing the per-user series limit.
+	seriesLimitRejectionBuckets = 1000
 )
 
 var (
 	errExemplarRef      = errors.New("exemplars not ingested because series not already present")
-	errIngesterStopping = errors.New("ingester stopping")
+	errIngesterStopping = status.Error(codes.Unavailable, "ingester stopping")
+
+	errInvalidWALReplayReadyPercentage = errors.New("the WAL replay ready percentage must be greater than 0 and lower than or equal to 100")
 )
 
 // Config for an Ingester.
@@ -110,6 +124,12 @@ type Config struct {
 	ActiveSeriesMetricsUpdatePeriod time.Duration `yaml:"active_series_metrics_update_period"`
 	ActiveSeriesMetricsIdleTimeout  time.Duration `yaml:"active_series_metrics_idle_timeout"`
 
+	SampleAgeMetricsEnabled bool `yaml:"sample_age_metrics_enabled"`
+
+	CreatedTimestampZeroIngestionEnabled bool `yaml:"created_timestamp_zero_ingestion_enabled"`
+
+	WALReplayReadyPercentage float64 `yaml:"wal_replay_ready_percentage"`
+
 	// Use blocks storage.
 	BlocksStorageConfig cortex_tsdb.BlocksStorageConfig `yaml:"-"`
 
@@ -120,6 +140,12 @@ type Config struct {
 	// to accurately apply global limits.
 	DistributorShardingStrategy string `yaml:"-"`
 	DistributorShardByAllLabels bool   `yaml:"-"`
+	// 0 if the series are not sharded by labels.
+	DistributorShardByLabelsShardCount int `yaml:"-"`
+
+	// Injected at runtime and read from the distributor config, required to
+	// verify the signature of the pushes received on streams.
+	SignWriteRequestsEnabled bool `yaml:"-"`
 
 	// Injected at runtime and read from querier config.
 	QueryIngestersWithin time.Duration `yaml:"-"`
@@ -127,6 +153,8 @@ type Config struct {
 	DefaultLimits    InstanceLimits         `yaml:"instance_limits"`
 	InstanceLimitsFn func() *InstanceLimits `yaml:"-"`
 
+	PushCircuitBreaker PushCircuitBreakerConfig `yaml:"push_circuit_breaker"`
+
 	IgnoreSeriesLimitForMetricNames string `yaml:"ignore_series_limit_for_metric_names"`
 
 	// For testing, you can override the address and ID of this ingester.
@@ -147,12 +175,20 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.ActiveSeriesMetricsEnabled, "ingester.active-series-metrics-enabled", true, "Enable tracking of active series and export them as metrics.")
 	f.DurationVar(&cfg.ActiveSeriesMetricsUpdatePeriod, "ingester.active-series-metrics-update-period", 1*time.Minute, "How often to update active series metrics.")
 	f.DurationVar(&cfg.ActiveSeriesMetricsIdleTimeout, "ingester.active-series-metrics-idle-timeout", 10*time.Minute, "After what time a series is considered to be inactive.")
+	f.BoolVar(&cfg.SampleAgeMetricsEnabled, "ingester.sample-age-metrics-enabled", false, "Enable tracking of the age of the received samples, computed as the difference between the wall clock and the sample timestamp, and export it as a histogram per user. Useful to detect clients sending increasingly delayed data before the samples get rejected as out of bounds.")
+
+	f.BoolVar(&cfg.CreatedTimestampZeroIngestionEnabled, "ingester.created-timestamp-zero-ingestion-enabled", false, "[Experimental] Enable appending a zero sample at the created timestamp of the series received with the remote write 2.0 protocol, before their first sample, so that the counters created between two pushes aren't missed by the rate functions.")
+
+	f.Float64Var(&cfg.WALReplayReadyPercentage, "ingester.wal-replay-ready-percentage", 100, "[Experimental] Percentage of the WAL of the existing TSDBs, weighted by size, which must be replayed on startup before the ingester joins the ring and reports ready. The remaining TSDBs are opened in background, and their tenants are rejected with a retryable error until then. The progress of the replay is reported by the /ingester/wal-replay endpoint.")
 
 	f.BoolVar(&cfg.UploadCompactedBlocksEnabled, "ingester.upload-compacted-blocks-enabled", true, "Enable uploading compacted blocks.")
 	f.Float64Var(&cfg.DefaultLimits.MaxIngestionRate, "ingester.instance-limits.max-ingestion-rate", 0, "Max ingestion rate (samples/sec) that ingester will accept. This limit is per-ingester, not per-tenant. Additional push requests will be rejected. Current ingestion rate is computed as exponentially weighted moving average, updated every second. This limit only works when using blocks engine. 0 = unlimited.")
 	f.Int64Var(&cfg.DefaultLimits.MaxInMemoryTenants, "ingester.instance-limits.max-tenants", 0, "Max users that this ingester can hold. Requests from additional users will be rejected. This limit only works when using blocks engine. 0 = unlimited.")
 	f.Int64Var(&cfg.DefaultLimits.MaxInMemorySeries, "ingester.instance-limits.max-series", 0, "Max series that this ingester can hold (across all tenants). Requests to create additional series will be rejected. This limit only works when using blocks engine. 0 = unlimited.")
 	f.Int64Var(&cfg.DefaultLimits.MaxInflightPushRequests, "ingester.instance-limits.max-inflight-push-requests", 0, "Max inflight push requests that this ingester can handle (across all tenants). Additional requests will be rejected. 0 = unlimited.")
+	f.Int64Var(&cfg.DefaultLimits.MaxInflightRulePushRequests, "ingester.instance-limits.max-inflight-rule-push-requests", 0, "Max inflight push requests of samples generated by the ruler that this ingester can handle (across all tenants). When set, these requests are not accounted in -ingester.instance-limits.max-inflight-push-requests, so that rule outputs are not rejected when the ingester is overloaded by raw ingestion. 0 = push requests from the ruler share the max inflight push requests limit.")
+
+	cfg.PushCircuitBreaker.RegisterFlagsWithPrefix("ingester.push-circuit-breaker.", f)
 
 	f.StringVar(&cfg.IgnoreSeriesLimitForMetricNames, "ingester.ignore-series-limit-for-metric-names", "", "Comma-separated list of metric names, for which -ingester.max-series-per-metric and -ingester.max-global-series-per-metric limits will be ignored. Does not affect max-series-per-user or max-global-series-per-metric limits.")
 
@@ -165,6 +201,14 @@ func (cfg *Config) Validate() error {
 		return err
 	}
 
+	if cfg.WALReplayReadyPercentage <= 0 || cfg.WALReplayReadyPercentage > 100 {
+		return errInvalidWALReplayReadyPercentage
+	}
+
+	if err := cfg.PushCircuitBreaker.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -220,8 +264,21 @@ type Ingester struct {
 	ingestionRate        *util_math.EwmaRate
 	inflightPushRequests atomic.Int64
 
+	// Inflight push requests of samples generated by the ruler, when accounted separately.
+	inflightRulePushRequests atomic.Int64
+
 	inflightQueryRequests    atomic.Int64
 	maxInflightQueryRequests util_math.MaxTracker
+
+	// Circuit breaker of the push requests, nil if disabled.
+	pushCircuitBreaker *pushCircuitBreaker
+
+	// Progress of the replay of the WAL on startup.
+	walReplay *walReplayProgress
+	// Closed once the TSDBs opened in background on startup are all open, if the ingester
+	// became ready before.
+	backgroundReplayDone chan struct{}
+	backgroundReplayErr  error
 }
 
 // Shipper interface is used to have an easy way to mock it in tests.
@@ -270,6 +327,11 @@ type userTSDB struct {
 
 	instanceSeriesCount *atomic.Int64 // Shared across all userTSDB instances created by ingester.
 	instanceLimitsFn    func() *InstanceLimits
+	metrics             *ingesterMetrics // Shared across all userTSDB instances created by ingester.
+
+	// Unix timestamp (in nanoseconds) since when the user has exceeded the per-user series
+	// limit, or 0 if not exceeded. Used to gradually enforce the limit.
+	seriesLimitExceededSince atomic.Int64
 
 	stateMtx       sync.RWMutex
 	state          tsdbState
@@ -296,6 +358,19 @@ type userTSDB struct {
 	// Cached shipped blocks.
 	shippedBlocksMtx sync.Mutex
 	shippedBlocks    map[ulid.ULID]struct{}
+
+	// Target number of samples per chunk the TSDB has been opened with.
+	samplesPerChunk int
+
+	// Duration, in milliseconds, of the blocks cut from the head the TSDB has been opened with.
+	blockDuration int64
+
+	// Unix timestamp (in nanoseconds) of the last regular head compaction, used to honor the
+	// per-tenant head compaction interval.
+	lastHeadCompaction atomic.Int64
+
+	// Registry of the TSDB metrics, used to read the head stats not exposed by the TSDB.
+	tsdbPromReg prometheus.Gatherer
 }
 
 // Explicitly wrapping the tsdb.DB functions that we use.
@@ -395,7 +470,12 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 
 	// Total series limit.
 	if err := u.limiter.AssertMaxSeriesPerUser(u.userID, int(u.Head().NumSeries())); err != nil {
-		return err
+		if u.shouldRejectSeriesOverUserLimit(metric, time.Now()) {
+			return err
+		}
+	} else if u.seriesLimitExceededSince.Load() != 0 {
+		u.seriesLimitExceededSince.Store(0)
+		u.metrics.seriesLimitRejectionRatio.DeleteLabelValues(u.userID)
 	}
 
 	// Series per metric name limit.
@@ -414,6 +494,30 @@ func (u *userTSDB) PreCreation(metric labels.Labels) error {
 	return nil
 }
 
+// shouldRejectSeriesOverUserLimit returns whether the input new series should be rejected, given the
+// user has exceeded the per-user series limit. The limit is gradually enforced based on how long
+// it has been exceeded: the series to reject are picked by their hash, so that the same series
+// keep being rejected while the rejection ratio increases.
+func (u *userTSDB) shouldRejectSeriesOverUserLimit(metric labels.Labels, now time.Time) bool {
+	since := u.seriesLimitExceededSince.Load()
+	if since == 0 {
+		since = now.UnixNano()
+		if !u.seriesLimitExceededSince.CompareAndSwap(0, since) {
+			since = u.seriesLimitExceededSince.Load()
+		}
+	}
+
+	ratio := u.limiter.SeriesPerUserRejectionRatio(u.userID, now.Sub(time.Unix(0, since)))
+	u.metrics.seriesLimitRejectionRatio.WithLabelValues(u.userID).Set(ratio)
+
+	if float64(metric.Hash()%seriesLimitRejectionBuckets)/seriesLimitRejectionBuckets < ratio {
+		return true
+	}
+
+	u.metrics.seriesOverUserLimitCreated.WithLabelValues(u.userID).Inc()
+	return false
+}
+
 // PostCreation implements SeriesLifecycleCallback interface.
 func (u *userTSDB) PostCreation(metric labels.Labels) {
 	u.instanceSeriesCount.Inc()
@@ -515,6 +619,57 @@ func (u *userTSDB) getOldestUnshippedBlockTime() uint64 {
 	return oldestTs
 }
 
+// setBlocksExtensions records the metric metadata in the Thanos extensions of the meta.json of
+// the blocks not shipped to the storage yet, so that they're uploaded along with the blocks.
+func (u *userTSDB) setBlocksExtensions(logger log.Logger, metricsMetadata []cortex_tsdb.BlockMetricMetadata) error {
+	shippedBlocks := u.getCachedShippedBlocks()
+
+	for _, b := range u.Blocks() {
+		if _, ok := shippedBlocks[b.Meta().ULID]; ok {
+			continue
+		}
+
+		meta, err := metadata.ReadFromDir(b.Dir())
+		if err != nil {
+			return errors.Wrapf(err, "read meta of block %s", b.Meta().ULID)
+		}
+		if ext, err := cortex_tsdb.GetBlockExtensions(meta); err == nil && ext != nil {
+			continue
+		}
+
+		// The shipper sets the external labels in the map, so it must not be nil.
+		if meta.Thanos.Labels == nil {
+			meta.Thanos.Labels = map[string]string{}
+		}
+		meta.Thanos.Extensions = &cortex_tsdb.BlockExtensions{
+			Metadata: metricsMetadata,
+		}
+		if err := meta.WriteToDir(logger, b.Dir()); err != nil {
+			return errors.Wrapf(err, "write meta of block %s", b.Meta().ULID)
+		}
+	}
+
+	return nil
+}
+
+// headChunksCount returns the number of chunks in the TSDB head, read from the TSDB metrics.
+func (u *userTSDB) headChunksCount() uint64 {
+	if u.tsdbPromReg == nil {
+		return 0
+	}
+
+	families, err := u.tsdbPromReg.Gather()
+	if err != nil {
+		return 0
+	}
+	for _, f := range families {
+		if f.GetName() == "prometheus_tsdb_head_chunks" && len(f.GetMetric()) > 0 {
+			return uint64(f.GetMetric()[0].GetGauge().GetValue())
+		}
+	}
+	return 0
+}
+
 func (u *userTSDB) isIdle(now time.Time, idle time.Duration) bool {
 	lu := u.lastUpdate.Load()
 
@@ -655,16 +810,23 @@ func New(cfg Config, limits *validation.Overrides, registerer prometheus.Registe
 		TSDBState:     newTSDBState(bucketClient, registerer),
 		logger:        logger,
 		ingestionRate: util_math.NewEWMARate(0.2, instanceIngestionRateTickInterval),
+		walReplay:     newWALReplayProgress(cfg.WALReplayReadyPercentage),
 	}
 	i.metrics = newIngesterMetrics(registerer,
 		false,
 		cfg.ActiveSeriesMetricsEnabled,
+		cfg.SampleAgeMetricsEnabled,
 		i.getInstanceLimits,
 		i.ingestionRate,
 		&i.inflightPushRequests,
+		&i.inflightRulePushRequests,
 		&i.maxInflightQueryRequests)
 	i.validateMetrics = validation.NewValidateMetrics(registerer)
 
+	if cfg.PushCircuitBreaker.Enabled {
+		i.pushCircuitBreaker = newPushCircuitBreaker(cfg.PushCircuitBreaker, logger, registerer)
+	}
+
 	// Replace specific metrics which we can't directly track but we need to read
 	// them from the underlying system (ie. TSDB).
 	if registerer != nil {
@@ -694,8 +856,8 @@ func New(cfg Config, limits *validation.Overrides, registerer prometheus.Registe
 		i.lifecycler,
 		cfg.DistributorShardingStrategy,
 		cfg.DistributorShardByAllLabels,
-		cfg.LifecyclerConfig.RingConfig.ReplicationFactor,
-		cfg.LifecyclerConfig.RingConfig.ZoneAwarenessEnabled,
+		cfg.DistributorShardByLabelsShardCount,
+		cfg.LifecyclerConfig.RingConfig,
 		cfg.AdminLimitMessage,
 	)
 
@@ -727,22 +889,25 @@ func NewForFlusher(cfg Config, limits *validation.Overrides, registerer promethe
 		limits:    limits,
 		TSDBState: newTSDBState(bucketClient, registerer),
 		logger:    logger,
+		walReplay: newWALReplayProgress(100),
 	}
 	i.limiter = NewLimiter(
 		limits,
 		i.lifecycler,
 		cfg.DistributorShardingStrategy,
 		cfg.DistributorShardByAllLabels,
-		cfg.LifecyclerConfig.RingConfig.ReplicationFactor,
-		cfg.LifecyclerConfig.RingConfig.ZoneAwarenessEnabled,
+		cfg.DistributorShardByLabelsShardCount,
+		cfg.LifecyclerConfig.RingConfig,
 		cfg.AdminLimitMessage,
 	)
 	i.metrics = newIngesterMetrics(registerer,
+		false,
 		false,
 		false,
 		i.getInstanceLimits,
 		nil,
 		&i.inflightPushRequests,
+		&i.inflightRulePushRequests,
 		&i.maxInflightQueryRequests,
 	)
 
@@ -775,7 +940,11 @@ func (i *Ingester) starting(ctx context.Context) error {
 		return errors.Wrap(err, "failed to start lifecycler")
 	}
 
-	if err := i.openExistingTSDB(ctx); err != nil {
+	openExistingTSDB := i.openExistingTSDB
+	if i.cfg.WALReplayReadyPercentage < 100 {
+		openExistingTSDB = i.openExistingTSDBUntilReady
+	}
+	if err := openExistingTSDB(ctx); err != nil {
 		// Try to rollback and close opened TSDBs before halting the ingester.
 		i.closeAllTSDB()
 
@@ -830,6 +999,11 @@ func (i *Ingester) stopping(_ error) error {
 		level.Warn(i.logger).Log("msg", "failed to stop ingester subservices", "err", err)
 	}
 
+	// The TSDBs still being opened in background must be open before being closed.
+	if err := i.waitBackgroundReplay(); err != nil && !errors.Is(err, context.Canceled) {
+		level.Warn(i.logger).Log("msg", "failed to open existing TSDBs", "err", err)
+	}
+
 	// Next initiate our graceful exit from the ring.
 	if err := services.StopAndAwaitTerminated(context.Background(), i.lifecycler); err != nil {
 		level.Warn(i.logger).Log("msg", "failed to stop ingester lifecycler", "err", err)
@@ -870,6 +1044,8 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 	maxInflightRequestResetTicker := time.NewTicker(maxInflightRequestResetPeriod)
 	defer maxInflightRequestResetTicker.Stop()
 
+	backgroundReplayDone := i.backgroundReplayDone
+
 	for {
 		select {
 		case <-metadataPurgeTicker.C:
@@ -894,6 +1070,11 @@ func (i *Ingester) updateLoop(ctx context.Context) error {
 			return nil
 		case err := <-i.subservicesWatcher.Chan():
 			return errors.Wrap(err, "ingester subservice failed")
+		case <-backgroundReplayDone:
+			if err := i.waitBackgroundReplay(); err != nil {
+				return err
+			}
+			backgroundReplayDone = nil
 		}
 	}
 }
@@ -921,9 +1102,21 @@ func (i *Ingester) updateUserTSDBConfigs() {
 		if err != nil {
 			level.Error(logutil.WithUserID(userID, i.logger)).Log("msg", "failed to update user tsdb configuration.")
 		}
+
+		if i.nativeHistogramsEnabled(userID) {
+			userDB.db.EnableNativeHistograms()
+		} else {
+			userDB.db.DisableNativeHistograms()
+		}
 	}
 }
 
+// nativeHistogramsEnabled returns whether the ingestion of native histograms is enabled
+// for the user, either globally or through the per-tenant limits.
+func (i *Ingester) nativeHistogramsEnabled(userID string) bool {
+	return i.cfg.BlocksStorageConfig.TSDB.EnableNativeHistograms || i.limits.NativeHistogramsIngestionEnabled(userID)
+}
+
 // getMaxExemplars returns the maxExemplars value set in limits config.
 // If limits value is set to zero, it falls back to old configuration
 // in block storage config.
@@ -937,6 +1130,24 @@ func (i *Ingester) getMaxExemplars(userID string) int64 {
 	return int64(maxExemplarsFromLimits)
 }
 
+// blockDuration returns the duration, in milliseconds, of the blocks cut from the head of the user's
+// TSDB. The per-tenant block duration is only honored if it evenly divides the first block range, so
+// that the blocks keep being aligned to the block ranges.
+func (i *Ingester) blockDuration(userID string, userLogger log.Logger) int64 {
+	globalDuration := i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0]
+
+	userDuration := i.limits.BlockDuration(userID)
+	if userDuration <= 0 || userDuration == globalDuration {
+		return globalDuration.Milliseconds()
+	}
+	if userDuration.Milliseconds() == 0 || globalDuration%userDuration != 0 {
+		level.Warn(userLogger).Log("msg", "ignoring the tenant block duration not evenly dividing the first block range", "block_duration", userDuration, "block_range", globalDuration)
+		return globalDuration.Milliseconds()
+	}
+
+	return userDuration.Milliseconds()
+}
+
 func (i *Ingester) updateActiveSeries(ctx context.Context) {
 	purgeTime := time.Now().Add(-i.cfg.ActiveSeriesMetricsIdleTimeout)
 
@@ -948,6 +1159,11 @@ func (i *Ingester) updateActiveSeries(ctx context.Context) {
 
 		userDB.activeSeries.Purge(purgeTime)
 		i.metrics.activeSeriesPerUser.WithLabelValues(userID).Set(float64(userDB.activeSeries.Active()))
+
+		series, buckets, averageSchema := activeNativeHistogramStats(userDB.activeSeries)
+		i.metrics.activeNativeHistogramSeriesPerUser.WithLabelValues(userID).Set(float64(series))
+		i.metrics.activeNativeHistogramBucketsPerUser.WithLabelValues(userID).Set(float64(buckets))
+		i.metrics.activeNativeHistogramAverageSchemaPerUser.WithLabelValues(userID).Set(averageSchema)
 		if err := userDB.labelSetCounter.UpdateMetric(ctx, userDB, i.metrics); err != nil {
 			level.Warn(i.logger).Log("msg", "failed to update per labelSet metrics", "user", userID, "err", err)
 		}
@@ -995,14 +1211,71 @@ func (i *Ingester) checkRunning() error {
 	return status.Error(codes.Unavailable, s.String())
 }
 
+// checkReadable returns nil if the ingester can serve read requests. While stopping, the ingester
+// keeps serving reads as long as the lifecycler is waiting for the ring handoff window to elapse,
+// given the TSDBs are closed only once the lifecycler has stopped.
+func (i *Ingester) checkReadable() error {
+	if i.State() == services.Stopping && i.lifecycler != nil && i.lifecycler.IsHandingOff() {
+		return nil
+	}
+	return i.checkRunning()
+}
+
 // GetRef() is an extra method added to TSDB to let Cortex check before calling Add()
 type extendedAppender interface {
 	storage.Appender
 	storage.GetRef
 }
 
+// PushStream implements client.IngesterServer. The pushes received on the stream are handled one at a
+// time, each with the metadata sent along with it, and the error of a push is returned in its response
+// without ending the stream.
+func (i *Ingester) PushStream(stream client.Ingester_PushStreamServer) error {
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		resp, err := client.NewPushStreamResponse(i.pushStreamRequest(stream.Context(), req))
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// pushStreamRequest handles a push received on a stream, verifying its signature like the unary
+// pushes, since the gRPC interceptors only see the metadata of the stream.
+func (i *Ingester) pushStreamRequest(ctx context.Context, req *client.PushStreamRequest) (*cortexpb.WriteResponse, error) {
+	span, ctx := client.StartPushStreamSpan(ctx, req)
+	defer span.Finish()
+
+	if req.Request == nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, "missing write request")
+	}
+	if i.cfg.SignWriteRequestsEnabled {
+		if err := grpcclient.VerifyRequestSignature(ctx, req.Request); err != nil {
+			return nil, err
+		}
+	}
+	return i.Push(ctx, req.Request)
+}
+
 // Push adds metrics to a block
 func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	if i.pushCircuitBreaker != nil {
+		return i.pushCircuitBreaker.push(ctx, req, i.push)
+	}
+	return i.push(ctx, req)
+}
+
+func (i *Ingester) push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
 	if err := i.checkRunning(); err != nil {
 		return nil, err
 	}
@@ -1010,17 +1283,25 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Ingester.Push")
 	defer span.Finish()
 
-	// We will report *this* request in the error too.
-	inflight := i.inflightPushRequests.Inc()
-	defer i.inflightPushRequests.Dec()
-
+	// Samples generated by the ruler have their own inflight budget, if configured, so that
+	// they're not rejected when the ingester is overloaded by raw ingestion.
 	gl := i.getInstanceLimits()
-	if gl != nil && gl.MaxInflightPushRequests > 0 {
-		if inflight > gl.MaxInflightPushRequests {
-			return nil, errTooManyInflightPushRequests
+	inflightRequests, maxInflightRequests, errInflight := &i.inflightPushRequests, int64(0), errTooManyInflightPushRequests
+	if gl != nil {
+		maxInflightRequests = gl.MaxInflightPushRequests
+		if req.Source == cortexpb.RULE && gl.MaxInflightRulePushRequests > 0 {
+			inflightRequests, maxInflightRequests, errInflight = &i.inflightRulePushRequests, gl.MaxInflightRulePushRequests, errTooManyInflightRulePushRequests
 		}
 	}
 
+	// We will report *this* request in the error too.
+	inflight := inflightRequests.Inc()
+	defer inflightRequests.Dec()
+
+	if maxInflightRequests > 0 && inflight > maxInflightRequests {
+		return nil, errInflight
+	}
+
 	var firstPartialErr error
 
 	// NOTE: because we use `unsafe` in deserialisation, we must not
@@ -1077,6 +1358,7 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		perLabelSetSeriesLimitCount = 0
 		perMetricSeriesLimitCount   = 0
 		nativeHistogramCount        = 0
+		nativeHistogramsEnabled     = i.nativeHistogramsEnabled(userID)
 
 		updateFirstPartial = func(errFn func() error) {
 			if firstPartialErr == nil {
@@ -1144,6 +1426,11 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		}
 	)
 
+	var sampleAge prometheus.Observer
+	if i.metrics.sampleAgePerUser != nil {
+		sampleAge = i.metrics.sampleAgePerUser.WithLabelValues(userID)
+	}
+
 	// Walk the samples, appending them to the users database
 	app := db.Appender(ctx).(extendedAppender)
 	for _, ts := range req.Timeseries {
@@ -1157,6 +1444,29 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 
 		// To find out if any sample was added to this series, we keep old value.
 		oldSucceededSamplesCount := succeededSamplesCount
+		// The last native histogram sample added to this series, if any, tracked by the active series.
+		var appendedHistogram *cortexpb.Histogram
+
+		if sampleAge != nil {
+			for _, s := range ts.Samples {
+				sampleAge.Observe(sampleAgeSeconds(startAppend, s.TimestampMs))
+			}
+			for _, hp := range ts.Histograms {
+				sampleAge.Observe(sampleAgeSeconds(startAppend, hp.TimestampMs))
+			}
+		}
+
+		if i.cfg.CreatedTimestampZeroIngestionEnabled && ts.CreatedTimestampMs > 0 && len(ts.Samples) > 0 {
+			if ref == 0 {
+				// Copy the label set because both TSDB and the active series tracker may retain it.
+				copiedLabels = cortexpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
+			}
+			// The zero sample is expected to fail for the series whose created timestamp has already
+			// been ingested, or is too old, in which case it's not appended.
+			if ctRef, err := app.AppendCTZeroSample(ref, copiedLabels, ts.Samples[0].TimestampMs, ts.CreatedTimestampMs); err == nil {
+				ref = ctRef
+			}
+		}
 
 		for _, s := range ts.Samples {
 			var err error
@@ -1192,8 +1502,9 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 			return nil, wrapWithUser(err, userID)
 		}
 
-		if i.cfg.BlocksStorageConfig.TSDB.EnableNativeHistograms {
-			for _, hp := range ts.Histograms {
+		if nativeHistogramsEnabled {
+			for idx := range ts.Histograms {
+				hp := &ts.Histograms[idx]
 				var (
 					err error
 					h   *histogram.Histogram
@@ -1201,14 +1512,15 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 				)
 
 				if hp.GetCountFloat() > 0 {
-					fh = cortexpb.FloatHistogramProtoToFloatHistogram(hp)
+					fh = cortexpb.FloatHistogramProtoToFloatHistogram(*hp)
 				} else {
-					h = cortexpb.HistogramProtoToHistogram(hp)
+					h = cortexpb.HistogramProtoToHistogram(*hp)
 				}
 
 				if ref != 0 {
 					if _, err = app.AppendHistogram(ref, copiedLabels, hp.TimestampMs, h, fh); err == nil {
 						succeededSamplesCount++
+						appendedHistogram = hp
 						continue
 					}
 				} else {
@@ -1216,6 +1528,7 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 					copiedLabels = cortexpb.FromLabelAdaptersToLabelsWithCopy(ts.Labels)
 					if ref, err = app.AppendHistogram(0, copiedLabels, hp.TimestampMs, h, fh); err == nil {
 						succeededSamplesCount++
+						appendedHistogram = hp
 						continue
 					}
 				}
@@ -1236,10 +1549,15 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		}
 
 		if i.cfg.ActiveSeriesMetricsEnabled && succeededSamplesCount > oldSucceededSamplesCount {
-			db.activeSeries.UpdateSeries(tsLabels, tsLabelsHash, startAppend, func(l labels.Labels) labels.Labels {
+			labelsCopy := func(l labels.Labels) labels.Labels {
 				// we must already have copied the labels if succeededSamplesCount has been incremented.
 				return copiedLabels
-			})
+			}
+			if appendedHistogram != nil {
+				db.activeSeries.UpdateNativeHistogramSeries(tsLabels, tsLabelsHash, startAppend, nativeHistogramBuckets(*appendedHistogram), appendedHistogram.Schema, labelsCopy)
+			} else {
+				db.activeSeries.UpdateSeries(tsLabels, tsLabelsHash, startAppend, labelsCopy)
+			}
 		}
 
 		maxExemplarsForUser := i.getMaxExemplars(userID)
@@ -1320,7 +1638,7 @@ func (i *Ingester) Push(ctx context.Context, req *cortexpb.WriteRequest) (*corte
 		i.validateMetrics.DiscardedSamples.WithLabelValues(perLabelsetSeriesLimit, userID).Add(float64(perLabelSetSeriesLimitCount))
 	}
 
-	if !i.cfg.BlocksStorageConfig.TSDB.EnableNativeHistograms && nativeHistogramCount > 0 {
+	if !nativeHistogramsEnabled && nativeHistogramCount > 0 {
 		i.validateMetrics.DiscardedSamples.WithLabelValues(nativeHistogramSample, userID).Add(float64(nativeHistogramCount))
 	}
 
@@ -1375,7 +1693,7 @@ func (u *userTSDB) releaseAppendLock() {
 
 // QueryExemplars implements service.IngesterServer
 func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQueryRequest) (*client.ExemplarQueryResponse, error) {
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, err
 	}
 
@@ -1394,6 +1712,10 @@ func (i *Ingester) QueryExemplars(ctx context.Context, req *client.ExemplarQuery
 
 	i.metrics.queries.Inc()
 
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.ExemplarQueryResponse{}, nil
@@ -1470,7 +1792,7 @@ func (i *Ingester) LabelValuesStream(req *client.LabelValuesRequest, stream clie
 // the cleanup function should be called in order to close the querier
 func (i *Ingester) labelsValuesCommon(ctx context.Context, req *client.LabelValuesRequest) (*client.LabelValuesResponse, func(), error) {
 	cleanup := func() {}
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, cleanup, err
 	}
 
@@ -1484,6 +1806,10 @@ func (i *Ingester) labelsValuesCommon(ctx context.Context, req *client.LabelValu
 		return nil, cleanup, err
 	}
 
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, cleanup, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.LabelValuesResponse{}, cleanup, nil
@@ -1555,7 +1881,7 @@ func (i *Ingester) LabelNamesStream(req *client.LabelNamesRequest, stream client
 // the cleanup function should be called in order to close the querier
 func (i *Ingester) labelNamesCommon(ctx context.Context, req *client.LabelNamesRequest) (*client.LabelNamesResponse, func(), error) {
 	cleanup := func() {}
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, cleanup, err
 	}
 
@@ -1564,6 +1890,10 @@ func (i *Ingester) labelNamesCommon(ctx context.Context, req *client.LabelNamesR
 		return nil, cleanup, err
 	}
 
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, cleanup, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.LabelNamesResponse{}, cleanup, nil
@@ -1629,7 +1959,7 @@ func (i *Ingester) MetricsForLabelMatchersStream(req *client.MetricsForLabelMatc
 // the cleanup function should be called in order to close the querier
 func (i *Ingester) metricsForLabelMatchersCommon(ctx context.Context, req *client.MetricsForLabelMatchersRequest) (*client.MetricsForLabelMatchersResponse, func(), error) {
 	cleanup := func() {}
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return nil, cleanup, err
 	}
 
@@ -1638,6 +1968,10 @@ func (i *Ingester) metricsForLabelMatchersCommon(ctx context.Context, req *clien
 		return nil, cleanup, err
 	}
 
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, cleanup, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.MetricsForLabelMatchersResponse{}, cleanup, nil
@@ -1751,6 +2085,10 @@ func (i *Ingester) UserStats(ctx context.Context, req *client.UserStatsRequest)
 		return nil, err
 	}
 
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return &client.UserStatsResponse{}, nil
@@ -1786,18 +2124,116 @@ func createUserStats(db *userTSDB, activeSeriesMetricsEnabled bool) *client.User
 	apiRate := db.ingestedAPISamples.Rate()
 	ruleRate := db.ingestedRuleSamples.Rate()
 
-	var activeSeries uint64
+	var (
+		activeSeries, nativeHistogramSeries, nativeHistogramBuckets uint64
+		nativeHistogramAverageSchema                                float64
+	)
 	if activeSeriesMetricsEnabled {
 		activeSeries = uint64(db.activeSeries.Active())
+
+		series, buckets, averageSchema := activeNativeHistogramStats(db.activeSeries)
+		nativeHistogramSeries, nativeHistogramBuckets, nativeHistogramAverageSchema = uint64(series), uint64(buckets), averageSchema
 	}
 
 	return &client.UserStatsResponse{
-		IngestionRate:     apiRate + ruleRate,
-		ApiIngestionRate:  apiRate,
-		RuleIngestionRate: ruleRate,
-		NumSeries:         db.Head().NumSeries(),
-		ActiveSeries:      activeSeries,
+		IngestionRate:                      apiRate + ruleRate,
+		ApiIngestionRate:                   apiRate,
+		RuleIngestionRate:                  ruleRate,
+		NumSeries:                          db.Head().NumSeries(),
+		ActiveSeries:                       activeSeries,
+		ActiveNativeHistogramSeries:        nativeHistogramSeries,
+		ActiveNativeHistogramBuckets:       nativeHistogramBuckets,
+		ActiveNativeHistogramAverageSchema: nativeHistogramAverageSchema,
+	}
+}
+
+// activeNativeHistogramStats returns the number of active native histogram series, their total number of
+// buckets and their average schema.
+func activeNativeHistogramStats(activeSeries *ActiveSeries) (series, buckets int, averageSchema float64) {
+	series, buckets, schemas := activeSeries.ActiveNativeHistograms()
+	if series > 0 {
+		averageSchema = float64(schemas) / float64(series)
+	}
+	return series, buckets, averageSchema
+}
+
+// nativeHistogramBuckets returns the number of populated buckets of the native histogram sample,
+// which are encoded as deltas for the integer histograms and as counts for the float ones.
+func nativeHistogramBuckets(hp cortexpb.Histogram) int {
+	return len(hp.PositiveDeltas) + len(hp.NegativeDeltas) + len(hp.PositiveCounts) + len(hp.NegativeCounts)
+}
+
+// TSDBStatus returns the cardinality statistics of the TSDB head of the user, like the
+// Prometheus /api/v1/status/tsdb API.
+func (i *Ingester) TSDBStatus(ctx context.Context, req *client.TSDBStatusRequest) (*client.TSDBStatusResponse, error) {
+	if err := i.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, err
+	}
+
+	db := i.getTSDB(userID)
+	if db == nil {
+		return &client.TSDBStatusResponse{}, nil
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultTSDBStatusLimit
+	}
+
+	stats := db.Head().Stats(labels.MetricName, limit)
+	return &client.TSDBStatusResponse{
+		NumSeries:                   stats.NumSeries,
+		NumLabelPairs:               uint64(stats.IndexPostingStats.NumLabelPairs),
+		ChunkCount:                  db.headChunksCount(),
+		MinTime:                     stats.MinTime,
+		MaxTime:                     stats.MaxTime,
+		SeriesCountByMetricName:     toTSDBStatistics(stats.IndexPostingStats.CardinalityMetricsStats),
+		LabelValueCountByLabelName:  toTSDBStatistics(stats.IndexPostingStats.CardinalityLabelStats),
+		MemoryInBytesByLabelName:    toTSDBStatistics(stats.IndexPostingStats.LabelValueStats),
+		SeriesCountByLabelValuePair: toTSDBStatistics(stats.IndexPostingStats.LabelValuePairsStats),
+	}, nil
+}
+
+func toTSDBStatistics(stats []index.Stat) []client.TSDBStatistic {
+	result := make([]client.TSDBStatistic, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, client.TSDBStatistic{Name: s.Name, Value: s.Count})
+	}
+	return result
+}
+
+// PrewarmTenant creates the TSDB of the user, if it doesn't exist yet, ahead of the ingestion of
+// its first series. It's used to pre-warm the ingesters of a tenant before a migration cutover,
+// so that the first requests don't pay the TSDB creation cost.
+func (i *Ingester) PrewarmTenant(ctx context.Context, _ *client.PrewarmTenantRequest) (*client.PrewarmTenantResponse, error) {
+	if err := i.checkRunning(); err != nil {
+		return nil, err
+	}
+
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.getTSDB(userID) != nil {
+		return &client.PrewarmTenantResponse{}, nil
 	}
+
+	if _, err := i.getOrCreateTSDB(userID, false); err != nil {
+		return nil, err
+	}
+
+	level.Info(i.logger).Log("msg", "pre-warmed the TSDB of the tenant", "user", userID)
+	return &client.PrewarmTenantResponse{Created: true}, nil
 }
 
 const queryStreamBatchMessageSize = 1 * 1024 * 1024
@@ -1805,7 +2241,7 @@ const queryStreamBatchMessageSize = 1 * 1024 * 1024
 // QueryStream implements service.IngesterServer
 // Streams metrics from a TSDB. This implements the client.IngesterServer interface
 func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_QueryStreamServer) error {
-	if err := i.checkRunning(); err != nil {
+	if err := i.checkReadable(); err != nil {
 		return err
 	}
 
@@ -1834,6 +2270,10 @@ func (i *Ingester) QueryStream(req *client.QueryRequest, stream client.Ingester_
 
 	i.metrics.queries.Inc()
 
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return err
+	}
+
 	db := i.getTSDB(userID)
 	if db == nil {
 		return nil
@@ -2001,6 +2441,11 @@ func (i *Ingester) getOrCreateTSDB(userID string, force bool) (*userTSDB, error)
 		return db, nil
 	}
 
+	// The TSDB of the tenant must not be created while it's being opened in background.
+	if err := i.checkTSDBReplayed(userID); err != nil {
+		return nil, err
+	}
+
 	// We're ready to create the TSDB, however we must be sure that the ingester
 	// is in the ACTIVE state, otherwise it may conflict with the transfer in/out.
 	// The TSDB is created when the first series is pushed and this shouldn't happen
@@ -2041,6 +2486,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 	userDB := &userTSDB{
 		userID:              userID,
+		tsdbPromReg:         tsdbPromReg,
 		activeSeries:        NewActiveSeries(),
 		seriesInMetric:      newMetricCounter(i.limiter, i.cfg.getIgnoreSeriesLimitForMetricNamesMap()),
 		labelSetCounter:     newLabelSetCounter(i.limiter),
@@ -2049,6 +2495,7 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 
 		instanceLimitsFn:    i.getInstanceLimits,
 		instanceSeriesCount: &i.TSDBState.seriesCount,
+		metrics:             i.metrics,
 	}
 
 	enableExemplars := false
@@ -2057,6 +2504,17 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		enableExemplars = true
 	}
 	oooTimeWindow := i.limits.OutOfOrderTimeWindow(userID)
+	userDB.samplesPerChunk = i.limits.SamplesPerChunk(userID)
+	if userDB.samplesPerChunk <= 0 {
+		userDB.samplesPerChunk = tsdb.DefaultSamplesPerChunk
+	}
+	userDB.blockDuration = i.blockDuration(userID, userLogger)
+	maxBlockDuration := blockRanges[len(blockRanges)-1]
+	if userDB.blockDuration != blockRanges[0] {
+		// Never compact the blocks of a tenant with a custom block duration together locally,
+		// since the shipper only uploads the blocks cut from the head.
+		maxBlockDuration = userDB.blockDuration
+	}
 	walCompressType := wlog.CompressionNone
 	// TODO(yeya24): expose zstd compression for WAL.
 	if i.cfg.BlocksStorageConfig.TSDB.WALCompressionEnabled {
@@ -2065,8 +2523,8 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 	// Create a new user database
 	db, err := tsdb.Open(udir, userLogger, tsdbPromReg, &tsdb.Options{
 		RetentionDuration:              i.cfg.BlocksStorageConfig.TSDB.Retention.Milliseconds(),
-		MinBlockDuration:               blockRanges[0],
-		MaxBlockDuration:               blockRanges[len(blockRanges)-1],
+		MinBlockDuration:               userDB.blockDuration,
+		MaxBlockDuration:               maxBlockDuration,
 		NoLockfile:                     true,
 		StripeSize:                     i.cfg.BlocksStorageConfig.TSDB.StripeSize,
 		HeadChunksWriteBufferSize:      i.cfg.BlocksStorageConfig.TSDB.HeadChunksWriteBufferSize,
@@ -2082,7 +2540,8 @@ func (i *Ingester) createTSDB(userID string) (*userTSDB, error) {
 		OutOfOrderTimeWindow:           time.Duration(oooTimeWindow).Milliseconds(),
 		OutOfOrderCapMax:               i.cfg.BlocksStorageConfig.TSDB.OutOfOrderCapMax,
 		EnableOverlappingCompaction:    false, // Always let compactors handle overlapped blocks, e.g. OOO blocks.
-		EnableNativeHistograms:         i.cfg.BlocksStorageConfig.TSDB.EnableNativeHistograms,
+		EnableNativeHistograms:         i.nativeHistogramsEnabled(userID),
+		SamplesPerChunk:                userDB.samplesPerChunk,
 	}, nil)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to open TSDB: %s", udir)
@@ -2193,6 +2652,7 @@ func (i *Ingester) closeAllTSDB() {
 // concurrently opening TSDB.
 func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 	level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "opening existing TSDBs")
+	i.walReplay.begin()
 
 	queue := make(chan string)
 	group, groupCtx := errgroup.WithContext(ctx)
@@ -2202,6 +2662,7 @@ func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 		group.Go(func() error {
 			for userID := range queue {
 				startTime := time.Now()
+				i.walReplay.start(userID)
 
 				db, err := i.createTSDB(userID)
 				if err != nil {
@@ -2214,6 +2675,7 @@ func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 				i.TSDBState.dbs[userID] = db
 				i.stoppedMtx.Unlock()
 				i.metrics.memUsers.Inc()
+				i.walReplay.finish(userID, db.Head().NumSeries())
 
 				i.TSDBState.walReplayTime.Observe(time.Since(startTime).Seconds())
 			}
@@ -2263,6 +2725,7 @@ func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 			}
 
 			// Enqueue the user to be processed.
+			i.walReplay.add(userID, walSize(path))
 			select {
 			case queue <- userID:
 				// Nothing to do.
@@ -2274,6 +2737,9 @@ func (i *Ingester) openExistingTSDB(ctx context.Context) error {
 			// Don't descend into subdirectories.
 			return filepath.SkipDir
 		})
+		if walkErr == nil {
+			i.walReplay.listingDone()
+		}
 
 		return errors.Wrapf(walkErr, "unable to walk directory %s containing existing TSDBs", i.cfg.BlocksStorageConfig.TSDB.Dir)
 	})
@@ -2407,6 +2873,16 @@ func (i *Ingester) shipBlocks(ctx context.Context, allowed *util.AllowedTenants)
 			}
 		}
 
+		// Record the chunk options and the metric metadata in the meta.json of the blocks before they're
+		// shipped, so that the metadata can still be queried after the ingester has been restarted.
+		var metricsMetadata []cortex_tsdb.BlockMetricMetadata
+		if userMetadata := i.getUserMetadata(userID); userMetadata != nil {
+			metricsMetadata = userMetadata.toBlockMetadata()
+		}
+		if err := userDB.setBlocksExtensions(i.logger, metricsMetadata); err != nil {
+			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "failed to set the extensions in the TSDB blocks meta", "user", userID, "err", err)
+		}
+
 		uploaded, err := userDB.shipper.Sync(ctx)
 		if err != nil {
 			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "shipper failed to synchronize TSDB blocks with the storage", "user", userID, "uploaded", uploaded, "err", err)
@@ -2445,11 +2921,19 @@ func (i *Ingester) compactionLoop(ctx context.Context) error {
 	ticker := util.NewSlottedTicker(infoFunc, i.cfg.BlocksStorageConfig.TSDB.HeadCompactionInterval, 1)
 	defer ticker.Stop()
 
+	// The tenants with a custom head compaction interval are compacted when due, which is checked
+	// more frequently than the global interval.
+	overridesTicker := time.NewTicker(headCompactionIntervalOverridesCheckPeriod)
+	defer overridesTicker.Stop()
+
 	for ctx.Err() == nil {
 		select {
 		case <-ticker.C:
 			i.compactBlocks(ctx, false, nil)
 
+		case <-overridesTicker.C:
+			i.compactBlocksWithCustomInterval(ctx)
+
 		case req := <-i.TSDBState.forceCompactTrigger:
 			i.compactBlocks(ctx, true, req.users)
 			close(req.callback) // Notify back.
@@ -2477,46 +2961,83 @@ func (i *Ingester) compactBlocks(ctx context.Context, force bool, allowed *util.
 			return nil
 		}
 
-		userDB := i.getTSDB(userID)
-		if userDB == nil {
+		// The tenants with a custom head compaction interval are regularly compacted only when due.
+		if !force && i.limits.HeadCompactionInterval(userID) > 0 {
+			return nil
+		}
+
+		i.compactUserBlocks(ctx, userID, force)
+		return nil
+	})
+}
+
+// compactBlocksWithCustomInterval compacts the head of the tenants with a custom head compaction
+// interval, if the interval has elapsed since their last compaction.
+func (i *Ingester) compactBlocksWithCustomInterval(ctx context.Context) {
+	if i.lifecycler != nil {
+		if ingesterState := i.lifecycler.GetState(); ingesterState == ring.JOINING {
+			return
+		}
+	}
+
+	now := time.Now()
+	_ = concurrency.ForEachUser(ctx, i.getTSDBUsers(), i.cfg.BlocksStorageConfig.TSDB.HeadCompactionConcurrency, func(ctx context.Context, userID string) error {
+		interval := i.limits.HeadCompactionInterval(userID)
+		if interval <= 0 {
 			return nil
 		}
 
-		// Don't do anything, if there is nothing to compact.
-		h := userDB.Head()
-		if h.NumSeries() == 0 {
+		userDB := i.getTSDB(userID)
+		if userDB == nil || now.Sub(time.Unix(0, userDB.lastHeadCompaction.Load())) < interval {
 			return nil
 		}
 
-		var err error
+		i.compactUserBlocks(ctx, userID, false)
+		return nil
+	})
+}
 
-		i.TSDBState.compactionsTriggered.Inc()
+// compactUserBlocks compacts the head of the user's TSDB. Force flag will force compaction even if
+// head is not compactable yet.
+func (i *Ingester) compactUserBlocks(ctx context.Context, userID string, force bool) {
+	userDB := i.getTSDB(userID)
+	if userDB == nil {
+		return
+	}
 
-		reason := ""
-		switch {
-		case force:
-			reason = "forced"
-			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
+	// Don't do anything, if there is nothing to compact.
+	h := userDB.Head()
+	if h.NumSeries() == 0 {
+		return
+	}
 
-		case i.TSDBState.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.TSDBState.compactionIdleTimeout):
-			reason = "idle"
-			level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB is idle, forcing compaction", "user", userID)
-			err = userDB.compactHead(i.cfg.BlocksStorageConfig.TSDB.BlockRanges[0].Milliseconds())
+	var err error
 
-		default:
-			reason = "regular"
-			err = userDB.Compact(ctx)
-		}
+	i.TSDBState.compactionsTriggered.Inc()
 
-		if err != nil {
-			i.TSDBState.compactionsFailed.Inc()
-			level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB blocks compaction for user has failed", "user", userID, "err", err, "compactReason", reason)
-		} else {
-			level.Debug(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB blocks compaction completed successfully", "user", userID, "compactReason", reason)
-		}
+	reason := ""
+	switch {
+	case force:
+		reason = "forced"
+		err = userDB.compactHead(userDB.blockDuration)
 
-		return nil
-	})
+	case i.TSDBState.compactionIdleTimeout > 0 && userDB.isIdle(time.Now(), i.TSDBState.compactionIdleTimeout):
+		reason = "idle"
+		level.Info(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB is idle, forcing compaction", "user", userID)
+		err = userDB.compactHead(userDB.blockDuration)
+
+	default:
+		reason = "regular"
+		userDB.lastHeadCompaction.Store(time.Now().UnixNano())
+		err = userDB.Compact(ctx)
+	}
+
+	if err != nil {
+		i.TSDBState.compactionsFailed.Inc()
+		level.Warn(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB blocks compaction for user has failed", "user", userID, "err", err, "compactReason", reason)
+	} else {
+		level.Debug(logutil.WithContext(ctx, i.logger)).Log("msg", "TSDB blocks compaction completed successfully", "user", userID, "compactReason", reason)
+	}
 }
 
 func (i *Ingester) closeAndDeleteIdleUserTSDBs(ctx context.Context) error {
@@ -2855,6 +3376,12 @@ func metadataQueryRange(queryStart, queryEnd int64, db *userTSDB, queryIngesters
 	return
 }
 
+// sampleAgeSeconds returns the age of a sample with the given timestamp at the given time,
+// or 0 if the sample is in the future.
+func sampleAgeSeconds(now time.Time, timestampMs int64) float64 {
+	return max(0, float64(now.UnixMilli()-timestampMs)/1000)
+}
+
 func wrappedTSDBIngestErr(ingestErr error, timestamp model.Time, labels []cortexpb.LabelAdapter) error {
 	if ingestErr == nil {
 		return nil
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/histogram"
 	"github.com/prometheus/prometheus/model/labels"
@@ -35,6 +36,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/shipper"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
@@ -53,6 +55,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
+	"github.com/cortexproject/cortex/pkg/util/grpcclient"
 	histogram_util "github.com/cortexproject/cortex/pkg/util/histogram"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/test"
@@ -510,6 +513,73 @@ func TestIngesterUserLimitExceeded(t *testing.T) {
 
 }
 
+func TestIngesterUserLimitExceeded_GradualEnforcement(t *testing.T) {
+	limits := defaultLimitsTestConfig()
+	limits.MaxLocalSeriesPerUser = 1
+	limits.MaxSeriesPerUserGracePeriod = model.Duration(time.Hour)
+	limits.MaxSeriesPerUserRampUpPeriod = model.Duration(time.Hour)
+
+	registry := prometheus.NewRegistry()
+	ing, err := prepareIngesterWithBlocksStorageAndLimits(t, defaultIngesterTestConfig(t), limits, nil, t.TempDir(), registry, true)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), ing))
+	defer services.StopAndAwaitTerminated(context.Background(), ing) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, time.Second, ring.ACTIVE, func() interface{} {
+		return ing.lifecycler.GetState()
+	})
+
+	userID := "1"
+	ctx := user.InjectOrgID(context.Background(), userID)
+
+	// pushSeries pushes each series in a dedicated request and returns the number of rejected series.
+	nextSeries := 0
+	pushSeries := func(count int) int {
+		rejected := 0
+		for i := 0; i < count; i++ {
+			lbls := labels.FromStrings(labels.MetricName, "testmetric", "series", strconv.Itoa(nextSeries))
+			nextSeries++
+
+			_, err := ing.Push(ctx, cortexpb.ToWriteRequest([]labels.Labels{lbls}, []cortexpb.Sample{{TimestampMs: 0, Value: 1}}, nil, nil, cortexpb.API))
+			if err != nil {
+				httpResp, ok := httpgrpc.HTTPResponseFromError(err)
+				require.True(t, ok, "returned error is not an httpgrpc response")
+				require.Equal(t, http.StatusBadRequest, int(httpResp.Code))
+				rejected++
+			}
+		}
+		return rejected
+	}
+
+	// Within the grace period, the series exceeding the limit are not rejected.
+	require.Equal(t, 0, pushSeries(3))
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_ingester_series_over_user_limit_created_total The total number of series created while the user exceeded the per-user series limit, because the limit is gradually enforced.
+		# TYPE cortex_ingester_series_over_user_limit_created_total counter
+		cortex_ingester_series_over_user_limit_created_total{user="1"} 2
+		# HELP cortex_ingester_series_limit_rejection_ratio The ratio of the new series rejected for users exceeding the per-user series limit, while the limit is gradually enforced.
+		# TYPE cortex_ingester_series_limit_rejection_ratio gauge
+		cortex_ingester_series_limit_rejection_ratio{user="1"} 0
+	`), "cortex_ingester_series_over_user_limit_created_total", "cortex_ingester_series_limit_rejection_ratio"))
+
+	// Halfway through the ramp-up period, a part of the new series is rejected.
+	db := ing.getTSDB(userID)
+	db.seriesLimitExceededSince.Store(time.Now().Add(-90 * time.Minute).UnixNano())
+	rejected := pushSeries(100)
+	assert.Greater(t, rejected, 20)
+	assert.Less(t, rejected, 80)
+
+	// After the ramp-up period, the limit is fully enforced.
+	db.seriesLimitExceededSince.Store(time.Now().Add(-3 * time.Hour).UnixNano())
+	require.Equal(t, 10, pushSeries(10))
+	require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_ingester_series_limit_rejection_ratio The ratio of the new series rejected for users exceeding the per-user series limit, while the limit is gradually enforced.
+		# TYPE cortex_ingester_series_limit_rejection_ratio gauge
+		cortex_ingester_series_limit_rejection_ratio{user="1"} 1
+	`), "cortex_ingester_series_limit_rejection_ratio"))
+}
+
 func benchmarkData(nSeries int) (allLabels []labels.Labels, allSamples []cortexpb.Sample) {
 	for j := 0; j < nSeries; j++ {
 		labels := chunk.BenchmarkLabels.Copy()
@@ -1760,6 +1830,121 @@ func TestIngester_Push_ShouldCorrectlyTrackMetricsInMultiTenantScenario(t *testi
 	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expectedMetrics), metricNames...))
 }
 
+func TestIngester_Push_ShouldTrackSampleAgePerUser(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	// Create a mocked ingester
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	cfg.SampleAgeMetricsEnabled = true
+
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until the ingester is ACTIVE
+	test.Poll(t, 100*time.Millisecond, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	now := time.Now()
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{
+			labels.FromStrings(labels.MetricName, "test", "series", "1"),
+			labels.FromStrings(labels.MetricName, "test", "series", "2"),
+			labels.FromStrings(labels.MetricName, "test", "series", "3"),
+		},
+		[]cortexpb.Sample{
+			{Value: 1, TimestampMs: now.Add(-8 * time.Minute).UnixMilli()},
+			{Value: 1, TimestampMs: now.Add(-3 * time.Second).UnixMilli()},
+			{Value: 1, TimestampMs: now.Add(time.Minute).UnixMilli()},
+		},
+		nil,
+		nil,
+		cortexpb.API)
+
+	_, err = i.Push(user.InjectOrgID(context.Background(), "test-1"), req)
+	require.NoError(t, err)
+
+	metrics, err := registry.Gather()
+	require.NoError(t, err)
+
+	var h *dto.Histogram
+	for _, mf := range metrics {
+		if mf.GetName() == "cortex_ingester_ingested_sample_age_seconds" {
+			require.Len(t, mf.GetMetric(), 1)
+			assert.Equal(t, "test-1", mf.GetMetric()[0].GetLabel()[0].GetValue())
+			h = mf.GetMetric()[0].GetHistogram()
+		}
+	}
+	require.NotNil(t, h)
+	assert.Equal(t, uint64(3), h.GetSampleCount())
+
+	// The sample in the future has an age of 0.
+	cumulativeCounts := map[float64]uint64{}
+	for _, b := range h.GetBucket() {
+		cumulativeCounts[b.GetUpperBound()] = b.GetCumulativeCount()
+	}
+	assert.Equal(t, uint64(1), cumulativeCounts[1])
+	assert.Equal(t, uint64(2), cumulativeCounts[5])
+	assert.Equal(t, uint64(2), cumulativeCounts[300])
+	assert.Equal(t, uint64(3), cumulativeCounts[600])
+
+	// The metrics are removed with the user TSDB.
+	i.metrics.deletePerUserMetrics("test-1")
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(""), "cortex_ingester_ingested_sample_age_seconds"))
+}
+
+func TestIngester_Push_ShouldAppendCreatedTimestampZeroSample(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enabled=%t", enabled), func(t *testing.T) {
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+			cfg.CreatedTimestampZeroIngestionEnabled = enabled
+
+			i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			// Wait until the ingester is ACTIVE
+			test.Poll(t, 100*time.Millisecond, ring.ACTIVE, func() interface{} {
+				return i.lifecycler.GetState()
+			})
+
+			ctx := user.InjectOrgID(context.Background(), "test-1")
+			lbls := labels.FromStrings(labels.MetricName, "test_total")
+			for _, sample := range []cortexpb.Sample{{Value: 5, TimestampMs: 20}, {Value: 8, TimestampMs: 30}} {
+				req := cortexpb.ToWriteRequest([]labels.Labels{lbls}, []cortexpb.Sample{sample}, nil, nil, cortexpb.API)
+				// The created timestamp is sent with every sample of the series.
+				req.Timeseries[0].CreatedTimestampMs = 10
+				_, err = i.Push(ctx, req)
+				require.NoError(t, err)
+			}
+
+			s := &mockQueryStreamServer{ctx: ctx}
+			err = i.QueryStream(&client.QueryRequest{
+				StartTimestampMs: math.MinInt64,
+				EndTimestampMs:   math.MaxInt64,
+				Matchers:         []*client.LabelMatcher{{Type: client.EQUAL, Name: labels.MetricName, Value: "test_total"}},
+			}, s)
+			require.NoError(t, err)
+			set, err := seriesSetFromResponseStream(s)
+			require.NoError(t, err)
+			r, err := client.SeriesSetToQueryResponse(set)
+			require.NoError(t, err)
+			require.Len(t, r.Timeseries, 1)
+
+			expected := []cortexpb.Sample{{Value: 5, TimestampMs: 20}, {Value: 8, TimestampMs: 30}}
+			if enabled {
+				expected = append([]cortexpb.Sample{{Value: 0, TimestampMs: 10}}, expected...)
+			}
+			assert.Equal(t, expected, r.Timeseries[0].Samples)
+		})
+	}
+}
+
 func TestIngester_Push_DecreaseInactiveSeries(t *testing.T) {
 	metricLabelAdapters := []cortexpb.LabelAdapter{{Name: labels.MetricName, Value: "test"}}
 	metricLabels := cortexpb.FromLabelAdaptersToLabels(metricLabelAdapters)
@@ -2921,6 +3106,80 @@ func TestIngester_QueryStream(t *testing.T) {
 	}
 }
 
+func TestIngester_PushStream(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.SignWriteRequestsEnabled = true
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE.
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	serv := grpc.NewServer(
+		grpc.StreamInterceptor(middleware.StreamServerUserHeaderInterceptor),
+		grpc.UnaryInterceptor(middleware.ServerUserHeaderInterceptor),
+	)
+	defer serv.GracefulStop()
+	client.RegisterIngesterServer(serv, i)
+
+	listener, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+
+	go func() {
+		require.NoError(t, serv.Serve(listener))
+	}()
+
+	clientCfg := defaultClientTestConfig()
+	clientCfg.PushStreamingEnabled = true
+	clientCfg.GRPCClientConfig.SignWriteRequestsEnabled = true
+	c, err := client.MakeIngesterClient(listener.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	defer c.Close()
+
+	// Push the samples on the stream, and an out of order sample failing its push.
+	ctx := user.InjectOrgID(context.Background(), userID)
+	lbls := labels.Labels{{Name: labels.MetricName, Value: "foo"}}
+	for ts := int64(1000); ts <= 3000; ts += 1000 {
+		req, _ := mockWriteRequest(t, lbls, float64(ts), ts)
+		_, err = c.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	req, _ := mockWriteRequest(t, lbls, 0, 500)
+	_, err = c.Push(ctx, req)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+
+	req, _ = mockWriteRequest(t, lbls, 4000, 4000)
+	_, err = c.Push(ctx, req)
+	require.NoError(t, err)
+
+	// The pushes which aren't signed are rejected.
+	clientCfg.GRPCClientConfig.SignWriteRequestsEnabled = false
+	unsigned, err := client.MakeIngesterClient(listener.Addr().String(), clientCfg)
+	require.NoError(t, err)
+	defer unsigned.Close()
+
+	req, _ = mockWriteRequest(t, lbls, 5000, 5000)
+	_, err = unsigned.Push(ctx, req)
+	require.ErrorContains(t, err, grpcclient.ErrSignatureNotPresent.Error())
+
+	res, _, err := runTestQuery(ctx, t, i, labels.MatchEqual, labels.MetricName, "foo")
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, []model.SamplePair{
+		{Timestamp: 1000, Value: 1000},
+		{Timestamp: 2000, Value: 2000},
+		{Timestamp: 3000, Value: 3000},
+		{Timestamp: 4000, Value: 4000},
+	}, res[0].Values)
+}
+
 func TestIngester_QueryStreamManySamplesChunks(t *testing.T) {
 	// Create ingester.
 	cfg := defaultIngesterTestConfig(t)
@@ -3569,6 +3828,171 @@ func TestIngester_sholdUpdateCacheShippedBlocks(t *testing.T) {
 	require.Equal(t, len(db.getCachedShippedBlocks()), 1)
 }
 
+func TestIngester_ShouldApplyPerTenantChunkOptions(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), userID)
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	limits := defaultLimitsTestConfig()
+	limits.SamplesPerChunk = 60
+	limits.NativeHistogramsIngestionEnabled = true
+
+	// Native histograms are disabled globally but enabled for the tenant.
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewRegistry(), false)
+	require.NoError(t, err)
+
+	// Use in-memory bucket.
+	bucket := objstore.NewInMemBucket()
+	i.TSDBState.bucket = bucket
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{labels.FromStrings(labels.MetricName, "test")}, nil,
+		[]*cortexpb.MetricMetadata{{MetricFamilyName: "test", Type: cortexpb.HISTOGRAM, Help: "a help"}},
+		[]cortexpb.Histogram{cortexpb.HistogramToHistogramProto(util.TimeToMillis(time.Now()), histogram_util.GenerateTestHistogram(1))},
+		cortexpb.API)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), i.TSDBState.seriesCount.Load())
+	assert.Equal(t, 60, i.getTSDB(userID).samplesPerChunk)
+
+	i.compactBlocks(context.Background(), true, nil)
+	i.shipBlocks(context.Background(), nil)
+
+	// The metric metadata is recorded in the meta.json of the shipped block.
+	var metas []*metadata.Meta
+	for name, data := range bucket.Objects() {
+		if filepath.Base(name) != metadata.MetaFilename {
+			continue
+		}
+		meta, err := metadata.Read(io.NopCloser(bytes.NewReader(data)))
+		require.NoError(t, err)
+		metas = append(metas, meta)
+	}
+	require.Len(t, metas, 1)
+	assert.Equal(t, userID, metas[0].Thanos.Labels[cortex_tsdb.TenantIDExternalLabel])
+
+	ext, err := cortex_tsdb.GetBlockExtensions(metas[0])
+	require.NoError(t, err)
+	require.NotNil(t, ext)
+	assert.Equal(t, []cortex_tsdb.BlockMetricMetadata{{Metric: "test", Type: "histogram", Help: "a help"}}, ext.Metadata)
+}
+
+func TestIngester_ShouldCutBlocksWithPerTenantBlockDuration(t *testing.T) {
+	for name, tc := range map[string]struct {
+		blockDuration    time.Duration
+		expectedDuration time.Duration
+		expectedBlocks   int
+	}{
+		"global block duration": {
+			expectedDuration: 2 * time.Hour,
+			expectedBlocks:   1,
+		},
+		"per-tenant block duration": {
+			blockDuration:    30 * time.Minute,
+			expectedDuration: 30 * time.Minute,
+			expectedBlocks:   2,
+		},
+		"per-tenant block duration not dividing the block range": {
+			blockDuration:    45 * time.Minute,
+			expectedDuration: 2 * time.Hour,
+			expectedBlocks:   1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := user.InjectOrgID(context.Background(), userID)
+			cfg := defaultIngesterTestConfig(t)
+			cfg.LifecyclerConfig.JoinAfter = 0
+
+			limits := defaultLimitsTestConfig()
+			limits.BlockDuration = model.Duration(tc.blockDuration)
+
+			i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewRegistry(), true)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			// Wait until it's ACTIVE
+			test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+				return i.lifecycler.GetState()
+			})
+
+			// Push samples spanning two 30m ranges.
+			lbls := labels.FromStrings(labels.MetricName, "test")
+			for _, ts := range []int64{10 * time.Minute.Milliseconds(), 40 * time.Minute.Milliseconds()} {
+				req, _ := mockWriteRequest(t, lbls, 1, ts)
+				_, err = i.Push(ctx, req)
+				require.NoError(t, err)
+			}
+
+			i.compactBlocks(context.Background(), true, nil)
+
+			db := i.getTSDB(userID)
+			require.NotNil(t, db)
+			assert.Equal(t, tc.expectedDuration.Milliseconds(), db.blockDuration)
+
+			blocks := db.Blocks()
+			require.Len(t, blocks, tc.expectedBlocks)
+			for _, b := range blocks {
+				assert.LessOrEqual(t, b.Meta().MaxTime-b.Meta().MinTime, tc.expectedDuration.Milliseconds())
+			}
+		})
+	}
+}
+
+func TestIngester_ShouldCompactHeadWithPerTenantHeadCompactionInterval(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), userID)
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+
+	limits := defaultLimitsTestConfig()
+	limits.HeadCompactionInterval = model.Duration(time.Hour)
+
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, limits, nil, "", prometheus.NewRegistry(), true)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	req, _ := mockWriteRequest(t, labels.FromStrings(labels.MetricName, "test"), 1, util.TimeToMillis(time.Now()))
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	triggered := func() float64 {
+		return testutil.ToFloat64(i.TSDBState.compactionsTriggered)
+	}
+
+	// The tenant isn't compacted at the global interval.
+	i.compactBlocks(context.Background(), false, nil)
+	assert.Equal(t, float64(0), triggered())
+
+	// The tenant is compacted when its interval has elapsed since the last compaction.
+	i.compactBlocksWithCustomInterval(context.Background())
+	assert.Equal(t, float64(1), triggered())
+
+	i.compactBlocksWithCustomInterval(context.Background())
+	assert.Equal(t, float64(1), triggered())
+
+	i.getTSDB(userID).lastHeadCompaction.Store(time.Now().Add(-2 * time.Hour).UnixNano())
+	i.compactBlocksWithCustomInterval(context.Background())
+	assert.Equal(t, float64(2), triggered())
+
+	// Forced compactions are not affected.
+	i.compactBlocks(context.Background(), true, nil)
+	assert.Equal(t, float64(3), triggered())
+}
+
 func TestIngester_closeAndDeleteUserTSDBIfIdle_shouldNotCloseTSDBIfShippingIsInProgress(t *testing.T) {
 	ctx := context.Background()
 	cfg := defaultIngesterTestConfig(t)
@@ -4083,6 +4507,140 @@ func Test_Ingester_UserStats(t *testing.T) {
 	assert.Equal(t, uint64(3), res.NumSeries)
 }
 
+func Test_Ingester_UserStats_NativeHistograms(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.ActiveSeriesMetricsEnabled = true
+	registry := prometheus.NewRegistry()
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorage(t, cfg, registry)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	// Push a float series, and two native histogram series with 20 buckets and a schema of 2 and 0.
+	ctx := user.InjectOrgID(context.Background(), "test")
+	floatHistogram := histogram_util.GenerateTestFloatHistogram(1)
+	floatHistogram.Schema = 0
+	req := cortexpb.ToWriteRequest(
+		[]labels.Labels{labels.FromStrings(labels.MetricName, "test_hist_1"), labels.FromStrings(labels.MetricName, "test_hist_2")},
+		nil,
+		nil,
+		[]cortexpb.Histogram{
+			cortexpb.HistogramToHistogramProto(100000, histogram_util.GenerateTestHistogram(1)),
+			cortexpb.FloatHistogramToHistogramProto(100000, floatHistogram),
+		},
+		cortexpb.API)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	req, _ = mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "test_1"}}, 1, 100000)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+
+	res, err := i.UserStats(ctx, &client.UserStatsRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), res.ActiveSeries)
+	assert.Equal(t, uint64(2), res.ActiveNativeHistogramSeries)
+	assert.Equal(t, uint64(40), res.ActiveNativeHistogramBuckets)
+	assert.Equal(t, float64(1), res.ActiveNativeHistogramAverageSchema)
+
+	i.updateActiveSeries(ctx)
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+		# HELP cortex_ingester_active_native_histogram_average_schema Average schema of the last sample of the currently active native histogram series per user.
+		# TYPE cortex_ingester_active_native_histogram_average_schema gauge
+		cortex_ingester_active_native_histogram_average_schema{user="test"} 1
+		# HELP cortex_ingester_active_native_histogram_buckets Total number of buckets of the last sample of the currently active native histogram series per user.
+		# TYPE cortex_ingester_active_native_histogram_buckets gauge
+		cortex_ingester_active_native_histogram_buckets{user="test"} 40
+		# HELP cortex_ingester_active_native_histogram_series Number of currently active native histogram series per user.
+		# TYPE cortex_ingester_active_native_histogram_series gauge
+		cortex_ingester_active_native_histogram_series{user="test"} 2
+	`), "cortex_ingester_active_native_histogram_series", "cortex_ingester_active_native_histogram_buckets", "cortex_ingester_active_native_histogram_average_schema"))
+}
+
+func Test_Ingester_TSDBStatus(t *testing.T) {
+	series := []struct {
+		lbls      labels.Labels
+		value     float64
+		timestamp int64
+	}{
+		{labels.Labels{{Name: labels.MetricName, Value: "test_1"}, {Name: "status", Value: "200"}}, 1, 100000},
+		{labels.Labels{{Name: labels.MetricName, Value: "test_1"}, {Name: "status", Value: "500"}}, 1, 110000},
+		{labels.Labels{{Name: labels.MetricName, Value: "test_2"}}, 2, 200000},
+	}
+
+	// Create ingester
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+
+	// Should return an empty response if the tenant has no TSDB.
+	res, err := i.TSDBStatus(ctx, &client.TSDBStatusRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &client.TSDBStatusResponse{}, res)
+
+	// Push series
+	for _, series := range series {
+		req, _ := mockWriteRequest(t, series.lbls, series.value, series.timestamp)
+		_, err := i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+
+	res, err = i.TSDBStatus(ctx, &client.TSDBStatusRequest{Limit: 1})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), res.NumSeries)
+	assert.Equal(t, uint64(3), res.ChunkCount)
+	assert.Equal(t, int64(100000), res.MinTime)
+	assert.Equal(t, int64(200000), res.MaxTime)
+	assert.Equal(t, []client.TSDBStatistic{{Name: "test_1", Value: 2}}, res.SeriesCountByMetricName)
+	assert.Len(t, res.LabelValueCountByLabelName, 1)
+}
+
+func Test_Ingester_PrewarmTenant(t *testing.T) {
+	i, err := prepareIngesterWithBlocksStorage(t, defaultIngesterTestConfig(t), prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	// Wait until it's ACTIVE
+	test.Poll(t, 1*time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	require.Nil(t, i.getTSDB("test"))
+
+	res, err := i.PrewarmTenant(ctx, &client.PrewarmTenantRequest{})
+	require.NoError(t, err)
+	assert.True(t, res.Created)
+	require.NotNil(t, i.getTSDB("test"))
+
+	// Should be a no-op if the TSDB already exists.
+	res, err = i.PrewarmTenant(ctx, &client.PrewarmTenantRequest{})
+	require.NoError(t, err)
+	assert.False(t, res.Created)
+
+	// The pre-warmed TSDB should be used to ingest the series.
+	req, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "test"}}, 1, 100000)
+	_, err = i.Push(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), i.getTSDB("test").Head().NumSeries())
+}
+
 func Test_Ingester_AllUserStats(t *testing.T) {
 	series := []struct {
 		user      string
@@ -4855,6 +5413,7 @@ func TestIngester_instanceLimitsMetrics(t *testing.T) {
 		# HELP cortex_ingester_instance_limits Instance limits used by this ingester.
 		# TYPE cortex_ingester_instance_limits gauge
 		cortex_ingester_instance_limits{limit="max_inflight_push_requests"} 0
+		cortex_ingester_instance_limits{limit="max_inflight_rule_push_requests"} 0
 		cortex_ingester_instance_limits{limit="max_ingestion_rate"} 10
 		cortex_ingester_instance_limits{limit="max_series"} 30
 		cortex_ingester_instance_limits{limit="max_tenants"} 20
@@ -4867,6 +5426,7 @@ func TestIngester_instanceLimitsMetrics(t *testing.T) {
 		# HELP cortex_ingester_instance_limits Instance limits used by this ingester.
 		# TYPE cortex_ingester_instance_limits gauge
 		cortex_ingester_instance_limits{limit="max_inflight_push_requests"} 0
+		cortex_ingester_instance_limits{limit="max_inflight_rule_push_requests"} 0
 		cortex_ingester_instance_limits{limit="max_ingestion_rate"} 10
 		cortex_ingester_instance_limits{limit="max_series"} 2000
 		cortex_ingester_instance_limits{limit="max_tenants"} 1000
@@ -4925,6 +5485,58 @@ func TestIngester_inflightPushRequests(t *testing.T) {
 	require.NoError(t, g.Wait())
 }
 
+func TestIngester_inflightRulePushRequests(t *testing.T) {
+	tests := map[string]struct {
+		maxInflightRulePushRequests int64
+		source                      cortexpb.WriteRequest_SourceEnum
+		expectedErr                 error
+	}{
+		"push from the API shares the inflight budget": {
+			maxInflightRulePushRequests: 1,
+			source:                      cortexpb.API,
+			expectedErr:                 errTooManyInflightPushRequests,
+		},
+		"push from the ruler shares the inflight budget if no separate budget is configured": {
+			source:      cortexpb.RULE,
+			expectedErr: errTooManyInflightPushRequests,
+		},
+		"push from the ruler uses its own inflight budget": {
+			maxInflightRulePushRequests: 1,
+			source:                      cortexpb.RULE,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits := InstanceLimits{MaxInflightPushRequests: 1, MaxInflightRulePushRequests: testData.maxInflightRulePushRequests}
+
+			cfg := defaultIngesterTestConfig(t)
+			cfg.InstanceLimitsFn = func() *InstanceLimits { return &limits }
+			cfg.LifecyclerConfig.JoinAfter = 0
+
+			i, err := prepareIngesterWithBlocksStorage(t, cfg, prometheus.NewRegistry())
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			test.Poll(t, 100*time.Millisecond, ring.ACTIVE, func() interface{} {
+				return i.lifecycler.GetState()
+			})
+
+			// Simulate the ingester being saturated by raw ingestion.
+			i.inflightPushRequests.Inc()
+			defer i.inflightPushRequests.Dec()
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			req := generateSamplesForLabel(labels.FromStrings(labels.MetricName, "test"), 1)
+			req.Source = testData.source
+
+			_, err = i.Push(ctx, req)
+			require.Equal(t, testData.expectedErr, err)
+		})
+	}
+}
+
 func TestIngester_MaxExemplarsFallBack(t *testing.T) {
 	// Create ingester.
 	cfg := defaultIngesterTestConfig(t)
@@ -3,10 +3,12 @@ package ingester
 import (
 	"fmt"
 	"math"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
@@ -37,11 +39,13 @@ type RingCount interface {
 type Limiter struct {
 	limits                 *validation.Overrides
 	ring                   RingCount
-	replicationFactor      int
+	ringCfg                ring.Config
 	shuffleShardingEnabled bool
 	shardByAllLabels       bool
-	zoneAwarenessEnabled   bool
-	AdminLimitMessage      string
+	// The number of shards of the series sharing the values of the labels they are sharded by,
+	// 0 if the series are not sharded by labels.
+	shardByLabelsShardCount int
+	AdminLimitMessage       string
 }
 
 // NewLimiter makes a new in-memory series limiter
@@ -50,18 +54,18 @@ func NewLimiter(
 	ring RingCount,
 	shardingStrategy string,
 	shardByAllLabels bool,
-	replicationFactor int,
-	zoneAwarenessEnabled bool,
+	shardByLabelsShardCount int,
+	ringCfg ring.Config,
 	AdminLimitMessage string,
 ) *Limiter {
 	return &Limiter{
-		limits:                 limits,
-		ring:                   ring,
-		replicationFactor:      replicationFactor,
-		shuffleShardingEnabled: shardingStrategy == util.ShardingStrategyShuffle,
-		shardByAllLabels:       shardByAllLabels,
-		zoneAwarenessEnabled:   zoneAwarenessEnabled,
-		AdminLimitMessage:      AdminLimitMessage,
+		limits:                  limits,
+		ring:                    ring,
+		ringCfg:                 ringCfg,
+		shuffleShardingEnabled:  shardingStrategy == util.ShardingStrategyShuffle,
+		shardByAllLabels:        shardByAllLabels,
+		shardByLabelsShardCount: shardByLabelsShardCount,
+		AdminLimitMessage:       AdminLimitMessage,
 	}
 }
 
@@ -95,6 +99,23 @@ func (l *Limiter) AssertMaxSeriesPerUser(userID string, series int) error {
 	return errMaxSeriesPerUserLimitExceeded
 }
 
+// SeriesPerUserRejectionRatio returns the ratio of the new series to reject for a user who has
+// exceeded the per-user series limit for the input duration. No series are rejected during the
+// grace period, then the ratio linearly increases up to 1 over the ramp-up period.
+func (l *Limiter) SeriesPerUserRejectionRatio(userID string, exceededFor time.Duration) float64 {
+	gracePeriod := l.limits.MaxSeriesPerUserGracePeriod(userID)
+	rampUpPeriod := l.limits.MaxSeriesPerUserRampUpPeriod(userID)
+
+	switch {
+	case exceededFor < gracePeriod:
+		return 0
+	case exceededFor >= gracePeriod+rampUpPeriod:
+		return 1
+	default:
+		return float64(exceededFor-gracePeriod) / float64(rampUpPeriod)
+	}
+}
+
 // AssertMaxMetricsWithMetadataPerUser limit has not been reached compared to the current
 // number of metrics with metadata in input and returns an error if so.
 func (l *Limiter) AssertMaxMetricsWithMetadataPerUser(userID string, metrics int) error {
@@ -113,7 +134,7 @@ func (l *Limiter) AssertMaxSeriesPerLabelSet(userID string, metric labels.Labels
 		maxSeriesFunc := func(string) int {
 			return limit.Limits.MaxSeries
 		}
-		local := l.maxByLocalAndGlobal(userID, maxSeriesFunc, maxSeriesFunc)
+		local := l.maxSeriesByLocalAndGlobal(userID, maxSeriesFunc, maxSeriesFunc)
 		if u, err := f(limit); err != nil {
 			return err
 		} else if u >= local {
@@ -216,7 +237,9 @@ func (l *Limiter) maxSeriesPerMetric(userID string) int {
 	globalLimit := l.limits.MaxGlobalSeriesPerMetric(userID)
 
 	if globalLimit > 0 {
-		if l.shardByAllLabels {
+		if l.shardByLabelsShardCount > 0 {
+			localLimit = minNonZero(localLimit, l.convertGlobalToShardByLabelsLocalLimit(userID, globalLimit))
+		} else if l.shardByAllLabels {
 			// We can assume that series are evenly distributed across ingesters
 			// so we do convert the global limit into a local limit
 			localLimit = minNonZero(localLimit, l.convertGlobalToLocalLimit(userID, globalLimit))
@@ -257,7 +280,7 @@ func (l *Limiter) maxMetadataPerMetric(userID string) int {
 }
 
 func (l *Limiter) maxSeriesPerUser(userID string) int {
-	return l.maxByLocalAndGlobal(
+	return l.maxSeriesByLocalAndGlobal(
 		userID,
 		l.limits.MaxLocalSeriesPerUser,
 		l.limits.MaxGlobalSeriesPerUser,
@@ -294,6 +317,21 @@ func (l *Limiter) maxByLocalAndGlobal(userID string, localLimitFn, globalLimitFn
 	return localLimit
 }
 
+// maxSeriesByLocalAndGlobal is like maxByLocalAndGlobal, but for the limits on the number of series,
+// which are not evenly distributed across ingesters when they are sharded by a subset of labels.
+func (l *Limiter) maxSeriesByLocalAndGlobal(userID string, localLimitFn, globalLimitFn func(string) int) int {
+	if l.shardByLabelsShardCount == 0 {
+		return l.maxByLocalAndGlobal(userID, localLimitFn, globalLimitFn)
+	}
+
+	localLimit := minNonZero(localLimitFn(userID), l.convertGlobalToShardByLabelsLocalLimit(userID, globalLimitFn(userID)))
+	if localLimit == 0 {
+		localLimit = math.MaxInt32
+	}
+
+	return localLimit
+}
+
 func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int {
 	if globalLimit == 0 {
 		return 0
@@ -319,7 +357,21 @@ func (l *Limiter) convertGlobalToLocalLimit(userID string, globalLimit int) int
 		numIngesters = min(numIngesters, util.ShuffleShardExpectedInstances(shardSize, l.getNumZones()))
 	}
 
-	return int((float64(globalLimit) / float64(numIngesters)) * float64(l.replicationFactor))
+	return int((float64(globalLimit) / float64(numIngesters)) * float64(l.getReplicationFactor(userID)))
+}
+
+// convertGlobalToShardByLabelsLocalLimit converts the global limit into a local limit when the series are
+// sharded by the values of a subset of labels. In the worst case, all the series share the same values and
+// are only spread across the replication sets of their shards, so we can configure the per-ingester local
+// limit equal to the global limit divided by the shard count, but not lower than the limit of the series
+// evenly distributed across ingesters.
+func (l *Limiter) convertGlobalToShardByLabelsLocalLimit(userID string, globalLimit int) int {
+	if globalLimit == 0 {
+		return 0
+	}
+
+	localLimit := int(math.Ceil(float64(globalLimit) / float64(l.shardByLabelsShardCount)))
+	return max(localLimit, l.convertGlobalToLocalLimit(userID, globalLimit))
 }
 
 func (l *Limiter) getShardSize(userID string) int {
@@ -330,8 +382,12 @@ func (l *Limiter) getShardSize(userID string) int {
 	return l.limits.IngestionTenantShardSize(userID)
 }
 
+func (l *Limiter) getReplicationFactor(userID string) int {
+	return l.ringCfg.TenantReplicationFactor(l.limits.IngestionReplicationFactor(userID))
+}
+
 func (l *Limiter) getNumZones() int {
-	if l.zoneAwarenessEnabled {
+	if l.ringCfg.ZoneAwarenessEnabled {
 		return max(l.ring.ZonesCount(), 1)
 	}
 	return 1
@@ -5,12 +5,15 @@ import (
 
 	"math"
 	"testing"
+	"time"
 
+	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
@@ -67,6 +70,55 @@ func TestLimiter_maxMetadataPerUser(t *testing.T) {
 	runLimiterMaxFunctionTest(t, applyLimits, runMaxFn, false)
 }
 
+func TestLimiter_ShardByLabels(t *testing.T) {
+	ring := &ringCountMock{}
+	ring.On("HealthyInstancesCount").Return(10)
+	ring.On("ZonesCount").Return(1)
+
+	limits := validation.Limits{
+		MaxGlobalSeriesPerMetric:            1000,
+		MaxGlobalSeriesPerUser:              1000,
+		MaxGlobalMetricsWithMetadataPerUser: 1000,
+		LimitsPerLabelSet: []validation.LimitsPerLabelSet{
+			{Limits: validation.LimitsPerLabelSetEntry{MaxSeries: 1000}, LabelSet: labels.FromStrings("cluster", "a")},
+		},
+	}
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	limiter := NewLimiter(overrides, ring, util.ShardingStrategyDefault, true, 1, ringConfig(3, false), "")
+
+	// With a single shard, all the series may be pushed to the same ingesters, so the global limits are enforced as is.
+	assert.Equal(t, 1000, limiter.maxSeriesPerMetric("test"))
+	assert.Equal(t, 1000, limiter.maxSeriesPerUser("test"))
+	assert.NoError(t, limiter.AssertMaxSeriesPerLabelSet("test", labels.FromStrings("cluster", "a"), func(validation.LimitsPerLabelSet) (int, error) {
+		return 999, nil
+	}))
+	assert.Error(t, limiter.AssertMaxSeriesPerLabelSet("test", labels.FromStrings("cluster", "a"), func(validation.LimitsPerLabelSet) (int, error) {
+		return 1000, nil
+	}))
+
+	// The metadata is still sharded by metric name.
+	assert.Equal(t, 300, limiter.maxMetadataPerUser("test"))
+
+	// The series limits are divided by the shard count.
+	limiter = NewLimiter(overrides, ring, util.ShardingStrategyDefault, true, 2, ringConfig(3, false), "")
+	assert.Equal(t, 500, limiter.maxSeriesPerMetric("test"))
+	assert.Equal(t, 500, limiter.maxSeriesPerUser("test"))
+
+	// But not below the limits of the series evenly distributed across the ingesters.
+	limiter = NewLimiter(overrides, ring, util.ShardingStrategyDefault, true, 8, ringConfig(3, false), "")
+	assert.Equal(t, 300, limiter.maxSeriesPerMetric("test"))
+	assert.Equal(t, 300, limiter.maxSeriesPerUser("test"))
+
+	// The local limit still applies when lower.
+	limits.MaxLocalSeriesPerUser = 200
+	overrides, err = validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+	limiter = NewLimiter(overrides, ring, util.ShardingStrategyDefault, true, 1, ringConfig(3, false), "")
+	assert.Equal(t, 200, limiter.maxSeriesPerUser("test"))
+}
+
 func runLimiterMaxFunctionTest(
 	t *testing.T,
 	applyLimits func(limits *validation.Limits, localLimit, globalLimit int),
@@ -225,12 +277,12 @@ func runLimiterMaxFunctionTest(
 			require.NoError(t, err)
 
 			// Assert on default sharding strategy.
-			limiter := NewLimiter(overrides, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, testData.ringZoneAwarenessEnabled, "")
+			limiter := NewLimiter(overrides, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, testData.ringZoneAwarenessEnabled), "")
 			actual := runMaxFn(limiter)
 			assert.Equal(t, testData.expectedDefaultSharding, actual)
 
 			// Assert on shuffle sharding strategy.
-			limiter = NewLimiter(overrides, ring, util.ShardingStrategyShuffle, testData.shardByAllLabels, testData.ringReplicationFactor, testData.ringZoneAwarenessEnabled, "")
+			limiter = NewLimiter(overrides, ring, util.ShardingStrategyShuffle, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, testData.ringZoneAwarenessEnabled), "")
 			actual = runMaxFn(limiter)
 			assert.Equal(t, testData.expectedShuffleSharding, actual)
 		})
@@ -292,7 +344,7 @@ func TestLimiter_AssertMaxSeriesPerMetric(t *testing.T) {
 			}, nil)
 			require.NoError(t, err)
 
-			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, false), "")
 			actual := limiter.AssertMaxSeriesPerMetric("test", testData.series)
 
 			assert.Equal(t, testData.expected, actual)
@@ -354,7 +406,7 @@ func TestLimiter_AssertMaxMetadataPerMetric(t *testing.T) {
 			}, nil)
 			require.NoError(t, err)
 
-			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, false), "")
 			actual := limiter.AssertMaxMetadataPerMetric("test", testData.metadata)
 
 			assert.Equal(t, testData.expected, actual)
@@ -417,7 +469,7 @@ func TestLimiter_AssertMaxSeriesPerUser(t *testing.T) {
 			}, nil)
 			require.NoError(t, err)
 
-			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, false), "")
 			actual := limiter.AssertMaxSeriesPerUser("test", testData.series)
 
 			assert.Equal(t, testData.expected, actual)
@@ -425,6 +477,114 @@ func TestLimiter_AssertMaxSeriesPerUser(t *testing.T) {
 	}
 }
 
+func TestLimiter_SeriesPerUserRejectionRatio(t *testing.T) {
+	tests := map[string]struct {
+		gracePeriod   time.Duration
+		rampUpPeriod  time.Duration
+		exceededFor   time.Duration
+		expectedRatio float64
+	}{
+		"gradual enforcement disabled": {
+			exceededFor:   0,
+			expectedRatio: 1,
+		},
+		"within the grace period": {
+			gracePeriod:   time.Hour,
+			rampUpPeriod:  time.Hour,
+			exceededFor:   30 * time.Minute,
+			expectedRatio: 0,
+		},
+		"within the ramp-up period": {
+			gracePeriod:   time.Hour,
+			rampUpPeriod:  time.Hour,
+			exceededFor:   90 * time.Minute,
+			expectedRatio: 0.5,
+		},
+		"after the ramp-up period": {
+			gracePeriod:   time.Hour,
+			rampUpPeriod:  time.Hour,
+			exceededFor:   2 * time.Hour,
+			expectedRatio: 1,
+		},
+		"after the grace period without ramp-up": {
+			gracePeriod:   time.Hour,
+			exceededFor:   time.Hour,
+			expectedRatio: 1,
+		},
+		"ramp-up without grace period": {
+			rampUpPeriod:  time.Hour,
+			exceededFor:   15 * time.Minute,
+			expectedRatio: 0.25,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits, err := validation.NewOverrides(validation.Limits{
+				MaxSeriesPerUserGracePeriod:  model.Duration(testData.gracePeriod),
+				MaxSeriesPerUserRampUpPeriod: model.Duration(testData.rampUpPeriod),
+			}, nil)
+			require.NoError(t, err)
+
+			limiter := NewLimiter(limits, nil, util.ShardingStrategyDefault, true, 0, ringConfig(1, false), "")
+			assert.Equal(t, testData.expectedRatio, limiter.SeriesPerUserRejectionRatio("test", testData.exceededFor))
+		})
+	}
+}
+
+func TestLimiter_AssertMaxSeriesPerUserWithTenantReplicationFactor(t *testing.T) {
+	tests := map[string]struct {
+		maxTenantReplicationFactor int
+		tenantReplicationFactor    int
+		expectedLimit              int
+	}{
+		"tenant replication factor not set": {
+			maxTenantReplicationFactor: 5,
+			tenantReplicationFactor:    0,
+			expectedLimit:              300,
+		},
+		"tenant replication factor within bounds": {
+			maxTenantReplicationFactor: 5,
+			tenantReplicationFactor:    1,
+			expectedLimit:              100,
+		},
+		"tenant replication factor above the max bound": {
+			maxTenantReplicationFactor: 5,
+			tenantReplicationFactor:    7,
+			expectedLimit:              500,
+		},
+		"tenant replication factor overrides disabled": {
+			maxTenantReplicationFactor: 0,
+			tenantReplicationFactor:    1,
+			expectedLimit:              300,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			ringCount := &ringCountMock{}
+			ringCount.On("HealthyInstancesCount").Return(10)
+			ringCount.On("ZonesCount").Return(1)
+
+			limits, err := validation.NewOverrides(validation.Limits{
+				MaxGlobalSeriesPerUser:     1000,
+				IngestionReplicationFactor: testData.tenantReplicationFactor,
+			}, nil)
+			require.NoError(t, err)
+
+			cfg := ringConfig(3, false)
+			cfg.MinTenantReplicationFactor = 1
+			cfg.MaxTenantReplicationFactor = testData.maxTenantReplicationFactor
+
+			limiter := NewLimiter(limits, ringCount, util.ShardingStrategyDefault, true, 0, cfg, "")
+			assert.NoError(t, limiter.AssertMaxSeriesPerUser("test", testData.expectedLimit-1))
+			assert.Equal(t, errMaxSeriesPerUserLimitExceeded, limiter.AssertMaxSeriesPerUser("test", testData.expectedLimit))
+		})
+	}
+}
+
 func TestLimiter_AssertMaxSeriesPerLabelSet(t *testing.T) {
 
 	tests := map[string]struct {
@@ -499,7 +659,7 @@ func TestLimiter_AssertMaxSeriesPerLabelSet(t *testing.T) {
 			limits, err := validation.NewOverrides(testData.limits, nil)
 			require.NoError(t, err)
 
-			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, false), "")
 			actual := limiter.AssertMaxSeriesPerLabelSet("test", labels.FromStrings("foo", "bar"), func(set validation.LimitsPerLabelSet) (int, error) {
 				return testData.series, nil
 			})
@@ -564,7 +724,7 @@ func TestLimiter_AssertMaxMetricsWithMetadataPerUser(t *testing.T) {
 			}, nil)
 			require.NoError(t, err)
 
-			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, testData.ringReplicationFactor, false, "")
+			limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, testData.shardByAllLabels, 0, ringConfig(testData.ringReplicationFactor, false), "")
 			actual := limiter.AssertMaxMetricsWithMetadataPerUser("test", testData.metadata)
 
 			assert.Equal(t, testData.expected, actual)
@@ -587,7 +747,7 @@ func TestLimiter_FormatError(t *testing.T) {
 	}, nil)
 	require.NoError(t, err)
 
-	limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, true, 3, false, "please contact administrator to raise it")
+	limiter := NewLimiter(limits, ring, util.ShardingStrategyDefault, true, 0, ringConfig(3, false), "please contact administrator to raise it")
 
 	actual := limiter.FormatError("user-1", errMaxSeriesPerUserLimitExceeded)
 	assert.EqualError(t, actual, "per-user series limit of 100 exceeded, please contact administrator to raise it (local limit: 0 global limit: 100 actual local limit: 100)")
@@ -663,3 +823,10 @@ func (m *ringCountMock) ZonesCount() int {
 	args := m.Called()
 	return args.Int(0)
 }
+
+func ringConfig(replicationFactor int, zoneAwarenessEnabled bool) ring.Config {
+	return ring.Config{
+		ReplicationFactor:    replicationFactor,
+		ZoneAwarenessEnabled: zoneAwarenessEnabled,
+	}
+}
@@ -43,25 +43,37 @@ type ingesterMetrics struct {
 	memMetadataRemovedTotal *prometheus.CounterVec
 
 	activeSeriesPerUser *prometheus.GaugeVec
+	sampleAgePerUser    *prometheus.HistogramVec
 	limitsPerLabelSet   *prometheus.GaugeVec
 	usagePerLabelSet    *prometheus.GaugeVec
 
+	activeNativeHistogramSeriesPerUser        *prometheus.GaugeVec
+	activeNativeHistogramBucketsPerUser       *prometheus.GaugeVec
+	activeNativeHistogramAverageSchemaPerUser *prometheus.GaugeVec
+
+	seriesOverUserLimitCreated *prometheus.CounterVec
+	seriesLimitRejectionRatio  *prometheus.GaugeVec
+
 	// Global limit metrics
-	maxUsersGauge           prometheus.GaugeFunc
-	maxSeriesGauge          prometheus.GaugeFunc
-	maxIngestionRate        prometheus.GaugeFunc
-	ingestionRate           prometheus.GaugeFunc
-	maxInflightPushRequests prometheus.GaugeFunc
-	inflightRequests        prometheus.GaugeFunc
-	inflightQueryRequests   prometheus.GaugeFunc
+	maxUsersGauge               prometheus.GaugeFunc
+	maxSeriesGauge              prometheus.GaugeFunc
+	maxIngestionRate            prometheus.GaugeFunc
+	ingestionRate               prometheus.GaugeFunc
+	maxInflightPushRequests     prometheus.GaugeFunc
+	maxInflightRulePushRequests prometheus.GaugeFunc
+	inflightRequests            prometheus.GaugeFunc
+	inflightRuleRequests        prometheus.GaugeFunc
+	inflightQueryRequests       prometheus.GaugeFunc
 }
 
 func newIngesterMetrics(r prometheus.Registerer,
 	createMetricsConflictingWithTSDB bool,
 	activeSeriesEnabled bool,
+	sampleAgeMetricsEnabled bool,
 	instanceLimitsFn func() *InstanceLimits,
 	ingestionRate *util_math.EwmaRate,
 	inflightPushRequests *atomic.Int64,
+	inflightRulePushRequests *atomic.Int64,
 	maxInflightQueryRequests *util_math.MaxTracker,
 ) *ingesterMetrics {
 	const (
@@ -188,6 +200,17 @@ func newIngesterMetrics(r prometheus.Registerer,
 			return 0
 		}),
 
+		maxInflightRulePushRequests: promauto.With(r).NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        instanceLimits,
+			Help:        instanceLimitsHelp,
+			ConstLabels: map[string]string{limitLabel: "max_inflight_rule_push_requests"},
+		}, func() float64 {
+			if g := instanceLimitsFn(); g != nil {
+				return float64(g.MaxInflightRulePushRequests)
+			}
+			return 0
+		}),
+
 		ingestionRate: promauto.With(r).NewGaugeFunc(prometheus.GaugeOpts{
 			Name: "cortex_ingester_ingestion_rate_samples_per_second",
 			Help: "Current ingestion rate in samples/sec that ingester is using to limit access.",
@@ -208,6 +231,16 @@ func newIngesterMetrics(r prometheus.Registerer,
 			return 0
 		}),
 
+		inflightRuleRequests: promauto.With(r).NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "cortex_ingester_inflight_rule_push_requests",
+			Help: "Current number of inflight push requests from the ruler in ingester, when accounted separately.",
+		}, func() float64 {
+			if inflightRulePushRequests != nil {
+				return float64(inflightRulePushRequests.Load())
+			}
+			return 0
+		}),
+
 		inflightQueryRequests: promauto.With(r).NewGaugeFunc(prometheus.GaugeOpts{
 			Name: "cortex_ingester_max_inflight_query_requests",
 			Help: "Max number of inflight query requests in ingester.",
@@ -228,15 +261,49 @@ func newIngesterMetrics(r prometheus.Registerer,
 			Help: "Current usage per user and labelset.",
 		}, []string{"user", "limit", "labelset"}),
 
+		seriesOverUserLimitCreated: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ingester_series_over_user_limit_created_total",
+			Help: "The total number of series created while the user exceeded the per-user series limit, because the limit is gradually enforced.",
+		}, []string{"user"}),
+
+		seriesLimitRejectionRatio: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_series_limit_rejection_ratio",
+			Help: "The ratio of the new series rejected for users exceeding the per-user series limit, while the limit is gradually enforced.",
+		}, []string{"user"}),
+
 		// Not registered automatically, but only if activeSeriesEnabled is true.
 		activeSeriesPerUser: prometheus.NewGaugeVec(prometheus.GaugeOpts{
 			Name: "cortex_ingester_active_series",
 			Help: "Number of currently active series per user.",
 		}, []string{"user"}),
+		activeNativeHistogramSeriesPerUser: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_active_native_histogram_series",
+			Help: "Number of currently active native histogram series per user.",
+		}, []string{"user"}),
+		activeNativeHistogramBucketsPerUser: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_active_native_histogram_buckets",
+			Help: "Total number of buckets of the last sample of the currently active native histogram series per user.",
+		}, []string{"user"}),
+		activeNativeHistogramAverageSchemaPerUser: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "cortex_ingester_active_native_histogram_average_schema",
+			Help: "Average schema of the last sample of the currently active native histogram series per user.",
+		}, []string{"user"}),
 	}
 
 	if activeSeriesEnabled && r != nil {
 		r.MustRegister(m.activeSeriesPerUser)
+		r.MustRegister(m.activeNativeHistogramSeriesPerUser)
+		r.MustRegister(m.activeNativeHistogramBucketsPerUser)
+		r.MustRegister(m.activeNativeHistogramAverageSchemaPerUser)
+	}
+
+	if sampleAgeMetricsEnabled {
+		m.sampleAgePerUser = promauto.With(r).NewHistogramVec(prometheus.HistogramOpts{
+			Name: "cortex_ingester_ingested_sample_age_seconds",
+			Help: "Age of the samples received by the ingester, computed as the difference between the wall clock and the sample timestamp, per user. Samples with a timestamp in the future have an age of 0.",
+			// From 1s to 12h, covering the out-of-order and out-of-bounds windows.
+			Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 14400, 43200},
+		}, []string{"user"})
 	}
 
 	if createMetricsConflictingWithTSDB {
@@ -258,6 +325,15 @@ func (m *ingesterMetrics) deletePerUserMetrics(userID string) {
 	m.memMetadataCreatedTotal.DeleteLabelValues(userID)
 	m.memMetadataRemovedTotal.DeleteLabelValues(userID)
 	m.activeSeriesPerUser.DeleteLabelValues(userID)
+	m.activeNativeHistogramSeriesPerUser.DeleteLabelValues(userID)
+	m.activeNativeHistogramBucketsPerUser.DeleteLabelValues(userID)
+	m.activeNativeHistogramAverageSchemaPerUser.DeleteLabelValues(userID)
+	m.seriesOverUserLimitCreated.DeleteLabelValues(userID)
+	m.seriesLimitRejectionRatio.DeleteLabelValues(userID)
+
+	if m.sampleAgePerUser != nil {
+		m.sampleAgePerUser.DeleteLabelValues(userID)
+	}
 
 	if m.memSeriesCreatedTotal != nil {
 		m.memSeriesCreatedTotal.DeleteLabelValues(userID)
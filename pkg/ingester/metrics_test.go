@@ -20,20 +20,25 @@ func TestIngesterMetrics(t *testing.T) {
 	maxInflightQueryRequests := util_math.MaxTracker{}
 	maxInflightQueryRequests.Track(98)
 	inflightPushRequests.Store(14)
+	inflightRulePushRequests := &atomic.Int64{}
+	inflightRulePushRequests.Store(3)
 
 	m := newIngesterMetrics(mainReg,
 		false,
 		true,
+		false,
 		func() *InstanceLimits {
 			return &InstanceLimits{
-				MaxIngestionRate:        12,
-				MaxInMemoryTenants:      1,
-				MaxInMemorySeries:       11,
-				MaxInflightPushRequests: 6,
+				MaxIngestionRate:            12,
+				MaxInMemoryTenants:          1,
+				MaxInMemorySeries:           11,
+				MaxInflightPushRequests:     6,
+				MaxInflightRulePushRequests: 2,
 			}
 		},
 		ingestionRate,
 		inflightPushRequests,
+		inflightRulePushRequests,
 		&maxInflightQueryRequests)
 
 	require.NotNil(t, m)
@@ -42,6 +47,9 @@ func TestIngesterMetrics(t *testing.T) {
 			# HELP cortex_ingester_inflight_push_requests Current number of inflight push requests in ingester.
 			# TYPE cortex_ingester_inflight_push_requests gauge
 			cortex_ingester_inflight_push_requests 14
+			# HELP cortex_ingester_inflight_rule_push_requests Current number of inflight push requests from the ruler in ingester, when accounted separately.
+			# TYPE cortex_ingester_inflight_rule_push_requests gauge
+			cortex_ingester_inflight_rule_push_requests 3
 			# HELP cortex_ingester_max_inflight_query_requests Max number of inflight query requests in ingester.
 			# TYPE cortex_ingester_max_inflight_query_requests gauge
 			cortex_ingester_max_inflight_query_requests 98
@@ -69,6 +77,7 @@ func TestIngesterMetrics(t *testing.T) {
 			# HELP cortex_ingester_instance_limits Instance limits used by this ingester.
 			# TYPE cortex_ingester_instance_limits gauge
 			cortex_ingester_instance_limits{limit="max_inflight_push_requests"} 6
+			cortex_ingester_instance_limits{limit="max_inflight_rule_push_requests"} 2
 			cortex_ingester_instance_limits{limit="max_ingestion_rate"} 12
 			cortex_ingester_instance_limits{limit="max_series"} 11
 			cortex_ingester_instance_limits{limit="max_tenants"} 1
@@ -0,0 +1,132 @@
+package ingester
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/sony/gobreaker"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+var (
+	errInvalidPushCircuitBreakerFailureThreshold = errors.New("the push circuit breaker failure threshold must be greater than 0 and lower than or equal to 1")
+	errInvalidPushCircuitBreakerWindow           = errors.New("the push circuit breaker window and open duration must be greater than 0")
+)
+
+// PushCircuitBreakerConfig configures the circuit breaker of the ingester push requests.
+type PushCircuitBreakerConfig struct {
+	Enabled              bool          `yaml:"enabled"`
+	Window               time.Duration `yaml:"window"`
+	MinRequests          uint          `yaml:"min_requests"`
+	FailureThreshold     float64       `yaml:"failure_threshold"`
+	SlowRequestThreshold time.Duration `yaml:"slow_request_threshold"`
+	OpenDuration         time.Duration `yaml:"open_duration"`
+	HalfOpenMaxRequests  uint          `yaml:"half_open_max_requests"`
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet.
+func (cfg *PushCircuitBreakerConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "[Experimental] Enable the circuit breaker of the push requests. When open, the push requests are rejected with a retriable error, so that the distributors shed the load to the other ingesters instead of piling up requests on an unhealthy one.")
+	f.DurationVar(&cfg.Window, prefix+"window", 10*time.Second, "Period over which the failed and slow push requests are counted while the circuit breaker is closed.")
+	f.UintVar(&cfg.MinRequests, prefix+"min-requests", 100, "Minimum number of push requests within the window before the circuit breaker can open.")
+	f.Float64Var(&cfg.FailureThreshold, prefix+"failure-threshold", 0.5, "Ratio of failed or slow push requests within the window, between 0 and 1, above which the circuit breaker opens. Requests rejected because of the tenant's data or limits are not failures.")
+	f.DurationVar(&cfg.SlowRequestThreshold, prefix+"slow-request-threshold", time.Second, "Push requests taking longer than this are counted as failures. 0 to only count the errors.")
+	f.DurationVar(&cfg.OpenDuration, prefix+"open-duration", 10*time.Second, "Period of the open state after which the circuit breaker becomes half-open.")
+	f.UintVar(&cfg.HalfOpenMaxRequests, prefix+"half-open-max-requests", 10, "Number of push requests allowed while the circuit breaker is half-open. The circuit breaker closes if all of them succeed.")
+}
+
+func (cfg *PushCircuitBreakerConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.FailureThreshold <= 0 || cfg.FailureThreshold > 1 {
+		return errInvalidPushCircuitBreakerFailureThreshold
+	}
+	if cfg.Window <= 0 || cfg.OpenDuration <= 0 {
+		return errInvalidPushCircuitBreakerWindow
+	}
+	return nil
+}
+
+// pushCircuitBreaker rejects the push requests while the ingester is failing or slow to serve
+// a large share of them.
+type pushCircuitBreaker struct {
+	cfg PushCircuitBreakerConfig
+	cb  *gobreaker.TwoStepCircuitBreaker
+
+	state    prometheus.Gauge
+	rejected prometheus.Counter
+}
+
+func newPushCircuitBreaker(cfg PushCircuitBreakerConfig, logger log.Logger, reg prometheus.Registerer) *pushCircuitBreaker {
+	b := &pushCircuitBreaker{
+		cfg: cfg,
+		state: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Name: "cortex_ingester_push_circuit_breaker_state",
+			Help: "State of the circuit breaker of the push requests (0: closed, 1: half-open, 2: open).",
+		}),
+		rejected: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ingester_push_circuit_breaker_rejected_requests_total",
+			Help: "Total number of push requests rejected by the open circuit breaker.",
+		}),
+	}
+
+	b.cb = gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+		Name:        "ingester-push",
+		MaxRequests: uint32(cfg.HalfOpenMaxRequests),
+		Interval:    cfg.Window,
+		Timeout:     cfg.OpenDuration,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.Requests >= uint32(cfg.MinRequests) && float64(counts.TotalFailures)/float64(counts.Requests) > cfg.FailureThreshold
+		},
+		OnStateChange: func(_ string, from, to gobreaker.State) {
+			level.Warn(logger).Log("msg", "push circuit breaker state changed", "from", from, "to", to)
+			b.state.Set(float64(to))
+		},
+	})
+
+	return b
+}
+
+// push calls the given push function unless the circuit breaker is open.
+func (b *pushCircuitBreaker) push(ctx context.Context, req *cortexpb.WriteRequest, push func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)) (*cortexpb.WriteResponse, error) {
+	done, err := b.cb.Allow()
+	if err != nil {
+		b.rejected.Inc()
+		// Release the request, which is never going to be pushed.
+		cortexpb.ReuseSlice(req.Timeseries)
+		return nil, httpgrpc.Errorf(http.StatusServiceUnavailable, "cannot push: ingester circuit breaker is %s", b.cb.State())
+	}
+
+	start := time.Now()
+	resp, err := push(ctx, req)
+	done(b.isSuccessful(ctx, err, time.Since(start)))
+
+	return resp, err
+}
+
+// isSuccessful returns whether the push request counts as a success for the circuit breaker. The
+// requests rejected because of the tenant's data or limits, and the requests canceled by the
+// caller, are not the ingester's fault. The requests exceeding the caller's deadline are failures,
+// since they're the symptom of a slow ingester.
+func (b *pushCircuitBreaker) isSuccessful(ctx context.Context, err error, took time.Duration) bool {
+	if b.cfg.SlowRequestThreshold > 0 && took > b.cfg.SlowRequestThreshold {
+		return false
+	}
+	if err == nil || errors.Is(ctx.Err(), context.Canceled) || errors.Is(err, context.Canceled) {
+		return true
+	}
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok && resp.Code/100 == 4 {
+		return true
+	}
+	return false
+}
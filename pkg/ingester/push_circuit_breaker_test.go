@@ -0,0 +1,158 @@
+package ingester
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+func TestPushCircuitBreaker(t *testing.T) {
+	cfg := PushCircuitBreakerConfig{
+		Enabled:              true,
+		Window:               time.Minute,
+		MinRequests:          10,
+		FailureThreshold:     0.5,
+		SlowRequestThreshold: 50 * time.Millisecond,
+		OpenDuration:         100 * time.Millisecond,
+		HalfOpenMaxRequests:  1,
+	}
+
+	var (
+		pushErr   error
+		pushDelay time.Duration
+		pushed    int
+	)
+	push := func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		pushed++
+		time.Sleep(pushDelay)
+		return &cortexpb.WriteResponse{}, pushErr
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	b := newPushCircuitBreaker(cfg, log.NewNopLogger(), reg)
+	ctx := context.Background()
+
+	// The errors caused by the tenant's data or limits don't open the circuit breaker.
+	pushErr = httpgrpc.Errorf(http.StatusBadRequest, "sample out of bounds")
+	for n := 0; n < 20; n++ {
+		_, err := b.push(ctx, &cortexpb.WriteRequest{}, push)
+		require.Equal(t, pushErr, err)
+	}
+	assert.Equal(t, float64(0), testutil.ToFloat64(b.state))
+
+	// The internal errors open the circuit breaker once above the threshold.
+	pushErr = errors.New("failed to append")
+	for n := 0; n < 21; n++ {
+		_, err := b.push(ctx, &cortexpb.WriteRequest{}, push)
+		require.Equal(t, pushErr, err)
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(b.state))
+
+	// The requests are rejected with a retriable error while the circuit breaker is open.
+	pushed = 0
+	_, err := b.push(ctx, &cortexpb.WriteRequest{}, push)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	assert.Equal(t, int32(http.StatusServiceUnavailable), resp.Code)
+	assert.Equal(t, 0, pushed)
+	assert.Equal(t, float64(1), testutil.ToFloat64(b.rejected))
+
+	// Once half-open, a slow request opens the circuit breaker again.
+	time.Sleep(cfg.OpenDuration)
+	pushErr, pushDelay = nil, 2*cfg.SlowRequestThreshold
+	_, err = b.push(ctx, &cortexpb.WriteRequest{}, push)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), testutil.ToFloat64(b.state))
+
+	// Once half-open, a successful request closes the circuit breaker.
+	time.Sleep(cfg.OpenDuration)
+	pushDelay = 0
+	_, err = b.push(ctx, &cortexpb.WriteRequest{}, push)
+	require.NoError(t, err)
+	assert.Equal(t, float64(0), testutil.ToFloat64(b.state))
+}
+
+func TestPushCircuitBreaker_IsSuccessful(t *testing.T) {
+	b := &pushCircuitBreaker{cfg: PushCircuitBreakerConfig{SlowRequestThreshold: time.Second}}
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	expiredCtx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+
+	for name, tc := range map[string]struct {
+		ctx      context.Context
+		err      error
+		took     time.Duration
+		expected bool
+	}{
+		"success": {
+			ctx:      context.Background(),
+			expected: true,
+		},
+		"slow success": {
+			ctx:  context.Background(),
+			took: 2 * time.Second,
+		},
+		"client error": {
+			ctx:      context.Background(),
+			err:      httpgrpc.Errorf(http.StatusBadRequest, "sample out of bounds"),
+			expected: true,
+		},
+		"internal error": {
+			ctx: context.Background(),
+			err: errors.New("failed to append"),
+		},
+		"canceled by the caller": {
+			ctx:      canceledCtx,
+			err:      context.Canceled,
+			expected: true,
+		},
+		"deadline exceeded": {
+			ctx: expiredCtx,
+			err: context.DeadlineExceeded,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, b.isSuccessful(tc.ctx, tc.err, tc.took))
+		})
+	}
+}
+
+func TestPushCircuitBreakerConfig_Validate(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg      PushCircuitBreakerConfig
+		expected error
+	}{
+		"disabled": {
+			cfg: PushCircuitBreakerConfig{},
+		},
+		"valid": {
+			cfg: PushCircuitBreakerConfig{Enabled: true, Window: time.Second, OpenDuration: time.Second, FailureThreshold: 1},
+		},
+		"failure threshold greater than 1": {
+			cfg:      PushCircuitBreakerConfig{Enabled: true, Window: time.Second, OpenDuration: time.Second, FailureThreshold: 1.5},
+			expected: errInvalidPushCircuitBreakerFailureThreshold,
+		},
+		"zero window": {
+			cfg:      PushCircuitBreakerConfig{Enabled: true, OpenDuration: time.Second, FailureThreshold: 0.5},
+			expected: errInvalidPushCircuitBreakerWindow,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.cfg.Validate())
+		})
+	}
+}
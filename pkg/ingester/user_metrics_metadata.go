@@ -7,6 +7,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
@@ -96,6 +97,24 @@ func (mm *userMetricsMetadata) toClientMetadata() []*cortexpb.MetricMetadata {
 	return r
 }
 
+// toBlockMetadata returns the metadata to record in the meta.json of the shipped blocks.
+func (mm *userMetricsMetadata) toBlockMetadata() []cortex_tsdb.BlockMetricMetadata {
+	mm.mtx.RLock()
+	defer mm.mtx.RUnlock()
+	r := make([]cortex_tsdb.BlockMetricMetadata, 0, len(mm.metricToMetadata))
+	for _, set := range mm.metricToMetadata {
+		for m := range set {
+			r = append(r, cortex_tsdb.BlockMetricMetadata{
+				Metric: m.MetricFamilyName,
+				Type:   string(cortexpb.MetricMetadataMetricTypeToMetricType(m.GetType())),
+				Help:   m.Help,
+				Unit:   m.Unit,
+			})
+		}
+	}
+	return r
+}
+
 type metricMetadataSet map[cortexpb.MetricMetadata]time.Time
 
 // If deadline is zero time, all metrics are purged.
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/cortexproject/cortex/pkg/ring"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
@@ -77,7 +78,7 @@ func TestMetricCounter(t *testing.T) {
 
 			// We're testing code that's not dependent on sharding strategy, replication factor, etc. To simplify the test,
 			// we use local limit only.
-			limiter := NewLimiter(overrides, nil, util.ShardingStrategyDefault, true, 3, false, "")
+			limiter := NewLimiter(overrides, nil, util.ShardingStrategyDefault, true, 0, ring.Config{ReplicationFactor: 3}, "")
 			mc := newMetricCounter(limiter, ignored)
 
 			for i := 0; i < tc.series; i++ {
@@ -0,0 +1,297 @@
+package ingester
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+const (
+	walReplayPending    = "pending"
+	walReplayInProgress = "replaying"
+	walReplayDone       = "done"
+)
+
+var errTSDBReplaying = status.Error(codes.Unavailable, "the WAL of the tenant is still being replayed")
+
+// walReplayProgress tracks the progress of the replay of the WAL of the existing TSDBs on startup,
+// weighted by the size of their WAL.
+type walReplayProgress struct {
+	readyPercentage float64
+	now             func() time.Time
+
+	mtx      sync.Mutex
+	started  time.Time
+	finished time.Time
+	// listed is set once all the tenants with an existing TSDB have been found.
+	listed        bool
+	tenants       map[string]*tenantWALReplay
+	totalBytes    int64
+	replayedBytes int64
+	// ready is closed once the ready percentage of the WAL has been replayed.
+	ready       chan struct{}
+	readyClosed bool
+}
+
+type tenantWALReplay struct {
+	state    string
+	walBytes int64
+	series   uint64
+	started  time.Time
+	finished time.Time
+}
+
+// WALReplayStatus is the progress of the replay of the WAL on startup, returned by the
+// /ingester/wal-replay endpoint.
+type WALReplayStatus struct {
+	State           string                  `json:"state"`
+	Ready           bool                    `json:"ready"`
+	ReadyPercentage float64                 `json:"ready_percentage"`
+	Percentage      float64                 `json:"percentage"`
+	TotalTenants    int                     `json:"total_tenants"`
+	ReplayedTenants int                     `json:"replayed_tenants"`
+	TotalBytes      int64                   `json:"total_bytes"`
+	ReplayedBytes   int64                   `json:"replayed_bytes"`
+	ReplayedSeries  uint64                  `json:"replayed_series"`
+	ElapsedSeconds  float64                 `json:"elapsed_seconds"`
+	ETASeconds      float64                 `json:"eta_seconds"`
+	Tenants         []TenantWALReplayStatus `json:"tenants"`
+}
+
+// TenantWALReplayStatus is the progress of the replay of the WAL of a tenant.
+type TenantWALReplayStatus struct {
+	UserID          string  `json:"user_id"`
+	State           string  `json:"state"`
+	WALBytes        int64   `json:"wal_bytes"`
+	Series          uint64  `json:"series"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+func newWALReplayProgress(readyPercentage float64) *walReplayProgress {
+	return &walReplayProgress{
+		readyPercentage: readyPercentage,
+		now:             time.Now,
+		tenants:         map[string]*tenantWALReplay{},
+		ready:           make(chan struct{}),
+	}
+}
+
+// begin is called when the replay starts.
+func (p *walReplayProgress) begin() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.started = p.now()
+}
+
+// add registers a tenant whose WAL has to be replayed.
+func (p *walReplayProgress) add(userID string, walBytes int64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.tenants[userID] = &tenantWALReplay{state: walReplayPending, walBytes: walBytes}
+	p.totalBytes += walBytes
+}
+
+// listingDone is called once all the tenants with an existing TSDB have been added.
+func (p *walReplayProgress) listingDone() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.listed = true
+	p.updateLocked()
+}
+
+func (p *walReplayProgress) start(userID string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if t, ok := p.tenants[userID]; ok {
+		t.state = walReplayInProgress
+		t.started = p.now()
+	}
+}
+
+// finish is called once the TSDB of the tenant has been opened, with the number of series replayed.
+func (p *walReplayProgress) finish(userID string, series uint64) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	t, ok := p.tenants[userID]
+	if !ok || t.state == walReplayDone {
+		return
+	}
+	t.state = walReplayDone
+	t.series = series
+	t.finished = p.now()
+	p.replayedBytes += t.walBytes
+	p.updateLocked()
+}
+
+// replaying returns whether the TSDB of the tenant exists on disk but hasn't been opened yet.
+func (p *walReplayProgress) replaying(userID string) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	t, ok := p.tenants[userID]
+	return ok && t.state != walReplayDone
+}
+
+// readyChan returns a channel closed once the ready percentage of the WAL has been replayed.
+func (p *walReplayProgress) readyChan() <-chan struct{} {
+	return p.ready
+}
+
+func (p *walReplayProgress) updateLocked() {
+	if !p.listed {
+		return
+	}
+	if p.replayedTenantsLocked() == len(p.tenants) {
+		p.finished = p.now()
+	}
+	if !p.readyClosed && p.percentageLocked() >= p.readyPercentage {
+		p.readyClosed = true
+		close(p.ready)
+	}
+}
+
+// percentageLocked returns the percentage of the WAL replayed. Each tenant accounts for one more
+// byte than its WAL, so that the tenants with an empty WAL are accounted too.
+func (p *walReplayProgress) percentageLocked() float64 {
+	total := p.totalBytes + int64(len(p.tenants))
+	if total == 0 {
+		if p.listed {
+			return 100
+		}
+		return 0
+	}
+	return float64(p.replayedBytes+int64(p.replayedTenantsLocked())) / float64(total) * 100
+}
+
+func (p *walReplayProgress) replayedTenantsLocked() int {
+	replayed := 0
+	for _, t := range p.tenants {
+		if t.state == walReplayDone {
+			replayed++
+		}
+	}
+	return replayed
+}
+
+func (p *walReplayProgress) report() WALReplayStatus {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	now := p.now()
+	s := WALReplayStatus{
+		State:           walReplayPending,
+		Ready:           p.readyClosed,
+		ReadyPercentage: p.readyPercentage,
+		Percentage:      p.percentageLocked(),
+		TotalTenants:    len(p.tenants),
+		ReplayedTenants: p.replayedTenantsLocked(),
+		TotalBytes:      p.totalBytes,
+		ReplayedBytes:   p.replayedBytes,
+		Tenants:         make([]TenantWALReplayStatus, 0, len(p.tenants)),
+	}
+
+	switch {
+	case !p.finished.IsZero():
+		s.State = walReplayDone
+		s.ElapsedSeconds = p.finished.Sub(p.started).Seconds()
+	case !p.started.IsZero():
+		s.State = walReplayInProgress
+		s.ElapsedSeconds = now.Sub(p.started).Seconds()
+		// The remaining time is estimated from the replay throughput so far.
+		if p.replayedBytes > 0 && p.listed {
+			s.ETASeconds = s.ElapsedSeconds * float64(p.totalBytes-p.replayedBytes) / float64(p.replayedBytes)
+		}
+	}
+
+	for userID, t := range p.tenants {
+		ts := TenantWALReplayStatus{
+			UserID:   userID,
+			State:    t.state,
+			WALBytes: t.walBytes,
+			Series:   t.series,
+		}
+		switch t.state {
+		case walReplayDone:
+			ts.DurationSeconds = t.finished.Sub(t.started).Seconds()
+		case walReplayInProgress:
+			ts.DurationSeconds = now.Sub(t.started).Seconds()
+		}
+		s.ReplayedSeries += t.series
+		s.Tenants = append(s.Tenants, ts)
+	}
+	sort.Slice(s.Tenants, func(i, j int) bool { return s.Tenants[i].UserID < s.Tenants[j].UserID })
+
+	return s
+}
+
+// walSize returns the size of the WAL and WBL of the TSDB in the directory.
+func walSize(dir string) int64 {
+	size := int64(0)
+	for _, d := range []string{filepath.Join(dir, "wal"), filepath.Join(dir, wlog.WblDirName)} {
+		_ = filepath.Walk(d, func(_ string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				size += info.Size()
+			}
+			return nil
+		})
+	}
+	return size
+}
+
+// openExistingTSDBUntilReady opens the existing TSDBs, and returns once the ready percentage of their
+// WAL has been replayed. The remaining TSDBs are opened in background, and their tenants can't be pushed
+// or queried until then.
+func (i *Ingester) openExistingTSDBUntilReady(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		i.backgroundReplayErr = i.openExistingTSDB(ctx)
+	}()
+
+	select {
+	case <-done:
+		return i.backgroundReplayErr
+	case <-i.walReplay.readyChan():
+		i.backgroundReplayDone = done
+		return nil
+	}
+}
+
+// checkTSDBReplayed returns an error if the WAL of the tenant is still being replayed.
+func (i *Ingester) checkTSDBReplayed(userID string) error {
+	if i.walReplay.replaying(userID) {
+		return errTSDBReplaying
+	}
+	return nil
+}
+
+// WALReplayHandler reports the progress of the replay of the WAL of the existing TSDBs on startup.
+func (i *Ingester) WALReplayHandler(w http.ResponseWriter, _ *http.Request) {
+	util.WriteJSONResponse(w, i.walReplay.report())
+}
+
+// waitBackgroundReplay waits for the TSDBs opened in background on startup, if any, and returns the
+// error of their replay.
+func (i *Ingester) waitBackgroundReplay() error {
+	if i.backgroundReplayDone == nil {
+		return nil
+	}
+	<-i.backgroundReplayDone
+	return errors.Wrap(i.backgroundReplayErr, "opening existing TSDBs")
+}
@@ -0,0 +1,166 @@
+package ingester
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/ring"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestWALReplayProgress(t *testing.T) {
+	now := time.Unix(0, 0)
+	p := newWALReplayProgress(50)
+	p.now = func() time.Time { return now }
+
+	p.begin()
+	p.add("user-1", 299)
+	p.add("user-2", 98)
+	p.add("user-3", 0)
+
+	// The ready percentage is only checked once all the tenants are known.
+	p.start("user-1")
+	now = now.Add(10 * time.Second)
+	p.finish("user-1", 30)
+	assertNotReady(t, p)
+
+	p.listingDone()
+	assertReady(t, p)
+
+	assert.True(t, p.replaying("user-2"))
+	assert.False(t, p.replaying("user-1"))
+	assert.False(t, p.replaying("user-4"))
+
+	p.start("user-2")
+	now = now.Add(5 * time.Second)
+	assert.Equal(t, WALReplayStatus{
+		State:           walReplayInProgress,
+		Ready:           true,
+		ReadyPercentage: 50,
+		Percentage:      75,
+		TotalTenants:    3,
+		ReplayedTenants: 1,
+		TotalBytes:      397,
+		ReplayedBytes:   299,
+		ReplayedSeries:  30,
+		ElapsedSeconds:  15,
+		ETASeconds:      15 * 98 / 299.0,
+		Tenants: []TenantWALReplayStatus{
+			{UserID: "user-1", State: walReplayDone, WALBytes: 299, Series: 30, DurationSeconds: 10},
+			{UserID: "user-2", State: walReplayInProgress, WALBytes: 98, DurationSeconds: 5},
+			{UserID: "user-3", State: walReplayPending},
+		},
+	}, p.report())
+
+	p.finish("user-2", 10)
+	p.start("user-3")
+	p.finish("user-3", 0)
+	now = now.Add(time.Minute)
+
+	report := p.report()
+	assert.Equal(t, walReplayDone, report.State)
+	assert.Equal(t, float64(100), report.Percentage)
+	assert.Equal(t, float64(15), report.ElapsedSeconds)
+	assert.Equal(t, uint64(40), report.ReplayedSeries)
+}
+
+func TestWALReplayProgress_NoTenants(t *testing.T) {
+	p := newWALReplayProgress(100)
+	p.begin()
+	assertNotReady(t, p)
+
+	p.listingDone()
+	assertReady(t, p)
+	assert.Equal(t, walReplayDone, p.report().State)
+}
+
+func TestIngester_WALReplay(t *testing.T) {
+	cfg := defaultIngesterTestConfig(t)
+	cfg.LifecyclerConfig.JoinAfter = 0
+	dataDir := t.TempDir()
+
+	// Push some series for several tenants, and stop the ingester keeping their WAL on disk.
+	i, err := prepareIngesterWithBlocksStorageAndLimits(t, cfg, defaultLimitsTestConfig(), nil, dataDir, prometheus.NewRegistry(), true)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	test.Poll(t, time.Second, ring.ACTIVE, func() interface{} {
+		return i.lifecycler.GetState()
+	})
+
+	for _, userID := range []string{"user-1", "user-2", "user-3"} {
+		ctx := user.InjectOrgID(context.Background(), userID)
+		req, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "foo"}}, 1, 1000)
+		_, err = i.Push(ctx, req)
+		require.NoError(t, err)
+	}
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), i))
+
+	// Restart the ingester, which is ready once a third of the WAL has been replayed.
+	cfg.WALReplayReadyPercentage = 30
+	i, err = prepareIngesterWithBlocksStorageAndLimits(t, cfg, defaultLimitsTestConfig(), nil, dataDir, prometheus.NewRegistry(), true)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(dataDir, "user-4", "wal"), 0700))
+
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	test.Poll(t, 5*time.Second, walReplayDone, func() interface{} {
+		return i.walReplay.report().State
+	})
+
+	resp := httptest.NewRecorder()
+	i.WALReplayHandler(resp, httptest.NewRequest("GET", "/ingester/wal-replay", nil))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	var status WALReplayStatus
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	assert.True(t, status.Ready)
+	assert.Equal(t, float64(100), status.Percentage)
+	assert.Equal(t, 4, status.TotalTenants)
+	assert.Equal(t, 4, status.ReplayedTenants)
+	assert.Equal(t, uint64(3), status.ReplayedSeries)
+	assert.Positive(t, status.ReplayedBytes)
+	for _, userID := range []string{"user-1", "user-2", "user-3", "user-4"} {
+		assert.NotNil(t, i.getTSDB(userID), userID)
+	}
+
+	// The tenants whose WAL hasn't been replayed yet can't be pushed or queried.
+	i.walReplay.add("user-5", 0)
+	ctx := user.InjectOrgID(context.Background(), "user-5")
+	req, _ := mockWriteRequest(t, labels.Labels{{Name: labels.MetricName, Value: "foo"}}, 1, 1000)
+	_, err = i.Push(ctx, req)
+	assert.ErrorContains(t, err, "the WAL of the tenant is still being replayed")
+	_, err = i.LabelNames(ctx, &client.LabelNamesRequest{})
+	assert.Equal(t, errTSDBReplaying, err)
+	assert.Nil(t, i.getTSDB("user-5"))
+}
+
+func assertReady(t *testing.T, p *walReplayProgress) {
+	select {
+	case <-p.readyChan():
+	default:
+		assert.Fail(t, "the replay should be ready")
+	}
+}
+
+func assertNotReady(t *testing.T, p *walReplayProgress) {
+	select {
+	case <-p.readyChan():
+		assert.Fail(t, "the replay shouldn't be ready")
+	default:
+	}
+}
@@ -0,0 +1,387 @@
+package purger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"flag"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/services"
+)
+
+const (
+	BlocksExportJobRunning = "running"
+	BlocksExportJobDone    = "done"
+	BlocksExportJobFailed  = "failed"
+
+	// The finished export jobs are kept for this period, and at most this number of them per tenant.
+	blocksExportFinishedJobsRetention    = 24 * time.Hour
+	maxBlocksExportFinishedJobsPerTenant = 10
+)
+
+// Config is the config of the purger APIs.
+type Config struct {
+	// ExportStorage is the destination bucket of the blocks export API.
+	ExportStorage bucket.Config `yaml:"export_storage"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	cfg.ExportStorage.RegisterFlagsWithPrefixAndBackend("purger.export-storage.", f, "")
+}
+
+// Validate validates the config.
+func (cfg *Config) Validate() error {
+	if !cfg.ExportEnabled() {
+		return nil
+	}
+	return errors.Wrap(cfg.ExportStorage.Validate(), "invalid purger export storage config")
+}
+
+// ExportEnabled returns whether the blocks export API is enabled.
+func (cfg *Config) ExportEnabled() bool {
+	return cfg.ExportStorage.Backend != ""
+}
+
+// BlocksExportJob is a job exporting the blocks of a tenant to the export storage.
+type BlocksExportJob struct {
+	ID             string            `json:"id"`
+	TenantID       string            `json:"tenant_id"`
+	MinTime        int64             `json:"min_time"`
+	MaxTime        int64             `json:"max_time"`
+	Prefix         string            `json:"prefix"`
+	ExternalLabels map[string]string `json:"external_labels"`
+	State          string            `json:"state"`
+	Error          string            `json:"error,omitempty"`
+	BlocksTotal    int               `json:"blocks_total"`
+	BlocksExported int               `json:"blocks_exported"`
+	StartedAt      time.Time         `json:"started_at"`
+	FinishedAt     time.Time         `json:"finished_at"`
+}
+
+// BlocksExportAPI exports the blocks of a tenant to another bucket, running each export
+// as a tracked background job. The blocks are downloaded and uploaded again, so they're
+// encrypted with the server-side encryption config of the export storage. The jobs are
+// canceled when the service stops.
+type BlocksExportAPI struct {
+	services.Service
+
+	bucketClient objstore.InstrumentedBucket
+	exportClient objstore.Bucket
+	cfgProvider  bucket.TenantConfigProvider
+	logger       log.Logger
+
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	jobsWG     sync.WaitGroup
+
+	jobsMtx sync.Mutex
+	jobs    map[string][]*BlocksExportJob
+}
+
+func NewBlocksExportAPI(storageCfg cortex_tsdb.BlocksStorageConfig, cfg Config, cfgProvider bucket.TenantConfigProvider, logger log.Logger, reg prometheus.Registerer) (*BlocksExportAPI, error) {
+	bucketClient, err := bucket.NewClient(context.Background(), storageCfg.Bucket, "purger-export-source", logger, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create bucket client")
+	}
+
+	exportClient, err := bucket.NewClient(context.Background(), cfg.ExportStorage, "purger-export-destination", logger, reg)
+	if err != nil {
+		return nil, errors.Wrap(err, "create export bucket client")
+	}
+
+	return newBlocksExportAPI(bucketClient, exportClient, cfgProvider, logger), nil
+}
+
+func newBlocksExportAPI(bkt objstore.InstrumentedBucket, exportBkt objstore.Bucket, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *BlocksExportAPI {
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+
+	api := &BlocksExportAPI{
+		bucketClient: bkt,
+		exportClient: exportBkt,
+		cfgProvider:  cfgProvider,
+		logger:       logger,
+		jobsCtx:      jobsCtx,
+		cancelJobs:   cancelJobs,
+		jobs:         map[string][]*BlocksExportJob{},
+	}
+
+	api.Service = services.NewIdleService(nil, api.stopping)
+	return api
+}
+
+func (api *BlocksExportAPI) stopping(_ error) error {
+	api.cancelJobs()
+	api.jobsWG.Wait()
+	return nil
+}
+
+// ExportBlocks starts a job exporting the blocks of the tenant overlapping the requested
+// time range. The blocks are exported to <prefix>/<tenant>/<block> in the export storage,
+// with the requested external labels set (or removed, if the value is empty).
+func (api *BlocksExportAPI) ExportBlocks(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if api.State() != services.Running {
+		http.Error(w, "the blocks export API is not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	minTime, err := util.ParseTimeParam(r, "start", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	maxTime, err := util.ParseTimeParam(r, "end", time.Now().Unix())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if maxTime <= minTime {
+		http.Error(w, "the end time must be after the start time", http.StatusBadRequest)
+		return
+	}
+
+	externalLabels := map[string]string{}
+	for _, l := range r.Form["external_label"] {
+		name, value, ok := strings.Cut(l, "=")
+		if !ok || !model.LabelName(name).IsValid() {
+			http.Error(w, "invalid external label "+l+", expected <name>=<value>", http.StatusBadRequest)
+			return
+		}
+		externalLabels[name] = value
+	}
+
+	job := &BlocksExportJob{
+		ID:             ulid.MustNew(ulid.Now(), rand.Reader).String(),
+		TenantID:       userID,
+		MinTime:        minTime,
+		MaxTime:        maxTime,
+		Prefix:         strings.Trim(r.FormValue("prefix"), "/"),
+		ExternalLabels: externalLabels,
+		State:          BlocksExportJobRunning,
+		StartedAt:      time.Now(),
+	}
+
+	api.jobsMtx.Lock()
+	api.pruneJobs(job.StartedAt)
+	for _, j := range api.jobs[userID] {
+		if j.State == BlocksExportJobRunning {
+			api.jobsMtx.Unlock()
+			http.Error(w, "an export job is already running for the tenant: "+j.ID, http.StatusConflict)
+			return
+		}
+	}
+	api.jobs[userID] = append(api.jobs[userID], job)
+	api.jobsMtx.Unlock()
+
+	level.Info(api.logger).Log("msg", "blocks export job started", "user", userID, "job", job.ID, "min_time", minTime, "max_time", maxTime)
+
+	// The job outlives the request.
+	api.jobsWG.Add(1)
+	go func() {
+		defer api.jobsWG.Done()
+		api.runJob(api.jobsCtx, job)
+	}()
+
+	util.WriteJSONResponse(w, api.jobCopy(job))
+}
+
+// ExportBlocksStatus returns the export jobs of the tenant, or the requested one.
+func (api *BlocksExportAPI) ExportBlocksStatus(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id := r.FormValue("id")
+	result := []BlocksExportJob{}
+
+	api.jobsMtx.Lock()
+	api.pruneJobs(time.Now())
+	for _, j := range api.jobs[userID] {
+		if id == "" || j.ID == id {
+			result = append(result, *j)
+		}
+	}
+	api.jobsMtx.Unlock()
+
+	if id != "" && len(result) == 0 {
+		http.Error(w, "export job not found", http.StatusNotFound)
+		return
+	}
+
+	util.WriteJSONResponse(w, result)
+}
+
+// pruneJobs removes the finished jobs older than the retention, and the oldest ones exceeding the
+// max number of finished jobs per tenant. It must be called with the jobs lock held.
+func (api *BlocksExportAPI) pruneJobs(now time.Time) {
+	for userID, jobs := range api.jobs {
+		var kept []*BlocksExportJob
+		finished := 0
+
+		// The jobs are sorted by start time, so the most recent ones are kept.
+		for i := len(jobs) - 1; i >= 0; i-- {
+			j := jobs[i]
+			if j.State != BlocksExportJobRunning {
+				if now.Sub(j.FinishedAt) > blocksExportFinishedJobsRetention || finished >= maxBlocksExportFinishedJobsPerTenant {
+					continue
+				}
+				finished++
+			}
+			kept = append([]*BlocksExportJob{j}, kept...)
+		}
+
+		if len(kept) == 0 {
+			delete(api.jobs, userID)
+		} else {
+			api.jobs[userID] = kept
+		}
+	}
+}
+
+func (api *BlocksExportAPI) jobCopy(job *BlocksExportJob) BlocksExportJob {
+	api.jobsMtx.Lock()
+	defer api.jobsMtx.Unlock()
+	return *job
+}
+
+func (api *BlocksExportAPI) runJob(ctx context.Context, job *BlocksExportJob) {
+	logger := log.With(api.logger, "user", job.TenantID, "job", job.ID)
+
+	err := api.exportBlocks(ctx, job)
+
+	api.jobsMtx.Lock()
+	job.FinishedAt = time.Now()
+	if err != nil {
+		job.State = BlocksExportJobFailed
+		job.Error = err.Error()
+	} else {
+		job.State = BlocksExportJobDone
+	}
+	exported := job.BlocksExported
+	api.jobsMtx.Unlock()
+
+	if err != nil {
+		level.Error(logger).Log("msg", "blocks export job failed", "exported", exported, "err", err)
+		return
+	}
+	level.Info(logger).Log("msg", "blocks export job completed", "exported", exported)
+}
+
+func (api *BlocksExportAPI) exportBlocks(ctx context.Context, job *BlocksExportJob) error {
+	userBucket := bucket.NewUserBucketClient(job.TenantID, api.bucketClient, api.cfgProvider)
+
+	var metas []metadata.Meta
+	err := userBucket.Iter(ctx, "", func(name string) error {
+		id, err := ulid.Parse(strings.TrimSuffix(name, "/"))
+		if err != nil {
+			return nil
+		}
+
+		// The blocks marked for deletion are not exported.
+		if deleted, err := userBucket.Exists(ctx, path.Join(id.String(), metadata.DeletionMarkFilename)); err != nil || deleted {
+			return err
+		}
+
+		meta, err := block.DownloadMeta(ctx, api.logger, userBucket, id)
+		if userBucket.IsObjNotFoundErr(errors.Cause(err)) {
+			// The block is being uploaded, or partially deleted.
+			level.Warn(api.logger).Log("msg", "skipped block without meta.json", "user", job.TenantID, "job", job.ID, "block", id)
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "read meta of block %s", id)
+		}
+		if meta.MinTime < job.MaxTime && meta.MaxTime > job.MinTime {
+			metas = append(metas, meta)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "list blocks")
+	}
+
+	api.jobsMtx.Lock()
+	job.BlocksTotal = len(metas)
+	api.jobsMtx.Unlock()
+
+	for _, meta := range metas {
+		if err := api.exportBlock(ctx, userBucket, job, meta); err != nil {
+			return errors.Wrapf(err, "export block %s", meta.ULID)
+		}
+
+		api.jobsMtx.Lock()
+		job.BlocksExported++
+		api.jobsMtx.Unlock()
+	}
+	return nil
+}
+
+// exportBlock copies the block files to the export storage. The meta.json is uploaded
+// last, with the external labels of the job, so that a partially exported block has none.
+func (api *BlocksExportAPI) exportBlock(ctx context.Context, userBucket objstore.Bucket, job *BlocksExportJob, meta metadata.Meta) error {
+	blockID := meta.ULID.String()
+	dstDir := path.Join(job.Prefix, job.TenantID, blockID)
+
+	err := userBucket.Iter(ctx, blockID+"/", func(name string) error {
+		file := strings.TrimPrefix(name, blockID+"/")
+		switch file {
+		case metadata.MetaFilename, metadata.DeletionMarkFilename, metadata.NoCompactMarkFilename:
+			return nil
+		}
+
+		r, err := userBucket.Get(ctx, name)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		return api.exportClient.Upload(ctx, path.Join(dstDir, file), r)
+	}, objstore.WithRecursiveIter)
+	if err != nil {
+		return err
+	}
+
+	if meta.Thanos.Labels == nil {
+		meta.Thanos.Labels = map[string]string{}
+	}
+	for name, value := range job.ExternalLabels {
+		if value == "" {
+			delete(meta.Thanos.Labels, name)
+		} else {
+			meta.Thanos.Labels[name] = value
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := meta.Write(&buf); err != nil {
+		return err
+	}
+	return api.exportClient.Upload(ctx, path.Join(dstDir, metadata.MetaFilename), &buf)
+}
@@ -0,0 +1,144 @@
+package purger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/cortexproject/cortex/pkg/util/test"
+)
+
+func TestBlocksExportAPI(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	bkt := objstore.NewInMemBucket()
+	exportBkt := objstore.NewInMemBucket()
+
+	uploadBlock := func(id ulid.ULID, minTime, maxTime int64, deleted bool) {
+		meta := metadata.Meta{}
+		meta.ULID = id
+		meta.MinTime = minTime
+		meta.MaxTime = maxTime
+		meta.Version = metadata.TSDBVersion1
+		meta.Thanos.Labels = map[string]string{"__org_id__": userID, "replica": "1"}
+
+		buf := bytes.Buffer{}
+		require.NoError(t, meta.Write(&buf))
+		require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), metadata.MetaFilename), &buf))
+		require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), "index"), bytes.NewReader([]byte("index"))))
+		require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), "chunks", "000001"), bytes.NewReader([]byte("chunks"))))
+		if deleted {
+			require.NoError(t, bkt.Upload(ctx, path.Join(userID, id.String(), metadata.DeletionMarkFilename), bytes.NewReader([]byte("{}"))))
+		}
+	}
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	uploadBlock(block1, 10000, 20000, false)
+	uploadBlock(block2, 30000, 40000, false)
+	uploadBlock(block3, 10000, 20000, true)
+
+	// A block without meta.json, eg. being uploaded, is skipped.
+	block4 := ulid.MustNew(4, nil)
+	require.NoError(t, bkt.Upload(ctx, path.Join(userID, block4.String(), "index"), bytes.NewReader([]byte("index"))))
+
+	api := newBlocksExportAPI(objstore.WithNoopInstr(bkt), exportBkt, nil, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(ctx, api))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(ctx, api)) })
+	reqCtx := user.InjectOrgID(ctx, userID)
+
+	// Only the blocks overlapping the time range and not marked for deletion are exported.
+	req := httptest.NewRequest(http.MethodPost, "/purger/export_blocks?start=15&end=25&prefix=offboarding&external_label=replica=&external_label=cluster=exported", nil)
+	resp := httptest.NewRecorder()
+	api.ExportBlocks(resp, req.WithContext(reqCtx))
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	job := BlocksExportJob{}
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &job))
+	assert.Equal(t, BlocksExportJobRunning, job.State)
+
+	test.Poll(t, 5*time.Second, BlocksExportJobDone, func() interface{} {
+		req := httptest.NewRequest(http.MethodGet, "/purger/export_blocks_status?id="+job.ID, nil)
+		resp := httptest.NewRecorder()
+		api.ExportBlocksStatus(resp, req.WithContext(reqCtx))
+
+		var jobs []BlocksExportJob
+		if err := json.Unmarshal(resp.Body.Bytes(), &jobs); err != nil || len(jobs) != 1 {
+			return nil
+		}
+		return jobs[0].State
+	})
+
+	blockDir := path.Join("offboarding", userID, block1.String())
+	assert.Equal(t, map[string][]byte{
+		path.Join(blockDir, "index"):               []byte("index"),
+		path.Join(blockDir, "chunks", "000001"):    []byte("chunks"),
+		path.Join(blockDir, metadata.MetaFilename): exportBkt.Objects()[path.Join(blockDir, metadata.MetaFilename)],
+	}, exportBkt.Objects())
+
+	// The external labels of the exported block are relabeled.
+	r, err := exportBkt.Get(ctx, path.Join(blockDir, metadata.MetaFilename))
+	require.NoError(t, err)
+	meta, err := metadata.Read(r)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"__org_id__": userID, "cluster": "exported"}, meta.Thanos.Labels)
+}
+
+func TestBlocksExportAPI_ShouldRejectInvalidRequests(t *testing.T) {
+	api := newBlocksExportAPI(objstore.WithNoopInstr(objstore.NewInMemBucket()), objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), api))
+	t.Cleanup(func() { assert.NoError(t, services.StopAndAwaitTerminated(context.Background(), api)) })
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+
+	for _, query := range []string{"start=20&end=10", "external_label=invalid", "external_label=1x=y"} {
+		req := httptest.NewRequest(http.MethodPost, "/purger/export_blocks?"+query, nil)
+		resp := httptest.NewRecorder()
+		api.ExportBlocks(resp, req.WithContext(ctx))
+		assert.Equal(t, http.StatusBadRequest, resp.Code, query)
+	}
+}
+
+func TestBlocksExportAPI_ShouldPruneTheFinishedJobs(t *testing.T) {
+	api := newBlocksExportAPI(objstore.WithNoopInstr(objstore.NewInMemBucket()), objstore.NewInMemBucket(), nil, log.NewNopLogger())
+	now := time.Now()
+
+	var jobs []*BlocksExportJob
+	for i := 0; i < maxBlocksExportFinishedJobsPerTenant+2; i++ {
+		jobs = append(jobs, &BlocksExportJob{ID: strconv.Itoa(i), State: BlocksExportJobDone, FinishedAt: now})
+	}
+	jobs = append(jobs, &BlocksExportJob{ID: "running", State: BlocksExportJobRunning})
+	api.jobs["user-1"] = jobs
+	api.jobs["user-2"] = []*BlocksExportJob{{ID: "expired", State: BlocksExportJobFailed, FinishedAt: now.Add(-blocksExportFinishedJobsRetention - time.Second)}}
+
+	api.pruneJobs(now)
+
+	// The oldest finished jobs exceeding the limit, and the expired ones, are removed.
+	assert.Equal(t, jobs[2:], api.jobs["user-1"])
+	assert.NotContains(t, api.jobs, "user-2")
+}
+
+func TestBlocksExportAPI_ShouldRejectRequestsWhenNotRunning(t *testing.T) {
+	api := newBlocksExportAPI(objstore.WithNoopInstr(objstore.NewInMemBucket()), objstore.NewInMemBucket(), nil, log.NewNopLogger())
+
+	req := httptest.NewRequest(http.MethodPost, "/purger/export_blocks", nil)
+	resp := httptest.NewRecorder()
+	api.ExportBlocks(resp, req.WithContext(user.InjectOrgID(context.Background(), "user-1")))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+}
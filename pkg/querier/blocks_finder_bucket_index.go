@@ -48,6 +48,30 @@ func NewBucketIndexBlocksFinder(cfg BucketIndexBlocksFinderConfig, bkt objstore.
 	}
 }
 
+// NewestShippedBlocksMaxTime implements NewestShippedBlocksFinder. The newest blocks are unknown if
+// the bucket index can't be loaded or is too old.
+func (f *BucketIndexBlocksFinder) NewestShippedBlocksMaxTime(ctx context.Context, userID string) (map[string]int64, bool) {
+	if f.State() != services.Running {
+		return nil, false
+	}
+
+	idx, _, err := f.loader.GetIndex(ctx, userID)
+	if err != nil || time.Since(idx.GetUpdatedAt()) > f.cfg.MaxStalePeriod {
+		return nil, false
+	}
+
+	newest := map[string]int64{}
+	for _, b := range idx.Blocks {
+		if b.IngesterID == "" {
+			continue
+		}
+		if t, ok := newest[b.IngesterID]; !ok || b.MaxTime > t {
+			newest[b.IngesterID] = b.MaxTime
+		}
+	}
+	return newest, true
+}
+
 // GetBlocks implements BlocksFinder.
 func (f *BucketIndexBlocksFinder) GetBlocks(ctx context.Context, userID string, minT, maxT int64) (bucketindex.Blocks, map[ulid.ULID]*bucketindex.BlockDeletionMark, error) {
 	if f.State() != services.Running {
@@ -279,6 +279,42 @@ func TestBucketIndexBlocksFinder_GetBlocks_BucketIndexIsTooOldWithCustomerKeyErr
 	}
 }
 
+func TestBucketIndexBlocksFinder_NewestShippedBlocksMaxTime(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	bkt, _ := cortex_testutil.PrepareFilesystemBucket(t)
+
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, "user-1", nil, &bucketindex.Index{
+		Version: bucketindex.IndexVersion1,
+		Blocks: bucketindex.Blocks{
+			{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 20, IngesterID: "ingester-1"},
+			{ID: ulid.MustNew(2, nil), MinTime: 20, MaxTime: 40, IngesterID: "ingester-1"},
+			{ID: ulid.MustNew(3, nil), MinTime: 10, MaxTime: 30, IngesterID: "ingester-2"},
+			{ID: ulid.MustNew(4, nil), MinTime: 10, MaxTime: 50},
+		},
+		UpdatedAt: time.Now().Unix(),
+	}))
+	require.NoError(t, bucketindex.WriteIndex(ctx, bkt, "user-2", nil, &bucketindex.Index{
+		Version:   bucketindex.IndexVersion1,
+		Blocks:    bucketindex.Blocks{{ID: ulid.MustNew(1, nil), MinTime: 10, MaxTime: 20, IngesterID: "ingester-1"}},
+		UpdatedAt: time.Now().Add(-2 * time.Hour).Unix(),
+	}))
+
+	finder := prepareBucketIndexBlocksFinder(t, bkt)
+
+	// The compacted blocks aren't shipped by any ingester.
+	newest, ok := finder.NewestShippedBlocksMaxTime(ctx, "user-1")
+	require.True(t, ok)
+	assert.Equal(t, map[string]int64{"ingester-1": 40, "ingester-2": 30}, newest)
+
+	// The newest blocks are unknown when the bucket index is too old or doesn't exist.
+	for _, userID := range []string{"user-2", "user-3"} {
+		_, ok := finder.NewestShippedBlocksMaxTime(ctx, userID)
+		assert.False(t, ok, userID)
+	}
+}
+
 func prepareBucketIndexBlocksFinder(t testing.TB, bkt objstore.Bucket) *BucketIndexBlocksFinder {
 	ctx := context.Background()
 	cfg := BucketIndexBlocksFinderConfig{
@@ -0,0 +1,143 @@
+package querier
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+)
+
+const (
+	// blocksMetadataReadConcurrency is the max number of meta.json concurrently read from the bucket.
+	blocksMetadataReadConcurrency = 16
+)
+
+// blocksMetadataReader reads the metric metadata persisted by the ingesters and the compactor
+// in the meta.json of the blocks stored in the bucket.
+type blocksMetadataReader struct {
+	finder       BlocksFinder
+	bucketClient objstore.Bucket
+	cfgProvider  bucket.TenantConfigProvider
+	lookback     time.Duration
+	logger       log.Logger
+
+	// Blocks are immutable, so we cache the metadata read from each block of a tenant. The
+	// cache is replaced at each read in order to drop the blocks not in the lookback anymore.
+	cacheMx sync.Mutex
+	cache   map[string]map[ulid.ULID][]scrape.MetricMetadata
+}
+
+func newBlocksMetadataReader(finder BlocksFinder, bucketClient objstore.Bucket, cfgProvider bucket.TenantConfigProvider, lookback time.Duration, logger log.Logger) *blocksMetadataReader {
+	return &blocksMetadataReader{
+		finder:       finder,
+		bucketClient: bucketClient,
+		cfgProvider:  cfgProvider,
+		lookback:     lookback,
+		logger:       logger,
+		cache:        map[string]map[ulid.ULID][]scrape.MetricMetadata{},
+	}
+}
+
+// MetricsMetadata returns the deduplicated metric metadata persisted in the blocks of the
+// tenant within the lookback period.
+func (r *blocksMetadataReader) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetadata, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	blocks, _, err := r.finder.GetBlocks(ctx, userID, util.TimeToMillis(now.Add(-r.lookback)), util.TimeToMillis(now))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to find blocks")
+	}
+
+	r.cacheMx.Lock()
+	cached := r.cache[userID]
+	r.cacheMx.Unlock()
+
+	var (
+		userBucket = bucket.NewUserBucketClient(userID, r.bucketClient, r.cfgProvider)
+		resultsMx  sync.Mutex
+		results    = make(map[ulid.ULID][]scrape.MetricMetadata, len(blocks))
+		jobs       []interface{}
+	)
+
+	for _, b := range blocks {
+		if m, ok := cached[b.ID]; ok {
+			results[b.ID] = m
+			continue
+		}
+		jobs = append(jobs, b.ID)
+	}
+
+	err = concurrency.ForEach(ctx, jobs, blocksMetadataReadConcurrency, func(ctx context.Context, job interface{}) error {
+		blockID := job.(ulid.ULID)
+
+		meta, err := block.DownloadMeta(ctx, r.logger, userBucket, blockID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read meta of block %s", blockID)
+		}
+
+		ext, err := cortex_tsdb.GetBlockExtensions(&meta)
+		if err != nil {
+			// Do not fail the whole request because of a single block.
+			level.Warn(r.logger).Log("msg", "failed to parse the extensions of block meta", "user", userID, "block", blockID, "err", err)
+		}
+
+		var metadata []scrape.MetricMetadata
+		if ext != nil {
+			metadata = make([]scrape.MetricMetadata, 0, len(ext.Metadata))
+			for _, m := range ext.Metadata {
+				metadata = append(metadata, scrape.MetricMetadata{
+					Metric: m.Metric,
+					Type:   model.MetricType(m.Type),
+					Help:   m.Help,
+					Unit:   m.Unit,
+				})
+			}
+		}
+
+		resultsMx.Lock()
+		results[blockID] = metadata
+		resultsMx.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.cacheMx.Lock()
+	r.cache[userID] = results
+	r.cacheMx.Unlock()
+
+	var (
+		result       []scrape.MetricMetadata
+		dedupTracker = map[scrape.MetricMetadata]struct{}{}
+	)
+	for _, metadata := range results {
+		for _, m := range metadata {
+			if _, ok := dedupTracker[m]; ok {
+				continue
+			}
+			dedupTracker[m] = struct{}{}
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
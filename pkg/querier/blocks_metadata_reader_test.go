@@ -0,0 +1,118 @@
+package querier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/oklog/ulid"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
+)
+
+func TestBlocksMetadataReader_MetricsMetadata(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := user.InjectOrgID(context.Background(), userID)
+	bkt := objstore.NewInMemBucket()
+
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+
+	uploadMetaWithExtensions(t, bkt, userID, block1, &cortex_tsdb.BlockExtensions{Metadata: []cortex_tsdb.BlockMetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "help 1"},
+		{Metric: "metric_2", Type: "gauge", Help: "help 2"},
+	}})
+	uploadMetaWithExtensions(t, bkt, userID, block2, &cortex_tsdb.BlockExtensions{Metadata: []cortex_tsdb.BlockMetricMetadata{
+		{Metric: "metric_2", Type: "gauge", Help: "help 2"},
+		{Metric: "metric_3", Type: "histogram", Help: "help 3", Unit: "seconds"},
+	}})
+	// Blocks without extensions are supported.
+	uploadMetaWithExtensions(t, bkt, userID, block3, nil)
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, userID, mock.Anything, mock.Anything).Return(bucketindex.Blocks{
+		{ID: block1}, {ID: block2}, {ID: block3},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), nil)
+
+	r := newBlocksMetadataReader(finder, bkt, &blocksStoreLimitsMock{}, time.Hour, log.NewNopLogger())
+
+	expected := []scrape.MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "help 1"},
+		{Metric: "metric_2", Type: "gauge", Help: "help 2"},
+		{Metric: "metric_3", Type: "histogram", Help: "help 3", Unit: "seconds"},
+	}
+
+	actual, err := r.MetricsMetadata(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, actual)
+
+	// The metadata of the blocks is cached, so deleting the meta.json doesn't affect the result.
+	require.NoError(t, bkt.Delete(ctx, path.Join(userID, block1.String(), metadata.MetaFilename)))
+
+	actual, err = r.MetricsMetadata(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, expected, actual)
+}
+
+func TestMergeMetricsMetadataQuerier(t *testing.T) {
+	d := &MockDistributor{}
+	d.On("MetricsMetadata", mock.Anything).Return([]scrape.MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "help 1"},
+	}, nil)
+
+	store := &metricsMetadataQueryableMock{metadata: []scrape.MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "help 1"},
+		{Metric: "metric_2", Type: "gauge", Help: "help 2"},
+	}}
+
+	q := NewMetricsMetadataQuerier(d, []QueryableWithFilter{UseAlwaysQueryable(store)}, log.NewNopLogger())
+
+	actual, err := q.MetricsMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []scrape.MetricMetadata{
+		{Metric: "metric_1", Type: "counter", Help: "help 1"},
+		{Metric: "metric_2", Type: "gauge", Help: "help 2"},
+	}, actual)
+
+	// If no store supports the metric metadata, the distributor is used as is.
+	assert.Equal(t, d, NewMetricsMetadataQuerier(d, nil, log.NewNopLogger()))
+}
+
+type metricsMetadataQueryableMock struct {
+	storage.Queryable
+	metadata []scrape.MetricMetadata
+}
+
+func (m *metricsMetadataQueryableMock) MetricsMetadata(_ context.Context) ([]scrape.MetricMetadata, error) {
+	return m.metadata, nil
+}
+
+func uploadMetaWithExtensions(t *testing.T, bkt objstore.Bucket, userID string, id ulid.ULID, ext *cortex_tsdb.BlockExtensions) {
+	meta := metadata.Meta{
+		BlockMeta: tsdb.BlockMeta{ULID: id, Version: metadata.TSDBVersion1},
+		Thanos:    metadata.Thanos{Version: metadata.ThanosVersion1},
+	}
+	if ext != nil {
+		meta.Thanos.Extensions = ext
+	}
+
+	data, err := json.Marshal(meta)
+	require.NoError(t, err)
+	require.NoError(t, bkt.Upload(context.Background(), path.Join(userID, id.String(), metadata.MetaFilename), bytes.NewReader(data)))
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -21,6 +22,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/scrape"
 	"github.com/prometheus/prometheus/storage"
 	"github.com/prometheus/prometheus/util/annotations"
 	"github.com/thanos-io/thanos/pkg/block"
@@ -107,8 +109,9 @@ type BlocksStoreLimits interface {
 }
 
 type blocksStoreQueryableMetrics struct {
-	storesHit prometheus.Histogram
-	refetches prometheus.Histogram
+	storesHit     prometheus.Histogram
+	refetches     prometheus.Histogram
+	skippedBlocks prometheus.Counter
 }
 
 func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQueryableMetrics {
@@ -125,6 +128,11 @@ func newBlocksStoreQueryableMetrics(reg prometheus.Registerer) *blocksStoreQuery
 			Help:      "Number of re-fetches attempted while querying store-gateway instances due to missing blocks.",
 			Buckets:   []float64{0, 1, 2},
 		}),
+		skippedBlocks: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_blocks_skipped_by_series_hints_total",
+			Help:      "Total number of blocks not queried because their series hints in the bucket index don't match the query.",
+		}),
 	}
 }
 
@@ -143,6 +151,12 @@ type BlocksStoreQueryable struct {
 
 	storeGatewayQueryStatsEnabled bool
 
+	// If enabled, the identical chunks returned by several store-gateways are only decoded once.
+	chunksDeduplication bool
+
+	// Reads the metric metadata persisted in the blocks. Nil if disabled.
+	metadataReader *blocksMetadataReader
+
 	// Subservices manager.
 	subservices        *services.Manager
 	subservicesWatcher *services.FailureWatcher
@@ -240,7 +254,7 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 			return nil, errors.Wrap(err, "failed to create store-gateway ring client")
 		}
 
-		stores, err = newBlocksStoreReplicationSet(storesRing, gatewayCfg.ShardingStrategy, randomLoadBalancing, limits, querierCfg.StoreGatewayClient, logger, reg, storesRingCfg.ZoneAwarenessEnabled, gatewayCfg.ShardingRing.ZoneStableShuffleSharding)
+		stores, err = newBlocksStoreReplicationSet(storesRing, gatewayCfg.ShardingStrategy, randomLoadBalancing, limits, querierCfg.StoreGatewayClient, logger, reg, storesRingCfg.ZoneAwarenessEnabled, gatewayCfg.ShardingRing.ZoneStableShuffleSharding, querierCfg.StoreGatewayPreferSyncedReplicas, querierCfg.StoreGatewayPreferredZone)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to create store set")
 		}
@@ -264,7 +278,27 @@ func NewBlocksStoreQueryableFromConfig(querierCfg Config, gatewayCfg storegatewa
 		reg,
 	)
 
-	return NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, querierCfg.StoreGatewayQueryStatsEnabled, logger, reg)
+	q, err := NewBlocksStoreQueryable(stores, finder, consistency, limits, querierCfg.QueryStoreAfter, querierCfg.StoreGatewayQueryStatsEnabled, logger, reg)
+	if err != nil {
+		return nil, err
+	}
+
+	q.chunksDeduplication = querierCfg.ChunksDeduplication
+
+	if querierCfg.MetadataBlocksLookback > 0 {
+		q.metadataReader = newBlocksMetadataReader(finder, bucketClient, limits, querierCfg.MetadataBlocksLookback, logger)
+	}
+
+	return q, nil
+}
+
+// MetricsMetadata returns the metric metadata persisted in the blocks of the tenant, or
+// no metadata if reading the metadata from the blocks is disabled.
+func (q *BlocksStoreQueryable) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetadata, error) {
+	if q.metadataReader == nil {
+		return nil, nil
+	}
+	return q.metadataReader.MetricsMetadata(ctx)
 }
 
 func (q *BlocksStoreQueryable) starting(ctx context.Context) error {
@@ -292,6 +326,14 @@ func (q *BlocksStoreQueryable) stopping(_ error) error {
 	return services.StopManagerAndAwaitStopped(context.Background(), q.subservices)
 }
 
+// NewestShippedBlocksMaxTime implements NewestShippedBlocksFinder.
+func (q *BlocksStoreQueryable) NewestShippedBlocksMaxTime(ctx context.Context, userID string) (map[string]int64, bool) {
+	if f, ok := q.finder.(NewestShippedBlocksFinder); ok {
+		return f.NewestShippedBlocksMaxTime(ctx, userID)
+	}
+	return nil, false
+}
+
 // Querier returns a new Querier on the storage.
 func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
 	if s := q.State(); s != services.Running {
@@ -309,6 +351,7 @@ func (q *BlocksStoreQueryable) Querier(mint, maxt int64) (storage.Querier, error
 		logger:                        q.logger,
 		queryStoreAfter:               q.queryStoreAfter,
 		storeGatewayQueryStatsEnabled: q.storeGatewayQueryStatsEnabled,
+		chunksDeduplication:           q.chunksDeduplication,
 	}, nil
 }
 
@@ -328,6 +371,9 @@ type blocksStoreQuerier struct {
 	// If enabled, query stats of store gateway requests will be logged
 	// using `info` level.
 	storeGatewayQueryStatsEnabled bool
+
+	// If enabled, the identical chunks returned by several store-gateways are only decoded once.
+	chunksDeduplication bool
 }
 
 // Select implements storage.Querier interface.
@@ -368,7 +414,7 @@ func (q *blocksStoreQuerier) LabelNames(ctx context.Context, matchers ...*labels
 		return queriedBlocks, nil, retryableError
 	}
 
-	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, userID, queryFunc); err != nil {
+	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, userID, matchers, queryFunc); err != nil {
 		return nil, nil, err
 	}
 
@@ -407,7 +453,7 @@ func (q *blocksStoreQuerier) LabelValues(ctx context.Context, name string, match
 		return queriedBlocks, nil, retryableError
 	}
 
-	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, userID, queryFunc); err != nil {
+	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, userID, matchers, queryFunc); err != nil {
 		return nil, nil, err
 	}
 
@@ -463,7 +509,7 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 		return queriedBlocks, nil, retryableError
 	}
 
-	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, userID, queryFunc); err != nil {
+	if err := q.queryWithConsistencyCheck(spanCtx, spanLog, minT, maxT, userID, matchers, queryFunc); err != nil {
 		return storage.ErrSeriesSet(err)
 	}
 
@@ -471,12 +517,17 @@ func (q *blocksStoreQuerier) selectSorted(ctx context.Context, sp *storage.Selec
 		storage.EmptySeriesSet()
 	}
 
+	mergeFunc := storage.ChainedSeriesMerge
+	if q.chunksDeduplication {
+		mergeFunc = newChunksDeduplicatingSeriesMerge(stats.FromContext(ctx))
+	}
+
 	return series.NewSeriesSetWithWarnings(
-		storage.NewMergeSeriesSet(resSeriesSets, storage.ChainedSeriesMerge),
+		storage.NewMergeSeriesSet(resSeriesSets, mergeFunc),
 		resWarnings)
 }
 
-func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, userID string,
+func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logger log.Logger, minT, maxT int64, userID string, matchers []*labels.Matcher,
 	queryFunc func(clients map[BlocksStoreClient][]ulid.ULID, minT, maxT int64) ([]ulid.ULID, error, error)) error {
 	// If queryStoreAfter is enabled, we do manipulate the query maxt to query samples up until
 	// now - queryStoreAfter, because the most recent time range is covered by ingesters. This
@@ -504,6 +555,19 @@ func (q *blocksStoreQuerier) queryWithConsistencyCheck(ctx context.Context, logg
 		return err
 	}
 
+	// Skip the blocks which can't have any series matching the query, so that the
+	// store-gateways don't need to load their index-header.
+	if len(matchers) > 0 {
+		matchingBlocks := knownBlocks[:0:0]
+		for _, b := range knownBlocks {
+			if b.SeriesHints == nil || b.SeriesHints.MayMatch(matchers) {
+				matchingBlocks = append(matchingBlocks, b)
+			}
+		}
+		q.metrics.skippedBlocks.Add(float64(len(knownBlocks) - len(matchingBlocks)))
+		knownBlocks = matchingBlocks
+	}
+
 	if len(knownBlocks) == 0 {
 		q.metrics.storesHit.Observe(0)
 		level.Debug(logger).Log("msg", "no blocks found")
@@ -656,6 +720,7 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			mySeries := []*storepb.Series(nil)
 			myWarnings := annotations.Annotations(nil)
 			myQueriedBlocks := []ulid.ULID(nil)
+			myChunkBytesAvoided := uint64(0)
 
 			for {
 				// Ensure the context hasn't been canceled in the meanwhile (eg. an error occurred
@@ -666,6 +731,13 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 
 				resp, err := stream.Recv()
 				if err == io.EOF {
+					// The chunk bytes avoided by the store-gateway are only known once
+					// the whole response has been sent, so they're sent in the trailer.
+					if values := stream.Trailer().Get(storegateway.ChunkBytesAvoidedTrailer); len(values) > 0 {
+						if v, err := strconv.ParseUint(values[0], 10, 64); err == nil {
+							myChunkBytesAvoided = v
+						}
+					}
 					break
 				}
 
@@ -759,6 +831,8 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			reqStats.AddFetchedDataBytes(uint64(dataBytes))
 			reqStats.AddStoreGatewayTouchedPostings(uint64(seriesQueryStats.PostingsTouched))
 			reqStats.AddStoreGatewayTouchedPostingBytes(uint64(seriesQueryStats.PostingsTouchedSizeSum))
+			reqStats.AddStoreGatewayQueriedBlocks(uint64(len(myQueriedBlocks)))
+			reqStats.AddStoreGatewayChunkBytesAvoided(myChunkBytesAvoided)
 
 			level.Debug(spanLog).Log("msg", "received series from store-gateway",
 				"instance", c.RemoteAddress(),
@@ -771,6 +845,8 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 			if q.storeGatewayQueryStatsEnabled && seriesQueryStats.BlocksQueried > 0 {
 				level.Info(spanLog).Log("msg", "store gateway series request stats",
 					"instance", c.RemoteAddress(),
+					"skip_chunks", skipChunks,
+					"chunk_bytes_avoided", myChunkBytesAvoided,
 					"queryable_chunk_bytes_fetched", chunkBytes,
 					"queryable_data_bytes_fetched", dataBytes,
 					"blocks_queried", seriesQueryStats.BlocksQueried,
@@ -801,8 +877,12 @@ func (q *blocksStoreQuerier) fetchSeriesFromStores(
 
 			// Store the result.
 			mtx.Lock()
-			// TODO: change other aggregations when downsampling is enabled.
-			seriesSets = append(seriesSets, thanosquery.NewPromSeriesSet(newStoreSeriesSet(mySeries), minT, maxT, defaultAggrs, nil))
+			if q.chunksDeduplication {
+				seriesSets = append(seriesSets, newStoreGatewaySeriesSet(mySeries, minT, maxT))
+			} else {
+				// TODO: change other aggregations when downsampling is enabled.
+				seriesSets = append(seriesSets, thanosquery.NewPromSeriesSet(newStoreSeriesSet(mySeries), minT, maxT, defaultAggrs, nil))
+			}
 			warnings.Merge(myWarnings)
 			queriedBlocks = append(queriedBlocks, myQueriedBlocks...)
 			mtx.Unlock()
@@ -33,10 +33,12 @@ import (
 	"github.com/weaveworks/common/user"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/cortexproject/cortex/pkg/chunk/encoding"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 	"github.com/cortexproject/cortex/pkg/storegateway"
 	"github.com/cortexproject/cortex/pkg/storegateway/storegatewaypb"
@@ -1561,7 +1563,7 @@ func TestBlocksStoreQuerier_Select(t *testing.T) {
 
 			// Assert on metrics (optional, only for test cases defining it).
 			if testData.expectedMetrics != "" {
-				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+				assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 			}
 		})
 	}
@@ -2048,7 +2050,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 
 					// Assert on metrics (optional, only for test cases defining it).
 					if testData.expectedMetrics != "" {
-						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 					}
 				}
 
@@ -2065,7 +2067,7 @@ func TestBlocksStoreQuerier_Labels(t *testing.T) {
 
 					// Assert on metrics (optional, only for test cases defining it).
 					if testData.expectedMetrics != "" {
-						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics)))
+						assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(testData.expectedMetrics), "cortex_querier_storegateway_instances_hit_per_query", "cortex_querier_storegateway_refetches_per_query"))
 					}
 				}
 			}
@@ -2154,6 +2156,122 @@ func TestBlocksStoreQuerier_SelectSortedShouldHonorQueryStoreAfter(t *testing.T)
 	}
 }
 
+func TestBlocksStoreQuerier_SelectSortedShouldSkipBlocksBySeriesHints(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	reg := prometheus.NewPedanticRegistry()
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	block3 := ulid.MustNew(3, nil)
+	series := labels.FromStrings(labels.MetricName, "up", "job", "api")
+
+	// Only the blocks which may have matching series are queried, otherwise the
+	// consistency check would fail because of the missing blocks.
+	stores := &blocksStoreSetMock{mockedResponses: []interface{}{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{
+				mockSeriesResponse(series, []cortexpb.Sample{{Value: 1, TimestampMs: minT}}, nil, nil),
+				mockHintsResponse(block1, block3),
+			}}: {block1, block3},
+		},
+	}}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+		{ID: block1, SeriesHints: bucketindex.NewSeriesHints(map[string][]string{labels.MetricName: {"up"}, "job": {"api"}})},
+		{ID: block2, SeriesHints: bucketindex.NewSeriesHints(map[string][]string{labels.MetricName: {"up"}, "job": {"web"}})},
+		{ID: block3},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(reg),
+		limits:      &blocksStoreLimitsMock{},
+	}
+
+	set := q.Select(ctx, true, nil,
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"),
+		labels.MustNewMatcher(labels.MatchEqual, "job", "api"))
+	require.True(t, set.Next())
+	assert.Equal(t, series, set.At().Labels())
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_blocks_skipped_by_series_hints_total Total number of blocks not queried because their series hints in the bucket index don't match the query.
+		# TYPE cortex_querier_blocks_skipped_by_series_hints_total counter
+		cortex_querier_blocks_skipped_by_series_hints_total 1
+	`), "cortex_querier_blocks_skipped_by_series_hints_total"))
+}
+
+func TestBlocksStoreQuerier_SelectSortedShouldTrackChunkBytesAvoided(t *testing.T) {
+	const (
+		minT = int64(10)
+		maxT = int64(20)
+	)
+
+	reqStats, ctx := stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "user-1"))
+	block1 := ulid.MustNew(1, nil)
+	block2 := ulid.MustNew(2, nil)
+	series := labels.FromStrings(labels.MetricName, "up")
+
+	stores := &blocksStoreSetMock{mockedResponses: []interface{}{
+		map[BlocksStoreClient][]ulid.ULID{
+			&storeGatewayClientMock{
+				remoteAddr: "1.1.1.1",
+				mockedSeriesResponses: []*storepb.SeriesResponse{
+					storepb.NewSeriesResponse(&storepb.Series{Labels: labelpb.ZLabelsFromPromLabels(series)}),
+					mockHintsResponse(block1),
+				},
+				mockedSeriesTrailer: metadata.Pairs(storegateway.ChunkBytesAvoidedTrailer, "1024"),
+			}: {block1},
+			&storeGatewayClientMock{
+				remoteAddr: "2.2.2.2",
+				mockedSeriesResponses: []*storepb.SeriesResponse{
+					storepb.NewSeriesResponse(&storepb.Series{Labels: labelpb.ZLabelsFromPromLabels(series)}),
+					mockHintsResponse(block2),
+				},
+				mockedSeriesTrailer: metadata.Pairs(storegateway.ChunkBytesAvoidedTrailer, "256"),
+			}: {block2},
+		},
+	}}
+
+	finder := &blocksFinderMock{}
+	finder.On("GetBlocks", mock.Anything, "user-1", minT, maxT).Return(bucketindex.Blocks{
+		{ID: block1},
+		{ID: block2},
+	}, map[ulid.ULID]*bucketindex.BlockDeletionMark(nil), error(nil))
+
+	q := &blocksStoreQuerier{
+		minT:        minT,
+		maxT:        maxT,
+		finder:      finder,
+		stores:      stores,
+		consistency: NewBlocksConsistencyChecker(0, 0, log.NewNopLogger(), nil),
+		logger:      log.NewNopLogger(),
+		metrics:     newBlocksStoreQueryableMetrics(prometheus.NewPedanticRegistry()),
+		limits:      &blocksStoreLimitsMock{},
+	}
+
+	set := q.Select(ctx, true, &storage.SelectHints{Start: minT, End: maxT, Func: "series"},
+		labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "up"))
+	require.True(t, set.Next())
+	assert.Equal(t, series, set.At().Labels())
+	require.False(t, set.Next())
+	require.NoError(t, set.Err())
+
+	assert.Equal(t, uint64(1280), reqStats.LoadStoreGatewayChunkBytesAvoided())
+}
+
 func TestBlocksStoreQuerier_PromQLExecution(t *testing.T) {
 	t.Parallel()
 	logger := log.NewNopLogger()
@@ -2335,6 +2453,7 @@ type storeGatewayClientMock struct {
 	mockedSeriesResponses     []*storepb.SeriesResponse
 	mockedSeriesErr           error
 	mockedSeriesStreamErr     error
+	mockedSeriesTrailer       metadata.MD
 	mockedLabelNamesResponse  *storepb.LabelNamesResponse
 	mockedLabelValuesResponse *storepb.LabelValuesResponse
 	mockedLabelValuesErr      error
@@ -2344,6 +2463,7 @@ func (m *storeGatewayClientMock) Series(ctx context.Context, in *storepb.SeriesR
 	seriesClient := &storeGatewaySeriesClientMock{
 		mockedResponses:       m.mockedSeriesResponses,
 		mockedSeriesStreamErr: m.mockedSeriesStreamErr,
+		mockedTrailer:         m.mockedSeriesTrailer,
 	}
 
 	return seriesClient, m.mockedSeriesErr
@@ -2366,6 +2486,7 @@ type storeGatewaySeriesClientMock struct {
 
 	mockedResponses       []*storepb.SeriesResponse
 	mockedSeriesStreamErr error
+	mockedTrailer         metadata.MD
 }
 
 func (m *storeGatewaySeriesClientMock) Recv() (*storepb.SeriesResponse, error) {
@@ -2381,6 +2502,10 @@ func (m *storeGatewaySeriesClientMock) Recv() (*storepb.SeriesResponse, error) {
 	return res, m.mockedSeriesStreamErr
 }
 
+func (m *storeGatewaySeriesClientMock) Trailer() metadata.MD {
+	return m.mockedTrailer
+}
+
 type blocksStoreLimitsMock struct {
 	maxChunksPerQuery           int
 	storeGatewayTenantShardSize float64
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"sort"
 
 	"github.com/go-kit/log"
 	"github.com/oklog/ulid"
@@ -39,6 +40,10 @@ type blocksStoreReplicationSet struct {
 
 	zoneAwarenessEnabled      bool
 	zoneStableShuffleSharding bool
+	preferSyncedReplicas      bool
+	preferredZone             string
+
+	zoneMetrics *storeGatewayZoneMetrics
 
 	// Subservices manager.
 	subservices        *services.Manager
@@ -55,6 +60,8 @@ func newBlocksStoreReplicationSet(
 	reg prometheus.Registerer,
 	zoneAwarenessEnabled bool,
 	zoneStableShuffleSharding bool,
+	preferSyncedReplicas bool,
+	preferredZone string,
 ) (*blocksStoreReplicationSet, error) {
 	s := &blocksStoreReplicationSet{
 		storesRing:        storesRing,
@@ -65,6 +72,9 @@ func newBlocksStoreReplicationSet(
 
 		zoneAwarenessEnabled:      zoneAwarenessEnabled,
 		zoneStableShuffleSharding: zoneStableShuffleSharding,
+		preferSyncedReplicas:      preferSyncedReplicas,
+		preferredZone:             preferredZone,
+		zoneMetrics:               newStoreGatewayZoneMetrics(reg),
 	}
 
 	var err error
@@ -105,6 +115,7 @@ func (s *blocksStoreReplicationSet) stopping(_ error) error {
 
 func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid.ULID, exclude map[ulid.ULID][]string, attemptedBlocksZones map[ulid.ULID]map[string]int) (map[BlocksStoreClient][]ulid.ULID, error) {
 	shards := map[string][]ulid.ULID{}
+	zones := map[string]string{}
 
 	// If shuffle sharding is enabled, we should build a subring for the user,
 	// otherwise we just use the full ring.
@@ -127,13 +138,14 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 		}
 
 		// Pick a non excluded store-gateway instance.
-		instance := getNonExcludedInstance(set, exclude[blockID], s.balancingStrategy, s.zoneAwarenessEnabled, attemptedBlocksZones[blockID])
+		instance := getNonExcludedInstance(set, exclude[blockID], s.balancingStrategy, s.zoneAwarenessEnabled, s.preferSyncedReplicas, s.preferredZone, attemptedBlocksZones[blockID])
 		// A valid instance should have a non-empty address.
 		if instance.Addr == "" {
 			return nil, fmt.Errorf("no store-gateway instance left after checking exclude for block %s", blockID.String())
 		}
 
 		shards[instance.Addr] = append(shards[instance.Addr], blockID)
+		zones[instance.Addr] = instance.Zone
 		if s.zoneAwarenessEnabled {
 			if _, ok := attemptedBlocksZones[blockID]; !ok {
 				attemptedBlocksZones[blockID] = make(map[string]int, 0)
@@ -151,13 +163,13 @@ func (s *blocksStoreReplicationSet) GetClientsFor(userID string, blockIDs []ulid
 			return nil, errors.Wrapf(err, "failed to get store-gateway client for %s", addr)
 		}
 
-		clients[c.(BlocksStoreClient)] = blockIDs
+		clients[s.zoneMetrics.wrapClient(c.(BlocksStoreClient), zones[addr], s.preferredZone)] = blockIDs
 	}
 
 	return clients, nil
 }
 
-func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy, zoneAwarenessEnabled bool, attemptedZones map[string]int) ring.InstanceDesc {
+func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancingStrategy loadBalancingStrategy, zoneAwarenessEnabled, preferSyncedReplicas bool, preferredZone string, attemptedZones map[string]int) ring.InstanceDesc {
 	if balancingStrategy == randomLoadBalancing {
 		// Randomize the list of instances to not always query the same one.
 		rand.Shuffle(len(set.Instances), func(i, j int) {
@@ -165,6 +177,25 @@ func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancing
 		})
 	}
 
+	if preferredZone != "" {
+		// Move the store-gateways in the preferred zone first, so that the inter-zone traffic is
+		// reduced. The sort is stable to preserve the load balancing within each zone, and done
+		// before the synced replicas one so that a synced replica is still preferred over a
+		// syncing one in the preferred zone.
+		sort.SliceStable(set.Instances, func(i, j int) bool {
+			return set.Instances[i].Zone == preferredZone && set.Instances[j].Zone != preferredZone
+		})
+	}
+
+	if preferSyncedReplicas {
+		// Move the store-gateways which have loaded all their blocks first, so that they're
+		// picked over the ones still syncing (eg. during a rollout). The sort is stable to
+		// preserve the load balancing among the synced ones.
+		sort.SliceStable(set.Instances, func(i, j int) bool {
+			return isSyncedInstance(set.Instances[i]) && !isSyncedInstance(set.Instances[j])
+		})
+	}
+
 	minAttempt := math.MaxInt
 	numOfZone := set.GetNumOfZones()
 	// There are still unattempted zones so we know min is 0.
@@ -192,3 +223,8 @@ func getNonExcludedInstance(set ring.ReplicationSet, exclude []string, balancing
 
 	return ring.InstanceDesc{}
 }
+
+// isSyncedInstance returns whether the store-gateway instance has loaded all its blocks.
+func isSyncedInstance(instance ring.InstanceDesc) bool {
+	return instance.BlocksSyncPercentage >= 100
+}
@@ -585,7 +585,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor(t *testing.T) {
 			}
 
 			reg := prometheus.NewPedanticRegistry()
-			s, err := newBlocksStoreReplicationSet(r, testData.shardingStrategy, noLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, testData.zoneAwarenessEnabled, true)
+			s, err := newBlocksStoreReplicationSet(r, testData.shardingStrategy, noLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, testData.zoneAwarenessEnabled, true, false, "")
 			require.NoError(t, err)
 			require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 			defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -647,7 +647,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ShouldSupportRandomLoadBalancin
 
 	limits := &blocksStoreLimitsMock{}
 	reg := prometheus.NewPedanticRegistry()
-	s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, false, false)
+	s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, false, false, false, "")
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -716,7 +716,7 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ZoneAwareness(t *testing.T) {
 
 	limits := &blocksStoreLimitsMock{}
 	reg := prometheus.NewPedanticRegistry()
-	s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, true, false)
+	s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, true, false, false, "")
 	require.NoError(t, err)
 	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
 	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
@@ -752,6 +752,70 @@ func TestBlocksStoreReplicationSet_GetClientsFor_ZoneAwareness(t *testing.T) {
 	}
 }
 
+func TestBlocksStoreReplicationSet_GetClientsFor_ShouldPreferSyncedReplicas(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numRuns      = 100
+		numInstances = 3
+	)
+
+	ctx := context.Background()
+	userID := "user-A"
+	registeredAt := time.Now()
+	block1 := ulid.MustNew(1, nil)
+
+	// Create a ring where only the instance-2 has loaded all its blocks.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, "test", func(in interface{}) (interface{}, bool, error) {
+		d := ring.NewDesc()
+		for n := 1; n <= numInstances; n++ {
+			instance := d.AddIngester(fmt.Sprintf("instance-%d", n), fmt.Sprintf("127.0.0.%d", n), "", []uint32{uint32(n)}, ring.ACTIVE, registeredAt)
+			instance.BlocksSyncPercentage = 50
+			if n == 2 {
+				instance.BlocksSyncPercentage = 100
+			}
+			d.Ingesters[fmt.Sprintf("instance-%d", n)] = instance
+		}
+		return d, true, nil
+	}))
+
+	// Configure a replication factor equal to the number of instances, so that every store-gateway gets all blocks.
+	ringCfg := ring.Config{}
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.ReplicationFactor = numInstances
+
+	r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, nil)
+	require.NoError(t, err)
+
+	limits := &blocksStoreLimitsMock{}
+	reg := prometheus.NewPedanticRegistry()
+	s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, false, false, true, "")
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	// Wait until the ring client has initialised the state.
+	test.Poll(t, time.Second, true, func() interface{} {
+		all, err := r.GetAllHealthy(ring.Read)
+		return err == nil && len(all.Instances) > 0
+	})
+
+	for n := 0; n < numRuns; n++ {
+		clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]ulid.ULID{"127.0.0.2": {block1}}, getStoreGatewayClientAddrs(clients))
+	}
+
+	// The replicas still syncing are queried if the synced one has been excluded.
+	clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, map[ulid.ULID][]string{block1: {"127.0.0.2"}}, nil)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.NotContains(t, getStoreGatewayClientAddrs(clients), "127.0.0.2")
+}
+
 func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[string][]ulid.ULID {
 	addrs := map[string][]ulid.ULID{}
 	for c, blockIDs := range clients {
@@ -759,3 +823,64 @@ func getStoreGatewayClientAddrs(clients map[BlocksStoreClient][]ulid.ULID) map[s
 	}
 	return addrs
 }
+
+func TestBlocksStoreReplicationSet_GetClientsFor_ShouldPreferTheLocalZone(t *testing.T) {
+	t.Parallel()
+
+	const (
+		numRuns      = 100
+		numInstances = 3
+	)
+
+	ctx := context.Background()
+	userID := "user-A"
+	registeredAt := time.Now()
+	block1 := ulid.MustNew(1, nil)
+
+	// Create a ring with one instance per zone.
+	ringStore, closer := consul.NewInMemoryClient(ring.GetCodec(), log.NewNopLogger(), nil)
+	t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+	require.NoError(t, ringStore.CAS(ctx, "test", func(in interface{}) (interface{}, bool, error) {
+		d := ring.NewDesc()
+		for n := 1; n <= numInstances; n++ {
+			d.AddIngester(fmt.Sprintf("instance-%d", n), fmt.Sprintf("127.0.0.%d", n), fmt.Sprintf("zone-%d", n), []uint32{uint32(n)}, ring.ACTIVE, registeredAt)
+		}
+		return d, true, nil
+	}))
+
+	// Configure a replication factor equal to the number of instances, so that every store-gateway gets all blocks.
+	ringCfg := ring.Config{}
+	flagext.DefaultValues(&ringCfg)
+	ringCfg.ReplicationFactor = numInstances
+	ringCfg.ZoneAwarenessEnabled = true
+
+	r, err := ring.NewWithStoreClientAndStrategy(ringCfg, "test", "test", ringStore, ring.NewIgnoreUnhealthyInstancesReplicationStrategy(), nil, nil)
+	require.NoError(t, err)
+
+	limits := &blocksStoreLimitsMock{}
+	reg := prometheus.NewPedanticRegistry()
+	s, err := newBlocksStoreReplicationSet(r, util.ShardingStrategyDefault, randomLoadBalancing, limits, ClientConfig{}, log.NewNopLogger(), reg, true, false, false, "zone-2")
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(ctx, s))
+	defer services.StopAndAwaitTerminated(ctx, s) //nolint:errcheck
+
+	// Wait until the ring client has initialised the state.
+	test.Poll(t, time.Second, true, func() interface{} {
+		all, err := r.GetAllHealthy(ring.Read)
+		return err == nil && len(all.Instances) > 0
+	})
+
+	for n := 0; n < numRuns; n++ {
+		clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, nil, map[ulid.ULID]map[string]int{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string][]ulid.ULID{"127.0.0.2": {block1}}, getStoreGatewayClientAddrs(clients))
+	}
+
+	// The replicas in the other zones are queried when retrying.
+	attemptedBlocksZones := map[ulid.ULID]map[string]int{block1: {"zone-2": 1}}
+	clients, err := s.GetClientsFor(userID, []ulid.ULID{block1}, map[ulid.ULID][]string{block1: {"127.0.0.2"}}, attemptedBlocksZones)
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.NotContains(t, getStoreGatewayClientAddrs(clients), "127.0.0.2")
+}
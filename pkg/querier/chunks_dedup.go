@@ -0,0 +1,275 @@
+package querier
+
+import (
+	"github.com/cespare/xxhash/v2"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/util/annotations"
+	thanosquery "github.com/thanos-io/thanos/pkg/query"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
+)
+
+// chunkKey identifies a chunk by its time range and the hash of its data, so that the same
+// chunk returned by different sources (e.g. an ingester and a store-gateway within the
+// overlap window of -querier.query-ingesters-within and -querier.query-store-after) can be
+// detected without decoding it.
+type chunkKey struct {
+	minT, maxT int64
+	hash       uint64
+	bytes      int
+}
+
+func newChunkKey(minT, maxT int64, data []byte) chunkKey {
+	if len(data) == 0 {
+		return chunkKey{minT: minT, maxT: maxT}
+	}
+	return chunkKey{minT: minT, maxT: maxT, hash: xxhash.Sum64(data), bytes: len(data)}
+}
+
+// dedupableSeries is a series whose chunks can be deduplicated before being decoded.
+type dedupableSeries interface {
+	storage.Series
+
+	// numChunks returns the number of chunks of the series.
+	numChunks() int
+
+	// chunkKeys returns the keys of the chunks of the series, in the order of the chunks.
+	chunkKeys() []chunkKey
+
+	// withChunks returns a copy of the series only made of the chunks at the given indexes.
+	withChunks(idxs []int) dedupableSeries
+}
+
+// ingesterChunksSeries is a series returned by the ingesters.
+type ingesterChunksSeries struct {
+	lset        labels.Labels
+	raw         []client.Chunk
+	chunks      []chunk.Chunk
+	chunkIterFn chunkIteratorFunc
+	minT, maxT  int64
+}
+
+func (s *ingesterChunksSeries) Labels() labels.Labels {
+	return s.lset
+}
+
+func (s *ingesterChunksSeries) Iterator(chunkenc.Iterator) chunkenc.Iterator {
+	return s.chunkIterFn(s.chunks, model.Time(s.minT), model.Time(s.maxT))
+}
+
+func (s *ingesterChunksSeries) numChunks() int {
+	return len(s.raw)
+}
+
+func (s *ingesterChunksSeries) chunkKeys() []chunkKey {
+	keys := make([]chunkKey, 0, len(s.raw))
+	for _, c := range s.raw {
+		keys = append(keys, newChunkKey(c.StartTimestampMs, c.EndTimestampMs, c.Data))
+	}
+	return keys
+}
+
+func (s *ingesterChunksSeries) withChunks(idxs []int) dedupableSeries {
+	res := *s
+	res.raw = make([]client.Chunk, 0, len(idxs))
+	res.chunks = make([]chunk.Chunk, 0, len(idxs))
+	for _, idx := range idxs {
+		res.raw = append(res.raw, s.raw[idx])
+		res.chunks = append(res.chunks, s.chunks[idx])
+	}
+	return &res
+}
+
+// storeGatewaySeriesSet is a storage.SeriesSet of the series returned by a store-gateway.
+type storeGatewaySeriesSet struct {
+	series     []*storepb.Series
+	minT, maxT int64
+	i          int
+}
+
+func newStoreGatewaySeriesSet(series []*storepb.Series, minT, maxT int64) *storeGatewaySeriesSet {
+	return &storeGatewaySeriesSet{series: series, minT: minT, maxT: maxT, i: -1}
+}
+
+func (s *storeGatewaySeriesSet) Next() bool {
+	if s.i >= len(s.series)-1 {
+		return false
+	}
+	s.i++
+	return true
+}
+
+func (s *storeGatewaySeriesSet) At() storage.Series {
+	return &storeGatewaySeries{series: s.series[s.i], minT: s.minT, maxT: s.maxT}
+}
+
+func (s *storeGatewaySeriesSet) Err() error {
+	return nil
+}
+
+func (s *storeGatewaySeriesSet) Warnings() annotations.Annotations {
+	return nil
+}
+
+// storeGatewaySeries is a series returned by a store-gateway.
+type storeGatewaySeries struct {
+	series     *storepb.Series
+	minT, maxT int64
+}
+
+func (s *storeGatewaySeries) Labels() labels.Labels {
+	return s.series.PromLabels()
+}
+
+func (s *storeGatewaySeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	// TODO: change other aggregations when downsampling is enabled.
+	set := thanosquery.NewPromSeriesSet(newStoreSeriesSet([]*storepb.Series{s.series}), s.minT, s.maxT, defaultAggrs, nil)
+	if !set.Next() {
+		return chunkenc.NewNopIterator()
+	}
+	return set.At().Iterator(it)
+}
+
+func (s *storeGatewaySeries) numChunks() int {
+	return len(s.series.Chunks)
+}
+
+func (s *storeGatewaySeries) chunkKeys() []chunkKey {
+	keys := make([]chunkKey, 0, len(s.series.Chunks))
+	for _, c := range s.series.Chunks {
+		// Only the raw chunks can be deduplicated, the other aggregations get an empty key.
+		var data []byte
+		if c.Raw != nil {
+			data = c.Raw.Data
+		}
+		keys = append(keys, newChunkKey(c.MinTime, c.MaxTime, data))
+	}
+	return keys
+}
+
+func (s *storeGatewaySeries) withChunks(idxs []int) dedupableSeries {
+	res := &storepb.Series{Labels: s.series.Labels, Chunks: make([]storepb.AggrChunk, 0, len(idxs))}
+	for _, idx := range idxs {
+		res.Chunks = append(res.Chunks, s.series.Chunks[idx])
+	}
+	return &storeGatewaySeries{series: res, minT: s.minT, maxT: s.maxT}
+}
+
+// mergedDedupableSeries is the merge of several dedupable series with the same labels, which
+// can be deduplicated again when merged with the series of other sources.
+type mergedDedupableSeries struct {
+	parts []dedupableSeries
+}
+
+func (s *mergedDedupableSeries) Labels() labels.Labels {
+	return s.parts[0].Labels()
+}
+
+func (s *mergedDedupableSeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	series := make([]storage.Series, 0, len(s.parts))
+	for _, p := range s.parts {
+		series = append(series, p)
+	}
+	return storage.ChainedSeriesMerge(series...).Iterator(it)
+}
+
+func (s *mergedDedupableSeries) numChunks() int {
+	n := 0
+	for _, p := range s.parts {
+		n += p.numChunks()
+	}
+	return n
+}
+
+func (s *mergedDedupableSeries) chunkKeys() []chunkKey {
+	var keys []chunkKey
+	for _, p := range s.parts {
+		keys = append(keys, p.chunkKeys()...)
+	}
+	return keys
+}
+
+func (s *mergedDedupableSeries) withChunks(idxs []int) dedupableSeries {
+	var (
+		parts  []dedupableSeries
+		offset int
+	)
+	for _, p := range s.parts {
+		numChunks := p.numChunks()
+
+		var partIdxs []int
+		for len(idxs) > 0 && idxs[0] < offset+numChunks {
+			partIdxs = append(partIdxs, idxs[0]-offset)
+			idxs = idxs[1:]
+		}
+		if len(partIdxs) > 0 {
+			parts = append(parts, p.withChunks(partIdxs))
+		}
+		offset += numChunks
+	}
+	return &mergedDedupableSeries{parts: parts}
+}
+
+// newChunksDeduplicatingSeriesMerge returns a storage.VerticalSeriesMergeFunc which drops the
+// chunks already returned by another source before merging the series, so that they're not
+// decoded twice. The chunks are only dropped when their time range and data are identical, so
+// the samples iterated are the same as the ones of storage.ChainedSeriesMerge. The deduplicated
+// chunks are tracked in the given query stats.
+func newChunksDeduplicatingSeriesMerge(stats *querier_stats.QueryStats) storage.VerticalSeriesMergeFunc {
+	return func(series ...storage.Series) storage.Series {
+		parts := make([]dedupableSeries, 0, len(series))
+		for _, s := range series {
+			ds, ok := s.(dedupableSeries)
+			if !ok {
+				return storage.ChainedSeriesMerge(series...)
+			}
+			parts = append(parts, ds)
+		}
+
+		var (
+			seen       = map[chunkKey]struct{}{}
+			res        = make([]dedupableSeries, 0, len(parts))
+			dedupCount int
+			dedupBytes int
+		)
+		for _, p := range parts {
+			keys := p.chunkKeys()
+			idxs := make([]int, 0, len(keys))
+			for idx, key := range keys {
+				// The chunks whose data is unknown are never deduplicated.
+				if key.bytes == 0 {
+					idxs = append(idxs, idx)
+					continue
+				}
+				if _, ok := seen[key]; ok {
+					dedupCount++
+					dedupBytes += key.bytes
+					continue
+				}
+				seen[key] = struct{}{}
+				idxs = append(idxs, idx)
+			}
+
+			switch {
+			case len(idxs) == len(keys):
+				res = append(res, p)
+			case len(idxs) > 0:
+				res = append(res, p.withChunks(idxs))
+			}
+		}
+
+		stats.AddDeduplicatedChunks(uint64(dedupCount))
+		stats.AddDeduplicatedChunkBytes(uint64(dedupBytes))
+
+		if len(res) == 0 {
+			return storage.ChainedSeriesMerge(series...)
+		}
+		return &mergedDedupableSeries{parts: res}
+	}
+}
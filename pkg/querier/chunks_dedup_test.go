@@ -0,0 +1,109 @@
+package querier
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/store/labelpb"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	promchunk "github.com/cortexproject/cortex/pkg/chunk/encoding"
+	"github.com/cortexproject/cortex/pkg/querier/batch"
+	"github.com/cortexproject/cortex/pkg/querier/stats"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/chunkcompat"
+)
+
+func TestChunksDeduplicatingSeriesMerge(t *testing.T) {
+	lset := labels.FromStrings(model.MetricNameLabel, "foo")
+	from := model.Time(0)
+
+	// The first chunk is returned by both the ingester and the store-gateways.
+	overlapping := util.GenerateChunk(t, time.Second, from, 120, promchunk.PrometheusXorChunk)
+	ingesterOnly := util.GenerateChunk(t, time.Second, from.Add(2*time.Minute), 120, promchunk.PrometheusXorChunk)
+	storeOnly := util.GenerateChunk(t, time.Second, from.Add(-2*time.Minute), 120, promchunk.PrometheusXorChunk)
+
+	newIngesterSeries := func() *ingesterChunksSeries {
+		chunks := []chunk.Chunk{overlapping, ingesterOnly}
+		raw, err := chunkcompat.ToChunks(chunks)
+		require.NoError(t, err)
+		return &ingesterChunksSeries{lset: lset, raw: raw, chunks: chunks, chunkIterFn: batch.NewChunkMergeIterator, minT: -1000000, maxT: 1000000}
+	}
+	newStoreGatewaySeries := func(chunks ...chunk.Chunk) *storeGatewaySeries {
+		s := &storepb.Series{Labels: labelpb.ZLabelsFromPromLabels(lset)}
+		for _, c := range chunks {
+			s.Chunks = append(s.Chunks, storepb.AggrChunk{
+				MinTime: int64(c.From),
+				MaxTime: int64(c.Through),
+				Raw:     &storepb.Chunk{Type: storepb.Chunk_XOR, Data: c.Data.Bytes()},
+			})
+		}
+		return &storeGatewaySeries{series: s, minT: -1000000, maxT: 1000000}
+	}
+
+	for name, tc := range map[string]struct {
+		series             func() []storage.Series
+		expectedDedupCount uint64
+		expectedDedupBytes uint64
+	}{
+		"ingester and store-gateway series with an identical chunk": {
+			series: func() []storage.Series {
+				return []storage.Series{newIngesterSeries(), newStoreGatewaySeries(storeOnly, overlapping)}
+			},
+			expectedDedupCount: 1,
+			expectedDedupBytes: uint64(len(overlapping.Data.Bytes())),
+		},
+		"series already merged from several store-gateways": {
+			series: func() []storage.Series {
+				merge := newChunksDeduplicatingSeriesMerge(nil)
+				return []storage.Series{
+					newIngesterSeries(),
+					merge(newStoreGatewaySeries(storeOnly), newStoreGatewaySeries(overlapping)),
+				}
+			},
+			expectedDedupCount: 1,
+			expectedDedupBytes: uint64(len(overlapping.Data.Bytes())),
+		},
+		"no identical chunk": {
+			series: func() []storage.Series {
+				return []storage.Series{newIngesterSeries(), newStoreGatewaySeries(storeOnly)}
+			},
+		},
+		"series which can't be deduplicated": {
+			series: func() []storage.Series {
+				return []storage.Series{newIngesterSeries(), storage.ChainedSeriesMerge(newStoreGatewaySeries(storeOnly, overlapping))}
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			queryStats := &stats.QueryStats{}
+			actual := newChunksDeduplicatingSeriesMerge(queryStats)(tc.series()...)
+			expected := storage.ChainedSeriesMerge(tc.series()...)
+
+			expectedSamples := iterateFloatSamples(t, expected.Iterator(nil))
+			require.NotEmpty(t, expectedSamples)
+
+			assert.Equal(t, lset, actual.Labels())
+			assert.Equal(t, expectedSamples, iterateFloatSamples(t, actual.Iterator(nil)))
+			assert.Equal(t, tc.expectedDedupCount, queryStats.LoadDeduplicatedChunks())
+			assert.Equal(t, tc.expectedDedupBytes, queryStats.LoadDeduplicatedChunkBytes())
+		})
+	}
+}
+
+func iterateFloatSamples(t *testing.T, it chunkenc.Iterator) []model.SamplePair {
+	var samples []model.SamplePair
+	for it.Next() != chunkenc.ValNone {
+		ts, v := it.At()
+		samples = append(samples, model.SamplePair{Timestamp: model.Time(ts), Value: model.SampleValue(v)})
+	}
+	require.NoError(t, it.Err())
+	return samples
+}
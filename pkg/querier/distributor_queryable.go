@@ -11,7 +11,6 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/prometheus/prometheus/scrape"
 	"github.com/prometheus/prometheus/storage"
-	"github.com/prometheus/prometheus/tsdb/chunkenc"
 	"github.com/prometheus/prometheus/util/annotations"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
@@ -34,6 +33,7 @@ type Distributor interface {
 	MetricsForLabelMatchers(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) ([]model.Metric, error)
 	MetricsForLabelMatchersStream(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) ([]model.Metric, error)
 	MetricsMetadata(ctx context.Context) ([]scrape.MetricMetadata, error)
+	TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error)
 }
 
 func newDistributorQueryable(distributor Distributor, streamingMetdata bool, iteratorFn chunkIteratorFunc, queryIngestersWithin time.Duration) QueryableWithFilter {
@@ -63,6 +63,17 @@ func (d distributorQueryable) Querier(mint, maxt int64) (storage.Querier, error)
 	}, nil
 }
 
+// TenantIngesterIDs implements tenantIngestersFinder.
+func (d distributorQueryable) TenantIngesterIDs(ctx context.Context) ([]string, error) {
+	f, ok := d.distributor.(interface {
+		GetIngesterIDsForMetadata(ctx context.Context) ([]string, error)
+	})
+	if !ok {
+		return nil, nil
+	}
+	return f.GetIngesterIDsForMetadata(ctx)
+}
+
 func (d distributorQueryable) UseQueryable(now time.Time, _, queryMaxT int64) bool {
 	// Include ingester only if maxt is within QueryIngestersWithin w.r.t. current time.
 	return d.queryIngestersWithin == 0 || queryMaxT >= util.TimeToMillis(now.Add(-d.queryIngestersWithin))
@@ -149,11 +160,13 @@ func (q *distributorQuerier) streamingSelect(ctx context.Context, sortSeries boo
 			return storage.ErrSeriesSet(err)
 		}
 
-		serieses = append(serieses, &storage.SeriesEntry{
-			Lset: ls,
-			SampleIteratorFn: func(_ chunkenc.Iterator) chunkenc.Iterator {
-				return q.chunkIterFn(chunks, model.Time(minT), model.Time(maxT))
-			},
+		serieses = append(serieses, &ingesterChunksSeries{
+			lset:        ls,
+			raw:         result.Chunks,
+			chunks:      chunks,
+			chunkIterFn: q.chunkIterFn,
+			minT:        minT,
+			maxT:        maxT,
 		})
 	}
 
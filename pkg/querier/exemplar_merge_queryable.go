@@ -0,0 +1,159 @@
+package querier
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+const errMaxExemplarsPerQueryLimit = "the query hit the max number of exemplars limit (limit: %d exemplars)"
+
+// exemplarStoreQueryable is a store able to serve exemplars. The store is queried
+// for exemplars only for the time ranges it would be queried for samples.
+type exemplarStoreQueryable struct {
+	storage.ExemplarQueryable
+	filter QueryableWithFilter
+}
+
+// newMergeExemplarQueryable returns an exemplar queryable which federates the exemplar
+// query across the distributor (ingesters) and every store able to serve exemplars,
+// deduplicating the results and enforcing the per-tenant exemplar query limit.
+func newMergeExemplarQueryable(distributor storage.ExemplarQueryable, stores []exemplarStoreQueryable, limits *validation.Overrides) storage.ExemplarQueryable {
+	return &mergeExemplarQueryable{
+		distributor: distributor,
+		stores:      stores,
+		limits:      limits,
+	}
+}
+
+type mergeExemplarQueryable struct {
+	distributor storage.ExemplarQueryable
+	stores      []exemplarStoreQueryable
+	limits      *validation.Overrides
+}
+
+func (m *mergeExemplarQueryable) ExemplarQuerier(ctx context.Context) (storage.ExemplarQuerier, error) {
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mergeExemplarQuerier{
+		ctx:          ctx,
+		queryable:    m,
+		maxExemplars: m.limits.MaxFetchedExemplarsPerQuery(userID),
+	}, nil
+}
+
+type mergeExemplarQuerier struct {
+	ctx          context.Context
+	queryable    *mergeExemplarQueryable
+	maxExemplars int
+}
+
+// Select implements storage.ExemplarQuerier.
+func (q *mergeExemplarQuerier) Select(start, end int64, matchers ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	queryables := []storage.ExemplarQueryable{q.queryable.distributor}
+	now := time.Now()
+	for _, s := range q.queryable.stores {
+		if s.filter.UseQueryable(now, start, end) {
+			queryables = append(queryables, s.ExemplarQueryable)
+		}
+	}
+
+	// The ingesters and the stores are queried concurrently, each source
+	// writing its own slot of the results.
+	results := make([][]exemplar.QueryResult, len(queryables))
+	jobs := make([]interface{}, len(queryables))
+	for i := range queryables {
+		jobs[i] = i
+	}
+
+	err := concurrency.ForEach(q.ctx, jobs, len(jobs), func(ctx context.Context, job interface{}) error {
+		idx := job.(int)
+
+		querier, err := queryables[idx].ExemplarQuerier(ctx)
+		if err != nil {
+			return err
+		}
+
+		res, err := querier.Select(start, end, matchers...)
+		if err != nil {
+			return err
+		}
+		results[idx] = res
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	merged := mergeExemplarQueryResults(results...)
+
+	if q.maxExemplars > 0 {
+		total := 0
+		for _, r := range merged {
+			total += len(r.Exemplars)
+		}
+		if total > q.maxExemplars {
+			return nil, validation.LimitError(fmt.Sprintf(errMaxExemplarsPerQueryLimit, q.maxExemplars))
+		}
+	}
+
+	return merged, nil
+}
+
+// mergeExemplarQueryResults merges exemplar query results by series, removing exemplars
+// returned by more than one source. The returned series are sorted by labels and their
+// exemplars are sorted by timestamp.
+func mergeExemplarQueryResults(results ...[]exemplar.QueryResult) []exemplar.QueryResult {
+	bySeries := map[string]*exemplar.QueryResult{}
+
+	for _, res := range results {
+		for _, r := range res {
+			key := r.SeriesLabels.String()
+			existing, ok := bySeries[key]
+			if !ok {
+				existing = &exemplar.QueryResult{SeriesLabels: r.SeriesLabels}
+				bySeries[key] = existing
+			}
+			existing.Exemplars = append(existing.Exemplars, r.Exemplars...)
+		}
+	}
+
+	merged := make([]exemplar.QueryResult, 0, len(bySeries))
+	for _, r := range bySeries {
+		sort.Slice(r.Exemplars, func(i, j int) bool {
+			if r.Exemplars[i].Ts != r.Exemplars[j].Ts {
+				return r.Exemplars[i].Ts < r.Exemplars[j].Ts
+			}
+			return labels.Compare(r.Exemplars[i].Labels, r.Exemplars[j].Labels) < 0
+		})
+
+		deduped := r.Exemplars[:0]
+		for i, e := range r.Exemplars {
+			if i > 0 && e.Equals(deduped[len(deduped)-1]) {
+				continue
+			}
+			deduped = append(deduped, e)
+		}
+		r.Exemplars = deduped
+
+		merged = append(merged, *r)
+	}
+
+	sort.Slice(merged, func(i, j int) bool {
+		return labels.Compare(merged[i].SeriesLabels, merged[j].SeriesLabels) < 0
+	})
+
+	return merged
+}
@@ -0,0 +1,139 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/exemplar"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+type mockExemplarQueryable struct {
+	storage.Queryable
+
+	results []exemplar.QueryResult
+	use     bool
+}
+
+func (m *mockExemplarQueryable) ExemplarQuerier(_ context.Context) (storage.ExemplarQuerier, error) {
+	return m, nil
+}
+
+func (m *mockExemplarQueryable) Select(_, _ int64, _ ...[]*labels.Matcher) ([]exemplar.QueryResult, error) {
+	return m.results, nil
+}
+
+func (m *mockExemplarQueryable) UseQueryable(_ time.Time, _, _ int64) bool {
+	return m.use
+}
+
+func TestMergeExemplarQueryable(t *testing.T) {
+	seriesA := labels.FromStrings("__name__", "a")
+	seriesB := labels.FromStrings("__name__", "b")
+	traceA := labels.FromStrings("trace_id", "a")
+	traceB := labels.FromStrings("trace_id", "b")
+
+	ingesters := &mockExemplarQueryable{results: []exemplar.QueryResult{
+		{SeriesLabels: seriesB, Exemplars: []exemplar.Exemplar{{Labels: traceB, Value: 2, Ts: 20, HasTs: true}}},
+		{SeriesLabels: seriesA, Exemplars: []exemplar.Exemplar{{Labels: traceA, Value: 1, Ts: 10, HasTs: true}}},
+	}}
+	blocks := &mockExemplarQueryable{use: true, results: []exemplar.QueryResult{
+		{SeriesLabels: seriesA, Exemplars: []exemplar.Exemplar{
+			{Labels: traceA, Value: 1, Ts: 10, HasTs: true},
+			{Labels: traceB, Value: 3, Ts: 5, HasTs: true},
+		}},
+	}}
+	skipped := &mockExemplarQueryable{use: false, results: []exemplar.QueryResult{
+		{SeriesLabels: seriesA, Exemplars: []exemplar.Exemplar{{Labels: traceA, Value: 9, Ts: 90, HasTs: true}}},
+	}}
+
+	tests := map[string]struct {
+		maxExemplars  int
+		expected      []exemplar.QueryResult
+		expectedError string
+	}{
+		"should merge and deduplicate exemplars from all sources": {
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: seriesA, Exemplars: []exemplar.Exemplar{
+					{Labels: traceB, Value: 3, Ts: 5, HasTs: true},
+					{Labels: traceA, Value: 1, Ts: 10, HasTs: true},
+				}},
+				{SeriesLabels: seriesB, Exemplars: []exemplar.Exemplar{{Labels: traceB, Value: 2, Ts: 20, HasTs: true}}},
+			},
+		},
+		"should not fail if the merged result is within the limit": {
+			maxExemplars: 3,
+			expected: []exemplar.QueryResult{
+				{SeriesLabels: seriesA, Exemplars: []exemplar.Exemplar{
+					{Labels: traceB, Value: 3, Ts: 5, HasTs: true},
+					{Labels: traceA, Value: 1, Ts: 10, HasTs: true},
+				}},
+				{SeriesLabels: seriesB, Exemplars: []exemplar.Exemplar{{Labels: traceB, Value: 2, Ts: 20, HasTs: true}}},
+			},
+		},
+		"should fail if the merged result exceeds the limit": {
+			maxExemplars:  2,
+			expectedError: "the query hit the max number of exemplars limit (limit: 2 exemplars)",
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			limits := DefaultLimitsConfig()
+			limits.MaxFetchedExemplarsPerQuery = testData.maxExemplars
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			queryable := newMergeExemplarQueryable(ingesters, []exemplarStoreQueryable{
+				{ExemplarQueryable: blocks, filter: blocks},
+				{ExemplarQueryable: skipped, filter: skipped},
+			}, overrides)
+			querier, err := queryable.ExemplarQuerier(user.InjectOrgID(context.Background(), "user-1"))
+			require.NoError(t, err)
+
+			res, err := querier.Select(0, 100)
+			if testData.expectedError != "" {
+				require.EqualError(t, err, testData.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, res)
+		})
+	}
+}
+
+func TestNew_ShouldFederateExemplarQueriesToStoresServingExemplars(t *testing.T) {
+	series := labels.FromStrings("__name__", "a")
+	trace := labels.FromStrings("trace_id", "a")
+
+	distributor := &MockDistributor{}
+	distributor.On("QueryExemplars", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&client.ExemplarQueryResponse{}, nil)
+
+	store := &mockExemplarQueryable{use: true, results: []exemplar.QueryResult{
+		{SeriesLabels: series, Exemplars: []exemplar.Exemplar{{Labels: trace, Value: 1, Ts: 10, HasTs: true}}},
+	}}
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	overrides, err := validation.NewOverrides(DefaultLimitsConfig(), nil)
+	require.NoError(t, err)
+
+	_, exemplarQueryable, _ := New(cfg, overrides, distributor, []QueryableWithFilter{store}, nil, log.NewNopLogger())
+	querier, err := exemplarQueryable.ExemplarQuerier(user.InjectOrgID(context.Background(), "user-1"))
+	require.NoError(t, err)
+
+	res, err := querier.Select(0, 100)
+	require.NoError(t, err)
+	assert.Equal(t, store.results, res)
+}
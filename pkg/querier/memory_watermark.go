@@ -0,0 +1,287 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+)
+
+// memoryWatermarkCheckInterval is how frequently the heap is checked while queries are running.
+const memoryWatermarkCheckInterval = 100 * time.Millisecond
+
+// memoryWatermarkError is the error of a query aborted because the querier heap exceeded the watermark.
+type memoryWatermarkError struct {
+	heapBytes uint64
+	watermark uint64
+	samples   uint64
+	running   int
+}
+
+func (e *memoryWatermarkError) Error() string {
+	return fmt.Sprintf("query aborted to protect the querier from running out of memory: the querier heap (%d bytes) exceeded the memory watermark (%d bytes, configured with -querier.memory-watermark-bytes) and this query had loaded the most samples (%d) of the %d queries running", e.heapBytes, e.watermark, e.samples, e.running)
+}
+
+// memoryWatermarkEngine is a promql.QueryEngine which aborts the running query having loaded the most
+// samples when the querier heap exceeds the watermark, to keep the querier alive under extreme queries.
+// Once a query has been aborted, no other query is aborted until a garbage collection has run, so that
+// the memory held by the aborted query is reclaimed before the heap is checked again.
+type memoryWatermarkEngine struct {
+	promql.QueryEngine
+
+	watermark uint64
+	logger    log.Logger
+	interval  time.Duration
+
+	// readHeap returns the bytes of the heap objects, and the number of garbage collections run.
+	readHeap func() (heapBytes, gcCycles uint64)
+
+	mtx     sync.Mutex
+	running map[*memoryWatermarkQuery]struct{}
+	stop    chan struct{}
+	// Number of garbage collections run when the last query has been aborted, if any.
+	abortedAtGCCycles uint64
+	aborted           bool
+
+	abortedQueries prometheus.Counter
+}
+
+func newMemoryWatermarkEngine(engine promql.QueryEngine, watermark uint64, logger log.Logger, reg prometheus.Registerer) *memoryWatermarkEngine {
+	return &memoryWatermarkEngine{
+		QueryEngine: engine,
+		watermark:   watermark,
+		logger:      logger,
+		interval:    memoryWatermarkCheckInterval,
+		readHeap:    readRuntimeHeap,
+		running:     map[*memoryWatermarkQuery]struct{}{},
+		abortedQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_memory_watermark_aborted_queries_total",
+			Help: "Total number of queries aborted because the querier heap exceeded the memory watermark.",
+		}),
+	}
+}
+
+// Close closes the wrapped engine, if it needs to.
+func (e *memoryWatermarkEngine) Close() error {
+	if closer, ok := e.QueryEngine.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// NewInstantQuery implements promql.QueryEngine.
+func (e *memoryWatermarkEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	wq := &memoryWatermarkQuery{engine: e}
+	query, err := e.QueryEngine.NewInstantQuery(ctx, &countingQueryable{Queryable: q, samples: &wq.samples}, opts, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+	wq.Query = query
+	return wq, nil
+}
+
+// NewRangeQuery implements promql.QueryEngine.
+func (e *memoryWatermarkEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	wq := &memoryWatermarkQuery{engine: e}
+	query, err := e.QueryEngine.NewRangeQuery(ctx, &countingQueryable{Queryable: q, samples: &wq.samples}, opts, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+	wq.Query = query
+	return wq, nil
+}
+
+// register tracks the running query, and starts checking the heap if it's the first one.
+func (e *memoryWatermarkEngine) register(q *memoryWatermarkQuery, abort context.CancelCauseFunc) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	q.abort = abort
+	e.running[q] = struct{}{}
+	if len(e.running) == 1 {
+		e.stop = make(chan struct{})
+		go e.checkLoop(e.stop)
+	}
+}
+
+// unregister stops tracking the query, and stops checking the heap if no query is running anymore.
+func (e *memoryWatermarkEngine) unregister(q *memoryWatermarkQuery) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	delete(e.running, q)
+	if len(e.running) == 0 {
+		close(e.stop)
+	}
+}
+
+func (e *memoryWatermarkEngine) checkLoop(stop chan struct{}) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			e.check()
+		}
+	}
+}
+
+// check aborts the running query having loaded the most samples if the heap exceeds the watermark.
+func (e *memoryWatermarkEngine) check() {
+	heapBytes, gcCycles := e.readHeap()
+	if heapBytes <= e.watermark {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.aborted && gcCycles == e.abortedAtGCCycles {
+		return
+	}
+
+	var largest *memoryWatermarkQuery
+	for q := range e.running {
+		if q.abort == nil {
+			continue
+		}
+		if largest == nil || q.samples.Load() > largest.samples.Load() {
+			largest = q
+		}
+	}
+	if largest == nil {
+		return
+	}
+
+	err := &memoryWatermarkError{heapBytes: heapBytes, watermark: e.watermark, samples: largest.samples.Load(), running: len(e.running)}
+	largest.abort(err)
+	largest.abort = nil
+	e.aborted = true
+	e.abortedAtGCCycles = gcCycles
+	e.abortedQueries.Inc()
+	level.Warn(e.logger).Log("msg", "aborted the query having loaded the most samples because the querier heap exceeded the memory watermark", "query", largest.String(), "samples", err.samples, "heap_bytes", heapBytes, "watermark_bytes", e.watermark)
+}
+
+func readRuntimeHeap() (heapBytes, gcCycles uint64) {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/heap/objects:bytes"},
+		{Name: "/gc/cycles/total:gc-cycles"},
+	}
+	metrics.Read(samples)
+	return samples[0].Value.Uint64(), samples[1].Value.Uint64()
+}
+
+type memoryWatermarkQuery struct {
+	promql.Query
+
+	engine *memoryWatermarkEngine
+
+	// Number of samples loaded by the query so far, approximated by the samples iterated.
+	samples atomic.Uint64
+	// abort cancels the query, nil once aborted. Guarded by the engine mutex.
+	abort context.CancelCauseFunc
+}
+
+// Exec implements promql.Query.
+func (q *memoryWatermarkQuery) Exec(ctx context.Context) *promql.Result {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	q.engine.register(q, cancel)
+	res := q.Query.Exec(ctx)
+	q.engine.unregister(q)
+
+	var watermarkErr *memoryWatermarkError
+	if errors.As(context.Cause(ctx), &watermarkErr) {
+		res.Err = watermarkErr
+		res.Value = nil
+	}
+	return res
+}
+
+// countingQueryable counts the samples iterated by the queriers.
+type countingQueryable struct {
+	storage.Queryable
+
+	samples *atomic.Uint64
+}
+
+func (q *countingQueryable) Querier(mint, maxt int64) (storage.Querier, error) {
+	querier, err := q.Queryable.Querier(mint, maxt)
+	if err != nil {
+		return nil, err
+	}
+	return &countingQuerier{Querier: querier, samples: q.samples}, nil
+}
+
+type countingQuerier struct {
+	storage.Querier
+
+	samples *atomic.Uint64
+}
+
+func (q *countingQuerier) Select(ctx context.Context, sortSeries bool, hints *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	return &countingSeriesSet{SeriesSet: q.Querier.Select(ctx, sortSeries, hints, matchers...), samples: q.samples}
+}
+
+type countingSeriesSet struct {
+	storage.SeriesSet
+
+	samples *atomic.Uint64
+}
+
+func (s *countingSeriesSet) At() storage.Series {
+	return &countingSeries{Series: s.SeriesSet.At(), samples: s.samples}
+}
+
+type countingSeries struct {
+	storage.Series
+
+	samples *atomic.Uint64
+}
+
+func (s *countingSeries) Iterator(it chunkenc.Iterator) chunkenc.Iterator {
+	// The iterator to reuse is the one wrapped by a previous call.
+	if c, ok := it.(*countingIterator); ok {
+		it = c.Iterator
+	}
+	return &countingIterator{Iterator: s.Series.Iterator(it), samples: s.samples}
+}
+
+type countingIterator struct {
+	chunkenc.Iterator
+
+	samples *atomic.Uint64
+}
+
+func (it *countingIterator) Next() chunkenc.ValueType {
+	vt := it.Iterator.Next()
+	if vt != chunkenc.ValNone {
+		it.samples.Add(1)
+	}
+	return vt
+}
+
+func (it *countingIterator) Seek(t int64) chunkenc.ValueType {
+	vt := it.Iterator.Seek(t)
+	if vt != chunkenc.ValNone {
+		it.samples.Add(1)
+	}
+	return vt
+}
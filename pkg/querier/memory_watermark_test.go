@@ -0,0 +1,132 @@
+package querier
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestMemoryWatermarkEngine(t *testing.T) {
+	var heapBytes, gcCycles atomic.Uint64
+	heapBytes.Store(10)
+
+	e := newMemoryWatermarkEngine(&mockWatermarkTestEngine{}, 100, log.NewNopLogger(), nil)
+	e.interval = time.Millisecond
+	e.readHeap = func() (uint64, uint64) { return heapBytes.Load(), gcCycles.Load() }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Each query loads the number of samples of its expression, then runs until canceled.
+	results := map[string]chan *promql.Result{}
+	for _, samples := range []int{10, 30, 20} {
+		qs := strconv.Itoa(samples)
+		query, err := e.NewInstantQuery(context.Background(), mockQueryable{}, nil, qs, time.Now())
+		require.NoError(t, err)
+		result := make(chan *promql.Result, 1)
+		results[qs] = result
+		go func() { result <- query.Exec(ctx) }()
+	}
+	require.Eventually(t, func() bool {
+		e.mtx.Lock()
+		defer e.mtx.Unlock()
+		total := uint64(0)
+		for q := range e.running {
+			total += q.samples.Load()
+		}
+		return total == 60
+	}, time.Second, time.Millisecond)
+
+	// The query having loaded the most samples is aborted when the heap exceeds the watermark.
+	heapBytes.Store(200)
+	select {
+	case res := <-results["30"]:
+		require.Error(t, res.Err)
+		assert.Contains(t, res.Err.Error(), "the querier heap (200 bytes) exceeded the memory watermark (100 bytes")
+		assert.Contains(t, res.Err.Error(), "this query had loaded the most samples (30) of the 3 queries running")
+	case <-time.After(time.Second):
+		require.FailNow(t, "the largest query has not been aborted")
+	}
+
+	// No other query is aborted until a garbage collection has run.
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, results["20"], 0)
+	assert.Len(t, results["10"], 0)
+
+	gcCycles.Inc()
+	select {
+	case res := <-results["20"]:
+		assert.Contains(t, res.Err.Error(), "this query had loaded the most samples (20) of the 2 queries running")
+	case <-time.After(time.Second):
+		require.FailNow(t, "the largest query has not been aborted")
+	}
+	assert.Equal(t, float64(2), testutil.ToFloat64(e.abortedQueries))
+
+	// The queries are not aborted anymore once the heap is below the watermark.
+	heapBytes.Store(10)
+	gcCycles.Inc()
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, results["10"], 0)
+
+	cancel()
+	assert.ErrorIs(t, (<-results["10"]).Err, context.Canceled)
+}
+
+type mockWatermarkTestEngine struct {
+	promql.QueryEngine
+}
+
+func (e *mockWatermarkTestEngine) NewInstantQuery(_ context.Context, q storage.Queryable, _ promql.QueryOpts, qs string, _ time.Time) (promql.Query, error) {
+	return &mockWatermarkTestQuery{queryable: q, qs: qs}, nil
+}
+
+type mockWatermarkTestQuery struct {
+	promql.Query
+
+	queryable storage.Queryable
+	qs        string
+}
+
+func (q *mockWatermarkTestQuery) Exec(ctx context.Context) *promql.Result {
+	samples, _ := strconv.Atoi(q.qs)
+	querier, err := q.queryable.Querier(0, int64(samples))
+	if err != nil {
+		return &promql.Result{Err: err}
+	}
+	set := querier.Select(ctx, false, &storage.SelectHints{End: int64(samples)})
+	var it chunkenc.Iterator
+	for set.Next() {
+		it = set.At().Iterator(it)
+		for it.Next() != chunkenc.ValNone {
+		}
+	}
+
+	<-ctx.Done()
+	return &promql.Result{Err: ctx.Err()}
+}
+
+func (q *mockWatermarkTestQuery) String() string {
+	return q.qs
+}
+
+// mockQueryable returns a series with a sample at each millisecond of the time range.
+type mockQueryable struct{}
+
+func (mockQueryable) Querier(_, maxt int64) (storage.Querier, error) {
+	series := model.SampleStream{Metric: model.Metric{"__name__": "up"}}
+	for ts := int64(0); ts < maxt; ts++ {
+		series.Values = append(series.Values, model.SamplePair{Timestamp: model.Time(ts), Value: 1})
+	}
+	return mockQuerier{matrix: model.Matrix{&series}}, nil
+}
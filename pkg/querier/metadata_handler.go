@@ -1,9 +1,16 @@
 package querier
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/prometheus/scrape"
+	"github.com/prometheus/prometheus/storage"
+
 	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
 
 type metricMetadata struct {
@@ -23,9 +30,78 @@ type metadataResult struct {
 	Error  string                      `json:"error,omitempty"`
 }
 
+// MetricsMetadataQuerier returns the metric metadata of a tenant.
+type MetricsMetadataQuerier interface {
+	MetricsMetadata(ctx context.Context) ([]scrape.MetricMetadata, error)
+}
+
+// NewMetricsMetadataQuerier returns a MetricsMetadataQuerier which merges the metric metadata
+// held by the ingesters with the metadata persisted in the blocks by the stores supporting it.
+// If no store supports it, the distributor is returned as is.
+func NewMetricsMetadataQuerier(distributor Distributor, stores []QueryableWithFilter, logger log.Logger) MetricsMetadataQuerier {
+	var metadataStores []MetricsMetadataQuerier
+	for _, s := range stores {
+		var q storage.Queryable = s
+		if a, ok := s.(alwaysTrueFilterQueryable); ok {
+			q = a.Queryable
+		}
+		if m, ok := q.(MetricsMetadataQuerier); ok {
+			metadataStores = append(metadataStores, m)
+		}
+	}
+
+	if len(metadataStores) == 0 {
+		return distributor
+	}
+
+	return &mergeMetricsMetadataQuerier{
+		distributor: distributor,
+		stores:      metadataStores,
+		logger:      logger,
+	}
+}
+
+type mergeMetricsMetadataQuerier struct {
+	distributor Distributor
+	stores      []MetricsMetadataQuerier
+	logger      log.Logger
+}
+
+// MetricsMetadata implements MetricsMetadataQuerier. The metadata read from the stores is
+// best effort: a failure is logged and the metadata held by the ingesters is returned anyway.
+func (q *mergeMetricsMetadataQuerier) MetricsMetadata(ctx context.Context) ([]scrape.MetricMetadata, error) {
+	result, err := q.distributor.MetricsMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dedupTracker := make(map[scrape.MetricMetadata]struct{}, len(result))
+	for _, m := range result {
+		dedupTracker[m] = struct{}{}
+	}
+
+	for _, s := range q.stores {
+		metadata, err := s.MetricsMetadata(ctx)
+		if err != nil {
+			level.Warn(util_log.WithContext(ctx, q.logger)).Log("msg", "failed to read the metric metadata from the storage", "err", err)
+			continue
+		}
+
+		for _, m := range metadata {
+			if _, ok := dedupTracker[m]; ok {
+				continue
+			}
+			dedupTracker[m] = struct{}{}
+			result = append(result, m)
+		}
+	}
+
+	return result, nil
+}
+
 // MetadataHandler returns metric metadata held by Cortex for a given tenant.
 // It is kept and returned as a set.
-func MetadataHandler(d Distributor) http.Handler {
+func MetadataHandler(d MetricsMetadataQuerier) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		resp, err := d.MetricsMetadata(r.Context())
 		if err != nil {
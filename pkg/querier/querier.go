@@ -46,7 +46,11 @@ type Config struct {
 	IngesterStreaming         bool          `yaml:"ingester_streaming" doc:"hidden"`
 	IngesterMetadataStreaming bool          `yaml:"ingester_metadata_streaming"`
 	MaxSamples                int           `yaml:"max_samples"`
+	MemoryWatermarkBytes      uint64        `yaml:"memory_watermark_bytes"`
+	ShadowEngineMaxConcurrent int           `yaml:"shadow_engine_max_concurrent"`
 	QueryIngestersWithin      time.Duration `yaml:"query_ingesters_within"`
+	LazyIngesterQuerying      bool          `yaml:"lazy_ingester_querying_enabled"`
+	ChunksDeduplication       bool          `yaml:"chunks_deduplication_enabled"`
 	AtModifierEnabled         bool          `yaml:"at_modifier_enabled" doc:"hidden"`
 	EnablePerStepStats        bool          `yaml:"per_step_stats_enabled"`
 
@@ -76,6 +80,15 @@ type Config struct {
 	StoreGatewayClient            ClientConfig `yaml:"store_gateway_client"`
 	StoreGatewayQueryStatsEnabled bool         `yaml:"store_gateway_query_stats"`
 
+	// Experimental. Prefer the store-gateways which have loaded all their blocks.
+	StoreGatewayPreferSyncedReplicas bool `yaml:"store_gateway_prefer_synced_replicas"`
+
+	// Experimental. Prefer the store-gateways in the same availability zone of the querier.
+	StoreGatewayPreferredZone string `yaml:"store_gateway_preferred_zone"`
+
+	// Experimental. How far back to look for the metric metadata persisted in the blocks.
+	MetadataBlocksLookback time.Duration `yaml:"metadata_blocks_lookback"`
+
 	ShuffleShardingIngestersLookbackPeriod time.Duration `yaml:"shuffle_sharding_ingesters_lookback_period"`
 
 	// Experimental. Use https://github.com/thanos-io/promql-engine rather than
@@ -110,7 +123,11 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.Timeout, "querier.timeout", 2*time.Minute, "The timeout for a query.")
 	f.BoolVar(&cfg.IngesterMetadataStreaming, "querier.ingester-metadata-streaming", false, "Use streaming RPCs for metadata APIs from ingester.")
 	f.IntVar(&cfg.MaxSamples, "querier.max-samples", 50e6, "Maximum number of samples a single query can load into memory.")
+	f.Uint64Var(&cfg.MemoryWatermarkBytes, "querier.memory-watermark-bytes", 0, "[Experimental] When greater than 0, the running query which has loaded the most samples is aborted with an error whenever the querier heap exceeds this number of bytes, to keep the querier alive under extreme queries. Once a query has been aborted, the next one is only aborted after a garbage collection has reclaimed its memory. 0 to disable.")
+	f.IntVar(&cfg.ShadowEngineMaxConcurrent, "querier.shadow-engine-max-concurrent", 2, "[Experimental] Max number of queries executed concurrently with the shadow engine, in addition to -querier.max-concurrent. The queries sampled by -querier.shadow-engine-fraction while the limit is reached are not executed with the shadow engine.")
 	f.DurationVar(&cfg.QueryIngestersWithin, "querier.query-ingesters-within", 0, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
+	f.BoolVar(&cfg.LazyIngesterQuerying, "querier.lazy-ingester-querying-enabled", false, "[Experimental] When enabled, queries whose time range ends before the newest block of the tenant shipped by each of its ingesters, minus the out-of-order time window, are not sent to ingesters, since their data has already been uploaded to the storage by all of them. The newest blocks are looked up in the bucket index for each tenant, so this requires the bucket index to be enabled.")
+	f.BoolVar(&cfg.ChunksDeduplication, "querier.chunks-deduplication-enabled", false, "[Experimental] When enabled, the chunks with the same time range and data returned by both the ingesters and the store-gateways, or by several store-gateways, are only decoded once. The number of deduplicated chunks is reported in the query stats.")
 	f.BoolVar(&cfg.EnablePerStepStats, "querier.per-step-stats-enabled", false, "Enable returning samples stats per steps in query response.")
 	f.DurationVar(&cfg.MaxQueryIntoFuture, "querier.max-query-into-future", 10*time.Minute, "Maximum duration into the future you can query. 0 to disable.")
 	f.DurationVar(&cfg.DefaultEvaluationInterval, "querier.default-evaluation-interval", time.Minute, "The default evaluation interval or step size for subqueries.")
@@ -118,6 +135,9 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.ActiveQueryTrackerDir, "querier.active-query-tracker-dir", "./active-query-tracker", "Active query tracker monitors active queries, and writes them to the file in given directory. If Cortex discovers any queries in this log during startup, it will log them to the log file. Setting to empty value disables active query tracker, which also disables -querier.max-concurrent option.")
 	f.StringVar(&cfg.StoreGatewayAddresses, "querier.store-gateway-addresses", "", "Comma separated list of store-gateway addresses in DNS Service Discovery format. This option should be set when using the blocks storage and the store-gateway sharding is disabled (when enabled, the store-gateway instances form a ring and addresses are picked from the ring).")
 	f.BoolVar(&cfg.StoreGatewayQueryStatsEnabled, "querier.store-gateway-query-stats-enabled", true, "If enabled, store gateway query stats will be logged using `info` log level.")
+	f.BoolVar(&cfg.StoreGatewayPreferSyncedReplicas, "querier.store-gateway-prefer-synced-replicas", false, "[Experimental] When enabled, the querier prefers the store-gateway replicas which have loaded all the blocks they own, as advertised in the store-gateway ring, over the replicas still syncing their blocks (eg. during a rolling restart). The replicas still syncing are queried only when no fully synced replica is available.")
+	f.StringVar(&cfg.StoreGatewayPreferredZone, "querier.store-gateway-preferred-zone", "", "[Experimental] The availability zone where the querier is running. When set, the querier prefers the store-gateway replicas in this zone, as advertised in the store-gateway ring, to reduce the inter-zone data transfer, and only queries the replicas in the other zones when no replica in this zone is available or when retrying. Empty disables the zone preference.")
+	f.DurationVar(&cfg.MetadataBlocksLookback, "querier.metadata-blocks-lookback", 0, "[Experimental] When greater than 0, the metric metadata API merges the metadata held by the ingesters with the metadata persisted in the meta.json of the blocks stored in the bucket within this lookback period, so that the metadata survives ingester restarts. 0 disables querying the metadata from the blocks.")
 	f.DurationVar(&cfg.LookbackDelta, "querier.lookback-delta", 5*time.Minute, "Time since the last sample after which a time series is considered stale and ignored by expression evaluations.")
 	f.DurationVar(&cfg.ShuffleShardingIngestersLookbackPeriod, "querier.shuffle-sharding-ingesters-lookback-period", 0, "When distributor's sharding strategy is shuffle-sharding and this setting is > 0, queriers fetch in-memory series from the minimum set of required ingesters, selecting only ingesters which may have received series since 'now - lookback period'. The lookback period should be greater or equal than the configured 'query store after' and 'query ingesters within'. If this setting is 0, queriers always query all ingesters (ingesters shuffle sharding on read path is disabled).")
 	f.BoolVar(&cfg.ThanosEngine, "querier.thanos-engine", false, "Experimental. Use Thanos promql engine https://github.com/thanos-io/promql-engine rather than the Prometheus promql engine.")
@@ -162,14 +182,21 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 	distributorQueryable := newDistributorQueryable(distributor, cfg.IngesterMetadataStreaming, iteratorFunc, cfg.QueryIngestersWithin)
 
 	ns := make([]QueryableWithFilter, len(stores))
+	var exemplarStores []exemplarStoreQueryable
 	for ix, s := range stores {
 		ns[ix] = storeQueryable{
 			QueryableWithFilter: s,
 			QueryStoreAfter:     cfg.QueryStoreAfter,
 		}
+
+		// Stores persisting exemplars are queried alongside the ingesters, honoring
+		// the same time range filtering used for the samples.
+		if es, ok := s.(storage.ExemplarQueryable); ok {
+			exemplarStores = append(exemplarStores, exemplarStoreQueryable{ExemplarQueryable: es, filter: ns[ix]})
+		}
 	}
 	queryable := NewQueryable(distributorQueryable, ns, iteratorFunc, cfg, limits)
-	exemplarQueryable := newDistributorExemplarQueryable(distributor)
+	exemplarQueryable := newMergeExemplarQueryable(newDistributorExemplarQueryable(distributor), exemplarStores, limits)
 
 	lazyQueryable := storage.QueryableFunc(func(mint int64, maxt int64) (storage.Querier, error) {
 		querier, err := queryable.Querier(mint, maxt)
@@ -210,6 +237,27 @@ func New(cfg Config, limits *validation.Overrides, distributor Distributor, stor
 	} else {
 		queryEngine = promql.NewEngine(opts)
 	}
+
+	if limits != nil {
+		// The queries shadowed are executed with the other engine. Its metrics are prefixed to not conflict
+		// with the primary engine ones, and it doesn't track the active queries.
+		queryEngine = newShadowEngine(queryEngine, func() promql.QueryEngine {
+			shadowOpts := opts
+			shadowOpts.Reg = prometheus.WrapRegistererWithPrefix("cortex_querier_shadow_", reg)
+			shadowOpts.ActiveQueryTracker = nil
+			if cfg.ThanosEngine {
+				return promql.NewEngine(shadowOpts)
+			}
+			return engine.New(engine.Opts{
+				EngineOpts:        shadowOpts,
+				LogicalOptimizers: logicalplan.AllOptimizers,
+			})
+		}, limits.QueryShadowEngineFraction, cfg.ShadowEngineMaxConcurrent, cfg.Timeout, logger, reg)
+	}
+
+	if cfg.MemoryWatermarkBytes > 0 {
+		queryEngine = newMemoryWatermarkEngine(queryEngine, cfg.MemoryWatermarkBytes, logger, reg)
+	}
 	return NewSampleAndChunkQueryable(lazyQueryable), exemplarQueryable, queryEngine
 }
 
@@ -237,6 +285,21 @@ func createActiveQueryTracker(cfg Config, logger log.Logger) promql.QueryTracker
 	return nil
 }
 
+// NewestShippedBlocksFinder is implemented by the stores knowing the newest block of each tenant
+// shipped by each ingester.
+type NewestShippedBlocksFinder interface {
+	// NewestShippedBlocksMaxTime returns the max time (milliseconds) of the newest block of the tenant
+	// shipped by each ingester, by ingester ID, and false if they are unknown.
+	NewestShippedBlocksMaxTime(ctx context.Context, userID string) (map[string]int64, bool)
+}
+
+// tenantIngestersFinder is implemented by the queryables knowing the ingesters holding the data
+// of each tenant.
+type tenantIngestersFinder interface {
+	// TenantIngesterIDs returns the IDs of the ingesters which may hold data of the tenant.
+	TenantIngesterIDs(ctx context.Context) ([]string, error)
+}
+
 // QueryableWithFilter extends Queryable interface with `UseQueryable` filtering function.
 type QueryableWithFilter interface {
 	storage.Queryable
@@ -262,6 +325,8 @@ func NewQueryable(distributor QueryableWithFilter, stores []QueryableWithFilter,
 			limits:               limits,
 			maxQueryIntoFuture:   cfg.MaxQueryIntoFuture,
 			ignoreMaxQueryLength: cfg.IgnoreMaxQueryLength,
+			lazyIngesterQuerying: cfg.LazyIngesterQuerying,
+			chunksDeduplication:  cfg.ChunksDeduplication,
 			distributor:          distributor,
 			stores:               stores,
 			limiterHolder:        &limiterHolder{},
@@ -283,6 +348,9 @@ type querier struct {
 	limiterHolder      *limiterHolder
 
 	ignoreMaxQueryLength bool
+
+	lazyIngesterQuerying bool
+	chunksDeduplication  bool
 }
 
 func (q querier) setupFromCtx(ctx context.Context) (context.Context, *querier_stats.QueryStats, string, int64, int64, storage.Querier, []storage.Querier, error) {
@@ -310,7 +378,7 @@ func (q querier) setupFromCtx(ctx context.Context) (context.Context, *querier_st
 	metadataQuerier := dqr
 
 	queriers := make([]storage.Querier, 0)
-	if q.distributor.UseQueryable(q.now, mint, maxt) {
+	if q.distributor.UseQueryable(q.now, mint, maxt) && !q.queryEndsBeforeIngestersData(ctx, userID, mint, maxt) {
 		queriers = append(queriers, dqr)
 	}
 
@@ -329,6 +397,50 @@ func (q querier) setupFromCtx(ctx context.Context) (context.Context, *querier_st
 	return ctx, stats, userID, mint, maxt, metadataQuerier, queriers, nil
 }
 
+// queryEndsBeforeIngestersData returns true if the lazy ingester querying is enabled and the query
+// ends before the newest block shipped by each ingester of the tenant, minus the out-of-order time
+// window, in which case the data of the query has been shipped by all the ingesters and they don't
+// need to be queried. The ingesters are queried if any of them hasn't shipped a block yet.
+func (q querier) queryEndsBeforeIngestersData(ctx context.Context, userID string, mint, maxt int64) bool {
+	if !q.lazyIngesterQuerying {
+		return false
+	}
+
+	f, ok := q.distributor.(tenantIngestersFinder)
+	if !ok {
+		return false
+	}
+	ingesterIDs, err := f.TenantIngesterIDs(ctx)
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "failed to look up the ingesters of the tenant, querying them", "user", userID, "err", err)
+		return false
+	}
+	if len(ingesterIDs) == 0 {
+		return false
+	}
+
+	// The out-of-order samples within the window may be ingested after the newest block has been shipped.
+	outOfOrderTimeWindow := time.Duration(q.limits.OutOfOrderTimeWindow(userID)).Milliseconds()
+
+	for _, s := range q.stores {
+		sf, ok := s.(NewestShippedBlocksFinder)
+		if !ok || !s.UseQueryable(q.now, mint, maxt) {
+			continue
+		}
+		newest, ok := sf.NewestShippedBlocksMaxTime(ctx, userID)
+		if !ok {
+			continue
+		}
+		for _, id := range ingesterIDs {
+			if t, ok := newest[id]; !ok || maxt >= t-outOfOrderTimeWindow {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 // Select implements storage.Querier interface.
 // The bool passed is ignored because the series is always sorted.
 func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
@@ -418,7 +530,12 @@ func (q querier) Select(ctx context.Context, sortSeries bool, sp *storage.Select
 		}
 	}
 
-	return storage.NewMergeSeriesSet(result, storage.ChainedSeriesMerge)
+	mergeFunc := storage.ChainedSeriesMerge
+	if q.chunksDeduplication {
+		mergeFunc = newChunksDeduplicatingSeriesMerge(stats)
+	}
+
+	return storage.NewMergeSeriesSet(result, mergeFunc)
 }
 
 // LabelValues implements storage.Querier.
@@ -539,6 +656,14 @@ func (s storeQueryable) UseQueryable(now time.Time, queryMinT, queryMaxT int64)
 	return s.QueryableWithFilter.UseQueryable(now, queryMinT, queryMaxT)
 }
 
+// NewestShippedBlocksMaxTime implements NewestShippedBlocksFinder.
+func (s storeQueryable) NewestShippedBlocksMaxTime(ctx context.Context, userID string) (map[string]int64, bool) {
+	if f, ok := s.QueryableWithFilter.(NewestShippedBlocksFinder); ok {
+		return f.NewestShippedBlocksMaxTime(ctx, userID)
+	}
+	return nil, false
+}
+
 type alwaysTrueFilterQueryable struct {
 	storage.Queryable
 }
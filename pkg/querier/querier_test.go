@@ -1326,6 +1326,10 @@ func (m *errDistributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricMe
 	return nil, errDistributorError
 }
 
+func (m *errDistributor) TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error) {
+	return nil, errDistributorError
+}
+
 type emptyChunkStore struct {
 	sync.Mutex
 	called bool
@@ -1382,6 +1386,10 @@ func (d *emptyDistributor) MetricsMetadata(ctx context.Context) ([]scrape.Metric
 	return nil, nil
 }
 
+func (d *emptyDistributor) TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error) {
+	return &client.TSDBStatusResponse{}, nil
+}
+
 type mockStore interface {
 	Get() ([]chunk.Chunk, error)
 }
@@ -1524,6 +1532,129 @@ func TestShortTermQueryToLTS(t *testing.T) {
 	}
 }
 
+type newestShippedBlocksQueryable struct {
+	QueryableWithFilter
+	newestShippedBlocksMaxTime map[string]int64
+}
+
+func (q newestShippedBlocksQueryable) NewestShippedBlocksMaxTime(_ context.Context, _ string) (map[string]int64, bool) {
+	return q.newestShippedBlocksMaxTime, q.newestShippedBlocksMaxTime != nil
+}
+
+type ingesterIDsDistributor struct {
+	*errDistributor
+	ingesterIDs []string
+}
+
+func (d ingesterIDsDistributor) GetIngesterIDsForMetadata(_ context.Context) ([]string, error) {
+	return d.ingesterIDs, nil
+}
+
+func TestLazyIngesterQuerying(t *testing.T) {
+	t.Parallel()
+	now := time.Now()
+	testCases := map[string]struct {
+		maxt                       time.Time
+		newestShippedBlocksMaxTime map[string]time.Time
+		queryIngestersWithin       time.Duration
+		outOfOrderTimeWindow       time.Duration
+		lazyIngesterQuerying       bool
+		hitIngester                bool
+	}{
+		"disabled": {
+			maxt:                       now.Add(-5 * time.Hour),
+			newestShippedBlocksMaxTime: map[string]time.Time{"ingester-1": now.Add(-2 * time.Hour), "ingester-2": now.Add(-2 * time.Hour)},
+			lazyIngesterQuerying:       false,
+			hitIngester:                true,
+		},
+		"query ending before the newest block shipped by each ingester": {
+			maxt:                       now.Add(-5 * time.Hour),
+			newestShippedBlocksMaxTime: map[string]time.Time{"ingester-1": now.Add(-2 * time.Hour), "ingester-2": now.Add(-4 * time.Hour)},
+			lazyIngesterQuerying:       true,
+			hitIngester:                false,
+		},
+		"query ending after the newest block shipped by an ingester": {
+			maxt:                       now.Add(-3 * time.Hour),
+			newestShippedBlocksMaxTime: map[string]time.Time{"ingester-1": now.Add(-2 * time.Hour), "ingester-2": now.Add(-4 * time.Hour)},
+			lazyIngesterQuerying:       true,
+			hitIngester:                true,
+		},
+		"query ending before the newest blocks, but an ingester hasn't shipped any block yet": {
+			maxt:                       now.Add(-5 * time.Hour),
+			newestShippedBlocksMaxTime: map[string]time.Time{"ingester-1": now.Add(-2 * time.Hour)},
+			lazyIngesterQuerying:       true,
+			hitIngester:                true,
+		},
+		"query ending before the newest blocks, with query ingesters within": {
+			maxt:                       now.Add(-5 * time.Hour),
+			newestShippedBlocksMaxTime: map[string]time.Time{"ingester-1": now.Add(-2 * time.Hour), "ingester-2": now.Add(-4 * time.Hour)},
+			queryIngestersWithin:       13 * time.Hour,
+			lazyIngesterQuerying:       true,
+			hitIngester:                false,
+		},
+		"query ending before the newest blocks, but within the out-of-order time window": {
+			maxt:                       now.Add(-5 * time.Hour),
+			newestShippedBlocksMaxTime: map[string]time.Time{"ingester-1": now.Add(-2 * time.Hour), "ingester-2": now.Add(-4 * time.Hour)},
+			outOfOrderTimeWindow:       2 * time.Hour,
+			lazyIngesterQuerying:       true,
+			hitIngester:                true,
+		},
+		"newest blocks unknown": {
+			maxt:                 now.Add(-5 * time.Hour),
+			lazyIngesterQuerying: true,
+			hitIngester:          true,
+		},
+	}
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		Logger:     log.NewNopLogger(),
+		MaxSamples: 1e6,
+		Timeout:    1 * time.Minute,
+	})
+
+	for name, c := range testCases {
+		c := c
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			// Disable active query tracker to avoid mmap error.
+			cfg.ActiveQueryTrackerDir = ""
+			cfg.QueryIngestersWithin = c.queryIngestersWithin
+			cfg.LazyIngesterQuerying = c.lazyIngesterQuerying
+
+			limits := DefaultLimitsConfig()
+			limits.OutOfOrderTimeWindow = model.Duration(c.outOfOrderTimeWindow)
+			overrides, err := validation.NewOverrides(limits, nil)
+			require.NoError(t, err)
+
+			var newestShippedBlocksMaxTime map[string]int64
+			if c.newestShippedBlocksMaxTime != nil {
+				newestShippedBlocksMaxTime = map[string]int64{}
+				for id, maxTime := range c.newestShippedBlocksMaxTime {
+					newestShippedBlocksMaxTime[id] = util.TimeToMillis(maxTime)
+				}
+			}
+			store := newestShippedBlocksQueryable{UseAlwaysQueryable(NewMockStoreQueryable(&emptyChunkStore{})), newestShippedBlocksMaxTime}
+			distributor := ingesterIDsDistributor{errDistributor: &errDistributor{}, ingesterIDs: []string{"ingester-1", "ingester-2"}}
+
+			queryable, _, _ := New(cfg, overrides, distributor, []QueryableWithFilter{store}, nil, log.NewNopLogger())
+			ctx := user.InjectOrgID(context.Background(), "0")
+			query, err := engine.NewRangeQuery(ctx, queryable, nil, "dummy", c.maxt.Add(-time.Hour), c.maxt, 1*time.Minute)
+			require.NoError(t, err)
+
+			_, err = query.Exec(ctx).Matrix()
+			if c.hitIngester {
+				// If the ingester was hit, the distributor always returns errDistributorError.
+				require.Error(t, err)
+				require.Equal(t, errors.Wrap(errDistributorError, "expanding series").Error(), err.Error())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestUseAlwaysQueryable(t *testing.T) {
 	t.Parallel()
 	m := &mockQueryableWithFilter{}
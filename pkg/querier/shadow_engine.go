@@ -0,0 +1,342 @@
+package querier
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// Max relative difference between the float values returned by the primary and the shadow engines
+// for them to be considered equal, because the engines may not sum the values in the same order.
+const shadowEngineTolerance = 1e-9
+
+// shadowEngine is a promql.QueryEngine which executes the queries with the primary engine, and a fraction
+// of the queries of each tenant also with the shadow engine, asynchronously. The shadow results are compared
+// with the primary ones and the mismatches are logged and counted, but never returned.
+type shadowEngine struct {
+	primary promql.QueryEngine
+
+	// The shadow engine is only created when the first query is shadowed.
+	shadow     promql.QueryEngine
+	shadowOnce sync.Once
+	newShadow  func() promql.QueryEngine
+
+	fraction func(userID string) float64
+	timeout  time.Duration
+	logger   log.Logger
+
+	// Tracks the shadow queries in progress. The sampled queries are not shadowed once
+	// maxConcurrent shadow queries are in progress, or once the engine has been closed.
+	mtx           sync.Mutex
+	running       int
+	maxConcurrent int
+	closed        bool
+	inflight      sync.WaitGroup
+
+	queries        *prometheus.CounterVec
+	droppedQueries prometheus.Counter
+}
+
+func newShadowEngine(primary promql.QueryEngine, newShadow func() promql.QueryEngine, fraction func(userID string) float64, maxConcurrent int, timeout time.Duration, logger log.Logger, reg prometheus.Registerer) *shadowEngine {
+	return &shadowEngine{
+		primary:       primary,
+		newShadow:     newShadow,
+		fraction:      fraction,
+		maxConcurrent: maxConcurrent,
+		timeout:       timeout,
+		logger:        logger,
+		queries: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_querier_shadow_engine_queries_total",
+			Help: "Total number of queries executed with the shadow engine, by result of the comparison with the primary engine.",
+		}, []string{"result"}),
+		droppedQueries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_querier_shadow_engine_dropped_queries_total",
+			Help: "Total number of sampled queries not executed with the shadow engine because the max number of concurrent shadow queries was reached.",
+		}),
+	}
+}
+
+// Close waits for the shadow queries in progress, and stops shadowing the queries.
+func (e *shadowEngine) Close() error {
+	e.mtx.Lock()
+	e.closed = true
+	e.mtx.Unlock()
+
+	e.inflight.Wait()
+	return nil
+}
+
+// startShadowQuery returns whether a shadow query can be started, and tracks it if so.
+func (e *shadowEngine) startShadowQuery() bool {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if e.closed {
+		return false
+	}
+	if e.running >= e.maxConcurrent {
+		e.droppedQueries.Inc()
+		return false
+	}
+	e.running++
+	e.inflight.Add(1)
+	return true
+}
+
+func (e *shadowEngine) doneShadowQuery() {
+	e.mtx.Lock()
+	e.running--
+	e.mtx.Unlock()
+	e.inflight.Done()
+}
+
+// NewInstantQuery implements promql.QueryEngine.
+func (e *shadowEngine) NewInstantQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, ts time.Time) (promql.Query, error) {
+	query, err := e.primary.NewInstantQuery(ctx, q, opts, qs, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.wrapQuery(ctx, query, func(ctx context.Context, engine promql.QueryEngine) (promql.Query, error) {
+		return engine.NewInstantQuery(ctx, q, opts, qs, ts)
+	}), nil
+}
+
+// NewRangeQuery implements promql.QueryEngine.
+func (e *shadowEngine) NewRangeQuery(ctx context.Context, q storage.Queryable, opts promql.QueryOpts, qs string, start, end time.Time, interval time.Duration) (promql.Query, error) {
+	query, err := e.primary.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.wrapQuery(ctx, query, func(ctx context.Context, engine promql.QueryEngine) (promql.Query, error) {
+		return engine.NewRangeQuery(ctx, q, opts, qs, start, end, interval)
+	}), nil
+}
+
+// wrapQuery returns the query wrapped to be also executed with the shadow engine, if the query is sampled.
+func (e *shadowEngine) wrapQuery(ctx context.Context, query promql.Query, newShadowQuery func(context.Context, promql.QueryEngine) (promql.Query, error)) promql.Query {
+	orgID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return query
+	}
+	tenantIDs, err := tenant.TenantIDsFromOrgID(orgID)
+	if err != nil {
+		return query
+	}
+
+	fraction := validation.SmallestPositiveNonZeroFloat64PerTenant(tenantIDs, e.fraction)
+	if fraction <= 0 || rand.Float64() >= fraction {
+		return query
+	}
+
+	return &shadowQuery{Query: query, engine: e, orgID: orgID, newShadowQuery: newShadowQuery}
+}
+
+func (e *shadowEngine) getShadow() promql.QueryEngine {
+	e.shadowOnce.Do(func() {
+		e.shadow = e.newShadow()
+	})
+	return e.shadow
+}
+
+// executeShadowQuery executes the query with the shadow engine, and compares its result with the primary one.
+func (e *shadowEngine) executeShadowQuery(orgID, qs string, primary *promql.Result, newShadowQuery func(context.Context, promql.QueryEngine) (promql.Query, error)) {
+	// The shadow query doesn't depend on the primary query context, which is canceled once the primary
+	// query result has been returned.
+	ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), orgID), e.timeout)
+	defer cancel()
+
+	shadow := &promql.Result{}
+	query, err := newShadowQuery(ctx, e.getShadow())
+	if err != nil {
+		shadow.Err = err
+	} else {
+		defer query.Close()
+		shadow = query.Exec(ctx)
+	}
+
+	if err := compareShadowResults(primary, shadow); err != nil {
+		e.queries.WithLabelValues("mismatch").Inc()
+		level.Warn(e.logger).Log("msg", "shadow engine query result doesn't match the primary engine one", "org_id", orgID, "query", qs, "err", err)
+		return
+	}
+	e.queries.WithLabelValues("match").Inc()
+}
+
+type shadowQuery struct {
+	promql.Query
+
+	engine         *shadowEngine
+	orgID          string
+	newShadowQuery func(context.Context, promql.QueryEngine) (promql.Query, error)
+}
+
+// Exec implements promql.Query.
+func (q *shadowQuery) Exec(ctx context.Context) *promql.Result {
+	res := q.Query.Exec(ctx)
+
+	// There's nothing to compare if the primary query has been canceled.
+	if ctx.Err() != nil {
+		return res
+	}
+
+	if !q.engine.startShadowQuery() {
+		return res
+	}
+
+	// The primary result is released when the query is closed, so it's copied for the comparison.
+	primary := &promql.Result{Err: res.Err, Value: copyPromQLValue(res.Value)}
+
+	go func() {
+		defer q.engine.doneShadowQuery()
+		q.engine.executeShadowQuery(q.orgID, q.String(), primary, q.newShadowQuery)
+	}()
+
+	return res
+}
+
+func copyPromQLValue(v parser.Value) parser.Value {
+	switch v := v.(type) {
+	case promql.Matrix:
+		m := make(promql.Matrix, 0, len(v))
+		for _, s := range v {
+			var hs []promql.HPoint
+			for _, h := range s.Histograms {
+				hs = append(hs, promql.HPoint{T: h.T, H: h.H.Copy()})
+			}
+			m = append(m, promql.Series{Metric: s.Metric, Floats: slices.Clone(s.Floats), Histograms: hs})
+		}
+		return m
+	case promql.Vector:
+		vec := make(promql.Vector, 0, len(v))
+		for _, s := range v {
+			if s.H != nil {
+				s.H = s.H.Copy()
+			}
+			vec = append(vec, s)
+		}
+		return vec
+	default:
+		// Scalars and strings are values.
+		return v
+	}
+}
+
+// compareShadowResults returns an error describing the first difference between the primary and the shadow
+// results. The series are compared regardless of their order.
+func compareShadowResults(primary, shadow *promql.Result) error {
+	switch {
+	case primary.Err != nil && shadow.Err != nil:
+		return nil
+	case primary.Err != nil:
+		return fmt.Errorf("the primary engine failed but not the shadow engine: %w", primary.Err)
+	case shadow.Err != nil:
+		return fmt.Errorf("the shadow engine failed but not the primary engine: %w", shadow.Err)
+	}
+
+	if primary.Value.Type() != shadow.Value.Type() {
+		return fmt.Errorf("the primary engine returned a %s but the shadow engine a %s", primary.Value.Type(), shadow.Value.Type())
+	}
+
+	switch p := primary.Value.(type) {
+	case promql.Matrix:
+		s := shadow.Value.(promql.Matrix)
+		if len(p) != len(s) {
+			return fmt.Errorf("the primary engine returned %d series but the shadow engine %d", len(p), len(s))
+		}
+		sort.Sort(p)
+		sort.Sort(s)
+		for i := range p {
+			if err := compareShadowSeries(p[i], s[i]); err != nil {
+				return err
+			}
+		}
+	case promql.Vector:
+		s := shadow.Value.(promql.Vector)
+		if len(p) != len(s) {
+			return fmt.Errorf("the primary engine returned %d series but the shadow engine %d", len(p), len(s))
+		}
+		sortVector(p)
+		sortVector(s)
+		for i := range p {
+			if !labels.Equal(p[i].Metric, s[i].Metric) {
+				return fmt.Errorf("the primary engine returned the series %s but the shadow engine %s", p[i].Metric, s[i].Metric)
+			}
+			if p[i].T != s[i].T || !shadowFloatEquals(p[i].F, s[i].F) || !shadowHistogramEquals(p[i].H, s[i].H) {
+				return fmt.Errorf("the primary engine returned the sample %s but the shadow engine %s", p[i], s[i])
+			}
+		}
+	case promql.Scalar:
+		s := shadow.Value.(promql.Scalar)
+		if p.T != s.T || !shadowFloatEquals(p.V, s.V) {
+			return fmt.Errorf("the primary engine returned the scalar %s but the shadow engine %s", p, s)
+		}
+	case promql.String:
+		s := shadow.Value.(promql.String)
+		if p != s {
+			return fmt.Errorf("the primary engine returned the string %s but the shadow engine %s", p, s)
+		}
+	}
+	return nil
+}
+
+func compareShadowSeries(p, s promql.Series) error {
+	if !labels.Equal(p.Metric, s.Metric) {
+		return fmt.Errorf("the primary engine returned the series %s but the shadow engine %s", p.Metric, s.Metric)
+	}
+	if len(p.Floats) != len(s.Floats) || len(p.Histograms) != len(s.Histograms) {
+		return fmt.Errorf("the primary engine returned %d float and %d histogram samples for the series %s but the shadow engine %d and %d", len(p.Floats), len(p.Histograms), p.Metric, len(s.Floats), len(s.Histograms))
+	}
+	for i := range p.Floats {
+		if p.Floats[i].T != s.Floats[i].T || !shadowFloatEquals(p.Floats[i].F, s.Floats[i].F) {
+			return fmt.Errorf("the primary engine returned the sample %s for the series %s but the shadow engine %s", p.Floats[i], p.Metric, s.Floats[i])
+		}
+	}
+	for i := range p.Histograms {
+		if p.Histograms[i].T != s.Histograms[i].T || !shadowHistogramEquals(p.Histograms[i].H, s.Histograms[i].H) {
+			return fmt.Errorf("the primary engine returned the histogram sample %s for the series %s but the shadow engine %s", p.Histograms[i], p.Metric, s.Histograms[i])
+		}
+	}
+	return nil
+}
+
+func sortVector(v promql.Vector) {
+	sort.Slice(v, func(i, j int) bool { return labels.Compare(v[i].Metric, v[j].Metric) < 0 })
+}
+
+func shadowFloatEquals(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	if a == b {
+		return true
+	}
+	return math.Abs(a-b) <= shadowEngineTolerance*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func shadowHistogramEquals(a, b *histogram.FloatHistogram) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equals(b)
+}
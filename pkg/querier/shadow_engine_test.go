@@ -0,0 +1,269 @@
+package querier
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	promutil "github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+)
+
+func TestShadowEngine(t *testing.T) {
+	matrix := promql.Matrix{
+		{Metric: labels.FromStrings("job", "a"), Floats: []promql.FPoint{{T: 1, F: 1}, {T: 2, F: 2}}},
+		{Metric: labels.FromStrings("job", "b"), Floats: []promql.FPoint{{T: 1, F: 3}}},
+	}
+
+	for _, tc := range []struct {
+		name          string
+		ctx           context.Context
+		fraction      float64
+		shadowResult  *promql.Result
+		expectedMatch float64
+		expectedDiff  float64
+	}{
+		{
+			name:         "disabled",
+			ctx:          user.InjectOrgID(context.Background(), "user-1"),
+			shadowResult: &promql.Result{Value: matrix},
+		},
+		{
+			name:         "no tenant",
+			ctx:          context.Background(),
+			fraction:     1,
+			shadowResult: &promql.Result{Value: matrix},
+		},
+		{
+			name:          "matching results",
+			ctx:           user.InjectOrgID(context.Background(), "user-1"),
+			fraction:      1,
+			shadowResult:  &promql.Result{Value: promql.Matrix{matrix[1], matrix[0]}},
+			expectedMatch: 1,
+		},
+		{
+			name:         "mismatching results",
+			ctx:          user.InjectOrgID(context.Background(), "user-1"),
+			fraction:     1,
+			shadowResult: &promql.Result{Value: matrix[:1]},
+			expectedDiff: 1,
+		},
+		{
+			name:         "shadow engine failure",
+			ctx:          user.InjectOrgID(context.Background(), "user-1"),
+			fraction:     1,
+			shadowResult: &promql.Result{Err: errors.New("failed")},
+			expectedDiff: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			primary := &mockShadowTestEngine{result: &promql.Result{Value: matrix}}
+			shadow := &mockShadowTestEngine{result: tc.shadowResult}
+			e := newShadowEngine(primary, func() promql.QueryEngine { return shadow }, func(string) float64 { return tc.fraction }, 10, time.Minute, log.NewNopLogger(), reg)
+
+			for _, newQuery := range []func() (promql.Query, error){
+				func() (promql.Query, error) {
+					return e.NewInstantQuery(tc.ctx, nil, nil, "up", time.Now())
+				},
+				func() (promql.Query, error) {
+					return e.NewRangeQuery(tc.ctx, nil, nil, "up", time.Now(), time.Now(), time.Minute)
+				},
+			} {
+				q, err := newQuery()
+				require.NoError(t, err)
+				res := q.Exec(tc.ctx)
+				q.Close()
+
+				// The primary result is always returned.
+				require.NoError(t, res.Err)
+				require.Equal(t, matrix, res.Value)
+			}
+
+			require.NoError(t, e.Close())
+			assert.Equal(t, 2*tc.expectedMatch, promutil.ToFloat64(e.queries.WithLabelValues("match")))
+			assert.Equal(t, 2*tc.expectedDiff, promutil.ToFloat64(e.queries.WithLabelValues("mismatch")))
+			assert.Equal(t, int32(2*(tc.expectedMatch+tc.expectedDiff)), shadow.queries.Load())
+		})
+	}
+}
+
+func TestShadowEngine_ShouldDropTheQueriesOverTheMaxConcurrency(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	matrix := promql.Matrix{{Metric: labels.FromStrings("job", "a"), Floats: []promql.FPoint{{T: 1, F: 1}}}}
+	primary := &mockShadowTestEngine{result: &promql.Result{Value: matrix}}
+	shadow := &mockShadowTestEngine{result: &promql.Result{Value: matrix}, block: make(chan struct{})}
+	e := newShadowEngine(primary, func() promql.QueryEngine { return shadow }, func(string) float64 { return 1 }, 2, time.Minute, log.NewNopLogger(), prometheus.NewPedanticRegistry())
+
+	exec := func() {
+		q, err := e.NewInstantQuery(ctx, nil, nil, "up", time.Now())
+		require.NoError(t, err)
+		require.NoError(t, q.Exec(ctx).Err)
+		q.Close()
+	}
+
+	// The queries sampled while the max number of shadow queries are in progress are not shadowed.
+	for i := 0; i < 3; i++ {
+		exec()
+	}
+	assert.Equal(t, 1.0, promutil.ToFloat64(e.droppedQueries))
+
+	// Closing the engine waits for the shadow queries in progress.
+	closed := make(chan struct{})
+	go func() {
+		assert.NoError(t, e.Close())
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("the engine has been closed while shadow queries are in progress")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(shadow.block)
+	<-closed
+	assert.Equal(t, 2.0, promutil.ToFloat64(e.queries.WithLabelValues("match")))
+
+	// The queries are not shadowed anymore once the engine has been closed.
+	exec()
+	assert.Equal(t, int32(2), shadow.queries.Load())
+}
+
+func TestShadowEngine_ShouldRegisterMetrics(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+	e := newShadowEngine(nil, nil, nil, 10, time.Minute, log.NewNopLogger(), reg)
+	e.queries.WithLabelValues("match").Add(2)
+	e.queries.WithLabelValues("mismatch").Inc()
+
+	require.NoError(t, promutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_shadow_engine_queries_total Total number of queries executed with the shadow engine, by result of the comparison with the primary engine.
+		# TYPE cortex_querier_shadow_engine_queries_total counter
+		cortex_querier_shadow_engine_queries_total{result="match"} 2
+		cortex_querier_shadow_engine_queries_total{result="mismatch"} 1
+	`), "cortex_querier_shadow_engine_queries_total"))
+}
+
+func TestCompareShadowResults(t *testing.T) {
+	series := func(job string, points ...promql.FPoint) promql.Series {
+		return promql.Series{Metric: labels.FromStrings("job", job), Floats: points}
+	}
+	sample := func(job string, t int64, f float64) promql.Sample {
+		return promql.Sample{Metric: labels.FromStrings("job", job), T: t, F: f}
+	}
+
+	for _, tc := range []struct {
+		name            string
+		primary, shadow *promql.Result
+		expectedErr     string
+	}{
+		{
+			name:    "same matrices in a different order",
+			primary: &promql.Result{Value: promql.Matrix{series("a", promql.FPoint{T: 1, F: 1}), series("b", promql.FPoint{T: 1, F: math.NaN()})}},
+			shadow:  &promql.Result{Value: promql.Matrix{series("b", promql.FPoint{T: 1, F: math.NaN()}), series("a", promql.FPoint{T: 1, F: 1 + 1e-12})}},
+		},
+		{
+			name:        "different matrix values",
+			primary:     &promql.Result{Value: promql.Matrix{series("a", promql.FPoint{T: 1, F: 1})}},
+			shadow:      &promql.Result{Value: promql.Matrix{series("a", promql.FPoint{T: 1, F: 1.1})}},
+			expectedErr: "the primary engine returned the sample 1 @[1] for the series {job=\"a\"} but the shadow engine 1.1 @[1]",
+		},
+		{
+			name:        "different matrix series",
+			primary:     &promql.Result{Value: promql.Matrix{series("a", promql.FPoint{T: 1, F: 1})}},
+			shadow:      &promql.Result{Value: promql.Matrix{series("b", promql.FPoint{T: 1, F: 1})}},
+			expectedErr: "the primary engine returned the series {job=\"a\"} but the shadow engine {job=\"b\"}",
+		},
+		{
+			name:    "same vectors in a different order",
+			primary: &promql.Result{Value: promql.Vector{sample("a", 1, 1), sample("b", 1, 2)}},
+			shadow:  &promql.Result{Value: promql.Vector{sample("b", 1, 2), sample("a", 1, 1)}},
+		},
+		{
+			name:        "different vector lengths",
+			primary:     &promql.Result{Value: promql.Vector{sample("a", 1, 1), sample("b", 1, 2)}},
+			shadow:      &promql.Result{Value: promql.Vector{sample("a", 1, 1)}},
+			expectedErr: "the primary engine returned 2 series but the shadow engine 1",
+		},
+		{
+			name:        "different scalars",
+			primary:     &promql.Result{Value: promql.Scalar{T: 1, V: 1}},
+			shadow:      &promql.Result{Value: promql.Scalar{T: 1, V: 2}},
+			expectedErr: "the primary engine returned the scalar scalar: 1 @[1] but the shadow engine scalar: 2 @[1]",
+		},
+		{
+			name:        "different types",
+			primary:     &promql.Result{Value: promql.Scalar{T: 1, V: 1}},
+			shadow:      &promql.Result{Value: promql.Vector{}},
+			expectedErr: "the primary engine returned a scalar but the shadow engine a vector",
+		},
+		{
+			name:    "both failed",
+			primary: &promql.Result{Err: errors.New("primary")},
+			shadow:  &promql.Result{Err: errors.New("shadow")},
+		},
+		{
+			name:        "only the primary engine failed",
+			primary:     &promql.Result{Err: errors.New("failed")},
+			shadow:      &promql.Result{Value: promql.Vector{}},
+			expectedErr: "the primary engine failed but not the shadow engine: failed",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := compareShadowResults(tc.primary, tc.shadow)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+type mockShadowTestEngine struct {
+	result  *promql.Result
+	queries atomic.Int32
+	// The queries are blocked until closed, if set.
+	block chan struct{}
+}
+
+func (e *mockShadowTestEngine) NewInstantQuery(_ context.Context, _ storage.Queryable, _ promql.QueryOpts, qs string, _ time.Time) (promql.Query, error) {
+	e.queries.Inc()
+	return &mockShadowTestQuery{result: e.result, qs: qs, block: e.block}, nil
+}
+
+func (e *mockShadowTestEngine) NewRangeQuery(_ context.Context, _ storage.Queryable, _ promql.QueryOpts, qs string, _, _ time.Time, _ time.Duration) (promql.Query, error) {
+	e.queries.Inc()
+	return &mockShadowTestQuery{result: e.result, qs: qs, block: e.block}, nil
+}
+
+type mockShadowTestQuery struct {
+	promql.Query
+
+	result *promql.Result
+	qs     string
+	block  chan struct{}
+}
+
+func (q *mockShadowTestQuery) Exec(_ context.Context) *promql.Result {
+	if q.block != nil {
+		<-q.block
+	}
+	// The caller owns the result.
+	return &promql.Result{Err: q.result.Err, Value: copyPromQLValue(q.result.Value)}
+}
+
+func (q *mockShadowTestQuery) Close() {}
+
+func (q *mockShadowTestQuery) String() string {
+	return q.qs
+}
@@ -20,7 +20,10 @@ type QueryStats struct {
 	Priority          int64
 	DataSelectMaxTime int64
 	DataSelectMinTime int64
-	m                 sync.Mutex
+	// The results cache lookups are only tracked in the query-frontend.
+	ResultsCacheHits   uint64
+	ResultsCacheMisses uint64
+	m                  sync.Mutex
 }
 
 // ContextWithEmptyStats returns a context with empty stats.
@@ -302,6 +305,102 @@ func (s *QueryStats) LoadStoreGatewayTouchedPostingBytes() uint64 {
 	return atomic.LoadUint64(&s.StoreGatewayTouchedPostingBytes)
 }
 
+func (s *QueryStats) AddStoreGatewayQueriedBlocks(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.StoreGatewayQueriedBlocks, count)
+}
+
+func (s *QueryStats) LoadStoreGatewayQueriedBlocks() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.StoreGatewayQueriedBlocks)
+}
+
+func (s *QueryStats) AddStoreGatewayChunkBytesAvoided(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.StoreGatewayChunkBytesAvoided, bytes)
+}
+
+func (s *QueryStats) LoadStoreGatewayChunkBytesAvoided() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.StoreGatewayChunkBytesAvoided)
+}
+
+func (s *QueryStats) AddDeduplicatedChunks(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.DeduplicatedChunksCount, count)
+}
+
+func (s *QueryStats) LoadDeduplicatedChunks() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.DeduplicatedChunksCount)
+}
+
+func (s *QueryStats) AddDeduplicatedChunkBytes(bytes uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.DeduplicatedChunkBytes, bytes)
+}
+
+func (s *QueryStats) LoadDeduplicatedChunkBytes() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.DeduplicatedChunkBytes)
+}
+
+func (s *QueryStats) AddResultsCacheHits(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.ResultsCacheHits, count)
+}
+
+func (s *QueryStats) LoadResultsCacheHits() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.ResultsCacheHits)
+}
+
+func (s *QueryStats) AddResultsCacheMisses(count uint64) {
+	if s == nil {
+		return
+	}
+
+	atomic.AddUint64(&s.ResultsCacheMisses, count)
+}
+
+func (s *QueryStats) LoadResultsCacheMisses() uint64 {
+	if s == nil {
+		return 0
+	}
+
+	return atomic.LoadUint64(&s.ResultsCacheMisses)
+}
+
 // Merge the provided Stats into this one.
 func (s *QueryStats) Merge(other *QueryStats) {
 	if s == nil || other == nil {
@@ -317,6 +416,10 @@ func (s *QueryStats) Merge(other *QueryStats) {
 	s.AddFetchedChunks(other.LoadFetchedChunks())
 	s.AddStoreGatewayTouchedPostings(other.LoadStoreGatewayTouchedPostings())
 	s.AddStoreGatewayTouchedPostingBytes(other.LoadStoreGatewayTouchedPostingBytes())
+	s.AddStoreGatewayQueriedBlocks(other.LoadStoreGatewayQueriedBlocks())
+	s.AddStoreGatewayChunkBytesAvoided(other.LoadStoreGatewayChunkBytesAvoided())
+	s.AddDeduplicatedChunks(other.LoadDeduplicatedChunks())
+	s.AddDeduplicatedChunkBytes(other.LoadDeduplicatedChunkBytes())
 	s.AddExtraFields(other.LoadExtraFields()...)
 }
 
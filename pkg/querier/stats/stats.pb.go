@@ -10,7 +10,7 @@ import (
 	github_com_gogo_protobuf_sortkeys "github.com/gogo/protobuf/sortkeys"
 	_ "github.com/gogo/protobuf/types"
 	github_com_gogo_protobuf_types "github.com/gogo/protobuf/types"
-	_ "github.com/golang/protobuf/ptypes/duration"
+	_ "google.golang.org/protobuf/types/known/durationpb"
 	io "io"
 	math "math"
 	math_bits "math/bits"
@@ -59,6 +59,17 @@ type Stats struct {
 	// The total size of postings touched in store gateway for a specific query, in bytes.
 	// Only successful requests from querier to store gateway are included.
 	StoreGatewayTouchedPostingBytes uint64 `protobuf:"varint,12,opt,name=store_gateway_touched_posting_bytes,json=storeGatewayTouchedPostingBytes,proto3" json:"store_gateway_touched_posting_bytes,omitempty"`
+	// The number of chunks fetched for the query which were identical to another fetched chunk,
+	// like the chunks returned by both the ingesters and the store-gateways, and weren't decoded.
+	DeduplicatedChunksCount uint64 `protobuf:"varint,13,opt,name=deduplicated_chunks_count,json=deduplicatedChunksCount,proto3" json:"deduplicated_chunks_count,omitempty"`
+	// The number of bytes of the deduplicated chunks.
+	DeduplicatedChunkBytes uint64 `protobuf:"varint,14,opt,name=deduplicated_chunk_bytes,json=deduplicatedChunkBytes,proto3" json:"deduplicated_chunk_bytes,omitempty"`
+	// The number of blocks queried in store gateway for a specific query.
+	// Only successful requests from querier to store gateway are included.
+	StoreGatewayQueriedBlocks uint64 `protobuf:"varint,15,opt,name=store_gateway_queried_blocks,json=storeGatewayQueriedBlocks,proto3" json:"store_gateway_queried_blocks,omitempty"`
+	// The estimated number of bytes of the chunks which weren't fetched from the store gateway
+	// because the query only needed the series labels, like the /api/v1/series requests.
+	StoreGatewayChunkBytesAvoided uint64 `protobuf:"varint,16,opt,name=store_gateway_chunk_bytes_avoided,json=storeGatewayChunkBytesAvoided,proto3" json:"store_gateway_chunk_bytes_avoided,omitempty"`
 }
 
 func (m *Stats) Reset()      { *m = Stats{} }
@@ -177,6 +188,34 @@ func (m *Stats) GetStoreGatewayTouchedPostingBytes() uint64 {
 	return 0
 }
 
+func (m *Stats) GetDeduplicatedChunksCount() uint64 {
+	if m != nil {
+		return m.DeduplicatedChunksCount
+	}
+	return 0
+}
+
+func (m *Stats) GetDeduplicatedChunkBytes() uint64 {
+	if m != nil {
+		return m.DeduplicatedChunkBytes
+	}
+	return 0
+}
+
+func (m *Stats) GetStoreGatewayQueriedBlocks() uint64 {
+	if m != nil {
+		return m.StoreGatewayQueriedBlocks
+	}
+	return 0
+}
+
+func (m *Stats) GetStoreGatewayChunkBytesAvoided() uint64 {
+	if m != nil {
+		return m.StoreGatewayChunkBytesAvoided
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Stats)(nil), "stats.Stats")
 	proto.RegisterMapType((map[string]string)(nil), "stats.Stats.ExtraFieldsEntry")
@@ -185,41 +224,47 @@ func init() {
 func init() { proto.RegisterFile("stats.proto", fileDescriptor_b4756a0aec8b9d44) }
 
 var fileDescriptor_b4756a0aec8b9d44 = []byte{
-	// 544 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x93, 0xcf, 0x6e, 0xd3, 0x40,
-	0x10, 0xc6, 0xbd, 0xcd, 0x1f, 0xe2, 0x4d, 0x90, 0x82, 0x09, 0xc2, 0x8d, 0xc4, 0x26, 0x50, 0x0e,
-	0x39, 0x20, 0x07, 0x85, 0x0b, 0x02, 0x09, 0x55, 0x69, 0x0b, 0x1c, 0x10, 0x82, 0xa4, 0x12, 0x52,
-	0x2f, 0xab, 0x4d, 0xb2, 0x71, 0xac, 0x3a, 0xde, 0x60, 0xaf, 0x29, 0xbe, 0x21, 0xf1, 0x02, 0x1c,
-	0x79, 0x04, 0x1e, 0x25, 0xc7, 0x1c, 0x7b, 0x2a, 0xc4, 0xb9, 0x70, 0xec, 0x23, 0xa0, 0x9d, 0xb5,
-	0x5b, 0xa8, 0x04, 0xe2, 0xe6, 0x9d, 0xdf, 0x37, 0x9f, 0xf6, 0x9b, 0x59, 0xe3, 0x6a, 0x24, 0x99,
-	0x8c, 0x9c, 0x45, 0x28, 0xa4, 0xb0, 0x4a, 0x70, 0x68, 0x36, 0x5c, 0xe1, 0x0a, 0xa8, 0x74, 0xd5,
-	0x97, 0x86, 0x4d, 0xe2, 0x0a, 0xe1, 0xfa, 0xbc, 0x0b, 0xa7, 0x51, 0x3c, 0xed, 0x4e, 0xe2, 0x90,
-	0x49, 0x4f, 0x04, 0x19, 0xdf, 0xbe, 0xca, 0x59, 0x90, 0x68, 0x74, 0xef, 0x73, 0x19, 0x97, 0x86,
-	0xca, 0xda, 0xda, 0xc5, 0xe6, 0x09, 0xf3, 0x7d, 0x2a, 0xbd, 0x39, 0xb7, 0x51, 0x1b, 0x75, 0xaa,
-	0xbd, 0x6d, 0x47, 0x37, 0x3a, 0x79, 0xa3, 0xb3, 0x9f, 0x19, 0xf7, 0x2b, 0xcb, 0xb3, 0x96, 0xf1,
-	0xf5, 0x7b, 0x0b, 0x0d, 0x2a, 0xaa, 0xeb, 0xd0, 0x9b, 0x73, 0xeb, 0x21, 0x6e, 0x4c, 0xb9, 0x1c,
-	0xcf, 0xf8, 0x84, 0x46, 0x3c, 0xf4, 0x78, 0x44, 0xc7, 0x22, 0x0e, 0xa4, 0xbd, 0xd5, 0x46, 0x9d,
-	0xe2, 0xc0, 0xca, 0xd8, 0x10, 0xd0, 0x9e, 0x22, 0x96, 0x83, 0x6f, 0xe6, 0x1d, 0xe3, 0x59, 0x1c,
-	0x1c, 0xd3, 0x51, 0x22, 0x79, 0x64, 0x17, 0xa0, 0xe1, 0x46, 0x86, 0xf6, 0x14, 0xe9, 0x2b, 0x60,
-	0x3d, 0xc0, 0xb9, 0x0b, 0x9d, 0x30, 0xc9, 0x32, 0x79, 0x11, 0xe4, 0xf5, 0x8c, 0xec, 0x33, 0xc9,
-	0xb4, 0x7a, 0x17, 0xd7, 0xf8, 0x47, 0x19, 0x32, 0x3a, 0xf5, 0xb8, 0x3f, 0x89, 0xec, 0x52, 0xbb,
-	0xd0, 0xa9, 0xf6, 0xee, 0x38, 0x7a, 0xae, 0x90, 0xda, 0x39, 0x50, 0x82, 0xe7, 0xc0, 0x0f, 0x02,
-	0x19, 0x26, 0x83, 0x2a, 0xbf, 0xac, 0xfc, 0x9e, 0x08, 0xee, 0x97, 0x27, 0x2a, 0xff, 0x91, 0x08,
-	0x2e, 0x98, 0x25, 0xea, 0xe1, 0x5b, 0x17, 0x33, 0x60, 0xf3, 0x85, 0x7f, 0x31, 0x84, 0x6b, 0xd0,
-	0x92, 0xc7, 0x1d, 0x6a, 0xa6, 0x7b, 0xee, 0x62, 0xd3, 0xf7, 0xe6, 0x9e, 0xa4, 0x33, 0x4f, 0xda,
-	0x95, 0x36, 0xea, 0x98, 0xfd, 0xe2, 0xf2, 0x4c, 0x8d, 0x16, 0xca, 0x2f, 0x3d, 0x69, 0xed, 0xe0,
-	0xeb, 0xd1, 0xc2, 0xf7, 0x24, 0x7d, 0x1f, 0xc3, 0xf8, 0x6c, 0x13, 0xec, 0x6a, 0x50, 0x7c, 0xab,
-	0x6b, 0xd6, 0x11, 0xbe, 0xad, 0x70, 0x42, 0x23, 0x29, 0x42, 0xe6, 0x72, 0x7a, 0xb9, 0x4f, 0xfc,
-	0xff, 0xfb, 0x6c, 0x80, 0xc7, 0x50, 0x5b, 0xbc, 0xcb, 0x77, 0xfb, 0x1a, 0xdf, 0x57, 0xae, 0x9c,
-	0xba, 0x4c, 0xf2, 0x13, 0x96, 0x50, 0x29, 0x62, 0x48, 0xb9, 0x10, 0x91, 0xf4, 0x02, 0x37, 0x8f,
-	0x59, 0x85, 0x7b, 0xb5, 0x41, 0xfb, 0x42, 0x4b, 0x0f, 0xb5, 0xf2, 0x4d, 0x26, 0xd4, 0x99, 0x5f,
-	0xe1, 0x9d, 0x7f, 0xfa, 0x65, 0xab, 0xad, 0x81, 0x5d, 0xeb, 0xef, 0x76, 0xb0, 0xe9, 0xe6, 0x33,
-	0x5c, 0xbf, 0xba, 0x48, 0xab, 0x8e, 0x0b, 0xc7, 0x3c, 0x81, 0x97, 0x6c, 0x0e, 0xd4, 0xa7, 0xd5,
-	0xc0, 0xa5, 0x0f, 0xcc, 0x8f, 0x39, 0x3c, 0x48, 0x73, 0xa0, 0x0f, 0x4f, 0xb6, 0x1e, 0xa3, 0xfe,
-	0xd3, 0xd5, 0x9a, 0x18, 0xa7, 0x6b, 0x62, 0x9c, 0xaf, 0x09, 0xfa, 0x94, 0x12, 0xf4, 0x2d, 0x25,
-	0x68, 0x99, 0x12, 0xb4, 0x4a, 0x09, 0xfa, 0x91, 0x12, 0xf4, 0x33, 0x25, 0xc6, 0x79, 0x4a, 0xd0,
-	0x97, 0x0d, 0x31, 0x56, 0x1b, 0x62, 0x9c, 0x6e, 0x88, 0x71, 0xa4, 0xff, 0xc9, 0x51, 0x19, 0xa6,
-	0xf9, 0xe8, 0x57, 0x00, 0x00, 0x00, 0xff, 0xff, 0x16, 0xa4, 0x90, 0x1b, 0xb0, 0x03, 0x00, 0x00,
+	// 626 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x54, 0x4f, 0x53, 0xd3, 0x40,
+	0x14, 0xcf, 0x02, 0xc5, 0x76, 0x0b, 0x5a, 0x63, 0x95, 0x94, 0x91, 0xa5, 0x88, 0x87, 0x1e, 0x9c,
+	0xe0, 0xe0, 0x85, 0xc1, 0x19, 0xc5, 0x02, 0xca, 0xc1, 0x71, 0xb4, 0x65, 0xc6, 0x19, 0x2e, 0x3b,
+	0xdb, 0x66, 0x09, 0x3b, 0xa4, 0xd9, 0x9a, 0x6c, 0xc0, 0xdc, 0x3c, 0xf8, 0x01, 0x3c, 0xfa, 0x11,
+	0xfc, 0x28, 0x1c, 0x39, 0x72, 0x42, 0x09, 0x17, 0x8f, 0x7c, 0x04, 0x27, 0x6f, 0x13, 0x08, 0x65,
+	0x74, 0xbc, 0x65, 0xdf, 0xef, 0xcf, 0xbe, 0xdf, 0x7b, 0x49, 0x70, 0x35, 0x54, 0x4c, 0x85, 0xf6,
+	0x30, 0x90, 0x4a, 0x9a, 0x25, 0x38, 0xcc, 0xd6, 0x5d, 0xe9, 0x4a, 0xa8, 0x2c, 0xa5, 0x4f, 0x1a,
+	0x9c, 0x25, 0xae, 0x94, 0xae, 0xc7, 0x97, 0xe0, 0xd4, 0x8b, 0x76, 0x97, 0x9c, 0x28, 0x60, 0x4a,
+	0x48, 0x3f, 0xc3, 0x1b, 0xa3, 0x38, 0xf3, 0x63, 0x0d, 0x3d, 0xfa, 0x5a, 0xc6, 0xa5, 0x6e, 0x6a,
+	0x6d, 0xae, 0xe1, 0xca, 0x21, 0xf3, 0x3c, 0xaa, 0xc4, 0x80, 0x5b, 0xa8, 0x89, 0x5a, 0xd5, 0xe5,
+	0x86, 0xad, 0x85, 0x76, 0x2e, 0xb4, 0x37, 0x32, 0xe3, 0x76, 0xf9, 0xe8, 0x74, 0xde, 0xf8, 0xfe,
+	0x73, 0x1e, 0x75, 0xca, 0xa9, 0x6a, 0x5b, 0x0c, 0xb8, 0xf9, 0x14, 0xd7, 0x77, 0xb9, 0xea, 0xef,
+	0x71, 0x87, 0x86, 0x3c, 0x10, 0x3c, 0xa4, 0x7d, 0x19, 0xf9, 0xca, 0x1a, 0x6b, 0xa2, 0xd6, 0x44,
+	0xc7, 0xcc, 0xb0, 0x2e, 0x40, 0xeb, 0x29, 0x62, 0xda, 0xf8, 0x5e, 0xae, 0xe8, 0xef, 0x45, 0xfe,
+	0x3e, 0xed, 0xc5, 0x8a, 0x87, 0xd6, 0x38, 0x08, 0xee, 0x66, 0xd0, 0x7a, 0x8a, 0xb4, 0x53, 0xc0,
+	0x7c, 0x82, 0x73, 0x17, 0xea, 0x30, 0xc5, 0x32, 0xfa, 0x04, 0xd0, 0x6b, 0x19, 0xb2, 0xc1, 0x14,
+	0xd3, 0xec, 0x35, 0x3c, 0xc5, 0x3f, 0xab, 0x80, 0xd1, 0x5d, 0xc1, 0x3d, 0x27, 0xb4, 0x4a, 0xcd,
+	0xf1, 0x56, 0x75, 0x79, 0xce, 0xd6, 0x73, 0x85, 0xd4, 0xf6, 0x66, 0x4a, 0x78, 0x0d, 0xf8, 0xa6,
+	0xaf, 0x82, 0xb8, 0x53, 0xe5, 0x57, 0x95, 0x62, 0x22, 0xe8, 0x2f, 0x4f, 0x34, 0x79, 0x2d, 0x11,
+	0x34, 0x98, 0x25, 0x5a, 0xc6, 0xf7, 0x2f, 0x67, 0xc0, 0x06, 0x43, 0xef, 0x72, 0x08, 0xb7, 0x40,
+	0x92, 0xc7, 0xed, 0x6a, 0x4c, 0x6b, 0x16, 0x70, 0xc5, 0x13, 0x03, 0xa1, 0xe8, 0x9e, 0x50, 0x56,
+	0xb9, 0x89, 0x5a, 0x95, 0xf6, 0xc4, 0xd1, 0x69, 0x3a, 0x5a, 0x28, 0x6f, 0x09, 0x65, 0x2e, 0xe2,
+	0xe9, 0x70, 0xe8, 0x09, 0x45, 0x3f, 0x45, 0x30, 0x3e, 0xab, 0x02, 0x76, 0x53, 0x50, 0xfc, 0xa0,
+	0x6b, 0xe6, 0x0e, 0x9e, 0x49, 0xe1, 0x98, 0x86, 0x4a, 0x06, 0xcc, 0xe5, 0xf4, 0x6a, 0x9f, 0xf8,
+	0xff, 0xf7, 0x59, 0x07, 0x8f, 0xae, 0xb6, 0xf8, 0x98, 0xef, 0xf6, 0x1d, 0x7e, 0x9c, 0xba, 0x72,
+	0xea, 0x32, 0xc5, 0x0f, 0x59, 0x4c, 0x95, 0x8c, 0x20, 0xe5, 0x50, 0x86, 0x4a, 0xf8, 0x6e, 0x1e,
+	0xb3, 0x0a, 0x7d, 0x35, 0x81, 0xfb, 0x46, 0x53, 0xb7, 0x35, 0xf3, 0x7d, 0x46, 0xd4, 0x99, 0xdf,
+	0xe2, 0xc5, 0x7f, 0xfa, 0x65, 0xab, 0x9d, 0x02, 0xbb, 0xf9, 0xbf, 0xdb, 0xe9, 0x4d, 0xaf, 0xe2,
+	0x86, 0xc3, 0x9d, 0x68, 0xe8, 0x89, 0x3e, 0x53, 0xa3, 0xcb, 0x9a, 0x06, 0x8f, 0x99, 0x22, 0xa1,
+	0xb8, 0xb1, 0x15, 0x6c, 0xdd, 0xd4, 0x66, 0xd7, 0xdf, 0x06, 0xe9, 0x83, 0x1b, 0x52, 0x7d, 0xeb,
+	0x4b, 0xfc, 0xf0, 0x7a, 0x06, 0xbd, 0x1c, 0x87, 0xf6, 0x3c, 0xd9, 0xdf, 0x0f, 0xad, 0x3b, 0xa0,
+	0x6e, 0x14, 0x9b, 0xd7, 0xab, 0x72, 0xda, 0x40, 0x30, 0xb7, 0xf0, 0xc2, 0x75, 0x83, 0xc2, 0xdd,
+	0x94, 0x1d, 0x48, 0xe1, 0x70, 0xc7, 0xaa, 0x81, 0xcb, 0x5c, 0xd1, 0xe5, 0xaa, 0x87, 0x57, 0x9a,
+	0x34, 0xfb, 0x02, 0xd7, 0x46, 0xdf, 0x64, 0xb3, 0x86, 0xc7, 0xf7, 0x79, 0x0c, 0x9f, 0x72, 0xa5,
+	0x93, 0x3e, 0x9a, 0x75, 0x5c, 0x3a, 0x60, 0x5e, 0xc4, 0xe1, 0x8b, 0xac, 0x74, 0xf4, 0x61, 0x75,
+	0x6c, 0x05, 0xb5, 0x9f, 0x1f, 0x9f, 0x11, 0xe3, 0xe4, 0x8c, 0x18, 0x17, 0x67, 0x04, 0x7d, 0x49,
+	0x08, 0xfa, 0x91, 0x10, 0x74, 0x94, 0x10, 0x74, 0x9c, 0x10, 0xf4, 0x2b, 0x21, 0xe8, 0x77, 0x42,
+	0x8c, 0x8b, 0x84, 0xa0, 0x6f, 0xe7, 0xc4, 0x38, 0x3e, 0x27, 0xc6, 0xc9, 0x39, 0x31, 0x76, 0xf4,
+	0x4f, 0xa9, 0x37, 0x09, 0xaf, 0xd3, 0xb3, 0x3f, 0x03, 0x00, 0x64, 0x4a, 0x9a, 0x9d, 0xb1, 0x04,
+	0x00, 0x00,
 }
 
 func (this *Stats) Equal(that interface{}) bool {
@@ -282,13 +327,25 @@ func (this *Stats) Equal(that interface{}) bool {
 	if this.StoreGatewayTouchedPostingBytes != that1.StoreGatewayTouchedPostingBytes {
 		return false
 	}
+	if this.DeduplicatedChunksCount != that1.DeduplicatedChunksCount {
+		return false
+	}
+	if this.DeduplicatedChunkBytes != that1.DeduplicatedChunkBytes {
+		return false
+	}
+	if this.StoreGatewayQueriedBlocks != that1.StoreGatewayQueriedBlocks {
+		return false
+	}
+	if this.StoreGatewayChunkBytesAvoided != that1.StoreGatewayChunkBytesAvoided {
+		return false
+	}
 	return true
 }
 func (this *Stats) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 16)
+	s := make([]string, 0, 20)
 	s = append(s, "&stats.Stats{")
 	s = append(s, "WallTime: "+fmt.Sprintf("%#v", this.WallTime)+",\n")
 	s = append(s, "FetchedSeriesCount: "+fmt.Sprintf("%#v", this.FetchedSeriesCount)+",\n")
@@ -314,6 +371,10 @@ func (this *Stats) GoString() string {
 	s = append(s, "QueryStorageWallTime: "+fmt.Sprintf("%#v", this.QueryStorageWallTime)+",\n")
 	s = append(s, "StoreGatewayTouchedPostingsCount: "+fmt.Sprintf("%#v", this.StoreGatewayTouchedPostingsCount)+",\n")
 	s = append(s, "StoreGatewayTouchedPostingBytes: "+fmt.Sprintf("%#v", this.StoreGatewayTouchedPostingBytes)+",\n")
+	s = append(s, "DeduplicatedChunksCount: "+fmt.Sprintf("%#v", this.DeduplicatedChunksCount)+",\n")
+	s = append(s, "DeduplicatedChunkBytes: "+fmt.Sprintf("%#v", this.DeduplicatedChunkBytes)+",\n")
+	s = append(s, "StoreGatewayQueriedBlocks: "+fmt.Sprintf("%#v", this.StoreGatewayQueriedBlocks)+",\n")
+	s = append(s, "StoreGatewayChunkBytesAvoided: "+fmt.Sprintf("%#v", this.StoreGatewayChunkBytesAvoided)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -345,6 +406,28 @@ func (m *Stats) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.StoreGatewayChunkBytesAvoided != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.StoreGatewayChunkBytesAvoided))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x80
+	}
+	if m.StoreGatewayQueriedBlocks != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.StoreGatewayQueriedBlocks))
+		i--
+		dAtA[i] = 0x78
+	}
+	if m.DeduplicatedChunkBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.DeduplicatedChunkBytes))
+		i--
+		dAtA[i] = 0x70
+	}
+	if m.DeduplicatedChunksCount != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.DeduplicatedChunksCount))
+		i--
+		dAtA[i] = 0x68
+	}
 	if m.StoreGatewayTouchedPostingBytes != 0 {
 		i = encodeVarintStats(dAtA, i, uint64(m.StoreGatewayTouchedPostingBytes))
 		i--
@@ -487,6 +570,18 @@ func (m *Stats) Size() (n int) {
 	if m.StoreGatewayTouchedPostingBytes != 0 {
 		n += 1 + sovStats(uint64(m.StoreGatewayTouchedPostingBytes))
 	}
+	if m.DeduplicatedChunksCount != 0 {
+		n += 1 + sovStats(uint64(m.DeduplicatedChunksCount))
+	}
+	if m.DeduplicatedChunkBytes != 0 {
+		n += 1 + sovStats(uint64(m.DeduplicatedChunkBytes))
+	}
+	if m.StoreGatewayQueriedBlocks != 0 {
+		n += 1 + sovStats(uint64(m.StoreGatewayQueriedBlocks))
+	}
+	if m.StoreGatewayChunkBytesAvoided != 0 {
+		n += 2 + sovStats(uint64(m.StoreGatewayChunkBytesAvoided))
+	}
 	return n
 }
 
@@ -511,7 +606,7 @@ func (this *Stats) String() string {
 	}
 	mapStringForExtraFields += "}"
 	s := strings.Join([]string{`&Stats{`,
-		`WallTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.WallTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`WallTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.WallTime), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
 		`FetchedSeriesCount:` + fmt.Sprintf("%v", this.FetchedSeriesCount) + `,`,
 		`FetchedChunkBytes:` + fmt.Sprintf("%v", this.FetchedChunkBytes) + `,`,
 		`FetchedDataBytes:` + fmt.Sprintf("%v", this.FetchedDataBytes) + `,`,
@@ -520,9 +615,13 @@ func (this *Stats) String() string {
 		`FetchedSamplesCount:` + fmt.Sprintf("%v", this.FetchedSamplesCount) + `,`,
 		`LimitHit:` + fmt.Sprintf("%v", this.LimitHit) + `,`,
 		`SplitQueries:` + fmt.Sprintf("%v", this.SplitQueries) + `,`,
-		`QueryStorageWallTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.QueryStorageWallTime), "Duration", "duration.Duration", 1), `&`, ``, 1) + `,`,
+		`QueryStorageWallTime:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.QueryStorageWallTime), "Duration", "durationpb.Duration", 1), `&`, ``, 1) + `,`,
 		`StoreGatewayTouchedPostingsCount:` + fmt.Sprintf("%v", this.StoreGatewayTouchedPostingsCount) + `,`,
 		`StoreGatewayTouchedPostingBytes:` + fmt.Sprintf("%v", this.StoreGatewayTouchedPostingBytes) + `,`,
+		`DeduplicatedChunksCount:` + fmt.Sprintf("%v", this.DeduplicatedChunksCount) + `,`,
+		`DeduplicatedChunkBytes:` + fmt.Sprintf("%v", this.DeduplicatedChunkBytes) + `,`,
+		`StoreGatewayQueriedBlocks:` + fmt.Sprintf("%v", this.StoreGatewayQueriedBlocks) + `,`,
+		`StoreGatewayChunkBytesAvoided:` + fmt.Sprintf("%v", this.StoreGatewayChunkBytesAvoided) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -941,6 +1040,82 @@ func (m *Stats) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeduplicatedChunksCount", wireType)
+			}
+			m.DeduplicatedChunksCount = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeduplicatedChunksCount |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DeduplicatedChunkBytes", wireType)
+			}
+			m.DeduplicatedChunkBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DeduplicatedChunkBytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreGatewayQueriedBlocks", wireType)
+			}
+			m.StoreGatewayQueriedBlocks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreGatewayQueriedBlocks |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 16:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StoreGatewayChunkBytesAvoided", wireType)
+			}
+			m.StoreGatewayChunkBytesAvoided = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.StoreGatewayChunkBytesAvoided |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
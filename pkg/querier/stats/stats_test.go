@@ -169,6 +169,64 @@ func TestStats_AddStoreGatewayTouchedPostingBytes(t *testing.T) {
 	})
 }
 
+func TestStats_AddStoreGatewayQueriedBlocks(t *testing.T) {
+	t.Parallel()
+	t.Run("add and load queried blocks", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddStoreGatewayQueriedBlocks(3)
+		stats.AddStoreGatewayQueriedBlocks(2)
+
+		assert.Equal(t, uint64(5), stats.LoadStoreGatewayQueriedBlocks())
+	})
+
+	t.Run("add and load queried blocks nil receiver", func(t *testing.T) {
+		var stats *QueryStats
+		stats.AddStoreGatewayQueriedBlocks(3)
+
+		assert.Equal(t, uint64(0), stats.LoadStoreGatewayQueriedBlocks())
+	})
+}
+
+func TestStats_AddStoreGatewayChunkBytesAvoided(t *testing.T) {
+	t.Parallel()
+	t.Run("add and load chunk bytes avoided", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddStoreGatewayChunkBytesAvoided(1024)
+		stats.AddStoreGatewayChunkBytesAvoided(512)
+
+		assert.Equal(t, uint64(1536), stats.LoadStoreGatewayChunkBytesAvoided())
+	})
+
+	t.Run("add and load chunk bytes avoided nil receiver", func(t *testing.T) {
+		var stats *QueryStats
+		stats.AddStoreGatewayChunkBytesAvoided(1024)
+
+		assert.Equal(t, uint64(0), stats.LoadStoreGatewayChunkBytesAvoided())
+	})
+}
+
+func TestStats_AddDeduplicatedChunks(t *testing.T) {
+	t.Parallel()
+	t.Run("add and load deduplicated chunks", func(t *testing.T) {
+		stats, _ := ContextWithEmptyStats(context.Background())
+		stats.AddDeduplicatedChunks(4)
+		stats.AddDeduplicatedChunks(4)
+		stats.AddDeduplicatedChunkBytes(4096)
+
+		assert.Equal(t, uint64(8), stats.LoadDeduplicatedChunks())
+		assert.Equal(t, uint64(4096), stats.LoadDeduplicatedChunkBytes())
+	})
+
+	t.Run("add and load deduplicated chunks nil receiver", func(t *testing.T) {
+		var stats *QueryStats
+		stats.AddDeduplicatedChunks(4)
+		stats.AddDeduplicatedChunkBytes(4096)
+
+		assert.Equal(t, uint64(0), stats.LoadDeduplicatedChunks())
+		assert.Equal(t, uint64(0), stats.LoadDeduplicatedChunkBytes())
+	})
+}
+
 func TestStats_StorageWallTime(t *testing.T) {
 	t.Run("add and load query storage wall time", func(t *testing.T) {
 		stats, _ := ContextWithEmptyStats(context.Background())
@@ -212,6 +270,10 @@ func TestStats_Merge(t *testing.T) {
 		stats1.AddStoreGatewayTouchedPostingBytes(301)
 		stats2.AddFetchedChunks(102)
 		stats2.AddFetchedSamples(103)
+		stats2.AddStoreGatewayQueriedBlocks(4)
+		stats2.AddStoreGatewayChunkBytesAvoided(2048)
+		stats2.AddDeduplicatedChunks(5)
+		stats2.AddDeduplicatedChunkBytes(500)
 		stats2.AddExtraFields("c", "d")
 
 		stats1.Merge(stats2)
@@ -225,6 +287,10 @@ func TestStats_Merge(t *testing.T) {
 		assert.Equal(t, uint64(212), stats1.LoadFetchedSamples())
 		assert.Equal(t, uint64(401), stats1.LoadStoreGatewayTouchedPostings())
 		assert.Equal(t, uint64(601), stats1.LoadStoreGatewayTouchedPostingBytes())
+		assert.Equal(t, uint64(4), stats1.LoadStoreGatewayQueriedBlocks())
+		assert.Equal(t, uint64(2048), stats1.LoadStoreGatewayChunkBytesAvoided())
+		assert.Equal(t, uint64(5), stats1.LoadDeduplicatedChunks())
+		assert.Equal(t, uint64(500), stats1.LoadDeduplicatedChunkBytes())
 		checkExtraFields(t, []interface{}{"a", "b", "c", "d"}, stats1.LoadExtraFields())
 	})
 
@@ -1,13 +1,17 @@
 package querier
 
 import (
+	"context"
 	"flag"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health/grpc_health_v1"
 
@@ -95,6 +99,94 @@ func newStoreGatewayClientPool(discovery client.PoolServiceDiscovery, clientConf
 	return client.NewPool("store-gateway", poolCfg, discovery, newStoreGatewayClientFactory(clientCfg, reg), clientsCount, logger)
 }
 
+// storeGatewayZoneMetrics tracks the requests sent to the store-gateways by zone, so that the
+// inter-zone traffic and latency can be compared with the traffic within the querier zone.
+type storeGatewayZoneMetrics struct {
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+func newStoreGatewayZoneMetrics(reg prometheus.Registerer) *storeGatewayZoneMetrics {
+	return &storeGatewayZoneMetrics{
+		requests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_zone_requests_total",
+			Help:      "Total number of requests sent to the store-gateways by zone. The local label is true for the store-gateways in the querier preferred zone.",
+		}, []string{"zone", "local"}),
+		requestDuration: promauto.With(reg).NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "cortex",
+			Name:      "querier_storegateway_zone_request_duration_seconds",
+			Help:      "Time spent executing requests to the store-gateways by zone, including the streaming of the response. The local label is true for the store-gateways in the querier preferred zone.",
+			Buckets:   prometheus.ExponentialBuckets(0.008, 4, 7),
+		}, []string{"zone", "local"}),
+	}
+}
+
+// wrapClient returns a client tracking the requests sent to the store-gateway in the input zone.
+func (m *storeGatewayZoneMetrics) wrapClient(c BlocksStoreClient, zone, preferredZone string) BlocksStoreClient {
+	local := strconv.FormatBool(preferredZone != "" && zone == preferredZone)
+
+	return &zoneTrackingStoreGatewayClient{
+		BlocksStoreClient: c,
+		requests:          m.requests.WithLabelValues(zone, local),
+		requestDuration:   m.requestDuration.WithLabelValues(zone, local),
+	}
+}
+
+type zoneTrackingStoreGatewayClient struct {
+	BlocksStoreClient
+
+	requests        prometheus.Counter
+	requestDuration prometheus.Observer
+}
+
+func (c *zoneTrackingStoreGatewayClient) Series(ctx context.Context, in *storepb.SeriesRequest, opts ...grpc.CallOption) (storegatewaypb.StoreGateway_SeriesClient, error) {
+	c.requests.Inc()
+	begin := time.Now()
+
+	stream, err := c.BlocksStoreClient.Series(ctx, in, opts...)
+	if err != nil {
+		c.requestDuration.Observe(time.Since(begin).Seconds())
+		return nil, err
+	}
+
+	return &zoneTrackingSeriesClient{StoreGateway_SeriesClient: stream, begin: begin, requestDuration: c.requestDuration}, nil
+}
+
+func (c *zoneTrackingStoreGatewayClient) LabelNames(ctx context.Context, in *storepb.LabelNamesRequest, opts ...grpc.CallOption) (*storepb.LabelNamesResponse, error) {
+	c.requests.Inc()
+	defer func(begin time.Time) { c.requestDuration.Observe(time.Since(begin).Seconds()) }(time.Now())
+
+	return c.BlocksStoreClient.LabelNames(ctx, in, opts...)
+}
+
+func (c *zoneTrackingStoreGatewayClient) LabelValues(ctx context.Context, in *storepb.LabelValuesRequest, opts ...grpc.CallOption) (*storepb.LabelValuesResponse, error) {
+	c.requests.Inc()
+	defer func(begin time.Time) { c.requestDuration.Observe(time.Since(begin).Seconds()) }(time.Now())
+
+	return c.BlocksStoreClient.LabelValues(ctx, in, opts...)
+}
+
+// zoneTrackingSeriesClient observes the request duration once the stream has been
+// fully received or has failed.
+type zoneTrackingSeriesClient struct {
+	storegatewaypb.StoreGateway_SeriesClient
+
+	begin           time.Time
+	requestDuration prometheus.Observer
+	once            sync.Once
+}
+
+func (c *zoneTrackingSeriesClient) Recv() (*storepb.SeriesResponse, error) {
+	resp, err := c.StoreGateway_SeriesClient.Recv()
+	if err != nil {
+		c.once.Do(func() {
+			c.requestDuration.Observe(time.Since(c.begin).Seconds())
+		})
+	}
+	return resp, err
+}
+
 type ClientConfig struct {
 	TLSEnabled      bool             `yaml:"tls_enabled"`
 	TLS             tls.ClientConfig `yaml:",inline"`
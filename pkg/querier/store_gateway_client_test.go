@@ -2,10 +2,15 @@ package querier
 
 import (
 	"context"
+	"io"
 	"net"
+	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -81,3 +86,39 @@ func (m *mockStoreGatewayServer) LabelNames(context.Context, *storepb.LabelNames
 func (m *mockStoreGatewayServer) LabelValues(context.Context, *storepb.LabelValuesRequest) (*storepb.LabelValuesResponse, error) {
 	return nil, nil
 }
+
+func TestStoreGatewayZoneMetrics(t *testing.T) {
+	ctx := context.Background()
+	reg := prometheus.NewPedanticRegistry()
+	metrics := newStoreGatewayZoneMetrics(reg)
+
+	local := metrics.wrapClient(&storeGatewayClientMock{remoteAddr: "1.1.1.1", mockedSeriesResponses: []*storepb.SeriesResponse{mockHintsResponse(ulid.MustNew(1, nil))}}, "zone-a", "zone-a")
+	remote := metrics.wrapClient(&storeGatewayClientMock{remoteAddr: "2.2.2.2", mockedLabelNamesResponse: &storepb.LabelNamesResponse{}}, "zone-b", "zone-a")
+	assert.Equal(t, "1.1.1.1", local.RemoteAddress())
+
+	stream, err := local.Series(ctx, &storepb.SeriesRequest{})
+	require.NoError(t, err)
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	require.Equal(t, io.EOF, err)
+
+	_, err = remote.LabelNames(ctx, &storepb.LabelNamesRequest{})
+	require.NoError(t, err)
+	_, err = remote.LabelNames(ctx, &storepb.LabelNamesRequest{})
+	require.NoError(t, err)
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_querier_storegateway_zone_requests_total Total number of requests sent to the store-gateways by zone. The local label is true for the store-gateways in the querier preferred zone.
+		# TYPE cortex_querier_storegateway_zone_requests_total counter
+		cortex_querier_storegateway_zone_requests_total{local="true",zone="zone-a"} 1
+		cortex_querier_storegateway_zone_requests_total{local="false",zone="zone-b"} 2
+	`), "cortex_querier_storegateway_zone_requests_total"))
+
+	// The duration of the series request is observed once the stream is fully received.
+	for zone, expected := range map[string]uint64{"zone-a": 1, "zone-b": 2} {
+		metric := &dto.Metric{}
+		require.NoError(t, metrics.requestDuration.WithLabelValues(zone, strconv.FormatBool(zone == "zone-a")).(prometheus.Histogram).Write(metric))
+		assert.Equal(t, expected, metric.GetHistogram().GetSampleCount(), zone)
+	}
+}
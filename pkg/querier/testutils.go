@@ -62,6 +62,11 @@ func (m *MockDistributor) MetricsMetadata(ctx context.Context) ([]scrape.MetricM
 	return args.Get(0).([]scrape.MetricMetadata), args.Error(1)
 }
 
+func (m *MockDistributor) TSDBStatus(ctx context.Context, limit int) (*client.TSDBStatusResponse, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).(*client.TSDBStatusResponse), args.Error(1)
+}
+
 type MockLimitingDistributor struct {
 	MockDistributor
 	response *client.QueryStreamResponse
@@ -0,0 +1,45 @@
+package tripperware
+
+import (
+	"flag"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+)
+
+var (
+	errInvalidInstantQueryCacheTimeAlignment = errors.New("the instant query cache time alignment must be greater than or equal to 0")
+	errInvalidInstantQueryCacheMaxStaleness  = errors.New("the instant query cache max staleness must be greater than or equal to 0")
+)
+
+// InstantQueryCacheConfig is the config for the instant query results cache.
+type InstantQueryCacheConfig struct {
+	Enabled       bool          `yaml:"enabled"`
+	TimeAlignment time.Duration `yaml:"time_alignment"`
+	MaxStaleness  time.Duration `yaml:"max_staleness"`
+	CacheConfig   cache.Config  `yaml:"cache"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *InstantQueryCacheConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "frontend.instant-query-cache.enabled", false, "[Experimental] Cache the instant query results in the query-frontend. The cached results are served until the per-tenant -frontend.instant-query-cache-ttl expires.")
+	f.DurationVar(&cfg.TimeAlignment, "frontend.instant-query-cache.time-alignment", 0, "The cached instant query results are shared by the queries whose evaluation time, truncated to this interval, is the same. 0 to use the -querier.default-evaluation-interval.")
+	f.DurationVar(&cfg.MaxStaleness, "frontend.instant-query-cache.max-staleness", 0, "Maximum time between the evaluation time of a cached instant query result and the evaluation time of the query it's served to. The queries evaluated before the cached result are never served from the cache. 0 to use the time alignment.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix("frontend.instant-query-cache.", "", f)
+}
+
+// Validate validates the config.
+func (cfg *InstantQueryCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TimeAlignment < 0 {
+		return errInvalidInstantQueryCacheTimeAlignment
+	}
+	if cfg.MaxStaleness < 0 {
+		return errInvalidInstantQueryCacheMaxStaleness
+	}
+	return cfg.CacheConfig.Validate()
+}
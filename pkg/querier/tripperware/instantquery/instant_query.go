@@ -316,6 +316,52 @@ func (instantQueryCodec) MergeResponse(ctx context.Context, req tripperware.Requ
 	return res, nil
 }
 
+// MergeAggregatedResponse merges the responses of the shards of a query sharded by series hash,
+// merging the samples returned by the shards with the merge function.
+func (c instantQueryCodec) MergeAggregatedResponse(ctx context.Context, req tripperware.Request, merge tripperware.SampleStreamsMerger, responses ...tripperware.Response) (tripperware.Response, error) {
+	shards := make([][]tripperware.SampleStream, 0, len(responses))
+	for _, res := range responses {
+		promRes := res.(*PrometheusInstantQueryResponse)
+		if promRes.Data.ResultType != model.ValVector.String() {
+			return nil, fmt.Errorf("unexpected result type on instant query sharded by series hash: %s", promRes.Data.ResultType)
+		}
+
+		var streams []tripperware.SampleStream
+		for _, sample := range promRes.Data.Result.GetVector().GetSamples() {
+			stream := tripperware.SampleStream{Labels: sample.Labels}
+			if sample.Sample != nil {
+				stream.Samples = []cortexpb.Sample{*sample.Sample}
+			}
+			if sample.Histogram != nil {
+				stream.Histograms = []tripperware.SampleHistogramPair{*sample.Histogram}
+			}
+			streams = append(streams, stream)
+		}
+		shards = append(shards, streams)
+	}
+	result, err := merge(shards...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merge of the responses takes care of the stats, warnings and status.
+	merged, err := c.MergeResponse(ctx, req, responses...)
+	if err != nil {
+		return nil, err
+	}
+
+	vector := &Vector{Samples: make([]*Sample, 0, len(result))}
+	for _, stream := range result {
+		for i := range stream.Samples {
+			vector.Samples = append(vector.Samples, &Sample{Labels: stream.Labels, Sample: &stream.Samples[i]})
+		}
+	}
+	merged.(*PrometheusInstantQueryResponse).Data.Result = PrometheusInstantQueryResult{
+		Result: &PrometheusInstantQueryResult_Vector{Vector: vector},
+	}
+	return merged, nil
+}
+
 func vectorMerge(ctx context.Context, req tripperware.Request, resps []*PrometheusInstantQueryResponse) (*Vector, error) {
 	output := map[string]*Sample{}
 	metrics := []string{} // Used to preserve the order for topk and bottomk.
@@ -4,20 +4,35 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/thanos-io/thanos/pkg/querysharding"
 
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 )
 
 func Middlewares(
+	cacheCfg tripperware.InstantQueryCacheConfig,
 	log log.Logger,
 	limits tripperware.Limits,
+	registerer prometheus.Registerer,
 	queryAnalyzer querysharding.Analyzer,
 	lookbackDelta time.Duration,
-) ([]tripperware.Middleware, error) {
-	m := []tripperware.Middleware{
-		NewLimitsMiddleware(limits, lookbackDelta),
-		tripperware.ShardByMiddleware(log, limits, InstantQueryCodec, queryAnalyzer),
+	defaultEvaluationInterval time.Duration,
+) ([]tripperware.Middleware, cache.Cache, error) {
+	m := []tripperware.Middleware{NewLimitsMiddleware(limits, lookbackDelta)}
+	m = append(m, tripperware.QueryCostMiddleware(limits, defaultEvaluationInterval))
+
+	var c cache.Cache
+	if cacheCfg.Enabled {
+		resultsCacheMiddleware, cache, err := NewResultsCacheMiddleware(cacheCfg, limits, defaultEvaluationInterval, log, registerer)
+		if err != nil {
+			return nil, nil, err
+		}
+		c = cache
+		m = append(m, resultsCacheMiddleware)
 	}
-	return m, nil
+
+	m = append(m, tripperware.ShardByMiddleware(log, limits, InstantQueryCodec, queryAnalyzer))
+	return m, c, nil
 }
@@ -19,6 +19,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
 )
 
 const testHistogramResponse = `{"status":"success","data":{"resultType":"vector","result":[{"metric":{"__name__":"prometheus_http_request_duration_seconds","handler":"/metrics","instance":"localhost:9090","job":"prometheus"},"histogram":[1719528871.898,{"count":"6342","sum":"43.31319875499995","buckets":[[0,"0.0013810679320049755","0.0015060652591874421","1"],[0,"0.0015060652591874421","0.001642375811042411","7"],[0,"0.001642375811042411","0.0017910235218841233","5"],[0,"0.0017910235218841233","0.001953125","13"],[0,"0.001953125","0.0021298979153618314","19"],[0,"0.0021298979153618314","0.0023226701464896895","13"],[0,"0.0023226701464896895","0.002532889755177753","13"],[0,"0.002532889755177753","0.002762135864009951","15"],[0,"0.002762135864009951","0.0030121305183748843","12"],[0,"0.0030121305183748843","0.003284751622084822","34"],[0,"0.003284751622084822","0.0035820470437682465","188"],[0,"0.0035820470437682465","0.00390625","372"],[0,"0.00390625","0.004259795830723663","400"],[0,"0.004259795830723663","0.004645340292979379","411"],[0,"0.004645340292979379","0.005065779510355506","425"],[0,"0.005065779510355506","0.005524271728019902","425"],[0,"0.005524271728019902","0.0060242610367497685","521"],[0,"0.0060242610367497685","0.006569503244169644","621"],[0,"0.006569503244169644","0.007164094087536493","593"],[0,"0.007164094087536493","0.0078125","506"],[0,"0.0078125","0.008519591661447326","458"],[0,"0.008519591661447326","0.009290680585958758","346"],[0,"0.009290680585958758","0.010131559020711013","285"],[0,"0.010131559020711013","0.011048543456039804","196"],[0,"0.011048543456039804","0.012048522073499537","129"],[0,"0.012048522073499537","0.013139006488339287","85"],[0,"0.013139006488339287","0.014328188175072986","65"],[0,"0.014328188175072986","0.015625","54"],[0,"0.015625","0.01703918332289465","53"],[0,"0.01703918332289465","0.018581361171917516","20"],[0,"0.018581361171917516","0.020263118041422026","21"],[0,"0.020263118041422026","0.022097086912079608","15"],[0,"0.022097086912079608","0.024097044146999074","11"],[0,"0.024097044146999074","0.026278012976678575","2"],[0,"0.026278012976678575","0.028656376350145972","3"],[0,"0.028656376350145972","0.03125","3"],[0,"0.04052623608284405","0.044194173824159216","2"]]}]}]}}`
@@ -479,6 +480,48 @@ func TestMergeResponse(t *testing.T) {
 	}
 }
 
+func TestMergeAggregatedResponse(t *testing.T) {
+	t.Parallel()
+	response := func(job string, value float64) tripperware.Response {
+		return &PrometheusInstantQueryResponse{
+			Status: queryrange.StatusSuccess,
+			Data: PrometheusInstantQueryData{
+				ResultType: model.ValVector.String(),
+				Result: PrometheusInstantQueryResult{
+					Result: &PrometheusInstantQueryResult_Vector{Vector: &Vector{Samples: []*Sample{{
+						Labels: []cortexpb.LabelAdapter{{Name: "job", Value: job}},
+						Sample: &cortexpb.Sample{TimestampMs: 1000, Value: value},
+					}}}},
+				},
+			},
+		}
+	}
+
+	stream := func(job string, value float64) []tripperware.SampleStream {
+		return []tripperware.SampleStream{{
+			Labels:  []cortexpb.LabelAdapter{{Name: "job", Value: job}},
+			Samples: []cortexpb.Sample{{TimestampMs: 1000, Value: value}},
+		}}
+	}
+
+	// The samples of the shards are passed to the merge function as sample streams.
+	merge := func(shards ...[]tripperware.SampleStream) ([]tripperware.SampleStream, error) {
+		require.Equal(t, [][]tripperware.SampleStream{stream("a", 1), stream("b", 2), stream("a", 3)}, shards)
+		return append(stream("a", 3), stream("b", 2)...), nil
+	}
+
+	codec := InstantQueryCodec.(tripperware.AggregationMerger)
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	merged, err := codec.MergeAggregatedResponse(ctx, &PrometheusRequest{Query: "max by (job) (up)"}, merge,
+		response("a", 1), response("b", 2), response("a", 3),
+	)
+	require.NoError(t, err)
+	require.Equal(t, []*Sample{
+		{Labels: []cortexpb.LabelAdapter{{Name: "job", Value: "a"}}, Sample: &cortexpb.Sample{TimestampMs: 1000, Value: 3}},
+		{Labels: []cortexpb.LabelAdapter{{Name: "job", Value: "b"}}, Sample: &cortexpb.Sample{TimestampMs: 1000, Value: 2}},
+	}, merged.(*PrometheusInstantQueryResponse).Data.Result.GetVector().Samples)
+}
+
 func Test_sortPlanForQuery(t *testing.T) {
 	tc := []struct {
 		query        string
@@ -103,13 +103,18 @@ func TestLimitsMiddleware_MaxQueryLength(t *testing.T) {
 
 type mockLimits struct {
 	validation.Overrides
-	maxQueryLength time.Duration
+	maxQueryLength       time.Duration
+	instantQueryCacheTTL map[string]time.Duration
 }
 
 func (m mockLimits) MaxQueryLength(string) time.Duration {
 	return m.maxQueryLength
 }
 
+func (m mockLimits) InstantQueryCacheTTL(userID string) time.Duration {
+	return m.instantQueryCacheTTL[userID]
+}
+
 type mockHandler struct {
 	mock.Mock
 }
@@ -0,0 +1,223 @@
+package instantquery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+const (
+	cacheControlHeader = "Cache-Control"
+	noStoreValue       = "no-store"
+)
+
+// cachedInstantQueryResponse is an instant query response stored in the results cache.
+type cachedInstantQueryResponse struct {
+	StoredAt    int64  `json:"stored_at"`
+	EvaluatedAt int64  `json:"evaluated_at"`
+	Response    []byte `json:"response"`
+}
+
+type resultsCache struct {
+	next   tripperware.Handler
+	limits tripperware.Limits
+	cache  cache.Cache
+	logger log.Logger
+
+	// The evaluation time of the queries is truncated to this interval to compute the cache key.
+	alignment time.Duration
+	// Maximum time between the evaluation time of a cached result and the requested one.
+	maxStaleness time.Duration
+
+	requests prometheus.Counter
+	hits     prometheus.Counter
+}
+
+// NewResultsCacheMiddleware creates a new Middleware caching the instant query results, keyed
+// by tenant, query and evaluation time. The evaluation time is truncated to the time alignment,
+// or to the default evaluation interval if not set, so that the identical queries issued by the
+// dashboards within the same interval share the cached result. The queries are always evaluated
+// at the requested time, and a cached result is only served to the queries evaluated at most the
+// max staleness after it.
+func NewResultsCacheMiddleware(cfg tripperware.InstantQueryCacheConfig, limits tripperware.Limits, defaultEvaluationInterval time.Duration, logger log.Logger, reg prometheus.Registerer) (tripperware.Middleware, cache.Cache, error) {
+	c, err := cache.New(cfg.CacheConfig, reg, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	alignment := cfg.TimeAlignment
+	if alignment == 0 {
+		alignment = defaultEvaluationInterval
+	}
+	maxStaleness := cfg.MaxStaleness
+	if maxStaleness == 0 {
+		maxStaleness = alignment
+	}
+
+	requests := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_frontend_instant_query_cache_requests_total",
+		Help: "Total number of instant queries looked up in the instant query results cache.",
+	})
+	hits := promauto.With(reg).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_frontend_instant_query_cache_hits_total",
+		Help: "Total number of instant queries served from the instant query results cache.",
+	})
+
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return &resultsCache{
+			next:         next,
+			limits:       limits,
+			cache:        c,
+			logger:       logger,
+			alignment:    alignment,
+			maxStaleness: maxStaleness,
+			requests:     requests,
+			hits:         hits,
+		}
+	}), c, nil
+}
+
+func (s *resultsCache) Do(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+	req, ok := r.(*PrometheusRequest)
+	if !ok || !s.shouldCacheRequest(req) {
+		return s.next.Do(ctx, r)
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	// A tenant disabling the cache disables it for the cross-tenant queries too.
+	var ttl time.Duration
+	for _, userID := range tenantIDs {
+		tenantTTL := s.limits.InstantQueryCacheTTL(userID)
+		if tenantTTL <= 0 {
+			return s.next.Do(ctx, r)
+		}
+		if ttl == 0 || tenantTTL < ttl {
+			ttl = tenantTTL
+		}
+	}
+
+	// The queries aligned to the same time share the same cache key, while the query is
+	// evaluated at the requested time.
+	alignedTime := req.Time
+	if alignment := s.alignment.Milliseconds(); alignment > 0 {
+		alignedTime = req.Time - req.Time%alignment
+	}
+	key := cacheKey(tenant.JoinTenantIDs(tenantIDs), req.Query, alignedTime)
+
+	s.requests.Inc()
+	if resp, ok := s.fetch(ctx, key, ttl, req.Time); ok {
+		s.hits.Inc()
+		return resp, nil
+	}
+
+	now := time.Now()
+	resp, err := s.next.Do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	if promResp, ok := resp.(*PrometheusInstantQueryResponse); ok && s.shouldCacheResponse(ctx, promResp) {
+		s.store(ctx, key, now, req.Time, promResp)
+	}
+	return resp, nil
+}
+
+// shouldCacheRequest returns whether the result of the request can be cached. The requests asking
+// for the query stats aren't cached, since the stats of a cached result would be misleading.
+func (s *resultsCache) shouldCacheRequest(r *PrometheusRequest) bool {
+	if r.Stats != "" {
+		return false
+	}
+	for _, value := range r.Headers.Values(cacheControlHeader) {
+		if strings.Contains(value, noStoreValue) {
+			return false
+		}
+	}
+
+	// The invalid queries are left to the queriers, which report the error.
+	_, err := parser.ParseExpr(r.Query)
+	return err == nil
+}
+
+func (s *resultsCache) shouldCacheResponse(ctx context.Context, r *PrometheusInstantQueryResponse) bool {
+	if r.Status != queryrange.StatusSuccess {
+		return false
+	}
+	for _, value := range r.HTTPHeaders()[cacheControlHeader] {
+		if value == noStoreValue {
+			level.Debug(util_log.WithContext(ctx, s.logger)).Log("msg", fmt.Sprintf("%s header in response is equal to %s, not caching the response", cacheControlHeader, noStoreValue))
+			return false
+		}
+	}
+	return true
+}
+
+// fetch returns the cached result for the input key, if it's not expired and it has been evaluated
+// at most the max staleness before the input evaluation time.
+func (s *resultsCache) fetch(ctx context.Context, key string, ttl time.Duration, evaluationTime int64) (tripperware.Response, bool) {
+	found, bufs, _ := s.cache.Fetch(ctx, []string{key})
+	if len(found) != 1 {
+		return nil, false
+	}
+
+	var cached cachedInstantQueryResponse
+	if err := json.Unmarshal(bufs[0], &cached); err != nil {
+		level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to decode cached instant query response", "err", err)
+		return nil, false
+	}
+	if time.Since(util.TimeFromMillis(cached.StoredAt)) > ttl {
+		return nil, false
+	}
+	if staleness := evaluationTime - cached.EvaluatedAt; staleness < 0 || staleness > s.maxStaleness.Milliseconds() {
+		return nil, false
+	}
+
+	resp := &PrometheusInstantQueryResponse{}
+	if err := resp.Unmarshal(cached.Response); err != nil {
+		level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to decode cached instant query response", "err", err)
+		return nil, false
+	}
+	return resp, true
+}
+
+func (s *resultsCache) store(ctx context.Context, key string, now time.Time, evaluationTime int64, resp *PrometheusInstantQueryResponse) {
+	// The headers aren't needed to send back the cached response.
+	withoutHeaders := *resp
+	withoutHeaders.Headers = nil
+
+	data, err := withoutHeaders.Marshal()
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to encode instant query response", "err", err)
+		return
+	}
+	buf, err := json.Marshal(cachedInstantQueryResponse{StoredAt: util.TimeToMillis(now), EvaluatedAt: evaluationTime, Response: data})
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, s.logger)).Log("msg", "failed to encode instant query response", "err", err)
+		return
+	}
+	s.cache.Store(ctx, []string{key}, [][]byte{buf})
+}
+
+func cacheKey(userID, query string, alignedTime int64) string {
+	return cache.HashKey(fmt.Sprintf("instant:%s:%s:%d", userID, query, alignedTime))
+}
@@ -0,0 +1,136 @@
+package instantquery
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware/queryrange"
+)
+
+func TestResultsCacheMiddleware(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	limits := &mockLimits{instantQueryCacheTTL: map[string]time.Duration{"user-1": time.Minute, "user-2": time.Minute}}
+	middleware, _, err := NewResultsCacheMiddleware(tripperware.InstantQueryCacheConfig{
+		Enabled:     true,
+		CacheConfig: cache.Config{Cache: cache.NewMockCache()},
+	}, limits, time.Minute, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	var downstreamTimes []int64
+	handler := middleware.Wrap(tripperware.HandlerFunc(func(_ context.Context, r tripperware.Request) (tripperware.Response, error) {
+		downstreamTimes = append(downstreamTimes, r.(*PrometheusRequest).GetTime())
+		return &PrometheusInstantQueryResponse{
+			Status:   queryrange.StatusSuccess,
+			Data:     PrometheusInstantQueryData{ResultType: "scalar"},
+			Warnings: []string{"call-" + strconv.Itoa(len(downstreamTimes))},
+		}, nil
+	}))
+
+	do := func(userID string, req *PrometheusRequest) string {
+		resp, err := handler.Do(user.InjectOrgID(context.Background(), userID), req)
+		require.NoError(t, err)
+		return resp.(*PrometheusInstantQueryResponse).Warnings[0]
+	}
+
+	now := time.Now().Truncate(time.Minute).UnixMilli()
+
+	// The first query is evaluated at the requested time, the next ones within the same
+	// evaluation interval are served from the cache.
+	assert.Equal(t, "call-1", do("user-1", &PrometheusRequest{Query: "up", Time: now + 10000}))
+	assert.Equal(t, "call-1", do("user-1", &PrometheusRequest{Query: "up", Time: now + 50000}))
+	assert.Equal(t, []int64{now + 10000}, downstreamTimes)
+
+	// The results are cached per tenant, query and aligned time.
+	assert.Equal(t, "call-2", do("user-2", &PrometheusRequest{Query: "up", Time: now}))
+	assert.Equal(t, "call-3", do("user-1", &PrometheusRequest{Query: "down", Time: now}))
+	assert.Equal(t, "call-4", do("user-1", &PrometheusRequest{Query: "up", Time: now + 60000}))
+
+	// The queries asking for stats or no caching, the invalid queries and the queries of the
+	// tenants without TTL are never cached.
+	assert.Equal(t, "call-5", do("user-1", &PrometheusRequest{Query: "up", Time: now, Stats: "all"}))
+	assert.Equal(t, "call-6", do("user-1", &PrometheusRequest{Query: "up", Time: now, Headers: http.Header{"Cache-Control": []string{"no-store"}}}))
+	assert.Equal(t, "call-7", do("user-1", &PrometheusRequest{Query: "up{", Time: now}))
+	assert.Equal(t, "call-8", do("user-3", &PrometheusRequest{Query: "up", Time: now}))
+	assert.Equal(t, "call-9", do("user-3", &PrometheusRequest{Query: "up", Time: now}))
+	assert.Equal(t, "call-10", do("user-1|user-3", &PrometheusRequest{Query: "up", Time: now}))
+
+	assert.Equal(t, float64(5), testutil.ToFloat64(handler.(*resultsCache).requests))
+	assert.Equal(t, float64(1), testutil.ToFloat64(handler.(*resultsCache).hits))
+}
+
+func TestResultsCacheMiddleware_MaxStaleness(t *testing.T) {
+	t.Parallel()
+
+	limits := &mockLimits{instantQueryCacheTTL: map[string]time.Duration{"user-1": time.Minute}}
+	middleware, _, err := NewResultsCacheMiddleware(tripperware.InstantQueryCacheConfig{
+		Enabled:      true,
+		MaxStaleness: 30 * time.Second,
+		CacheConfig:  cache.Config{Cache: cache.NewMockCache()},
+	}, limits, time.Minute, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	var downstreamTimes []int64
+	handler := middleware.Wrap(tripperware.HandlerFunc(func(_ context.Context, r tripperware.Request) (tripperware.Response, error) {
+		downstreamTimes = append(downstreamTimes, r.(*PrometheusRequest).GetTime())
+		return &PrometheusInstantQueryResponse{Status: queryrange.StatusSuccess}, nil
+	}))
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	now := time.Now().Truncate(time.Minute).UnixMilli()
+	for _, ts := range []int64{
+		now + 10000, // Evaluated.
+		now + 40000, // Served from the cache, evaluated 30s before.
+		now + 50000, // Evaluated, since the cached result is more than 30s older.
+		now + 20000, // Evaluated, since the cached result is more recent.
+		now + 20000, // Served from the cache.
+	} {
+		_, err := handler.Do(ctx, &PrometheusRequest{Query: "up", Time: ts})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, []int64{now + 10000, now + 50000, now + 20000}, downstreamTimes)
+}
+
+func TestResultsCacheMiddleware_TTL(t *testing.T) {
+	t.Parallel()
+
+	limits := &mockLimits{instantQueryCacheTTL: map[string]time.Duration{"user-1": 100 * time.Millisecond}}
+	middleware, _, err := NewResultsCacheMiddleware(tripperware.InstantQueryCacheConfig{
+		Enabled:     true,
+		CacheConfig: cache.Config{Cache: cache.NewMockCache()},
+	}, limits, time.Minute, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	calls := 0
+	handler := middleware.Wrap(tripperware.HandlerFunc(func(context.Context, tripperware.Request) (tripperware.Response, error) {
+		calls++
+		return &PrometheusInstantQueryResponse{Status: queryrange.StatusSuccess}, nil
+	}))
+
+	ctx := user.InjectOrgID(context.Background(), "user-1")
+	req := &PrometheusRequest{Query: "up", Time: time.Now().UnixMilli()}
+	for i := 0; i < 2; i++ {
+		_, err := handler.Do(ctx, req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, calls)
+
+	// The cached result expires after the tenant TTL.
+	time.Sleep(150 * time.Millisecond)
+	_, err = handler.Do(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,284 @@
+package tripperware
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"flag"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+var (
+	errInvalidLabelsCacheTTL        = errors.New("the labels cache TTL must be greater than 0")
+	errInvalidLabelsCacheTimeBucket = errors.New("the labels cache time bucket must be greater than 0")
+)
+
+// LabelsCacheConfig is the config for the label names and values responses cache.
+type LabelsCacheConfig struct {
+	Enabled     bool          `yaml:"enabled"`
+	TTL         time.Duration `yaml:"ttl"`
+	TimeBucket  time.Duration `yaml:"time_bucket"`
+	CacheConfig cache.Config  `yaml:"cache"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *LabelsCacheConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "frontend.labels-cache.enabled", false, "Cache the label names and values responses in the query-frontend.")
+	f.DurationVar(&cfg.TTL, "frontend.labels-cache.ttl", time.Minute, "How long a cached label names or values response is served before being fetched again from the queriers.")
+	f.DurationVar(&cfg.TimeBucket, "frontend.labels-cache.time-bucket", 5*time.Minute, "The start and end time of the label names and values requests are truncated to this interval when computing the cache key, so that requests for slightly different time ranges share the same cached response.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix("frontend.labels-cache.", "", f)
+}
+
+// Validate validates the config.
+func (cfg *LabelsCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 {
+		return errInvalidLabelsCacheTTL
+	}
+	if cfg.TimeBucket <= 0 {
+		return errInvalidLabelsCacheTimeBucket
+	}
+	return cfg.CacheConfig.Validate()
+}
+
+// cachedLabelsResponse is a label names or values response stored in the labels cache.
+type cachedLabelsResponse struct {
+	StoredAt        int64  `json:"stored_at"`
+	ContentType     string `json:"content_type,omitempty"`
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	Body            []byte `json:"body"`
+}
+
+// LabelsCache caches the label names and values responses, which are requested
+// at a high rate by the dashboards, keyed by tenant, request parameters and time bucket.
+// The cached responses of a tenant can be invalidated before their TTL expires.
+type LabelsCache struct {
+	cfg    LabelsCacheConfig
+	cache  cache.Cache
+	logger log.Logger
+
+	requests prometheus.Counter
+	hits     prometheus.Counter
+}
+
+// NewLabelsCache makes a new LabelsCache.
+func NewLabelsCache(cfg LabelsCacheConfig, logger log.Logger, reg prometheus.Registerer) (*LabelsCache, error) {
+	c, err := cache.New(cfg.CacheConfig, reg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LabelsCache{
+		cfg:    cfg,
+		cache:  c,
+		logger: logger,
+		requests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_labels_cache_requests_total",
+			Help: "Total number of label names and values requests looked up in the labels cache.",
+		}),
+		hits: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_frontend_labels_cache_hits_total",
+			Help: "Total number of label names and values requests served from the labels cache.",
+		}),
+	}, nil
+}
+
+// Wrap returns a http.RoundTripper serving the label names and values requests from
+// the cache, and forwarding all the other requests to the next round tripper.
+func (c *LabelsCache) Wrap(next http.RoundTripper) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if !isLabelsRequest(r) {
+			return next.RoundTrip(r)
+		}
+
+		tenantIDs, err := tenant.TenantIDs(r.Context())
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+
+		key, ok := c.cacheKey(tenant.JoinTenantIDs(tenantIDs), r)
+		if !ok {
+			return next.RoundTrip(r)
+		}
+
+		c.requests.Inc()
+		if resp, ok := c.fetch(r, key, tenantIDs); ok {
+			c.hits.Inc()
+			return resp, nil
+		}
+
+		now := time.Now()
+		resp, err := next.RoundTrip(r)
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return resp, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		c.store(r, key, cachedLabelsResponse{
+			StoredAt:        util.TimeToMillis(now),
+			ContentType:     resp.Header.Get("Content-Type"),
+			ContentEncoding: resp.Header.Get("Content-Encoding"),
+			Body:            body,
+		})
+
+		return resp, nil
+	})
+}
+
+// Invalidate invalidates the cached responses of the input tenant, including the
+// responses of the cross-tenant requests involving it.
+func (c *LabelsCache) Invalidate(ctx context.Context, userID string) {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(util.TimeToMillis(time.Now())))
+	c.cache.Store(ctx, []string{invalidationCacheKey(userID)}, [][]byte{buf})
+}
+
+// InvalidateHandler is a http.Handler invalidating the cached responses of the tenant
+// issuing the request.
+func (c *LabelsCache) InvalidateHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.Invalidate(r.Context(), userID)
+	level.Info(util_log.WithContext(r.Context(), c.logger)).Log("msg", "invalidated the labels cache")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Stop stops the underlying cache.
+func (c *LabelsCache) Stop() {
+	c.cache.Stop()
+}
+
+// fetch looks up the cached response for the input key. A cached response is discarded
+// if it's older than the TTL or has been stored before the last invalidation of any of
+// the input tenants.
+func (c *LabelsCache) fetch(r *http.Request, key string, tenantIDs []string) (*http.Response, bool) {
+	keys := []string{key}
+	for _, userID := range tenantIDs {
+		keys = append(keys, invalidationCacheKey(userID))
+	}
+
+	found, bufs, _ := c.cache.Fetch(r.Context(), keys)
+
+	var (
+		cached        *cachedLabelsResponse
+		invalidatedAt int64
+	)
+	for i, k := range found {
+		if k == key {
+			cached = &cachedLabelsResponse{}
+			if err := json.Unmarshal(bufs[i], cached); err != nil {
+				level.Warn(c.logger).Log("msg", "failed to decode cached labels response", "err", err)
+				return nil, false
+			}
+			continue
+		}
+		if len(bufs[i]) == 8 {
+			invalidatedAt = max(invalidatedAt, int64(binary.BigEndian.Uint64(bufs[i])))
+		}
+	}
+
+	if cached == nil || cached.StoredAt <= invalidatedAt || time.Since(util.TimeFromMillis(cached.StoredAt)) > c.cfg.TTL {
+		return nil, false
+	}
+
+	header := http.Header{}
+	if cached.ContentType != "" {
+		header.Set("Content-Type", cached.ContentType)
+	}
+	if cached.ContentEncoding != "" {
+		header.Set("Content-Encoding", cached.ContentEncoding)
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(http.StatusOK),
+		StatusCode:    http.StatusOK,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       r,
+	}, true
+}
+
+func (c *LabelsCache) store(r *http.Request, key string, resp cachedLabelsResponse) {
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "failed to encode labels response", "err", err)
+		return
+	}
+	c.cache.Store(r.Context(), []string{key}, [][]byte{buf})
+}
+
+// cacheKey returns the cache key of the input request, built from the tenant, the path and
+// the sorted request parameters, with the start and end time truncated to the time bucket.
+// It returns false if the request can't be cached.
+func (c *LabelsCache) cacheKey(userID string, r *http.Request) (string, bool) {
+	if err := r.ParseForm(); err != nil {
+		return "", false
+	}
+
+	params := make(url.Values, len(r.Form))
+	for name, values := range r.Form {
+		if name != "start" && name != "end" {
+			values = append([]string(nil), values...)
+			sort.Strings(values)
+			params[name] = values
+			continue
+		}
+
+		bucketed := make([]string, 0, len(values))
+		for _, v := range values {
+			ts, err := util.ParseTime(v)
+			if err != nil {
+				return "", false
+			}
+			bucketed = append(bucketed, strconv.FormatInt(ts/c.cfg.TimeBucket.Milliseconds(), 10))
+		}
+		params[name] = bucketed
+	}
+
+	// The cached response body may be compressed, so it can only be served to the clients
+	// accepting the same encodings.
+	key := strings.Join([]string{"labels", userID, r.URL.Path, params.Encode(), r.Header.Get("Accept-Encoding")}, ":")
+	return cache.HashKey(key), true
+}
+
+func invalidationCacheKey(userID string) string {
+	return cache.HashKey("labels-invalidation:" + userID)
+}
+
+func isLabelsRequest(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		return false
+	}
+	return strings.HasSuffix(r.URL.Path, "/labels") || (strings.HasSuffix(r.URL.Path, "/values") && strings.Contains(r.URL.Path, "/label/"))
+}
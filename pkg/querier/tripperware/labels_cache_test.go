@@ -0,0 +1,133 @@
+package tripperware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+)
+
+func TestLabelsCache(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewPedanticRegistry()
+	labelsCache, err := NewLabelsCache(LabelsCacheConfig{
+		Enabled:     true,
+		TTL:         time.Minute,
+		TimeBucket:  5 * time.Minute,
+		CacheConfig: cache.Config{Cache: cache.NewMockCache()},
+	}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	downstreamCalls := 0
+	rt := labelsCache.Wrap(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		downstreamCalls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": []string{"application/json"}},
+			Body:       io.NopCloser(strings.NewReader(`{"status":"success","data":["call-` + strconv.Itoa(downstreamCalls) + `"]}`)),
+		}, nil
+	}))
+
+	do := func(userID, target string) string {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), userID))
+
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	now := time.Now().Truncate(5 * time.Minute).Unix()
+	labels := "/api/v1/labels?match[]=up&start=" + strconv.FormatInt(now, 10)
+
+	// The first request is forwarded downstream, the next ones are served from the cache.
+	assert.Equal(t, `{"status":"success","data":["call-1"]}`, do("user-1", labels))
+	assert.Equal(t, `{"status":"success","data":["call-1"]}`, do("user-1", labels))
+
+	// The requests in the same time bucket share the cached response.
+	assert.Equal(t, `{"status":"success","data":["call-1"]}`, do("user-1", "/api/v1/labels?match[]=up&start="+strconv.FormatInt(now+60, 10)))
+
+	// The responses are cached per tenant and request parameters.
+	assert.Equal(t, `{"status":"success","data":["call-2"]}`, do("user-2", labels))
+	assert.Equal(t, `{"status":"success","data":["call-3"]}`, do("user-1", "/api/v1/labels?match[]=down&start="+strconv.FormatInt(now, 10)))
+	assert.Equal(t, `{"status":"success","data":["call-4"]}`, do("user-1", "/api/v1/label/job/values?start="+strconv.FormatInt(now, 10)))
+	assert.Equal(t, `{"status":"success","data":["call-4"]}`, do("user-1", "/api/v1/label/job/values?start="+strconv.FormatInt(now, 10)))
+
+	// The other requests are never cached.
+	assert.Equal(t, `{"status":"success","data":["call-5"]}`, do("user-1", "/api/v1/series?match[]=up"))
+	assert.Equal(t, `{"status":"success","data":["call-6"]}`, do("user-1", "/api/v1/series?match[]=up"))
+
+	// Invalidating a tenant only discards its cached responses.
+	time.Sleep(time.Millisecond)
+	req := httptest.NewRequest(http.MethodPost, "/frontend/labels_cache/invalidate", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+	w := httptest.NewRecorder()
+	labelsCache.InvalidateHandler(w, req)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	time.Sleep(time.Millisecond)
+
+	assert.Equal(t, `{"status":"success","data":["call-7"]}`, do("user-1", labels))
+	assert.Equal(t, `{"status":"success","data":["call-7"]}`, do("user-1", labels))
+	assert.Equal(t, `{"status":"success","data":["call-2"]}`, do("user-2", labels))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_frontend_labels_cache_hits_total Total number of label names and values requests served from the labels cache.
+		# TYPE cortex_frontend_labels_cache_hits_total counter
+		cortex_frontend_labels_cache_hits_total 5
+		# HELP cortex_frontend_labels_cache_requests_total Total number of label names and values requests looked up in the labels cache.
+		# TYPE cortex_frontend_labels_cache_requests_total counter
+		cortex_frontend_labels_cache_requests_total 10
+	`), "cortex_frontend_labels_cache_hits_total", "cortex_frontend_labels_cache_requests_total"))
+}
+
+func TestLabelsCache_ShouldExpireResponsesAfterTTL(t *testing.T) {
+	t.Parallel()
+
+	labelsCache, err := NewLabelsCache(LabelsCacheConfig{
+		Enabled:     true,
+		TTL:         100 * time.Millisecond,
+		TimeBucket:  5 * time.Minute,
+		CacheConfig: cache.Config{Cache: cache.NewMockCache()},
+	}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	downstreamCalls := 0
+	rt := labelsCache.Wrap(RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		downstreamCalls++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{}"))}, nil
+	}))
+
+	do := func() {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/labels", nil)
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+		_, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+	}
+
+	do()
+	do()
+	assert.Equal(t, 1, downstreamCalls)
+
+	time.Sleep(200 * time.Millisecond)
+	do()
+	assert.Equal(t, 2, downstreamCalls)
+}
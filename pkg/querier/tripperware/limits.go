@@ -26,6 +26,33 @@ type Limits interface {
 	// QueryVerticalShardSize returns the maximum number of queriers that can handle requests for this user.
 	QueryVerticalShardSize(userID string) int
 
+	// QueryVerticalShardBySeriesHash returns whether the queries which can't be sharded by labels
+	// can be sharded by series hash for this user.
+	QueryVerticalShardBySeriesHash(userID string) bool
+
+	// QueryVerticalShardApproximateTopK returns whether the topk and bottomk of a sum can be
+	// approximated when sharded by series hash for this user.
+	QueryVerticalShardApproximateTopK(userID string) bool
+
 	// QueryPriority returns the query priority config for the tenant, including different priorities and their attributes.
 	QueryPriority(userID string) validation.QueryPriority
+
+	// MaxResponsePoints returns the maximum number of points returned by a range query,
+	// above which the response is downsampled.
+	MaxResponsePoints(userID string) int
+
+	// InstantQueryCacheTTL returns how long a cached instant query result is served, 0 if the
+	// instant query results shouldn't be cached.
+	InstantQueryCacheTTL(userID string) time.Duration
+
+	// MaxEstimatedQueryCost returns the maximum estimated cost of a query, 0 if the cost of the
+	// queries shouldn't be estimated.
+	MaxEstimatedQueryCost(userID string) int64
+
+	// QueryCostScrapeInterval returns the scrape interval used to estimate the cost of the queries.
+	QueryCostScrapeInterval(userID string) time.Duration
+
+	// QueryCostOverrideAllowed returns whether the queries are allowed to override the max estimated
+	// query cost with the QueryCostOverrideHeader.
+	QueryCostOverrideAllowed(userID string) bool
 }
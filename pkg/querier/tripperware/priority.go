@@ -6,7 +6,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
-func GetPriority(query string, minTime, maxTime int64, now time.Time, queryPriority validation.QueryPriority) int64 {
+func GetPriority(query, userAgent string, minTime, maxTime int64, now time.Time, queryPriority validation.QueryPriority) int64 {
 	if !queryPriority.Enabled || query == "" || len(queryPriority.Priorities) == 0 {
 		return queryPriority.DefaultPriority
 	}
@@ -19,6 +19,10 @@ func GetPriority(query string, minTime, maxTime int64, now time.Time, queryPrior
 				}
 			}
 
+			if attribute.UserAgentRegex != "" && attribute.CompiledUserAgentRegex != nil && !attribute.CompiledUserAgentRegex.MatchString(userAgent) {
+				continue
+			}
+
 			if isWithinTimeAttributes(attribute.TimeWindow, now, minTime, maxTime) {
 				return priority.Priority
 			}
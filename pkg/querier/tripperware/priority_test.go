@@ -45,7 +45,7 @@ func Test_GetPriorityShouldReturnDefaultPriorityIfNotEnabledOrInvalidQueryString
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
 			limits.queryPriority.Enabled = testData.queryPriorityEnabled
-			priority := GetPriority(testData.query, 0, 0, now, limits.queryPriority)
+			priority := GetPriority(testData.query, "", 0, 0, now, limits.queryPriority)
 			assert.Equal(t, int64(0), priority)
 		})
 	}
@@ -103,7 +103,60 @@ func Test_GetPriorityShouldConsiderRegex(t *testing.T) {
 		t.Run(testName, func(t *testing.T) {
 			limits.queryPriority.Priorities[0].QueryAttributes[0].Regex = testData.regex
 			limits.queryPriority.Priorities[0].QueryAttributes[0].CompiledRegex = regexp.MustCompile(testData.regex)
-			priority := GetPriority(testData.query, 0, 0, now, limits.queryPriority)
+			priority := GetPriority(testData.query, "", 0, 0, now, limits.queryPriority)
+			assert.Equal(t, int64(testData.expectedPriority), priority)
+		})
+	}
+}
+
+func Test_GetPriorityShouldConsiderUserAgentRegex(t *testing.T) {
+	now := time.Now()
+	limits := mockLimits{queryPriority: validation.QueryPriority{
+		Enabled: true,
+		Priorities: []validation.PriorityDef{
+			{
+				Priority: 1,
+				QueryAttributes: []validation.QueryAttribute{
+					{},
+				},
+			},
+		},
+	}}
+
+	type testCase struct {
+		userAgentRegex   string
+		userAgent        string
+		expectedPriority int
+	}
+
+	tests := map[string]testCase{
+		"should hit if user agent regex matches": {
+			userAgentRegex:   "^Grafana.*alerting",
+			userAgent:        "Grafana/10.4.0 alerting",
+			expectedPriority: 1,
+		},
+		"should miss if user agent regex doesn't match": {
+			userAgentRegex:   "^Grafana.*alerting",
+			userAgent:        "Grafana/10.4.0",
+			expectedPriority: 0,
+		},
+		"should miss if user agent is missing": {
+			userAgentRegex:   "^Grafana.*alerting",
+			userAgent:        "",
+			expectedPriority: 0,
+		},
+		"should hit if user agent regex is an empty string": {
+			userAgentRegex:   "",
+			userAgent:        "curl/8.0.1",
+			expectedPriority: 1,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			limits.queryPriority.Priorities[0].QueryAttributes[0].UserAgentRegex = testData.userAgentRegex
+			limits.queryPriority.Priorities[0].QueryAttributes[0].CompiledUserAgentRegex = regexp.MustCompile(testData.userAgentRegex)
+			priority := GetPriority("sum(up)", testData.userAgent, 0, 0, now, limits.queryPriority)
 			assert.Equal(t, int64(testData.expectedPriority), priority)
 		})
 	}
@@ -171,7 +224,7 @@ func Test_GetPriorityShouldConsiderStartAndEndTime(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			priority := GetPriority("sum(up)", testData.start.UnixMilli(), testData.end.UnixMilli(), now, limits.queryPriority)
+			priority := GetPriority("sum(up)", "", testData.start.UnixMilli(), testData.end.UnixMilli(), now, limits.queryPriority)
 			assert.Equal(t, int64(testData.expectedPriority), priority)
 		})
 	}
@@ -215,7 +268,7 @@ func Test_GetPriorityShouldNotConsiderStartAndEndTimeIfEmpty(t *testing.T) {
 
 	for testName, testData := range tests {
 		t.Run(testName, func(t *testing.T) {
-			priority := GetPriority("sum(up)", testData.start.Unix(), testData.end.Unix(), now, limits.queryPriority)
+			priority := GetPriority("sum(up)", "", testData.start.Unix(), testData.end.Unix(), now, limits.queryPriority)
 			assert.Equal(t, int64(1), priority)
 		})
 	}
@@ -50,6 +50,14 @@ type Codec interface {
 	EncodeResponse(context.Context, Response) (*http.Response, error)
 }
 
+// StreamingCodec is a Codec which can stream the encoded response to the client, instead of buffering it.
+type StreamingCodec interface {
+	Codec
+	// EncodeStreamingResponse encodes a Response into an http response whose body is encoded while it's read,
+	// in a format negotiated with the original http request.
+	EncodeStreamingResponse(context.Context, *http.Request, Response) (*http.Response, error)
+}
+
 // Merger is used by middlewares making multiple requests to merge back all responses into a single one.
 type Merger interface {
 	// MergeResponse merges responses from multiple requests into a single Response
@@ -0,0 +1,443 @@
+package tripperware
+
+import (
+	"context"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// QueryCostOverrideHeader is the header allowing a query whose estimated cost exceeds the limit to be executed
+// anyway, if the tenant is allowed to override the limit.
+const QueryCostOverrideHeader = "X-Cortex-Query-Cost-Override"
+
+const (
+	// queryCostCardinalityLimit is the number of metric names and label value pairs whose number of
+	// series is fetched to estimate the cost of the queries. The series count of the metric names and
+	// label value pairs not in the top ones is bounded by the smallest returned.
+	queryCostCardinalityLimit = 1000
+
+	// queryCostCardinalityTTL is how long the cardinality statistics of a tenant are reused to
+	// estimate the cost of its queries, before being fetched again.
+	queryCostCardinalityTTL = time.Minute
+)
+
+var (
+	ErrQueryCostTooHigh = "the estimated cost of the query (%d samples) exceeds the limit (%d samples). Narrow down the selectors or the time range of the query"
+)
+
+// queryCostOverridden returns whether the request overrides the max estimated query cost, which is
+// honored only if all the tenants of the request are allowed to.
+func queryCostOverridden(r *http.Request, limits Limits, tenantIDs []string) bool {
+	if limits == nil || r.Header.Get(QueryCostOverrideHeader) == "" {
+		return false
+	}
+	for _, userID := range tenantIDs {
+		if !limits.QueryCostOverrideAllowed(userID) {
+			return false
+		}
+	}
+	return true
+}
+
+type queryCostContextKey int
+
+const queryCostCardinalityKey queryCostContextKey = 0
+
+// queryCostCardinality caches the cardinality statistics of the tenants, fetched from the TSDB status
+// API of the next round tripper, to estimate the cost of their queries.
+type queryCostCardinality struct {
+	next http.RoundTripper
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantCardinality
+}
+
+func newQueryCostCardinality(next http.RoundTripper) *queryCostCardinality {
+	return &queryCostCardinality{
+		next:    next,
+		tenants: map[string]*tenantCardinality{},
+	}
+}
+
+// contextWithQueryCostCardinality returns a context allowing the QueryCostMiddleware to fetch the
+// cardinality statistics at the TSDB status API path sibling of the input request path.
+func contextWithQueryCostCardinality(r *http.Request, c *queryCostCardinality) context.Context {
+	return context.WithValue(r.Context(), queryCostCardinalityKey, &queryCostCardinalityRequest{
+		cardinality: c,
+		statusPath:  path.Join(path.Dir(r.URL.Path), "status", "tsdb"),
+	})
+}
+
+type queryCostCardinalityRequest struct {
+	cardinality *queryCostCardinality
+	statusPath  string
+}
+
+// tenantCardinality holds the series counts of the TSDB head of the ingesters for a tenant.
+type tenantCardinality struct {
+	// done is closed once the statistics have been fetched.
+	done      chan struct{}
+	fetchedAt time.Time
+	err       error
+
+	numSeries               int64
+	seriesCountByMetricName map[string]int64
+	// metricNamesTruncated is true if the tenant has more metric names than seriesCountByMetricName,
+	// in which case minMetricNameCount bounds the series count of the missing ones.
+	metricNamesTruncated bool
+	minMetricNameCount   int64
+	// seriesCountByLabelValuePair is keyed by name=value.
+	seriesCountByLabelValuePair map[string]int64
+	labelValuePairsTruncated    bool
+	minLabelValuePairCount      int64
+}
+
+// get returns the cardinality statistics of the tenant, fetching them if they are missing or expired.
+// Concurrent queries of the tenant wait for the same fetch.
+func (c *queryCostCardinality) get(ctx context.Context, userID, statusPath string) (*tenantCardinality, error) {
+	c.mtx.Lock()
+	t, ok := c.tenants[userID]
+	if !ok || (isClosed(t.done) && time.Since(t.fetchedAt) > queryCostCardinalityTTL) {
+		t = &tenantCardinality{done: make(chan struct{})}
+		c.tenants[userID] = t
+		c.pruneExpired()
+		c.mtx.Unlock()
+
+		// The fetch isn't bound to the query, whose cancellation would fail the waiting queries.
+		fetchCtx, cancel := context.WithTimeout(user.InjectOrgID(context.WithoutCancel(ctx), userID), queryCostCardinalityTTL)
+		t.err = t.fetch(fetchCtx, c.next, statusPath)
+		cancel()
+		t.fetchedAt = time.Now()
+		close(t.done)
+	} else {
+		c.mtx.Unlock()
+	}
+
+	select {
+	case <-t.done:
+		return t, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pruneExpired removes the statistics of the tenants which haven't queried for a while.
+// It must be called with the lock held.
+func (c *queryCostCardinality) pruneExpired() {
+	for userID, t := range c.tenants {
+		if isClosed(t.done) && time.Since(t.fetchedAt) > 10*queryCostCardinalityTTL {
+			delete(c.tenants, userID)
+		}
+	}
+}
+
+func isClosed(ch chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t *tenantCardinality) fetch(ctx context.Context, next http.RoundTripper, statusPath string) error {
+	u := &url.URL{
+		Path:     statusPath,
+		RawQuery: url.Values{"limit": []string{strconv.Itoa(queryCostCardinalityLimit)}}.Encode(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if err := user.InjectOrgIDIntoHTTPRequest(ctx, req); err != nil {
+		return err
+	}
+
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Errorf("unexpected status code %d: %s", resp.StatusCode, body)
+	}
+
+	type stat struct {
+		Name  string `json:"name"`
+		Value int64  `json:"value"`
+	}
+	var status struct {
+		Data struct {
+			HeadStats struct {
+				NumSeries int64 `json:"numSeries"`
+			} `json:"headStats"`
+			SeriesCountByMetricName     []stat `json:"seriesCountByMetricName"`
+			SeriesCountByLabelValuePair []stat `json:"seriesCountByLabelValuePair"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return err
+	}
+
+	toMap := func(stats []stat) (map[string]int64, bool, int64) {
+		m := make(map[string]int64, len(stats))
+		minCount := int64(math.MaxInt64)
+		for _, s := range stats {
+			m[s.Name] = s.Value
+			minCount = min(minCount, s.Value)
+		}
+		return m, len(stats) >= queryCostCardinalityLimit, minCount
+	}
+	t.numSeries = status.Data.HeadStats.NumSeries
+	t.seriesCountByMetricName, t.metricNamesTruncated, t.minMetricNameCount = toMap(status.Data.SeriesCountByMetricName)
+	t.seriesCountByLabelValuePair, t.labelValuePairsTruncated, t.minLabelValuePairCount = toMap(status.Data.SeriesCountByLabelValuePair)
+	return nil
+}
+
+// estimateSeries returns an upper bound of the number of series matching the matchers: the smallest
+// number of series selected by the equality and regexp matchers, or the number of series of the tenant
+// if there's none which can be estimated.
+func (t *tenantCardinality) estimateSeries(matchers []*labels.Matcher) int64 {
+	estimate := t.numSeries
+	for _, m := range matchers {
+		if count, ok := t.matcherSeries(m); ok {
+			estimate = min(estimate, count)
+		}
+	}
+	return estimate
+}
+
+// matcherSeries returns an upper bound of the number of series selected by the matcher, and whether
+// it can be estimated. An equality matcher selects the series of its metric name or label value pair.
+// A regexp matcher selects the series of all the values it matches: the values of a set of alternatives
+// are looked up, while the other regexps are matched against the values of the label in the statistics,
+// as long as none is missing.
+func (t *tenantCardinality) matcherSeries(m *labels.Matcher) (int64, bool) {
+	switch m.Type {
+	case labels.MatchEqual:
+		if m.Value == "" {
+			return 0, false
+		}
+		return t.valueSeries(m.Name, m.Value), true
+
+	case labels.MatchRegexp:
+		// The matchers matching the empty value select the series without the label too.
+		if m.Matches("") {
+			return 0, false
+		}
+
+		if values := m.SetMatches(); len(values) > 0 {
+			var count int64
+			for _, v := range values {
+				count = saturatingAdd(count, t.valueSeries(m.Name, v))
+			}
+			return count, true
+		}
+
+		var count int64
+		if m.Name == labels.MetricName {
+			if t.metricNamesTruncated {
+				return 0, false
+			}
+			for name, c := range t.seriesCountByMetricName {
+				if m.Matches(name) {
+					count = saturatingAdd(count, c)
+				}
+			}
+			return count, true
+		}
+
+		if t.labelValuePairsTruncated {
+			return 0, false
+		}
+		for pair, c := range t.seriesCountByLabelValuePair {
+			if name, value, _ := strings.Cut(pair, "="); name == m.Name && m.Matches(value) {
+				count = saturatingAdd(count, c)
+			}
+		}
+		return count, true
+	}
+
+	return 0, false
+}
+
+// valueSeries returns an upper bound of the number of series of the metric name, or label value pair.
+func (t *tenantCardinality) valueSeries(name, value string) int64 {
+	if name == labels.MetricName {
+		if count, ok := t.seriesCountByMetricName[value]; ok {
+			return count
+		}
+		return missingCount(t.metricNamesTruncated, t.minMetricNameCount)
+	}
+
+	if count, ok := t.seriesCountByLabelValuePair[name+"="+value]; ok {
+		return count
+	}
+	return missingCount(t.labelValuePairsTruncated, t.minLabelValuePairCount)
+}
+
+// missingCount returns the upper bound of the series count of an item missing from the statistics.
+func missingCount(truncated bool, minCount int64) int64 {
+	if truncated {
+		return minCount
+	}
+	return 0
+}
+
+// QueryCostMiddleware rejects the instant or range queries whose estimated cost exceeds the max
+// estimated query cost of the tenants. It's expected to be placed before the split by interval,
+// so that the cost of the whole query is checked before any part of it is executed.
+//
+// The cost is the number of samples evaluated by the query: for each selector, the estimated number
+// of matching series times the number of evaluation steps, times the number of samples selected per
+// series at each step. The number of matching series is estimated from the cardinality statistics of
+// the TSDB head of the tenant, cached for queryCostCardinalityTTL: it's the smallest series count of
+// the metric names and label value pairs selected by the equality and regexp matchers of the selector.
+// The queries whose cost can't be estimated are executed.
+func QueryCostMiddleware(limits Limits, defaultSubQueryInterval time.Duration) Middleware {
+	return MiddlewareFunc(func(next Handler) Handler {
+		return HandlerFunc(func(ctx context.Context, r Request) (Response, error) {
+			if err := checkQueryCost(ctx, r, limits, defaultSubQueryInterval); err != nil {
+				return nil, err
+			}
+			return next.Do(ctx, r)
+		})
+	})
+}
+
+func checkQueryCost(ctx context.Context, r Request, limits Limits, defaultSubQueryInterval time.Duration) error {
+	// The cardinality is missing if the cost of the query must not be checked.
+	cr, ok := ctx.Value(queryCostCardinalityKey).(*queryCostCardinalityRequest)
+	if !ok {
+		return nil
+	}
+
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil
+	}
+	maxCost := validation.SmallestPositiveNonZeroInt64PerTenant(tenantIDs, limits.MaxEstimatedQueryCost)
+	if maxCost <= 0 {
+		return nil
+	}
+
+	expr, err := parser.ParseExpr(r.GetQuery())
+	if err != nil {
+		// The query fails later on because of the invalid expression.
+		return nil
+	}
+
+	steps := int64(1)
+	if r.GetStep() > 0 {
+		if r.GetEnd() < r.GetStart() {
+			// The query fails later on because of the invalid parameters.
+			return nil
+		}
+		steps = (r.GetEnd()-r.GetStart())/r.GetStep() + 1
+	}
+
+	scrapeInterval := validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, limits.QueryCostScrapeInterval)
+	selectors := costSelectors(expr, scrapeInterval, defaultSubQueryInterval)
+
+	var cost int64
+	for _, userID := range tenantIDs {
+		cardinality, err := cr.cardinality.get(ctx, userID, cr.statusPath)
+		if err != nil {
+			level.Warn(util_log.WithContext(ctx, util_log.Logger)).Log("msg", "failed to fetch the cardinality statistics to estimate the cost of the query, executing it", "user", userID, "err", err)
+			return nil
+		}
+
+		for _, sel := range selectors {
+			perSeries := saturatingMul(steps, sel.samplesPerStep)
+			cost = saturatingAdd(cost, saturatingMul(cardinality.estimateSeries(sel.matchers), perSeries))
+		}
+	}
+
+	if cost > maxCost {
+		return httpgrpc.Errorf(http.StatusBadRequest, ErrQueryCostTooHigh, cost, maxCost)
+	}
+	return nil
+}
+
+type costSelector struct {
+	matchers []*labels.Matcher
+	// samplesPerStep is the number of samples selected per series at each evaluation step.
+	samplesPerStep int64
+}
+
+// costSelectors returns the selectors of the query, along with the number of samples they select per
+// series at each evaluation step of the query: one for the instant selectors, the range divided by
+// the scrape interval for the range selectors, multiplied by the number of evaluations of the
+// enclosing subqueries.
+func costSelectors(expr parser.Expr, scrapeInterval, defaultSubQueryInterval time.Duration) []costSelector {
+	var selectors []costSelector
+	parser.Inspect(expr, func(node parser.Node, path []parser.Node) error {
+		vs, ok := node.(*parser.VectorSelector)
+		if !ok {
+			return nil
+		}
+
+		samples := int64(1)
+		for i, p := range path {
+			switch p := p.(type) {
+			case *parser.MatrixSelector:
+				// The range selector is the direct parent of its vector selector.
+				if i == len(path)-1 && scrapeInterval > 0 {
+					samples = saturatingMul(samples, max(int64(p.Range/scrapeInterval), 1))
+				}
+			case *parser.SubqueryExpr:
+				step := p.Step
+				if step == 0 {
+					step = defaultSubQueryInterval
+				}
+				if step > 0 {
+					samples = saturatingMul(samples, max(int64(p.Range/step), 1))
+				}
+			}
+		}
+
+		selectors = append(selectors, costSelector{
+			matchers:       vs.LabelMatchers,
+			samplesPerStep: samples,
+		})
+		return nil
+	})
+	return selectors
+}
+
+func saturatingMul(a, b int64) int64 {
+	if a != 0 && b > math.MaxInt64/a {
+		return math.MaxInt64
+	}
+	return a * b
+}
+
+func saturatingAdd(a, b int64) int64 {
+	if a > math.MaxInt64-b {
+		return math.MaxInt64
+	}
+	return a + b
+}
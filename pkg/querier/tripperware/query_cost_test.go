@@ -0,0 +1,318 @@
+package tripperware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+)
+
+const queryCostTestStatus = `{"status":"success","data":{
+	"headStats":{"numSeries":1000},
+	"seriesCountByMetricName":[{"name":"http_requests_total","value":100},{"name":"up","value":10}],
+	"seriesCountByLabelValuePair":[{"name":"job=api","value":50},{"name":"a=b","value":5}]
+}}`
+
+func queryCostTestRoundTripper(t *testing.T, fetches *atomic.Int64, status int, body string) http.RoundTripper {
+	return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		fetches.Inc()
+		require.Equal(t, "/prometheus/api/v1/status/tsdb", r.URL.Path)
+		require.Equal(t, strconv.Itoa(queryCostCardinalityLimit), r.FormValue("limit"))
+		orgID, err := user.ExtractOrgID(r.Context())
+		require.NoError(t, err)
+		require.Equal(t, r.Header.Get(user.OrgIDHeaderName), orgID)
+		return &http.Response{
+			StatusCode: status,
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}, nil
+	})
+}
+
+func TestQueryCostMiddleware(t *testing.T) {
+	for name, tc := range map[string]struct {
+		req           Request
+		noCardinality bool
+		limits        mockLimits
+		expectedErr   string
+	}{
+		"disabled": {
+			req:    &queryCostInstantRequest{query: "up", time: 1000},
+			limits: mockLimits{},
+		},
+		"instant query within the limit": {
+			req:    &queryCostInstantRequest{query: "sum(up)", time: 1000},
+			limits: mockLimits{maxEstimatedQueryCost: 10},
+		},
+		"instant query exceeding the limit": {
+			req:         &queryCostInstantRequest{query: "http_requests_total", time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 10},
+			expectedErr: "the estimated cost of the query (100 samples) exceeds the limit (10 samples)",
+		},
+		"instant query exceeding the limit with the override header": {
+			req:           &queryCostInstantRequest{query: "http_requests_total", time: 1000},
+			noCardinality: true,
+			limits:        mockLimits{maxEstimatedQueryCost: 10},
+		},
+		"range query within the limit": {
+			// 10 series * 61 steps.
+			req:    &queryCostRangeRequest{query: "up", start: 0, end: 600000, step: 10000},
+			limits: mockLimits{maxEstimatedQueryCost: 610},
+		},
+		"range query exceeding the limit": {
+			req:         &queryCostRangeRequest{query: "up", start: 0, end: 600000, step: 10000},
+			limits:      mockLimits{maxEstimatedQueryCost: 609},
+			expectedErr: "the estimated cost of the query (610 samples) exceeds the limit (609 samples)",
+		},
+		"the whole range query is estimated": {
+			// 10 series * 8641 steps over 1 day, whatever the split by interval.
+			req:         &queryCostRangeRequest{query: "up", start: 0, end: 86400000, step: 10000},
+			limits:      mockLimits{maxEstimatedQueryCost: 86409},
+			expectedErr: "the estimated cost of the query (86410 samples) exceeds the limit (86409 samples)",
+		},
+		"range selector": {
+			// 5 series * 4 samples per step.
+			req:         &queryCostInstantRequest{query: `rate(http_requests_total{a="b"}[1m])`, time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 19, queryCostScrapeInterval: 15 * time.Second},
+			expectedErr: "the estimated cost of the query (20 samples) exceeds the limit (19 samples)",
+		},
+		"the cost of the selectors is summed": {
+			req:         &queryCostInstantRequest{query: `http_requests_total{job="api"} / up`, time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 59},
+			expectedErr: "the estimated cost of the query (60 samples) exceeds the limit (59 samples)",
+		},
+		"selector without matchers which can be estimated": {
+			req:         &queryCostInstantRequest{query: `{__name__!="", job=~".*"}`, time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 999},
+			expectedErr: "the estimated cost of the query (1000 samples) exceeds the limit (999 samples)",
+		},
+		"selector with a regexp matcher on the metric name": {
+			req:         &queryCostInstantRequest{query: `{__name__=~"http_.+"}`, time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 99},
+			expectedErr: "the estimated cost of the query (100 samples) exceeds the limit (99 samples)",
+		},
+		"selector with a regexp matcher on a label": {
+			req:         &queryCostInstantRequest{query: `{job=~"ap.*"}`, time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 49},
+			expectedErr: "the estimated cost of the query (50 samples) exceeds the limit (49 samples)",
+		},
+		"selector with a regexp matcher on a set of metric names": {
+			// 100 + 10 series.
+			req:         &queryCostInstantRequest{query: `{__name__=~"http_requests_total|up|missing"}`, time: 1000},
+			limits:      mockLimits{maxEstimatedQueryCost: 109},
+			expectedErr: "the estimated cost of the query (110 samples) exceeds the limit (109 samples)",
+		},
+		"selector of a metric without series": {
+			req:    &queryCostInstantRequest{query: `missing`, time: 1000},
+			limits: mockLimits{maxEstimatedQueryCost: 1},
+		},
+		"invalid range query": {
+			req:    &queryCostRangeRequest{query: "up", start: 600000, end: 0, step: 10000},
+			limits: mockLimits{maxEstimatedQueryCost: 1},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			fetches := atomic.NewInt64(0)
+			next := queryCostTestRoundTripper(t, fetches, http.StatusOK, queryCostTestStatus)
+
+			ctx := user.InjectOrgID(context.Background(), "user-1")
+			if !tc.noCardinality {
+				r := httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/query", nil).WithContext(ctx)
+				ctx = contextWithQueryCostCardinality(r, newQueryCostCardinality(next))
+			}
+
+			executed := false
+			handler := QueryCostMiddleware(tc.limits, time.Minute).Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+				executed = true
+				return &mockResponse{}, nil
+			}))
+
+			_, err := handler.Do(ctx, tc.req)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+				assert.True(t, executed)
+				return
+			}
+			require.Error(t, err)
+			assert.False(t, executed)
+			resp, ok := httpgrpc.HTTPResponseFromError(err)
+			require.True(t, ok)
+			assert.Equal(t, int32(http.StatusBadRequest), resp.Code)
+			assert.Contains(t, string(resp.Body), tc.expectedErr)
+		})
+	}
+}
+
+func TestQueryCostMiddleware_CardinalityIsCached(t *testing.T) {
+	fetches := atomic.NewInt64(0)
+	cardinality := newQueryCostCardinality(queryCostTestRoundTripper(t, fetches, http.StatusOK, queryCostTestStatus))
+
+	handler := QueryCostMiddleware(mockLimits{maxEstimatedQueryCost: 10}, time.Minute).Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+		return &mockResponse{}, nil
+	}))
+	for _, orgID := range []string{"user-1", "user-1", "user-2", "user-1"} {
+		r := httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/query", nil)
+		r = r.WithContext(user.InjectOrgID(context.Background(), orgID))
+		_, err := handler.Do(contextWithQueryCostCardinality(r, cardinality), &queryCostInstantRequest{query: "up", time: 1000})
+		require.NoError(t, err)
+	}
+	// The statistics are fetched once per tenant.
+	assert.Equal(t, int64(2), fetches.Load())
+}
+
+func TestQueryCostMiddleware_MultipleTenants(t *testing.T) {
+	tenant.WithDefaultResolver(tenant.NewMultiResolver())
+	t.Cleanup(func() { tenant.WithDefaultResolver(tenant.NewSingleResolver()) })
+
+	fetches := atomic.NewInt64(0)
+	next := queryCostTestRoundTripper(t, fetches, http.StatusOK, queryCostTestStatus)
+
+	r := httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/query", nil)
+	r = r.WithContext(user.InjectOrgID(context.Background(), "user-1|user-2"))
+
+	handler := QueryCostMiddleware(mockLimits{maxEstimatedQueryCost: 19}, time.Minute).Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+		return &mockResponse{}, nil
+	}))
+	// The cost of the query is summed across the tenants.
+	_, err := handler.Do(contextWithQueryCostCardinality(r, newQueryCostCardinality(next)), &queryCostInstantRequest{query: "up", time: 1000})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "the estimated cost of the query (20 samples) exceeds the limit (19 samples)")
+	assert.Equal(t, int64(2), fetches.Load())
+}
+
+func TestQueryCostMiddleware_CardinalityFailure(t *testing.T) {
+	fetches := atomic.NewInt64(0)
+	next := queryCostTestRoundTripper(t, fetches, http.StatusServiceUnavailable, "unavailable")
+
+	r := httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/query", nil)
+	r = r.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+	executed := false
+	handler := QueryCostMiddleware(mockLimits{maxEstimatedQueryCost: 1}, time.Minute).Wrap(HandlerFunc(func(context.Context, Request) (Response, error) {
+		executed = true
+		return &mockResponse{}, nil
+	}))
+	// The queries whose cost can't be estimated are executed.
+	_, err := handler.Do(contextWithQueryCostCardinality(r, newQueryCostCardinality(next)), &queryCostInstantRequest{query: "up", time: 1000})
+	require.NoError(t, err)
+	assert.True(t, executed)
+	assert.Equal(t, int64(1), fetches.Load())
+}
+
+func TestTenantCardinality_EstimateSeries_ShouldNotEstimateRegexpsWithTruncatedStatistics(t *testing.T) {
+	cardinality := &tenantCardinality{
+		numSeries:                   1000,
+		seriesCountByLabelValuePair: map[string]int64{"job=api": 50, "job=web": 20},
+		labelValuePairsTruncated:    true,
+		minLabelValuePairCount:      20,
+	}
+
+	// Some values matching the regexp may be missing from the statistics.
+	assert.Equal(t, int64(1000), cardinality.estimateSeries([]*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "a.*")}))
+
+	// The values of a set of alternatives are bounded by the smallest count when missing.
+	assert.Equal(t, int64(90), cardinality.estimateSeries([]*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "job", "api|web|missing")}))
+}
+
+func TestQueryCostOverridden(t *testing.T) {
+	for name, tc := range map[string]struct {
+		header   bool
+		limits   Limits
+		expected bool
+	}{
+		"no header": {
+			limits: mockLimits{queryCostOverrideAllowed: true},
+		},
+		"header not allowed": {
+			header: true,
+			limits: mockLimits{},
+		},
+		"header allowed": {
+			header:   true,
+			limits:   mockLimits{queryCostOverrideAllowed: true},
+			expected: true,
+		},
+		"no limits": {
+			header: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/prometheus/api/v1/query", nil)
+			if tc.header {
+				r.Header.Set(QueryCostOverrideHeader, "true")
+			}
+			assert.Equal(t, tc.expected, queryCostOverridden(r, tc.limits, []string{"user-1"}))
+		})
+	}
+}
+
+func TestCostSelectors(t *testing.T) {
+	for query, expected := range map[string][]struct {
+		selector       string
+		samplesPerStep int64
+	}{
+		`up`: {
+			{selector: `{__name__="up"}`, samplesPerStep: 1},
+		},
+		`rate(up{job="a"}[5m] offset 1h)`: {
+			{selector: `{__name__="up",job="a"}`, samplesPerStep: 20},
+		},
+		`max_over_time(rate(up[1m])[1h:5m])`: {
+			{selector: `{__name__="up"}`, samplesPerStep: 48},
+		},
+		`max_over_time(up[1h:])`: {
+			{selector: `{__name__="up"}`, samplesPerStep: 60},
+		},
+		`rate(up[5s]) + foo`: {
+			{selector: `{__name__="up"}`, samplesPerStep: 1},
+			{selector: `{__name__="foo"}`, samplesPerStep: 1},
+		},
+	} {
+		t.Run(query, func(t *testing.T) {
+			expr, err := parser.ParseExpr(query)
+			require.NoError(t, err)
+			actual := costSelectors(expr, 15*time.Second, time.Minute)
+			require.Len(t, actual, len(expected))
+			for i, sel := range actual {
+				assert.Equal(t, expected[i].samplesPerStep, sel.samplesPerStep)
+				assert.Equal(t, expected[i].selector, (&parser.VectorSelector{LabelMatchers: sel.matchers}).String())
+			}
+		})
+	}
+}
+
+type queryCostRangeRequest struct {
+	Request
+	query            string
+	start, end, step int64
+}
+
+func (r *queryCostRangeRequest) GetQuery() string { return r.query }
+func (r *queryCostRangeRequest) GetStart() int64  { return r.start }
+func (r *queryCostRangeRequest) GetEnd() int64    { return r.end }
+func (r *queryCostRangeRequest) GetStep() int64   { return r.step }
+
+type queryCostInstantRequest struct {
+	Request
+	query string
+	time  int64
+}
+
+func (r *queryCostInstantRequest) GetQuery() string { return r.query }
+func (r *queryCostInstantRequest) GetStart() int64  { return 0 }
+func (r *queryCostInstantRequest) GetEnd() int64    { return 0 }
+func (r *queryCostInstantRequest) GetStep() int64   { return 0 }
+func (r *queryCostInstantRequest) GetTime() int64   { return r.time }
@@ -0,0 +1,167 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util/spanlogger"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+type downsampleMiddleware struct {
+	limits tripperware.Limits
+	next   tripperware.Handler
+}
+
+// NewDownsampleMiddleware creates a new Middleware that downsamples the range query responses
+// exceeding the max number of points of the tenant, so that they can still be rendered, and
+// adds a warning to them.
+func NewDownsampleMiddleware(limits tripperware.Limits) tripperware.Middleware {
+	return tripperware.MiddlewareFunc(func(next tripperware.Handler) tripperware.Handler {
+		return downsampleMiddleware{
+			limits: limits,
+			next:   next,
+		}
+	})
+}
+
+func (d downsampleMiddleware) Do(ctx context.Context, r tripperware.Request) (tripperware.Response, error) {
+	tenantIDs, err := tenant.TenantIDs(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	resp, err := d.next.Do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	maxPoints := validation.SmallestPositiveIntPerTenant(tenantIDs, d.limits.MaxResponsePoints)
+	if maxPoints <= 0 {
+		return resp, nil
+	}
+
+	promResp, ok := resp.(*PrometheusResponse)
+	if !ok || promResp.Status != StatusSuccess || promResp.Data.ResultType != model.ValMatrix.String() {
+		return resp, nil
+	}
+
+	downsampled, before, after := downsampleResponse(promResp, maxPoints)
+	if downsampled == promResp {
+		return resp, nil
+	}
+
+	log, _ := spanlogger.New(ctx, "downsample")
+	defer log.Finish()
+	level.Debug(log).Log("msg", "downsampled the query response exceeding the max response points", "before", before, "after", after, "limit", maxPoints)
+
+	return downsampled, nil
+}
+
+// downsampleResponse returns a copy of the input response with its series downsampled to about
+// maxPoints points overall, reducing each series proportionally to its number of points, along
+// with the number of points before and after the downsampling. The input response is returned
+// as is if it doesn't exceed maxPoints.
+func downsampleResponse(resp *PrometheusResponse, maxPoints int) (*PrometheusResponse, int, int) {
+	before := 0
+	for _, s := range resp.Data.Result {
+		before += len(s.Samples) + len(s.Histograms)
+	}
+	if before <= maxPoints {
+		return resp, before, before
+	}
+
+	ratio := float64(maxPoints) / float64(before)
+	result := make([]tripperware.SampleStream, 0, len(resp.Data.Result))
+	after := 0
+	for _, s := range resp.Data.Result {
+		s.Samples = lttb(s.Samples, downsampledLen(len(s.Samples), ratio))
+		s.Histograms = evenlySpacedHistograms(s.Histograms, downsampledLen(len(s.Histograms), ratio))
+		after += len(s.Samples) + len(s.Histograms)
+		result = append(result, s)
+	}
+
+	downsampled := *resp
+	downsampled.Data.Result = result
+	downsampled.Warnings = append(append([]string(nil), resp.Warnings...), fmt.Sprintf(
+		"the query response has been downsampled from %d to %d points because it exceeds the limit of %d points (-frontend.max-response-points)", before, after, maxPoints))
+
+	return &downsampled, before, after
+}
+
+// downsampledLen returns the number of points a series of n points is downsampled to, keeping at
+// least the first and last points.
+func downsampledLen(n int, ratio float64) int {
+	return min(n, max(2, int(math.Floor(float64(n)*ratio))))
+}
+
+// lttb downsamples the input samples to threshold samples using the Largest-Triangle-Three-Buckets
+// algorithm, which preserves the visual shape of the series better than picking evenly spaced samples.
+func lttb(samples []cortexpb.Sample, threshold int) []cortexpb.Sample {
+	if threshold >= len(samples) {
+		return samples
+	}
+	if threshold < 3 {
+		return []cortexpb.Sample{samples[0], samples[len(samples)-1]}
+	}
+
+	result := make([]cortexpb.Sample, 0, threshold)
+	result = append(result, samples[0])
+
+	// The first and last samples are always kept, so we split the others in threshold-2 buckets
+	// and keep, for each bucket, the sample forming the largest triangle with the sample kept in
+	// the previous bucket and the average of the next bucket.
+	bucketSize := float64(len(samples)-2) / float64(threshold-2)
+	prev := 0
+	for i := 0; i < threshold-2; i++ {
+		bucketStart := int(math.Floor(float64(i)*bucketSize)) + 1
+		bucketEnd := int(math.Floor(float64(i+1)*bucketSize)) + 1
+
+		nextStart := bucketEnd
+		nextEnd := min(int(math.Floor(float64(i+2)*bucketSize))+1, len(samples))
+		var avgX, avgY float64
+		for j := nextStart; j < nextEnd; j++ {
+			avgX += float64(samples[j].TimestampMs)
+			avgY += samples[j].Value
+		}
+		avgX /= float64(nextEnd - nextStart)
+		avgY /= float64(nextEnd - nextStart)
+
+		prevX, prevY := float64(samples[prev].TimestampMs), samples[prev].Value
+		maxArea, maxIdx := -1.0, bucketStart
+		for j := bucketStart; j < bucketEnd; j++ {
+			area := math.Abs((prevX-avgX)*(samples[j].Value-prevY) - (prevX-float64(samples[j].TimestampMs))*(avgY-prevY))
+			if area > maxArea {
+				maxArea, maxIdx = area, j
+			}
+		}
+
+		result = append(result, samples[maxIdx])
+		prev = maxIdx
+	}
+
+	return append(result, samples[len(samples)-1])
+}
+
+// evenlySpacedHistograms downsamples the input histograms to n histograms picking them at evenly
+// spaced positions, keeping the first and last ones.
+func evenlySpacedHistograms(histograms []tripperware.SampleHistogramPair, n int) []tripperware.SampleHistogramPair {
+	if n >= len(histograms) {
+		return histograms
+	}
+
+	result := make([]tripperware.SampleHistogramPair, 0, n)
+	for i := 0; i < n; i++ {
+		result = append(result, histograms[i*(len(histograms)-1)/(n-1)])
+	}
+	return result
+}
@@ -0,0 +1,119 @@
+package queryrange
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+func TestDownsampleMiddleware(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		maxResponsePoints  int
+		series             []int
+		expectedPoints     []int
+		expectedDownsample bool
+	}{
+		"should not downsample if the limit is disabled": {
+			maxResponsePoints: 0,
+			series:            []int{100, 100},
+			expectedPoints:    []int{100, 100},
+		},
+		"should not downsample if the response is within the limit": {
+			maxResponsePoints: 200,
+			series:            []int{100, 100},
+			expectedPoints:    []int{100, 100},
+		},
+		"should downsample the series proportionally if the response exceeds the limit": {
+			maxResponsePoints:  100,
+			series:             []int{150, 50},
+			expectedPoints:     []int{75, 25},
+			expectedDownsample: true,
+		},
+		"should keep at least the first and last points of each series": {
+			maxResponsePoints:  10,
+			series:             []int{100, 3, 1},
+			expectedPoints:     []int{9, 2, 1},
+			expectedDownsample: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			innerRes := NewEmptyPrometheusResponse()
+			for _, n := range testData.series {
+				innerRes.Data.Result = append(innerRes.Data.Result, tripperware.SampleStream{Samples: generateDownsampleTestSamples(n)})
+			}
+
+			inner := &mockHandler{}
+			inner.On("Do", mock.Anything, mock.Anything).Return(innerRes, nil)
+
+			middleware := NewDownsampleMiddleware(mockLimits{maxResponsePoints: testData.maxResponsePoints})
+			res, err := middleware.Wrap(inner).Do(user.InjectOrgID(context.Background(), "test"), &PrometheusRequest{Query: "up"})
+			require.NoError(t, err)
+
+			if !testData.expectedDownsample {
+				assert.Same(t, innerRes, res)
+				return
+			}
+
+			promRes := res.(*PrometheusResponse)
+			require.Len(t, promRes.Data.Result, len(testData.expectedPoints))
+			for i, expected := range testData.expectedPoints {
+				samples := promRes.Data.Result[i].Samples
+				assert.Len(t, samples, expected)
+				assert.Equal(t, int64(0), samples[0].TimestampMs)
+				assert.Equal(t, int64(testData.series[i]-1), samples[len(samples)-1].TimestampMs)
+			}
+			require.Len(t, promRes.Warnings, 1)
+			assert.Contains(t, promRes.Warnings[0], "the query response has been downsampled")
+
+			// The response returned by the inner handler should have not been modified.
+			for i, n := range testData.series {
+				assert.Len(t, innerRes.Data.Result[i].Samples, n)
+			}
+			assert.Empty(t, innerRes.Warnings)
+		})
+	}
+}
+
+func TestLTTB(t *testing.T) {
+	t.Parallel()
+
+	// A flat series with a single spike, which should be preserved by the downsampling.
+	samples := make([]cortexpb.Sample, 0, 100)
+	for i := 0; i < 100; i++ {
+		samples = append(samples, cortexpb.Sample{TimestampMs: int64(i), Value: 1})
+	}
+	samples[42].Value = 100
+
+	downsampled := lttb(samples, 10)
+	require.Len(t, downsampled, 10)
+	assert.Equal(t, samples[0], downsampled[0])
+	assert.Equal(t, samples[99], downsampled[9])
+	assert.Contains(t, downsampled, samples[42])
+
+	// The samples should be kept in order.
+	for i := 1; i < len(downsampled); i++ {
+		assert.Less(t, downsampled[i-1].TimestampMs, downsampled[i].TimestampMs)
+	}
+}
+
+func generateDownsampleTestSamples(n int) []cortexpb.Sample {
+	samples := make([]cortexpb.Sample, 0, n)
+	for i := 0; i < n; i++ {
+		samples = append(samples, cortexpb.Sample{TimestampMs: int64(i), Value: float64(i % 7)})
+	}
+	return samples
+}
@@ -236,6 +236,7 @@ type mockLimits struct {
 	maxQueryLookback  time.Duration
 	maxQueryLength    time.Duration
 	maxCacheFreshness time.Duration
+	maxResponsePoints int
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -262,6 +263,34 @@ func (m mockLimits) QueryPriority(userID string) validation.QueryPriority {
 	return validation.QueryPriority{}
 }
 
+func (m mockLimits) MaxResponsePoints(string) int {
+	return m.maxResponsePoints
+}
+
+func (m mockLimits) QueryVerticalShardBySeriesHash(string) bool {
+	return false
+}
+
+func (m mockLimits) QueryVerticalShardApproximateTopK(string) bool {
+	return false
+}
+
+func (m mockLimits) InstantQueryCacheTTL(string) time.Duration {
+	return 0
+}
+
+func (m mockLimits) MaxEstimatedQueryCost(string) int64 {
+	return 0
+}
+
+func (m mockLimits) QueryCostScrapeInterval(string) time.Duration {
+	return 0
+}
+
+func (m mockLimits) QueryCostOverrideAllowed(string) bool {
+	return false
+}
+
 type mockHandler struct {
 	mock.Mock
 }
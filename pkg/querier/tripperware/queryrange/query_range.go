@@ -46,11 +46,12 @@ var (
 )
 
 type prometheusCodec struct {
-	sharded bool
+	sharded         bool
+	streamResponses bool
 }
 
-func NewPrometheusCodec(sharded bool) *prometheusCodec { //nolint:revive
-	return &prometheusCodec{sharded: sharded}
+func NewPrometheusCodec(sharded, streamResponses bool) *prometheusCodec { //nolint:revive
+	return &prometheusCodec{sharded: sharded, streamResponses: streamResponses}
 }
 
 // WithStartEnd clones the current `PrometheusRequest` with a new `start` and `end` timestamp.
@@ -164,6 +165,27 @@ func (c prometheusCodec) MergeResponse(ctx context.Context, _ tripperware.Reques
 	return &response, nil
 }
 
+// MergeAggregatedResponse merges the responses of the shards of a query sharded by series hash,
+// merging the series returned by the shards with the merge function.
+func (c prometheusCodec) MergeAggregatedResponse(ctx context.Context, req tripperware.Request, merge tripperware.SampleStreamsMerger, responses ...tripperware.Response) (tripperware.Response, error) {
+	shards := make([][]tripperware.SampleStream, 0, len(responses))
+	for _, res := range responses {
+		shards = append(shards, res.(*PrometheusResponse).Data.Result)
+	}
+	result, err := merge(shards...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The merge of the responses takes care of the stats, warnings and status.
+	merged, err := c.MergeResponse(ctx, req, responses...)
+	if err != nil {
+		return nil, err
+	}
+	merged.(*PrometheusResponse).Data.Result = result
+	return merged, nil
+}
+
 func (c prometheusCodec) DecodeRequest(_ context.Context, r *http.Request, forwardHeaders []string) (tripperware.Request, error) {
 	var result PrometheusRequest
 	var err error
@@ -42,6 +42,12 @@ type Config struct {
 	// List of headers which query_range middleware chain would forward to downstream querier.
 	ForwardHeaders flagext.StringSlice `yaml:"forward_headers_list"`
 
+	LabelsCache tripperware.LabelsCacheConfig `yaml:"labels_cache"`
+
+	InstantQueryCache tripperware.InstantQueryCacheConfig `yaml:"instant_query_cache"`
+
+	StreamResponses bool `yaml:"stream_responses"`
+
 	// Populated based on the query configuration
 	VerticalShardSize int `yaml:"-"`
 }
@@ -53,7 +59,10 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.BoolVar(&cfg.AlignQueriesWithStep, "querier.align-querier-with-step", false, "Mutate incoming queries to align their start and end with their step.")
 	f.BoolVar(&cfg.CacheResults, "querier.cache-results", false, "Cache query results.")
 	f.Var(&cfg.ForwardHeaders, "frontend.forward-headers-list", "List of headers forwarded by the query Frontend to downstream querier.")
+	f.BoolVar(&cfg.StreamResponses, "frontend.stream-responses", false, "[Experimental] Stream the range query responses to the clients one series at a time, instead of buffering the whole encoded response. The response is encoded in protobuf if the client accepts application/x-protobuf, and in JSON otherwise.")
 	cfg.ResultsCacheConfig.RegisterFlags(f)
+	cfg.LabelsCache.RegisterFlags(f)
+	cfg.InstantQueryCache.RegisterFlags(f)
 }
 
 // Validate validates the config.
@@ -66,6 +75,12 @@ func (cfg *Config) Validate(qCfg querier.Config) error {
 			return errors.Wrap(err, "invalid ResultsCache config")
 		}
 	}
+	if err := cfg.LabelsCache.Validate(); err != nil {
+		return errors.Wrap(err, "invalid LabelsCache config")
+	}
+	if err := cfg.InstantQueryCache.Validate(); err != nil {
+		return errors.Wrap(err, "invalid InstantQueryCache config")
+	}
 	return nil
 }
 
@@ -80,14 +95,19 @@ func Middlewares(
 	prometheusCodec tripperware.Codec,
 	shardedPrometheusCodec tripperware.Codec,
 	lookbackDelta time.Duration,
+	defaultSubQueryInterval time.Duration,
 ) ([]tripperware.Middleware, cache.Cache, error) {
 	// Metric used to keep track of each middleware execution duration.
 	metrics := tripperware.NewInstrumentMiddlewareMetrics(registerer)
 
 	queryRangeMiddleware := []tripperware.Middleware{NewLimitsMiddleware(limits, lookbackDelta)}
+	// The downsampling applies to the whole response, once merged and extended from the results cache.
+	queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("downsample", metrics), NewDownsampleMiddleware(limits))
 	if cfg.AlignQueriesWithStep {
 		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("step_align", metrics), StepAlignMiddleware)
 	}
+	// The cost of the whole query is checked before any part of it is executed.
+	queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("query_cost", metrics), tripperware.QueryCostMiddleware(limits, defaultSubQueryInterval))
 	if cfg.SplitQueriesByInterval != 0 {
 		staticIntervalFn := func(_ tripperware.Request) time.Duration { return cfg.SplitQueriesByInterval }
 		queryRangeMiddleware = append(queryRangeMiddleware, tripperware.InstrumentMiddleware("split_by_interval", metrics), SplitByIntervalMiddleware(staticIntervalFn, limits, prometheusCodec, registerer))
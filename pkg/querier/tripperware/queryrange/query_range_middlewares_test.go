@@ -20,8 +20,8 @@ import (
 )
 
 var (
-	PrometheusCodec        = NewPrometheusCodec(false)
-	ShardedPrometheusCodec = NewPrometheusCodec(false)
+	PrometheusCodec        = NewPrometheusCodec(false, false)
+	ShardedPrometheusCodec = NewPrometheusCodec(false, false)
 )
 
 func TestRoundTrip(t *testing.T) {
@@ -63,6 +63,7 @@ func TestRoundTrip(t *testing.T) {
 		PrometheusCodec,
 		ShardedPrometheusCodec,
 		5*time.Minute,
+		time.Minute,
 	)
 	require.NoError(t, err)
 
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 
@@ -755,6 +756,124 @@ func TestMergeAPIResponses(t *testing.T) {
 	}
 }
 
+func TestStreamingResponse(t *testing.T) {
+	t.Parallel()
+	stats := &tripperware.PrometheusResponseStats{
+		Samples: &tripperware.PrometheusResponseSamplesStats{
+			TotalQueryableSamples: 10,
+			TotalQueryableSamplesPerStep: []*tripperware.PrometheusResponseQueryableSamplesStatsPerStep{
+				{Value: 5, TimestampMs: 1536673680000},
+				{Value: 5, TimestampMs: 1536673780000},
+			},
+		},
+	}
+	series := func(name string) tripperware.SampleStream {
+		return tripperware.SampleStream{
+			Labels:  []cortexpb.LabelAdapter{{Name: "foo", Value: name}},
+			Samples: []cortexpb.Sample{{Value: 137, TimestampMs: 1536673680000}, {Value: 138, TimestampMs: 1536673780000}},
+		}
+	}
+
+	for name, res := range map[string]*PrometheusResponse{
+		"no series": {
+			Status: StatusSuccess,
+			Data:   PrometheusData{ResultType: model.ValMatrix.String(), Result: []tripperware.SampleStream{}},
+		},
+		"nil series": {
+			Status: StatusSuccess,
+			Data:   PrometheusData{ResultType: model.ValMatrix.String()},
+		},
+		"many series": {
+			Status:   StatusSuccess,
+			Data:     PrometheusData{ResultType: model.ValMatrix.String(), Result: []tripperware.SampleStream{series("a"), series("b"), series("c")}},
+			Warnings: []string{"warning", ""},
+		},
+		"stats": {
+			Status: StatusSuccess,
+			Data:   PrometheusData{ResultType: model.ValMatrix.String(), Result: []tripperware.SampleStream{series("a")}, Stats: stats},
+		},
+	} {
+		res := res
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			codec := NewPrometheusCodec(false, true)
+
+			// The streamed JSON is the same as the buffered one.
+			buffered, err := codec.EncodeResponse(context.Background(), res)
+			require.NoError(t, err)
+			expected, err := io.ReadAll(buffered.Body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/query_range", nil)
+			streamed, err := codec.EncodeStreamingResponse(context.Background(), req, res)
+			require.NoError(t, err)
+			require.Equal(t, "application/json", streamed.Header.Get("Content-Type"))
+			require.Equal(t, int64(-1), streamed.ContentLength)
+			actual, err := io.ReadAll(streamed.Body)
+			require.NoError(t, err)
+			require.NoError(t, streamed.Body.Close())
+			require.Equal(t, string(expected), string(actual))
+
+			// The streamed protobuf is the same as the marshaled response, without the headers.
+			req.Header.Set("Accept", "application/json;q=0.9, application/x-protobuf")
+			streamed, err = codec.EncodeStreamingResponse(context.Background(), req, res)
+			require.NoError(t, err)
+			require.Equal(t, "application/x-protobuf", streamed.Header.Get("Content-Type"))
+			actual, err = io.ReadAll(streamed.Body)
+			require.NoError(t, err)
+			require.NoError(t, streamed.Body.Close())
+
+			withoutHeaders := *res
+			withoutHeaders.Headers = nil
+			expected, err = withoutHeaders.Marshal()
+			require.NoError(t, err)
+			require.Equal(t, expected, actual)
+
+			var decoded PrometheusResponse
+			require.NoError(t, decoded.Unmarshal(actual))
+			require.Len(t, decoded.Data.Result, len(res.Data.Result))
+		})
+	}
+}
+
+func TestStreamingResponse_Disabled(t *testing.T) {
+	t.Parallel()
+	res := &PrometheusResponse{
+		Status: StatusSuccess,
+		Data:   PrometheusData{ResultType: model.ValMatrix.String(), Result: []tripperware.SampleStream{}},
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/query_range", nil)
+	req.Header.Set("Accept", "application/x-protobuf")
+
+	expected, err := PrometheusCodec.EncodeResponse(context.Background(), res)
+	require.NoError(t, err)
+	actual, err := PrometheusCodec.EncodeStreamingResponse(context.Background(), req, res)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+}
+
+func TestStreamingResponse_ShouldStopWritingWhenBodyIsClosed(t *testing.T) {
+	t.Parallel()
+	res := &PrometheusResponse{Status: StatusSuccess, Data: PrometheusData{ResultType: model.ValMatrix.String()}}
+	for i := 0; i < 10000; i++ {
+		res.Data.Result = append(res.Data.Result, tripperware.SampleStream{
+			Labels:  []cortexpb.LabelAdapter{{Name: "foo", Value: strconv.Itoa(i)}},
+			Samples: []cortexpb.Sample{{Value: 1, TimestampMs: 1}},
+		})
+	}
+
+	streamed, err := NewPrometheusCodec(false, true).EncodeStreamingResponse(context.Background(), httptest.NewRequest(http.MethodGet, "/api/v1/query_range", nil), res)
+	require.NoError(t, err)
+
+	// Read less than the whole response, then close the body.
+	_, err = io.ReadFull(streamed.Body, make([]byte, 10))
+	require.NoError(t, err)
+	require.NoError(t, streamed.Body.Close())
+
+	_, err = streamed.Body.Read(make([]byte, 10))
+	require.ErrorIs(t, err, io.ErrClosedPipe)
+}
+
 func TestGzippedResponse(t *testing.T) {
 	t.Parallel()
 	for _, tc := range []struct {
@@ -821,3 +940,50 @@ func mustParse(t *testing.T, response string) tripperware.Response {
 	require.NoError(t, json.Unmarshal([]byte(response), &resp))
 	return &resp
 }
+
+func TestMergeAggregatedResponse(t *testing.T) {
+	t.Parallel()
+	response := func(samples ...cortexpb.Sample) tripperware.Response {
+		return &PrometheusResponse{
+			Status: StatusSuccess,
+			Data: PrometheusData{
+				ResultType: matrix,
+				Result:     []tripperware.SampleStream{{Labels: []cortexpb.LabelAdapter{}, Samples: samples}},
+			},
+		}
+	}
+
+	// Sum the samples of the shards by timestamp.
+	merge := func(shards ...[]tripperware.SampleStream) ([]tripperware.SampleStream, error) {
+		require.Len(t, shards, 3)
+		sums := map[int64]float64{}
+		for _, streams := range shards {
+			for _, stream := range streams {
+				for _, sample := range stream.Samples {
+					sums[sample.TimestampMs] += sample.Value
+				}
+			}
+		}
+		return []tripperware.SampleStream{{
+			Labels:  []cortexpb.LabelAdapter{},
+			Samples: []cortexpb.Sample{{TimestampMs: 0, Value: sums[0]}, {TimestampMs: 1000, Value: sums[1000]}},
+		}}, nil
+	}
+
+	merged, err := PrometheusCodec.MergeAggregatedResponse(context.Background(), &PrometheusRequest{}, merge,
+		response(cortexpb.Sample{TimestampMs: 0, Value: 1}, cortexpb.Sample{TimestampMs: 1000, Value: 2}),
+		response(cortexpb.Sample{TimestampMs: 0, Value: 3}),
+		response(cortexpb.Sample{TimestampMs: 1000, Value: 4}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, &PrometheusResponse{
+		Status: StatusSuccess,
+		Data: PrometheusData{
+			ResultType: matrix,
+			Result: []tripperware.SampleStream{{
+				Labels:  []cortexpb.LabelAdapter{},
+				Samples: []cortexpb.Sample{{TimestampMs: 0, Value: 4}, {TimestampMs: 1000, Value: 6}},
+			}},
+		},
+	}, merged)
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
 	"github.com/cortexproject/cortex/pkg/querier"
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
@@ -231,13 +232,16 @@ func (s resultsCache) Do(ctx context.Context, r tripperware.Request) (tripperwar
 	maxCacheTime := int64(model.Now().Add(-maxCacheFreshness))
 	if r.GetStart() > maxCacheTime {
 		level.Debug(util_log.WithContext(ctx, s.logger)).Log("msg", "cache miss", "start", r.GetStart(), "spanID", jaegerSpanID(ctx))
+		querier_stats.FromContext(ctx).AddResultsCacheMisses(1)
 		return s.next.Do(ctx, r)
 	}
 
 	cached, ok := s.get(ctx, key)
 	if ok {
+		querier_stats.FromContext(ctx).AddResultsCacheHits(1)
 		response, extents, err = s.handleHit(ctx, r, cached, maxCacheTime)
 	} else {
+		querier_stats.FromContext(ctx).AddResultsCacheMisses(1)
 		response, extents, err = s.handleMiss(ctx, r, maxCacheTime)
 	}
 
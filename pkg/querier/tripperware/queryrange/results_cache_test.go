@@ -16,6 +16,7 @@ import (
 
 	"github.com/cortexproject/cortex/pkg/chunk/cache"
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	querier_stats "github.com/cortexproject/cortex/pkg/querier/stats"
 	"github.com/cortexproject/cortex/pkg/querier/tripperware"
 	"github.com/cortexproject/cortex/pkg/util/flagext"
 )
@@ -985,7 +986,7 @@ func TestResultsCache(t *testing.T) {
 		calls++
 		return parsedResponse, nil
 	}))
-	ctx := user.InjectOrgID(context.Background(), "1")
+	stats, ctx := querier_stats.ContextWithEmptyStats(user.InjectOrgID(context.Background(), "1"))
 	resp, err := rc.Do(ctx, parsedRequest)
 	require.NoError(t, err)
 	require.Equal(t, 1, calls)
@@ -1002,6 +1003,10 @@ func TestResultsCache(t *testing.T) {
 	_, err = rc.Do(ctx, req)
 	require.NoError(t, err)
 	require.Equal(t, 2, calls)
+
+	// The cache lookups are tracked in the query stats.
+	require.Equal(t, uint64(2), stats.LoadResultsCacheHits())
+	require.Equal(t, uint64(1), stats.LoadResultsCacheMisses())
 }
 
 func TestResultsCacheRecent(t *testing.T) {
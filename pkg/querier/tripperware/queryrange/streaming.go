@@ -0,0 +1,203 @@
+package queryrange
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/opentracing/opentracing-go"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/querier/tripperware"
+)
+
+const (
+	applicationProtobuf = "application/x-protobuf"
+
+	// Size of the buffer used to batch the writes of the streamed responses.
+	streamingBufferSize = 32 * 1024
+)
+
+// EncodeStreamingResponse implements tripperware.StreamingCodec. The response is encoded one series
+// at a time while the body is read, so the encoded response is never fully buffered. The response is
+// encoded in protobuf if the client accepts it, and in JSON otherwise. If the streaming of the responses
+// is disabled, the response is encoded like EncodeResponse does.
+func (c prometheusCodec) EncodeStreamingResponse(ctx context.Context, r *http.Request, res tripperware.Response) (*http.Response, error) {
+	if !c.streamResponses {
+		return c.EncodeResponse(ctx, res)
+	}
+
+	sp, _ := opentracing.StartSpanFromContext(ctx, "APIResponse.ToStreamingHTTPResponse")
+	defer sp.Finish()
+
+	a, ok := res.(*PrometheusResponse)
+	if !ok {
+		return nil, httpgrpc.Errorf(http.StatusInternalServerError, "invalid response format")
+	}
+
+	sp.LogFields(otlog.Int("series", len(a.Data.Result)))
+
+	contentType, write := "application/json", writeJSONResponse
+	if acceptsProtobuf(r) {
+		contentType, write = applicationProtobuf, writeProtobufResponse
+	}
+
+	resp := http.Response{
+		Header: http.Header{
+			"Content-Type": []string{contentType},
+		},
+		Body:       streamResponse(a, write),
+		StatusCode: http.StatusOK,
+		// The length of the response is unknown until it has been fully encoded.
+		ContentLength: -1,
+	}
+	return &resp, nil
+}
+
+// streamResponse returns a body from which the response is read while it's written by a goroutine.
+// The goroutine terminates once the response has been fully read or the body has been closed.
+func streamResponse(a *PrometheusResponse, write func(io.Writer, *PrometheusResponse) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		w := bufio.NewWriterSize(pw, streamingBufferSize)
+		err := write(w, a)
+		if err == nil {
+			err = w.Flush()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+// acceptsProtobuf returns whether the client accepts protobuf responses.
+func acceptsProtobuf(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaType := range strings.Split(accept, ",") {
+			if mt, _, err := mime.ParseMediaType(mediaType); err == nil && mt == applicationProtobuf {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writeJSONResponse writes the same JSON as EncodeResponse, encoding one series at a time.
+func writeJSONResponse(w io.Writer, a *PrometheusResponse) error {
+	// The response is encoded without its series, which are then written in place of the empty result.
+	envelope := *a
+	if envelope.Data.Result != nil {
+		envelope.Data.Result = []tripperware.SampleStream{}
+	}
+	b, err := json.Marshal(&envelope)
+	if err != nil {
+		return err
+	}
+
+	head, tail, found := bytes.Cut(b, []byte(`"result":[]`))
+	if !found {
+		_, err := w.Write(b)
+		return err
+	}
+
+	if _, err := w.Write(head); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `"result":[`); err != nil {
+		return err
+	}
+	for i := range a.Data.Result {
+		if i > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		s, err := json.Marshal(&a.Data.Result[i])
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(s); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, "]"); err != nil {
+		return err
+	}
+	_, err = w.Write(tail)
+	return err
+}
+
+// writeProtobufResponse writes the response in the same protobuf wire format as PrometheusResponse.Marshal(),
+// encoding one series at a time. The headers are not written, because they're not part of the API response.
+func writeProtobufResponse(w io.Writer, a *PrometheusResponse) error {
+	pw := &protobufWriter{w: w}
+	pw.writeString(1, a.Status)
+
+	// The data is a non-nullable message, so it's always written.
+	pw.writeFieldHeader(2, a.Data.Size())
+	pw.writeString(1, a.Data.ResultType)
+	var buf []byte
+	for i := range a.Data.Result {
+		size := a.Data.Result[i].Size()
+		if cap(buf) < size {
+			buf = make([]byte, size)
+		}
+		buf = buf[:size]
+		if _, err := a.Data.Result[i].MarshalToSizedBuffer(buf); err != nil {
+			return err
+		}
+		pw.writeBytes(2, buf)
+	}
+	if a.Data.Stats != nil {
+		b, err := a.Data.Stats.Marshal()
+		if err != nil {
+			return err
+		}
+		pw.writeBytes(3, b)
+	}
+
+	pw.writeString(3, a.ErrorType)
+	pw.writeString(4, a.Error)
+	for _, warning := range a.Warnings {
+		pw.writeBytes(6, []byte(warning))
+	}
+	return pw.err
+}
+
+// protobufWriter writes length-delimited protobuf fields, keeping the first write error.
+type protobufWriter struct {
+	w   io.Writer
+	buf [2 * binary.MaxVarintLen64]byte
+	err error
+}
+
+func (p *protobufWriter) writeFieldHeader(field, size int) {
+	if p.err != nil {
+		return
+	}
+	// All the written fields have the length-delimited wire type.
+	n := binary.PutUvarint(p.buf[:], uint64(field<<3|2))
+	n += binary.PutUvarint(p.buf[n:], uint64(size))
+	_, p.err = p.w.Write(p.buf[:n])
+}
+
+func (p *protobufWriter) writeBytes(field int, b []byte) {
+	p.writeFieldHeader(field, len(b))
+	if p.err != nil {
+		return
+	}
+	_, p.err = p.w.Write(b)
+}
+
+// writeString writes a proto3 optional string field, which is omitted if empty.
+func (p *protobufWriter) writeString(field int, s string) {
+	if s == "" {
+		return
+	}
+	p.writeBytes(field, []byte(s))
+}
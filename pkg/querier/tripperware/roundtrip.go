@@ -127,6 +127,7 @@ func NewQueryTripperware(
 		if len(queryRangeMiddleware) > 0 || len(instantRangeMiddleware) > 0 {
 			queryrange := NewRoundTripper(next, queryRangeCodec, forwardHeaders, queryRangeMiddleware...)
 			instantQuery := NewRoundTripper(next, instantQueryCodec, forwardHeaders, instantRangeMiddleware...)
+			queryCostCardinality := newQueryCostCardinality(next)
 			return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 				isQuery := strings.HasSuffix(r.URL.Path, "/query")
 				isQueryRange := strings.HasSuffix(r.URL.Path, "/query_range")
@@ -170,9 +171,14 @@ func NewQueryTripperware(
 					reqStats.SetDataSelectMinTime(minTime)
 
 					if limits != nil && limits.QueryPriority(userStr).Enabled {
-						priority := GetPriority(query, minTime, maxTime, now, limits.QueryPriority(userStr))
+						priority := GetPriority(query, r.Header.Get("User-Agent"), minTime, maxTime, now, limits.QueryPriority(userStr))
 						reqStats.SetPriority(priority)
 					}
+
+					// The cost of the query is checked by the QueryCostMiddleware, before the split by interval.
+					if !queryCostOverridden(r, limits, tenantIDs) {
+						r = r.WithContext(contextWithQueryCostCardinality(r, queryCostCardinality))
+					}
 				}
 
 				if isQueryRange {
@@ -216,6 +222,9 @@ func (q roundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
 		return nil, err
 	}
 
+	if codec, ok := q.codec.(StreamingCodec); ok {
+		return codec.EncodeStreamingResponse(r.Context(), r, response)
+	}
 	return q.codec.EncodeResponse(r.Context(), response)
 }
 
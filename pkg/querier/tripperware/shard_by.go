@@ -6,6 +6,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
 	"github.com/thanos-io/thanos/pkg/querysharding"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/weaveworks/common/httpgrpc"
@@ -65,6 +66,16 @@ func (s shardBy) Do(ctx context.Context, r Request) (Response, error) {
 	)
 
 	if !analysis.IsShardable() {
+		if merger, ok := s.merger.(AggregationMerger); ok && allTenantsEnabled(tenantIDs, s.limits.QueryVerticalShardBySeriesHash) {
+			approximateTopK := allTenantsEnabled(tenantIDs, s.limits.QueryVerticalShardApproximateTopK)
+			if plan, ok := planSeriesHashSharding(r.GetQuery(), approximateTopK); ok {
+				stats.AddExtraFields(
+					"shard_by.series_hash", true,
+					"shard_by.series_hash_approximate", plan.approximate,
+				)
+				return s.doSeriesHashSharded(ctx, logger, numShards, r, plan, merger)
+			}
+		}
 		return s.next.Do(ctx, r)
 	}
 
@@ -102,3 +113,49 @@ func (s shardBy) shardQuery(l log.Logger, numShards int, r Request, analysis que
 
 	return reqs
 }
+
+// allTenantsEnabled returns whether the per-tenant feature is enabled for all the tenants.
+func allTenantsEnabled(tenantIDs []string, enabled func(userID string) bool) bool {
+	for _, userID := range tenantIDs {
+		if !enabled(userID) {
+			return false
+		}
+	}
+	return true
+}
+
+// doSeriesHashSharded runs the query of the plan sharded by series hash, and merges the responses
+// of the shards with the merge function of the plan. The query is run unsharded if the responses
+// of the shards can't be merged.
+func (s shardBy) doSeriesHashSharded(ctx context.Context, logger log.Logger, numShards int, r Request, plan seriesHashShardingPlan, merger AggregationMerger) (Response, error) {
+	reqs := make([]Request, numShards)
+	for i := 0; i < numShards; i++ {
+		// Without sharding labels, the series are sharded by the hash of all their labels.
+		q, err := cquerysharding.InjectShardingInfo(plan.query, &storepb.ShardInfo{
+			TotalShards: int64(numShards),
+			ShardIndex:  int64(i),
+		})
+		if err != nil {
+			level.Warn(logger).Log("msg", "error sharding query by series hash", "q", r.GetQuery(), "err", err)
+			return s.next.Do(ctx, r)
+		}
+		reqs[i] = r.WithQuery(q)
+	}
+
+	reqResps, err := DoRequests(ctx, s.next, reqs, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	resps := make([]Response, 0, len(reqResps))
+	for _, reqResp := range reqResps {
+		resps = append(resps, reqResp.Response)
+	}
+
+	resp, err := merger.MergeAggregatedResponse(ctx, r, plan.merge, resps...)
+	if errors.Is(err, errSeriesHashShardedHistograms) {
+		level.Debug(logger).Log("msg", "running the query unsharded", "q", r.GetQuery(), "err", err)
+		return s.next.Do(ctx, r)
+	}
+	return resp, err
+}
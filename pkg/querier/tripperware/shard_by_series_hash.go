@@ -0,0 +1,466 @@
+package tripperware
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+var errSeriesHashShardedHistograms = errors.New("the native histograms of a query sharded by series hash can't be aggregated")
+
+// SampleStreamsMerger merges the sample streams returned by the shards of a query sharded by
+// series hash into the result of the query.
+type SampleStreamsMerger func(shards ...[]SampleStream) ([]SampleStream, error)
+
+// AggregationMerger is a Merger which can merge the responses of the shards of a query sharded by
+// series hash, by applying the outer operation of the query to the results of the shards.
+type AggregationMerger interface {
+	Merger
+
+	// MergeAggregatedResponse merges the responses of the shards of a query sharded by series
+	// hash, merging the sample streams of the responses with the merge function.
+	MergeAggregatedResponse(ctx context.Context, req Request, merge SampleStreamsMerger, responses ...Response) (Response, error)
+}
+
+// seriesHashShardingPlan describes how a query is sharded by series hash.
+type seriesHashShardingPlan struct {
+	// The query run by each shard over its partition of the series.
+	query string
+
+	// The function merging the results of the shards.
+	merge SampleStreamsMerger
+
+	// Whether the merged result is an approximation of the query result.
+	approximate bool
+}
+
+// seriesHashUnshardableFunctions are the functions whose result depends on several series or on
+// the absence of series, so that their input can't be split across series hash partitions.
+var seriesHashUnshardableFunctions = map[string]struct{}{
+	"absent":             {},
+	"absent_over_time":   {},
+	"histogram_quantile": {},
+	"info":               {},
+	"scalar":             {},
+	"sort":               {},
+	"sort_by_label":      {},
+	"sort_by_label_desc": {},
+	"sort_desc":          {},
+	"vector":             {},
+}
+
+// planSeriesHashSharding returns how the query can be sharded by series hash, if it can:
+//   - the sum, min, max, count and group aggregations of an expression evaluated independently
+//     for each series are evaluated by each shard and aggregated again;
+//   - the topk and bottomk of such an expression are evaluated by each shard, and the k series
+//     are selected again among the ones returned by the shards;
+//   - the histogram_quantile of the sum by le of such an expression is computed from the sum of
+//     the buckets returned by the shards;
+//   - if approximateTopK is true, the topk and bottomk of the sum of such an expression are
+//     approximated by summing the k partial sums returned by each shard.
+func planSeriesHashSharding(query string, approximateTopK bool) (seriesHashShardingPlan, bool) {
+	expr, err := parser.ParseExpr(query)
+	if err != nil {
+		return seriesHashShardingPlan{}, false
+	}
+	expr = unwrapParens(expr)
+
+	switch e := expr.(type) {
+	case *parser.AggregateExpr:
+		switch e.Op {
+		case parser.SUM, parser.MIN, parser.MAX, parser.COUNT, parser.GROUP:
+			if !isSeriesHashShardable(e.Expr) {
+				return seriesHashShardingPlan{}, false
+			}
+			op := e.Op
+			return seriesHashShardingPlan{
+				query: query,
+				merge: func(shards ...[]SampleStream) ([]SampleStream, error) {
+					return aggregateSampleStreams(op, shards...)
+				},
+			}, true
+
+		case parser.TOPK, parser.BOTTOMK:
+			k, ok := numberLiteral(e.Param)
+			if !ok || k < 1 {
+				return seriesHashShardingPlan{}, false
+			}
+			op, grouping, without := e.Op, e.Grouping, e.Without
+
+			if isSeriesHashShardable(e.Expr) {
+				// Each series is in a single shard, so the k series of each group are in the
+				// k series of the group returned by the shards.
+				return seriesHashShardingPlan{
+					query: query,
+					merge: func(shards ...[]SampleStream) ([]SampleStream, error) {
+						return selectSampleStreams(op, int(k), grouping, without, concatSampleStreams(shards)), nil
+					},
+				}, true
+			}
+
+			inner, ok := unwrapParens(e.Expr).(*parser.AggregateExpr)
+			if !approximateTopK || !ok || inner.Op != parser.SUM || !isSeriesHashShardable(inner.Expr) {
+				return seriesHashShardingPlan{}, false
+			}
+			// The series whose partial sum isn't in the k series of a shard are missing from
+			// the sum, so the merged result is approximate.
+			return seriesHashShardingPlan{
+				query: query,
+				merge: func(shards ...[]SampleStream) ([]SampleStream, error) {
+					sums, err := aggregateSampleStreams(parser.SUM, shards...)
+					if err != nil {
+						return nil, err
+					}
+					return selectSampleStreams(op, int(k), grouping, without, sums), nil
+				},
+				approximate: true,
+			}, true
+		}
+
+	case *parser.Call:
+		if e.Func.Name != "histogram_quantile" {
+			return seriesHashShardingPlan{}, false
+		}
+		q, ok := numberLiteral(e.Args[0])
+		if !ok {
+			return seriesHashShardingPlan{}, false
+		}
+		inner, ok := unwrapParens(e.Args[1]).(*parser.AggregateExpr)
+		if !ok || inner.Op != parser.SUM || inner.Without || !util.StringsContain(inner.Grouping, bucketLabel) || !isSeriesHashShardable(inner.Expr) {
+			return seriesHashShardingPlan{}, false
+		}
+		// The shards run the sum of the buckets, and the quantile is computed from their sum.
+		return seriesHashShardingPlan{
+			query: inner.String(),
+			merge: func(shards ...[]SampleStream) ([]SampleStream, error) {
+				buckets, err := aggregateSampleStreams(parser.SUM, shards...)
+				if err != nil {
+					return nil, err
+				}
+				return bucketQuantileSampleStreams(q, buckets), nil
+			},
+		}, true
+	}
+
+	return seriesHashShardingPlan{}, false
+}
+
+// isSeriesHashShardable returns whether the expression is evaluated independently for each series,
+// i.e. without aggregation, binary operation between vectors or function over several series.
+func isSeriesHashShardable(expr parser.Expr) bool {
+	shardable, hasSelector := true, false
+	parser.Inspect(expr, func(node parser.Node, _ []parser.Node) error {
+		switch n := node.(type) {
+		case *parser.AggregateExpr:
+			shardable = false
+		case *parser.BinaryExpr:
+			if n.LHS.Type() == parser.ValueTypeVector && n.RHS.Type() == parser.ValueTypeVector {
+				shardable = false
+			}
+		case *parser.Call:
+			if _, ok := seriesHashUnshardableFunctions[n.Func.Name]; ok {
+				shardable = false
+			}
+		case *parser.VectorSelector:
+			hasSelector = true
+		}
+		return nil
+	})
+	return shardable && hasSelector
+}
+
+func unwrapParens(expr parser.Expr) parser.Expr {
+	for {
+		paren, ok := expr.(*parser.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.Expr
+	}
+}
+
+func numberLiteral(expr parser.Expr) (float64, bool) {
+	n, ok := unwrapParens(expr).(*parser.NumberLiteral)
+	if !ok {
+		return 0, false
+	}
+	return n.Val, true
+}
+
+// aggregateSampleStreams merges the sample streams of the shards of a query sharded by series hash,
+// aggregating the samples with the same labels and timestamp with the aggregation op. The series are
+// sorted by labels and their samples by timestamp.
+func aggregateSampleStreams(op parser.ItemType, shards ...[]SampleStream) ([]SampleStream, error) {
+	type series struct {
+		labels  []cortexpb.LabelAdapter
+		samples map[int64]float64
+	}
+
+	output := map[string]*series{}
+	buf := make([]byte, 0, 1024)
+	for _, streams := range shards {
+		for _, stream := range streams {
+			if len(stream.Histograms) > 0 {
+				return nil, errSeriesHashShardedHistograms
+			}
+
+			key := string(cortexpb.FromLabelAdaptersToLabels(stream.Labels).Bytes(buf))
+			s, ok := output[key]
+			if !ok {
+				s = &series{labels: stream.Labels, samples: make(map[int64]float64, len(stream.Samples))}
+				output[key] = s
+			}
+			for _, sample := range stream.Samples {
+				if existing, ok := s.samples[sample.TimestampMs]; ok {
+					s.samples[sample.TimestampMs] = aggregateSeriesHashShardedValues(op, existing, sample.Value)
+				} else {
+					s.samples[sample.TimestampMs] = sample.Value
+				}
+			}
+		}
+	}
+
+	result := make([]SampleStream, 0, len(output))
+	for _, s := range output {
+		result = append(result, newSampleStream(s.labels, s.samples))
+	}
+	sortSampleStreamsByLabels(result)
+	return result, nil
+}
+
+// aggregateSeriesHashShardedValues aggregates the values of the same output series returned by two
+// shards, consistently with the PromQL aggregation op.
+func aggregateSeriesHashShardedValues(op parser.ItemType, a, b float64) float64 {
+	switch op {
+	case parser.MIN:
+		if b < a || math.IsNaN(a) {
+			return b
+		}
+		return a
+	case parser.MAX:
+		if b > a || math.IsNaN(a) {
+			return b
+		}
+		return a
+	case parser.GROUP:
+		return 1
+	default:
+		// The sum of the counts of the shards is the count of the whole query.
+		return a + b
+	}
+}
+
+// selectSampleStreams selects, at each timestamp, the k samples with the highest (topk) or lowest
+// (bottomk) value of each group of series. The NaN values are selected last.
+func selectSampleStreams(op parser.ItemType, k int, grouping []string, without bool, streams []SampleStream) []SampleStream {
+	type candidate struct {
+		series int
+		value  float64
+	}
+
+	// The grouping labels must be sorted to build the group keys.
+	grouping = append([]string{}, grouping...)
+	if without {
+		grouping = append(grouping, labels.MetricName)
+	}
+	sort.Strings(grouping)
+
+	candidates := map[string]map[int64][]candidate{}
+	buf := make([]byte, 0, 1024)
+	for i, stream := range streams {
+		lbls := cortexpb.FromLabelAdaptersToLabels(stream.Labels)
+		var key string
+		if without {
+			key = string(lbls.BytesWithoutLabels(buf, grouping...))
+		} else {
+			key = string(lbls.BytesWithLabels(buf, grouping...))
+		}
+		if candidates[key] == nil {
+			candidates[key] = map[int64][]candidate{}
+		}
+		for _, sample := range stream.Samples {
+			candidates[key][sample.TimestampMs] = append(candidates[key][sample.TimestampMs], candidate{series: i, value: sample.Value})
+		}
+	}
+
+	less := func(a, b float64) bool {
+		if math.IsNaN(b) {
+			return !math.IsNaN(a)
+		}
+		if op == parser.BOTTOMK {
+			return a < b
+		}
+		return a > b
+	}
+
+	selected := make([]map[int64]float64, len(streams))
+	for _, byTimestamp := range candidates {
+		for ts, cs := range byTimestamp {
+			sort.SliceStable(cs, func(i, j int) bool { return less(cs[i].value, cs[j].value) })
+			for _, c := range cs[:min(k, len(cs))] {
+				if selected[c.series] == nil {
+					selected[c.series] = map[int64]float64{}
+				}
+				selected[c.series][ts] = c.value
+			}
+		}
+	}
+
+	result := make([]SampleStream, 0, len(streams))
+	for i, samples := range selected {
+		if samples != nil {
+			result = append(result, newSampleStream(streams[i].Labels, samples))
+		}
+	}
+	sortSampleStreamsByLabels(result)
+
+	// The series of an instant query are sorted by value, like in PromQL.
+	instant := true
+	for _, stream := range result {
+		instant = instant && len(stream.Samples) == 1 && stream.Samples[0].TimestampMs == result[0].Samples[0].TimestampMs
+	}
+	if instant {
+		sort.SliceStable(result, func(i, j int) bool { return less(result[i].Samples[0].Value, result[j].Samples[0].Value) })
+	}
+	return result
+}
+
+const bucketLabel = "le"
+
+// bucketQuantileSampleStreams computes, at each timestamp, the q-quantile of the classic histograms
+// made of the bucket series with the same labels but the le label, like the PromQL histogram_quantile.
+func bucketQuantileSampleStreams(q float64, streams []SampleStream) []SampleStream {
+	type bucket struct {
+		upperBound float64
+		count      float64
+	}
+	type histogram struct {
+		labels  []cortexpb.LabelAdapter
+		buckets map[int64][]bucket
+	}
+
+	histograms := map[string]*histogram{}
+	buf := make([]byte, 0, 1024)
+	for _, stream := range streams {
+		lbls := cortexpb.FromLabelAdaptersToLabels(stream.Labels)
+		upperBound, err := strconv.ParseFloat(lbls.Get(bucketLabel), 64)
+		if err != nil {
+			// The series without a valid le label are ignored, like in PromQL.
+			continue
+		}
+
+		key := string(lbls.BytesWithoutLabels(buf, bucketLabel))
+		h, ok := histograms[key]
+		if !ok {
+			h = &histogram{
+				labels:  cortexpb.FromLabelsToLabelAdapters(labels.NewBuilder(lbls).Del(bucketLabel, labels.MetricName).Labels()),
+				buckets: map[int64][]bucket{},
+			}
+			histograms[key] = h
+		}
+		for _, sample := range stream.Samples {
+			h.buckets[sample.TimestampMs] = append(h.buckets[sample.TimestampMs], bucket{upperBound: upperBound, count: sample.Value})
+		}
+	}
+
+	quantile := func(buckets []bucket) float64 {
+		if math.IsNaN(q) {
+			return math.NaN()
+		}
+		if q < 0 {
+			return math.Inf(-1)
+		}
+		if q > 1 {
+			return math.Inf(+1)
+		}
+		sort.Slice(buckets, func(i, j int) bool { return buckets[i].upperBound < buckets[j].upperBound })
+		if !math.IsInf(buckets[len(buckets)-1].upperBound, +1) {
+			return math.NaN()
+		}
+
+		// Coalesce the buckets with the same upper bound, and force the monotonicity of the counts.
+		coalesced := buckets[:1]
+		for _, b := range buckets[1:] {
+			last := &coalesced[len(coalesced)-1]
+			if b.upperBound == last.upperBound {
+				last.count += b.count
+				continue
+			}
+			coalesced = append(coalesced, b)
+		}
+		for i := 1; i < len(coalesced); i++ {
+			coalesced[i].count = math.Max(coalesced[i].count, coalesced[i-1].count)
+		}
+		buckets = coalesced
+
+		if len(buckets) < 2 {
+			return math.NaN()
+		}
+		observations := buckets[len(buckets)-1].count
+		if observations == 0 {
+			return math.NaN()
+		}
+		rank := q * observations
+		b := sort.Search(len(buckets)-1, func(i int) bool { return buckets[i].count >= rank })
+		if b == len(buckets)-1 {
+			return buckets[len(buckets)-2].upperBound
+		}
+		if b == 0 && buckets[0].upperBound <= 0 {
+			return buckets[0].upperBound
+		}
+
+		var bucketStart float64
+		bucketEnd, count := buckets[b].upperBound, buckets[b].count
+		if b > 0 {
+			bucketStart = buckets[b-1].upperBound
+			count -= buckets[b-1].count
+			rank -= buckets[b-1].count
+		}
+		return bucketStart + (bucketEnd-bucketStart)*(rank/count)
+	}
+
+	result := make([]SampleStream, 0, len(histograms))
+	for _, h := range histograms {
+		samples := make(map[int64]float64, len(h.buckets))
+		for ts, buckets := range h.buckets {
+			samples[ts] = quantile(buckets)
+		}
+		result = append(result, newSampleStream(h.labels, samples))
+	}
+	sortSampleStreamsByLabels(result)
+	return result
+}
+
+func concatSampleStreams(shards [][]SampleStream) []SampleStream {
+	var result []SampleStream
+	for _, streams := range shards {
+		result = append(result, streams...)
+	}
+	return result
+}
+
+// newSampleStream returns a sample stream with the samples sorted by timestamp.
+func newSampleStream(lbls []cortexpb.LabelAdapter, samples map[int64]float64) SampleStream {
+	stream := SampleStream{Labels: lbls, Samples: make([]cortexpb.Sample, 0, len(samples))}
+	for ts, v := range samples {
+		stream.Samples = append(stream.Samples, cortexpb.Sample{TimestampMs: ts, Value: v})
+	}
+	sort.Slice(stream.Samples, func(i, j int) bool {
+		return stream.Samples[i].TimestampMs < stream.Samples[j].TimestampMs
+	})
+	return stream
+}
+
+func sortSampleStreamsByLabels(streams []SampleStream) {
+	sort.Slice(streams, func(i, j int) bool {
+		return labels.Compare(cortexpb.FromLabelAdaptersToLabels(streams[i].Labels), cortexpb.FromLabelAdaptersToLabels(streams[j].Labels)) < 0
+	})
+}
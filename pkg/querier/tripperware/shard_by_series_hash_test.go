@@ -0,0 +1,354 @@
+package tripperware
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/querysharding"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+func TestPlanSeriesHashSharding(t *testing.T) {
+	for _, tc := range []struct {
+		query           string
+		approximateTopK bool
+		shardable       bool
+		shardQuery      string
+		approximate     bool
+	}{
+		{query: `sum(rate(http_requests_total[5m]))`, shardable: true},
+		{query: `(max(http_requests_total))`, shardable: true},
+		{query: `min(http_requests_total{job="api"} offset 5m)`, shardable: true},
+		{query: `count(up == 1)`, shardable: true},
+		{query: `group(label_replace(up, "a", "$1", "job", "(.*)"))`, shardable: true},
+		{query: `topk(5, rate(http_requests_total[5m]))`, shardable: true},
+		{query: `bottomk by (job) (5, http_requests_total)`, shardable: true},
+		{query: `topk(5, sum by (job) (rate(http_requests_total[5m])))`},
+		{query: `topk(5, sum by (job) (rate(http_requests_total[5m])))`, approximateTopK: true, shardable: true, approximate: true},
+		{query: `topk(5, max by (job) (http_requests_total))`, approximateTopK: true},
+		{query: `topk(scalar(up), http_requests_total)`},
+		{
+			query:      `histogram_quantile(0.9, sum by (le) (rate(http_request_duration_seconds_bucket[5m])))`,
+			shardable:  true,
+			shardQuery: `sum by (le) (rate(http_request_duration_seconds_bucket[5m]))`,
+		},
+		{query: `histogram_quantile(0.9, sum without (le) (rate(http_request_duration_seconds_bucket[5m])))`},
+		{query: `histogram_quantile(0.9, sum(rate(http_request_duration_seconds_bucket[5m])))`},
+		{query: `histogram_quantile(0.9, rate(http_request_duration_seconds_bucket[5m]))`},
+		{query: `sum(rate(http_requests_total[5m])) * 2`},
+		{query: `avg(http_requests_total)`},
+		{query: `sum(sum by (job) (http_requests_total))`},
+		{query: `sum(http_requests_total / http_requests_duration_seconds)`},
+		{query: `sum(absent(http_requests_total))`},
+		{query: `sum(vector(1))`},
+		{query: `http_requests_total`},
+		{query: `sum(http_requests_total`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			plan, shardable := planSeriesHashSharding(tc.query, tc.approximateTopK)
+			require.Equal(t, tc.shardable, shardable)
+			if !tc.shardable {
+				return
+			}
+
+			expectedShardQuery := tc.query
+			if tc.shardQuery != "" {
+				expectedShardQuery = tc.shardQuery
+			}
+			require.Equal(t, expectedShardQuery, plan.query)
+			require.Equal(t, tc.approximate, plan.approximate)
+			require.NotNil(t, plan.merge)
+		})
+	}
+}
+
+func TestAggregateSampleStreams(t *testing.T) {
+	series := func(job string, samples ...cortexpb.Sample) SampleStream {
+		return SampleStream{Labels: []cortexpb.LabelAdapter{{Name: "job", Value: job}}, Samples: samples}
+	}
+	shards := [][]SampleStream{
+		{
+			series("b", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: math.NaN()}),
+			series("a", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+		},
+		{
+			series("b", cortexpb.Sample{TimestampMs: 1, Value: 3}, cortexpb.Sample{TimestampMs: 2, Value: 5}),
+		},
+		{
+			series("b", cortexpb.Sample{TimestampMs: 3, Value: 2}),
+		},
+	}
+
+	for _, tc := range []struct {
+		op       parser.ItemType
+		expected []SampleStream
+	}{
+		{
+			op: parser.SUM,
+			expected: []SampleStream{
+				series("a", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+				series("b", cortexpb.Sample{TimestampMs: 1, Value: 4}, cortexpb.Sample{TimestampMs: 2, Value: math.NaN()}, cortexpb.Sample{TimestampMs: 3, Value: 2}),
+			},
+		},
+		{
+			op: parser.MIN,
+			expected: []SampleStream{
+				series("a", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+				series("b", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: 5}, cortexpb.Sample{TimestampMs: 3, Value: 2}),
+			},
+		},
+		{
+			op: parser.MAX,
+			expected: []SampleStream{
+				series("a", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+				series("b", cortexpb.Sample{TimestampMs: 1, Value: 3}, cortexpb.Sample{TimestampMs: 2, Value: 5}, cortexpb.Sample{TimestampMs: 3, Value: 2}),
+			},
+		},
+		{
+			op: parser.GROUP,
+			expected: []SampleStream{
+				series("a", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+				series("b", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: 1}, cortexpb.Sample{TimestampMs: 3, Value: 2}),
+			},
+		},
+	} {
+		t.Run(tc.op.String(), func(t *testing.T) {
+			actual, err := aggregateSampleStreams(tc.op, shards...)
+			require.NoError(t, err)
+			requireSampleStreamsEqual(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("histograms", func(t *testing.T) {
+		_, err := aggregateSampleStreams(parser.SUM, []SampleStream{{Histograms: []SampleHistogramPair{{TimestampMs: 1}}}})
+		require.ErrorIs(t, err, errSeriesHashShardedHistograms)
+	})
+}
+
+func TestSelectSampleStreams(t *testing.T) {
+	series := func(name, job, instance string, samples ...cortexpb.Sample) SampleStream {
+		return SampleStream{
+			Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: name}, {Name: "instance", Value: instance}, {Name: "job", Value: job}},
+			Samples: samples,
+		}
+	}
+	streams := []SampleStream{
+		series("up", "a", "1", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: 5}),
+		series("up", "a", "2", cortexpb.Sample{TimestampMs: 1, Value: 3}, cortexpb.Sample{TimestampMs: 2, Value: math.NaN()}),
+		series("up", "b", "1", cortexpb.Sample{TimestampMs: 1, Value: 2}),
+		series("down", "b", "2", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+	}
+
+	for _, tc := range []struct {
+		name     string
+		op       parser.ItemType
+		grouping []string
+		without  bool
+		expected []SampleStream
+	}{
+		{
+			name: "topk",
+			op:   parser.TOPK,
+			expected: []SampleStream{
+				series("down", "b", "2", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+				series("up", "a", "1", cortexpb.Sample{TimestampMs: 2, Value: 5}),
+			},
+		},
+		{
+			// The NaN values are selected last.
+			name: "bottomk",
+			op:   parser.BOTTOMK,
+			expected: []SampleStream{
+				series("up", "a", "1", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: 5}),
+			},
+		},
+		{
+			name:     "topk by job",
+			op:       parser.TOPK,
+			grouping: []string{"job"},
+			expected: []SampleStream{
+				series("down", "b", "2", cortexpb.Sample{TimestampMs: 1, Value: 4}),
+				series("up", "a", "1", cortexpb.Sample{TimestampMs: 2, Value: 5}),
+				series("up", "a", "2", cortexpb.Sample{TimestampMs: 1, Value: 3}),
+			},
+		},
+		{
+			// The metric name is dropped from the grouping labels too.
+			name:     "bottomk without instance",
+			op:       parser.BOTTOMK,
+			grouping: []string{"instance"},
+			without:  true,
+			expected: []SampleStream{
+				series("up", "a", "1", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: 5}),
+				series("up", "b", "1", cortexpb.Sample{TimestampMs: 1, Value: 2}),
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := selectSampleStreams(tc.op, 1, tc.grouping, tc.without, streams)
+			requireSampleStreamsEqual(t, tc.expected, actual)
+		})
+	}
+
+	t.Run("instant query results are sorted by value", func(t *testing.T) {
+		instant := []SampleStream{
+			series("up", "a", "1", cortexpb.Sample{TimestampMs: 1, Value: 1}),
+			series("up", "a", "2", cortexpb.Sample{TimestampMs: 1, Value: 3}),
+			series("up", "b", "1", cortexpb.Sample{TimestampMs: 1, Value: 2}),
+		}
+		actual := selectSampleStreams(parser.TOPK, 2, nil, false, instant)
+		requireSampleStreamsEqual(t, []SampleStream{instant[1], instant[2]}, actual)
+	})
+}
+
+func TestBucketQuantileSampleStreams(t *testing.T) {
+	bucket := func(job, le string, samples ...cortexpb.Sample) SampleStream {
+		return SampleStream{
+			Labels:  []cortexpb.LabelAdapter{{Name: "__name__", Value: "http_request_duration_seconds_bucket"}, {Name: "job", Value: job}, {Name: "le", Value: le}},
+			Samples: samples,
+		}
+	}
+	streams := []SampleStream{
+		bucket("a", "1", cortexpb.Sample{TimestampMs: 1, Value: 1}, cortexpb.Sample{TimestampMs: 2, Value: 0}),
+		bucket("a", "2", cortexpb.Sample{TimestampMs: 1, Value: 3}, cortexpb.Sample{TimestampMs: 2, Value: 2}),
+		bucket("a", "+Inf", cortexpb.Sample{TimestampMs: 1, Value: 4}, cortexpb.Sample{TimestampMs: 2, Value: 2}),
+		bucket("b", "1", cortexpb.Sample{TimestampMs: 1, Value: 1}),
+		bucket("b", "2", cortexpb.Sample{TimestampMs: 1, Value: 2}),
+		bucket("b", "invalid", cortexpb.Sample{TimestampMs: 1, Value: 10}),
+	}
+
+	actual := bucketQuantileSampleStreams(0.5, streams)
+	requireSampleStreamsEqual(t, []SampleStream{
+		{
+			Labels:  []cortexpb.LabelAdapter{{Name: "job", Value: "a"}},
+			Samples: []cortexpb.Sample{{TimestampMs: 1, Value: 1.5}, {TimestampMs: 2, Value: 1.5}},
+		},
+		{
+			// The histograms without a +Inf bucket have no quantile.
+			Labels:  []cortexpb.LabelAdapter{{Name: "job", Value: "b"}},
+			Samples: []cortexpb.Sample{{TimestampMs: 1, Value: math.NaN()}},
+		},
+	}, actual)
+}
+
+func requireSampleStreamsEqual(t *testing.T, expected, actual []SampleStream) {
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.Equal(t, expected[i].Labels, actual[i].Labels)
+		require.Len(t, actual[i].Samples, len(expected[i].Samples))
+		for j, sample := range expected[i].Samples {
+			require.Equal(t, sample.TimestampMs, actual[i].Samples[j].TimestampMs)
+			if math.IsNaN(sample.Value) {
+				require.True(t, math.IsNaN(actual[i].Samples[j].Value))
+			} else {
+				require.Equal(t, sample.Value, actual[i].Samples[j].Value)
+			}
+		}
+	}
+}
+
+type seriesHashRequest struct {
+	Request
+	query string
+}
+
+func (r *seriesHashRequest) GetQuery() string {
+	return r.query
+}
+
+func (r *seriesHashRequest) WithQuery(query string) Request {
+	return &seriesHashRequest{query: query}
+}
+
+type seriesHashMerger struct {
+	Merger
+	err error
+}
+
+func (m *seriesHashMerger) MergeAggregatedResponse(_ context.Context, _ Request, _ SampleStreamsMerger, _ ...Response) (Response, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &mockResponse{resp: "merged"}, nil
+}
+
+func TestShardBy_SeriesHash(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		query            string
+		enabled          bool
+		mergeErr         error
+		expectedQueries  int
+		expectedResponse string
+	}{
+		{
+			name:             "disabled",
+			query:            `sum(rate(http_requests_total[5m]))`,
+			expectedQueries:  1,
+			expectedResponse: "unsharded",
+		},
+		{
+			name:             "shardable aggregation",
+			query:            `sum(rate(http_requests_total[5m]))`,
+			enabled:          true,
+			expectedQueries:  3,
+			expectedResponse: "merged",
+		},
+		{
+			name:             "unshardable aggregation",
+			query:            `avg(rate(http_requests_total[5m]))`,
+			enabled:          true,
+			expectedQueries:  1,
+			expectedResponse: "unsharded",
+		},
+		{
+			name:             "histograms",
+			query:            `sum(rate(http_requests_total[5m]))`,
+			enabled:          true,
+			mergeErr:         errSeriesHashShardedHistograms,
+			expectedQueries:  4,
+			expectedResponse: "unsharded",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				mtx     sync.Mutex
+				queries []string
+			)
+			downstream := HandlerFunc(func(_ context.Context, req Request) (Response, error) {
+				mtx.Lock()
+				defer mtx.Unlock()
+				queries = append(queries, req.GetQuery())
+				if req.GetQuery() == tc.query {
+					return &mockResponse{resp: "unsharded"}, nil
+				}
+				return &mockResponse{resp: "shard"}, nil
+			})
+
+			merger := &seriesHashMerger{err: tc.mergeErr}
+			limits := mockLimits{shardSize: 3, shardBySeriesHash: tc.enabled}
+			handler := ShardByMiddleware(log.NewNopLogger(), limits, merger, querysharding.NewQueryAnalyzer()).Wrap(downstream)
+
+			resp, err := handler.Do(user.InjectOrgID(context.Background(), "user-1"), &seriesHashRequest{query: tc.query})
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedResponse, resp.(*mockResponse).resp)
+			require.Len(t, queries, tc.expectedQueries)
+
+			if tc.expectedQueries > 1 {
+				// Each shard selects a distinct partition of the series.
+				sort.Strings(queries)
+				for i := 1; i < len(queries); i++ {
+					require.NotEqual(t, queries[i-1], queries[i])
+				}
+			}
+		})
+	}
+}
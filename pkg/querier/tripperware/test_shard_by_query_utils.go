@@ -478,6 +478,12 @@ type mockLimits struct {
 	maxCacheFreshness time.Duration
 	shardSize         int
 	queryPriority     validation.QueryPriority
+	shardBySeriesHash bool
+	approximateTopK   bool
+
+	maxEstimatedQueryCost    int64
+	queryCostScrapeInterval  time.Duration
+	queryCostOverrideAllowed bool
 }
 
 func (m mockLimits) MaxQueryLookback(string) time.Duration {
@@ -504,6 +510,34 @@ func (m mockLimits) QueryPriority(userID string) validation.QueryPriority {
 	return m.queryPriority
 }
 
+func (m mockLimits) MaxResponsePoints(string) int {
+	return 0
+}
+
+func (m mockLimits) QueryVerticalShardBySeriesHash(string) bool {
+	return m.shardBySeriesHash
+}
+
+func (m mockLimits) QueryVerticalShardApproximateTopK(string) bool {
+	return m.approximateTopK
+}
+
+func (m mockLimits) InstantQueryCacheTTL(string) time.Duration {
+	return 0
+}
+
+func (m mockLimits) MaxEstimatedQueryCost(string) int64 {
+	return m.maxEstimatedQueryCost
+}
+
+func (m mockLimits) QueryCostScrapeInterval(string) time.Duration {
+	return m.queryCostScrapeInterval
+}
+
+func (m mockLimits) QueryCostOverrideAllowed(string) bool {
+	return m.queryCostOverrideAllowed
+}
+
 type singleHostRoundTripper struct {
 	host string
 	next http.RoundTripper
@@ -7,6 +7,7 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/tenant"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
@@ -17,6 +18,7 @@ type RequestResponse struct {
 }
 
 // DoRequests executes a list of requests in parallel. The limits parameters is used to limit parallelism per single request.
+// Each request is given a child query ID derived from the query ID in the context, if any.
 func DoRequests(ctx context.Context, downstream Handler, reqs []Request, limits Limits) ([]RequestResponse, error) {
 	tenantIDs, err := tenant.TenantIDs(ctx)
 	if err != nil {
@@ -28,10 +30,10 @@ func DoRequests(ctx context.Context, downstream Handler, reqs []Request, limits
 	defer cancel()
 
 	// Feed all requests to a bounded intermediate channel to limit parallelism.
-	intermediate := make(chan Request)
+	intermediate := make(chan int)
 	go func() {
-		for _, req := range reqs {
-			intermediate <- req
+		for idx := range reqs {
+			intermediate <- idx
 		}
 		close(intermediate)
 	}()
@@ -43,8 +45,9 @@ func DoRequests(ctx context.Context, downstream Handler, reqs []Request, limits
 	}
 	for i := 0; i < parallelism; i++ {
 		go func() {
-			for req := range intermediate {
-				resp, err := downstream.Do(ctx, req)
+			for idx := range intermediate {
+				req := reqs[idx]
+				resp, err := downstream.Do(util_log.ContextWithChildQueryID(ctx, idx+1), req)
 				if err != nil {
 					errChan <- err
 				} else {
@@ -0,0 +1,35 @@
+package tripperware
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+)
+
+func TestDoRequests_ShouldPropagateChildQueryIDs(t *testing.T) {
+	var (
+		mtx      sync.Mutex
+		queryIDs []string
+	)
+	downstream := HandlerFunc(func(ctx context.Context, req Request) (Response, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		queryIDs = append(queryIDs, req.(*mockRequest).resp+"="+util_log.QueryIDFromContext(ctx))
+		return &mockResponse{}, nil
+	})
+
+	ctx := util_log.ContextWithQueryID(user.InjectOrgID(context.Background(), "user-1"), "abc")
+	reqs := []Request{&mockRequest{resp: "first"}, &mockRequest{resp: "second"}, &mockRequest{resp: "third"}}
+
+	_, err := DoRequests(ctx, downstream, reqs, mockLimits{})
+	require.NoError(t, err)
+
+	sort.Strings(queryIDs)
+	require.Equal(t, []string{"first=abc.1", "second=abc.2", "third=abc.3"}, queryIDs)
+}
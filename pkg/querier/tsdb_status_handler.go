@@ -0,0 +1,91 @@
+package querier
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/util"
+)
+
+// defaultTSDBStatusLimit is the default number of items returned for each statistic, like Prometheus.
+const defaultTSDBStatusLimit = 10
+
+type tsdbHeadStats struct {
+	NumSeries     uint64 `json:"numSeries"`
+	NumLabelPairs uint64 `json:"numLabelPairs"`
+	ChunkCount    uint64 `json:"chunkCount"`
+	MinTime       int64  `json:"minTime"`
+	MaxTime       int64  `json:"maxTime"`
+}
+
+type tsdbStat struct {
+	Name  string `json:"name"`
+	Value uint64 `json:"value"`
+}
+
+type tsdbStatus struct {
+	HeadStats                   tsdbHeadStats `json:"headStats"`
+	SeriesCountByMetricName     []tsdbStat    `json:"seriesCountByMetricName"`
+	LabelValueCountByLabelName  []tsdbStat    `json:"labelValueCountByLabelName"`
+	MemoryInBytesByLabelName    []tsdbStat    `json:"memoryInBytesByLabelName"`
+	SeriesCountByLabelValuePair []tsdbStat    `json:"seriesCountByLabelValuePair"`
+}
+
+type tsdbStatusResult struct {
+	Status string      `json:"status"`
+	Data   *tsdbStatus `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// TSDBStatusHandler returns the cardinality statistics of the TSDB head of a given tenant,
+// aggregated across the ingesters, in the format of the Prometheus /api/v1/status/tsdb API.
+func TSDBStatusHandler(d Distributor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultTSDBStatusLimit
+		if s := r.FormValue("limit"); s != "" {
+			var err error
+			if limit, err = strconv.Atoi(s); err != nil || limit <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				util.WriteJSONResponse(w, tsdbStatusResult{Status: statusError, Error: "limit must be a positive number"})
+				return
+			}
+		}
+
+		resp, err := d.TSDBStatus(r.Context(), limit)
+		if err != nil {
+			// Only the errors carrying an HTTP status are client errors, the others are server errors.
+			code, msg := http.StatusInternalServerError, err.Error()
+			if errResp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+				code, msg = int(errResp.Code), string(errResp.Body)
+			}
+			w.WriteHeader(code)
+			util.WriteJSONResponse(w, tsdbStatusResult{Status: statusError, Error: msg})
+			return
+		}
+
+		util.WriteJSONResponse(w, tsdbStatusResult{Status: statusSuccess, Data: &tsdbStatus{
+			HeadStats: tsdbHeadStats{
+				NumSeries:     resp.NumSeries,
+				NumLabelPairs: resp.NumLabelPairs,
+				ChunkCount:    resp.ChunkCount,
+				MinTime:       resp.MinTime,
+				MaxTime:       resp.MaxTime,
+			},
+			SeriesCountByMetricName:     toTSDBStats(resp.SeriesCountByMetricName),
+			LabelValueCountByLabelName:  toTSDBStats(resp.LabelValueCountByLabelName),
+			MemoryInBytesByLabelName:    toTSDBStats(resp.MemoryInBytesByLabelName),
+			SeriesCountByLabelValuePair: toTSDBStats(resp.SeriesCountByLabelValuePair),
+		}})
+	})
+}
+
+func toTSDBStats(stats []client.TSDBStatistic) []tsdbStat {
+	result := make([]tsdbStat, 0, len(stats))
+	for _, s := range stats {
+		result = append(result, tsdbStat{Name: s.Name, Value: s.Value})
+	}
+	return result
+}
@@ -0,0 +1,82 @@
+package querier
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+)
+
+func TestTSDBStatusHandler(t *testing.T) {
+	d := &MockDistributor{}
+	d.On("TSDBStatus", mock.Anything, 5).Return(&client.TSDBStatusResponse{
+		NumSeries:               3,
+		NumLabelPairs:           4,
+		ChunkCount:              6,
+		MinTime:                 1000,
+		MaxTime:                 2000,
+		SeriesCountByMetricName: []client.TSDBStatistic{{Name: "up", Value: 3}},
+	}, nil)
+
+	handler := TSDBStatusHandler(d)
+
+	t.Run("valid request", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/status/tsdb?limit=5", nil)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusOK, recorder.Result().StatusCode)
+		body, err := io.ReadAll(recorder.Result().Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{
+			"status": "success",
+			"data": {
+				"headStats": {"numSeries": 3, "numLabelPairs": 4, "chunkCount": 6, "minTime": 1000, "maxTime": 2000},
+				"seriesCountByMetricName": [{"name": "up", "value": 3}],
+				"labelValueCountByLabelName": [],
+				"memoryInBytesByLabelName": [],
+				"seriesCountByLabelValuePair": []
+			}
+		}`, string(body))
+	})
+
+	t.Run("invalid limit", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/status/tsdb?limit=-1", nil)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusBadRequest, recorder.Result().StatusCode)
+	})
+
+	t.Run("distributor error", func(t *testing.T) {
+		d := &MockDistributor{}
+		d.On("TSDBStatus", mock.Anything, defaultTSDBStatusLimit).Return((*client.TSDBStatusResponse)(nil), context.Canceled)
+
+		req := httptest.NewRequest("GET", "/api/v1/status/tsdb", nil)
+		recorder := httptest.NewRecorder()
+		TSDBStatusHandler(d).ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusInternalServerError, recorder.Result().StatusCode)
+	})
+
+	t.Run("distributor client error", func(t *testing.T) {
+		d := &MockDistributor{}
+		d.On("TSDBStatus", mock.Anything, defaultTSDBStatusLimit).Return((*client.TSDBStatusResponse)(nil), httpgrpc.Errorf(http.StatusTooManyRequests, "too many requests"))
+
+		req := httptest.NewRequest("GET", "/api/v1/status/tsdb", nil)
+		recorder := httptest.NewRecorder()
+		TSDBStatusHandler(d).ServeHTTP(recorder, req)
+
+		require.Equal(t, http.StatusTooManyRequests, recorder.Result().StatusCode)
+		body, err := io.ReadAll(recorder.Result().Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"status":"error","error":"too many requests"}`, string(body))
+	})
+}
@@ -8,6 +8,7 @@ import (
 	"go.uber.org/atomic"
 	"google.golang.org/grpc/status"
 
+	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/httpgrpcutil"
 )
 
@@ -33,6 +34,12 @@ type itemTracker struct {
 	remaining   atomic.Int32
 	err4xx      atomic.Error
 	err5xx      atomic.Error
+
+	// The instances the item failed on with a retriable error, and the ones it has been
+	// retried on.
+	retryMtx  sync.Mutex
+	failedOn  []string
+	retriedOn []string
 }
 
 func (i *itemTracker) recordError(err error) int32 {
@@ -54,6 +61,22 @@ func (i *itemTracker) getError() error {
 	return i.err4xx.Load()
 }
 
+// DoBatchOptions are the optional settings of DoBatchWithOptions.
+type DoBatchOptions struct {
+	// Cleanup is always called, either on an error before starting the batches or after they all finish.
+	Cleanup func()
+
+	// IsRetriable returns whether the error returned by the callback for an instance has been
+	// caused by the instance transitioning state (eg. leaving the ring). In such case, the items
+	// are retried on the instances extending their replica set, as if the failed instance was
+	// not ACTIVE. Retries are disabled if nil.
+	IsRetriable func(error) bool
+
+	// MaxRetries is the max number of times the items failed on an instance are retried on
+	// another instance. Retries are disabled if 0.
+	MaxRetries int
+}
+
 // DoBatch request against a set of keys in the ring, handling replication and
 // failures. For example if we want to write N items where they may all
 // hit different instances, and we want them all replicated R ways with
@@ -67,6 +90,20 @@ func (i *itemTracker) getError() error {
 //
 // Not implemented as a method on Ring so we can test separately.
 func DoBatch(ctx context.Context, op Operation, r ReadRing, keys []uint32, callback func(InstanceDesc, []int) error, cleanup func()) error {
+	return DoBatchWithOptions(ctx, op, r, keys, func(desc InstanceDesc, indexes []int, _ int) error {
+		return callback(desc, indexes)
+	}, DoBatchOptions{Cleanup: cleanup})
+}
+
+// DoBatchWithOptions is like DoBatch, but it supports retrying the items failed on an
+// instance transitioning state on the instances extending their replica set. The callback
+// is also passed the attempt number: 0 for the first attempt, and N for the Nth retry.
+func DoBatchWithOptions(ctx context.Context, op Operation, r ReadRing, keys []uint32, callback func(InstanceDesc, []int, int) error, opts DoBatchOptions) error {
+	cleanup := opts.Cleanup
+	if cleanup == nil {
+		cleanup = func() {}
+	}
+
 	if r.InstancesCount() <= 0 {
 		cleanup()
 		return fmt.Errorf("DoBatch: InstancesCount <= 0")
@@ -116,8 +153,12 @@ func DoBatch(ctx context.Context, op Operation, r ReadRing, keys []uint32, callb
 	wg.Add(len(instances))
 	for _, i := range instances {
 		go func(i instance) {
-			err := callback(i.desc, i.indexes)
-			tracker.record(i, err)
+			err := callback(i.desc, i.indexes, 0)
+			if err != nil && opts.shouldRetry(err) {
+				tracker.retryOnExtendedReplicaSet(op, r, keys, callback, i, err, opts)
+			} else {
+				tracker.record(i, err)
+			}
 			wg.Done()
 		}(i)
 	}
@@ -139,6 +180,122 @@ func DoBatch(ctx context.Context, op Operation, r ReadRing, keys []uint32, callb
 	}
 }
 
+func (o DoBatchOptions) shouldRetry(err error) bool {
+	return o.MaxRetries > 0 && o.IsRetriable != nil && o.IsRetriable(err)
+}
+
+// retryOnExtendedReplicaSet retries the items failed on the input instance on the instances
+// extending their replica set, up to the max number of retries. The outcome of each item is
+// recorded once, with the result of the last attempt.
+func (b *batchTracker) retryOnExtendedReplicaSet(op Operation, r ReadRing, keys []uint32, callback func(InstanceDesc, []int, int) error, failed instance, err error, opts DoBatchOptions) {
+	for attempt := 0; attempt < opts.MaxRetries && len(failed.indexes) > 0; attempt++ {
+		// Group the failed items by the instance to retry them on.
+		retries := map[string]instance{}
+		noReplacement := instance{desc: failed.desc}
+
+		for n, idx := range failed.indexes {
+			desc, ok := failed.itemTrackers[n].nextRetryInstance(r, keys[idx], op, failed.desc.Addr)
+			if !ok {
+				noReplacement.itemTrackers = append(noReplacement.itemTrackers, failed.itemTrackers[n])
+				noReplacement.indexes = append(noReplacement.indexes, idx)
+				continue
+			}
+
+			curr := retries[desc.Addr]
+			retries[desc.Addr] = instance{
+				desc:         desc,
+				itemTrackers: append(curr.itemTrackers, failed.itemTrackers[n]),
+				indexes:      append(curr.indexes, idx),
+			}
+		}
+
+		if len(noReplacement.indexes) > 0 {
+			b.record(noReplacement, err)
+		}
+
+		var (
+			wg   sync.WaitGroup
+			mtx  sync.Mutex
+			next instance
+		)
+
+		wg.Add(len(retries))
+		for _, retry := range retries {
+			go func(retry instance) {
+				defer wg.Done()
+
+				retryErr := callback(retry.desc, retry.indexes, attempt+1)
+				if retryErr == nil || !opts.IsRetriable(retryErr) {
+					b.record(retry, retryErr)
+					return
+				}
+
+				// The items failed on the replacement instance too, and will be retried again.
+				mtx.Lock()
+				defer mtx.Unlock()
+				next.desc = retry.desc
+				next.itemTrackers = append(next.itemTrackers, retry.itemTrackers...)
+				next.indexes = append(next.indexes, retry.indexes...)
+				err = retryErr
+			}(retry)
+		}
+		wg.Wait()
+
+		failed = next
+	}
+
+	if len(failed.indexes) > 0 {
+		b.record(failed, err)
+	}
+}
+
+// nextRetryInstance records that the item failed on the input instance, and returns the
+// instance to retry it on: the first instance extending its replica set, when the instances
+// it failed on are considered not ACTIVE, which hasn't been tried yet. Returns false if
+// there's no such instance.
+func (i *itemTracker) nextRetryInstance(r ReadRing, key uint32, op Operation, failedAddr string) (InstanceDesc, bool) {
+	var (
+		base              *Ring
+		replicationFactor int
+	)
+
+	switch rr := r.(type) {
+	case *Ring:
+		base, replicationFactor = rr, rr.cfg.ReplicationFactor
+	case *replicationFactorRing:
+		base, replicationFactor = rr.Ring, rr.replicationFactor
+	default:
+		return InstanceDesc{}, false
+	}
+
+	// The item may be retried concurrently for different failed instances of its replica set.
+	i.retryMtx.Lock()
+	defer i.retryMtx.Unlock()
+
+	i.failedOn = append(i.failedOn, failedAddr)
+
+	current, err := base.get(key, op, replicationFactor, nil, nil, nil, nil)
+	if err != nil {
+		return InstanceDesc{}, false
+	}
+
+	extended, err := base.get(key, op, replicationFactor, i.failedOn, nil, nil, nil)
+	if err != nil {
+		return InstanceDesc{}, false
+	}
+
+	for _, instance := range extended.Instances {
+		if current.Includes(instance.Addr) || util.StringsContain(i.failedOn, instance.Addr) || util.StringsContain(i.retriedOn, instance.Addr) {
+			continue
+		}
+
+		i.retriedOn = append(i.retriedOn, instance.Addr)
+		return instance, true
+	}
+
+	return InstanceDesc{}, false
+}
+
 func (b *batchTracker) record(instance instance, err error) {
 	// If we reach the required number of successful puts on this sample, then decrement the
 	// number of pending samples by one.
@@ -145,6 +145,11 @@ func (r *Ring) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if req.URL.Query().Get("mode") == "token_load" {
+		r.serveTokenLoadReport(w, req)
+		return
+	}
+
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
 
@@ -226,7 +231,7 @@ func renderHTTPResponse(w http.ResponseWriter, v httpResponse, t *template.Templ
 }
 
 // WriteJSONResponse writes some JSON as a HTTP response.
-func writeJSONResponse(w http.ResponseWriter, v httpResponse) {
+func writeJSONResponse(w http.ResponseWriter, v interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 
 	data, err := json.Marshal(v)
@@ -15,6 +15,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ring/kv/dynamodb"
 	"github.com/cortexproject/cortex/pkg/ring/kv/etcd"
 	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
+	"github.com/cortexproject/cortex/pkg/ring/kv/zookeeper"
 )
 
 const (
@@ -39,13 +40,14 @@ var inmemoryStoreInit sync.Once
 var inmemoryStore Client
 
 // StoreConfig is a configuration used for building single store client, either
-// Consul, Etcd, Memberlist or MultiClient. It was extracted from Config to keep
+// Consul, Etcd, Zookeeper, Memberlist or MultiClient. It was extracted from Config to keep
 // single-client config separate from final client-config (with all the wrappers)
 type StoreConfig struct {
-	DynamoDB dynamodb.Config `yaml:"dynamodb"`
-	Consul   consul.Config   `yaml:"consul"`
-	Etcd     etcd.Config     `yaml:"etcd"`
-	Multi    MultiConfig     `yaml:"multi"`
+	DynamoDB  dynamodb.Config  `yaml:"dynamodb"`
+	Consul    consul.Config    `yaml:"consul"`
+	Etcd      etcd.Config      `yaml:"etcd"`
+	Zookeeper zookeeper.Config `yaml:"zookeeper"`
+	Multi     MultiConfig      `yaml:"multi"`
 
 	// Function that returns memberlist.KV store to use. By using a function, we can delay
 	// initialization of memberlist.KV until it is actually required.
@@ -75,13 +77,14 @@ func (cfg *Config) RegisterFlagsWithPrefix(flagsPrefix, defaultPrefix string, f
 	cfg.DynamoDB.RegisterFlags(f, flagsPrefix)
 	cfg.Consul.RegisterFlags(f, flagsPrefix)
 	cfg.Etcd.RegisterFlagsWithPrefix(f, flagsPrefix)
+	cfg.Zookeeper.RegisterFlagsWithPrefix(f, flagsPrefix)
 	cfg.Multi.RegisterFlagsWithPrefix(f, flagsPrefix)
 
 	if flagsPrefix == "" {
 		flagsPrefix = "ring."
 	}
 	f.StringVar(&cfg.Prefix, flagsPrefix+"prefix", defaultPrefix, "The prefix for the keys in the store. Should end with a /.")
-	f.StringVar(&cfg.Store, flagsPrefix+"store", "consul", "Backend storage to use for the ring. Supported values are: consul, etcd, inmemory, memberlist, multi.")
+	f.StringVar(&cfg.Store, flagsPrefix+"store", "consul", "Backend storage to use for the ring. Supported values are: consul, etcd, inmemory, memberlist, multi, zookeeper.")
 }
 
 // Client is a high-level client for key-value stores (such as Etcd and
@@ -120,7 +123,7 @@ type Client interface {
 	LastUpdateTime(key string) time.Time
 }
 
-// NewClient creates a new Client (consul, etcd or inmemory) based on the config,
+// NewClient creates a new Client (consul, etcd, zookeeper or inmemory) based on the config,
 // encodes and decodes data for storage using the codec.
 func NewClient(cfg Config, codec codec.Codec, reg prometheus.Registerer, logger log.Logger) (Client, error) {
 	if cfg.Mock != nil {
@@ -144,6 +147,9 @@ func createClient(backend string, prefix string, cfg StoreConfig, codec codec.Co
 	case "etcd":
 		client, err = etcd.New(cfg.Etcd, codec, logger)
 
+	case "zookeeper":
+		client, err = zookeeper.New(cfg.Zookeeper, codec, logger)
+
 	case "inmemory":
 		// If we use the in-memory store, make sure everyone gets the same instance
 		// within the same process.
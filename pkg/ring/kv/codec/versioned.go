@@ -0,0 +1,129 @@
+package codec
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// versionedMagic prefixes the values encoded with a schema version greater than 0. A snappy
+// encoded value starting with a zero byte has a zero decoded length, so it is made of that
+// byte only: values longer than one byte and starting with it are never legacy values.
+var versionedMagic = []byte{0x00, 'V'}
+
+var decodedValues = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "cortex_kv_codec_decoded_values_total",
+	Help: "Total number of values decoded by the KV store codecs, by codec and schema version of the value.",
+}, []string{"codec", "version"})
+
+// Migration converts values between two consecutive schema versions. Both functions
+// receive and return values of the type decoded by the wrapped codec, and must not
+// modify their input value.
+type Migration struct {
+	// Up converts a value decoded from the previous schema version to this version.
+	Up func(interface{}) (interface{}, error)
+
+	// Down converts a value of this schema version to the previous version, so that it
+	// can be decoded by clients not knowing this version yet.
+	Down func(interface{}) (interface{}, error)
+}
+
+// Versioned wraps a Codec to version the schema of the encoded values, so that schema
+// changes can be rolled out to clusters running mixed versions:
+//
+//  1. a release adds the Migration to the new version, while still writing the previous one;
+//  2. once all clients run it, the next release writes the new version.
+//
+// Values of older versions are converted up when decoded, while values of newer versions
+// are decoded as they are, relying on the forward compatibility of the wrapped codec.
+// The schema version 0 is encoded without any header, as by the wrapped codec. Multi-key
+// values are not versioned.
+type Versioned struct {
+	Codec
+
+	// migrations[i] converts between the versions i and i+1.
+	migrations   []Migration
+	writeVersion int
+}
+
+// NewVersionedCodec returns a Versioned codec whose current schema version is the number
+// of migrations, and which encodes the values with the given version.
+func NewVersionedCodec(c Codec, migrations []Migration, writeVersion int) Versioned {
+	if writeVersion < 0 || writeVersion > len(migrations) {
+		panic(fmt.Sprintf("invalid write version %d for codec %s with %d migrations", writeVersion, c.CodecID(), len(migrations)))
+	}
+
+	return Versioned{Codec: c, migrations: migrations, writeVersion: writeVersion}
+}
+
+// Version returns the current schema version.
+func (v Versioned) Version() int {
+	return len(v.migrations)
+}
+
+// Decode implements Codec.
+func (v Versioned) Decode(data []byte) (interface{}, error) {
+	version, payload, err := splitVersion(data)
+	if err != nil {
+		return nil, err
+	}
+
+	decodedValues.WithLabelValues(v.CodecID(), strconv.Itoa(version)).Inc()
+
+	out, err := v.Codec.Decode(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := version; i < len(v.migrations); i++ {
+		if v.migrations[i].Up == nil {
+			continue
+		}
+		if out, err = v.migrations[i].Up(out); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert value from schema version %d to %d", i, i+1)
+		}
+	}
+
+	return out, nil
+}
+
+// Encode implements Codec.
+func (v Versioned) Encode(msg interface{}) ([]byte, error) {
+	var err error
+	for i := len(v.migrations); i > v.writeVersion; i-- {
+		if v.migrations[i-1].Down == nil {
+			continue
+		}
+		if msg, err = v.migrations[i-1].Down(msg); err != nil {
+			return nil, errors.Wrapf(err, "failed to convert value from schema version %d to %d", i, i-1)
+		}
+	}
+
+	payload, err := v.Codec.Encode(msg)
+	if err != nil || v.writeVersion == 0 {
+		return payload, err
+	}
+
+	out := make([]byte, 0, len(versionedMagic)+binary.MaxVarintLen64+len(payload))
+	out = append(out, versionedMagic...)
+	out = binary.AppendUvarint(out, uint64(v.writeVersion))
+	return append(out, payload...), nil
+}
+
+// splitVersion returns the schema version of the encoded value and its payload.
+func splitVersion(data []byte) (int, []byte, error) {
+	if len(data) <= len(versionedMagic) || data[0] != versionedMagic[0] || data[1] != versionedMagic[1] {
+		return 0, data, nil
+	}
+
+	version, n := binary.Uvarint(data[len(versionedMagic):])
+	if n <= 0 {
+		return 0, nil, errors.New("invalid schema version header")
+	}
+
+	return int(version), data[len(versionedMagic)+n:], nil
+}
@@ -0,0 +1,67 @@
+package codec
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersioned(t *testing.T) {
+	// The version 1 of the schema upper cases the values.
+	migrations := []Migration{{
+		Up:   func(in interface{}) (interface{}, error) { return strings.ToUpper(in.(string)), nil },
+		Down: func(in interface{}) (interface{}, error) { return strings.ToLower(in.(string)), nil },
+	}}
+
+	var (
+		v0 = NewVersionedCodec(String{}, nil, 0)
+		// Knows about the version 1, but still writes the version 0.
+		v1Rollout = NewVersionedCodec(String{}, migrations, 0)
+		v1        = NewVersionedCodec(String{}, migrations, 1)
+	)
+	assert.Equal(t, 1, v1.Version())
+
+	// The version 0 is encoded as by the wrapped codec.
+	encoded, err := v1Rollout.Encode("VALUE")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), encoded)
+
+	// Values of older versions are converted up.
+	decoded, err := v1.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "VALUE", decoded)
+
+	// Values of newer versions are decoded as they are.
+	encoded, err = v1.Encode("VALUE")
+	require.NoError(t, err)
+	assert.Equal(t, append([]byte{0x00, 'V', 1}, "VALUE"...), encoded)
+
+	before := testutil.ToFloat64(decodedValues.WithLabelValues("string", "1"))
+	decoded, err = v0.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "VALUE", decoded)
+	assert.Equal(t, before+1, testutil.ToFloat64(decodedValues.WithLabelValues("string", "1")))
+
+	decoded, err = v1Rollout.Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "VALUE", decoded)
+
+	_, err = v1.Decode([]byte{0x00, 'V', 0x80})
+	require.Error(t, err)
+}
+
+func TestVersioned_ShouldDecodeLegacyProtoValues(t *testing.T) {
+	legacy := NewProtoCodec("test", newMockMessage)
+	versioned := NewVersionedCodec(legacy, []Migration{{}}, 1)
+
+	// An empty message is snappy encoded as a single zero byte.
+	encoded, err := legacy.Encode(&mockMessage{})
+	require.NoError(t, err)
+
+	decoded, err := versioned.Decode(encoded)
+	require.NoError(t, err)
+	assert.IsType(t, &mockMessage{}, decoded)
+}
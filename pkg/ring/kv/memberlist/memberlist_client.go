@@ -161,6 +161,13 @@ type KVConfig struct {
 	// How much space to use to keep received and sent messages in memory (for troubleshooting).
 	MessageHistoryBufferBytes int `yaml:"message_history_buffer_bytes"`
 
+	// Max number of messages kept in the broadcast queue. Zero = no limit.
+	MaxQueuedBroadcasts int `yaml:"max_queued_broadcasts"`
+
+	// Packet loss ratio above which full state is synced over streams. Zero = disabled.
+	PacketLossFallbackThreshold     float64       `yaml:"packet_loss_fallback_threshold"`
+	PacketLossFallbackCheckInterval time.Duration `yaml:"packet_loss_fallback_check_interval"`
+
 	TCPTransport TCPTransportConfig `yaml:",inline"`
 
 	// Where to put custom metrics. Metrics are not registered, if this is nil.
@@ -194,6 +201,9 @@ func (cfg *KVConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
 	f.DurationVar(&cfg.GossipToTheDeadTime, prefix+"memberlist.gossip-to-dead-nodes-time", mlDefaults.GossipToTheDeadTime, "How long to keep gossiping to dead nodes, to give them chance to refute their death.")
 	f.DurationVar(&cfg.DeadNodeReclaimTime, prefix+"memberlist.dead-node-reclaim-time", mlDefaults.DeadNodeReclaimTime, "How soon can dead node's name be reclaimed with new address. 0 to disable.")
 	f.IntVar(&cfg.MessageHistoryBufferBytes, prefix+"memberlist.message-history-buffer-bytes", 0, "How much space to use for keeping received and sent messages in memory for troubleshooting (two buffers). 0 to disable.")
+	f.IntVar(&cfg.MaxQueuedBroadcasts, prefix+"memberlist.max-queued-broadcasts", 0, "Max number of messages kept in the broadcast queue. When the limit is exceeded, the oldest messages are dropped. 0 to disable the limit.")
+	f.Float64Var(&cfg.PacketLossFallbackThreshold, prefix+"memberlist.packet-loss-fallback-threshold", 0, "Ratio (between 0 and 1) of packets which could not be sent to other nodes, above which this node falls back to synchronize its full state with other nodes over TCP streams (push/pull), instead of only relying on gossiped packets. 0 to disable.")
+	f.DurationVar(&cfg.PacketLossFallbackCheckInterval, prefix+"memberlist.packet-loss-fallback-check-interval", 30*time.Second, "How often the packet loss ratio is computed and checked against -memberlist.packet-loss-fallback-threshold.")
 	f.BoolVar(&cfg.EnableCompression, prefix+"memberlist.compression-enabled", mlDefaults.EnableCompression, "Enable message compression. This can be used to reduce bandwidth usage at the cost of slightly more CPU utilization.")
 	f.StringVar(&cfg.AdvertiseAddr, prefix+"memberlist.advertise-addr", mlDefaults.AdvertiseAddr, "Gossip address to advertise to other members in the cluster. Used for NAT traversal.")
 	f.IntVar(&cfg.AdvertisePort, prefix+"memberlist.advertise-port", mlDefaults.AdvertisePort, "Gossip port to advertise to other members in the cluster. Used for NAT traversal.")
@@ -234,6 +244,10 @@ type KV struct {
 	initWG     sync.WaitGroup
 	memberlist *memberlist.Memberlist
 	broadcasts *memberlist.TransmitLimitedQueue
+	transport  *TCPTransport
+
+	// Closed once the first attempt to join the cluster has completed.
+	joined chan struct{}
 
 	// KV Store.
 	storeMu sync.Mutex
@@ -270,6 +284,9 @@ type KV struct {
 	numberOfBroadcastMessagesInQueue    prometheus.GaugeFunc
 	totalSizeOfBroadcastMessagesInQueue prometheus.Gauge
 	numberOfBroadcastMessagesDropped    prometheus.Counter
+	numberOfBroadcastMessagesOverflow   prometheus.Counter
+	packetLossRatio                     prometheus.Gauge
+	packetLossFallbacks                 prometheus.Counter
 	casAttempts                         prometheus.Counter
 	casFailures                         prometheus.Counter
 	casSuccesses                        prometheus.Counter
@@ -353,6 +370,7 @@ func NewKV(cfg KVConfig, logger log.Logger, dnsProvider DNSProvider, registerer
 		watchers:       make(map[string][]chan string),
 		prefixWatchers: make(map[string][]chan string),
 		shutdown:       make(chan struct{}),
+		joined:         make(chan struct{}),
 		maxCasRetries:  maxCasRetries,
 	}
 
@@ -401,6 +419,7 @@ func (m *KV) buildMemberlistConfig() (*memberlist.Config, error) {
 
 	mlCfg.LogOutput = newMemberlistLoggerAdapter(m.logger, false)
 	mlCfg.Transport = tr
+	m.transport = tr
 
 	// Memberlist uses UDPBufferSize to figure out how many messages it can put into single "packet".
 	// As we don't use UDP for sending packets, we can use higher value here.
@@ -464,6 +483,8 @@ func (m *KV) running(ctx context.Context) error {
 		members := m.discoverMembers(ctx, m.cfg.JoinMembers)
 
 		err := m.joinMembersOnRunning(ctx, members)
+		close(m.joined)
+
 		if err != nil {
 			level.Error(m.logger).Log("msg", "failed to join memberlist cluster", "err", err)
 
@@ -471,6 +492,8 @@ func (m *KV) running(ctx context.Context) error {
 				return errFailedToJoinCluster
 			}
 		}
+	} else {
+		close(m.joined)
 	}
 
 	var tickerChan <-chan time.Time
@@ -487,16 +510,63 @@ func (m *KV) running(ctx context.Context) error {
 		tickerChan = t.C
 	}
 
+	var packetLossChan <-chan time.Time
+	if m.cfg.PacketLossFallbackThreshold > 0 && m.cfg.PacketLossFallbackCheckInterval > 0 {
+		t := time.NewTicker(m.cfg.PacketLossFallbackCheckInterval)
+		defer t.Stop()
+
+		packetLossChan = t.C
+	}
+
 	for {
 		select {
 		case <-tickerChan:
 			m.rejoinMemberlist(ctx)
+		case <-packetLossChan:
+			m.checkPacketLoss()
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
+// checkPacketLoss computes the packet loss ratio since the last check and, if it exceeds the
+// configured threshold, synchronizes the full state with some other nodes via push/pull. Push/pull
+// uses TCP streams, which don't suffer from lost packets, so that updates are still propagated.
+func (m *KV) checkPacketLoss() {
+	ratio := m.transport.packetLossRatio()
+	m.packetLossRatio.Set(ratio)
+
+	if ratio <= m.cfg.PacketLossFallbackThreshold {
+		return
+	}
+
+	var members []string
+	for _, n := range m.memberlist.Members() {
+		if n.Name == m.memberlist.LocalNode().Name {
+			continue
+		}
+		members = append(members, n.Address())
+	}
+
+	if len(members) == 0 {
+		return
+	}
+
+	// Sync with the same number of nodes we gossip to.
+	mathrand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	if len(members) > m.cfg.GossipNodes && m.cfg.GossipNodes > 0 {
+		members = members[:m.cfg.GossipNodes]
+	}
+
+	m.packetLossFallbacks.Inc()
+	level.Warn(m.logger).Log("msg", "packet loss ratio exceeded the threshold, synchronizing full state over TCP streams", "ratio", ratio, "threshold", m.cfg.PacketLossFallbackThreshold, "nodes", len(members))
+
+	if _, err := m.memberlist.Join(members); err != nil {
+		level.Warn(m.logger).Log("msg", "failed to synchronize full state over TCP streams", "err", err)
+	}
+}
+
 func (m *KV) rejoinMemberlist(ctx context.Context) {
 	members := m.discoverMembers(ctx, m.cfg.JoinMembers)
 
@@ -530,6 +600,22 @@ func (m *KV) JoinMembers(members []string) (int, error) {
 	return m.memberlist.Join(members)
 }
 
+// WaitJoined waits until the first attempt to join the cluster has completed, successfully or not.
+// Joining the cluster synchronizes the full state with the reached members, so once joined the KV
+// store contains the values known by the cluster.
+func (m *KV) WaitJoined(ctx context.Context) error {
+	if err := m.AwaitRunning(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case <-m.joined:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (m *KV) joinMembersOnRunning(ctx context.Context, members []string) error {
 	reached, err := m.memberlist.Join(members)
 	if err == nil {
@@ -1031,6 +1117,13 @@ func (m *KV) queueBroadcast(key string, content []string, version uint, message
 
 	m.totalSizeOfBroadcastMessagesInQueue.Add(float64(l))
 	m.broadcasts.QueueBroadcast(b)
+
+	if m.cfg.MaxQueuedBroadcasts > 0 {
+		if queued := m.broadcasts.NumQueued(); queued > m.cfg.MaxQueuedBroadcasts {
+			m.broadcasts.Prune(m.cfg.MaxQueuedBroadcasts)
+			m.numberOfBroadcastMessagesOverflow.Add(float64(queued - m.broadcasts.NumQueued()))
+		}
+	}
 }
 
 // GetBroadcasts is method from Memberlist Delegate interface
@@ -17,6 +17,7 @@ import (
 
 	"github.com/go-kit/log"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -1358,3 +1359,32 @@ func (p *dnsProviderMock) Resolve(ctx context.Context, addrs []string) error {
 func (p dnsProviderMock) Addresses() []string {
 	return p.resolved
 }
+
+func TestBroadcastQueueOverflow(t *testing.T) {
+	codec := dataCodec{}
+
+	cfg := KVConfig{}
+	cfg.RetransmitMult = 1
+	cfg.MaxQueuedBroadcasts = 1
+	cfg.Codecs = append(cfg.Codecs, codec)
+
+	kv := NewKV(cfg, log.NewNopLogger(), &dnsProviderMock{}, prometheus.NewPedanticRegistry())
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), kv))
+	defer services.StopAndAwaitTerminated(context.Background(), kv) //nolint:errcheck
+
+	client, err := NewClient(kv, codec)
+	require.NoError(t, err)
+
+	now := time.Now()
+	for _, k := range []string{"key-1", "key-2"} {
+		require.NoError(t, client.CAS(context.Background(), k, func(in interface{}) (out interface{}, retry bool, err error) {
+			d := getOrCreateData(in)
+			d.Members["a"] = member{Timestamp: now.Unix(), State: JOINING}
+			return d, true, nil
+		}))
+	}
+
+	// The oldest message has been dropped from the queue.
+	assert.Equal(t, float64(1), testutil.ToFloat64(kv.numberOfBroadcastMessagesOverflow))
+	assert.Equal(t, 1, len(kv.GetBroadcasts(0, math.MaxInt32)))
+}
@@ -91,6 +91,27 @@ func (m *KV) createAndRegisterMetrics() {
 		Help:      "Number of broadcast messages intended to be sent but were dropped due to encoding errors or for being too big",
 	})
 
+	m.numberOfBroadcastMessagesOverflow = promauto.With(m.registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: m.cfg.MetricsNamespace,
+		Subsystem: subsystem,
+		Name:      "messages_in_broadcast_queue_overflow_total",
+		Help:      "Number of broadcast messages dropped because the broadcast queue exceeded its max size",
+	})
+
+	m.packetLossRatio = promauto.With(m.registerer).NewGauge(prometheus.GaugeOpts{
+		Namespace: m.cfg.MetricsNamespace,
+		Subsystem: subsystem,
+		Name:      "packet_loss_ratio",
+		Help:      "Ratio of packets which could not be sent to other nodes, computed at the last packet loss check",
+	})
+
+	m.packetLossFallbacks = promauto.With(m.registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: m.cfg.MetricsNamespace,
+		Subsystem: subsystem,
+		Name:      "packet_loss_fallbacks_total",
+		Help:      "Number of times the full state was synchronized over TCP streams because the packet loss ratio exceeded the threshold",
+	})
+
 	m.casAttempts = promauto.With(m.registerer).NewCounter(prometheus.CounterOpts{
 		Namespace: m.cfg.MetricsNamespace,
 		Subsystem: subsystem,
@@ -50,6 +50,9 @@ type TCPTransportConfig struct {
 	// Timeout for writing packet data. Zero = no timeout.
 	PacketWriteTimeout time.Duration `yaml:"packet_write_timeout"`
 
+	// How many times to retry sending a packet after a failure. Zero = no retries.
+	PacketWriteRetries int `yaml:"packet_write_retries"`
+
 	// Transport logs lot of messages at debug level, so it deserves an extra flag for turning it on
 	TransportDebug bool `yaml:"-"`
 
@@ -72,6 +75,7 @@ func (cfg *TCPTransportConfig) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix s
 	f.IntVar(&cfg.BindPort, prefix+"memberlist.bind-port", 7946, "Port to listen on for gossip messages.")
 	f.DurationVar(&cfg.PacketDialTimeout, prefix+"memberlist.packet-dial-timeout", 5*time.Second, "Timeout used when connecting to other nodes to send packet.")
 	f.DurationVar(&cfg.PacketWriteTimeout, prefix+"memberlist.packet-write-timeout", 5*time.Second, "Timeout for writing 'packet' data.")
+	f.IntVar(&cfg.PacketWriteRetries, prefix+"memberlist.packet-write-retries", 0, "How many times to retry sending a 'packet' to another node after a failure. 0 to disable retries.")
 	f.BoolVar(&cfg.TransportDebug, prefix+"memberlist.transport-debug", false, "Log debug transport messages. Note: global log.level must be at debug level as well.")
 
 	f.BoolVar(&cfg.TLSEnabled, prefix+"memberlist.tls-enabled", false, "Enable TLS on the memberlist transport layer.")
@@ -92,6 +96,10 @@ type TCPTransport struct {
 
 	shutdown atomic.Int32
 
+	// Number of sent and lost packets since the last call to packetLossRatio().
+	packetsSentSinceLastCheck atomic.Int64
+	packetsLostSinceLastCheck atomic.Int64
+
 	advertiseMu   sync.RWMutex
 	advertiseAddr string
 
@@ -106,7 +114,10 @@ type TCPTransport struct {
 	sentPackets           prometheus.Counter
 	sentPacketsBytes      prometheus.Counter
 	sentPacketsErrors     prometheus.Counter
+	sentPacketsRetries    prometheus.Counter
 	unknownConnections    prometheus.Counter
+
+	receivedPacketsCorrupted prometheus.Counter
 }
 
 // NewTCPTransport returns a new tcp-based transport with the given configuration. On
@@ -301,6 +312,7 @@ func (t *TCPTransport) handleConnection(conn net.Conn) {
 
 		if !bytes.Equal(receivedDigest, expectedDigest[:]) {
 			t.receivedPacketsErrors.Inc()
+			t.receivedPacketsCorrupted.Inc()
 			level.Warn(t.logger).Log("msg", "packet digest mismatch", "expected", fmt.Sprintf("%x", expectedDigest), "received", fmt.Sprintf("%x", receivedDigest), "data_length", len(buf), "remote", conn.RemoteAddr())
 		}
 
@@ -414,10 +426,19 @@ func (t *TCPTransport) getAdvertisedAddr() string {
 func (t *TCPTransport) WriteTo(b []byte, addr string) (time.Time, error) {
 	t.sentPackets.Inc()
 	t.sentPacketsBytes.Add(float64(len(b)))
+	t.packetsSentSinceLastCheck.Inc()
 
 	err := t.writeTo(b, addr)
+	for retry := 0; err != nil && retry < t.cfg.PacketWriteRetries && t.shutdown.Load() == 0; retry++ {
+		t.sentPacketsRetries.Inc()
+		t.debugLog().Log("msg", "WriteTo failed, retrying", "addr", addr, "retry", retry+1, "err", err)
+
+		err = t.writeTo(b, addr)
+	}
+
 	if err != nil {
 		t.sentPacketsErrors.Inc()
+		t.packetsLostSinceLastCheck.Inc()
 
 		logLevel := level.Warn(t.logger)
 		if strings.Contains(err.Error(), "connection refused") {
@@ -512,6 +533,17 @@ func (t *TCPTransport) writeTo(b []byte, addr string) error {
 	return nil
 }
 
+// packetLossRatio returns the ratio of packets which could not be sent (after retries) to the
+// total number of sent packets since the last call, and resets the counters.
+func (t *TCPTransport) packetLossRatio() float64 {
+	sent := t.packetsSentSinceLastCheck.Swap(0)
+	lost := t.packetsLostSinceLastCheck.Swap(0)
+	if sent == 0 {
+		return 0
+	}
+	return float64(lost) / float64(sent)
+}
+
 // PacketCh returns a channel that can be read to receive incoming
 // packets from other peers.
 func (t *TCPTransport) PacketCh() <-chan *memberlist.Packet {
@@ -627,6 +659,20 @@ func (t *TCPTransport) registerMetrics(registerer prometheus.Registerer) {
 		Help:      "Number of errors when sending memberlist packets",
 	})
 
+	t.sentPacketsRetries = promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: t.cfg.MetricsNamespace,
+		Subsystem: subsystem,
+		Name:      "packets_sent_retries_total",
+		Help:      "Number of retries when sending memberlist packets",
+	})
+
+	t.receivedPacketsCorrupted = promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Namespace: t.cfg.MetricsNamespace,
+		Subsystem: subsystem,
+		Name:      "packets_received_corrupted_total",
+		Help:      "Number of received memberlist packets with a digest mismatch",
+	})
+
 	t.unknownConnections = promauto.With(registerer).NewCounter(prometheus.CounterOpts{
 		Namespace: t.cfg.MetricsNamespace,
 		Subsystem: subsystem,
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -59,3 +60,27 @@ func TestTCPTransport_WriteTo_ShouldNotLogAsWarningExpectedFailures(t *testing.T
 		})
 	}
 }
+
+func TestTCPTransport_WriteTo_ShouldRetryAndTrackPacketLoss(t *testing.T) {
+	cfg := TCPTransportConfig{}
+	flagext.DefaultValues(&cfg)
+	cfg.BindAddrs = []string{"localhost"}
+	cfg.BindPort = 0
+	cfg.PacketWriteRetries = 2
+
+	transport, err := NewTCPTransport(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = transport.Shutdown() })
+
+	// Nobody is listening on the remote address, so the packet is lost after all retries.
+	_, err = transport.WriteTo([]byte("test"), "localhost:12345")
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(transport.sentPackets))
+	assert.Equal(t, float64(2), testutil.ToFloat64(transport.sentPacketsRetries))
+	assert.Equal(t, float64(1), testutil.ToFloat64(transport.sentPacketsErrors))
+
+	assert.Equal(t, float64(1), transport.packetLossRatio())
+	// Counters are reset after each check.
+	assert.Equal(t, float64(0), transport.packetLossRatio())
+}
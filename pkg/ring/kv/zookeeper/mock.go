@@ -0,0 +1,260 @@
+package zookeeper
+
+import (
+	"io"
+	"path"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-zookeeper/zk"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+// NewInMemoryClient creates a Zookeeper Client implementation that uses an in-memory
+// version of the underlying Zookeeper connection.
+func NewInMemoryClient(codec codec.Codec, logger log.Logger) (*Client, io.Closer) {
+	// Make sure to set default values for the config including number of retries,
+	// otherwise the client won't even attempt a CAS operation.
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+
+	conn := newMockConn()
+	client, err := newClient(cfg, codec, conn, zk.WorldACL(zk.PermAll), logger)
+	if err != nil {
+		// Can't happen, given the in-memory connection never fails creating the root path.
+		panic(err)
+	}
+
+	return client, mockCloser{conn: conn}
+}
+
+type mockNode struct {
+	data    []byte
+	version int32
+}
+
+// mockConn is an in-memory implementation of a Zookeeper connection.
+//
+// This implementation only exists to be used by the Zookeeper kv.Client implementation
+// during unit tests, so it has many limitations compared to the real connection:
+//
+//   - ACLs and node flags are ignored
+//   - Watches are fired with the event type only
+//   - Closing the connection fires the pending watches with an EventNotWatching event
+type mockConn struct {
+	mtx    sync.Mutex
+	nodes  map[string]*mockNode
+	closed bool
+
+	// One-shot watches, by node path.
+	dataWatches     map[string][]chan zk.Event
+	childrenWatches map[string][]chan zk.Event
+}
+
+func newMockConn() *mockConn {
+	return &mockConn{
+		nodes:           map[string]*mockNode{"/": {}},
+		dataWatches:     map[string][]chan zk.Event{},
+		childrenWatches: map[string][]chan zk.Event{},
+	}
+}
+
+func (m *mockConn) Get(p string) ([]byte, *zk.Stat, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return nil, nil, zk.ErrClosing
+	}
+
+	node, ok := m.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return node.data, &zk.Stat{Version: node.version}, nil
+}
+
+func (m *mockConn) GetW(p string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return nil, nil, nil, zk.ErrClosing
+	}
+
+	node, ok := m.nodes[p]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return node.data, &zk.Stat{Version: node.version}, m.addWatch(m.dataWatches, p), nil
+}
+
+func (m *mockConn) ExistsW(p string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return false, nil, nil, zk.ErrClosing
+	}
+
+	events := m.addWatch(m.dataWatches, p)
+	node, ok := m.nodes[p]
+	if !ok {
+		return false, nil, events, nil
+	}
+	return true, &zk.Stat{Version: node.version}, events, nil
+}
+
+func (m *mockConn) Children(p string) ([]string, *zk.Stat, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return nil, nil, zk.ErrClosing
+	}
+
+	node, ok := m.nodes[p]
+	if !ok {
+		return nil, nil, zk.ErrNoNode
+	}
+	return m.children(p), &zk.Stat{Version: node.version}, nil
+}
+
+func (m *mockConn) ChildrenW(p string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return nil, nil, nil, zk.ErrClosing
+	}
+
+	node, ok := m.nodes[p]
+	if !ok {
+		return nil, nil, nil, zk.ErrNoNode
+	}
+	return m.children(p), &zk.Stat{Version: node.version}, m.addWatch(m.childrenWatches, p), nil
+}
+
+func (m *mockConn) Create(p string, data []byte, _ int32, _ []zk.ACL) (string, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return "", zk.ErrClosing
+	}
+
+	if _, ok := m.nodes[p]; ok {
+		return "", zk.ErrNodeExists
+	}
+	if _, ok := m.nodes[path.Dir(p)]; !ok {
+		return "", zk.ErrNoNode
+	}
+
+	m.nodes[p] = &mockNode{data: data}
+	m.fireWatches(m.dataWatches, p, zk.EventNodeCreated)
+	m.fireWatches(m.childrenWatches, path.Dir(p), zk.EventNodeChildrenChanged)
+	return p, nil
+}
+
+func (m *mockConn) Set(p string, data []byte, version int32) (*zk.Stat, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return nil, zk.ErrClosing
+	}
+
+	node, ok := m.nodes[p]
+	if !ok {
+		return nil, zk.ErrNoNode
+	}
+	if version != -1 && version != node.version {
+		return nil, zk.ErrBadVersion
+	}
+
+	node.data = data
+	node.version++
+	m.fireWatches(m.dataWatches, p, zk.EventNodeDataChanged)
+	return &zk.Stat{Version: node.version}, nil
+}
+
+func (m *mockConn) Delete(p string, version int32) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return zk.ErrClosing
+	}
+
+	node, ok := m.nodes[p]
+	if !ok {
+		return zk.ErrNoNode
+	}
+	if version != -1 && version != node.version {
+		return zk.ErrBadVersion
+	}
+	if len(m.children(p)) > 0 {
+		return zk.ErrNotEmpty
+	}
+
+	delete(m.nodes, p)
+	m.fireWatches(m.dataWatches, p, zk.EventNodeDeleted)
+	m.fireWatches(m.childrenWatches, path.Dir(p), zk.EventNodeChildrenChanged)
+	return nil
+}
+
+func (m *mockConn) Close() {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	if m.closed {
+		return
+	}
+	m.closed = true
+
+	for p := range m.dataWatches {
+		m.fireWatches(m.dataWatches, p, zk.EventNotWatching)
+	}
+	for p := range m.childrenWatches {
+		m.fireWatches(m.childrenWatches, p, zk.EventNotWatching)
+	}
+}
+
+// mockCloser adapts the mockConn to io.Closer.
+type mockCloser struct {
+	conn *mockConn
+}
+
+func (c mockCloser) Close() error {
+	c.conn.Close()
+	return nil
+}
+
+// children returns the names of the children of the node. Must be called with the lock held.
+func (m *mockConn) children(p string) []string {
+	var children []string
+	for child := range m.nodes {
+		if child != "/" && path.Dir(child) == p {
+			children = append(children, path.Base(child))
+		}
+	}
+	return children
+}
+
+// addWatch registers a one-shot watch on the node. Must be called with the lock held.
+func (m *mockConn) addWatch(watches map[string][]chan zk.Event, p string) <-chan zk.Event {
+	events := make(chan zk.Event, 1)
+	watches[p] = append(watches[p], events)
+	return events
+}
+
+// fireWatches fires and removes the watches of the node. Must be called with the lock held.
+func (m *mockConn) fireWatches(watches map[string][]chan zk.Event, p string, eventType zk.EventType) {
+	for _, events := range watches[p] {
+		events <- zk.Event{Type: eventType, Path: p}
+		close(events)
+	}
+	delete(watches, p)
+}
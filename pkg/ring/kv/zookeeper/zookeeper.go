@@ -0,0 +1,460 @@
+package zookeeper
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-zookeeper/zk"
+	"github.com/pkg/errors"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util/backoff"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+var (
+	errEmptyKey            = errors.New("zookeeper: the key must not be empty")
+	errInvalidRootPath     = errors.New("zookeeper: the root path must start with a / and must not end with a /")
+	errNoServersConfigured = errors.New("zookeeper: no servers configured")
+)
+
+// Config for a new zookeeper.Client.
+type Config struct {
+	Servers        []string      `yaml:"servers"`
+	SessionTimeout time.Duration `yaml:"session_timeout"`
+	RootPath       string        `yaml:"root_path"`
+	MaxRetries     int           `yaml:"max_retries"`
+	UserName       string        `yaml:"username"`
+	Password       string        `yaml:"password"`
+}
+
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	cfg.RegisterFlagsWithPrefix(f, "")
+}
+
+// RegisterFlagsWithPrefix adds the flags required to config this to the given FlagSet.
+func (cfg *Config) RegisterFlagsWithPrefix(f *flag.FlagSet, prefix string) {
+	cfg.Servers = []string{}
+	f.Var((*flagext.StringSlice)(&cfg.Servers), prefix+"zookeeper.servers", "The Zookeeper servers to connect to.")
+	f.DurationVar(&cfg.SessionTimeout, prefix+"zookeeper.session-timeout", 10*time.Second, "The Zookeeper session timeout.")
+	f.StringVar(&cfg.RootPath, prefix+"zookeeper.root-path", "/cortex", "The Zookeeper node under which the keys are stored. It's created if it doesn't exist.")
+	f.IntVar(&cfg.MaxRetries, prefix+"zookeeper.max-retries", 10, "The maximum number of retries to do for failed ops.")
+	f.StringVar(&cfg.UserName, prefix+"zookeeper.username", "", "Zookeeper username, used for the digest authentication. If set, the nodes are only accessible by the authenticated user.")
+	f.StringVar(&cfg.Password, prefix+"zookeeper.password", "", "Zookeeper password, used for the digest authentication.")
+}
+
+// Conn is the subset of the Zookeeper connection operations required to implement
+// a Zookeeper version of kv.Client.
+type Conn interface {
+	Get(path string) ([]byte, *zk.Stat, error)
+	GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error)
+	ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error)
+	Children(path string) ([]string, *zk.Stat, error)
+	ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error)
+	Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error)
+	Set(path string, data []byte, version int32) (*zk.Stat, error)
+	Delete(path string, version int32) error
+	Close()
+}
+
+// Client implements kv.Client for Zookeeper.
+//
+// The keys are stored as children of the root path node, with their name escaped, so
+// that any key can be stored regardless of the Zookeeper hierarchical namespace, and
+// the keys with a given prefix can be listed and watched by only listing the children
+// of the root node. The Zookeeper watches are one-shot, so they're set again each time
+// they fire.
+type Client struct {
+	cfg    Config
+	codec  codec.Codec
+	conn   Conn
+	acl    []zk.ACL
+	logger log.Logger
+}
+
+// New makes a new Client.
+func New(cfg Config, codec codec.Codec, logger log.Logger) (*Client, error) {
+	if len(cfg.Servers) == 0 {
+		return nil, errNoServersConfigured
+	}
+	if !strings.HasPrefix(cfg.RootPath, "/") || strings.HasSuffix(cfg.RootPath, "/") {
+		return nil, errInvalidRootPath
+	}
+
+	conn, _, err := zk.Connect(cfg.Servers, cfg.SessionTimeout, zk.WithLogger(zkLogger{logger}))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to connect to Zookeeper")
+	}
+
+	acl := zk.WorldACL(zk.PermAll)
+	if cfg.UserName != "" {
+		if err := conn.AddAuth("digest", []byte(cfg.UserName+":"+cfg.Password)); err != nil {
+			conn.Close()
+			return nil, errors.Wrap(err, "unable to authenticate to Zookeeper")
+		}
+		acl = zk.DigestACL(zk.PermAll, cfg.UserName, cfg.Password)
+	}
+
+	return newClient(cfg, codec, conn, acl, logger)
+}
+
+func newClient(cfg Config, codec codec.Codec, conn Conn, acl []zk.ACL, logger log.Logger) (*Client, error) {
+	c := &Client{
+		cfg:    cfg,
+		codec:  codec,
+		conn:   conn,
+		acl:    acl,
+		logger: logger,
+	}
+
+	if err := c.createRootPath(); err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "unable to create the Zookeeper root path %s", cfg.RootPath)
+	}
+	return c, nil
+}
+
+// createRootPath creates the root path node and its parents if they don't exist.
+func (c *Client) createRootPath() error {
+	path := ""
+	for _, node := range strings.Split(strings.TrimPrefix(c.cfg.RootPath, "/"), "/") {
+		path += "/" + node
+		if _, err := c.conn.Create(path, nil, 0, c.acl); err != nil && !errors.Is(err, zk.ErrNodeExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+// CAS implements kv.Client.
+func (c *Client) CAS(ctx context.Context, key string, f func(in interface{}) (out interface{}, retry bool, err error)) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+
+	for i := 0; i < c.cfg.MaxRetries; i++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		var intermediate interface{}
+		exists := true
+		data, stat, err := c.conn.Get(path)
+		switch {
+		case errors.Is(err, zk.ErrNoNode):
+			exists = false
+		case err != nil:
+			level.Error(c.logger).Log("msg", "error getting key", "key", key, "err", err)
+			lastErr = err
+			continue
+		default:
+			intermediate, err = c.codec.Decode(data)
+			if err != nil {
+				level.Error(c.logger).Log("msg", "error decoding key", "key", key, "err", err)
+				lastErr = err
+				continue
+			}
+		}
+
+		var retry bool
+		intermediate, retry, err = f(intermediate)
+		if err != nil {
+			if !retry {
+				return err
+			}
+			lastErr = err
+			continue
+		}
+
+		// Callback returning nil means it doesn't want to CAS anymore.
+		if intermediate == nil {
+			return nil
+		}
+
+		buf, err := c.codec.Encode(intermediate)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "error serialising value", "key", key, "err", err)
+			lastErr = err
+			continue
+		}
+
+		if exists {
+			_, err = c.conn.Set(path, buf, stat.Version)
+		} else {
+			_, err = c.conn.Create(path, buf, 0, c.acl)
+		}
+
+		// The node has been modified, or created, in the meanwhile.
+		if errors.Is(err, zk.ErrBadVersion) || errors.Is(err, zk.ErrNodeExists) || errors.Is(err, zk.ErrNoNode) {
+			level.Debug(c.logger).Log("msg", "failed to CAS, the node has been modified in the meanwhile", "key", key)
+			continue
+		}
+		if err != nil {
+			level.Error(c.logger).Log("msg", "error CASing", "key", key, "err", err)
+			lastErr = err
+			continue
+		}
+
+		return nil
+	}
+
+	if lastErr != nil {
+		return lastErr
+	}
+	return fmt.Errorf("failed to CAS %s", key)
+}
+
+// WatchKey implements kv.Client.
+func (c *Client) WatchKey(ctx context.Context, key string, f func(interface{}) bool) {
+	path, err := c.path(key)
+	if err != nil {
+		level.Error(c.logger).Log("msg", "invalid key to watch", "key", key, "err", err)
+		return
+	}
+
+	backoff := backoff.New(ctx, backoff.Config{
+		MinBackoff: 1 * time.Second,
+		MaxBackoff: 1 * time.Minute,
+	})
+
+	level.Debug(c.logger).Log("msg", "Watching key", "key", key)
+	defer level.Debug(c.logger).Log("msg", "Finished watching key", "key", key)
+
+	// The last version of the key notified, used to skip the notifications of unchanged values
+	// after the watch gets set again. -1 if the key doesn't exist.
+	lastVersion := int32(-1)
+
+	for backoff.Ongoing() {
+		data, stat, events, err := c.conn.GetW(path)
+		if errors.Is(err, zk.ErrNoNode) {
+			// Wait for the key to be created.
+			var exists bool
+			exists, _, events, err = c.conn.ExistsW(path)
+			if err == nil && exists {
+				// The key has been created in the meanwhile.
+				continue
+			}
+			lastVersion = -1
+		}
+		if err != nil {
+			level.Error(c.logger).Log("msg", "watch error", "key", key, "err", err)
+			backoff.Wait()
+			continue
+		}
+
+		backoff.Reset()
+
+		if stat != nil && stat.Version != lastVersion {
+			lastVersion = stat.Version
+
+			out, err := c.codec.Decode(data)
+			if err != nil {
+				level.Error(c.logger).Log("msg", "error decoding key", "key", key, "err", err)
+			} else if !f(out) {
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+		}
+	}
+}
+
+// WatchPrefix implements kv.Client.
+func (c *Client) WatchPrefix(ctx context.Context, prefix string, f func(string, interface{}) bool) {
+	backoff := backoff.New(ctx, backoff.Config{
+		MinBackoff: 1 * time.Second,
+		MaxBackoff: 1 * time.Minute,
+	})
+
+	level.Debug(c.logger).Log("msg", "Watching prefix", "key", prefix)
+	defer level.Debug(c.logger).Log("msg", "Finished watching prefix", "key", prefix)
+
+	// The watches fired, identified by the key, or by an empty string for the root node children.
+	fired := make(chan string, 1)
+	forward := func(key string, events <-chan zk.Event) {
+		select {
+		case <-ctx.Done():
+		case <-events:
+			select {
+			case <-ctx.Done():
+			case fired <- key:
+			}
+		}
+	}
+
+	var (
+		watchingChildren bool
+		watching         = map[string]bool{}  // Whether a watch is set for the key.
+		lastVersions     = map[string]int32{} // The last version of the key notified.
+	)
+
+	// watchKey sets the watch on the key, and notifies its value if it changed.
+	watchKey := func(key string) (bool, error) {
+		data, stat, events, err := c.conn.GetW(c.escapedPath(key))
+		if errors.Is(err, zk.ErrNoNode) {
+			// Deletions are not notified, given not all KV store clients (and codecs) support them.
+			delete(watching, key)
+			delete(lastVersions, key)
+			return true, nil
+		}
+		if err != nil {
+			return true, err
+		}
+
+		watching[key] = true
+		go forward(key, events)
+
+		if version, ok := lastVersions[key]; ok && version == stat.Version {
+			return true, nil
+		}
+		lastVersions[key] = stat.Version
+
+		out, err := c.codec.Decode(data)
+		if err != nil {
+			level.Error(c.logger).Log("msg", "error decoding key", "key", key, "err", err)
+			return true, nil
+		}
+		return f(key, out), nil
+	}
+
+	for backoff.Ongoing() {
+		if !watchingChildren {
+			children, _, events, err := c.conn.ChildrenW(c.cfg.RootPath)
+			if err != nil {
+				level.Error(c.logger).Log("msg", "watch error", "key", prefix, "err", err)
+				backoff.Wait()
+				continue
+			}
+
+			watchingChildren = true
+			go forward("", events)
+
+			for _, child := range children {
+				key, err := url.PathUnescape(child)
+				if err != nil || !strings.HasPrefix(key, prefix) || watching[key] {
+					continue
+				}
+
+				if cont, err := watchKey(key); err != nil {
+					level.Error(c.logger).Log("msg", "watch error", "key", key, "err", err)
+					// Make sure the children get listed again.
+					watchingChildren = false
+				} else if !cont {
+					return
+				}
+			}
+		}
+
+		backoff.Reset()
+
+		select {
+		case <-ctx.Done():
+			return
+		case key := <-fired:
+			if key == "" {
+				watchingChildren = false
+				continue
+			}
+
+			watching[key] = false
+			if cont, err := watchKey(key); err != nil {
+				level.Error(c.logger).Log("msg", "watch error", "key", key, "err", err)
+				// The key gets watched again once the children are listed again.
+				delete(watching, key)
+				watchingChildren = false
+				backoff.Wait()
+			} else if !cont {
+				return
+			}
+		}
+	}
+}
+
+// List implements kv.Client.
+func (c *Client) List(_ context.Context, prefix string) ([]string, error) {
+	children, _, err := c.conn.Children(c.cfg.RootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(children))
+	for _, child := range children {
+		key, err := url.PathUnescape(child)
+		if err != nil {
+			continue
+		}
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Get implements kv.Client.
+func (c *Client) Get(_ context.Context, key string) (interface{}, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, _, err := c.conn.Get(path)
+	if errors.Is(err, zk.ErrNoNode) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.codec.Decode(data)
+}
+
+// Delete implements kv.Client.
+func (c *Client) Delete(_ context.Context, key string) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := c.conn.Delete(path, -1); err != nil && !errors.Is(err, zk.ErrNoNode) {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) LastUpdateTime(_ string) time.Time {
+	return time.Now().UTC()
+}
+
+// path returns the path of the node storing the key.
+func (c *Client) path(key string) (string, error) {
+	if key == "" {
+		return "", errEmptyKey
+	}
+	return c.escapedPath(key), nil
+}
+
+func (c *Client) escapedPath(key string) string {
+	// The dots are escaped too, given the "." and ".." node names are reserved.
+	return c.cfg.RootPath + "/" + strings.ReplaceAll(url.PathEscape(key), ".", "%2E")
+}
+
+// zkLogger adapts the logger to the Zookeeper client logger.
+type zkLogger struct {
+	logger log.Logger
+}
+
+func (l zkLogger) Printf(format string, args ...interface{}) {
+	level.Debug(l.logger).Log("msg", fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,74 @@
+package zookeeper
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/go-zookeeper/zk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cortexproject/cortex/pkg/ring/kv/codec"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+func TestClient_ShouldCreateTheRootPath(t *testing.T) {
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.RootPath = "/cortex/ring"
+
+	conn := newMockConn()
+	_, err := newClient(cfg, codec.String{}, conn, zk.WorldACL(zk.PermAll), log.NewNopLogger())
+	require.NoError(t, err)
+
+	children, _, err := conn.Children("/cortex")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ring"}, children)
+
+	// Creating the client again should not fail if the root path already exists.
+	_, err = newClient(cfg, codec.String{}, conn, zk.WorldACL(zk.PermAll), log.NewNopLogger())
+	require.NoError(t, err)
+}
+
+func TestClient_ShouldEscapeTheKeys(t *testing.T) {
+	ctx := context.Background()
+	client, closer := NewInMemoryClient(codec.String{}, log.NewNopLogger())
+	t.Cleanup(func() { _ = closer.Close() })
+
+	keys := []string{"collectors/ring", "..", "a b/.c"}
+	for _, key := range keys {
+		require.NoError(t, client.CAS(ctx, key, func(interface{}) (interface{}, bool, error) {
+			return key, false, nil
+		}))
+	}
+
+	for _, key := range keys {
+		value, err := client.Get(ctx, key)
+		require.NoError(t, err)
+		assert.Equal(t, key, value)
+	}
+
+	listed, err := client.List(ctx, "")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, keys, listed)
+
+	listed, err = client.List(ctx, "collectors/")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collectors/ring"}, listed)
+
+	require.NoError(t, client.Delete(ctx, "collectors/ring"))
+	require.NoError(t, client.Delete(ctx, "collectors/ring"))
+
+	value, err := client.Get(ctx, "collectors/ring")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+}
+
+func TestClient_ShouldRejectEmptyKeys(t *testing.T) {
+	client, closer := NewInMemoryClient(codec.String{}, log.NewNopLogger())
+	t.Cleanup(func() { _ = closer.Close() })
+
+	_, err := client.Get(context.Background(), "")
+	assert.Equal(t, errEmptyKey, err)
+}
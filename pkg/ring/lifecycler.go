@@ -25,6 +25,7 @@ import (
 
 var (
 	errInvalidTokensGeneratorStrategy = errors.New("invalid token generator strategy")
+	errInvalidHandoffWindow           = errors.New("the handoff window must be greater than or equal to 0")
 )
 
 // LifecyclerConfig is the config to build a Lifecycler.
@@ -44,6 +45,7 @@ type LifecyclerConfig struct {
 	Zone                     string        `yaml:"availability_zone"`
 	UnregisterOnShutdown     bool          `yaml:"unregister_on_shutdown"`
 	ReadinessCheckRingHealth bool          `yaml:"readiness_check_ring_health"`
+	HandoffWindow            time.Duration `yaml:"handoff_window"`
 
 	// For testing, you can override the address and ID of this ingester
 	Addr string `yaml:"address" doc:"hidden"`
@@ -91,12 +93,16 @@ func (cfg *LifecyclerConfig) RegisterFlagsWithPrefix(prefix string, f *flag.Flag
 	f.StringVar(&cfg.Zone, prefix+"availability-zone", "", "The availability zone where this instance is running.")
 	f.BoolVar(&cfg.UnregisterOnShutdown, prefix+"unregister-on-shutdown", true, "Unregister from the ring upon clean shutdown. It can be useful to disable for rolling restarts with consistent naming in conjunction with -distributor.extend-writes=false.")
 	f.BoolVar(&cfg.ReadinessCheckRingHealth, prefix+"readiness-check-ring-health", true, "When enabled the readiness probe succeeds only after all instances are ACTIVE and healthy in the ring, otherwise only the instance itself is checked. This option should be disabled if in your cluster multiple instances can be rolled out simultaneously, otherwise rolling updates may be slowed down.")
+	f.DurationVar(&cfg.HandoffWindow, prefix+"handoff-window", 0, "EXPERIMENTAL: When greater than 0, the instance stays LEAVING in the ring on shutdown and keeps serving reads for its tokens until a new instance has registered to the ring and this period has elapsed since then, or until this period has elapsed without any new instance registering. Applies only when unregistering on shutdown. 0 to disable.")
 }
 
 func (cfg *LifecyclerConfig) Validate() error {
 	if cfg.TokensGeneratorStrategy != "" && !slices.Contains(supportedTokenStrategy, strings.ToLower(cfg.TokensGeneratorStrategy)) {
 		return errInvalidTokensGeneratorStrategy
 	}
+	if cfg.HandoffWindow < 0 {
+		return errInvalidHandoffWindow
+	}
 
 	return nil
 }
@@ -132,6 +138,10 @@ type Lifecycler struct {
 	state        InstanceState
 	tokens       Tokens
 	registeredAt time.Time
+	handoffAt    time.Time
+
+	// Whether the instance is waiting for the handoff window to elapse on shutdown.
+	handingOff *atomic.Bool
 
 	// Controls the ready-reporting
 	readyLock  sync.Mutex
@@ -201,6 +211,7 @@ func NewLifecycler(
 		autoJoinOnStartup:    autoJoinOnStartup,
 		flushOnShutdown:      atomic.NewBool(flushOnShutdown),
 		unregisterOnShutdown: atomic.NewBool(cfg.UnregisterOnShutdown),
+		handingOff:           atomic.NewBool(false),
 		Zone:                 zone,
 		actorChan:            make(chan func()),
 		autojoinChan:         make(chan struct{}, 1),
@@ -363,6 +374,18 @@ func (i *Lifecycler) setRegisteredAt(registeredAt time.Time) {
 	i.registeredAt = registeredAt
 }
 
+func (i *Lifecycler) getHandoffAt() time.Time {
+	i.stateMtx.RLock()
+	defer i.stateMtx.RUnlock()
+	return i.handoffAt
+}
+
+func (i *Lifecycler) setHandoffAt(handoffAt time.Time) {
+	i.stateMtx.Lock()
+	defer i.stateMtx.Unlock()
+	i.handoffAt = handoffAt
+}
+
 // ClaimTokensFor takes all the tokens for the supplied ingester and assigns them to this ingester.
 //
 // For this method to work correctly (especially when using gossiping), source ingester (specified by
@@ -841,6 +864,10 @@ func (i *Lifecycler) updateConsul(ctx context.Context) error {
 			instanceDesc.Addr = i.Addr
 			instanceDesc.Zone = i.Zone
 			instanceDesc.RegisteredTimestamp = i.getRegisteredAt().Unix()
+			instanceDesc.HandoffTimestamp = 0
+			if handoffAt := i.getHandoffAt(); !handoffAt.IsZero() {
+				instanceDesc.HandoffTimestamp = handoffAt.Unix()
+			}
 			ringDesc.Ingesters[i.ID] = instanceDesc
 		}
 
@@ -926,6 +953,12 @@ func (i *Lifecycler) SetUnregisterOnShutdown(enabled bool) {
 	i.unregisterOnShutdown.Store(enabled)
 }
 
+// IsHandingOff returns true while the instance is LEAVING and waiting for the handoff
+// window to elapse on shutdown. In the meanwhile the instance should keep serving reads.
+func (i *Lifecycler) IsHandingOff() bool {
+	return i.handingOff.Load()
+}
+
 func (i *Lifecycler) processShutdown(ctx context.Context) {
 	flushRequired := i.flushOnShutdown.Load()
 
@@ -935,11 +968,84 @@ func (i *Lifecycler) processShutdown(ctx context.Context) {
 		i.lifecyclerMetrics.shutdownDuration.WithLabelValues("flush", "success").Observe(time.Since(flushStart).Seconds())
 	}
 
+	if i.cfg.HandoffWindow > 0 && i.ShouldUnregisterOnShutdown() {
+		i.waitHandoff(ctx)
+	}
+
 	// Sleep so the shutdownDuration metric can be collected.
 	level.Info(i.logger).Log("msg", "lifecycler entering final sleep before shutdown", "final_sleep", i.cfg.FinalSleep)
 	time.Sleep(i.cfg.FinalSleep)
 }
 
+// waitHandoff keeps the instance LEAVING in the ring, so that it keeps serving reads for its
+// tokens, until a new instance has registered to the ring and the handoff window has elapsed
+// since then. If no new instance registers within the handoff window, it returns once the
+// window has elapsed since the beginning of the wait.
+func (i *Lifecycler) waitHandoff(ctx context.Context) {
+	i.handingOff.Store(true)
+	defer i.handingOff.Store(false)
+
+	start := time.Now()
+	level.Info(i.logger).Log("msg", "waiting for a new instance to register to the ring before leaving", "handoff_window", i.cfg.HandoffWindow, "ring", i.RingName)
+
+	pollPeriod := i.cfg.HeartbeatPeriod
+	if pollPeriod <= 0 || pollPeriod > i.cfg.HandoffWindow {
+		pollPeriod = i.cfg.HandoffWindow
+	}
+
+	ticker := time.NewTicker(pollPeriod)
+	defer ticker.Stop()
+
+	deadline := start.Add(i.cfg.HandoffWindow)
+	for time.Now().Before(deadline) {
+		if i.getHandoffAt().IsZero() && i.hasNewInstanceRegisteredSince(ctx, start) {
+			handoffAt := time.Now()
+			i.setHandoffAt(handoffAt)
+			if err := i.updateConsul(ctx); err != nil {
+				level.Error(i.logger).Log("msg", "failed to mark the handoff in the KV store", "ring", i.RingName, "err", err)
+			}
+
+			level.Info(i.logger).Log("msg", "a new instance registered to the ring, handing off tokens", "handoff_window", i.cfg.HandoffWindow, "ring", i.RingName)
+			deadline = handoffAt.Add(i.cfg.HandoffWindow)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+
+	status := "success"
+	if i.getHandoffAt().IsZero() {
+		status = "timeout"
+		level.Info(i.logger).Log("msg", "no new instance registered to the ring within the handoff window", "ring", i.RingName)
+	}
+	i.lifecyclerMetrics.shutdownDuration.WithLabelValues("handoff", status).Observe(time.Since(start).Seconds())
+}
+
+// hasNewInstanceRegisteredSince returns true if an ACTIVE instance other than this one has
+// registered to the ring after the input time.
+func (i *Lifecycler) hasNewInstanceRegisteredSince(ctx context.Context, since time.Time) bool {
+	desc, err := i.KVStore.Get(ctx, i.RingKey)
+	if err != nil {
+		level.Error(i.logger).Log("msg", "error talking to the KV store", "ring", i.RingName, "err", err)
+		return false
+	}
+
+	ringDesc, ok := desc.(*Desc)
+	if !ok || ringDesc == nil {
+		return false
+	}
+
+	for id, instance := range ringDesc.Ingesters {
+		if id != i.ID && instance.State == ACTIVE && !instance.GetRegisteredAt().Before(since.Truncate(time.Second)) {
+			return true
+		}
+	}
+	return false
+}
+
 // unregister removes our entry from consul.
 func (i *Lifecycler) unregister(ctx context.Context) error {
 	level.Debug(i.logger).Log("msg", "unregistering instance from ring", "ring", i.RingName)
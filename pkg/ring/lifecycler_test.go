@@ -280,6 +280,76 @@ func TestLifecycler_TwoRingsWithDifferentKeysOnTheSameKVStore(t *testing.T) {
 	})
 }
 
+func TestLifecycler_HandoffWindow(t *testing.T) {
+	tests := map[string]struct {
+		newInstanceRegisters bool
+	}{
+		"should leave after the handoff window if no new instance registers": {
+			newInstanceRegisters: false,
+		},
+		"should leave after the handoff window since a new instance registered": {
+			newInstanceRegisters: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			ringStore, closer := consul.NewInMemoryClient(GetCodec(), log.NewNopLogger(), nil)
+			t.Cleanup(func() { assert.NoError(t, closer.Close()) })
+
+			var ringConfig Config
+			flagext.DefaultValues(&ringConfig)
+			ringConfig.KVStore.Mock = ringStore
+
+			lifecyclerConfig := testLifecyclerConfig(ringConfig, "ing1")
+			lifecyclerConfig.HandoffWindow = time.Second
+
+			l1, err := NewLifecycler(lifecyclerConfig, &nopFlushTransferer{}, "ingester", ringKey, true, true, log.NewNopLogger(), nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(ctx, l1))
+
+			test.Poll(t, time.Second, ACTIVE, func() interface{} {
+				return l1.GetState()
+			})
+
+			// Stop the instance and check it keeps its tokens in the ring while handing off.
+			l1.StopAsync()
+			test.Poll(t, time.Second, true, func() interface{} {
+				return l1.IsHandingOff()
+			})
+
+			d, err := ringStore.Get(ctx, ringKey)
+			require.NoError(t, err)
+			instance := d.(*Desc).Ingesters["ing1"]
+			assert.Equal(t, LEAVING, instance.State)
+			assert.Len(t, instance.Tokens, 1)
+
+			if testData.newInstanceRegisters {
+				l2, err := NewLifecycler(testLifecyclerConfig(ringConfig, "ing2"), &nopFlushTransferer{}, "ingester", ringKey, true, true, log.NewNopLogger(), nil)
+				require.NoError(t, err)
+				require.NoError(t, services.StartAndAwaitRunning(ctx, l2))
+				defer services.StopAndAwaitTerminated(ctx, l2) //nolint:errcheck
+
+				// The handoff should be marked in the ring.
+				test.Poll(t, 2*time.Second, true, func() interface{} {
+					d, err := ringStore.Get(ctx, ringKey)
+					require.NoError(t, err)
+					return d.(*Desc).Ingesters["ing1"].HandoffTimestamp > 0
+				})
+				assert.True(t, l1.IsHandingOff())
+			}
+
+			require.NoError(t, l1.AwaitTerminated(ctx))
+			assert.False(t, l1.IsHandingOff())
+
+			d, err = ringStore.Get(ctx, ringKey)
+			require.NoError(t, err)
+			assert.NotContains(t, d.(*Desc).Ingesters, "ing1")
+		})
+	}
+}
+
 type nopFlushTransferer struct{}
 
 func (f *nopFlushTransferer) Flush() {}
@@ -25,9 +25,16 @@ func ProtoDescFactory() proto.Message {
 	return NewDesc()
 }
 
+// descMigrations are the Desc schema migrations, see codec.Versioned. The write version
+// must only be bumped once all the clients know about the new migration.
+var (
+	descMigrations   []codec.Migration
+	descWriteVersion = 0
+)
+
 // GetCodec returns the codec used to encode and decode data being put by ring.
 func GetCodec() codec.Codec {
-	return codec.NewProtoCodec("ringDesc", ProtoDescFactory)
+	return codec.NewVersionedCodec(codec.NewProtoCodec("ringDesc", ProtoDescFactory), descMigrations, descWriteVersion)
 }
 
 // NewDesc returns an empty ring.Desc
@@ -592,6 +599,16 @@ func (d *Desc) RingCompare(o *Desc) CompareResult {
 		if ing.State != oing.State {
 			equalStatesAndTimestamps = false
 		}
+
+		// The blocks sync percentage is updated on heartbeat, like the timestamp,
+		// and doesn't change the ring topology.
+		if ing.BlocksSyncPercentage != oing.BlocksSyncPercentage {
+			equalStatesAndTimestamps = false
+		}
+
+		if ing.HandoffTimestamp != oing.HandoffTimestamp {
+			equalStatesAndTimestamps = false
+		}
 	}
 
 	if equalStatesAndTimestamps {
@@ -124,6 +124,41 @@ func (r ReplicationSet) GetAddressesWithout(exclude string) []string {
 	return addrs
 }
 
+// ZoneQuorum returns the replication set of the instances of the zone and of the next zones, in sorted
+// order, needed to reach the quorum of zones. All of its instances must succeed. A write succeeds once
+// written to a quorum of zones, so any sample written is held by at least one of these zones. It returns
+// false if the replication set isn't zone-aware or has no instance in the zone, in which case the zone
+// has been excluded from the replication set because of unhealthy instances.
+func (r ReplicationSet) ZoneQuorum(zone string) (ReplicationSet, bool) {
+	if r.MaxUnavailableZones == 0 {
+		return ReplicationSet{}, false
+	}
+
+	byZone := map[string][]InstanceDesc{}
+	for _, instance := range r.Instances {
+		byZone[instance.Zone] = append(byZone[instance.Zone], instance)
+	}
+	if len(byZone[zone]) == 0 {
+		return ReplicationSet{}, false
+	}
+
+	zones := make([]string, 0, len(byZone))
+	for z := range byZone {
+		zones = append(zones, z)
+	}
+	sort.Strings(zones)
+
+	// Start from the zone, so that the queries of each zone are spread to the next ones.
+	start := sort.SearchStrings(zones, zone)
+	quorum := len(zones) - r.MaxUnavailableZones
+	instances := make([]InstanceDesc, 0, len(r.Instances))
+	for i := 0; i < quorum; i++ {
+		instances = append(instances, byZone[zones[(start+i)%len(zones)]]...)
+	}
+
+	return ReplicationSet{Instances: instances}, true
+}
+
 // GetNumOfZones returns number of distinct zones.
 func (r ReplicationSet) GetNumOfZones() int {
 	set := make(map[string]struct{})
@@ -138,6 +173,8 @@ func (r ReplicationSet) GetNumOfZones() int {
 func HasReplicationSetChanged(before, after ReplicationSet) bool {
 	return hasReplicationSetChangedExcluding(before, after, func(i *InstanceDesc) {
 		i.Timestamp = 0
+		i.BlocksSyncPercentage = 0
+		i.HandoffTimestamp = 0
 	})
 }
 
@@ -148,6 +185,8 @@ func HasReplicationSetChangedWithoutState(before, after ReplicationSet) bool {
 	return hasReplicationSetChangedExcluding(before, after, func(i *InstanceDesc) {
 		i.Timestamp = 0
 		i.State = PENDING
+		i.BlocksSyncPercentage = 0
+		i.HandoffTimestamp = 0
 	})
 }
 
@@ -81,6 +81,35 @@ func TestReplicationSet_GetAddressesWithout(t *testing.T) {
 	}
 }
 
+func TestReplicationSet_ZoneQuorum(t *testing.T) {
+	rs := ReplicationSet{
+		Instances: []InstanceDesc{
+			{Addr: "127.0.0.1", Zone: "zone-a"},
+			{Addr: "127.0.0.2", Zone: "zone-a"},
+			{Addr: "127.0.0.3", Zone: "zone-b"},
+			{Addr: "127.0.0.4", Zone: "zone-c"},
+		},
+		MaxUnavailableZones: 1,
+	}
+
+	quorum, ok := rs.ZoneQuorum("zone-a")
+	require.True(t, ok)
+	assert.Equal(t, ReplicationSet{Instances: rs.Instances[:3]}, quorum)
+
+	// The next zones wrap around.
+	quorum, ok = rs.ZoneQuorum("zone-c")
+	require.True(t, ok)
+	assert.Equal(t, ReplicationSet{Instances: []InstanceDesc{rs.Instances[3], rs.Instances[0], rs.Instances[1]}}, quorum)
+
+	// The zone has been excluded from the replication set.
+	_, ok = rs.ZoneQuorum("zone-d")
+	assert.False(t, ok)
+
+	// The replication set isn't zone-aware.
+	_, ok = ReplicationSet{Instances: rs.Instances, MaxErrors: 1}.ZoneQuorum("zone-a")
+	assert.False(t, ok)
+}
+
 var (
 	errFailure     = errors.New("failed")
 	errZoneFailure = errors.New("zone failed")
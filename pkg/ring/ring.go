@@ -134,12 +134,14 @@ var (
 
 // Config for a Ring
 type Config struct {
-	KVStore                kv.Config              `yaml:"kvstore"`
-	HeartbeatTimeout       time.Duration          `yaml:"heartbeat_timeout"`
-	ReplicationFactor      int                    `yaml:"replication_factor"`
-	ZoneAwarenessEnabled   bool                   `yaml:"zone_awareness_enabled"`
-	ExcludedZones          flagext.StringSliceCSV `yaml:"excluded_zones"`
-	DetailedMetricsEnabled bool                   `yaml:"detailed_metrics_enabled"`
+	KVStore                    kv.Config              `yaml:"kvstore"`
+	HeartbeatTimeout           time.Duration          `yaml:"heartbeat_timeout"`
+	ReplicationFactor          int                    `yaml:"replication_factor"`
+	MinTenantReplicationFactor int                    `yaml:"tenant_replication_factor_min"`
+	MaxTenantReplicationFactor int                    `yaml:"tenant_replication_factor_max"`
+	ZoneAwarenessEnabled       bool                   `yaml:"zone_awareness_enabled"`
+	ExcludedZones              flagext.StringSliceCSV `yaml:"excluded_zones"`
+	DetailedMetricsEnabled     bool                   `yaml:"detailed_metrics_enabled"`
 
 	// Whether the shuffle-sharding subring cache is disabled. This option is set
 	// internally and never exposed to the user.
@@ -158,6 +160,8 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&cfg.HeartbeatTimeout, prefix+"ring.heartbeat-timeout", time.Minute, "The heartbeat timeout after which ingesters are skipped for reads/writes. 0 = never (timeout disabled).")
 	f.BoolVar(&cfg.DetailedMetricsEnabled, prefix+"ring.detailed-metrics-enabled", true, "Set to true to enable ring detailed metrics. These metrics provide detailed information, such as token count and ownership per tenant. Disabling them can significantly decrease the number of metrics emitted by the distributors.")
 	f.IntVar(&cfg.ReplicationFactor, prefix+"distributor.replication-factor", 3, "The number of ingesters to write to and read from.")
+	f.IntVar(&cfg.MinTenantReplicationFactor, prefix+"distributor.tenant-replication-factor-min", 1, "The minimum replication factor a tenant can be configured with through the per-tenant ingestion replication factor override.")
+	f.IntVar(&cfg.MaxTenantReplicationFactor, prefix+"distributor.tenant-replication-factor-max", 0, "The maximum replication factor a tenant can be configured with through the per-tenant ingestion replication factor override. 0 to disable per-tenant replication factor overrides.")
 	f.BoolVar(&cfg.ZoneAwarenessEnabled, prefix+"distributor.zone-awareness-enabled", false, "True to enable the zone-awareness and replicate ingested samples across different availability zones.")
 	f.Var(&cfg.ExcludedZones, prefix+"distributor.excluded-zones", "Comma-separated list of zones to exclude from the ring. Instances in excluded zones will be filtered out from the ring.")
 }
@@ -235,6 +239,9 @@ func NewWithStoreClientAndStrategy(cfg Config, name, key string, store kv.Client
 	if cfg.ReplicationFactor <= 0 {
 		return nil, fmt.Errorf("ReplicationFactor must be greater than zero: %d", cfg.ReplicationFactor)
 	}
+	if cfg.MaxTenantReplicationFactor > 0 && (cfg.MinTenantReplicationFactor <= 0 || cfg.MinTenantReplicationFactor > cfg.MaxTenantReplicationFactor) {
+		return nil, fmt.Errorf("tenant replication factor bounds are invalid: min %d, max %d", cfg.MinTenantReplicationFactor, cfg.MaxTenantReplicationFactor)
+	}
 
 	r := &Ring{
 		key:                  key,
@@ -360,6 +367,12 @@ func (r *Ring) updateRingState(ringDesc *Desc) {
 // - Stability: given the same ring, two invocations returns the same set for same operation.
 // - Consistency: adding/removing 1 instance from the ring returns set with no more than 1 difference for same operation.
 func (r *Ring) Get(key uint32, op Operation, bufDescs []InstanceDesc, bufHosts []string, bufZones map[string]int) (ReplicationSet, error) {
+	return r.get(key, op, r.cfg.ReplicationFactor, nil, bufDescs, bufHosts, bufZones)
+}
+
+// get returns the replication set for the input key. The replica set is extended for each
+// instance whose address is in extendOnAddrs, as if the instance was not ACTIVE.
+func (r *Ring) get(key uint32, op Operation, ringReplicationFactor int, extendOnAddrs []string, bufDescs []InstanceDesc, bufHosts []string, bufZones map[string]int) (ReplicationSet, error) {
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
 	if r.ringDesc == nil || len(r.ringTokens) == 0 {
@@ -367,7 +380,7 @@ func (r *Ring) Get(key uint32, op Operation, bufDescs []InstanceDesc, bufHosts [
 	}
 
 	var (
-		replicationFactor      = r.cfg.ReplicationFactor
+		replicationFactor      = ringReplicationFactor
 		instances              = bufDescs[:0]
 		start                  = searchToken(r.ringTokens, key)
 		iterations             = 0
@@ -415,7 +428,7 @@ func (r *Ring) Get(key uint32, op Operation, bufDescs []InstanceDesc, bufHosts [
 
 		// Check whether the replica set should be extended given we're including
 		// this instance.
-		if op.ShouldExtendReplicaSetOnState(instance.State) {
+		if op.ShouldExtendReplicaSetOnState(instance.State) || util.StringsContain(extendOnAddrs, instance.Addr) {
 			replicationFactor++
 		} else if r.cfg.ZoneAwarenessEnabled && info.Zone != "" {
 			// We should only add the zone if we are not going to extend,
@@ -434,7 +447,7 @@ func (r *Ring) Get(key uint32, op Operation, bufDescs []InstanceDesc, bufHosts [
 		instances = append(instances, instance)
 	}
 
-	healthyInstances, maxFailure, err := r.strategy.Filter(instances, op, r.cfg.ReplicationFactor, r.cfg.HeartbeatTimeout, r.cfg.ZoneAwarenessEnabled, r.KVClient.LastUpdateTime(r.key))
+	healthyInstances, maxFailure, err := r.strategy.Filter(instances, op, ringReplicationFactor, r.cfg.HeartbeatTimeout, r.cfg.ZoneAwarenessEnabled, r.KVClient.LastUpdateTime(r.key))
 	if err != nil {
 		return ReplicationSet{}, err
 	}
@@ -512,6 +525,10 @@ func (r *Ring) GetInstanceDescsForOperation(op Operation) (map[string]InstanceDe
 
 // GetReplicationSetForOperation implements ReadRing.
 func (r *Ring) GetReplicationSetForOperation(op Operation) (ReplicationSet, error) {
+	return r.getReplicationSetForOperation(op, r.cfg.ReplicationFactor)
+}
+
+func (r *Ring) getReplicationSetForOperation(op Operation, replicationFactor int) (ReplicationSet, error) {
 	r.mtx.RLock()
 	defer r.mtx.RUnlock()
 
@@ -541,7 +558,7 @@ func (r *Ring) GetReplicationSetForOperation(op Operation) (ReplicationSet, erro
 		// Given data is replicated to RF different zones, we can tolerate a number of
 		// RF/2 failing zones. However, we need to protect from the case the ring currently
 		// contains instances in a number of zones < RF.
-		numReplicatedZones := min(len(r.ringZones), r.cfg.ReplicationFactor)
+		numReplicatedZones := min(len(r.ringZones), replicationFactor)
 		minSuccessZones := (numReplicatedZones / 2) + 1
 		maxUnavailableZones = minSuccessZones - 1
 
@@ -572,11 +589,11 @@ func (r *Ring) GetReplicationSetForOperation(op Operation) (ReplicationSet, erro
 		// Calculate the number of required instances;
 		// ensure we always require at least RF-1 when RF=3.
 		numRequired := len(r.ringDesc.Ingesters)
-		if numRequired < r.cfg.ReplicationFactor {
-			numRequired = r.cfg.ReplicationFactor
+		if numRequired < replicationFactor {
+			numRequired = replicationFactor
 		}
 		// We can tolerate this many failures
-		numRequired -= r.cfg.ReplicationFactor / 2
+		numRequired -= replicationFactor / 2
 
 		if len(healthyInstances) < numRequired {
 			return ReplicationSet{}, ErrTooManyUnhealthyInstances
@@ -928,6 +945,8 @@ func (r *Ring) getCachedShuffledSubring(identifier string, size int, zoneStableS
 		ing := r.ringDesc.Ingesters[name]
 		cachedIng.State = ing.State
 		cachedIng.Timestamp = ing.Timestamp
+		cachedIng.BlocksSyncPercentage = ing.BlocksSyncPercentage
+		cachedIng.HandoffTimestamp = ing.HandoffTimestamp
 		cached.ringDesc.Ingesters[name] = cachedIng
 	}
 	return cached
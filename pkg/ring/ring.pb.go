@@ -124,6 +124,13 @@ type InstanceDesc struct {
 	// was already registered before "now". If unknown (0), it should be left as is, and the
 	// code will properly deal with that.
 	RegisteredTimestamp int64 `protobuf:"varint,8,opt,name=registered_timestamp,json=registeredTimestamp,proto3" json:"registered_timestamp,omitempty"`
+	// Percentage (0-100) of the blocks owned by the instance which have been loaded. This
+	// field is only set by the store-gateways, and 0 means no block loaded yet or unknown.
+	BlocksSyncPercentage uint32 `protobuf:"varint,9,opt,name=blocks_sync_percentage,json=blocksSyncPercentage,proto3" json:"blocks_sync_percentage,omitempty"`
+	// Unix timestamp (with seconds precision) of when a new instance has registered to the ring
+	// while this instance was LEAVING. The LEAVING instance keeps serving reads for its tokens
+	// until the handoff window has elapsed since this timestamp. 0 means no handoff in progress.
+	HandoffTimestamp int64 `protobuf:"varint,10,opt,name=handoff_timestamp,json=handoffTimestamp,proto3" json:"handoff_timestamp,omitempty"`
 }
 
 func (m *InstanceDesc) Reset()      { *m = InstanceDesc{} }
@@ -200,6 +207,20 @@ func (m *InstanceDesc) GetRegisteredTimestamp() int64 {
 	return 0
 }
 
+func (m *InstanceDesc) GetBlocksSyncPercentage() uint32 {
+	if m != nil {
+		return m.BlocksSyncPercentage
+	}
+	return 0
+}
+
+func (m *InstanceDesc) GetHandoffTimestamp() int64 {
+	if m != nil {
+		return m.HandoffTimestamp
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterEnum("ring.InstanceState", InstanceState_name, InstanceState_value)
 	proto.RegisterType((*Desc)(nil), "ring.Desc")
@@ -210,33 +231,36 @@ func init() {
 func init() { proto.RegisterFile("ring.proto", fileDescriptor_26381ed67e202a6e) }
 
 var fileDescriptor_26381ed67e202a6e = []byte{
-	// 409 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x92, 0xc1, 0x8a, 0xd3, 0x50,
-	0x18, 0x85, 0xef, 0x9f, 0xdc, 0x64, 0xd2, 0xbf, 0xce, 0x10, 0xee, 0x0c, 0x12, 0x07, 0xb9, 0x86,
-	0x59, 0x45, 0x17, 0x15, 0xab, 0x0b, 0x11, 0x5c, 0xcc, 0x38, 0x51, 0x12, 0x4a, 0x1d, 0x62, 0x99,
-	0xad, 0xc4, 0xe9, 0x25, 0x84, 0xb1, 0x49, 0x49, 0xae, 0x42, 0x5d, 0xf9, 0x08, 0xbe, 0x80, 0x7b,
-	0x1f, 0xa5, 0xcb, 0xae, 0xa4, 0x2b, 0xb1, 0xe9, 0xc6, 0x65, 0x1f, 0x41, 0x6e, 0xd2, 0x92, 0xe9,
-	0xee, 0x9c, 0xff, 0x9c, 0x9c, 0x2f, 0x81, 0x20, 0x16, 0x69, 0x96, 0xf4, 0xa6, 0x45, 0x2e, 0x73,
-	0x46, 0x95, 0x3e, 0x3d, 0x49, 0xf2, 0x24, 0xaf, 0x0f, 0x4f, 0x95, 0x6a, 0xb2, 0xb3, 0x9f, 0x80,
-	0xf4, 0x52, 0x94, 0x37, 0xec, 0x35, 0x76, 0xd2, 0x2c, 0x11, 0xa5, 0x14, 0x45, 0xe9, 0x80, 0xab,
-	0x7b, 0xdd, 0xfe, 0x83, 0x5e, 0x3d, 0xa2, 0xe2, 0x5e, 0xb0, 0xcb, 0xfc, 0x4c, 0x16, 0xb3, 0x0b,
-	0x3a, 0xff, 0xf3, 0x88, 0x44, 0xed, 0x13, 0xa7, 0x57, 0x78, 0xb4, 0x5f, 0x61, 0x36, 0xea, 0xb7,
-	0x62, 0xe6, 0x80, 0x0b, 0x5e, 0x27, 0x52, 0x92, 0x79, 0x68, 0x7c, 0x8d, 0x3f, 0x7f, 0x11, 0x8e,
-	0xe6, 0x82, 0xd7, 0xed, 0xb3, 0x66, 0x3e, 0xc8, 0x4a, 0x19, 0x67, 0x37, 0x42, 0x61, 0xa2, 0xa6,
-	0xf0, 0x4a, 0x7b, 0x09, 0x21, 0xb5, 0x34, 0x5b, 0x3f, 0xfb, 0x0d, 0x78, 0xef, 0x6e, 0x83, 0x31,
-	0xa4, 0xf1, 0x78, 0x5c, 0x6c, 0x77, 0x6b, 0xcd, 0x1e, 0x62, 0x47, 0xa6, 0x13, 0x51, 0xca, 0x78,
-	0x32, 0xad, 0xc7, 0xf5, 0xa8, 0x3d, 0xb0, 0xc7, 0x68, 0x94, 0x32, 0x96, 0xc2, 0xd1, 0x5d, 0xf0,
-	0x8e, 0xfa, 0xc7, 0xfb, 0xd8, 0x0f, 0x2a, 0x8a, 0x9a, 0x06, 0xbb, 0x8f, 0xa6, 0xcc, 0x6f, 0x45,
-	0x56, 0x3a, 0xa6, 0xab, 0x7b, 0x87, 0xd1, 0xd6, 0x29, 0xe8, 0xb7, 0x3c, 0x13, 0xce, 0x41, 0x03,
-	0x55, 0x9a, 0x3d, 0xc3, 0x93, 0x42, 0x24, 0xa9, 0xfa, 0x62, 0x31, 0xfe, 0xd8, 0xf2, 0xad, 0x9a,
-	0x7f, 0xdc, 0x66, 0xa3, 0x5d, 0x14, 0x52, 0x8b, 0xda, 0x46, 0x48, 0x2d, 0xc3, 0x36, 0x9f, 0x0c,
-	0xf0, 0x70, 0xef, 0x15, 0x18, 0xa2, 0x79, 0xfe, 0x66, 0x14, 0x5c, 0xfb, 0x36, 0x61, 0x5d, 0x3c,
-	0x18, 0xf8, 0xe7, 0xd7, 0xc1, 0xf0, 0x9d, 0x0d, 0xca, 0x5c, 0xf9, 0xc3, 0x4b, 0x65, 0x34, 0x65,
-	0xc2, 0xf7, 0xc1, 0x50, 0x19, 0x9d, 0x59, 0x48, 0x07, 0xfe, 0xdb, 0x91, 0x4d, 0x2f, 0x5e, 0x2c,
-	0x56, 0x9c, 0x2c, 0x57, 0x9c, 0x6c, 0x56, 0x1c, 0xbe, 0x57, 0x1c, 0x7e, 0x55, 0x1c, 0xe6, 0x15,
-	0x87, 0x45, 0xc5, 0xe1, 0x6f, 0xc5, 0xe1, 0x5f, 0xc5, 0xc9, 0xa6, 0xe2, 0xf0, 0x63, 0xcd, 0xc9,
-	0x62, 0xcd, 0xc9, 0x72, 0xcd, 0xc9, 0x27, 0xb3, 0xfe, 0x07, 0x9e, 0xff, 0x0f, 0x00, 0x00, 0xff,
-	0xff, 0xd3, 0x1c, 0x09, 0x3a, 0x2d, 0x02, 0x00, 0x00,
+	// 461 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x54, 0x92, 0xb1, 0x6e, 0xd3, 0x40,
+	0x18, 0xc7, 0x7d, 0xf6, 0xc5, 0x75, 0xbe, 0x90, 0xca, 0x5c, 0xa3, 0xca, 0x54, 0xe8, 0xb0, 0x3a,
+	0x19, 0x90, 0x82, 0x08, 0x1d, 0x10, 0x12, 0x43, 0x4b, 0x0d, 0x72, 0x14, 0x85, 0xc8, 0x8d, 0xba,
+	0x46, 0xae, 0x73, 0x35, 0x51, 0xda, 0x73, 0xe4, 0x3b, 0x90, 0xc2, 0xc4, 0x23, 0xf0, 0x02, 0xec,
+	0xbc, 0x02, 0x6f, 0xd0, 0x31, 0x63, 0x27, 0x44, 0x9c, 0x85, 0xb1, 0x8f, 0x80, 0xce, 0x0e, 0x71,
+	0xb3, 0xfd, 0xff, 0xf7, 0xfb, 0xfc, 0xfd, 0x7c, 0xd2, 0x01, 0x64, 0x13, 0x9e, 0xb4, 0x67, 0x59,
+	0x2a, 0x53, 0x82, 0x55, 0x3e, 0x68, 0x25, 0x69, 0x92, 0x16, 0x07, 0x2f, 0x54, 0x2a, 0xd9, 0xe1,
+	0x0f, 0x04, 0xf8, 0x94, 0x89, 0x98, 0xbc, 0x85, 0xfa, 0x84, 0x27, 0x4c, 0x48, 0x96, 0x09, 0x07,
+	0xb9, 0x86, 0xd7, 0xe8, 0x3c, 0x6a, 0x17, 0x4b, 0x14, 0x6e, 0x07, 0xff, 0x99, 0xcf, 0x65, 0x36,
+	0x3f, 0xc1, 0x37, 0xbf, 0x9f, 0x68, 0x61, 0xf5, 0xc5, 0xc1, 0x00, 0x76, 0xb7, 0x47, 0x88, 0x0d,
+	0xc6, 0x94, 0xcd, 0x1d, 0xe4, 0x22, 0xaf, 0x1e, 0xaa, 0x48, 0x3c, 0xa8, 0x7d, 0x89, 0xae, 0x3e,
+	0x33, 0x47, 0x77, 0x91, 0xd7, 0xe8, 0x90, 0x72, 0x7d, 0xc0, 0x85, 0x8c, 0x78, 0xcc, 0x94, 0x26,
+	0x2c, 0x07, 0xde, 0xe8, 0xaf, 0x51, 0x17, 0x5b, 0xba, 0x6d, 0x1c, 0xfe, 0xd2, 0xe1, 0xc1, 0xfd,
+	0x09, 0x42, 0x00, 0x47, 0xe3, 0x71, 0xb6, 0xde, 0x5b, 0x64, 0xf2, 0x18, 0xea, 0x72, 0x72, 0xcd,
+	0x84, 0x8c, 0xae, 0x67, 0xc5, 0x72, 0x23, 0xac, 0x0e, 0xc8, 0x53, 0xa8, 0x09, 0x19, 0x49, 0xe6,
+	0x18, 0x2e, 0xf2, 0x76, 0x3b, 0x7b, 0xdb, 0xda, 0x33, 0x85, 0xc2, 0x72, 0x82, 0xec, 0x83, 0x29,
+	0xd3, 0x29, 0xe3, 0xc2, 0x31, 0x5d, 0xc3, 0x6b, 0x86, 0xeb, 0xa6, 0xa4, 0x5f, 0x53, 0xce, 0x9c,
+	0x9d, 0x52, 0xaa, 0x32, 0x79, 0x09, 0xad, 0x8c, 0x25, 0x13, 0x75, 0x63, 0x36, 0x1e, 0x55, 0x7e,
+	0xab, 0xf0, 0xef, 0x55, 0x6c, 0xb8, 0xf9, 0x93, 0x23, 0xd8, 0xbf, 0xb8, 0x4a, 0xe3, 0xa9, 0x18,
+	0x89, 0x39, 0x8f, 0x47, 0x33, 0x96, 0xc5, 0x8c, 0xcb, 0x28, 0x61, 0x4e, 0xdd, 0x45, 0x5e, 0x33,
+	0x6c, 0x95, 0xf4, 0x6c, 0xce, 0xe3, 0xc1, 0x86, 0x91, 0xe7, 0xf0, 0xf0, 0x53, 0xc4, 0xc7, 0xe9,
+	0xe5, 0xe5, 0x3d, 0x0b, 0x14, 0x16, 0x7b, 0x0d, 0x36, 0x8a, 0x2e, 0xb6, 0xb0, 0x5d, 0xeb, 0x62,
+	0xab, 0x66, 0x9b, 0xcf, 0x7a, 0xd0, 0xdc, 0xba, 0x25, 0x01, 0x30, 0x8f, 0xdf, 0x0d, 0x83, 0x73,
+	0xdf, 0xd6, 0x48, 0x03, 0x76, 0x7a, 0xfe, 0xf1, 0x79, 0xd0, 0xff, 0x60, 0x23, 0x55, 0x06, 0x7e,
+	0xff, 0x54, 0x15, 0x5d, 0x95, 0xee, 0xc7, 0xa0, 0xaf, 0x8a, 0x41, 0x2c, 0xc0, 0x3d, 0xff, 0xfd,
+	0xd0, 0xc6, 0x27, 0x47, 0x8b, 0x25, 0xd5, 0x6e, 0x97, 0x54, 0xbb, 0x5b, 0x52, 0xf4, 0x2d, 0xa7,
+	0xe8, 0x67, 0x4e, 0xd1, 0x4d, 0x4e, 0xd1, 0x22, 0xa7, 0xe8, 0x4f, 0x4e, 0xd1, 0xdf, 0x9c, 0x6a,
+	0x77, 0x39, 0x45, 0xdf, 0x57, 0x54, 0x5b, 0xac, 0xa8, 0x76, 0xbb, 0xa2, 0xda, 0x85, 0x59, 0x3c,
+	0xb3, 0x57, 0xff, 0x06, 0x00, 0x08, 0x18, 0x5f, 0x1c, 0x90, 0x02, 0x00, 0x00,
 }
 
 func (x InstanceState) String() string {
@@ -319,6 +343,12 @@ func (this *InstanceDesc) Equal(that interface{}) bool {
 	if this.RegisteredTimestamp != that1.RegisteredTimestamp {
 		return false
 	}
+	if this.BlocksSyncPercentage != that1.BlocksSyncPercentage {
+		return false
+	}
+	if this.HandoffTimestamp != that1.HandoffTimestamp {
+		return false
+	}
 	return true
 }
 func (this *Desc) GoString() string {
@@ -347,7 +377,7 @@ func (this *InstanceDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 10)
+	s := make([]string, 0, 12)
 	s = append(s, "&ring.InstanceDesc{")
 	s = append(s, "Addr: "+fmt.Sprintf("%#v", this.Addr)+",\n")
 	s = append(s, "Timestamp: "+fmt.Sprintf("%#v", this.Timestamp)+",\n")
@@ -355,6 +385,8 @@ func (this *InstanceDesc) GoString() string {
 	s = append(s, "Tokens: "+fmt.Sprintf("%#v", this.Tokens)+",\n")
 	s = append(s, "Zone: "+fmt.Sprintf("%#v", this.Zone)+",\n")
 	s = append(s, "RegisteredTimestamp: "+fmt.Sprintf("%#v", this.RegisteredTimestamp)+",\n")
+	s = append(s, "BlocksSyncPercentage: "+fmt.Sprintf("%#v", this.BlocksSyncPercentage)+",\n")
+	s = append(s, "HandoffTimestamp: "+fmt.Sprintf("%#v", this.HandoffTimestamp)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -433,6 +465,16 @@ func (m *InstanceDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.HandoffTimestamp != 0 {
+		i = encodeVarintRing(dAtA, i, uint64(m.HandoffTimestamp))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.BlocksSyncPercentage != 0 {
+		i = encodeVarintRing(dAtA, i, uint64(m.BlocksSyncPercentage))
+		i--
+		dAtA[i] = 0x48
+	}
 	if m.RegisteredTimestamp != 0 {
 		i = encodeVarintRing(dAtA, i, uint64(m.RegisteredTimestamp))
 		i--
@@ -542,6 +584,12 @@ func (m *InstanceDesc) Size() (n int) {
 	if m.RegisteredTimestamp != 0 {
 		n += 1 + sovRing(uint64(m.RegisteredTimestamp))
 	}
+	if m.BlocksSyncPercentage != 0 {
+		n += 1 + sovRing(uint64(m.BlocksSyncPercentage))
+	}
+	if m.HandoffTimestamp != 0 {
+		n += 1 + sovRing(uint64(m.HandoffTimestamp))
+	}
 	return n
 }
 
@@ -582,6 +630,8 @@ func (this *InstanceDesc) String() string {
 		`Tokens:` + fmt.Sprintf("%v", this.Tokens) + `,`,
 		`Zone:` + fmt.Sprintf("%v", this.Zone) + `,`,
 		`RegisteredTimestamp:` + fmt.Sprintf("%v", this.RegisteredTimestamp) + `,`,
+		`BlocksSyncPercentage:` + fmt.Sprintf("%v", this.BlocksSyncPercentage) + `,`,
+		`HandoffTimestamp:` + fmt.Sprintf("%v", this.HandoffTimestamp) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1002,6 +1052,44 @@ func (m *InstanceDesc) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlocksSyncPercentage", wireType)
+			}
+			m.BlocksSyncPercentage = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlocksSyncPercentage |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HandoffTimestamp", wireType)
+			}
+			m.HandoffTimestamp = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRing
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HandoffTimestamp |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRing(dAtA[iNdEx:])
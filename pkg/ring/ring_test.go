@@ -3,12 +3,14 @@ package ring
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -170,6 +172,102 @@ func TestDoBatchZeroInstances(t *testing.T) {
 	require.Error(t, DoBatch(ctx, Write, &r, keys, callback, cleanup))
 }
 
+func TestDoBatchWithOptions_ShouldRetryOnExtendedReplicaSet(t *testing.T) {
+	errRetriable := errors.New("instance leaving")
+	errNotRetriable := errors.New("bad request")
+
+	tests := map[string]struct {
+		failingErr    error
+		maxRetries    int
+		expectedErr   bool
+		expectedCalls []string
+	}{
+		"should fail if retries are disabled": {
+			failingErr:    errRetriable,
+			maxRetries:    0,
+			expectedErr:   true,
+			expectedCalls: []string{"instance-1", "instance-2", "instance-3"},
+		},
+		"should fail if the error is not retriable": {
+			failingErr:    errNotRetriable,
+			maxRetries:    1,
+			expectedErr:   true,
+			expectedCalls: []string{"instance-1", "instance-2", "instance-3"},
+		},
+		"should succeed retrying on the instances extending the replica set": {
+			failingErr:    errRetriable,
+			maxRetries:    1,
+			expectedErr:   false,
+			expectedCalls: []string{"instance-1", "instance-2", "instance-3", "instance-4", "instance-5"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			// The key is owned by the instances 1, 2 and 3, and the replica set is extended
+			// with the instances 4 and 5.
+			desc := NewDesc()
+			for i := 1; i <= 5; i++ {
+				desc.AddIngester(fmt.Sprintf("instance-%d", i), fmt.Sprintf("instance-%d", i), "", []uint32{uint32(i * 100)}, ACTIVE, time.Now())
+			}
+
+			cfg := Config{}
+			flagext.DefaultValues(&cfg)
+			cfg.ReplicationFactor = 3
+			r := Ring{
+				cfg:                 cfg,
+				ringDesc:            desc,
+				strategy:            NewDefaultReplicationStrategy(),
+				ringTokens:          desc.GetTokens(),
+				ringZones:           getZones(desc.getTokensByZone()),
+				ringTokensByZone:    desc.getTokensByZone(),
+				ringInstanceByToken: desc.getTokensInfo(),
+				KVClient:            &MockClient{},
+			}
+
+			var (
+				callsMtx sync.Mutex
+				calls    []string
+				done     = make(chan struct{})
+			)
+
+			callback := func(instance InstanceDesc, indexes []int, attempt int) error {
+				callsMtx.Lock()
+				calls = append(calls, instance.Addr)
+				callsMtx.Unlock()
+
+				// The instances extending the replica set are only called by the retries.
+				if instance.Addr == "instance-4" || instance.Addr == "instance-5" {
+					assert.Equal(t, 1, attempt)
+				} else {
+					assert.Equal(t, 0, attempt)
+				}
+
+				// The instances 2 and 3 are leaving.
+				if instance.Addr == "instance-2" || instance.Addr == "instance-3" {
+					return testData.failingErr
+				}
+				return nil
+			}
+
+			err := DoBatchWithOptions(context.Background(), Write, &r, []uint32{50}, callback, DoBatchOptions{
+				Cleanup:     func() { close(done) },
+				IsRetriable: func(err error) bool { return errors.Is(err, errRetriable) },
+				MaxRetries:  testData.maxRetries,
+			})
+			if testData.expectedErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			// Wait until all the calls have completed.
+			<-done
+			assert.ElementsMatch(t, testData.expectedCalls, calls)
+		})
+	}
+}
+
 func TestAddIngester(t *testing.T) {
 	r := NewDesc()
 
@@ -3157,3 +3255,53 @@ func TestUpdateMetricsWithRemoval(t *testing.T) {
 	`))
 	assert.NoError(t, err)
 }
+
+func TestComputeTokenLoadReport(t *testing.T) {
+	const quarter = uint32(1 << 30)
+
+	desc := NewDesc()
+	// The instance-1 owns 3/4 of the ring, while instance-2 owns 1/4.
+	desc.AddIngester("instance-1", "127.0.0.1", "", []uint32{quarter, 2 * quarter, 3 * quarter}, ACTIVE, time.Now())
+	desc.AddIngester("instance-2", "127.0.0.2", "", []uint32{math.MaxUint32}, ACTIVE, time.Now())
+
+	tokensByZone := map[string][]uint32{"": desc.GetTokens()}
+
+	t.Run("should report the ownership and suggest the moves reducing the imbalance", func(t *testing.T) {
+		report := computeTokenLoadReport(tokensByZone, desc.getTokensInfo(), desc.Ingesters, false, defaultMaxTokenMoveSuggestions)
+
+		require.Len(t, report.Instances, 2)
+		assert.Equal(t, "instance-1", report.Instances[0].ID)
+		assert.Equal(t, 3, report.Instances[0].NumTokens)
+		assert.InDelta(t, 75, report.Instances[0].Ownership, 0.01)
+		assert.InDelta(t, 50, report.Instances[0].ExpectedOwnership, 0.01)
+		assert.InDelta(t, 25, report.Instances[0].DiffOwnership, 0.01)
+		assert.InDelta(t, 25, report.Instances[1].Ownership, 0.01)
+		assert.InDelta(t, 25, report.MaxDiffOwnership, 0.01)
+
+		// Moving a single token balances the ring, so a single move is suggested.
+		require.Len(t, report.Suggestions, 1)
+		assert.Equal(t, "instance-1", report.Suggestions[0].From)
+		assert.Equal(t, "instance-2", report.Suggestions[0].To)
+		assert.Equal(t, quarter, report.Suggestions[0].Token)
+		assert.InDelta(t, 25, report.Suggestions[0].Ownership, 0.01)
+	})
+
+	t.Run("should honor the max number of suggestions", func(t *testing.T) {
+		report := computeTokenLoadReport(tokensByZone, desc.getTokensInfo(), desc.Ingesters, false, 0)
+		assert.Empty(t, report.Suggestions)
+	})
+
+	t.Run("should honor the max number of suggestions per zone", func(t *testing.T) {
+		zonesDesc := NewDesc()
+		zonesDesc.AddIngester("instance-a-1", "127.0.0.1", "zone-a", []uint32{quarter, 2 * quarter, 3 * quarter}, ACTIVE, time.Now())
+		zonesDesc.AddIngester("instance-a-2", "127.0.0.2", "zone-a", []uint32{math.MaxUint32}, ACTIVE, time.Now())
+		zonesDesc.AddIngester("instance-b-1", "127.0.0.3", "zone-b", []uint32{quarter + 1, 2*quarter + 1, 3*quarter + 1}, ACTIVE, time.Now())
+		zonesDesc.AddIngester("instance-b-2", "127.0.0.4", "zone-b", []uint32{math.MaxUint32 - 1}, ACTIVE, time.Now())
+
+		report := computeTokenLoadReport(zonesDesc.getTokensByZone(), zonesDesc.getTokensInfo(), zonesDesc.Ingesters, true, 1)
+
+		require.Len(t, report.Suggestions, 2)
+		assert.Equal(t, "zone-a", report.Suggestions[0].Zone)
+		assert.Equal(t, "zone-b", report.Suggestions[1].Zone)
+	})
+}
@@ -0,0 +1,87 @@
+package ring
+
+import (
+	"time"
+)
+
+// TenantReplicationFactor returns the replication factor to use for a tenant
+// given its configured override. The override is clamped to the configured
+// bounds, and the ring replication factor is returned if the override is not
+// set or per-tenant overrides are disabled.
+func (cfg *Config) TenantReplicationFactor(override int) int {
+	if override <= 0 || cfg.MaxTenantReplicationFactor <= 0 {
+		return cfg.ReplicationFactor
+	}
+
+	return max(cfg.MinTenantReplicationFactor, min(override, cfg.MaxTenantReplicationFactor))
+}
+
+// WithTenantReplicationFactor returns a ReadRing which uses the input replication factor
+// override (bounded by the ring configuration) to lookup the instances for write and read
+// operations. The input ring is returned as is if the replication factor doesn't change or
+// the ring doesn't support overriding it.
+func WithTenantReplicationFactor(r ReadRing, override int) ReadRing {
+	var base *Ring
+
+	switch rr := r.(type) {
+	case *Ring:
+		base = rr
+	case *replicationFactorRing:
+		base = rr.Ring
+	default:
+		return r
+	}
+
+	replicationFactor := base.cfg.TenantReplicationFactor(override)
+	if replicationFactor == base.cfg.ReplicationFactor {
+		return base
+	}
+
+	return &replicationFactorRing{Ring: base, replicationFactor: replicationFactor}
+}
+
+// replicationFactorRing is a Ring using a replication factor different than the configured one.
+type replicationFactorRing struct {
+	*Ring
+
+	replicationFactor int
+}
+
+// Get implements ReadRing.
+func (r *replicationFactorRing) Get(key uint32, op Operation, bufDescs []InstanceDesc, bufHosts []string, bufZones map[string]int) (ReplicationSet, error) {
+	return r.Ring.get(key, op, r.replicationFactor, nil, bufDescs, bufHosts, bufZones)
+}
+
+// GetReplicationSetForOperation implements ReadRing.
+func (r *replicationFactorRing) GetReplicationSetForOperation(op Operation) (ReplicationSet, error) {
+	return r.Ring.getReplicationSetForOperation(op, r.replicationFactor)
+}
+
+// ReplicationFactor implements ReadRing.
+func (r *replicationFactorRing) ReplicationFactor() int {
+	return r.replicationFactor
+}
+
+// ShuffleShard implements ReadRing.
+func (r *replicationFactorRing) ShuffleShard(identifier string, size int) ReadRing {
+	return r.wrap(r.Ring.ShuffleShard(identifier, size))
+}
+
+// ShuffleShardWithZoneStability implements ReadRing.
+func (r *replicationFactorRing) ShuffleShardWithZoneStability(identifier string, size int) ReadRing {
+	return r.wrap(r.Ring.ShuffleShardWithZoneStability(identifier, size))
+}
+
+// ShuffleShardWithLookback implements ReadRing.
+func (r *replicationFactorRing) ShuffleShardWithLookback(identifier string, size int, lookbackPeriod time.Duration, now time.Time) ReadRing {
+	return r.wrap(r.Ring.ShuffleShardWithLookback(identifier, size, lookbackPeriod, now))
+}
+
+func (r *replicationFactorRing) wrap(subring ReadRing) ReadRing {
+	base, ok := subring.(*Ring)
+	if !ok {
+		return subring
+	}
+
+	return &replicationFactorRing{Ring: base, replicationFactor: r.replicationFactor}
+}
@@ -0,0 +1,112 @@
+package ring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_TenantReplicationFactor(t *testing.T) {
+	tests := map[string]struct {
+		min, max int
+		override int
+		expected int
+	}{
+		"override not set": {
+			min: 1, max: 5, override: 0, expected: 3,
+		},
+		"override within bounds": {
+			min: 1, max: 5, override: 5, expected: 5,
+		},
+		"override below the min bound": {
+			min: 2, max: 5, override: 1, expected: 2,
+		},
+		"override above the max bound": {
+			min: 1, max: 5, override: 7, expected: 5,
+		},
+		"overrides disabled": {
+			min: 1, max: 0, override: 1, expected: 3,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			cfg := Config{ReplicationFactor: 3, MinTenantReplicationFactor: testData.min, MaxTenantReplicationFactor: testData.max}
+			assert.Equal(t, testData.expected, cfg.TenantReplicationFactor(testData.override))
+		})
+	}
+}
+
+func TestWithTenantReplicationFactor(t *testing.T) {
+	now := time.Now()
+	g := NewRandomTokenGenerator()
+
+	newRing := func(unhealthyInstance string) *Ring {
+		ringDesc := &Desc{Ingesters: map[string]InstanceDesc{}}
+		for _, id := range []string{"instance-1", "instance-2", "instance-3", "instance-4", "instance-5"} {
+			instance := InstanceDesc{Addr: id, State: ACTIVE, Timestamp: now.Unix(), Tokens: g.GenerateTokens(NewDesc(), id, "", 128, true)}
+			if id == unhealthyInstance {
+				instance.Timestamp = now.Add(-2 * time.Minute).Unix()
+			}
+			ringDesc.Ingesters[id] = instance
+		}
+
+		return &Ring{
+			cfg: Config{
+				HeartbeatTimeout:           time.Minute,
+				ReplicationFactor:          3,
+				MinTenantReplicationFactor: 1,
+				MaxTenantReplicationFactor: 5,
+				SubringCacheDisabled:       true,
+			},
+			ringDesc:            ringDesc,
+			ringTokens:          ringDesc.GetTokens(),
+			ringTokensByZone:    ringDesc.getTokensByZone(),
+			ringInstanceByToken: ringDesc.getTokensInfo(),
+			ringZones:           getZones(ringDesc.getTokensByZone()),
+			strategy:            NewDefaultReplicationStrategy(),
+			KVClient:            &MockClient{},
+		}
+	}
+
+	t.Run("should return the input ring if the replication factor doesn't change", func(t *testing.T) {
+		ring := newRing("")
+		assert.Same(t, ring, WithTenantReplicationFactor(ring, 0))
+		assert.Same(t, ring, WithTenantReplicationFactor(ring, 3))
+	})
+
+	t.Run("should write to RF instances", func(t *testing.T) {
+		ring := newRing("")
+
+		for _, rf := range []int{1, 5} {
+			tenantRing := WithTenantReplicationFactor(ring, rf)
+			assert.Equal(t, rf, tenantRing.ReplicationFactor())
+
+			bufDescs, bufHosts, bufZones := MakeBuffersForGet()
+			set, err := tenantRing.Get(12345, WriteNoExtend, bufDescs, bufHosts, bufZones)
+			require.NoError(t, err)
+			assert.Len(t, set.Instances, rf)
+			assert.Equal(t, rf-(rf/2+1), set.MaxErrors)
+		}
+	})
+
+	t.Run("should tolerate read failures based on the tenant replication factor", func(t *testing.T) {
+		ring := newRing("instance-5")
+
+		set, err := WithTenantReplicationFactor(ring, 5).GetReplicationSetForOperation(Read)
+		require.NoError(t, err)
+		assert.Len(t, set.Instances, 4)
+		assert.Equal(t, 1, set.MaxErrors)
+
+		// With RF=1 no failure can be tolerated.
+		_, err = WithTenantReplicationFactor(ring, 1).GetReplicationSetForOperation(Read)
+		assert.Equal(t, ErrTooManyUnhealthyInstances, err)
+	})
+
+	t.Run("should preserve the replication factor on the shuffle shard subring", func(t *testing.T) {
+		subring := WithTenantReplicationFactor(newRing(""), 5).ShuffleShard("user-1", 4)
+		assert.Equal(t, 5, subring.ReplicationFactor())
+	})
+}
@@ -0,0 +1,182 @@
+package ring
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const defaultMaxTokenMoveSuggestions = 10
+
+// instanceTokenLoad is the token ownership of a single instance.
+type instanceTokenLoad struct {
+	ID                string  `json:"id"`
+	Zone              string  `json:"zone"`
+	NumTokens         int     `json:"num_tokens"`
+	Ownership         float64 `json:"ownership_percent"`
+	ExpectedOwnership float64 `json:"expected_ownership_percent"`
+	DiffOwnership     float64 `json:"diff_ownership_percent"`
+}
+
+// tokenMove is a suggestion to move a token between two instances of the same zone,
+// transferring the ownership of the range ending at the token.
+type tokenMove struct {
+	Token     uint32  `json:"token"`
+	From      string  `json:"from"`
+	To        string  `json:"to"`
+	Zone      string  `json:"zone"`
+	Ownership float64 `json:"ownership_percent"`
+}
+
+type tokenLoadReport struct {
+	Instances        []instanceTokenLoad `json:"instances"`
+	MaxDiffOwnership float64             `json:"max_diff_ownership_percent"`
+	Suggestions      []tokenMove         `json:"suggestions"`
+}
+
+// serveTokenLoadReport reports the token ownership imbalance between the ring instances and
+// suggests the token moves which would reduce it. The suggestions are computed
+// independently for each zone if zone-awareness is enabled. Suggestions are never applied.
+func (r *Ring) serveTokenLoadReport(w http.ResponseWriter, req *http.Request) {
+	maxSuggestions := defaultMaxTokenMoveSuggestions
+	if v := req.URL.Query().Get("max_suggestions"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			http.Error(w, "invalid max_suggestions parameter", http.StatusBadRequest)
+			return
+		}
+		maxSuggestions = n
+	}
+
+	r.mtx.RLock()
+	tokensByZone := map[string][]uint32{"": r.ringTokens}
+	if r.cfg.ZoneAwarenessEnabled {
+		tokensByZone = r.ringDesc.getTokensByZone()
+	}
+	instances := make(map[string]InstanceDesc, len(r.ringDesc.Ingesters))
+	for id, inst := range r.ringDesc.Ingesters {
+		instances[id] = inst
+	}
+	report := computeTokenLoadReport(tokensByZone, r.ringInstanceByToken, instances, r.cfg.ZoneAwarenessEnabled, maxSuggestions)
+	r.mtx.RUnlock()
+
+	writeJSONResponse(w, report)
+}
+
+// computeTokenLoadReport computes the token ownership for each instance and suggests up to maxSuggestions
+// token moves per zone. Each suggested move transfers the range owned by a token from the instance owning
+// the most to the instance owning the least in the same zone, as long as the move reduces the
+// ownership difference between them. Instances are never left without tokens.
+func computeTokenLoadReport(tokensByZone map[string][]uint32, instanceByToken map[uint32]instanceInfo, instances map[string]InstanceDesc, zoneAware bool, maxSuggestions int) tokenLoadReport {
+	report := tokenLoadReport{Instances: []instanceTokenLoad{}, Suggestions: []tokenMove{}}
+
+	zones := make([]string, 0, len(tokensByZone))
+	for zone := range tokensByZone {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+
+	for _, zone := range zones {
+		tokens := tokensByZone[zone]
+
+		// Ownership of each instance and ranges owned by each token, within the zone.
+		owned := map[string]int64{}
+		rangesByInstance := map[string]map[uint32]int64{}
+		for id, inst := range instances {
+			if !zoneAware || inst.Zone == zone {
+				owned[id] = 0
+				rangesByInstance[id] = map[uint32]int64{}
+			}
+		}
+		for i := 1; i <= len(tokens); i++ {
+			token := tokens[i%len(tokens)]
+			diff := tokenDistance(tokens[i-1], token)
+			id := instanceByToken[token].InstanceID
+			if _, ok := owned[id]; !ok {
+				continue
+			}
+			owned[id] += diff
+			rangesByInstance[id][token] = diff
+		}
+		if len(owned) == 0 {
+			continue
+		}
+
+		ids := make([]string, 0, len(owned))
+		for id := range owned {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		expected := 1 / float64(len(owned)) * 100
+		for _, id := range ids {
+			ownership := tokenRangeToPercent(owned[id])
+			diff := ownership - expected
+			report.Instances = append(report.Instances, instanceTokenLoad{
+				ID:                id,
+				Zone:              instances[id].Zone,
+				NumTokens:         len(instances[id].Tokens),
+				Ownership:         ownership,
+				ExpectedOwnership: expected,
+				DiffOwnership:     diff,
+			})
+			if math.Abs(diff) > math.Abs(report.MaxDiffOwnership) {
+				report.MaxDiffOwnership = diff
+			}
+		}
+
+		// The suggestions are limited per zone, so that the first zones don't starve the others.
+		for zoneSuggestions := 0; zoneSuggestions < maxSuggestions && len(ids) > 1; zoneSuggestions++ {
+			from, to := ids[0], ids[0]
+			for _, id := range ids {
+				if owned[id] > owned[from] {
+					from = id
+				}
+				if owned[id] < owned[to] {
+					to = id
+				}
+			}
+
+			// Pick the token whose range, once moved, minimises the imbalance between
+			// the two instances. Only moves reducing the imbalance are considered.
+			var (
+				bestToken uint32
+				bestRange int64
+				imbalance = owned[from] - owned[to]
+				bestAfter = imbalance
+			)
+			for token, size := range rangesByInstance[from] {
+				after := imbalance - 2*size
+				if after < 0 {
+					after = -after
+				}
+				if after < bestAfter || (after == bestAfter && bestRange > 0 && token < bestToken) {
+					bestToken, bestRange, bestAfter = token, size, after
+				}
+			}
+			if bestRange == 0 || len(rangesByInstance[from]) <= 1 {
+				break
+			}
+
+			delete(rangesByInstance[from], bestToken)
+			rangesByInstance[to][bestToken] = bestRange
+			owned[from] -= bestRange
+			owned[to] += bestRange
+
+			report.Suggestions = append(report.Suggestions, tokenMove{
+				Token:     bestToken,
+				From:      from,
+				To:        to,
+				Zone:      instances[from].Zone,
+				Ownership: tokenRangeToPercent(bestRange),
+			})
+		}
+	}
+
+	return report
+}
+
+func tokenRangeToPercent(size int64) float64 {
+	return (float64(size) / float64(math.MaxUint32+1)) * 100
+}
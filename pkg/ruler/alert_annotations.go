@@ -0,0 +1,94 @@
+package ruler
+
+import (
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/notifier"
+
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// truncatedAnnotationSuffix is appended to the annotation values truncated by the ruler.
+const truncatedAnnotationSuffix = "...(truncated)"
+
+// annotationLimitingSender enforces the tenant's alert annotation size limits before sending
+// the alerts, so that huge templated annotations don't break the notifications delivery.
+type annotationLimitingSender struct {
+	sender
+	limits  RulesLimits
+	userID  string
+	limited prometheus.Counter
+}
+
+func (s *annotationLimitingSender) Send(alerts ...*notifier.Alert) {
+	maxSize := s.limits.RulerMaxAlertAnnotationSizeBytes(s.userID)
+	maxTotalSize := s.limits.RulerMaxAlertAnnotationsSizeBytes(s.userID)
+
+	if maxSize > 0 || maxTotalSize > 0 {
+		drop := s.limits.RulerAlertAnnotationLimitAction(s.userID) == validation.RulerAlertAnnotationLimitActionDrop
+		for _, a := range alerts {
+			var limited int
+			a.Annotations, limited = limitAnnotations(a.Annotations, maxSize, maxTotalSize, drop)
+			s.limited.Add(float64(limited))
+		}
+	}
+
+	s.sender.Send(alerts...)
+}
+
+// limitAnnotations returns the annotations whose values fit both the max size of each value and
+// the max total size of the names and values, the latter being consumed in name order. The
+// annotations exceeding the limits are dropped or truncated, and their number is returned too.
+func limitAnnotations(annotations labels.Labels, maxSize, maxTotalSize int, drop bool) (labels.Labels, int) {
+	var (
+		b         = labels.NewScratchBuilder(annotations.Len())
+		remaining = maxTotalSize
+		limited   = 0
+	)
+
+	annotations.Range(func(l labels.Label) {
+		limit := -1
+		if maxSize > 0 {
+			limit = maxSize
+		}
+		if maxTotalSize > 0 {
+			if available := max(0, remaining-len(l.Name)); limit < 0 || available < limit {
+				limit = available
+			}
+		}
+
+		value := l.Value
+		if limit >= 0 && len(value) > limit {
+			limited++
+			if drop {
+				return
+			}
+			if value = truncateAnnotation(value, limit); value == "" {
+				return
+			}
+		}
+
+		remaining -= len(l.Name) + len(value)
+		b.Add(l.Name, value)
+	})
+
+	if limited == 0 {
+		return annotations, 0
+	}
+	return b.Labels(), limited
+}
+
+// truncateAnnotation truncates the value to the limit, including the truncation suffix, without
+// splitting UTF-8 characters. It returns an empty string if the suffix doesn't fit the limit.
+func truncateAnnotation(value string, limit int) string {
+	cut := limit - len(truncatedAnnotationSuffix)
+	if cut < 0 {
+		return ""
+	}
+	for cut > 0 && !utf8.RuneStart(value[cut]) {
+		cut--
+	}
+	return value[:cut] + truncatedAnnotationSuffix
+}
@@ -1,6 +1,8 @@
 package ruler
 
 import (
+	"bytes"
+	_ "embed" // Used to embed the OpenAPI specification.
 	"encoding/json"
 	"fmt"
 	io "io"
@@ -469,10 +471,14 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 
 	level.Debug(logger).Log("msg", "attempting to unmarshal rulegroup", "userID", userID, "group", string(payload))
 
-	rg := rulefmt.RuleGroup{}
-	err = yaml.Unmarshal(payload, &rg)
+	rg, err := decodeRuleGroup(payload, a.ruler.cfg.APIStrictValidation)
 	if err != nil {
 		level.Error(logger).Log("msg", "unable to unmarshal rule group payload", "err", err.Error())
+		if a.ruler.cfg.APIStrictValidation {
+			// Report the details of the validation failure, like the location of the unknown fields.
+			http.Error(w, fmt.Sprintf("%s: %s", ErrBadRuleGroup.Error(), err.Error()), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, ErrBadRuleGroup.Error(), http.StatusBadRequest)
 		return
 	}
@@ -495,6 +501,12 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if err := a.ruler.AssertMinEvaluationInterval(userID, time.Duration(rg.Interval)); err != nil {
+		level.Error(logger).Log("msg", "limit validation failure", "err", err.Error(), "user", userID)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if a.ruler.HasMaxRuleGroupsLimit(userID) {
 		rgs, err := a.store.ListRuleGroupsForUserAndNamespace(req.Context(), userID, "")
 		if err != nil {
@@ -522,6 +534,17 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Updating a paused rule group doesn't resume its evaluation.
+	existing, err := a.store.GetRuleGroup(req.Context(), userID, namespace, rgProto.Name)
+	if err != nil && err != rulestore.ErrGroupNotFound && err != rulestore.ErrUserNotFound {
+		level.Error(logger).Log("msg", "unable to fetch the current rule group", "err", err.Error(), "user", userID)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if existing != nil {
+		rgProto.Paused = existing.Paused
+	}
+
 	level.Debug(logger).Log("msg", "attempting to store rulegroup", "userID", userID, "group", rgProto.String())
 	err = a.store.SetRuleGroup(req.Context(), userID, namespace, rgProto)
 	if err != nil {
@@ -533,6 +556,41 @@ func (a *API) CreateRuleGroup(w http.ResponseWriter, req *http.Request) {
 	respondAccepted(w, logger)
 }
 
+// decodeRuleGroup decodes the rule group in the input YAML payload. When strict, the payload
+// must contain a single document and the unknown fields are rejected.
+func decodeRuleGroup(payload []byte, strict bool) (rulefmt.RuleGroup, error) {
+	rg := rulefmt.RuleGroup{}
+	if !strict {
+		return rg, yaml.Unmarshal(payload, &rg)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(payload))
+	dec.KnownFields(true)
+
+	// An empty payload decodes to an empty rule group, which then fails the validation.
+	if err := dec.Decode(&rg); err != nil && !errors.Is(err, io.EOF) {
+		return rg, err
+	}
+	if err := dec.Decode(&yaml.Node{}); !errors.Is(err, io.EOF) {
+		return rg, errors.New("the payload must contain a single YAML document")
+	}
+
+	return rg, nil
+}
+
+// openAPISpec is the OpenAPI specification of the ruler configuration API.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// OpenAPISpec serves the OpenAPI specification of the ruler configuration API.
+func (a *API) OpenAPISpec(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := w.Write(openAPISpec); err != nil {
+		level.Error(a.logger).Log("msg", "error writing the OpenAPI specification", "err", err)
+	}
+}
+
 func (a *API) DeleteNamespace(w http.ResponseWriter, req *http.Request) {
 	logger := util_log.WithContext(req.Context(), a.logger)
 
@@ -576,3 +634,71 @@ func (a *API) DeleteRuleGroup(w http.ResponseWriter, req *http.Request) {
 
 	respondAccepted(w, logger)
 }
+
+// PauseRuleGroup pauses the evaluation of a rule group, or of all the rule groups in a namespace
+// when no group name is given. The rule groups are kept in the store.
+func (a *API) PauseRuleGroup(w http.ResponseWriter, req *http.Request) {
+	a.setRuleGroupsPaused(w, req, true)
+}
+
+// ResumeRuleGroup resumes the evaluation of a rule group, or of all the rule groups in a namespace
+// when no group name is given.
+func (a *API) ResumeRuleGroup(w http.ResponseWriter, req *http.Request) {
+	a.setRuleGroupsPaused(w, req, false)
+}
+
+func (a *API) setRuleGroupsPaused(w http.ResponseWriter, req *http.Request, paused bool) {
+	logger := util_log.WithContext(req.Context(), a.logger)
+
+	userID, namespace, groupName, err := parseRequest(req, true, false)
+	if err != nil {
+		util_api.RespondError(logger, w, v1.ErrBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var groups rulespb.RuleGroupList
+	if groupName != "" {
+		rg, err := a.store.GetRuleGroup(req.Context(), userID, namespace, groupName)
+		if err != nil {
+			if err == rulestore.ErrGroupNotFound || err == rulestore.ErrUserNotFound {
+				http.Error(w, rulestore.ErrGroupNotFound.Error(), http.StatusNotFound)
+				return
+			}
+			util_api.RespondError(logger, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		groups = rulespb.RuleGroupList{rg}
+	} else {
+		rgs, err := a.store.ListRuleGroupsForUserAndNamespace(req.Context(), userID, namespace)
+		if err != nil {
+			util_api.RespondError(logger, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(rgs) == 0 {
+			http.Error(w, rulestore.ErrGroupNamespaceNotFound.Error(), http.StatusNotFound)
+			return
+		}
+
+		loaded, err := a.store.LoadRuleGroups(req.Context(), map[string]rulespb.RuleGroupList{userID: rgs})
+		if err != nil {
+			util_api.RespondError(logger, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		groups = loaded[userID]
+	}
+
+	for _, rg := range groups {
+		if rg.Paused == paused {
+			continue
+		}
+
+		rg.Paused = paused
+		if err := a.store.SetRuleGroup(req.Context(), userID, rg.Namespace, rg); err != nil {
+			level.Error(logger).Log("msg", "unable to store rule group", "err", err.Error(), "namespace", rg.Namespace, "group", rg.Name)
+			util_api.RespondError(logger, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	respondAccepted(w, logger)
+}
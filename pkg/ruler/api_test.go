@@ -7,15 +7,20 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/gorilla/mux"
+	"github.com/prometheus/prometheus/model/rulefmt"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
+	"gopkg.in/yaml.v3"
 
 	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
+	"github.com/cortexproject/cortex/pkg/ruler/rulestore"
 	util_api "github.com/cortexproject/cortex/pkg/util/api"
 	"github.com/cortexproject/cortex/pkg/util/services"
 )
@@ -310,6 +315,133 @@ rules:
 	}
 }
 
+func TestRuler_CreateWithStrictValidation(t *testing.T) {
+	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList), nil)
+	cfg := defaultRulerConfig(t)
+	cfg.APIStrictValidation = true
+
+	r := newTestRuler(t, cfg, store, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), r) //nolint:errcheck
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	tc := []struct {
+		name   string
+		input  string
+		status int
+		err    string
+	}{
+		{
+			name:   "with an empty payload",
+			input:  "",
+			status: 400,
+			err:    "invalid rules config: rule group name must not be empty",
+		},
+		{
+			name:   "with a valid rules file",
+			status: 202,
+			input: `
+name: test
+interval: 15s
+rules:
+- record: up_rule
+  expr: up{}
+`,
+		},
+		{
+			name:   "with an unknown rule group field",
+			status: 400,
+			input: `
+name: test
+evaluation_interval: 15s
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			err: "unable to decoded rule group: yaml: unmarshal errors:\n  line 3: field evaluation_interval not found in type rulefmt.RuleGroup",
+		},
+		{
+			name:   "with an unknown rule field",
+			status: 400,
+			input: `
+name: test
+rules:
+- record: up_rule
+  expr: up{}
+  label:
+    foo: bar
+`,
+			err: "unable to decoded rule group: yaml: unmarshal errors:\n  line 6: field label not found in type rulefmt.RuleNode",
+		},
+		{
+			name:   "with multiple documents",
+			status: 400,
+			input: `
+name: test
+rules:
+- record: up_rule
+  expr: up{}
+---
+name: other
+`,
+			err: "unable to decoded rule group: the payload must contain a single YAML document",
+		},
+	}
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			router := mux.NewRouter()
+			router.Path("/api/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+
+			req := requestFor(t, http.MethodPost, "https://localhost:8080/api/v1/rules/namespace", strings.NewReader(tt.input), "user1")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+			if tt.err != "" {
+				require.Equal(t, tt.err+"\n", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRuler_OpenAPISpec(t *testing.T) {
+	a := NewAPI(nil, nil, log.NewNopLogger())
+
+	w := httptest.NewRecorder()
+	a.OpenAPISpec(w, httptest.NewRequest(http.MethodGet, "/ruler/openapi.yaml", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+
+	spec := struct {
+		Paths      map[string]interface{} `yaml:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]interface{} `yaml:"properties"`
+			} `yaml:"schemas"`
+		} `yaml:"components"`
+	}{}
+	require.NoError(t, yaml.Unmarshal(w.Body.Bytes(), &spec))
+	require.Contains(t, spec.Paths, "/api/v1/rules/{namespace}")
+
+	// The schemas must be kept in sync with the fields accepted by the API.
+	for schema, typ := range map[string]reflect.Type{
+		"RuleGroup": reflect.TypeOf(rulefmt.RuleGroup{}),
+		"Rule":      reflect.TypeOf(rulefmt.RuleNode{}),
+	} {
+		var fields []string
+		for i := 0; i < typ.NumField(); i++ {
+			fields = append(fields, strings.Split(typ.Field(i).Tag.Get("yaml"), ",")[0])
+		}
+
+		var properties []string
+		for name := range spec.Components.Schemas[schema].Properties {
+			properties = append(properties, name)
+		}
+		require.ElementsMatch(t, fields, properties, schema)
+	}
+}
+
 func TestRuler_DeleteNamespace(t *testing.T) {
 	store := newMockRuleStore(mockRulesNamespaces, nil)
 	cfg := defaultRulerConfig(t)
@@ -348,6 +480,84 @@ func TestRuler_DeleteNamespace(t *testing.T) {
 	require.Equal(t, "{\"status\":\"error\",\"errorType\":\"server_error\",\"error\":\"unable to delete rg\"}", w.Body.String())
 }
 
+func TestRuler_PauseRuleGroup(t *testing.T) {
+	newGroup := func(namespace, name string) *rulespb.RuleGroupDesc {
+		return &rulespb.RuleGroupDesc{
+			Name:      name,
+			Namespace: namespace,
+			User:      "user1",
+			Rules:     []*rulespb.RuleDesc{{Record: "UP_RULE", Expr: "up"}},
+			Interval:  interval,
+		}
+	}
+	store := newMockRuleStore(map[string]rulespb.RuleGroupList{
+		"user1": {newGroup("namespace1", "group1"), newGroup("namespace1", "group2"), newGroup("namespace2", "group1")},
+	}, nil)
+	cfg := defaultRulerConfig(t)
+
+	r := newTestRuler(t, cfg, store, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), r) //nolint:errcheck
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/rules/{namespace}").Methods(http.MethodPost).HandlerFunc(a.CreateRuleGroup)
+	router.Path("/api/v1/rules/{namespace}/pause").Methods(http.MethodPost).HandlerFunc(a.PauseRuleGroup)
+	router.Path("/api/v1/rules/{namespace}/resume").Methods(http.MethodPost).HandlerFunc(a.ResumeRuleGroup)
+	router.Path("/api/v1/rules/{namespace}/{groupName}/pause").Methods(http.MethodPost).HandlerFunc(a.PauseRuleGroup)
+	router.Path("/api/v1/rules/{namespace}/{groupName}/resume").Methods(http.MethodPost).HandlerFunc(a.ResumeRuleGroup)
+
+	post := func(url, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, requestFor(t, http.MethodPost, "https://localhost:8080"+url, strings.NewReader(body), "user1"))
+		return w
+	}
+	paused := func() map[string]bool {
+		res := map[string]bool{}
+		for _, rg := range store.rules["user1"] {
+			res[rg.Namespace+"/"+rg.Name] = rg.Paused
+		}
+		return res
+	}
+
+	// Pause a single rule group.
+	w := post("/api/v1/rules/namespace1/group1/pause", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, "{\"status\":\"success\"}", w.Body.String())
+	require.Equal(t, map[string]bool{"namespace1/group1": true, "namespace1/group2": false, "namespace2/group1": false}, paused())
+
+	// Updating the rule group keeps it paused.
+	w = post("/api/v1/rules/namespace1", "name: group1\nrules:\n- record: UP_RULE\n  expr: up > 0\n")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, map[string]bool{"namespace1/group1": true, "namespace1/group2": false, "namespace2/group1": false}, paused())
+
+	// The paused rule groups are not loaded by the ruler.
+	owned, _, err := r.loadRuleGroups(context.Background())
+	require.NoError(t, err)
+	require.Len(t, owned["user1"], 2)
+	for _, rg := range owned["user1"] {
+		require.False(t, rg.Namespace == "namespace1" && rg.Name == "group1")
+	}
+
+	// Pause and resume a whole namespace.
+	w = post("/api/v1/rules/namespace1/pause", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, map[string]bool{"namespace1/group1": true, "namespace1/group2": true, "namespace2/group1": false}, paused())
+
+	w = post("/api/v1/rules/namespace1/resume", "")
+	require.Equal(t, http.StatusAccepted, w.Code)
+	require.Equal(t, map[string]bool{"namespace1/group1": false, "namespace1/group2": false, "namespace2/group1": false}, paused())
+
+	// Unknown rule groups and namespaces.
+	w = post("/api/v1/rules/namespace1/unknown/pause", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, rulestore.ErrGroupNotFound.Error()+"\n", w.Body.String())
+
+	w = post("/api/v1/rules/unknown/resume", "")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, rulestore.ErrGroupNamespaceNotFound.Error()+"\n", w.Body.String())
+}
+
 func TestRuler_LimitsPerGroup(t *testing.T) {
 	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList), nil)
 	cfg := defaultRulerConfig(t)
@@ -463,6 +673,84 @@ rules:
 	}
 }
 
+func TestRuler_MinEvaluationInterval(t *testing.T) {
+	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList), nil)
+	cfg := defaultRulerConfig(t)
+
+	r := newTestRuler(t, cfg, store, nil)
+	defer services.StopAndAwaitTerminated(context.Background(), r) //nolint:errcheck
+
+	r.limits = ruleLimits{defaultEvalInterval: time.Minute, minEvalInterval: 30 * time.Second}
+
+	a := NewAPI(r, r.store, log.NewNopLogger())
+
+	tc := []struct {
+		name   string
+		input  string
+		output string
+		status int
+	}{
+		{
+			name:   "when the interval is lower than the minimum",
+			status: 400,
+			input: `
+name: test_fast
+interval: 15s
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "per-user rule group minimum evaluation interval (limit: 30s actual: 15s) exceeded\n",
+		},
+		{
+			name:   "when the interval is greater than the minimum",
+			status: 202,
+			input: `
+name: test_slow
+interval: 30s
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "{\"status\":\"success\"}",
+		},
+		{
+			name:   "when the interval is not set",
+			status: 202,
+			input: `
+name: test_default
+rules:
+- record: up_rule
+  expr: up{}
+`,
+			output: "{\"status\":\"success\"}",
+		},
+	}
+
+	router := mux.NewRouter()
+	router.Path("/api/v1/rules/{namespace}").Methods("POST").HandlerFunc(a.CreateRuleGroup)
+
+	for _, tt := range tc {
+		t.Run(tt.name, func(t *testing.T) {
+			req := requestFor(t, http.MethodPost, "https://localhost:8080/api/v1/rules/namespace", strings.NewReader(tt.input), "user1")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tt.status, w.Code)
+			require.Equal(t, tt.output, w.Body.String())
+		})
+	}
+
+	// The rule groups not setting an interval are evaluated at the tenant default interval.
+	configs := map[string]rulespb.RuleGroupList{"user1": {
+		{Name: "default", Namespace: "namespace", User: "user1"},
+		{Name: "custom", Namespace: "namespace", User: "user1", Interval: 2 * time.Minute},
+	}}
+	r.applyDefaultEvaluationInterval(configs)
+	require.Equal(t, time.Minute, configs["user1"][0].Interval)
+	require.Equal(t, 2*time.Minute, configs["user1"][1].Interval)
+}
+
 func TestRuler_ProtoToRuleGroupYamlConvertion(t *testing.T) {
 	store := newMockRuleStore(make(map[string]rulespb.RuleGroupList), nil)
 	cfg := defaultRulerConfig(t)
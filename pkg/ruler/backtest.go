@@ -0,0 +1,245 @@
+package ruler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/storage"
+
+	"github.com/cortexproject/cortex/pkg/tenant"
+	"github.com/cortexproject/cortex/pkg/util"
+	util_api "github.com/cortexproject/cortex/pkg/util/api"
+	util_log "github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
+)
+
+// maxBacktestSteps is the maximum number of evaluations of a backtest, the same as the
+// maximum number of points per series of the Prometheus range queries.
+const maxBacktestSteps = 11000
+
+// BacktestInterval is a time interval during which a backtested alert would have fired.
+type BacktestInterval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// BacktestAlert has the firing intervals of a backtested alert.
+type BacktestAlert struct {
+	Labels    labels.Labels      `json:"labels"`
+	Intervals []BacktestInterval `json:"intervals"`
+}
+
+// BacktestResult is the result of a backtest.
+type BacktestResult struct {
+	Alerts []*BacktestAlert `json:"alerts"`
+}
+
+// Backtester evaluates a candidate alerting rule expression over a historical time range,
+// through the query path, and returns the intervals during which the alerts would have fired.
+type Backtester struct {
+	engine      promql.QueryEngine
+	queryable   storage.Queryable
+	limits      RulesLimits
+	defaultStep time.Duration
+	logger      log.Logger
+}
+
+// NewBacktester makes a new Backtester.
+func NewBacktester(cfg Config, engine promql.QueryEngine, queryable storage.Queryable, limits RulesLimits, logger log.Logger) *Backtester {
+	return &Backtester{
+		engine:      engine,
+		queryable:   queryable,
+		limits:      limits,
+		defaultStep: cfg.EvaluationInterval,
+		logger:      logger,
+	}
+}
+
+// Backtest evaluates the input expression every step between start and end, and returns the
+// intervals during which the alerts would have fired given the input for duration.
+func (b *Backtester) Backtest(ctx context.Context, userID, expr string, forDuration time.Duration, start, end time.Time, step time.Duration) (*BacktestResult, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("end timestamp must not be before start time")
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("zero or negative step is not accepted")
+	}
+	if end.Sub(start)/step > maxBacktestSteps {
+		return nil, fmt.Errorf("exceeded maximum resolution of %d evaluations per backtest, try increasing the step", maxBacktestSteps)
+	}
+	if maxQueryLength := b.limits.MaxQueryLength(userID); maxQueryLength > 0 && end.Sub(start) > maxQueryLength {
+		return nil, validation.LimitError(fmt.Sprintf(validation.ErrQueryTooLong, end.Sub(start), maxQueryLength))
+	}
+
+	q, err := b.engine.NewRangeQuery(ctx, b.queryable, nil, expr, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+	defer q.Close()
+
+	res := q.Exec(ctx)
+	if res.Err != nil {
+		return nil, res.Err
+	}
+	matrix, ok := res.Value.(promql.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("rule result is not a vector or scalar")
+	}
+
+	// Like the alerting rules, the metric name is dropped from the alert labels, so
+	// multiple series may be the same alert.
+	active := map[string][]int64{}
+	alertLabels := map[string]labels.Labels{}
+	for _, series := range matrix {
+		lbls := labels.NewBuilder(series.Metric).Del(labels.MetricName).Labels()
+		key := lbls.String()
+		alertLabels[key] = lbls
+
+		for _, p := range series.Floats {
+			active[key] = append(active[key], p.T)
+		}
+		for _, p := range series.Histograms {
+			active[key] = append(active[key], p.T)
+		}
+	}
+
+	result := &BacktestResult{Alerts: []*BacktestAlert{}}
+	for key, timestamps := range active {
+		intervals := firingIntervals(timestamps, forDuration.Milliseconds(), step.Milliseconds())
+		if len(intervals) == 0 {
+			continue
+		}
+		result.Alerts = append(result.Alerts, &BacktestAlert{Labels: alertLabels[key], Intervals: intervals})
+	}
+
+	sort.Slice(result.Alerts, func(i, j int) bool {
+		return labels.Compare(result.Alerts[i].Labels, result.Alerts[j].Labels) < 0
+	})
+
+	return result, nil
+}
+
+// firingIntervals returns the intervals during which an alert active at the input evaluation
+// timestamps would have fired. As with the alerting rules, an alert fires once it has been
+// active for the for duration in consecutive evaluations.
+func firingIntervals(timestamps []int64, forMs, stepMs int64) []BacktestInterval {
+	slices.Sort(timestamps)
+	timestamps = slices.Compact(timestamps)
+
+	var (
+		intervals []BacktestInterval
+		activeAt  int64
+		firingAt  int64
+		firing    bool
+	)
+	for i, ts := range timestamps {
+		if i == 0 || ts-timestamps[i-1] > stepMs {
+			activeAt = ts
+		}
+		if !firing && ts-activeAt >= forMs {
+			firing, firingAt = true, ts
+		}
+
+		// Close the firing interval at the end of the consecutive evaluations.
+		if firing && (i == len(timestamps)-1 || timestamps[i+1]-ts > stepMs) {
+			intervals = append(intervals, BacktestInterval{Start: util.TimeFromMillis(firingAt), End: util.TimeFromMillis(ts)})
+			firing = false
+		}
+	}
+
+	return intervals
+}
+
+// BacktestHandler is a http.Handler running the backtest of the candidate alerting rule
+// expression received in the request parameters.
+func (b *Backtester) BacktestHandler(w http.ResponseWriter, req *http.Request) {
+	logger := util_log.WithContext(req.Context(), b.logger)
+	userID, err := tenant.TenantID(req.Context())
+	if err != nil || userID == "" {
+		level.Error(logger).Log("msg", "error extracting org id from context", "err", err)
+		util_api.RespondError(logger, w, v1.ErrBadData, "no valid org id found", http.StatusBadRequest)
+		return
+	}
+
+	expr := req.FormValue("expr")
+	if expr == "" {
+		util_api.RespondError(logger, w, v1.ErrBadData, "the expr parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	start, err := util.ParseTime(req.FormValue("start"))
+	if err != nil {
+		util_api.RespondError(logger, w, v1.ErrBadData, "invalid start parameter", http.StatusBadRequest)
+		return
+	}
+	end, err := util.ParseTime(req.FormValue("end"))
+	if err != nil {
+		util_api.RespondError(logger, w, v1.ErrBadData, "invalid end parameter", http.StatusBadRequest)
+		return
+	}
+
+	step := b.defaultStep
+	if req.FormValue("step") != "" {
+		if step, err = parseBacktestDuration(req.FormValue("step")); err != nil {
+			util_api.RespondError(logger, w, v1.ErrBadData, "invalid step parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	var forDuration time.Duration
+	if req.FormValue("for") != "" {
+		if forDuration, err = parseBacktestDuration(req.FormValue("for")); err != nil || forDuration < 0 {
+			util_api.RespondError(logger, w, v1.ErrBadData, "invalid for parameter", http.StatusBadRequest)
+			return
+		}
+	}
+
+	result, err := b.Backtest(req.Context(), userID, expr, forDuration, util.TimeFromMillis(start), util.TimeFromMillis(end), step)
+	if err != nil {
+		util_api.RespondError(logger, w, v1.ErrBadData, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(&util_api.Response{
+		Status: "success",
+		Data:   result,
+	})
+	if err != nil {
+		level.Error(logger).Log("msg", "error marshaling json response", "err", err)
+		util_api.RespondError(logger, w, v1.ErrServer, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if n, err := w.Write(data); err != nil {
+		level.Error(logger).Log("msg", "error writing response", "bytesWritten", n, "err", err)
+	}
+}
+
+// parseBacktestDuration parses a duration expressed either in seconds or in the Prometheus duration format.
+func parseBacktestDuration(s string) (time.Duration, error) {
+	if d, err := strconv.ParseFloat(s, 64); err == nil {
+		ts := d * float64(time.Second)
+		if ts > float64(math.MaxInt64) || ts < float64(math.MinInt64) {
+			return 0, fmt.Errorf("cannot parse %q to a valid duration, it overflows int64", s)
+		}
+		return time.Duration(ts), nil
+	}
+	if d, err := model.ParseDuration(s); err == nil {
+		return time.Duration(d), nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
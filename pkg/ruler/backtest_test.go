@@ -0,0 +1,149 @@
+package ruler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	util_api "github.com/cortexproject/cortex/pkg/util/api"
+)
+
+func TestFiringIntervals(t *testing.T) {
+	tests := map[string]struct {
+		timestamps []int64
+		forMs      int64
+		expected   [][2]int64
+	}{
+		"no active evaluations": {
+			timestamps: nil,
+			forMs:      0,
+			expected:   nil,
+		},
+		"fires at the first evaluation without for duration": {
+			timestamps: []int64{10, 20, 30, 50},
+			forMs:      0,
+			expected:   [][2]int64{{10, 30}, {50, 50}},
+		},
+		"fires once active for the for duration": {
+			timestamps: []int64{10, 20, 30, 40, 60, 70},
+			forMs:      20,
+			expected:   [][2]int64{{30, 40}},
+		},
+		"never fires if not active long enough": {
+			timestamps: []int64{10, 20, 40, 50},
+			forMs:      20,
+			expected:   nil,
+		},
+		"duplicate evaluations": {
+			timestamps: []int64{30, 10, 20, 20, 30},
+			forMs:      10,
+			expected:   [][2]int64{{20, 30}},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			var actual [][2]int64
+			for _, interval := range firingIntervals(testData.timestamps, testData.forMs, 10) {
+				actual = append(actual, [2]int64{util.TimeToMillis(interval.Start), util.TimeToMillis(interval.End)})
+			}
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestBacktester_BacktestHandler(t *testing.T) {
+	db, err := tsdb.Open(t.TempDir(), nil, nil, tsdb.DefaultOptions(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	// Series "a" is above the threshold between 2m and 6m, series "b" between 8m and 9m.
+	start := time.Now().Truncate(time.Hour).Add(-time.Hour)
+	app := db.Appender(context.Background())
+	for i := 0; i <= 10; i++ {
+		ts := util.TimeToMillis(start.Add(time.Duration(i) * time.Minute))
+		valueA, valueB := 0.0, 0.0
+		if i >= 2 && i <= 6 {
+			valueA = 1
+		}
+		if i >= 8 && i <= 9 {
+			valueB = 1
+		}
+		_, err := app.Append(0, labels.FromStrings(labels.MetricName, "errors", "instance", "a"), ts, valueA)
+		require.NoError(t, err)
+		_, err = app.Append(0, labels.FromStrings(labels.MetricName, "errors", "instance", "b"), ts, valueB)
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Commit())
+
+	engine := promql.NewEngine(promql.EngineOpts{
+		MaxSamples:    1e6,
+		Timeout:       time.Minute,
+		LookbackDelta: time.Minute,
+	})
+	backtester := NewBacktester(Config{EvaluationInterval: time.Minute}, engine, db, ruleLimits{}, log.NewNopLogger())
+
+	backtest := func(params url.Values) (int, util_api.Response, *BacktestResult) {
+		req := httptest.NewRequest(http.MethodPost, "/ruler/backtest", strings.NewReader(params.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req = req.WithContext(user.InjectOrgID(context.Background(), "user-1"))
+
+		w := httptest.NewRecorder()
+		backtester.BacktestHandler(w, req)
+
+		result := &BacktestResult{}
+		resp := util_api.Response{Data: result}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		return w.Code, resp, result
+	}
+
+	params := url.Values{
+		"expr":  []string{"errors > 0"},
+		"for":   []string{"2m"},
+		"start": []string{start.Format(time.RFC3339)},
+		"end":   []string{start.Add(10 * time.Minute).Format(time.RFC3339)},
+	}
+	code, resp, result := backtest(params)
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "success", resp.Status)
+	require.Len(t, result.Alerts, 1)
+	assert.Equal(t, labels.FromStrings("instance", "a"), result.Alerts[0].Labels)
+	require.Len(t, result.Alerts[0].Intervals, 1)
+	assert.True(t, start.Add(4*time.Minute).Equal(result.Alerts[0].Intervals[0].Start))
+	assert.True(t, start.Add(6*time.Minute).Equal(result.Alerts[0].Intervals[0].End))
+
+	// Without for duration, both series would have fired.
+	params.Set("for", "0")
+	code, _, result = backtest(params)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, result.Alerts, 2)
+	assert.True(t, start.Add(2*time.Minute).Equal(result.Alerts[0].Intervals[0].Start))
+	assert.Equal(t, labels.FromStrings("instance", "b"), result.Alerts[1].Labels)
+	assert.True(t, start.Add(8*time.Minute).Equal(result.Alerts[1].Intervals[0].Start))
+	assert.True(t, start.Add(9*time.Minute).Equal(result.Alerts[1].Intervals[0].End))
+
+	// Invalid requests are rejected.
+	params.Set("step", "1ms")
+	code, resp, _ = backtest(params)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "error", resp.Status)
+
+	params.Del("step")
+	params.Del("expr")
+	code, _, _ = backtest(params)
+	assert.Equal(t, http.StatusBadRequest, code)
+}
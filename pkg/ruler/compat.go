@@ -19,7 +19,6 @@ import (
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/prometheus/prometheus/rules"
 	"github.com/prometheus/prometheus/storage"
-	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/user"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
@@ -47,6 +46,7 @@ type PusherAppender struct {
 	histograms      []cortexpb.Histogram
 	userID          string
 	evaluationDelay time.Duration
+	writeBuffer     *writeBuffer
 }
 
 func (a *PusherAppender) AppendHistogram(_ storage.SeriesRef, l labels.Labels, t int64, h *histogram.Histogram, fh *histogram.FloatHistogram) (storage.SeriesRef, error) {
@@ -111,12 +111,15 @@ func (a *PusherAppender) Commit() error {
 	req.AddHistogramTimeSeries(a.histogramLabels, a.histograms)
 	// Since a.pusher is distributor, client.ReuseSlice will be called in a.pusher.Push.
 	// We shouldn't call client.ReuseSlice here.
-	_, err := a.pusher.Push(user.InjectOrgID(a.ctx, a.userID), req)
-	if err != nil {
-		// Don't report errors that ended with 4xx HTTP status code (series limits, duplicate samples, out of order, etc.)
-		if resp, ok := httpgrpc.HTTPResponseFromError(err); !ok || resp.Code/100 != 4 {
-			a.failedWrites.Inc()
-		}
+	var err error
+	if a.writeBuffer != nil {
+		err = a.writeBuffer.push(user.InjectOrgID(a.ctx, a.userID), a.userID, a.pusher, req)
+	} else {
+		_, err = a.pusher.Push(user.InjectOrgID(a.ctx, a.userID), req)
+	}
+	// Don't report errors that ended with 4xx HTTP status code (series limits, duplicate samples, out of order, etc.)
+	if err != nil && isRetriableWriteError(err) {
+		a.failedWrites.Inc()
 	}
 
 	a.labels = nil
@@ -146,6 +149,9 @@ type PusherAppendable struct {
 
 	totalWrites  prometheus.Counter
 	failedWrites prometheus.Counter
+
+	// writeBuffer buffers the writes failing because of the write path, if enabled.
+	writeBuffer *writeBuffer
 }
 
 func NewPusherAppendable(pusher Pusher, userID string, limits RulesLimits, totalWrites, failedWrites prometheus.Counter) *PusherAppendable {
@@ -168,6 +174,7 @@ func (t *PusherAppendable) Appender(ctx context.Context) storage.Appender {
 		pusher:          t.pusher,
 		userID:          t.userID,
 		evaluationDelay: t.rulesLimits.EvaluationDelay(t.userID),
+		writeBuffer:     t.writeBuffer,
 	}
 }
 
@@ -179,6 +186,13 @@ type RulesLimits interface {
 	RulerMaxRuleGroupsPerTenant(userID string) int
 	RulerMaxRulesPerRuleGroup(userID string) int
 	DisabledRuleGroups(userID string) validation.DisabledRuleGroups
+	RulerMetaMonitoringEnabled(userID string) bool
+	RulerMetaMonitoringLag(userID string) time.Duration
+	RulerMaxAlertAnnotationSizeBytes(userID string) int
+	RulerMaxAlertAnnotationsSizeBytes(userID string) int
+	RulerAlertAnnotationLimitAction(userID string) string
+	RulerDefaultEvaluationInterval(userID string) time.Duration
+	RulerMinEvaluationInterval(userID string) time.Duration
 }
 
 // EngineQueryFunc returns a new engine query function by passing an altered timestamp.
@@ -330,6 +344,11 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engi
 	// Errors from PromQL are always "user" errors.
 	q = querier.NewErrorTranslateQueryableWithFn(q, WrapQueryableErrors)
 
+	var buffer *writeBuffer
+	if cfg.WriteBuffer.Dir != "" {
+		buffer = newWriteBuffer(cfg.WriteBuffer, util_log.Logger, reg)
+	}
+
 	return func(ctx context.Context, userID string, notifier *notifier.Manager, logger log.Logger, reg prometheus.Registerer) RulesManager {
 		var queryTime prometheus.Counter
 		if evalMetrics.RulerQuerySeconds != nil {
@@ -340,17 +359,21 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engi
 		totalQueries := evalMetrics.TotalQueriesVec.WithLabelValues(userID)
 		totalWrites := evalMetrics.TotalWritesVec.WithLabelValues(userID)
 		failedWrites := evalMetrics.FailedWritesVec.WithLabelValues(userID)
+		limitedAnnotations := evalMetrics.LimitedAnnotationsVec.WithLabelValues(userID)
 
 		engineQueryFunc := EngineQueryFunc(engine, q, overrides, userID, cfg.LookbackDelta)
 		metricsQueryFunc := MetricsQueryFunc(engineQueryFunc, totalQueries, failedQueries)
 
-		return rules.NewManager(&rules.ManagerOptions{
-			Appendable:             NewPusherAppendable(p, userID, overrides, totalWrites, failedWrites),
+		appendable := NewPusherAppendable(p, userID, overrides, totalWrites, failedWrites)
+		appendable.writeBuffer = buffer
+
+		manager := rules.NewManager(&rules.ManagerOptions{
+			Appendable:             appendable,
 			Queryable:              q,
 			QueryFunc:              RecordAndReportRuleQueryMetrics(metricsQueryFunc, queryTime, logger),
 			Context:                user.InjectOrgID(ctx, userID),
 			ExternalURL:            cfg.ExternalURL.URL,
-			NotifyFunc:             SendAlerts(notifier, cfg.ExternalURL.URL.String()),
+			NotifyFunc:             SendAlerts(&annotationLimitingSender{sender: notifier, limits: overrides, userID: userID, limited: limitedAnnotations}, cfg.ExternalURL.URL.String()),
 			Logger:                 log.With(logger, "user", userID),
 			Registerer:             reg,
 			OutageTolerance:        cfg.OutageTolerance,
@@ -359,9 +382,30 @@ func DefaultTenantManagerFactory(cfg Config, p Pusher, q storage.Queryable, engi
 			ConcurrentEvalsEnabled: cfg.ConcurrentEvalsEnabled,
 			MaxConcurrentEvals:     cfg.MaxConcurrentEvals,
 		})
+		if buffer == nil {
+			return manager
+		}
+		return &writeBufferRulesManager{RulesManager: manager, ctx: ctx, userID: userID, pusher: p, buffer: buffer}
 	}
 }
 
+// writeBufferRulesManager closes the tenant write buffer once the rules manager is stopped.
+type writeBufferRulesManager struct {
+	RulesManager
+
+	ctx    context.Context
+	userID string
+	pusher Pusher
+	buffer *writeBuffer
+}
+
+// Stop stops the rules manager and closes the tenant write buffer. The ruler context is only
+// canceled at shutdown, otherwise the tenant has been removed from this ruler.
+func (m *writeBufferRulesManager) Stop() {
+	m.RulesManager.Stop()
+	m.buffer.close(m.userID, m.pusher, m.ctx.Err() == nil)
+}
+
 type QueryableError struct {
 	err error
 }
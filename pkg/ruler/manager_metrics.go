@@ -225,11 +225,12 @@ func (m *ManagerMetrics) Collect(out chan<- prometheus.Metric) {
 }
 
 type RuleEvalMetrics struct {
-	TotalWritesVec    *prometheus.CounterVec
-	FailedWritesVec   *prometheus.CounterVec
-	TotalQueriesVec   *prometheus.CounterVec
-	FailedQueriesVec  *prometheus.CounterVec
-	RulerQuerySeconds *prometheus.CounterVec
+	TotalWritesVec        *prometheus.CounterVec
+	FailedWritesVec       *prometheus.CounterVec
+	TotalQueriesVec       *prometheus.CounterVec
+	FailedQueriesVec      *prometheus.CounterVec
+	RulerQuerySeconds     *prometheus.CounterVec
+	LimitedAnnotationsVec *prometheus.CounterVec
 }
 
 func NewRuleEvalMetrics(cfg Config, reg prometheus.Registerer) *RuleEvalMetrics {
@@ -250,6 +251,10 @@ func NewRuleEvalMetrics(cfg Config, reg prometheus.Registerer) *RuleEvalMetrics
 			Name: "cortex_ruler_queries_failed_total",
 			Help: "Number of failed queries by ruler.",
 		}, []string{"user"}),
+		LimitedAnnotationsVec: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_alert_annotations_limited_total",
+			Help: "Number of alert annotations truncated or dropped by the ruler because they exceeded the annotation size limits.",
+		}, []string{"user"}),
 	}
 	if cfg.EnableQueryStats {
 		m.RulerQuerySeconds = promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
@@ -266,6 +271,7 @@ func (m *RuleEvalMetrics) deletePerUserMetrics(userID string) {
 	m.FailedWritesVec.DeleteLabelValues(userID)
 	m.TotalQueriesVec.DeleteLabelValues(userID)
 	m.FailedQueriesVec.DeleteLabelValues(userID)
+	m.LimitedAnnotationsVec.DeleteLabelValues(userID)
 
 	if m.RulerQuerySeconds != nil {
 		m.RulerQuerySeconds.DeleteLabelValues(userID)
@@ -0,0 +1,99 @@
+package ruler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	promRules "github.com/prometheus/prometheus/rules"
+)
+
+const (
+	ruleGroupUnhealthyFailing = "failing"
+	ruleGroupUnhealthyLagging = "lagging"
+)
+
+// ruleGroupHealthCollector implements the ruler meta-monitoring: for each tenant having it enabled, it
+// exposes a synthetic series for every rule group whose last evaluation failed or which has not been
+// evaluated for longer than its interval plus the tenant's lag threshold. Platform operators
+// can alert on these series instead of writing per-tenant meta rules.
+type ruleGroupHealthCollector struct {
+	manager MultiTenantManager
+	limits  RulesLimits
+	now     func() time.Time
+
+	usersMtx sync.RWMutex
+	users    []string
+
+	unhealthy *prometheus.Desc
+}
+
+func newRuleGroupHealthCollector(manager MultiTenantManager, limits RulesLimits) *ruleGroupHealthCollector {
+	return &ruleGroupHealthCollector{
+		manager: manager,
+		limits:  limits,
+		now:     time.Now,
+		unhealthy: prometheus.NewDesc(
+			"cortex_ruler_rule_group_unhealthy",
+			"Set to 1 for each rule group whose last evaluation failed or which is lagging behind its evaluation interval. Only exposed for tenants with the ruler meta-monitoring enabled.",
+			[]string{"user", "rule_group", "reason"},
+			nil,
+		),
+	}
+}
+
+// setUsers sets the tenants whose rule groups are evaluated by this ruler.
+func (c *ruleGroupHealthCollector) setUsers(users []string) {
+	c.usersMtx.Lock()
+	defer c.usersMtx.Unlock()
+	c.users = users
+}
+
+// Describe implements prometheus.Collector.
+func (c *ruleGroupHealthCollector) Describe(out chan<- *prometheus.Desc) {
+	out <- c.unhealthy
+}
+
+// Collect implements prometheus.Collector.
+func (c *ruleGroupHealthCollector) Collect(out chan<- prometheus.Metric) {
+	c.usersMtx.RLock()
+	users := c.users
+	c.usersMtx.RUnlock()
+
+	now := c.now()
+	for _, userID := range users {
+		if !c.limits.RulerMetaMonitoringEnabled(userID) {
+			continue
+		}
+		lag := c.limits.RulerMetaMonitoringLag(userID)
+
+		for _, g := range c.manager.GetRules(userID) {
+			key := promRules.GroupKey(g.File(), g.Name())
+			if ruleGroupFailing(g) {
+				out <- prometheus.MustNewConstMetric(c.unhealthy, prometheus.GaugeValue, 1, userID, key, ruleGroupUnhealthyFailing)
+			}
+			if ruleGroupLagging(g.GetLastEvaluation(), g.Interval(), now, lag) {
+				out <- prometheus.MustNewConstMetric(c.unhealthy, prometheus.GaugeValue, 1, userID, key, ruleGroupUnhealthyLagging)
+			}
+		}
+	}
+}
+
+// ruleGroupFailing returns true if the last evaluation of any rule in the group failed.
+func ruleGroupFailing(g *promRules.Group) bool {
+	for _, r := range g.Rules() {
+		if r.Health() == promRules.HealthBad {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleGroupLagging returns true if the group has not been evaluated for longer than its interval
+// plus the lag threshold. Groups never evaluated yet are not considered lagging.
+func ruleGroupLagging(lastEvaluation time.Time, interval time.Duration, now time.Time, lag time.Duration) bool {
+	if lastEvaluation.IsZero() {
+		return false
+	}
+	return now.Sub(lastEvaluation) > interval+lag
+}
@@ -0,0 +1,73 @@
+package ruler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	promRules "github.com/prometheus/prometheus/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ruleGroupsManager struct {
+	MultiTenantManager
+	groups map[string][]*promRules.Group
+}
+
+func (m *ruleGroupsManager) GetRules(userID string) []*promRules.Group {
+	return m.groups[userID]
+}
+
+func TestRuleGroupHealthCollector(t *testing.T) {
+	newGroup := func(name string, health promRules.RuleHealth) *promRules.Group {
+		expr, err := parser.ParseExpr("up")
+		require.NoError(t, err)
+
+		rule := promRules.NewRecordingRule("rule", expr, labels.EmptyLabels())
+		rule.SetHealth(health)
+		return promRules.NewGroup(promRules.GroupOptions{
+			Name:     name,
+			File:     "namespace",
+			Interval: time.Minute,
+			Rules:    []promRules.Rule{rule},
+			Opts:     &promRules.ManagerOptions{Logger: log.NewNopLogger(), Registerer: prometheus.NewRegistry()},
+		})
+	}
+
+	manager := &ruleGroupsManager{groups: map[string][]*promRules.Group{
+		"user-1": {newGroup("healthy", promRules.HealthGood), newGroup("failing", promRules.HealthBad)},
+		"user-2": {newGroup("failing", promRules.HealthBad)},
+	}}
+
+	reg := prometheus.NewPedanticRegistry()
+	collector := newRuleGroupHealthCollector(manager, ruleLimits{metaMonitoring: true})
+	reg.MustRegister(collector)
+
+	// Only the tenants evaluated by the ruler are reported.
+	collector.setUsers([]string{"user-1"})
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ruler_rule_group_unhealthy Set to 1 for each rule group whose last evaluation failed or which is lagging behind its evaluation interval. Only exposed for tenants with the ruler meta-monitoring enabled.
+		# TYPE cortex_ruler_rule_group_unhealthy gauge
+		cortex_ruler_rule_group_unhealthy{reason="failing",rule_group="namespace;failing",user="user-1"} 1
+	`)))
+
+	// Nothing is reported when the meta-monitoring is disabled.
+	collector.limits = ruleLimits{}
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(``)))
+}
+
+func TestRuleGroupLagging(t *testing.T) {
+	now := time.Now()
+
+	assert.False(t, ruleGroupLagging(time.Time{}, time.Minute, now, time.Minute))
+	assert.False(t, ruleGroupLagging(now.Add(-90*time.Second), time.Minute, now, time.Minute))
+	assert.True(t, ruleGroupLagging(now.Add(-3*time.Minute), time.Minute, now, time.Minute))
+	assert.True(t, ruleGroupLagging(now.Add(-90*time.Second), time.Minute, now, 0))
+}
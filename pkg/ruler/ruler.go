@@ -60,13 +60,15 @@ const (
 	loadRulesConcurrency  = 10
 	fetchRulesConcurrency = 16
 
-	rulerSyncReasonInitial    = "initial"
-	rulerSyncReasonPeriodic   = "periodic"
-	rulerSyncReasonRingChange = "ring-change"
+	rulerSyncReasonInitial     = "initial"
+	rulerSyncReasonPeriodic    = "periodic"
+	rulerSyncReasonRingChange  = "ring-change"
+	rulerSyncReasonStoreChange = "rule-store-change"
 
 	// Limit errors
 	errMaxRuleGroupsPerUserLimitExceeded        = "per-user rule groups limit (limit: %d actual: %d) exceeded"
 	errMaxRulesPerRuleGroupPerUserLimitExceeded = "per-user rules per rule group limit (limit: %d actual: %d) exceeded"
+	errMinEvaluationIntervalExceeded            = "per-user rule group minimum evaluation interval (limit: %s actual: %s) exceeded"
 
 	// errors
 	errListAllUser = "unable to list the ruler users"
@@ -132,6 +134,7 @@ type Config struct {
 
 	EnableAPI           bool `yaml:"enable_api"`
 	APIDeduplicateRules bool `yaml:"api_deduplicate_rules"`
+	APIStrictValidation bool `yaml:"api_strict_validation"`
 
 	EnabledTenants  flagext.StringSliceCSV `yaml:"enabled_tenants"`
 	DisabledTenants flagext.StringSliceCSV `yaml:"disabled_tenants"`
@@ -143,6 +146,8 @@ type Config struct {
 
 	EnableQueryStats      bool `yaml:"query_stats_enabled"`
 	DisableRuleGroupLabel bool `yaml:"disable_rule_group_label"`
+
+	WriteBuffer WriteBufferConfig `yaml:"write_buffer"`
 }
 
 // Validate config and returns error on failure
@@ -162,6 +167,10 @@ func (cfg *Config) Validate(limits validation.Limits, log log.Logger) error {
 	if cfg.ConcurrentEvalsEnabled && cfg.MaxConcurrentEvals <= 0 {
 		return errInvalidMaxConcurrentEvals
 	}
+
+	if err := cfg.WriteBuffer.Validate(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -170,6 +179,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.ClientTLSConfig.RegisterFlagsWithPrefix("ruler.client", f)
 	cfg.Ring.RegisterFlags(f)
 	cfg.Notifier.RegisterFlags(f)
+	cfg.WriteBuffer.RegisterFlags(f)
 
 	// Deprecated Flags that will be maintained to avoid user disruption
 
@@ -200,6 +210,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.StringVar(&cfg.RulePath, "ruler.rule-path", "/rules", "file path to store temporary rule files for the prometheus rule managers")
 	f.BoolVar(&cfg.EnableAPI, "experimental.ruler.enable-api", false, "Enable the ruler api")
 	f.BoolVar(&cfg.APIDeduplicateRules, "experimental.ruler.api-deduplicate-rules", false, "EXPERIMENTAL: Remove duplicate rules in the prometheus rules and alerts API response. If there are duplicate rules the rule with the latest evaluation timestamp will be kept.")
+	f.BoolVar(&cfg.APIStrictValidation, "ruler.api-strict-validation", false, "[Experimental] Reject the rule groups submitted to the ruler config API which contain unknown fields or multiple YAML documents, instead of silently ignoring them. The OpenAPI specification of the API is served at /ruler/openapi.yaml.")
 	f.DurationVar(&cfg.OutageTolerance, "ruler.for-outage-tolerance", time.Hour, `Max time to tolerate outage for restoring "for" state of alert.`)
 	f.DurationVar(&cfg.ForGracePeriod, "ruler.for-grace-period", 10*time.Minute, `Minimum duration between alert and restored "for" state. This is maintained only for alerts with configured "for" time greater than grace period.`)
 	f.DurationVar(&cfg.ResendDelay, "ruler.resend-delay", time.Minute, `Minimum amount of time to wait before resending an alert to Alertmanager.`)
@@ -286,6 +297,7 @@ type Ruler struct {
 	ruleGroupStoreLoadDuration prometheus.Gauge
 	ruleGroupSyncDuration      prometheus.Gauge
 	rulerGetRulesFailures      *prometheus.CounterVec
+	ruleGroupHealth            *ruleGroupHealthCollector
 
 	allowedTenants *util.AllowedTenants
 
@@ -333,6 +345,12 @@ func newRuler(cfg Config, manager MultiTenantManager, reg prometheus.Registerer,
 			Name: "cortex_ruler_get_rules_failure_total",
 			Help: "The total number of failed rules request sent to rulers in getShardedRules.",
 		}, []string{"ruler"}),
+
+		ruleGroupHealth: newRuleGroupHealthCollector(manager, limits),
+	}
+
+	if reg != nil {
+		reg.MustRegister(ruler.ruleGroupHealth)
 	}
 
 	if len(cfg.EnabledTenants) > 0 {
@@ -465,6 +483,28 @@ func ruleGroupDisabled(ruleGroup *rulespb.RuleGroupDesc, disabledRuleGroupsForUs
 	return false
 }
 
+// filterPausedRuleGroups removes the rule groups whose evaluation has been paused through the API.
+// Users left without rule groups are removed too.
+func filterPausedRuleGroups(configs map[string]rulespb.RuleGroupList, logger log.Logger) map[string]rulespb.RuleGroupList {
+	for userID, groups := range configs {
+		filtered := groups[:0]
+		for _, group := range groups {
+			if group.Paused {
+				level.Debug(logger).Log("msg", "rule group paused", "name", group.Name, "namespace", group.Namespace, "user", userID)
+				continue
+			}
+			filtered = append(filtered, group)
+		}
+
+		if len(filtered) == 0 {
+			delete(configs, userID)
+			continue
+		}
+		configs[userID] = filtered
+	}
+	return configs
+}
+
 var sep = []byte("/")
 
 func tokenForGroup(g *rulespb.RuleGroupDesc) uint32 {
@@ -546,6 +586,15 @@ func (r *Ruler) run(ctx context.Context) error {
 		ringTickerChan = ringTicker.C
 	}
 
+	// Rule stores able to notify about changes trigger a sync as soon as the rule groups change.
+	var storeChangesChan <-chan struct{}
+	if watcher, ok := r.store.(rulestore.ChangesWatcher); ok {
+		var err error
+		if storeChangesChan, err = watcher.WatchChanges(ctx); err != nil {
+			return errors.Wrap(err, "unable to watch the rule store for changes")
+		}
+	}
+
 	r.syncRules(ctx, rulerSyncReasonInitial)
 	for {
 		select {
@@ -562,6 +611,12 @@ func (r *Ruler) run(ctx context.Context) error {
 				ringLastState = currRingState
 				r.syncRules(ctx, rulerSyncReasonRingChange)
 			}
+		case _, ok := <-storeChangesChan:
+			if !ok {
+				storeChangesChan = nil
+				continue
+			}
+			r.syncRules(ctx, rulerSyncReasonStoreChange)
 		case err := <-r.subservicesWatcher.Chan():
 			return errors.Wrap(err, "ruler subservice failed")
 		}
@@ -586,6 +641,12 @@ func (r *Ruler) syncRules(ctx context.Context, reason string) {
 	// This will also delete local group files for users that are no longer in 'configs' map.
 	r.manager.SyncRuleGroups(ctx, loadedConfigs)
 
+	users := make([]string, 0, len(loadedConfigs))
+	for userID := range loadedConfigs {
+		users = append(users, userID)
+	}
+	r.ruleGroupHealth.setUsers(users)
+
 	if r.cfg.RulesBackupEnabled() {
 		r.manager.BackUpRuleGroups(ctx, backupConfigs)
 	}
@@ -609,11 +670,15 @@ func (r *Ruler) loadRuleGroups(ctx context.Context) (map[string]rulespb.RuleGrou
 	if err != nil {
 		level.Warn(r.logger).Log("msg", "failed to load some rules owned by this ruler", "count", len(ownedConfigs)-len(loadedOwnedConfigs), "err", err)
 	}
+	// Whether a rule group is paused is only known once its content has been loaded.
+	loadedOwnedConfigs = filterPausedRuleGroups(loadedOwnedConfigs, r.logger)
+	r.applyDefaultEvaluationInterval(loadedOwnedConfigs)
 	if r.cfg.RulesBackupEnabled() {
 		loadedBackupConfigs, err := r.store.LoadRuleGroups(ctx, backupConfigs)
 		if err != nil {
 			level.Warn(r.logger).Log("msg", "failed to load some rules backed up by this ruler", "count", len(backupConfigs)-len(loadedBackupConfigs), "err", err)
 		}
+		r.applyDefaultEvaluationInterval(loadedBackupConfigs)
 		return loadedOwnedConfigs, loadedBackupConfigs, nil
 	}
 	return loadedOwnedConfigs, nil, nil
@@ -1025,7 +1090,7 @@ func (r *Ruler) ruleGroupListToGroupStateDesc(userID string, backupGroups rulesp
 				continue
 			}
 		}
-		interval := r.cfg.EvaluationInterval
+		interval := r.defaultEvaluationInterval(userID)
 		if group.Interval != 0 {
 			interval = group.Interval
 		}
@@ -1233,6 +1298,48 @@ func (r *Ruler) AssertMaxRulesPerRuleGroup(userID string, rules int) error {
 	return fmt.Errorf(errMaxRulesPerRuleGroupPerUserLimitExceeded, limit, rules)
 }
 
+// AssertMinEvaluationInterval checks the evaluation interval of a rule group of the user, the
+// default one if not set, is not lower than the minimum evaluation interval and returns an error if so.
+func (r *Ruler) AssertMinEvaluationInterval(userID string, interval time.Duration) error {
+	limit := r.limits.RulerMinEvaluationInterval(userID)
+
+	if limit <= 0 {
+		return nil
+	}
+
+	if interval == 0 {
+		interval = r.defaultEvaluationInterval(userID)
+	}
+	if interval >= limit {
+		return nil
+	}
+	return fmt.Errorf(errMinEvaluationIntervalExceeded, limit, interval)
+}
+
+// defaultEvaluationInterval returns the evaluation interval of the rule groups of the user not setting one.
+func (r *Ruler) defaultEvaluationInterval(userID string) time.Duration {
+	if interval := r.limits.RulerDefaultEvaluationInterval(userID); interval > 0 {
+		return interval
+	}
+	return r.cfg.EvaluationInterval
+}
+
+// applyDefaultEvaluationInterval sets the per-tenant default evaluation interval on the rule groups
+// not setting one, if the tenant overrides it.
+func (r *Ruler) applyDefaultEvaluationInterval(configs map[string]rulespb.RuleGroupList) {
+	for userID, groups := range configs {
+		interval := r.limits.RulerDefaultEvaluationInterval(userID)
+		if interval <= 0 {
+			continue
+		}
+		for _, group := range groups {
+			if group.Interval == 0 {
+				group.Interval = interval
+			}
+		}
+	}
+}
+
 func (r *Ruler) DeleteTenantConfiguration(w http.ResponseWriter, req *http.Request) {
 	logger := util_log.WithContext(req.Context(), r.logger)
 
@@ -88,6 +88,13 @@ type ruleLimits struct {
 	maxRuleGroups        int
 	disabledRuleGroups   validation.DisabledRuleGroups
 	maxQueryLength       time.Duration
+	metaMonitoring       bool
+	metaMonitoringLag    time.Duration
+	maxAnnotationSize    int
+	maxAnnotationsSize   int
+	annotationAction     string
+	defaultEvalInterval  time.Duration
+	minEvalInterval      time.Duration
 }
 
 func (r ruleLimits) EvaluationDelay(_ string) time.Duration {
@@ -112,6 +119,22 @@ func (r ruleLimits) DisabledRuleGroups(userID string) validation.DisabledRuleGro
 
 func (r ruleLimits) MaxQueryLength(_ string) time.Duration { return r.maxQueryLength }
 
+func (r ruleLimits) RulerMetaMonitoringEnabled(_ string) bool { return r.metaMonitoring }
+
+func (r ruleLimits) RulerMetaMonitoringLag(_ string) time.Duration { return r.metaMonitoringLag }
+
+func (r ruleLimits) RulerMaxAlertAnnotationSizeBytes(_ string) int { return r.maxAnnotationSize }
+
+func (r ruleLimits) RulerMaxAlertAnnotationsSizeBytes(_ string) int { return r.maxAnnotationsSize }
+
+func (r ruleLimits) RulerAlertAnnotationLimitAction(_ string) string { return r.annotationAction }
+
+func (r ruleLimits) RulerDefaultEvaluationInterval(_ string) time.Duration {
+	return r.defaultEvalInterval
+}
+
+func (r ruleLimits) RulerMinEvaluationInterval(_ string) time.Duration { return r.minEvalInterval }
+
 func newEmptyQueryable() storage.Queryable {
 	return storage.QueryableFunc(func(mint, maxt int64) (storage.Querier, error) {
 		return emptyQuerier{}, nil
@@ -1733,6 +1756,7 @@ func Test_LoadPartialGroups(t *testing.T) {
 	store := newMockRuleStore(allRules, map[string]error{user1: fmt.Errorf("test")})
 	u, _ := url.Parse("")
 	cfg := Config{
+		RulePath:         t.TempDir(),
 		EnableSharding:   true,
 		ExternalURL:      flagext.URLValue{URL: u},
 		PollInterval:     time.Millisecond * 100,
@@ -1993,6 +2017,63 @@ func TestSendAlerts(t *testing.T) {
 	}
 }
 
+func TestSendAlerts_ShouldLimitAnnotations(t *testing.T) {
+	annotations := labels.FromStrings("description", strings.Repeat("é", 20), "runbook", "http://runbook.example.com/alerts/up", "summary", "short")
+
+	tests := map[string]struct {
+		limits          ruleLimits
+		expected        labels.Labels
+		expectedLimited float64
+	}{
+		"no limits": {
+			limits:   ruleLimits{annotationAction: validation.RulerAlertAnnotationLimitActionTruncate},
+			expected: annotations,
+		},
+		"truncate annotations exceeding the max size without splitting characters": {
+			limits:          ruleLimits{maxAnnotationSize: 21, annotationAction: validation.RulerAlertAnnotationLimitActionTruncate},
+			expected:        labels.FromStrings("description", "ééé"+truncatedAnnotationSuffix, "runbook", "http://"+truncatedAnnotationSuffix, "summary", "short"),
+			expectedLimited: 2,
+		},
+		"drop annotations exceeding the max size": {
+			limits:          ruleLimits{maxAnnotationSize: 14, annotationAction: validation.RulerAlertAnnotationLimitActionDrop},
+			expected:        labels.FromStrings("summary", "short"),
+			expectedLimited: 2,
+		},
+		"truncate annotations exceeding the max total size in name order": {
+			limits:          ruleLimits{maxAnnotationsSize: 80, annotationAction: validation.RulerAlertAnnotationLimitActionTruncate},
+			expected:        labels.FromStrings("description", strings.Repeat("é", 20), "runbook", "http://r"+truncatedAnnotationSuffix),
+			expectedLimited: 2,
+		},
+		"drop annotations exceeding the max total size in name order": {
+			limits:          ruleLimits{maxAnnotationsSize: 80, annotationAction: validation.RulerAlertAnnotationLimitActionDrop},
+			expected:        labels.FromStrings("description", strings.Repeat("é", 20), "summary", "short"),
+			expectedLimited: 1,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			limited := prometheus.NewCounter(prometheus.CounterOpts{Name: "limited"})
+
+			var sent []*notifier.Alert
+			s := &annotationLimitingSender{
+				sender:  senderFunc(func(alerts ...*notifier.Alert) { sent = alerts }),
+				limits:  tc.limits,
+				userID:  "user-1",
+				limited: limited,
+			}
+			SendAlerts(s, "http://localhost:9090")(context.TODO(), "up", &promRules.Alert{
+				Labels:      labels.FromStrings("l1", "v1"),
+				Annotations: annotations,
+			})
+
+			require.Len(t, sent, 1)
+			assert.Equal(t, tc.expected, sent[0].Annotations)
+			assert.Equal(t, tc.expectedLimited, prom_testutil.ToFloat64(limited))
+		})
+	}
+}
+
 // Tests for whether the Ruler is able to recover ALERTS_FOR_STATE state
 func TestRecoverAlertsPostOutage(t *testing.T) {
 	// Test Setup
@@ -45,6 +45,8 @@ type RuleGroupDesc struct {
 	// to the Prometheus Manager.
 	Options []*types.Any `protobuf:"bytes,9,rep,name=options,proto3" json:"options,omitempty"`
 	Limit   int64        `protobuf:"varint,10,opt,name=limit,proto3" json:"limit,omitempty"`
+	// Whether the evaluation of the rule group has been paused.
+	Paused bool `protobuf:"varint,11,opt,name=paused,proto3" json:"paused,omitempty"`
 }
 
 func (m *RuleGroupDesc) Reset()      { *m = RuleGroupDesc{} }
@@ -128,6 +130,13 @@ func (m *RuleGroupDesc) GetLimit() int64 {
 	return 0
 }
 
+func (m *RuleGroupDesc) GetPaused() bool {
+	if m != nil {
+		return m.Paused
+	}
+	return false
+}
+
 // RuleDesc is a proto representation of a Prometheus Rule
 type RuleDesc struct {
 	Expr          string                                                      `protobuf:"bytes,1,opt,name=expr,proto3" json:"expr,omitempty"`
@@ -214,40 +223,41 @@ func init() {
 func init() { proto.RegisterFile("rules.proto", fileDescriptor_8e722d3e922f0937) }
 
 var fileDescriptor_8e722d3e922f0937 = []byte{
-	// 524 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x52, 0x4f, 0x6f, 0xd3, 0x30,
-	0x1c, 0x8d, 0xd7, 0x34, 0x4b, 0x5d, 0x55, 0xab, 0x4c, 0x85, 0xbc, 0x81, 0xdc, 0x6a, 0x12, 0x52,
-	0x4f, 0x89, 0x34, 0xc4, 0x81, 0x03, 0x42, 0xad, 0xa6, 0x21, 0x55, 0x1c, 0x50, 0x8e, 0x08, 0x69,
-	0x72, 0x52, 0x37, 0x84, 0xa5, 0x71, 0xe4, 0x38, 0x68, 0xbb, 0xf1, 0x11, 0xb8, 0x20, 0xf1, 0x11,
-	0xf8, 0x28, 0x3b, 0x96, 0xdb, 0xc4, 0xa1, 0xd0, 0xf4, 0x82, 0x38, 0xed, 0x23, 0x20, 0xdb, 0x09,
-	0x7f, 0x0f, 0xc0, 0x81, 0x53, 0x7e, 0xcf, 0x2f, 0xcf, 0xef, 0xf9, 0xd9, 0xb0, 0x2b, 0xca, 0x94,
-	0x15, 0x5e, 0x2e, 0xb8, 0xe4, 0xa8, 0xad, 0xc1, 0xc1, 0x20, 0xe6, 0x31, 0xd7, 0x2b, 0xbe, 0x9a,
-	0x0c, 0x79, 0x40, 0x62, 0xce, 0xe3, 0x94, 0xf9, 0x1a, 0x85, 0xe5, 0xc2, 0x9f, 0x97, 0x82, 0xca,
-	0x84, 0x67, 0x35, 0xbf, 0xff, 0x2b, 0x4f, 0xb3, 0x8b, 0x9a, 0xba, 0x1f, 0x27, 0xf2, 0x79, 0x19,
-	0x7a, 0x11, 0x5f, 0xfa, 0x11, 0x17, 0x92, 0x9d, 0xe7, 0x82, 0xbf, 0x60, 0x91, 0xac, 0x91, 0x9f,
-	0x9f, 0xc5, 0x0d, 0x11, 0xd6, 0x83, 0x91, 0x1e, 0xbe, 0xd9, 0x81, 0xbd, 0xa0, 0x4c, 0xd9, 0x23,
-	0xc1, 0xcb, 0xfc, 0x98, 0x15, 0x11, 0x42, 0xd0, 0xce, 0xe8, 0x92, 0x61, 0x30, 0x02, 0xe3, 0x4e,
-	0xa0, 0x67, 0x74, 0x1b, 0x76, 0xd4, 0xb7, 0xc8, 0x69, 0xc4, 0xf0, 0x8e, 0x26, 0xbe, 0x2f, 0xa0,
-	0x87, 0xd0, 0x4d, 0x32, 0xc9, 0xc4, 0x4b, 0x9a, 0xe2, 0xd6, 0x08, 0x8c, 0xbb, 0x47, 0xfb, 0x9e,
-	0x09, 0xeb, 0x35, 0x61, 0xbd, 0xe3, 0xfa, 0x30, 0x53, 0xf7, 0x72, 0x3d, 0xb4, 0xde, 0x7e, 0x1c,
-	0x82, 0xe0, 0x9b, 0x08, 0xdd, 0x81, 0xa6, 0x19, 0x6c, 0x8f, 0x5a, 0xe3, 0xee, 0xd1, 0x9e, 0x67,
-	0x4a, 0x53, 0xb9, 0x54, 0xa4, 0xc0, 0xb0, 0x2a, 0x59, 0x59, 0x30, 0x81, 0x1d, 0x93, 0x4c, 0xcd,
-	0xc8, 0x83, 0xbb, 0x3c, 0x57, 0x1b, 0x17, 0xb8, 0xa3, 0xc5, 0x83, 0xdf, 0xac, 0x27, 0xd9, 0x45,
-	0xd0, 0xfc, 0x84, 0x06, 0xb0, 0x9d, 0x26, 0xcb, 0x44, 0x62, 0x38, 0x02, 0xe3, 0x56, 0x60, 0xc0,
-	0xcc, 0x76, 0xdb, 0x7d, 0x67, 0x66, 0xbb, 0xbb, 0x7d, 0x77, 0x66, 0xbb, 0x6e, 0xbf, 0x73, 0xf8,
-	0xbe, 0x05, 0xdd, 0xc6, 0x5f, 0x19, 0xab, 0x4a, 0x9b, 0x4a, 0xd4, 0x8c, 0x6e, 0x42, 0x47, 0xb0,
-	0x88, 0x8b, 0x79, 0xdd, 0x47, 0x8d, 0x94, 0x01, 0x4d, 0x99, 0x90, 0xba, 0x89, 0x4e, 0x60, 0x00,
-	0xba, 0x07, 0x5b, 0x0b, 0x2e, 0xb0, 0xfd, 0xf7, 0xed, 0xa8, 0xff, 0x51, 0x06, 0x9d, 0x94, 0x86,
-	0x2c, 0x2d, 0x70, 0x5b, 0x1f, 0xee, 0x86, 0xd7, 0xdc, 0xa2, 0xf7, 0x58, 0xad, 0x3f, 0xa1, 0x89,
-	0x98, 0x4e, 0x94, 0xe6, 0xc3, 0x7a, 0xf8, 0x4f, 0xaf, 0xc0, 0xe8, 0x27, 0x73, 0x9a, 0x4b, 0x26,
-	0x82, 0xda, 0x05, 0x9d, 0xc3, 0x2e, 0xcd, 0x32, 0x2e, 0xa9, 0x69, 0xd4, 0xf9, 0xaf, 0xa6, 0x3f,
-	0x5a, 0xa1, 0x67, 0xb0, 0x77, 0xc6, 0x58, 0x7e, 0x92, 0x88, 0x24, 0x8b, 0x4f, 0xb8, 0xc0, 0xbd,
-	0x3f, 0x55, 0x75, 0x4b, 0x25, 0xf8, 0xb2, 0x1e, 0xee, 0x29, 0xdd, 0xe9, 0x42, 0x0b, 0x4f, 0x17,
-	0x5c, 0xe8, 0xf6, 0x7e, 0xde, 0x4c, 0xdf, 0x6c, 0x6f, 0xfa, 0x60, 0xb5, 0x21, 0xd6, 0xd5, 0x86,
-	0x58, 0xd7, 0x1b, 0x02, 0x5e, 0x55, 0x04, 0xbc, 0xab, 0x08, 0xb8, 0xac, 0x08, 0x58, 0x55, 0x04,
-	0x7c, 0xaa, 0x08, 0xf8, 0x5c, 0x11, 0xeb, 0xba, 0x22, 0xe0, 0xf5, 0x96, 0x58, 0xab, 0x2d, 0xb1,
-	0xae, 0xb6, 0xc4, 0x7a, 0xba, 0xab, 0x1f, 0x5f, 0x1e, 0x86, 0x8e, 0xce, 0x70, 0xf7, 0x6b, 0x00,
-	0x00, 0x00, 0xff, 0xff, 0x77, 0x8c, 0xa0, 0x4e, 0xd3, 0x03, 0x00, 0x00,
+	// 535 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x52, 0x3f, 0x6f, 0xd3, 0x40,
+	0x1c, 0xf5, 0x35, 0x8e, 0xeb, 0x5c, 0x14, 0x35, 0x3a, 0x22, 0x74, 0x2d, 0xe8, 0x12, 0x55, 0x42,
+	0xca, 0x64, 0x4b, 0x45, 0x0c, 0x0c, 0x08, 0x25, 0xaa, 0x8a, 0x14, 0x31, 0x20, 0x8f, 0x08, 0xa9,
+	0x3a, 0x3b, 0x17, 0x63, 0xea, 0xf8, 0xac, 0xf3, 0x19, 0xb5, 0x1b, 0x1f, 0x81, 0x91, 0x8f, 0xc0,
+	0xc2, 0xf7, 0xe8, 0x18, 0xb6, 0x8a, 0x21, 0x10, 0x67, 0x41, 0x4c, 0xfd, 0x08, 0xe8, 0xee, 0x6c,
+	0xfe, 0x0e, 0xc0, 0xc0, 0xe4, 0xdf, 0xfb, 0x3d, 0xbf, 0xdf, 0xef, 0xdd, 0xbb, 0x83, 0x5d, 0x51,
+	0xa6, 0xac, 0xf0, 0x72, 0xc1, 0x25, 0x47, 0x6d, 0x0d, 0x0e, 0x06, 0x31, 0x8f, 0xb9, 0xee, 0xf8,
+	0xaa, 0x32, 0xe4, 0x01, 0x89, 0x39, 0x8f, 0x53, 0xe6, 0x6b, 0x14, 0x96, 0x0b, 0x7f, 0x5e, 0x0a,
+	0x2a, 0x13, 0x9e, 0xd5, 0xfc, 0xfe, 0xaf, 0x3c, 0xcd, 0x2e, 0x6a, 0xea, 0x7e, 0x9c, 0xc8, 0xe7,
+	0x65, 0xe8, 0x45, 0x7c, 0xe9, 0x47, 0x5c, 0x48, 0x76, 0x9e, 0x0b, 0xfe, 0x82, 0x45, 0xb2, 0x46,
+	0x7e, 0x7e, 0x16, 0x37, 0x44, 0x58, 0x17, 0x46, 0x7a, 0xf8, 0x6e, 0x07, 0xf6, 0x82, 0x32, 0x65,
+	0x8f, 0x04, 0x2f, 0xf3, 0x63, 0x56, 0x44, 0x08, 0x41, 0x3b, 0xa3, 0x4b, 0x86, 0xc1, 0x08, 0x8c,
+	0x3b, 0x81, 0xae, 0xd1, 0x6d, 0xd8, 0x51, 0xdf, 0x22, 0xa7, 0x11, 0xc3, 0x3b, 0x9a, 0xf8, 0xde,
+	0x40, 0x0f, 0xa1, 0x9b, 0x64, 0x92, 0x89, 0x97, 0x34, 0xc5, 0xad, 0x11, 0x18, 0x77, 0x8f, 0xf6,
+	0x3d, 0x63, 0xd6, 0x6b, 0xcc, 0x7a, 0xc7, 0xf5, 0x61, 0xa6, 0xee, 0xe5, 0x7a, 0x68, 0xbd, 0xf9,
+	0x38, 0x04, 0xc1, 0x37, 0x11, 0xba, 0x03, 0x4d, 0x32, 0xd8, 0x1e, 0xb5, 0xc6, 0xdd, 0xa3, 0x3d,
+	0x4f, 0x23, 0x4f, 0xf9, 0x52, 0x96, 0x02, 0xc3, 0x2a, 0x67, 0x65, 0xc1, 0x04, 0x76, 0x8c, 0x33,
+	0x55, 0x23, 0x0f, 0xee, 0xf2, 0x5c, 0x0d, 0x2e, 0x70, 0x47, 0x8b, 0x07, 0xbf, 0xad, 0x9e, 0x64,
+	0x17, 0x41, 0xf3, 0x13, 0x1a, 0xc0, 0x76, 0x9a, 0x2c, 0x13, 0x89, 0xe1, 0x08, 0x8c, 0x5b, 0x81,
+	0x01, 0xe8, 0x26, 0x74, 0x72, 0x5a, 0x16, 0x6c, 0x8e, 0xbb, 0x23, 0x30, 0x76, 0x83, 0x1a, 0xcd,
+	0x6c, 0xb7, 0xdd, 0x77, 0x66, 0xb6, 0xbb, 0xdb, 0x77, 0x67, 0xb6, 0xeb, 0xf6, 0x3b, 0x87, 0xef,
+	0x5b, 0xd0, 0x6d, 0x7c, 0x29, 0x43, 0x2a, 0xea, 0x26, 0x2a, 0x55, 0xab, 0x51, 0x82, 0x45, 0x5c,
+	0xcc, 0xeb, 0x9c, 0x6a, 0xa4, 0x16, 0xd3, 0x94, 0x09, 0xa9, 0x13, 0xea, 0x04, 0x06, 0xa0, 0x7b,
+	0xb0, 0xb5, 0xe0, 0x02, 0xdb, 0x7f, 0x9f, 0x9a, 0xfa, 0x1f, 0x65, 0xd0, 0x49, 0x69, 0xc8, 0xd2,
+	0x02, 0xb7, 0xf5, 0xa1, 0x6f, 0x78, 0xcd, 0xed, 0x7a, 0x8f, 0x55, 0xff, 0x09, 0x4d, 0xc4, 0x74,
+	0xa2, 0x34, 0x1f, 0xd6, 0xc3, 0x7f, 0x7a, 0x1d, 0x46, 0x3f, 0x99, 0xd3, 0x5c, 0x32, 0x11, 0xd4,
+	0x5b, 0xd0, 0x39, 0xec, 0xd2, 0x2c, 0xe3, 0x92, 0x9a, 0xa4, 0x9d, 0xff, 0xba, 0xf4, 0xc7, 0x55,
+	0xe8, 0x19, 0xec, 0x9d, 0x31, 0x96, 0x9f, 0x24, 0x22, 0xc9, 0xe2, 0x13, 0x2e, 0x70, 0xef, 0x4f,
+	0x51, 0xdd, 0x52, 0x0e, 0xbe, 0xac, 0x87, 0x7b, 0x4a, 0x77, 0xba, 0xd0, 0xc2, 0xd3, 0x05, 0x17,
+	0x3a, 0xbd, 0x9f, 0x87, 0xe9, 0x9b, 0xed, 0x4d, 0x1f, 0xac, 0x36, 0xc4, 0xba, 0xda, 0x10, 0xeb,
+	0x7a, 0x43, 0xc0, 0xab, 0x8a, 0x80, 0xb7, 0x15, 0x01, 0x97, 0x15, 0x01, 0xab, 0x8a, 0x80, 0x4f,
+	0x15, 0x01, 0x9f, 0x2b, 0x62, 0x5d, 0x57, 0x04, 0xbc, 0xde, 0x12, 0x6b, 0xb5, 0x25, 0xd6, 0xd5,
+	0x96, 0x58, 0x4f, 0x77, 0xf5, 0xa3, 0xcc, 0xc3, 0xd0, 0xd1, 0x1e, 0xee, 0x7e, 0x1d, 0x00, 0x07,
+	0x92, 0x03, 0xf1, 0xeb, 0x03, 0x00, 0x00,
 }
 
 func (this *RuleGroupDesc) Equal(that interface{}) bool {
@@ -300,6 +310,9 @@ func (this *RuleGroupDesc) Equal(that interface{}) bool {
 	if this.Limit != that1.Limit {
 		return false
 	}
+	if this.Paused != that1.Paused {
+		return false
+	}
 	return true
 }
 func (this *RuleDesc) Equal(that interface{}) bool {
@@ -358,7 +371,7 @@ func (this *RuleGroupDesc) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 11)
+	s := make([]string, 0, 12)
 	s = append(s, "&rulespb.RuleGroupDesc{")
 	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
 	s = append(s, "Namespace: "+fmt.Sprintf("%#v", this.Namespace)+",\n")
@@ -371,6 +384,7 @@ func (this *RuleGroupDesc) GoString() string {
 		s = append(s, "Options: "+fmt.Sprintf("%#v", this.Options)+",\n")
 	}
 	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "Paused: "+fmt.Sprintf("%#v", this.Paused)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -418,6 +432,16 @@ func (m *RuleGroupDesc) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.Paused {
+		i--
+		if m.Paused {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x58
+	}
 	if m.Limit != 0 {
 		i = encodeVarintRules(dAtA, i, uint64(m.Limit))
 		i--
@@ -617,6 +641,9 @@ func (m *RuleGroupDesc) Size() (n int) {
 	if m.Limit != 0 {
 		n += 1 + sovRules(uint64(m.Limit))
 	}
+	if m.Paused {
+		n += 2
+	}
 	return n
 }
 
@@ -685,6 +712,7 @@ func (this *RuleGroupDesc) String() string {
 		`User:` + fmt.Sprintf("%v", this.User) + `,`,
 		`Options:` + repeatedStringForOptions + `,`,
 		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`Paused:` + fmt.Sprintf("%v", this.Paused) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -958,6 +986,26 @@ func (m *RuleGroupDesc) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Paused", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowRules
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Paused = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipRules(dAtA[iNdEx:])
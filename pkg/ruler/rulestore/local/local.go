@@ -5,8 +5,13 @@ import (
 	"flag"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	promRules "github.com/prometheus/prometheus/rules"
 
 	"github.com/cortexproject/cortex/pkg/ruler/rulespb"
@@ -17,12 +22,14 @@ const (
 )
 
 type Config struct {
-	Directory string `yaml:"directory"`
+	Directory    string `yaml:"directory"`
+	WatchEnabled bool   `yaml:"watch_enabled"`
 }
 
 // RegisterFlags registers flags.
 func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.StringVar(&cfg.Directory, prefix+"local.directory", "", "Directory to scan for rules")
+	f.BoolVar(&cfg.WatchEnabled, prefix+"local.watch-enabled", false, "[Experimental] Watch the directory for changes and reload the rule groups as soon as a rule file is added, updated or removed. A tenant whose rule files fail to load keeps its last successfully loaded rule groups.")
 }
 
 // Client expects to load already existing rules located at:
@@ -31,16 +38,43 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 type Client struct {
 	cfg    Config
 	loader promRules.GroupLoader
+	logger log.Logger
+
+	// Last successfully loaded rule groups for each user, used when the watcher is enabled.
+	lastValidMtx sync.Mutex
+	lastValid    map[string]rulespb.RuleGroupList
+
+	lastLoadSuccessful *prometheus.GaugeVec
 }
 
-func NewLocalRulesClient(cfg Config, loader promRules.GroupLoader) (*Client, error) {
+func NewLocalRulesClient(cfg Config, loader promRules.GroupLoader, logger log.Logger, reg prometheus.Registerer) (*Client, error) {
 	if cfg.Directory == "" {
 		return nil, errors.New("directory required for local rules config")
 	}
 
+	lastLoadSuccessful := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cortex_ruler_local_rule_store_last_load_successful",
+		Help: "Whether the last load of the rule groups of a tenant from the local rule store was successful.",
+	}, []string{"user"})
+
+	// The rule store may be created more than once with the same registerer, in which case
+	// the clients share the metric.
+	if reg != nil {
+		if err := reg.Register(lastLoadSuccessful); err != nil {
+			alreadyRegistered := prometheus.AlreadyRegisteredError{}
+			if !errors.As(err, &alreadyRegistered) {
+				return nil, err
+			}
+			lastLoadSuccessful = alreadyRegistered.ExistingCollector.(*prometheus.GaugeVec)
+		}
+	}
+
 	return &Client{
-		cfg:    cfg,
-		loader: loader,
+		cfg:                cfg,
+		loader:             loader,
+		logger:             logger,
+		lastValid:          map[string]rulespb.RuleGroupList{},
+		lastLoadSuccessful: lastLoadSuccessful,
 	}, nil
 }
 
@@ -81,21 +115,156 @@ func (l *Client) ListAllRuleGroups(ctx context.Context) (map[string]rulespb.Rule
 		return nil, err
 	}
 
+	if l.cfg.WatchEnabled {
+		return l.listAllRuleGroupsKeepingLastValid(ctx, users), nil
+	}
+
 	lists := make(map[string]rulespb.RuleGroupList)
 	for _, user := range users {
 		list, err := l.loadAllRulesGroupsForUser(ctx, user)
 		if err != nil {
+			l.lastLoadSuccessful.WithLabelValues(user).Set(0)
 			return nil, errors.Wrapf(err, "failed to list rule groups for user %s", user)
 		}
 
+		l.lastLoadSuccessful.WithLabelValues(user).Set(1)
 		lists[user] = list
 	}
 
 	return lists, nil
 }
 
+// listAllRuleGroupsKeepingLastValid loads the rule groups of each user. Since files are edited
+// in place when the watcher is enabled, a user whose rule files fail to load keeps its last
+// successfully loaded rule groups instead of failing the whole sync.
+func (l *Client) listAllRuleGroupsKeepingLastValid(ctx context.Context, users []string) map[string]rulespb.RuleGroupList {
+	l.lastValidMtx.Lock()
+	defer l.lastValidMtx.Unlock()
+
+	lists := make(map[string]rulespb.RuleGroupList, len(users))
+	for _, user := range users {
+		lists[user] = l.loadAllRulesGroupsForUserKeepingLastValid(ctx, user)
+	}
+
+	// Forget the users whose directory has been removed.
+	for user := range l.lastValid {
+		if _, ok := lists[user]; !ok {
+			delete(l.lastValid, user)
+			l.lastLoadSuccessful.DeleteLabelValues(user)
+		}
+	}
+
+	return lists
+}
+
+// loadAllRulesGroupsForUserKeepingLastValid loads the rule groups of the user, falling back to
+// its last successfully loaded rule groups on failure. The lastValidMtx must be held.
+func (l *Client) loadAllRulesGroupsForUserKeepingLastValid(ctx context.Context, userID string) rulespb.RuleGroupList {
+	list, err := l.loadAllRulesGroupsForUser(ctx, userID)
+	if err != nil {
+		level.Warn(l.logger).Log("msg", "failed to load rule groups from local rule store, keeping the last successfully loaded ones", "user", userID, "err", err)
+		l.lastLoadSuccessful.WithLabelValues(userID).Set(0)
+
+		// Users which never loaded successfully have no rule groups, but are tracked
+		// anyway to clean up their metric once their directory is removed.
+		list = l.lastValid[userID]
+		l.lastValid[userID] = list
+		return list
+	}
+
+	l.lastLoadSuccessful.WithLabelValues(userID).Set(1)
+	l.lastValid[userID] = list
+	return list
+}
+
+// WatchChanges implements rulestore.ChangesWatcher. It watches the directory and the users
+// sub-directories, and notifies on any change to them. It returns a nil channel if the
+// watcher is disabled.
+func (l *Client) WatchChanges(ctx context.Context) (<-chan struct{}, error) {
+	if !l.cfg.WatchEnabled {
+		return nil, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create rule store directory watcher")
+	}
+
+	if err := l.addWatches(ctx, watcher); err != nil {
+		_ = watcher.Close()
+		return nil, err
+	}
+
+	// Buffered so that changes happening while a reload is in progress are not lost,
+	// while a burst of changes only triggers one more reload.
+	changes := make(chan struct{}, 1)
+
+	go func() {
+		defer close(changes)
+		defer watcher.Close() //nolint:errcheck
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				// New users directories have to be watched too.
+				if event.Has(fsnotify.Create) && filepath.Dir(event.Name) == filepath.Clean(l.cfg.Directory) {
+					if err := l.addWatches(ctx, watcher); err != nil {
+						level.Warn(l.logger).Log("msg", "failed to watch rule store directory", "err", err)
+					}
+				}
+
+				select {
+				case changes <- struct{}{}:
+				default:
+				}
+
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				level.Warn(l.logger).Log("msg", "error while watching rule store directory", "err", err)
+			}
+		}
+	}()
+
+	return changes, nil
+}
+
+// addWatches adds the directory and all users sub-directories to the watcher. Adding an
+// already watched path is a no-op.
+func (l *Client) addWatches(ctx context.Context, watcher *fsnotify.Watcher) error {
+	if err := watcher.Add(l.cfg.Directory); err != nil {
+		return errors.Wrapf(err, "unable to watch dir %s", l.cfg.Directory)
+	}
+
+	users, err := l.ListAllUsers(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		path := filepath.Join(l.cfg.Directory, user)
+		if err := watcher.Add(path); err != nil {
+			return errors.Wrapf(err, "unable to watch dir %s", path)
+		}
+	}
+
+	return nil
+}
+
 // ListRuleGroupsForUserAndNamespace implements rules.RuleStore. This method also loads the rules.
 func (l *Client) ListRuleGroupsForUserAndNamespace(ctx context.Context, userID string, namespace string) (rulespb.RuleGroupList, error) {
+	if l.cfg.WatchEnabled {
+		return l.listRuleGroupsForUserAndNamespaceKeepingLastValid(ctx, userID, namespace), nil
+	}
+
 	if namespace != "" {
 		return l.loadAllRulesGroupsForUserAndNamespace(ctx, userID, namespace)
 	}
@@ -103,6 +272,27 @@ func (l *Client) ListRuleGroupsForUserAndNamespace(ctx context.Context, userID s
 	return l.loadAllRulesGroupsForUser(ctx, userID)
 }
 
+// listRuleGroupsForUserAndNamespaceKeepingLastValid is the ListRuleGroupsForUserAndNamespace
+// counterpart of listAllRuleGroupsKeepingLastValid, so that a user whose rule files fail to
+// load keeps its last successfully loaded rule groups whichever method syncs them.
+func (l *Client) listRuleGroupsForUserAndNamespaceKeepingLastValid(ctx context.Context, userID string, namespace string) rulespb.RuleGroupList {
+	l.lastValidMtx.Lock()
+	defer l.lastValidMtx.Unlock()
+
+	list := l.loadAllRulesGroupsForUserKeepingLastValid(ctx, userID)
+	if namespace == "" {
+		return list
+	}
+
+	var filtered rulespb.RuleGroupList
+	for _, group := range list {
+		if group.Namespace == namespace {
+			filtered = append(filtered, group)
+		}
+	}
+	return filtered
+}
+
 func (l *Client) LoadRuleGroups(_ context.Context, load map[string]rulespb.RuleGroupList) (map[string]rulespb.RuleGroupList, error) {
 	// This Client already loads the rules in its List methods, there is nothing left to do here.
 	return load, nil
@@ -4,9 +4,13 @@ import (
 	"context"
 	"os"
 	"path"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/rulefmt"
 	promRules "github.com/prometheus/prometheus/rules"
@@ -66,7 +70,7 @@ func TestClient_LoadAllRuleGroups(t *testing.T) {
 
 	client, err := NewLocalRulesClient(Config{
 		Directory: dir,
-	}, promRules.FileLoader{})
+	}, promRules.FileLoader{}, log.NewNopLogger(), nil)
 	require.NoError(t, err)
 
 	ctx := context.Background()
@@ -83,3 +87,91 @@ func TestClient_LoadAllRuleGroups(t *testing.T) {
 		require.Equal(t, rulespb.ToProto(u, namespace2, ruleGroups.Groups[0]), actual[1])
 	}
 }
+
+func TestClient_WatchEnabled(t *testing.T) {
+	const (
+		user      = "user"
+		namespace = "ns"
+	)
+
+	validRules := []byte(`
+groups:
+  - name: group
+    rules:
+      - record: test_rule
+        expr: up
+`)
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(dir, user), 0777))
+	require.NoError(t, os.WriteFile(path.Join(dir, user, namespace), validRules, 0777))
+
+	reg := prometheus.NewPedanticRegistry()
+	client, err := NewLocalRulesClient(Config{Directory: dir, WatchEnabled: true}, promRules.FileLoader{}, log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes, err := client.WatchChanges(ctx)
+	require.NoError(t, err)
+
+	userMap, err := client.ListAllRuleGroups(ctx)
+	require.NoError(t, err)
+	require.Len(t, userMap[user], 1)
+	require.Equal(t, "group", userMap[user][0].Name)
+
+	// Break the rule file: the change is notified and the last valid rule groups are kept.
+	require.NoError(t, os.WriteFile(path.Join(dir, user, namespace), []byte("groups: [invalid"), 0777))
+
+	select {
+	case <-changes:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "the rule store change has not been notified")
+	}
+
+	userMap, err = client.ListAllRuleGroups(ctx)
+	require.NoError(t, err)
+	require.Len(t, userMap[user], 1)
+	require.Equal(t, "group", userMap[user][0].Name)
+
+	// Listing the rule groups of the user keeps the last valid ones too.
+	for _, ns := range []string{"", namespace} {
+		groups, err := client.ListRuleGroupsForUserAndNamespace(ctx, user, ns)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, "group", groups[0].Name)
+	}
+
+	groups, err := client.ListRuleGroupsForUserAndNamespace(ctx, user, "other")
+	require.NoError(t, err)
+	require.Empty(t, groups)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_ruler_local_rule_store_last_load_successful Whether the last load of the rule groups of a tenant from the local rule store was successful.
+		# TYPE cortex_ruler_local_rule_store_last_load_successful gauge
+		cortex_ruler_local_rule_store_last_load_successful{user="user"} 0
+	`)))
+
+	// Removing the user forgets about it.
+	require.NoError(t, os.RemoveAll(path.Join(dir, user)))
+
+	userMap, err = client.ListAllRuleGroups(ctx)
+	require.NoError(t, err)
+	require.Empty(t, userMap)
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(``)))
+
+	// The notifications channel is closed once the context is done.
+	cancel()
+	for range changes {
+	}
+}
+
+func TestClient_WatchDisabled(t *testing.T) {
+	client, err := NewLocalRulesClient(Config{Directory: t.TempDir()}, promRules.FileLoader{}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	changes, err := client.WatchChanges(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, changes)
+}
@@ -48,3 +48,10 @@ type RuleStore interface {
 	// If namespace is empty, deletes all rule groups for user.
 	DeleteNamespace(ctx context.Context, userID, namespace string) error
 }
+
+// ChangesWatcher is implemented by the rule stores able to notify when their rule groups change.
+type ChangesWatcher interface {
+	// WatchChanges returns a channel receiving a notification each time the rule groups change,
+	// until the context is done. A nil channel is returned if watching is disabled.
+	WatchChanges(ctx context.Context) (<-chan struct{}, error)
+}
@@ -28,7 +28,7 @@ func NewRuleStore(ctx context.Context, cfg rulestore.Config, cfgProvider bucket.
 	}
 
 	if cfg.Backend == local.Name {
-		return local.NewLocalRulesClient(cfg.Local, loader)
+		return local.NewLocalRulesClient(cfg.Local, loader, logger, reg)
 	}
 
 	bucketClient, err := bucket.NewClient(ctx, cfg.Config, "ruler-storage", logger, reg)
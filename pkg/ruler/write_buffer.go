@@ -0,0 +1,396 @@
+package ruler
+
+import (
+	"context"
+	"encoding/binary"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/tsdb/wlog"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"go.uber.org/atomic"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+const (
+	// writeBufferReplayBackoff is the min time between two attempts to push the buffered
+	// writes, so that the evaluations don't wait for the write path on each commit during
+	// an outage.
+	writeBufferReplayBackoff = 5 * time.Second
+	// writeBufferDrainTimeout is the max time spent pushing the buffered writes of a tenant
+	// once its rules manager is stopped.
+	writeBufferDrainTimeout = time.Minute
+
+	discardReasonTooOld    = "too_old"
+	discardReasonFull      = "full"
+	discardReasonCorrupted = "corrupted"
+	discardReasonRejected  = "rejected"
+	discardReasonRemoved   = "removed"
+)
+
+var errInvalidWriteBufferConfig = errors.New("the write buffer max size and max age must be greater than 0")
+
+// WriteBufferConfig is the config of the local WAL buffering the rule evaluation results
+// which failed to be pushed to the ingesters.
+type WriteBufferConfig struct {
+	Dir          string        `yaml:"dir"`
+	MaxSizeBytes int64         `yaml:"max_size_bytes"`
+	MaxAge       time.Duration `yaml:"max_age"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *WriteBufferConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.Dir, "ruler.write-buffer.dir", "", "[Experimental] Directory of the local WAL used to buffer the rule evaluation results which failed to be pushed to the ingesters. The buffered results are pushed in order once the write path recovers. The buffering is disabled if empty.")
+	f.Int64Var(&cfg.MaxSizeBytes, "ruler.write-buffer.max-size-bytes", 128*1024*1024, "[Experimental] Max size, in bytes, of the results buffered for a tenant. Once reached, the results which failed to be pushed are discarded.")
+	f.DurationVar(&cfg.MaxAge, "ruler.write-buffer.max-age", time.Hour, "[Experimental] Max age of the buffered results. The older results are discarded instead of being pushed once the write path recovers.")
+}
+
+// Validate validates the config.
+func (cfg *WriteBufferConfig) Validate() error {
+	if cfg.Dir != "" && (cfg.MaxSizeBytes <= 0 || cfg.MaxAge <= 0) {
+		return errInvalidWriteBufferConfig
+	}
+	return nil
+}
+
+// writeBuffer buffers, in a per-tenant local WAL, the writes which failed to be pushed
+// because of the write path, and pushes them back in order before any newer write once
+// the write path recovers.
+type writeBuffer struct {
+	cfg    WriteBufferConfig
+	logger log.Logger
+
+	tenantsMtx sync.Mutex
+	tenants    map[string]*tenantWriteBuffer
+
+	bufferedWrites  prometheus.Counter
+	replayedWrites  prometheus.Counter
+	discardedWrites *prometheus.CounterVec
+}
+
+type tenantWriteBuffer struct {
+	// pending is true if the WAL may have writes which haven't been pushed yet.
+	pending atomic.Bool
+
+	mtx sync.Mutex
+	wal *wlog.WL
+	// replaying is true while a push replays the buffered writes. The other pushes buffer
+	// their writes meanwhile, so that the samples are pushed in order.
+	replaying bool
+	// appended is true if writes have been buffered since the current replay started.
+	appended      bool
+	lastReplayErr time.Time
+
+	// replayed is the number of writes at the beginning of the WAL which have already
+	// been pushed, when the last replay stopped in the middle of the WAL. It's only
+	// accessed by the push replaying the buffered writes.
+	replayed int
+}
+
+func newWriteBuffer(cfg WriteBufferConfig, logger log.Logger, reg prometheus.Registerer) *writeBuffer {
+	return &writeBuffer{
+		cfg:     cfg,
+		logger:  logger,
+		tenants: map[string]*tenantWriteBuffer{},
+		bufferedWrites: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ruler_write_buffer_buffered_writes_total",
+			Help: "Total number of rule evaluation writes which failed to be pushed and have been buffered.",
+		}),
+		replayedWrites: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_ruler_write_buffer_replayed_writes_total",
+			Help: "Total number of buffered rule evaluation writes which have been pushed.",
+		}),
+		discardedWrites: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_ruler_write_buffer_discarded_writes_total",
+			Help: "Total number of rule evaluation writes which have been discarded by the write buffer.",
+		}, []string{"reason"}),
+	}
+}
+
+// push pushes the input request, after the writes buffered for the tenant. The request is
+// buffered, and no error is returned, if it can't be pushed because of the write path.
+func (b *writeBuffer) push(ctx context.Context, userID string, pusher Pusher, req *cortexpb.WriteRequest) error {
+	tb, err := b.tenant(userID)
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "failed to open the ruler write buffer, pushing without buffering", "user", userID, "err", err)
+		_, err = pusher.Push(ctx, req)
+		return err
+	}
+
+	// The pusher reuses the request slices once pushed, so the record to buffer is
+	// encoded upfront.
+	rec, err := encodeWriteBufferRecord(time.Now(), req)
+	if err != nil {
+		_, err = pusher.Push(ctx, req)
+		return err
+	}
+
+	if !tb.pending.Load() {
+		_, err := pusher.Push(ctx, req)
+		if err == nil || !isRetriableWriteError(err) {
+			return err
+		}
+
+		tb.mtx.Lock()
+		defer tb.mtx.Unlock()
+		return b.append(userID, tb, rec, err)
+	}
+
+	// The buffered writes are pushed before the newer ones, so that the samples are pushed in order.
+	tb.mtx.Lock()
+	if tb.replaying || time.Since(tb.lastReplayErr) < writeBufferReplayBackoff {
+		defer tb.mtx.Unlock()
+		return b.append(userID, tb, rec, nil)
+	}
+	tb.replaying = true
+	tb.mtx.Unlock()
+
+	err = b.replay(ctx, userID, tb, pusher)
+
+	tb.mtx.Lock()
+	tb.replaying = false
+	if err != nil {
+		defer tb.mtx.Unlock()
+		tb.lastReplayErr = time.Now()
+		return b.append(userID, tb, rec, err)
+	}
+	tb.mtx.Unlock()
+
+	_, err = pusher.Push(ctx, req)
+	if err == nil || !isRetriableWriteError(err) {
+		return err
+	}
+
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+	return b.append(userID, tb, rec, err)
+}
+
+// tenant returns the buffer of the input tenant, opening its WAL if not opened yet.
+func (b *writeBuffer) tenant(userID string) (*tenantWriteBuffer, error) {
+	b.tenantsMtx.Lock()
+	defer b.tenantsMtx.Unlock()
+
+	if tb, ok := b.tenants[userID]; ok {
+		return tb, nil
+	}
+
+	wal, err := wlog.NewSize(log.With(b.logger, "user", userID), nil, filepath.Join(b.cfg.Dir, userID), wlog.DefaultSegmentSize, wlog.CompressionSnappy)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &tenantWriteBuffer{wal: wal}
+
+	// The writes buffered before a restart are pushed too.
+	size, err := wal.Size()
+	if err != nil {
+		return nil, err
+	}
+	tb.pending.Store(size > 0)
+
+	b.tenants[userID] = tb
+	return tb, nil
+}
+
+// append buffers the input record. It returns the input push error if the record
+// can't be buffered, nil otherwise. The tenant buffer lock must be held.
+func (b *writeBuffer) append(userID string, tb *tenantWriteBuffer, rec []byte, pushErr error) error {
+	size, err := tb.wal.Size()
+	if err == nil && size+int64(len(rec)) > b.cfg.MaxSizeBytes {
+		b.discardedWrites.WithLabelValues(discardReasonFull).Inc()
+		err = errors.New("the write buffer is full")
+	}
+	if err == nil {
+		err = tb.wal.Log(rec)
+	}
+	if err != nil {
+		level.Warn(b.logger).Log("msg", "failed to buffer the rule evaluation write", "user", userID, "err", err)
+		if pushErr == nil {
+			return err
+		}
+		return pushErr
+	}
+
+	tb.pending.Store(true)
+	tb.appended = true
+	b.bufferedWrites.Inc()
+	return nil
+}
+
+// replay pushes the buffered writes in order, and truncates the WAL once they've all been
+// pushed. It stops at the first write failing because of the write path, and returns its
+// error. The writes are pushed without holding the tenant buffer lock, so that the other
+// pushes don't wait for the replay, and the caller must have set the replaying flag.
+func (b *writeBuffer) replay(ctx context.Context, userID string, tb *tenantWriteBuffer, pusher Pusher) error {
+	// The writes buffered from now on go to a new segment, so that the replayed segments
+	// aren't written while being read.
+	tb.mtx.Lock()
+	next, err := tb.wal.NextSegmentSync()
+	tb.appended = false
+	tb.mtx.Unlock()
+	if err != nil {
+		return err
+	}
+
+	segments, err := wlog.NewSegmentsRangeReader(wlog.SegmentRange{Dir: tb.wal.Dir(), First: -1, Last: next - 1})
+	if err != nil {
+		return err
+	}
+	defer segments.Close()
+
+	minTime := time.Now().Add(-b.cfg.MaxAge)
+	reader := wlog.NewReader(segments)
+	for i := 0; reader.Next(); i++ {
+		if i < tb.replayed {
+			continue
+		}
+
+		ts, req, err := decodeWriteBufferRecord(reader.Record())
+		switch {
+		case err != nil:
+			b.discardedWrites.WithLabelValues(discardReasonCorrupted).Inc()
+		case ts.Before(minTime):
+			b.discardedWrites.WithLabelValues(discardReasonTooOld).Inc()
+		default:
+			if _, err := pusher.Push(ctx, req); err != nil {
+				if isRetriableWriteError(err) {
+					return err
+				}
+				// The write would have been rejected if pushed in time too (eg. limits).
+				b.discardedWrites.WithLabelValues(discardReasonRejected).Inc()
+			} else {
+				b.replayedWrites.Inc()
+			}
+		}
+		tb.replayed++
+	}
+	if err := reader.Err(); err != nil {
+		// The last record may be torn after a crash, and the previous ones have been pushed.
+		level.Warn(b.logger).Log("msg", "failed to read the ruler write buffer", "user", userID, "err", err)
+	}
+
+	// All the replayed writes have been pushed, so their segments are deleted. The writes
+	// of a segment failing to be deleted are pushed again, and rejected as duplicates.
+	tb.replayed = 0
+
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+	if err := tb.wal.Truncate(next); err != nil {
+		return err
+	}
+
+	// The writes buffered during the replay are pushed at the next one.
+	tb.pending.Store(tb.appended)
+	return nil
+}
+
+// close pushes the writes buffered for the tenant, once its rules manager has been stopped,
+// and closes its WAL. If the tenant has been removed from this ruler (eg. resharded to another
+// ruler), the WAL is deleted and the writes still failing to be pushed are discarded, since
+// the ruler now owning the tenant can't push them. Otherwise (ie. at shutdown) they're kept,
+// and pushed after the restart.
+func (b *writeBuffer) close(userID string, pusher Pusher, removed bool) {
+	b.tenantsMtx.Lock()
+	tb, ok := b.tenants[userID]
+	delete(b.tenants, userID)
+	b.tenantsMtx.Unlock()
+
+	dir := filepath.Join(b.cfg.Dir, userID)
+	if !ok {
+		if removed {
+			b.deleteWAL(userID, dir)
+		}
+		return
+	}
+
+	tb.mtx.Lock()
+	drain := tb.pending.Load() && !tb.replaying
+	tb.replaying = drain
+	tb.mtx.Unlock()
+
+	var err error
+	if drain {
+		ctx, cancel := context.WithTimeout(user.InjectOrgID(context.Background(), userID), writeBufferDrainTimeout)
+		err = b.replay(ctx, userID, tb, pusher)
+		cancel()
+	}
+
+	tb.mtx.Lock()
+	defer tb.mtx.Unlock()
+	tb.replaying = false
+
+	if err != nil && removed {
+		level.Warn(b.logger).Log("msg", "failed to push the buffered rule evaluation writes of the removed tenant, discarding them", "user", userID, "err", err)
+		b.discardedWrites.WithLabelValues(discardReasonRemoved).Add(float64(b.countRecords(tb) - tb.replayed))
+	} else if err != nil {
+		level.Warn(b.logger).Log("msg", "failed to push the buffered rule evaluation writes, they will be pushed after the restart", "user", userID, "err", err)
+	}
+
+	if err := tb.wal.Close(); err != nil {
+		level.Warn(b.logger).Log("msg", "failed to close the ruler write buffer", "user", userID, "err", err)
+	}
+	if removed {
+		b.deleteWAL(userID, dir)
+	}
+}
+
+// countRecords returns the number of writes in the WAL. The tenant buffer lock must be held.
+func (b *writeBuffer) countRecords(tb *tenantWriteBuffer) int {
+	segments, err := wlog.NewSegmentsReader(tb.wal.Dir())
+	if err != nil {
+		return 0
+	}
+	defer segments.Close()
+
+	count := 0
+	for reader := wlog.NewReader(segments); reader.Next(); {
+		count++
+	}
+	return count
+}
+
+func (b *writeBuffer) deleteWAL(userID, dir string) {
+	if err := os.RemoveAll(dir); err != nil {
+		level.Warn(b.logger).Log("msg", "failed to delete the ruler write buffer", "user", userID, "err", err)
+	}
+}
+
+// isRetriableWriteError returns whether the input push error has been caused by the write
+// path rather than by the pushed samples (series limits, duplicate samples, out of order, etc.).
+func isRetriableWriteError(err error) bool {
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	return !ok || resp.Code/100 != 4
+}
+
+// encodeWriteBufferRecord encodes the input request along with the time it's buffered at.
+func encodeWriteBufferRecord(ts time.Time, req *cortexpb.WriteRequest) ([]byte, error) {
+	rec := make([]byte, 8+req.Size())
+	binary.BigEndian.PutUint64(rec, uint64(ts.UnixMilli()))
+	if _, err := req.MarshalToSizedBuffer(rec[8:]); err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func decodeWriteBufferRecord(rec []byte) (time.Time, *cortexpb.WriteRequest, error) {
+	if len(rec) < 8 {
+		return time.Time{}, nil, errors.New("record too short")
+	}
+
+	req := &cortexpb.WriteRequest{}
+	if err := req.Unmarshal(rec[8:]); err != nil {
+		return time.Time{}, nil, err
+	}
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(rec))), req, nil
+}
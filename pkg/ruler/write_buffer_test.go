@@ -0,0 +1,223 @@
+package ruler
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+
+	"github.com/cortexproject/cortex/pkg/cortexpb"
+)
+
+type recordingPusher struct {
+	err    error
+	pushed []float64
+}
+
+func (p *recordingPusher) Push(_ context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	for _, series := range req.Timeseries {
+		for _, s := range series.Samples {
+			p.pushed = append(p.pushed, s.Value)
+		}
+	}
+	return &cortexpb.WriteResponse{}, nil
+}
+
+func writeBufferRequest(value float64) *cortexpb.WriteRequest {
+	return cortexpb.ToWriteRequest([]labels.Labels{labels.FromStrings(labels.MetricName, "foo")}, []cortexpb.Sample{{TimestampMs: int64(value), Value: value}}, nil, nil, cortexpb.RULE)
+}
+
+func TestWriteBuffer_ShouldReplayTheBufferedWritesInOrder(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := WriteBufferConfig{Dir: t.TempDir(), MaxSizeBytes: 1024 * 1024, MaxAge: time.Hour}
+	reg := prometheus.NewPedanticRegistry()
+	buffer := newWriteBuffer(cfg, log.NewNopLogger(), reg)
+	pusher := &recordingPusher{}
+
+	// The writes rejected because of the samples aren't buffered.
+	pusher.err = httpgrpc.Errorf(http.StatusBadRequest, "out of order")
+	require.Equal(t, pusher.err, buffer.push(ctx, userID, pusher, writeBufferRequest(0)))
+
+	// The writes failing because of the write path are.
+	pusher.err = httpgrpc.Errorf(http.StatusServiceUnavailable, "unavailable")
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(1)))
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(2)))
+
+	// The buffered writes are pushed first once the write path recovers.
+	pusher.err = nil
+	tb, err := buffer.tenant(userID)
+	require.NoError(t, err)
+	tb.lastReplayErr = time.Time{}
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(4)))
+	assert.Equal(t, []float64{1, 2, 4}, pusher.pushed)
+	assert.False(t, tb.pending.Load())
+
+	// The following writes are pushed directly.
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(5)))
+	assert.Equal(t, []float64{1, 2, 4, 5}, pusher.pushed)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(buffer.bufferedWrites))
+	assert.Equal(t, 2.0, testutil.ToFloat64(buffer.replayedWrites))
+}
+
+func TestWriteBuffer_ShouldReplayTheWritesBufferedBeforeARestart(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := WriteBufferConfig{Dir: t.TempDir(), MaxSizeBytes: 1024 * 1024, MaxAge: time.Hour}
+	pusher := &recordingPusher{err: httpgrpc.Errorf(http.StatusInternalServerError, "failed")}
+
+	buffer := newWriteBuffer(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(1)))
+
+	// A write older than the max age is discarded instead of being pushed.
+	tb, err := buffer.tenant(userID)
+	require.NoError(t, err)
+	rec, err := encodeWriteBufferRecord(time.Now().Add(-2*time.Hour), writeBufferRequest(2))
+	require.NoError(t, err)
+	tb.mtx.Lock()
+	require.NoError(t, buffer.append(userID, tb, rec, nil))
+	tb.mtx.Unlock()
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(3)))
+	require.NoError(t, tb.wal.Close())
+
+	pusher.err = nil
+	buffer = newWriteBuffer(cfg, log.NewNopLogger(), nil)
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(4)))
+	assert.Equal(t, []float64{1, 3, 4}, pusher.pushed)
+	assert.Equal(t, 1.0, testutil.ToFloat64(buffer.discardedWrites.WithLabelValues(discardReasonTooOld)))
+}
+
+func TestWriteBuffer_ShouldReturnThePushErrorWhenFull(t *testing.T) {
+	cfg := WriteBufferConfig{Dir: t.TempDir(), MaxSizeBytes: 10, MaxAge: time.Hour}
+	buffer := newWriteBuffer(cfg, log.NewNopLogger(), nil)
+	pusher := &recordingPusher{err: httpgrpc.Errorf(http.StatusInternalServerError, "failed")}
+
+	require.Equal(t, pusher.err, buffer.push(context.Background(), "user-1", pusher, writeBufferRequest(1)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(buffer.discardedWrites.WithLabelValues(discardReasonFull)))
+	assert.Equal(t, 0.0, testutil.ToFloat64(buffer.bufferedWrites))
+}
+
+// blockingPusher blocks the pushes until unblocked.
+type blockingPusher struct {
+	recordingPusher
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (p *blockingPusher) Push(ctx context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+	p.started <- struct{}{}
+	<-p.unblock
+	return p.recordingPusher.Push(ctx, req)
+}
+
+func TestWriteBuffer_ShouldBufferTheWritesPushedDuringAReplay(t *testing.T) {
+	const userID = "user-1"
+
+	ctx := context.Background()
+	cfg := WriteBufferConfig{Dir: t.TempDir(), MaxSizeBytes: 1024 * 1024, MaxAge: time.Hour}
+	buffer := newWriteBuffer(cfg, log.NewNopLogger(), nil)
+
+	failing := &recordingPusher{err: httpgrpc.Errorf(http.StatusServiceUnavailable, "unavailable")}
+	require.NoError(t, buffer.push(ctx, userID, failing, writeBufferRequest(1)))
+	tb, err := buffer.tenant(userID)
+	require.NoError(t, err)
+	tb.lastReplayErr = time.Time{}
+
+	// The replay doesn't hold the tenant buffer lock while pushing.
+	pusher := &blockingPusher{started: make(chan struct{}), unblock: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		done <- buffer.push(ctx, userID, pusher, writeBufferRequest(2))
+	}()
+	<-pusher.started
+
+	// The concurrent writes are buffered, to be pushed after the replayed ones.
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(3)))
+
+	// Unblock the replayed write and the write of the replaying push.
+	close(pusher.unblock)
+	go func() {
+		for range pusher.started {
+		}
+	}()
+	require.NoError(t, <-done)
+	assert.Equal(t, []float64{1, 2}, pusher.pushed)
+	assert.True(t, tb.pending.Load())
+
+	require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(4)))
+	assert.Equal(t, []float64{1, 2, 3, 4}, pusher.pushed)
+	assert.False(t, tb.pending.Load())
+	close(pusher.started)
+}
+
+func TestWriteBuffer_Close(t *testing.T) {
+	const userID = "user-1"
+
+	tests := map[string]struct {
+		removed         bool
+		pushErr         error
+		expectedPushed  []float64
+		expectedDiscard float64
+		expectedWAL     bool
+	}{
+		"should push the buffered writes and delete the WAL of a removed tenant": {
+			removed:        true,
+			expectedPushed: []float64{1, 2},
+		},
+		"should discard the writes failing to be pushed and delete the WAL of a removed tenant": {
+			removed:         true,
+			pushErr:         httpgrpc.Errorf(http.StatusServiceUnavailable, "unavailable"),
+			expectedDiscard: 2,
+		},
+		"should keep the writes failing to be pushed at shutdown": {
+			pushErr:     httpgrpc.Errorf(http.StatusServiceUnavailable, "unavailable"),
+			expectedWAL: true,
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			ctx := context.Background()
+			cfg := WriteBufferConfig{Dir: t.TempDir(), MaxSizeBytes: 1024 * 1024, MaxAge: time.Hour}
+			buffer := newWriteBuffer(cfg, log.NewNopLogger(), nil)
+
+			pusher := &recordingPusher{err: httpgrpc.Errorf(http.StatusServiceUnavailable, "unavailable")}
+			require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(1)))
+			require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(2)))
+
+			pusher.err = testData.pushErr
+			buffer.close(userID, pusher, testData.removed)
+			assert.Equal(t, testData.expectedPushed, pusher.pushed)
+			assert.Equal(t, testData.expectedDiscard, testutil.ToFloat64(buffer.discardedWrites.WithLabelValues(discardReasonRemoved)))
+
+			_, err := os.Stat(filepath.Join(cfg.Dir, userID))
+			if !testData.expectedWAL {
+				assert.True(t, os.IsNotExist(err))
+				return
+			}
+			require.NoError(t, err)
+
+			// The kept writes are pushed after the restart.
+			pusher.err = nil
+			buffer = newWriteBuffer(cfg, log.NewNopLogger(), nil)
+			require.NoError(t, buffer.push(ctx, userID, pusher, writeBufferRequest(3)))
+			assert.Equal(t, []float64{1, 2, 3}, pusher.pushed)
+		})
+	}
+}
@@ -180,6 +180,26 @@ FindQueue:
 	goto FindQueue
 }
 
+// DrainRequests removes all the requests from the queues and returns them, so that they can be handed off
+// to another queue. The queue keeps accepting and dispatching the requests enqueued afterwards.
+func (q *RequestQueue) DrainRequests() []Request {
+	q.mtx.Lock()
+	defer q.mtx.Unlock()
+
+	var requests []Request
+	for userID, uq := range q.queues.userQueues {
+		for uq.queue.length() > 0 {
+			requests = append(requests, uq.queue.dequeueRequest(0, false))
+		}
+		q.queues.deleteQueue(userID)
+	}
+
+	// Tell stopping() the queues may be empty.
+	q.cond.Broadcast()
+
+	return requests
+}
+
 func (q *RequestQueue) getPriorityForQuerier(userID string, querierID string) (int64, bool) {
 	if priority, ok := q.queues.userQueues[userID].reservedQueriers[querierID]; ok {
 		return priority, true
@@ -393,6 +393,35 @@ func TestExitingRequestsShouldPersistEvenIfTheConfigHasChanged(t *testing.T) {
 	assert.Equal(t, 2, queue.queues.userQueues["userID"].queue.length())
 }
 
+func TestRequestQueue_DrainRequests(t *testing.T) {
+	queue := NewRequestQueue(0, 0,
+		prometheus.NewGaugeVec(prometheus.GaugeOpts{}, []string{"user", "priority", "type"}),
+		prometheus.NewCounterVec(prometheus.CounterOpts{}, []string{"user", "priority"}),
+		MockLimits{MaxOutstanding: 3},
+		nil,
+	)
+	ctx := context.Background()
+	queue.RegisterQuerierConnection("querier-1")
+
+	request1 := MockRequest{id: "query 1"}
+	request2 := MockRequest{id: "query 2"}
+	request3 := MockRequest{id: "query 3"}
+
+	assert.NoError(t, queue.EnqueueRequest("user-1", request1, 0, func() {}))
+	assert.NoError(t, queue.EnqueueRequest("user-1", request2, 0, func() {}))
+	assert.NoError(t, queue.EnqueueRequest("user-2", request3, 0, func() {}))
+
+	assert.ElementsMatch(t, []Request{request1, request2, request3}, queue.DrainRequests())
+	assert.Equal(t, 0, queue.queues.len())
+	assert.Empty(t, queue.DrainRequests())
+
+	// The requests enqueued after the drain are still dispatched to the queriers.
+	assert.NoError(t, queue.EnqueueRequest("user-1", request1, 0, func() {}))
+	nextRequest, _, err := queue.GetNextRequestForQuerier(ctx, FirstUser(), "querier-1")
+	require.NoError(t, err)
+	assert.Equal(t, request1, nextRequest)
+}
+
 type MockRequest struct {
 	id       string
 	priority int64
@@ -20,6 +20,10 @@ type Limits interface {
 	// QueryPriority returns query priority config for the tenant, including priority level,
 	// their attributes, and how many reserved queriers each priority has.
 	QueryPriority(user string) validation.QueryPriority
+
+	// QueryQueueWeight returns the number of requests dequeued from the tenant queue
+	// each time the tenant takes its turn.
+	QueryQueueWeight(user string) int
 }
 
 // querier holds information about a querier registered in the queue.
@@ -77,6 +81,11 @@ type userQueue struct {
 	priorityList    []int64
 	priorityEnabled bool
 
+	// Number of requests dequeued on each turn of the user, and number of requests
+	// which can still be dequeued in the current turn.
+	weight int
+	turns  int
+
 	// Seed for shuffle sharding of queriers. This seed is based on userID only and is therefore consistent
 	// between different frontends.
 	seed int64
@@ -174,6 +183,8 @@ func (q *queues) getOrAddQueue(userID string, maxQueriers int) userRequestQueue
 		uq.priorityEnabled = priorityEnabled
 	}
 
+	uq.weight = q.limits.QueryQueueWeight(userID)
+
 	if uq.maxQueriers != maxQueriers {
 		uq.maxQueriers = maxQueriers
 		uq.queriers = shuffleQueriersForUser(uq.seed, maxQueriers, q.sortedQueriers, nil)
@@ -223,6 +234,17 @@ func (q *queues) createUserRequestQueue(userID string) userRequestQueue {
 func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (userRequestQueue, string, int) {
 	uid := lastUserIndex
 
+	// The last user keeps its turn until as many requests as its weight have been dequeued.
+	if uid >= 0 && uid < len(q.users) && q.users[uid] != "" {
+		u := q.users[uid]
+		uq := q.userQueues[u]
+
+		if uq.turns > 0 && uq.isQuerierAllowed(querierID) {
+			uq.turns--
+			return uq.queue, u, uid
+		}
+	}
+
 	for iters := 0; iters < len(q.users); iters++ {
 		uid = uid + 1
 
@@ -239,18 +261,26 @@ func (q *queues) getNextQueueForQuerier(lastUserIndex int, querierID string) (us
 
 		uq := q.userQueues[u]
 
-		if uq.queriers != nil {
-			if _, ok := uq.queriers[querierID]; !ok {
-				// This querier is not handling the user.
-				continue
-			}
+		if !uq.isQuerierAllowed(querierID) {
+			// This querier is not handling the user.
+			continue
 		}
 
+		uq.turns = uq.weight - 1
 		return uq.queue, u, uid
 	}
 	return nil, "", uid
 }
 
+// isQuerierAllowed returns whether the querier can handle the user requests.
+func (uq *userQueue) isQuerierAllowed(querierID string) bool {
+	if uq.queriers == nil {
+		return true
+	}
+	_, ok := uq.queriers[querierID]
+	return ok
+}
+
 func (q *queues) addQuerierConnection(querierID string) {
 	info := q.queriers[querierID]
 	if info != nil {
@@ -422,6 +452,7 @@ type MockLimits struct {
 	MaxOutstanding        int
 	MaxQueriersPerUserVal float64
 	QueryPriorityVal      validation.QueryPriority
+	QueryQueueWeightVal   int
 }
 
 func (l MockLimits) MaxQueriersPerUser(_ string) float64 {
@@ -435,3 +466,7 @@ func (l MockLimits) MaxOutstandingPerTenant(_ string) int {
 func (l MockLimits) QueryPriority(_ string) validation.QueryPriority {
 	return l.QueryPriorityVal
 }
+
+func (l MockLimits) QueryQueueWeight(_ string) int {
+	return l.QueryQueueWeightVal
+}
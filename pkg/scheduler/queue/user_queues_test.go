@@ -69,6 +69,36 @@ func TestQueues(t *testing.T) {
 	assert.Nil(t, q)
 }
 
+type weightedMockLimits struct {
+	MockLimits
+	weights map[string]int
+}
+
+func (l weightedMockLimits) QueryQueueWeight(user string) int {
+	return l.weights[user]
+}
+
+func TestQueuesWithWeights(t *testing.T) {
+	uq := newUserQueues(0, 0, weightedMockLimits{weights: map[string]int{"one": 3, "two": 1}}, nil)
+	assert.NotNil(t, uq)
+
+	// [one two]
+	qOne := getOrAdd(t, uq, "one", 0)
+	qTwo := getOrAdd(t, uq, "two", 0)
+
+	// "one" keeps its turn for 3 requests, "two" for 1 request.
+	lastUserIndex := confirmOrderForQuerier(t, uq, "querier-1", -1, qOne, qOne, qOne, qTwo, qOne, qOne, qOne, qTwo)
+
+	// [one two three], where "three" has no weight and takes its turn for 1 request.
+	qThree := getOrAdd(t, uq, "three", 0)
+	lastUserIndex = confirmOrderForQuerier(t, uq, "querier-1", lastUserIndex, qThree, qOne, qOne)
+
+	// Remove one while in its turn: ["" two three]
+	uq.deleteQueue("one")
+	assert.NoError(t, isConsistent(uq))
+	confirmOrderForQuerier(t, uq, "querier-1", lastUserIndex, qTwo, qThree, qTwo)
+}
+
 func TestQueuesWithQueriers(t *testing.T) {
 	uq := newUserQueues(0, 0, MockLimits{}, nil)
 	assert.NotNil(t, uq)
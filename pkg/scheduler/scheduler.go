@@ -27,12 +27,21 @@ import (
 	"github.com/cortexproject/cortex/pkg/scheduler/schedulerpb"
 	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/cortexproject/cortex/pkg/util/concurrency"
 	"github.com/cortexproject/cortex/pkg/util/grpcclient"
 	"github.com/cortexproject/cortex/pkg/util/httpgrpcutil"
 	"github.com/cortexproject/cortex/pkg/util/services"
 	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
+const (
+	// Max number of query-frontends the queued requests are handed off to concurrently on shutdown.
+	handoffConcurrency = 16
+
+	// Max time spent handing off the queued requests on shutdown.
+	handoffTimeout = 10 * time.Second
+)
+
 var (
 	errSchedulerIsNotRunning = errors.New("scheduler is not running")
 )
@@ -65,6 +74,7 @@ type Scheduler struct {
 	connectedQuerierClients  prometheus.GaugeFunc
 	connectedFrontendClients prometheus.GaugeFunc
 	queueDuration            prometheus.Histogram
+	handedOffRequests        prometheus.Counter
 }
 
 type requestKey struct {
@@ -85,12 +95,15 @@ type Config struct {
 	MaxOutstandingPerTenant int               `yaml:"max_outstanding_requests_per_tenant"`
 	QuerierForgetDelay      time.Duration     `yaml:"querier_forget_delay"`
 	GRPCClientConfig        grpcclient.Config `yaml:"grpc_client_config" doc:"description=This configures the gRPC client used to report errors back to the query-frontend."`
+
+	HandoffQueuedRequestsOnShutdown bool `yaml:"handoff_queued_requests_on_shutdown"`
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxOutstandingPerTenant, "query-scheduler.max-outstanding-requests-per-tenant", 0, "Deprecated (use frontend.max-outstanding-requests-per-tenant instead) and will be removed in v1.17.0: Maximum number of outstanding requests per tenant per query-scheduler. In-flight requests above this limit will fail with HTTP response status code 429.")
 	f.DurationVar(&cfg.QuerierForgetDelay, "query-scheduler.querier-forget-delay", 0, "If a querier disconnects without sending notification about graceful shutdown, the query-scheduler will keep the querier in the tenant's shard until the forget delay has passed. This feature is useful to reduce the blast radius when shuffle-sharding is enabled.")
 	cfg.GRPCClientConfig.RegisterFlagsWithPrefix("query-scheduler.grpc-client-config", f)
+	f.BoolVar(&cfg.HandoffQueuedRequestsOnShutdown, "query-scheduler.handoff-queued-requests-on-shutdown", false, "[Experimental] If true, on graceful shutdown the query-scheduler hands off the requests still queued to their query-frontend, which enqueues them again to another query-scheduler, instead of waiting for the connected queriers to dispatch them. Requires query-frontends supporting the handoff.")
 }
 
 // NewScheduler creates a new Scheduler.
@@ -121,6 +134,10 @@ func NewScheduler(cfg Config, limits Limits, log log.Logger, registerer promethe
 		Help:    "Time spend by requests in queue before getting picked up by a querier.",
 		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 20, 30, 60},
 	})
+	s.handedOffRequests = promauto.With(registerer).NewCounter(prometheus.CounterOpts{
+		Name: "cortex_query_scheduler_handed_off_requests_total",
+		Help: "Total number of queued requests handed off to the query-frontends on shutdown.",
+	})
 	s.connectedQuerierClients = promauto.With(registerer).NewGaugeFunc(prometheus.GaugeOpts{
 		Name: "cortex_query_scheduler_connected_querier_clients",
 		Help: "Number of querier worker clients currently connected to the query-scheduler.",
@@ -417,6 +434,9 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 	// Make sure to cancel request at the end to cleanup resources.
 	defer s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
 
+	// Sign the tenant once dequeued, so that the time spent in the queue doesn't expire the signature.
+	grpcclient.SignTenantHTTPRequest(user.InjectOrgID(req.ctx, req.userID), req.request)
+
 	// Handle the stream sending & receiving on a goroutine so we can
 	// monitoring the contexts in a select and cancel things appropriately.
 	errCh := make(chan error, 1)
@@ -456,16 +476,7 @@ func (s *Scheduler) forwardRequestToQuerier(querier schedulerpb.SchedulerForQuer
 }
 
 func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRequest, requestErr error) {
-	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
-		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
-		middleware.ClientUserHeaderInterceptor},
-		nil)
-	if err != nil {
-		level.Warn(s.log).Log("msg", "failed to create gRPC options for the connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
-		return
-	}
-
-	conn, err := grpc.NewClient(req.frontendAddress, opts...)
+	conn, err := s.dialFrontend(req.frontendAddress)
 	if err != nil {
 		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to report error", "frontend", req.frontendAddress, "err", err, "requestErr", requestErr)
 		return
@@ -492,6 +503,85 @@ func (s *Scheduler) forwardErrorToFrontend(ctx context.Context, req *schedulerRe
 	}
 }
 
+func (s *Scheduler) dialFrontend(frontendAddress string) (*grpc.ClientConn, error) {
+	opts, err := s.cfg.GRPCClientConfig.DialOption([]grpc.UnaryClientInterceptor{
+		otgrpc.OpenTracingClientInterceptor(opentracing.GlobalTracer()),
+		middleware.ClientUserHeaderInterceptor},
+		nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.NewClient(frontendAddress, opts...)
+}
+
+// handoffQueuedRequests removes the requests still queued from the queue, and reports them to their query-frontend
+// with a handoff response, so that the query-frontend enqueues them again to another query-scheduler.
+func (s *Scheduler) handoffQueuedRequests() {
+	byFrontend := map[string][]*schedulerRequest{}
+	for _, r := range s.requestQueue.DrainRequests() {
+		req := r.(*schedulerRequest)
+		req.queueSpan.Finish()
+		byFrontend[req.frontendAddress] = append(byFrontend[req.frontendAddress], req)
+	}
+
+	frontends := make([]string, 0, len(byFrontend))
+	for frontendAddress := range byFrontend {
+		frontends = append(frontends, frontendAddress)
+	}
+
+	// The requests contexts may be already canceled because the query-frontends disconnect from a stopping
+	// query-scheduler, so we use a new context.
+	ctx, cancel := context.WithTimeout(context.Background(), handoffTimeout)
+	defer cancel()
+
+	_ = concurrency.ForEach(ctx, concurrency.CreateJobsFromStrings(frontends), handoffConcurrency, func(ctx context.Context, job interface{}) error {
+		frontendAddress := job.(string)
+		s.handoffRequestsToFrontend(ctx, frontendAddress, byFrontend[frontendAddress])
+		return nil
+	})
+}
+
+func (s *Scheduler) handoffRequestsToFrontend(ctx context.Context, frontendAddress string, reqs []*schedulerRequest) {
+	conn, err := s.dialFrontend(frontendAddress)
+	if err != nil {
+		level.Warn(s.log).Log("msg", "failed to create gRPC connection to frontend to hand off requests", "frontend", frontendAddress, "err", err)
+		return
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	client := frontendv2pb.NewFrontendForQuerierClient(conn)
+
+	for _, req := range reqs {
+		// Skip the requests canceled by the query-frontend.
+		s.pendingRequestsMu.Lock()
+		_, pending := s.pendingRequests[requestKey{frontendAddr: req.frontendAddress, queryID: req.queryID}]
+		s.pendingRequestsMu.Unlock()
+
+		if pending {
+			_, err = client.QueryResult(user.InjectOrgID(ctx, req.userID), &frontendv2pb.QueryResultRequest{
+				QueryID:   req.queryID,
+				HandedOff: true,
+				// The query-frontends not supporting the handoff fail the request with this response.
+				HttpResponse: &httpgrpc.HTTPResponse{
+					Code: http.StatusServiceUnavailable,
+					Body: []byte("query-scheduler is shutting down"),
+				},
+			})
+			if err != nil {
+				level.Warn(s.log).Log("msg", "failed to hand off request to frontend", "frontend", frontendAddress, "queryID", req.queryID, "err", err)
+			} else {
+				s.handedOffRequests.Inc()
+			}
+		}
+
+		s.cancelRequestAndRemoveFromPending(req.frontendAddress, req.queryID)
+	}
+}
+
 func (s *Scheduler) isRunningOrStopping() bool {
 	st := s.State()
 	return st == services.Running || st == services.Stopping
@@ -520,6 +610,10 @@ func (s *Scheduler) running(ctx context.Context) error {
 
 // Close the Scheduler.
 func (s *Scheduler) stopping(_ error) error {
+	if s.cfg.HandoffQueuedRequestsOnShutdown {
+		s.handoffQueuedRequests()
+	}
+
 	// This will also stop the requests queue, which stop accepting new requests and errors out any pending requests.
 	return services.StopManagerAndAwaitStopped(context.Background(), s.subservices)
 }
@@ -35,6 +35,10 @@ const testMaxOutstandingPerTenant = 5
 func setupScheduler(t *testing.T, reg prometheus.Registerer) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
 	cfg := Config{}
 	flagext.DefaultValues(&cfg)
+	return setupSchedulerWithConfig(t, cfg, reg)
+}
+
+func setupSchedulerWithConfig(t *testing.T, cfg Config, reg prometheus.Registerer) (*Scheduler, schedulerpb.SchedulerForFrontendClient, schedulerpb.SchedulerForQuerierClient) {
 	cfg.MaxOutstandingPerTenant = testMaxOutstandingPerTenant
 
 	s, err := NewScheduler(cfg, frontendv1.MockLimits{Queriers: 2, MockLimits: queue.MockLimits{MaxOutstanding: testMaxOutstandingPerTenant}}, log.NewNopLogger(), reg)
@@ -316,6 +320,50 @@ func TestSchedulerShutdown_QuerierLoop(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestSchedulerShutdown_HandoffQueuedRequests(t *testing.T) {
+	reg := prometheus.NewPedanticRegistry()
+
+	cfg := Config{}
+	flagext.DefaultValues(&cfg)
+	cfg.HandoffQueuedRequestsOnShutdown = true
+	scheduler, frontendClient, _ := setupSchedulerWithConfig(t, cfg, reg)
+
+	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
+	frontendAddress := startFrontendMock(t, fm)
+
+	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
+	for queryID := uint64(1); queryID <= 3; queryID++ {
+		frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+			Type:        schedulerpb.ENQUEUE,
+			QueryID:     queryID,
+			UserID:      "test",
+			HttpRequest: &httpgrpc.HTTPRequest{Method: "GET", Url: "/hello"},
+		})
+	}
+
+	// The canceled requests aren't handed off.
+	frontendToScheduler(t, frontendLoop, &schedulerpb.FrontendToScheduler{
+		Type:    schedulerpb.CANCEL,
+		QueryID: 3,
+	})
+
+	// No querier is connected, so the queued requests are handed off to the frontend on shutdown.
+	require.NoError(t, services.StopAndAwaitTerminated(context.Background(), scheduler))
+
+	for queryID := uint64(1); queryID <= 2; queryID++ {
+		require.True(t, fm.isHandedOff(queryID))
+	}
+	require.False(t, fm.isHandedOff(3))
+	require.Nil(t, fm.getRequest(3))
+	verifyNoPendingRequestsLeft(t, scheduler)
+
+	require.NoError(t, promtest.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_query_scheduler_handed_off_requests_total Total number of queued requests handed off to the query-frontends on shutdown.
+		# TYPE cortex_query_scheduler_handed_off_requests_total counter
+		cortex_query_scheduler_handed_off_requests_total 2
+	`), "cortex_query_scheduler_handed_off_requests_total"))
+}
+
 func TestSchedulerMaxOutstandingRequests(t *testing.T) {
 	_, frontendClient, _ := setupScheduler(t, nil)
 
@@ -352,26 +400,7 @@ func TestSchedulerForwardsErrorToFrontend(t *testing.T) {
 	_, frontendClient, querierClient := setupScheduler(t, nil)
 
 	fm := &frontendMock{resp: map[uint64]*httpgrpc.HTTPResponse{}}
-	frontendAddress := ""
-
-	// Setup frontend grpc server
-	{
-		frontendGrpcServer := grpc.NewServer()
-		frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
-
-		l, err := net.Listen("tcp", "")
-		require.NoError(t, err)
-
-		frontendAddress = l.Addr().String()
-
-		go func() {
-			_ = frontendGrpcServer.Serve(l)
-		}()
-
-		t.Cleanup(func() {
-			_ = l.Close()
-		})
-	}
+	frontendAddress := startFrontendMock(t, fm)
 
 	// After preparations, start frontend and querier.
 	frontendLoop := initFrontendLoop(t, frontendClient, frontendAddress)
@@ -496,9 +525,29 @@ func verifyNoPendingRequestsLeft(t *testing.T, scheduler *Scheduler) {
 	})
 }
 
+// startFrontendMock starts a frontend gRPC server, and returns its address.
+func startFrontendMock(t *testing.T, fm *frontendMock) string {
+	frontendGrpcServer := grpc.NewServer()
+	frontendv2pb.RegisterFrontendForQuerierServer(frontendGrpcServer, fm)
+
+	l, err := net.Listen("tcp", "")
+	require.NoError(t, err)
+
+	go func() {
+		_ = frontendGrpcServer.Serve(l)
+	}()
+
+	t.Cleanup(func() {
+		_ = l.Close()
+	})
+
+	return l.Addr().String()
+}
+
 type frontendMock struct {
-	mu   sync.Mutex
-	resp map[uint64]*httpgrpc.HTTPResponse
+	mu        sync.Mutex
+	resp      map[uint64]*httpgrpc.HTTPResponse
+	handedOff map[uint64]bool
 }
 
 func (f *frontendMock) QueryResult(_ context.Context, request *frontendv2pb.QueryResultRequest) (*frontendv2pb.QueryResultResponse, error) {
@@ -506,6 +555,12 @@ func (f *frontendMock) QueryResult(_ context.Context, request *frontendv2pb.Quer
 	defer f.mu.Unlock()
 
 	f.resp[request.QueryID] = request.HttpResponse
+	if request.HandedOff {
+		if f.handedOff == nil {
+			f.handedOff = map[uint64]bool{}
+		}
+		f.handedOff[request.QueryID] = true
+	}
 	return &frontendv2pb.QueryResultResponse{}, nil
 }
 
@@ -515,3 +570,10 @@ func (f *frontendMock) getRequest(queryID uint64) *httpgrpc.HTTPResponse {
 
 	return f.resp[queryID]
 }
+
+func (f *frontendMock) isHandedOff(queryID uint64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.handedOff[queryID]
+}
@@ -0,0 +1,94 @@
+package tsdb
+
+import (
+	"sort"
+
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+// BlockExtensions is the Cortex specific information stored in the Thanos extensions
+// of the block meta.json.
+type BlockExtensions struct {
+	// Metadata is the metric metadata known by the ingester when the block was shipped,
+	// or the union of the metadata of the source blocks for compacted blocks.
+	Metadata []BlockMetricMetadata `json:"metadata,omitempty"`
+
+	// HistogramStats are the statistics of the native histograms in the block, recorded
+	// by the compactor for the blocks it compacts. Nil if unknown or no native histograms.
+	HistogramStats *BlockHistogramStats `json:"histogram_stats,omitempty"`
+}
+
+// BlockHistogramStats are the statistics of the native histograms in a block.
+type BlockHistogramStats struct {
+	// Series is the number of series having native histogram chunks.
+	Series uint64 `json:"series"`
+
+	// ChunksBytes is the size in bytes of the native histogram chunks.
+	ChunksBytes uint64 `json:"chunks_bytes"`
+}
+
+// BlockMetricMetadata is the metadata of a metric family persisted in the block meta.json.
+type BlockMetricMetadata struct {
+	Metric string `json:"metric"`
+	Type   string `json:"type"`
+	Help   string `json:"help,omitempty"`
+	Unit   string `json:"unit,omitempty"`
+}
+
+// GetBlockExtensions returns the Cortex extensions of the input block meta, or nil if
+// the block has no extensions.
+func GetBlockExtensions(meta *metadata.Meta) (*BlockExtensions, error) {
+	ext, err := meta.Thanos.ParseExtensions(&BlockExtensions{})
+	if err != nil || ext == nil {
+		return nil, err
+	}
+	return ext.(*BlockExtensions), nil
+}
+
+// MergeBlockExtensions returns the extensions to record in the meta.json of a block compacted
+// from the input blocks, or nil if none of the input blocks has extensions. The metric metadata
+// is the deduplicated union of the metadata of the input blocks.
+func MergeBlockExtensions(metas []*metadata.Meta) *BlockExtensions {
+	var (
+		merged *BlockExtensions
+		seen   = map[BlockMetricMetadata]struct{}{}
+	)
+
+	for _, meta := range metas {
+		ext, err := GetBlockExtensions(meta)
+		if err != nil || ext == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &BlockExtensions{}
+		}
+
+		for _, m := range ext.Metadata {
+			if _, ok := seen[m]; ok {
+				continue
+			}
+			seen[m] = struct{}{}
+			merged.Metadata = append(merged.Metadata, m)
+		}
+	}
+
+	if merged == nil {
+		return nil
+	}
+
+	sort.Slice(merged.Metadata, func(i, j int) bool {
+		a, b := merged.Metadata[i], merged.Metadata[j]
+		if a.Metric != b.Metric {
+			return a.Metric < b.Metric
+		}
+		if a.Type != b.Type {
+			return a.Type < b.Type
+		}
+		if a.Help != b.Help {
+			return a.Help < b.Help
+		}
+		return a.Unit < b.Unit
+	})
+
+	return merged
+}
@@ -0,0 +1,58 @@
+package tsdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+)
+
+func TestMergeBlockExtensions(t *testing.T) {
+	withExtensions := func(ext *BlockExtensions) *metadata.Meta {
+		meta := &metadata.Meta{}
+		if ext != nil {
+			meta.Thanos.Extensions = ext
+		}
+		return meta
+	}
+
+	tests := map[string]struct {
+		metas    []*metadata.Meta
+		expected *BlockExtensions
+	}{
+		"no block has extensions": {
+			metas:    []*metadata.Meta{withExtensions(nil), withExtensions(nil)},
+			expected: nil,
+		},
+		"overlapping metadata": {
+			metas: []*metadata.Meta{
+				withExtensions(&BlockExtensions{Metadata: []BlockMetricMetadata{
+					{Metric: "b", Type: "gauge"},
+					{Metric: "a", Type: "counter", Help: "help"},
+				}}),
+				withExtensions(&BlockExtensions{Metadata: []BlockMetricMetadata{
+					{Metric: "a", Type: "counter", Help: "help"},
+					{Metric: "a", Type: "counter", Help: "another help"},
+				}}),
+			},
+			expected: &BlockExtensions{Metadata: []BlockMetricMetadata{
+				{Metric: "a", Type: "counter", Help: "another help"},
+				{Metric: "a", Type: "counter", Help: "help"},
+				{Metric: "b", Type: "gauge"},
+			}},
+		},
+		"some blocks without extensions": {
+			metas: []*metadata.Meta{
+				withExtensions(nil),
+				withExtensions(&BlockExtensions{Metadata: []BlockMetricMetadata{{Metric: "a", Type: "counter"}}}),
+			},
+			expected: &BlockExtensions{Metadata: []BlockMetricMetadata{{Metric: "a", Type: "counter"}}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MergeBlockExtensions(tc.metas))
+		})
+	}
+}
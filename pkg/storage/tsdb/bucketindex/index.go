@@ -87,6 +87,18 @@ type Block struct {
 	// UploadedAt is a unix timestamp (seconds precision) of when the block has been completed to be uploaded
 	// to the storage.
 	UploadedAt int64 `json:"uploaded_at"`
+
+	// SeriesHints summarise the series of small blocks. Nil if unknown.
+	SeriesHints *SeriesHints `json:"series_hints,omitempty"`
+
+	// Number of series with native histograms and size in bytes of their chunks, as recorded
+	// by the compactor in the block meta.json. Zero if unknown or no native histograms.
+	HistogramSeries      uint64 `json:"histogram_series,omitempty"`
+	HistogramChunksBytes uint64 `json:"histogram_chunks_bytes,omitempty"`
+
+	// IngesterID is the ID of the ingester which shipped the block, from the block external labels.
+	// Empty for the compacted blocks.
+	IngesterID string `json:"ingester_id,omitempty"`
 }
 
 // Within returns whether the block contains samples within the provided range.
@@ -145,7 +157,7 @@ func (m *Block) String() string {
 func BlockFromThanosMeta(meta metadata.Meta) *Block {
 	segmentsFormat, segmentsNum := detectBlockSegmentsFormat(meta)
 
-	return &Block{
+	b := &Block{
 		ID:             meta.ULID,
 		MinTime:        meta.MinTime,
 		MaxTime:        meta.MaxTime,
@@ -153,7 +165,16 @@ func BlockFromThanosMeta(meta metadata.Meta) *Block {
 		SegmentsNum:    segmentsNum,
 		SeriesMaxSize:  meta.Thanos.IndexStats.SeriesMaxSize,
 		ChunkMaxSize:   meta.Thanos.IndexStats.ChunkMaxSize,
+		IngesterID:     meta.Thanos.Labels[cortex_tsdb.IngesterIDExternalLabel],
 	}
+
+	// The extensions are best-effort: a block with invalid extensions has just no histogram stats.
+	if ext, err := cortex_tsdb.GetBlockExtensions(&meta); err == nil && ext != nil && ext.HistogramStats != nil {
+		b.HistogramSeries = ext.HistogramStats.Series
+		b.HistogramChunksBytes = ext.HistogramStats.ChunksBytes
+	}
+
+	return b
 }
 
 func detectBlockSegmentsFormat(meta metadata.Meta) (string, int) {
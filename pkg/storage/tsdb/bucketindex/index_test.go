@@ -7,6 +7,8 @@ import (
 	"github.com/prometheus/prometheus/tsdb"
 	"github.com/stretchr/testify/assert"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
 )
 
 func TestIndex_RemoveBlock(t *testing.T) {
@@ -153,6 +155,50 @@ func TestBlockFromThanosMeta(t *testing.T) {
 				SegmentsNum:    0,
 			},
 		},
+		"meta.json with native histogram stats in the extensions": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+				},
+				Thanos: metadata.Thanos{
+					Extensions: &cortex_tsdb.BlockExtensions{
+						HistogramStats: &cortex_tsdb.BlockHistogramStats{Series: 5, ChunksBytes: 1024},
+					},
+				},
+			},
+			expected: Block{
+				ID:                   blockID,
+				MinTime:              10,
+				MaxTime:              20,
+				SegmentsFormat:       SegmentsFormatUnknown,
+				HistogramSeries:      5,
+				HistogramChunksBytes: 1024,
+			},
+		},
+		"meta.json of a block shipped by an ingester": {
+			meta: metadata.Meta{
+				BlockMeta: tsdb.BlockMeta{
+					ULID:    blockID,
+					MinTime: 10,
+					MaxTime: 20,
+				},
+				Thanos: metadata.Thanos{
+					Labels: map[string]string{
+						cortex_tsdb.TenantIDExternalLabel:   "user-1",
+						cortex_tsdb.IngesterIDExternalLabel: "ingester-1",
+					},
+				},
+			},
+			expected: Block{
+				ID:             blockID,
+				MinTime:        10,
+				MaxTime:        20,
+				SegmentsFormat: SegmentsFormatUnknown,
+				IngesterID:     "ingester-1",
+			},
+		},
 		"meta.json with SegmentFiles": {
 			meta: metadata.Meta{
 				BlockMeta: tsdb.BlockMeta{
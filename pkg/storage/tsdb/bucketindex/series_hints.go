@@ -0,0 +1,175 @@
+package bucketindex
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/oklog/ulid"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/tsdb/index"
+	"github.com/thanos-io/thanos/pkg/block"
+
+	"github.com/cortexproject/cortex/pkg/util/runutil"
+)
+
+const (
+	seriesHintsBitsPerPair = 10
+	seriesHintsHashes      = 7
+)
+
+// SeriesHints summarise the series of a block, so that the blocks which can't have any
+// series matching a query are skipped without loading their index-header.
+type SeriesHints struct {
+	// LabelNames are the sorted label names of the series in the block.
+	LabelNames []string `json:"label_names"`
+
+	// Bloom is a bloom filter of the label name and value pairs of the series in the block.
+	Bloom []byte `json:"bloom"`
+}
+
+// NewSeriesHints builds the hints of a block from its label values by label name.
+func NewSeriesHints(values map[string][]string) *SeriesHints {
+	pairs := 0
+	names := make([]string, 0, len(values))
+	for name, v := range values {
+		names = append(names, name)
+		pairs += len(v)
+	}
+
+	h := &SeriesHints{
+		LabelNames: names,
+		Bloom:      make([]byte, max(8, (pairs*seriesHintsBitsPerPair+7)/8)),
+	}
+	sort.Strings(h.LabelNames)
+
+	for name, v := range values {
+		for _, value := range v {
+			h.forEachBit(name, value, func(bit uint64) bool {
+				h.Bloom[bit/8] |= 1 << (bit % 8)
+				return true
+			})
+		}
+	}
+	return h
+}
+
+// MayMatch returns false if the block can't have any series matching all the matchers.
+func (h *SeriesHints) MayMatch(matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		// Matchers accepting the empty value also match series without the label.
+		if m.Matches("") {
+			continue
+		}
+
+		if !h.hasLabelName(m.Name) {
+			return false
+		}
+
+		var values []string
+		switch m.Type {
+		case labels.MatchEqual:
+			values = []string{m.Value}
+		case labels.MatchRegexp:
+			values = m.SetMatches()
+		}
+
+		// Only the matchers with a known set of values can be checked against the bloom filter.
+		if len(values) == 0 {
+			continue
+		}
+
+		found := false
+		for _, v := range values {
+			if h.mayContain(m.Name, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *SeriesHints) hasLabelName(name string) bool {
+	i := sort.SearchStrings(h.LabelNames, name)
+	return i < len(h.LabelNames) && h.LabelNames[i] == name
+}
+
+func (h *SeriesHints) mayContain(name, value string) bool {
+	if len(h.Bloom) == 0 {
+		return true
+	}
+
+	contained := true
+	h.forEachBit(name, value, func(bit uint64) bool {
+		contained = h.Bloom[bit/8]&(1<<(bit%8)) != 0
+		return contained
+	})
+	return contained
+}
+
+// forEachBit calls f with the bloom filter bits of the pair, using double hashing, until f returns false.
+func (h *SeriesHints) forEachBit(name, value string, f func(bit uint64) bool) {
+	d := xxhash.New()
+	_, _ = d.WriteString(name)
+	_, _ = d.Write([]byte{0xff})
+	_, _ = d.WriteString(value)
+	sum := d.Sum64()
+
+	bits := uint64(len(h.Bloom)) * 8
+	h1, h2 := sum&0xffffffff, sum>>32
+	for i := uint64(0); i < seriesHintsHashes; i++ {
+		if !f((h1 + i*h2) % bits) {
+			return
+		}
+	}
+}
+
+// byteSlice implements index.ByteSlice over an in-memory index.
+type byteSlice []byte
+
+func (b byteSlice) Len() int                    { return len(b) }
+func (b byteSlice) Range(start, end int) []byte { return b[start:end] }
+
+// readSeriesHints downloads the index of the block and builds its series hints.
+func (w *Updater) readSeriesHints(ctx context.Context, id ulid.ULID) (*SeriesHints, error) {
+	indexFile := path.Join(id.String(), block.IndexFilename)
+
+	r, err := w.bkt.Get(ctx, indexFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "get block index file: %v", indexFile)
+	}
+	defer runutil.CloseWithLogOnErr(w.logger, r, "close get block index file")
+
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read block index file: %v", indexFile)
+	}
+
+	reader, err := index.NewReader(byteSlice(content))
+	if err != nil {
+		return nil, errors.Wrapf(err, "open block index file: %v", indexFile)
+	}
+	defer runutil.CloseWithLogOnErr(w.logger, reader, "close block index reader")
+
+	names, err := reader.LabelNames(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "read label names")
+	}
+
+	values := make(map[string][]string, len(names))
+	for _, name := range names {
+		if values[name], err = reader.SortedLabelValues(ctx, name); err != nil {
+			return nil, errors.Wrapf(err, "read label values of %s", name)
+		}
+	}
+
+	return NewSeriesHints(values), nil
+}
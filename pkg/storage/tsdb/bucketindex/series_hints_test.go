@@ -0,0 +1,136 @@
+package bucketindex
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/block"
+	"github.com/thanos-io/thanos/pkg/block/metadata"
+
+	"github.com/cortexproject/cortex/pkg/storage/bucket"
+	"github.com/cortexproject/cortex/pkg/storage/tsdb/testutil"
+)
+
+func TestSeriesHints_MayMatch(t *testing.T) {
+	hints := NewSeriesHints(map[string][]string{
+		"__name__": {"up", "http_requests_total"},
+		"job":      {"api", "web"},
+	})
+
+	tests := map[string]struct {
+		matchers []*labels.Matcher
+		expected bool
+	}{
+		"no matchers": {
+			expected: true,
+		},
+		"equal matchers on existing values": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "__name__", "up"),
+				labels.MustNewMatcher(labels.MatchEqual, "job", "web"),
+			},
+			expected: true,
+		},
+		"equal matcher on a missing value": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "__name__", "down"),
+			},
+			expected: false,
+		},
+		"equal matcher on a missing label name": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "instance", "localhost"),
+			},
+			expected: false,
+		},
+		"regexp set matcher with an existing value": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, "job", "db|web"),
+			},
+			expected: true,
+		},
+		"regexp set matcher without existing values": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, "job", "db|cache"),
+			},
+			expected: false,
+		},
+		"regexp matcher without a set of values": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchRegexp, "job", "d.*"),
+			},
+			expected: true,
+		},
+		"not equal matcher on a missing label name": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchNotEqual, "instance", "localhost"),
+			},
+			expected: true,
+		},
+		"matcher accepting the empty value on a missing label name": {
+			matchers: []*labels.Matcher{
+				labels.MustNewMatcher(labels.MatchEqual, "instance", ""),
+			},
+			expected: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, hints.MayMatch(tc.matchers))
+		})
+	}
+}
+
+func TestUpdater_UpdateIndex_ShouldBuildSeriesHints(t *testing.T) {
+	const userID = "user-1"
+
+	bkt, _ := testutil.PrepareFilesystemBucket(t)
+	ctx := context.Background()
+	logger := log.NewNopLogger()
+
+	// Create a real block, since the hints are built from the block index.
+	series := []storage.Series{
+		storage.NewListSeries(labels.FromStrings("__name__", "up", "job", "api"), []chunks.Sample{sample{t: 10, f: 1}}),
+		storage.NewListSeries(labels.FromStrings("__name__", "up", "job", "web"), []chunks.Sample{sample{t: 19, f: 1}}),
+	}
+	dir, err := tsdb.CreateBlock(series, t.TempDir(), 0, logger)
+	require.NoError(t, err)
+	require.NoError(t, block.UploadPromBlock(ctx, logger, bucket.NewUserBucketClient(userID, bkt, nil), dir, metadata.NoneFunc))
+
+	// The hints are not built if the block has more series than the limit.
+	idx, _, _, err := NewUpdater(bkt, userID, nil, logger).WithSeriesHints(1).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 1)
+	assert.Nil(t, idx.Blocks[0].SeriesHints)
+
+	idx, _, _, err = NewUpdater(bkt, userID, nil, logger).WithSeriesHints(2).UpdateIndex(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, idx.Blocks, 1)
+	require.NotNil(t, idx.Blocks[0].SeriesHints)
+	assert.Equal(t, filepath.Base(dir), idx.Blocks[0].ID.String())
+	assert.Equal(t, []string{"__name__", "job"}, idx.Blocks[0].SeriesHints.LabelNames)
+	assert.True(t, idx.Blocks[0].SeriesHints.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "web")}))
+	assert.False(t, idx.Blocks[0].SeriesHints.MayMatch([]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "job", "db")}))
+}
+
+type sample struct {
+	t int64
+	f float64
+}
+
+func (s sample) T() int64                      { return s.t }
+func (s sample) F() float64                    { return s.f }
+func (s sample) H() *histogram.Histogram       { return nil }
+func (s sample) FH() *histogram.FloatHistogram { return nil }
+func (s sample) Type() chunkenc.ValueType      { return chunkenc.ValFloat }
@@ -35,6 +35,9 @@ var (
 type Updater struct {
 	bkt    objstore.InstrumentedBucket
 	logger log.Logger
+
+	// Series hints are built for the new blocks with at most this number of series (0 = disabled).
+	seriesHintsMaxSeries uint64
 }
 
 func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantConfigProvider, logger log.Logger) *Updater {
@@ -44,6 +47,13 @@ func NewUpdater(bkt objstore.Bucket, userID string, cfgProvider bucket.TenantCon
 	}
 }
 
+// WithSeriesHints enables building the series hints of the new blocks having at most
+// maxSeries series, which requires downloading their index.
+func (w *Updater) WithSeriesHints(maxSeries uint64) *Updater {
+	w.seriesHintsMaxSeries = maxSeries
+	return w
+}
+
 // UpdateIndex generates the bucket index and returns it, without storing it to the storage.
 // If the old index is not passed in input, then the bucket index will be generated from scratch.
 func (w *Updater) UpdateIndex(ctx context.Context, old *Index) (*Index, map[ulid.ULID]error, int64, error) {
@@ -177,6 +187,13 @@ func (w *Updater) updateBlockIndexEntry(ctx context.Context, id ulid.ULID) (*Blo
 	// the block has completed to be uploaded.
 	block.UploadedAt = attrs.LastModified.Unix()
 
+	// The series hints are best-effort: blocks without hints are just never skipped.
+	if w.seriesHintsMaxSeries > 0 && m.Stats.NumSeries > 0 && m.Stats.NumSeries <= w.seriesHintsMaxSeries {
+		if block.SeriesHints, err = w.readSeriesHints(ctx, id); err != nil {
+			level.Warn(w.logger).Log("msg", "failed to build block series hints", "block", id.String(), "err", err)
+		}
+	}
+
 	return block, nil
 }
 
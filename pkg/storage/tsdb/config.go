@@ -253,6 +253,9 @@ type BucketStoreConfig struct {
 	TenantSyncConcurrency    int                 `yaml:"tenant_sync_concurrency"`
 	BlockSyncConcurrency     int                 `yaml:"block_sync_concurrency"`
 	MetaSyncConcurrency      int                 `yaml:"meta_sync_concurrency"`
+	SyncIOMaxConcurrency     int                 `yaml:"sync_io_max_concurrency"`
+	SyncIOMaxBytesPerSecond  int64               `yaml:"sync_io_max_bytes_per_second"`
+	QueryIOMaxConcurrency    int                 `yaml:"query_io_max_concurrency"`
 	ConsistencyDelay         time.Duration       `yaml:"consistency_delay"`
 	IndexCache               IndexCacheConfig    `yaml:"index_cache"`
 	ChunksCache              ChunksCacheConfig   `yaml:"chunks_cache"`
@@ -292,6 +295,9 @@ type BucketStoreConfig struct {
 
 	// Controls how many series to fetch per batch in Store Gateway. Default value is 10000.
 	SeriesBatchSize int `yaml:"series_batch_size"`
+
+	// Controls whether the key bucket store metrics are also exported per tenant.
+	PerTenantMetricsEnabled bool `yaml:"per_tenant_metrics_enabled"`
 }
 
 // RegisterFlags registers the BucketStore flags
@@ -311,6 +317,9 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.TenantSyncConcurrency, "blocks-storage.bucket-store.tenant-sync-concurrency", 10, "Maximum number of concurrent tenants syncing blocks.")
 	f.IntVar(&cfg.BlockSyncConcurrency, "blocks-storage.bucket-store.block-sync-concurrency", 20, "Maximum number of concurrent blocks syncing per tenant.")
 	f.IntVar(&cfg.MetaSyncConcurrency, "blocks-storage.bucket-store.meta-sync-concurrency", 20, "Number of Go routines to use when syncing block meta files from object storage per tenant.")
+	f.IntVar(&cfg.SyncIOMaxConcurrency, "blocks-storage.bucket-store.sync-io-max-concurrency", 0, "[Experimental] Max number of concurrent object storage operations issued by the blocks sync, shared across all tenants. The listings and the operations served by the caches aren't limited. Limits the impact of a large re-sync (e.g. after a ring topology change) on the in-flight queries. 0 to disable the limit.")
+	f.Int64Var(&cfg.SyncIOMaxBytesPerSecond, "blocks-storage.bucket-store.sync-io-max-bytes-per-second", 0, "[Experimental] Max number of bytes per second read from the object storage by the blocks sync, shared across all tenants. 0 to disable the limit.")
+	f.IntVar(&cfg.QueryIOMaxConcurrency, "blocks-storage.bucket-store.query-io-max-concurrency", 0, "[Experimental] Max number of concurrent object storage operations issued by the queries, shared across all tenants. The listings and the operations served by the caches aren't limited. The queries and the blocks sync are limited separately, so that neither can take all the object storage connections. 0 to disable the limit.")
 	f.DurationVar(&cfg.ConsistencyDelay, "blocks-storage.bucket-store.consistency-delay", 0, "Minimum age of a block before it's being read. Set it to safe value (e.g 30m) if your object storage is eventually consistent. GCS and S3 are (roughly) strongly consistent.")
 	f.DurationVar(&cfg.IgnoreDeletionMarksDelay, "blocks-storage.bucket-store.ignore-deletion-marks-delay", time.Hour*6, "Duration after which the blocks marked for deletion will be filtered out while fetching blocks. "+
 		"The idea of ignore-deletion-marks-delay is to ignore blocks that are marked for deletion with some delay. This ensures store can still serve blocks that are meant to be deleted but do not have a replacement yet. "+
@@ -324,6 +333,7 @@ func (cfg *BucketStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.Uint64Var(&cfg.EstimatedMaxChunkSizeBytes, "blocks-storage.bucket-store.estimated-max-chunk-size-bytes", store.EstimatedMaxChunkSize, "Estimated max chunk size in bytes. Setting a large value might result in over fetching data while a small value might result in data refetch. Default value is 16KiB.")
 	f.BoolVar(&cfg.LazyExpandedPostingsEnabled, "blocks-storage.bucket-store.lazy-expanded-postings-enabled", false, "If true, Store Gateway will estimate postings size and try to lazily expand postings if it downloads less data than expanding all postings.")
 	f.IntVar(&cfg.SeriesBatchSize, "blocks-storage.bucket-store.series-batch-size", store.SeriesBatchSize, "Controls how many series to fetch per batch in Store Gateway. Default value is 10000.")
+	f.BoolVar(&cfg.PerTenantMetricsEnabled, "blocks-storage.bucket-store.per-tenant-metrics-enabled", false, "If true, the store-gateway also exports the blocks loads, the bytes fetched and the series requests duration per tenant. The number of exported series grows with the number of tenants, so enable it only when the tenants are bounded.")
 	f.StringVar(&cfg.BlockDiscoveryStrategy, "blocks-storage.bucket-store.block-discovery-strategy", string(ConcurrentDiscovery), "One of "+strings.Join(supportedBlockDiscoveryStrategies, ", ")+". When set to concurrent, stores will concurrently issue one call per directory to discover active blocks in the bucket. The recursive strategy iterates through all objects in the bucket, recursively traversing into each directory. This avoids N+1 calls at the expense of having slower bucket iterations. bucket_index strategy can be used in Compactor only and utilizes the existing bucket index to fetch block IDs to sync. This avoids iterating the bucket but can be impacted by delays of cleaner creating bucket index.")
 }
 
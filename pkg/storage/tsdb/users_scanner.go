@@ -34,6 +34,11 @@ func NewUsersScanner(bucketClient objstore.Bucket, isOwned func(userID string) (
 	}
 }
 
+// IsOwned returns whether the user is owned by this instance.
+func (s *UsersScanner) IsOwned(userID string) (bool, error) {
+	return s.isOwned(userID)
+}
+
 // ScanUsers returns a fresh list of users found in the storage, that are not marked for deletion,
 // and list of users marked for deletion.
 //
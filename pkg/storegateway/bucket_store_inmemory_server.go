@@ -8,6 +8,7 @@ import (
 	"github.com/prometheus/prometheus/util/annotations"
 	"github.com/thanos-io/thanos/pkg/store/hintspb"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
+	"google.golang.org/grpc/metadata"
 )
 
 // bucketStoreSeriesServer is a fake in-memory gRPC server used to
@@ -22,12 +23,17 @@ type bucketStoreSeriesServer struct {
 	SeriesSet []*storepb.Series
 	Warnings  annotations.Annotations
 	Hints     hintspb.SeriesResponseHints
+	Trailer   metadata.MD
 }
 
 func newBucketStoreSeriesServer(ctx context.Context) *bucketStoreSeriesServer {
 	return &bucketStoreSeriesServer{ctx: ctx}
 }
 
+func (s *bucketStoreSeriesServer) SetTrailer(md metadata.MD) {
+	s.Trailer = metadata.Join(s.Trailer, md)
+}
+
 func (s *bucketStoreSeriesServer) Send(r *storepb.SeriesResponse) error {
 	if r.GetWarning() != "" {
 		s.Warnings.Add(errors.New(r.GetWarning()))
@@ -11,6 +11,9 @@ import (
 type BucketStoreMetrics struct {
 	regs *util.UserRegistries
 
+	// Whether to also export the per-tenant metrics.
+	perTenantMetricsEnabled bool
+
 	// exported metrics, gathered from Thanos BucketStore
 	blockLoads            *prometheus.Desc
 	blockLoadFailures     *prometheus.Desc
@@ -54,11 +57,17 @@ type BucketStoreMetrics struct {
 	indexHeaderLazyUnloadCount       *prometheus.Desc
 	indexHeaderLazyUnloadFailedCount *prometheus.Desc
 	indexHeaderLazyLoadDuration      *prometheus.Desc
+
+	// per-tenant metrics, exported only if enabled
+	tenantBlockLoads            *prometheus.Desc
+	tenantSeriesDataSizeFetched *prometheus.Desc
+	tenantSeriesGetAllDuration  *prometheus.Desc
 }
 
-func NewBucketStoreMetrics() *BucketStoreMetrics {
+func NewBucketStoreMetrics(perTenantMetricsEnabled bool) *BucketStoreMetrics {
 	return &BucketStoreMetrics{
-		regs: util.NewUserRegistries(),
+		regs:                    util.NewUserRegistries(),
+		perTenantMetricsEnabled: perTenantMetricsEnabled,
 
 		blockLoads: prometheus.NewDesc(
 			"cortex_bucket_store_block_loads_total",
@@ -217,6 +226,19 @@ func NewBucketStoreMetrics() *BucketStoreMetrics {
 			"cortex_bucket_store_lazy_expanded_posting_series_overfetched_size_bytes_total",
 			"Total number of series size in bytes overfetched due to posting lazy expansion.",
 			nil, nil),
+
+		tenantBlockLoads: prometheus.NewDesc(
+			"cortex_bucket_store_tenant_block_loads_total",
+			"Total number of remote block loading attempts per tenant.",
+			[]string{"user"}, nil),
+		tenantSeriesDataSizeFetched: prometheus.NewDesc(
+			"cortex_bucket_store_tenant_series_data_size_fetched_bytes",
+			"Size of all items of all data types in a block fetched for a single series request, per tenant.",
+			[]string{"user"}, nil),
+		tenantSeriesGetAllDuration: prometheus.NewDesc(
+			"cortex_bucket_store_tenant_series_get_all_duration_seconds",
+			"Time it takes until all per-block prepares and preloads for a query are finished, per tenant.",
+			[]string{"user"}, nil),
 	}
 }
 
@@ -271,6 +293,12 @@ func (m *BucketStoreMetrics) Describe(out chan<- *prometheus.Desc) {
 	out <- m.lazyExpandedPostingsCount
 	out <- m.lazyExpandedPostingSizeBytes
 	out <- m.lazyExpandedPostingSeriesOverfetchedSizeBytes
+
+	if m.perTenantMetricsEnabled {
+		out <- m.tenantBlockLoads
+		out <- m.tenantSeriesDataSizeFetched
+		out <- m.tenantSeriesGetAllDuration
+	}
 }
 
 func (m *BucketStoreMetrics) Collect(out chan<- prometheus.Metric) {
@@ -321,4 +349,10 @@ func (m *BucketStoreMetrics) Collect(out chan<- prometheus.Metric) {
 	data.SendSumOfCounters(out, m.lazyExpandedPostingsCount, "thanos_bucket_store_lazy_expanded_postings_total")
 	data.SendSumOfCounters(out, m.lazyExpandedPostingSizeBytes, "thanos_bucket_store_lazy_expanded_posting_size_bytes_total")
 	data.SendSumOfCounters(out, m.lazyExpandedPostingSeriesOverfetchedSizeBytes, "thanos_bucket_store_lazy_expanded_posting_series_overfetched_size_bytes_total")
+
+	if m.perTenantMetricsEnabled {
+		data.SendSumOfCountersPerUser(out, m.tenantBlockLoads, "thanos_bucket_store_block_loads_total")
+		data.SendSumOfHistogramsPerUser(out, m.tenantSeriesDataSizeFetched, "thanos_bucket_store_series_data_size_fetched_bytes")
+		data.SendSumOfHistogramsPerUser(out, m.tenantSeriesGetAllDuration, "thanos_bucket_store_series_get_all_duration_seconds")
+	}
 }
@@ -15,7 +15,7 @@ func TestBucketStoreMetrics(t *testing.T) {
 	t.Parallel()
 	mainReg := prometheus.NewPedanticRegistry()
 
-	tsdbMetrics := NewBucketStoreMetrics()
+	tsdbMetrics := NewBucketStoreMetrics(false)
 	mainReg.MustRegister(tsdbMetrics)
 
 	tsdbMetrics.AddUserRegistry("user1", populateMockedBucketStoreMetrics(5328))
@@ -572,6 +572,100 @@ func TestBucketStoreMetrics(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestBucketStoreMetrics_PerTenantMetrics(t *testing.T) {
+	t.Parallel()
+	mainReg := prometheus.NewPedanticRegistry()
+
+	tsdbMetrics := NewBucketStoreMetrics(true)
+	mainReg.MustRegister(tsdbMetrics)
+
+	tsdbMetrics.AddUserRegistry("user1", populateMockedBucketStoreMetrics(1))
+	tsdbMetrics.AddUserRegistry("user2", populateMockedBucketStoreMetrics(2))
+
+	//noinspection ALL
+	err := testutil.GatherAndCompare(mainReg, bytes.NewBufferString(`
+			# HELP cortex_bucket_store_tenant_block_loads_total Total number of remote block loading attempts per tenant.
+			# TYPE cortex_bucket_store_tenant_block_loads_total counter
+			cortex_bucket_store_tenant_block_loads_total{user="user1"} 2
+			cortex_bucket_store_tenant_block_loads_total{user="user2"} 4
+			# HELP cortex_bucket_store_tenant_series_data_size_fetched_bytes Size of all items of all data types in a block fetched for a single series request, per tenant.
+			# TYPE cortex_bucket_store_tenant_series_data_size_fetched_bytes histogram
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="1024"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="2048"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="4096"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="8192"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="16384"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="32768"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="65536"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="131072"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="262144"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="524288"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="1.048576e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="2.097152e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="4.194304e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="8.388608e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="1.6777216e+07"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user1",le="+Inf"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_sum{user="user1"} 48
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_count{user="user1"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="1024"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="2048"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="4096"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="8192"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="16384"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="32768"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="65536"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="131072"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="262144"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="524288"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="1.048576e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="2.097152e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="4.194304e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="8.388608e+06"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="1.6777216e+07"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_bucket{user="user2",le="+Inf"} 3
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_sum{user="user2"} 96
+			cortex_bucket_store_tenant_series_data_size_fetched_bytes_count{user="user2"} 3
+			# HELP cortex_bucket_store_tenant_series_get_all_duration_seconds Time it takes until all per-block prepares and preloads for a query are finished, per tenant.
+			# TYPE cortex_bucket_store_tenant_series_get_all_duration_seconds histogram
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="0.001"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="0.01"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="0.1"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="0.3"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="0.6"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="1"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="3"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="6"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="9"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="20"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="30"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="60"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="90"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="120"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user1",le="+Inf"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_sum{user="user1"} 66
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_count{user="user1"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="0.001"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="0.01"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="0.1"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="0.3"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="0.6"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="1"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="3"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="6"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="9"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="20"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="30"} 0
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="60"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="90"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="120"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_bucket{user="user2",le="+Inf"} 3
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_sum{user="user2"} 132
+			cortex_bucket_store_tenant_series_get_all_duration_seconds_count{user="user2"} 3
+`), "cortex_bucket_store_tenant_block_loads_total", "cortex_bucket_store_tenant_series_data_size_fetched_bytes", "cortex_bucket_store_tenant_series_get_all_duration_seconds")
+	require.NoError(t, err)
+}
+
 func BenchmarkMetricsCollections10(b *testing.B) {
 	benchmarkMetricsCollection(b, 10)
 }
@@ -591,7 +685,7 @@ func BenchmarkMetricsCollections10000(b *testing.B) {
 func benchmarkMetricsCollection(b *testing.B, users int) {
 	mainReg := prometheus.NewRegistry()
 
-	tsdbMetrics := NewBucketStoreMetrics()
+	tsdbMetrics := NewBucketStoreMetrics(false)
 	mainReg.MustRegister(tsdbMetrics)
 
 	base := 123456.0
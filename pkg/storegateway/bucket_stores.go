@@ -7,12 +7,14 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
+	"github.com/gogo/protobuf/types"
 	"github.com/oklog/ulid"
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
@@ -26,6 +28,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/pool"
 	"github.com/thanos-io/thanos/pkg/store"
 	storecache "github.com/thanos-io/thanos/pkg/store/cache"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/logging"
@@ -69,6 +72,12 @@ type BucketStores struct {
 	storesMu sync.RWMutex
 	stores   map[string]*store.BucketStore
 
+	// Keeps the filter tracking the owned blocks for each tenant. Guarded by storesMu.
+	ownedBlocks map[string]*OwnedBlocksFilter
+
+	// Keeps the filter tracking the chunks size per series of the blocks for each tenant. Guarded by storesMu.
+	chunksBytesPerSeries map[string]*ChunksBytesPerSeriesFilter
+
 	// Keeps the last sync error for the  bucket store for each tenant.
 	storesErrorsMu sync.RWMutex
 	storesErrors   map[string]error
@@ -82,6 +91,10 @@ type BucketStores struct {
 	syncLastSuccess   prometheus.Gauge
 	tenantsDiscovered prometheus.Gauge
 	tenantsSynced     prometheus.Gauge
+
+	indexOnlySeriesRequests prometheus.Counter
+	indexOnlySeries         prometheus.Counter
+	indexOnlyChunksBytes    prometheus.Counter
 }
 
 var ErrTooManyInflightRequests = status.Error(codes.ResourceExhausted, "too many inflight requests in store gateway")
@@ -89,7 +102,10 @@ var ErrTooManyInflightRequests = status.Error(codes.ResourceExhausted, "too many
 // NewBucketStores makes a new BucketStores.
 func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStrategy, bucketClient objstore.InstrumentedBucket, limits *validation.Overrides, logLevel logging.Level, logger log.Logger, reg prometheus.Registerer) (*BucketStores, error) {
 	matchers := tsdb.NewMatchers()
-	cachingBucket, err := tsdb.CreateCachingBucket(cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, matchers, bucketClient, logger, reg)
+	// The IO limits only apply to the operations actually issued to the object storage, not to the
+	// ones served by the caches.
+	ioPriorityBucket := newIOPriorityBucket(cfg.BucketStore, bucketClient, reg)
+	cachingBucket, err := tsdb.CreateCachingBucket(cfg.BucketStore.ChunksCache, cfg.BucketStore.MetadataCache, matchers, ioPriorityBucket, logger, reg)
 	if err != nil {
 		return nil, errors.Wrapf(err, "create caching bucket")
 	}
@@ -103,18 +119,20 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 	}).Set(float64(cfg.BucketStore.MaxConcurrent))
 
 	u := &BucketStores{
-		logger:             logger,
-		cfg:                cfg,
-		limits:             limits,
-		bucket:             cachingBucket,
-		shardingStrategy:   shardingStrategy,
-		stores:             map[string]*store.BucketStore{},
-		storesErrors:       map[string]error{},
-		logLevel:           logLevel,
-		bucketStoreMetrics: NewBucketStoreMetrics(),
-		metaFetcherMetrics: NewMetadataFetcherMetrics(),
-		queryGate:          queryGate,
-		partitioner:        newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, reg),
+		logger:               logger,
+		cfg:                  cfg,
+		limits:               limits,
+		bucket:               cachingBucket,
+		shardingStrategy:     shardingStrategy,
+		stores:               map[string]*store.BucketStore{},
+		ownedBlocks:          map[string]*OwnedBlocksFilter{},
+		chunksBytesPerSeries: map[string]*ChunksBytesPerSeriesFilter{},
+		storesErrors:         map[string]error{},
+		logLevel:             logLevel,
+		bucketStoreMetrics:   NewBucketStoreMetrics(cfg.BucketStore.PerTenantMetricsEnabled),
+		metaFetcherMetrics:   NewMetadataFetcherMetrics(),
+		queryGate:            queryGate,
+		partitioner:          newGapBasedPartitioner(cfg.BucketStore.PartitionerMaxGapBytes, reg),
 		syncTimes: promauto.With(reg).NewHistogram(prometheus.HistogramOpts{
 			Name:    "cortex_bucket_stores_blocks_sync_seconds",
 			Help:    "The total time it takes to perform a sync stores",
@@ -132,6 +150,18 @@ func NewBucketStores(cfg tsdb.BlocksStorageConfig, shardingStrategy ShardingStra
 			Name: "cortex_bucket_stores_tenants_synced",
 			Help: "Number of tenants synced.",
 		}),
+		indexOnlySeriesRequests: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_index_only_series_requests_total",
+			Help: "Total number of series requests served from the index-headers and postings only, without touching chunks.",
+		}),
+		indexOnlySeries: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_index_only_series_total",
+			Help: "Total number of series returned by series requests served without touching chunks.",
+		}),
+		indexOnlyChunksBytes: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Name: "cortex_bucket_stores_index_only_chunks_bytes_avoided_total",
+			Help: "Estimated number of chunks bytes not fetched because the series requests were served without touching chunks.",
+		}),
 	}
 
 	// Init the index cache.
@@ -205,6 +235,9 @@ func (u *BucketStores) syncUsersBlocks(ctx context.Context, f func(context.Conte
 		}
 	}(time.Now())
 
+	// The object storage operations of the sync are limited separately from the queries ones.
+	ctx = contextWithSyncIO(ctx)
+
 	type job struct {
 		userID string
 		store  *store.BucketStore
@@ -296,6 +329,10 @@ func (u *BucketStores) syncUsersBlocks(ctx context.Context, f func(context.Conte
 	return errs.Err()
 }
 
+// ChunkBytesAvoidedTrailer is the gRPC trailer of the series requests skipping chunks, reporting
+// the estimated number of chunk bytes which haven't been fetched to serve them.
+const ChunkBytesAvoidedTrailer = "cortex-chunk-bytes-avoided"
+
 // Series makes a series request to the underlying user bucket store.
 func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_SeriesServer) error {
 	spanLog, spanCtx := spanlogger.New(srv.Context(), "BucketStores.Series")
@@ -331,14 +368,56 @@ func (u *BucketStores) Series(req *storepb.SeriesRequest, srv storepb.Store_Seri
 		defer u.decrementInflightRequestCnt()
 	}
 
-	err = store.Series(req, spanSeriesServer{
+	var seriesSrv storepb.Store_SeriesServer = spanSeriesServer{
 		Store_SeriesServer: srv,
 		ctx:                spanCtx,
-	})
+	}
+
+	// Requests not needing chunks (eg. /api/v1/series) are short-circuited by the bucket store:
+	// no chunks reader is created for them and the series chunk metas are only decoded up to the
+	// first one overlapping the request time range, so no chunk is ever fetched.
+	var indexOnlySrv *indexOnlySeriesServer
+	if req.SkipChunks {
+		indexOnlySrv = &indexOnlySeriesServer{Store_SeriesServer: seriesSrv}
+		seriesSrv = indexOnlySrv
+	}
+
+	err = store.Series(req, seriesSrv)
+
+	if indexOnlySrv != nil {
+		// The series entries touched in each block, including all their chunk metas, are the chunks we
+		// didn't fetch. Fall back to the returned series if the request didn't enable the query stats.
+		seriesTouched := indexOnlySrv.seriesTouched
+		if seriesTouched == 0 {
+			seriesTouched = int64(indexOnlySrv.series)
+		}
+		avoidedBytes := uint64(float64(seriesTouched) * u.getChunksBytesPerSeries(userID, indexOnlySrv.queriedBlocks, req.MinTime, req.MaxTime))
+
+		u.indexOnlySeriesRequests.Inc()
+		u.indexOnlySeries.Add(float64(indexOnlySrv.series))
+		u.indexOnlyChunksBytes.Add(float64(avoidedBytes))
+		level.Debug(spanLog).Log("msg", "served series request without touching chunks", "series", indexOnlySrv.series, "series_touched", seriesTouched, "blocks", len(indexOnlySrv.queriedBlocks), "chunk_bytes_avoided", avoidedBytes)
+
+		// Report the avoided bytes to the querier, to be added to the query stats.
+		if err == nil {
+			srv.SetTrailer(metadata.Pairs(ChunkBytesAvoidedTrailer, strconv.FormatUint(avoidedBytes, 10)))
+		}
+	}
 
 	return err
 }
 
+func (u *BucketStores) getChunksBytesPerSeries(userID string, blockIDs []ulid.ULID, minT, maxT int64) float64 {
+	u.storesMu.RLock()
+	f := u.chunksBytesPerSeries[userID]
+	u.storesMu.RUnlock()
+
+	if f == nil {
+		return 0
+	}
+	return f.ChunksBytesPerSeries(blockIDs, minT, maxT)
+}
+
 func (u *BucketStores) getInflightRequestCnt() int {
 	u.inflightRequestMu.RLock()
 	defer u.inflightRequestMu.RUnlock()
@@ -472,6 +551,8 @@ func (u *BucketStores) closeEmptyBucketStore(userID string) error {
 	}
 
 	delete(u.stores, userID)
+	delete(u.ownedBlocks, userID)
+	delete(u.chunksBytesPerSeries, userID)
 	unlockInDefer = false
 	u.storesMu.Unlock()
 
@@ -480,6 +561,24 @@ func (u *BucketStores) closeEmptyBucketStore(userID string) error {
 	return bs.Close()
 }
 
+// BlocksSyncPercentage returns the percentage (0-100) of the blocks owned by the store-gateway
+// which have been loaded, across all tenants. It returns 100 if the store-gateway owns no block.
+func (u *BucketStores) BlocksSyncPercentage() uint32 {
+	u.storesMu.RLock()
+	owned := int64(0)
+	for _, f := range u.ownedBlocks {
+		owned += f.OwnedBlocks()
+	}
+	u.storesMu.RUnlock()
+
+	if owned == 0 {
+		return 100
+	}
+
+	loaded := u.bucketStoreMetrics.regs.BuildMetricFamiliesPerUser().GetSumOfGauges("thanos_bucket_store_blocks_loaded")
+	return uint32(min(100, 100*loaded/float64(owned)))
+}
+
 func isEmptyBucketStore(bs *store.BucketStore) bool {
 	min, max := bs.TimeRange()
 	return min == math.MaxInt64 && max == math.MinInt64
@@ -533,6 +632,11 @@ func (u *BucketStores) getOrCreateStore(userID string) (*store.BucketStore, erro
 		filters = append(filters, NewIgnoreNonQueryableBlocksFilter(userLogger, u.cfg.BucketStore.IgnoreBlocksWithin))
 	}
 
+	// The owned blocks and chunks size filters MUST be the last ones, in order to track the blocks which will be loaded.
+	ownedBlocks := &OwnedBlocksFilter{}
+	chunksBytesPerSeries := &ChunksBytesPerSeriesFilter{}
+	filters = append(filters, ownedBlocks, chunksBytesPerSeries)
+
 	// Instantiate a different blocks metadata fetcher based on whether bucket index is enabled or not.
 	var fetcher block.MetadataFetcher
 	if u.cfg.BucketStore.BucketIndex.Enabled {
@@ -633,6 +737,8 @@ func (u *BucketStores) getOrCreateStore(userID string) (*store.BucketStore, erro
 	}
 
 	u.stores[userID] = bs
+	u.ownedBlocks[userID] = ownedBlocks
+	u.chunksBytesPerSeries[userID] = chunksBytesPerSeries
 	u.metaFetcherMetrics.AddUserRegistry(userID, fetcherReg)
 	u.bucketStoreMetrics.AddUserRegistry(userID, bucketStoreReg)
 
@@ -731,6 +837,35 @@ func (s spanSeriesServer) Context() context.Context {
 	return s.ctx
 }
 
+// indexOnlySeriesServer counts the series sent for a request which skips chunks
+// and tracks the blocks queried and the series entries touched to serve it.
+type indexOnlySeriesServer struct {
+	storepb.Store_SeriesServer
+
+	series        int
+	seriesTouched int64
+	queriedBlocks []ulid.ULID
+}
+
+func (s *indexOnlySeriesServer) Send(r *storepb.SeriesResponse) error {
+	if r.GetSeries() != nil {
+		s.series++
+	} else if h := r.GetHints(); h != nil {
+		hints := hintspb.SeriesResponseHints{}
+		if err := types.UnmarshalAny(h, &hints); err == nil {
+			for _, b := range hints.QueriedBlocks {
+				if id, err := ulid.Parse(b.Id); err == nil {
+					s.queriedBlocks = append(s.queriedBlocks, id)
+				}
+			}
+			if hints.QueryStats != nil {
+				s.seriesTouched += hints.QueryStats.SeriesTouched
+			}
+		}
+	}
+	return s.Store_SeriesServer.Send(r)
+}
+
 type limiter struct {
 	limiter *store.Limiter
 }
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log"
+	"github.com/gogo/protobuf/types"
 	"github.com/gogo/status"
 	"github.com/oklog/ulid"
 	"github.com/prometheus/client_golang/prometheus"
@@ -30,6 +31,7 @@ import (
 	"github.com/thanos-io/thanos/pkg/block"
 	thanos_metadata "github.com/thanos-io/thanos/pkg/block/metadata"
 	"github.com/thanos-io/thanos/pkg/store"
+	"github.com/thanos-io/thanos/pkg/store/hintspb"
 	"github.com/thanos-io/thanos/pkg/store/labelpb"
 	"github.com/thanos-io/thanos/pkg/store/storepb"
 	"github.com/weaveworks/common/logging"
@@ -390,6 +392,86 @@ func TestBucketStores_SyncBlocks(t *testing.T) {
 	assert.Greater(t, testutil.ToFloat64(stores.syncLastSuccess), float64(0))
 }
 
+func TestBucketStores_SyncBlocks_ShouldNotDeadlockWithSyncIOConcurrencyLimit(t *testing.T) {
+	t.Parallel()
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+		numBlocks  = 150
+	)
+
+	cfg := prepareStorageConfig(t)
+	cfg.BucketStore.SyncIOMaxConcurrency = 1
+	cfg.BucketStore.QueryIOMaxConcurrency = 1
+
+	storageDir := t.TempDir()
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	// More blocks than the block lister checks concurrently and buffers while listing them.
+	for i := 0; i < numBlocks; i++ {
+		generateStorageBlock(t, storageDir, userID, metricName, int64(i*100), int64((i+1)*100), 15)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	require.NoError(t, stores.InitialSync(ctx))
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+			# HELP cortex_bucket_store_blocks_loaded Number of currently loaded blocks.
+			# TYPE cortex_bucket_store_blocks_loaded gauge
+			cortex_bucket_store_blocks_loaded{user="user-1"} 150
+	`), "cortex_bucket_store_blocks_loaded"))
+}
+
+func TestBucketStores_BlocksSyncPercentage(t *testing.T) {
+	t.Parallel()
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	storageDir := t.TempDir()
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(bucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	// No block is owned yet.
+	assert.Equal(t, uint32(100), stores.BlocksSyncPercentage())
+
+	// Run an initial sync to discover and load 1 block.
+	generateStorageBlock(t, storageDir, userID, metricName, 10, 100, 15)
+	require.NoError(t, stores.InitialSync(ctx))
+	assert.Equal(t, uint32(100), stores.BlocksSyncPercentage())
+
+	// Generate another block which can't be loaded because its index is missing.
+	entries, err := os.ReadDir(filepath.Join(storageDir, userID))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	generateStorageBlock(t, storageDir, userID, metricName, 100, 200, 15)
+
+	newEntries, err := os.ReadDir(filepath.Join(storageDir, userID))
+	require.NoError(t, err)
+	require.Len(t, newEntries, 2)
+	for _, entry := range newEntries {
+		if entry.Name() != entries[0].Name() {
+			require.NoError(t, os.Remove(filepath.Join(storageDir, userID, entry.Name(), "index")))
+		}
+	}
+
+	require.NoError(t, stores.SyncBlocks(ctx))
+	assert.Equal(t, uint32(50), stores.BlocksSyncPercentage())
+}
+
 func TestBucketStores_syncUsersBlocks(t *testing.T) {
 	t.Parallel()
 	allUsers := []string{"user-1", "user-2", "user-3"}
@@ -517,6 +599,114 @@ func testBucketStoresSeriesShouldCorrectlyQuerySeriesSpanningMultipleChunks(t *t
 	}
 }
 
+func TestBucketStores_Series_ShouldNotTouchChunksIfSkipChunksIsRequested(t *testing.T) {
+	const (
+		userID     = "user-1"
+		metricName = "series_1"
+	)
+
+	ctx := context.Background()
+	cfg := prepareStorageConfig(t)
+
+	storageDir := t.TempDir()
+	generateStorageBlock(t, storageDir, userID, metricName, 0, 10000, 1)
+
+	// The TSDB snapshot doesn't list the block files in the meta.json, so we add the chunks file.
+	blockDirs, err := os.ReadDir(filepath.Join(storageDir, userID))
+	require.NoError(t, err)
+	require.Len(t, blockDirs, 1)
+	blockDir := filepath.Join(storageDir, userID, blockDirs[0].Name())
+	meta, err := thanos_metadata.ReadFromDir(blockDir)
+	require.NoError(t, err)
+	meta.Thanos.Files = []thanos_metadata.File{{RelPath: "chunks/000001", SizeBytes: 1024}, {RelPath: "index", SizeBytes: 512}}
+	require.NoError(t, meta.WriteToDir(log.NewNopLogger(), blockDir))
+
+	bucket, err := filesystem.NewBucketClient(filesystem.Config{Directory: storageDir})
+	require.NoError(t, err)
+	chunksReadsBucket := &chunksReadsCountingBucket{Bucket: bucket}
+
+	reg := prometheus.NewPedanticRegistry()
+	stores, err := NewBucketStores(cfg, NewNoShardingStrategy(log.NewNopLogger(), nil), objstore.WithNoopInstr(chunksReadsBucket), defaultLimitsOverrides(t), mockLoggingLevel(), log.NewNopLogger(), reg)
+	require.NoError(t, err)
+	require.NoError(t, stores.InitialSync(ctx))
+
+	req := &storepb.SeriesRequest{
+		MinTime: math.MinInt64,
+		MaxTime: math.MaxInt64,
+		Matchers: []storepb.LabelMatcher{{
+			Type:  storepb.LabelMatcher_EQ,
+			Name:  labels.MetricName,
+			Value: metricName,
+		}},
+		SkipChunks:              true,
+		PartialResponseStrategy: storepb.PartialResponseStrategy_ABORT,
+	}
+	req.Hints, err = types.MarshalAny(&hintspb.SeriesRequestHints{EnableQueryStats: true})
+	require.NoError(t, err)
+
+	srv := newBucketStoreSeriesServer(setUserIDToGRPCContext(ctx, userID))
+	require.NoError(t, stores.Series(req, srv))
+	require.Len(t, srv.SeriesSet, 1)
+	assert.Empty(t, srv.SeriesSet[0].Chunks)
+	assert.Zero(t, chunksReadsBucket.reads.Load())
+
+	// The chunk bytes avoided by the request are returned to the querier.
+	require.NotNil(t, srv.Hints.QueryStats)
+	assert.Equal(t, int64(1), srv.Hints.QueryStats.SeriesTouched)
+	assert.Equal(t, []string{"1024"}, srv.Trailer.Get(ChunkBytesAvoidedTrailer))
+
+	// Only the chunks within the request time range are accounted.
+	req.MinTime, req.MaxTime = meta.MinTime, meta.MinTime+(meta.MaxTime-meta.MinTime)/4-1
+	srv = newBucketStoreSeriesServer(setUserIDToGRPCContext(ctx, userID))
+	require.NoError(t, stores.Series(req, srv))
+	require.Len(t, srv.SeriesSet, 1)
+	assert.Equal(t, []string{"256"}, srv.Trailer.Get(ChunkBytesAvoidedTrailer))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP cortex_bucket_stores_index_only_chunks_bytes_avoided_total Estimated number of chunks bytes not fetched because the series requests were served without touching chunks.
+		# TYPE cortex_bucket_stores_index_only_chunks_bytes_avoided_total counter
+		cortex_bucket_stores_index_only_chunks_bytes_avoided_total 1280
+
+		# HELP cortex_bucket_stores_index_only_series_requests_total Total number of series requests served from the index-headers and postings only, without touching chunks.
+		# TYPE cortex_bucket_stores_index_only_series_requests_total counter
+		cortex_bucket_stores_index_only_series_requests_total 2
+
+		# HELP cortex_bucket_stores_index_only_series_total Total number of series returned by series requests served without touching chunks.
+		# TYPE cortex_bucket_stores_index_only_series_total counter
+		cortex_bucket_stores_index_only_series_total 2
+	`), "cortex_bucket_stores_index_only_series_requests_total", "cortex_bucket_stores_index_only_series_total", "cortex_bucket_stores_index_only_chunks_bytes_avoided_total"))
+
+	// A request needing chunks reads them from the bucket.
+	seriesSet, _, err := querySeries(stores, userID, metricName, math.MinInt64, math.MaxInt64)
+	require.NoError(t, err)
+	require.Len(t, seriesSet, 1)
+	assert.NotEmpty(t, seriesSet[0].Chunks)
+	assert.NotZero(t, chunksReadsBucket.reads.Load())
+}
+
+// chunksReadsCountingBucket counts the reads of the blocks chunks files.
+type chunksReadsCountingBucket struct {
+	objstore.Bucket
+
+	reads atomic.Int32
+}
+
+func (b *chunksReadsCountingBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	b.countChunksRead(name)
+	return b.Bucket.Get(ctx, name)
+}
+
+func (b *chunksReadsCountingBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	b.countChunksRead(name)
+	return b.Bucket.GetRange(ctx, name, off, length)
+}
+
+func (b *chunksReadsCountingBucket) countChunksRead(name string) {
+	if strings.Contains(name, "/"+block.ChunksDirname+"/") {
+		b.reads.Inc()
+	}
+}
+
 func TestBucketStores_Series_ShouldReturnErrorIfMaxInflightRequestIsReached(t *testing.T) {
 	cfg := prepareStorageConfig(t)
 	cfg.BucketStore.MaxInflightRequests = 10
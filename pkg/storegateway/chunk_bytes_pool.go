@@ -1,47 +1,148 @@
 package storegateway
 
 import (
+	"sync"
+
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/thanos-io/thanos/pkg/pool"
+	"go.uber.org/atomic"
+)
+
+const (
+	chunkPoolHit       = "hit"
+	chunkPoolMiss      = "miss"
+	chunkPoolOversized = "oversized"
+	chunkPoolExhausted = "exhausted"
 )
 
+// chunkBytesPool is the pool of the byte slices the chunks are read into by the bucket stores.
+// The slices are pooled in buckets of sizes growing by a factor of 2 between the min and max
+// bucket size, and no more than maxChunkPoolBytes can be used at a given time unless it's 0.
 type chunkBytesPool struct {
-	pool *pool.BucketedBytes
+	buckets   []sync.Pool
+	sizes     []int
+	maxTotal  uint64
+	usedTotal atomic.Uint64
 
 	// Metrics.
 	poolByteStats *prometheus.CounterVec
+	poolRequests  *prometheus.CounterVec
 }
 
 func newChunkBytesPool(minBucketSize, maxBucketSize int, maxChunkPoolBytes uint64, reg prometheus.Registerer) (*chunkBytesPool, error) {
-	upstream, err := pool.NewBucketedBytes(minBucketSize, maxBucketSize, 2, maxChunkPoolBytes)
-	if err != nil {
-		return nil, err
+	if minBucketSize < 1 {
+		return nil, errors.New("invalid minimum pool size")
+	}
+	if maxBucketSize < 1 {
+		return nil, errors.New("invalid maximum pool size")
+	}
+
+	var sizes []int
+	for s := minBucketSize; s <= maxBucketSize; s *= 2 {
+		sizes = append(sizes, s)
 	}
 
-	return &chunkBytesPool{
-		pool: upstream,
+	p := &chunkBytesPool{
+		buckets:  make([]sync.Pool, len(sizes)),
+		sizes:    sizes,
+		maxTotal: maxChunkPoolBytes,
 		poolByteStats: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
 			Name: "cortex_bucket_store_chunk_pool_operation_bytes_total",
 			Help: "Total bytes number of bytes pooled by operation.",
 		}, []string{"operation", "stats"}),
-	}, nil
+		poolRequests: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_bucket_store_chunk_pool_requests_total",
+			Help: "Total number of byte slices requested to the chunks pool, by result. A hit is a slice reused from the pool, a miss a slice allocated because none was available, and an oversized request a slice larger than the largest pool bucket.",
+		}, []string{"result"}),
+	}
+
+	promauto.With(reg).NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "cortex_bucket_store_chunk_pool_used_bytes",
+		Help: "Number of bytes of the byte slices of the chunks pool currently in use.",
+	}, func() float64 {
+		return float64(p.usedTotal.Load())
+	})
+
+	return p, nil
 }
 
+// Get returns a byte slice with a capacity of at least sz.
 func (p *chunkBytesPool) Get(sz int) (*[]byte, error) {
-	buffer, err := p.pool.Get(sz)
-	if err != nil {
-		return buffer, err
+	bucket := -1
+	size := sz
+	for i, bktSize := range p.sizes {
+		if sz <= bktSize {
+			bucket, size = i, bktSize
+			break
+		}
+	}
+
+	if !p.reserve(uint64(sz), uint64(size)) {
+		p.poolRequests.WithLabelValues(chunkPoolExhausted).Inc()
+		return nil, pool.ErrPoolExhausted
 	}
 
 	p.poolByteStats.WithLabelValues("get", "requested").Add(float64(sz))
-	p.poolByteStats.WithLabelValues("get", "cap").Add(float64(cap(*buffer)))
+	p.poolByteStats.WithLabelValues("get", "cap").Add(float64(size))
+
+	if bucket < 0 {
+		// The requested size exceeds that of the largest bucket, allocate it directly.
+		p.poolRequests.WithLabelValues(chunkPoolOversized).Inc()
+		b := make([]byte, 0, sz)
+		return &b, nil
+	}
+
+	if b, ok := p.buckets[bucket].Get().(*[]byte); ok {
+		p.poolRequests.WithLabelValues(chunkPoolHit).Inc()
+		return b, nil
+	}
 
-	return buffer, err
+	p.poolRequests.WithLabelValues(chunkPoolMiss).Inc()
+	b := make([]byte, 0, size)
+	return &b, nil
+}
+
+// reserve accounts size bytes in use, unless the requested sz bytes would exceed the max total.
+func (p *chunkBytesPool) reserve(sz, size uint64) bool {
+	for {
+		used := p.usedTotal.Load()
+		if p.maxTotal > 0 && used+sz > p.maxTotal {
+			return false
+		}
+		if p.usedTotal.CompareAndSwap(used, used+size) {
+			return true
+		}
+	}
 }
 
+// Put returns the byte slice to the bucket of its capacity.
 func (p *chunkBytesPool) Put(b *[]byte) {
+	if b == nil {
+		return
+	}
+
+	sz := uint64(cap(*b))
 	p.poolByteStats.WithLabelValues("put", "len").Add(float64(len(*b)))
-	p.poolByteStats.WithLabelValues("put", "cap").Add(float64(cap(*b)))
-	p.pool.Put(b)
+	p.poolByteStats.WithLabelValues("put", "cap").Add(float64(sz))
+
+	// The slices are only pooled in the bucket of their exact capacity, so that the slices of
+	// a bucket can always hold the sizes requested to it.
+	for i, bktSize := range p.sizes {
+		if int(sz) == bktSize {
+			*b = (*b)[:0]
+			p.buckets[i].Put(b)
+			break
+		}
+	}
+
+	// We could assume here that our users will not make the slices larger
+	// but lets be on the safe side to avoid an underflow of the used total.
+	for {
+		used := p.usedTotal.Load()
+		if p.usedTotal.CompareAndSwap(used, used-min(used, sz)) {
+			return
+		}
+	}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/thanos/pkg/pool"
 	"github.com/thanos-io/thanos/pkg/store"
 
 	cortex_tsdb "github.com/cortexproject/cortex/pkg/storage/tsdb"
@@ -37,5 +38,53 @@ func TestChunkBytesPool_Get(t *testing.T) {
 		cortex_bucket_store_chunk_pool_operation_bytes_total{operation="get",stats="requested"} %d
 		cortex_bucket_store_chunk_pool_operation_bytes_total{operation="put",stats="cap"} %d
 		cortex_bucket_store_chunk_pool_operation_bytes_total{operation="put",stats="len"} %d
-	`, store.EstimatedMaxChunkSize*3, store.EstimatedMaxChunkSize*2, store.EstimatedMaxChunkSize*2, len(testBytes)))))
+		# HELP cortex_bucket_store_chunk_pool_requests_total Total number of byte slices requested to the chunks pool, by result. A hit is a slice reused from the pool, a miss a slice allocated because none was available, and an oversized request a slice larger than the largest pool bucket.
+		# TYPE cortex_bucket_store_chunk_pool_requests_total counter
+		cortex_bucket_store_chunk_pool_requests_total{result="miss"} 2
+		# HELP cortex_bucket_store_chunk_pool_used_bytes Number of bytes of the byte slices of the chunks pool currently in use.
+		# TYPE cortex_bucket_store_chunk_pool_used_bytes gauge
+		cortex_bucket_store_chunk_pool_used_bytes %d
+	`, store.EstimatedMaxChunkSize*3, store.EstimatedMaxChunkSize*2, store.EstimatedMaxChunkSize*2, len(testBytes), store.EstimatedMaxChunkSize))))
+}
+
+func TestChunkBytesPool_Requests(t *testing.T) {
+	t.Parallel()
+	reg := prometheus.NewPedanticRegistry()
+	p, err := newChunkBytesPool(1000, 4000, 10000, reg)
+	require.NoError(t, err)
+
+	small, err := p.Get(500)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, cap(*small))
+
+	large, err := p.Get(3000)
+	require.NoError(t, err)
+	assert.Equal(t, 4000, cap(*large))
+
+	oversized, err := p.Get(5000)
+	require.NoError(t, err)
+	assert.Equal(t, 5000, cap(*oversized))
+
+	// The slices in use can't exceed the max total size.
+	_, err = p.Get(1000)
+	assert.Equal(t, pool.ErrPoolExhausted, err)
+	assert.Equal(t, uint64(10000), p.usedTotal.Load())
+
+	p.Put(small)
+	p.Put(large)
+	p.Put(oversized)
+	assert.Equal(t, uint64(0), p.usedTotal.Load())
+
+	// The pooled slices are reused, unless dropped by the runtime.
+	reused, err := p.Get(800)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, cap(*reused))
+	assert.Len(t, *reused, 0)
+
+	requests := func(result string) float64 {
+		return testutil.ToFloat64(p.poolRequests.WithLabelValues(result))
+	}
+	assert.Equal(t, float64(3), requests(chunkPoolHit)+requests(chunkPoolMiss))
+	assert.Equal(t, float64(1), requests(chunkPoolOversized))
+	assert.Equal(t, float64(1), requests(chunkPoolExhausted))
 }
@@ -404,7 +404,10 @@ func (g *StoreGateway) OnRingInstanceRegister(lc *ring.BasicLifecycler, ringDesc
 
 func (g *StoreGateway) OnRingInstanceTokens(_ *ring.BasicLifecycler, _ ring.Tokens) {}
 func (g *StoreGateway) OnRingInstanceStopping(_ *ring.BasicLifecycler)              {}
-func (g *StoreGateway) OnRingInstanceHeartbeat(_ *ring.BasicLifecycler, _ *ring.Desc, _ *ring.InstanceDesc) {
+func (g *StoreGateway) OnRingInstanceHeartbeat(_ *ring.BasicLifecycler, _ *ring.Desc, instanceDesc *ring.InstanceDesc) {
+	// Advertise how many of the owned blocks have been loaded, so that the queriers
+	// can prefer the store-gateways which are fully synced.
+	instanceDesc.BlocksSyncPercentage = g.stores.BlocksSyncPercentage()
 }
 
 func createBucketClient(cfg cortex_tsdb.BlocksStorageConfig, logger log.Logger, reg prometheus.Registerer) (objstore.InstrumentedBucket, error) {
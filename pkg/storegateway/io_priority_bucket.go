@@ -0,0 +1,218 @@
+package storegateway
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/thanos-io/objstore"
+	"github.com/thanos-io/thanos/pkg/extprom"
+	"github.com/thanos-io/thanos/pkg/gate"
+	"golang.org/x/time/rate"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb"
+)
+
+type syncIOContextKey struct{}
+
+// contextWithSyncIO marks the object storage operations issued with the returned context as
+// issued by the blocks sync, rather than by the queries.
+func contextWithSyncIO(ctx context.Context) context.Context {
+	return context.WithValue(ctx, syncIOContextKey{}, true)
+}
+
+func isSyncIO(ctx context.Context) bool {
+	v, ok := ctx.Value(syncIOContextKey{}).(bool)
+	return ok && v
+}
+
+// ioPriorityBucket is an objstore.InstrumentedBucket which limits the concurrency of the object
+// storage operations issued by the blocks sync and by the queries through separate gates, and
+// optionally rate limits the bytes read by the blocks sync, so that a large re-sync (e.g. after
+// a store-gateway ring topology change) doesn't starve the in-flight queries.
+type ioPriorityBucket struct {
+	bucket objstore.InstrumentedBucket
+
+	syncGate    gate.Gate
+	queryGate   gate.Gate
+	syncLimiter *rate.Limiter
+}
+
+// newIOPriorityBucket wraps the bucket with the sync and query IO limits, or returns the bucket
+// itself if no limit is configured.
+func newIOPriorityBucket(cfg tsdb.BucketStoreConfig, bucket objstore.InstrumentedBucket, reg prometheus.Registerer) objstore.InstrumentedBucket {
+	if cfg.SyncIOMaxConcurrency <= 0 && cfg.QueryIOMaxConcurrency <= 0 && cfg.SyncIOMaxBytesPerSecond <= 0 {
+		return bucket
+	}
+
+	gateReg := extprom.WrapRegistererWithPrefix("cortex_bucket_stores_", reg)
+	b := &ioPriorityBucket{
+		bucket:    bucket,
+		syncGate:  gate.New(gateReg, cfg.SyncIOMaxConcurrency, "sync_io"),
+		queryGate: gate.New(gateReg, cfg.QueryIOMaxConcurrency, "query_io"),
+	}
+	if cfg.SyncIOMaxBytesPerSecond > 0 {
+		b.syncLimiter = rate.NewLimiter(rate.Limit(cfg.SyncIOMaxBytesPerSecond), int(cfg.SyncIOMaxBytesPerSecond))
+	}
+	return b
+}
+
+// start waits for an IO slot of the class of the operation, and returns the function to call
+// once the operation is done.
+func (b *ioPriorityBucket) start(ctx context.Context) (func(), error) {
+	g := b.queryGate
+	if isSyncIO(ctx) {
+		g = b.syncGate
+	}
+	if err := g.Start(ctx); err != nil {
+		return nil, err
+	}
+	return g.Done, nil
+}
+
+// Close implements objstore.Bucket.
+func (b *ioPriorityBucket) Close() error {
+	return b.bucket.Close()
+}
+
+// Upload implements objstore.Bucket.
+func (b *ioPriorityBucket) Upload(ctx context.Context, name string, r io.Reader) error {
+	return b.bucket.Upload(ctx, name, r)
+}
+
+// Delete implements objstore.Bucket.
+func (b *ioPriorityBucket) Delete(ctx context.Context, name string) error {
+	return b.bucket.Delete(ctx, name)
+}
+
+// Name implements objstore.Bucket.
+func (b *ioPriorityBucket) Name() string {
+	return b.bucket.Name()
+}
+
+// Iter implements objstore.Bucket. The listings aren't limited, since the callers issue other
+// operations from the callback, which would wait forever for the IO slot held by the listing.
+func (b *ioPriorityBucket) Iter(ctx context.Context, dir string, f func(string) error, options ...objstore.IterOption) error {
+	return b.bucket.Iter(ctx, dir, f, options...)
+}
+
+// Get implements objstore.Bucket.
+func (b *ioPriorityBucket) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	done, err := b.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := b.bucket.Get(ctx, name)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	return b.newReader(ctx, r, done), nil
+}
+
+// GetRange implements objstore.Bucket.
+func (b *ioPriorityBucket) GetRange(ctx context.Context, name string, off, length int64) (io.ReadCloser, error) {
+	done, err := b.start(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := b.bucket.GetRange(ctx, name, off, length)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	return b.newReader(ctx, r, done), nil
+}
+
+// Exists implements objstore.Bucket.
+func (b *ioPriorityBucket) Exists(ctx context.Context, name string) (bool, error) {
+	done, err := b.start(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer done()
+
+	return b.bucket.Exists(ctx, name)
+}
+
+// IsObjNotFoundErr implements objstore.Bucket.
+func (b *ioPriorityBucket) IsObjNotFoundErr(err error) bool {
+	return b.bucket.IsObjNotFoundErr(err)
+}
+
+// IsAccessDeniedErr implements objstore.Bucket.
+func (b *ioPriorityBucket) IsAccessDeniedErr(err error) bool {
+	return b.bucket.IsAccessDeniedErr(err)
+}
+
+// Attributes implements objstore.Bucket.
+func (b *ioPriorityBucket) Attributes(ctx context.Context, name string) (objstore.ObjectAttributes, error) {
+	done, err := b.start(ctx)
+	if err != nil {
+		return objstore.ObjectAttributes{}, err
+	}
+	defer done()
+
+	return b.bucket.Attributes(ctx, name)
+}
+
+// ReaderWithExpectedErrs implements objstore.InstrumentedBucket.
+func (b *ioPriorityBucket) ReaderWithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.BucketReader {
+	return b.WithExpectedErrs(fn)
+}
+
+// WithExpectedErrs implements objstore.InstrumentedBucket.
+func (b *ioPriorityBucket) WithExpectedErrs(fn objstore.IsOpFailureExpectedFunc) objstore.Bucket {
+	if ib, ok := b.bucket.WithExpectedErrs(fn).(objstore.InstrumentedBucket); ok {
+		return &ioPriorityBucket{
+			bucket:      ib,
+			syncGate:    b.syncGate,
+			queryGate:   b.queryGate,
+			syncLimiter: b.syncLimiter,
+		}
+	}
+
+	return b
+}
+
+func (b *ioPriorityBucket) newReader(ctx context.Context, r io.ReadCloser, done func()) io.ReadCloser {
+	reader := &ioPriorityReader{ReadCloser: r, ctx: ctx, done: done}
+	if isSyncIO(ctx) {
+		reader.limiter = b.syncLimiter
+	}
+	return reader
+}
+
+// ioPriorityReader holds the IO slot until the reader is closed, and rate limits the bytes read
+// if a limiter is set.
+type ioPriorityReader struct {
+	io.ReadCloser
+
+	ctx     context.Context
+	limiter *rate.Limiter
+	done    func()
+	once    sync.Once
+}
+
+func (r *ioPriorityReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if r.limiter != nil {
+		// The limiter can't wait for more tokens than its burst at once.
+		for remaining := n; remaining > 0; {
+			tokens := min(remaining, r.limiter.Burst())
+			if waitErr := r.limiter.WaitN(r.ctx, tokens); waitErr != nil {
+				return n, waitErr
+			}
+			remaining -= tokens
+		}
+	}
+	return n, err
+}
+
+func (r *ioPriorityReader) Close() error {
+	r.once.Do(r.done)
+	return r.ReadCloser.Close()
+}
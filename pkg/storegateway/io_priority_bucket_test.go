@@ -0,0 +1,91 @@
+package storegateway
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/thanos-io/objstore"
+
+	"github.com/cortexproject/cortex/pkg/storage/tsdb"
+)
+
+func TestIOPriorityBucket_ShouldReturnTheBucketIfNoLimitIsConfigured(t *testing.T) {
+	bkt := objstore.WithNoopInstr(objstore.NewInMemBucket())
+	assert.Equal(t, bkt, newIOPriorityBucket(tsdb.BucketStoreConfig{}, bkt, prometheus.NewPedanticRegistry()))
+}
+
+func TestIOPriorityBucket_ShouldLimitSyncAndQueryConcurrencySeparately(t *testing.T) {
+	inmem := objstore.NewInMemBucket()
+	require.NoError(t, inmem.Upload(context.Background(), "object", strings.NewReader("content")))
+
+	reg := prometheus.NewPedanticRegistry()
+	bkt := newIOPriorityBucket(tsdb.BucketStoreConfig{SyncIOMaxConcurrency: 1, QueryIOMaxConcurrency: 1}, objstore.WithNoopInstr(inmem), reg)
+	syncCtx := contextWithSyncIO(context.Background())
+
+	// The sync IO slot is held until the reader is closed.
+	syncReader, err := bkt.Get(syncCtx, "object")
+	require.NoError(t, err)
+
+	timeoutCtx, cancel := context.WithTimeout(syncCtx, 100*time.Millisecond)
+	defer cancel()
+	_, err = bkt.Exists(timeoutCtx, "object")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// The queries aren't blocked by the sync.
+	queryReader, err := bkt.GetRange(context.Background(), "object", 0, 4)
+	require.NoError(t, err)
+	content, err := io.ReadAll(queryReader)
+	require.NoError(t, err)
+	assert.Equal(t, "cont", string(content))
+
+	assert.NoError(t, testutil.GatherAndCompare(reg, bytes.NewBufferString(`
+		# HELP cortex_bucket_stores_gate_query_io_in_flight Number of query_io that are currently in flight.
+		# TYPE cortex_bucket_stores_gate_query_io_in_flight gauge
+		cortex_bucket_stores_gate_query_io_in_flight 1
+		# HELP cortex_bucket_stores_gate_sync_io_in_flight Number of sync_io that are currently in flight.
+		# TYPE cortex_bucket_stores_gate_sync_io_in_flight gauge
+		cortex_bucket_stores_gate_sync_io_in_flight 1
+	`), "cortex_bucket_stores_gate_sync_io_in_flight", "cortex_bucket_stores_gate_query_io_in_flight"))
+
+	require.NoError(t, queryReader.Close())
+	require.NoError(t, syncReader.Close())
+
+	// Closing the reader twice doesn't release the slot twice.
+	require.NoError(t, syncReader.Close())
+
+	exists, err := bkt.Exists(syncCtx, "object")
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestIOPriorityBucket_ShouldRateLimitSyncBytes(t *testing.T) {
+	inmem := objstore.NewInMemBucket()
+	require.NoError(t, inmem.Upload(context.Background(), "object", strings.NewReader("0123456789")))
+
+	bkt := newIOPriorityBucket(tsdb.BucketStoreConfig{SyncIOMaxBytesPerSecond: 1000}, objstore.WithNoopInstr(inmem), prometheus.NewPedanticRegistry())
+	limiter := bkt.(*ioPriorityBucket).syncLimiter
+
+	// The bytes read by the queries aren't rate limited.
+	r, err := bkt.Get(context.Background(), "object")
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.InDelta(t, 1000, limiter.Tokens(), 1)
+
+	// The bytes read by the sync are.
+	r, err = bkt.Get(contextWithSyncIO(context.Background()), "object")
+	require.NoError(t, err)
+	_, err = io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.InDelta(t, 990, limiter.Tokens(), 1)
+}
@@ -2,6 +2,8 @@ package storegateway
 
 import (
 	"context"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-kit/log"
@@ -10,6 +12,7 @@ import (
 	"github.com/thanos-io/objstore"
 	"github.com/thanos-io/thanos/pkg/block"
 	"github.com/thanos-io/thanos/pkg/block/metadata"
+	"go.uber.org/atomic"
 
 	"github.com/cortexproject/cortex/pkg/storage/tsdb/bucketindex"
 )
@@ -105,3 +108,100 @@ func (f *IgnoreNonQueryableBlocksFilter) Filter(ctx context.Context, metas map[u
 
 	return nil
 }
+
+// OwnedBlocksFilter doesn't filter out any block, but keeps track of the number of blocks
+// which passed the previous filters. When used as the last filter, it tracks the number of
+// blocks owned by the store-gateway.
+type OwnedBlocksFilter struct {
+	owned atomic.Int64
+}
+
+// Filter implements block.MetadataFilter.
+func (f *OwnedBlocksFilter) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, _ block.GaugeVec, _ block.GaugeVec) error {
+	f.owned.Store(int64(len(metas)))
+	return nil
+}
+
+// OwnedBlocks returns the number of blocks which passed the previous filters in the last run.
+func (f *OwnedBlocksFilter) OwnedBlocks() int64 {
+	return f.owned.Load()
+}
+
+// ChunksBytesPerSeriesFilter doesn't filter out any block, but keeps track of the average
+// size of the chunks per series of each block which passed the previous filters. The size
+// is computed from the files listed in the block meta.json, so blocks whose meta doesn't
+// list the files are not tracked.
+type ChunksBytesPerSeriesFilter struct {
+	mtx    sync.RWMutex
+	blocks map[ulid.ULID]blockChunksBytes
+}
+
+type blockChunksBytes struct {
+	bytesPerSeries   float64
+	minTime, maxTime int64
+}
+
+// Filter implements block.MetadataFilter.
+func (f *ChunksBytesPerSeriesFilter) Filter(_ context.Context, metas map[ulid.ULID]*metadata.Meta, _ block.GaugeVec, _ block.GaugeVec) error {
+	blocks := make(map[ulid.ULID]blockChunksBytes, len(metas))
+
+	for id, m := range metas {
+		if m.Stats.NumSeries == 0 {
+			continue
+		}
+
+		chunksBytes := int64(0)
+		for _, file := range m.Thanos.Files {
+			if strings.HasPrefix(file.RelPath, block.ChunksDirname+"/") {
+				chunksBytes += file.SizeBytes
+			}
+		}
+
+		if chunksBytes > 0 {
+			blocks[id] = blockChunksBytes{
+				bytesPerSeries: float64(chunksBytes) / float64(m.Stats.NumSeries),
+				minTime:        m.MinTime,
+				maxTime:        m.MaxTime,
+			}
+		}
+	}
+
+	f.mtx.Lock()
+	f.blocks = blocks
+	f.mtx.Unlock()
+
+	return nil
+}
+
+// ChunksBytesPerSeries returns the average, across the input blocks, of the size of the chunks
+// per series of each block within [minT, maxT], assuming the chunks of a series are evenly
+// spread over the block time range. Blocks not tracked by the last run are skipped.
+func (f *ChunksBytesPerSeriesFilter) ChunksBytesPerSeries(blockIDs []ulid.ULID, minT, maxT int64) float64 {
+	f.mtx.RLock()
+	defer f.mtx.RUnlock()
+
+	sum, tracked := float64(0), 0
+	for _, id := range blockIDs {
+		b, ok := f.blocks[id]
+		if !ok {
+			continue
+		}
+		tracked++
+
+		// The block max time is exclusive while the request max time is inclusive.
+		end := b.maxTime
+		if maxT < end {
+			end = maxT + 1
+		}
+		overlap := end - max(b.minTime, minT)
+		if overlap <= 0 || b.maxTime <= b.minTime {
+			continue
+		}
+		sum += b.bytesPerSeries * float64(overlap) / float64(b.maxTime-b.minTime)
+	}
+
+	if tracked == 0 {
+		return 0
+	}
+	return sum / float64(tracked)
+}
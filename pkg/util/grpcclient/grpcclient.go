@@ -96,6 +96,11 @@ func (cfg *Config) DialOption(unaryClientInterceptors []grpc.UnaryClientIntercep
 		unaryClientInterceptors = append(unaryClientInterceptors, UnarySigningClientInterceptor)
 	}
 
+	if tenantSigner != nil {
+		unaryClientInterceptors = append(unaryClientInterceptors, UnaryTenantSigningClientInterceptor(tenantSigner))
+		streamClientInterceptors = append(streamClientInterceptors, StreamTenantSigningClientInterceptor(tenantSigner))
+	}
+
 	return append(
 		opts,
 		grpc.WithDefaultCallOptions(cfg.CallOptions()...),
@@ -33,29 +33,38 @@ func UnarySigningServerInterceptor(ctx context.Context, req interface{}, _ *grpc
 		return handler(ctx, req)
 	}
 
+	if err := VerifyRequestSignature(ctx, rs); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// VerifyRequestSignature verifies the signature of the request found in the incoming metadata of the context.
+func VerifyRequestSignature(ctx context.Context, rs SignRequest) error {
 	md, ok := metadata.FromIncomingContext(ctx)
 
 	if !ok {
-		return nil, ErrSignatureNotPresent
+		return ErrSignatureNotPresent
 	}
 
 	sig, ok := md[reqSignHeaderName]
 
 	if !ok || len(sig) != 1 {
-		return nil, ErrSignatureNotPresent
+		return ErrSignatureNotPresent
 	}
 
 	valid, err := rs.VerifySign(ctx, sig[0])
 
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if !valid {
-		return nil, ErrSignatureMismatch
+		return ErrSignatureMismatch
 	}
 
-	return handler(ctx, req)
+	return nil
 }
 
 func UnarySigningClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
@@ -65,32 +74,40 @@ func UnarySigningClientInterceptor(ctx context.Context, method string, req, repl
 		return invoker(ctx, method, req, reply, cc, opts...)
 	}
 
-	signature, err := rs.Sign(ctx)
+	newCtx, err := SignRequestToOutgoingContext(ctx, rs)
 
 	if err != nil {
 		return err
 	}
 
+	return invoker(newCtx, method, req, reply, cc, opts...)
+}
+
+// SignRequestToOutgoingContext returns the context with the signature of the request added to its outgoing metadata.
+func SignRequestToOutgoingContext(ctx context.Context, rs SignRequest) (context.Context, error) {
+	signature, err := rs.Sign(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
 	md, ok := metadata.FromOutgoingContext(ctx)
 	if !ok {
 		md = metadata.New(map[string]string{})
 	}
 
-	newCtx := ctx
-
 	if s, ok := md[reqSignHeaderName]; ok {
 		if len(s) == 1 {
 			if s[0] != signature {
-				return ErrDifferentSignaturePresent
+				return nil, ErrDifferentSignaturePresent
 			}
 		} else {
-			return ErrMultipleSignaturePresent
+			return nil, ErrMultipleSignaturePresent
 		}
-	} else {
-		md = md.Copy()
-		md[reqSignHeaderName] = []string{signature}
-		newCtx = metadata.NewOutgoingContext(ctx, md)
+		return ctx, nil
 	}
 
-	return invoker(newCtx, method, req, reply, cc, opts...)
+	md = md.Copy()
+	md[reqSignHeaderName] = []string{signature}
+	return metadata.NewOutgoingContext(ctx, md), nil
 }
@@ -0,0 +1,356 @@
+package grpcclient
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+)
+
+const (
+	tenantSignatureHeaderName = "x-scope-orgid-signature"
+
+	// tenantHTTPSignatureHeaderName is the header carrying the tenant signature of HTTP requests
+	// wrapped in protobuf, which are forwarded from the query-frontend to queriers.
+	tenantHTTPSignatureHeaderName = "X-Scope-Orgid-Signature"
+
+	tenantSignatureMissing = "missing"
+	tenantSignatureInvalid = "invalid"
+	tenantSignatureExpired = "expired"
+)
+
+var (
+	orgIDHeaderName = strings.ToLower(user.OrgIDHeaderName)
+
+	errTenantSigningKeysMissing    = errors.New("at least one tenant signing key must be configured when tenant signing is enabled")
+	errTenantSigningMaxAgeNegative = errors.New("the tenant signature max age must not be negative")
+	errTenantSignatureInvalid      = errors.New("invalid tenant signature")
+	errTenantSignatureExpired      = errors.New("expired tenant signature")
+
+	// tenantSigner is used by all gRPC clients to sign the propagated tenant ID, if set.
+	tenantSigner *TenantSigner
+)
+
+// TenantSigningConfig configures the signing of the tenant ID propagated between Cortex components.
+type TenantSigningConfig struct {
+	Enabled bool                   `yaml:"enabled"`
+	Keys    flagext.StringSliceCSV `yaml:"keys"`
+	Enforce bool                   `yaml:"enforce"`
+	MaxAge  time.Duration          `yaml:"max_age"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *TenantSigningConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "auth.tenant-signing.enabled", false, "[Experimental] If enabled, the tenant ID propagated in gRPC calls between Cortex components, and in the queries forwarded from the query-frontend to queriers, is signed with HMAC-SHA256 and the signature is verified by the receiving component. The signature is bound to the request method, the request body (for queries and write requests) and the signing time.")
+	f.Var(&cfg.Keys, "auth.tenant-signing.keys", "[Experimental] Comma-separated list of keys used to sign the tenant ID. The first key is used to sign, while all keys are accepted when verifying, which allows to rotate the keys without downtime.")
+	f.BoolVar(&cfg.Enforce, "auth.tenant-signing.enforce", false, "[Experimental] If enabled, gRPC requests with a missing or invalid tenant signature are rejected. If disabled, they are only logged and counted, which allows to roll out the tenant signing.")
+	f.DurationVar(&cfg.MaxAge, "auth.tenant-signing.max-age", time.Minute, "[Experimental] Maximum age of a tenant signature, after which it's rejected to prevent replays. It must cover the clock skew between Cortex components. Queries are signed when dequeued by the query-frontend or query-scheduler, so the time spent in the queue doesn't count towards it. 0 to disable.")
+}
+
+// Validate the config.
+func (cfg *TenantSigningConfig) Validate() error {
+	if cfg.Enabled && len(cfg.Keys) == 0 {
+		return errTenantSigningKeysMissing
+	}
+	if cfg.MaxAge < 0 {
+		return errTenantSigningMaxAgeNegative
+	}
+	return nil
+}
+
+// TenantSigner signs and verifies tenant IDs.
+//
+// A signature is bound to the tenant ID, the method of the request, a digest of the request
+// body and the time it was computed at, so that it can't be replayed for a different request
+// nor after maxAge.
+type TenantSigner struct {
+	keys   [][]byte
+	maxAge time.Duration
+}
+
+// NewTenantSigner makes a new TenantSigner. The first key is used to sign.
+func NewTenantSigner(keys []string, maxAge time.Duration) *TenantSigner {
+	s := &TenantSigner{maxAge: maxAge}
+	for _, k := range keys {
+		s.keys = append(s.keys, []byte(k))
+	}
+	return s
+}
+
+// Sign returns the signature of the tenant ID for the given request method and body digest.
+func (s *TenantSigner) Sign(orgID, method string, digest []byte) string {
+	return s.sign(orgID, method, digest, time.Now())
+}
+
+func (s *TenantSigner) sign(orgID, method string, digest []byte, now time.Time) string {
+	ts := strconv.FormatInt(now.UnixMilli(), 10)
+	return ts + "." + hex.EncodeToString(signTenant(s.keys[0], orgID, method, digest, ts))
+}
+
+// Verify returns nil if the signature of the tenant ID was computed with any of the keys for
+// the given request method and body digest, and it's not older than maxAge.
+func (s *TenantSigner) Verify(orgID, method string, digest []byte, signature string) error {
+	return s.verify(orgID, method, digest, signature, time.Now())
+}
+
+func (s *TenantSigner) verify(orgID, method string, digest []byte, signature string, now time.Time) error {
+	ts, encoded, ok := strings.Cut(signature, ".")
+	if !ok {
+		return errTenantSignatureInvalid
+	}
+	sig, err := hex.DecodeString(encoded)
+	if err != nil {
+		return errTenantSignatureInvalid
+	}
+
+	valid := false
+	for _, k := range s.keys {
+		if hmac.Equal(sig, signTenant(k, orgID, method, digest, ts)) {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return errTenantSignatureInvalid
+	}
+
+	// The timestamp is covered by the signature, so it can be trusted once the signature is valid.
+	// Signatures from the future are accepted up to maxAge too, to tolerate clock skew.
+	signedAt, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errTenantSignatureInvalid
+	}
+	if age := now.Sub(time.UnixMilli(signedAt)); s.maxAge > 0 && (age > s.maxAge || age < -s.maxAge) {
+		return errTenantSignatureExpired
+	}
+	return nil
+}
+
+func signTenant(key []byte, orgID, method string, digest []byte, ts string) []byte {
+	mac := hmac.New(sha256.New, key)
+	for _, part := range [][]byte{[]byte(orgID), []byte(method), digest, []byte(ts)} {
+		// Length-prefix each part, so that their boundaries can't be shifted.
+		var size [binary.MaxVarintLen64]byte
+		_, _ = mac.Write(size[:binary.PutUvarint(size[:], uint64(len(part)))])
+		_, _ = mac.Write(part)
+	}
+	return mac.Sum(nil)
+}
+
+// SetTenantSigner sets the signer used by all gRPC clients to sign the propagated tenant ID.
+func SetTenantSigner(s *TenantSigner) {
+	tenantSigner = s
+}
+
+// UnaryTenantSigningClientInterceptor signs the tenant ID propagated in the request. The signature
+// is bound to the gRPC method and, for requests implementing SignRequest, to the request content.
+func UnaryTenantSigningClientInterceptor(s *TenantSigner) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(signOutgoingTenant(ctx, s, method, req), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamTenantSigningClientInterceptor signs the tenant ID propagated in the stream. The signature
+// is bound to the gRPC method only, because the stream messages are not known when it's opened.
+func StreamTenantSigningClientInterceptor(s *TenantSigner) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(signOutgoingTenant(ctx, s, method, nil), desc, cc, method, opts...)
+	}
+}
+
+func signOutgoingTenant(ctx context.Context, s *TenantSigner, method string, req interface{}) context.Context {
+	orgID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, tenantSignatureHeaderName, s.Sign(orgID, method, requestDigest(user.InjectOrgID(ctx, orgID), req)))
+}
+
+// requestDigest returns the digest of the request content, if the request can be deterministically
+// signed, or nil otherwise. The protobuf encoding can't be used, because it's not deterministic for maps.
+func requestDigest(ctx context.Context, req interface{}) []byte {
+	rs, ok := req.(SignRequest)
+	if !ok {
+		return nil
+	}
+	sig, err := rs.Sign(ctx)
+	if err != nil {
+		return nil
+	}
+	return []byte(sig)
+}
+
+// SignTenantHTTPRequest signs the tenant ID of a HTTP request wrapped in protobuf, binding the
+// signature to the HTTP method, URL and body. Queries are signed when dequeued and forwarded to
+// a querier, so that the time spent in the queue doesn't count towards the signature max age.
+// Any previous signature is replaced. It's a no-op if tenant signing is disabled.
+func SignTenantHTTPRequest(ctx context.Context, req *httpgrpc.HTTPRequest) {
+	if tenantSigner == nil {
+		return
+	}
+	orgID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return
+	}
+
+	headers := req.Headers[:0]
+	for _, h := range req.Headers {
+		if textproto.CanonicalMIMEHeaderKey(h.Key) != tenantHTTPSignatureHeaderName {
+			headers = append(headers, h)
+		}
+	}
+	req.Headers = append(headers, &httpgrpc.Header{
+		Key:    tenantHTTPSignatureHeaderName,
+		Values: []string{tenantSigner.Sign(orgID, httpRequestMethod(req), httpRequestDigest(req))},
+	})
+}
+
+func httpRequestMethod(req *httpgrpc.HTTPRequest) string {
+	return req.Method + " " + req.Url
+}
+
+func httpRequestDigest(req *httpgrpc.HTTPRequest) []byte {
+	digest := sha256.Sum256(req.Body)
+	return digest[:]
+}
+
+// TenantSigningVerifier verifies the signature of the tenant ID received in gRPC requests.
+type TenantSigningVerifier struct {
+	signer  *TenantSigner
+	enforce bool
+	logger  log.Logger
+
+	failures *prometheus.CounterVec
+}
+
+// NewTenantSigningVerifier makes a new TenantSigningVerifier.
+func NewTenantSigningVerifier(signer *TenantSigner, enforce bool, logger log.Logger, reg prometheus.Registerer) *TenantSigningVerifier {
+	return &TenantSigningVerifier{
+		signer:  signer,
+		enforce: enforce,
+		logger:  logger,
+		failures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_tenant_signature_verification_failures_total",
+			Help: "Total number of requests whose tenant ID signature failed the verification.",
+		}, []string{"reason"}),
+	}
+}
+
+// UnaryServerInterceptor verifies the tenant signature of unary requests.
+func (v *TenantSigningVerifier) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := v.verifyGRPC(ctx, info.FullMethod, req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// StreamServerInterceptor verifies the tenant signature of streams.
+func (v *TenantSigningVerifier) StreamServerInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := v.verifyGRPC(ss.Context(), info.FullMethod, nil); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+func (v *TenantSigningVerifier) verifyGRPC(ctx context.Context, method string, req interface{}) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil
+	}
+
+	// Requests not carrying a tenant are not subject to the verification.
+	orgIDs := md.Get(orgIDHeaderName)
+	if len(orgIDs) != 1 {
+		return nil
+	}
+
+	digest := requestDigest(user.InjectOrgID(ctx, orgIDs[0]), req)
+	if reason := v.verify(orgIDs[0], method, digest, md.Get(tenantSignatureHeaderName)); reason != "" {
+		return status.Errorf(codes.Unauthenticated, "tenant signature %s", reason)
+	}
+	return nil
+}
+
+// WrapHTTPGRPCHandler returns a handler verifying the tenant signature of the HTTP requests wrapped
+// in protobuf, as signed by SignTenantHTTPRequest, before passing them to the next handler.
+func (v *TenantSigningVerifier) WrapHTTPGRPCHandler(next HTTPGRPCHandler) HTTPGRPCHandler {
+	return httpGRPCHandlerFunc(func(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+		// Requests not carrying a tenant are not subject to the verification.
+		orgID, err := user.ExtractOrgID(ctx)
+		if err != nil {
+			return next.Handle(ctx, req)
+		}
+
+		var sigs []string
+		for _, h := range req.Headers {
+			if textproto.CanonicalMIMEHeaderKey(h.Key) == tenantHTTPSignatureHeaderName {
+				sigs = append(sigs, h.Values...)
+			}
+		}
+
+		if reason := v.verify(orgID, httpRequestMethod(req), httpRequestDigest(req), sigs); reason != "" {
+			return &httpgrpc.HTTPResponse{
+				Code: http.StatusUnauthorized,
+				Body: []byte(fmt.Sprintf("tenant signature %s", reason)),
+			}, nil
+		}
+		return next.Handle(ctx, req)
+	})
+}
+
+// verify returns the reason why the verification failed, or an empty string if the request
+// is accepted. Failures are always tracked, but only rejected if the verification is enforced.
+func (v *TenantSigningVerifier) verify(orgID, method string, digest []byte, sigs []string) string {
+	reason := ""
+	if len(sigs) != 1 {
+		reason = tenantSignatureMissing
+	} else if err := v.signer.Verify(orgID, method, digest, sigs[0]); errors.Is(err, errTenantSignatureExpired) {
+		reason = tenantSignatureExpired
+	} else if err != nil {
+		reason = tenantSignatureInvalid
+	}
+	if reason == "" {
+		return ""
+	}
+
+	v.failures.WithLabelValues(reason).Inc()
+	level.Warn(v.logger).Log("msg", "tenant signature verification failed", "method", method, "reason", reason, "enforced", v.enforce)
+
+	if v.enforce {
+		return reason
+	}
+	return ""
+}
+
+// HTTPGRPCHandler handles HTTP requests wrapped in protobuf.
+type HTTPGRPCHandler interface {
+	Handle(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+}
+
+type httpGRPCHandlerFunc func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error)
+
+func (f httpGRPCHandlerFunc) Handle(ctx context.Context, req *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+	return f(ctx, req)
+}
@@ -0,0 +1,303 @@
+package grpcclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/middleware"
+	"github.com/weaveworks/common/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantSigner(t *testing.T) {
+	oldSigner := NewTenantSigner([]string{"old"}, time.Minute)
+	rotatedSigner := NewTenantSigner([]string{"new", "old"}, time.Minute)
+
+	now := time.Now()
+	sig := oldSigner.sign("user-1", "/method", []byte("body"), now)
+	assert.NoError(t, oldSigner.verify("user-1", "/method", []byte("body"), sig, now))
+	assert.ErrorIs(t, oldSigner.verify("user-2", "/method", []byte("body"), sig, now), errTenantSignatureInvalid)
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/other", []byte("body"), sig, now), errTenantSignatureInvalid)
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/method", []byte("other"), sig, now), errTenantSignatureInvalid)
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/method", []byte("body"), "not-hex", now), errTenantSignatureInvalid)
+
+	// The timestamp can't be tampered with.
+	_, mac, _ := strings.Cut(sig, ".")
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/method", []byte("body"), "1."+mac, now), errTenantSignatureInvalid)
+
+	// Signatures are rejected once older than the max age, or too far in the future.
+	assert.NoError(t, oldSigner.verify("user-1", "/method", []byte("body"), sig, now.Add(59*time.Second)))
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/method", []byte("body"), sig, now.Add(2*time.Minute)), errTenantSignatureExpired)
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/method", []byte("body"), sig, now.Add(-2*time.Minute)), errTenantSignatureExpired)
+
+	// Signatures made with the old key are still accepted while rotating keys.
+	assert.NoError(t, rotatedSigner.verify("user-1", "/method", []byte("body"), sig, now))
+	assert.ErrorIs(t, oldSigner.verify("user-1", "/method", []byte("body"), rotatedSigner.sign("user-1", "/method", []byte("body"), now), now), errTenantSignatureInvalid)
+}
+
+func TestTenantSigningVerifier(t *testing.T) {
+	signer := NewTenantSigner([]string{"key"}, time.Minute)
+
+	// outgoingToIncoming propagates the tenant through the client interceptors and returns
+	// the context as received by the server.
+	outgoingToIncoming := func(t *testing.T, ctx context.Context, sign bool, req interface{}) context.Context {
+		interceptors := []grpc.UnaryClientInterceptor{middleware.ClientUserHeaderInterceptor}
+		if sign {
+			interceptors = append(interceptors, UnaryTenantSigningClientInterceptor(signer))
+		}
+
+		for _, interceptor := range interceptors {
+			require.NoError(t, interceptor(ctx, "/method", req, nil, nil, func(c context.Context, _ string, _, _ interface{}, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+				ctx = c
+				return nil
+			}))
+		}
+
+		md, _ := metadata.FromOutgoingContext(ctx)
+		return metadata.NewIncomingContext(context.Background(), md)
+	}
+
+	tests := map[string]struct {
+		ctx             func(t *testing.T) context.Context
+		method          string
+		req             interface{}
+		enforce         bool
+		expectedCode    codes.Code
+		expectedFailure string
+	}{
+		"signed tenant": {
+			ctx: func(t *testing.T) context.Context {
+				return outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), true, nil)
+			},
+			enforce: true,
+		},
+		"no tenant": {
+			ctx: func(t *testing.T) context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.MD{})
+			},
+			enforce: true,
+		},
+		"unsigned tenant, enforced": {
+			ctx: func(t *testing.T) context.Context {
+				return outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), false, nil)
+			},
+			enforce:         true,
+			expectedCode:    codes.Unauthenticated,
+			expectedFailure: tenantSignatureMissing,
+		},
+		"unsigned tenant, not enforced": {
+			ctx: func(t *testing.T) context.Context {
+				return outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), false, nil)
+			},
+			expectedFailure: tenantSignatureMissing,
+		},
+		"spoofed tenant": {
+			ctx: func(t *testing.T) context.Context {
+				ctx := outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), true, nil)
+				md, _ := metadata.FromIncomingContext(ctx)
+				md.Set(orgIDHeaderName, "user-2")
+				return metadata.NewIncomingContext(ctx, md)
+			},
+			enforce:         true,
+			expectedCode:    codes.Unauthenticated,
+			expectedFailure: tenantSignatureInvalid,
+		},
+		"signature replayed for a different method": {
+			ctx: func(t *testing.T) context.Context {
+				return outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), true, nil)
+			},
+			method:          "/other",
+			enforce:         true,
+			expectedCode:    codes.Unauthenticated,
+			expectedFailure: tenantSignatureInvalid,
+		},
+		"signed request": {
+			ctx: func(t *testing.T) context.Context {
+				return outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), true, &mockSignRequest{content: "body"})
+			},
+			req:     &mockSignRequest{content: "body"},
+			enforce: true,
+		},
+		"signature replayed for a different request": {
+			ctx: func(t *testing.T) context.Context {
+				return outgoingToIncoming(t, user.InjectOrgID(context.Background(), "user-1"), true, &mockSignRequest{content: "body"})
+			},
+			req:             &mockSignRequest{content: "other"},
+			enforce:         true,
+			expectedCode:    codes.Unauthenticated,
+			expectedFailure: tenantSignatureInvalid,
+		},
+		"expired signature": {
+			ctx: func(t *testing.T) context.Context {
+				sig := signer.sign("user-1", "/method", nil, time.Now().Add(-2*time.Minute))
+				return metadata.NewIncomingContext(context.Background(), metadata.Pairs(orgIDHeaderName, "user-1", tenantSignatureHeaderName, sig))
+			},
+			enforce:         true,
+			expectedCode:    codes.Unauthenticated,
+			expectedFailure: tenantSignatureExpired,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			verifier := NewTenantSigningVerifier(signer, testData.enforce, log.NewNopLogger(), reg)
+
+			method := testData.method
+			if method == "" {
+				method = "/method"
+			}
+
+			called := false
+			_, err := verifier.UnaryServerInterceptor(testData.ctx(t), testData.req, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, interface{}) (interface{}, error) {
+				called = true
+				return nil, nil
+			})
+
+			if testData.expectedCode != codes.OK {
+				require.Error(t, err)
+				assert.Equal(t, testData.expectedCode, status.Code(err))
+				assert.False(t, called)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, called)
+			}
+
+			expectedMetrics := ""
+			if testData.expectedFailure != "" {
+				expectedMetrics = `
+					# HELP cortex_tenant_signature_verification_failures_total Total number of requests whose tenant ID signature failed the verification.
+					# TYPE cortex_tenant_signature_verification_failures_total counter
+					cortex_tenant_signature_verification_failures_total{reason="` + testData.expectedFailure + `"} 1
+				`
+			}
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_tenant_signature_verification_failures_total"))
+		})
+	}
+}
+
+func TestTenantSigningVerifier_WrapHTTPGRPCHandler(t *testing.T) {
+	signer := NewTenantSigner([]string{"key"}, time.Minute)
+	SetTenantSigner(signer)
+	t.Cleanup(func() { SetTenantSigner(nil) })
+
+	signedRequest := func(orgID string) *httpgrpc.HTTPRequest {
+		req := &httpgrpc.HTTPRequest{Method: "POST", Url: "/api/v1/query", Body: []byte("query=up")}
+		SignTenantHTTPRequest(user.InjectOrgID(context.Background(), orgID), req)
+		return req
+	}
+
+	tests := map[string]struct {
+		req             func() *httpgrpc.HTTPRequest
+		enforce         bool
+		expectedCode    int32
+		expectedFailure string
+	}{
+		"signed request": {
+			req:          func() *httpgrpc.HTTPRequest { return signedRequest("user-1") },
+			enforce:      true,
+			expectedCode: http.StatusOK,
+		},
+		"signed request, signed again": {
+			req: func() *httpgrpc.HTTPRequest {
+				req := signedRequest("user-1")
+				SignTenantHTTPRequest(user.InjectOrgID(context.Background(), "user-1"), req)
+				return req
+			},
+			enforce:      true,
+			expectedCode: http.StatusOK,
+		},
+		"unsigned request, enforced": {
+			req: func() *httpgrpc.HTTPRequest {
+				return &httpgrpc.HTTPRequest{Method: "POST", Url: "/api/v1/query", Body: []byte("query=up")}
+			},
+			enforce:         true,
+			expectedCode:    http.StatusUnauthorized,
+			expectedFailure: tenantSignatureMissing,
+		},
+		"unsigned request, not enforced": {
+			req: func() *httpgrpc.HTTPRequest {
+				return &httpgrpc.HTTPRequest{Method: "POST", Url: "/api/v1/query", Body: []byte("query=up")}
+			},
+			expectedCode:    http.StatusOK,
+			expectedFailure: tenantSignatureMissing,
+		},
+		"signature of another tenant": {
+			req:             func() *httpgrpc.HTTPRequest { return signedRequest("user-2") },
+			enforce:         true,
+			expectedCode:    http.StatusUnauthorized,
+			expectedFailure: tenantSignatureInvalid,
+		},
+		"tampered body": {
+			req: func() *httpgrpc.HTTPRequest {
+				req := signedRequest("user-1")
+				req.Body = []byte("query=secret")
+				return req
+			},
+			enforce:         true,
+			expectedCode:    http.StatusUnauthorized,
+			expectedFailure: tenantSignatureInvalid,
+		},
+		"tampered URL": {
+			req: func() *httpgrpc.HTTPRequest {
+				req := signedRequest("user-1")
+				req.Url = "/api/v1/series"
+				return req
+			},
+			enforce:         true,
+			expectedCode:    http.StatusUnauthorized,
+			expectedFailure: tenantSignatureInvalid,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewPedanticRegistry()
+			verifier := NewTenantSigningVerifier(signer, testData.enforce, log.NewNopLogger(), reg)
+
+			handler := verifier.WrapHTTPGRPCHandler(httpGRPCHandlerFunc(func(context.Context, *httpgrpc.HTTPRequest) (*httpgrpc.HTTPResponse, error) {
+				return &httpgrpc.HTTPResponse{Code: http.StatusOK}, nil
+			}))
+
+			resp, err := handler.Handle(user.InjectOrgID(context.Background(), "user-1"), testData.req())
+			require.NoError(t, err)
+			assert.Equal(t, testData.expectedCode, resp.Code)
+
+			expectedMetrics := ""
+			if testData.expectedFailure != "" {
+				expectedMetrics = `
+					# HELP cortex_tenant_signature_verification_failures_total Total number of requests whose tenant ID signature failed the verification.
+					# TYPE cortex_tenant_signature_verification_failures_total counter
+					cortex_tenant_signature_verification_failures_total{reason="` + testData.expectedFailure + `"} 1
+				`
+			}
+			assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expectedMetrics), "cortex_tenant_signature_verification_failures_total"))
+		})
+	}
+}
+
+type mockSignRequest struct {
+	content string
+}
+
+func (r *mockSignRequest) Sign(ctx context.Context) (string, error) {
+	orgID, err := user.ExtractOrgID(ctx)
+	return orgID + "/" + r.content, err
+}
+
+func (r *mockSignRequest) VerifySign(ctx context.Context, signature string) (bool, error) {
+	s, err := r.Sign(ctx)
+	return s == signature, err
+}
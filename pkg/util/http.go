@@ -10,11 +10,15 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/gogo/protobuf/proto"
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
 	"github.com/opentracing/opentracing-go"
 	otlog "github.com/opentracing/opentracing-go/log"
 	yaml "gopkg.in/yaml.v2"
@@ -143,6 +147,16 @@ type CompressionType int
 const (
 	NoCompression CompressionType = iota
 	RawSnappy
+	Gzip
+	// Deflate is the zlib format, as per the HTTP "deflate" content encoding.
+	Deflate
+	Zstd
+)
+
+var (
+	gzipReaderPool  sync.Pool
+	zlibReaderPool  sync.Pool
+	zstdDecoderPool sync.Pool
 )
 
 // ParseProtoReader parses a compressed proto from an io.Reader.
@@ -209,12 +223,12 @@ func decompressFromReader(reader io.Reader, expectedSize, maxSize int, compressi
 	case NoCompression:
 		_, err = buf.ReadFrom(reader)
 		body = buf.Bytes()
-	case RawSnappy:
+	case RawSnappy, Gzip, Deflate, Zstd:
 		_, err = buf.ReadFrom(reader)
 		if err != nil {
 			return nil, err
 		}
-		body, err = decompressFromBuffer(&buf, maxSize, RawSnappy, sp)
+		body, err = decompressFromBuffer(&buf, maxSize, compression, sp)
 	}
 	return body, err
 }
@@ -243,10 +257,71 @@ func decompressFromBuffer(buffer *bytes.Buffer, maxSize int, compression Compres
 			return nil, err
 		}
 		return body, nil
+	case Gzip, Deflate, Zstd:
+		if sp != nil {
+			sp.LogFields(otlog.String("event", "util.ParseProtoRequest[decompress]"),
+				otlog.Int("size", len(buffer.Bytes())))
+		}
+		return decompressStream(bytes.NewReader(buffer.Bytes()), maxSize, compression)
 	}
 	return nil, nil
 }
 
+// decompressStream decompresses a gzip, zlib or zstd stream, using pooled decompressors. Unlike snappy,
+// the decompressed size is not known in advance, so the decompression stops once it exceeds maxSize.
+func decompressStream(reader io.Reader, maxSize int, compression CompressionType) ([]byte, error) {
+	var decompressed io.Reader
+
+	switch compression {
+	case Gzip:
+		gr, ok := gzipReaderPool.Get().(*gzip.Reader)
+		if !ok {
+			gr = &gzip.Reader{}
+		}
+		if err := gr.Reset(reader); err != nil {
+			return nil, err
+		}
+		defer gzipReaderPool.Put(gr)
+		decompressed = gr
+	case Deflate:
+		zr, ok := zlibReaderPool.Get().(io.ReadCloser)
+		if !ok {
+			var err error
+			if zr, err = zlib.NewReader(reader); err != nil {
+				return nil, err
+			}
+		} else if err := zr.(zlib.Resetter).Reset(reader, nil); err != nil {
+			return nil, err
+		}
+		defer zlibReaderPool.Put(zr)
+		decompressed = zr
+	case Zstd:
+		dec, ok := zstdDecoderPool.Get().(*zstd.Decoder)
+		if !ok {
+			var err error
+			if dec, err = zstd.NewReader(nil, zstd.WithDecoderConcurrency(1)); err != nil {
+				return nil, err
+			}
+		}
+		if err := dec.Reset(reader); err != nil {
+			return nil, err
+		}
+		defer zstdDecoderPool.Put(dec)
+		decompressed = dec
+	default:
+		return nil, fmt.Errorf("unsupported compression type %d", compression)
+	}
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(io.LimitReader(decompressed, int64(maxSize)+1)); err != nil {
+		return nil, err
+	}
+	if buf.Len() > maxSize {
+		return nil, fmt.Errorf(messageSizeLargerErrFmt, buf.Len(), maxSize)
+	}
+	return buf.Bytes(), nil
+}
+
 // tryBufferFromReader attempts to cast the reader to a `*bytes.Buffer` this is possible when using httpgrpc.
 // If it fails it will return nil and false.
 func tryBufferFromReader(reader io.Reader) (*bytes.Buffer, bool) {
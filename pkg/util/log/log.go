@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -20,6 +21,10 @@ const (
 	headerMapContextKey contextKey = 0
 
 	HeaderPropagationStringForRequestLogging string = "x-http-header-forwarding-logging"
+
+	// QueryIDHeaderName is the HTTP header carrying the ID of a query. It's propagated and logged
+	// alongside the HTTP request headers to log.
+	QueryIDHeaderName = "X-Cortex-Query-Id"
 )
 
 var (
@@ -160,6 +165,33 @@ func InjectHeadersIntoHTTPRequest(headerMap map[string]string, request *http.Req
 	}
 }
 
+// ContextWithQueryID returns a context with the query ID added to the header map.
+func ContextWithQueryID(ctx context.Context, queryID string) context.Context {
+	parent := HeaderMapFromContext(ctx)
+	headerMap := make(map[string]string, len(parent)+1)
+	for header, contents := range parent {
+		headerMap[header] = contents
+	}
+	headerMap[QueryIDHeaderName] = queryID
+	return ContextWithHeaderMap(ctx, headerMap)
+}
+
+// QueryIDFromContext returns the query ID from the header map, or an empty string if there's none.
+func QueryIDFromContext(ctx context.Context) string {
+	return HeaderMapFromContext(ctx)[QueryIDHeaderName]
+}
+
+// ContextWithChildQueryID returns a context with the ID of the idx-th subrequest of the query in the
+// context. The child ID is derived from the query ID, so that it's stable and the subrequests of
+// a query can be correlated in the logs. The context is returned as is if it has no query ID.
+func ContextWithChildQueryID(ctx context.Context, idx int) context.Context {
+	queryID := QueryIDFromContext(ctx)
+	if queryID == "" {
+		return ctx
+	}
+	return ContextWithQueryID(ctx, queryID+"."+strconv.Itoa(idx))
+}
+
 func ContextWithHeaderMapFromMetadata(ctx context.Context, md metadata.MD) context.Context {
 	headersSlice, ok := md[HeaderPropagationStringForRequestLogging]
 	if !ok || len(headersSlice)%2 == 1 {
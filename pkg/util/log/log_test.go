@@ -99,3 +99,20 @@ func BenchmarkDisallowedLogLevels(b *testing.B) {
 		level.Debug(Logger).Log("hello", "world", "number", i)
 	}
 }
+
+func TestContextWithChildQueryID(t *testing.T) {
+	// The context is left untouched if there's no query ID.
+	ctx := context.Background()
+	require.Equal(t, ctx, ContextWithChildQueryID(ctx, 1))
+
+	ctx = ContextWithHeaderMap(ctx, map[string]string{"TestHeader": "SomeInformation"})
+	ctx = ContextWithQueryID(ctx, "abc")
+
+	child := ContextWithChildQueryID(ctx, 2)
+	grandchild := ContextWithChildQueryID(child, 1)
+
+	require.Equal(t, "abc", QueryIDFromContext(ctx))
+	require.Equal(t, "abc.2", QueryIDFromContext(child))
+	require.Equal(t, "abc.2.1", QueryIDFromContext(grandchild))
+	require.Equal(t, "SomeInformation", HeaderMapFromContext(grandchild)["TestHeader"])
+}
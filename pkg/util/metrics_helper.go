@@ -300,6 +300,17 @@ func (d MetricFamiliesPerUser) SendSumOfHistograms(out chan<- prometheus.Metric,
 	out <- hd.Metric(desc)
 }
 
+func (d MetricFamiliesPerUser) SendSumOfHistogramsPerUser(out chan<- prometheus.Metric, desc *prometheus.Desc, histogramName string) {
+	for _, userEntry := range d {
+		if userEntry.user == "" {
+			continue
+		}
+
+		data := userEntry.metrics.SumHistograms(histogramName)
+		out <- data.Metric(desc, userEntry.user)
+	}
+}
+
 func (d MetricFamiliesPerUser) SendSumOfHistogramsWithLabels(out chan<- prometheus.Metric, desc *prometheus.Desc, histogramName string, labelNames ...string) {
 	type histogramResult struct {
 		data        HistogramData
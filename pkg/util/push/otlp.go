@@ -14,12 +14,16 @@ import (
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/tenant"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/cortexproject/cortex/pkg/util/log"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
-// OTLPHandler is a http.Handler which accepts OTLP metrics.
-func OTLPHandler(sourceIPs *middleware.SourceIPExtractor, push Func) http.Handler {
+// OTLPHandler is a http.Handler which accepts OTLP metrics. The exponential histograms are
+// converted to Prometheus native histograms, and the resource attributes of the metrics
+// promoted to labels according to the tenant's limits.
+func OTLPHandler(overrides *validation.Overrides, sourceIPs *middleware.SourceIPExtractor, push Func) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := log.WithContext(ctx, log.Logger)
@@ -30,6 +34,12 @@ func OTLPHandler(sourceIPs *middleware.SourceIPExtractor, push Func) http.Handle
 				logger = log.WithSourceIPs(source, logger)
 			}
 		}
+		userID, err := tenant.TenantID(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		req, err := remote.DecodeOTLPWriteRequest(r)
 		if err != nil {
 			level.Error(logger).Log("err", err.Error())
@@ -38,7 +48,7 @@ func OTLPHandler(sourceIPs *middleware.SourceIPExtractor, push Func) http.Handle
 		}
 
 		promConverter := prometheusremotewrite.NewPrometheusConverter()
-		err = promConverter.FromMetrics(convertToMetricsAttributes(req.Metrics()), prometheusremotewrite.Settings{DisableTargetInfo: true})
+		err = promConverter.FromMetrics(convertToMetricsAttributes(req.Metrics(), overrides.PromoteResourceAttributes(userID)), prometheusremotewrite.Settings{DisableTargetInfo: true})
 		if err != nil {
 			level.Error(logger).Log("err", err.Error())
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -54,9 +64,10 @@ func OTLPHandler(sourceIPs *middleware.SourceIPExtractor, push Func) http.Handle
 		tsList := []cortexpb.PreallocTimeseries(nil)
 		for _, v := range promConverter.TimeSeries() {
 			tsList = append(tsList, cortexpb.PreallocTimeseries{TimeSeries: &cortexpb.TimeSeries{
-				Labels:    makeLabels(v.Labels),
-				Samples:   makeSamples(v.Samples),
-				Exemplars: makeExemplars(v.Exemplars),
+				Labels:     makeLabels(v.Labels),
+				Samples:    makeSamples(v.Samples),
+				Exemplars:  makeExemplars(v.Exemplars),
+				Histograms: makeHistograms(v.Histograms),
 			}})
 		}
 		prwReq.Timeseries = tsList
@@ -96,6 +107,19 @@ func makeSamples(in []prompb.Sample) []cortexpb.Sample {
 	return out
 }
 
+// makeHistograms converts the native histograms translated from the OTLP exponential histograms.
+func makeHistograms(in []prompb.Histogram) []cortexpb.Histogram {
+	out := make([]cortexpb.Histogram, 0, len(in))
+	for _, h := range in {
+		if h.IsFloatHistogram() {
+			out = append(out, cortexpb.FloatHistogramToHistogramProto(h.Timestamp, remote.FloatHistogramProtoToFloatHistogram(h)))
+			continue
+		}
+		out = append(out, cortexpb.HistogramToHistogramProto(h.Timestamp, remote.HistogramProtoToHistogram(h)))
+	}
+	return out
+}
+
 func makeExemplars(in []prompb.Exemplar) []cortexpb.Exemplar {
 	out := make([]cortexpb.Exemplar, 0, len(in))
 	for _, e := range in {
@@ -108,25 +132,43 @@ func makeExemplars(in []prompb.Exemplar) []cortexpb.Exemplar {
 	return out
 }
 
-func convertToMetricsAttributes(md pmetric.Metrics) pmetric.Metrics {
+// convertToMetricsAttributes returns a copy of the input metrics with the resource attributes
+// copied to the attributes of the data points, so that they're converted to labels. Only the
+// promoted resource attributes are copied, or all of them if none is promoted.
+func convertToMetricsAttributes(md pmetric.Metrics, promotedAttributes []string) pmetric.Metrics {
 	cloneMd := pmetric.NewMetrics()
 	md.CopyTo(cloneMd)
 	rms := cloneMd.ResourceMetrics()
 	for i := 0; i < rms.Len(); i++ {
-		resource := rms.At(i).Resource()
+		attributes := promoteResourceAttributes(rms.At(i).Resource().Attributes(), promotedAttributes)
 
 		ilms := rms.At(i).ScopeMetrics()
 		for j := 0; j < ilms.Len(); j++ {
 			ilm := ilms.At(j)
 			metricSlice := ilm.Metrics()
 			for k := 0; k < metricSlice.Len(); k++ {
-				addAttributesToMetric(metricSlice.At(k), resource.Attributes())
+				addAttributesToMetric(metricSlice.At(k), attributes)
 			}
 		}
 	}
 	return cloneMd
 }
 
+// promoteResourceAttributes returns the resource attributes to promote to labels.
+func promoteResourceAttributes(attributes pcommon.Map, promotedAttributes []string) pcommon.Map {
+	if len(promotedAttributes) == 0 {
+		return attributes
+	}
+
+	promoted := pcommon.NewMap()
+	for _, name := range promotedAttributes {
+		if v, ok := attributes.Get(name); ok {
+			v.CopyTo(promoted.PutEmpty(name))
+		}
+	}
+	return promoted
+}
+
 // addAttributesToMetric adds additional labels to the given metric
 func addAttributesToMetric(metric pmetric.Metric, labelMap pcommon.Map) {
 	switch metric.Type() {
@@ -8,13 +8,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 	"go.opentelemetry.io/collector/pdata/pmetric/pmetricotlp"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	"github.com/cortexproject/cortex/pkg/util/flagext"
+	"github.com/cortexproject/cortex/pkg/util/validation"
 )
 
 func TestOTLPWriteHandler(t *testing.T) {
@@ -26,9 +30,10 @@ func TestOTLPWriteHandler(t *testing.T) {
 	req, err := http.NewRequest("", "", bytes.NewReader(buf))
 	require.NoError(t, err)
 	req.Header.Set("Content-Type", "application/x-protobuf")
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
 
 	push := verifyOTLPWriteRequestHandler(t, cortexpb.API)
-	handler := OTLPHandler(nil, push)
+	handler := OTLPHandler(newTestOverrides(t, nil), nil, push)
 
 	recorder := httptest.NewRecorder()
 	handler.ServeHTTP(recorder, req)
@@ -37,6 +42,112 @@ func TestOTLPWriteHandler(t *testing.T) {
 	require.Equal(t, http.StatusOK, resp.StatusCode)
 }
 
+func TestOTLPWriteHandler_ShouldFailWithoutTenant(t *testing.T) {
+	buf, err := generateOTLPWriteRequest(t).MarshalProto()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("", "", bytes.NewReader(buf))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	recorder := httptest.NewRecorder()
+	OTLPHandler(newTestOverrides(t, nil), nil, verifyOTLPWriteRequestHandler(t, cortexpb.API)).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestOTLPWriteHandler_ExponentialHistograms(t *testing.T) {
+	buf, err := generateOTLPWriteRequest(t).MarshalProto()
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("", "", bytes.NewReader(buf))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+	var pushed *cortexpb.WriteRequest
+	push := func(_ context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		pushed = req
+		return &cortexpb.WriteResponse{}, nil
+	}
+
+	recorder := httptest.NewRecorder()
+	OTLPHandler(newTestOverrides(t, nil), nil, push).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var histograms []cortexpb.Histogram
+	for _, ts := range pushed.Timeseries {
+		if cortexpb.FromLabelAdaptersToLabels(ts.Labels).Get(labels.MetricName) == "test_exponential_histogram" {
+			assert.Empty(t, ts.Samples)
+			histograms = append(histograms, ts.Histograms...)
+		}
+	}
+
+	// The exponential histogram should be converted to a native histogram.
+	require.Len(t, histograms, 1)
+	h := cortexpb.HistogramProtoToHistogram(histograms[0])
+	assert.Equal(t, int32(2), h.Schema)
+	assert.Equal(t, uint64(10), h.Count)
+	assert.Equal(t, uint64(2), h.ZeroCount)
+	assert.Equal(t, 30.0, h.Sum)
+}
+
+func TestOTLPWriteHandler_PromoteResourceAttributes(t *testing.T) {
+	tests := map[string]struct {
+		promoteResourceAttributes []string
+		expectedLabels            map[string]string
+		unexpectedLabels          []string
+	}{
+		"should promote all the resource attributes by default": {
+			expectedLabels: map[string]string{"service_name": "test-service", "service_instance_id": "test-instance", "host_name": "test-host"},
+		},
+		"should only promote the configured resource attributes": {
+			promoteResourceAttributes: []string{"host.name", "unknown"},
+			expectedLabels:            map[string]string{"host_name": "test-host"},
+			unexpectedLabels:          []string{"service_name", "service_instance_id", "unknown"},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			buf, err := generateOTLPWriteRequest(t).MarshalProto()
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("", "", bytes.NewReader(buf))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/x-protobuf")
+			req = req.WithContext(user.InjectOrgID(req.Context(), "user-1"))
+
+			push := func(_ context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+				require.NotEmpty(t, req.Timeseries)
+				for _, ts := range req.Timeseries {
+					lbls := cortexpb.FromLabelAdaptersToLabels(ts.Labels)
+					for name, value := range testData.expectedLabels {
+						assert.Equal(t, value, lbls.Get(name))
+					}
+					for _, name := range testData.unexpectedLabels {
+						assert.False(t, lbls.Has(name))
+					}
+				}
+				return &cortexpb.WriteResponse{}, nil
+			}
+
+			recorder := httptest.NewRecorder()
+			OTLPHandler(newTestOverrides(t, testData.promoteResourceAttributes), nil, push).ServeHTTP(recorder, req)
+			require.Equal(t, http.StatusOK, recorder.Code)
+		})
+	}
+}
+
+func newTestOverrides(t *testing.T, promoteResourceAttributes []string) *validation.Overrides {
+	limits := validation.Limits{}
+	flagext.DefaultValues(&limits)
+	limits.PromoteResourceAttributes = promoteResourceAttributes
+
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+	return overrides
+}
+
 func generateOTLPWriteRequest(t *testing.T) pmetricotlp.ExportRequest {
 	d := pmetric.NewMetrics()
 
@@ -2,9 +2,15 @@ package push
 
 import (
 	"context"
+	"fmt"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 
@@ -13,11 +19,76 @@ import (
 	"github.com/cortexproject/cortex/pkg/util/log"
 )
 
+var (
+	// compressionByEncoding is the compression of the push request body for each supported content encoding.
+	// Requests without content encoding are expected to be snappy compressed, as per the remote write protocol.
+	compressionByEncoding = map[string]util.CompressionType{
+		"":        util.RawSnappy,
+		"snappy":  util.RawSnappy,
+		"gzip":    util.Gzip,
+		"deflate": util.Deflate,
+		"zstd":    util.Zstd,
+	}
+)
+
+const (
+	// The protobuf messages of the remote write 1.0 and 2.0 protocols, negotiated with the proto
+	// parameter of the Content-Type header.
+	remoteWriteV1ProtoMsg = "prometheus.WriteRequest"
+	remoteWriteV2ProtoMsg = "io.prometheus.write.v2.Request"
+
+	// The headers of the remote write 2.0 responses, with the number of samples, histograms and
+	// exemplars written.
+	samplesWrittenHeader    = "X-Prometheus-Remote-Write-Samples-Written"
+	histogramsWrittenHeader = "X-Prometheus-Remote-Write-Histograms-Written"
+	exemplarsWrittenHeader  = "X-Prometheus-Remote-Write-Exemplars-Written"
+)
+
+// IdempotencyKeyHeader is the header of the push requests carrying a key identifying the request,
+// which the distributors use to deduplicate the retries of a request already pushed successfully.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+type contextKey int
+
+const idempotencyKey contextKey = 0
+
+// ContextWithIdempotencyKey returns a new context carrying the idempotency key of the push request.
+func ContextWithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKey, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of the push request, or an empty string.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKey).(string)
+	return key
+}
+
+// HandlerMetrics holds the metrics of the push handlers. They're shared by all the
+// handlers registered by a process.
+type HandlerMetrics struct {
+	requestsByEncoding *prometheus.CounterVec
+	decodeFailures     *prometheus.CounterVec
+}
+
+// NewHandlerMetrics makes a new HandlerMetrics.
+func NewHandlerMetrics(reg prometheus.Registerer) *HandlerMetrics {
+	return &HandlerMetrics{
+		requestsByEncoding: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_push_requests_by_encoding_total",
+			Help: "Total number of push requests received, by content encoding.",
+		}, []string{"encoding"}),
+		decodeFailures: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Name: "cortex_push_request_decode_failures_total",
+			Help: "Total number of push requests which failed to be decoded, by content encoding.",
+		}, []string{"encoding"}),
+	}
+}
+
 // Func defines the type of the push. It is similar to http.HandlerFunc.
 type Func func(context.Context, *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error)
 
 // Handler is a http.Handler which accepts WriteRequests.
-func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push Func) http.Handler {
+func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push Func, metrics *HandlerMetrics) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 		logger := log.WithContext(ctx, log.Logger)
@@ -28,9 +99,34 @@ func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push F
 				logger = log.WithSourceIPs(source, logger)
 			}
 		}
-		var req cortexpb.PreallocWriteRequest
-		err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, &req, util.RawSnappy)
+		if key := r.Header.Get(IdempotencyKeyHeader); key != "" {
+			ctx = ContextWithIdempotencyKey(ctx, key)
+		}
+		encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		compression, ok := compressionByEncoding[encoding]
+		if !ok {
+			http.Error(w, fmt.Sprintf("unsupported content encoding %q", encoding), http.StatusUnsupportedMediaType)
+			return
+		}
+		if encoding == "" {
+			encoding = "snappy"
+		}
+		metrics.requestsByEncoding.WithLabelValues(encoding).Inc()
+
+		protoMsg, err := remoteWriteProtoMsg(r.Header.Get("Content-Type"))
 		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var req *cortexpb.WriteRequest
+		if protoMsg == remoteWriteV2ProtoMsg {
+			req, err = parseWriteRequestV2(ctx, r, maxRecvMsgSize, compression)
+		} else {
+			req, err = parseWriteRequest(ctx, r, maxRecvMsgSize, compression)
+		}
+		if err != nil {
+			metrics.decodeFailures.WithLabelValues(encoding).Inc()
 			level.Error(logger).Log("err", err.Error())
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -41,18 +137,61 @@ func Handler(maxRecvMsgSize int, sourceIPs *middleware.SourceIPExtractor, push F
 			req.Source = cortexpb.API
 		}
 
-		if _, err := push(ctx, &req.WriteRequest); err != nil {
-			resp, ok := httpgrpc.HTTPResponseFromError(err)
+		resp, err := push(ctx, req)
+		if protoMsg == remoteWriteV2ProtoMsg {
+			// The written stats are the samples accepted by the push, which are also reported for
+			// the requests partially rejected.
+			w.Header().Set(samplesWrittenHeader, strconv.FormatInt(resp.GetSamplesWritten(), 10))
+			w.Header().Set(histogramsWrittenHeader, strconv.FormatInt(resp.GetHistogramsWritten(), 10))
+			w.Header().Set(exemplarsWrittenHeader, strconv.FormatInt(resp.GetExemplarsWritten(), 10))
+		}
+		if err != nil {
+			httpResp, ok := httpgrpc.HTTPResponseFromError(err)
 			if !ok {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			if resp.GetCode()/100 == 5 {
+			if httpResp.GetCode()/100 == 5 {
 				level.Error(logger).Log("msg", "push error", "err", err)
-			} else if resp.GetCode() != http.StatusAccepted && resp.GetCode() != http.StatusTooManyRequests {
+			} else if httpResp.GetCode() != http.StatusAccepted && httpResp.GetCode() != http.StatusTooManyRequests {
 				level.Warn(logger).Log("msg", "push refused", "err", err)
 			}
-			http.Error(w, string(resp.Body), int(resp.Code))
+			http.Error(w, string(httpResp.Body), int(httpResp.Code))
+			return
 		}
 	})
 }
+
+// remoteWriteProtoMsg returns the protobuf message of the push request negotiated with the proto
+// parameter of its content type. The requests without it are remote write 1.0, as the clients
+// predating the remote write 2.0 protocol don't always set the content type.
+func remoteWriteProtoMsg(contentType string) (string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || mediaType != "application/x-protobuf" {
+		return remoteWriteV1ProtoMsg, nil
+	}
+	switch protoMsg := params["proto"]; protoMsg {
+	case "", remoteWriteV1ProtoMsg:
+		return remoteWriteV1ProtoMsg, nil
+	case remoteWriteV2ProtoMsg:
+		return remoteWriteV2ProtoMsg, nil
+	default:
+		return "", fmt.Errorf("unsupported remote write protobuf message %q", protoMsg)
+	}
+}
+
+func parseWriteRequest(ctx context.Context, r *http.Request, maxRecvMsgSize int, compression util.CompressionType) (*cortexpb.WriteRequest, error) {
+	var req cortexpb.PreallocWriteRequest
+	if err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, &req, compression); err != nil {
+		return nil, err
+	}
+	return &req.WriteRequest, nil
+}
+
+func parseWriteRequestV2(ctx context.Context, r *http.Request, maxRecvMsgSize int, compression util.CompressionType) (*cortexpb.WriteRequest, error) {
+	var req cortexpb.WriteRequestV2
+	if err := util.ParseProtoReader(ctx, r.Body, int(r.ContentLength), maxRecvMsgSize, &req, compression); err != nil {
+		return nil, err
+	}
+	return req.ToWriteRequest(cortexpb.API)
+}
@@ -5,13 +5,20 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zlib"
+	"github.com/klauspost/compress/zstd"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/prompb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
 	"github.com/weaveworks/common/middleware"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
@@ -20,16 +27,108 @@ import (
 func TestHandler_remoteWrite(t *testing.T) {
 	req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
 	resp := httptest.NewRecorder()
-	handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.API))
+	handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.API), NewHandlerMetrics(nil))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, 200, resp.Code)
 }
 
+func TestHandler_remoteWriteContentEncodings(t *testing.T) {
+	protobuf := createPrometheusRemoteWriteProtobuf(t)
+
+	tests := map[string]struct {
+		encode       func(t *testing.T, data []byte) []byte
+		expectedCode int
+	}{
+		"snappy": {
+			encode:       func(_ *testing.T, data []byte) []byte { return snappy.Encode(nil, data) },
+			expectedCode: http.StatusOK,
+		},
+		"gzip": {
+			encode: func(t *testing.T, data []byte) []byte {
+				var buf bytes.Buffer
+				w := gzip.NewWriter(&buf)
+				_, err := w.Write(data)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+				return buf.Bytes()
+			},
+			expectedCode: http.StatusOK,
+		},
+		"deflate": {
+			encode: func(t *testing.T, data []byte) []byte {
+				var buf bytes.Buffer
+				w := zlib.NewWriter(&buf)
+				_, err := w.Write(data)
+				require.NoError(t, err)
+				require.NoError(t, w.Close())
+				return buf.Bytes()
+			},
+			expectedCode: http.StatusOK,
+		},
+		"zstd": {
+			encode: func(t *testing.T, data []byte) []byte {
+				enc, err := zstd.NewWriter(nil)
+				require.NoError(t, err)
+				defer enc.Close()
+				return enc.EncodeAll(data, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		"br": {
+			encode:       func(_ *testing.T, data []byte) []byte { return data },
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for encoding, testData := range tests {
+		t.Run(encoding, func(t *testing.T) {
+			metrics := NewHandlerMetrics(prometheus.NewPedanticRegistry())
+			handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.API), metrics)
+
+			// Run twice to exercise the pooled decompressors.
+			for i := 0; i < 2; i++ {
+				req, err := http.NewRequest("POST", "http://localhost/", bytes.NewReader(testData.encode(t, protobuf)))
+				require.NoError(t, err)
+				req.Header.Set("Content-Encoding", encoding)
+
+				resp := httptest.NewRecorder()
+				handler.ServeHTTP(resp, req)
+				assert.Equal(t, testData.expectedCode, resp.Code)
+			}
+
+			if testData.expectedCode == http.StatusOK {
+				assert.Equal(t, float64(2), testutil.ToFloat64(metrics.requestsByEncoding.WithLabelValues(encoding)))
+				assert.Equal(t, float64(0), testutil.ToFloat64(metrics.decodeFailures.WithLabelValues(encoding)))
+			}
+		})
+	}
+}
+
+func TestHandler_remoteWriteShouldRejectDecompressedBodyLargerThanMaxSize(t *testing.T) {
+	protobuf := createPrometheusRemoteWriteProtobuf(t)
+
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(protobuf)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req, err := http.NewRequest("POST", "http://localhost/", bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Encoding", "gzip")
+
+	resp := httptest.NewRecorder()
+	handler := Handler(len(protobuf)-1, nil, verifyWriteRequestHandler(t, cortexpb.API), NewHandlerMetrics(nil))
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), "received message larger than max")
+}
+
 func TestHandler_cortexWriteRequest(t *testing.T) {
 	req := createRequest(t, createCortexWriteRequestProtobuf(t, false))
 	resp := httptest.NewRecorder()
 	sourceIPs, _ := middleware.NewSourceIPs("SomeField", "(.*)")
-	handler := Handler(100000, sourceIPs, verifyWriteRequestHandler(t, cortexpb.RULE))
+	handler := Handler(100000, sourceIPs, verifyWriteRequestHandler(t, cortexpb.RULE), NewHandlerMetrics(nil))
 	handler.ServeHTTP(resp, req)
 	assert.Equal(t, 200, resp.Code)
 }
@@ -40,12 +139,131 @@ func TestHandler_ignoresSkipLabelNameValidationIfSet(t *testing.T) {
 		createRequest(t, createCortexWriteRequestProtobuf(t, false)),
 	} {
 		resp := httptest.NewRecorder()
-		handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.RULE))
+		handler := Handler(100000, nil, verifyWriteRequestHandler(t, cortexpb.RULE), NewHandlerMetrics(nil))
 		handler.ServeHTTP(resp, req)
 		assert.Equal(t, 200, resp.Code)
 	}
 }
 
+func TestHandler_shouldPropagateIdempotencyKey(t *testing.T) {
+	for _, key := range []string{"", "some-key"} {
+		req := createRequest(t, createPrometheusRemoteWriteProtobuf(t))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+
+		resp := httptest.NewRecorder()
+		handler := Handler(100000, nil, func(ctx context.Context, _ *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+			assert.Equal(t, key, IdempotencyKeyFromContext(ctx))
+			return &cortexpb.WriteResponse{}, nil
+		}, NewHandlerMetrics(nil))
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, 200, resp.Code)
+	}
+}
+
+func TestHandler_remoteWriteV2(t *testing.T) {
+	v2 := &cortexpb.WriteRequestV2{
+		Symbols: []string{"", "__name__", "foo", "job", "test", "Help of foo.", "trace_id", "abc"},
+		Timeseries: []cortexpb.TimeSeriesV2{{
+			LabelsRefs:       []uint32{1, 2, 3, 4},
+			Samples:          []cortexpb.Sample{{Value: 1, TimestampMs: 20}, {Value: 2, TimestampMs: 30}},
+			Exemplars:        []cortexpb.ExemplarV2{{LabelsRefs: []uint32{6, 7}, Value: 1, Timestamp: 20}},
+			Metadata:         cortexpb.MetadataV2{Type: cortexpb.COUNTER, HelpRef: 5},
+			CreatedTimestamp: 10,
+		}},
+	}
+	protobuf, err := v2.Marshal()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		contentType  string
+		protobuf     []byte
+		expectedCode int
+	}{
+		"remote write 2.0": {
+			contentType:  "application/x-protobuf;proto=io.prometheus.write.v2.Request",
+			protobuf:     protobuf,
+			expectedCode: http.StatusOK,
+		},
+		"remote write 1.0 with the proto parameter": {
+			contentType:  "application/x-protobuf;proto=prometheus.WriteRequest",
+			protobuf:     createPrometheusRemoteWriteProtobuf(t),
+			expectedCode: http.StatusOK,
+		},
+		"remote write 1.0 without content type": {
+			protobuf:     createPrometheusRemoteWriteProtobuf(t),
+			expectedCode: http.StatusOK,
+		},
+		"unsupported protobuf message": {
+			contentType:  "application/x-protobuf;proto=io.prometheus.write.v3.Request",
+			protobuf:     protobuf,
+			expectedCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for name, testData := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := createRequest(t, testData.protobuf)
+			req.Header.Set("Content-Type", testData.contentType)
+
+			var received *cortexpb.WriteRequest
+			resp := httptest.NewRecorder()
+			handler := Handler(100000, nil, func(_ context.Context, req *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+				received = req
+				// One of the samples is dropped by the push.
+				return &cortexpb.WriteResponse{SamplesWritten: 1, ExemplarsWritten: 1}, nil
+			}, NewHandlerMetrics(nil))
+			handler.ServeHTTP(resp, req)
+			require.Equal(t, testData.expectedCode, resp.Code)
+			if testData.expectedCode != http.StatusOK {
+				return
+			}
+
+			require.Len(t, received.Timeseries, 1)
+			assert.Equal(t, "foo", received.Timeseries[0].Labels[0].Value)
+			if !strings.Contains(testData.contentType, remoteWriteV2ProtoMsg) {
+				assert.Empty(t, resp.Header().Get(samplesWrittenHeader))
+				return
+			}
+
+			assert.Equal(t, []cortexpb.LabelAdapter{{Name: "__name__", Value: "foo"}, {Name: "job", Value: "test"}}, received.Timeseries[0].Labels)
+			assert.Equal(t, int64(10), received.Timeseries[0].CreatedTimestampMs)
+			assert.Equal(t, []cortexpb.Exemplar{{Labels: []cortexpb.LabelAdapter{{Name: "trace_id", Value: "abc"}}, Value: 1, TimestampMs: 20}}, received.Timeseries[0].Exemplars)
+			assert.Equal(t, []*cortexpb.MetricMetadata{{Type: cortexpb.COUNTER, MetricFamilyName: "foo", Help: "Help of foo."}}, received.Metadata)
+			assert.Equal(t, "1", resp.Header().Get(samplesWrittenHeader))
+			assert.Equal(t, "0", resp.Header().Get(histogramsWrittenHeader))
+			assert.Equal(t, "1", resp.Header().Get(exemplarsWrittenHeader))
+		})
+	}
+}
+
+func TestHandler_remoteWrite2WrittenHeadersOnPartialRejection(t *testing.T) {
+	v2 := cortexpb.WriteRequestV2{
+		Symbols: []string{"", "__name__", "foo"},
+		Timeseries: []cortexpb.TimeSeriesV2{{
+			LabelsRefs: []uint32{1, 2},
+			Samples:    []cortexpb.Sample{{Value: 1, TimestampMs: 10}, {Value: 2, TimestampMs: 20}},
+		}},
+	}
+	protobuf, err := v2.Marshal()
+	require.NoError(t, err)
+
+	req := createRequest(t, protobuf)
+	req.Header.Set("Content-Type", "application/x-protobuf;proto=io.prometheus.write.v2.Request")
+
+	resp := httptest.NewRecorder()
+	handler := Handler(100000, nil, func(_ context.Context, _ *cortexpb.WriteRequest) (*cortexpb.WriteResponse, error) {
+		return &cortexpb.WriteResponse{SamplesWritten: 1}, httpgrpc.Errorf(http.StatusBadRequest, "sample too old")
+	}, NewHandlerMetrics(nil))
+	handler.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "1", resp.Header().Get(samplesWrittenHeader))
+	assert.Equal(t, "0", resp.Header().Get(histogramsWrittenHeader))
+	assert.Equal(t, "0", resp.Header().Get(exemplarsWrittenHeader))
+}
+
 func verifyWriteRequestHandler(t *testing.T, expectSource cortexpb.WriteRequest_SourceEnum) func(ctx context.Context, request *cortexpb.WriteRequest) (response *cortexpb.WriteResponse, err error) {
 	t.Helper()
 	return func(ctx context.Context, request *cortexpb.WriteRequest) (response *cortexpb.WriteResponse, err error) {
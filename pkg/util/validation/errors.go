@@ -20,6 +20,7 @@ type ValidationError error
 // error format only contains the cause and the series.
 type genericValidationError struct {
 	message string
+	reason  string
 	cause   string
 	series  []cortexpb.LabelAdapter
 }
@@ -94,6 +95,7 @@ func labelSizeBytesExceededError(series []cortexpb.LabelAdapter, labelsSizeBytes
 func newInvalidLabelError(series []cortexpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
 		message: "sample invalid label: %.200q metric %.200q",
+		reason:  invalidLabel,
 		cause:   labelName,
 		series:  series,
 	}
@@ -102,6 +104,7 @@ func newInvalidLabelError(series []cortexpb.LabelAdapter, labelName string) Vali
 func newDuplicatedLabelError(series []cortexpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
 		message: "duplicate label name: %.200q metric %.200q",
+		reason:  duplicateLabelNames,
 		cause:   labelName,
 		series:  series,
 	}
@@ -110,6 +113,7 @@ func newDuplicatedLabelError(series []cortexpb.LabelAdapter, labelName string) V
 func newLabelsNotSortedError(series []cortexpb.LabelAdapter, labelName string) ValidationError {
 	return &genericValidationError{
 		message: "labels not sorted: %.200q metric %.200q",
+		reason:  labelsNotSorted,
 		cause:   labelName,
 		series:  series,
 	}
@@ -160,6 +164,7 @@ func (e *invalidMetricNameError) Error() string {
 // sampleValidationError is a ValidationError implementation suitable for sample validation errors.
 type sampleValidationError struct {
 	message    string
+	reason     string
 	metricName string
 	timestamp  int64
 }
@@ -171,6 +176,7 @@ func (e *sampleValidationError) Error() string {
 func newSampleTimestampTooOldError(metricName string, timestamp int64) ValidationError {
 	return &sampleValidationError{
 		message:    "timestamp too old: %d metric: %.200q",
+		reason:     greaterThanMaxSampleAge,
 		metricName: metricName,
 		timestamp:  timestamp,
 	}
@@ -179,14 +185,63 @@ func newSampleTimestampTooOldError(metricName string, timestamp int64) Validatio
 func newSampleTimestampTooNewError(metricName string, timestamp int64) ValidationError {
 	return &sampleValidationError{
 		message:    "timestamp too new: %d metric: %.200q",
+		reason:     tooFarInFuture,
 		metricName: metricName,
 		timestamp:  timestamp,
 	}
 }
 
+// NewSampleIntervalTooShortError returns the error of a sample closer than the min sample interval to the
+// last sample accepted for its series.
+func NewSampleIntervalTooShortError(metricName string, timestamp int64) ValidationError {
+	return &sampleValidationError{
+		message:    "timestamp too close to the previous sample of the series, the min sample interval is enforced: %d metric: %.200q",
+		reason:     SampleIntervalTooShort,
+		metricName: metricName,
+		timestamp:  timestamp,
+	}
+}
+
+// nativeHistogramValidationError is a ValidationError implementation suitable for native histogram
+// validation errors.
+type nativeHistogramValidationError struct {
+	message       string
+	reason        string
+	metricName    string
+	timestamp     int64
+	actual, limit int
+}
+
+func (e *nativeHistogramValidationError) Error() string {
+	return fmt.Sprintf(e.message, e.actual, e.limit, e.timestamp, e.metricName)
+}
+
+func newNativeHistogramSchemaTooHighError(metricName string, timestamp int64, schema, limit int) ValidationError {
+	return &nativeHistogramValidationError{
+		message:    "native histogram schema too high (actual: %d, limit: %d) timestamp: %d metric: %.200q",
+		reason:     nativeHistogramSchemaTooHigh,
+		metricName: metricName,
+		timestamp:  timestamp,
+		actual:     schema,
+		limit:      limit,
+	}
+}
+
+func newNativeHistogramTooManyBucketsError(metricName string, timestamp int64, buckets, limit int) ValidationError {
+	return &nativeHistogramValidationError{
+		message:    "native histogram has too many buckets (actual: %d, limit: %d) timestamp: %d metric: %.200q",
+		reason:     nativeHistogramTooManyBuckets,
+		metricName: metricName,
+		timestamp:  timestamp,
+		actual:     buckets,
+		limit:      limit,
+	}
+}
+
 // exemplarValidationError is a ValidationError implementation suitable for exemplar validation errors.
 type exemplarValidationError struct {
 	message        string
+	reason         string
 	seriesLabels   []cortexpb.LabelAdapter
 	exemplarLabels []cortexpb.LabelAdapter
 	timestamp      int64
@@ -199,6 +254,7 @@ func (e *exemplarValidationError) Error() string {
 func newExemplarEmtpyLabelsError(seriesLabels []cortexpb.LabelAdapter, exemplarLabels []cortexpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
 		message:        "exemplar missing labels, timestamp: %d series: %s labels: %s",
+		reason:         exemplarLabelsMissing,
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
@@ -208,6 +264,7 @@ func newExemplarEmtpyLabelsError(seriesLabels []cortexpb.LabelAdapter, exemplarL
 func newExemplarMissingTimestampError(seriesLabels []cortexpb.LabelAdapter, exemplarLabels []cortexpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
 		message:        "exemplar missing timestamp, timestamp: %d series: %s labels: %s",
+		reason:         exemplarTimestampInvalid,
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
@@ -219,12 +276,43 @@ var labelLenMsg = "exemplar combined labelset exceeds " + strconv.Itoa(ExemplarM
 func newExemplarLabelLengthError(seriesLabels []cortexpb.LabelAdapter, exemplarLabels []cortexpb.LabelAdapter, timestamp int64) ValidationError {
 	return &exemplarValidationError{
 		message:        labelLenMsg,
+		reason:         exemplarLabelsTooLong,
 		seriesLabels:   seriesLabels,
 		exemplarLabels: exemplarLabels,
 		timestamp:      timestamp,
 	}
 }
 
+// ValidationErrorReason returns the reason, as tracked by the discarded samples and exemplars
+// metrics, of the input validation error or an empty string if the error is not a series
+// validation error.
+func ValidationErrorReason(err ValidationError) string {
+	switch e := err.(type) {
+	case *genericValidationError:
+		return e.reason
+	case *labelNameTooLongError:
+		return labelNameTooLong
+	case *labelValueTooLongError:
+		return labelValueTooLong
+	case *labelsSizeBytesExceededError:
+		return labelsSizeBytesExceeded
+	case *tooManyLabelsError:
+		return maxLabelNamesPerSeries
+	case *noMetricNameError:
+		return missingMetricName
+	case *invalidMetricNameError:
+		return invalidMetricName
+	case *sampleValidationError:
+		return e.reason
+	case *nativeHistogramValidationError:
+		return e.reason
+	case *exemplarValidationError:
+		return e.reason
+	default:
+		return ""
+	}
+}
+
 // formatLabelSet formats label adapters as a metric name with labels, while preserving
 // label order, and keeping duplicates. If there are multiple "__name__" labels, only
 // first one is used as metric name, other ones will be included as regular labels.
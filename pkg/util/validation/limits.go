@@ -2,9 +2,12 @@ package validation
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"flag"
+	"fmt"
 	"math"
 	"regexp"
 	"strings"
@@ -25,11 +28,37 @@ var errMaxGlobalSeriesPerUserValidation = errors.New("The ingester.max-global-se
 var errDuplicateQueryPriorities = errors.New("duplicate entry of priorities found. Make sure they are all unique, including the default priority")
 var errCompilingQueryPriorityRegex = errors.New("error compiling query priority regex")
 var errDuplicatePerLabelSetLimit = errors.New("duplicate per labelSet limits found. Make sure they are all unique")
+var errInvalidStalenessMarkerPolicy = errors.New("invalid staleness marker policy, supported values are: accept, drop, convert")
+var errInvalidMinSampleIntervalPolicy = errors.New("invalid min sample interval policy, supported values are: reject, coalesce")
+var errInvalidNativeHistogramLimitsPolicy = errors.New("invalid native histogram limits policy, supported values are: reject, downscale")
+var errInvalidMaxNativeHistogramSchema = errors.New("invalid max native histogram schema, must be between -4 and 8")
+var errInvalidRulerEvaluationInterval = errors.New("the ruler default and min evaluation intervals must be greater than or equal to 0, and the default evaluation interval must not be lower than the min one")
+var errInvalidRulerAlertAnnotationLimitAction = errors.New("invalid ruler alert annotation limit action, supported values are: truncate, drop")
+var errInvalidHALabelPair = errors.New("invalid HA label pair, the cluster and replica labels must be set and different")
+var errInvalidMetricRelabelConfig = errors.New("invalid metric relabel config")
+var errInvalidHeadCompactionInterval = errors.New("invalid head compaction interval, must be between 0 and 30m")
+var errInvalidBlockDuration = errors.New("invalid block duration, must not be negative")
+
+// ErrInvalidPushToken is returned when a push token is invalid, alone or along with the other tokens of the tenant.
+var ErrInvalidPushToken = errors.New("invalid push token, the name must be set and unique, the token_sha256 must be a hex encoded SHA-256 hash and the supported scopes are: series, metadata, exemplars")
 
 // Supported values for enum limits
 const (
 	LocalIngestionRateStrategy  = "local"
 	GlobalIngestionRateStrategy = "global"
+
+	StalenessMarkerPolicyAccept  = "accept"
+	StalenessMarkerPolicyDrop    = "drop"
+	StalenessMarkerPolicyConvert = "convert"
+
+	MinSampleIntervalPolicyReject   = "reject"
+	MinSampleIntervalPolicyCoalesce = "coalesce"
+
+	NativeHistogramLimitsPolicyReject    = "reject"
+	NativeHistogramLimitsPolicyDownscale = "downscale"
+
+	RulerAlertAnnotationLimitActionTruncate = "truncate"
+	RulerAlertAnnotationLimitActionDrop     = "drop"
 )
 
 // AccessDeniedError are errors that do not comply with the limits specified.
@@ -54,6 +83,87 @@ type DisabledRuleGroup struct {
 
 type DisabledRuleGroups []DisabledRuleGroup
 
+type HALabelPair struct {
+	Cluster string `yaml:"cluster" json:"cluster" doc:"nocli|description=Label to look for in samples to identify a HA cluster."`
+	Replica string `yaml:"replica" json:"replica" doc:"nocli|description=Label to look for in samples to identify a HA replica."`
+}
+
+type HALabelPairs []HALabelPair
+
+// Supported scopes of the push tokens.
+const (
+	PushTokenScopeSeries    = "series"
+	PushTokenScopeMetadata  = "metadata"
+	PushTokenScopeExemplars = "exemplars"
+)
+
+type PushToken struct {
+	Name        string   `yaml:"name" json:"name" doc:"nocli|description=Name of the token, reported in the logs and the push tokens API."`
+	TokenSHA256 string   `yaml:"token_sha256" json:"token_sha256" doc:"nocli|description=Hex encoded SHA-256 hash of the token, as printed by sha256sum for the token without a trailing newline."`
+	Scopes      []string `yaml:"scopes" json:"scopes" doc:"nocli|description=What the token allows to push. Supported values are: series (series samples), metadata and exemplars."`
+}
+
+// ValidatePushTokens returns an error if a push token has no name or a name already used by another
+// token, an invalid hash or an unsupported scope.
+func ValidatePushTokens(tokens []PushToken) error {
+	names := map[string]struct{}{}
+	for _, t := range tokens {
+		if _, ok := names[t.Name]; ok || t.Name == "" {
+			return ErrInvalidPushToken
+		}
+		names[t.Name] = struct{}{}
+
+		if hash, err := hex.DecodeString(t.TokenSHA256); err != nil || len(hash) != sha256.Size {
+			return ErrInvalidPushToken
+		}
+		for _, scope := range t.Scopes {
+			switch scope {
+			case PushTokenScopeSeries, PushTokenScopeMetadata, PushTokenScopeExemplars:
+			default:
+				return ErrInvalidPushToken
+			}
+		}
+	}
+	return nil
+}
+
+// HasScope returns whether the push token has the given scope.
+func (t PushToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// String implements flag.Value.
+func (p HALabelPairs) String() string {
+	pairs := make([]string, 0, len(p))
+	for _, pair := range p {
+		pairs = append(pairs, pair.Cluster+":"+pair.Replica)
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value. The pairs are comma separated and each pair is made of the cluster
+// and replica label names separated by a colon.
+func (p *HALabelPairs) Set(s string) error {
+	pairs := HALabelPairs{}
+	for _, pair := range strings.Split(s, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		cluster, replica, ok := strings.Cut(pair, ":")
+		if !ok {
+			return fmt.Errorf("invalid HA label pair %q, expected <cluster>:<replica>", pair)
+		}
+		pairs = append(pairs, HALabelPair{Cluster: strings.TrimSpace(cluster), Replica: strings.TrimSpace(replica)})
+	}
+	*p = pairs
+	return nil
+}
+
 type QueryPriority struct {
 	Enabled         bool          `yaml:"enabled" json:"enabled"`
 	DefaultPriority int64         `yaml:"default_priority" json:"default_priority"`
@@ -70,6 +180,9 @@ type QueryAttribute struct {
 	Regex         string     `yaml:"regex" json:"regex" doc:"nocli|description=Regex that the query string should match. If not set, it won't be checked."`
 	TimeWindow    TimeWindow `yaml:"time_window" json:"time_window" doc:"nocli|description=Overall data select time window (including range selectors, modifiers and lookback delta) that the query should be within. If not set, it won't be checked."`
 	CompiledRegex *regexp.Regexp
+
+	UserAgentRegex         string `yaml:"user_agent_regex" json:"user_agent_regex" doc:"nocli|description=Regex that the User-Agent header of the query request should match, for example to assign a higher priority to the alerting queries. If not set, it won't be checked."`
+	CompiledUserAgentRegex *regexp.Regexp
 }
 
 type TimeWindow struct {
@@ -92,27 +205,48 @@ type LimitsPerLabelSet struct {
 // limits via flags, or per-user limits via yaml config.
 type Limits struct {
 	// Distributor enforced limits.
-	IngestionRate             float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
-	IngestionRateStrategy     string              `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
-	IngestionBurstSize        int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
-	AcceptHASamples           bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
-	HAClusterLabel            string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
-	HAReplicaLabel            string              `yaml:"ha_replica_label" json:"ha_replica_label"`
-	HAMaxClusters             int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
-	DropLabels                flagext.StringSlice `yaml:"drop_labels" json:"drop_labels"`
-	MaxLabelNameLength        int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
-	MaxLabelValueLength       int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
-	MaxLabelNamesPerSeries    int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
-	MaxLabelsSizeBytes        int                 `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes"`
-	MaxMetadataLength         int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
-	RejectOldSamples          bool                `yaml:"reject_old_samples" json:"reject_old_samples"`
-	RejectOldSamplesMaxAge    model.Duration      `yaml:"reject_old_samples_max_age" json:"reject_old_samples_max_age"`
-	CreationGracePeriod       model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period"`
-	EnforceMetadataMetricName bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name"`
-	EnforceMetricName         bool                `yaml:"enforce_metric_name" json:"enforce_metric_name"`
-	IngestionTenantShardSize  int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
-	MetricRelabelConfigs      []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs."`
-	MaxExemplars              int                 `yaml:"max_exemplars" json:"max_exemplars"`
+	IngestionRate                          float64             `yaml:"ingestion_rate" json:"ingestion_rate"`
+	IngestionRateStrategy                  string              `yaml:"ingestion_rate_strategy" json:"ingestion_rate_strategy"`
+	IngestionBurstSize                     int                 `yaml:"ingestion_burst_size" json:"ingestion_burst_size"`
+	RequestRate                            float64             `yaml:"request_rate" json:"request_rate"`
+	RequestBurstSize                       int                 `yaml:"request_burst_size" json:"request_burst_size"`
+	AcceptHASamples                        bool                `yaml:"accept_ha_samples" json:"accept_ha_samples"`
+	HAClusterLabel                         string              `yaml:"ha_cluster_label" json:"ha_cluster_label"`
+	HAReplicaLabel                         string              `yaml:"ha_replica_label" json:"ha_replica_label"`
+	HALabelPairs                           HALabelPairs        `yaml:"ha_label_pairs" json:"ha_label_pairs"`
+	HAMaxClusters                          int                 `yaml:"ha_max_clusters" json:"ha_max_clusters"`
+	HATrackerUpdateTimeout                 model.Duration      `yaml:"ha_tracker_update_timeout" json:"ha_tracker_update_timeout" doc:"nocli|description=Per-user override of the HA tracker update timeout. 0 to use the -distributor.ha-tracker.update-timeout value.|default=0s"`
+	HATrackerFailoverTimeout               model.Duration      `yaml:"ha_tracker_failover_timeout" json:"ha_tracker_failover_timeout" doc:"nocli|description=Per-user override of the HA tracker failover timeout. It's raised to at least 1s greater than the update timeout plus the max jitter. 0 to use the -distributor.ha-tracker.failover-timeout value.|default=0s"`
+	DropLabels                             flagext.StringSlice `yaml:"drop_labels" json:"drop_labels"`
+	MaxLabelNameLength                     int                 `yaml:"max_label_name_length" json:"max_label_name_length"`
+	MaxLabelValueLength                    int                 `yaml:"max_label_value_length" json:"max_label_value_length"`
+	MaxLabelNamesPerSeries                 int                 `yaml:"max_label_names_per_series" json:"max_label_names_per_series"`
+	MaxLabelNamesPerSeriesReductionEnabled bool                `yaml:"max_label_names_per_series_reduction_enabled" json:"max_label_names_per_series_reduction_enabled"`
+	MaxLabelNamesPerSeriesPriority         flagext.StringSlice `yaml:"max_label_names_per_series_priority" json:"max_label_names_per_series_priority"`
+	MaxLabelsSizeBytes                     int                 `yaml:"max_labels_size_bytes" json:"max_labels_size_bytes"`
+	MaxMetadataLength                      int                 `yaml:"max_metadata_length" json:"max_metadata_length"`
+	RejectOldSamples                       bool                `yaml:"reject_old_samples" json:"reject_old_samples"`
+	RejectOldSamplesMaxAge                 model.Duration      `yaml:"reject_old_samples_max_age" json:"reject_old_samples_max_age"`
+	CreationGracePeriod                    model.Duration      `yaml:"creation_grace_period" json:"creation_grace_period"`
+	EnforceMetadataMetricName              bool                `yaml:"enforce_metadata_metric_name" json:"enforce_metadata_metric_name"`
+	EnforceMetricName                      bool                `yaml:"enforce_metric_name" json:"enforce_metric_name"`
+	StalenessMarkerPolicy                  string              `yaml:"staleness_marker_policy" json:"staleness_marker_policy"`
+	MinSampleInterval                      model.Duration      `yaml:"min_sample_interval" json:"min_sample_interval"`
+	MinSampleIntervalPolicy                string              `yaml:"min_sample_interval_policy" json:"min_sample_interval_policy"`
+	MaxNativeHistogramBuckets              int                 `yaml:"max_native_histogram_buckets" json:"max_native_histogram_buckets"`
+	MaxNativeHistogramSchema               int                 `yaml:"max_native_histogram_schema" json:"max_native_histogram_schema"`
+	NativeHistogramLimitsPolicy            string              `yaml:"native_histogram_limits_policy" json:"native_histogram_limits_policy"`
+	IngestionTenantShardSize               int                 `yaml:"ingestion_tenant_shard_size" json:"ingestion_tenant_shard_size"`
+	IngestionReplicationFactor             int                 `yaml:"ingestion_replication_factor" json:"ingestion_replication_factor"`
+	MetricRelabelConfigs                   []*relabel.Config   `yaml:"metric_relabel_configs,omitempty" json:"metric_relabel_configs,omitempty" doc:"nocli|description=List of metric relabel configurations, applied by the distributor to the series after the HA deduplication and before removing the drop_labels, validating and sharding them, so the series are sharded by their relabeled labels. The series left without labels are dropped. Note that in most situations, it is more effective to use metrics relabeling directly in the Prometheus server, e.g. remote_write.write_relabel_configs."`
+	PushTokens                             []PushToken         `yaml:"push_tokens" json:"push_tokens" doc:"nocli|description=List of tokens accepted by the push endpoints for the tenant, each allowing to push some kind of data. When set, the push requests must carry one of the tokens in the 'Authorization: Bearer <token>' header and are rejected otherwise, including the pushes received via gRPC which can't carry a token."`
+	MaxExemplars                           int                 `yaml:"max_exemplars" json:"max_exemplars"`
+	RejectedSeriesSamplesPerReason         int                 `yaml:"rejected_series_samples_per_reason" json:"rejected_series_samples_per_reason"`
+	DiscardedSamplesMetaSeriesEnabled      bool                `yaml:"discarded_samples_meta_series_enabled" json:"discarded_samples_meta_series_enabled"`
+	SpillBufferMaxSizeBytes                int                 `yaml:"spill_buffer_max_size_bytes" json:"spill_buffer_max_size_bytes"`
+
+	// OTLP.
+	PromoteResourceAttributes flagext.StringSliceCSV `yaml:"promote_resource_attributes" json:"promote_resource_attributes"`
 
 	// Ingester enforced limits.
 	// Series
@@ -121,6 +255,9 @@ type Limits struct {
 	MaxGlobalSeriesPerUser   int                 `yaml:"max_global_series_per_user" json:"max_global_series_per_user"`
 	MaxGlobalSeriesPerMetric int                 `yaml:"max_global_series_per_metric" json:"max_global_series_per_metric"`
 	LimitsPerLabelSet        []LimitsPerLabelSet `yaml:"limits_per_label_set" json:"limits_per_label_set" doc:"nocli|description=[Experimental] Enable limits per LabelSet. Supported limits per labelSet: [max_series]"`
+	// Gradual enforcement of the per-user series limit
+	MaxSeriesPerUserGracePeriod  model.Duration `yaml:"max_series_per_user_grace_period" json:"max_series_per_user_grace_period"`
+	MaxSeriesPerUserRampUpPeriod model.Duration `yaml:"max_series_per_user_ramp_up_period" json:"max_series_per_user_ramp_up_period"`
 
 	// Metadata
 	MaxLocalMetricsWithMetadataPerUser  int `yaml:"max_metadata_per_user" json:"max_metadata_per_user"`
@@ -129,30 +266,60 @@ type Limits struct {
 	MaxGlobalMetadataPerMetric          int `yaml:"max_global_metadata_per_metric" json:"max_global_metadata_per_metric"`
 	// Out-of-order
 	OutOfOrderTimeWindow model.Duration `yaml:"out_of_order_time_window" json:"out_of_order_time_window"`
+	// Head compaction
+	HeadCompactionInterval model.Duration `yaml:"head_compaction_interval" json:"head_compaction_interval"`
+	BlockDuration          model.Duration `yaml:"block_duration" json:"block_duration"`
+	// Chunk encoding
+	SamplesPerChunk                  int  `yaml:"samples_per_chunk" json:"samples_per_chunk"`
+	NativeHistogramsIngestionEnabled bool `yaml:"native_histograms_ingestion_enabled" json:"native_histograms_ingestion_enabled"`
 
 	// Querier enforced limits.
 	MaxChunksPerQuery            int            `yaml:"max_fetched_chunks_per_query" json:"max_fetched_chunks_per_query"`
 	MaxFetchedSeriesPerQuery     int            `yaml:"max_fetched_series_per_query" json:"max_fetched_series_per_query"`
 	MaxFetchedChunkBytesPerQuery int            `yaml:"max_fetched_chunk_bytes_per_query" json:"max_fetched_chunk_bytes_per_query"`
 	MaxFetchedDataBytesPerQuery  int            `yaml:"max_fetched_data_bytes_per_query" json:"max_fetched_data_bytes_per_query"`
+	MaxFetchedExemplarsPerQuery  int            `yaml:"max_fetched_exemplars_per_query" json:"max_fetched_exemplars_per_query"`
 	MaxQueryLookback             model.Duration `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxQueryLength               model.Duration `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryParallelism          int            `yaml:"max_query_parallelism" json:"max_query_parallelism"`
 	MaxCacheFreshness            model.Duration `yaml:"max_cache_freshness" json:"max_cache_freshness"`
+	InstantQueryCacheTTL         model.Duration `yaml:"instant_query_cache_ttl" json:"instant_query_cache_ttl"`
 	MaxQueriersPerTenant         float64        `yaml:"max_queriers_per_tenant" json:"max_queriers_per_tenant"`
 	QueryVerticalShardSize       int            `yaml:"query_vertical_shard_size" json:"query_vertical_shard_size" doc:"hidden"`
 
+	QueryVerticalShardBySeriesHash    bool `yaml:"query_vertical_shard_by_series_hash" json:"query_vertical_shard_by_series_hash" doc:"hidden"`
+	QueryVerticalShardApproximateTopK bool `yaml:"query_vertical_shard_approximate_topk" json:"query_vertical_shard_approximate_topk" doc:"hidden"`
+
+	QueryShadowEngineFraction float64 `yaml:"query_shadow_engine_fraction" json:"query_shadow_engine_fraction"`
+
 	// Query Frontend / Scheduler enforced limits.
 	MaxOutstandingPerTenant    int           `yaml:"max_outstanding_requests_per_tenant" json:"max_outstanding_requests_per_tenant"`
+	MaxQueryBytesPerDay        int64         `yaml:"max_query_bytes_per_day" json:"max_query_bytes_per_day"`
+	MaxResponsePoints          int           `yaml:"max_response_points" json:"max_response_points"`
 	QueryPriority              QueryPriority `yaml:"query_priority" json:"query_priority" doc:"nocli|description=Configuration for query priority."`
 	queryPriorityRegexHash     uint64
 	queryPriorityCompiledRegex map[string]*regexp.Regexp
 
+	QueryQueueWeight int `yaml:"query_queue_weight" json:"query_queue_weight"`
+
+	MaxEstimatedQueryCost    int64          `yaml:"max_estimated_query_cost" json:"max_estimated_query_cost"`
+	QueryCostScrapeInterval  model.Duration `yaml:"query_cost_scrape_interval" json:"query_cost_scrape_interval"`
+	QueryCostOverrideAllowed bool           `yaml:"query_cost_override_allowed" json:"query_cost_override_allowed"`
+
 	// Ruler defaults and limits.
 	RulerEvaluationDelay        model.Duration `yaml:"ruler_evaluation_delay_duration" json:"ruler_evaluation_delay_duration"`
 	RulerTenantShardSize        int            `yaml:"ruler_tenant_shard_size" json:"ruler_tenant_shard_size"`
 	RulerMaxRulesPerRuleGroup   int            `yaml:"ruler_max_rules_per_rule_group" json:"ruler_max_rules_per_rule_group"`
 	RulerMaxRuleGroupsPerTenant int            `yaml:"ruler_max_rule_groups_per_tenant" json:"ruler_max_rule_groups_per_tenant"`
+	RulerMetaMonitoringEnabled  bool           `yaml:"ruler_meta_monitoring_enabled" json:"ruler_meta_monitoring_enabled"`
+	RulerMetaMonitoringLag      model.Duration `yaml:"ruler_meta_monitoring_lag_threshold" json:"ruler_meta_monitoring_lag_threshold"`
+
+	RulerMaxAlertAnnotationSizeBytes  int    `yaml:"ruler_max_alert_annotation_size_bytes" json:"ruler_max_alert_annotation_size_bytes"`
+	RulerMaxAlertAnnotationsSizeBytes int    `yaml:"ruler_max_alert_annotations_size_bytes" json:"ruler_max_alert_annotations_size_bytes"`
+	RulerAlertAnnotationLimitAction   string `yaml:"ruler_alert_annotation_limit_action" json:"ruler_alert_annotation_limit_action"`
+
+	RulerDefaultEvaluationInterval model.Duration `yaml:"ruler_default_evaluation_interval" json:"ruler_default_evaluation_interval"`
+	RulerMinEvaluationInterval     model.Duration `yaml:"ruler_min_evaluation_interval" json:"ruler_min_evaluation_interval"`
 
 	// Store-gateway.
 	StoreGatewayTenantShardSize  float64 `yaml:"store_gateway_tenant_shard_size" json:"store_gateway_tenant_shard_size"`
@@ -181,6 +348,15 @@ type Limits struct {
 	AlertmanagerMaxDispatcherAggregationGroups int                `yaml:"alertmanager_max_dispatcher_aggregation_groups" json:"alertmanager_max_dispatcher_aggregation_groups"`
 	AlertmanagerMaxAlertsCount                 int                `yaml:"alertmanager_max_alerts_count" json:"alertmanager_max_alerts_count"`
 	AlertmanagerMaxAlertsSizeBytes             int                `yaml:"alertmanager_max_alerts_size_bytes" json:"alertmanager_max_alerts_size_bytes"`
+	AlertmanagerMaxAlertGroups                 int                `yaml:"alertmanager_max_alert_groups" json:"alertmanager_max_alert_groups"`
+	AlertmanagerMaxSilencesCount               int                `yaml:"alertmanager_max_silences_count" json:"alertmanager_max_silences_count"`
+	AlertmanagerMaxSilenceLifetime             model.Duration     `yaml:"alertmanager_max_silence_lifetime" json:"alertmanager_max_silence_lifetime"`
+	AlertmanagerExpireUnusedSilencesAfter      model.Duration     `yaml:"alertmanager_expire_unused_silences_after" json:"alertmanager_expire_unused_silences_after"`
+	AlertmanagerConfigUpdatesRateLimit         float64            `yaml:"alertmanager_config_updates_rate_limit" json:"alertmanager_config_updates_rate_limit"`
+	AlertmanagerConfigUpdatesBurstSize         int                `yaml:"alertmanager_config_updates_burst_size" json:"alertmanager_config_updates_burst_size"`
+	AlertmanagerMaxStateSizeBytes              int                `yaml:"alertmanager_max_state_size_bytes" json:"alertmanager_max_state_size_bytes"`
+	AlertmanagerNotificationLogRetention       model.Duration     `yaml:"alertmanager_notification_log_retention" json:"alertmanager_notification_log_retention"`
+	AlertmanagerAlertsArchiveRetention         model.Duration     `yaml:"alertmanager_alerts_archive_retention" json:"alertmanager_alerts_archive_retention"`
 	DisabledRuleGroups                         DisabledRuleGroups `yaml:"disabled_rule_groups" json:"disabled_rule_groups" doc:"nocli|description=list of rule groups to disable"`
 }
 
@@ -189,17 +365,25 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	flagext.DeprecatedFlag(f, "ingester.max-series-per-query", "Deprecated: The maximum number of series for which a query can fetch samples from each ingester. This limit is enforced only in the ingesters (when querying samples not flushed to the storage yet) and it's a per-instance limit. This limit is ignored when running the Cortex blocks storage. When running Cortex with blocks storage use -querier.max-fetched-series-per-query limit instead.", util_log.Logger)
 
 	f.IntVar(&l.IngestionTenantShardSize, "distributor.ingestion-tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used. Must be set both on ingesters and distributors. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant.")
+	f.IntVar(&l.IngestionReplicationFactor, "distributor.ingestion-replication-factor", 0, "The tenant's ingesters replication factor, used for writes and reads. The value is bounded by -distributor.tenant-replication-factor-min and -distributor.tenant-replication-factor-max, and it's ignored if the max is 0. Must be set on distributors, queriers and ingesters. 0 to use the ring replication factor.")
 	f.Float64Var(&l.IngestionRate, "distributor.ingestion-rate-limit", 25000, "Per-user ingestion rate limit in samples per second.")
 	f.StringVar(&l.IngestionRateStrategy, "distributor.ingestion-rate-limit-strategy", "local", "Whether the ingestion rate limit should be applied individually to each distributor instance (local), or evenly shared across the cluster (global).")
 	f.IntVar(&l.IngestionBurstSize, "distributor.ingestion-burst-size", 50000, "Per-user allowed ingestion burst size (in number of samples).")
+	f.Float64Var(&l.RequestRate, "distributor.request-rate-limit", 0, "Per-user push request rate limit in requests per second, enforced before decoding the request body. The limit is applied according to -distributor.ingestion-rate-limit-strategy. 0 to disable.")
+	f.IntVar(&l.RequestBurstSize, "distributor.request-burst-size", 0, "Per-user allowed push request burst size (in number of requests). 0 to use the request rate limit, rounded up.")
+	f.IntVar(&l.SpillBufferMaxSizeBytes, "distributor.spill-buffer.tenant-max-size-bytes", 0, "[Experimental] Per-user max size (in bytes) of the pushes buffered on the disk of each distributor by the -distributor.spill-buffer.enabled spill buffer. The pushes exceeding it are rejected. 0 to only apply -distributor.spill-buffer.max-size-bytes.")
 	f.BoolVar(&l.AcceptHASamples, "distributor.ha-tracker.enable-for-all-users", false, "Flag to enable, for all users, handling of samples with external labels identifying replicas in an HA Prometheus setup.")
 	f.StringVar(&l.HAClusterLabel, "distributor.ha-tracker.cluster", "cluster", "Prometheus label to look for in samples to identify a Prometheus HA cluster.")
 	f.StringVar(&l.HAReplicaLabel, "distributor.ha-tracker.replica", "__replica__", "Prometheus label to look for in samples to identify a Prometheus HA replica.")
+	f.Var(&l.HALabelPairs, "distributor.ha-tracker.label-pairs", "Ordered list of HA cluster and replica label pairs to look for in samples, so that agents using different label conventions can be deduplicated, eg. 'cluster:__replica__,prometheus:prometheus_replica'. The first pair whose labels are both found in a request is used to deduplicate it. In YAML, the pairs are a list of cluster and replica label names. When set, -distributor.ha-tracker.cluster and -distributor.ha-tracker.replica are ignored.")
 	f.IntVar(&l.HAMaxClusters, "distributor.ha-tracker.max-clusters", 0, "Maximum number of clusters that HA tracker will keep track of for single user. 0 to disable the limit.")
+	f.Var(&l.PromoteResourceAttributes, "distributor.promote-resource-attributes", "Comma separated list of OTLP resource attributes to promote to labels of the OTLP metrics. If empty, all the resource attributes are promoted.")
 	f.Var(&l.DropLabels, "distributor.drop-label", "This flag can be used to specify label names that to drop during sample ingestion within the distributor and can be repeated in order to drop multiple labels.")
 	f.IntVar(&l.MaxLabelNameLength, "validation.max-length-label-name", 1024, "Maximum length accepted for label names")
 	f.IntVar(&l.MaxLabelValueLength, "validation.max-length-label-value", 2048, "Maximum length accepted for label value. This setting also applies to the metric name")
 	f.IntVar(&l.MaxLabelNamesPerSeries, "validation.max-label-names-per-series", 30, "Maximum number of label names per series.")
+	f.BoolVar(&l.MaxLabelNamesPerSeriesReductionEnabled, "validation.max-label-names-per-series-reduction-enabled", false, "[Experimental] If enabled, series exceeding the max label names per series limit are not rejected. Instead, labels beyond the limit are dropped, keeping the metric name first, then the labels listed in -validation.max-label-names-per-series-priority (in order) and finally the remaining labels in lexicographic order. Note that series which only differ by the dropped labels are merged into the same series, so their samples may be rejected by the ingesters as duplicated or out-of-order.")
+	f.Var(&l.MaxLabelNamesPerSeriesPriority, "validation.max-label-names-per-series-priority", "[Experimental] Label names to keep, in priority order, when a series exceeding the max label names per series limit is reduced. Only applies when -validation.max-label-names-per-series-reduction-enabled is true. Can be repeated to specify multiple label names.")
 	f.IntVar(&l.MaxLabelsSizeBytes, "validation.max-labels-size-bytes", 0, "Maximum combined size in bytes of all labels and label values accepted for a series. 0 to disable the limit.")
 	f.IntVar(&l.MaxMetadataLength, "validation.max-metadata-length", 1024, "Maximum length accepted for metric metadata. Metadata refers to Metric Name, HELP and UNIT.")
 	f.BoolVar(&l.RejectOldSamples, "validation.reject-old-samples", false, "Reject old samples.")
@@ -208,14 +392,28 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.CreationGracePeriod.Set("10m")
 	f.Var(&l.CreationGracePeriod, "validation.create-grace-period", "Duration which table will be created/deleted before/after it's needed; we won't accept sample from before this time.")
 	f.BoolVar(&l.EnforceMetricName, "validation.enforce-metric-name", true, "Enforce every sample has a metric name.")
+	f.StringVar(&l.StalenessMarkerPolicy, "validation.staleness-marker-policy", StalenessMarkerPolicyAccept, "How to handle float samples which are Prometheus staleness markers. Supported values are: accept (ingest the staleness markers), drop (discard the staleness markers, tracked as discarded samples) and convert (don't ingest the staleness markers but track them as end-of-series events).")
+	f.Var(&l.MinSampleInterval, "validation.min-sample-interval", "[Experimental] Minimum interval between the timestamps of the samples of each series, approximating a minimum scrape interval. The samples closer to the last sample accepted for their series are handled according to -validation.min-sample-interval-policy. The last timestamp of each series is tracked by each distributor, so the interval is only enforced between the samples received by the same distributor. 0 to disable.")
+	f.StringVar(&l.MinSampleIntervalPolicy, "validation.min-sample-interval-policy", MinSampleIntervalPolicyReject, "How to handle the samples closer than -validation.min-sample-interval to the last sample accepted for their series. Supported values are: reject (reject the series with a validation error) and coalesce (discard the samples and ingest the others). In both cases, the discarded samples are tracked as discarded samples.")
+	f.IntVar(&l.MaxNativeHistogramBuckets, "validation.max-native-histogram-buckets", 0, "Maximum number of positive and negative buckets of a native histogram sample. 0 to disable.")
+	f.IntVar(&l.MaxNativeHistogramSchema, "validation.max-native-histogram-schema", 8, "Maximum schema, ie. resolution, of a native histogram sample, between -4 and 8.")
+	f.StringVar(&l.NativeHistogramLimitsPolicy, "validation.native-histogram-limits-policy", NativeHistogramLimitsPolicyReject, "How to handle the native histogram samples exceeding -validation.max-native-histogram-schema or -validation.max-native-histogram-buckets. Supported values are: reject (reject the series with a validation error) and downscale (reduce the resolution of the histogram down to the max schema, and further until its buckets are within the limit, rejecting it only if the limit can't be reached at the lowest resolution). Downscaled histograms are tracked by the cortex_downscaled_native_histograms_total metric.")
 	f.BoolVar(&l.EnforceMetadataMetricName, "validation.enforce-metadata-metric-name", true, "Enforce every metadata has a metric name.")
+	f.IntVar(&l.RejectedSeriesSamplesPerReason, "validation.rejected-series-samples-per-reason", 0, "Maximum number of series rejected by the distributor validation, per reason, whose full label set is sampled every hour and exposed by the /api/v1/rejected_series API. 0 to disable the sampling.")
+	f.BoolVar(&l.DiscardedSamplesMetaSeriesEnabled, "distributor.discarded-samples-meta-series-enabled", false, "[Experimental] True to periodically push the number of samples discarded by each distributor, per reason, into the tenant's own data, as the cortex_discarded_samples_total series, so that the tenant can query them.")
 
 	f.IntVar(&l.MaxLocalSeriesPerUser, "ingester.max-series-per-user", 5000000, "The maximum number of active series per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxLocalSeriesPerMetric, "ingester.max-series-per-metric", 50000, "The maximum number of active series per metric name, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxGlobalSeriesPerUser, "ingester.max-global-series-per-user", 0, "The maximum number of active series per user, across the cluster before replication. 0 to disable. Supported only if -distributor.shard-by-all-labels is true.")
 	f.IntVar(&l.MaxGlobalSeriesPerMetric, "ingester.max-global-series-per-metric", 0, "The maximum number of active series per metric name, across the cluster before replication. 0 to disable.")
+	f.Var(&l.MaxSeriesPerUserGracePeriod, "ingester.max-series-per-user-grace-period", "[Experimental] Period of time, since the user has exceeded the per-user series limit, during which the new series are not rejected but only tracked by the cortex_ingester_series_over_user_limit_created_total metric. 0 to disable.")
+	f.Var(&l.MaxSeriesPerUserRampUpPeriod, "ingester.max-series-per-user-ramp-up-period", "[Experimental] Period of time, after the per-user series limit grace period, during which the ratio of the new series rejected linearly increases from 0 to 1, until the limit is fully enforced. 0 to disable.")
 	f.IntVar(&l.MaxExemplars, "ingester.max-exemplars", 0, "Enables support for exemplars in TSDB and sets the maximum number that will be stored. less than zero means disabled. If the value is set to zero, cortex will fallback to blocks-storage.tsdb.max-exemplars value.")
-	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "[Experimental] Configures the allowed time window for ingestion of out-of-order samples. Disabled (0s) by default.")
+	f.Var(&l.OutOfOrderTimeWindow, "ingester.out-of-order-time-window", "[Experimental] Configures the allowed time window for ingestion of out-of-order samples. The samples within the window are accepted by the distributor even if older than -validation.reject-old-samples.max-age. Disabled (0s) by default.")
+	f.Var(&l.HeadCompactionInterval, "ingester.head-compaction-interval", "[Experimental] How frequently the ingesters try to compact the tenant's TSDB head, overriding -blocks-storage.tsdb.head-compaction-interval. Must be max 30 minutes. 0 to use the global setting.")
+	f.Var(&l.BlockDuration, "ingester.block-duration", "[Experimental] Duration of the blocks cut from the tenant's TSDB head, overriding the first -blocks-storage.tsdb.block-ranges-period. It must evenly divide the first block range, otherwise the global setting is used. The setting is applied when the tenant's TSDB is opened by the ingester. 0 to use the global setting.")
+	f.IntVar(&l.SamplesPerChunk, "ingester.samples-per-chunk", 0, "[Experimental] Target number of samples per TSDB head chunk. The setting is applied when the tenant's TSDB is opened by the ingester. 0 to use the TSDB default (120).")
+	f.BoolVar(&l.NativeHistogramsIngestionEnabled, "ingester.native-histograms-ingestion-enabled", false, "[Experimental] True to enable the ingestion of native histograms for the tenant, even if -blocks-storage.tsdb.enable-native-histograms is disabled.")
 
 	f.IntVar(&l.MaxLocalMetricsWithMetadataPerUser, "ingester.max-metadata-per-user", 8000, "The maximum number of active metrics with metadata per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxLocalMetadataPerMetric, "ingester.max-metadata-per-metric", 10, "The maximum number of metadata per metric, per ingester. 0 to disable.")
@@ -225,22 +423,43 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.MaxFetchedSeriesPerQuery, "querier.max-fetched-series-per-query", 0, "The maximum number of unique series for which a query can fetch samples from each ingesters and blocks storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable")
 	f.IntVar(&l.MaxFetchedChunkBytesPerQuery, "querier.max-fetched-chunk-bytes-per-query", 0, "Deprecated (use max-fetched-data-bytes-per-query instead): The maximum size of all chunks in bytes that a query can fetch from each ingester and storage. This limit is enforced in the querier, ruler and store-gateway. 0 to disable.")
 	f.IntVar(&l.MaxFetchedDataBytesPerQuery, "querier.max-fetched-data-bytes-per-query", 0, "The maximum combined size of all data that a query can fetch from each ingester and storage. This limit is enforced in the querier and ruler for `query`, `query_range` and `series` APIs. 0 to disable.")
+	f.IntVar(&l.MaxFetchedExemplarsPerQuery, "querier.max-fetched-exemplars-per-query", 0, "The maximum number of exemplars a single exemplar query can return after merging the results from ingesters and blocks storage. This limit is enforced in the querier. 0 to disable.")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "Limit the query time range (end - start time of range query parameter and max - min of data fetched time range). This limit is enforced in the query-frontend and ruler (on the received query). 0 to disable.")
 	f.Var(&l.MaxQueryLookback, "querier.max-query-lookback", "Limit how long back data (series and metadata) can be queried, up until <lookback> duration ago. This limit is enforced in the query-frontend, querier and ruler. If the requested time range is outside the allowed range, the request will not fail but will be manipulated to only query data within the allowed time range. 0 to disable.")
+	f.Float64Var(&l.QueryShadowEngineFraction, "querier.shadow-engine-fraction", 0, "[Experimental] Fraction of the queries of the tenant, between 0 and 1, also executed asynchronously with the PromQL engine other than the one configured by -querier.thanos-engine, comparing the results and reporting the mismatches in the logs and in the cortex_querier_shadow_engine_queries_total metric. The shadow query results are never returned. 0 to disable.")
 	f.IntVar(&l.MaxQueryParallelism, "querier.max-query-parallelism", 14, "Maximum number of split queries will be scheduled in parallel by the frontend.")
 	_ = l.MaxCacheFreshness.Set("1m")
 	f.Var(&l.MaxCacheFreshness, "frontend.max-cache-freshness", "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
+	_ = l.InstantQueryCacheTTL.Set("1m")
+	f.Var(&l.InstantQueryCacheTTL, "frontend.instant-query-cache-ttl", "How long a cached instant query result is served before the query is evaluated again. Requires -frontend.instant-query-cache.enabled. 0 to disable the instant query results cache for the tenant.")
 	f.Float64Var(&l.MaxQueriersPerTenant, "frontend.max-queriers-per-tenant", 0, "Maximum number of queriers that can handle requests for a single tenant. If set to 0 or value higher than number of available queriers, *all* queriers will handle requests for the tenant. If the value is < 1, it will be treated as a percentage and the gets a percentage of the total queriers. Each frontend (or query-scheduler, if used) will select the same set of queriers for the same tenant (given that all queriers are connected to all frontends / query-schedulers). This option only works with queriers connecting to the query-frontend / query-scheduler, not when using downstream URL.")
 	f.IntVar(&l.QueryVerticalShardSize, "frontend.query-vertical-shard-size", 0, "[Experimental] Number of shards to use when distributing shardable PromQL queries.")
+	f.BoolVar(&l.QueryVerticalShardBySeriesHash, "frontend.query-vertical-shard-by-series-hash", false, "[Experimental] Shard the sum, min, max, count, group, topk and bottomk aggregations and the histogram_quantile of the sum by le which can't be sharded by their grouping labels across partitions of the series hash, and merge the results of the shards in the query-frontend. Requires -frontend.query-vertical-shard-size to be greater than 1.")
+	f.BoolVar(&l.QueryVerticalShardApproximateTopK, "frontend.query-vertical-shard-approximate-topk", false, "[Experimental] When sharding by series hash, approximate the topk and bottomk of a sum by summing the k partial sums returned by each shard. The result may miss the series whose partial sums aren't in the k series of every shard. Requires -frontend.query-vertical-shard-by-series-hash.")
 	f.BoolVar(&l.QueryPriority.Enabled, "frontend.query-priority.enabled", false, "Whether queries are assigned with priorities.")
 	f.Int64Var(&l.QueryPriority.DefaultPriority, "frontend.query-priority.default-priority", 0, "Priority assigned to all queries by default. Must be a unique value. Use this as a baseline to make certain queries higher/lower priority.")
 
+	f.IntVar(&l.QueryQueueWeight, "frontend.query-queue-weight", 1, "[Experimental] Number of requests dequeued from the tenant queue each time the queriers take their turn on the tenant, when fairly iterating over the tenant queues of the query-frontend or query-scheduler. A tenant with a higher weight gets a proportionally larger share of the queriers.")
 	f.IntVar(&l.MaxOutstandingPerTenant, "frontend.max-outstanding-requests-per-tenant", 100, "Maximum number of outstanding requests per tenant per request queue (either query frontend or query scheduler); requests beyond this error with HTTP 429.")
+	f.Int64Var(&l.MaxQueryBytesPerDay, "frontend.max-query-bytes-per-day", 0, "[Experimental] Maximum total size of the data fetched by the queries of a tenant per day (UTC). Once exceeded, the queries of the tenant are rejected with HTTP 429 until the next day. The query which exceeds the budget still completes. Requires -frontend.query-bytes-budget.enabled. 0 to disable.")
+	f.Int64Var(&l.MaxEstimatedQueryCost, "frontend.max-estimated-query-cost", 0, "[Experimental] Maximum estimated cost of an instant or range query, estimated by the query-frontend before splitting and executing it as the number of samples evaluated: for each selector of the query, the estimated number of matching series, times the number of evaluation steps, times the number of samples selected per series at each step. The number of matching series is estimated from the cardinality statistics of the ingesters TSDB head, fetched once a minute per tenant, as the smallest series count of the metric names and label value pairs selected by the equality and regexp matchers of the selector. The queries exceeding it are rejected with HTTP 400, unless the X-Cortex-Query-Cost-Override header is set and allowed by -frontend.query-cost-override-allowed. 0 to disable.")
+	_ = l.QueryCostScrapeInterval.Set("15s")
+	f.Var(&l.QueryCostScrapeInterval, "frontend.query-cost-scrape-interval", "[Experimental] Scrape interval of the series of the tenant, used to estimate the number of samples selected by the range selectors when estimating the cost of the queries.")
+	f.BoolVar(&l.QueryCostOverrideAllowed, "frontend.query-cost-override-allowed", false, "[Experimental] Whether the queries of the tenant are allowed to exceed -frontend.max-estimated-query-cost by setting the X-Cortex-Query-Cost-Override header.")
+	f.IntVar(&l.MaxResponsePoints, "frontend.max-response-points", 0, "[Experimental] Maximum number of points (float samples and native histograms) returned by a range query. When a response exceeds it, the query-frontend downsamples its series down to about this number of points, using the Largest-Triangle-Three-Buckets algorithm for the float samples, and returns a warning instead of failing the query. 0 to disable.")
 
 	f.Var(&l.RulerEvaluationDelay, "ruler.evaluation-delay-duration", "Duration to delay the evaluation of rules to ensure the underlying metrics have been pushed to Cortex.")
 	f.IntVar(&l.RulerTenantShardSize, "ruler.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by ruler. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant.")
 	f.IntVar(&l.RulerMaxRulesPerRuleGroup, "ruler.max-rules-per-rule-group", 0, "Maximum number of rules per rule group per-tenant. 0 to disable.")
 	f.IntVar(&l.RulerMaxRuleGroupsPerTenant, "ruler.max-rule-groups-per-tenant", 0, "Maximum number of rule groups per-tenant. 0 to disable.")
+	f.BoolVar(&l.RulerMetaMonitoringEnabled, "ruler.meta-monitoring-enabled", false, "[Experimental] If enabled, the ruler exposes the cortex_ruler_rule_group_unhealthy metric for the tenant's rule groups whose last evaluation failed or which have not been evaluated for longer than their interval plus the lag threshold.")
+	_ = l.RulerMetaMonitoringLag.Set("1m")
+	f.Var(&l.RulerMetaMonitoringLag, "ruler.meta-monitoring-lag-threshold", "[Experimental] How long a rule group evaluation can be delayed beyond its interval before the rule group is reported as lagging by the ruler meta-monitoring.")
+	f.IntVar(&l.RulerMaxAlertAnnotationSizeBytes, "ruler.max-alert-annotation-size-bytes", 0, "Maximum size in bytes of the value of each annotation of the alerts sent by the ruler to the Alertmanager. Bigger annotations are handled according to -ruler.alert-annotation-limit-action. 0 to disable.")
+	f.IntVar(&l.RulerMaxAlertAnnotationsSizeBytes, "ruler.max-alert-annotations-size-bytes", 0, "Maximum total size in bytes of the annotation names and values of each alert sent by the ruler to the Alertmanager. The annotations exceeding the remaining size, in name order, are handled according to -ruler.alert-annotation-limit-action. 0 to disable.")
+	f.StringVar(&l.RulerAlertAnnotationLimitAction, "ruler.alert-annotation-limit-action", RulerAlertAnnotationLimitActionTruncate, "How to handle the alert annotations exceeding the ruler annotation size limits. Supported values are: truncate (truncate the value and mark it as truncated) and drop (remove the annotation).")
+	f.Var(&l.RulerDefaultEvaluationInterval, "ruler.default-evaluation-interval", "Evaluation interval of the tenant's rule groups not setting one. 0 to use -ruler.evaluation-interval.")
+	f.Var(&l.RulerMinEvaluationInterval, "ruler.min-evaluation-interval", "Minimum evaluation interval of the tenant's rule groups. The rule groups with a lower interval are rejected by the ruler API. 0 to disable.")
 
 	f.Var(&l.CompactorBlocksRetentionPeriod, "compactor.blocks-retention-period", "Delete blocks containing samples older than the specified retention period. 0 to disable.")
 	f.IntVar(&l.CompactorTenantShardSize, "compactor.tenant-shard-size", 0, "The default tenant's shard size when the shuffle-sharding strategy is used by the compactor. When this setting is specified in the per-tenant overrides, a value of 0 disables shuffle sharding for the tenant.")
@@ -265,6 +484,15 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.AlertmanagerMaxDispatcherAggregationGroups, "alertmanager.max-dispatcher-aggregation-groups", 0, "Maximum number of aggregation groups in Alertmanager's dispatcher that a tenant can have. Each active aggregation group uses single goroutine. When the limit is reached, dispatcher will not dispatch alerts that belong to additional aggregation groups, but existing groups will keep working properly. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxAlertsCount, "alertmanager.max-alerts-count", 0, "Maximum number of alerts that a single user can have. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
 	f.IntVar(&l.AlertmanagerMaxAlertsSizeBytes, "alertmanager.max-alerts-size-bytes", 0, "Maximum total size of alerts that a single user can have, alert size is the sum of the bytes of its labels, annotations and generatorURL. Inserting more alerts will fail with a log message and metric increment. 0 = no limit.")
+	f.IntVar(&l.AlertmanagerMaxAlertGroups, "alertmanager.max-alert-groups", 0, "[Experimental] Maximum number of alert groups, derived from the group_by of the routes of the tenant's configuration, that the alerts of a single user can belong to. Inserting alerts which would create additional groups will fail with a log message and metric increment, while the alerts of the existing groups keep being inserted. The groups are not tracked when the limit is disabled, so when enabling it at runtime the alerts already stored are only accounted once received again. 0 = no limit.")
+	f.IntVar(&l.AlertmanagerMaxSilencesCount, "alertmanager.max-silences-count", 0, "Maximum number of active and pending silences that a single user can have. Creating more silences will fail with an error and metric increment. 0 = no limit.")
+	f.Var(&l.AlertmanagerMaxSilenceLifetime, "alertmanager.max-silence-lifetime", "Maximum duration a silence of a single user can be active for. Creating longer silences will fail with an error and metric increment, and the silences active for longer are expired. 0 = no limit.")
+	f.Var(&l.AlertmanagerExpireUnusedSilencesAfter, "alertmanager.expire-unused-silences-after", "Expire the active silences of a single user which haven't matched any alert for longer than this duration. 0 to disable.")
+	f.Float64Var(&l.AlertmanagerConfigUpdatesRateLimit, "alertmanager.config-updates-rate-limit", 0, "Per-user rate limit of the Alertmanager configuration updates via Alertmanager API, in updates/sec. The updates exceeding the limit are rejected with 429. 0 = no limit.")
+	f.IntVar(&l.AlertmanagerConfigUpdatesBurstSize, "alertmanager.config-updates-burst-size", 5, "Per-user allowed burst size of the Alertmanager configuration updates via Alertmanager API.")
+	f.IntVar(&l.AlertmanagerMaxStateSizeBytes, "alertmanager.max-state-size-bytes", 0, "Maximum size of the state (notification log and silences) of a single user persisted to object storage. When exceeded, the oldest notification log entries are not persisted, while the silences are always persisted. Warning: the notification log entries not persisted are lost when the state is restored from object storage (ie. when all the replicas of the user restart at once), and the notifications they deduplicate may be sent again. 0 = no limit.")
+	f.Var(&l.AlertmanagerNotificationLogRetention, "alertmanager.notification-log-retention", "How long the notification log entries of a single user are kept in the state persisted to object storage. The older entries are not persisted even if not expired yet. Warning: they are lost when the state is restored from object storage (ie. when all the replicas of the user restart at once), and the notifications they deduplicate may be sent again, so it should be longer than the longest repeat interval. 0 to keep them until they expire according to -alertmanager.storage.retention.")
+	f.Var(&l.AlertmanagerAlertsArchiveRetention, "alertmanager.alerts-archive-retention", "[Experimental] Archive the alerts received by a single user to the Alertmanager object storage, in batches flushed every -alertmanager.alerts-archive-flush-interval, and delete the archived alerts after this retention. Requires an object storage backend. 0 to disable the archive, in which case the already archived alerts are not deleted.")
 }
 
 // Validate the limits config and returns an error if the validation
@@ -276,9 +504,78 @@ func (l *Limits) Validate(shardByAllLabels bool) error {
 		return errMaxGlobalSeriesPerUserValidation
 	}
 
+	switch l.StalenessMarkerPolicy {
+	case "", StalenessMarkerPolicyAccept, StalenessMarkerPolicyDrop, StalenessMarkerPolicyConvert:
+	default:
+		return errInvalidStalenessMarkerPolicy
+	}
+
+	switch l.MinSampleIntervalPolicy {
+	case "", MinSampleIntervalPolicyReject, MinSampleIntervalPolicyCoalesce:
+	default:
+		return errInvalidMinSampleIntervalPolicy
+	}
+
+	switch l.NativeHistogramLimitsPolicy {
+	case "", NativeHistogramLimitsPolicyReject, NativeHistogramLimitsPolicyDownscale:
+	default:
+		return errInvalidNativeHistogramLimitsPolicy
+	}
+
+	if l.MaxNativeHistogramSchema < nativeHistogramMinSchema || l.MaxNativeHistogramSchema > nativeHistogramMaxSchema {
+		return errInvalidMaxNativeHistogramSchema
+	}
+
+	if l.HeadCompactionInterval < 0 || time.Duration(l.HeadCompactionInterval) > 30*time.Minute {
+		return errInvalidHeadCompactionInterval
+	}
+
+	if l.BlockDuration < 0 {
+		return errInvalidBlockDuration
+	}
+
+	switch l.RulerAlertAnnotationLimitAction {
+	case "", RulerAlertAnnotationLimitActionTruncate, RulerAlertAnnotationLimitActionDrop:
+	default:
+		return errInvalidRulerAlertAnnotationLimitAction
+	}
+
+	if l.RulerDefaultEvaluationInterval < 0 || l.RulerMinEvaluationInterval < 0 || (l.RulerMinEvaluationInterval > 0 && l.RulerDefaultEvaluationInterval > 0 && l.RulerDefaultEvaluationInterval < l.RulerMinEvaluationInterval) {
+		return errInvalidRulerEvaluationInterval
+	}
+
+	for _, p := range l.HALabelPairs {
+		if p.Cluster == "" || p.Replica == "" || p.Cluster == p.Replica {
+			return errInvalidHALabelPair
+		}
+	}
+
+	if err := ValidatePushTokens(l.PushTokens); err != nil {
+		return err
+	}
+
+	// The relabel configs are validated when unmarshalled from YAML, but not from JSON.
+	for _, c := range l.MetricRelabelConfigs {
+		if c == nil || c.Regex.Regexp == nil {
+			return errInvalidMetricRelabelConfig
+		}
+		if err := c.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", errInvalidMetricRelabelConfig, err)
+		}
+	}
+
 	return nil
 }
 
+// HALabels returns the ordered list of HA cluster and replica label pairs to look for in samples,
+// falling back to the single ha_cluster_label and ha_replica_label pair if none is configured.
+func (l *Limits) HALabels() HALabelPairs {
+	if len(l.HALabelPairs) > 0 {
+		return l.HALabelPairs
+	}
+	return HALabelPairs{{Cluster: l.HAClusterLabel, Replica: l.HAReplicaLabel}}
+}
+
 // UnmarshalYAML implements the yaml.Unmarshaler interface.
 func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	// We want to set l to the defaults and then overwrite it with the input.
@@ -369,6 +666,8 @@ func (l *Limits) hasQueryPriorityRegexChanged() bool {
 		for _, attribute := range priority.QueryAttributes {
 			_, _ = h.WriteString(attribute.Regex)
 			_, _ = h.Write(seps)
+			_, _ = h.WriteString(attribute.UserAgentRegex)
+			_, _ = h.Write(seps)
 		}
 	}
 	newHash = h.Sum64()
@@ -401,8 +700,20 @@ func (l *Limits) compileQueryPriorityRegex() error {
 					}
 					newQueryPriorityCompiledRegex[attribute.Regex] = compiledRegex
 					l.QueryPriority.Priorities[i].QueryAttributes[j].CompiledRegex = compiledRegex
+
+					if attribute.UserAgentRegex != "" {
+						compiledUserAgentRegex, err := regexp.Compile(attribute.UserAgentRegex)
+						if err != nil {
+							return errors.Join(errCompilingQueryPriorityRegex, err)
+						}
+						newQueryPriorityCompiledRegex[attribute.UserAgentRegex] = compiledUserAgentRegex
+						l.QueryPriority.Priorities[i].QueryAttributes[j].CompiledUserAgentRegex = compiledUserAgentRegex
+					}
 				} else {
 					l.QueryPriority.Priorities[i].QueryAttributes[j].CompiledRegex = l.queryPriorityCompiledRegex[attribute.Regex]
+					if attribute.UserAgentRegex != "" {
+						l.QueryPriority.Priorities[i].QueryAttributes[j].CompiledUserAgentRegex = l.queryPriorityCompiledRegex[attribute.UserAgentRegex]
+					}
 				}
 			}
 		}
@@ -469,6 +780,21 @@ func (o *Overrides) IngestionBurstSize(userID string) int {
 	return o.GetOverridesForUser(userID).IngestionBurstSize
 }
 
+// RequestRate returns the limit on the push request rate (requests per second).
+func (o *Overrides) RequestRate(userID string) float64 {
+	return o.GetOverridesForUser(userID).RequestRate
+}
+
+// RequestBurstSize returns the burst size for the push request rate.
+func (o *Overrides) RequestBurstSize(userID string) int {
+	return o.GetOverridesForUser(userID).RequestBurstSize
+}
+
+// SpillBufferMaxSizeBytes returns the max size of the pushes of a given user buffered on the disk of a distributor.
+func (o *Overrides) SpillBufferMaxSizeBytes(userID string) int {
+	return o.GetOverridesForUser(userID).SpillBufferMaxSizeBytes
+}
+
 // AcceptHASamples returns whether the distributor should track and accept samples from HA replicas for this user.
 func (o *Overrides) AcceptHASamples(userID string) bool {
 	return o.GetOverridesForUser(userID).AcceptHASamples
@@ -489,6 +815,11 @@ func (o *Overrides) DropLabels(userID string) flagext.StringSlice {
 	return o.GetOverridesForUser(userID).DropLabels
 }
 
+// PromoteResourceAttributes returns the OTLP resource attributes to promote to labels for the given user.
+func (o *Overrides) PromoteResourceAttributes(userID string) flagext.StringSliceCSV {
+	return o.GetOverridesForUser(userID).PromoteResourceAttributes
+}
+
 // MaxLabelNameLength returns maximum length a label name can be.
 func (o *Overrides) MaxLabelNameLength(userID string) int {
 	return o.GetOverridesForUser(userID).MaxLabelNameLength
@@ -533,6 +864,11 @@ func (o *Overrides) CreationGracePeriod(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).CreationGracePeriod)
 }
 
+// MinSampleInterval returns the minimum interval between the timestamps of the samples of each series of the user.
+func (o *Overrides) MinSampleInterval(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).MinSampleInterval)
+}
+
 // MaxLocalSeriesPerUser returns the maximum number of series a user is allowed to store in a single ingester.
 func (o *Overrides) MaxLocalSeriesPerUser(userID string) int {
 	return o.GetOverridesForUser(userID).MaxLocalSeriesPerUser
@@ -548,11 +884,45 @@ func (o *Overrides) MaxGlobalSeriesPerUser(userID string) int {
 	return o.GetOverridesForUser(userID).MaxGlobalSeriesPerUser
 }
 
+// MaxSeriesPerUserGracePeriod returns the period of time during which the new series of a user
+// exceeding the per-user series limit are not rejected.
+func (o *Overrides) MaxSeriesPerUserGracePeriod(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).MaxSeriesPerUserGracePeriod)
+}
+
+// MaxSeriesPerUserRampUpPeriod returns the period of time, after the grace period, over which the
+// per-user series limit is gradually enforced.
+func (o *Overrides) MaxSeriesPerUserRampUpPeriod(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).MaxSeriesPerUserRampUpPeriod)
+}
+
 // OutOfOrderTimeWindow returns the allowed time window for ingestion of out-of-order samples.
 func (o *Overrides) OutOfOrderTimeWindow(userID string) model.Duration {
 	return o.GetOverridesForUser(userID).OutOfOrderTimeWindow
 }
 
+// HeadCompactionInterval returns how frequently the ingesters try to compact the user's TSDB head,
+// or 0 to use the global setting.
+func (o *Overrides) HeadCompactionInterval(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).HeadCompactionInterval)
+}
+
+// BlockDuration returns the duration of the blocks cut from the user's TSDB head, or 0 to use the
+// global setting.
+func (o *Overrides) BlockDuration(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).BlockDuration)
+}
+
+// SamplesPerChunk returns the target number of samples per TSDB head chunk for the user.
+func (o *Overrides) SamplesPerChunk(userID string) int {
+	return o.GetOverridesForUser(userID).SamplesPerChunk
+}
+
+// NativeHistogramsIngestionEnabled returns whether the ingestion of native histograms is enabled for the user.
+func (o *Overrides) NativeHistogramsIngestionEnabled(userID string) bool {
+	return o.GetOverridesForUser(userID).NativeHistogramsIngestionEnabled
+}
+
 // MaxGlobalSeriesPerMetric returns the maximum number of series allowed per metric across the cluster.
 func (o *Overrides) MaxGlobalSeriesPerMetric(userID string) int {
 	return o.GetOverridesForUser(userID).MaxGlobalSeriesPerMetric
@@ -591,6 +961,12 @@ func (o *Overrides) MaxFetchedDataBytesPerQuery(userID string) int {
 	return o.GetOverridesForUser(userID).MaxFetchedDataBytesPerQuery
 }
 
+// MaxFetchedExemplarsPerQuery returns the maximum number of exemplars allowed per exemplar query
+// after merging the results from ingesters and blocks storage.
+func (o *Overrides) MaxFetchedExemplarsPerQuery(userID string) int {
+	return o.GetOverridesForUser(userID).MaxFetchedExemplarsPerQuery
+}
+
 // MaxDownloadedBytesPerRequest returns the maximum number of bytes to download for each gRPC request in Store Gateway,
 // including any data fetched from cache or object storage.
 func (o *Overrides) MaxDownloadedBytesPerRequest(userID string) int {
@@ -613,6 +989,11 @@ func (o *Overrides) MaxCacheFreshness(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).MaxCacheFreshness)
 }
 
+// QueryShadowEngineFraction returns the fraction of the queries of this user also executed with the shadow PromQL engine.
+func (o *Overrides) QueryShadowEngineFraction(userID string) float64 {
+	return o.GetOverridesForUser(userID).QueryShadowEngineFraction
+}
+
 // MaxQueriersPerUser returns the maximum number of queriers that can handle requests for this user.
 func (o *Overrides) MaxQueriersPerUser(userID string) float64 {
 	return o.GetOverridesForUser(userID).MaxQueriersPerTenant
@@ -635,6 +1016,51 @@ func (o *Overrides) MaxOutstandingPerTenant(userID string) int {
 	return o.GetOverridesForUser(userID).MaxOutstandingPerTenant
 }
 
+// InstantQueryCacheTTL returns how long a cached instant query result is served.
+func (o *Overrides) InstantQueryCacheTTL(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).InstantQueryCacheTTL)
+}
+
+// QueryVerticalShardBySeriesHash returns whether the queries which can't be sharded by labels can be sharded by series hash.
+func (o *Overrides) QueryVerticalShardBySeriesHash(userID string) bool {
+	return o.GetOverridesForUser(userID).QueryVerticalShardBySeriesHash
+}
+
+// QueryVerticalShardApproximateTopK returns whether the topk and bottomk of a sum can be approximated when sharded by series hash.
+func (o *Overrides) QueryVerticalShardApproximateTopK(userID string) bool {
+	return o.GetOverridesForUser(userID).QueryVerticalShardApproximateTopK
+}
+
+// MaxResponsePoints returns the maximum number of points returned by a range query before it gets downsampled.
+func (o *Overrides) MaxResponsePoints(userID string) int {
+	return o.GetOverridesForUser(userID).MaxResponsePoints
+}
+
+// MaxEstimatedQueryCost returns the maximum estimated cost of a query of the tenant.
+func (o *Overrides) MaxEstimatedQueryCost(userID string) int64 {
+	return o.GetOverridesForUser(userID).MaxEstimatedQueryCost
+}
+
+// QueryCostScrapeInterval returns the scrape interval used to estimate the cost of the queries of the tenant.
+func (o *Overrides) QueryCostScrapeInterval(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).QueryCostScrapeInterval)
+}
+
+// QueryCostOverrideAllowed returns whether the queries of the tenant are allowed to override the max estimated query cost.
+func (o *Overrides) QueryCostOverrideAllowed(userID string) bool {
+	return o.GetOverridesForUser(userID).QueryCostOverrideAllowed
+}
+
+// MaxQueryBytesPerDay returns the maximum size of the data fetched by the queries of the tenant per day.
+func (o *Overrides) MaxQueryBytesPerDay(userID string) int64 {
+	return o.GetOverridesForUser(userID).MaxQueryBytesPerDay
+}
+
+// QueryQueueWeight returns the number of requests dequeued from the tenant queue on each turn of the tenant.
+func (o *Overrides) QueryQueueWeight(userID string) int {
+	return o.GetOverridesForUser(userID).QueryQueueWeight
+}
+
 // QueryPriority returns the query priority config for the tenant, including different priorities and their attributes
 func (o *Overrides) QueryPriority(userID string) QueryPriority {
 	return o.GetOverridesForUser(userID).QueryPriority
@@ -675,6 +1101,11 @@ func (o *Overrides) IngestionTenantShardSize(userID string) int {
 	return o.GetOverridesForUser(userID).IngestionTenantShardSize
 }
 
+// IngestionReplicationFactor returns the ingesters replication factor override for a given user.
+func (o *Overrides) IngestionReplicationFactor(userID string) int {
+	return o.GetOverridesForUser(userID).IngestionReplicationFactor
+}
+
 // EvaluationDelay returns the rules evaluation delay for a given user.
 func (o *Overrides) EvaluationDelay(userID string) time.Duration {
 	return time.Duration(o.GetOverridesForUser(userID).RulerEvaluationDelay)
@@ -690,6 +1121,11 @@ func (o *Overrides) CompactorTenantShardSize(userID string) int {
 	return o.GetOverridesForUser(userID).CompactorTenantShardSize
 }
 
+// PushTokens returns the tokens accepted by the push endpoints for a given user.
+func (o *Overrides) PushTokens(userID string) []PushToken {
+	return o.GetOverridesForUser(userID).PushTokens
+}
+
 // MetricRelabelConfigs returns the metric relabel configs for a given user.
 func (o *Overrides) MetricRelabelConfigs(userID string) []*relabel.Config {
 	return o.GetOverridesForUser(userID).MetricRelabelConfigs
@@ -710,6 +1146,41 @@ func (o *Overrides) RulerMaxRuleGroupsPerTenant(userID string) int {
 	return o.GetOverridesForUser(userID).RulerMaxRuleGroupsPerTenant
 }
 
+// RulerDefaultEvaluationInterval returns the evaluation interval of the rule groups not setting one for a given user.
+func (o *Overrides) RulerDefaultEvaluationInterval(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).RulerDefaultEvaluationInterval)
+}
+
+// RulerMinEvaluationInterval returns the minimum evaluation interval of the rule groups for a given user.
+func (o *Overrides) RulerMinEvaluationInterval(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).RulerMinEvaluationInterval)
+}
+
+// RulerMetaMonitoringEnabled returns whether the ruler meta-monitoring is enabled for a given user.
+func (o *Overrides) RulerMetaMonitoringEnabled(userID string) bool {
+	return o.GetOverridesForUser(userID).RulerMetaMonitoringEnabled
+}
+
+// RulerMetaMonitoringLag returns the lag threshold of the ruler meta-monitoring for a given user.
+func (o *Overrides) RulerMetaMonitoringLag(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).RulerMetaMonitoringLag)
+}
+
+// RulerMaxAlertAnnotationSizeBytes returns the maximum size of each alert annotation for a given user.
+func (o *Overrides) RulerMaxAlertAnnotationSizeBytes(userID string) int {
+	return o.GetOverridesForUser(userID).RulerMaxAlertAnnotationSizeBytes
+}
+
+// RulerMaxAlertAnnotationsSizeBytes returns the maximum total size of the annotations of an alert for a given user.
+func (o *Overrides) RulerMaxAlertAnnotationsSizeBytes(userID string) int {
+	return o.GetOverridesForUser(userID).RulerMaxAlertAnnotationsSizeBytes
+}
+
+// RulerAlertAnnotationLimitAction returns how the alert annotations exceeding the size limits are handled for a given user.
+func (o *Overrides) RulerAlertAnnotationLimitAction(userID string) string {
+	return o.GetOverridesForUser(userID).RulerAlertAnnotationLimitAction
+}
+
 // StoreGatewayTenantShardSize returns the store-gateway shard size for a given user.
 func (o *Overrides) StoreGatewayTenantShardSize(userID string) float64 {
 	return o.GetOverridesForUser(userID).StoreGatewayTenantShardSize
@@ -720,6 +1191,22 @@ func (o *Overrides) MaxHAReplicaGroups(user string) int {
 	return o.GetOverridesForUser(user).HAMaxClusters
 }
 
+// DiscardedSamplesMetaSeriesEnabled returns whether the distributors push the number of discarded
+// samples into the tenant's own data.
+func (o *Overrides) DiscardedSamplesMetaSeriesEnabled(userID string) bool {
+	return o.GetOverridesForUser(userID).DiscardedSamplesMetaSeriesEnabled
+}
+
+// HATrackerUpdateTimeout returns the HA tracker update timeout for a user, or 0 to use the configured one.
+func (o *Overrides) HATrackerUpdateTimeout(user string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(user).HATrackerUpdateTimeout)
+}
+
+// HATrackerFailoverTimeout returns the HA tracker failover timeout for a user, or 0 to use the configured one.
+func (o *Overrides) HATrackerFailoverTimeout(user string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(user).HATrackerFailoverTimeout)
+}
+
 // S3SSEType returns the per-tenant S3 SSE type.
 func (o *Overrides) S3SSEType(user string) string {
 	return o.GetOverridesForUser(user).S3SSEType
@@ -824,6 +1311,42 @@ func (o *Overrides) AlertmanagerMaxAlertsSizeBytes(userID string) int {
 	return o.GetOverridesForUser(userID).AlertmanagerMaxAlertsSizeBytes
 }
 
+func (o *Overrides) AlertmanagerMaxAlertGroups(userID string) int {
+	return o.GetOverridesForUser(userID).AlertmanagerMaxAlertGroups
+}
+
+func (o *Overrides) AlertmanagerMaxSilencesCount(userID string) int {
+	return o.GetOverridesForUser(userID).AlertmanagerMaxSilencesCount
+}
+
+func (o *Overrides) AlertmanagerMaxSilenceLifetime(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).AlertmanagerMaxSilenceLifetime)
+}
+
+func (o *Overrides) AlertmanagerExpireUnusedSilencesAfter(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).AlertmanagerExpireUnusedSilencesAfter)
+}
+
+func (o *Overrides) AlertmanagerConfigUpdatesRateLimit(userID string) float64 {
+	return o.GetOverridesForUser(userID).AlertmanagerConfigUpdatesRateLimit
+}
+
+func (o *Overrides) AlertmanagerConfigUpdatesBurstSize(userID string) int {
+	return o.GetOverridesForUser(userID).AlertmanagerConfigUpdatesBurstSize
+}
+
+func (o *Overrides) AlertmanagerMaxStateSizeBytes(userID string) int {
+	return o.GetOverridesForUser(userID).AlertmanagerMaxStateSizeBytes
+}
+
+func (o *Overrides) AlertmanagerNotificationLogRetention(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).AlertmanagerNotificationLogRetention)
+}
+
+func (o *Overrides) AlertmanagerAlertsArchiveRetention(userID string) time.Duration {
+	return time.Duration(o.GetOverridesForUser(userID).AlertmanagerAlertsArchiveRetention)
+}
+
 func (o *Overrides) DisabledRuleGroups(userID string) DisabledRuleGroups {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits.ByUserID(userID)
@@ -889,6 +1412,24 @@ func SmallestPositiveNonZeroFloat64PerTenant(tenantIDs []string, f func(string)
 	return *result
 }
 
+// SmallestPositiveNonZeroInt64PerTenant is returning the minimal positive and
+// non-zero value of the supplied limit function for all given tenants. In many
+// limits a value of 0 means unlimited so the method will return 0 only if all
+// inputs have a limit of 0 or an empty tenant list is given.
+func SmallestPositiveNonZeroInt64PerTenant(tenantIDs []string, f func(string) int64) int64 {
+	var result *int64
+	for _, tenantID := range tenantIDs {
+		v := f(tenantID)
+		if v > 0 && (result == nil || v < *result) {
+			result = &v
+		}
+	}
+	if result == nil {
+		return 0
+	}
+	return *result
+}
+
 // SmallestPositiveNonZeroDurationPerTenant is returning the minimal positive
 // and non-zero value of the supplied limit function for all given tenants. In
 // many limits a value of 0 means unlimited so the method will return 0 only if
@@ -62,6 +62,66 @@ func TestLimits_Validate(t *testing.T) {
 			shardByAllLabels: true,
 			expected:         nil,
 		},
+		"valid HA label pairs": {
+			limits:   Limits{HALabelPairs: HALabelPairs{{Cluster: "cluster", Replica: "__replica__"}, {Cluster: "k8s_cluster", Replica: "pod"}}},
+			expected: nil,
+		},
+		"HA label pair with an empty replica label": {
+			limits:   Limits{HALabelPairs: HALabelPairs{{Cluster: "cluster"}}},
+			expected: errInvalidHALabelPair,
+		},
+		"HA label pair with the same cluster and replica labels": {
+			limits:   Limits{HALabelPairs: HALabelPairs{{Cluster: "cluster", Replica: "cluster"}}},
+			expected: errInvalidHALabelPair,
+		},
+		"invalid min sample interval policy": {
+			limits:   Limits{MinSampleIntervalPolicy: "drop"},
+			expected: errInvalidMinSampleIntervalPolicy,
+		},
+		"invalid native histogram limits policy": {
+			limits:   Limits{NativeHistogramLimitsPolicy: "drop"},
+			expected: errInvalidNativeHistogramLimitsPolicy,
+		},
+		"max native histogram schema out of range": {
+			limits:   Limits{MaxNativeHistogramSchema: 9},
+			expected: errInvalidMaxNativeHistogramSchema,
+		},
+		"ruler default evaluation interval lower than the min one": {
+			limits:   Limits{RulerDefaultEvaluationInterval: model.Duration(10 * time.Second), RulerMinEvaluationInterval: model.Duration(time.Minute)},
+			expected: errInvalidRulerEvaluationInterval,
+		},
+		"head compaction interval greater than 30m": {
+			limits:   Limits{HeadCompactionInterval: model.Duration(time.Hour)},
+			expected: errInvalidHeadCompactionInterval,
+		},
+		"negative block duration": {
+			limits:   Limits{BlockDuration: model.Duration(-time.Minute)},
+			expected: errInvalidBlockDuration,
+		},
+		"valid metric relabel config": {
+			limits:   Limits{MetricRelabelConfigs: []*relabel.Config{{SourceLabels: model.LabelNames{"cluster"}, Action: relabel.Drop, Regex: relabel.MustNewRegexp("dev")}}},
+			expected: nil,
+		},
+		"valid push tokens": {
+			limits:   Limits{PushTokens: []PushToken{{Name: "prometheus", TokenSHA256: strings.Repeat("ab", 32), Scopes: []string{PushTokenScopeSeries, PushTokenScopeExemplars}}}},
+			expected: nil,
+		},
+		"push token with an invalid hash": {
+			limits:   Limits{PushTokens: []PushToken{{Name: "prometheus", TokenSHA256: "secret", Scopes: []string{PushTokenScopeSeries}}}},
+			expected: ErrInvalidPushToken,
+		},
+		"push token with an unknown scope": {
+			limits:   Limits{PushTokens: []PushToken{{Name: "prometheus", TokenSHA256: strings.Repeat("ab", 32), Scopes: []string{"samples"}}}},
+			expected: ErrInvalidPushToken,
+		},
+		"push tokens with the same name": {
+			limits:   Limits{PushTokens: []PushToken{{Name: "prometheus", TokenSHA256: strings.Repeat("ab", 32)}, {Name: "prometheus", TokenSHA256: strings.Repeat("cd", 32)}}},
+			expected: ErrInvalidPushToken,
+		},
+		"metric relabel config without regex": {
+			limits:   Limits{MetricRelabelConfigs: []*relabel.Config{{SourceLabels: model.LabelNames{"cluster"}, Action: relabel.Drop}}},
+			expected: errInvalidMetricRelabelConfig,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -73,6 +133,30 @@ func TestLimits_Validate(t *testing.T) {
 	}
 }
 
+func TestLimits_HALabels(t *testing.T) {
+	t.Parallel()
+
+	limits := Limits{HAClusterLabel: "cluster", HAReplicaLabel: "__replica__"}
+	assert.Equal(t, HALabelPairs{{Cluster: "cluster", Replica: "__replica__"}}, limits.HALabels())
+
+	limits.HALabelPairs = HALabelPairs{{Cluster: "k8s_cluster", Replica: "pod"}}
+	assert.Equal(t, HALabelPairs{{Cluster: "k8s_cluster", Replica: "pod"}}, limits.HALabels())
+}
+
+func TestHALabelPairs_Set(t *testing.T) {
+	t.Parallel()
+
+	var pairs HALabelPairs
+	require.NoError(t, pairs.Set("cluster:__replica__, prometheus:prometheus_replica"))
+	assert.Equal(t, HALabelPairs{{Cluster: "cluster", Replica: "__replica__"}, {Cluster: "prometheus", Replica: "prometheus_replica"}}, pairs)
+	assert.Equal(t, "cluster:__replica__,prometheus:prometheus_replica", pairs.String())
+
+	require.NoError(t, pairs.Set(""))
+	assert.Empty(t, pairs)
+
+	assert.Error(t, pairs.Set("cluster"))
+}
+
 func TestOverrides_MaxChunksPerQueryFromStore(t *testing.T) {
 	limits := Limits{}
 	flagext.DefaultValues(&limits)
@@ -326,6 +410,38 @@ func TestSmallestPositiveNonZeroFloat64PerTenant(t *testing.T) {
 	}
 }
 
+func TestSmallestPositiveNonZeroInt64PerTenant(t *testing.T) {
+	tenantLimits := map[string]*Limits{
+		"tenant-a": {
+			MaxEstimatedQueryCost: 5,
+		},
+		"tenant-b": {
+			MaxEstimatedQueryCost: 10,
+		},
+	}
+
+	defaults := Limits{
+		MaxEstimatedQueryCost: 0,
+	}
+	ov, err := NewOverrides(defaults, newMockTenantLimits(tenantLimits))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		tenantIDs []string
+		expLimit  int64
+	}{
+		{tenantIDs: []string{}, expLimit: 0},
+		{tenantIDs: []string{"tenant-a"}, expLimit: 5},
+		{tenantIDs: []string{"tenant-b"}, expLimit: 10},
+		{tenantIDs: []string{"tenant-c"}, expLimit: 0},
+		{tenantIDs: []string{"tenant-a", "tenant-b"}, expLimit: 5},
+		{tenantIDs: []string{"tenant-c", "tenant-d", "tenant-e"}, expLimit: 0},
+		{tenantIDs: []string{"tenant-a", "tenant-b", "tenant-c"}, expLimit: 5},
+	} {
+		assert.Equal(t, tc.expLimit, SmallestPositiveNonZeroInt64PerTenant(tc.tenantIDs, ov.MaxEstimatedQueryCost))
+	}
+}
+
 func TestSmallestPositiveNonZeroDurationPerTenant(t *testing.T) {
 	tenantLimits := map[string]*Limits{
 		"tenant-a": {
@@ -662,6 +778,10 @@ func TestHasQueryPriorityRegexChanged(t *testing.T) {
 	l.QueryPriority.Priorities[0].QueryAttributes = l.QueryPriority.Priorities[0].QueryAttributes[:1]
 
 	require.True(t, l.hasQueryPriorityRegexChanged())
+
+	l.QueryPriority.Priorities[0].QueryAttributes[0].UserAgentRegex = "^Grafana"
+
+	require.True(t, l.hasQueryPriorityRegexChanged())
 }
 
 func TestCompileQueryPriorityRegex(t *testing.T) {
@@ -698,6 +818,19 @@ func TestCompileQueryPriorityRegex(t *testing.T) {
 	err = l.compileQueryPriorityRegex()
 	require.NoError(t, err)
 	require.Equal(t, regexp.MustCompile("new"), l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledRegex)
+	require.Nil(t, l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledUserAgentRegex)
+
+	l.QueryPriority.Priorities[0].QueryAttributes[0].UserAgentRegex = "^Grafana"
+
+	err = l.compileQueryPriorityRegex()
+	require.NoError(t, err)
+	require.Equal(t, regexp.MustCompile("^Grafana"), l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledUserAgentRegex)
+
+	l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledUserAgentRegex = nil
+
+	err = l.compileQueryPriorityRegex()
+	require.NoError(t, err)
+	require.Equal(t, regexp.MustCompile("^Grafana"), l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledUserAgentRegex)
 
 	l.QueryPriority.Enabled = false
 	l.QueryPriority.Priorities[0].QueryAttributes[0].CompiledRegex = nil
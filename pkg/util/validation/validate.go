@@ -47,6 +47,15 @@ const (
 	labelValueTooLong       = "label_value_too_long"
 	labelsSizeBytesExceeded = "labels_size_bytes_exceeded"
 
+	// Native histogram specific validation reasons
+	nativeHistogramSchemaTooHigh  = "native_histogram_schema_too_high"
+	nativeHistogramTooManyBuckets = "native_histogram_buckets_exceeded"
+
+	// The lowest schema, ie. resolution, of the native histograms.
+	nativeHistogramMinSchema = -4
+	// The highest schema, ie. resolution, of the native histograms.
+	nativeHistogramMaxSchema = 8
+
 	// Exemplar-specific validation reasons
 	exemplarLabelsMissing    = "exemplar_labels_missing"
 	exemplarLabelsTooLong    = "exemplar_labels_too_long"
@@ -59,6 +68,18 @@ const (
 	// Too many HA clusters is one of the reasons for discarding samples.
 	TooManyHAClusters = "too_many_ha_clusters"
 
+	// StalenessMarkerDropped Samples discarded because they are staleness markers and the
+	// tenant staleness marker policy is to drop them.
+	StalenessMarkerDropped = "staleness_marker"
+
+	// SampleIntervalTooShort Samples discarded because they're closer than the tenant min sample
+	// interval to the last sample accepted for their series.
+	SampleIntervalTooShort = "sample_interval_too_short"
+
+	// PushTokenScopeMissing Exemplars discarded because the push token of the request doesn't have
+	// the exemplars scope.
+	PushTokenScopeMissing = "push_token_scope_missing"
+
 	// DroppedByRelabelConfiguration Samples can also be discarded because of relabeling configuration
 	DroppedByRelabelConfiguration = "relabel_configuration"
 	// DroppedByUserConfigurationOverride Samples discarded due to user configuration removing label __name__
@@ -70,9 +91,10 @@ const (
 )
 
 type ValidateMetrics struct {
-	DiscardedSamples   *prometheus.CounterVec
-	DiscardedExemplars *prometheus.CounterVec
-	DiscardedMetadata  *prometheus.CounterVec
+	DiscardedSamples           *prometheus.CounterVec
+	DiscardedExemplars         *prometheus.CounterVec
+	DiscardedMetadata          *prometheus.CounterVec
+	DownscaledNativeHistograms *prometheus.CounterVec
 }
 
 func registerCollector(r prometheus.Registerer, c prometheus.Collector) {
@@ -107,10 +129,19 @@ func NewValidateMetrics(r prometheus.Registerer) *ValidateMetrics {
 		[]string{discardReasonLabel, "user"},
 	)
 	registerCollector(r, discardedMetadata)
+	downscaledNativeHistograms := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "cortex_downscaled_native_histograms_total",
+			Help: "The total number of native histograms whose resolution was reduced to fit within the limits.",
+		},
+		[]string{"user"},
+	)
+	registerCollector(r, downscaledNativeHistograms)
 	m := &ValidateMetrics{
-		DiscardedSamples:   discardedSamples,
-		DiscardedExemplars: discardedExemplars,
-		DiscardedMetadata:  discardedMetadata,
+		DiscardedSamples:           discardedSamples,
+		DiscardedExemplars:         discardedExemplars,
+		DiscardedMetadata:          discardedMetadata,
+		DownscaledNativeHistograms: downscaledNativeHistograms,
 	}
 
 	return m
@@ -121,7 +152,10 @@ func NewValidateMetrics(r prometheus.Registerer) *ValidateMetrics {
 func ValidateSampleTimestamp(validateMetrics *ValidateMetrics, limits *Limits, userID string, ls []cortexpb.LabelAdapter, timestampMs int64) ValidationError {
 	unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ls)
 
-	if limits.RejectOldSamples && model.Time(timestampMs) < model.Now().Add(-time.Duration(limits.RejectOldSamplesMaxAge)) {
+	// The samples within the out-of-order time window are accepted by the ingesters, even if older
+	// than the max sample age.
+	maxAge := max(time.Duration(limits.RejectOldSamplesMaxAge), time.Duration(limits.OutOfOrderTimeWindow))
+	if limits.RejectOldSamples && model.Time(timestampMs) < model.Now().Add(-maxAge) {
 		validateMetrics.DiscardedSamples.WithLabelValues(greaterThanMaxSampleAge, userID).Inc()
 		return newSampleTimestampTooOldError(unsafeMetricName, timestampMs)
 	}
@@ -134,6 +168,68 @@ func ValidateSampleTimestamp(validateMetrics *ValidateMetrics, limits *Limits, u
 	return nil
 }
 
+// ValidateNativeHistogram returns an error if the native histogram exceeds the max schema or the max
+// number of buckets of the tenant, unless the tenant policy is to downscale it, in which case the
+// returned histogram has its resolution reduced to fit within the limits.
+// The returned error may retain the provided series labels.
+func ValidateNativeHistogram(validateMetrics *ValidateMetrics, limits *Limits, userID string, ls []cortexpb.LabelAdapter, h cortexpb.Histogram) (cortexpb.Histogram, ValidationError) {
+	maxSchema := int32(limits.MaxNativeHistogramSchema)
+	tooManyBuckets := func(h cortexpb.Histogram) bool {
+		return limits.MaxNativeHistogramBuckets > 0 && nativeHistogramBuckets(h) > limits.MaxNativeHistogramBuckets
+	}
+
+	if h.Schema <= maxSchema && !tooManyBuckets(h) {
+		return h, nil
+	}
+
+	unsafeMetricName, _ := extract.UnsafeMetricNameFromLabelAdapters(ls)
+
+	if limits.NativeHistogramLimitsPolicy != NativeHistogramLimitsPolicyDownscale {
+		if h.Schema > maxSchema {
+			validateMetrics.DiscardedSamples.WithLabelValues(nativeHistogramSchemaTooHigh, userID).Inc()
+			return h, newNativeHistogramSchemaTooHighError(unsafeMetricName, h.TimestampMs, int(h.Schema), int(maxSchema))
+		}
+		validateMetrics.DiscardedSamples.WithLabelValues(nativeHistogramTooManyBuckets, userID).Inc()
+		return h, newNativeHistogramTooManyBucketsError(unsafeMetricName, h.TimestampMs, nativeHistogramBuckets(h), limits.MaxNativeHistogramBuckets)
+	}
+
+	if h.Schema > maxSchema {
+		h = downscaleNativeHistogram(h, maxSchema)
+	}
+	// Halve the resolution until the number of buckets is within the limit.
+	for tooManyBuckets(h) {
+		if h.Schema <= nativeHistogramMinSchema {
+			validateMetrics.DiscardedSamples.WithLabelValues(nativeHistogramTooManyBuckets, userID).Inc()
+			return h, newNativeHistogramTooManyBucketsError(unsafeMetricName, h.TimestampMs, nativeHistogramBuckets(h), limits.MaxNativeHistogramBuckets)
+		}
+		h = downscaleNativeHistogram(h, h.Schema-1)
+	}
+
+	validateMetrics.DownscaledNativeHistograms.WithLabelValues(userID).Inc()
+	return h, nil
+}
+
+// nativeHistogramBuckets returns the number of positive and negative buckets of the native histogram.
+func nativeHistogramBuckets(h cortexpb.Histogram) int {
+	buckets := 0
+	for _, s := range h.PositiveSpans {
+		buckets += int(s.Length)
+	}
+	for _, s := range h.NegativeSpans {
+		buckets += int(s.Length)
+	}
+	return buckets
+}
+
+// downscaleNativeHistogram reduces the resolution of the native histogram to the given schema,
+// merging its buckets in place.
+func downscaleNativeHistogram(h cortexpb.Histogram, schema int32) cortexpb.Histogram {
+	if h.IsFloatHistogram() {
+		return cortexpb.FloatHistogramToHistogramProto(h.TimestampMs, cortexpb.FloatHistogramProtoToFloatHistogram(h).ReduceResolution(schema))
+	}
+	return cortexpb.HistogramToHistogramProto(h.TimestampMs, cortexpb.HistogramProtoToHistogram(h).ReduceResolution(schema))
+}
+
 // ValidateExemplar returns an error if the exemplar is invalid.
 // The returned error may retain the provided series labels.
 func ValidateExemplar(validateMetrics *ValidateMetrics, userID string, ls []cortexpb.LabelAdapter, e cortexpb.Exemplar) ValidationError {
@@ -274,4 +370,7 @@ func DeletePerUserValidationMetrics(validateMetrics *ValidateMetrics, userID str
 	if err := util.DeleteMatchingLabels(validateMetrics.DiscardedMetadata, filter); err != nil {
 		level.Warn(log).Log("msg", "failed to remove cortex_discarded_metadata_total metric for user", "user", userID, "err", err)
 	}
+	if err := util.DeleteMatchingLabels(validateMetrics.DownscaledNativeHistograms, filter); err != nil {
+		level.Warn(log).Log("msg", "failed to remove cortex_downscaled_native_histograms_total metric for user", "user", userID, "err", err)
+	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/cortexproject/cortex/pkg/cortexpb"
+	histogram_util "github.com/cortexproject/cortex/pkg/util/histogram"
 	util_log "github.com/cortexproject/cortex/pkg/util/log"
 )
 
@@ -291,3 +292,88 @@ func TestValidateLabelDuplication(t *testing.T) {
 	}, "a")
 	assert.Equal(t, expected, actual)
 }
+
+func TestValidateNativeHistogram(t *testing.T) {
+	userID := "testUser"
+	ls := []cortexpb.LabelAdapter{{Name: model.MetricNameLabel, Value: "foo"}}
+
+	for name, tc := range map[string]struct {
+		limits              Limits
+		float               bool
+		expectedSchema      int32
+		expectedBuckets     int
+		expectedErr         string
+		expectedDiscarded   string
+		expectedDownscaling bool
+	}{
+		"within the limits": {
+			limits:          Limits{MaxNativeHistogramSchema: 8, MaxNativeHistogramBuckets: 20},
+			expectedSchema:  2,
+			expectedBuckets: 20,
+		},
+		"schema too high": {
+			limits:            Limits{MaxNativeHistogramSchema: 1},
+			expectedErr:       `native histogram schema too high (actual: 2, limit: 1) timestamp: 1000 metric: "foo"`,
+			expectedDiscarded: nativeHistogramSchemaTooHigh,
+		},
+		"too many buckets": {
+			limits:            Limits{MaxNativeHistogramSchema: 8, MaxNativeHistogramBuckets: 8},
+			expectedErr:       `native histogram has too many buckets (actual: 20, limit: 8) timestamp: 1000 metric: "foo"`,
+			expectedDiscarded: nativeHistogramTooManyBuckets,
+		},
+		"schema too high downscaled": {
+			limits:              Limits{MaxNativeHistogramSchema: 1, NativeHistogramLimitsPolicy: NativeHistogramLimitsPolicyDownscale},
+			expectedSchema:      1,
+			expectedBuckets:     14,
+			expectedDownscaling: true,
+		},
+		"too many buckets downscaled": {
+			limits:              Limits{MaxNativeHistogramSchema: 8, MaxNativeHistogramBuckets: 8, NativeHistogramLimitsPolicy: NativeHistogramLimitsPolicyDownscale},
+			expectedSchema:      0,
+			expectedBuckets:     8,
+			expectedDownscaling: true,
+		},
+		"too many float histogram buckets downscaled": {
+			limits:              Limits{MaxNativeHistogramSchema: 8, MaxNativeHistogramBuckets: 8, NativeHistogramLimitsPolicy: NativeHistogramLimitsPolicyDownscale},
+			float:               true,
+			expectedSchema:      0,
+			expectedBuckets:     8,
+			expectedDownscaling: true,
+		},
+		"too many buckets at the lowest resolution": {
+			limits:            Limits{MaxNativeHistogramSchema: 8, MaxNativeHistogramBuckets: 1, NativeHistogramLimitsPolicy: NativeHistogramLimitsPolicyDownscale},
+			expectedErr:       `native histogram has too many buckets (actual: 2, limit: 1) timestamp: 1000 metric: "foo"`,
+			expectedDiscarded: nativeHistogramTooManyBuckets,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			validateMetrics := NewValidateMetrics(reg)
+
+			h := cortexpb.HistogramToHistogramProto(1000, histogram_util.GenerateTestHistogram(0))
+			if tc.float {
+				h = cortexpb.FloatHistogramToHistogramProto(1000, histogram_util.GenerateTestFloatHistogram(0))
+			}
+
+			actual, err := ValidateNativeHistogram(validateMetrics, &tc.limits, userID, ls, h)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				assert.Equal(t, tc.expectedDiscarded, ValidationErrorReason(err))
+				assert.Equal(t, float64(1), testutil.ToFloat64(validateMetrics.DiscardedSamples.WithLabelValues(tc.expectedDiscarded, userID)))
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSchema, actual.Schema)
+			assert.Equal(t, tc.expectedBuckets, nativeHistogramBuckets(actual))
+			assert.Equal(t, tc.float, actual.IsFloatHistogram())
+			assert.Equal(t, int64(1000), actual.TimestampMs)
+
+			expectedDownscaled := 0
+			if tc.expectedDownscaling {
+				expectedDownscaled = 1
+			}
+			assert.Equal(t, expectedDownscaled, testutil.CollectAndCount(validateMetrics.DownscaledNativeHistograms))
+		})
+	}
+}
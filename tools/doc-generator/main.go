@@ -30,6 +30,7 @@ import (
 	"github.com/cortexproject/cortex/pkg/ring/kv/consul"
 	"github.com/cortexproject/cortex/pkg/ring/kv/etcd"
 	"github.com/cortexproject/cortex/pkg/ring/kv/memberlist"
+	"github.com/cortexproject/cortex/pkg/ring/kv/zookeeper"
 	"github.com/cortexproject/cortex/pkg/ruler"
 	"github.com/cortexproject/cortex/pkg/ruler/rulestore"
 	"github.com/cortexproject/cortex/pkg/storage/bucket/s3"
@@ -204,6 +205,11 @@ var (
 			structType: reflect.TypeOf(tracing.Config{}),
 			desc:       "The tracing_config configures backends cortex uses.",
 		},
+		{
+			name:       "zookeeper_config",
+			structType: reflect.TypeOf(zookeeper.Config{}),
+			desc:       "The zookeeper_config configures the Zookeeper client.",
+		},
 	}
 )
 
@@ -234,6 +234,11 @@ func parseConfig(block *configBlock, cfg interface{}, flags map[uintptr]*flag.Fl
 			continue
 		}
 
+		// The lists of blocks configured via a CLI flag are empty by default.
+		if !strings.HasPrefix(fieldType, "list of ") || fieldFlag.DefValue != "" {
+			fieldDefault = fieldFlag.DefValue
+		}
+
 		block.Add(&configEntry{
 			kind:         "field",
 			name:         fieldName,
@@ -241,7 +246,7 @@ func parseConfig(block *configBlock, cfg interface{}, flags map[uintptr]*flag.Fl
 			fieldFlag:    fieldFlag.Name,
 			fieldDesc:    getFieldDescription(field, fieldFlag.Usage),
 			fieldType:    fieldType,
-			fieldDefault: fieldFlag.DefValue,
+			fieldDefault: fieldDefault,
 		})
 
 	}
@@ -278,7 +283,7 @@ func getFieldType(t reflect.Type) (string, error) {
 	switch t.String() {
 	case "*url.URL":
 		return "url", nil
-	case "time.Duration":
+	case "time.Duration", "model.Duration":
 		return "duration", nil
 	case "cortex.moduleName":
 		return "string", nil